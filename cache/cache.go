@@ -5,17 +5,25 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"repo-explanation/config"
+	"repo-explanation/internal/atrest"
+	"repo-explanation/internal/diskcache"
 	"repo-explanation/internal/openai"
+	"repo-explanation/internal/portable"
 )
 
 // Cache handles caching of analysis results
 type Cache struct {
-	config *config.Config
+	config   *config.Config
+	language string            // Output language code; non-English summaries are cached separately
+	budget   *diskcache.Budget // Keeps the directory under cache.max_size_mb
+	sealer   *atrest.Sealer    // Encrypts entries when encryption is enabled
+	promptVariant string       // Fingerprint of custom prompt templates; their summaries are cached separately
 }
 
 // CacheEntry represents a cached analysis result
@@ -27,7 +35,17 @@ type CacheEntry struct {
 
 // NewCache creates a new cache instance
 func NewCache(cfg *config.Config) *Cache {
-	return &Cache{config: cfg}
+	return &Cache{config: cfg, budget: diskcache.For(cfg.Cache.Directory, cfg.GetCacheMaxBytes()), sealer: atrest.For(cfg)}
+}
+
+// SetLanguage keeps summaries written in another language apart from the English ones
+func (c *Cache) SetLanguage(code string) {
+	c.language = code
+}
+
+// SetPromptVariant keeps summaries written with custom prompt templates apart from the others
+func (c *Cache) SetPromptVariant(fingerprint string) {
+	c.promptVariant = fingerprint
 }
 
 // GetFileSummary retrieves cached file summary if available and valid
@@ -82,12 +100,12 @@ func (c *Cache) SetFileSummary(filepath, content string, summary *openai.FileSum
 }
 
 // GetFolderSummary retrieves cached folder summary
-func (c *Cache) GetFolderSummary(folderPath string, fileSummaries map[string]openai.FileSummary) (*openai.FolderSummary, bool) {
+func (c *Cache) GetFolderSummary(folderPath string, fileSummaries map[string]openai.FileSummary, childSummaries map[string]openai.FolderSummary) (*openai.FolderSummary, bool) {
 	if !c.config.Cache.Enabled {
 		return nil, false
 	}
 
-	hash := c.hashFileSummaries(fileSummaries)
+	hash := c.hashFolderInputs(fileSummaries, childSummaries)
 	cacheFile := c.getFileCachePath(folderPath, "folder")
 	
 	entry, err := c.loadCacheEntry(cacheFile)
@@ -113,12 +131,12 @@ func (c *Cache) GetFolderSummary(folderPath string, fileSummaries map[string]ope
 }
 
 // SetFolderSummary caches a folder summary
-func (c *Cache) SetFolderSummary(folderPath string, fileSummaries map[string]openai.FileSummary, summary *openai.FolderSummary) error {
+func (c *Cache) SetFolderSummary(folderPath string, fileSummaries map[string]openai.FileSummary, childSummaries map[string]openai.FolderSummary, summary *openai.FolderSummary) error {
 	if !c.config.Cache.Enabled {
 		return nil
 	}
 
-	hash := c.hashFileSummaries(fileSummaries)
+	hash := c.hashFolderInputs(fileSummaries, childSummaries)
 	cacheFile := c.getFileCachePath(folderPath, "folder")
 	
 	entry := CacheEntry{
@@ -145,7 +163,7 @@ func (c *Cache) GetProjectSummary(projectPath string, folderSummaries map[string
 		hash = c.hashContent(projectPath + "_stable") // Add stable suffix for cache versioning
 		safeFilename := c.getSafeFilenameFromURL(projectPath)
 		urlHash := c.hashContent(projectPath)
-		filename := fmt.Sprintf("%s_project_%s.json", safeFilename, urlHash[:8])
+		filename := fmt.Sprintf("%s_%s_%s.json", safeFilename, c.localizedType("project"), urlHash[:8])
 		cacheFile = filepath.Join(c.config.Cache.Directory, filename)
 	} else {
 		// Traditional path-based caching - use content hash
@@ -198,7 +216,7 @@ func (c *Cache) SetProjectSummary(projectPath string, folderSummaries map[string
 		hash = c.hashContent(projectPath + "_stable") // Add stable suffix for cache versioning
 		safeFilename := c.getSafeFilenameFromURL(projectPath)
 		urlHash := c.hashContent(projectPath)
-		filename := fmt.Sprintf("%s_project_%s.json", safeFilename, urlHash[:8])
+		filename := fmt.Sprintf("%s_%s_%s.json", safeFilename, c.localizedType("project"), urlHash[:8])
 		cacheFile = filepath.Join(c.config.Cache.Directory, filename)
 	} else {
 		// Traditional path-based caching - use content hash
@@ -285,6 +303,15 @@ func (c *Cache) hashFileSummaries(summaries map[string]openai.FileSummary) strin
 	return fmt.Sprintf("%x", hash)
 }
 
+// hashFolderInputs hashes a folder's file summaries together with its subfolder summaries
+func (c *Cache) hashFolderInputs(fileSummaries map[string]openai.FileSummary, childSummaries map[string]openai.FolderSummary) string {
+	// Leaf folders keep the plain file hash so existing cache entries stay valid
+	if len(childSummaries) == 0 {
+		return c.hashFileSummaries(fileSummaries)
+	}
+	return c.hashContent(c.hashFileSummaries(fileSummaries) + c.hashFolderSummaries(childSummaries))
+}
+
 // hashFolderSummaries creates a hash of folder summaries for cache key
 func (c *Cache) hashFolderSummaries(summaries map[string]openai.FolderSummary) string {
 	data, _ := json.Marshal(summaries)
@@ -311,21 +338,47 @@ func (c *Cache) hashRepositoryDetailsInputs(folderSummaries map[string]openai.Fo
 	return fmt.Sprintf("%x", hash)
 }
 
+// unsafeFilenameChars replaces characters that are not allowed in file names on Windows
+var unsafeFilenameChars = strings.NewReplacer(
+	"/", "-",
+	"\\", "-",
+	":", "-",
+	"*", "-",
+	"?", "-",
+	"\"", "-",
+	"<", "-",
+	">", "-",
+	"|", "-",
+)
+
 // getFileCachePath generates cache file path
 func (c *Cache) getFileCachePath(originalPath, cacheType string) string {
-	// Create safe filename from path
-	safeFilename := filepath.Base(originalPath)
-	if safeFilename == "." || safeFilename == "/" {
+	// Create safe filename from path; separators are normalized so `a\b` and `a/b` share an entry
+	slashPath := portable.Slash(originalPath)
+	safeFilename := unsafeFilenameChars.Replace(path.Base(slashPath))
+	if safeFilename == "." || safeFilename == "-" || safeFilename == "" {
 		safeFilename = "root"
 	}
 	
 	// Add hash of full path to avoid collisions
-	pathHash := c.hashContent(originalPath)
-	filename := fmt.Sprintf("%s_%s_%s.json", safeFilename, cacheType, pathHash[:8])
+	pathHash := c.hashContent(slashPath)
+	filename := fmt.Sprintf("%s_%s_%s.json", safeFilename, c.localizedType(cacheType), pathHash[:8])
 	
 	return filepath.Join(c.config.Cache.Directory, filename)
 }
 
+// localizedType tags a cache entry type with the output language, if it is not English, and the
+// prompt templates, if they are not the built-in ones
+func (c *Cache) localizedType(cacheType string) string {
+	if c.language != "" {
+		cacheType += "_" + c.language
+	}
+	if c.promptVariant != "" {
+		cacheType += "_p" + c.promptVariant
+	}
+	return cacheType
+}
+
 // getRepositoryDetailsCachePath generates cache file path for repository details
 func (c *Cache) getRepositoryDetailsCachePath(repositoryURL string) string {
 	// Create safe filename from repository URL
@@ -333,7 +386,7 @@ func (c *Cache) getRepositoryDetailsCachePath(repositoryURL string) string {
 	
 	// Add hash of full URL to avoid collisions
 	urlHash := c.hashContent(repositoryURL)
-	filename := fmt.Sprintf("%s_details_%s.json", safeFilename, urlHash[:8])
+	filename := fmt.Sprintf("%s_%s_%s.json", safeFilename, c.localizedType("details"), urlHash[:8])
 	
 	return filepath.Join(c.config.Cache.Directory, filename)
 }
@@ -363,15 +416,7 @@ func (c *Cache) getSafeFilenameFromURL(url string) string {
 	}
 	
 	// Ultimate fallback: sanitize the whole URL
-	safe := strings.NewReplacer(
-		"/", "-",
-		":", "-",
-		".", "-",
-		"?", "-",
-		"&", "-",
-		"=", "-",
-		" ", "_",
-	).Replace(url)
+	safe := strings.NewReplacer(".", "-", "&", "-", "=", "-", " ", "_").Replace(unsafeFilenameChars.Replace(url))
 	
 	// Limit length
 	if len(safe) > 50 {
@@ -383,7 +428,7 @@ func (c *Cache) getSafeFilenameFromURL(url string) string {
 
 // loadCacheEntry loads cache entry from file
 func (c *Cache) loadCacheEntry(filePath string) (*CacheEntry, error) {
-	data, err := os.ReadFile(filePath)
+	data, err := c.sealer.ReadFile(filePath)
 	if err != nil {
 		return nil, err
 	}
@@ -393,6 +438,8 @@ func (c *Cache) loadCacheEntry(filePath string) (*CacheEntry, error) {
 		return nil, err
 	}
 
+	// A read counts as a use, so eviction keeps the entry
+	diskcache.Touch(filePath)
 	return &entry, nil
 }
 
@@ -409,7 +456,11 @@ func (c *Cache) saveCacheEntry(filePath string, entry CacheEntry) error {
 		return err
 	}
 
-	return os.WriteFile(filePath, data, 0644)
+	if err := c.sealer.WriteFile(filePath, data, 0644); err != nil {
+		return err
+	}
+	c.budget.Wrote(int64(len(data)))
+	return nil
 }
 
 // isExpired checks if cache entry is expired
@@ -106,6 +106,24 @@ func (s *SecretCommand) Handle(args []string) string {
 		}
 	}
 	
+	// Display Per-Environment Secrets
+	if len(projectSecrets.Environments) > 0 {
+		fmt.Println("🌐 ENVIRONMENTS")
+		fmt.Println(strings.Repeat("-", 40))
+		if len(projectSecrets.RequiredEverywhere) > 0 {
+			fmt.Printf("Needed in every environment: %s\n", strings.Join(projectSecrets.RequiredEverywhere, ", "))
+		}
+		for _, env := range projectSecrets.Environments {
+			fmt.Printf("🏷️  %s (%s)\n", env.Name, strings.Join(env.Files, ", "))
+			if len(env.Specific) > 0 {
+				fmt.Printf("   Only here: %s\n", strings.Join(env.Specific, ", "))
+			} else {
+				fmt.Println("   Nothing beyond the shared variables")
+			}
+		}
+		fmt.Println()
+	}
+	
 	// Setup Instructions
 	if projectSecrets.RequiredCount > 0 {
 		output.WriteString("🛠️  SETUP INSTRUCTIONS\n")
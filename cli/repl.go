@@ -5,16 +5,41 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"os/signal"
 	"os/user"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"repo-explanation/config"
+	"repo-explanation/internal/advisories"
+	"repo-explanation/internal/archmap"
+	"repo-explanation/internal/auth"
+	"repo-explanation/internal/complexity"
+	"repo-explanation/internal/console"
+	"repo-explanation/internal/contracts"
 	"repo-explanation/internal/commands"
+	"repo-explanation/internal/database"
+	"repo-explanation/internal/deadcode"
+	"repo-explanation/internal/dependents"
+	"repo-explanation/internal/doccoverage"
+	"repo-explanation/internal/featureflags"
+	"repo-explanation/internal/frontend"
+	"repo-explanation/internal/generated"
+	"repo-explanation/internal/glossary"
+	"repo-explanation/internal/infrastructure"
+	"repo-explanation/internal/jobs"
+	"repo-explanation/internal/languages"
+	"repo-explanation/internal/microservices"
+	"repo-explanation/internal/modules"
 	"repo-explanation/internal/openai"
+	"repo-explanation/internal/ownership"
 	"repo-explanation/internal/pipeline"
+	"repo-explanation/internal/pkggraph"
+	"repo-explanation/internal/relationships"
 	"repo-explanation/internal/secrets"
+	"repo-explanation/internal/tenancy"
+	"repo-explanation/internal/toolchain"
 )
 
 type REPL struct {
@@ -24,6 +49,9 @@ type REPL struct {
 	targetPath      string
 	analysisResult  *pipeline.AnalysisResult
 	onboardingCmds  *commands.OnboardingCommands
+	options         pipeline.AnalysisOptions
+	config          *config.Config
+	console         *console.Console
 }
 
 func NewREPL() *REPL {
@@ -34,6 +62,19 @@ func NewREPL() *REPL {
 	}
 }
 
+// NewREPLWithOptions creates a REPL using the startup configuration, with its analysis scoped by the given options
+func NewREPLWithOptions(cfg *config.Config, opts pipeline.AnalysisOptions) *REPL {
+	r := NewREPL()
+	r.config = cfg
+	r.options = opts
+	return r
+}
+
+// SetConsole renders the analysis log with a progress bar, as JSON or not at all
+func (r *REPL) SetConsole(c *console.Console) {
+	r.console = c
+}
+
 func (r *REPL) Start() {
 	fmt.Println("🚀 Repo Explanation CLI Started")
 
@@ -45,7 +86,9 @@ func (r *REPL) Start() {
 	// Then start command loop
 	fmt.Println("Type 'try me' to test, '/end' to exit")
 	fmt.Println("Secret extraction: 'secrets [path]' (path optional if already set)")
-	fmt.Println("Onboarding commands: 'list services', 'set config'")
+	fmt.Println("Onboarding commands: 'list services', 'set config', 'auth model'")
+	fmt.Println("Explain one file or folder: 'explain <path>' (no full analysis needed)")
+	fmt.Println("After an analysis: 'dependents <service|package|table>' lists what depends on it")
 	fmt.Print("> ")
 
 	for r.running && r.scanner.Scan() {
@@ -162,39 +205,12 @@ func (r *REPL) countFolders(rootPath string) (int, error) {
 	return count, err
 }
 
+// loadConfig returns the configuration passed in at startup, loading it when the REPL was created without one
 func (r *REPL) loadConfig() (*config.Config, error) {
-	// Get current working directory
-	cwd, err := os.Getwd()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get current directory: %v", err)
-	}
-	
-	// List of possible config file locations
-	configPaths := []string{
-		filepath.Join(cwd, "config.yaml"),       // Current directory
-		filepath.Join(cwd, "..", "config.yaml"), // Parent directory
-		"config.yaml",                           // Relative to current dir
-	}
-	
-	var lastErr error
-	for _, path := range configPaths {
-		if _, err := os.Stat(path); err == nil {
-			fmt.Printf("Found config file: %s\n", path)
-			cfg, err := config.LoadConfig(path)
-			if err != nil {
-				fmt.Printf("Error loading config from %s: %v\n", path, err)
-				lastErr = err
-				continue
-			}
-			return cfg, nil
-		}
-	}
-	
-	// If no config file found, return the last error or a generic error
-	if lastErr != nil {
-		return nil, lastErr
+	if r.config != nil {
+		return r.config, nil
 	}
-	return nil, fmt.Errorf("config.yaml not found in any of the expected locations: %v", configPaths)
+	return config.Load()
 }
 
 func (r *REPL) analyzeRepository() error {
@@ -204,12 +220,22 @@ func (r *REPL) analyzeRepository() error {
 		return fmt.Errorf("failed to load config: %v", err)
 	}
 
-	// Validate API key
-	if cfg.OpenAI.APIKey == "" {
-		return fmt.Errorf("OpenAI API key not configured. Please set OPENAI_API_KEY environment variable or update config.yaml")
+	// In quiet mode only the results are printed
+	if !r.console.Quiet() {
+		if cfg.Offline {
+			fmt.Println("\n📴 Starting repository analysis in offline mode (heuristic summaries, no LLM)...")
+		} else if r.options.Quick {
+			fmt.Println("\n⚡ Starting quick scan (deterministic analyzers plus one LLM project summary)...")
+		} else {
+			fmt.Println("\n🧠 Starting repository analysis with LLM...")
+		}
+		if r.options.Language != "" && !cfg.Offline {
+			fmt.Printf("🌐 LLM-written sections will be in %s\n", r.options.Language)
+		}
+		if cfg.Privacy.Enabled && !cfg.Offline {
+			fmt.Println("🔒 Privacy mode: file contents are anonymized before they reach the LLM")
+		}
 	}
-
-	fmt.Println("\n🧠 Starting repository analysis with LLM...")
 	startTime := time.Now()
 
 	// Create analyzer
@@ -217,12 +243,20 @@ func (r *REPL) analyzeRepository() error {
 	if err != nil {
 		return fmt.Errorf("failed to create analyzer: %v", err)
 	}
+	if err := analyzer.SetOptions(r.options); err != nil {
+		return fmt.Errorf("invalid analysis options: %v", err)
+	}
+	if !r.confirmEstimate(cfg, analyzer) {
+		return fmt.Errorf("analysis cancelled before any LLM call")
+	}
 
-	// Run analysis with extended timeout for large repositories
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Minute)
+	// Run analysis with the configured timeout; Ctrl-C aborts the analysis instead of the whole REPL
+	sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, cancel := context.WithTimeout(sigCtx, cfg.GetAnalysisTimeout())
 	defer cancel()
 
-	result, err := analyzer.AnalyzeProject(ctx)
+	result, err := r.console.Analyze(ctx, analyzer)
 	if err != nil {
 		return fmt.Errorf("analysis failed: %v", err)
 	}
@@ -240,11 +274,47 @@ func (r *REPL) analyzeRepository() error {
 	return nil
 }
 
+// confirmEstimate estimates the analysis and, when it would cost more than estimate.confirm_above_usd,
+// shows the estimate and asks whether to go ahead
+func (r *REPL) confirmEstimate(cfg *config.Config, analyzer *pipeline.Analyzer) bool {
+	if cfg.Offline || cfg.Estimate.ConfirmAboveUSD <= 0 {
+		return true
+	}
+	estimate, err := analyzer.Estimate()
+	if err != nil {
+		fmt.Printf("⚠️  Could not estimate the analysis cost: %v\n", err)
+		return true
+	}
+	if !estimate.Priced || estimate.CostUSD <= cfg.Estimate.ConfirmAboveUSD {
+		return true
+	}
+
+	estimate.Display()
+	fmt.Printf("💸 Estimated cost $%.2f is above estimate.confirm_above_usd ($%.2f). Proceed? [y/N] ", estimate.CostUSD, cfg.Estimate.ConfirmAboveUSD)
+	if !r.scanner.Scan() {
+		return false
+	}
+	answer := strings.ToLower(strings.TrimSpace(r.scanner.Text()))
+	return answer == "y" || answer == "yes"
+}
+
 func (r *REPL) displayAnalysisResults(result *pipeline.AnalysisResult) {
 	fmt.Println("\n" + strings.Repeat("=", 80))
 	fmt.Println("📊 REPOSITORY ANALYSIS RESULTS")
 	fmt.Println(strings.Repeat("=", 80))
 
+	if result.Offline {
+		fmt.Println("📴 OFFLINE MODE: summaries marked [offline] were produced by heuristics.")
+		fmt.Printf("   Fields that an LLM run would enhance: %s\n\n", strings.Join(result.LLMEnhanceableFields, ", "))
+	}
+	if result.Quick {
+		fmt.Println("⚡ QUICK SCAN: file and folder summaries are heuristic; run without -quick for the deep analysis.")
+		fmt.Printf("   Fields that a full run would enhance: %s\n\n", strings.Join(result.LLMEnhanceableFields, ", "))
+	}
+	if result.Privacy != nil {
+		r.displayPrivacy(result.Privacy)
+	}
+
 	// Display project type summary at the top
 	if result.ProjectType != nil {
 		result.ProjectType.PrintSummary()
@@ -278,7 +348,89 @@ func (r *REPL) displayAnalysisResults(result *pipeline.AnalysisResult) {
 			}
 		}
 
+		if docs := result.ProjectSummary.Documentation; docs != nil && len(docs.SetupSteps)+len(docs.RunCommands) > 0 {
+			fmt.Printf("\n📖 HOW TO RUN (from %s):\n", strings.Join(docs.Sources, ", "))
+			for _, step := range docs.SetupSteps {
+				fmt.Printf("   $ %s\n", step)
+			}
+			for _, command := range docs.RunCommands {
+				fmt.Printf("   ▶ %s\n", command)
+			}
+		}
+
+
+	}
+
+	r.displayPinnedServices(result.Services)
+	r.displayStartupSequences(result.Services)
+
+	if len(result.SequenceFlows) > 0 {
+		r.displaySequenceFlows(result.SequenceFlows)
+	}
+
+	if result.Toolchains != nil {
+		r.displayToolchains(result.Toolchains)
+	}
+	if result.Advisories != nil && len(result.Advisories.Advisories) > 0 {
+		r.displayAdvisories(result.Advisories)
+	}
+
+	if result.Frontend != nil {
+		r.displayFrontend(result.Frontend)
+	}
+
+	if result.PackageGraph != nil {
+		r.displayPackageGraph(result.PackageGraph)
+	}
+	if result.Modules != nil {
+		r.displayModules(result.Modules)
+	}
+	if result.FeatureFlags != nil {
+		r.displayFeatureFlags(result.FeatureFlags)
+	}
+	if result.AuthSurface != nil {
+		r.displayAuthSurface(result.AuthSurface)
+	}
+	if result.Jobs != nil {
+		r.displayJobs(result.Jobs)
+	}
+	if result.DataContracts != nil {
+		r.displayDataContracts(result.DataContracts)
+	}
+	if result.TableOwnership != nil {
+		r.displayTableOwnership(result.TableOwnership)
+	}
+	if result.DatabaseSchema != nil && result.DatabaseSchema.Sensitivity != nil {
+		r.displaySensitivity(result.DatabaseSchema.Sensitivity)
+	}
+	if result.DatabaseSchema != nil && result.DatabaseSchema.ERDSplit != nil {
+		r.displayERDSplit(result.DatabaseSchema.ERDSplit)
+	}
+	if result.Tenancy != nil {
+		r.displayTenancy(result.Tenancy)
+	}
+	if result.ArchitectureMap != nil {
+		r.displayArchitectureMap(result.ArchitectureMap)
+	}
+	if result.Complexity != nil {
+		r.displayComplexity(result.Complexity)
+	}
+	if result.DocCoverage != nil {
+		r.displayDocCoverage(result.DocCoverage)
+	}
+	if result.Generated != nil {
+		r.displayGenerated(result.Generated)
+	}
+	if result.Glossary != nil {
+		r.displayGlossary(result.Glossary)
+	}
 
+	if result.Infrastructure != nil {
+		r.displayInfrastructure(result.Infrastructure)
+	}
+
+	if result.DeadCode != nil {
+		r.displayDeadCode(result.DeadCode)
 	}
 
 	// Show statistics
@@ -297,11 +449,472 @@ func (r *REPL) displayAnalysisResults(result *pipeline.AnalysisResult) {
 				fmt.Printf("     - %s: %d files\n", ext, count)
 			}
 		}
+		if loc, ok := result.Stats["loc"].(languages.Lines); ok && loc.Total > 0 {
+			fmt.Printf("   • Lines: %d code, %d comment, %d blank\n", loc.Code, loc.Comment, loc.Blank)
+		}
+		if langs, ok := result.Stats["languages"].([]languages.LanguageStat); ok && len(langs) > 0 {
+			fmt.Println("   • Languages:")
+			for _, lang := range langs {
+				if lang.Percentage > 0 {
+					fmt.Printf("     - %s: %.1f%% (%d lines, %d files)\n", lang.Name, lang.Percentage, lang.Code, lang.Files)
+				} else {
+					fmt.Printf("     - %s: %d lines, %d files (%s)\n", lang.Name, lang.Code, lang.Files, lang.Type)
+				}
+			}
+		}
+		if services, ok := result.Stats["languages_by_service"].([]pipeline.ServiceLanguages); ok && len(services) > 0 {
+			fmt.Println("   • Languages by service:")
+			for _, service := range services {
+				var parts []string
+				for _, lang := range service.Languages {
+					if lang.Percentage > 0 {
+						parts = append(parts, fmt.Sprintf("%s %.1f%%", lang.Name, lang.Percentage))
+					}
+				}
+				fmt.Printf("     - %s: %d lines of code (%s)\n", service.Service, service.Total.Code, strings.Join(parts, ", "))
+			}
+		}
 	}
 
 	fmt.Println("\n" + strings.Repeat("=", 80))
 }
 
+// displayPrivacy lists what privacy mode kept from the LLM
+func (r *REPL) displayPrivacy(report *pipeline.PrivacyReport) {
+	var removed []string
+	if report.StringsStripped {
+		removed = append(removed, "string literals")
+	}
+	if report.ContactCommentsStripped {
+		removed = append(removed, "comments with emails or URLs")
+	}
+	if report.SensitivePatterns > 0 {
+		removed = append(removed, fmt.Sprintf("identifiers matching %d sensitive patterns", report.SensitivePatterns))
+	}
+	if len(removed) > 0 {
+		fmt.Printf("🔒 PRIVACY MODE: removed before reaching the LLM: %s.\n", strings.Join(removed, ", "))
+	} else {
+		fmt.Println("🔒 PRIVACY MODE: restricted files were kept from the LLM.")
+	}
+	if len(report.RestrictedFiles) > 0 {
+		fmt.Printf("   %d restricted files were never sent, e.g. %s\n", len(report.RestrictedFiles), report.RestrictedFiles[0])
+	}
+	fmt.Println()
+}
+
+func (r *REPL) displayPinnedServices(services []microservices.DiscoveredService) {
+	pinned := false
+	for _, service := range services {
+		if len(service.UserSpecified) == 0 {
+			continue
+		}
+		if !pinned {
+			fmt.Println("\n📌 PINNED IN .repo-analyzer.yaml:")
+			pinned = true
+		}
+		fmt.Printf("   • %s (%s): %s\n", service.Name, service.Path, strings.Join(service.UserSpecified, ", "))
+	}
+}
+
+func (r *REPL) displayStartupSequences(services []microservices.DiscoveredService) {
+	traced := false
+	for _, service := range services {
+		if len(service.Startup) == 0 {
+			continue
+		}
+		if !traced {
+			fmt.Println("\n🚀 STARTUP SEQUENCES:")
+			traced = true
+		}
+		fmt.Printf("   • %s (%s)\n", service.Name, service.Path)
+		for i, step := range service.Startup {
+			fmt.Printf("     %d. %s\n", i+1, step)
+		}
+	}
+}
+
+func (r *REPL) displaySequenceFlows(flows []relationships.SequenceFlow) {
+	fmt.Println("\n🔀 KEY REQUEST FLOWS:")
+	for _, flow := range flows {
+		fmt.Printf("   • %s: %s (confidence %.0f%%)\n", flow.Name, flow.Path(), flow.Confidence*100)
+		if flow.Description != "" {
+			fmt.Printf("     %s\n", flow.Description)
+		}
+		for _, step := range flow.Steps {
+			fmt.Printf("     %s → %s: %s [%s]\n", step.From, step.To, step.Message, step.FilePath)
+			if step.HandlerFile != "" {
+				fmt.Printf("       handled in %s\n", step.HandlerFile)
+			}
+		}
+		fmt.Println("     Mermaid:")
+		for _, line := range strings.Split(strings.TrimSpace(flow.Mermaid), "\n") {
+			fmt.Printf("       %s\n", line)
+		}
+	}
+}
+
+func (r *REPL) displayToolchains(report *toolchain.Report) {
+	fmt.Println("\n🧰 TOOLCHAINS TO INSTALL:")
+	for _, tc := range report.Toolchains {
+		var files []string
+		for _, source := range tc.Sources {
+			files = append(files, source.File)
+		}
+		fmt.Printf("   • %s %s (%s)\n", tc.Name, tc.Version, strings.Join(files, ", "))
+	}
+	for _, conflict := range report.Conflicts {
+		fmt.Printf("   ⚠️  %s\n", conflict.Message)
+	}
+}
+
+func (r *REPL) displayAdvisories(report *advisories.Report) {
+	fmt.Println("\n🗓️  VERSION ADVISORIES:")
+	icons := map[advisories.Status]string{advisories.EndOfLife: "⛔", advisories.EndingSoon: "⏳", advisories.OutdatedMajor: "🕰️ "}
+	for _, advisory := range report.Advisories {
+		location := advisory.File
+		if advisory.Service != "" {
+			location = advisory.Service + ", " + location
+		}
+		fmt.Printf("   %s %s (%s)\n", icons[advisory.Status], advisory.Message, location)
+	}
+	fmt.Printf("   %s\n", report.Summary)
+}
+
+func (r *REPL) displayFrontend(report *frontend.Report) {
+	fmt.Println("\n🖼️  FRONTEND ARCHITECTURE:")
+	for _, app := range report.Apps {
+		location := app.Path
+		if location == "" {
+			location = "."
+		}
+		fmt.Printf("   • %s (%s) in %s\n", app.Name, app.Framework, location)
+		if app.Router != nil {
+			fmt.Printf("     Routing: %s, %s, %d routes\n", app.Router.Library, app.Router.Style, len(app.Router.Routes))
+			for i, route := range app.Router.Routes {
+				if i == 10 {
+					fmt.Printf("       ... and %d more\n", len(app.Router.Routes)-i)
+					break
+				}
+				fmt.Printf("       %s → %s\n", route.Path, route.File)
+			}
+		}
+		for _, library := range app.State {
+			fmt.Printf("     State: %s (%s, imported in %d files)\n", library.Name, library.Package, library.Files)
+		}
+		for _, library := range app.DesignSystems {
+			fmt.Printf("     Design system: %s (%s, imported in %d files)\n", library.Name, library.Package, library.Files)
+		}
+		fmt.Printf("     Components: %d total, %d pages, %d layouts, %d shared\n",
+			app.Components.Total, app.Components.Pages, app.Components.Layouts, app.Components.Shared)
+	}
+}
+
+func (r *REPL) displayPackageGraph(graph *pkggraph.Graph) {
+	fmt.Println("\n🕸️  PACKAGE GRAPH:")
+	fmt.Printf("   %s\n", graph.Summary)
+	for _, pkg := range graph.Packages {
+		fmt.Printf("   • %s (%s): %d dependents, %d dependencies\n", pkg.Name, pkg.Path, pkg.FanIn, pkg.FanOut)
+	}
+	for _, hotspot := range graph.Hotspots {
+		fmt.Printf("   ⚠️  %s\n", hotspot.Message)
+	}
+}
+
+func (r *REPL) displayModules(report *modules.Report) {
+	fmt.Println("\n🧩 MODULES:")
+	fmt.Printf("   %s\n", report.Summary)
+	for _, module := range report.Modules {
+		fmt.Printf("   • %s: %d packages, %d files, cohesion %.2f\n", module.Name, len(module.Packages), module.Files, module.Cohesion)
+		if module.Description != "" {
+			fmt.Printf("       %s\n", module.Description)
+		}
+	}
+	if len(report.Shared) > 0 {
+		fmt.Printf("   Shared: %s\n", strings.Join(report.Shared, ", "))
+	}
+	for _, dep := range report.Dependencies {
+		fmt.Printf("   %s → %s (%d imports)\n", dep.From, dep.To, dep.Imports)
+	}
+}
+
+func (r *REPL) displayFeatureFlags(report *featureflags.Report) {
+	fmt.Println("\n🚩 FEATURE FLAGS:")
+	fmt.Printf("   %s\n", report.Summary)
+	for i, flag := range report.Flags {
+		if i == 20 {
+			fmt.Printf("   ... and %d more\n", len(report.Flags)-i)
+			break
+		}
+		fmt.Printf("   • %s (%s, %s) checked in %d files\n", flag.Key, flag.Provider, flag.Kind, flag.Files)
+		for j, usage := range flag.Usages {
+			if j == 3 {
+				fmt.Printf("       ... and %d more\n", len(flag.Usages)-j)
+				break
+			}
+			fmt.Printf("       %s:%d\n", usage.File, usage.Line)
+		}
+	}
+}
+
+func (r *REPL) displayAuthSurface(report *auth.Report) {
+	fmt.Println("\n🔐 AUTH MODEL:")
+	fmt.Printf("   %s\n", report.Summary)
+	for _, service := range report.Services {
+		name := service.Service
+		if name == "" {
+			name = "(outside services)"
+		}
+		fmt.Printf("   • %s: %s\n", name, service.Model)
+		for i, point := range service.EnforcementPoints {
+			if i == 5 {
+				fmt.Printf("       ... and %d more\n", len(service.EnforcementPoints)-i)
+				break
+			}
+			fmt.Printf("       %s:%d (%s)\n", point.File, point.Line, point.Detail)
+		}
+	}
+}
+
+func (r *REPL) displayJobs(report *jobs.Report) {
+	fmt.Println("\n⏰ BACKGROUND JOBS:")
+	fmt.Printf("   %s\n", report.Summary)
+	for i, job := range report.Jobs {
+		if i == 30 {
+			fmt.Printf("   ... and %d more\n", len(report.Jobs)-i)
+			break
+		}
+		when := job.Cadence
+		if job.Trigger == jobs.Queued {
+			when = "queue " + job.Queue
+		} else if when == "" {
+			when = job.Schedule
+		}
+		location := fmt.Sprintf("%s:%d", job.File, job.Line)
+		if job.Service != "" {
+			location = job.Service + ", " + location
+		}
+		fmt.Printf("   • %s (%s) %s → %s [%s]\n", job.Name, job.Kind, when, job.Handler, location)
+	}
+}
+
+func (r *REPL) displayDataContracts(report *contracts.Report) {
+	fmt.Println("\n📨 DATA CONTRACTS:")
+	fmt.Printf("   %s\n", report.Summary)
+	for i, contract := range report.Contracts {
+		if i == 30 {
+			fmt.Printf("   ... and %d more\n", len(report.Contracts)-i)
+			break
+		}
+		fmt.Printf("   • %s (%s, %d fields) [%s:%d]\n", contract.Name, contract.Format, contract.Fields, contract.File, contract.Line)
+		if len(contract.Channels) == 0 {
+			continue
+		}
+		fmt.Printf("     on %s", strings.Join(contract.Channels, ", "))
+		if len(contract.Producers) > 0 {
+			fmt.Printf("; from %s", strings.Join(contract.Producers, ", "))
+		}
+		if len(contract.Consumers) > 0 {
+			fmt.Printf("; to %s", strings.Join(contract.Consumers, ", "))
+		}
+		fmt.Println()
+	}
+}
+
+func (r *REPL) displayTableOwnership(report *ownership.Report) {
+	fmt.Println("\n🏷️  TABLE OWNERSHIP:")
+	fmt.Printf("   %s\n", report.Summary)
+	for i, table := range report.Tables {
+		if i == 40 {
+			fmt.Printf("   ... and %d more\n", len(report.Tables)-i)
+			break
+		}
+		owner := table.Owner
+		if owner == "" {
+			owner = "unowned"
+		}
+		line := fmt.Sprintf("   • %s → %s", table.Table, owner)
+		if table.Shared {
+			line += fmt.Sprintf(" ⚠️  shared with %s", strings.Join(table.AccessedBy[1:], ", "))
+		}
+		fmt.Println(line)
+		fmt.Printf("     %s\n", table.Describe())
+	}
+}
+
+func (r *REPL) displaySensitivity(report *database.SensitivityReport) {
+	fmt.Println("\n🔐 DATA SENSITIVITY:")
+	fmt.Printf("   %s\n", report.Summary)
+	for i, column := range report.Columns {
+		if i == 30 {
+			fmt.Printf("   ... and %d more\n", len(report.Columns)-i)
+			break
+		}
+		fmt.Printf("   • %s.%s: %s (%s)\n", column.Table, column.Column, column.Class, column.Label)
+	}
+	for _, warning := range report.Warnings {
+		fmt.Printf("   ⚠️  %s\n", warning)
+	}
+}
+
+func (r *REPL) displayERDSplit(split *database.ERDSplit) {
+	fmt.Println("\n🧩 ERD DOMAINS:")
+	fmt.Printf("   %s\n", split.Summary)
+	for _, domain := range split.Domains {
+		fmt.Printf("   • %s (%d): %s\n", domain.Name, len(domain.Tables), strings.Join(domain.Tables, ", "))
+	}
+	for _, link := range split.Links {
+		fmt.Printf("     %s → %s (%d foreign keys)\n", link.From, link.To, link.ForeignKeys)
+	}
+}
+
+func (r *REPL) displayTenancy(report *tenancy.Report) {
+	fmt.Println("\n🏢 MULTI-TENANCY:")
+	fmt.Printf("   %s\n", report.Summary)
+	for _, line := range report.Guidance {
+		fmt.Printf("   • %s\n", line)
+	}
+	for i, e := range report.Evidence {
+		if i == 10 {
+			fmt.Printf("   ... and %d more\n", len(report.Evidence)-i)
+			break
+		}
+		fmt.Printf("     %s (%s:%d)\n", e.Detail, e.File, e.Line)
+	}
+}
+
+func (r *REPL) displayArchitectureMap(m *archmap.Map) {
+	fmt.Println("\n🗺️  ARCHITECTURE MAP:")
+	fmt.Printf("   %s (%d folders)\n", m.Root.Name, m.Folders)
+	var walk func(node *archmap.Node, indent string, depth int)
+	walk = func(node *archmap.Node, indent string, depth int) {
+		for _, child := range node.Children {
+			line := fmt.Sprintf("%s├─ %s/", indent, child.Name)
+			if purpose := child.Brief(); purpose != "" {
+				line += " — " + purpose
+			}
+			fmt.Println(line)
+			if len(child.Children) == 0 {
+				continue
+			}
+			if depth+1 >= archmap.DefaultMindmapDepth {
+				fmt.Printf("%s│  ... %d more folders\n", indent, child.Descendants())
+				continue
+			}
+			walk(child, indent+"│  ", depth+1)
+		}
+	}
+	walk(m.Root, "   ", 0)
+}
+
+func (r *REPL) displayComplexity(report *complexity.Report) {
+	fmt.Println("\n🧮 COMPLEXITY:")
+	project := report.Project
+	fmt.Printf("   Project: %.0f/100 (%s), expected onboarding %s\n", project.Score, project.Level, project.Onboarding)
+	for _, factor := range project.Factors {
+		fmt.Printf("     %-12s %8d  %4.1f / %g\n", factor.Name, factor.Value, factor.Points, factor.Weight)
+	}
+	for _, service := range report.Services {
+		fmt.Printf("   • %s: %.0f/100 (%s), onboarding %s\n", service.Name, service.Score, service.Level, service.Onboarding)
+	}
+	fmt.Printf("   Formula: %s\n", report.Formula)
+}
+
+func (r *REPL) displayDocCoverage(report *doccoverage.Report) {
+	fmt.Println("\n📚 DOCUMENTATION COVERAGE:")
+	fmt.Printf("   %s\n", report.Summary)
+	for _, area := range report.Areas {
+		fmt.Printf("   • %s: %.0f/100 (%s)\n", area.Name, area.Score, area.Grade)
+		if len(area.Gaps) > 0 {
+			fmt.Printf("     %s\n", strings.Join(area.Gaps, "; "))
+		}
+	}
+}
+
+func (r *REPL) displayGenerated(report *generated.Report) {
+	fmt.Println("\n🏭 GENERATED CODE:")
+	fmt.Printf("   %s\n", report.Summary)
+	linked := make(map[string]bool)
+	for _, spec := range report.Specs {
+		fmt.Printf("   • %s (%s) → %s\n", spec.Path, spec.Kind, strings.Join(spec.Outputs, ", "))
+		for _, output := range spec.Outputs {
+			linked[output] = true
+		}
+	}
+	for _, artifact := range report.Artifacts {
+		if linked[artifact.Path] {
+			continue
+		}
+		if artifact.Generator != "" {
+			fmt.Printf("   • %s (%s, %s)\n", artifact.Path, artifact.Kind, artifact.Generator)
+		} else {
+			fmt.Printf("   • %s (%s)\n", artifact.Path, artifact.Kind)
+		}
+	}
+	if len(report.Pipelines) > 0 {
+		fmt.Println("   Code generation pipelines (never edit their output; regenerate instead):")
+		for _, pipeline := range report.Pipelines {
+			fmt.Printf("   • %s (%s): %s → %s\n", pipeline.Generator, pipeline.Config, pipeline.Command, strings.Join(pipeline.Outputs, ", "))
+		}
+	}
+}
+
+func (r *REPL) displayGlossary(g *glossary.Glossary) {
+	fmt.Println("\n📖 GLOSSARY:")
+	fmt.Printf("   %s\n", g.Summary)
+	for _, term := range g.Terms {
+		fmt.Printf("   • %s (%s): %s\n", term.Term, term.Kind, term.Definition)
+		if len(term.Sources) > 0 {
+			fmt.Printf("       %s\n", strings.Join(term.Sources, ", "))
+		}
+	}
+}
+
+func (r *REPL) displayInfrastructure(inventory *infrastructure.Inventory) {
+	fmt.Println("\n☁️  INFRASTRUCTURE:")
+	fmt.Printf("   %s\n", inventory.Summary)
+
+	for _, provider := range inventory.Providers {
+		if provider.Region != "" {
+			fmt.Printf("   • Provider %s (%s)\n", provider.Name, provider.Region)
+		} else {
+			fmt.Printf("   • Provider %s\n", provider.Name)
+		}
+	}
+	for _, resource := range inventory.Resources {
+		if resource.CloudName != "" {
+			fmt.Printf("   • [%s] %s (%s)\n", resource.Category, resource.Address(), resource.CloudName)
+		} else {
+			fmt.Printf("   • [%s] %s\n", resource.Category, resource.Address())
+		}
+	}
+	for _, link := range inventory.Links {
+		fmt.Printf("   ↳ %s uses %s: %s (%s)\n", link.Service, link.Resource, link.Evidence, link.FilePath)
+	}
+}
+
+func (r *REPL) displayDeadCode(report *deadcode.Report) {
+	fmt.Println("\n🪦 LIKELY DEAD CODE (safe to skip while onboarding):")
+	fmt.Printf("   %s\n", report.Summary)
+
+	sections := []struct {
+		title    string
+		findings []deadcode.Finding
+	}{
+		{"Go packages", report.GoPackages},
+		{"JS/TS files", report.JSFiles},
+		{"Tables", report.UnusedTables},
+	}
+	for _, section := range sections {
+		if len(section.findings) == 0 {
+			continue
+		}
+		fmt.Printf("   %s:\n", section.title)
+		for _, finding := range section.findings {
+			fmt.Printf("     • %s - %s (%.0f%%)\n", finding.Name, finding.Reason, finding.Confidence*100)
+		}
+	}
+}
+
 func (r *REPL) displayDetailedAnalysis(analysis *openai.RepositoryAnalysis) {
 	fmt.Println("🔬 DETAILED ARCHITECTURAL ANALYSIS")
 	fmt.Println(strings.Repeat("-", 50))
@@ -343,6 +956,19 @@ func (r *REPL) displayDetailedAnalysis(analysis *openai.RepositoryAnalysis) {
 			if service.EntryPoint != "" {
 				fmt.Printf("     Entry: %s\n", service.EntryPoint)
 			}
+			
+			// Display how the service is containerized if it has a Dockerfile
+			if service.Container != "" {
+				fmt.Printf("     Container: %s\n", service.Container)
+			}
+			
+			// Display internal architecture classification if available
+			if service.Architecture != nil {
+				fmt.Printf("     Architecture: %s (%.0f%% confidence, %s)\n", service.Architecture.Pattern, service.Architecture.Confidence*100, service.Architecture.Source)
+				if len(service.Architecture.Evidence) > 0 {
+					fmt.Printf("     Evidence: %s\n", strings.Join(service.Architecture.Evidence, ", "))
+				}
+			}
 		}
 	}
 
@@ -411,6 +1037,10 @@ func (r *REPL) processCommand(input string) {
 		r.running = false
 	case "secrets":
 		r.handleSecretsCommand(args)
+	case "explain":
+		r.handleExplainCommand(args)
+	case "dependents":
+		r.handleDependentsCommand(args)
 	case "list":
 		if len(parts) > 1 && parts[1] == "services" {
 			r.handleOnboardingCommand(input)
@@ -419,11 +1049,13 @@ func (r *REPL) processCommand(input string) {
 		r.handleOnboardingCommand(input)
 	case "set config", "config":
 		r.handleOnboardingCommand(input)
+	case "auth":
+		r.handleOnboardingCommand(input)
 	default:
 		fmt.Println("unsupported function")
-		fmt.Println("Available commands: 'secrets [path]', 'try me', '/end'")
+		fmt.Println("Available commands: 'secrets [path]', 'explain <path>', 'try me', '/end'")
 		if r.analysisResult != nil {
-			fmt.Println("Additional onboarding commands: 'list services', 'set config'")
+			fmt.Println("Additional onboarding commands: 'list services', 'set config', 'auth model', 'dependents <name>'")
 		}
 	}
 }
@@ -444,6 +1076,61 @@ func (r *REPL) handleOnboardingCommand(command string) {
 	}
 }
 
+// handleExplainCommand summarizes one file or folder of the target project, relative to it or absolute
+func (r *REPL) handleExplainCommand(args []string) {
+	if len(args) == 0 {
+		fmt.Println("❌ Please provide a file or folder. Usage: explain internal/pipeline")
+		return
+	}
+
+	cfg, err := r.loadConfig()
+	if err != nil {
+		fmt.Printf("❌ Failed to load config: %v\n", err)
+		return
+	}
+	analyzer, err := pipeline.NewAnalyzer(cfg, r.targetPath)
+	if err != nil {
+		fmt.Printf("❌ Failed to create analyzer: %v\n", err)
+		return
+	}
+	if err := analyzer.SetOptions(r.options); err != nil {
+		fmt.Printf("❌ Invalid analysis options: %v\n", err)
+		return
+	}
+
+	sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, cancel := context.WithTimeout(sigCtx, cfg.GetAnalysisTimeout())
+	defer cancel()
+
+	explanation, err := analyzer.Explain(ctx, strings.Join(args, " "))
+	if err != nil {
+		fmt.Printf("❌ Explain failed: %v\n", err)
+		return
+	}
+	explanation.Display()
+}
+
+// handleDependentsCommand lists what depends on a service, package or table of the analyzed project
+func (r *REPL) handleDependentsCommand(args []string) {
+	if len(args) == 0 {
+		fmt.Println("❌ Please provide a service, package or table. Usage: dependents users (or table:users)")
+		return
+	}
+	if r.analysisResult == nil {
+		fmt.Println("❌ Please analyze a project first; dependents are looked up in its results")
+		return
+	}
+	reports, err := dependents.Find(r.analysisResult, strings.Join(args, " "))
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	for i := range reports {
+		reports[i].Display()
+	}
+}
+
 func (r *REPL) handleSecretsCommand(args []string) {
 	var folderPath string
 	
@@ -497,6 +1184,9 @@ func (r *REPL) handleSecretsCommand(args []string) {
 			fmt.Printf("%d. %s\n", i+1, secret.Name)
 			fmt.Printf("   Type: %s\n", strings.ToUpper(secret.Type))
 			fmt.Printf("   Source: %s\n", secret.Source)
+			if secret.Origin != "" {
+				fmt.Printf("   Deployment: %s for service %s\n", secret.Origin, secret.TargetService)
+			}
 			fmt.Printf("   Description: %s\n", secret.Description)
 			if secret.Example != "" {
 				fmt.Printf("   Example: %s=%s\n", secret.Name, secret.Example)
@@ -520,6 +1210,9 @@ func (r *REPL) handleSecretsCommand(args []string) {
 					fmt.Printf("  %d. %s\n", i+1, secret.Name)
 					fmt.Printf("     Type: %s\n", strings.ToUpper(secret.Type))
 					fmt.Printf("     Source: %s\n", secret.Source)
+					if secret.Origin != "" {
+						fmt.Printf("     Deployment: %s for service %s\n", secret.Origin, secret.TargetService)
+					}
 					fmt.Printf("     Description: %s\n", secret.Description)
 					if secret.Example != "" {
 						fmt.Printf("     Example: %s=%s\n", secret.Name, secret.Example)
@@ -533,6 +1226,24 @@ func (r *REPL) handleSecretsCommand(args []string) {
 		}
 	}
 	
+	// Display Per-Environment Secrets
+	if len(projectSecrets.Environments) > 0 {
+		fmt.Println("🌐 ENVIRONMENTS")
+		fmt.Println(strings.Repeat("-", 40))
+		if len(projectSecrets.RequiredEverywhere) > 0 {
+			fmt.Printf("Needed in every environment: %s\n", strings.Join(projectSecrets.RequiredEverywhere, ", "))
+		}
+		for _, env := range projectSecrets.Environments {
+			fmt.Printf("🏷️  %s (%s)\n", env.Name, strings.Join(env.Files, ", "))
+			if len(env.Specific) > 0 {
+				fmt.Printf("   Only here: %s\n", strings.Join(env.Specific, ", "))
+			} else {
+				fmt.Println("   Nothing beyond the shared variables")
+			}
+		}
+		fmt.Println()
+	}
+	
 	// Setup Instructions
 	if projectSecrets.RequiredCount > 0 {
 		fmt.Println("🛠️  SETUP INSTRUCTIONS")
@@ -0,0 +1,82 @@
+// Command benchcheck reads the output of go test -bench -benchmem on standard input and fails when
+// a benchmark is slower than internal/bench/baseline.json by more than BENCH_THRESHOLD, or
+// rewrites the baseline when UPDATE_BENCH is set. Run it from the repository root:
+//
+//	go test -run '^$' -bench . -benchmem -count 3 ./internal/bench | go run ./cmd/benchcheck
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"repo-explanation/internal/bench"
+)
+
+func main() {
+	update := os.Getenv(bench.UpdateEnv) != ""
+	threshold := bench.DefaultThreshold
+	if value := os.Getenv(bench.ThresholdEnv); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed <= 0 {
+			fmt.Printf("❌ %s must be a positive fraction such as 0.25 (got %q)\n", bench.ThresholdEnv, value)
+			os.Exit(2)
+		}
+		threshold = parsed
+	}
+
+	measured, err := bench.Parse(os.Stdin)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(2)
+	}
+
+	var results []bench.Result
+	if update {
+		if err := bench.WriteBaseline(bench.BaselineFile, measured); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(2)
+		}
+		for _, m := range measured.Measurements {
+			results = append(results, bench.Result{Name: m.Name, Status: "updated", Actual: m})
+		}
+	} else {
+		baseline, err := bench.ReadBaseline(bench.BaselineFile)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(2)
+		}
+		results = bench.Compare(measured, baseline, threshold)
+	}
+
+	failed := 0
+	for _, result := range results {
+		actual := fmt.Sprintf("%8.1f ms/op %9d allocs/op %6.1f× calibration", float64(result.Actual.NsPerOp)/1e6, result.Actual.AllocsPerOp, result.Actual.Relative)
+		switch result.Status {
+		case "updated":
+			fmt.Printf("📝 %-38s %s\n", result.Name, actual)
+		case "missing":
+			failed++
+			fmt.Printf("❌ %-38s %s  no baseline; run with %s=1 to record it\n", result.Name, actual, bench.UpdateEnv)
+		case "regressed":
+			failed++
+			fmt.Printf("❌ %-38s %s  %s\n", result.Name, actual, result.Reason)
+		default:
+			note := ""
+			if bench.IOBound[result.Name] {
+				note = " (I/O-bound: time not gated)"
+			}
+			fmt.Printf("✅ %-38s %s  %+.0f%%%s\n", result.Name, actual, result.Change*100, note)
+		}
+	}
+	fmt.Printf("\nCalibration %d ns/op on %s, %s\n", measured.Calibration, measured.Platform, measured.GoVersion)
+	if update {
+		fmt.Printf("Baseline written to %s\n", bench.BaselineFile)
+		return
+	}
+	fmt.Printf("%d of %d benchmarks within %.0f%% of the baseline\n", len(results)-failed, len(results), threshold*100)
+	if failed > 0 {
+		fmt.Printf("Profile the regression, or run with %s=1 to accept an intended change\n", bench.UpdateEnv)
+		os.Exit(1)
+	}
+}
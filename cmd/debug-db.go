@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"repo-explanation/internal/database"
+	"repo-explanation/internal/filestore"
 )
 
 func main() {
@@ -30,7 +31,7 @@ func main() {
 		os.Exit(1)
 	}
 	
-	fmt.Printf("✅ Found %d total files\n", len(files))
+	fmt.Printf("✅ Found %d total files\n", files.Len())
 
 	// Step 2: Filter for SQL files
 	fmt.Println("\n📄 Step 2: Filtering for SQL migration files...")
@@ -139,9 +140,9 @@ func main() {
 	fmt.Println("\n✅ Database schema extraction completed successfully!")
 }
 
-// scanFiles recursively scans a directory for all files
-func scanFiles(rootPath string) (map[string]string, error) {
-	files := make(map[string]string)
+// scanFiles recursively indexes a directory's files without reading them
+func scanFiles(rootPath string) (*filestore.FileStore, error) {
+	files := filestore.New(0, nil)
 	
 	err := filepath.WalkDir(rootPath, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
@@ -158,31 +159,24 @@ func scanFiles(rootPath string) (map[string]string, error) {
 			return err
 		}
 		
-		// Read file content
-		content, err := os.ReadFile(path)
+		info, err := d.Info()
 		if err != nil {
-			// Skip files we can't read
+			// Skip files we can't stat
 			return nil
 		}
 		
-		files[relPath] = string(content)
+		files.Add(relPath, path, info.Size())
 		return nil
 	})
 	
 	return files, err
 }
 
-// filterSQLFiles filters for SQL files that might contain migrations
-func filterSQLFiles(files map[string]string) map[string]string {
-	sqlFiles := make(map[string]string)
-	
-	for path, content := range files {
-		if strings.HasSuffix(strings.ToLower(path), ".sql") {
-			sqlFiles[path] = content
-		}
-	}
-	
-	return sqlFiles
+// filterSQLFiles reads the SQL files that might contain migrations
+func filterSQLFiles(files *filestore.FileStore) map[string]string {
+	return files.Contents(func(path string) bool {
+		return strings.HasSuffix(strings.ToLower(path), ".sql") || database.IsLiquibaseChangelog(path)
+	})
 }
 
 // findMigrationDirectories finds directories that contain "migration" in their path
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 
+	"repo-explanation/config"
 	"repo-explanation/controllers"
 	"repo-explanation/routes"
 
@@ -19,12 +20,13 @@ func main() {
 	e.Use(middleware.CORS())
 
 	// Initialize controllers
-	healthController := controllers.NewHealthController()
+	cfg := config.Defaults()
+	healthController := controllers.NewHealthController(cfg)
 
 	fmt.Println("running into this")
 
 	// Setup routes
-	routes.SetupRoutes(e, healthController, nil)
+	routes.SetupRoutes(e, cfg, healthController, nil, controllers.NewTrackingController(nil))
 
 	// Start server
 	e.Logger.Fatal(e.Start(":8080"))
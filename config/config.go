@@ -1,10 +1,17 @@
 package config
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -18,7 +25,25 @@ type Config struct {
 	FileProcessing  FileProcessingConfig  `yaml:"file_processing"`
 	Cache           CacheConfig           `yaml:"cache"`
 	Security        SecurityConfig        `yaml:"security"`
+	Privacy         PrivacyConfig         `yaml:"privacy"`
+	Audit           AuditConfig           `yaml:"audit"`
+	Encryption      EncryptionConfig      `yaml:"encryption"`
+	Estimate        EstimateConfig        `yaml:"estimate"`
 	Output          OutputConfig          `yaml:"output"`
+	Timeouts        TimeoutsConfig        `yaml:"timeouts"`
+	Limits          LimitsConfig          `yaml:"limits"`
+	Server          ServerConfig          `yaml:"server"`
+	Detection       DetectionConfig       `yaml:"detection"`
+	Relationships   RelationshipsConfig   `yaml:"relationships"`
+	Tracking        TrackingConfig        `yaml:"tracking"`
+	Publish         PublishConfig         `yaml:"publish"`
+	// Profiles are named analysis presets selected with --profile or a request's profile field
+	Profiles        map[string]ProfileConfig `yaml:"profiles"`
+	// Offline skips every LLM call and produces heuristic summaries instead
+	Offline         bool                  `yaml:"offline"`
+
+	path      string   // Config file the values came from; empty when only defaults applied
+	overrides []string // ANALYZER_* variables that were applied
 }
 
 type OpenAIConfig struct {
@@ -26,18 +51,32 @@ type OpenAIConfig struct {
 	Model               string  `yaml:"model"`
 	MaxTokensPerRequest int     `yaml:"max_tokens_per_request"`
 	Temperature         float32 `yaml:"temperature"`
-	BaseURL             string  `yaml:"base_url"`
+	BaseURL             string  `yaml:"base_url"` // An OpenAI-compatible gateway, e.g. https://llm-gateway.internal/v1
+	// Organization is sent as the OpenAI-Organization header
+	Organization        string  `yaml:"organization"`
+	// Proxy routes LLM traffic through an HTTP(S) or SOCKS5 proxy; empty uses HTTPS_PROXY and NO_PROXY
+	Proxy               string  `yaml:"proxy"`
+	// Headers are added to every LLM request, e.g. gateway routing or tenant headers
+	Headers             map[string]string `yaml:"headers"`
+	// PromptsDir holds Go templates replacing the built-in prompts (file.tmpl, file.system.tmpl, ...)
+	PromptsDir          string  `yaml:"prompts_dir"`
 }
 
 type RateLimitingConfig struct {
 	RequestsPerMinute  int `yaml:"requests_per_minute"`
 	RequestsPerDay     int `yaml:"requests_per_day"`
 	ConcurrentWorkers  int `yaml:"concurrent_workers"`
+	// AdaptiveConcurrency starts the map phase at ConcurrentWorkers and tunes the worker count
+	// between 1 and MaxConcurrentWorkers from LLM latency, errors and 429 responses
+	AdaptiveConcurrency  bool `yaml:"adaptive_concurrency"`
+	MaxConcurrentWorkers int  `yaml:"max_concurrent_workers"`
 }
 
 type FileProcessingConfig struct {
 	MaxFileSizeMB         int      `yaml:"max_file_size_mb"`
 	ChunkSizeTokens       int      `yaml:"chunk_size_tokens"`
+	ChunkStrategy         string   `yaml:"chunk_strategy"` // "syntax" ends chunks between declarations where it can; "tokens" cuts at the token limit
+	BatchSize             int      `yaml:"batch_size"` // Small files summarized per LLM call; 0 or 1 disables batching
 	SupportedExtensions   []string `yaml:"supported_extensions"`
 }
 
@@ -45,6 +84,7 @@ type CacheConfig struct {
 	Enabled   bool   `yaml:"enabled"`
 	Directory string `yaml:"directory"`
 	TTLHours  int    `yaml:"ttl_hours"`
+	MaxSizeMB int    `yaml:"max_size_mb"` // Least recently used entries are evicted past this size; 0 for no limit
 }
 
 type SecurityConfig struct {
@@ -52,14 +92,243 @@ type SecurityConfig struct {
 	SkipSecretFiles  []string `yaml:"skip_secret_files"`
 }
 
+// PrivacyConfig anonymizes what is sent to the LLM, for codebases whose contents are restricted
+type PrivacyConfig struct {
+	Enabled              bool     `yaml:"enabled"`
+	StripStrings         bool     `yaml:"strip_strings"`          // Blank string literals
+	StripContactComments bool     `yaml:"strip_contact_comments"` // Drop comments holding emails or URLs
+	SensitiveIdentifiers []string `yaml:"sensitive_identifiers"`  // Regexes; matching identifiers become stable placeholders
+	RestrictedPaths      []string `yaml:"restricted_paths"`       // Globs or folders whose contents are never sent
+}
+
+// AuditConfig records every LLM prompt and response for compliance reviews and debugging
+type AuditConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Path    string `yaml:"path"` // Append-only JSONL file; secrets are redacted before writing
+}
+
+// EncryptionConfig encrypts caches, checkpoints and stored results at rest with AES-256-GCM.
+// The base64-encoded 32-byte key comes from exactly one of Key, KeyFile and KeyCommand.
+type EncryptionConfig struct {
+	Enabled    bool   `yaml:"enabled"`
+	Key        string `yaml:"key"`         // Usually ${ANALYZER_DATA_KEY} rather than the key itself
+	KeyFile    string `yaml:"key_file"`    // File holding the key, e.g. a mounted Kubernetes secret
+	KeyCommand string `yaml:"key_command"` // Shell command printing the key, e.g. a KMS decrypt of a wrapped data key
+}
+
+// EstimateConfig prices the LLM calls of an analysis before they are made
+type EstimateConfig struct {
+	// ConfirmAboveUSD makes interactive analyses estimated above this cost ask before starting; 0 never asks
+	ConfirmAboveUSD float64 `yaml:"confirm_above_usd"`
+	// Prices per million tokens overriding the built-in price of openai.model, or pricing a model
+	// without one; 0 keeps the built-in price
+	InputUSDPerMillion  float64 `yaml:"input_usd_per_million"`
+	OutputUSDPerMillion float64 `yaml:"output_usd_per_million"`
+}
+
 type OutputConfig struct {
 	SummaryMaxLength         int    `yaml:"summary_max_length"`
 	SaveIntermediateResults  bool   `yaml:"save_intermediate_results"`
 	OutputDirectory          string `yaml:"output_directory"`
 }
 
-// LoadConfig loads configuration from YAML file with environment variable substitution
+// TimeoutsConfig bounds the whole analysis and each pipeline phase (0 disables a phase timeout)
+type TimeoutsConfig struct {
+	AnalysisMinutes  int `yaml:"analysis_minutes"`
+	FilesMinutes     int `yaml:"files_minutes"`
+	FoldersMinutes   int `yaml:"folders_minutes"`
+	ProjectMinutes   int `yaml:"project_minutes"`
+	ServicesMinutes  int `yaml:"services_minutes"`
+	SchemaMinutes    int `yaml:"schema_minutes"`
+	InfraMinutes     int `yaml:"infrastructure_minutes"`
+	DeadCodeMinutes  int `yaml:"deadcode_minutes"`
+	SecretsMinutes   int `yaml:"secrets_minutes"`
+	QuestionsMinutes int `yaml:"questions_minutes"`
+	GlossaryMinutes  int `yaml:"glossary_minutes"`
+}
+
+// LimitsConfig holds the thresholds of the extraction heuristics; raise them for large repositories
+type LimitsConfig struct {
+	DetectionReadKB         int `yaml:"detection_read_kb"`        // Largest file test-detection reads
+	ImportantFileChars      int `yaml:"important_file_chars"`     // Characters of each manifest, README or CI file in the architecture prompt
+	FilePromptChars         int `yaml:"file_prompt_chars"`        // Characters of a file in the lightweight per-file prompt
+	BatchFileBytes          int `yaml:"batch_file_bytes"`         // Largest file packed into a batched prompt
+	KeyFiles                int `yaml:"key_files"`                // Key files listed for question generation
+	DocumentationFiles      int `yaml:"documentation_files"`      // Documentation files feeding the project digest
+	ArchitectureDirectories int `yaml:"architecture_directories"` // Directories listed to the LLM per service
+	SequenceFlows           int `yaml:"sequence_flows"`           // Request flows drawn as sequence diagrams
+	GlossaryConcepts        int `yaml:"glossary_concepts"`        // Recurring nouns added to the glossary
+	RelationshipCacheHours  int `yaml:"relationship_cache_hours"` // Age at which a cached service graph is rediscovered
+	ScanFileKB              int `yaml:"scan_file_kb"`             // Largest file the static scanners (auth, jobs, package graph, dead code, endpoints, ...) read
+}
+
+// ServerConfig protects a shared server deployment from overload (0 disables a limit)
+type ServerConfig struct {
+	RequestsPerMinute     int `yaml:"requests_per_minute"`     // API requests per client IP
+	Burst                 int `yaml:"burst"`                   // Requests a client may make at once before the rate applies
+	MaxConcurrentAnalyses int `yaml:"max_concurrent_analyses"` // Analyses running at the same time
+	AnalysisQueueSize     int `yaml:"analysis_queue_size"`     // Analyses waiting for a slot; beyond this requests get 429
+	MaxBodyKB             int `yaml:"max_body_kb"`             // Largest accepted request body
+	MaxUploadMB           int `yaml:"max_upload_mb"`           // Largest archive the upload endpoint accepts; 0 disables uploads
+	MaxExtractedMB        int `yaml:"max_extracted_mb"`        // Largest total size an uploaded archive may expand to
+
+	// AllowedRoots are the only directories whose contents path-based endpoints may read; empty disables them
+	AllowedRoots []string `yaml:"allowed_roots"`
+
+	// WebUI serves the embedded single-page UI at / when there is no ./static frontend build
+	WebUI bool `yaml:"web_ui"`
+
+	// AdminToken is the bearer token admin endpoints (cache priming) require; empty disables them
+	AdminToken string `yaml:"admin_token"`
+}
+
+// DetectionConfig extends project type detection
+type DetectionConfig struct {
+	// RulesFile is a YAML or JSON rule pack adding or overriding detection rules; empty uses the built-in rules only
+	RulesFile string `yaml:"rules_file"`
+}
+
+// RelationshipsConfig filters the service dependency graph
+type RelationshipsConfig struct {
+	// MinConfidence drops relationships whose confidence (0-1) is lower; 0 keeps every relationship
+	MinConfidence float64 `yaml:"min_confidence"`
+
+	// OnlyEvidence keeps relationships backed by these evidence types (config, import, network,
+	// api_spec, client_library, shared_database); empty keeps every type
+	OnlyEvidence []string `yaml:"only_evidence"`
+}
+
+// TrackingConfig re-analyzes repositories on a schedule in server mode and reports what changed
+// since the previous run
+type TrackingConfig struct {
+	// StateDirectory keeps the last analysis of each repository, and clones of those given by URL
+	StateDirectory string              `yaml:"state_directory"`
+	Repositories   []TrackedRepository `yaml:"repositories"`
+	SMTP           SMTPConfig          `yaml:"smtp"` // Required when a repository emails its reports
+}
+
+// TrackedRepository is one repository the server re-analyzes on its own cron schedule
+type TrackedRepository struct {
+	Name     string   `yaml:"name"`
+	Path     string   `yaml:"path"`     // Local checkout, pulled before each run when Pull is set
+	URL      string   `yaml:"url"`      // Git URL cloned into the state directory; set Path or URL
+	Pull     bool     `yaml:"pull"`     // git pull --ff-only before analyzing a local checkout
+	Schedule string   `yaml:"schedule"` // Five-field cron expression or a macro such as @daily, in server local time
+	Phases   []string `yaml:"phases"`   // Phases run; empty runs services, schema and secrets, which need no LLM
+	Webhooks []string `yaml:"webhooks"` // URLs posted the drift report as JSON; Slack and Teams incoming webhooks read its text
+	Email    []string `yaml:"email"`    // Addresses emailed the drift report
+	// NotifyUnchanged also reports runs that found no change
+	NotifyUnchanged bool `yaml:"notify_unchanged"`
+}
+
+// SMTPConfig is the mail server drift reports are sent through
+type SMTPConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	From     string `yaml:"from"`
+}
+
+// PublishConfig holds the wiki pages -publish pushes the onboarding report to. Re-publishing a
+// repository updates its page, found by title, in place.
+type PublishConfig struct {
+	Title      string           `yaml:"title"` // Page title; {repo} is replaced by the repository name
+	Confluence ConfluenceConfig `yaml:"confluence"`
+	Notion     NotionConfig     `yaml:"notion"`
+}
+
+// ConfluenceConfig is a Confluence space the report is published to
+type ConfluenceConfig struct {
+	BaseURL  string `yaml:"base_url"`  // e.g. https://acme.atlassian.net/wiki
+	Email    string `yaml:"email"`     // Account of the API token; empty sends the token as a personal access token (Data Center)
+	APIToken string `yaml:"api_token"`
+	SpaceKey string `yaml:"space_key"`
+	ParentID string `yaml:"parent_id"` // Page the report is created under; empty creates it at the top of the space
+}
+
+// NotionConfig is a Notion page the report is published under
+type NotionConfig struct {
+	Token        string `yaml:"token"`          // Internal integration secret; share the parent page with the integration
+	ParentPageID string `yaml:"parent_page_id"`
+}
+
+// ProfileConfig preselects the phases an analysis runs and frames its project-level prompts
+type ProfileConfig struct {
+	Description string   `yaml:"description"`
+	Phases      []string `yaml:"phases"` // Phases run when a request selects none; empty runs every phase
+	Focus       string   `yaml:"focus"`  // Added to the project overview, architecture and question prompts
+}
+
+// analysisPhases are the phase names profiles.*.phases accepts
+var analysisPhases = []string{"files", "folders", "project", "services", "schema", "infrastructure", "deadcode", "secrets", "questions", "glossary"}
+
+// relationshipEvidence are the evidence types relationships.only_evidence accepts
+var relationshipEvidence = []string{"config", "import", "network", "api_spec", "client_library", "shared_database"}
+
+// headerNameRegex matches the HTTP header names openai.headers accepts
+var headerNameRegex = regexp.MustCompile(`^[A-Za-z0-9!#$%&'*+.^_|~-]+$`)
+
+// searchPaths are tried in order when REPO_CONFIG does not name the config file
+var searchPaths = []string{
+	"config.yaml",      // Same directory (Docker container)
+	"../config.yaml",   // Parent directory (local development)
+	"/app/config.yaml", // Absolute path in container
+}
+
+var (
+	loadOnce   sync.Once
+	loadedConf *Config
+	loadErr    error
+)
+
+// Load finds and loads the process configuration. The file is read once at startup;
+// later calls return the same configuration (or the same error).
+func Load() (*Config, error) {
+	loadOnce.Do(func() {
+		loadedConf, loadErr = LoadConfig(FindConfigFile())
+	})
+	return loadedConf, loadErr
+}
+
+// FindConfigFile returns the file named by REPO_CONFIG, else the first search path that exists.
+// An empty result means no file was found and only defaults and environment overrides apply.
+func FindConfigFile() string {
+	if path := os.Getenv("REPO_CONFIG"); path != "" {
+		return path
+	}
+	for _, path := range searchPaths {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// LoadConfig loads configuration from YAML file with environment variable substitution,
+// then validates it and creates the cache and output directories
 func LoadConfig(configPath string) (*Config, error) {
+	config, err := Resolve(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate configuration
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration in %s:%v", config.Source(), err)
+	}
+
+	// Ensure directories exist
+	if err := config.ensureDirectories(); err != nil {
+		return nil, fmt.Errorf("failed to create directories: %v", err)
+	}
+
+	return config, nil
+}
+
+// Resolve merges the defaults, the config file (skipped when configPath is empty) and
+// ANALYZER_* environment overrides, without validating the result
+func Resolve(configPath string) (*Config, error) {
 	// Load .env file if it exists (ignore errors if file doesn't exist)
 	if err := godotenv.Load(); err != nil {
 		// Only log if the error is NOT "file not found"
@@ -68,56 +337,430 @@ func LoadConfig(configPath string) (*Config, error) {
 		}
 	}
 
-	// Read config file
-	data, err := os.ReadFile(configPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %v", err)
-	}
+	config := Defaults()
+	config.path = configPath
 
-	// Substitute environment variables
-	content := string(data)
-	content = expandEnvVars(content)
+	if configPath != "" {
+		// Read config file
+		data, err := os.ReadFile(configPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %v", err)
+		}
+
+		// Substitute environment variables
+		content := expandEnvVars(string(data))
 
-	// Parse YAML
-	var config Config
-	if err := yaml.Unmarshal([]byte(content), &config); err != nil {
-		return nil, fmt.Errorf("failed to parse config YAML: %v", err)
+		// Parse YAML on top of the defaults; misspelled keys are errors rather than silently ignored
+		decoder := yaml.NewDecoder(strings.NewReader(content))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(config); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("failed to parse %s: %v", configPath, err)
+		}
+
+		// Profiles from the file add to the built-in ones, replacing those with the same name
+		for name, profile := range Defaults().Profiles {
+			if _, ok := config.Profiles[name]; !ok {
+				if config.Profiles == nil {
+					config.Profiles = make(map[string]ProfileConfig)
+				}
+				config.Profiles[name] = profile
+			}
+		}
 	}
 
-	// Validate configuration
-	if err := config.Validate(); err != nil {
-		return nil, fmt.Errorf("invalid configuration: %v", err)
+	if err := config.applyEnvOverrides(); err != nil {
+		return nil, err
 	}
 
-	// Ensure directories exist
-	if err := config.ensureDirectories(); err != nil {
-		return nil, fmt.Errorf("failed to create directories: %v", err)
+	return config, nil
+}
+
+// Defaults returns the configuration used for every field the config file and environment leave unset
+func Defaults() *Config {
+	return &Config{
+		OpenAI: OpenAIConfig{
+			APIKey:              os.Getenv("OPENAI_API_KEY"),
+			Model:               "gpt-4o-mini",
+			MaxTokensPerRequest: 4000,
+			Temperature:         0.1,
+		},
+		RateLimiting: RateLimitingConfig{
+			RequestsPerMinute: 500,
+			RequestsPerDay:    10000,
+			ConcurrentWorkers:    6,
+			MaxConcurrentWorkers: 24,
+		},
+		FileProcessing: FileProcessingConfig{
+			MaxFileSizeMB:   10,
+			ChunkSizeTokens: 3000,
+			ChunkStrategy:   "syntax",
+			BatchSize:       8,
+			SupportedExtensions: []string{
+				".go", ".js", ".ts", ".py", ".java", ".cpp", ".c", ".h", ".hpp", ".rs", ".rb", ".php",
+				".cs", ".kt", ".swift", ".scala", ".clj", ".hs", ".ml", ".r", ".sql", ".sh", ".bash",
+				".zsh", ".ps1", ".html", ".css", ".scss", ".less", ".json", ".xml", ".yaml", ".yml",
+				".toml", ".tf", ".ini", ".cfg", ".md", ".rst", ".txt", ".dockerfile", ".makefile",
+			},
+		},
+		Cache: CacheConfig{
+			Enabled:   true,
+			Directory: "./cache",
+			TTLHours:  24,
+			MaxSizeMB: 1024,
+		},
+		Security: SecurityConfig{
+			RedactSecrets: true,
+			SkipSecretFiles: []string{
+				".env", ".env.local", ".env.production", "secrets.yaml", "secrets.json",
+				"id_rsa", "id_ed25519", "*.key", "*.pem", "*.p12", "*.pfx",
+			},
+		},
+		Privacy: PrivacyConfig{
+			StripStrings:         true,
+			StripContactComments: true,
+		},
+		Publish: PublishConfig{
+			Title: "{repo} onboarding guide",
+		},
+		Audit: AuditConfig{
+			Path: "./analysis_results/llm_audit.jsonl",
+		},
+		Estimate: EstimateConfig{
+			ConfirmAboveUSD: 1,
+		},
+		Output: OutputConfig{
+			SummaryMaxLength:        500,
+			SaveIntermediateResults: true,
+			OutputDirectory:         "./analysis_results",
+		},
+		Timeouts: TimeoutsConfig{
+			AnalysisMinutes:  60,
+			ServicesMinutes:  5,
+			SchemaMinutes:    5,
+			InfraMinutes:     2,
+			DeadCodeMinutes:  2,
+			SecretsMinutes:   2,
+			QuestionsMinutes: 5,
+			GlossaryMinutes:  3,
+		},
+		Limits: LimitsConfig{
+			DetectionReadKB:         100,
+			ImportantFileChars:      2000,
+			FilePromptChars:         2000,
+			BatchFileBytes:          2000,
+			KeyFiles:                8,
+			DocumentationFiles:      20,
+			ArchitectureDirectories: 80,
+			SequenceFlows:           5,
+			GlossaryConcepts:        15,
+			RelationshipCacheHours:  24,
+			ScanFileKB:              1024,
+		},
+		Server: ServerConfig{
+			RequestsPerMinute:     60,
+			Burst:                 20,
+			MaxConcurrentAnalyses: 2,
+			AnalysisQueueSize:     4,
+			MaxBodyKB:             256,
+			MaxUploadMB:           100,
+			MaxExtractedMB:        1024,
+			WebUI:                 true,
+		},
+		Tracking: TrackingConfig{
+			StateDirectory: "./analysis_results/tracking",
+			SMTP:           SMTPConfig{Port: 587},
+		},
+		Profiles: map[string]ProfileConfig{
+			"backend": {
+				Description: "Services, APIs, data stores and their dependencies",
+				Phases:      []string{"files", "folders", "project", "services", "schema", "infrastructure", "secrets", "questions", "glossary"},
+				Focus:       "Frame the analysis for a backend engineer: emphasize services, API endpoints, request handling, data stores, inter-service dependencies and operational configuration.",
+			},
+			"frontend": {
+				Description: "Routes, components, state management and client configuration",
+				Phases:      []string{"files", "folders", "project", "deadcode", "secrets", "questions", "glossary"},
+				Focus:       "Frame the analysis for a frontend engineer: emphasize routes, pages, components, state management, API clients and build tooling.",
+			},
+			"data": {
+				Description: "Schemas, migrations, pipelines and the infrastructure they run on",
+				Phases:      []string{"files", "folders", "project", "schema", "infrastructure", "questions", "glossary"},
+				Focus:       "Frame the analysis for a data platform engineer: emphasize database schemas, migrations, data models, ETL and streaming pipelines, scheduled jobs and storage infrastructure.",
+			},
+		},
 	}
+}
 
-	return &config, nil
+// Profile returns the named analysis profile; an empty name selects no profile
+func (c *Config) Profile(name string) (ProfileConfig, error) {
+	if name == "" {
+		return ProfileConfig{}, nil
+	}
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return ProfileConfig{}, fmt.Errorf("unknown profile %q (available profiles: %s)", name, strings.Join(c.ProfileNames(), ","))
+	}
+	return profile, nil
 }
 
-// Validate checks if the configuration is valid
+// ProfileNames lists the configured profiles, sorted
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate checks if the configuration is valid, reporting every problem at once by its YAML path
 func (c *Config) Validate() error {
-	if c.OpenAI.APIKey == "" {
-		return fmt.Errorf("OpenAI API key is required")
+	var problems []string
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	check(c.OpenAI.APIKey != "" || c.Offline,
+		"openai.api_key is required (set OPENAI_API_KEY, or offline: true / ANALYZER_OFFLINE=true to analyze without an LLM)")
+	check(c.OpenAI.Model != "" || c.Offline, "openai.model is required")
+	check(c.OpenAI.MaxTokensPerRequest > 0, "openai.max_tokens_per_request must be positive (got %d)", c.OpenAI.MaxTokensPerRequest)
+	check(c.OpenAI.Temperature >= 0 && c.OpenAI.Temperature <= 2, "openai.temperature must be between 0 and 2 (got %g)", c.OpenAI.Temperature)
+	if c.OpenAI.BaseURL != "" {
+		parsed, err := url.Parse(c.OpenAI.BaseURL)
+		check(err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != "",
+			"openai.base_url must be an http(s) URL (got %q)", c.OpenAI.BaseURL)
+	}
+	if c.OpenAI.Proxy != "" {
+		parsed, err := url.Parse(c.OpenAI.Proxy)
+		check(err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https" || parsed.Scheme == "socks5") && parsed.Host != "",
+			"openai.proxy must be an http(s) or socks5 URL (got %q)", c.OpenAI.Proxy)
+	}
+	for name := range c.OpenAI.Headers {
+		check(headerNameRegex.MatchString(name), "openai.headers has an invalid header name %q", name)
+	}
+
+	check(c.RateLimiting.RequestsPerMinute > 0, "rate_limiting.requests_per_minute must be positive (got %d)", c.RateLimiting.RequestsPerMinute)
+	check(c.RateLimiting.RequestsPerDay >= 0, "rate_limiting.requests_per_day must not be negative (got %d)", c.RateLimiting.RequestsPerDay)
+	check(c.RateLimiting.ConcurrentWorkers >= 1 && c.RateLimiting.ConcurrentWorkers <= 64,
+		"rate_limiting.concurrent_workers must be between 1 and 64 (got %d)", c.RateLimiting.ConcurrentWorkers)
+	check(!c.RateLimiting.AdaptiveConcurrency || (c.RateLimiting.MaxConcurrentWorkers >= c.RateLimiting.ConcurrentWorkers && c.RateLimiting.MaxConcurrentWorkers <= 64),
+		"rate_limiting.max_concurrent_workers must be between concurrent_workers (%d) and 64 when adaptive_concurrency is on (got %d)",
+		c.RateLimiting.ConcurrentWorkers, c.RateLimiting.MaxConcurrentWorkers)
+
+	check(c.FileProcessing.MaxFileSizeMB > 0, "file_processing.max_file_size_mb must be positive (got %d)", c.FileProcessing.MaxFileSizeMB)
+	check(c.FileProcessing.ChunkSizeTokens > 0, "file_processing.chunk_size_tokens must be positive (got %d)", c.FileProcessing.ChunkSizeTokens)
+	check(c.FileProcessing.ChunkStrategy == "syntax" || c.FileProcessing.ChunkStrategy == "tokens",
+		"file_processing.chunk_strategy must be syntax or tokens (got %q)", c.FileProcessing.ChunkStrategy)
+	check(c.FileProcessing.BatchSize >= 0 && c.FileProcessing.BatchSize <= 50,
+		"file_processing.batch_size must be between 0 and 50 (got %d)", c.FileProcessing.BatchSize)
+	check(len(c.FileProcessing.SupportedExtensions) > 0, "file_processing.supported_extensions must list at least one extension")
+	for i, ext := range c.FileProcessing.SupportedExtensions {
+		check(strings.HasPrefix(ext, ".") && ext == strings.ToLower(ext),
+			"file_processing.supported_extensions[%d] must be a lowercase extension starting with a dot (got %q)", i, ext)
 	}
 
-	if c.OpenAI.Model == "" {
-		return fmt.Errorf("OpenAI model is required")
+	check(!c.Cache.Enabled || c.Cache.Directory != "", "cache.directory is required when cache.enabled is true")
+	check(c.Cache.TTLHours >= 0, "cache.ttl_hours must not be negative (got %d)", c.Cache.TTLHours)
+	check(c.Cache.MaxSizeMB >= 0, "cache.max_size_mb must not be negative (got %d)", c.Cache.MaxSizeMB)
+
+	for i, pattern := range c.Security.SkipSecretFiles {
+		_, err := filepath.Match(pattern, "")
+		check(err == nil, "security.skip_secret_files[%d] is not a valid glob (got %q)", i, pattern)
+	}
+
+	for i, pattern := range c.Privacy.SensitiveIdentifiers {
+		_, err := regexp.Compile(pattern)
+		check(pattern != "" && err == nil, "privacy.sensitive_identifiers[%d] is not a valid regular expression (got %q)", i, pattern)
+	}
+	for i, pattern := range c.Privacy.RestrictedPaths {
+		check(strings.TrimSpace(pattern) != "", "privacy.restricted_paths[%d] must not be empty", i)
+	}
+
+	check(c.Publish.Title != "", "publish.title is required")
+	if c.Publish.Confluence.BaseURL != "" {
+		parsed, err := url.Parse(c.Publish.Confluence.BaseURL)
+		check(err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != "",
+			"publish.confluence.base_url must be an http(s) URL (got %q)", c.Publish.Confluence.BaseURL)
+	}
+
+	check(!c.Audit.Enabled || c.Audit.Path != "", "audit.path is required when audit.enabled is true")
+
+	if c.Encryption.Enabled {
+		sources := 0
+		for _, source := range []string{c.Encryption.Key, c.Encryption.KeyFile, c.Encryption.KeyCommand} {
+			if source != "" {
+				sources++
+			}
+		}
+		check(sources == 1, "encryption.enabled needs exactly one of encryption.key, encryption.key_file and encryption.key_command (got %d)", sources)
 	}
 
-	if c.FileProcessing.ChunkSizeTokens <= 0 {
-		return fmt.Errorf("chunk size tokens must be positive")
+	check(c.Estimate.ConfirmAboveUSD >= 0, "estimate.confirm_above_usd must not be negative (got %g)", c.Estimate.ConfirmAboveUSD)
+	check(c.Estimate.InputUSDPerMillion >= 0, "estimate.input_usd_per_million must not be negative (got %g)", c.Estimate.InputUSDPerMillion)
+	check(c.Estimate.OutputUSDPerMillion >= 0, "estimate.output_usd_per_million must not be negative (got %g)", c.Estimate.OutputUSDPerMillion)
+
+	check(c.Output.SummaryMaxLength >= 0, "output.summary_max_length must not be negative (got %d)", c.Output.SummaryMaxLength)
+	check(c.Output.OutputDirectory != "", "output.output_directory is required")
+
+	timeouts := reflect.ValueOf(c.Timeouts)
+	for i := 0; i < timeouts.NumField(); i++ {
+		minutes := timeouts.Field(i).Int()
+		check(minutes >= 0, "timeouts.%s must not be negative (got %d)", yamlName(timeouts.Type().Field(i)), minutes)
+	}
+
+	limits := reflect.ValueOf(c.Limits)
+	for i := 0; i < limits.NumField(); i++ {
+		value := limits.Field(i).Int()
+		check(value > 0, "limits.%s must be positive (got %d)", yamlName(limits.Type().Field(i)), value)
+	}
+
+	server := reflect.ValueOf(c.Server)
+	for i := 0; i < server.NumField(); i++ {
+		if server.Field(i).Kind() != reflect.Int {
+			continue
+		}
+		value := server.Field(i).Int()
+		check(value >= 0, "server.%s must not be negative (got %d)", yamlName(server.Type().Field(i)), value)
+	}
+	for i, root := range c.Server.AllowedRoots {
+		check(filepath.IsAbs(root), "server.allowed_roots[%d] must be an absolute path (got %q)", i, root)
+	}
+
+	if c.OpenAI.PromptsDir != "" {
+		info, err := os.Stat(c.OpenAI.PromptsDir)
+		if err == nil && !info.IsDir() {
+			err = fmt.Errorf("%s is not a directory", c.OpenAI.PromptsDir)
+		}
+		check(err == nil, "openai.prompts_dir must name a readable directory (%v)", err)
+	}
+
+	if c.Detection.RulesFile != "" {
+		_, err := os.Stat(c.Detection.RulesFile)
+		check(err == nil, "detection.rules_file must name a readable file (%v)", err)
+	}
+
+	check(c.Relationships.MinConfidence >= 0 && c.Relationships.MinConfidence <= 1,
+		"relationships.min_confidence must be between 0 and 1 (got %g)", c.Relationships.MinConfidence)
+	for i, evidence := range c.Relationships.OnlyEvidence {
+		known := false
+		for _, name := range relationshipEvidence {
+			known = known || strings.EqualFold(strings.TrimSpace(evidence), name)
+		}
+		check(known, "relationships.only_evidence[%d] must be one of %s (got %q)", i, strings.Join(relationshipEvidence, ", "), evidence)
+	}
+
+	names := make(map[string]bool)
+	for i, repo := range c.Tracking.Repositories {
+		check(repo.Name != "", "tracking.repositories[%d].name is required", i)
+		check(!names[repo.Name], "tracking.repositories[%d].name %q is used twice", i, repo.Name)
+		names[repo.Name] = true
+		check((repo.Path == "") != (repo.URL == ""), "tracking.repositories[%d] must set exactly one of path and url", i)
+		check(repo.Path == "" || filepath.IsAbs(repo.Path), "tracking.repositories[%d].path must be an absolute path (got %q)", i, repo.Path)
+		check(repo.Schedule != "", "tracking.repositories[%d].schedule is required", i)
+		for j, phase := range repo.Phases {
+			known := false
+			for _, p := range analysisPhases {
+				known = known || strings.EqualFold(strings.TrimSpace(phase), p)
+			}
+			check(known, "tracking.repositories[%d].phases[%d] must be one of %s (got %q)", i, j, strings.Join(analysisPhases, ", "), phase)
+		}
+		for j, hook := range repo.Webhooks {
+			parsed, err := url.Parse(hook)
+			check(err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != "",
+				"tracking.repositories[%d].webhooks[%d] must be an http(s) URL (got %q)", i, j, hook)
+		}
+		check(len(repo.Email) == 0 || (c.Tracking.SMTP.Host != "" && c.Tracking.SMTP.From != ""),
+			"tracking.smtp.host and tracking.smtp.from are required when tracking.repositories[%d] sends email", i)
 	}
+	check(len(c.Tracking.Repositories) == 0 || c.Tracking.StateDirectory != "",
+		"tracking.state_directory is required when repositories are tracked")
+	check(c.Tracking.SMTP.Port >= 0, "tracking.smtp.port must not be negative (got %d)", c.Tracking.SMTP.Port)
 
-	if c.RateLimiting.RequestsPerMinute <= 0 {
-		return fmt.Errorf("requests per minute must be positive")
+	for _, name := range c.ProfileNames() {
+		for i, phase := range c.Profiles[name].Phases {
+			known := false
+			for _, p := range analysisPhases {
+				known = known || strings.EqualFold(strings.TrimSpace(phase), p)
+			}
+			check(known, "profiles.%s.phases[%d] must be one of %s (got %q)", name, i, strings.Join(analysisPhases, ", "), phase)
+		}
 	}
 
+	if len(problems) > 0 {
+		return fmt.Errorf("\n  - %s", strings.Join(problems, "\n  - "))
+	}
 	return nil
 }
 
+// Source describes where the configuration was loaded from
+func (c *Config) Source() string {
+	if c.path == "" {
+		return "built-in defaults (no config file found)"
+	}
+	return c.path
+}
+
+// EnvOverrides lists the ANALYZER_* variables that overrode config values
+func (c *Config) EnvOverrides() []string {
+	return c.overrides
+}
+
+// EffectiveYAML renders the merged configuration with the API key redacted
+func (c *Config) EffectiveYAML() ([]byte, error) {
+	redacted := *c
+	if key := redacted.OpenAI.APIKey; len(key) > 8 {
+		redacted.OpenAI.APIKey = "****" + key[len(key)-4:]
+	} else if key != "" {
+		redacted.OpenAI.APIKey = "****"
+	}
+	if len(c.OpenAI.Headers) > 0 {
+		// Gateway headers often carry credentials
+		redacted.OpenAI.Headers = make(map[string]string, len(c.OpenAI.Headers))
+		for name := range c.OpenAI.Headers {
+			redacted.OpenAI.Headers[name] = "****"
+		}
+	}
+	if parsed, err := url.Parse(c.OpenAI.Proxy); err == nil && parsed.User != nil {
+		parsed.User = url.User("****")
+		redacted.OpenAI.Proxy = parsed.String()
+	}
+	if redacted.Encryption.Key != "" {
+		redacted.Encryption.Key = "****"
+	}
+	if redacted.Server.AdminToken != "" {
+		redacted.Server.AdminToken = "****"
+	}
+	if redacted.Tracking.SMTP.Password != "" {
+		redacted.Tracking.SMTP.Password = "****"
+	}
+	if redacted.Publish.Confluence.APIToken != "" {
+		redacted.Publish.Confluence.APIToken = "****"
+	}
+	if redacted.Publish.Notion.Token != "" {
+		redacted.Publish.Notion.Token = "****"
+	}
+	redacted.Tracking.Repositories = make([]TrackedRepository, len(c.Tracking.Repositories))
+	for i, repo := range c.Tracking.Repositories {
+		if parsed, err := url.Parse(repo.URL); err == nil && parsed.User != nil {
+			parsed.User = url.User("****")
+			repo.URL = parsed.String()
+		}
+		redacted.Tracking.Repositories[i] = repo
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&redacted); err != nil {
+		return nil, fmt.Errorf("failed to render config: %v", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to render config: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
 // ensureDirectories creates necessary directories
 func (c *Config) ensureDirectories() error {
 	dirs := []string{
@@ -126,6 +769,9 @@ func (c *Config) ensureDirectories() error {
 	}
 
 	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
 		if err := os.MkdirAll(dir, 0755); err != nil {
 			return fmt.Errorf("failed to create directory %s: %v", dir, err)
 		}
@@ -141,11 +787,74 @@ func expandEnvVars(content string) string {
 	})
 }
 
+// isTruthy reports whether an environment value means "enabled"
+func isTruthy(value string) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "1", "true", "yes", "on":
+		return true
+	}
+	return false
+}
+
 // GetCacheTTL returns the cache TTL as a time.Duration
 func (c *Config) GetCacheTTL() time.Duration {
 	return time.Duration(c.Cache.TTLHours) * time.Hour
 }
 
+// GetRelationshipCacheTTL returns how long a cached service graph is reused
+func (c *Config) GetRelationshipCacheTTL() time.Duration {
+	return time.Duration(c.Limits.RelationshipCacheHours) * time.Hour
+}
+
+// GetScanFileBytes returns the size of the largest file the static scanners read
+func (c *Config) GetScanFileBytes() int64 {
+	return int64(c.Limits.ScanFileKB) * 1024
+}
+
+// GetCacheMaxBytes returns the cache size limit in bytes, 0 for no limit
+func (c *Config) GetCacheMaxBytes() int64 {
+	return int64(c.Cache.MaxSizeMB) * 1024 * 1024
+}
+
+// GetAnalysisTimeout returns the overall analysis timeout (60 minutes when unset)
+func (c *Config) GetAnalysisTimeout() time.Duration {
+	if c.Timeouts.AnalysisMinutes <= 0 {
+		return 60 * time.Minute
+	}
+	return time.Duration(c.Timeouts.AnalysisMinutes) * time.Minute
+}
+
+// GetPhaseTimeout returns the timeout for a pipeline phase, or 0 when the phase is unbounded
+func (c *Config) GetPhaseTimeout(phase string) time.Duration {
+	minutes := 0
+	switch phase {
+	case "files":
+		minutes = c.Timeouts.FilesMinutes
+	case "folders":
+		minutes = c.Timeouts.FoldersMinutes
+	case "project":
+		minutes = c.Timeouts.ProjectMinutes
+	case "services":
+		minutes = c.Timeouts.ServicesMinutes
+	case "schema":
+		minutes = c.Timeouts.SchemaMinutes
+	case "infrastructure":
+		minutes = c.Timeouts.InfraMinutes
+	case "deadcode":
+		minutes = c.Timeouts.DeadCodeMinutes
+	case "secrets":
+		minutes = c.Timeouts.SecretsMinutes
+	case "questions":
+		minutes = c.Timeouts.QuestionsMinutes
+	case "glossary":
+		minutes = c.Timeouts.GlossaryMinutes
+	}
+	if minutes <= 0 {
+		return 0
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
 // IsFileSupported checks if a file extension is supported
 func (c *Config) IsFileSupported(filename string) bool {
 	ext := strings.ToLower(filepath.Ext(filename))
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// envPrefix starts every override variable: ANALYZER_<SECTION>_<FIELD>, e.g. ANALYZER_OPENAI_MODEL
+const envPrefix = "ANALYZER_"
+
+// applyEnvOverrides sets every field whose ANALYZER_* variable is present.
+// Lists are comma-separated and maps are comma-separated name=value pairs; an empty value clears either.
+func (c *Config) applyEnvOverrides() error {
+	var problems []string
+	walkFields(reflect.ValueOf(c).Elem(), "", func(path string, field reflect.Value) {
+		name := envName(path)
+		raw, ok := os.LookupEnv(name)
+		if !ok {
+			return
+		}
+		if err := setField(field, raw); err != nil {
+			problems = append(problems, fmt.Sprintf("%s (%s): %v", name, path, err))
+			return
+		}
+		c.overrides = append(c.overrides, name)
+	})
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid environment overrides:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+// walkFields calls visit for every leaf field with its dotted YAML path
+func walkFields(value reflect.Value, prefix string, visit func(path string, field reflect.Value)) {
+	for i := 0; i < value.NumField(); i++ {
+		structField := value.Type().Field(i)
+		if !structField.IsExported() {
+			continue
+		}
+		path := yamlName(structField)
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+
+		field := value.Field(i)
+		if field.Kind() == reflect.Struct {
+			walkFields(field, path, visit)
+			continue
+		}
+		visit(path, field)
+	}
+}
+
+// yamlName returns the YAML key of a struct field
+func yamlName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+	if name == "" {
+		return strings.ToLower(field.Name)
+	}
+	return name
+}
+
+// envName maps a YAML path such as rate_limiting.concurrent_workers to ANALYZER_RATE_LIMITING_CONCURRENT_WORKERS
+func envName(path string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(path, ".", "_"))
+}
+
+// setField parses an environment value into a config field
+func setField(field reflect.Value, raw string) error {
+	raw = strings.TrimSpace(raw)
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Bool:
+		switch strings.ToLower(raw) {
+		case "0", "false", "no", "off", "":
+			field.SetBool(false)
+		default:
+			if !isTruthy(raw) {
+				return fmt.Errorf("expected true or false, got %q", raw)
+			}
+			field.SetBool(true)
+		}
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("expected an integer, got %q", raw)
+		}
+		field.SetInt(int64(n))
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("expected a number, got %q", raw)
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported field type %s, set it in the config file", field.Type())
+		}
+		items := []string{}
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	case reflect.Map:
+		if field.Type().Key().Kind() != reflect.String || field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported field type %s, set it in the config file", field.Type())
+		}
+		// Name=value pairs, comma-separated
+		entries := map[string]string{}
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			key, value, ok := strings.Cut(item, "=")
+			if !ok || strings.TrimSpace(key) == "" {
+				return fmt.Errorf("expected name=value pairs, got %q", item)
+			}
+			entries[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+		field.Set(reflect.ValueOf(entries))
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
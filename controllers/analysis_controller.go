@@ -13,17 +13,35 @@ import (
 
 	"github.com/labstack/echo/v4"
 	"repo-explanation/config"
+	"repo-explanation/internal/contextpack"
+	"repo-explanation/internal/mermaid"
 	"repo-explanation/internal/pipeline"
+	"repo-explanation/internal/probe"
+	"repo-explanation/internal/sandbox"
 )
 
 type AnalysisController struct {
-	config *config.Config
+	config  *config.Config
+	sandbox *sandbox.Sandbox // Directories that path-based endpoints may read
 }
 
 type AnalysisRequest struct {
-	URL   string `json:"url" validate:"required"`
-	Type  string `json:"type" validate:"required"`
+	URL   string `json:"url" validate:"required"`  // GitHub URL, or with type local_path an absolute path on the server
+	Type  string `json:"type" validate:"required"` // "github_url" or "local_path"
 	Token string `json:"token,omitempty"` // GitHub personal access token for private repos
+	Offline bool `json:"offline,omitempty"` // Skip all LLM calls and use heuristic summaries
+	Include []string `json:"include,omitempty"` // Globs or directories to analyze
+	Exclude []string `json:"exclude,omitempty"` // Globs or directories to skip
+	Service string   `json:"service,omitempty"` // Discovered service to analyze, with the services it calls directly
+	Phases  []string `json:"phases,omitempty"`  // Phases to run (files, folders, project, services, schema, infrastructure, deadcode, secrets, questions, glossary)
+	Resume  bool     `json:"resume,omitempty"`  // Resume from the last checkpoint of a previous run
+	Quick   bool     `json:"quick,omitempty"`   // Skip per-file LLM summaries; one project-level LLM call only
+	MinConfidence float64  `json:"min_confidence,omitempty"` // Drop service relationships below this confidence (0-1)
+	OnlyEvidence  []string `json:"only_evidence,omitempty"`  // Relationship evidence types to keep (config, import, network, ...)
+	Profile       string   `json:"profile,omitempty"`        // Analysis profile from the config (backend, frontend, data, ...)
+	Lang          string   `json:"lang,omitempty"`           // Language of LLM-written prose (en, vi, ja, ...)
+	ContextPack   string   `json:"context_pack,omitempty"`   // markdown or json: answer /analyze with the context pack as a download instead of the result
+	ContextPackTokens int  `json:"context_pack_tokens,omitempty"` // Largest size of the context pack, in estimated tokens (default 8000)
 }
 
 type AnalysisResponse struct {
@@ -34,6 +52,71 @@ type AnalysisResponse struct {
 	Error      string                 `json:"error,omitempty"`
 }
 
+// Validate checks the request before any cloning starts
+func (req AnalysisRequest) Validate() error {
+	switch req.Type {
+	case "github_url":
+		if !isValidGitHubURL(req.URL) {
+			return fmt.Errorf("Invalid GitHub URL format")
+		}
+	case "local_path":
+		if err := validateLocalPath(req.URL); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("Only GitHub URLs and local paths are supported")
+	}
+	if err := optionsForRequest(req).Validate(); err != nil {
+		return fmt.Errorf("Invalid analysis options: %v", err)
+	}
+	if req.ContextPack != "" && req.ContextPack != contextpack.Markdown && req.ContextPack != contextpack.JSON {
+		return fmt.Errorf("context_pack must be markdown or json")
+	}
+	if req.ContextPackTokens < 0 {
+		return fmt.Errorf("context_pack_tokens must not be negative")
+	}
+	return nil
+}
+
+// ServiceStatusRequest selects the local checkout to probe
+type ServiceStatusRequest struct {
+	Path string `query:"path" validate:"required"` // Absolute path of a local checkout on the server's machine
+	Host string `query:"host"`                     // Host to probe, localhost when empty
+}
+
+// Validate checks that the path is absolute and not the filesystem root; whether it may be read
+// is up to the sandbox
+func (req ServiceStatusRequest) Validate() error {
+	if req.Path == "" {
+		return fmt.Errorf("The path query parameter is required")
+	}
+	return validateLocalPath(req.Path)
+}
+
+// validateLocalPath checks that a path is absolute and not the filesystem root
+func validateLocalPath(path string) error {
+	clean := filepath.Clean(path)
+	if !filepath.IsAbs(clean) {
+		return fmt.Errorf("The path must be absolute (got %s)", path)
+	}
+	if clean == filepath.VolumeName(clean)+string(filepath.Separator) {
+		return fmt.Errorf("Refusing to analyze the filesystem root")
+	}
+	return nil
+}
+
+type ServiceStatusResponse struct {
+	Status  string        `json:"status"`
+	Results *probe.Report `json:"results,omitempty"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// ErrorResponse is returned when a request is rejected before it reaches a controller
+type ErrorResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+}
+
 type RepositoryInfo struct {
 	URL       string `json:"url"`
 	Name      string `json:"name"`
@@ -53,31 +136,11 @@ type StreamEvent struct {
 
 // Use the pipeline's ProgressCallback type to avoid type conflicts
 
-func NewAnalysisController() *AnalysisController {
-	// Try multiple config paths for different environments
-	configPaths := []string{
-		"config.yaml",        // Same directory (Docker container)
-		"../config.yaml",     // Parent directory (local development)
-		"./config.yaml",      // Current working directory
-		"/app/config.yaml",   // Absolute path in container
-	}
-	
-	var cfg *config.Config
-	var err error
-	
-	for _, path := range configPaths {
-		cfg, err = config.LoadConfig(path)
-		if err == nil {
-			break
-		}
-	}
-	
-	if cfg == nil {
-		panic(fmt.Sprintf("Failed to load config from any path %v: %v", configPaths, err))
-	}
-	
+// NewAnalysisController creates the controller with the configuration loaded at startup
+func NewAnalysisController(cfg *config.Config) *AnalysisController {
 	return &AnalysisController{
-		config: cfg,
+		config:  cfg,
+		sandbox: sandbox.New(cfg.Server.AllowedRoots),
 	}
 }
 
@@ -91,21 +154,41 @@ func (ac *AnalysisController) AnalyzeRepository(c echo.Context) error {
 		})
 	}
 
-	// Validate GitHub URL
-	if req.Type != "github_url" {
+	if err := req.Validate(); err != nil {
 		return c.JSON(http.StatusBadRequest, AnalysisResponse{
 			Status: "error",
-			Error:  "Only GitHub URLs are supported",
+			Error:  err.Error(),
 		})
 	}
-
-	if !isValidGitHubURL(req.URL) {
+	if _, err := ac.config.Profile(req.Profile); err != nil {
 		return c.JSON(http.StatusBadRequest, AnalysisResponse{
 			Status: "error",
-			Error:  "Invalid GitHub URL format",
+			Error:  fmt.Sprintf("Invalid analysis options: %v", err),
 		})
 	}
 
+	// A local checkout is analyzed in place, without cloning
+	if req.Type == "local_path" {
+		projectPath, status, err := ac.resolveLocalPath(req.URL)
+		if err != nil {
+			return c.JSON(status, AnalysisResponse{
+				Status: "error",
+				Error:  err.Error(),
+			})
+		}
+		repoInfo := localRepoInfo(projectPath)
+		analyzer, err := pipeline.NewAnalyzer(ac.configForRequest(req), projectPath)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, AnalysisResponse{
+				Status:     "error",
+				Error:      fmt.Sprintf("Failed to create analyzer: %v", err),
+				Repository: &repoInfo,
+			})
+		}
+		analyzer.SetOptions(optionsForRequest(req))
+		return ac.respondWithAnalysis(c, req, analyzer, repoInfo)
+	}
+
 	// Extract repository info
 	repoInfo := extractRepoInfo(req.URL)
 	
@@ -174,7 +257,7 @@ func (ac *AnalysisController) AnalyzeRepository(c echo.Context) error {
 
 	// Perform analysis using existing pipeline with URL for proper caching
 	c.Logger().Infof("Starting analysis of cloned repository")
-	analyzer, err := pipeline.NewAnalyzerWithURL(ac.config, tempDir, req.URL)
+	analyzer, err := pipeline.NewAnalyzerWithURL(ac.configForRequest(req), tempDir, req.URL)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, AnalysisResponse{
 			Status:     "error", 
@@ -182,9 +265,16 @@ func (ac *AnalysisController) AnalyzeRepository(c echo.Context) error {
 			Repository: &repoInfo,
 		})
 	}
+	// Options were validated up front, so this only applies them
+	analyzer.SetOptions(optionsForRequest(req))
+	return ac.respondWithAnalysis(c, req, analyzer, repoInfo)
+}
 
-	// Run analysis with extended timeout (60 minutes for large repositories)
-	ctx, cancel := context.WithTimeout(c.Request().Context(), 60*time.Minute)
+// respondWithAnalysis runs the analysis under the configured timeout and writes the JSON response
+func (ac *AnalysisController) respondWithAnalysis(c echo.Context, req AnalysisRequest, analyzer *pipeline.Analyzer, repoInfo RepositoryInfo) error {
+	// Run analysis with the configured timeout (60 minutes by default for large repositories)
+	analysisTimeout := ac.config.GetAnalysisTimeout()
+	ctx, cancel := context.WithTimeout(c.Request().Context(), analysisTimeout)
 	defer cancel()
 
 	// Create a channel to handle analysis result or timeout
@@ -206,6 +296,9 @@ func (ac *AnalysisController) AnalyzeRepository(c echo.Context) error {
 	select {
 	case results := <-resultChan:
 		c.Logger().Infof("Analysis completed successfully for %s", req.URL)
+		if req.ContextPack != "" {
+			return respondWithContextPack(c, req, results, repoInfo)
+		}
 		return c.JSON(http.StatusOK, AnalysisResponse{
 			Status:     "success",
 			Message:    "Repository analysis completed successfully",
@@ -222,15 +315,80 @@ func (ac *AnalysisController) AnalyzeRepository(c echo.Context) error {
 		})
 		
 	case <-ctx.Done():
-		c.Logger().Warnf("Analysis timed out for %s after 60 minutes", req.URL)
+		c.Logger().Warnf("Analysis timed out for %s after %v", req.URL, analysisTimeout)
 		return c.JSON(http.StatusRequestTimeout, AnalysisResponse{
 			Status:     "timeout",
-			Error:      "Analysis timed out after 60 minutes. The repository may be too large or complex for analysis.",
+			Error:      fmt.Sprintf("Analysis timed out after %v. The repository may be too large or complex for analysis.", analysisTimeout),
 			Repository: &repoInfo,
 		})
 	}
 }
 
+// respondWithContextPack answers with the context pack of an analysis as a file download
+func respondWithContextPack(c echo.Context, req AnalysisRequest, results *pipeline.AnalysisResult, repoInfo RepositoryInfo) error {
+	name := repoInfo.Name
+	if repoInfo.Owner != "" {
+		name = repoInfo.Owner + "/" + repoInfo.Name
+	}
+	pack := contextpack.Build(name, results, req.ContextPackTokens)
+	filename := strings.Trim(mermaid.ID(repoInfo.Name), "_") + "_context_pack"
+	if req.ContextPack == contextpack.JSON {
+		c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename+".json"))
+		return c.JSON(http.StatusOK, pack)
+	}
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename+".md"))
+	return c.Blob(http.StatusOK, "text/markdown; charset=utf-8", []byte(pack.Markdown()))
+}
+
+// ProfileInfo describes an analysis profile a request can select
+type ProfileInfo struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Phases      []string `json:"phases,omitempty"` // Phases the profile runs; empty runs every phase
+}
+
+type ProfilesResponse struct {
+	Status   string        `json:"status"`
+	Profiles []ProfileInfo `json:"profiles"`
+}
+
+// Profiles lists the analysis profiles configured on the server
+func (ac *AnalysisController) Profiles(c echo.Context) error {
+	profiles := []ProfileInfo{}
+	for _, name := range ac.config.ProfileNames() {
+		profile := ac.config.Profiles[name]
+		profiles = append(profiles, ProfileInfo{Name: name, Description: profile.Description, Phases: profile.Phases})
+	}
+	return c.JSON(http.StatusOK, ProfilesResponse{Status: "success", Profiles: profiles})
+}
+
+// optionsForRequest converts the request scoping fields into pipeline options
+func optionsForRequest(req AnalysisRequest) pipeline.AnalysisOptions {
+	return pipeline.AnalysisOptions{
+		Include: req.Include,
+		Exclude: req.Exclude,
+		Service: req.Service,
+		Phases:  req.Phases,
+		Resume:  req.Resume,
+		Quick:   req.Quick,
+		Profile: req.Profile,
+		Language: req.Lang,
+
+		MinConfidence: req.MinConfidence,
+		OnlyEvidence:  req.OnlyEvidence,
+	}
+}
+
+// configForRequest returns the controller config, switched to offline mode when the request asks for it
+func (ac *AnalysisController) configForRequest(req AnalysisRequest) *config.Config {
+	if !req.Offline || ac.config.Offline {
+		return ac.config
+	}
+	cfg := *ac.config
+	cfg.Offline = true
+	return &cfg
+}
+
 // isValidGitHubURL validates if the URL is a valid GitHub repository URL
 func isValidGitHubURL(url string) bool {
 	return strings.HasPrefix(url, "https://github.com/") && strings.Count(url, "/") >= 4
@@ -365,75 +523,55 @@ func (ac *AnalysisController) StreamAnalyzeRepository(c echo.Context) error {
 	
 	fmt.Printf("✅ [STREAM] Request parsed: URL=%s, Type=%s, HasToken=%v\n", req.URL, req.Type, req.Token != "")
 
-	// Validate GitHub URL
-	if req.Type != "github_url" {
-		fmt.Printf("❌ [STREAM] Invalid request type: %s\n", req.Type)
+	if err := req.Validate(); err != nil {
+		fmt.Printf("❌ [STREAM] Invalid request: %v\n", err)
 		return c.JSON(http.StatusBadRequest, AnalysisResponse{
 			Status: "error",
-			Error:  "Only GitHub URLs are supported",
+			Error:  err.Error(),
 		})
 	}
-
-	if !isValidGitHubURL(req.URL) {
-		fmt.Printf("❌ [STREAM] Invalid GitHub URL: %s\n", req.URL)
+	if _, err := ac.config.Profile(req.Profile); err != nil {
+		fmt.Printf("❌ [STREAM] Invalid profile: %v\n", err)
 		return c.JSON(http.StatusBadRequest, AnalysisResponse{
 			Status: "error",
-			Error:  "Invalid GitHub URL format",
+			Error:  fmt.Sprintf("Invalid analysis options: %v", err),
 		})
 	}
 	
-	fmt.Println("✅ [STREAM] URL validation passed")
-
-	// Set up SSE headers with proxy-friendly configuration
-	fmt.Println("🔧 [STREAM] Setting up SSE headers")
-	c.Response().Header().Set("Content-Type", "text/event-stream")
-	c.Response().Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
-	c.Response().Header().Set("Connection", "keep-alive")
-	c.Response().Header().Set("Access-Control-Allow-Origin", "*")
-	c.Response().Header().Set("Access-Control-Allow-Headers", "Cache-Control")
-	// Additional headers for proxy compatibility
-	c.Response().Header().Set("X-Accel-Buffering", "no") // Disable nginx buffering
-	c.Response().Header().Set("Transfer-Encoding", "chunked")
-	c.Response().Header().Set("Pragma", "no-cache")
-	c.Response().Header().Set("Expires", "0")
-	fmt.Println("✅ [STREAM] SSE headers configured")
+	fmt.Println("✅ [STREAM] Request validation passed")
 
-	// Create progress callback for streaming updates
-	fmt.Println("🔧 [STREAM] Creating progress callback")
-	progressCallback := pipeline.ProgressCallback(func(eventType, stage, message string, progress int, data interface{}) {
-		fmt.Printf("📡 [STREAM] Progress callback: type=%s, stage=%s, progress=%d, message=%s\n", eventType, stage, progress, message)
-		
-		event := StreamEvent{
-			Type:      eventType,
-			Stage:     stage,
-			Progress:  progress,
-			Data:      data,
-			Message:   message,
-			Timestamp: time.Now(),
-		}
-		
-		eventJSON, err := json.Marshal(event)
+	// Local paths are checked before the stream opens so a rejected path gets a plain error status
+	localPath := ""
+	if req.Type == "local_path" {
+		projectPath, status, err := ac.resolveLocalPath(req.URL)
 		if err != nil {
-			fmt.Printf("❌ [STREAM] Failed to marshal event: %v\n", err)
-			return
-		}
-		
-		fmt.Printf("📤 [STREAM] Sending event: %s\n", string(eventJSON))
-		
-		// Send the event with proper SSE format
-		fmt.Fprintf(c.Response(), "data: %s\n\n", string(eventJSON))
-		
-		// Force flush to ensure immediate delivery through proxies
-		if flusher, ok := c.Response().Writer.(http.Flusher); ok {
-			flusher.Flush()
+			fmt.Printf("❌ [STREAM] Local path rejected: %v\n", err)
+			return c.JSON(status, AnalysisResponse{
+				Status: "error",
+				Error:  err.Error(),
+			})
 		}
-		c.Response().Flush()
-	})
+		localPath = projectPath
+	}
+
+	progressCallback := openEventStream(c)
 
 	// Send initial progress event
 	fmt.Println("🚀 [STREAM] Sending initial progress event")
 	progressCallback("progress", "🚀 Initializing analysis...", "Starting repository analysis", 0, nil)
 
+	if localPath != "" {
+		progressCallback("progress", "📂 Reading local checkout...", localPath, 15, nil)
+		analyzer, err := pipeline.NewAnalyzer(ac.configForRequest(req), localPath)
+		if err != nil {
+			fmt.Printf("❌ [STREAM] Failed to create analyzer: %v\n", err)
+			progressCallback("error", "", fmt.Sprintf("Failed to create analyzer: %v", err), 0, nil)
+			return nil
+		}
+		analyzer.SetOptions(optionsForRequest(req))
+		return ac.streamAnalysis(c, analyzer, progressCallback)
+	}
+
 	// Extract repository info
 	fmt.Println("🔍 [STREAM] Extracting repository info")
 	repoInfo := extractRepoInfo(req.URL)
@@ -506,17 +644,74 @@ func (ac *AnalysisController) StreamAnalyzeRepository(c echo.Context) error {
 
 	// Perform analysis with progress updates using URL for proper caching
 	fmt.Println("🔬 [STREAM] Creating analyzer")
-	analyzer, err := pipeline.NewAnalyzerWithURL(ac.config, tempDir, req.URL)
+	analyzer, err := pipeline.NewAnalyzerWithURL(ac.configForRequest(req), tempDir, req.URL)
 	if err != nil {
 		fmt.Printf("❌ [STREAM] Failed to create analyzer: %v\n", err)
 		progressCallback("error", "", fmt.Sprintf("Failed to create analyzer: %v", err), 0, nil)
 		return nil
 	}
+	analyzer.SetOptions(optionsForRequest(req))
 	fmt.Println("✅ [STREAM] Analyzer created successfully")
+	return ac.streamAnalysis(c, analyzer, progressCallback)
+}
+
+// openEventStream switches the response to Server-Sent Events and returns a progress callback
+// that sends each event as one data line
+func openEventStream(c echo.Context) pipeline.ProgressCallback {
+	// Set up SSE headers with proxy-friendly configuration
+	fmt.Println("🔧 [STREAM] Setting up SSE headers")
+	c.Response().Header().Set("Content-Type", "text/event-stream")
+	c.Response().Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	c.Response().Header().Set("Connection", "keep-alive")
+	c.Response().Header().Set("Access-Control-Allow-Origin", "*")
+	c.Response().Header().Set("Access-Control-Allow-Headers", "Cache-Control")
+	// Additional headers for proxy compatibility
+	c.Response().Header().Set("X-Accel-Buffering", "no") // Disable nginx buffering
+	c.Response().Header().Set("Transfer-Encoding", "chunked")
+	c.Response().Header().Set("Pragma", "no-cache")
+	c.Response().Header().Set("Expires", "0")
+	fmt.Println("✅ [STREAM] SSE headers configured")
+
+	// Create progress callback for streaming updates
+	fmt.Println("🔧 [STREAM] Creating progress callback")
+	return pipeline.ProgressCallback(func(eventType, stage, message string, progress int, data interface{}) {
+		fmt.Printf("📡 [STREAM] Progress callback: type=%s, stage=%s, progress=%d, message=%s\n", eventType, stage, progress, message)
+		
+		event := StreamEvent{
+			Type:      eventType,
+			Stage:     stage,
+			Progress:  progress,
+			Data:      data,
+			Message:   message,
+			Timestamp: time.Now(),
+		}
+		
+		eventJSON, err := json.Marshal(event)
+		if err != nil {
+			fmt.Printf("❌ [STREAM] Failed to marshal event: %v\n", err)
+			return
+		}
+		
+		fmt.Printf("📤 [STREAM] Sending event: %s\n", string(eventJSON))
+		
+		// Send the event with proper SSE format
+		fmt.Fprintf(c.Response(), "data: %s\n\n", string(eventJSON))
+		
+		// Force flush to ensure immediate delivery through proxies
+		if flusher, ok := c.Response().Writer.(http.Flusher); ok {
+			flusher.Flush()
+		}
+		c.Response().Flush()
+	})
+}
+
+// streamAnalysis runs the analysis with progress events and ends the stream with the result
+func (ac *AnalysisController) streamAnalysis(c echo.Context, analyzer *pipeline.Analyzer, progressCallback pipeline.ProgressCallback) error {
 
 	// Run analysis with extended timeout and progress callbacks
-	fmt.Println("⏱️ [STREAM] Setting up 60-minute context timeout")
-	ctx, cancel := context.WithTimeout(c.Request().Context(), 60*time.Minute)
+	analysisTimeout := ac.config.GetAnalysisTimeout()
+	fmt.Printf("⏱️ [STREAM] Setting up %v context timeout\n", analysisTimeout)
+	ctx, cancel := context.WithTimeout(c.Request().Context(), analysisTimeout)
 	defer cancel()
 
 	// Run streaming analysis
@@ -561,3 +756,142 @@ func (ac *AnalysisController) runStreamingAnalysis(ctx context.Context, analyzer
 	fmt.Println("✅ [STREAM] AnalyzeProjectWithProgress completed successfully")
 	return result, nil
 }
+
+// resolveLocalPath checks a requested local path against the sandbox and returns its canonical
+// form, or the status to reject it with
+func (ac *AnalysisController) resolveLocalPath(path string) (string, int, error) {
+	// Check the sandbox before touching the filesystem, so responses never reveal what exists outside it
+	projectPath, err := ac.sandbox.Resolve(path)
+	if err != nil {
+		return "", http.StatusForbidden, err
+	}
+	if info, err := os.Stat(projectPath); err != nil || !info.IsDir() {
+		return "", http.StatusBadRequest, fmt.Errorf("%s is not a directory", path)
+	}
+	return projectPath, http.StatusOK, nil
+}
+
+// localRepoInfo describes a local checkout the way extractRepoInfo describes a GitHub URL
+func localRepoInfo(projectPath string) RepositoryInfo {
+	return RepositoryInfo{
+		URL:       projectPath,
+		Name:      filepath.Base(projectPath),
+		LocalPath: projectPath,
+	}
+}
+
+// ServiceStatus probes the services of a local checkout (?path=) on this machine and reports
+// which are live, unhealthy or down. ?host= probes another host instead of localhost.
+func (ac *AnalysisController) ServiceStatus(c echo.Context) error {
+	var req ServiceStatusRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ServiceStatusResponse{
+			Status: "error",
+			Error:  "Invalid request format",
+		})
+	}
+	if err := req.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, ServiceStatusResponse{
+			Status: "error",
+			Error:  err.Error(),
+		})
+	}
+
+	projectPath, status, err := ac.resolveLocalPath(req.Path)
+	if err != nil {
+		return c.JSON(status, ServiceStatusResponse{
+			Status: "error",
+			Error:  err.Error(),
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), ac.config.GetAnalysisTimeout())
+	defer cancel()
+
+	report, err := probe.Run(ctx, ac.config, projectPath, probe.NewProber(req.Host, probe.DefaultTimeout))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ServiceStatusResponse{
+			Status: "error",
+			Error:  err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, ServiceStatusResponse{
+		Status:  "success",
+		Results: report,
+	})
+}
+
+// PrimeCacheRequest selects the repository whose file summaries to cache
+type PrimeCacheRequest struct {
+	URL     string   `json:"url" validate:"required"`  // GitHub URL, or with type local_path an absolute path on the server
+	Type    string   `json:"type" validate:"required"` // "github_url" or "local_path"
+	Token   string   `json:"token,omitempty"`          // GitHub personal access token for private repos
+	Include []string `json:"include,omitempty"`        // Globs or directories to prime
+	Exclude []string `json:"exclude,omitempty"`        // Globs or directories to skip
+}
+
+type PrimeCacheResponse struct {
+	Status     string                `json:"status"`
+	Report     *pipeline.PrimeReport `json:"report,omitempty"`
+	Repository *RepositoryInfo       `json:"repository,omitempty"`
+	Error      string                `json:"error,omitempty"`
+}
+
+// PrimeCache runs only the map phase over a repository at full concurrency so the file summary
+// cache is warm for later analyses, e.g. from an off-hours cron job
+func (ac *AnalysisController) PrimeCache(c echo.Context) error {
+	var req PrimeCacheRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, PrimeCacheResponse{Status: "error", Error: "Invalid request format"})
+	}
+	analysisReq := AnalysisRequest{URL: req.URL, Type: req.Type, Token: req.Token, Include: req.Include, Exclude: req.Exclude}
+	if err := analysisReq.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, PrimeCacheResponse{Status: "error", Error: err.Error()})
+	}
+
+	var repoInfo RepositoryInfo
+	var analyzer *pipeline.Analyzer
+	var err error
+	if req.Type == "local_path" {
+		projectPath, status, resolveErr := ac.resolveLocalPath(req.URL)
+		if resolveErr != nil {
+			return c.JSON(status, PrimeCacheResponse{Status: "error", Error: resolveErr.Error()})
+		}
+		repoInfo = localRepoInfo(projectPath)
+		analyzer, err = pipeline.NewAnalyzer(ac.config, projectPath)
+	} else {
+		repoInfo = extractRepoInfo(req.URL)
+		tempDir, tempErr := os.MkdirTemp("", "repo-prime-")
+		if tempErr != nil {
+			return c.JSON(http.StatusInternalServerError, PrimeCacheResponse{Status: "error", Error: fmt.Sprintf("Failed to create temp directory: %v", tempErr)})
+		}
+		defer os.RemoveAll(tempDir)
+
+		// Public access first, then the token for private repositories
+		cloneErr := cloneRepository(req.URL, tempDir, "")
+		if cloneErr != nil && isPrivateRepoError(cloneErr) && req.Token != "" {
+			cloneErr = cloneRepository(req.URL, tempDir, req.Token)
+		}
+		if cloneErr != nil {
+			status := http.StatusInternalServerError
+			if isPrivateRepoError(cloneErr) {
+				status = http.StatusUnauthorized
+			}
+			return c.JSON(status, PrimeCacheResponse{Status: "error", Error: fmt.Sprintf("Failed to clone repository: %v", cloneErr), Repository: &repoInfo})
+		}
+		analyzer, err = pipeline.NewAnalyzerWithURL(ac.config, tempDir, req.URL)
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, PrimeCacheResponse{Status: "error", Error: fmt.Sprintf("Failed to create analyzer: %v", err), Repository: &repoInfo})
+	}
+	analyzer.SetOptions(optionsForRequest(analysisReq))
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), ac.config.GetAnalysisTimeout())
+	defer cancel()
+
+	report, err := analyzer.PrimeCache(ctx)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, PrimeCacheResponse{Status: "error", Report: report, Repository: &repoInfo, Error: err.Error()})
+	}
+	return c.JSON(http.StatusOK, PrimeCacheResponse{Status: "success", Report: report, Repository: &repoInfo})
+}
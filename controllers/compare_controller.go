@@ -0,0 +1,117 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"repo-explanation/internal/compare"
+)
+
+// CompareRequest asks for the architectural difference between two refs of a local checkout
+type CompareRequest struct {
+	Path    string   `json:"path" validate:"required"` // Absolute path of a git checkout inside the server's allowed roots
+	RefA    string   `json:"refA" validate:"required"` // Base branch, tag or commit
+	RefB    string   `json:"refB" validate:"required"` // Branch, tag or commit compared against the base
+	Offline bool     `json:"offline,omitempty"`
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+	Phases  []string `json:"phases,omitempty"` // Defaults to services, schema and secrets
+}
+
+type CompareResponse struct {
+	Status  string          `json:"status"`
+	Message string          `json:"message,omitempty"`
+	Results *compare.Result `json:"results,omitempty"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// analysisRequest exposes the settings a comparison shares with single-repository requests
+func (req CompareRequest) analysisRequest() AnalysisRequest {
+	return AnalysisRequest{
+		Offline: req.Offline,
+		Include: req.Include,
+		Exclude: req.Exclude,
+		Phases:  req.Phases,
+	}
+}
+
+// Validate checks the path and refs before anything is checked out
+func (req CompareRequest) Validate() error {
+	if req.Path == "" {
+		return fmt.Errorf("The path is required")
+	}
+	if err := validateLocalPath(req.Path); err != nil {
+		return err
+	}
+	if err := compare.ValidateRef(req.RefA); err != nil {
+		return fmt.Errorf("Invalid refA: %v", err)
+	}
+	if err := compare.ValidateRef(req.RefB); err != nil {
+		return fmt.Errorf("Invalid refB: %v", err)
+	}
+	if err := optionsForRequest(req.analysisRequest()).Validate(); err != nil {
+		return fmt.Errorf("Invalid analysis options: %v", err)
+	}
+	return nil
+}
+
+// CompareRefs checks out two refs of a local checkout into temporary worktrees, analyzes both
+// and returns the services, schema, endpoints, dependencies and secrets that differ
+func (ac *AnalysisController) CompareRefs(c echo.Context) error {
+	var req CompareRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, CompareResponse{
+			Status: "error",
+			Error:  "Invalid request format",
+		})
+	}
+	if err := req.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, CompareResponse{
+			Status: "error",
+			Error:  err.Error(),
+		})
+	}
+
+	projectPath, status, err := ac.resolveLocalPath(req.Path)
+	if err != nil {
+		return c.JSON(status, CompareResponse{
+			Status: "error",
+			Error:  err.Error(),
+		})
+	}
+
+	comparer, err := compare.NewComparer(ac.configForRequest(req.analysisRequest()), projectPath, req.RefA, req.RefB, optionsForRequest(req.analysisRequest()))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, CompareResponse{
+			Status: "error",
+			Error:  err.Error(),
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), ac.config.GetAnalysisTimeout())
+	defer cancel()
+
+	results, err := comparer.Compare(ctx, func(eventType, stage, message string, progress int, data interface{}) {
+		if eventType == "progress" {
+			fmt.Printf("🔀 [COMPARE] %d%% %s - %s\n", progress, stage, message)
+		}
+	})
+	if err != nil {
+		status := http.StatusInternalServerError
+		if ctx.Err() == context.DeadlineExceeded {
+			status = http.StatusRequestTimeout
+		}
+		return c.JSON(status, CompareResponse{
+			Status: "error",
+			Error:  fmt.Sprintf("Comparison failed: %v", err),
+		})
+	}
+
+	return c.JSON(http.StatusOK, CompareResponse{
+		Status:  "success",
+		Message: results.Summary,
+		Results: results,
+	})
+}
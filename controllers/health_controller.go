@@ -1,21 +1,179 @@
 package controllers
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"os"
+	"sync"
+	"time"
 
 	"github.com/labstack/echo/v4"
+	"repo-explanation/config"
+	"repo-explanation/internal/buildinfo"
+	"repo-explanation/internal/openai"
 )
 
-type HealthController struct{}
+// openAICheckTTL is how long an OpenAI reachability result is reused, so frequent readiness
+// probes do not each call the API
+const openAICheckTTL = 30 * time.Second
 
-func NewHealthController() *HealthController {
-	return &HealthController{}
+// openAICheckTimeout bounds one OpenAI reachability check
+const openAICheckTimeout = 5 * time.Second
+
+type HealthController struct {
+	config *config.Config
+
+	mu         sync.Mutex
+	openAI     ReadinessCheck // Last OpenAI check, reused for openAICheckTTL
+	openAIWhen time.Time
+}
+
+type HealthResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Service string `json:"service"`
+}
+
+// LivenessResponse reports that the process is serving requests
+type LivenessResponse struct {
+	Status        string `json:"status"` // Always "alive"
+	UptimeSeconds int64  `json:"uptime_seconds"`
+}
+
+// ReadinessCheck is the outcome of one dependency check
+type ReadinessCheck struct {
+	Name      string `json:"name"`
+	Status    string `json:"status"` // "ok", "fail" or "skipped"
+	Detail    string `json:"detail,omitempty"`
+	LatencyMS int64  `json:"latency_ms"`
+}
+
+// ReadinessResponse lists the dependency checks; the server is ready when none failed
+type ReadinessResponse struct {
+	Status string           `json:"status"` // "ready" or "not_ready"
+	Checks []ReadinessCheck `json:"checks"`
+}
+
+// VersionResponse describes the running binary
+type VersionResponse struct {
+	Service string `json:"service"`
+	buildinfo.Info
+}
+
+// NewHealthController checks the dependencies named in cfg; nil skips every dependency check
+func NewHealthController(cfg *config.Config) *HealthController {
+	return &HealthController{config: cfg}
 }
 
 func (hc *HealthController) HealthCheck(c echo.Context) error {
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"status":  "healthy",
-		"message": "Server is running",
-		"service": "repo-explanation",
+	return c.JSON(http.StatusOK, HealthResponse{
+		Status:  "healthy",
+		Message: "Server is running",
+		Service: "repo-explanation",
+	})
+}
+
+// Liveness answers as long as the process can serve requests; it checks no dependencies, so a
+// slow LLM provider never gets the server restarted
+func (hc *HealthController) Liveness(c echo.Context) error {
+	return c.JSON(http.StatusOK, LivenessResponse{
+		Status:        "alive",
+		UptimeSeconds: int64(buildinfo.Uptime().Seconds()),
+	})
+}
+
+// Readiness checks the configuration, the OpenAI API, the output directory and the cache
+// directory, answering 503 when any of them fails
+func (hc *HealthController) Readiness(c echo.Context) error {
+	response := ReadinessResponse{Status: "ready"}
+	if hc.config == nil {
+		response.Checks = append(response.Checks, ReadinessCheck{Name: "config", Status: "skipped", Detail: "no configuration loaded"})
+		return c.JSON(http.StatusOK, response)
+	}
+
+	response.Checks = []ReadinessCheck{
+		timed("config", func() (string, string) {
+			if err := hc.config.Validate(); err != nil {
+				return "fail", err.Error()
+			}
+			return "ok", "loaded from " + hc.config.Source()
+		}),
+		hc.checkOpenAI(c.Request().Context()),
+		timed("storage", func() (string, string) {
+			return writable(hc.config.Output.OutputDirectory)
+		}),
+		timed("cache", func() (string, string) {
+			if !hc.config.Cache.Enabled {
+				return "skipped", "cache disabled"
+			}
+			return writable(hc.config.Cache.Directory)
+		}),
+	}
+
+	status := http.StatusOK
+	for _, check := range response.Checks {
+		if check.Status == "fail" {
+			response.Status = "not_ready"
+			status = http.StatusServiceUnavailable
+		}
+	}
+	return c.JSON(status, response)
+}
+
+// Version reports the build the server runs
+func (hc *HealthController) Version(c echo.Context) error {
+	return c.JSON(http.StatusOK, VersionResponse{Service: "repo-explanation", Info: buildinfo.Get()})
+}
+
+// checkOpenAI lists the models to confirm the API is reachable and accepts the key, reusing a
+// recent result
+func (hc *HealthController) checkOpenAI(ctx context.Context) ReadinessCheck {
+	if hc.config.Offline {
+		return ReadinessCheck{Name: "openai", Status: "skipped", Detail: "offline mode"}
+	}
+	if hc.config.OpenAI.APIKey == "" {
+		return ReadinessCheck{Name: "openai", Status: "fail", Detail: "no API key configured"}
+	}
+
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	if !hc.openAIWhen.IsZero() && time.Since(hc.openAIWhen) < openAICheckTTL {
+		return hc.openAI
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, openAICheckTimeout)
+	defer cancel()
+	hc.openAI = timed("openai", func() (string, string) {
+		if err := openai.NewClient(hc.config).Ping(ctx); err != nil {
+			return "fail", fmt.Sprintf("API not reachable: %v", err)
+		}
+		return "ok", "API reachable"
 	})
+	hc.openAIWhen = time.Now()
+	return hc.openAI
+}
+
+// timed runs a check and records how long it took
+func timed(name string, check func() (status, detail string)) ReadinessCheck {
+	started := time.Now()
+	status, detail := check()
+	return ReadinessCheck{Name: name, Status: status, Detail: detail, LatencyMS: time.Since(started).Milliseconds()}
+}
+
+// writable reports whether files can be created in dir, creating dir if needed
+func writable(dir string) (string, string) {
+	if dir == "" {
+		return "fail", "no directory configured"
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "fail", fmt.Sprintf("cannot create %s: %v", dir, err)
+	}
+	probe, err := os.CreateTemp(dir, ".readyz-*")
+	if err != nil {
+		return "fail", fmt.Sprintf("%s is not writable: %v", dir, err)
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+	return "ok", dir + " writable"
 }
@@ -0,0 +1,21 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"repo-explanation/internal/openapi"
+)
+
+// OpenAPIController serves the OpenAPI document generated from the route table
+type OpenAPIController struct {
+	document *openapi.Document
+}
+
+func NewOpenAPIController(document *openapi.Document) *OpenAPIController {
+	return &OpenAPIController{document: document}
+}
+
+func (oc *OpenAPIController) Spec(c echo.Context) error {
+	return c.JSON(http.StatusOK, oc.document)
+}
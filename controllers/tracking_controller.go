@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"repo-explanation/internal/tracking"
+)
+
+// TrackingController reports on and triggers the scheduled re-analysis of tracked repositories
+type TrackingController struct {
+	scheduler *tracking.Scheduler // Nil when the configuration tracks no repository
+}
+
+// TrackingStatusResponse lists the tracked repositories and their latest runs
+type TrackingStatusResponse struct {
+	Status       string            `json:"status"`
+	Repositories []tracking.Status `json:"repositories"`
+}
+
+// TrackingRunRequest names the tracked repository to re-analyze now
+type TrackingRunRequest struct {
+	Name string `json:"name" validate:"required"`
+}
+
+type TrackingRunResponse struct {
+	Status string        `json:"status"`
+	Run    *tracking.Run `json:"run,omitempty"`
+	Error  string        `json:"error,omitempty"`
+}
+
+// TimelineRequest limits a timeline to the latest runs
+type TimelineRequest struct {
+	Limit int `query:"limit"` // Latest runs to return; 0 returns every run recorded
+}
+
+// TimelineResponse is the evolution of a tracked repository across its runs
+type TimelineResponse struct {
+	Status   string             `json:"status"`
+	Timeline *tracking.Timeline `json:"timeline,omitempty"`
+	Error    string             `json:"error,omitempty"`
+}
+
+func NewTrackingController(scheduler *tracking.Scheduler) *TrackingController {
+	return &TrackingController{scheduler: scheduler}
+}
+
+// Repositories lists every tracked repository with its schedule, next run and last result
+func (tc *TrackingController) Repositories(c echo.Context) error {
+	statuses := []tracking.Status{}
+	if tc.scheduler != nil {
+		statuses = tc.scheduler.Statuses()
+	}
+	return c.JSON(http.StatusOK, TrackingStatusResponse{Status: "success", Repositories: statuses})
+}
+
+// Run re-analyzes a tracked repository immediately and sends its drift report as a scheduled run would
+func (tc *TrackingController) Run(c echo.Context) error {
+	var req TrackingRunRequest
+	if err := c.Bind(&req); err != nil || req.Name == "" {
+		return c.JSON(http.StatusBadRequest, TrackingRunResponse{
+			Status: "error",
+			Error:  "The name of a tracked repository is required",
+		})
+	}
+	if tc.scheduler == nil {
+		return c.JSON(http.StatusNotFound, TrackingRunResponse{
+			Status: "error",
+			Error:  tracking.ErrUnknownRepository.Error(),
+		})
+	}
+
+	run, err := tc.scheduler.Run(c.Request().Context(), req.Name)
+	switch {
+	case errors.Is(err, tracking.ErrUnknownRepository):
+		return c.JSON(http.StatusNotFound, TrackingRunResponse{Status: "error", Error: err.Error()})
+	case errors.Is(err, tracking.ErrRunning):
+		return c.JSON(http.StatusConflict, TrackingRunResponse{Status: "error", Error: err.Error()})
+	case err != nil:
+		return c.JSON(http.StatusInternalServerError, TrackingRunResponse{Status: "error", Run: run, Error: err.Error()})
+	}
+	return c.JSON(http.StatusOK, TrackingRunResponse{Status: "success", Run: run})
+}
+
+// Timeline returns how the services, tables, lines of code, dependencies and required secrets of a
+// tracked repository evolved across its runs, as series a dashboard can draw as sparklines
+func (tc *TrackingController) Timeline(c echo.Context) error {
+	var req TimelineRequest
+	if err := c.Bind(&req); err != nil || req.Limit < 0 {
+		return c.JSON(http.StatusBadRequest, TimelineResponse{
+			Status: "error",
+			Error:  "limit must be a non-negative number of runs",
+		})
+	}
+	if tc.scheduler == nil {
+		return c.JSON(http.StatusNotFound, TimelineResponse{
+			Status: "error",
+			Error:  tracking.ErrUnknownRepository.Error(),
+		})
+	}
+
+	timeline, err := tc.scheduler.Timeline(c.Param("id"), req.Limit)
+	switch {
+	case errors.Is(err, tracking.ErrUnknownRepository):
+		return c.JSON(http.StatusNotFound, TimelineResponse{Status: "error", Error: err.Error()})
+	case err != nil:
+		return c.JSON(http.StatusInternalServerError, TimelineResponse{Status: "error", Error: err.Error()})
+	}
+	return c.JSON(http.StatusOK, TimelineResponse{Status: "success", Timeline: timeline})
+}
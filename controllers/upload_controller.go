@@ -0,0 +1,218 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"repo-explanation/internal/pipeline"
+	"repo-explanation/internal/sandbox"
+)
+
+// maxUploadFiles bounds the files an uploaded archive may hold
+const maxUploadFiles = 200000
+
+// UploadArchiveField is the multipart file part holding the project archive
+const UploadArchiveField = "archive"
+
+// UploadRequest is the options of an archive upload, sent as multipart form fields alongside the
+// archive; list fields are comma-separated
+type UploadRequest struct {
+	Offline       bool    `form:"offline"`
+	Include       string  `form:"include"`
+	Exclude       string  `form:"exclude"`
+	Service       string  `form:"service"`
+	Phases        string  `form:"phases"`
+	Quick         bool    `form:"quick"`
+	Profile       string  `form:"profile"`
+	Lang          string  `form:"lang"`
+	MinConfidence float64 `form:"min_confidence"`
+	OnlyEvidence  string  `form:"only_evidence"`
+	Stream        bool    `form:"stream"` // Answer with Server-Sent Events, as /analyze/stream does
+}
+
+// AnalyzeUpload analyzes a project uploaded as an archive, for repositories the server cannot
+// clone or read. The archive is extracted into a temporary folder that is removed afterwards.
+func (ac *AnalysisController) AnalyzeUpload(c echo.Context) error {
+	maxUpload := int64(ac.config.Server.MaxUploadMB) * 1024 * 1024
+	if maxUpload == 0 {
+		return c.JSON(http.StatusNotFound, AnalysisResponse{
+			Status: "error",
+			Error:  "Uploads are disabled on this server (configure server.max_upload_mb to enable them)",
+		})
+	}
+	// Multipart framing takes a little room beyond the archive itself
+	c.Request().Body = http.MaxBytesReader(c.Response(), c.Request().Body, maxUpload+1024*1024)
+
+	fileHeader, err := c.FormFile(UploadArchiveField)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return c.JSON(http.StatusRequestEntityTooLarge, AnalysisResponse{
+				Status: "error",
+				Error:  fmt.Sprintf("Archive is larger than the server's limit of %d MB", ac.config.Server.MaxUploadMB),
+			})
+		}
+		return c.JSON(http.StatusBadRequest, AnalysisResponse{
+			Status: "error",
+			Error:  "A multipart form with the project archive in its archive field is required",
+		})
+	}
+	if fileHeader.Size > maxUpload {
+		return c.JSON(http.StatusRequestEntityTooLarge, AnalysisResponse{
+			Status: "error",
+			Error:  fmt.Sprintf("Archive is larger than the server's limit of %d MB", ac.config.Server.MaxUploadMB),
+		})
+	}
+
+	form := UploadRequest{
+		Offline:      formBool(c, "offline"),
+		Include:      c.FormValue("include"),
+		Exclude:      c.FormValue("exclude"),
+		Service:      c.FormValue("service"),
+		Phases:       c.FormValue("phases"),
+		Quick:        formBool(c, "quick"),
+		Profile:      c.FormValue("profile"),
+		Lang:         c.FormValue("lang"),
+		OnlyEvidence: c.FormValue("only_evidence"),
+		Stream:       formBool(c, "stream") || c.QueryParam("stream") == "true",
+	}
+	if value := c.FormValue("min_confidence"); value != "" {
+		if _, err := fmt.Sscanf(value, "%g", &form.MinConfidence); err != nil {
+			return c.JSON(http.StatusBadRequest, AnalysisResponse{Status: "error", Error: "min_confidence must be a number between 0 and 1"})
+		}
+	}
+
+	name := archiveName(fileHeader.Filename)
+	req := AnalysisRequest{
+		URL:           "upload://" + name,
+		Type:          "upload",
+		Offline:       form.Offline,
+		Include:       pipeline.ParseList(form.Include),
+		Exclude:       pipeline.ParseList(form.Exclude),
+		Service:       strings.TrimSpace(form.Service),
+		Phases:        pipeline.ParseList(form.Phases),
+		Quick:         form.Quick,
+		Profile:       form.Profile,
+		Lang:          form.Lang,
+		MinConfidence: form.MinConfidence,
+		OnlyEvidence:  pipeline.ParseList(form.OnlyEvidence),
+	}
+	if err := optionsForRequest(req).Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, AnalysisResponse{Status: "error", Error: fmt.Sprintf("Invalid analysis options: %v", err)})
+	}
+	if _, err := ac.config.Profile(req.Profile); err != nil {
+		return c.JSON(http.StatusBadRequest, AnalysisResponse{Status: "error", Error: fmt.Sprintf("Invalid analysis options: %v", err)})
+	}
+
+	tempDir, err := os.MkdirTemp("", "repo-upload-")
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, AnalysisResponse{Status: "error", Error: fmt.Sprintf("Failed to create temp directory: %v", err)})
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			fmt.Printf("Warning: Failed to clean up temp directory %s: %v\n", tempDir, err)
+		}
+	}()
+
+	projectPath, status, err := ac.extractUpload(fileHeader, tempDir)
+	if err != nil {
+		return c.JSON(status, AnalysisResponse{Status: "error", Error: err.Error()})
+	}
+	c.Logger().Infof("Extracted uploaded archive %s to %s", fileHeader.Filename, projectPath)
+
+	repoInfo := RepositoryInfo{URL: req.URL, Name: name, LocalPath: projectPath}
+	// Uploads of the same archive name share cache entries; the content hash keeps them correct
+	analyzer, err := pipeline.NewAnalyzerWithURL(ac.configForRequest(req), projectPath, req.URL)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, AnalysisResponse{
+			Status:     "error",
+			Error:      fmt.Sprintf("Failed to create analyzer: %v", err),
+			Repository: &repoInfo,
+		})
+	}
+	if err := analyzer.SetOptions(optionsForRequest(req)); err != nil {
+		return c.JSON(http.StatusBadRequest, AnalysisResponse{
+			Status:     "error",
+			Error:      fmt.Sprintf("Invalid analysis options: %v", err),
+			Repository: &repoInfo,
+		})
+	}
+
+	if form.Stream {
+		progressCallback := openEventStream(c)
+		progressCallback("progress", "📦 Archive extracted", fileHeader.Filename, 15, nil)
+		return ac.streamAnalysis(c, analyzer, progressCallback)
+	}
+	return ac.respondWithAnalysis(c, req, analyzer, repoInfo)
+}
+
+// extractUpload saves the uploaded archive next to its extraction folder and unpacks it, returning
+// the project root or the status to reject the upload with
+func (ac *AnalysisController) extractUpload(upload *multipart.FileHeader, tempDir string) (string, int, error) {
+	src, err := upload.Open()
+	if err != nil {
+		return "", http.StatusBadRequest, fmt.Errorf("Failed to read the uploaded archive: %v", err)
+	}
+	defer src.Close()
+
+	archivePath := filepath.Join(tempDir, "upload.archive")
+	dst, err := os.Create(archivePath)
+	if err != nil {
+		return "", http.StatusInternalServerError, fmt.Errorf("Failed to store the uploaded archive: %v", err)
+	}
+	_, err = io.Copy(dst, src)
+	dst.Close()
+	if err != nil {
+		return "", http.StatusInternalServerError, fmt.Errorf("Failed to store the uploaded archive: %v", err)
+	}
+
+	dest := filepath.Join(tempDir, "src")
+	if err := os.Mkdir(dest, 0755); err != nil {
+		return "", http.StatusInternalServerError, fmt.Errorf("Failed to create extraction folder: %v", err)
+	}
+	root, err := sandbox.Extract(archivePath, dest, sandbox.ArchiveLimits{
+		MaxBytes: int64(ac.config.Server.MaxExtractedMB) * 1024 * 1024,
+		MaxFiles: maxUploadFiles,
+	})
+	os.Remove(archivePath)
+	var limitErr *sandbox.LimitError
+	switch {
+	case errors.As(err, &limitErr):
+		return "", http.StatusRequestEntityTooLarge, err
+	case err != nil:
+		return "", http.StatusBadRequest, fmt.Errorf("Failed to extract the archive: %v", err)
+	}
+	return root, http.StatusOK, nil
+}
+
+// archiveName names an upload after its file, without the archive extension
+func archiveName(filename string) string {
+	name := filepath.Base(strings.ReplaceAll(filename, `\`, "/"))
+	lower := strings.ToLower(name)
+	for _, ext := range []string{".tar.gz", ".tgz", ".zip", ".tar"} {
+		if strings.HasSuffix(lower, ext) {
+			name = name[:len(name)-len(ext)]
+			break
+		}
+	}
+	if name == "" || name == "." || name == "/" {
+		return "upload"
+	}
+	return name
+}
+
+// formBool reads a checkbox-style form field
+func formBool(c echo.Context, name string) bool {
+	switch strings.ToLower(c.FormValue(name)) {
+	case "1", "true", "yes", "on":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// watchHeartbeat keeps idle watch streams open through proxies
+const watchHeartbeat = 30 * time.Second
+
+// WatchController fans watch-mode analysis events out to every connected SSE client
+type WatchController struct {
+	mu          sync.Mutex
+	subscribers map[chan []byte]bool
+	latest      []byte // Most recent complete event, replayed to clients that connect later
+}
+
+func NewWatchController() *WatchController {
+	return &WatchController{
+		subscribers: make(map[chan []byte]bool),
+	}
+}
+
+// Publish sends an event to every subscriber; its signature matches pipeline.ProgressCallback
+func (wc *WatchController) Publish(eventType, stage, message string, progress int, data interface{}) {
+	event := StreamEvent{
+		Type:      eventType,
+		Stage:     stage,
+		Progress:  progress,
+		Data:      data,
+		Message:   message,
+		Timestamp: time.Now(),
+	}
+	if eventType == "error" {
+		event.Error = message
+	}
+
+	eventJSON, err := json.Marshal(event)
+	if err != nil {
+		fmt.Printf("❌ [WATCH] Failed to marshal event: %v\n", err)
+		return
+	}
+
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+	if eventType == "complete" {
+		wc.latest = eventJSON
+	}
+	for ch := range wc.subscribers {
+		select {
+		case ch <- eventJSON:
+		default:
+			// A stalled client misses progress; the next complete event brings it up to date
+			fmt.Println("⚠️  [WATCH] Dropping event for slow client")
+		}
+	}
+}
+
+// subscribe registers a client and returns its event channel along with the latest complete event
+func (wc *WatchController) subscribe() (chan []byte, []byte) {
+	ch := make(chan []byte, 64)
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+	wc.subscribers[ch] = true
+	return ch, wc.latest
+}
+
+func (wc *WatchController) unsubscribe(ch chan []byte) {
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+	delete(wc.subscribers, ch)
+}
+
+// Stream keeps an SSE connection open and forwards every watch event until the client disconnects
+func (wc *WatchController) Stream(c echo.Context) error {
+	c.Response().Header().Set("Content-Type", "text/event-stream")
+	c.Response().Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	c.Response().Header().Set("Connection", "keep-alive")
+	c.Response().Header().Set("Access-Control-Allow-Origin", "*")
+	c.Response().Header().Set("X-Accel-Buffering", "no") // Disable nginx buffering
+	c.Response().WriteHeader(http.StatusOK)
+
+	ch, latest := wc.subscribe()
+	defer wc.unsubscribe(ch)
+
+	send := func(payload string) {
+		fmt.Fprint(c.Response(), payload)
+		c.Response().Flush()
+	}
+
+	if latest != nil {
+		send(fmt.Sprintf("data: %s\n\n", latest))
+	} else {
+		send(": waiting for the first analysis\n\n")
+	}
+
+	heartbeat := time.NewTicker(watchHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case eventJSON := <-ch:
+			send(fmt.Sprintf("data: %s\n\n", eventJSON))
+		case <-heartbeat.C:
+			send(": ping\n\n")
+		case <-c.Request().Context().Done():
+			return nil
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"repo-explanation/internal/workspace"
+)
+
+// WorkspaceRequest asks for a combined analysis of several GitHub repositories
+type WorkspaceRequest struct {
+	Name          string                 `json:"name"`
+	Repositories  []workspace.Repository `json:"repositories" validate:"required"`
+	Offline       bool                   `json:"offline,omitempty"`
+	Include       []string               `json:"include,omitempty"`
+	Exclude       []string               `json:"exclude,omitempty"`
+	Phases        []string               `json:"phases,omitempty"`
+	Quick         bool                   `json:"quick,omitempty"`
+	MinConfidence float64                `json:"min_confidence,omitempty"`
+	OnlyEvidence  []string               `json:"only_evidence,omitempty"`
+	Profile       string                 `json:"profile,omitempty"`
+	Lang          string                 `json:"lang,omitempty"`
+}
+
+type WorkspaceResponse struct {
+	Status  string            `json:"status"`
+	Message string            `json:"message,omitempty"`
+	Results *workspace.Result `json:"results,omitempty"`
+	Error   string            `json:"error,omitempty"`
+}
+
+// analysisRequest exposes the settings a workspace request shares with single-repository requests
+func (req WorkspaceRequest) analysisRequest() AnalysisRequest {
+	return AnalysisRequest{
+		Offline: req.Offline,
+		Include: req.Include,
+		Exclude: req.Exclude,
+		Phases:  req.Phases,
+		Quick:   req.Quick,
+
+		MinConfidence: req.MinConfidence,
+		OnlyEvidence:  req.OnlyEvidence,
+		Profile:       req.Profile,
+		Lang:          req.Lang,
+	}
+}
+
+func (req WorkspaceRequest) workspace() *workspace.Workspace {
+	return &workspace.Workspace{Name: req.Name, Repositories: req.Repositories}
+}
+
+// Validate checks the repositories and options before any cloning starts.
+// Only GitHub URLs are accepted over HTTP; local paths are a CLI-only feature.
+func (req WorkspaceRequest) Validate() error {
+	for _, repo := range req.Repositories {
+		if repo.Path != "" || !isValidGitHubURL(repo.URL) {
+			return fmt.Errorf("Invalid repository %q: only GitHub URLs are supported", repo.URL)
+		}
+	}
+	if err := req.workspace().Validate(); err != nil {
+		return fmt.Errorf("Invalid workspace: %v", err)
+	}
+	if err := optionsForRequest(req.analysisRequest()).Validate(); err != nil {
+		return fmt.Errorf("Invalid analysis options: %v", err)
+	}
+	return nil
+}
+
+// AnalyzeWorkspace clones every repository in the request, analyzes each one and links them together
+func (ac *AnalysisController) AnalyzeWorkspace(c echo.Context) error {
+	var req WorkspaceRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, WorkspaceResponse{
+			Status: "error",
+			Error:  "Invalid request format",
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, WorkspaceResponse{
+			Status: "error",
+			Error:  err.Error(),
+		})
+	}
+	if _, err := ac.config.Profile(req.Profile); err != nil {
+		return c.JSON(http.StatusBadRequest, WorkspaceResponse{
+			Status: "error",
+			Error:  fmt.Sprintf("Invalid analysis options: %v", err),
+		})
+	}
+	ws := req.workspace()
+	options := optionsForRequest(req.analysisRequest())
+
+	// Clone every repository into one temp directory, removed after the analysis
+	tempDir := filepath.Join(os.TempDir(), "repo-analysis", fmt.Sprintf("workspace-%d", time.Now().UnixNano()))
+	if err := os.MkdirAll(tempDir, 0755); err != nil {
+		return c.JSON(http.StatusInternalServerError, WorkspaceResponse{
+			Status: "error",
+			Error:  fmt.Sprintf("Failed to create temp directory: %v", err),
+		})
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			fmt.Printf("Warning: Failed to clean up temp directory %s: %v\n", tempDir, err)
+		}
+	}()
+
+	for i := range ws.Repositories {
+		repo := &ws.Repositories[i]
+		// Names come from the request body, so only the index is used in the path
+		repo.Path = filepath.Join(tempDir, fmt.Sprintf("repo-%02d", i))
+
+		c.Logger().Infof("Cloning workspace repository %s to %s", repo.URL, repo.Path)
+		err := cloneRepository(repo.URL, repo.Path, "")
+		if err != nil && isPrivateRepoError(err) && repo.Token != "" {
+			err = cloneRepository(repo.URL, repo.Path, repo.Token)
+		}
+		if err != nil {
+			status := http.StatusInternalServerError
+			if isPrivateRepoError(err) {
+				status = http.StatusUnauthorized
+			}
+			return c.JSON(status, WorkspaceResponse{
+				Status: "error",
+				Error:  fmt.Sprintf("Failed to clone %s: %v", repo.URL, err),
+			})
+		}
+	}
+
+	analyzer, err := workspace.NewAnalyzer(ac.configForRequest(req.analysisRequest()), ws, options)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, WorkspaceResponse{
+			Status: "error",
+			Error:  fmt.Sprintf("Failed to create workspace analyzer: %v", err),
+		})
+	}
+
+	analysisTimeout := ac.config.GetAnalysisTimeout()
+	ctx, cancel := context.WithTimeout(c.Request().Context(), analysisTimeout)
+	defer cancel()
+
+	results, err := analyzer.Analyze(ctx, func(eventType, stage, message string, progress int, data interface{}) {
+		if eventType == "progress" {
+			fmt.Printf("📦 [WORKSPACE] %d%% %s - %s\n", progress, stage, message)
+		}
+	})
+	if err != nil {
+		status := http.StatusInternalServerError
+		if ctx.Err() == context.DeadlineExceeded {
+			status = http.StatusRequestTimeout
+		}
+		return c.JSON(status, WorkspaceResponse{
+			Status: "error",
+			Error:  fmt.Sprintf("Workspace analysis failed: %v", err),
+		})
+	}
+
+	return c.JSON(http.StatusOK, WorkspaceResponse{
+		Status:  "success",
+		Message: fmt.Sprintf("Analyzed %d repositories", len(results.Repositories)),
+		Results: results,
+	})
+}
@@ -202,7 +202,23 @@ function App() {
       services: results.services || [],
       relationships: results.relationships || [],
       databaseSchema: results.database_schema || null,
+      tableOwnership: results.table_ownership || null,
+      tenancy: results.tenancy || null,
       projectSecrets: results.project_secrets || null,
+      toolchains: results.toolchains || null,
+      advisories: results.advisories || null,
+      frontend: results.frontend || null,
+      packageGraph: results.package_graph || null,
+      featureFlags: results.feature_flags || null,
+      authSurface: results.auth_surface || null,
+      jobs: results.jobs || null,
+      dataContracts: results.data_contracts || null,
+      architectureMap: results.architecture_map || null,
+      complexity: results.complexity || null,
+      docCoverage: results.doc_coverage || null,
+      generated: results.generated || null,
+      glossary: results.glossary || null,
+      privacy: results.privacy || null,
       helpfulQuestions: results.helpful_questions || [],
       fileSummaries: results.file_summaries || {},
       folderSummaries: results.folder_summaries || {},
@@ -232,8 +248,9 @@ function App() {
       data.projectType.primary_type || data.projectSummary.purpose;
     const hasFileStats = data.stats.total_files > 0;
     const hasLanguages =
-      data.projectSummary.languages &&
-      Object.keys(data.projectSummary.languages).length > 0;
+      (data.stats.languages && data.stats.languages.length > 0) ||
+      (data.projectSummary.languages &&
+        Object.keys(data.projectSummary.languages).length > 0);
 
     return !hasProjectInfo && !hasFileStats && !hasLanguages;
   };
@@ -1166,39 +1183,574 @@ function OverviewTab({ getAnalysisData }) {
           <CardTitle>Technology Stack</CardTitle>
         </CardHeader>
         <CardContent>
-          <div className="flex flex-wrap gap-2">
-            {(() => {
-              const languages = data?.projectSummary?.languages || {};
-              const languageEntries = Object.entries(languages);
-
-              if (languageEntries.length === 0) {
-                return (
-                  <div className="text-center py-4 text-muted-foreground w-full">
-                    <Code2 className="h-8 w-8 mx-auto mb-2 opacity-50" />
-                    <p>No programming languages detected</p>
-                  </div>
-                );
-              }
+          {(() => {
+            // Deterministic line counts from the analyzer, falling back to the LLM-reported map
+            const detected = data?.stats?.languages || [];
+            if (detected.length > 0) {
+              const weighted = detected.filter((lang) => lang.percentage > 0);
+              const other = detected.filter((lang) => !(lang.percentage > 0));
+              return (
+                <div className="space-y-3">
+                  {weighted.map((lang) => (
+                    <div key={lang.name} className="space-y-1">
+                      <div className="flex justify-between text-sm">
+                        <span className="font-medium">{lang.name}</span>
+                        <span className="text-muted-foreground">
+                          {lang.percentage.toFixed(1)}% · {lang.code} lines ·{" "}
+                          {lang.files} files
+                        </span>
+                      </div>
+                      <Progress value={lang.percentage} />
+                    </div>
+                  ))}
+                  {other.length > 0 && (
+                    <div className="flex flex-wrap gap-2 pt-2">
+                      {other.map((lang) => (
+                        <Badge key={lang.name} variant="secondary">
+                          {lang.name} ({lang.code} lines)
+                        </Badge>
+                      ))}
+                    </div>
+                  )}
+                  {data?.stats?.loc && (
+                    <p className="text-xs text-muted-foreground pt-2">
+                      {data.stats.loc.code} lines of code,{" "}
+                      {data.stats.loc.comment} comment,{" "}
+                      {data.stats.loc.blank} blank
+                    </p>
+                  )}
+                </div>
+              );
+            }
 
-              return languageEntries.map(([lang, lines]) => (
-                <Badge key={lang} variant="default">
-                  {lang} ({lines} lines)
-                </Badge>
-              ));
-            })()}
-          </div>
+            const languages = data?.projectSummary?.languages || {};
+            const languageEntries = Object.entries(languages);
+
+            if (languageEntries.length === 0) {
+              return (
+                <div className="text-center py-4 text-muted-foreground w-full">
+                  <Code2 className="h-8 w-8 mx-auto mb-2 opacity-50" />
+                  <p>No programming languages detected</p>
+                </div>
+              );
+            }
+
+            return (
+              <div className="flex flex-wrap gap-2">
+                {languageEntries.map(([lang, lines]) => (
+                  <Badge key={lang} variant="default">
+                    {lang} ({lines} lines)
+                  </Badge>
+                ))}
+              </div>
+            );
+          })()}
         </CardContent>
       </Card>
+
+      {(data?.projectSummary?.documentation?.setup_steps?.length > 0 ||
+        data?.projectSummary?.documentation?.run_commands?.length > 0) && (
+        <Card>
+          <CardHeader>
+            <CardTitle>How to Run</CardTitle>
+            <CardDescription>
+              From {data.projectSummary.documentation.sources?.join(", ")}
+            </CardDescription>
+          </CardHeader>
+          <CardContent className="space-y-4">
+            {data.projectSummary.documentation.setup_steps?.length > 0 && (
+              <div>
+                <div className="text-sm font-medium mb-2">Setup</div>
+                <pre className="text-xs bg-muted/30 rounded-lg p-3 overflow-x-auto">
+                  {data.projectSummary.documentation.setup_steps.join("\n")}
+                </pre>
+              </div>
+            )}
+            {data.projectSummary.documentation.run_commands?.length > 0 && (
+              <div>
+                <div className="text-sm font-medium mb-2">Run</div>
+                <pre className="text-xs bg-muted/30 rounded-lg p-3 overflow-x-auto">
+                  {data.projectSummary.documentation.run_commands.join("\n")}
+                </pre>
+              </div>
+            )}
+          </CardContent>
+        </Card>
+      )}
+
+      {data?.glossary?.terms?.length > 0 && (
+        <Card>
+          <CardHeader>
+            <CardTitle>Glossary</CardTitle>
+            <CardDescription>{data.glossary.summary}</CardDescription>
+          </CardHeader>
+          <CardContent>
+            <div className="space-y-3">
+              {data.glossary.terms.map((term) => (
+                <div key={`${term.kind}-${term.term}`} className="text-sm">
+                  <div className="flex items-center gap-2">
+                    <span className="font-medium">{term.term}</span>
+                    <Badge variant="secondary">{term.kind}</Badge>
+                  </div>
+                  <div>{term.definition}</div>
+                  {term.sources?.length > 0 && (
+                    <div className="text-xs text-muted-foreground">
+                      {term.sources.join(", ")}
+                    </div>
+                  )}
+                </div>
+              ))}
+            </div>
+          </CardContent>
+        </Card>
+      )}
+
+      {data?.frontend?.apps?.length > 0 && (
+        <Card>
+          <CardHeader>
+            <CardTitle>Frontend Architecture</CardTitle>
+          </CardHeader>
+          <CardContent>
+            <div className="space-y-4">
+              {data.frontend.apps.map((app) => (
+                <div key={app.path || "root"} className="space-y-2 text-sm">
+                  <div className="flex items-center gap-2">
+                    <span className="font-medium">{app.name}</span>
+                    <Badge variant="secondary">{app.framework}</Badge>
+                    <span className="text-muted-foreground">
+                      {app.path || "."}
+                    </span>
+                  </div>
+                  {app.router && (
+                    <div className="text-muted-foreground">
+                      Routing: {app.router.library} ({app.router.style}),{" "}
+                      {app.router.routes.length} routes
+                      {app.router.routes.length > 0 && (
+                        <span>
+                          {" "}
+                          •{" "}
+                          {app.router.routes
+                            .slice(0, 8)
+                            .map((route) => route.path)
+                            .join(", ")}
+                          {app.router.routes.length > 8 && ", ..."}
+                        </span>
+                      )}
+                    </div>
+                  )}
+                  {(app.state?.length > 0 ||
+                    app.design_systems?.length > 0) && (
+                    <div className="flex flex-wrap gap-2">
+                      {app.state?.map((library) => (
+                        <Badge key={library.name} variant="outline">
+                          State: {library.name}
+                        </Badge>
+                      ))}
+                      {app.design_systems?.map((library) => (
+                        <Badge key={library.name} variant="outline">
+                          UI: {library.name}
+                        </Badge>
+                      ))}
+                    </div>
+                  )}
+                  <div className="text-xs text-muted-foreground">
+                    {app.components.total} components •{" "}
+                    {app.components.pages} pages • {app.components.layouts}{" "}
+                    layouts • {app.components.shared} shared
+                  </div>
+                </div>
+              ))}
+            </div>
+          </CardContent>
+        </Card>
+      )}
+
+      {data?.complexity && (
+        <Card>
+          <CardHeader>
+            <CardTitle>Complexity & Onboarding</CardTitle>
+            <CardDescription>{data.complexity.summary}</CardDescription>
+          </CardHeader>
+          <CardContent>
+            <div className="space-y-4 text-sm">
+              <div className="flex flex-wrap items-center gap-2">
+                <span className="text-2xl font-semibold">
+                  {Math.round(data.complexity.project.score)}/100
+                </span>
+                <Badge variant="secondary">{data.complexity.project.level}</Badge>
+                <span className="text-muted-foreground">
+                  Expected onboarding {data.complexity.project.onboarding}
+                </span>
+              </div>
+              <div className="grid grid-cols-2 gap-2 md:grid-cols-5">
+                {data.complexity.project.factors.map((factor) => (
+                  <div key={factor.name} className="rounded border p-2">
+                    <div className="text-xs text-muted-foreground">
+                      {factor.name}
+                    </div>
+                    <div className="font-medium">
+                      {factor.value.toLocaleString()}
+                    </div>
+                    <div className="text-xs text-muted-foreground">
+                      {factor.points} / {factor.weight} pts
+                    </div>
+                  </div>
+                ))}
+              </div>
+              {data.complexity.services?.length > 0 && (
+                <div className="space-y-1">
+                  {data.complexity.services.map((service) => (
+                    <div
+                      key={service.name}
+                      className="flex flex-wrap items-center gap-2"
+                    >
+                      <span className="font-medium">{service.name}</span>
+                      <Badge variant="outline">
+                        {Math.round(service.score)} ({service.level})
+                      </Badge>
+                      <span className="text-xs text-muted-foreground">
+                        onboarding {service.onboarding}
+                      </span>
+                    </div>
+                  ))}
+                </div>
+              )}
+              <div className="font-mono text-xs text-muted-foreground">
+                {data.complexity.formula}
+              </div>
+            </div>
+          </CardContent>
+        </Card>
+      )}
+
+      {data?.docCoverage && (
+        <Card>
+          <CardHeader>
+            <CardTitle>Documentation Coverage</CardTitle>
+            <CardDescription>{data.docCoverage.summary}</CardDescription>
+          </CardHeader>
+          <CardContent>
+            <div className="space-y-1 text-sm">
+              {data.docCoverage.areas.map((area) => (
+                <div key={area.name} className="flex flex-wrap items-center gap-2">
+                  <span className="font-medium">{area.name}</span>
+                  <Badge
+                    variant={
+                      data.docCoverage.least_documented.includes(area.name)
+                        ? "destructive"
+                        : "outline"
+                    }
+                  >
+                    {Math.round(area.score)} ({area.grade})
+                  </Badge>
+                  {area.gaps.length > 0 && (
+                    <span className="text-xs text-muted-foreground">
+                      {area.gaps.join("; ")}
+                    </span>
+                  )}
+                </div>
+              ))}
+            </div>
+          </CardContent>
+        </Card>
+      )}
+
+      {(data?.generated?.artifacts?.length > 0 || data?.generated?.pipelines?.length > 0) && (
+        <Card>
+          <CardHeader>
+            <CardTitle>Generated Code</CardTitle>
+            <CardDescription>{data.generated.summary}</CardDescription>
+          </CardHeader>
+          <CardContent>
+            <div className="space-y-2 text-sm">
+              {data.generated.specs?.map((spec) => (
+                <div key={spec.path}>
+                  <div className="flex items-center gap-2">
+                    <span className="font-medium">{spec.path}</span>
+                    <Badge variant="outline">{spec.kind}</Badge>
+                  </div>
+                  <div className="text-xs text-muted-foreground">
+                    → {spec.outputs.join(", ")}
+                  </div>
+                </div>
+              ))}
+              {data.generated.artifacts
+                .filter((artifact) => !artifact.source)
+                .map((artifact) => (
+                  <div key={artifact.path} className="flex items-center gap-2">
+                    <span>{artifact.path}</span>
+                    <Badge variant="secondary">{artifact.kind}</Badge>
+                    {artifact.generator && (
+                      <span className="text-xs text-muted-foreground">
+                        {artifact.generator}
+                      </span>
+                    )}
+                  </div>
+                ))}
+              {data.generated.pipelines?.map((pipeline) => (
+                <div key={`${pipeline.config}:${pipeline.line || 0}:${pipeline.command}`}>
+                  <div className="flex items-center gap-2">
+                    <code className="text-xs">{pipeline.command}</code>
+                    <Badge variant="outline">{pipeline.generator}</Badge>
+                  </div>
+                  <div className="text-xs text-muted-foreground">
+                    → {pipeline.outputs.join(", ")} (never edit by hand)
+                  </div>
+                </div>
+              ))}
+            </div>
+          </CardContent>
+        </Card>
+      )}
+
+      {data?.authSurface?.services?.length > 0 && (
+        <Card>
+          <CardHeader>
+            <CardTitle>Auth Model</CardTitle>
+            <CardDescription>{data.authSurface.summary}</CardDescription>
+          </CardHeader>
+          <CardContent>
+            <div className="space-y-4">
+              {data.authSurface.services.map((service) => (
+                <div key={service.service || "root"} className="space-y-2 text-sm">
+                  <div className="flex flex-wrap items-center gap-2">
+                    <span className="font-medium">
+                      {service.service || "(outside services)"}
+                    </span>
+                    {service.mechanisms.map((mechanism) => (
+                      <Badge key={mechanism.name} variant="secondary">
+                        {mechanism.label}
+                      </Badge>
+                    ))}
+                  </div>
+                  <div className="text-muted-foreground">{service.model}</div>
+                  {service.enforcement_points.length > 0 && (
+                    <div className="text-xs text-muted-foreground">
+                      Enforced at{" "}
+                      {service.enforcement_points
+                        .slice(0, 5)
+                        .map((point) => `${point.file}:${point.line}`)
+                        .join(", ")}
+                      {service.enforcement_points.length > 5 && ", ..."}
+                    </div>
+                  )}
+                </div>
+              ))}
+            </div>
+          </CardContent>
+        </Card>
+      )}
+
+      {data?.jobs?.jobs?.length > 0 && (
+        <Card>
+          <CardHeader>
+            <CardTitle>Background Jobs</CardTitle>
+            <CardDescription>{data.jobs.summary}</CardDescription>
+          </CardHeader>
+          <CardContent>
+            <div className="space-y-2 text-sm">
+              {data.jobs.jobs.map((job) => (
+                <div key={`${job.file}:${job.line}`}>
+                  <div className="flex flex-wrap items-center gap-2">
+                    <span className="font-medium">{job.name}</span>
+                    <Badge variant="outline">{job.kind}</Badge>
+                    <Badge variant="secondary">
+                      {job.trigger === "queue"
+                        ? `queue ${job.queue || ""}`
+                        : job.cadence || job.schedule}
+                    </Badge>
+                    {job.service && (
+                      <span className="text-xs text-muted-foreground">{job.service}</span>
+                    )}
+                  </div>
+                  <div className="text-xs text-muted-foreground">
+                    {job.handler && `${job.handler} · `}
+                    {job.file}:{job.line}
+                  </div>
+                </div>
+              ))}
+            </div>
+          </CardContent>
+        </Card>
+      )}
+
+      {data?.dataContracts?.contracts?.length > 0 && (
+        <Card>
+          <CardHeader>
+            <CardTitle>Data Contracts</CardTitle>
+            <CardDescription>{data.dataContracts.summary}</CardDescription>
+          </CardHeader>
+          <CardContent>
+            <div className="space-y-2 text-sm">
+              {data.dataContracts.contracts.map((contract) => (
+                <div key={`${contract.file}:${contract.line}`}>
+                  <div className="flex flex-wrap items-center gap-2">
+                    <span className="font-medium">{contract.name}</span>
+                    <Badge variant="outline">{contract.format}</Badge>
+                    {contract.channels.map((channel) => (
+                      <Badge key={channel} variant="secondary">{channel}</Badge>
+                    ))}
+                  </div>
+                  <div className="text-xs text-muted-foreground">
+                    {contract.producers.length > 0 && `from ${contract.producers.join(", ")} · `}
+                    {contract.consumers.length > 0 && `to ${contract.consumers.join(", ")} · `}
+                    {contract.file}:{contract.line}
+                  </div>
+                </div>
+              ))}
+            </div>
+          </CardContent>
+        </Card>
+      )}
+
+      {data?.architectureMap?.root && (
+        <Card>
+          <CardHeader>
+            <CardTitle>Architecture Map</CardTitle>
+            <CardDescription>
+              {data.architectureMap.folders} folders, nested by location
+            </CardDescription>
+          </CardHeader>
+          <CardContent className="text-sm">
+            <FolderTreeNode node={data.architectureMap.root} depth={0} />
+          </CardContent>
+        </Card>
+      )}
+
+      {data?.featureFlags?.flags?.length > 0 && (
+        <Card>
+          <CardHeader>
+            <CardTitle>Feature Flags</CardTitle>
+            <CardDescription>{data.featureFlags.summary}</CardDescription>
+          </CardHeader>
+          <CardContent>
+            <div className="space-y-3">
+              {data.featureFlags.flags.slice(0, 30).map((flag) => (
+                <div
+                  key={`${flag.provider}-${flag.key}`}
+                  className="space-y-1 text-sm"
+                >
+                  <div className="flex items-center gap-2">
+                    <span className="font-mono font-medium">{flag.key}</span>
+                    <Badge variant="secondary">{flag.provider}</Badge>
+                    <Badge variant="outline">{flag.kind}</Badge>
+                  </div>
+                  <div className="text-xs text-muted-foreground">
+                    {flag.usages
+                      .slice(0, 3)
+                      .map((usage) => `${usage.file}:${usage.line}`)
+                      .join(", ")}
+                    {flag.usages.length > 3 &&
+                      ` and ${flag.usages.length - 3} more`}
+                  </div>
+                </div>
+              ))}
+              {data.featureFlags.flags.length > 30 && (
+                <div className="text-xs text-muted-foreground">
+                  ... and {data.featureFlags.flags.length - 30} more flags
+                </div>
+              )}
+            </div>
+          </CardContent>
+        </Card>
+      )}
     </div>
   );
 }
 
+function FolderTreeNode({ node, depth }) {
+  const label = (
+    <>
+      <span className="font-medium">{depth > 0 ? `${node.name}/` : node.name}</span>
+      {node.purpose && (
+        <span className="text-xs text-muted-foreground ml-2">{node.purpose}</span>
+      )}
+    </>
+  );
+  if (!node.children || node.children.length === 0) {
+    return <div className="py-0.5">{label}</div>;
+  }
+  return (
+    <details open={depth < 1} className="py-0.5">
+      <summary className="cursor-pointer">{label}</summary>
+      <div className="ml-4 border-l pl-3">
+        {node.children.map((child) => (
+          <FolderTreeNode key={child.path} node={child} depth={depth + 1} />
+        ))}
+      </div>
+    </details>
+  );
+}
+
 function ServicesTab({ getAnalysisData }) {
   const data = getAnalysisData();
   const services = data?.services || [];
+  const toolchains = data?.toolchains;
+  const advisories = data?.advisories;
 
   return (
     <div className="space-y-6">
+      {toolchains && toolchains.toolchains?.length > 0 && (
+        <Card>
+          <CardHeader>
+            <CardTitle>Toolchains to Install</CardTitle>
+            <CardDescription>{toolchains.summary}</CardDescription>
+          </CardHeader>
+          <CardContent>
+            <div className="space-y-2">
+              {toolchains.toolchains.map((toolchain) => (
+                <div
+                  key={toolchain.name}
+                  className="flex items-center justify-between text-sm"
+                >
+                  <span className="font-medium">{toolchain.name}</span>
+                  <span className="text-muted-foreground">
+                    {toolchain.version} •{" "}
+                    {toolchain.sources.map((source) => source.file).join(", ")}
+                  </span>
+                </div>
+              ))}
+              {toolchains.conflicts?.map((conflict, index) => (
+                <div key={index} className="text-xs text-amber-700">
+                  ⚠️ {conflict.message}
+                </div>
+              ))}
+            </div>
+          </CardContent>
+        </Card>
+      )}
+      {advisories && advisories.advisories?.length > 0 && (
+        <Card>
+          <CardHeader>
+            <CardTitle>Version Advisories</CardTitle>
+            <CardDescription>{advisories.summary}</CardDescription>
+          </CardHeader>
+          <CardContent>
+            <div className="space-y-2">
+              {advisories.advisories.map((advisory, index) => (
+                <div
+                  key={index}
+                  className="flex items-center justify-between text-sm"
+                >
+                  <span
+                    className={
+                      advisory.status === "end-of-life"
+                        ? "text-red-700"
+                        : "text-amber-700"
+                    }
+                  >
+                    {advisory.message}
+                  </span>
+                  <span className="text-muted-foreground">
+                    {advisory.service ? `${advisory.service} • ` : ""}
+                    {advisory.file}
+                  </span>
+                </div>
+              ))}
+            </div>
+          </CardContent>
+        </Card>
+      )}
       <Card>
         <CardHeader>
           <CardTitle>Discovered Services</CardTitle>
@@ -1237,7 +1789,18 @@ function ServicesTab({ getAnalysisData }) {
                   <div className="flex items-center gap-3">
                     <Server className="h-6 w-6 text-primary" />
                     <div>
-                      <div className="font-medium">{service.name}</div>
+                      <div className="font-medium">
+                        {service.name}
+                        {service.user_specified &&
+                          service.user_specified.length > 0 && (
+                            <span
+                              className="ml-2 text-xs text-muted-foreground"
+                              title={`Set in .repo-analyzer.yaml: ${service.user_specified.join(", ")}`}
+                            >
+                              📌 pinned
+                            </span>
+                          )}
+                      </div>
                       <div className="text-sm text-muted-foreground">
                         {service.port && `Port: ${service.port}`}
                       </div>
@@ -1246,6 +1809,30 @@ function ServicesTab({ getAnalysisData }) {
                           {service.description}
                         </div>
                       )}
+                      {service.container && (
+                        <div className="text-xs text-muted-foreground mt-1">
+                          🐳 {service.container.summary}
+                        </div>
+                      )}
+                      {service.startup && service.startup.length > 0 && (
+                        <details className="text-xs text-muted-foreground mt-1">
+                          <summary className="cursor-pointer">
+                            🚀 Startup sequence ({service.startup.length} steps)
+                          </summary>
+                          <ol className="list-decimal ml-5 mt-1 space-y-0.5">
+                            {service.startup.map((step, index) => (
+                              <li key={index}>
+                                <span className="font-medium">{step.kind}</span>{" "}
+                                <code>{step.detail}</code>{" "}
+                                <span>
+                                  {step.file}:{step.line}
+                                  {step.via && ` via ${step.via}`}
+                                </span>
+                              </li>
+                            ))}
+                          </ol>
+                        </details>
+                      )}
                     </div>
                   </div>
                   <Badge
@@ -1269,8 +1856,12 @@ function ServicesTab({ getAnalysisData }) {
 function DatabaseTab({ getAnalysisData }) {
   const data = getAnalysisData();
   const databaseSchema = data?.databaseSchema;
+  const tableOwnership = data?.tableOwnership;
+  const tenancy = data?.tenancy;
+  const erdSplit = databaseSchema?.erd_split;
   const [expandedTables, setExpandedTables] = useState(new Set());
   const [activeView, setActiveView] = useState("tables");
+  const [erdDomain, setErdDomain] = useState("");
 
   const toggleTableExpansion = (tableName) => {
     const newExpanded = new Set(expandedTables);
@@ -1287,9 +1878,14 @@ function DatabaseTab({ getAnalysisData }) {
 
     let mermaid = "erDiagram\n";
 
+    // Schema-qualified names (auth.users) need a Mermaid-safe id and keep the real name as a label
+    const entityId = (name) => name.replace(/\./g, "__");
+
     // Add tables and their columns with correct Mermaid.js syntax
     Object.entries(databaseSchema.tables).forEach(([tableName, tableInfo]) => {
-      mermaid += `    ${tableName} {\n`;
+      mermaid += tableName.includes(".")
+        ? `    ${entityId(tableName)}["${tableName}"] {\n`
+        : `    ${tableName} {\n`;
 
       // Add columns with proper data type format
       Object.entries(tableInfo.columns || {}).forEach(([colName, colInfo]) => {
@@ -1308,6 +1904,7 @@ function DatabaseTab({ getAnalysisData }) {
         const cleanType = (colInfo.type || "varchar").replace(/[^\w]/g, "");
         mermaid += `        ${cleanType} ${colName}`;
         if (constraintText) mermaid += ` ${constraintText}`;
+        if (colInfo.classification) mermaid += ` "${colInfo.classification}"`;
         mermaid += "\n";
       });
 
@@ -1319,11 +1916,30 @@ function DatabaseTab({ getAnalysisData }) {
       Object.entries(tableInfo.columns || {}).forEach(([colName, colInfo]) => {
         if (colInfo.references) {
           // Mermaid ERD relationship format: PARENT_TABLE ||--o{ CHILD_TABLE : "relationship_name"
-          mermaid += `    ${colInfo.references.table} ||--o{ ${tableName} : has\n`;
+          mermaid += `    ${entityId(colInfo.references.table)} ||--o{ ${entityId(tableName)} : has\n`;
         }
       });
     });
 
+    // Fill each table with its owning service's color and outline tables several services use
+    if (tableOwnership) {
+      tableOwnership.services.forEach((service, i) => {
+        const owned = tableOwnership.tables
+          .filter((t) => t.owner === service.name && databaseSchema.tables[t.table])
+          .map((t) => entityId(t.table));
+        if (owned.length === 0) return;
+        mermaid += `    classDef owner${i} fill:${service.color}\n`;
+        mermaid += `    class ${owned.join(",")} owner${i}\n`;
+      });
+      const shared = tableOwnership.tables
+        .filter((t) => t.shared && databaseSchema.tables[t.table])
+        .map((t) => entityId(t.table));
+      if (shared.length > 0) {
+        mermaid += "    classDef shared stroke:#dc2626,stroke-width:3px\n";
+        mermaid += `    class ${shared.join(",")} shared\n`;
+      }
+    }
+
     return mermaid;
   };
 
@@ -1386,6 +2002,8 @@ function DatabaseTab({ getAnalysisData }) {
   }
 
   const tableCount = Object.keys(databaseSchema.tables).length;
+  const quality = databaseSchema.quality;
+  const sensitivity = databaseSchema.sensitivity;
   const totalColumns = Object.values(databaseSchema.tables).reduce(
     (sum, table) => sum + Object.keys(table.columns || {}).length,
     0
@@ -1400,7 +2018,11 @@ function DatabaseTab({ getAnalysisData }) {
   return (
     <div className="space-y-8">
       {/* Database Overview Stats */}
-      <div className="grid grid-cols-1 md:grid-cols-3 gap-6">
+      <div
+        className={`grid grid-cols-1 ${
+          quality ? "md:grid-cols-4" : "md:grid-cols-3"
+        } gap-6`}
+      >
         <Card
           className="border-0 shadow-sm"
           style={{ backgroundColor: "white" }}
@@ -1499,8 +2121,84 @@ function DatabaseTab({ getAnalysisData }) {
             </div>
           </CardContent>
         </Card>
+
+        {quality && (
+          <Card
+            className="border-0 shadow-sm"
+            style={{ backgroundColor: "white" }}
+          >
+            <CardContent className="pt-6 pb-6">
+              <div className="flex items-center gap-4">
+                <div
+                  className="p-3 rounded-xl"
+                  style={{ backgroundColor: "hsl(var(--slate-100))" }}
+                >
+                  <Shield
+                    className="h-6 w-6"
+                    style={{ color: "hsl(var(--slate-700))" }}
+                  />
+                </div>
+                <div>
+                  <div
+                    className="text-sm font-medium"
+                    style={{ color: "hsl(var(--slate-500))" }}
+                  >
+                    Schema Quality
+                  </div>
+                  <div
+                    className="text-2xl font-bold"
+                    style={{ color: "hsl(var(--slate-800))" }}
+                    title={(quality.findings || [])
+                      .map((finding) => finding.message)
+                      .join("\n")}
+                  >
+                    {quality.score}/100 ({quality.grade})
+                  </div>
+                  <div
+                    className="text-xs"
+                    style={{ color: "hsl(var(--slate-500))" }}
+                  >
+                    {quality.errors} errors • {quality.warnings} warnings •{" "}
+                    {quality.infos} suggestions
+                  </div>
+                </div>
+              </div>
+            </CardContent>
+          </Card>
+        )}
       </div>
 
+      {/* Data Sensitivity */}
+      {sensitivity && (
+        <Card className="border-0 shadow-sm" style={{ backgroundColor: "white" }}>
+          <CardHeader>
+            <CardTitle style={{ color: "hsl(var(--slate-800))" }}>
+              Data Sensitivity
+            </CardTitle>
+            <CardDescription>{sensitivity.summary}</CardDescription>
+          </CardHeader>
+          <CardContent className="space-y-2 text-sm">
+            {sensitivity.warnings.map((warning) => (
+              <div key={warning} style={{ color: "hsl(var(--red-600))" }}>
+                {warning}
+              </div>
+            ))}
+            <div className="flex flex-wrap gap-2">
+              {sensitivity.columns.map((column) => (
+                <Badge
+                  key={`${column.table}.${column.column}`}
+                  variant="outline"
+                  className="text-xs"
+                  title={column.label}
+                >
+                  {column.table}.{column.column}: {column.class}
+                </Badge>
+              ))}
+            </div>
+          </CardContent>
+        </Card>
+      )}
+
       {/* View Selector */}
       <Card className="border-0 shadow-sm" style={{ backgroundColor: "white" }}>
         <CardHeader style={{ backgroundColor: "hsl(var(--slate-50))" }}>
@@ -1554,6 +2252,7 @@ function DatabaseTab({ getAnalysisData }) {
                   const isExpanded = expandedTables.has(tableName);
                   const columns = Object.entries(tableInfo.columns || {});
                   const primaryKeys = tableInfo.primary_keys || [];
+                  const triggers = tableInfo.triggers || [];
 
                   return (
                     <Card
@@ -1594,6 +2293,10 @@ function DatabaseTab({ getAnalysisData }) {
                                   ` • ${primaryKeys.length} primary key${
                                     primaryKeys.length > 1 ? "s" : ""
                                   }`}
+                                {triggers.length > 0 &&
+                                  ` • ${triggers.length} trigger${
+                                    triggers.length > 1 ? "s" : ""
+                                  }`}
                               </div>
                             </div>
                           </div>
@@ -1697,6 +2400,14 @@ function DatabaseTab({ getAnalysisData }) {
                                           FK
                                         </Badge>
                                       )}
+                                      {colInfo.classification && (
+                                        <Badge
+                                          variant="destructive"
+                                          className="text-xs"
+                                        >
+                                          {colInfo.classification}
+                                        </Badge>
+                                      )}
                                       {constraints.includes("unique") && (
                                         <Badge
                                           variant="outline"
@@ -1718,6 +2429,29 @@ function DatabaseTab({ getAnalysisData }) {
                                 );
                               })}
                             </div>
+                            {triggers.length > 0 && (
+                              <div className="mt-4 grid gap-2">
+                                {triggers.map((trigger) => (
+                                  <div
+                                    key={trigger.name}
+                                    className="p-3 rounded-lg text-sm"
+                                    style={{
+                                      backgroundColor: "hsl(var(--slate-50))",
+                                      color: "hsl(var(--slate-600))",
+                                    }}
+                                  >
+                                    <span
+                                      className="font-medium"
+                                      style={{ color: "hsl(var(--slate-800))" }}
+                                    >
+                                      {trigger.name}
+                                    </span>
+                                    {` ${trigger.timing} ${(trigger.events || []).join(" or ")} for each ${trigger.for_each}`}
+                                    {trigger.function && ` → ${trigger.function}()`}
+                                  </div>
+                                ))}
+                              </div>
+                            )}
                           </div>
                         </CardContent>
                       )}
@@ -1736,15 +2470,111 @@ function DatabaseTab({ getAnalysisData }) {
                   Database Relationship Diagram
                 </div>
               </div>
+              {erdSplit && (
+                <div className="space-y-2">
+                  <div
+                    className="text-sm"
+                    style={{ color: "hsl(var(--slate-600))" }}
+                  >
+                    {erdSplit.summary}
+                  </div>
+                  <div className="flex flex-wrap gap-2">
+                    <Button
+                      variant={erdDomain === "" ? "default" : "outline"}
+                      size="sm"
+                      onClick={() => setErdDomain("")}
+                    >
+                      All domains
+                    </Button>
+                    {erdSplit.domains.map((domain) => (
+                      <Button
+                        key={domain.name}
+                        variant={erdDomain === domain.name ? "default" : "outline"}
+                        size="sm"
+                        onClick={() => setErdDomain(domain.name)}
+                      >
+                        {domain.name} ({domain.tables.length})
+                      </Button>
+                    ))}
+                  </div>
+                </div>
+              )}
               <ZoomableMermaid
-                mermaidCode={generateMermaidERD()}
-                title="Database Entity Relationship Diagram"
+                key={erdDomain}
+                mermaidCode={
+                  erdSplit
+                    ? erdSplit.domains.find((domain) => domain.name === erdDomain)
+                        ?.mermaid || erdSplit.index
+                    : generateMermaidERD()
+                }
+                title={
+                  erdSplit && erdDomain
+                    ? `Database Entity Relationship Diagram: ${erdDomain}`
+                    : "Database Entity Relationship Diagram"
+                }
                 className="min-h-96"
                 containerClassName="bg-white"
                 initialZoom={0.8}
                 maxZoom={3.0}
                 minZoom={0.2}
               />
+              {tableOwnership && (
+                <div className="space-y-2 text-sm">
+                  <div style={{ color: "hsl(var(--slate-600))" }}>
+                    {tableOwnership.summary}
+                  </div>
+                  <div className="flex flex-wrap gap-2">
+                    {tableOwnership.services
+                      .filter((service) => service.owns > 0)
+                      .map((service) => (
+                        <span
+                          key={service.name}
+                          className="px-2 py-1 rounded text-xs"
+                          style={{ backgroundColor: service.color }}
+                        >
+                          {service.name} ({service.owns})
+                        </span>
+                      ))}
+                  </div>
+                  {tableOwnership.tables
+                    .filter((table) => table.shared)
+                    .map((table) => (
+                      <div
+                        key={table.table}
+                        className="text-xs"
+                        style={{ color: "hsl(var(--red-600))" }}
+                      >
+                        Shared: <code>{table.table}</code> owned by{" "}
+                        {table.owner}, also used by{" "}
+                        {table.accessed_by.slice(1).join(", ")}
+                      </div>
+                    ))}
+                </div>
+              )}
+              {tenancy && (
+                <div className="space-y-2 text-sm">
+                  <div className="font-medium">
+                    Multi-tenancy: {tenancy.label}
+                  </div>
+                  <div style={{ color: "hsl(var(--slate-600))" }}>
+                    {tenancy.summary}
+                  </div>
+                  <ul className="list-disc pl-5 space-y-1">
+                    {tenancy.guidance.map((line) => (
+                      <li key={line}>{line}</li>
+                    ))}
+                  </ul>
+                  {tenancy.evidence.map((e) => (
+                    <div
+                      key={`${e.file}:${e.line}:${e.detail}`}
+                      className="text-xs"
+                      style={{ color: "hsl(var(--slate-500))" }}
+                    >
+                      {e.detail} — <code>{e.file}:{e.line}</code>
+                    </div>
+                  ))}
+                </div>
+              )}
               {totalRelationships === 0 && (
                 <div
                   className="text-center py-8"
@@ -2148,7 +2978,9 @@ function RelationshipsTab({ getAnalysisData }) {
       const fromId = rel.from.replace(/[^a-zA-Z0-9]/g, "_");
       const toId = rel.to.replace(/[^a-zA-Z0-9]/g, "_");
       const linkType = rel.type || "depends on";
-      mermaid += `    ${fromId} --> ${toId}\n`;
+      // Low-confidence relationships (below 0.85) are dashed, as in the server-side graph
+      const arrow = (rel.confidence ?? 1) < 0.85 ? "-.->" : "-->";
+      mermaid += `    ${fromId} ${arrow} ${toId}\n`;
     });
 
     // Add styling
@@ -2202,6 +3034,38 @@ function RelationshipsTab({ getAnalysisData }) {
         </Card>
       )}
 
+      {/* Package Dependency Graph */}
+      {data.packageGraph?.packages?.length > 0 && (
+        <Card>
+          <CardHeader>
+            <CardTitle className="flex items-center gap-2">
+              <Link className="h-5 w-5" />
+              Package Dependency Graph
+            </CardTitle>
+            <CardDescription>{data.packageGraph.summary}</CardDescription>
+          </CardHeader>
+          <CardContent className="space-y-4">
+            <ZoomableMermaid
+              mermaidCode={data.packageGraph.mermaid_graph.replace(/\\n/g, "\n")}
+              title="Package Dependency Graph"
+              className="min-h-80"
+              containerClassName="bg-white"
+              initialZoom={0.9}
+              maxZoom={3.0}
+              minZoom={0.3}
+            />
+            {data.packageGraph.hotspots?.map((hotspot) => (
+              <div
+                key={`${hotspot.package}-${hotspot.direction}`}
+                className="text-xs text-amber-700"
+              >
+                ⚠️ {hotspot.message}
+              </div>
+            ))}
+          </CardContent>
+        </Card>
+      )}
+
       {/* Detailed Relationships List */}
       <Card>
         <CardHeader>
@@ -2288,6 +3152,16 @@ function AnalysisTab({ getAnalysisData }) {
         </CardHeader>
         <CardContent>
           <div className="space-y-6">
+            {data.privacy && (
+              <p className="text-xs text-muted-foreground">
+                🔒 Privacy mode: contents were anonymized before reaching the
+                LLM
+                {data.privacy.restricted_files?.length > 0 &&
+                  `; ${data.privacy.restricted_files.length} restricted files were never sent`}
+                .
+              </p>
+            )}
+
             {projectSummary.purpose && (
               <div>
                 <h3 className="font-medium mb-2">Purpose</h3>
@@ -2652,6 +3526,83 @@ function SecretsTab({ getAnalysisData }) {
         </div>
       )}
 
+      {/* Variables no discovered service reads */}
+      {projectSecrets.unattributed &&
+        projectSecrets.unattributed.length > 0 && (
+          <Card
+            className="border-0 shadow-sm"
+            style={{ backgroundColor: "white" }}
+          >
+            <CardHeader style={{ backgroundColor: "hsl(var(--slate-50))" }}>
+              <CardTitle
+                className="flex items-center gap-3"
+                style={{ color: "hsl(var(--slate-800))" }}
+              >
+                <div
+                  className="p-2 rounded-lg"
+                  style={{ backgroundColor: "hsl(var(--slate-100))" }}
+                >
+                  <Shield
+                    className="h-6 w-6"
+                    style={{ color: "hsl(var(--slate-700))" }}
+                  />
+                </div>
+                Unattributed Variables
+              </CardTitle>
+              <CardDescription>
+                Declared in configuration but not read by any discovered
+                service
+              </CardDescription>
+            </CardHeader>
+            <CardContent className="pt-6">
+              <div className="space-y-4">
+                {projectSecrets.unattributed.map((secret, index) => (
+                  <SecretVariableCard key={index} secret={secret} />
+                ))}
+              </div>
+            </CardContent>
+          </Card>
+        )}
+
+      {/* Variables per deployment environment */}
+      {projectSecrets.environments &&
+        projectSecrets.environments.length > 0 && (
+          <Card
+            className="border-0 shadow-sm"
+            style={{ backgroundColor: "white" }}
+          >
+            <CardHeader style={{ backgroundColor: "hsl(var(--slate-50))" }}>
+              <CardTitle style={{ color: "hsl(var(--slate-800))" }}>
+                Environments
+              </CardTitle>
+              <CardDescription>
+                Needed in every environment:{" "}
+                {(projectSecrets.required_everywhere || []).join(", ") ||
+                  "none"}
+              </CardDescription>
+            </CardHeader>
+            <CardContent className="pt-6">
+              <div className="space-y-3 text-sm">
+                {projectSecrets.environments.map((env) => (
+                  <div key={env.name}>
+                    <div className="flex flex-wrap items-center gap-2">
+                      <Badge variant="outline">{env.name}</Badge>
+                      <span className="text-xs text-muted-foreground">
+                        {env.files.join(", ")}
+                      </span>
+                    </div>
+                    <div className="mt-1">
+                      {env.specific.length > 0
+                        ? `Only here: ${env.specific.join(", ")}`
+                        : "Nothing beyond the shared variables"}
+                    </div>
+                  </div>
+                ))}
+              </div>
+            </CardContent>
+          </Card>
+        )}
+
       {/* Setup Instructions */}
       {totalRequired > 0 && (
         <Card
@@ -2800,6 +3751,7 @@ function SecretVariableCard({ secret }) {
             </p>
             <div className="text-xs" style={{ color: "hsl(var(--slate-500))" }}>
               Source: {secret.source}
+              {secret.origin && ` · ${secret.origin} for ${secret.target_service}`}
             </div>
           </div>
         </div>
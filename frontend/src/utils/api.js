@@ -3,7 +3,7 @@ import axios from "axios";
 // API base configuration
 const API_BASE_URL =
   import.meta.env.VITE_API_URL ||
-  "https://vibe-code-onboard-engineer.yachts/api";
+  "https://vibe-code-onboard-engineer.yachts/api/v1";
 
 const api = axios.create({
   baseURL: API_BASE_URL,
@@ -0,0 +1,275 @@
+package advisories
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"repo-explanation/internal/filestore"
+	"repo-explanation/internal/toolchain"
+)
+
+// Status is why a version is flagged
+type Status string
+
+const (
+	EndOfLife     Status = "end-of-life"    // Past its end-of-life date, or from a line the dataset no longer tracks
+	EndingSoon    Status = "ending-soon"    // Reaches end of life within endingSoonWindow
+	OutdatedMajor Status = "outdated-major" // Supported, but oldMajors or more majors behind the latest
+)
+
+// endingSoonWindow is how far ahead an end-of-life date counts as imminent
+const endingSoonWindow = 180 * 24 * time.Hour
+
+// oldMajors is how many major versions behind the latest a version must be to count as very old
+const oldMajors = 2
+
+// Advisory flags one framework or runtime version the project uses
+type Advisory struct {
+	Product string `json:"product"`         // Dataset name, e.g. spring-boot
+	Label   string `json:"label"`           // Display name, e.g. Spring Boot
+	Version string `json:"version"`         // Version read from the file
+	Cycle   string `json:"cycle,omitempty"` // Release cycle the version belongs to; empty when the dataset has none
+	Latest  string `json:"latest"`          // Newest cycle in the dataset
+	EOL     string `json:"eol,omitempty"`   // End-of-life date of the cycle, YYYY-MM-DD
+	Status  Status `json:"status"`
+	File    string `json:"file"`
+	Service string `json:"service,omitempty"`
+	Message string `json:"message"`
+}
+
+// Report lists the flagged versions, most urgent first
+type Report struct {
+	Advisories     []Advisory `json:"advisories"`
+	Checked        int        `json:"checked"`         // Versions compared against the dataset
+	DatasetUpdated string     `json:"dataset_updated"` // Date the embedded dataset was last updated
+	Summary        string     `json:"summary"`
+}
+
+// Product is one framework or runtime in the dataset, newest cycle first
+type Product struct {
+	Name   string  `json:"name"`
+	Label  string  `json:"label"`
+	Source string  `json:"source"`
+	Note   string  `json:"note,omitempty"`
+	Cycles []Cycle `json:"cycles"`
+}
+
+// Cycle is a release line; an empty EOL means no end-of-life date was announced when the dataset was updated
+type Cycle struct {
+	Cycle string `json:"cycle"`
+	EOL   string `json:"eol"`
+}
+
+// Dataset is the embedded end-of-life table
+type Dataset struct {
+	Updated  string    `json:"updated"`
+	Products []Product `json:"products"`
+}
+
+//go:embed eol.json
+var datasetJSON []byte
+
+var dataset = mustLoad()
+
+func mustLoad() Dataset {
+	var d Dataset
+	if err := json.Unmarshal(datasetJSON, &d); err != nil {
+		panic(fmt.Sprintf("invalid embedded EOL dataset: %v", err))
+	}
+	return d
+}
+
+// Data returns the embedded dataset
+func Data() Dataset {
+	return dataset
+}
+
+// usage is one version of a product found in the project
+type usage struct {
+	product, version, file, service string
+}
+
+// Check reads framework versions from the project's manifests (package.json, requirements files,
+// pyproject.toml, Pipfile, pom.xml, Gradle builds) and runtime versions from the toolchain report,
+// and flags those that are end-of-life, close to it, or several majors behind. owner names the service
+// holding a file.
+func Check(files *filestore.FileStore, owner func(relPath string) string, toolchains *toolchain.Report, now time.Time) *Report {
+	var usages []usage
+	files.Walk(isManifest, func(relPath, content string) error {
+		for _, found := range parseManifest(relPath, content) {
+			found.file = relPath
+			found.service = owner(relPath)
+			usages = append(usages, found)
+		}
+		return nil
+	})
+	if toolchains != nil {
+		for _, tc := range toolchains.Toolchains {
+			for _, source := range tc.Sources {
+				// A minimum such as engines ">=18" says little about the runtime in use, but the go
+				// directive is the language version the module is written against
+				if !source.Exact && tc.Name != "go" {
+					continue
+				}
+				usages = append(usages, usage{tc.Name, source.Version, source.File, source.Service})
+			}
+		}
+	}
+	return evaluate(usages, now)
+}
+
+// evaluate compares every usage against the dataset
+func evaluate(usages []usage, now time.Time) *Report {
+	report := &Report{Advisories: []Advisory{}, DatasetUpdated: dataset.Updated}
+	seen := make(map[usage]bool)
+	for _, u := range usages {
+		if seen[u] {
+			continue
+		}
+		seen[u] = true
+		product := find(u.product)
+		if product == nil || len(product.Cycles) == 0 {
+			continue
+		}
+		report.Checked++
+		if advisory, ok := product.assess(u.version, now); ok {
+			advisory.File = u.file
+			advisory.Service = u.service
+			report.Advisories = append(report.Advisories, advisory)
+		}
+	}
+
+	rank := map[Status]int{EndOfLife: 0, EndingSoon: 1, OutdatedMajor: 2}
+	sort.SliceStable(report.Advisories, func(i, j int) bool {
+		a, b := report.Advisories[i], report.Advisories[j]
+		if rank[a.Status] != rank[b.Status] {
+			return rank[a.Status] < rank[b.Status]
+		}
+		if a.Product != b.Product {
+			return a.Product < b.Product
+		}
+		return a.File < b.File
+	})
+	report.Summary = summarize(report)
+	return report
+}
+
+func find(name string) *Product {
+	for i := range dataset.Products {
+		if dataset.Products[i].Name == name {
+			return &dataset.Products[i]
+		}
+	}
+	return nil
+}
+
+// assess flags a version of the product, returning false when it is current
+func (p *Product) assess(version string, now time.Time) (Advisory, bool) {
+	latest := p.Cycles[0].Cycle
+	advisory := Advisory{Product: p.Name, Label: p.Label, Version: version, Latest: latest}
+	digits := strings.Count(latest, ".") + 1
+	cycleVersion := truncate(version, digits)
+
+	var cycle *Cycle
+	for i := range p.Cycles {
+		if compareVersions(p.Cycles[i].Cycle, cycleVersion) == 0 {
+			cycle = &p.Cycles[i]
+			break
+		}
+	}
+	if cycle == nil {
+		// Newer than the dataset knows about; anything older it leaves out is a line that ended long
+		// ago (Go 1.15, Node 15)
+		if compareVersions(cycleVersion, latest) > 0 {
+			return advisory, false
+		}
+		advisory.Status = EndOfLife
+		advisory.Message = fmt.Sprintf("%s %s is from a release line that is long past end of life; upgrade to %s", p.Label, version, latest)
+		return advisory, true
+	}
+	advisory.Cycle = cycle.Cycle
+	advisory.EOL = cycle.EOL
+
+	if cycle.EOL != "" {
+		if eol, err := time.Parse("2006-01-02", cycle.EOL); err == nil {
+			switch {
+			case !now.Before(eol):
+				advisory.Status = EndOfLife
+				advisory.Message = fmt.Sprintf("%s %s reached end of life on %s; upgrade to %s", p.Label, cycle.Cycle, cycle.EOL, latest)
+				return advisory, true
+			case eol.Sub(now) <= endingSoonWindow:
+				advisory.Status = EndingSoon
+				advisory.Message = fmt.Sprintf("%s %s reaches end of life on %s; plan an upgrade to %s", p.Label, cycle.Cycle, cycle.EOL, latest)
+				return advisory, true
+			}
+		}
+	}
+
+	if behind := major(latest) - major(cycle.Cycle); behind >= oldMajors {
+		advisory.Status = OutdatedMajor
+		advisory.Message = fmt.Sprintf("%s %s is %d major versions behind %s", p.Label, cycle.Cycle, behind, latest)
+		return advisory, true
+	}
+	return advisory, false
+}
+
+func summarize(report *Report) string {
+	if report.Checked == 0 {
+		return "No framework or runtime versions to check"
+	}
+	if len(report.Advisories) == 0 {
+		return fmt.Sprintf("All %d framework and runtime versions are supported (dataset updated %s)", report.Checked, report.DatasetUpdated)
+	}
+	counts := make(map[Status]int)
+	for _, advisory := range report.Advisories {
+		counts[advisory.Status]++
+	}
+	var parts []string
+	for _, status := range []Status{EndOfLife, EndingSoon, OutdatedMajor} {
+		if counts[status] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[status], status))
+		}
+	}
+	return fmt.Sprintf("%d of %d framework and runtime versions need attention: %s (dataset updated %s)",
+		len(report.Advisories), report.Checked, strings.Join(parts, ", "), report.DatasetUpdated)
+}
+
+// truncate keeps the first digits components of a version
+func truncate(version string, digits int) string {
+	parts := strings.Split(version, ".")
+	if len(parts) > digits {
+		parts = parts[:digits]
+	}
+	return strings.Join(parts, ".")
+}
+
+func major(version string) int {
+	n, _ := strconv.Atoi(strings.SplitN(version, ".", 2)[0])
+	return n
+}
+
+// compareVersions compares dotted numeric versions; missing components count as zero
+func compareVersions(a, b string) int {
+	aParts, bParts := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		var x, y int
+		if i < len(aParts) {
+			x, _ = strconv.Atoi(aParts[i])
+		}
+		if i < len(bParts) {
+			y, _ = strconv.Atoi(bParts[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
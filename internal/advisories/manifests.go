@@ -0,0 +1,86 @@
+package advisories
+
+import (
+	"encoding/json"
+	"path"
+	"regexp"
+	"strings"
+)
+
+var (
+	versionRegex = regexp.MustCompile(`\d+(?:\.\d+){0,2}`)
+
+	// django==4.2.7, Django>=3.2,<4, django = "^4.2" (Poetry), django = "==3.2" (Pipfile)
+	djangoRegex = regexp.MustCompile(`(?im)^\s*["']?django["']?\s*(?:\[[^\]]*\])?\s*(=\s*["'])?\s*([=<>~^!]*)\s*(\d+(?:\.\d+){0,2})([^\n"']*)`)
+
+	springBootParentRegex   = regexp.MustCompile(`(?s)<artifactId>spring-boot-(?:starter-parent|dependencies)</artifactId>\s*<version>([^<]+)</version>`)
+	springBootPropertyRegex = regexp.MustCompile(`<spring-boot\.version>([^<]+)</spring-boot\.version>`)
+	springBootPluginRegex   = regexp.MustCompile(`org\.springframework\.boot["']\)?\s+version\s+["']([^"']+)["']`)
+	springBootGradleRegex   = regexp.MustCompile(`(?i)spring_?boot_?version["']?\s*[=:]\s*["']([^"']+)["']`)
+)
+
+// isManifest accepts the files framework versions are read from
+func isManifest(relPath string) bool {
+	if strings.Contains("/"+relPath, "/node_modules/") || strings.Contains("/"+relPath, "/vendor/") {
+		return false
+	}
+	name := path.Base(relPath)
+	switch name {
+	case "package.json", "pyproject.toml", "Pipfile", "pom.xml", "build.gradle", "build.gradle.kts", "gradle.properties":
+		return true
+	}
+	return strings.HasPrefix(name, "requirements") && strings.HasSuffix(name, ".txt")
+}
+
+// parseManifest reads the framework versions one manifest declares
+func parseManifest(relPath, content string) []usage {
+	var found []usage
+	add := func(product, version string) {
+		if version = versionRegex.FindString(version); version != "" {
+			found = append(found, usage{product: product, version: version})
+		}
+	}
+
+	switch name := path.Base(relPath); name {
+	case "package.json":
+		var pkg struct {
+			Dependencies    map[string]string `json:"dependencies"`
+			DevDependencies map[string]string `json:"devDependencies"`
+		}
+		if json.Unmarshal([]byte(content), &pkg) != nil {
+			return nil
+		}
+		constraint, ok := pkg.Dependencies["react"]
+		if !ok {
+			constraint, ok = pkg.DevDependencies["react"]
+		}
+		// "workspace:*", "latest" and git URLs name no version; ">=16" says nothing about the installed major
+		if ok && !strings.Contains(constraint, ":") && !strings.HasPrefix(strings.TrimSpace(constraint), ">") {
+			add("react", constraint)
+		}
+	case "pom.xml":
+		if matches := springBootParentRegex.FindStringSubmatch(content); matches != nil {
+			add("spring-boot", matches[1])
+		} else if matches := springBootPropertyRegex.FindStringSubmatch(content); matches != nil {
+			add("spring-boot", matches[1])
+		}
+	case "build.gradle", "build.gradle.kts", "gradle.properties":
+		if matches := springBootPluginRegex.FindStringSubmatch(content); matches != nil {
+			add("spring-boot", matches[1])
+		} else if matches := springBootGradleRegex.FindStringSubmatch(content); matches != nil {
+			add("spring-boot", matches[1])
+		}
+	default:
+		// requirements*.txt, pyproject.toml, Pipfile
+		for _, matches := range djangoRegex.FindAllStringSubmatch(content, -1) {
+			operator, rest := matches[2], matches[4]
+			// A bare minimum (Django>=3.2) allows any newer release, so only pins and bounded ranges count
+			if strings.HasPrefix(operator, ">") && !strings.Contains(rest, "<") {
+				continue
+			}
+			add("django", matches[3])
+			break
+		}
+	}
+	return found
+}
@@ -0,0 +1,258 @@
+// Package annotations exports a one-line purpose and an importance score for every summarized
+// file, as .repo-analyzer/annotations.json in the analyzed repository, for editor extensions to
+// show as inline hints without talking to the analyzer.
+package annotations
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"repo-explanation/internal/heuristics"
+	internalOpenai "repo-explanation/internal/openai"
+)
+
+// Version is the format version; it changes only when fields are removed or change meaning
+const Version = 1
+
+// Dir and FileName locate the export inside the analyzed repository
+const (
+	Dir      = ".repo-analyzer"
+	FileName = "annotations.json"
+)
+
+// maxPurpose bounds the purpose to what fits in an editor hint
+const maxPurpose = 120
+
+// Annotation is the hint for one file
+type Annotation struct {
+	Purpose    string `json:"purpose"`
+	Importance int    `json:"importance"` // 0-100; see Build
+}
+
+// Export maps repository-relative, slash-separated file paths to their annotations
+type Export struct {
+	Version     int                   `json:"version"`
+	GeneratedAt string                `json:"generated_at"` // RFC 3339
+	Files       map[string]Annotation `json:"files"`
+}
+
+// Signals are what the analysis knows about files beyond their summaries
+type Signals struct {
+	EntryPoints []string        // Service entry points
+	Unused      map[string]bool // Files, or directories of packages, nothing appears to use
+	Skip        map[string]bool // Generated, vendored and restricted files, left out of the export
+}
+
+// Build scores each summarized file from 0 to 100: 10 to start, up to 40 for the files importing
+// it (logarithmically, so 31 importers reach the maximum), 25 for a service entry point, 15 or 8 for
+// high or medium complexity, up to 10 for the types and functions it defines and 5 for side
+// effects. Files nothing appears to use are capped at 10.
+func Build(summaries map[string]*internalOpenai.FileSummary, signals Signals) *Export {
+	export := &Export{Version: Version, GeneratedAt: time.Now().UTC().Format(time.RFC3339), Files: make(map[string]Annotation)}
+	fanIn := importers(summaries)
+	entryPoints := make(map[string]bool, len(signals.EntryPoints))
+	for _, entry := range signals.EntryPoints {
+		entryPoints[filepath.ToSlash(entry)] = true
+	}
+
+	for filePath, summary := range summaries {
+		rel := filepath.ToSlash(filePath)
+		if summary == nil || signals.Skip[rel] {
+			continue
+		}
+		score := 10.0
+		score += math.Min(40, 8*math.Log2(1+float64(fanIn[rel])))
+		if entryPoints[rel] {
+			score += 25
+		}
+		switch summary.Complexity {
+		case "high":
+			score += 15
+		case "medium":
+			score += 8
+		}
+		score += math.Min(10, float64(len(summary.KeyTypes)+len(summary.Functions)))
+		if len(summary.SideEffects) > 0 {
+			score += 5
+		}
+		if signals.Unused[rel] || signals.Unused[path.Dir(rel)] {
+			score = math.Min(score, 10)
+		}
+		export.Files[rel] = Annotation{Purpose: oneLine(summary.Purpose), Importance: int(math.Min(100, math.Round(score)))}
+	}
+	return export
+}
+
+// importers counts, for each file, the other files whose imports name it or its directory
+func importers(summaries map[string]*internalOpenai.FileSummary) map[string]int {
+	// Every trailing run of path segments of a file, with and without its extension, and of its
+	// directory, which Go imports name
+	bySuffix := make(map[string][]string)
+	for filePath := range summaries {
+		rel := filepath.ToSlash(filePath)
+		keys := map[string]bool{}
+		for _, target := range []string{strings.TrimSuffix(rel, path.Ext(rel)), path.Dir(rel)} {
+			segments := strings.Split(target, "/")
+			for i := range segments {
+				if suffix := strings.Join(segments[i:], "/"); suffix != "." && suffix != "" {
+					keys[suffix] = true
+				}
+			}
+		}
+		for key := range keys {
+			bySuffix[key] = append(bySuffix[key], rel)
+		}
+	}
+
+	counts := make(map[string]int)
+	for filePath, summary := range summaries {
+		if summary == nil {
+			continue
+		}
+		from := filepath.ToSlash(filePath)
+		seen := map[string]bool{from: true}
+		for _, imp := range summary.Imports {
+			for _, key := range importKeys(imp) {
+				for _, target := range bySuffix[key] {
+					if !seen[target] {
+						seen[target] = true
+						counts[target]++
+					}
+				}
+			}
+		}
+	}
+	return counts
+}
+
+// importKeys normalizes an import as written ("./utils/format", "@/lib/api", "app.models.user",
+// "example.com/mod/internal/store") to the path suffixes it may name. Bare single names such as
+// "fmt" or "react" name no file.
+func importKeys(imp string) []string {
+	imp = strings.Trim(strings.TrimSpace(imp), `"'`)
+	for _, prefix := range []string{"@/", "~/", "./"} {
+		imp = strings.TrimPrefix(imp, prefix)
+	}
+	for strings.HasPrefix(imp, "../") {
+		imp = strings.TrimPrefix(imp, "../")
+	}
+	if imp == "" {
+		return nil
+	}
+	if !strings.Contains(imp, "/") {
+		if !strings.Contains(imp, ".") {
+			return nil
+		}
+		imp = strings.ReplaceAll(strings.TrimLeft(imp, "."), ".", "/") // Python modules
+	}
+	keys := []string{strings.TrimSuffix(imp, path.Ext(imp))}
+	// Module-qualified imports name the repository path after the module: try every suffix of two
+	// or more segments
+	segments := strings.Split(imp, "/")
+	for i := 1; i+2 <= len(segments); i++ {
+		keys = append(keys, strings.Join(segments[i:], "/"))
+	}
+	return keys
+}
+
+// oneLine returns the first sentence of a purpose, without the offline marker, within maxPurpose
+func oneLine(purpose string) string {
+	purpose = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(purpose), heuristics.OfflineMarker))
+	if i := strings.IndexAny(purpose, "\r\n"); i >= 0 {
+		purpose = purpose[:i]
+	}
+	if i := strings.Index(purpose, ". "); i >= 0 {
+		purpose = purpose[:i+1]
+	}
+	if len(purpose) > maxPurpose {
+		cut := strings.LastIndex(purpose[:maxPurpose], " ")
+		if cut <= 0 {
+			cut = maxPurpose
+		}
+		purpose = strings.TrimRight(purpose[:cut], " ,;:") + "…"
+	}
+	return purpose
+}
+
+// Marshal encodes the export with one file per line, in path order, so it stays small, diffs
+// line by line and can be read by a streaming parser
+func (e *Export) Marshal() ([]byte, error) {
+	paths := make([]string, 0, len(e.Files))
+	for filePath := range e.Files {
+		paths = append(paths, filePath)
+	}
+	sort.Strings(paths)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "{\"version\":%d,\"generated_at\":%q,\"files\":{", e.Version, e.GeneratedAt)
+	for i, filePath := range paths {
+		key, err := json.Marshal(filePath)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(e.Files[filePath])
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString("\n")
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteString("\n}}\n")
+	return buf.Bytes(), nil
+}
+
+// Path returns where the export of a repository is written
+func Path(projectPath string) string {
+	return filepath.Join(projectPath, Dir, FileName)
+}
+
+// Write saves the export in the repository, replacing the previous one atomically so an editor
+// never reads half a file
+func (e *Export) Write(projectPath string) (string, error) {
+	content, err := e.Marshal()
+	if err != nil {
+		return "", fmt.Errorf("failed to encode annotations: %v", err)
+	}
+	target := Path(projectPath)
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %v", Dir, err)
+	}
+	tmp := target + ".tmp"
+	if err := os.WriteFile(tmp, content, 0644); err != nil {
+		return "", fmt.Errorf("failed to write annotations: %v", err)
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		os.Remove(tmp)
+		return "", fmt.Errorf("failed to write annotations: %v", err)
+	}
+	return target, nil
+}
+
+// Read loads the export of a repository
+func Read(projectPath string) (*Export, error) {
+	content, err := os.ReadFile(Path(projectPath))
+	if err != nil {
+		return nil, err
+	}
+	var export Export
+	if err := json.Unmarshal(content, &export); err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", FileName, err)
+	}
+	if export.Version > Version {
+		return nil, fmt.Errorf("%s has format version %d; this build reads up to %d", FileName, export.Version, Version)
+	}
+	return &export, nil
+}
@@ -0,0 +1,211 @@
+// Package archmap turns the flat folder summaries of an analysis into an architecture map: a tree
+// of folders with their purpose, exported as JSON and as a Mermaid mindmap.
+package archmap
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"repo-explanation/internal/mermaid"
+	internalOpenai "repo-explanation/internal/openai"
+	"repo-explanation/internal/portable"
+)
+
+// rootKey is the folder summary key of the files at the top of the repository
+const rootKey = "root"
+
+// DefaultMindmapDepth is how many folder levels the mindmap in the analysis result shows
+const DefaultMindmapDepth = 3
+
+// Node is one folder of the map
+type Node struct {
+	Path         string         `json:"path"` // Relative to the project root; "." for the root
+	Name         string         `json:"name"` // Path below the parent node
+	Purpose      string         `json:"purpose,omitempty"`
+	Architecture string         `json:"architecture,omitempty"`
+	KeyModules   []string       `json:"key_modules,omitempty"`
+	Languages    map[string]int `json:"languages,omitempty"`
+	Files        int            `json:"files"`      // Files summarized directly in this folder
+	Summarized   bool           `json:"summarized"` // False for folders added only to connect the tree
+	Children     []*Node        `json:"children,omitempty"`
+}
+
+// Map is the architecture map of a project
+type Map struct {
+	Root    *Node  `json:"root"`
+	Folders int    `json:"folders"` // Summarized folders in the tree
+	Mindmap string `json:"mindmap"` // Mermaid mindmap of the top DefaultMindmapDepth levels
+}
+
+// Build arranges folder summaries into a tree under the project root. Each folder hangs under its
+// nearest summarized ancestor, so pass-through directories such as src/main/java do not add levels.
+// It returns nil when there are no folder summaries.
+func Build(projectName string, folders map[string]*internalOpenai.FolderSummary) *Map {
+	if len(folders) == 0 {
+		return nil
+	}
+
+	nodes := make(map[string]*Node, len(folders))
+	for key, summary := range folders {
+		if summary == nil {
+			continue
+		}
+		p := portable.Slash(key)
+		if key == rootKey {
+			p = "."
+		}
+		nodes[p] = &Node{
+			Path:         p,
+			Purpose:      strings.TrimSpace(summary.Purpose),
+			Architecture: strings.TrimSpace(summary.Architecture),
+			KeyModules:   summary.KeyModules,
+			Languages:    summary.Languages,
+			Files:        len(summary.FileSummaries),
+			Summarized:   true,
+		}
+	}
+	if len(nodes) == 0 {
+		return nil
+	}
+
+	root, ok := nodes["."]
+	if !ok {
+		root = &Node{Path: "."}
+		nodes["."] = root
+	}
+	root.Name = projectName
+
+	paths := make([]string, 0, len(nodes))
+	for p := range nodes {
+		if p != "." {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		parent := path.Dir(p)
+		for parent != "." && nodes[parent] == nil {
+			parent = path.Dir(parent)
+		}
+		// Name a folder by its path below its parent node, so collapsed directories stay visible
+		nodes[p].Name = strings.TrimPrefix(p, parent+"/")
+		nodes[parent].Children = append(nodes[parent].Children, nodes[p])
+	}
+
+	m := &Map{Root: root, Folders: len(folders)}
+	m.Mindmap = m.Root.Mindmap(DefaultMindmapDepth)
+	return m
+}
+
+// maxPurpose bounds the purpose shown next to a folder name in the mindmap
+const maxPurpose = 60
+
+// Mindmap renders the tree below n as a Mermaid mindmap, showing depth levels of folders (0 for
+// all). Deeper folders are collapsed into a "+N more" node under their ancestor, so a renderer
+// can expand them from the JSON tree on demand.
+func (n *Node) Mindmap(depth int) string {
+	var b strings.Builder
+	b.WriteString("mindmap\n")
+	fmt.Fprintf(&b, "  %s((%s))\n", mermaid.ID("root_"+n.Name), mindmapText(n.Name))
+	for _, child := range n.Children {
+		writeMindmapNode(&b, child, 2, depth)
+	}
+	return b.String()
+}
+
+func writeMindmapNode(b *strings.Builder, n *Node, level, depth int) {
+	indent := strings.Repeat("  ", level)
+	label := mindmapText(n.Name)
+	if purpose := n.Brief(); purpose != "" {
+		label += ": " + mindmapText(purpose)
+	}
+	fmt.Fprintf(b, "%s%s[%s]\n", indent, mermaid.ID("folder_"+n.Path), label)
+
+	if len(n.Children) == 0 {
+		return
+	}
+	if depth > 0 && level-1 >= depth {
+		hidden := n.Descendants()
+		fmt.Fprintf(b, "%s  %s(+%d more %s)\n", indent, mermaid.ID("more_"+n.Path), hidden, plural(hidden, "folder"))
+		return
+	}
+	for _, child := range n.Children {
+		writeMindmapNode(b, child, level+1, depth)
+	}
+}
+
+// Descendants counts the folders below n
+func (n *Node) Descendants() int {
+	count := 0
+	for _, child := range n.Children {
+		count += 1 + child.Descendants()
+	}
+	return count
+}
+
+// Brief is the first sentence of the folder's purpose, cut at a word boundary
+func (n *Node) Brief() string {
+	purpose := n.Purpose
+	if i := strings.Index(purpose, ". "); i >= 0 {
+		purpose = purpose[:i]
+	}
+	purpose = strings.TrimSuffix(strings.TrimSpace(purpose), ".")
+	if len(purpose) <= maxPurpose {
+		return purpose
+	}
+	cut := purpose[:maxPurpose]
+	if i := strings.LastIndex(cut, " "); i > maxPurpose/2 {
+		cut = cut[:i]
+	}
+	return cut + "…"
+}
+
+// mindmapReplacer drops the characters mindmap node text cannot hold: its shape delimiters
+var mindmapReplacer = strings.NewReplacer(
+	"(", " ", ")", " ", "[", " ", "]", " ", "{", " ", "}", " ", `"`, "'", "\n", " ", "\r", " ", "\t", " ",
+)
+
+func mindmapText(text string) string {
+	return strings.Join(strings.Fields(mindmapReplacer.Replace(text)), " ")
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
+}
+
+// Export writes the map as JSON and a complete (uncollapsed) Mermaid mindmap into dir
+func (m *Map) Export(dir string) ([]string, error) {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode architecture map: %v", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %v", err)
+	}
+	base := strings.Trim(mermaid.ID(m.Root.Name), "_")
+	outputs := []struct {
+		name    string
+		content []byte
+	}{
+		{base + "_architecture_map.json", data},
+		{base + "_architecture_map.mmd", []byte(m.Root.Mindmap(0))},
+	}
+
+	var paths []string
+	for _, output := range outputs {
+		p := filepath.Join(dir, output.name)
+		if err := os.WriteFile(p, output.content, 0644); err != nil {
+			return paths, fmt.Errorf("failed to write %s: %v", p, err)
+		}
+		paths = append(paths, p)
+	}
+	return paths, nil
+}
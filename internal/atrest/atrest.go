@@ -0,0 +1,181 @@
+// Package atrest encrypts the caches and stored results the analyzer writes to disk, which hold
+// source snippets and summaries, with AES-256-GCM when encryption is enabled in the config. A nil
+// *Sealer leaves data as it is, so callers seal and open unconditionally.
+package atrest
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"repo-explanation/config"
+)
+
+// magic starts every sealed file, so files written before encryption was enabled still read
+var magic = []byte("RXAES1\n")
+
+// keyCommandTimeout bounds the key command, which usually calls a KMS over the network
+const keyCommandTimeout = 30 * time.Second
+
+// Sealer encrypts and decrypts with one data key
+type Sealer struct {
+	aead cipher.AEAD
+	err  error // Set when the key could not be loaded; every Seal and Open fails with it
+}
+
+var (
+	sealersMu sync.Mutex
+	sealers   = make(map[config.EncryptionConfig]*Sealer)
+)
+
+// For returns the sealer of the config's encryption settings, nil when encryption is off. The key
+// is loaded once per process; when it cannot be, the sealer fails every call (see Err) rather
+// than letting anything be written in the clear.
+func For(cfg *config.Config) *Sealer {
+	if cfg == nil || !cfg.Encryption.Enabled {
+		return nil
+	}
+	sealersMu.Lock()
+	defer sealersMu.Unlock()
+	if sealer, ok := sealers[cfg.Encryption]; ok {
+		return sealer
+	}
+	key, err := loadKey(cfg.Encryption)
+	var sealer *Sealer
+	if err == nil {
+		sealer, err = New(key)
+	}
+	if err != nil {
+		sealer = &Sealer{err: fmt.Errorf("encryption key unavailable: %v", err)}
+	}
+	sealers[cfg.Encryption] = sealer
+	return sealer
+}
+
+// New creates a sealer from a base64-encoded 32-byte key
+func New(key string) (*Sealer, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(key))
+	if err != nil {
+		return nil, fmt.Errorf("key is not base64: %v", err)
+	}
+	if len(raw) != 32 {
+		return nil, fmt.Errorf("key must be 32 bytes for AES-256, got %d", len(raw))
+	}
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Sealer{aead: aead}, nil
+}
+
+// loadKey reads the key from the config, a file or the stdout of a command
+func loadKey(cfg config.EncryptionConfig) (string, error) {
+	switch {
+	case cfg.Key != "":
+		return cfg.Key, nil
+	case cfg.KeyFile != "":
+		data, err := os.ReadFile(cfg.KeyFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read key file: %v", err)
+		}
+		return string(data), nil
+	case cfg.KeyCommand != "":
+		ctx, cancel := context.WithTimeout(context.Background(), keyCommandTimeout)
+		defer cancel()
+		var stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, "sh", "-c", cfg.KeyCommand)
+		cmd.Stderr = &stderr
+		output, err := cmd.Output()
+		if err != nil {
+			return "", fmt.Errorf("key command failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		return string(output), nil
+	}
+	return "", fmt.Errorf("no key configured")
+}
+
+// Err reports why the sealer cannot encrypt; nil for a working or nil sealer
+func (s *Sealer) Err() error {
+	if s == nil {
+		return nil
+	}
+	return s.err
+}
+
+// Seal encrypts data under a fresh random nonce
+func (s *Sealer) Seal(data []byte) ([]byte, error) {
+	if s == nil {
+		return data, nil
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %v", err)
+	}
+	sealed := make([]byte, 0, len(magic)+len(nonce)+len(data)+s.aead.Overhead())
+	sealed = append(sealed, magic...)
+	sealed = append(sealed, nonce...)
+	return s.aead.Seal(sealed, nonce, data, nil), nil
+}
+
+// Open decrypts sealed data. Data that was never sealed is returned as it is, so caches written
+// before encryption was enabled keep working.
+func (s *Sealer) Open(data []byte) ([]byte, error) {
+	if !IsSealed(data) {
+		return data, nil
+	}
+	if s == nil {
+		return nil, fmt.Errorf("data is encrypted; enable encryption with its key to read it")
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	data = data[len(magic):]
+	if len(data) < s.aead.NonceSize() {
+		return nil, fmt.Errorf("encrypted data is truncated")
+	}
+	nonce, ciphertext := data[:s.aead.NonceSize()], data[s.aead.NonceSize():]
+	plain, err := s.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt (wrong key or corrupted data)")
+	}
+	return plain, nil
+}
+
+// IsSealed reports whether data was written by Seal
+func IsSealed(data []byte) bool {
+	return bytes.HasPrefix(data, magic)
+}
+
+// ReadFile reads and opens a file
+func (s *Sealer) ReadFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return s.Open(data)
+}
+
+// WriteFile seals data and writes it to a file
+func (s *Sealer) WriteFile(path string, data []byte, perm os.FileMode) error {
+	sealed, err := s.Seal(data)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, sealed, perm)
+}
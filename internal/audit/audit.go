@@ -0,0 +1,273 @@
+// Package audit records every LLM request and response to an append-only JSONL file, with
+// secrets redacted, so a run's prompts can be inspected or replayed later. With encryption at rest
+// enabled each line is sealed on its own and stored base64-encoded, so the file stays append-only.
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"repo-explanation/internal/atrest"
+)
+
+// Message is one chat message as sent to the model
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Entry is one LLM call
+type Entry struct {
+	Time             time.Time `json:"time"`
+	Run              string    `json:"run"`
+	Label            string    `json:"label,omitempty"` // What the run analyzed
+	Call             int       `json:"call"`            // 1-based position within the run
+	Model            string    `json:"model"`
+	Temperature      float32   `json:"temperature"`
+	MaxTokens        int       `json:"max_tokens,omitempty"`
+	ResponseFormat   string    `json:"response_format,omitempty"`
+	Messages         []Message `json:"messages"`
+	Response         string    `json:"response,omitempty"`
+	FinishReason     string    `json:"finish_reason,omitempty"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	TotalTokens      int       `json:"total_tokens"`
+	LatencyMS        int64     `json:"latency_ms"`
+	Cached           bool      `json:"cached,omitempty"` // Answered from the prompt cache without reaching the API
+	Error            string    `json:"error,omitempty"`
+}
+
+// writeMu serializes appends from every log in the process, so concurrent analyses sharing a
+// file never interleave lines
+var writeMu sync.Mutex
+
+// Log appends the calls of one run at a time to a JSONL file
+type Log struct {
+	path    string
+	sealer  *atrest.Sealer // Encrypts each line when encryption is enabled
+	secrets []string       // Exact values always masked, such as the API key
+
+	mu    sync.Mutex
+	run   string
+	label string
+	calls int
+}
+
+// NewLog returns a log appending to path, sealing each line with sealer (nil writes them as they
+// are). Every value in secrets is masked wherever it appears.
+func NewLog(path string, sealer *atrest.Sealer, secrets ...string) *Log {
+	log := &Log{path: path, sealer: sealer}
+	for _, secret := range secrets {
+		if len(secret) >= 8 {
+			log.secrets = append(log.secrets, secret)
+		}
+	}
+	log.StartRun("")
+	return log
+}
+
+// StartRun begins a new run; later calls are numbered from 1 under a fresh run ID, which is returned
+func (l *Log) StartRun(label string) string {
+	suffix := make([]byte, 3)
+	rand.Read(suffix)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.run = time.Now().UTC().Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+	l.label = label
+	l.calls = 0
+	return l.run
+}
+
+// Run returns the current run ID
+func (l *Log) Run() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.run
+}
+
+// Record redacts an entry, stamps it with the run and appends it to the file. A log that cannot be
+// written is reported and the analysis carries on.
+func (l *Log) Record(entry Entry) {
+	l.mu.Lock()
+	l.calls++
+	entry.Run, entry.Label, entry.Call = l.run, l.label, l.calls
+	l.mu.Unlock()
+
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+	for i := range entry.Messages {
+		entry.Messages[i].Content = l.redact(entry.Messages[i].Content)
+	}
+	entry.Response = l.redact(entry.Response)
+	entry.Error = l.redact(entry.Error)
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		fmt.Printf("⚠️  Failed to encode LLM audit entry: %v\n", err)
+		return
+	}
+	if l.sealer != nil {
+		sealed, err := l.sealer.Seal(line)
+		if err != nil {
+			fmt.Printf("⚠️  Failed to encrypt LLM audit entry: %v\n", err)
+			return
+		}
+		line = []byte(base64.StdEncoding.EncodeToString(sealed))
+	}
+	line = append(line, '\n')
+
+	writeMu.Lock()
+	defer writeMu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		fmt.Printf("⚠️  Failed to write LLM audit log: %v\n", err)
+		return
+	}
+	file, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		fmt.Printf("⚠️  Failed to write LLM audit log: %v\n", err)
+		return
+	}
+	defer file.Close()
+	if _, err := file.Write(line); err != nil {
+		fmt.Printf("⚠️  Failed to write LLM audit log: %v\n", err)
+	}
+}
+
+func (l *Log) redact(text string) string {
+	for _, secret := range l.secrets {
+		text = strings.ReplaceAll(text, secret, redacted)
+	}
+	return Redact(text)
+}
+
+// Read loads every entry of an audit log, in the order they were written. Sealed lines are opened
+// with sealer; lines written before encryption was enabled read as they are.
+func Read(path string, sealer *atrest.Sealer) ([]Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 1024*1024), 256*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		data := bytes.TrimSpace(scanner.Bytes())
+		if data[0] != '{' {
+			sealed, err := base64.StdEncoding.DecodeString(string(data))
+			if err != nil {
+				return entries, fmt.Errorf("%s line %d: %v", path, line, err)
+			}
+			if data, err = sealer.Open(sealed); err != nil {
+				return entries, fmt.Errorf("%s line %d: %v", path, line, err)
+			}
+		}
+		var entry Entry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return entries, fmt.Errorf("%s line %d: %v", path, line, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return entries, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	return entries, nil
+}
+
+// RunSummary totals the calls of one run
+type RunSummary struct {
+	Run              string    `json:"run"`
+	Label            string    `json:"label,omitempty"`
+	Started          time.Time `json:"started"`
+	Calls            int       `json:"calls"`
+	Cached           int       `json:"cached"`
+	Errors           int       `json:"errors"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	LatencyMS        int64     `json:"latency_ms"` // Summed over calls; concurrent calls overlap
+}
+
+// Runs summarizes the runs in a log, oldest first
+func Runs(entries []Entry) []RunSummary {
+	index := make(map[string]int)
+	var runs []RunSummary
+	for _, entry := range entries {
+		i, ok := index[entry.Run]
+		if !ok {
+			i = len(runs)
+			index[entry.Run] = i
+			runs = append(runs, RunSummary{Run: entry.Run, Label: entry.Label, Started: entry.Time})
+		}
+		run := &runs[i]
+		run.Calls++
+		if entry.Cached {
+			run.Cached++
+		}
+		if entry.Error != "" {
+			run.Errors++
+		}
+		run.PromptTokens += entry.PromptTokens
+		run.CompletionTokens += entry.CompletionTokens
+		run.LatencyMS += entry.LatencyMS
+		if entry.Time.Before(run.Started) {
+			run.Started = entry.Time
+		}
+	}
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].Started.Before(runs[j].Started) })
+	return runs
+}
+
+// Calls returns the entries of one run in call order. run may be "last" or a unique prefix of a run ID.
+func Calls(entries []Entry, run string) (string, []Entry, error) {
+	runs := Runs(entries)
+	if len(runs) == 0 {
+		return "", nil, fmt.Errorf("the audit log has no runs")
+	}
+
+	var matched []string
+	if run == "last" {
+		matched = []string{runs[len(runs)-1].Run}
+	} else {
+		for _, summary := range runs {
+			if summary.Run == run {
+				matched = []string{run}
+				break
+			}
+			if strings.HasPrefix(summary.Run, run) {
+				matched = append(matched, summary.Run)
+			}
+		}
+	}
+	switch {
+	case len(matched) == 0:
+		return "", nil, fmt.Errorf("no run %q in the audit log", run)
+	case len(matched) > 1:
+		return "", nil, fmt.Errorf("run %q is ambiguous: %s", run, strings.Join(matched, ", "))
+	}
+
+	var calls []Entry
+	for _, entry := range entries {
+		if entry.Run == matched[0] {
+			calls = append(calls, entry)
+		}
+	}
+	sort.SliceStable(calls, func(i, j int) bool { return calls[i].Call < calls[j].Call })
+	return matched[0], calls, nil
+}
@@ -0,0 +1,46 @@
+package audit
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"repo-explanation/internal/atrest"
+)
+
+func TestRecordSealsEachLine(t *testing.T) {
+	sealer, err := atrest.New(base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	// A line written before encryption was enabled stays readable after it
+	NewLog(path, nil).Record(Entry{Model: "plain", Messages: []Message{{Role: "user", Content: "func legacy()"}}})
+	NewLog(path, sealer).Record(Entry{Model: "sealed", Messages: []Message{{Role: "user", Content: "func handleOrder()"}}})
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "handleOrder") {
+		t.Errorf("sealed entry is stored in the clear:\n%s", data)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+
+	entries, err := Read(path, sealer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Model != "plain" || entries[1].Model != "sealed" || entries[1].Messages[0].Content != "func handleOrder()" {
+		t.Errorf("Read = %+v", entries)
+	}
+
+	if _, err := Read(path, nil); err == nil {
+		t.Error("Read without the key succeeded on a sealed line")
+	}
+}
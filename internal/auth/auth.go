@@ -0,0 +1,251 @@
+package auth
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"repo-explanation/internal/filestore"
+	"repo-explanation/internal/microservices"
+	"repo-explanation/internal/portable"
+)
+
+// Evidence is one line showing how a service authenticates or authorizes requests
+type Evidence struct {
+	Mechanism string `json:"mechanism"`
+	Detail    string `json:"detail"` // Library or idiom, e.g. "echo JWT middleware"
+	File      string `json:"file"`   // Relative to the project root
+	Line      int    `json:"line"`
+	Enforces  bool   `json:"enforces"` // Rejects requests, as opposed to issuing credentials
+}
+
+// Mechanism is one way a service authenticates or authorizes requests
+type Mechanism struct {
+	Name     string     `json:"name"` // jwt, oauth, session, api_key, basic, rbac or custom
+	Label    string     `json:"label"`
+	Details  []string   `json:"details"`
+	Evidence []Evidence `json:"evidence"`
+}
+
+// ServiceAuth is the auth model of one service
+type ServiceAuth struct {
+	Service           string      `json:"service"` // Empty for files outside every discovered service
+	Mechanisms        []Mechanism `json:"mechanisms"`
+	EnforcementPoints []Evidence  `json:"enforcement_points"` // Files where requests are checked
+	Model             string      `json:"model"`
+}
+
+// Report is the authentication and authorization surface of a project
+type Report struct {
+	Services []ServiceAuth `json:"services"`
+	Summary  string        `json:"summary"`
+}
+
+// Directories that never hold first-party code
+var skippedDirs = map[string]bool{
+	".git": true, "node_modules": true, "vendor": true, "testdata": true, "dist": true, "build": true,
+	".next": true, "coverage": true, "__pycache__": true, ".venv": true, "venv": true,
+	"test": true, "tests": true, "__tests__": true, "spec": true,
+}
+
+// maxEvidence bounds the lines kept per mechanism and service
+const maxEvidence = 25
+
+var sourceExtensions = map[string]bool{
+	".go": true, ".js": true, ".jsx": true, ".ts": true, ".tsx": true, ".mjs": true, ".cjs": true,
+	".py": true, ".rb": true, ".java": true, ".kt": true, ".cs": true, ".php": true, ".rs": true,
+}
+
+// Analyze scans the project's source for JWT middleware, OAuth flows, session cookies, API keys,
+// HTTP Basic, role checks and homegrown auth middleware, and groups what it finds by the service
+// whose directory holds each file
+func Analyze(files *filestore.FileStore, services []microservices.DiscoveredService) (*Report, error) {
+	fmt.Printf("🔐 [DEBUG] Analyzing auth surface of %d files\n", files.Len())
+
+	found := make(map[string][]Evidence) // Service -> evidence
+	err := files.Walk(func(rel string) bool {
+		name := path.Base(rel)
+		return !filestore.InDirs(rel, skippedDirs) && sourceExtensions[path.Ext(name)] && !isTestFile(name)
+	}, func(rel, content string) error {
+		service := microservices.OwningService(path.Dir(rel), services)
+		found[service] = append(found[service], scanFile(rel, content)...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan for auth mechanisms: %v", err)
+	}
+
+	report := &Report{Services: []ServiceAuth{}}
+	for _, service := range services {
+		if _, ok := found[service.Name]; !ok {
+			found[service.Name] = nil
+		}
+	}
+	for _, name := range sortedKeys(found) {
+		evidence := found[name]
+		if name == "" && len(evidence) == 0 {
+			continue
+		}
+		report.Services = append(report.Services, buildServiceAuth(name, evidence))
+	}
+	report.Summary = summarize(report)
+	fmt.Printf("✅ [DEBUG] %s\n", report.Summary)
+	return report, nil
+}
+
+// scanFile matches every rule against the file's code lines; each rule counts once per line
+func scanFile(rel, content string) []Evidence {
+	var evidence []Evidence
+	for i, line := range portable.Lines(content) {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "*") {
+			continue
+		}
+		// Pattern definitions (such as this package's rules) name libraries without using them
+		if strings.Contains(line, "regexp.MustCompile(") || strings.Contains(line, "re.compile(") || strings.Contains(line, "new RegExp(") {
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, r := range rules {
+			if seen[r.mechanism+r.detail] || !r.pattern.MatchString(line) {
+				continue
+			}
+			seen[r.mechanism+r.detail] = true
+			evidence = append(evidence, Evidence{
+				Mechanism: r.mechanism,
+				Detail:    r.detail,
+				File:      rel,
+				Line:      i + 1,
+				Enforces:  r.enforces,
+			})
+		}
+	}
+	return evidence
+}
+
+// buildServiceAuth groups a service's evidence by mechanism and describes its auth model
+func buildServiceAuth(service string, evidence []Evidence) ServiceAuth {
+	auth := ServiceAuth{Service: service, Mechanisms: []Mechanism{}, EnforcementPoints: []Evidence{}}
+
+	byMechanism := make(map[string]*Mechanism)
+	enforcedFiles := make(map[string]bool)
+	for _, e := range evidence {
+		mechanism, ok := byMechanism[e.Mechanism]
+		if !ok {
+			mechanism = &Mechanism{Name: e.Mechanism, Label: mechanismLabels[e.Mechanism]}
+			byMechanism[e.Mechanism] = mechanism
+		}
+		if !contains(mechanism.Details, e.Detail) {
+			mechanism.Details = append(mechanism.Details, e.Detail)
+		}
+		if len(mechanism.Evidence) < maxEvidence {
+			mechanism.Evidence = append(mechanism.Evidence, e)
+		}
+		// One enforcement point per file and mechanism keeps the list readable
+		key := e.File + "\x00" + e.Mechanism
+		if e.Enforces && !enforcedFiles[key] {
+			enforcedFiles[key] = true
+			auth.EnforcementPoints = append(auth.EnforcementPoints, e)
+		}
+	}
+	for _, name := range mechanismOrder {
+		if mechanism, ok := byMechanism[name]; ok {
+			auth.Mechanisms = append(auth.Mechanisms, *mechanism)
+		}
+	}
+
+	auth.Model = describeModel(auth)
+	return auth
+}
+
+// describeModel is a one-line account of how the service authenticates and authorizes requests
+func describeModel(auth ServiceAuth) string {
+	if len(auth.Mechanisms) == 0 {
+		return "No authentication found; requests appear to be unauthenticated or authenticated upstream (gateway, proxy)"
+	}
+
+	var authn, authz []string
+	for _, mechanism := range auth.Mechanisms {
+		part := fmt.Sprintf("%s (%s)", mechanism.Label, strings.Join(mechanism.Details, ", "))
+		if mechanism.Name == RBAC {
+			authz = append(authz, part)
+		} else {
+			authn = append(authn, part)
+		}
+	}
+
+	var parts []string
+	if len(authn) > 0 {
+		parts = append(parts, "Authenticates with "+strings.Join(authn, "; "))
+	} else {
+		parts = append(parts, "No authentication mechanism found")
+	}
+	if len(authz) > 0 {
+		parts = append(parts, "authorizes with "+strings.Join(authz, "; "))
+	}
+
+	files := make(map[string]bool)
+	for _, point := range auth.EnforcementPoints {
+		files[point.File] = true
+	}
+	if len(files) == 0 {
+		parts = append(parts, "no enforcement point found (credentials are issued or configured but never checked here)")
+	} else {
+		parts = append(parts, fmt.Sprintf("enforced in %d files", len(files)))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func summarize(report *Report) string {
+	if len(report.Services) == 0 {
+		return "No authentication or authorization found"
+	}
+	counts := make(map[string]int)
+	unprotected := 0
+	for _, service := range report.Services {
+		if len(service.Mechanisms) == 0 {
+			unprotected++
+		}
+		for _, mechanism := range service.Mechanisms {
+			counts[mechanism.Name]++
+		}
+	}
+	var parts []string
+	for _, name := range mechanismOrder {
+		if counts[name] > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d", mechanismLabels[name], counts[name]))
+		}
+	}
+	summary := fmt.Sprintf("Auth surface of %d services", len(report.Services))
+	if len(parts) > 0 {
+		summary += ": " + strings.Join(parts, ", ")
+	}
+	if unprotected > 0 {
+		summary += fmt.Sprintf("; %d without authentication", unprotected)
+	}
+	return summary
+}
+
+func isTestFile(name string) bool {
+	return strings.HasSuffix(name, "_test.go") || strings.Contains(name, ".test.") ||
+		strings.Contains(name, ".spec.") || strings.HasPrefix(name, "test_")
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string][]Evidence) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
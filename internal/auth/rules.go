@@ -0,0 +1,96 @@
+package auth
+
+import "regexp"
+
+// Mechanisms, in the order the report lists them
+const (
+	JWT     = "jwt"
+	OAuth   = "oauth"
+	Session = "session"
+	APIKey  = "api_key"
+	Basic   = "basic"
+	RBAC    = "rbac"
+	Custom  = "custom"
+)
+
+var mechanismOrder = []string{JWT, OAuth, Session, APIKey, Basic, RBAC, Custom}
+
+var mechanismLabels = map[string]string{
+	JWT:     "JWT",
+	OAuth:   "OAuth/OIDC",
+	Session: "Session cookies",
+	APIKey:  "API keys",
+	Basic:   "HTTP Basic",
+	RBAC:    "Role/permission checks",
+	Custom:  "Custom auth middleware",
+}
+
+// rule recognizes one auth library or idiom on a source line
+type rule struct {
+	mechanism string
+	detail    string
+	pattern   *regexp.Regexp
+	enforces  bool // Matches reject unauthenticated or unauthorized requests rather than issue credentials
+}
+
+var rules = []rule{
+	// JWT
+	{JWT, "echo JWT middleware", regexp.MustCompile(`echojwt\.(?:JWT|WithConfig)|middleware\.JWT(?:WithConfig)?\(`), true},
+	{JWT, "gin-jwt", regexp.MustCompile(`appleboy/gin-jwt|jwt\.GinJWTMiddleware`), true},
+	{JWT, "express-jwt", regexp.MustCompile(`express-jwt|expressjwt\(|express-oauth2-jwt-bearer`), true},
+	{JWT, "passport-jwt", regexp.MustCompile(`passport-jwt|new JwtStrategy|JwtStrategy\b`), true},
+	{JWT, "NestJS JWT guard", regexp.MustCompile(`AuthGuard\(\s*['"]jwt['"]\s*\)|JwtAuthGuard`), true},
+	{JWT, "flask-jwt-extended", regexp.MustCompile(`@jwt_required|jwt_required\(|verify_jwt_in_request`), true},
+	{JWT, "FastAPI OAuth2PasswordBearer", regexp.MustCompile(`OAuth2PasswordBearer\(|HTTPBearer\(`), true},
+	{JWT, "djangorestframework-simplejwt", regexp.MustCompile(`rest_framework_simplejwt|JWTAuthentication`), true},
+	{JWT, "Spring resource server", regexp.MustCompile(`oauth2ResourceServer\(|JwtAuthenticationFilter|JwtDecoder\b`), true},
+	{JWT, "JWT verification", regexp.MustCompile(`jwt\.(?:Parse|ParseWithClaims|verify|decode)\(|jwtVerify\(|jwt\.decode\(|JWT\.decode\(|Jwts\.parser`), true},
+	{JWT, "JWT issuing", regexp.MustCompile(`jwt\.(?:sign|NewWithClaims|encode)\(|\.SignedString\(|create_access_token\(|JWT\.encode\(|Jwts\.builder`), false},
+
+	// OAuth / OIDC
+	{OAuth, "golang.org/x/oauth2", regexp.MustCompile(`golang\.org/x/oauth2|oauth2\.Config\{`), false},
+	{OAuth, "go-oidc", regexp.MustCompile(`coreos/go-oidc|oidc\.NewProvider\(`), true},
+	{OAuth, "goth", regexp.MustCompile(`markbates/goth|gothic\.`), false},
+	{OAuth, "Passport OAuth strategy", regexp.MustCompile(`passport-(?:google|github|facebook|twitter|oauth2|openidconnect|azure-ad|saml)[\w-]*|OAuth2Strategy|GoogleStrategy|GitHubStrategy`), false},
+	{OAuth, "NextAuth", regexp.MustCompile(`next-auth|NextAuth\(|@auth/(?:core|nextjs|sveltekit)`), true},
+	{OAuth, "openid-client", regexp.MustCompile(`openid-client|express-openid-connect|requiresAuth\(\)`), true},
+	{OAuth, "Authlib", regexp.MustCompile(`\bauthlib\b|oauth\.register\(`), false},
+	{OAuth, "Spring OAuth2 login", regexp.MustCompile(`oauth2Login\(|spring-security-oauth2`), true},
+	{OAuth, "OmniAuth", regexp.MustCompile(`omniauth`), false},
+	{OAuth, "OAuth authorization code flow", regexp.MustCompile(`\.AuthCodeURL\(|authorization_code|/oauth2?/callback|/auth/[\w:]+/callback`), false},
+
+	// Sessions
+	{Session, "gorilla/sessions", regexp.MustCompile(`gorilla/sessions|sessions\.NewCookieStore\(|sessions\.NewFilesystemStore\(`), false},
+	{Session, "scs", regexp.MustCompile(`alexedwards/scs|scs\.New\(\)|\.LoadAndSave\(`), true},
+	{Session, "gin sessions", regexp.MustCompile(`gin-contrib/sessions|sessions\.Sessions\(`), true},
+	{Session, "express-session", regexp.MustCompile(`express-session|cookie-session|\bsession\(\{`), true},
+	{Session, "Flask-Login", regexp.MustCompile(`flask_login|LoginManager\(|@login_required`), true},
+	{Session, "Django sessions", regexp.MustCompile(`django\.contrib\.sessions|SessionAuthentication|LoginRequiredMixin|@login_required`), true},
+	{Session, "Starlette SessionMiddleware", regexp.MustCompile(`SessionMiddleware`), true},
+	{Session, "Devise", regexp.MustCompile(`\bdevise\b|authenticate_user!`), true},
+	{Session, "session cookie", regexp.MustCompile(`(?i)(?:set_cookie|setcookie|res\.cookie|http\.Cookie\{|cookies\.set)\b.*session`), false},
+
+	// API keys
+	{APIKey, "echo KeyAuth middleware", regexp.MustCompile(`middleware\.KeyAuth(?:WithConfig)?\(`), true},
+	{APIKey, "API key header", regexp.MustCompile(`(?i)(?:Header\.Get|GetHeader|req\.get|req\.header|headers\.get|headers\[|request\.headers)\s*[\(\[]?\s*["'](?:x-api-key|api-key|x-api-token|apikey)["']`), true},
+	{APIKey, "FastAPI APIKeyHeader", regexp.MustCompile(`APIKeyHeader\(|APIKeyQuery\(`), true},
+	{APIKey, "passport-headerapikey", regexp.MustCompile(`passport-headerapikey|HeaderAPIKeyStrategy`), true},
+
+	// HTTP Basic
+	{Basic, "HTTP Basic middleware", regexp.MustCompile(`middleware\.BasicAuth(?:WithConfig)?\(|gin\.BasicAuth\(|express-basic-auth|HTTPBasicAuth\(|httpBasic\(\)`), true},
+	{Basic, "HTTP Basic credentials", regexp.MustCompile(`\.BasicAuth\(\)`), true},
+
+	// Roles and permissions
+	{RBAC, "Casbin", regexp.MustCompile(`casbin|\.Enforce\(`), true},
+	{RBAC, "Spring method security", regexp.MustCompile(`@PreAuthorize|@Secured|@RolesAllowed|hasRole\(|hasAuthority\(|hasAnyRole\(`), true},
+	{RBAC, "NestJS roles guard", regexp.MustCompile(`RolesGuard|@Roles\(`), true},
+	{RBAC, "Django permissions", regexp.MustCompile(`permission_required|PermissionRequiredMixin|permission_classes|has_perm\(`), true},
+	{RBAC, "Pundit/CanCanCan", regexp.MustCompile(`\bpundit\b|\bauthorize!?\s+[:@]|cancancan|load_and_authorize_resource`), true},
+	{RBAC, "CASL/accesscontrol", regexp.MustCompile(`@casl/ability|accesscontrol|\bdefineAbility\b`), true},
+	{RBAC, "role check", regexp.MustCompile(`\b(?:[hH]as_?[rR]ole|[rR]equire_?[rR]oles?|[cC]heck_?[rR]ole|[hH]as_?[pP]ermission|[rR]equire_?[pP]ermission|[iI]s_?[aA]dmin)\s*\(`), true},
+	{RBAC, "role comparison", regexp.MustCompile(`\.[rR]ole\s*(?:==|===|!=|!==)\s*["']`), true},
+
+	// Homegrown middleware
+	{Custom, "auth middleware", regexp.MustCompile(`\.(?:Use|use|before_action|UseMiddleware)\s*\(?\s*:?\w*(?:[aA]uth(?:enticate|enticated|Middleware|Required|orize)?|[rR]equire(?:Auth|Login|User)|[eE]nsureAuthenticated|[iI]sAuthenticated)\b`), true},
+	{Custom, "FastAPI dependency", regexp.MustCompile(`Depends\(\s*(?:get_current_\w*user|verify_token|require_\w+)\s*\)`), true},
+}
@@ -0,0 +1,203 @@
+// Package bench compares the output of the parsing subsystem benchmarks (migration SQL parsing,
+// project type detection, relationship scanning and the crawler, on synthetic repositories of
+// 10,000 files and 1,000 migrations) with a committed baseline, so slowdowns fail a build. The
+// benchmarks themselves are the Benchmark functions of this package's tests.
+package bench
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// UpdateEnv is the environment variable that rewrites the baseline instead of comparing against it
+const UpdateEnv = "UPDATE_BENCH"
+
+// ThresholdEnv overrides the allowed slowdown, as a fraction (0.25 allows 25%)
+const ThresholdEnv = "BENCH_THRESHOLD"
+
+// DefaultThreshold is the allowed slowdown when ThresholdEnv is not set
+const DefaultThreshold = 0.25
+
+// BaselineFile is where the baseline lives, relative to the repository root
+const BaselineFile = "internal/bench/baseline.json"
+
+// CalibrationBenchmark is the fixed workload other benchmarks are measured in multiples of
+const CalibrationBenchmark = "BenchmarkCalibration"
+
+// IOBound lists the benchmarks dominated by file system access. Their time is reported but not
+// gated, since disk and page cache speed do not scale with the CPU-bound calibration loop; their
+// allocations are still compared.
+var IOBound = map[string]bool{
+	"BenchmarkCrawlerWalk10kFiles": true,
+}
+
+// Measurement is the cost of one benchmark operation
+type Measurement struct {
+	Name        string  `json:"name"`
+	NsPerOp     int64   `json:"ns_per_op"`
+	AllocsPerOp int64   `json:"allocs_per_op"`
+	BytesPerOp  int64   `json:"bytes_per_op"`
+	Relative    float64 `json:"relative"` // NsPerOp in units of the calibration loop, comparable across machines
+}
+
+// Baseline is a recorded set of measurements and the machine they were taken on
+type Baseline struct {
+	Calibration  int64         `json:"calibration_ns_per_op"`
+	Platform     string        `json:"platform"`
+	GoVersion    string        `json:"go_version"`
+	Measurements []Measurement `json:"measurements"`
+}
+
+// Result compares one benchmark with its baseline
+type Result struct {
+	Name     string       `json:"name"`
+	Status   string       `json:"status"` // "ok", "regressed", "missing" or "updated"
+	Actual   Measurement  `json:"actual"`
+	Baseline *Measurement `json:"baseline,omitempty"`
+	Change   float64      `json:"change"` // Relative time change from the baseline; 0.1 is 10% slower
+	Reason   string       `json:"reason,omitempty"`
+}
+
+// resultLine matches a benchmark result of go test -bench -benchmem, e.g.
+// "BenchmarkCrawlerWalk10kFiles-8   5   250578369 ns/op   8083488 B/op   67987 allocs/op"
+var resultLine = regexp.MustCompile(`^(Benchmark\S+?)(?:-\d+)?\s+\d+\s+(\d+(?:\.\d+)?) ns/op(?:\s+(\d+) B/op)?(?:\s+(\d+) allocs/op)?`)
+
+// Parse reads the output of go test -bench -benchmem. Benchmarks run several times (-count) keep
+// their median run, so one run slowed or sped up by other processes does not decide the result. A
+// failed test run, or output without the calibration benchmark, is an error.
+func Parse(r io.Reader) (*Baseline, error) {
+	measured := &Baseline{GoVersion: runtime.Version()}
+	var goos, goarch string
+	runs := make(map[string][]Measurement)
+	var order []string
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "goos: "):
+			goos = strings.TrimPrefix(line, "goos: ")
+			continue
+		case strings.HasPrefix(line, "goarch: "):
+			goarch = strings.TrimPrefix(line, "goarch: ")
+			continue
+		case line == "FAIL" || strings.HasPrefix(line, "FAIL\t") || strings.HasPrefix(line, "--- FAIL"):
+			return nil, fmt.Errorf("benchmark run failed: %s", line)
+		}
+
+		match := resultLine.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		ns, _ := strconv.ParseFloat(match[2], 64)
+		m := Measurement{Name: match[1], NsPerOp: int64(ns)}
+		m.BytesPerOp, _ = strconv.ParseInt(match[3], 10, 64)
+		m.AllocsPerOp, _ = strconv.ParseInt(match[4], 10, 64)
+		if _, seen := runs[m.Name]; !seen {
+			order = append(order, m.Name)
+		}
+		runs[m.Name] = append(runs[m.Name], m)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	calibration, ok := median(runs[CalibrationBenchmark])
+	if !ok || calibration.NsPerOp == 0 {
+		return nil, fmt.Errorf("no %s result in the benchmark output", CalibrationBenchmark)
+	}
+	measured.Calibration = calibration.NsPerOp
+	if goos != "" && goarch != "" {
+		measured.Platform = goos + "/" + goarch
+	}
+	for _, name := range order {
+		if name == CalibrationBenchmark {
+			continue
+		}
+		m, _ := median(runs[name])
+		m.Relative = float64(m.NsPerOp) / float64(measured.Calibration)
+		measured.Measurements = append(measured.Measurements, m)
+	}
+	if len(measured.Measurements) == 0 {
+		return nil, fmt.Errorf("no benchmark results besides %s", CalibrationBenchmark)
+	}
+	return measured, nil
+}
+
+// median returns the run with the median time, the faster of the two middle runs for an even count
+func median(runs []Measurement) (Measurement, bool) {
+	if len(runs) == 0 {
+		return Measurement{}, false
+	}
+	sorted := append([]Measurement(nil), runs...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].NsPerOp < sorted[j].NsPerOp })
+	return sorted[(len(sorted)-1)/2], true
+}
+
+// ReadBaseline loads a recorded baseline
+func ReadBaseline(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline: %v", err)
+	}
+	var baseline Baseline
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, fmt.Errorf("%s is not a benchmark baseline: %v", path, err)
+	}
+	return &baseline, nil
+}
+
+// WriteBaseline records measurements as the new baseline
+func WriteBaseline(path string, measured *Baseline) error {
+	data, err := json.MarshalIndent(measured, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode baseline: %v", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write baseline: %v", err)
+	}
+	return nil
+}
+
+// Compare checks measurements against a baseline, allowing each to be up to threshold slower (in
+// time relative to the calibration loop, and in allocations). Only the allocations of IOBound
+// benchmarks are checked. Regressions are listed first.
+func Compare(measured, baseline *Baseline, threshold float64) []Result {
+	recorded := make(map[string]Measurement, len(baseline.Measurements))
+	for _, m := range baseline.Measurements {
+		recorded[m.Name] = m
+	}
+
+	var results []Result
+	for _, m := range measured.Measurements {
+		result := Result{Name: m.Name, Status: "ok", Actual: m}
+		base, ok := recorded[m.Name]
+		if !ok || base.Relative == 0 {
+			result.Status = "missing"
+			results = append(results, result)
+			continue
+		}
+		result.Baseline = &base
+		result.Change = m.Relative/base.Relative - 1
+		switch {
+		case result.Change > threshold && !IOBound[m.Name]:
+			result.Status = "regressed"
+			result.Reason = fmt.Sprintf("%.0f%% slower", result.Change*100)
+		case base.AllocsPerOp > 0 && float64(m.AllocsPerOp) > float64(base.AllocsPerOp)*(1+threshold):
+			result.Status = "regressed"
+			result.Reason = fmt.Sprintf("allocations per op grew from %d to %d", base.AllocsPerOp, m.AllocsPerOp)
+		}
+		results = append(results, result)
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Status == "regressed" && results[j].Status != "regressed" })
+	return results
+}
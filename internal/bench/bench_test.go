@@ -0,0 +1,154 @@
+package bench
+
+import (
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"repo-explanation/config"
+	"repo-explanation/internal/database"
+	"repo-explanation/internal/detector"
+	"repo-explanation/internal/filestore"
+	"repo-explanation/internal/pipeline"
+	"repo-explanation/internal/relationships"
+)
+
+// The synthetic inputs are generated once and shared by every run of every benchmark
+var (
+	repositoryOnce sync.Once
+	repository     map[string]string
+
+	crawlOnce sync.Once
+	crawlDir  string
+	crawlErr  error
+)
+
+func syntheticInputs() map[string]string {
+	repositoryOnce.Do(func() { repository = syntheticRepository(syntheticFileCount) })
+	return repository
+}
+
+// crawlRepository writes the synthetic repository to a temporary directory, removed by TestMain
+func crawlRepository() (string, error) {
+	crawlOnce.Do(func() {
+		crawlDir, crawlErr = os.MkdirTemp("", "bench-crawler-")
+		if crawlErr == nil {
+			crawlErr = writeRepository(crawlDir, syntheticInputs())
+		}
+	})
+	return crawlDir, crawlErr
+}
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if crawlDir != "" {
+		os.RemoveAll(crawlDir)
+	}
+	os.Exit(code)
+}
+
+// silence discards standard output until the returned function is called; the subsystems log as
+// they parse, which would break up the benchmark results
+func silence() func() {
+	stdout := os.Stdout
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		return func() {}
+	}
+	os.Stdout = devNull
+	return func() {
+		os.Stdout = stdout
+		devNull.Close()
+	}
+}
+
+// BenchmarkCalibration is a fixed CPU- and memory-bound workload; the other benchmarks are compared
+// in multiples of it so a baseline taken on one machine is meaningful on another
+func BenchmarkCalibration(b *testing.B) {
+	data := make([]byte, 1<<20)
+	for i := range data {
+		data[i] = byte(i * 31)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sum := sha256.Sum256(data)
+		data[i%len(data)] = sum[0]
+	}
+}
+
+// BenchmarkStreamingParser1kMigrations measures streaming schema extraction over all migrations
+func BenchmarkStreamingParser1kMigrations(b *testing.B) {
+	migrations := syntheticMigrations(syntheticMigrationCount)
+	defer silence()()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		extractor := database.NewStreamingSchemaExtractor("postgres")
+		if err := extractor.BuildSchemaAndStream(migrations, func(database.StreamingResponse) {}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkDetectorRules10kFiles measures the project type detection rules over the file list
+func BenchmarkDetectorRules10kFiles(b *testing.B) {
+	inputs := syntheticInputs()
+	files := make([]detector.FileInfo, 0, len(inputs))
+	contents := make(map[string]string)
+	for relPath, content := range inputs {
+		files = append(files, detector.FileInfo{
+			Path:         relPath,
+			RelativePath: relPath,
+			Size:         int64(len(content)),
+			Extension:    filepath.Ext(relPath),
+		})
+		if detector.NeedsContent(relPath) {
+			contents[relPath] = content
+		}
+	}
+	projectDetector := detector.NewProjectDetector()
+	defer silence()()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		projectDetector.DetectProjectType(files, contents)
+	}
+}
+
+// BenchmarkRelationshipScan10kFiles measures the relationship discovery regexes over every file
+func BenchmarkRelationshipScan10kFiles(b *testing.B) {
+	store := filestore.FromMap(syntheticInputs())
+	services := syntheticServiceList()
+	defer silence()()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		discovery := relationships.NewRelationshipDiscovery(services, store)
+		if _, err := discovery.DiscoverRelationships("."); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkCrawlerWalk10kFiles measures crawling the repository written to a temporary directory
+func BenchmarkCrawlerWalk10kFiles(b *testing.B) {
+	dir, err := crawlRepository()
+	if err != nil {
+		b.Fatal(err)
+	}
+	crawler, err := pipeline.NewCrawler(config.Defaults(), dir)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer silence()()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := crawler.CrawlFiles(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package bench
+
+import (
+	"strings"
+	"testing"
+)
+
+const sampleOutput = `goos: linux
+goarch: amd64
+pkg: repo-explanation/internal/bench
+BenchmarkCalibration-8                   1000   1000000 ns/op   0 B/op   0 allocs/op
+BenchmarkCalibration-8                   1000   3000000 ns/op   0 B/op   0 allocs/op
+BenchmarkCalibration-8                   1000   1100000 ns/op   0 B/op   0 allocs/op
+BenchmarkStreamingParser1kMigrations-8     10  90000000 ns/op  500 B/op  10 allocs/op
+BenchmarkStreamingParser1kMigrations-8     10 150000000 ns/op  500 B/op  10 allocs/op
+BenchmarkStreamingParser1kMigrations-8     10 165000000 ns/op  500 B/op  10 allocs/op
+BenchmarkCrawlerWalk10kFiles-8              5 800000000 ns/op  900 B/op 100 allocs/op
+PASS
+ok  	repo-explanation/internal/bench	12.345s
+`
+
+func TestParseKeepsMedianRun(t *testing.T) {
+	measured, err := Parse(strings.NewReader(sampleOutput))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if measured.Calibration != 1100000 || measured.Platform != "linux/amd64" {
+		t.Errorf("calibration = %d on %s, want the median 1100000 on linux/amd64", measured.Calibration, measured.Platform)
+	}
+	if len(measured.Measurements) != 2 {
+		t.Fatalf("got %d measurements, want 2: %+v", len(measured.Measurements), measured.Measurements)
+	}
+	if parser := measured.Measurements[0]; parser.NsPerOp != 150000000 {
+		t.Errorf("%s = %d ns/op, want the median 150000000", parser.Name, parser.NsPerOp)
+	}
+
+	if _, err := Parse(strings.NewReader(sampleOutput + "--- FAIL: BenchmarkCrawlerWalk10kFiles\n")); err == nil {
+		t.Error("Parse accepted a failed run")
+	}
+}
+
+func TestCompareGatesOnlyAllocationsOfIOBoundBenchmarks(t *testing.T) {
+	baseline := &Baseline{Measurements: []Measurement{
+		{Name: "BenchmarkStreamingParser1kMigrations", AllocsPerOp: 100, Relative: 100},
+		{Name: "BenchmarkCrawlerWalk10kFiles", AllocsPerOp: 100, Relative: 100},
+	}}
+	tests := []struct {
+		name     string
+		measured Measurement
+		want     string
+	}{
+		{"CPU-bound within the threshold", Measurement{Name: "BenchmarkStreamingParser1kMigrations", AllocsPerOp: 100, Relative: 120}, "ok"},
+		{"CPU-bound slower", Measurement{Name: "BenchmarkStreamingParser1kMigrations", AllocsPerOp: 100, Relative: 130}, "regressed"},
+		{"I/O-bound slower", Measurement{Name: "BenchmarkCrawlerWalk10kFiles", AllocsPerOp: 100, Relative: 300}, "ok"},
+		{"I/O-bound allocating more", Measurement{Name: "BenchmarkCrawlerWalk10kFiles", AllocsPerOp: 130, Relative: 100}, "regressed"},
+		{"no baseline", Measurement{Name: "BenchmarkNew", Relative: 1}, "missing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := Compare(&Baseline{Measurements: []Measurement{tt.measured}}, baseline, 0.25)
+			if len(results) != 1 || results[0].Status != tt.want {
+				t.Errorf("Compare = %+v, want status %q", results, tt.want)
+			}
+		})
+	}
+}
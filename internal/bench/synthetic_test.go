@@ -0,0 +1,160 @@
+package bench
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"repo-explanation/internal/database"
+	"repo-explanation/internal/microservices"
+)
+
+// Sizes of the synthetic inputs
+const (
+	syntheticFileCount      = 10000
+	syntheticMigrationCount = 1000
+	syntheticServices       = 50
+)
+
+// syntheticRepository returns a monorepo of n files keyed by relative path: Go services that import
+// and call each other over HTTP and gRPC, a TypeScript frontend, Python jobs, configs and docs, in
+// roughly the proportions of a large real repository
+func syntheticRepository(n int) map[string]string {
+	files := map[string]string{
+		"go.mod":             "module example.com/mono\n\ngo 1.22\n\nrequire github.com/labstack/echo/v4 v4.11.4\n",
+		"package.json":       `{"name":"web","dependencies":{"react":"^18.2.0","next":"^14.0.0"},"scripts":{"dev":"next dev"}}` + "\n",
+		"requirements.txt":   "django==4.2\ncelery==5.3\n",
+		"Makefile":           "build:\n\tgo build ./...\n\ntest:\n\tgo test ./...\n",
+		"README.md":          "# Monorepo\n\nServices, a web app and data jobs.\n",
+		"docker-compose.yml": composeFile(),
+		".gitignore":         "node_modules/\ndist/\n*.log\n",
+	}
+	for i := 0; len(files) < n; i++ {
+		service := serviceName(i % syntheticServices)
+		peer := serviceName((i*7 + 3) % syntheticServices)
+		switch i % 10 {
+		case 0, 1, 2, 3, 4:
+			files[fmt.Sprintf("services/%s/internal/handler%d.go", service, i)] = goSource(service, peer, i)
+		case 5:
+			files[fmt.Sprintf("services/%s/cmd/main%d.go", service, i)] = goSource(service, peer, i)
+		case 6, 7:
+			files[fmt.Sprintf("web/src/components/feature%d/Component%d.tsx", i%200, i)] = tsxSource(peer, i)
+		case 8:
+			files[fmt.Sprintf("jobs/pipeline%d/task%d.py", i%100, i)] = pythonSource(peer, i)
+		default:
+			files[fmt.Sprintf("deploy/%s/values%d.yaml", service, i)] = fmt.Sprintf("image: %s:%d\nenv:\n  PEER_URL: http://%s:8080\n  LOG_LEVEL: info\n", service, i, peer)
+		}
+	}
+	return files
+}
+
+// syntheticServiceList returns the services of syntheticRepository
+func syntheticServiceList() []microservices.DiscoveredService {
+	services := make([]microservices.DiscoveredService, syntheticServices)
+	for i := range services {
+		name := serviceName(i)
+		services[i] = microservices.DiscoveredService{Name: name, Path: "services/" + name, EntryPoint: "services/" + name + "/cmd/main.go", APIType: "REST", Port: "8080"}
+	}
+	return services
+}
+
+// syntheticMigrations returns n Postgres migrations growing a schema the way a long-lived
+// application does: new tables with foreign keys to older ones, added columns, indexes and enums
+func syntheticMigrations(n int) []database.Migration {
+	migrations := make([]database.Migration, n)
+	for i := range migrations {
+		var sql strings.Builder
+		table := fmt.Sprintf("entity_%d", i)
+		fmt.Fprintf(&sql, "CREATE TABLE %s (\n    id BIGSERIAL PRIMARY KEY,\n    name VARCHAR(255) NOT NULL,\n    status VARCHAR(32) DEFAULT 'active',\n    amount NUMERIC(12, 2),\n    metadata JSONB,\n", table)
+		if i > 0 {
+			fmt.Fprintf(&sql, "    parent_id BIGINT REFERENCES entity_%d(id) ON DELETE CASCADE,\n", i/2)
+		}
+		sql.WriteString("    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),\n    updated_at TIMESTAMPTZ\n);\n")
+		fmt.Fprintf(&sql, "CREATE INDEX idx_%s_name ON %s (name);\n", table, table)
+		if i%5 == 4 {
+			fmt.Fprintf(&sql, "ALTER TABLE entity_%d ADD COLUMN note_%d TEXT;\n", i/3, i)
+		}
+		if i%10 == 9 {
+			fmt.Fprintf(&sql, "CREATE TYPE kind_%d AS ENUM ('a', 'b', 'c');\n", i)
+			fmt.Fprintf(&sql, "ALTER TABLE %s ADD CONSTRAINT uq_%s_name UNIQUE (name);\n", table, table)
+		}
+		migrations[i] = database.Migration{Name: fmt.Sprintf("%04d_create_%s.up.sql", i+1, table), SQL: sql.String()}
+	}
+	return migrations
+}
+
+// writeRepository writes files under dir
+func writeRepository(dir string, files map[string]string) error {
+	for relPath, content := range files {
+		target := filepath.Join(dir, filepath.FromSlash(relPath))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(target, []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func serviceName(i int) string {
+	return fmt.Sprintf("svc%02d", i)
+}
+
+func composeFile() string {
+	var b strings.Builder
+	b.WriteString("services:\n")
+	for i := 0; i < syntheticServices; i++ {
+		fmt.Fprintf(&b, "  %s:\n    build: ./services/%s\n    environment:\n      - PEER_URL=http://%s:8080\n    depends_on:\n      - %s\n", serviceName(i), serviceName(i), serviceName((i+1)%syntheticServices), serviceName((i+2)%syntheticServices))
+	}
+	return b.String()
+}
+
+func goSource(service, peer string, i int) string {
+	return fmt.Sprintf(`package handler
+
+import "example.com/mono/services/%[2]s/pkg/client"
+import "net/http"
+
+// Handler%[3]d serves the %[1]s endpoint and calls %[2]s
+func Handler%[3]d(w http.ResponseWriter, r *http.Request) {
+	resp, err := http.Get("http://%[2]s:8080/api/v1/items/%[3]d")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	conn, _ := grpc.Dial("%[2]s:9090", grpc.WithInsecure())
+	_ = %[2]spb.New%[4]sClient(conn)
+	_ = client.New()
+	w.WriteHeader(http.StatusOK)
+}
+`, service, peer, i, strings.ToUpper(peer[:1])+peer[1:])
+}
+
+func tsxSource(peer string, i int) string {
+	return fmt.Sprintf(`import React, { useEffect, useState } from "react";
+
+export function Component%[2]d() {
+  const [items, setItems] = useState([]);
+  useEffect(() => {
+    fetch("http://%[1]s:8080/api/v1/items").then((r) => r.json()).then(setItems);
+  }, []);
+  return <ul>{items.map((item) => <li key={item.id}>{item.name}</li>)}</ul>;
+}
+`, peer, i)
+}
+
+func pythonSource(peer string, i int) string {
+	return fmt.Sprintf(`import requests
+from celery import shared_task
+
+
+@shared_task
+def task_%[2]d():
+    """Syncs items from %[1]s."""
+    response = requests.get("http://%[1]s:8080/api/v1/items")
+    return [item["id"] for item in response.json()]
+`, peer, i)
+}
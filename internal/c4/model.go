@@ -0,0 +1,323 @@
+package c4
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"repo-explanation/internal/microservices"
+	"repo-explanation/internal/pipeline"
+	"repo-explanation/internal/relationships"
+)
+
+// Element is a person, software system or container in the C4 model
+type Element struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Technology  string `json:"technology,omitempty"`
+	Database    bool   `json:"database,omitempty"`
+}
+
+// Relationship is a labelled arrow between two elements, referenced by ID
+type Relationship struct {
+	From       string `json:"from"`
+	To         string `json:"to"`
+	Label      string `json:"label"`
+	Technology string `json:"technology,omitempty"`
+}
+
+// Model maps one analysis onto the C4 context and container levels
+type Model struct {
+	User       Element   `json:"user"`
+	System     Element   `json:"system"`
+	Containers []Element `json:"containers"`
+	External   []Element `json:"external_systems"`
+
+	// ContainerRelationships connect the user and containers to each other and to external systems
+	ContainerRelationships []Relationship `json:"container_relationships"`
+	// ContextRelationships connect the user and the system to external systems
+	ContextRelationships []Relationship `json:"context_relationships"`
+}
+
+// genericExternalWords are skipped when matching an external system name against service configuration
+var genericExternalWords = map[string]bool{
+	"api": true, "service": true, "services": true, "the": true, "cloud": true, "database": true, "db": true,
+	"server": true, "platform": true, "sdk": true, "external": true, "third-party": true,
+}
+
+// Build maps the analysis onto C4: the project is the software system, discovered services are its
+// containers (with a database container when a schema was found) and the project summary's external
+// services are external systems.
+func Build(name string, result *pipeline.AnalysisResult) *Model {
+	ids := newIDSet()
+	model := &Model{
+		User:   Element{ID: ids.unique("user"), Name: "User", Description: "Uses " + name},
+		System: Element{ID: ids.unique("system"), Name: name, Description: systemDescription(result)},
+	}
+
+	containerIDs := make(map[string]string) // service name -> element ID
+	for _, service := range containerServices(result) {
+		id := ids.unique(service.Name)
+		containerIDs[service.Name] = id
+		model.Containers = append(model.Containers, Element{
+			ID:          id,
+			Name:        service.Name,
+			Description: firstNonEmpty(service.Description, service.Path),
+			Technology:  apiTechnology(service.APIType),
+		})
+	}
+	if len(model.Containers) == 0 {
+		// A single deployable: the whole project is one container
+		id := ids.unique(name + "_app")
+		containerIDs[name] = id
+		model.Containers = append(model.Containers, Element{ID: id, Name: name, Description: "Application", Technology: mainStacks(result)})
+	}
+
+	if result != nil && result.DatabaseSchema != nil && len(result.DatabaseSchema.Tables) > 0 {
+		model.Containers = append(model.Containers, Element{
+			ID:          ids.unique("database"),
+			Name:        "Database",
+			Description: fmt.Sprintf("%d tables", len(result.DatabaseSchema.Tables)),
+			Technology:  "SQL",
+			Database:    true,
+		})
+		if len(containerIDs) == 1 {
+			for _, id := range containerIDs {
+				model.ContainerRelationships = append(model.ContainerRelationships, Relationship{From: id, To: model.Containers[len(model.Containers)-1].ID, Label: "Reads from and writes to", Technology: "SQL"})
+			}
+		}
+	}
+
+	// Service-to-service calls, one arrow per pair; anything nothing calls is an entry point for the user
+	called := make(map[string]bool)
+	if result != nil {
+		for _, rel := range strongestRelationships(result.ServiceRelationships) {
+			from, to := containerIDs[rel.From], containerIDs[rel.To]
+			if from == "" || to == "" {
+				continue
+			}
+			called[to] = true
+			model.ContainerRelationships = append(model.ContainerRelationships, Relationship{From: from, To: to, Label: relationshipLabel(rel), Technology: relationshipTechnology(rel)})
+		}
+	}
+	var userRels []Relationship
+	for _, container := range model.Containers {
+		if !container.Database && !called[container.ID] {
+			userRels = append(userRels, Relationship{From: model.User.ID, To: container.ID, Label: "Uses", Technology: container.Technology})
+		}
+	}
+	model.ContainerRelationships = append(userRels, model.ContainerRelationships...)
+	model.ContextRelationships = append(model.ContextRelationships, Relationship{From: model.User.ID, To: model.System.ID, Label: "Uses"})
+
+	// External systems are attributed to the containers whose configuration or description names them
+	for _, external := range externalServices(result) {
+		element := Element{ID: ids.unique("ext_" + external), Name: external, Description: "External system"}
+		model.External = append(model.External, element)
+		model.ContextRelationships = append(model.ContextRelationships, Relationship{From: model.System.ID, To: element.ID, Label: "Uses"})
+		for _, serviceName := range servicesUsing(result, external) {
+			if id := containerIDs[serviceName]; id != "" {
+				model.ContainerRelationships = append(model.ContainerRelationships, Relationship{From: id, To: element.ID, Label: "Uses"})
+			}
+		}
+	}
+
+	return model
+}
+
+// containerServices prefers discovered services and falls back to the monorepo services of the detailed analysis
+func containerServices(result *pipeline.AnalysisResult) []microservices.DiscoveredService {
+	if result == nil {
+		return nil
+	}
+	if len(result.Services) > 0 {
+		return result.Services
+	}
+	var services []microservices.DiscoveredService
+	if result.ProjectSummary != nil && result.ProjectSummary.DetailedAnalysis != nil {
+		for _, service := range result.ProjectSummary.DetailedAnalysis.MonorepoServices {
+			services = append(services, microservices.DiscoveredService{
+				Name:        service.Name,
+				Path:        service.Path,
+				APIType:     microservices.ServiceType(service.APIType),
+				Description: service.ShortPurpose,
+			})
+		}
+	}
+	return services
+}
+
+func systemDescription(result *pipeline.AnalysisResult) string {
+	if result != nil && result.ProjectSummary != nil {
+		return result.ProjectSummary.Purpose
+	}
+	return ""
+}
+
+func mainStacks(result *pipeline.AnalysisResult) string {
+	if result != nil && result.ProjectSummary != nil && result.ProjectSummary.DetailedAnalysis != nil {
+		return strings.Join(result.ProjectSummary.DetailedAnalysis.MainStacks, ", ")
+	}
+	return ""
+}
+
+// externalServices returns the project summary's external services, deduplicated
+func externalServices(result *pipeline.AnalysisResult) []string {
+	if result == nil || result.ProjectSummary == nil {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var externals []string
+	for _, external := range result.ProjectSummary.ExternalServices {
+		external = strings.TrimSpace(external)
+		if external == "" || seen[strings.ToLower(external)] {
+			continue
+		}
+		seen[strings.ToLower(external)] = true
+		externals = append(externals, external)
+	}
+	return externals
+}
+
+// servicesUsing finds services whose variables or description mention a distinctive word of the external system's name
+func servicesUsing(result *pipeline.AnalysisResult, external string) []string {
+	var keywords []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(external), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-')
+	}) {
+		if len(word) >= 3 && !genericExternalWords[word] {
+			keywords = append(keywords, strings.ReplaceAll(word, "-", ""))
+		}
+	}
+	if len(keywords) == 0 {
+		return nil
+	}
+	mentions := func(text string) bool {
+		text = strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(text))
+		for _, keyword := range keywords {
+			if strings.Contains(text, keyword) {
+				return true
+			}
+		}
+		return false
+	}
+
+	users := make(map[string]bool)
+	for _, service := range result.Services {
+		if mentions(service.Description) {
+			users[service.Name] = true
+		}
+	}
+	if result.ProjectSecrets != nil {
+		for _, service := range result.ProjectSecrets.Services {
+			for _, variable := range service.Variables {
+				if mentions(variable.Name) {
+					users[service.ServiceName] = true
+				}
+			}
+		}
+	}
+
+	names := make([]string, 0, len(users))
+	for name := range users {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// strongestRelationships keeps one relationship per service pair, preferring network evidence
+func strongestRelationships(rels []relationships.ServiceRelationship) []relationships.ServiceRelationship {
+	type pair struct{ from, to string }
+	best := make(map[pair]relationships.ServiceRelationship)
+	var order []pair
+	for _, rel := range rels {
+		if rel.From == rel.To {
+			continue
+		}
+		key := pair{rel.From, rel.To}
+		existing, ok := best[key]
+		if !ok {
+			order = append(order, key)
+		}
+		if !ok || (rel.EvidenceType == relationships.NetworkEvidence && existing.EvidenceType != relationships.NetworkEvidence) ||
+			(rel.EvidenceType == existing.EvidenceType && rel.Confidence > existing.Confidence) {
+			best[key] = rel
+		}
+	}
+	result := make([]relationships.ServiceRelationship, 0, len(order))
+	for _, key := range order {
+		result = append(result, best[key])
+	}
+	return result
+}
+
+func relationshipLabel(rel relationships.ServiceRelationship) string {
+	switch rel.EvidenceType {
+	case relationships.NetworkEvidence, relationships.APISpecEvidence, relationships.ClientLibraryEvidence:
+		return "Calls"
+	case relationships.SharedDatabaseEvidence:
+		return "Shares database with"
+	}
+	return "Depends on"
+}
+
+func relationshipTechnology(rel relationships.ServiceRelationship) string {
+	lower := strings.ToLower(rel.Evidence)
+	switch {
+	case strings.Contains(lower, "grpc"):
+		return "gRPC"
+	case strings.Contains(lower, "http"):
+		return "HTTP"
+	}
+	return ""
+}
+
+func apiTechnology(apiType microservices.ServiceType) string {
+	switch apiType {
+	case microservices.HTTPService:
+		return "HTTP API"
+	case microservices.GRPCService:
+		return "gRPC"
+	case microservices.GraphQLService:
+		return "GraphQL"
+	}
+	return ""
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// idSet hands out identifiers valid in both PlantUML and Structurizr DSL, unique within one model
+type idSet map[string]bool
+
+func newIDSet() idSet {
+	return make(idSet)
+}
+
+func (s idSet) unique(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else if b.Len() > 0 && !strings.HasSuffix(b.String(), "_") {
+			b.WriteRune('_')
+		}
+	}
+	id := strings.Trim(b.String(), "_")
+	if id == "" || (id[0] >= '0' && id[0] <= '9') {
+		id = "e_" + id
+	}
+	candidate := id
+	for i := 2; s[candidate]; i++ {
+		candidate = fmt.Sprintf("%s_%d", id, i)
+	}
+	s[candidate] = true
+	return candidate
+}
@@ -0,0 +1,148 @@
+package c4
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ContextPlantUML renders the system context diagram with the C4-PlantUML standard library
+func (m *Model) ContextPlantUML() string {
+	var b strings.Builder
+	b.WriteString("@startuml\n")
+	b.WriteString("!include <C4/C4_Context>\n\n")
+	b.WriteString(fmt.Sprintf("title System Context diagram for %s\n\n", plantUMLText(m.System.Name)))
+	b.WriteString(fmt.Sprintf("Person(%s, %s, %s)\n", m.User.ID, plantUMLString(m.User.Name), plantUMLString(m.User.Description)))
+	b.WriteString(fmt.Sprintf("System(%s, %s, %s)\n", m.System.ID, plantUMLString(m.System.Name), plantUMLString(m.System.Description)))
+	for _, external := range m.External {
+		b.WriteString(fmt.Sprintf("System_Ext(%s, %s, %s)\n", external.ID, plantUMLString(external.Name), plantUMLString(external.Description)))
+	}
+	b.WriteString("\n")
+	writePlantUMLRelationships(&b, m.ContextRelationships)
+	b.WriteString("\nSHOW_LEGEND()\n@enduml\n")
+	return b.String()
+}
+
+// ContainerPlantUML renders the container diagram: the system's containers inside its boundary
+func (m *Model) ContainerPlantUML() string {
+	var b strings.Builder
+	b.WriteString("@startuml\n")
+	b.WriteString("!include <C4/C4_Container>\n\n")
+	b.WriteString(fmt.Sprintf("title Container diagram for %s\n\n", plantUMLText(m.System.Name)))
+	b.WriteString(fmt.Sprintf("Person(%s, %s, %s)\n\n", m.User.ID, plantUMLString(m.User.Name), plantUMLString(m.User.Description)))
+	b.WriteString(fmt.Sprintf("System_Boundary(%s, %s) {\n", m.System.ID, plantUMLString(m.System.Name)))
+	for _, container := range m.Containers {
+		macro := "Container"
+		if container.Database {
+			macro = "ContainerDb"
+		}
+		b.WriteString(fmt.Sprintf("  %s(%s, %s, %s, %s)\n", macro, container.ID, plantUMLString(container.Name), plantUMLString(container.Technology), plantUMLString(container.Description)))
+	}
+	b.WriteString("}\n\n")
+	for _, external := range m.External {
+		b.WriteString(fmt.Sprintf("System_Ext(%s, %s, %s)\n", external.ID, plantUMLString(external.Name), plantUMLString(external.Description)))
+	}
+	b.WriteString("\n")
+	writePlantUMLRelationships(&b, m.ContainerRelationships)
+	b.WriteString("\nSHOW_LEGEND()\n@enduml\n")
+	return b.String()
+}
+
+// StructurizrDSL renders one workspace holding the model and both views.
+// External systems linked only at system level get a system relationship; the rest are implied by their containers.
+func (m *Model) StructurizrDSL() string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("workspace %s %s {\n\n", dslString(m.System.Name), dslString(m.System.Description)))
+	b.WriteString("    model {\n")
+	b.WriteString(fmt.Sprintf("        %s = person %s %s\n", m.User.ID, dslString(m.User.Name), dslString(m.User.Description)))
+	b.WriteString(fmt.Sprintf("        %s = softwareSystem %s %s {\n", m.System.ID, dslString(m.System.Name), dslString(m.System.Description)))
+	for _, container := range m.Containers {
+		tags := ""
+		if container.Database {
+			tags = " " + dslString("Database")
+		}
+		b.WriteString(fmt.Sprintf("            %s = container %s %s %s%s\n", container.ID, dslString(container.Name), dslString(container.Description), dslString(container.Technology), tags))
+	}
+	b.WriteString("        }\n")
+	for _, external := range m.External {
+		b.WriteString(fmt.Sprintf("        %s = softwareSystem %s %s %s\n", external.ID, dslString(external.Name), dslString(external.Description), dslString("External")))
+	}
+	b.WriteString("\n")
+
+	attributed := make(map[string]bool)
+	for _, rel := range m.ContainerRelationships {
+		attributed[rel.To] = true
+		b.WriteString(fmt.Sprintf("        %s -> %s %s %s\n", rel.From, rel.To, dslString(rel.Label), dslString(rel.Technology)))
+	}
+	for _, rel := range m.ContextRelationships {
+		if rel.From == m.System.ID && !attributed[rel.To] {
+			b.WriteString(fmt.Sprintf("        %s -> %s %s\n", rel.From, rel.To, dslString(rel.Label)))
+		}
+	}
+	b.WriteString("    }\n\n")
+
+	b.WriteString("    views {\n")
+	b.WriteString(fmt.Sprintf("        systemContext %s \"SystemContext\" {\n            include *\n            autolayout lr\n        }\n\n", m.System.ID))
+	b.WriteString(fmt.Sprintf("        container %s \"Containers\" {\n            include *\n            autolayout lr\n        }\n\n", m.System.ID))
+	b.WriteString("        styles {\n")
+	b.WriteString("            element \"Person\" {\n                shape person\n            }\n")
+	b.WriteString("            element \"Database\" {\n                shape cylinder\n            }\n")
+	b.WriteString("            element \"External\" {\n                background #999999\n                color #ffffff\n            }\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n}\n")
+	return b.String()
+}
+
+// Export writes the C4-PlantUML context and container diagrams and the Structurizr workspace to dir,
+// returning the written paths
+func (m *Model) Export(dir string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %v", err)
+	}
+
+	base := strings.Trim(newIDSet().unique(m.System.Name), "_")
+	outputs := []struct {
+		name    string
+		content string
+	}{
+		{base + "_c4_context.puml", m.ContextPlantUML()},
+		{base + "_c4_container.puml", m.ContainerPlantUML()},
+		{base + "_c4.dsl", m.StructurizrDSL()},
+	}
+
+	var paths []string
+	for _, output := range outputs {
+		path := filepath.Join(dir, output.name)
+		if err := os.WriteFile(path, []byte(output.content), 0644); err != nil {
+			return paths, fmt.Errorf("failed to write %s: %v", path, err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+func writePlantUMLRelationships(b *strings.Builder, rels []Relationship) {
+	for _, rel := range rels {
+		if rel.Technology != "" {
+			b.WriteString(fmt.Sprintf("Rel(%s, %s, %s, %s)\n", rel.From, rel.To, plantUMLString(rel.Label), plantUMLString(rel.Technology)))
+		} else {
+			b.WriteString(fmt.Sprintf("Rel(%s, %s, %s)\n", rel.From, rel.To, plantUMLString(rel.Label)))
+		}
+	}
+}
+
+// plantUMLText flattens text onto one line; C4-PlantUML macro arguments cannot span lines
+func plantUMLText(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// plantUMLString quotes a macro argument; PlantUML strings have no escape for double quotes
+func plantUMLString(text string) string {
+	return `"` + strings.ReplaceAll(plantUMLText(text), `"`, "'") + `"`
+}
+
+// dslString quotes a Structurizr DSL token
+func dslString(text string) string {
+	return `"` + strings.ReplaceAll(plantUMLText(text), `"`, `\"`) + `"`
+}
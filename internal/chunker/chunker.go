@@ -15,8 +15,9 @@ type Chunk struct {
 	Tokens    int    `json:"tokens"`
 }
 
-// ChunkFile splits file content into chunks based on token limits
-func ChunkFile(content string, maxTokens int, filepath string) ([]Chunk, error) {
+// ChunkFile splits file content into chunks based on token limits; with the syntax strategy,
+// chunks of Go, TypeScript/JavaScript and Python files end between declarations
+func ChunkFile(content string, maxTokens int, filepath, strategy string) ([]Chunk, error) {
 	if content == "" {
 		return nil, nil
 	}
@@ -36,58 +37,20 @@ func ChunkFile(content string, maxTokens int, filepath string) ([]Chunk, error)
 		}, nil
 	}
 
-	// Split into logical chunks
+	if strategy != StrategyTokens {
+		if chunks := splitSyntax(content, maxTokens, filepath); chunks != nil {
+			return chunks, nil
+		}
+	}
+
+	// Split into windows of whole lines
 	return splitContent(content, maxTokens)
 }
 
-// splitContent intelligently splits content into chunks
+// splitContent splits content into windows of whole lines
 func splitContent(content string, maxTokens int) ([]Chunk, error) {
-	lines := strings.Split(content, "\n")
-	chunks := make([]Chunk, 0)
-	
-	currentChunk := strings.Builder{}
-	startLine := 1
-	currentLine := 1
-	
-	for _, line := range lines {
-		// Estimate tokens for current chunk + new line
-		newContent := currentChunk.String() + line + "\n"
-		estimatedTokens := estimateTokens(newContent)
-		
-		if estimatedTokens > maxTokens && currentChunk.Len() > 0 {
-			// Current chunk is full, save it
-			chunk := Chunk{
-				Content:   currentChunk.String(),
-				StartLine: startLine,
-				EndLine:   currentLine - 1,
-				Tokens:    estimateTokens(currentChunk.String()),
-			}
-			chunks = append(chunks, chunk)
-			
-			// Start new chunk
-			currentChunk.Reset()
-			currentChunk.WriteString(line + "\n")
-			startLine = currentLine
-		} else {
-			// Add line to current chunk
-			currentChunk.WriteString(line + "\n")
-		}
-		
-		currentLine++
-	}
-	
-	// Add final chunk if it has content
-	if currentChunk.Len() > 0 {
-		chunk := Chunk{
-			Content:   currentChunk.String(),
-			StartLine: startLine,
-			EndLine:   len(lines),
-			Tokens:    estimateTokens(currentChunk.String()),
-		}
-		chunks = append(chunks, chunk)
-	}
-	
-	return chunks, nil
+	doc := newDocument(content)
+	return buildChunks(doc, windows(doc, span{0, len(doc.lines)}, maxTokens)), nil
 }
 
 // estimateTokens provides a rough estimate of token count
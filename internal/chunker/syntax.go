@@ -0,0 +1,197 @@
+package chunker
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Chunking strategies, selected with file_processing.chunk_strategy
+const (
+	StrategySyntax = "syntax" // Split between declarations in Go, TypeScript/JavaScript and Python; token windows elsewhere
+	StrategyTokens = "tokens" // Split into windows of whole lines up to the token limit
+)
+
+// Strategies lists the valid chunking strategies
+var Strategies = []string{StrategySyntax, StrategyTokens}
+
+// grammar finds declaration boundaries in one language with line patterns, which is enough for
+// formatted code and never fails on code a parser would reject
+type grammar struct {
+	declaration *regexp.Regexp // Lines starting a top-level declaration
+	member      *regexp.Regexp // Lines starting a method or nested function, for declarations larger than a chunk
+	leading     *regexp.Regexp // Doc comment, decorator and annotation lines that belong to the declaration below
+}
+
+var (
+	goGrammar = &grammar{
+		declaration: regexp.MustCompile(`^(func|type|var|const|import)\b`),
+		leading:     regexp.MustCompile(`^\s*//`),
+	}
+	scriptGrammar = &grammar{
+		declaration: regexp.MustCompile(`^(export\s+)?(default\s+)?(declare\s+)?(async\s+)?(abstract\s+)?(function\*?|class|interface|type|enum|const|let|var|namespace|module)\b|^export\b`),
+		member:      regexp.MustCompile(`^\s{2,4}((public|private|protected|static|readonly|async|get|set|override)\s+)*[A-Za-z_$#][\w$]*\s*(<[^>]*>)?\s*\([^;]*$|^\s{2,4}(export\s+)?(async\s+)?function\b`),
+		leading:     regexp.MustCompile(`^\s*(//|/\*|\*|@\w)`),
+	}
+	pythonGrammar = &grammar{
+		declaration: regexp.MustCompile(`^(async\s+def|def|class)\s`),
+		member:      regexp.MustCompile(`^\s+(async\s+def|def|class)\s`),
+		leading:     regexp.MustCompile(`^\s*(#|@)`),
+	}
+)
+
+var grammarsByExtension = map[string]*grammar{
+	".go":  goGrammar,
+	".ts":  scriptGrammar,
+	".tsx": scriptGrammar,
+	".js":  scriptGrammar,
+	".jsx": scriptGrammar,
+	".mjs": scriptGrammar,
+	".cjs": scriptGrammar,
+	".py":  pythonGrammar,
+}
+
+// span is a range of lines [start, end)
+type span struct {
+	start, end int
+}
+
+// document holds a file's lines with the running rune count, so a span's tokens cost nothing to estimate
+type document struct {
+	lines []string
+	runes []int // runes[i] counts the runes of lines[:i], newlines included
+}
+
+func newDocument(content string) *document {
+	d := &document{lines: strings.Split(content, "\n")}
+	d.runes = make([]int, len(d.lines)+1)
+	for i, line := range d.lines {
+		d.runes[i+1] = d.runes[i] + utf8.RuneCountInString(line) + 1
+	}
+	return d
+}
+
+// tokens applies estimateTokens to the lines of s
+func (d *document) tokens(s span) int {
+	return (d.runes[s.end]-d.runes[s.start])/3 + 10
+}
+
+// splitSyntax chunks content between declarations, keeping each declaration whole unless it alone
+// exceeds maxTokens; it returns nil when the language is unknown or no declarations are found
+func splitSyntax(content string, maxTokens int, path string) []Chunk {
+	g := grammarsByExtension[strings.ToLower(filepath.Ext(path))]
+	if g == nil {
+		return nil
+	}
+	doc := newDocument(content)
+	lines := doc.lines
+	declarations := boundaries(lines, span{0, len(lines)}, g.declaration, g.leading)
+	if len(declarations) < 2 {
+		return nil
+	}
+
+	// Declarations too large for a chunk are split between their members, and by lines when that fails
+	var units []span
+	for _, declaration := range declarations {
+		if doc.tokens(declaration) <= maxTokens {
+			units = append(units, declaration)
+			continue
+		}
+		var members []span
+		if g.member != nil {
+			members = boundaries(lines, declaration, g.member, g.leading)
+		}
+		if len(members) < 2 {
+			members = []span{declaration}
+		} else if doc.tokens(span{members[0].start, members[1].end}) <= maxTokens {
+			// A class header stays with its first member rather than with the declaration above
+			members[1].start = members[0].start
+			members = members[1:]
+		}
+		for _, member := range members {
+			if doc.tokens(member) <= maxTokens {
+				units = append(units, member)
+			} else {
+				units = append(units, windows(doc, member, maxTokens)...)
+			}
+		}
+	}
+	return buildChunks(doc, pack(doc, units, maxTokens))
+}
+
+// boundaries splits within into spans that each start at a line matching start, pulling the
+// leading comment and decorator lines above it into the span; lines before the first match,
+// such as a file's package clause and imports, form a span of their own
+func boundaries(lines []string, within span, start, leading *regexp.Regexp) []span {
+	var starts []int
+	for i := within.start + 1; i < within.end; i++ {
+		if !start.MatchString(lines[i]) {
+			continue
+		}
+		first := i
+		previous := within.start
+		if len(starts) > 0 {
+			previous = starts[len(starts)-1]
+		}
+		for first-1 > previous && strings.TrimSpace(lines[first-1]) != "" && leading.MatchString(lines[first-1]) {
+			first--
+		}
+		starts = append(starts, first)
+	}
+
+	spans := make([]span, 0, len(starts)+1)
+	begin := within.start
+	for _, s := range starts {
+		if s > begin {
+			spans = append(spans, span{begin, s})
+			begin = s
+		}
+	}
+	return append(spans, span{begin, within.end})
+}
+
+// pack merges consecutive units into spans of up to maxTokens
+func pack(doc *document, units []span, maxTokens int) []span {
+	var packed []span
+	for _, unit := range units {
+		if n := len(packed); n > 0 && doc.tokens(span{packed[n-1].start, unit.end}) <= maxTokens {
+			packed[n-1].end = unit.end
+			continue
+		}
+		packed = append(packed, unit)
+	}
+	return packed
+}
+
+// windows splits within into spans of whole lines up to maxTokens; a single line longer than
+// the limit becomes a span of its own
+func windows(doc *document, within span, maxTokens int) []span {
+	var spans []span
+	current := span{within.start, within.start}
+	for i := within.start; i < within.end; i++ {
+		if current.end > current.start && doc.tokens(span{current.start, i + 1}) > maxTokens {
+			spans = append(spans, current)
+			current = span{i, i}
+		}
+		current.end = i + 1
+	}
+	if current.end > current.start {
+		spans = append(spans, current)
+	}
+	return spans
+}
+
+func buildChunks(doc *document, spans []span) []Chunk {
+	chunks := make([]Chunk, 0, len(spans))
+	for _, s := range spans {
+		content := strings.Join(doc.lines[s.start:s.end], "\n") + "\n"
+		chunks = append(chunks, Chunk{
+			Content:   content,
+			StartLine: s.start + 1,
+			EndLine:   s.end,
+			Tokens:    estimateTokens(content),
+		})
+	}
+	return chunks
+}
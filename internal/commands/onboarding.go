@@ -36,6 +36,8 @@ func (oc *OnboardingCommands) ExecuteCommand(command string) error {
 		return oc.ListServices()
 	case "set config", "config":
 		return oc.SetConfig()
+	case "auth model", "auth":
+		return oc.AuthModel()
 	default:
 		return fmt.Errorf("unsupported command: %s", command)
 	}
@@ -79,6 +81,40 @@ func (oc *OnboardingCommands) SetConfig() error {
 	return nil
 }
 
+// AuthModel shows how each service authenticates and authorizes requests, and where it is enforced
+func (oc *OnboardingCommands) AuthModel() error {
+	report := oc.analysisResult.AuthSurface
+	if report == nil {
+		return oc.createFramedException("No Auth Model Found",
+			"No JWT, OAuth, session, API key, Basic or role checks were found in this project.",
+			"Requests may be authenticated upstream, e.g. by an API gateway or reverse proxy.")
+	}
+
+	lines := []string{
+		"🔐 AUTH MODEL",
+		"",
+		report.Summary,
+	}
+	for _, service := range report.Services {
+		name := service.Service
+		if name == "" {
+			name = "(outside services)"
+		}
+		lines = append(lines, "", fmt.Sprintf("• %s", name))
+		lines = append(lines, fmt.Sprintf("   %s", service.Model))
+		for i, point := range service.EnforcementPoints {
+			if i == 5 {
+				lines = append(lines, fmt.Sprintf("   ... and %d more enforcement points", len(service.EnforcementPoints)-i))
+				break
+			}
+			lines = append(lines, fmt.Sprintf("   ↳ %s:%d (%s)", point.File, point.Line, point.Detail))
+		}
+	}
+
+	fmt.Println(oc.createFrame(lines, 80))
+	return nil
+}
+
 // validateSupportedProject ensures the project is React.js, Node.js, or Go
 func (oc *OnboardingCommands) validateSupportedProject() error {
 	if oc.analysisResult == nil || oc.analysisResult.ProjectSummary == nil {
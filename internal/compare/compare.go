@@ -0,0 +1,209 @@
+package compare
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"repo-explanation/config"
+	"repo-explanation/internal/endpoints"
+	"repo-explanation/internal/pipeline"
+)
+
+// DefaultPhases are the pipeline phases a comparison runs when the request does not choose: the
+// ones whose output is compared. File and folder summaries are skipped, so no LLM call is needed.
+var DefaultPhases = []string{pipeline.PhaseServices, pipeline.PhaseSchema, pipeline.PhaseSecrets}
+
+// Ref is one side of a comparison
+type Ref struct {
+	Ref    string `json:"ref"`
+	Commit string `json:"commit"`
+}
+
+// Result is the architectural difference between two refs of a repository
+type Result struct {
+	Path         string            `json:"path"`
+	RefA         Ref               `json:"ref_a"` // Base
+	RefB         Ref               `json:"ref_b"` // Compared against the base
+	Services     ServiceChanges    `json:"services"`
+	Schema       SchemaChanges     `json:"schema"`
+	Endpoints    EndpointChanges   `json:"endpoints"`
+	Dependencies DependencyChanges `json:"dependencies"`
+	Secrets      SecretChanges     `json:"secrets"`
+	Summary      string            `json:"summary"`
+}
+
+// Snapshot is everything compared about one ref; it can be stored and diffed against a later one
+type Snapshot struct {
+	Ref          Ref                      `json:"ref"`
+	Result       *pipeline.AnalysisResult `json:"result"`
+	Endpoints    []endpoints.Endpoint     `json:"endpoints"`
+	Dependencies map[string]Dependency    `json:"dependencies"`
+}
+
+// Comparer checks out two refs of a local repository and compares their analyses
+type Comparer struct {
+	config      *config.Config
+	projectPath string
+	refA, refB  string
+	options     pipeline.AnalysisOptions
+}
+
+// NewComparer creates a comparer for two refs (branches, tags or commits) of the repository
+// containing projectPath; options without phases run DefaultPhases
+func NewComparer(cfg *config.Config, projectPath, refA, refB string, opts pipeline.AnalysisOptions) (*Comparer, error) {
+	for _, ref := range []string{refA, refB} {
+		if err := ValidateRef(ref); err != nil {
+			return nil, err
+		}
+	}
+	if len(opts.Phases) == 0 {
+		opts.Phases = DefaultPhases
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid analysis options: %v", err)
+	}
+	return &Comparer{
+		config:      cfg,
+		projectPath: projectPath,
+		refA:        refA,
+		refB:        refB,
+		options:     opts,
+	}, nil
+}
+
+// ValidateRef rejects refs that git could read as options or that cannot name a commit
+func ValidateRef(ref string) error {
+	if ref == "" {
+		return fmt.Errorf("ref must not be empty")
+	}
+	if strings.HasPrefix(ref, "-") || strings.ContainsAny(ref, " \t\n\\") || strings.Contains(ref, "..") {
+		return fmt.Errorf("invalid ref %q", ref)
+	}
+	return nil
+}
+
+// Compare analyzes both refs in temporary worktrees, removed afterwards, and diffs the results
+func (c *Comparer) Compare(ctx context.Context, callback pipeline.ProgressCallback) (*Result, error) {
+	root, err := git(ctx, c.projectPath, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("%s is not inside a git repository: %v", c.projectPath, err)
+	}
+	// A path below the repository root is compared at the same place in each worktree
+	subdir, err := filepath.Rel(root, c.projectPath)
+	if err != nil {
+		subdir = "."
+	}
+
+	tempDir := filepath.Join(os.TempDir(), "repo-analysis", fmt.Sprintf("compare-%d", time.Now().UnixNano()))
+	if err := os.MkdirAll(tempDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			fmt.Printf("Warning: Failed to clean up temp directory %s: %v\n", tempDir, err)
+		}
+	}()
+
+	var snapshots [2]*Snapshot
+	for i, ref := range []string{c.refA, c.refB} {
+		// Map each ref's 0-100 progress into its half of the first 90%
+		refCallback := func(eventType, stage, message string, progress int, data interface{}) {
+			callback(eventType, fmt.Sprintf("[%s] %s", ref, stage), message, i*45+progress*45/100, nil)
+		}
+		snap, err := c.analyzeRef(ctx, root, subdir, ref, filepath.Join(tempDir, fmt.Sprintf("ref-%d", i)), refCallback)
+		if err != nil {
+			return nil, err
+		}
+		snapshots[i] = snap
+	}
+
+	callback("progress", "🔀 Comparing refs...", "Diffing services, schema, endpoints, dependencies and secrets", 92, nil)
+	result := Diff(snapshots[0], snapshots[1])
+	result.Path = c.projectPath
+	fmt.Printf("✅ [COMPARE] %s\n", result.Summary)
+	return result, nil
+}
+
+// analyzeRef checks ref out into dir and runs the scoped analysis on it
+func (c *Comparer) analyzeRef(ctx context.Context, root, subdir, ref, dir string, callback pipeline.ProgressCallback) (*Snapshot, error) {
+	commit, err := git(ctx, root, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		return nil, fmt.Errorf("unknown ref %q", ref)
+	}
+
+	fmt.Printf("🔀 [COMPARE] Checking out %s (%s) into %s\n", ref, shortCommit(commit), dir)
+	if _, err := git(ctx, root, "worktree", "add", "--detach", dir, commit); err != nil {
+		return nil, fmt.Errorf("failed to check out %s: %v", ref, err)
+	}
+	defer func() {
+		// A fresh context: the worktree must be unregistered even when the analysis was cancelled
+		if _, err := git(context.Background(), root, "worktree", "remove", "--force", dir); err != nil {
+			fmt.Printf("Warning: Failed to remove worktree %s: %v\n", dir, err)
+			git(context.Background(), root, "worktree", "prune")
+		}
+	}()
+
+	projectPath := filepath.Join(dir, subdir)
+	if info, err := os.Stat(projectPath); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("%s does not exist at %s", subdir, ref)
+	}
+
+	analyzer, err := pipeline.NewAnalyzer(c.config, projectPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create analyzer: %v", err)
+	}
+	if err := analyzer.SetOptions(c.options); err != nil {
+		return nil, fmt.Errorf("invalid analysis options: %v", err)
+	}
+	result, err := analyzer.AnalyzeProjectWithProgress(ctx, callback)
+	if err != nil {
+		return nil, fmt.Errorf("analysis of %s failed: %v", ref, err)
+	}
+
+	snap, err := Capture(projectPath, Ref{Ref: ref, Commit: commit}, result, c.config.GetScanFileBytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to capture %s: %v", ref, err)
+	}
+	return snap, nil
+}
+
+// Capture completes the analysis of a checkout with its endpoints and declared dependencies, reading
+// source files up to maxFileSize bytes
+func Capture(projectPath string, ref Ref, result *pipeline.AnalysisResult, maxFileSize int64) (*Snapshot, error) {
+	routes, err := endpoints.Extract(projectPath, maxFileSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract endpoints: %v", err)
+	}
+	deps, err := ReadDependencies(projectPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dependencies: %v", err)
+	}
+	return &Snapshot{
+		Ref:          ref,
+		Result:       result,
+		Endpoints:    routes,
+		Dependencies: deps,
+	}, nil
+}
+
+// git runs a git command in dir and returns its trimmed output
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %v: %s", args[0], err, strings.TrimSpace(string(output)))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
@@ -0,0 +1,262 @@
+package compare
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Dependency is one package a manifest declares
+type Dependency struct {
+	Name      string `json:"name"`
+	Version   string `json:"version,omitempty"`
+	Ecosystem string `json:"ecosystem"` // npm, go, pip, cargo, gem, maven, gradle, composer
+	Manifest  string `json:"manifest"`
+}
+
+// Directories that never hold the project's own manifests
+var skippedDirs = map[string]bool{
+	".git": true, "node_modules": true, "vendor": true, "dist": true, "build": true, ".next": true,
+	"__pycache__": true, ".venv": true, "venv": true, "target": true, "testdata": true,
+}
+
+// maxManifestSize bounds the manifests read; lockfiles are not read at all
+const maxManifestSize = 512 * 1024
+
+var (
+	goRequireLineRegex  = regexp.MustCompile(`(?m)^\s*require\s+(\S+)\s+(\S+)`)
+	goRequireBlockRegex = regexp.MustCompile(`(?s)require\s*\((.*?)\)`)
+	requirementRegex    = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)(?:\[[^\]]*\])?\s*((?:[=<>!~]=?|===)\s*[^;#\s]+)?`)
+	tomlSectionRegex    = regexp.MustCompile(`^\[([^\]]+)\]`)
+	tomlDepRegex        = regexp.MustCompile(`^([A-Za-z0-9_.-]+)\s*=\s*(?:"([^"]*)"|\{[^}]*version\s*=\s*"([^"]*)")?`)
+	pyprojectListRegex  = regexp.MustCompile(`(?s)\bdependencies\s*=\s*\[(.*?)\]`)
+	quotedRegex         = regexp.MustCompile(`["']([^"']+)["']`)
+	gemRegex            = regexp.MustCompile(`^\s*gem\s+['"]([^'"]+)['"](?:\s*,\s*['"]([^'"]+)['"])?`)
+	mavenDepRegex       = regexp.MustCompile(`(?s)<dependency>(.*?)</dependency>`)
+	mavenFieldRegex     = regexp.MustCompile(`<(groupId|artifactId|version)>\s*([^<]+?)\s*</`)
+	gradleDepRegex      = regexp.MustCompile(`\b(?:implementation|api|compileOnly|runtimeOnly|testImplementation|kapt|annotationProcessor)\s*\(?\s*['"]([^:'"]+):([^:'"]+)(?::([^'"]+))?['"]`)
+)
+
+// ReadDependencies lists the dependencies declared by every manifest in the project, keyed by
+// manifest and package name
+func ReadDependencies(projectPath string) (map[string]Dependency, error) {
+	deps := make(map[string]Dependency)
+	err := filepath.Walk(projectPath, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if filePath != projectPath && skippedDirs[info.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		parse := manifestParser(info.Name())
+		if parse == nil || info.Size() > maxManifestSize {
+			return nil
+		}
+		rel, err := filepath.Rel(projectPath, filePath)
+		if err != nil {
+			return nil
+		}
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		for _, dep := range parse(string(content)) {
+			dep.Manifest = rel
+			deps[rel+"|"+dep.Name] = dep
+		}
+		return nil
+	})
+	return deps, err
+}
+
+func manifestParser(name string) func(string) []Dependency {
+	switch {
+	case name == "package.json":
+		return packageJSONDependencies
+	case name == "go.mod":
+		return goModDependencies
+	case name == "requirements.txt" || (strings.HasPrefix(name, "requirements") && path.Ext(name) == ".txt"):
+		return requirementsDependencies
+	case name == "pyproject.toml":
+		return pyprojectDependencies
+	case name == "Cargo.toml":
+		return cargoDependencies
+	case name == "Gemfile":
+		return gemfileDependencies
+	case name == "pom.xml":
+		return mavenDependencies
+	case name == "build.gradle" || name == "build.gradle.kts":
+		return gradleDependencies
+	case name == "composer.json":
+		return composerDependencies
+	}
+	return nil
+}
+
+func packageJSONDependencies(content string) []Dependency {
+	var manifest struct {
+		Dependencies         map[string]string `json:"dependencies"`
+		DevDependencies      map[string]string `json:"devDependencies"`
+		PeerDependencies     map[string]string `json:"peerDependencies"`
+		OptionalDependencies map[string]string `json:"optionalDependencies"`
+	}
+	if json.Unmarshal([]byte(content), &manifest) != nil {
+		return nil
+	}
+	var deps []Dependency
+	for _, group := range []map[string]string{manifest.Dependencies, manifest.DevDependencies, manifest.PeerDependencies, manifest.OptionalDependencies} {
+		for name, version := range group {
+			deps = append(deps, Dependency{Name: name, Version: version, Ecosystem: "npm"})
+		}
+	}
+	return deps
+}
+
+func composerDependencies(content string) []Dependency {
+	var manifest struct {
+		Require    map[string]string `json:"require"`
+		RequireDev map[string]string `json:"require-dev"`
+	}
+	if json.Unmarshal([]byte(content), &manifest) != nil {
+		return nil
+	}
+	var deps []Dependency
+	for _, group := range []map[string]string{manifest.Require, manifest.RequireDev} {
+		for name, version := range group {
+			deps = append(deps, Dependency{Name: name, Version: version, Ecosystem: "composer"})
+		}
+	}
+	return deps
+}
+
+func goModDependencies(content string) []Dependency {
+	var deps []Dependency
+	for _, match := range goRequireLineRegex.FindAllStringSubmatch(content, -1) {
+		if match[1] != "(" {
+			deps = append(deps, Dependency{Name: match[1], Version: match[2], Ecosystem: "go"})
+		}
+	}
+	for _, block := range goRequireBlockRegex.FindAllStringSubmatch(content, -1) {
+		for _, line := range strings.Split(block[1], "\n") {
+			if fields := strings.Fields(line); len(fields) >= 2 && !strings.HasPrefix(fields[0], "//") {
+				deps = append(deps, Dependency{Name: fields[0], Version: fields[1], Ecosystem: "go"})
+			}
+		}
+	}
+	return deps
+}
+
+func requirementsDependencies(content string) []Dependency {
+	var deps []Dependency
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "-") {
+			continue
+		}
+		if dep, ok := requirement(line); ok {
+			deps = append(deps, dep)
+		}
+	}
+	return deps
+}
+
+func requirement(spec string) (Dependency, bool) {
+	match := requirementRegex.FindStringSubmatch(strings.TrimSpace(spec))
+	if match == nil {
+		return Dependency{}, false
+	}
+	return Dependency{Name: strings.ToLower(match[1]), Version: strings.ReplaceAll(match[2], " ", ""), Ecosystem: "pip"}, true
+}
+
+func pyprojectDependencies(content string) []Dependency {
+	var deps []Dependency
+	// PEP 621: dependencies = ["requests>=2", ...]
+	for _, list := range pyprojectListRegex.FindAllStringSubmatch(content, -1) {
+		for _, quoted := range quotedRegex.FindAllStringSubmatch(list[1], -1) {
+			if dep, ok := requirement(quoted[1]); ok {
+				deps = append(deps, dep)
+			}
+		}
+	}
+	// Poetry: [tool.poetry.dependencies] tables
+	for _, dep := range tomlDependencies(content, "pip", func(section string) bool {
+		return strings.HasPrefix(section, "tool.poetry.") && strings.HasSuffix(section, "dependencies")
+	}) {
+		if dep.Name != "python" {
+			dep.Name = strings.ToLower(dep.Name)
+			deps = append(deps, dep)
+		}
+	}
+	return deps
+}
+
+func cargoDependencies(content string) []Dependency {
+	return tomlDependencies(content, "cargo", func(section string) bool {
+		return strings.HasSuffix(section, "dependencies")
+	})
+}
+
+// tomlDependencies reads `name = "version"` and `name = { version = "..." }` lines from the sections
+// the filter accepts
+func tomlDependencies(content, ecosystem string, inSection func(string) bool) []Dependency {
+	var deps []Dependency
+	active := false
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if match := tomlSectionRegex.FindStringSubmatch(line); match != nil {
+			active = inSection(match[1])
+			continue
+		}
+		if !active || line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if match := tomlDepRegex.FindStringSubmatch(line); match != nil {
+			version := match[2]
+			if version == "" {
+				version = match[3]
+			}
+			deps = append(deps, Dependency{Name: match[1], Version: version, Ecosystem: ecosystem})
+		}
+	}
+	return deps
+}
+
+func gemfileDependencies(content string) []Dependency {
+	var deps []Dependency
+	for _, line := range strings.Split(content, "\n") {
+		if match := gemRegex.FindStringSubmatch(line); match != nil {
+			deps = append(deps, Dependency{Name: match[1], Version: match[2], Ecosystem: "gem"})
+		}
+	}
+	return deps
+}
+
+func mavenDependencies(content string) []Dependency {
+	var deps []Dependency
+	for _, block := range mavenDepRegex.FindAllStringSubmatch(content, -1) {
+		fields := make(map[string]string)
+		for _, field := range mavenFieldRegex.FindAllStringSubmatch(block[1], -1) {
+			fields[field[1]] = field[2]
+		}
+		if fields["artifactId"] == "" {
+			continue
+		}
+		deps = append(deps, Dependency{Name: fields["groupId"] + ":" + fields["artifactId"], Version: fields["version"], Ecosystem: "maven"})
+	}
+	return deps
+}
+
+func gradleDependencies(content string) []Dependency {
+	var deps []Dependency
+	for _, match := range gradleDepRegex.FindAllStringSubmatch(content, -1) {
+		deps = append(deps, Dependency{Name: match[1] + ":" + match[2], Version: match[3], Ecosystem: "gradle"})
+	}
+	return deps
+}
@@ -0,0 +1,418 @@
+package compare
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"repo-explanation/internal/database"
+	"repo-explanation/internal/endpoints"
+	"repo-explanation/internal/microservices"
+	"repo-explanation/internal/pipeline"
+	"repo-explanation/internal/relationships"
+	"repo-explanation/internal/secrets"
+)
+
+// ServiceChanges lists services added, removed or changed between the refs
+type ServiceChanges struct {
+	Added                []microservices.DiscoveredService `json:"added"`
+	Removed              []microservices.DiscoveredService `json:"removed"`
+	Changed              []ServiceChange                   `json:"changed"`
+	AddedRelationships   []string                          `json:"added_relationships"` // "from → to"
+	RemovedRelationships []string                          `json:"removed_relationships"`
+}
+
+// ServiceChange describes how one service present on both refs changed
+type ServiceChange struct {
+	Name    string   `json:"name"`
+	Changes []string `json:"changes"` // e.g. "port: 8080 → 9090"
+}
+
+// SchemaChanges lists the database schema differences between the refs
+type SchemaChanges struct {
+	AddedTables        []string      `json:"added_tables"`
+	RemovedTables      []string      `json:"removed_tables"`
+	ChangedTables      []TableChange `json:"changed_tables"`
+	AddedForeignKeys   []string      `json:"added_foreign_keys"` // "orders.user_id → users.id"
+	RemovedForeignKeys []string      `json:"removed_foreign_keys"`
+}
+
+// TableChange describes how one table present on both refs changed
+type TableChange struct {
+	Table          string   `json:"table"`
+	AddedColumns   []string `json:"added_columns,omitempty"`
+	RemovedColumns []string `json:"removed_columns,omitempty"`
+	ChangedColumns []string `json:"changed_columns,omitempty"` // e.g. "amount: integer → numeric"
+}
+
+// EndpointChanges lists HTTP endpoints added or removed, matched by method and path
+type EndpointChanges struct {
+	Added   []endpoints.Endpoint `json:"added"`
+	Removed []endpoints.Endpoint `json:"removed"`
+}
+
+// DependencyChanges lists manifest dependencies added, removed or moved to another version
+type DependencyChanges struct {
+	Added   []Dependency       `json:"added"`
+	Removed []Dependency       `json:"removed"`
+	Changed []DependencyChange `json:"changed"`
+}
+
+// DependencyChange is a dependency whose declared version changed
+type DependencyChange struct {
+	Name      string `json:"name"`
+	Ecosystem string `json:"ecosystem"`
+	Manifest  string `json:"manifest"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+}
+
+// SecretChanges lists environment variables added or removed, and those that became required
+type SecretChanges struct {
+	Added       []SecretChange `json:"added"`
+	Removed     []SecretChange `json:"removed"`
+	NowRequired []string       `json:"now_required"`
+}
+
+// SecretChange is one environment variable and the services that use it
+type SecretChange struct {
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	Required bool     `json:"required"`
+	Services []string `json:"services,omitempty"`
+}
+
+// Diff compares snapshot b against the base snapshot a
+func Diff(a, b *Snapshot) *Result {
+	result := &Result{
+		RefA:         a.Ref,
+		RefB:         b.Ref,
+		Services:     diffServices(a.Result, b.Result),
+		Schema:       diffSchema(a.Result.DatabaseSchema, b.Result.DatabaseSchema),
+		Endpoints:    diffEndpoints(a.Endpoints, b.Endpoints),
+		Dependencies: diffDependencies(a.Dependencies, b.Dependencies),
+		Secrets:      diffSecrets(a.Result.ProjectSecrets, b.Result.ProjectSecrets),
+	}
+	result.Summary = summarize(result)
+	return result
+}
+
+func diffServices(a, b *pipeline.AnalysisResult) ServiceChanges {
+	changes := ServiceChanges{
+		Added:   []microservices.DiscoveredService{},
+		Removed: []microservices.DiscoveredService{},
+		Changed: []ServiceChange{},
+	}
+	before := make(map[string]microservices.DiscoveredService)
+	for _, service := range a.Services {
+		before[service.Name] = service
+	}
+	after := make(map[string]microservices.DiscoveredService)
+	for _, service := range b.Services {
+		after[service.Name] = service
+		old, ok := before[service.Name]
+		if !ok {
+			changes.Added = append(changes.Added, service)
+			continue
+		}
+		if diffs := serviceDiffs(old, service); len(diffs) > 0 {
+			changes.Changed = append(changes.Changed, ServiceChange{Name: service.Name, Changes: diffs})
+		}
+	}
+	for _, service := range a.Services {
+		if _, ok := after[service.Name]; !ok {
+			changes.Removed = append(changes.Removed, service)
+		}
+	}
+
+	changes.AddedRelationships, changes.RemovedRelationships = diffSets(relationshipSet(a.ServiceRelationships), relationshipSet(b.ServiceRelationships))
+	return changes
+}
+
+func serviceDiffs(a, b microservices.DiscoveredService) []string {
+	var diffs []string
+	field := func(name, before, after string) {
+		if before != after {
+			diffs = append(diffs, fmt.Sprintf("%s: %s → %s", name, orNone(before), orNone(after)))
+		}
+	}
+	field("path", a.Path, b.Path)
+	field("entry point", a.EntryPoint, b.EntryPoint)
+	field("api type", string(a.APIType), string(b.APIType))
+	field("port", a.Port, b.Port)
+
+	var imageA, imageB, userA, userB string
+	if a.Container != nil {
+		imageA, userA = a.Container.BaseImage, a.Container.User
+	}
+	if b.Container != nil {
+		imageB, userB = b.Container.BaseImage, b.Container.User
+	}
+	field("base image", imageA, imageB)
+	field("container user", userA, userB)
+	return diffs
+}
+
+func relationshipSet(rels []relationships.ServiceRelationship) map[string]bool {
+	set := make(map[string]bool)
+	for _, rel := range rels {
+		set[rel.From+" → "+rel.To] = true
+	}
+	return set
+}
+
+func diffSchema(a, b *database.DatabaseSchema) SchemaChanges {
+	changes := SchemaChanges{ChangedTables: []TableChange{}}
+	tablesA, tablesB := tables(a), tables(b)
+
+	changes.AddedTables, changes.RemovedTables = diffSets(keySet(tablesA), keySet(tablesB))
+	for _, name := range sortedKeys(tablesB) {
+		old, ok := tablesA[name]
+		if !ok {
+			continue
+		}
+		table := tablesB[name]
+		change := TableChange{Table: name}
+		change.AddedColumns, change.RemovedColumns = diffSets(keySet(old.Columns), keySet(table.Columns))
+		for _, column := range sortedKeys(table.Columns) {
+			before, ok := old.Columns[column]
+			if !ok {
+				continue
+			}
+			if diff := columnDiff(before, table.Columns[column]); diff != "" {
+				change.ChangedColumns = append(change.ChangedColumns, column+": "+diff)
+			}
+		}
+		if len(change.AddedColumns)+len(change.RemovedColumns)+len(change.ChangedColumns) > 0 {
+			changes.ChangedTables = append(changes.ChangedTables, change)
+		}
+	}
+
+	changes.AddedForeignKeys, changes.RemovedForeignKeys = diffSets(foreignKeys(tablesA), foreignKeys(tablesB))
+	return changes
+}
+
+func tables(schema *database.DatabaseSchema) map[string]database.Table {
+	if schema == nil || schema.Tables == nil {
+		return map[string]database.Table{}
+	}
+	return schema.Tables
+}
+
+func columnDiff(a, b database.Column) string {
+	var parts []string
+	if !strings.EqualFold(a.Type, b.Type) {
+		parts = append(parts, fmt.Sprintf("%s → %s", a.Type, b.Type))
+	}
+	before, after := constraintList(a), constraintList(b)
+	if before != after {
+		parts = append(parts, fmt.Sprintf("constraints %s → %s", orNone(before), orNone(after)))
+	}
+	if a.DefaultValue != b.DefaultValue {
+		parts = append(parts, fmt.Sprintf("default %s → %s", orNone(a.DefaultValue), orNone(b.DefaultValue)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func constraintList(column database.Column) string {
+	var constraints []string
+	for _, constraint := range column.Constraints {
+		constraints = append(constraints, string(constraint))
+	}
+	sort.Strings(constraints)
+	return strings.Join(constraints, " ")
+}
+
+func foreignKeys(tables map[string]database.Table) map[string]bool {
+	set := make(map[string]bool)
+	for name, table := range tables {
+		for column, definition := range table.Columns {
+			if definition.References != nil {
+				set[fmt.Sprintf("%s.%s → %s.%s", name, column, definition.References.Table, definition.References.Column)] = true
+			}
+		}
+	}
+	return set
+}
+
+func diffEndpoints(a, b []endpoints.Endpoint) EndpointChanges {
+	changes := EndpointChanges{Added: []endpoints.Endpoint{}, Removed: []endpoints.Endpoint{}}
+	before := make(map[string]bool)
+	for _, endpoint := range a {
+		before[endpoint.Key()] = true
+	}
+	after := make(map[string]bool)
+	for _, endpoint := range b {
+		if !before[endpoint.Key()] && !after[endpoint.Key()] {
+			changes.Added = append(changes.Added, endpoint)
+		}
+		after[endpoint.Key()] = true
+	}
+	for _, endpoint := range a {
+		if !after[endpoint.Key()] {
+			changes.Removed = append(changes.Removed, endpoint)
+			after[endpoint.Key()] = true // Report each removed route once
+		}
+	}
+	return changes
+}
+
+func diffDependencies(a, b map[string]Dependency) DependencyChanges {
+	changes := DependencyChanges{Added: []Dependency{}, Removed: []Dependency{}, Changed: []DependencyChange{}}
+	for _, key := range sortedKeys(b) {
+		dep := b[key]
+		old, ok := a[key]
+		switch {
+		case !ok:
+			changes.Added = append(changes.Added, dep)
+		case old.Version != dep.Version:
+			changes.Changed = append(changes.Changed, DependencyChange{Name: dep.Name, Ecosystem: dep.Ecosystem, Manifest: dep.Manifest, From: old.Version, To: dep.Version})
+		}
+	}
+	for _, key := range sortedKeys(a) {
+		if _, ok := b[key]; !ok {
+			changes.Removed = append(changes.Removed, a[key])
+		}
+	}
+	return changes
+}
+
+func diffSecrets(a, b *secrets.ProjectSecrets) SecretChanges {
+	changes := SecretChanges{Added: []SecretChange{}, Removed: []SecretChange{}, NowRequired: []string{}}
+	before, after := secretVariables(a), secretVariables(b)
+	for _, name := range sortedKeys(after) {
+		old, ok := before[name]
+		switch {
+		case !ok:
+			changes.Added = append(changes.Added, *after[name])
+		case after[name].Required && !old.Required:
+			changes.NowRequired = append(changes.NowRequired, name)
+		}
+	}
+	for _, name := range sortedKeys(before) {
+		if _, ok := after[name]; !ok {
+			changes.Removed = append(changes.Removed, *before[name])
+		}
+	}
+	return changes
+}
+
+// secretVariables flattens the project's variables by name, recording the services that use each
+func secretVariables(project *secrets.ProjectSecrets) map[string]*SecretChange {
+	variables := make(map[string]*SecretChange)
+	if project == nil {
+		return variables
+	}
+	add := func(variable secrets.SecretVariable, service string) {
+		entry, ok := variables[variable.Name]
+		if !ok {
+			entry = &SecretChange{Name: variable.Name, Type: variable.Type}
+			variables[variable.Name] = entry
+		}
+		entry.Required = entry.Required || variable.Required
+		if service != "" && !contains(entry.Services, service) {
+			entry.Services = append(entry.Services, service)
+		}
+	}
+	for _, variable := range project.GlobalSecrets {
+		add(variable, "")
+	}
+	for _, service := range project.Services {
+		for _, variable := range service.Variables {
+			add(variable, service.ServiceName)
+		}
+	}
+	for _, variable := range project.Unattributed {
+		add(variable, "")
+	}
+	return variables
+}
+
+// summarize describes the comparison in one line
+func summarize(result *Result) string {
+	parts := changeCounts(result)
+	if len(parts) == 0 {
+		return fmt.Sprintf("No architectural changes between %s and %s", result.RefA.Ref, result.RefB.Ref)
+	}
+	return fmt.Sprintf("%s..%s: %s", result.RefA.Ref, result.RefB.Ref, strings.Join(parts, ", "))
+}
+
+// HasChanges reports whether anything compared differs between the refs
+func (r *Result) HasChanges() bool {
+	return len(changeCounts(r)) > 0
+}
+
+// changeCounts counts each kind of change, e.g. "2 new endpoints", omitting those that did not occur
+func changeCounts(result *Result) []string {
+	var parts []string
+	count := func(n int, singular, plural string) {
+		if n == 1 {
+			parts = append(parts, "1 "+singular)
+		} else if n > 1 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, plural))
+		}
+	}
+	count(len(result.Services.Added), "service added", "services added")
+	count(len(result.Services.Removed), "service removed", "services removed")
+	count(len(result.Services.Changed), "service changed", "services changed")
+	count(len(result.Schema.AddedTables), "table added", "tables added")
+	count(len(result.Schema.RemovedTables), "table removed", "tables removed")
+	count(len(result.Schema.ChangedTables), "table changed", "tables changed")
+	count(len(result.Endpoints.Added), "new endpoint", "new endpoints")
+	count(len(result.Endpoints.Removed), "endpoint removed", "endpoints removed")
+	count(len(result.Dependencies.Added), "new dependency", "new dependencies")
+	count(len(result.Dependencies.Removed), "dependency removed", "dependencies removed")
+	count(len(result.Dependencies.Changed), "dependency upgraded or downgraded", "dependencies upgraded or downgraded")
+	count(len(result.Secrets.Added), "new secret", "new secrets")
+	count(len(result.Secrets.Removed), "secret removed", "secrets removed")
+	return parts
+}
+
+// diffSets returns the keys only in b (added) and only in a (removed), sorted
+func diffSets(a, b map[string]bool) ([]string, []string) {
+	added, removed := []string{}, []string{}
+	for _, key := range sortedKeys(b) {
+		if !a[key] {
+			added = append(added, key)
+		}
+	}
+	for _, key := range sortedKeys(a) {
+		if !b[key] {
+			removed = append(removed, key)
+		}
+	}
+	return added, removed
+}
+
+func keySet[V any](m map[string]V) map[string]bool {
+	set := make(map[string]bool, len(m))
+	for key := range m {
+		set[key] = true
+	}
+	return set
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func contains(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
+
+func orNone(value string) string {
+	if value == "" {
+		return "(none)"
+	}
+	return value
+}
@@ -0,0 +1,107 @@
+package compare
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Text renders the comparison as a plain-text report for email and chat, one section per kind of change
+func (r *Result) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n", r.Summary)
+	if r.RefA.Commit != "" || r.RefB.Commit != "" {
+		fmt.Fprintf(&b, "Commits: %s → %s\n", orNone(shortCommit(r.RefA.Commit)), orNone(shortCommit(r.RefB.Commit)))
+	}
+
+	section := func(title string, lines []string) {
+		if len(lines) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "\n%s:\n", title)
+		for _, line := range lines {
+			fmt.Fprintf(&b, "  - %s\n", line)
+		}
+	}
+
+	var services []string
+	for _, service := range r.Services.Added {
+		services = append(services, fmt.Sprintf("added %s (%s)", service.Name, service.Path))
+	}
+	for _, service := range r.Services.Removed {
+		services = append(services, fmt.Sprintf("removed %s (%s)", service.Name, service.Path))
+	}
+	for _, change := range r.Services.Changed {
+		services = append(services, fmt.Sprintf("changed %s: %s", change.Name, strings.Join(change.Changes, "; ")))
+	}
+	section("Services", services)
+
+	var relationships []string
+	for _, rel := range r.Services.AddedRelationships {
+		relationships = append(relationships, "added "+rel)
+	}
+	for _, rel := range r.Services.RemovedRelationships {
+		relationships = append(relationships, "removed "+rel)
+	}
+	section("Service relationships", relationships)
+
+	var schema []string
+	for _, table := range r.Schema.AddedTables {
+		schema = append(schema, "added table "+table)
+	}
+	for _, table := range r.Schema.RemovedTables {
+		schema = append(schema, "removed table "+table)
+	}
+	for _, table := range r.Schema.ChangedTables {
+		var changes []string
+		for _, column := range table.AddedColumns {
+			changes = append(changes, "+"+column)
+		}
+		for _, column := range table.RemovedColumns {
+			changes = append(changes, "-"+column)
+		}
+		changes = append(changes, table.ChangedColumns...)
+		schema = append(schema, fmt.Sprintf("changed table %s: %s", table.Table, strings.Join(changes, ", ")))
+	}
+	for _, fk := range r.Schema.AddedForeignKeys {
+		schema = append(schema, "added foreign key "+fk)
+	}
+	for _, fk := range r.Schema.RemovedForeignKeys {
+		schema = append(schema, "removed foreign key "+fk)
+	}
+	section("Database schema", schema)
+
+	var routes []string
+	for _, endpoint := range r.Endpoints.Added {
+		routes = append(routes, fmt.Sprintf("added %s (%s:%d)", endpoint.Key(), endpoint.File, endpoint.Line))
+	}
+	for _, endpoint := range r.Endpoints.Removed {
+		routes = append(routes, "removed "+endpoint.Key())
+	}
+	section("Endpoints", routes)
+
+	var deps []string
+	for _, dep := range r.Dependencies.Added {
+		deps = append(deps, fmt.Sprintf("added %s %s (%s)", dep.Name, dep.Version, dep.Manifest))
+	}
+	for _, dep := range r.Dependencies.Removed {
+		deps = append(deps, fmt.Sprintf("removed %s (%s)", dep.Name, dep.Manifest))
+	}
+	for _, dep := range r.Dependencies.Changed {
+		deps = append(deps, fmt.Sprintf("%s %s → %s (%s)", dep.Name, orNone(dep.From), orNone(dep.To), dep.Manifest))
+	}
+	section("Dependencies", deps)
+
+	var secrets []string
+	for _, secret := range r.Secrets.Added {
+		secrets = append(secrets, "added "+secret.Name)
+	}
+	for _, secret := range r.Secrets.Removed {
+		secrets = append(secrets, "removed "+secret.Name)
+	}
+	for _, name := range r.Secrets.NowRequired {
+		secrets = append(secrets, "now required "+name)
+	}
+	section("Secrets", secrets)
+
+	return b.String()
+}
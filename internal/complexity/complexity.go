@@ -0,0 +1,161 @@
+package complexity
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Metrics are the measurable inputs of a complexity score
+type Metrics struct {
+	Files        int `json:"files"`
+	LOC          int `json:"loc"`          // Code lines of programming and markup files; comments and blanks excluded
+	Dependencies int `json:"dependencies"` // Service: services it calls. Project: service and workspace package edges
+	Tables       int `json:"tables"`       // Tables its migrations create; the project counts the extracted schema when there is one
+	Languages    int `json:"languages"`    // Programming languages with at least one line of code
+}
+
+// Factor is one metric's contribution to a score
+type Factor struct {
+	Name       string  `json:"name"`
+	Value      int     `json:"value"`
+	Weight     float64 `json:"weight"`     // Points the factor contributes at saturation
+	Floor      int     `json:"floor"`      // Value up to which the factor contributes nothing
+	Saturation int     `json:"saturation"` // Value from which the factor contributes its full weight
+	Scale      string  `json:"scale"`      // log or linear growth between floor and saturation
+	Points     float64 `json:"points"`
+}
+
+// Score rates the complexity of the project or one service from 0 to 100, with the expected time a
+// new engineer needs before making changes confidently
+type Score struct {
+	Name    string   `json:"name,omitempty"` // Service name; empty for the project
+	Path    string   `json:"path,omitempty"`
+	Metrics Metrics  `json:"metrics"`
+	Factors []Factor `json:"factors"`
+	Score   float64  `json:"score"`
+	Level   string   `json:"level"` // low, moderate, high or very high
+
+	OnboardingDays float64 `json:"onboarding_days"` // Working days, rounded to half days
+	Onboarding     string  `json:"onboarding"`      // e.g. "about 3 days (2-5 days)"
+}
+
+// Report scores the project and each discovered service
+type Report struct {
+	Project  Score   `json:"project"`
+	Services []Score `json:"services,omitempty"` // Most complex first
+	Formula  string  `json:"formula"`
+	Summary  string  `json:"summary"`
+}
+
+// factors are the weights, floors, saturations and scales of the score; the weights add up to 100.
+// Size grows on a log scale, since doubling a large codebase adds less to learn than doubling a
+// small one; every dependency and language beyond the first is another thing to learn.
+var factors = []struct {
+	name              string
+	weight            float64
+	floor, saturation int
+	scale             string
+	value             func(Metrics) int
+}{
+	{"files", 15, 10, 5000, "log", func(m Metrics) int { return m.Files }},
+	{"loc", 35, 1000, 500000, "log", func(m Metrics) int { return m.LOC }},
+	{"dependencies", 20, 0, 20, "linear", func(m Metrics) int { return m.Dependencies }},
+	{"tables", 15, 1, 200, "log", func(m Metrics) int { return m.Tables }},
+	{"languages", 15, 1, 6, "linear", func(m Metrics) int { return m.Languages }},
+}
+
+// Onboarding time grows linearly with the score, from half a day for a trivial codebase to
+// about ten days at the top of the scale; the range reflects how much prior experience matters
+const (
+	baseOnboardingHours     = 4
+	onboardingHoursPerPoint = 0.76
+	hoursPerDay             = 8
+)
+
+// Formula describes how scores and onboarding estimates are computed
+func Formula() string {
+	terms := make([]string, len(factors))
+	for i, f := range factors {
+		terms[i] = fmt.Sprintf("%g*%s(%s, %d, %d)", f.weight, f.scale, f.name, f.floor, f.saturation)
+	}
+	return fmt.Sprintf("score = %s, where linear(v, a, b) = (v-a)/(b-a) and log(v, a, b) = ln(v/a)/ln(b/a), clamped to 0-1; "+
+		"onboarding hours = %d + %g * score at %d hours per day, range 0.75x-1.5x",
+		strings.Join(terms, " + "), baseOnboardingHours, onboardingHoursPerPoint, hoursPerDay)
+}
+
+// Compute scores one set of metrics
+func Compute(name, path string, m Metrics) Score {
+	score := Score{Name: name, Path: path, Metrics: m}
+	for _, f := range factors {
+		value := f.value(m)
+		var fraction float64
+		switch {
+		case value <= f.floor:
+		case f.scale == "log":
+			fraction = math.Log(float64(value)/float64(f.floor)) / math.Log(float64(f.saturation)/float64(f.floor))
+		default:
+			fraction = float64(value-f.floor) / float64(f.saturation-f.floor)
+		}
+		points := math.Round(f.weight*math.Min(1, fraction)*10) / 10
+		score.Factors = append(score.Factors, Factor{
+			Name: f.name, Value: value, Weight: f.weight, Floor: f.floor, Saturation: f.saturation, Scale: f.scale, Points: points,
+		})
+		score.Score += points
+	}
+	score.Score = math.Round(score.Score*10) / 10
+	score.Level = level(score.Score)
+
+	hours := baseOnboardingHours + onboardingHoursPerPoint*score.Score
+	score.OnboardingDays = halfDays(hours)
+	score.Onboarding = fmt.Sprintf("about %s (%s-%s)", days(score.OnboardingDays), trimDays(halfDays(hours*0.75)), days(halfDays(hours*1.5)))
+	return score
+}
+
+// NewReport scores the project and its services
+func NewReport(project Metrics, services []Score) *Report {
+	report := &Report{Project: Compute("", "", project), Services: services, Formula: Formula()}
+	sort.SliceStable(report.Services, func(i, j int) bool {
+		if report.Services[i].Score != report.Services[j].Score {
+			return report.Services[i].Score > report.Services[j].Score
+		}
+		return report.Services[i].Name < report.Services[j].Name
+	})
+
+	report.Summary = fmt.Sprintf("Complexity %.0f/100 (%s): %d files, %d lines of code, %d languages; expected onboarding %s",
+		report.Project.Score, report.Project.Level, project.Files, project.LOC, project.Languages, report.Project.Onboarding)
+	if len(report.Services) > 0 {
+		top := report.Services[0]
+		report.Summary += fmt.Sprintf("; most complex service: %s (%.0f)", top.Name, top.Score)
+	}
+	return report
+}
+
+func level(score float64) string {
+	switch {
+	case score < 25:
+		return "low"
+	case score < 50:
+		return "moderate"
+	case score < 75:
+		return "high"
+	}
+	return "very high"
+}
+
+// halfDays converts hours to working days rounded to the nearest half day, at least half a day
+func halfDays(hours float64) float64 {
+	return math.Max(0.5, math.Round(hours/hoursPerDay*2)/2)
+}
+
+func days(d float64) string {
+	if d == 1 {
+		return "1 day"
+	}
+	return trimDays(d) + " days"
+}
+
+func trimDays(d float64) string {
+	return strings.TrimSuffix(fmt.Sprintf("%.1f", d), ".0")
+}
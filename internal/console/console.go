@@ -0,0 +1,346 @@
+// Package console renders what command-line analyses print: a progress bar on interactive
+// terminals, JSON lines for CI logs, or nothing but the final result. Analyses write their log to
+// standard output as they always have; the console captures it for the length of a run.
+package console
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"repo-explanation/internal/pipeline"
+)
+
+// Log formats, selected with -log-format
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Formats lists the valid log formats
+var Formats = []string{FormatText, FormatJSON}
+
+const (
+	barWidth     = 24
+	redrawPeriod = 250 * time.Millisecond
+)
+
+// Console captures an analysis's output and renders it. A nil Console prints the log as it is.
+type Console struct {
+	quiet bool // Discard the log
+	json  bool // Write the log as JSON lines
+	bar   bool // Draw a progress bar on stderr, keeping only warnings and errors of the log
+
+	mu         sync.Mutex
+	out        *os.File // Standard output while it is captured
+	pipe       *os.File // Write end of the capture, which progress events are queued on behind the log
+	stage      string
+	percent    int
+	filesDone  int
+	filesTotal int
+	filesStart time.Time
+	start      time.Time
+	drawn      bool // The bar is on screen and must be cleared before printing
+	lastFiles  int  // Twentieths of the files reported in the last JSON file event
+}
+
+// eventMarker starts the lines Progress queues on the capture, so events and the log lines
+// printed before them are rendered in order
+const eventMarker = "\x1eprogress "
+
+// event is a progress event queued on the capture
+type event struct {
+	Stage      string `json:"stage"`
+	Message    string `json:"message"`
+	Progress   int    `json:"progress"`
+	FilesDone  int    `json:"files_done"`
+	FilesTotal int    `json:"files_total"`
+}
+
+// record is one JSON log line
+type record struct {
+	Time       string `json:"time"`
+	Level      string `json:"level"`
+	Message    string `json:"msg"`
+	Percent    *int   `json:"percent,omitempty"`
+	FilesDone  int    `json:"files_done,omitempty"`
+	FilesTotal int    `json:"files_total,omitempty"`
+	ETASeconds int    `json:"eta_seconds,omitempty"`
+}
+
+// New returns the console for the flags. The progress bar is drawn when both standard output and
+// standard error are terminals; with neither quiet, JSON nor a terminal the log is printed as it
+// is and New returns nil.
+func New(quiet bool, format string) (*Console, error) {
+	switch format {
+	case "", FormatText:
+	case FormatJSON:
+	default:
+		return nil, fmt.Errorf("unknown log format %q (available: %s)", format, strings.Join(Formats, ", "))
+	}
+	c := &Console{quiet: quiet, json: format == FormatJSON && !quiet}
+	c.bar = !c.quiet && !c.json && isTerminal(os.Stdout) && isTerminal(os.Stderr) && os.Getenv("TERM") != "dumb"
+	if !c.quiet && !c.json && !c.bar {
+		return nil, nil
+	}
+	return c, nil
+}
+
+// Quiet reports whether only final results are printed
+func (c *Console) Quiet() bool {
+	return c != nil && c.quiet
+}
+
+// Analyze runs the analysis with its output rendered by the console
+func (c *Console) Analyze(ctx context.Context, analyzer *pipeline.Analyzer) (*pipeline.AnalysisResult, error) {
+	if c == nil {
+		return analyzer.AnalyzeProject(ctx)
+	}
+	if !c.quiet {
+		analyzer.SetProgress(c.Progress)
+		defer analyzer.SetProgress(nil)
+	}
+	var result *pipeline.AnalysisResult
+	err := c.Run(func() error {
+		var err error
+		result, err = analyzer.AnalyzeProject(ctx)
+		return err
+	})
+	return result, err
+}
+
+// Run calls fn with standard output captured and rendered by the console
+func (c *Console) Run(fn func() error) error {
+	if c == nil {
+		return fn()
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		return fn()
+	}
+
+	c.mu.Lock()
+	c.out = os.Stdout
+	c.pipe = w
+	c.start = time.Now()
+	c.stage, c.percent, c.filesDone, c.filesTotal, c.lastFiles = "", 0, 0, 0, 0
+	c.filesStart = time.Time{}
+	c.mu.Unlock()
+	os.Stdout = w
+
+	read := make(chan struct{})
+	go func() {
+		defer close(read)
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			c.line(scanner.Text())
+		}
+		// A line too long to scan must not leave writers blocked on a full pipe
+		io.Copy(io.Discard, r)
+	}()
+
+	stop := make(chan struct{})
+	ticked := make(chan struct{})
+	go func() {
+		defer close(ticked)
+		if !c.bar {
+			return
+		}
+		ticker := time.NewTicker(redrawPeriod)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				c.mu.Lock()
+				c.draw()
+				c.mu.Unlock()
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	defer func() {
+		close(stop)
+		<-ticked
+		c.mu.Lock()
+		os.Stdout = c.out
+		c.pipe = nil
+		c.mu.Unlock()
+		w.Close()
+		<-read
+		r.Close()
+		c.mu.Lock()
+		c.clear()
+		c.mu.Unlock()
+	}()
+	return fn()
+}
+
+// Progress takes the analyzer's phase and file events; it is a pipeline.ProgressCallback
+func (c *Console) Progress(eventType, stage, message string, progress int, data interface{}) {
+	e := event{Stage: stage, Message: message, Progress: progress}
+	if fields, ok := data.(map[string]interface{}); ok {
+		e.FilesDone, _ = fields["files_done"].(int)
+		e.FilesTotal, _ = fields["files_total"].(int)
+	}
+	c.mu.Lock()
+	pipe := c.pipe
+	c.mu.Unlock()
+	if pipe == nil {
+		c.handle(e)
+		return
+	}
+	encoded, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	pipe.Write([]byte(eventMarker + string(encoded) + "\n"))
+}
+
+// handle renders a progress event
+func (c *Console) handle(e event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	phaseChanged := e.Stage != "" && e.Stage != c.stage
+	if e.Stage != "" {
+		c.stage = e.Stage
+	}
+	if e.Progress > c.percent {
+		c.percent = e.Progress
+	}
+	fileEvent := e.FilesTotal > 0
+	if fileEvent {
+		if c.filesStart.IsZero() {
+			c.filesStart = time.Now()
+		}
+		c.filesDone, c.filesTotal = e.FilesDone, e.FilesTotal
+	}
+
+	switch {
+	case c.json && fileEvent:
+		// File events are logged in steps of 5% so large repositories do not flood the log
+		step := c.filesDone * 20 / c.filesTotal
+		if step == c.lastFiles && c.filesDone != c.filesTotal {
+			return
+		}
+		c.lastFiles = step
+		percent := c.percent
+		c.emit(record{Level: "info", Message: e.Message, Percent: &percent, FilesDone: c.filesDone, FilesTotal: c.filesTotal, ETASeconds: int(c.eta().Seconds())})
+	case c.json && phaseChanged:
+		percent := c.percent
+		c.emit(record{Level: "info", Message: strings.TrimSpace(e.Stage), Percent: &percent})
+	case c.bar:
+		c.draw()
+	}
+}
+
+// line renders one line the analysis printed
+func (c *Console) line(text string) {
+	if strings.HasPrefix(text, eventMarker) {
+		var e event
+		if json.Unmarshal([]byte(strings.TrimPrefix(text, eventMarker)), &e) == nil {
+			c.handle(e)
+		}
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	level := levelOf(text)
+	switch {
+	case c.quiet:
+	case c.json:
+		if strings.TrimSpace(text) != "" {
+			c.emit(record{Level: level, Message: strings.TrimSpace(text)})
+		}
+	case c.bar:
+		// Per-file chatter is what the bar replaces; problems stay visible above it
+		if level == "warn" || level == "error" {
+			c.clear()
+			fmt.Fprintln(c.out, text)
+			c.draw()
+		}
+	}
+}
+
+func (c *Console) emit(r record) {
+	r.Time = time.Now().UTC().Format(time.RFC3339Nano)
+	data, err := json.Marshal(r)
+	if err != nil {
+		return
+	}
+	c.out.Write(append(data, '\n'))
+}
+
+// draw redraws the bar on stderr: overall progress, the phase, and the file count and ETA while
+// files are analyzed
+func (c *Console) draw() {
+	if !c.bar || c.stage == "" {
+		return
+	}
+	filled := c.percent * barWidth / 100
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", barWidth-filled)
+	status := fmt.Sprintf("%3d%%", c.percent)
+	if c.filesTotal > 0 && c.filesDone < c.filesTotal {
+		status += fmt.Sprintf("  %d/%d files", c.filesDone, c.filesTotal)
+		if eta := c.eta(); eta >= time.Second {
+			status += "  ETA " + eta.Round(time.Second).String()
+		}
+	} else {
+		status += "  " + time.Since(c.start).Round(time.Second).String()
+	}
+	fmt.Fprintf(os.Stderr, "\r\033[K%s %s  %s", bar, status, truncate(strings.TrimSpace(c.stage), 40))
+	c.drawn = true
+}
+
+func (c *Console) clear() {
+	if c.drawn {
+		fmt.Fprint(os.Stderr, "\r\033[K")
+		c.drawn = false
+	}
+}
+
+// eta extrapolates the rest of the map phase from the files finished so far
+func (c *Console) eta() time.Duration {
+	if c.filesDone == 0 || c.filesDone >= c.filesTotal || c.filesStart.IsZero() {
+		return 0
+	}
+	elapsed := time.Since(c.filesStart)
+	return time.Duration(float64(elapsed) / float64(c.filesDone) * float64(c.filesTotal-c.filesDone))
+}
+
+// levelOf reads a log line's level from the emoji it starts with
+func levelOf(text string) string {
+	text = strings.TrimSpace(text)
+	switch {
+	case strings.HasPrefix(text, "❌"):
+		return "error"
+	case strings.HasPrefix(text, "⚠"), strings.HasPrefix(text, "⏱"):
+		return "warn"
+	case strings.Contains(text, "[DEBUG]"):
+		return "debug"
+	}
+	return "info"
+}
+
+func truncate(text string, max int) string {
+	if utf8.RuneCountInString(text) <= max {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:max-1]) + "…"
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,398 @@
+// Package contextpack condenses an analysis into a "context pack": one Markdown or JSON bundle of
+// the project summary, commands, services and their graph, key files, folders and database, sized
+// to a token budget so it can be pasted into a chat assistant for follow-up questions.
+package contextpack
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"repo-explanation/internal/heuristics"
+	internalOpenai "repo-explanation/internal/openai"
+	"repo-explanation/internal/pipeline"
+)
+
+// DefaultMaxTokens fits the pack, with room for the conversation, in the context window of any
+// current chat assistant
+const DefaultMaxTokens = 8000
+
+// Formats a pack is rendered in
+const (
+	Markdown = "markdown"
+	JSON     = "json"
+)
+
+// Section is one part of the pack
+type Section struct {
+	Title   string   `json:"title"`
+	Code    bool     `json:"code,omitempty"` // Lines are shell commands
+	Lines   []string `json:"lines"`
+	Omitted int      `json:"omitted,omitempty"` // Lines left out to fit the token budget
+}
+
+// Pack is the context pack of one repository
+type Pack struct {
+	Repository      string    `json:"repository"`
+	MaxTokens       int       `json:"max_tokens"`
+	EstimatedTokens int       `json:"estimated_tokens"`
+	Sections        []Section `json:"sections"`
+	OmittedSections []string  `json:"omitted_sections,omitempty"` // Sections with no room left in the budget
+}
+
+// Caps on what a section lists before the budget is applied
+const (
+	maxFolders       = 40
+	maxKeyFiles      = 40
+	maxKeyFilesEach  = 3
+	maxTables        = 60
+	maxGlossaryTerms = 30
+	maxLineRunes     = 300
+)
+
+// Build condenses an analysis into a pack of at most maxTokens estimated tokens, DefaultMaxTokens
+// when maxTokens is 0. Sections come in order of importance; once the budget runs short a section
+// keeps the lines that fit and the sections with no room left are named as left out.
+func Build(repository string, result *pipeline.AnalysisResult, maxTokens int) *Pack {
+	if maxTokens <= 0 {
+		maxTokens = DefaultMaxTokens
+	}
+	pack := &Pack{Repository: repository, MaxTokens: maxTokens, Sections: []Section{}}
+	candidates := []Section{
+		overview(result),
+		commands(result),
+		services(result),
+		serviceGraph(result),
+		keyFiles(result),
+		folders(result),
+		database(result),
+		glossary(result),
+	}
+
+	used := EstimateTokens(pack.header())
+	for _, candidate := range candidates {
+		if len(candidate.Lines) == 0 {
+			continue
+		}
+		// Room for the heading, the fence of a code block and the omission note
+		cost := EstimateTokens("\n## "+candidate.Title+"\n") + 20
+		if used+cost+EstimateTokens(candidate.Lines[0]) > maxTokens {
+			pack.OmittedSections = append(pack.OmittedSections, candidate.Title)
+			continue
+		}
+		used += cost
+		section := Section{Title: candidate.Title, Code: candidate.Code, Lines: []string{}, Omitted: candidate.Omitted}
+		for i, line := range candidate.Lines {
+			lineCost := EstimateTokens(line + "\n")
+			if used+lineCost > maxTokens {
+				section.Omitted += len(candidate.Lines) - i
+				break
+			}
+			used += lineCost
+			section.Lines = append(section.Lines, line)
+		}
+		pack.Sections = append(pack.Sections, section)
+	}
+	pack.EstimatedTokens = EstimateTokens(pack.Markdown())
+	return pack
+}
+
+// EstimateTokens approximates the tokens of text at four characters per token
+func EstimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
+
+func (p *Pack) header() string {
+	return fmt.Sprintf("# Context pack: %s\n\nAn automated analysis of the %s repository, condensed for a chat assistant. Answer questions about the repository from it, and say when something is not covered.\n", p.Repository, p.Repository)
+}
+
+// Markdown renders the pack as one Markdown document
+func (p *Pack) Markdown() string {
+	var b strings.Builder
+	b.WriteString(p.header())
+	for _, section := range p.Sections {
+		b.WriteString("\n## " + section.Title + "\n")
+		if section.Code {
+			b.WriteString("```sh\n" + strings.Join(section.Lines, "\n") + "\n```\n")
+		} else {
+			for _, line := range section.Lines {
+				b.WriteString("- " + line + "\n")
+			}
+		}
+		if section.Omitted > 0 {
+			b.WriteString(fmt.Sprintf("\n(%d more left out to fit the context budget)\n", section.Omitted))
+		}
+	}
+	if len(p.OmittedSections) > 0 {
+		b.WriteString("\nLeft out to fit the context budget: " + strings.Join(p.OmittedSections, ", ") + ".\n")
+	}
+	return b.String()
+}
+
+func overview(result *pipeline.AnalysisResult) Section {
+	section := Section{Title: "Overview"}
+	summary := result.ProjectSummary
+	if summary != nil && summary.Purpose != "" {
+		section.Lines = append(section.Lines, "Purpose: "+clean(summary.Purpose))
+	}
+	if result.ProjectType != nil && result.ProjectType.PrimaryType != "" {
+		kind := string(result.ProjectType.PrimaryType)
+		if result.ProjectType.SecondaryType != "" {
+			kind += " with " + string(result.ProjectType.SecondaryType)
+		}
+		section.Lines = append(section.Lines, "Project type: "+kind)
+	}
+	if summary == nil {
+		return section
+	}
+	if details := summary.DetailedAnalysis; details != nil {
+		if details.Architecture != "" || details.RepoLayout != "" {
+			section.Lines = append(section.Lines, "Layout: "+strings.Trim(details.Architecture+", "+details.RepoLayout, ", "))
+		}
+		if len(details.MainStacks) > 0 {
+			section.Lines = append(section.Lines, "Main stacks: "+strings.Join(details.MainStacks, ", "))
+		}
+	}
+	if summary.Architecture != "" {
+		section.Lines = append(section.Lines, "Architecture: "+clean(summary.Architecture))
+	}
+	if languages := byCount(summary.Languages); len(languages) > 0 {
+		section.Lines = append(section.Lines, "Languages: "+strings.Join(languages[:min(len(languages), 6)], ", "))
+	}
+	if len(summary.DataModels) > 0 {
+		section.Lines = append(section.Lines, "Data models: "+strings.Join(summary.DataModels, ", "))
+	}
+	if len(summary.ExternalServices) > 0 {
+		section.Lines = append(section.Lines, "External services: "+strings.Join(summary.ExternalServices, ", "))
+	}
+	if result.Offline {
+		section.Lines = append(section.Lines, "Summaries are heuristic: the analysis ran without an LLM")
+	}
+	return section
+}
+
+func commands(result *pipeline.AnalysisResult) Section {
+	section := Section{Title: "Commands", Code: true}
+	if summary := result.ProjectSummary; summary != nil && summary.Documentation != nil {
+		for _, step := range summary.Documentation.SetupSteps {
+			section.Lines = append(section.Lines, step)
+		}
+		for _, command := range summary.Documentation.RunCommands {
+			section.Lines = append(section.Lines, command)
+		}
+	}
+	if result.Generated != nil {
+		for _, codegen := range result.Generated.Pipelines {
+			section.Lines = append(section.Lines, fmt.Sprintf("%s  # regenerates %s", codegen.Command, strings.Join(codegen.Outputs, ", ")))
+		}
+	}
+	return section
+}
+
+func services(result *pipeline.AnalysisResult) Section {
+	section := Section{Title: "Services"}
+	for _, service := range result.Services {
+		line := fmt.Sprintf("%s (%s", service.Name, service.Path)
+		if service.APIType != "" {
+			line += ", " + string(service.APIType)
+		}
+		if service.Port != "" {
+			line += ", port " + service.Port
+		}
+		if service.EntryPoint != "" {
+			line += ", entry " + service.EntryPoint
+		}
+		line += ")"
+		if service.Description != "" {
+			line += ": " + clean(service.Description)
+		}
+		section.Lines = append(section.Lines, line)
+	}
+	return section
+}
+
+func serviceGraph(result *pipeline.AnalysisResult) Section {
+	section := Section{Title: "Service graph"}
+	for _, rel := range result.ServiceRelationships {
+		section.Lines = append(section.Lines, fmt.Sprintf("%s → %s (%s, %.0f%%)", rel.From, rel.To, rel.EvidenceType, rel.Confidence*100))
+	}
+	return section
+}
+
+// keyFiles lists the service entry points, then the key modules of each folder, shallow folders first
+func keyFiles(result *pipeline.AnalysisResult) Section {
+	section := Section{Title: "Key files"}
+	seen := make(map[string]bool)
+	for _, service := range result.Services {
+		if service.EntryPoint == "" {
+			continue
+		}
+		file := service.EntryPoint
+		if !strings.Contains(file, "/") {
+			file = path.Join(service.Path, file)
+		}
+		if !seen[file] {
+			seen[file] = true
+			section.Lines = append(section.Lines, fmt.Sprintf("%s: entry point of %s", file, service.Name))
+		}
+	}
+	for _, folder := range folderPaths(result) {
+		summary := result.FolderSummaries[folder]
+		listed := 0
+		for _, module := range summary.KeyModules {
+			if listed == maxKeyFilesEach || len(section.Lines) == maxKeyFiles {
+				break
+			}
+			file, purpose := module, ""
+			for _, filePath := range sortedFiles(summary.FileSummaries) {
+				if path.Base(filePath) == path.Base(module) {
+					file, purpose = filePath, summary.FileSummaries[filePath].Purpose
+					break
+				}
+			}
+			if !strings.Contains(file, "/") && folder != "." && folder != "" {
+				file = path.Join(folder, file)
+			}
+			if seen[file] {
+				continue
+			}
+			seen[file] = true
+			listed++
+			if purpose != "" {
+				file += ": " + clean(purpose)
+			}
+			section.Lines = append(section.Lines, file)
+		}
+	}
+	return section
+}
+
+func folders(result *pipeline.AnalysisResult) Section {
+	section := Section{Title: "Folders"}
+	for _, folder := range folderPaths(result) {
+		if len(section.Lines) == maxFolders {
+			section.Omitted = len(result.FolderSummaries) - maxFolders
+			break
+		}
+		if purpose := clean(result.FolderSummaries[folder].Purpose); purpose != "" {
+			section.Lines = append(section.Lines, folder+": "+purpose)
+		}
+	}
+	return section
+}
+
+// database renders the schema as a compact ERD: each table with its columns, primary keys and
+// foreign keys
+func database(result *pipeline.AnalysisResult) Section {
+	section := Section{Title: "Database"}
+	schema := result.DatabaseSchema
+	if schema == nil {
+		return section
+	}
+	names := make([]string, 0, len(schema.Tables))
+	for name := range schema.Tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names[:min(len(names), maxTables)] {
+		table := schema.Tables[name]
+		primary := make(map[string]bool)
+		for _, key := range table.PrimaryKeys {
+			primary[key] = true
+		}
+		columns := make([]string, 0, len(table.Columns))
+		for columnName := range table.Columns {
+			columns = append(columns, columnName)
+		}
+		sort.Slice(columns, func(i, j int) bool {
+			if primary[columns[i]] != primary[columns[j]] {
+				return primary[columns[i]]
+			}
+			return columns[i] < columns[j]
+		})
+		parts := make([]string, len(columns))
+		for i, columnName := range columns {
+			column := table.Columns[columnName]
+			part := columnName + " " + strings.ToLower(column.Type)
+			if primary[columnName] {
+				part += " PK"
+			}
+			if ref := column.References; ref != nil {
+				part += fmt.Sprintf(" → %s.%s", ref.Table, ref.Column)
+			}
+			parts[i] = part
+		}
+		section.Lines = append(section.Lines, fmt.Sprintf("%s(%s)", name, strings.Join(parts, ", ")))
+	}
+	if len(names) > maxTables {
+		section.Omitted = len(names) - maxTables
+	}
+	return section
+}
+
+func glossary(result *pipeline.AnalysisResult) Section {
+	section := Section{Title: "Glossary"}
+	if result.Glossary == nil {
+		return section
+	}
+	for _, term := range result.Glossary.Terms {
+		if len(section.Lines) == maxGlossaryTerms {
+			break
+		}
+		section.Lines = append(section.Lines, term.Term+": "+clean(term.Definition))
+	}
+	return section
+}
+
+// folderPaths returns the summarized folders, shallow ones first
+func folderPaths(result *pipeline.AnalysisResult) []string {
+	paths := make([]string, 0, len(result.FolderSummaries))
+	for folder, summary := range result.FolderSummaries {
+		if summary != nil {
+			paths = append(paths, folder)
+		}
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		di, dj := strings.Count(paths[i], "/"), strings.Count(paths[j], "/")
+		if di != dj {
+			return di < dj
+		}
+		return paths[i] < paths[j]
+	})
+	return paths
+}
+
+func sortedFiles(files map[string]internalOpenai.FileSummary) []string {
+	paths := make([]string, 0, len(files))
+	for filePath := range files {
+		paths = append(paths, filePath)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// clean drops the offline marker and line breaks of a summary and bounds its length
+func clean(text string) string {
+	text = strings.Join(strings.Fields(strings.TrimPrefix(strings.TrimSpace(text), heuristics.OfflineMarker)), " ")
+	if utf8.RuneCountInString(text) > maxLineRunes {
+		text = string([]rune(text)[:maxLineRunes]) + "…"
+	}
+	return text
+}
+
+// byCount lists map keys by descending count, then by name
+func byCount(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
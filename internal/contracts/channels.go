@@ -0,0 +1,166 @@
+package contracts
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Roles of a service on a channel
+const (
+	Producer = "producer"
+	Consumer = "consumer"
+)
+
+// usage is a topic or queue produced to or consumed from on one line of a source file
+type usage struct {
+	channel string
+	broker  string
+	role    string
+	line    int
+}
+
+// channelRules recognize the producer and consumer calls of the common messaging clients, first
+// match per line winning. An empty broker is taken from the file's imports.
+var channelRules = []struct {
+	broker string
+	role   string
+	regex  *regexp.Regexp
+}{
+	// Spring Kafka and Spring AMQP
+	{"kafka", Consumer, regexp.MustCompile(`@KafkaListener\([^)]*topics\s*=\s*\{?\s*"([^"]+)"`)},
+	{"kafka", Producer, regexp.MustCompile(`(?i)kafkaTemplate\.send(?:Default)?\(\s*"([^"]+)"`)},
+	{"rabbitmq", Consumer, regexp.MustCompile(`@RabbitListener\([^)]*queues\s*=\s*\{?\s*"([^"]+)"`)},
+	{"rabbitmq", Producer, regexp.MustCompile(`(?i)rabbitTemplate\.convertAndSend\(\s*"([^"]+)"`)},
+	// kafka-python, confluent-kafka and sarama
+	{"kafka", Consumer, regexp.MustCompile(`KafkaConsumer\(\s*['"]([^'"]+)['"]`)},
+	{"kafka", Consumer, regexp.MustCompile(`ConsumePartition\(\s*"([^"]+)"`)},
+	{"kafka", Producer, regexp.MustCompile(`(?i)producer\w*\.(?:send|produce)\(\s*['"]([^'"]+)['"]`)},
+	// pika, amqplib and amqp091-go
+	{"rabbitmq", Producer, regexp.MustCompile(`basic_publish\([^)]*routing_key\s*=\s*['"]([^'"]+)['"]`)},
+	{"rabbitmq", Consumer, regexp.MustCompile(`basic_consume\([^)]*queue\s*=\s*['"]([^'"]+)['"]`)},
+	{"rabbitmq", Producer, regexp.MustCompile(`sendToQueue\(\s*['"]([^'"]+)['"]`)},
+	{"rabbitmq", Producer, regexp.MustCompile(`\.Publish(?:WithContext)?\(\s*(?:\w+\s*,\s*)?"[^"]*"\s*,\s*"([^"]+)"`)},
+	// Google Cloud Pub/Sub
+	{"pubsub", Producer, regexp.MustCompile(`(?i)\.topic\(\s*['"]([^'"]+)['"]\s*\)`)},
+	{"pubsub", Consumer, regexp.MustCompile(`(?i)\.subscription\(\s*['"]([^'"]+)['"]\s*\)`)},
+	{"pubsub", Producer, regexp.MustCompile(`topic_path\(\s*[^,]+,\s*['"]([^'"]+)['"]`)},
+	{"pubsub", Consumer, regexp.MustCompile(`subscription_path\(\s*[^,]+,\s*['"]([^'"]+)['"]`)},
+	// NATS, Redis and other publish/subscribe clients
+	{"", Consumer, regexp.MustCompile(`\.(?:[cC]onsume|(?:Queue)?[sS]ubscribe(?:Sync)?)\(\s*\[?\s*['"]([^'"]+)['"]`)},
+	{"", Producer, regexp.MustCompile(`\.[pP]ublish\(\s*['"]([^'"]+)['"]`)},
+}
+
+// topicFieldRegex matches a topic set as a field or option, as in kafka.WriterConfig{Topic: "x"}
+// or consumer.subscribe({ topic: 'x' }); the surrounding lines tell producer from consumer
+var topicFieldRegex = regexp.MustCompile(`\b[tT]opics?\s*[:=]\s*\[?\s*['"]([^'"]+)['"]`)
+
+var (
+	producerContext = regexp.MustCompile(`(?i)writer|producer|\bsend|publish|produce`)
+	consumerContext = regexp.MustCompile(`(?i)reader|consumer|subscribe|listen|consume`)
+)
+
+// brokerHints name the broker a file talks to from its imports and client types
+var brokerHints = []struct {
+	broker string
+	regex  *regexp.Regexp
+}{
+	{"kafka", regexp.MustCompile(`(?i)kafka|sarama`)},
+	{"rabbitmq", regexp.MustCompile(`(?i)amqp|rabbit|\bpika\b`)},
+	{"nats", regexp.MustCompile(`(?i)\bnats\b|nats\.go|nats-io`)},
+	{"pubsub", regexp.MustCompile(`(?i)pubsub`)},
+	{"redis", regexp.MustCompile(`(?i)redis`)},
+}
+
+// channelNameRegex accepts literal topic, queue and subject names, not templates or expressions
+var channelNameRegex = regexp.MustCompile(`^[\w.\-:/*>#]+$`)
+
+// scanChannels returns the topics and queues a source file produces to or consumes from
+func scanChannels(content string) []usage {
+	fileBroker := ""
+	for _, hint := range brokerHints {
+		if hint.regex.MatchString(content) {
+			fileBroker = hint.broker
+			break
+		}
+	}
+	// Without a messaging client, Publish and Subscribe are something else
+	if fileBroker == "" {
+		return nil
+	}
+
+	var found []usage
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "*") {
+			continue
+		}
+		u, ok := matchRule(line, fileBroker)
+		if !ok {
+			m := topicFieldRegex.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			u = usage{channel: m[1], broker: fileBroker, role: contextRole(lines, i)}
+			if u.role == "" {
+				continue
+			}
+		}
+		u.channel = channelName(u.channel)
+		if u.channel == "" {
+			continue
+		}
+		u.line = i + 1
+		found = append(found, u)
+	}
+	return found
+}
+
+func matchRule(line, fileBroker string) (usage, bool) {
+	for _, rule := range channelRules {
+		if m := rule.regex.FindStringSubmatch(line); m != nil {
+			broker := rule.broker
+			if broker == "" {
+				broker = fileBroker
+			}
+			return usage{channel: m[1], broker: broker, role: rule.role}, true
+		}
+	}
+	return usage{}, false
+}
+
+// contextRole looks back from a topic field for the nearest sign of a producer or consumer
+func contextRole(lines []string, i int) string {
+	for j := i; j >= 0 && j > i-6; j-- {
+		producer := lastIndex(producerContext, lines[j])
+		consumer := lastIndex(consumerContext, lines[j])
+		switch {
+		case producer > consumer:
+			return Producer
+		case consumer > producer:
+			return Consumer
+		}
+	}
+	return ""
+}
+
+func lastIndex(regex *regexp.Regexp, line string) int {
+	matches := regex.FindAllStringIndex(line, -1)
+	if len(matches) == 0 {
+		return -1
+	}
+	return matches[len(matches)-1][0]
+}
+
+// channelName validates a channel name, shortening Pub/Sub resource paths to the topic or
+// subscription; it is "" for names that are not literal
+func channelName(name string) string {
+	name = strings.TrimSpace(name)
+	if !channelNameRegex.MatchString(name) {
+		return ""
+	}
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
@@ -0,0 +1,330 @@
+// Package contracts inventories the data contracts of event-driven code: Avro schemas, JSON Schema
+// documents and protobuf messages, linked to the topics and queues they travel on and to the
+// services producing and consuming those.
+package contracts
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"repo-explanation/internal/filestore"
+	"repo-explanation/internal/jobs"
+	"repo-explanation/internal/microservices"
+)
+
+// Contract is one message schema
+type Contract struct {
+	Name      string   `json:"name"`
+	Format    string   `json:"format"`              // avro, json_schema or protobuf
+	Namespace string   `json:"namespace,omitempty"` // Avro namespace, proto package or JSON Schema $id
+	Fields    int      `json:"fields"`
+	Service   string   `json:"service,omitempty"` // Service whose directory holds the schema
+	File      string   `json:"file"`              // Relative to the project root
+	Line      int      `json:"line"`
+	Channels  []string `json:"channels"`  // Topics and queues it is sent on
+	Producers []string `json:"producers"` // Services (or files outside every service) sending it
+	Consumers []string `json:"consumers"`
+}
+
+// Endpoint is a place in the code producing to or consuming from a channel
+type Endpoint struct {
+	Service string `json:"service,omitempty"`
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+}
+
+// Channel is a topic, queue or subject messages are sent on
+type Channel struct {
+	Name      string     `json:"name"`
+	Broker    string     `json:"broker,omitempty"` // kafka, rabbitmq, nats, pubsub, redis, or the queue worker kind
+	Producers []Endpoint `json:"producers"`
+	Consumers []Endpoint `json:"consumers"`
+	Contracts []string   `json:"contracts"`
+}
+
+// Report lists a project's data contracts and the channels they were linked to
+type Report struct {
+	Contracts []Contract `json:"contracts"`
+	Channels  []Channel  `json:"channels"`
+	Summary   string     `json:"summary"`
+}
+
+// Directories that never hold first-party schemas or code
+var skippedDirs = map[string]bool{
+	".git": true, "node_modules": true, "vendor": true, "testdata": true, "dist": true,
+	".next": true, "coverage": true, "__pycache__": true, ".venv": true, "venv": true,
+	"test": true, "tests": true, "__tests__": true, "spec": true,
+}
+
+var sourceExtensions = map[string]bool{
+	".go": true, ".js": true, ".jsx": true, ".ts": true, ".tsx": true, ".mjs": true, ".cjs": true,
+	".py": true, ".rb": true, ".java": true, ".kt": true,
+}
+
+var schemaExtensions = map[string]bool{".avsc": true, ".avdl": true, ".proto": true, ".json": true}
+
+// sourceFile is a source file producing to or consuming from channels
+type sourceFile struct {
+	rel     string
+	service string
+	content string
+	usages  []usage
+}
+
+// Analyze inventories the schema files of the project and links each contract to the channels of
+// the code naming it, and of the topics named after it. Queue workers found by the job inventory
+// count as consumers of their queues. Protobuf messages are kept only when linked to a channel,
+// since most describe RPCs rather than events.
+func Analyze(files *filestore.FileStore, services []microservices.DiscoveredService, queued *jobs.Report) (*Report, error) {
+	fmt.Printf("📨 [DEBUG] Inventorying data contracts of %d files\n", files.Len())
+
+	var found []Contract
+	var sources []sourceFile
+	err := files.Walk(func(rel string) bool {
+		ext := strings.ToLower(path.Ext(rel))
+		return !filestore.InDirs(rel, skippedDirs) && !isTestFile(path.Base(rel)) && (schemaExtensions[ext] || sourceExtensions[ext])
+	}, func(rel, content string) error {
+		service := microservices.OwningService(path.Dir(rel), services)
+		if format := schemaFormat(rel, content); format != "" {
+			for _, contract := range parseSchemas(format, rel, content) {
+				contract.File = rel
+				contract.Service = service
+				found = append(found, contract)
+			}
+		}
+		if sourceExtensions[strings.ToLower(path.Ext(rel))] {
+			if usages := scanChannels(content); len(usages) > 0 {
+				sources = append(sources, sourceFile{rel: rel, service: service, content: content, usages: usages})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan for data contracts: %v", err)
+	}
+
+	channels := collectChannels(sources, queued)
+	report := &Report{Contracts: []Contract{}, Channels: []Channel{}}
+	for _, contract := range found {
+		linked := linkedChannels(contract, sources, channels)
+		if contract.Format == Protobuf && len(linked) == 0 {
+			continue
+		}
+		contract.Channels, contract.Producers, contract.Consumers = []string{}, []string{}, []string{}
+		for _, name := range linked {
+			channel := channels[name]
+			channel.Contracts = appendUnique(channel.Contracts, contract.Name)
+			contract.Channels = append(contract.Channels, name)
+			for _, endpoint := range channel.Producers {
+				contract.Producers = appendUnique(contract.Producers, endpoint.owner())
+			}
+			for _, endpoint := range channel.Consumers {
+				contract.Consumers = appendUnique(contract.Consumers, endpoint.owner())
+			}
+		}
+		sort.Strings(contract.Producers)
+		sort.Strings(contract.Consumers)
+		report.Contracts = append(report.Contracts, contract)
+	}
+	sort.SliceStable(report.Contracts, func(i, j int) bool {
+		a, b := report.Contracts[i], report.Contracts[j]
+		if a.Service != b.Service {
+			return a.Service < b.Service
+		}
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+	for _, channel := range channels {
+		sort.Strings(channel.Contracts)
+		report.Channels = append(report.Channels, *channel)
+	}
+	sort.Slice(report.Channels, func(i, j int) bool { return report.Channels[i].Name < report.Channels[j].Name })
+
+	report.Summary = summarize(report)
+	fmt.Printf("✅ [DEBUG] %s\n", report.Summary)
+	return report, nil
+}
+
+// collectChannels gathers the producers and consumers of every channel found in the code and the
+// job inventory
+func collectChannels(sources []sourceFile, queued *jobs.Report) map[string]*Channel {
+	channels := make(map[string]*Channel)
+	channelFor := func(name, broker string) *Channel {
+		channel, ok := channels[name]
+		if !ok {
+			channel = &Channel{Name: name, Producers: []Endpoint{}, Consumers: []Endpoint{}, Contracts: []string{}}
+			channels[name] = channel
+		}
+		if channel.Broker == "" {
+			channel.Broker = broker
+		}
+		return channel
+	}
+	for _, source := range sources {
+		for _, u := range source.usages {
+			channel := channelFor(u.channel, u.broker)
+			endpoint := Endpoint{Service: source.service, File: source.rel, Line: u.line}
+			if u.role == Producer {
+				channel.Producers = append(channel.Producers, endpoint)
+			} else {
+				channel.Consumers = append(channel.Consumers, endpoint)
+			}
+		}
+	}
+	if queued != nil {
+		for _, job := range queued.Jobs {
+			if job.Trigger != jobs.Queued || job.Queue == "" {
+				continue
+			}
+			channel := channelFor(job.Queue, job.Kind)
+			channel.Consumers = append(channel.Consumers, Endpoint{Service: job.Service, File: job.File, Line: job.Line})
+		}
+	}
+	return channels
+}
+
+// linkedChannels returns the channels a contract travels on: those used by code naming the
+// contract, and those whose name ends with the contract's (orders.order-created for OrderCreated)
+func linkedChannels(contract Contract, sources []sourceFile, channels map[string]*Channel) []string {
+	linked := make(map[string]bool)
+	if mention := mentionRegex(contract.Name); mention != nil {
+		for _, source := range sources {
+			if !mention.MatchString(source.content) {
+				continue
+			}
+			for _, u := range source.usages {
+				linked[u.channel] = true
+			}
+		}
+	}
+	if contractWords := nameWords(contract.Name, true); len(contractWords) > 0 {
+		for name := range channels {
+			if endsWith(nameWords(name, false), contractWords) {
+				linked[name] = true
+			}
+		}
+	}
+	names := make([]string, 0, len(linked))
+	for name := range linked {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// genericNames are contract names too common to link by mention alone
+var genericNames = map[string]bool{
+	"event": true, "message": true, "payload": true, "request": true, "response": true,
+	"record": true, "data": true, "value": true, "key": true, "envelope": true, "metadata": true,
+}
+
+// mentionRegex matches a contract name written whole in code, or is nil for short or generic names
+func mentionRegex(name string) *regexp.Regexp {
+	if len(name) < 4 || genericNames[strings.ToLower(name)] {
+		return nil
+	}
+	return regexp.MustCompile(`\b` + regexp.QuoteMeta(name) + `\b`)
+}
+
+var (
+	camelBoundary = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+	versionWord   = regexp.MustCompile(`^v\d+$`)
+)
+
+// nameWords splits a contract or channel name into singular lowercase words, without version
+// words; a contract also drops trailing words naming what it is (OrderCreatedEvent is OrderCreated)
+func nameWords(name string, contract bool) []string {
+	name = camelBoundary.ReplaceAllString(name, "${1} ${2}")
+	var words []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	}) {
+		if versionWord.MatchString(word) {
+			continue
+		}
+		if len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") {
+			word = strings.TrimSuffix(word, "s")
+		}
+		words = append(words, word)
+	}
+	if contract {
+		for len(words) > 1 && genericNames[words[len(words)-1]] {
+			words = words[:len(words)-1]
+		}
+		if len(words) == 1 && genericNames[words[0]] {
+			return nil
+		}
+	}
+	return words
+}
+
+func endsWith(words, suffix []string) bool {
+	if len(suffix) == 0 || len(suffix) > len(words) {
+		return false
+	}
+	offset := len(words) - len(suffix)
+	for i, word := range suffix {
+		if words[offset+i] != word {
+			return false
+		}
+	}
+	return true
+}
+
+// owner names the service of an endpoint, or its file when it is outside every service
+func (e Endpoint) owner() string {
+	if e.Service != "" {
+		return e.Service
+	}
+	return e.File
+}
+
+func summarize(report *Report) string {
+	if len(report.Contracts) == 0 {
+		return "No data contracts found"
+	}
+	formats := make(map[string]int)
+	unlinked := 0
+	for _, contract := range report.Contracts {
+		formats[contract.Format]++
+		if len(contract.Channels) == 0 {
+			unlinked++
+		}
+	}
+	var parts []string
+	for _, format := range []string{Avro, JSONSchema, Protobuf} {
+		if formats[format] > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d", format, formats[format]))
+		}
+	}
+	used := 0
+	for _, channel := range report.Channels {
+		if len(channel.Contracts) > 0 {
+			used++
+		}
+	}
+	summary := fmt.Sprintf("%d data contracts (%s) on %d of %d channels", len(report.Contracts), strings.Join(parts, ", "), used, len(report.Channels))
+	if unlinked > 0 {
+		summary += fmt.Sprintf("; %d not linked to a channel", unlinked)
+	}
+	return summary
+}
+
+func isTestFile(name string) bool {
+	return strings.HasSuffix(name, "_test.go") || strings.Contains(name, ".test.") ||
+		strings.Contains(name, ".spec.") || strings.HasPrefix(name, "test_")
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
@@ -0,0 +1,226 @@
+package contracts
+
+import (
+	"encoding/json"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Contract formats
+const (
+	Avro       = "avro"
+	JSONSchema = "json_schema"
+	Protobuf   = "protobuf"
+)
+
+// schemaFormat returns the format a file may define contracts in, or "" for other files
+func schemaFormat(rel, content string) string {
+	name := strings.ToLower(path.Base(rel))
+	switch {
+	case strings.HasSuffix(name, ".avsc"), strings.HasSuffix(name, ".avdl"):
+		return Avro
+	case strings.HasSuffix(name, ".proto"):
+		return Protobuf
+	case strings.HasSuffix(name, ".schema.json"):
+		return JSONSchema
+	case strings.HasSuffix(name, ".json") && jsonSchemaRegex.MatchString(content):
+		return JSONSchema
+	}
+	return ""
+}
+
+// jsonSchemaRegex recognizes a JSON Schema document by its $schema keyword
+var jsonSchemaRegex = regexp.MustCompile(`"\$schema"\s*:\s*"https?://json-schema\.org/`)
+
+// parseSchemas returns the contracts a schema file defines
+func parseSchemas(format, rel, content string) []Contract {
+	switch format {
+	case Avro:
+		if strings.HasSuffix(strings.ToLower(rel), ".avdl") {
+			return parseAvroIDL(content)
+		}
+		return parseAvroSchema(content)
+	case JSONSchema:
+		return parseJSONSchema(rel, content)
+	case Protobuf:
+		return parseProto(content)
+	}
+	return nil
+}
+
+// parseAvroSchema reads the named records of an .avsc file: a record, or a union of records
+func parseAvroSchema(content string) []Contract {
+	var schema interface{}
+	if err := json.Unmarshal([]byte(content), &schema); err != nil {
+		return nil
+	}
+	var found []Contract
+	var visit func(node interface{}, namespace string, top bool)
+	visit = func(node interface{}, namespace string, top bool) {
+		switch value := node.(type) {
+		case []interface{}:
+			for _, item := range value {
+				visit(item, namespace, top)
+			}
+		case map[string]interface{}:
+			if ns, ok := value["namespace"].(string); ok {
+				namespace = ns
+			}
+			name, _ := value["name"].(string)
+			if value["type"] != "record" || name == "" {
+				return
+			}
+			if i := strings.LastIndex(name, "."); i >= 0 {
+				namespace = name[:i]
+			}
+			fields, _ := value["fields"].([]interface{})
+			// Nested records are part of the contract that holds them
+			if top {
+				found = append(found, Contract{
+					Name:      lastSegment(name),
+					Format:    Avro,
+					Namespace: namespace,
+					Fields:    len(fields),
+					Line:      lineOf(content, `"`+name+`"`),
+				})
+			}
+			for _, field := range fields {
+				if f, ok := field.(map[string]interface{}); ok {
+					visit(f["type"], namespace, false)
+				}
+			}
+		}
+	}
+	visit(schema, "", true)
+	return found
+}
+
+var (
+	avroNamespaceRegex = regexp.MustCompile(`@namespace\(\s*"([^"]+)"\s*\)`)
+	avroRecordRegex    = regexp.MustCompile(`^\s*(?:record|error)\s+(\w+)\s*\{`)
+)
+
+// parseAvroIDL reads the records of an Avro IDL protocol
+func parseAvroIDL(content string) []Contract {
+	namespace := ""
+	if m := avroNamespaceRegex.FindStringSubmatch(content); m != nil {
+		namespace = m[1]
+	}
+	var found []Contract
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		m := avroRecordRegex.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		found = append(found, Contract{
+			Name:      m[1],
+			Format:    Avro,
+			Namespace: namespace,
+			Fields:    countFields(lines, i, avroFieldRegex),
+			Line:      i + 1,
+		})
+	}
+	return found
+}
+
+var avroFieldRegex = regexp.MustCompile(`^\s*[\w.<>, ?]+\s+\w+\s*(?:=.*)?;`)
+
+// parseJSONSchema reads a JSON Schema document as one contract named by its title or file name
+func parseJSONSchema(rel, content string) []Contract {
+	var schema struct {
+		Title      string                     `json:"title"`
+		ID         string                     `json:"$id"`
+		Properties map[string]json.RawMessage `json:"properties"`
+	}
+	if err := json.Unmarshal([]byte(content), &schema); err != nil {
+		return nil
+	}
+	name := strings.TrimSpace(schema.Title)
+	if name == "" {
+		name = path.Base(rel)
+		for _, suffix := range []string{".json", ".schema"} {
+			name = strings.TrimSuffix(name, suffix)
+		}
+	}
+	return []Contract{{
+		Name:      name,
+		Format:    JSONSchema,
+		Namespace: schema.ID,
+		Fields:    len(schema.Properties),
+		Line:      1,
+	}}
+}
+
+var (
+	protoPackageRegex = regexp.MustCompile(`(?m)^\s*package\s+([\w.]+)\s*;`)
+	protoMessageRegex = regexp.MustCompile(`^message\s+(\w+)\s*\{`)
+	protoRPCRegex     = regexp.MustCompile(`rpc\s+\w+\s*\(\s*(?:stream\s+)?([\w.]+)\s*\)\s*returns\s*\(\s*(?:stream\s+)?([\w.]+)\s*\)`)
+	protoFieldRegex   = regexp.MustCompile(`^\s*(?:(?:repeated|optional|required)\s+)?(?:map\s*<[^>]+>|[\w.]+)\s+\w+\s*=\s*\d+`)
+)
+
+// parseProto reads the top-level messages of a .proto file, leaving out the request and response
+// types of its RPCs, which are not event payloads
+func parseProto(content string) []Contract {
+	namespace := ""
+	if m := protoPackageRegex.FindStringSubmatch(content); m != nil {
+		namespace = m[1]
+	}
+	rpcTypes := make(map[string]bool)
+	for _, m := range protoRPCRegex.FindAllStringSubmatch(content, -1) {
+		rpcTypes[lastSegment(m[1])] = true
+		rpcTypes[lastSegment(m[2])] = true
+	}
+	var found []Contract
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		m := protoMessageRegex.FindStringSubmatch(line)
+		if m == nil || rpcTypes[m[1]] {
+			continue
+		}
+		found = append(found, Contract{
+			Name:      m[1],
+			Format:    Protobuf,
+			Namespace: namespace,
+			Fields:    countFields(lines, i, protoFieldRegex),
+			Line:      i + 1,
+		})
+	}
+	return found
+}
+
+// countFields counts the lines matching field directly inside the block opened on lines[start]
+func countFields(lines []string, start int, field *regexp.Regexp) int {
+	depth, count := 0, 0
+	for i := start; i < len(lines); i++ {
+		line := lines[i]
+		if comment := strings.Index(line, "//"); comment >= 0 {
+			line = line[:comment]
+		}
+		if depth == 1 && i > start && field.MatchString(line) {
+			count++
+		}
+		depth += strings.Count(line, "{") - strings.Count(line, "}")
+		if depth <= 0 {
+			break
+		}
+	}
+	return count
+}
+
+// lineOf returns the line of the first occurrence of text, or 1
+func lineOf(content, text string) int {
+	if i := strings.Index(content, text); i >= 0 {
+		return strings.Count(content[:i], "\n") + 1
+	}
+	return 1
+}
+
+// lastSegment drops the namespace of a dotted name
+func lastSegment(name string) string {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
@@ -0,0 +1,177 @@
+package database
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Data classes a column can be tagged with
+const (
+	ClassPII        = "pii"        // Identifies or locates a person
+	ClassCredential = "credential" // Grants access: passwords, tokens, keys
+	ClassFinancial  = "financial"  // Payment cards, bank accounts, pay
+)
+
+var classOrder = []string{ClassCredential, ClassFinancial, ClassPII}
+
+// classificationRules recognize sensitive columns by the words of their name, first match winning.
+// Words are matched whole between underscores, so "email" matches billing_email but not emails_sent.
+var classificationRules = []struct {
+	class string
+	label string
+	words []string
+}{
+	{ClassCredential, "API key or token", []string{"api_key", "apikey", "access_key", "token", "recovery_code", "recovery_codes", "credential", "credentials"}},
+	{ClassCredential, "secret", []string{"secret", "private_key", "signing_key", "encryption_key", "key_hash", "salt"}},
+	{ClassCredential, "password", []string{"password", "passwd", "pwd", "passphrase", "pin_hash"}},
+	{ClassFinancial, "payment card", []string{"card", "cc_number", "credit_card", "cvv", "cvc"}},
+	{ClassFinancial, "bank account", []string{"iban", "bic", "swift", "bank_account", "account_number", "routing_number", "sort_code"}},
+	{ClassFinancial, "income or tax", []string{"salary", "income", "wage", "tax_id", "vat_number", "credit_score"}},
+	{ClassPII, "government ID", []string{"ssn", "social_security", "national_id", "passport", "driver_license", "drivers_license", "driver_licence", "drivers_licence"}},
+	{ClassPII, "email", []string{"email", "e_mail"}},
+	{ClassPII, "phone", []string{"phone", "mobile", "msisdn", "fax"}},
+	{ClassPII, "name", []string{"first_name", "last_name", "full_name", "middle_name", "surname", "maiden_name", "legal_name",
+		"customer_name", "contact_name", "recipient_name", "holder_name", "patient_name", "employee_name", "billing_name", "shipping_name"}},
+	{ClassPII, "birth date", []string{"dob", "date_of_birth", "birth_date", "birthdate", "birthday"}},
+	{ClassPII, "IP address", []string{"ip", "ip_address"}},
+	{ClassPII, "address", []string{"address", "street", "postal_code", "post_code", "postcode", "zip", "zipcode"}},
+	{ClassPII, "demographics", []string{"gender", "sex", "nationality", "ethnicity", "religion"}},
+}
+
+// notDataRegex drops columns about a sensitive value rather than holding it: flags, timestamps,
+// counters and foreign keys such as password_changed_at, email_verified or api_key_id
+var notDataRegex = regexp.MustCompile(`^(?:is|has|can|should|allow)_|_(?:at|on|date|time|count|id|ids|expires?|expiry|type|kind|length|version|enabled|required|verified|confirmed|status|policy|provider|format)$`)
+
+// personalTables hold people, where a bare "name" column is a person's name
+var personalTables = regexp.MustCompile(`^(?:users?|customers?|employees?|contacts?|people|persons?|members?|patients?|profiles?|accounts?|students?|candidates?|applicants?|recipients?|guests?)$`)
+
+// hashedRegex marks a credential column stored hashed or encrypted
+var hashedRegex = regexp.MustCompile(`(?:^|_)(?:hash|hashed|digest|encrypted|enc|crypted|bcrypt|argon2?)(?:_|$)`)
+
+// ClassifyColumn returns the data class of a column and what it holds, or "" when it looks
+// ordinary. The table name tells a person's name from a product's.
+func ClassifyColumn(table, column, columnType string) (class, label string) {
+	name := strings.ToLower(column)
+	if strings.Contains(name, "_") && notDataRegex.MatchString(name) {
+		return "", ""
+	}
+	for _, rule := range classificationRules {
+		for _, word := range rule.words {
+			if hasWord(name, word) {
+				return rule.class, rule.label
+			}
+		}
+	}
+	_, tableName := splitTableKey(strings.ToLower(table))
+	if (name == "name" || name == "display_name") && personalTables.MatchString(tableName) {
+		return ClassPII, "name"
+	}
+	if strings.EqualFold(strings.TrimSpace(columnType), "inet") {
+		return ClassPII, "IP address"
+	}
+	return "", ""
+}
+
+// classifyColumns tags every column of the schema with its data class
+func classifyColumns(schema *CanonicalSchema) {
+	for tableName, table := range schema.Tables {
+		for colName, column := range table.Columns {
+			column.Classification, _ = ClassifyColumn(tableName, colName, column.Type)
+		}
+	}
+}
+
+// ClassifiedColumn is one sensitive column
+type ClassifiedColumn struct {
+	Table  string `json:"table"`
+	Column string `json:"column"`
+	Class  string `json:"class"`
+	Label  string `json:"label"` // What it holds: email, password, payment card, ...
+}
+
+// SensitivityReport summarizes the sensitive data a schema holds
+type SensitivityReport struct {
+	Counts   map[string]int     `json:"counts"` // Columns per class
+	Columns  []ClassifiedColumn `json:"columns"`
+	Tables   []string           `json:"tables"`   // Tables with sensitive columns, most first
+	Warnings []string           `json:"warnings"` // Credentials that look stored in plain text
+	Summary  string             `json:"summary"`
+}
+
+// SummarizeSensitivity lists the sensitive columns of a schema by class, for onboarding and
+// compliance reviews. It returns nil when no column looks sensitive.
+func SummarizeSensitivity(schema *CanonicalSchema) *SensitivityReport {
+	if schema == nil {
+		return nil
+	}
+	report := &SensitivityReport{Counts: make(map[string]int), Columns: []ClassifiedColumn{}, Tables: []string{}, Warnings: []string{}}
+	perTable := make(map[string]int)
+	for tableName, table := range schema.Tables {
+		// Partitions repeat their parent's columns
+		if table.PartitionOf != nil {
+			continue
+		}
+		for colName, column := range table.Columns {
+			class, label := ClassifyColumn(tableName, colName, column.Type)
+			if class == "" {
+				continue
+			}
+			report.Columns = append(report.Columns, ClassifiedColumn{Table: tableName, Column: colName, Class: class, Label: label})
+			report.Counts[class]++
+			perTable[tableName]++
+			if label == "password" && !hashedRegex.MatchString(strings.ToLower(colName)) {
+				report.Warnings = append(report.Warnings, fmt.Sprintf("%s.%s may hold plain-text passwords; store a hash and name the column after it (password_hash)", tableName, colName))
+			}
+		}
+	}
+	if len(report.Columns) == 0 {
+		return nil
+	}
+
+	sort.Slice(report.Columns, func(i, j int) bool {
+		if report.Columns[i].Table != report.Columns[j].Table {
+			return report.Columns[i].Table < report.Columns[j].Table
+		}
+		return report.Columns[i].Column < report.Columns[j].Column
+	})
+	sort.Strings(report.Warnings)
+	for table := range perTable {
+		report.Tables = append(report.Tables, table)
+	}
+	sort.Slice(report.Tables, func(i, j int) bool {
+		if perTable[report.Tables[i]] != perTable[report.Tables[j]] {
+			return perTable[report.Tables[i]] > perTable[report.Tables[j]]
+		}
+		return report.Tables[i] < report.Tables[j]
+	})
+
+	var parts []string
+	for _, class := range classOrder {
+		if n := report.Counts[class]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, class))
+		}
+	}
+	report.Summary = fmt.Sprintf("%s in %d of %d tables (%s)", plural(len(report.Columns), "sensitive column"), len(report.Tables), len(schema.Tables), strings.Join(parts, ", "))
+	if len(report.Warnings) > 0 {
+		report.Summary += fmt.Sprintf("; %d possibly plain-text passwords", len(report.Warnings))
+	}
+	return report
+}
+
+// hasWord reports whether word appears in name whole, between underscores or at either end
+func hasWord(name, word string) bool {
+	for i := strings.Index(name, word); i >= 0; {
+		end := i + len(word)
+		if (i == 0 || name[i-1] == '_') && (end == len(name) || name[end] == '_') {
+			return true
+		}
+		next := strings.Index(name[i+1:], word)
+		if next < 0 {
+			return false
+		}
+		i += next + 1
+	}
+	return false
+}
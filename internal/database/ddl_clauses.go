@@ -0,0 +1,151 @@
+package database
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	partitionOfRegex   = regexp.MustCompile(`(?i)^\s*PARTITION\s+OF\s+(` + sqlQualifiedIdentifier + `)\s*`)
+	partitionByRegex   = regexp.MustCompile(`(?i)PARTITION\s+BY\s+(RANGE|LIST|HASH)\s*\(`)
+	attachRegex        = regexp.MustCompile(`(?i)ATTACH\s+PARTITION\s+(` + sqlQualifiedIdentifier + `)\s+(.+)$`)
+	detachRegex        = regexp.MustCompile(`(?i)DETACH\s+PARTITION\s+(` + sqlQualifiedIdentifier + `)`)
+	identityRegex      = regexp.MustCompile(`(?i)\bGENERATED\s+(ALWAYS|BY\s+DEFAULT)\s+AS\s+IDENTITY\b`)
+	generatedRegex     = regexp.MustCompile(`(?i)(?:\bGENERATED\s+ALWAYS\s+)?\bAS\s*\(`)
+	generatedKindRegex = regexp.MustCompile(`(?i)^\s*(STORED|VIRTUAL|PERSISTENT)\b`)
+
+	createIndexRegex      = regexp.MustCompile(`(?i)^CREATE\s+(UNIQUE\s+)?INDEX\s+(?:CONCURRENTLY\s+)?(?:IF\s+NOT\s+EXISTS\s+)?(?:(` + sqlQualifiedIdentifier + `)\s+)?ON\s+(?:ONLY\s+)?` + sqlQualifiedIdentifier + `\s*(?:USING\s+(\w+)\s*)?\(`)
+	indexUsingSuffixRegex = regexp.MustCompile(`(?i)^\s*USING\s+(\w+)`)
+	dropIndexRegex        = regexp.MustCompile(`(?i)^DROP\s+INDEX\s+(?:CONCURRENTLY\s+)?(?:IF\s+EXISTS\s+)?(.+?)(?:\s+ON\s+(` + sqlQualifiedIdentifier + `))?(?:\s+(?:CASCADE|RESTRICT))?\s*$`)
+	inlineIndexRegex      = regexp.MustCompile(`(?i)^\s*(UNIQUE\s+)?(?:INDEX|KEY)\s+(?:(` + sqlIdentifier + `)\s*)?(?:USING\s+\w+\s*)?\(((?:[^()]|\([^()]*\))*)\)`)
+	inlineIndexTailRegex  = regexp.MustCompile(`(?i)^\s*(?:USING\s+\w+\s*)?(?:COMMENT\s+'[^']*'\s*)?$`)
+	addIndexRegex         = regexp.MustCompile(`(?i)\bADD\s+(?:UNIQUE\s+)?(?:INDEX|KEY)\b`)
+	addConstraintRegex    = regexp.MustCompile(`(?i)\bADD\s+(?:CONSTRAINT|PRIMARY\s+KEY|FOREIGN\s+KEY|UNIQUE)\b`)
+	prefixLengthRegex     = regexp.MustCompile(`^(.+?)\s*\(\d+\)$`)
+	digitsRegex           = regexp.MustCompile(`^[\d\s,]+$`)
+)
+
+// matchingParen returns the index of the parenthesis closing the one at open, skipping quoted
+// text, or -1 when it is unbalanced
+func matchingParen(s string, open int) int {
+	depth := 0
+	var quote byte
+	for i := open; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// parsePartitionBy reads a PARTITION BY RANGE|LIST|HASH (key) clause
+func parsePartitionBy(clause string) *CanonicalPartition {
+	loc := partitionByRegex.FindStringSubmatchIndex(clause)
+	if loc == nil {
+		return nil
+	}
+	open := loc[1] - 1
+	end := matchingParen(clause, open)
+	if end < 0 {
+		return nil
+	}
+	return &CanonicalPartition{
+		Strategy: strings.ToLower(clause[loc[2]:loc[3]]),
+		Key:      strings.TrimSpace(clause[open+1 : end]),
+	}
+}
+
+// extractIdentity removes a GENERATED ... AS IDENTITY [(options)] clause from a column
+// definition, returning what is left, the identity kind and the sequence options
+func extractIdentity(def string) (rest, kind, options string) {
+	loc := identityRegex.FindStringSubmatchIndex(def)
+	if loc == nil {
+		return def, "", ""
+	}
+	kind = strings.ToLower(strings.Join(strings.Fields(def[loc[2]:loc[3]]), " "))
+	end := loc[1]
+	if trimmed := strings.TrimLeft(def[end:], " "); strings.HasPrefix(trimmed, "(") {
+		open := len(def) - len(trimmed)
+		if close := matchingParen(def, open); close >= 0 {
+			options = strings.TrimSpace(def[open+1 : close])
+			end = close + 1
+		}
+	}
+	return def[:loc[0]] + def[end:], kind, options
+}
+
+// extractGenerated removes a generated column clause (GENERATED ALWAYS AS (expr) STORED, or
+// MySQL's AS (expr) VIRTUAL) from a column definition
+func extractGenerated(def string) (string, *GeneratedColumn) {
+	loc := generatedRegex.FindStringIndex(def)
+	if loc == nil {
+		return def, nil
+	}
+	open := loc[1] - 1
+	end := matchingParen(def, open)
+	if end < 0 {
+		return def, nil
+	}
+
+	generated := &GeneratedColumn{Expression: strings.TrimSpace(def[open+1 : end])}
+	end++
+	if kind := generatedKindRegex.FindStringSubmatchIndex(def[end:]); kind != nil {
+		generated.Stored = !strings.EqualFold(def[end+kind[2]:end+kind[3]], "VIRTUAL")
+		end += kind[1]
+	}
+	return def[:loc[0]] + def[end:], generated
+}
+
+// columnType returns the column type starting at fields[1], rejoining types that contain spaces
+// inside parentheses such as numeric(10, 2)
+func columnType(fields []string) string {
+	columnType := fields[1]
+	for i := 2; i < len(fields) && strings.Count(columnType, "(") > strings.Count(columnType, ")"); i++ {
+		columnType += " " + fields[i]
+	}
+	return strings.ToLower(columnType)
+}
+
+// isInlineIndexDef reports whether a CREATE TABLE definition is a MySQL INDEX / KEY entry rather
+// than a column that happens to be named key, such as "key varchar(255)"
+func isInlineIndexDef(def string) bool {
+	loc := inlineIndexRegex.FindStringSubmatchIndex(def)
+	if loc == nil {
+		return false
+	}
+	return inlineIndexTailRegex.MatchString(def[loc[1]:]) && !digitsRegex.MatchString(def[loc[6]:loc[7]])
+}
+
+// indexColumns parses an index column list, dropping sort order and MySQL prefix lengths and
+// keeping expressions as written
+func indexColumns(list string) []string {
+	var columns []string
+	for _, entry := range splitTopLevel(list) {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if matches := prefixLengthRegex.FindStringSubmatch(entry); matches != nil {
+			entry = matches[1]
+		}
+		if strings.Contains(entry, "(") {
+			columns = append(columns, strings.ToLower(strings.Join(strings.Fields(entry), " ")))
+			continue
+		}
+		columns = append(columns, splitQualifiedName(strings.Fields(entry)[0])[0])
+	}
+	return columns
+}
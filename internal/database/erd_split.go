@@ -0,0 +1,300 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"repo-explanation/internal/mermaid"
+)
+
+// ERDDomain is one domain of a split ERD: a group of tables drawn as their own erDiagram
+type ERDDomain struct {
+	Name    string   `json:"name"`
+	Tables  []string `json:"tables"`
+	Mermaid string   `json:"mermaid"` // Its tables, plus the tables of other domains they reference without their columns
+}
+
+// ERDLink counts the foreign keys from the tables of one domain to those of another
+type ERDLink struct {
+	From        string `json:"from"`
+	To          string `json:"to"`
+	ForeignKeys int    `json:"foreign_keys"`
+}
+
+// ERDSplit is an ERD too large to read as one diagram, split into domain subdiagrams with an index
+// diagram of the links between them
+type ERDSplit struct {
+	Domains []ERDDomain `json:"domains"`
+	Links   []ERDLink   `json:"links"`
+	Index   string      `json:"index"` // Mermaid flowchart of the domains and their cross-domain foreign keys
+	Summary string      `json:"summary"`
+}
+
+// otherDomain holds the tables no rule places in a domain
+const otherDomain = "other"
+
+// erdDomains are the domains recognized from the words of a table's name, first match winning
+var erdDomains = []struct {
+	name  string
+	words []string
+}{
+	{"users/auth", []string{"user", "account", "auth", "session", "token", "role", "permission", "login", "password", "credential", "oauth", "profile", "identity", "mfa", "otp", "invite", "invitation", "apikey"}},
+	{"organizations", []string{"organization", "organisation", "org", "tenant", "team", "workspace", "member", "membership", "company"}},
+	{"billing", []string{"billing", "invoice", "payment", "subscription", "charge", "refund", "plan", "price", "pricing", "coupon", "discount", "transaction", "payout", "tax", "wallet", "ledger"}},
+	{"catalog", []string{"product", "catalog", "category", "sku", "inventory", "variant", "brand", "stock", "warehouse"}},
+	{"orders", []string{"order", "cart", "checkout", "shipment", "shipping", "fulfillment", "delivery"}},
+	{"content", []string{"post", "comment", "article", "page", "media", "attachment", "tag", "blog", "review", "document", "image"}},
+	{"messaging", []string{"notification", "message", "email", "sms", "chat", "conversation", "webhook"}},
+	{"scheduling", []string{"booking", "appointment", "reservation", "schedule", "calendar", "slot"}},
+	{"audit", []string{"audit", "log", "activity", "history", "event"}},
+	{"analytics", []string{"metric", "analytics", "stat", "report"}},
+}
+
+// SplitERD clusters the tables into domains and renders one erDiagram per domain. Tables take the
+// domain of their Postgres schema when the tables span several schemas, then of a well-known word
+// in their name (users/auth, billing, catalog, ...), then of a name prefix they share with other
+// tables. The rest join the domain they have the most foreign keys to; connected leftovers form a
+// domain named after their most referenced table and lone tables go to "other".
+func (s *DatabaseSchema) SplitERD() *ERDSplit {
+	if len(s.Tables) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(s.Tables))
+	for name := range s.Tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Foreign keys as child -> parent pairs, one per column; self-references do not cluster
+	var edges [][2]string
+	neighbours := make(map[string]map[string]int)
+	referenced := make(map[string]int)
+	for _, name := range names {
+		for _, column := range sortedColumns(s.Tables[name]) {
+			ref := column.References
+			if ref == nil || ref.Table == name {
+				continue
+			}
+			if _, ok := s.Tables[ref.Table]; !ok {
+				continue
+			}
+			edges = append(edges, [2]string{name, ref.Table})
+			referenced[ref.Table]++
+			for _, pair := range [][2]string{{name, ref.Table}, {ref.Table, name}} {
+				if neighbours[pair[0]] == nil {
+					neighbours[pair[0]] = make(map[string]int)
+				}
+				neighbours[pair[0]][pair[1]]++
+			}
+		}
+	}
+
+	domain := make(map[string]string)
+	schemas := make(map[string]bool)
+	for _, name := range names {
+		schemas[defaultSchema(name)] = true
+	}
+	for _, name := range names {
+		if schema := defaultSchema(name); len(schemas) > 1 && schema != "" {
+			domain[name] = schema
+		} else if d := keywordDomain(name); d != "" {
+			domain[name] = d
+		}
+	}
+
+	// Shared name prefixes: projects, project_members and project_tasks form "projects"
+	prefixed := make(map[string][]string)
+	for _, name := range names {
+		if domain[name] == "" {
+			words := nameWords(name)
+			prefixed[words[0]] = append(prefixed[words[0]], name)
+		}
+	}
+	for _, tables := range prefixed {
+		if len(tables) < 2 {
+			continue
+		}
+		// Named as the shortest table writes it: "projects" rather than "project"
+		shortest := tables[0]
+		for _, name := range tables {
+			if len(name) < len(shortest) {
+				shortest = name
+			}
+		}
+		_, label := splitTableKey(shortest)
+		label = strings.FieldsFunc(strings.ToLower(label), func(r rune) bool { return r == '_' || r == '-' })[0]
+		for _, name := range tables {
+			domain[name] = label
+		}
+	}
+
+	// Foreign key connectivity, a round at a time so the result does not depend on table order
+	for changed := true; changed; {
+		changed = false
+		joined := make(map[string]string)
+		for _, name := range names {
+			if domain[name] != "" {
+				continue
+			}
+			weights := make(map[string]int)
+			for neighbour, count := range neighbours[name] {
+				if d := domain[neighbour]; d != "" {
+					weights[d] += count
+				}
+			}
+			if best := heaviest(weights); best != "" {
+				joined[name] = best
+			}
+		}
+		for name, d := range joined {
+			domain[name] = d
+			changed = true
+		}
+	}
+
+	// Leftovers linked only to each other form their own domains
+	for _, name := range names {
+		if domain[name] != "" {
+			continue
+		}
+		component := []string{name}
+		domain[name] = "\x00"
+		for i := 0; i < len(component); i++ {
+			for neighbour := range neighbours[component[i]] {
+				if domain[neighbour] == "" {
+					domain[neighbour] = "\x00"
+					component = append(component, neighbour)
+				}
+			}
+		}
+		label := otherDomain
+		if len(component) > 1 {
+			weights := make(map[string]int)
+			for _, table := range component {
+				weights[table] = referenced[table]
+			}
+			_, label = splitTableKey(heaviest(weights))
+		}
+		for _, table := range component {
+			domain[table] = label
+		}
+	}
+
+	byDomain := make(map[string][]string)
+	for _, name := range names {
+		byDomain[domain[name]] = append(byDomain[domain[name]], name)
+	}
+	split := &ERDSplit{Domains: []ERDDomain{}, Links: []ERDLink{}}
+	for name, tables := range byDomain {
+		sort.Strings(tables)
+		split.Domains = append(split.Domains, ERDDomain{Name: name, Tables: tables, Mermaid: s.mermaidERD(tables)})
+	}
+	sort.Slice(split.Domains, func(i, j int) bool {
+		if (split.Domains[i].Name == otherDomain) != (split.Domains[j].Name == otherDomain) {
+			return split.Domains[j].Name == otherDomain
+		}
+		return split.Domains[i].Name < split.Domains[j].Name
+	})
+
+	counts := make(map[[2]string]int)
+	for _, edge := range edges {
+		if from, to := domain[edge[0]], domain[edge[1]]; from != to {
+			counts[[2]string{from, to}]++
+		}
+	}
+	crossing := 0
+	for pair, count := range counts {
+		split.Links = append(split.Links, ERDLink{From: pair[0], To: pair[1], ForeignKeys: count})
+		crossing += count
+	}
+	sort.Slice(split.Links, func(i, j int) bool {
+		if split.Links[i].From != split.Links[j].From {
+			return split.Links[i].From < split.Links[j].From
+		}
+		return split.Links[i].To < split.Links[j].To
+	})
+
+	chart := mermaid.NewFlowchart("LR")
+	for _, d := range split.Domains {
+		chart.Node("domain_"+d.Name, fmt.Sprintf("%s (%s)", d.Name, plural(len(d.Tables), "table")), mermaid.Rounded)
+	}
+	for _, link := range split.Links {
+		chart.Edge("domain_"+link.From, "domain_"+link.To, mermaid.Solid, plural(link.ForeignKeys, "FK"))
+	}
+	split.Index = chart.String()
+
+	split.Summary = fmt.Sprintf("%s in %s; %s cross domains", plural(len(names), "table"), plural(len(split.Domains), "domain"), plural(crossing, "foreign key"))
+	return split
+}
+
+// keywordDomain returns the domain named by the first well-known word of a table's name, or ""
+func keywordDomain(table string) string {
+	for _, word := range nameWords(table) {
+		for _, d := range erdDomains {
+			for _, keyword := range d.words {
+				if word == keyword {
+					return d.name
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// nameWords splits a table's name, without its schema, into singular lowercase words
+func nameWords(table string) []string {
+	_, name := splitTableKey(table)
+	var words []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(name), func(r rune) bool { return r == '_' || r == '-' }) {
+		switch {
+		case strings.HasSuffix(word, "ies") && len(word) > 4:
+			word = strings.TrimSuffix(word, "ies") + "y"
+		case strings.HasSuffix(word, "sses"), strings.HasSuffix(word, "xes"), strings.HasSuffix(word, "ches"), strings.HasSuffix(word, "shes"):
+			word = strings.TrimSuffix(word, "es")
+		case strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") && len(word) > 3:
+			word = strings.TrimSuffix(word, "s")
+		}
+		words = append(words, word)
+	}
+	if len(words) == 0 {
+		return []string{name}
+	}
+	return words
+}
+
+// defaultSchema returns a table's schema, or "" for the default one
+func defaultSchema(table string) string {
+	schema, _ := splitTableKey(table)
+	if schema == "public" || schema == "dbo" {
+		return ""
+	}
+	return schema
+}
+
+// heaviest returns the key with the largest weight, the alphabetically first on ties
+func heaviest(weights map[string]int) string {
+	best := ""
+	for key, weight := range weights {
+		if best == "" || weight > weights[best] || weight == weights[best] && key < best {
+			best = key
+		}
+	}
+	return best
+}
+
+func sortedColumns(table Table) []Column {
+	columns := make([]Column, 0, len(table.Columns))
+	for _, column := range table.Columns {
+		columns = append(columns, column)
+	}
+	sort.Slice(columns, func(i, j int) bool { return columns[i].Name < columns[j].Name })
+	return columns
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,829 @@
+package database
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"repo-explanation/internal/portable"
+)
+
+// Severity ranks a lint finding
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// Lint rule identifiers
+const (
+	RuleDuplicateVersion        = "duplicate-version"
+	RuleMissingDown             = "missing-down"
+	RuleNonIdempotent           = "non-idempotent"
+	RuleLockingAlter            = "locking-alter"
+	RuleDroppedColumnReferenced = "dropped-column-referenced"
+	RuleDownMismatch            = "down-mismatch"
+)
+
+// LintFinding is one problem found in a migration file
+type LintFinding struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	File     string   `json:"file"`
+	Line     int      `json:"line,omitempty"`
+	Message  string   `json:"message"`
+}
+
+// LintReport lists the findings over a project's migrations, ordered by file and line
+type LintReport struct {
+	Migrations int           `json:"migrations"`
+	Findings   []LintFinding `json:"findings"`
+	Errors     int           `json:"errors"`
+	Warnings   int           `json:"warnings"`
+	Infos      int           `json:"infos"`
+	// Tables maps each table, by lowercase name without its schema, to the up migration that first
+	// creates it; schema lint findings are located there
+	Tables map[string]TableDefinition `json:"-"`
+}
+
+// TableDefinition is the CREATE TABLE statement of a migration
+type TableDefinition struct {
+	File string
+	Line int
+}
+
+// HasErrors reports whether any finding is an error
+func (r *LintReport) HasErrors() bool {
+	return r.Errors > 0
+}
+
+// Definition returns where a table, named as in the schema, is first created
+func (r *LintReport) Definition(table string) (TableDefinition, bool) {
+	definition, ok := r.Tables[lintName(table)]
+	return definition, ok
+}
+
+// Migration directions
+const (
+	directionUp   = "up"
+	directionDown = "down"
+)
+
+// migrationScript is a migration file with the version and direction read from its name
+type migrationScript struct {
+	Path       string
+	Dir        string // Folder the script is versioned within; a Flyway script's location root
+	Name       string
+	Version    string // Normalized version; empty for repeatable or unversioned scripts
+	Direction  string
+	Content    string
+	HasDown    bool // An in-file down section (goose, sql-migrate, dbmate)
+	Repeatable bool // A Flyway R__ script, re-applied after every versioned migration
+}
+
+var (
+	flywayName   = regexp.MustCompile(`^([VvUu])(\d+(?:[._]\d+)*)__`)
+	flywayRepeat = regexp.MustCompile(`^[Rr]__`)
+	numberedName = regexp.MustCompile(`^(\d+)`)
+	downSection  = regexp.MustCompile(`(?im)^\s*--\s*(\+goose\s+down|\+migrate\s+down|migrate:down)\b`)
+)
+
+// parseMigrationScript reads the version and direction of a migration from its file name
+func parseMigrationScript(filePath, content string) migrationScript {
+	slashPath := portable.Slash(filePath)
+	script := migrationScript{
+		Path:      slashPath,
+		Dir:       path.Dir(slashPath),
+		Name:      path.Base(slashPath),
+		Direction: directionUp,
+		Content:   portable.Text(content),
+	}
+	lower := strings.ToLower(script.Name)
+
+	if m := flywayName.FindStringSubmatch(script.Name); m != nil {
+		script.Version = normalizeVersion(m[2])
+		script.Dir = flywayLocation(script.Dir)
+		if strings.EqualFold(m[1], "u") {
+			script.Direction = directionDown
+		}
+	} else if flywayRepeat.MatchString(script.Name) {
+		script.Repeatable = true
+		script.Dir = flywayLocation(script.Dir)
+	} else if m := numberedName.FindStringSubmatch(script.Name); m != nil {
+		script.Version = normalizeVersion(m[1])
+	}
+	if strings.HasSuffix(lower, ".down.sql") || strings.HasSuffix(lower, "_down.sql") {
+		script.Direction = directionDown
+	}
+	script.HasDown = downSection.MatchString(script.Content)
+	return script
+}
+
+// flywayLocation returns the Flyway location a script folder belongs to: the deepest folder named
+// like "migration", since Flyway orders scripts in subfolders (db/migration/v2/...) as one sequence
+func flywayLocation(dir string) string {
+	segments := strings.Split(dir, "/")
+	for i := len(segments) - 1; i >= 0; i-- {
+		if strings.Contains(strings.ToLower(segments[i]), "migration") {
+			return strings.Join(segments[:i+1], "/")
+		}
+	}
+	return dir
+}
+
+// normalizeVersion drops leading zeros so 001 and 1 compare equal
+func normalizeVersion(version string) string {
+	parts := strings.FieldsFunc(version, func(r rune) bool { return r == '.' || r == '_' })
+	for i, part := range parts {
+		if n, err := strconv.ParseUint(part, 10, 64); err == nil {
+			parts[i] = strconv.FormatUint(n, 10)
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// sortMigrationScripts orders scripts the way migration tools apply them: by folder, then version,
+// then name, with Flyway repeatable scripts after all versioned ones
+func sortMigrationScripts(scripts []migrationScript) {
+	sort.Slice(scripts, func(i, j int) bool {
+		if scripts[i].Dir != scripts[j].Dir {
+			return scripts[i].Dir < scripts[j].Dir
+		}
+		if scripts[i].Repeatable != scripts[j].Repeatable {
+			return scripts[j].Repeatable
+		}
+		if c := compareVersions(scripts[i].Version, scripts[j].Version); c != 0 {
+			return c < 0
+		}
+		return scripts[i].Name < scripts[j].Name
+	})
+}
+
+// compareVersions orders normalized versions numerically, component by component
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aErr := strconv.ParseUint(as[i], 10, 64)
+		bn, bErr := strconv.ParseUint(bs[i], 10, 64)
+		switch {
+		case aErr != nil || bErr != nil:
+			if c := strings.Compare(as[i], bs[i]); c != 0 {
+				return c
+			}
+		case an < bn:
+			return -1
+		case an > bn:
+			return 1
+		}
+	}
+	return len(as) - len(bs)
+}
+
+// sqlStatement is one statement of a script with the line it starts on; comments are removed
+type sqlStatement struct {
+	Text string
+	Line int
+}
+
+// splitStatementsWithLines splits SQL on semicolons outside strings, quoted identifiers,
+// dollar-quoted bodies and comments, keeping the starting line of each statement
+func splitStatementsWithLines(content string) []sqlStatement {
+	var statements []sqlStatement
+	var current strings.Builder
+	line, start := 1, 0
+
+	flush := func() {
+		if text := strings.TrimSpace(current.String()); text != "" {
+			statements = append(statements, sqlStatement{Text: text, Line: start})
+		}
+		current.Reset()
+		start = 0
+	}
+
+	for i := 0; i < len(content); i++ {
+		ch := content[i]
+		if start == 0 && !isSpace(ch) && !strings.HasPrefix(content[i:], "--") && !strings.HasPrefix(content[i:], "/*") {
+			start = line
+		}
+		switch {
+		case ch == '\n':
+			line++
+			current.WriteByte(ch)
+		case strings.HasPrefix(content[i:], "--"):
+			end := strings.IndexByte(content[i:], '\n')
+			if end < 0 {
+				i = len(content)
+			} else {
+				i += end - 1
+			}
+		case strings.HasPrefix(content[i:], "/*"):
+			end := strings.Index(content[i+2:], "*/")
+			if end < 0 {
+				end = len(content) - i - 2
+			}
+			comment := content[i : i+2+end]
+			line += strings.Count(comment, "\n")
+			current.WriteByte(' ')
+			i += 2 + end + 1
+		case ch == '\'' || ch == '"' || ch == '`':
+			end := i + 1
+			for end < len(content) && content[end] != ch {
+				end++
+			}
+			if end >= len(content) {
+				end = len(content) - 1
+			}
+			quoted := content[i : end+1]
+			line += strings.Count(quoted, "\n")
+			current.WriteString(quoted)
+			i = end
+		case ch == '$':
+			if tag := dollarTag.FindString(content[i:]); tag != "" {
+				end := strings.Index(content[i+len(tag):], tag)
+				if end < 0 {
+					end = len(content) - i - len(tag)
+				} else {
+					end += len(tag)
+				}
+				body := content[i : i+len(tag)+end]
+				line += strings.Count(body, "\n")
+				current.WriteString(body)
+				i += len(tag) + end - 1
+				break
+			}
+			current.WriteByte(ch)
+		case ch == ';':
+			flush()
+		default:
+			current.WriteByte(ch)
+		}
+	}
+	flush()
+	return statements
+}
+
+var dollarTag = regexp.MustCompile(`^\$[A-Za-z_]*\$`)
+
+func isSpace(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r'
+}
+
+const lintIdent = `(?:"[^"]+"|` + "`[^`]+`" + `|[\w$]+)(?:\.(?:"[^"]+"|` + "`[^`]+`" + `|[\w$]+))?`
+
+var (
+	lintCreateTable   = regexp.MustCompile(`(?is)^CREATE\s+(?:(?:GLOBAL|LOCAL)\s+)?(?:TEMP(?:ORARY)?\s+|UNLOGGED\s+)?TABLE\s+(IF\s+NOT\s+EXISTS\s+)?(` + lintIdent + `)\s*\((.*)\)`)
+	lintCreateIndex   = regexp.MustCompile(`(?is)^CREATE\s+(?:UNIQUE\s+)?INDEX\s+(CONCURRENTLY\s+)?(IF\s+NOT\s+EXISTS\s+)?(` + lintIdent + `\s+)?ON\s+(?:ONLY\s+)?(` + lintIdent + `)\s*(?:USING\s+\w+\s*)?\(([^)]*)\)`)
+	lintCreateOther   = regexp.MustCompile(`(?is)^CREATE\s+(SCHEMA|SEQUENCE|EXTENSION|MATERIALIZED\s+VIEW|VIEW|FUNCTION|PROCEDURE|TRIGGER)\s+(IF\s+NOT\s+EXISTS\s+)?`)
+	lintDrop          = regexp.MustCompile(`(?is)^DROP\s+(TABLE|INDEX|VIEW|MATERIALIZED\s+VIEW|SEQUENCE|SCHEMA|TYPE|FUNCTION|PROCEDURE|TRIGGER|EXTENSION|DOMAIN)\s+(?:CONCURRENTLY\s+)?(IF\s+EXISTS\s+)?([^;]*)`)
+	lintAlterTable    = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+(?:IF\s+EXISTS\s+)?(?:ONLY\s+)?(` + lintIdent + `)\s+(.*)$`)
+	lintAddConstraint = regexp.MustCompile(`(?is)^ADD\s+(?:CONSTRAINT\s+(` + lintIdent + `)\s+)?(FOREIGN\s+KEY|CHECK|UNIQUE|PRIMARY\s+KEY|INDEX|KEY)\b(.*)$`)
+	lintAddColumn     = regexp.MustCompile(`(?is)^ADD\s+(?:COLUMN\s+)?(IF\s+NOT\s+EXISTS\s+)?(` + lintIdent + `)\s+(.*)$`)
+	lintDropColumn    = regexp.MustCompile(`(?is)^DROP\s+(?:COLUMN\s+)?(IF\s+EXISTS\s+)?(` + lintIdent + `)(.*)$`)
+	lintDropNamed     = regexp.MustCompile(`(?is)^DROP\s+(CONSTRAINT|INDEX|KEY|FOREIGN\s+KEY)\s+(IF\s+EXISTS\s+)?(` + lintIdent + `)`)
+	lintAlterType     = regexp.MustCompile(`(?is)^(?:ALTER\s+(?:COLUMN\s+)?(` + lintIdent + `)\s+(?:SET\s+DATA\s+)?TYPE\b|(?:MODIFY|CHANGE)\s+(?:COLUMN\s+)?(` + lintIdent + `))`)
+	lintSetNotNull    = regexp.MustCompile(`(?is)^ALTER\s+(?:COLUMN\s+)?(` + lintIdent + `)\s+SET\s+NOT\s+NULL`)
+	lintRenameColumn  = regexp.MustCompile(`(?is)^RENAME\s+(?:COLUMN\s+)?(` + lintIdent + `)\s+TO\s+(` + lintIdent + `)`)
+	lintReferences    = regexp.MustCompile(`(?is)\bREFERENCES\s+(` + lintIdent + `)\s*(?:\(([^)]*)\))?`)
+	lintForeignKey    = regexp.MustCompile(`(?is)FOREIGN\s+KEY\s*\(([^)]*)\)\s*REFERENCES\s+(` + lintIdent + `)\s*(?:\(([^)]*)\))?`)
+	lintConstraint    = regexp.MustCompile(`(?is)^CONSTRAINT\s+(` + lintIdent + `)\s+(.*)$`)
+	lintNotValid      = regexp.MustCompile(`(?i)\bNOT\s+VALID\b`)
+	lintCascade       = regexp.MustCompile(`(?i)\bCASCADE\b`)
+	lintLockAware     = regexp.MustCompile(`(?i)\block_timeout\b|\bLOCK\s*=\s*(NONE|SHARED)\b|\bALGORITHM\s*=\s*(INPLACE|INSTANT)\b|\bpt-online-schema-change\b|\bgh-ost\b`)
+	lintTableKeywords = map[string]bool{"CONSTRAINT": true, "PRIMARY": true, "FOREIGN": true, "UNIQUE": true, "CHECK": true, "INDEX": true, "KEY": true, "EXCLUDE": true, "LIKE": true}
+)
+
+// lintIndex and lintForeignKey are the dependencies tracked to catch dropped columns still in use
+type lintIndex struct {
+	Name    string
+	Table   string
+	Columns []string
+	File    string
+	Line    int
+}
+
+type lintFK struct {
+	Name       string
+	Table      string
+	Columns    []string
+	RefTable   string
+	RefColumns []string
+	File       string
+	Line       int
+}
+
+// migrationLinter replays up migrations in version order, tracking indexes and foreign keys
+type migrationLinter struct {
+	report  *LintReport
+	indexes []*lintIndex
+	fks     []*lintFK
+}
+
+// LintMigrations checks the SQL migrations among files (keyed by repository-relative path) for
+// duplicate versions, missing down migrations, non-idempotent statements, locking ALTER TABLEs and
+// dropped columns that indexes or foreign keys still reference
+func LintMigrations(files map[string]string) *LintReport {
+	var scripts []migrationScript
+	for filePath, content := range files {
+		if !strings.HasSuffix(strings.ToLower(filePath), ".sql") {
+			continue
+		}
+		if !isMigrationDir(path.Dir(portable.Slash(filePath))) {
+			continue
+		}
+		scripts = append(scripts, parseMigrationScript(filePath, content))
+	}
+	sortMigrationScripts(scripts)
+
+	l := &migrationLinter{report: &LintReport{Migrations: len(scripts), Findings: []LintFinding{}, Tables: map[string]TableDefinition{}}}
+	l.checkVersions(scripts)
+	l.checkReversals(scripts)
+
+	for _, script := range scripts {
+		l.checkStatements(script)
+	}
+
+	sort.SliceStable(l.report.Findings, func(i, j int) bool {
+		a, b := l.report.Findings[i], l.report.Findings[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+	for _, finding := range l.report.Findings {
+		switch finding.Severity {
+		case SeverityError:
+			l.report.Errors++
+		case SeverityWarning:
+			l.report.Warnings++
+		default:
+			l.report.Infos++
+		}
+	}
+	return l.report
+}
+
+// isMigrationDir reports whether a directory holds migrations, like FindMigrationFolders does
+func isMigrationDir(dir string) bool {
+	for _, segment := range portable.Segments(dir) {
+		if strings.Contains(strings.ToLower(segment), "migration") {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *migrationLinter) add(rule string, severity Severity, file string, line int, format string, args ...interface{}) {
+	l.report.Findings = append(l.report.Findings, LintFinding{
+		Rule:     rule,
+		Severity: severity,
+		File:     file,
+		Line:     line,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// checkVersions reports duplicate versions and up migrations without a way back, per directory
+func (l *migrationLinter) checkVersions(scripts []migrationScript) {
+	byDir := make(map[string][]migrationScript)
+	var dirs []string
+	for _, script := range scripts {
+		if _, seen := byDir[script.Dir]; !seen {
+			dirs = append(dirs, script.Dir)
+		}
+		byDir[script.Dir] = append(byDir[script.Dir], script)
+	}
+
+	for _, dir := range dirs {
+		ups := make(map[string][]migrationScript)
+		downs := make(map[string]bool)
+		usesDownFiles, usesDownSections := false, false
+		for _, script := range byDir[dir] {
+			if script.Version == "" {
+				continue
+			}
+			if script.Direction == directionDown {
+				downs[script.Version] = true
+				usesDownFiles = true
+				continue
+			}
+			ups[script.Version] = append(ups[script.Version], script)
+			usesDownSections = usesDownSections || script.HasDown
+		}
+
+		var versions []string
+		for version := range ups {
+			versions = append(versions, version)
+		}
+		sort.Slice(versions, func(i, j int) bool { return compareVersions(versions[i], versions[j]) < 0 })
+
+		for _, version := range versions {
+			group := ups[version]
+			if len(group) > 1 {
+				names := make([]string, len(group))
+				for i, script := range group {
+					names[i] = script.Name
+				}
+				for _, script := range group[1:] {
+					l.add(RuleDuplicateVersion, SeverityError, script.Path, 0,
+						"version %s is used by %d migrations (%s); only one will run", version, len(group), strings.Join(names, ", "))
+				}
+			}
+			for _, script := range group {
+				if (usesDownFiles || usesDownSections) && !downs[version] && !script.HasDown {
+					l.add(RuleMissingDown, SeverityWarning, script.Path, 0, "no down migration for version %s", version)
+				}
+			}
+		}
+
+		if len(versions) > 0 && !usesDownFiles && !usesDownSections {
+			l.add(RuleMissingDown, SeverityInfo, dir, 0,
+				"none of the %d migrations in %s has a down migration; rollbacks must be written by hand", len(versions), dir)
+		}
+	}
+}
+
+// checkStatements lints each statement of a script; only up migrations change the tracked schema
+func (l *migrationLinter) checkStatements(script migrationScript) {
+	content := script.Content
+	if script.HasDown {
+		// Statements after the down marker undo the up section and are not replayed
+		if loc := downSection.FindStringIndex(content); loc != nil {
+			l.lintStatements(script, content[loc[0]:], false, strings.Count(content[:loc[0]], "\n"))
+			content = content[:loc[0]]
+		}
+	}
+	l.lintStatements(script, content, script.Direction == directionUp, 0)
+}
+
+func (l *migrationLinter) lintStatements(script migrationScript, content string, replay bool, lineOffset int) {
+	lockAware := lintLockAware.MatchString(content)
+	created := make(map[string]bool) // Tables created earlier in this script are empty, so locking them is harmless
+
+	for _, stmt := range splitStatementsWithLines(content) {
+		text, line := stmt.Text, stmt.Line+lineOffset
+
+		if m := lintCreateTable.FindStringSubmatch(text); m != nil {
+			table := lintName(m[2])
+			created[table] = true
+			if m[1] == "" {
+				l.add(RuleNonIdempotent, SeverityWarning, script.Path, line, "CREATE TABLE %s without IF NOT EXISTS fails when re-run", table)
+			}
+			if replay {
+				l.trackCreateTable(table, m[3], script.Path, line)
+				if _, seen := l.report.Tables[table]; !seen {
+					l.report.Tables[table] = TableDefinition{File: script.Path, Line: line}
+				}
+			}
+			continue
+		}
+
+		if m := lintCreateIndex.FindStringSubmatch(text); m != nil {
+			table := lintName(m[4])
+			if m[2] == "" && m[3] != "" {
+				l.add(RuleNonIdempotent, SeverityWarning, script.Path, line, "CREATE INDEX %s without IF NOT EXISTS fails when re-run", lintName(m[3]))
+			}
+			if m[1] == "" && !created[table] && !lockAware {
+				l.add(RuleLockingAlter, SeverityWarning, script.Path, line,
+					"CREATE INDEX on %s blocks writes while it builds; use CREATE INDEX CONCURRENTLY (outside a transaction)", table)
+			}
+			if replay {
+				l.indexes = append(l.indexes, &lintIndex{Name: lintName(m[3]), Table: table, Columns: lintColumns(m[5]), File: script.Path, Line: line})
+			}
+			continue
+		}
+
+		if m := lintCreateOther.FindStringSubmatch(text); m != nil {
+			kind := strings.ToUpper(strings.Join(strings.Fields(m[1]), " "))
+			switch kind {
+			case "VIEW", "FUNCTION", "PROCEDURE", "TRIGGER":
+				l.add(RuleNonIdempotent, SeverityWarning, script.Path, line, "CREATE %s without OR REPLACE fails when re-run", kind)
+			default:
+				if m[2] == "" {
+					l.add(RuleNonIdempotent, SeverityWarning, script.Path, line, "CREATE %s without IF NOT EXISTS fails when re-run", kind)
+				}
+			}
+			continue
+		}
+
+		if m := lintDrop.FindStringSubmatch(text); m != nil {
+			kind := strings.ToUpper(strings.Join(strings.Fields(m[1]), " "))
+			if m[2] == "" {
+				l.add(RuleNonIdempotent, SeverityWarning, script.Path, line, "DROP %s without IF EXISTS fails when re-run", kind)
+			}
+			if replay {
+				for _, name := range strings.Split(m[3], ",") {
+					fields := strings.Fields(name)
+					if len(fields) == 0 {
+						continue
+					}
+					switch kind {
+					case "TABLE":
+						l.forgetTable(lintName(fields[0]))
+					case "INDEX":
+						l.forgetIndex(lintName(fields[0]))
+					}
+				}
+			}
+			continue
+		}
+
+		if m := lintAlterTable.FindStringSubmatch(text); m != nil {
+			table := lintName(m[1])
+			for _, action := range splitTopLevel(m[2]) {
+				l.lintAlterAction(script, table, strings.TrimSpace(action), line, replay, lockAware || created[table])
+			}
+		}
+	}
+}
+
+// lintAlterAction checks one comma-separated action of an ALTER TABLE
+func (l *migrationLinter) lintAlterAction(script migrationScript, table, action string, line int, replay, lockSafe bool) {
+	if m := lintAddConstraint.FindStringSubmatch(action); m != nil {
+		kind := strings.ToUpper(strings.Join(strings.Fields(m[2]), " "))
+		if !lockSafe && (kind == "FOREIGN KEY" || kind == "CHECK") && !lintNotValid.MatchString(action) {
+			l.add(RuleLockingAlter, SeverityWarning, script.Path, line,
+				"adding %s constraint to %s validates every row under lock; add it NOT VALID and VALIDATE CONSTRAINT separately", kind, table)
+		}
+		if replay && kind == "FOREIGN KEY" {
+			if fk := lintForeignKey.FindStringSubmatch(action); fk != nil {
+				l.fks = append(l.fks, &lintFK{Name: lintName(m[1]), Table: table, Columns: lintColumns(fk[1]),
+					RefTable: lintName(fk[2]), RefColumns: lintColumns(fk[3]), File: script.Path, Line: line})
+			}
+		}
+		if replay && (kind == "INDEX" || kind == "KEY" || kind == "UNIQUE") {
+			if open := strings.Index(m[3], "("); open >= 0 {
+				if end := strings.Index(m[3][open:], ")"); end > 0 {
+					l.indexes = append(l.indexes, &lintIndex{Name: lintName(m[1]), Table: table, Columns: lintColumns(m[3][open+1 : open+end]), File: script.Path, Line: line})
+				}
+			}
+		}
+		return
+	}
+
+	if m := lintDropNamed.FindStringSubmatch(action); m != nil {
+		if m[2] == "" {
+			l.add(RuleNonIdempotent, SeverityWarning, script.Path, line, "DROP %s %s without IF EXISTS fails when re-run", strings.ToUpper(m[1]), lintName(m[3]))
+		}
+		if replay {
+			l.forgetIndex(lintName(m[3]))
+			l.forgetForeignKey(lintName(m[3]))
+		}
+		return
+	}
+
+	if m := lintRenameColumn.FindStringSubmatch(action); m != nil {
+		if replay {
+			l.renameColumn(table, lintName(m[1]), lintName(m[2]))
+		}
+		return
+	}
+
+	if m := lintAlterType.FindStringSubmatch(action); m != nil {
+		if !lockSafe {
+			column := lintName(m[1] + m[2])
+			l.add(RuleLockingAlter, SeverityWarning, script.Path, line,
+				"changing the type of %s.%s rewrites the table under an exclusive lock", table, column)
+		}
+		return
+	}
+
+	if m := lintSetNotNull.FindStringSubmatch(action); m != nil {
+		if !lockSafe {
+			l.add(RuleLockingAlter, SeverityWarning, script.Path, line,
+				"SET NOT NULL on %s.%s scans the whole table under an exclusive lock; add a NOT VALID CHECK constraint first", table, lintName(m[1]))
+		}
+		return
+	}
+
+	if m := lintDropColumn.FindStringSubmatch(action); m != nil && !lintTableKeywords[strings.ToUpper(lintName(m[2]))] {
+		column := lintName(m[2])
+		if m[1] == "" {
+			l.add(RuleNonIdempotent, SeverityWarning, script.Path, line, "DROP COLUMN %s.%s without IF EXISTS fails when re-run", table, column)
+		}
+		if replay {
+			l.checkDroppedColumn(script, table, column, line, lintCascade.MatchString(m[3]))
+		}
+		return
+	}
+
+	if m := lintAddColumn.FindStringSubmatch(action); m != nil && !lintTableKeywords[strings.ToUpper(lintName(m[2]))] {
+		column := lintName(m[2])
+		definition := strings.ToUpper(m[3])
+		if m[1] == "" {
+			l.add(RuleNonIdempotent, SeverityWarning, script.Path, line, "ADD COLUMN %s.%s without IF NOT EXISTS fails when re-run", table, column)
+		}
+		if !lockSafe && strings.Contains(definition, "NOT NULL") && !strings.Contains(definition, "DEFAULT") {
+			l.add(RuleLockingAlter, SeverityWarning, script.Path, line,
+				"adding NOT NULL column %s.%s without a DEFAULT fails on a non-empty table", table, column)
+		}
+		if replay {
+			if ref := lintReferences.FindStringSubmatch(m[3]); ref != nil {
+				l.fks = append(l.fks, &lintFK{Table: table, Columns: []string{column}, RefTable: lintName(ref[1]), RefColumns: lintColumns(ref[2]), File: script.Path, Line: line})
+			}
+		}
+	}
+}
+
+// trackCreateTable records the foreign keys declared in a CREATE TABLE body
+func (l *migrationLinter) trackCreateTable(table, body, file string, line int) {
+	for _, def := range splitTopLevel(body) {
+		def = strings.TrimSpace(def)
+		name := ""
+		if m := lintConstraint.FindStringSubmatch(def); m != nil {
+			name, def = lintName(m[1]), m[2]
+		}
+		if m := lintForeignKey.FindStringSubmatch(def); m != nil {
+			l.fks = append(l.fks, &lintFK{Name: name, Table: table, Columns: lintColumns(m[1]), RefTable: lintName(m[2]), RefColumns: lintColumns(m[3]), File: file, Line: line})
+			continue
+		}
+		fields := strings.Fields(def)
+		if len(fields) == 0 || lintTableKeywords[strings.ToUpper(fields[0])] {
+			continue
+		}
+		if m := lintReferences.FindStringSubmatch(def); m != nil {
+			l.fks = append(l.fks, &lintFK{Name: name, Table: table, Columns: []string{lintName(fields[0])}, RefTable: lintName(m[1]), RefColumns: lintColumns(m[2]), File: file, Line: line})
+		}
+	}
+}
+
+// checkDroppedColumn reports indexes and foreign keys that still use a dropped column
+func (l *migrationLinter) checkDroppedColumn(script migrationScript, table, column string, line int, cascade bool) {
+	severity := SeverityError
+	consequence := "the migration fails or leaves them broken"
+	if cascade {
+		severity = SeverityWarning
+		consequence = "CASCADE drops them silently"
+	}
+
+	for _, index := range l.indexes {
+		if index.Table == table && containsName(index.Columns, column) {
+			label := index.Name
+			if label == "" {
+				label = "unnamed index"
+			}
+			l.add(RuleDroppedColumnReferenced, severity, script.Path, line,
+				"dropped column %s.%s is still used by index %s (%s:%d); %s", table, column, label, index.File, index.Line, consequence)
+		}
+	}
+	for _, fk := range l.fks {
+		referenced := (fk.Table == table && containsName(fk.Columns, column)) ||
+			(fk.RefTable == table && (containsName(fk.RefColumns, column) || (len(fk.RefColumns) == 0 && column == "id")))
+		if referenced {
+			l.add(RuleDroppedColumnReferenced, severity, script.Path, line,
+				"dropped column %s.%s is still used by foreign key %s(%s) -> %s (%s:%d); %s",
+				table, column, fk.Table, strings.Join(fk.Columns, ", "), fk.RefTable, fk.File, fk.Line, consequence)
+		}
+	}
+	l.forgetColumn(table, column)
+}
+
+func (l *migrationLinter) forgetTable(table string) {
+	indexes := l.indexes[:0]
+	for _, index := range l.indexes {
+		if index.Table != table {
+			indexes = append(indexes, index)
+		}
+	}
+	l.indexes = indexes
+	fks := l.fks[:0]
+	for _, fk := range l.fks {
+		if fk.Table != table {
+			fks = append(fks, fk)
+		}
+	}
+	l.fks = fks
+}
+
+func (l *migrationLinter) forgetIndex(name string) {
+	indexes := l.indexes[:0]
+	for _, index := range l.indexes {
+		if index.Name == "" || index.Name != name {
+			indexes = append(indexes, index)
+		}
+	}
+	l.indexes = indexes
+}
+
+func (l *migrationLinter) forgetForeignKey(name string) {
+	fks := l.fks[:0]
+	for _, fk := range l.fks {
+		if fk.Name == "" || fk.Name != name {
+			fks = append(fks, fk)
+		}
+	}
+	l.fks = fks
+}
+
+// forgetColumn removes dependencies on a dropped column so they are reported only once
+func (l *migrationLinter) forgetColumn(table, column string) {
+	indexes := l.indexes[:0]
+	for _, index := range l.indexes {
+		if index.Table != table || !containsName(index.Columns, column) {
+			indexes = append(indexes, index)
+		}
+	}
+	l.indexes = indexes
+	fks := l.fks[:0]
+	for _, fk := range l.fks {
+		if !(fk.Table == table && containsName(fk.Columns, column)) && !(fk.RefTable == table && containsName(fk.RefColumns, column)) {
+			fks = append(fks, fk)
+		}
+	}
+	l.fks = fks
+}
+
+func (l *migrationLinter) renameColumn(table, from, to string) {
+	rename := func(columns []string) {
+		for i, column := range columns {
+			if column == from {
+				columns[i] = to
+			}
+		}
+	}
+	for _, index := range l.indexes {
+		if index.Table == table {
+			rename(index.Columns)
+		}
+	}
+	for _, fk := range l.fks {
+		if fk.Table == table {
+			rename(fk.Columns)
+		}
+		if fk.RefTable == table {
+			rename(fk.RefColumns)
+		}
+	}
+}
+
+// splitTopLevel splits on commas that are not inside parentheses or quotes
+func splitTopLevel(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '"' || ch == '`':
+			quote = ch
+		case ch == '(':
+			depth++
+		case ch == ')':
+			depth--
+		case ch == ',' && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// lintName unquotes an identifier, drops its schema and lowercases it
+func lintName(ident string) string {
+	ident = strings.TrimSpace(ident)
+	var quote rune
+	lastDot := -1
+	for i, ch := range ident {
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '`':
+			quote = ch
+		case ch == '.':
+			lastDot = i
+		}
+	}
+	return strings.ToLower(strings.Trim(ident[lastDot+1:], "\"`"))
+}
+
+// lintColumns parses an index or key column list, ignoring sort order and expressions
+func lintColumns(list string) []string {
+	var columns []string
+	for _, part := range splitTopLevel(list) {
+		fields := strings.Fields(part)
+		if len(fields) == 0 || strings.Contains(fields[0], "(") {
+			continue
+		}
+		columns = append(columns, lintName(fields[0]))
+	}
+	return columns
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,248 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+)
+
+// schemaObject is a table, column or index an up migration creates, which its down should drop
+type schemaObject struct {
+	Kind  string // "table", "column" or "index"
+	Table string
+	Name  string
+	Line  int
+}
+
+func (o schemaObject) String() string {
+	if o.Kind == "column" {
+		return fmt.Sprintf("column %s.%s", o.Table, o.Name)
+	}
+	return o.Kind + " " + o.Name
+}
+
+func (o schemaObject) key() string {
+	if o.Kind == "column" {
+		return o.Table + "." + o.Name
+	}
+	return o.Name
+}
+
+// migrationPair is the up and down SQL of one version, from two files or one file with a down section
+type migrationPair struct {
+	Version  string
+	UpFile   string
+	Up       string
+	DownFile string
+	Down     string
+	DownLine int // Line of the down marker within DownFile, 0 for a separate file
+}
+
+// line converts a line of the down SQL to a line of DownFile
+func (p migrationPair) line(downLine int) int {
+	if p.DownLine == 0 {
+		return downLine
+	}
+	return p.DownLine + downLine - 1
+}
+
+// checkReversals reports down migrations that do not undo their up migration: objects the up
+// creates that the down leaves behind, and tables the down drops that the up never created
+func (l *migrationLinter) checkReversals(scripts []migrationScript) {
+	for _, pair := range migrationPairs(scripts) {
+		created := createdObjects(pair.Up)
+		dropped, drops := droppedObjects(pair.Down)
+
+		for _, object := range created {
+			if dropped["table:"+object.Table] || dropped[object.Kind+":"+object.key()] {
+				continue
+			}
+			l.add(RuleDownMismatch, SeverityWarning, pair.DownFile, pair.DownLine,
+				"down migration for version %s does not drop %s created in %s:%d", pair.Version, object, pair.UpFile, object.Line)
+		}
+
+		createdTables := make(map[string]bool)
+		for _, object := range created {
+			if object.Kind == "table" {
+				createdTables[object.Table] = true
+			}
+		}
+		for _, drop := range drops {
+			if drop.Kind == "table" && !createdTables[drop.Table] {
+				l.add(RuleDownMismatch, SeverityWarning, pair.DownFile, pair.line(drop.Line),
+					"down migration for version %s drops table %s, which %s does not create; rolling back loses its data", pair.Version, drop.Table, pair.UpFile)
+			}
+		}
+	}
+}
+
+// migrationPairs matches each up migration with its down: a .down.sql or U file of the same
+// version in the same folder, or the down section of the file itself
+func migrationPairs(scripts []migrationScript) []migrationPair {
+	counts := make(map[string]int)
+	downs := make(map[string]migrationScript)
+	for _, script := range scripts {
+		if script.Version == "" {
+			continue
+		}
+		key := script.Dir + "\x00" + script.Direction + "\x00" + script.Version
+		counts[key]++
+		if script.Direction == directionDown {
+			downs[script.Dir+"\x00"+script.Version] = script
+		}
+	}
+
+	var pairs []migrationPair
+	for _, script := range scripts {
+		if script.Version == "" || script.Direction != directionUp {
+			continue
+		}
+		// Duplicate versions are reported by checkVersions; their pairing is ambiguous
+		if counts[script.Dir+"\x00"+directionUp+"\x00"+script.Version] > 1 {
+			continue
+		}
+
+		if script.HasDown {
+			if loc := downSection.FindStringIndex(script.Content); loc != nil {
+				pairs = append(pairs, migrationPair{
+					Version:  script.Version,
+					UpFile:   script.Path,
+					Up:       script.Content[:loc[0]],
+					DownFile: script.Path,
+					Down:     script.Content[loc[0]:],
+					DownLine: strings.Count(script.Content[:loc[0]], "\n") + 1,
+				})
+				continue
+			}
+		}
+		key := script.Dir + "\x00" + script.Version
+		if down, ok := downs[key]; ok && counts[script.Dir+"\x00"+directionDown+"\x00"+script.Version] == 1 {
+			pairs = append(pairs, migrationPair{
+				Version:  script.Version,
+				UpFile:   script.Path,
+				Up:       script.Content,
+				DownFile: down.Path,
+				Down:     down.Content,
+			})
+		}
+	}
+	return pairs
+}
+
+// createdObjects lists the tables, added columns and named indexes a script creates
+func createdObjects(content string) []schemaObject {
+	var objects []schemaObject
+	tables := make(map[string]bool)
+	for _, stmt := range splitStatementsWithLines(content) {
+		if m := lintCreateTable.FindStringSubmatch(stmt.Text); m != nil {
+			table := lintName(m[2])
+			tables[table] = true
+			objects = append(objects, schemaObject{Kind: "table", Table: table, Name: table, Line: stmt.Line})
+			continue
+		}
+		if m := lintCreateIndex.FindStringSubmatch(stmt.Text); m != nil {
+			if name := lintName(m[3]); name != "" {
+				objects = append(objects, schemaObject{Kind: "index", Table: lintName(m[4]), Name: name, Line: stmt.Line})
+			}
+			continue
+		}
+		m := lintAlterTable.FindStringSubmatch(stmt.Text)
+		if m == nil {
+			continue
+		}
+		table := lintName(m[1])
+		if tables[table] {
+			continue // Dropping the table removes what the same script added to it
+		}
+		for _, action := range splitTopLevel(m[2]) {
+			action = strings.TrimSpace(action)
+			if lintAddConstraint.MatchString(action) {
+				continue
+			}
+			if add := lintAddColumn.FindStringSubmatch(action); add != nil && !lintTableKeywords[strings.ToUpper(lintName(add[2]))] {
+				objects = append(objects, schemaObject{Kind: "column", Table: table, Name: lintName(add[2]), Line: stmt.Line})
+			}
+		}
+	}
+	return objects
+}
+
+// droppedObjects returns the set of objects a script drops, keyed by kind and name, and the
+// table drops in order with their lines
+func droppedObjects(content string) (map[string]bool, []schemaObject) {
+	dropped := make(map[string]bool)
+	var drops []schemaObject
+	for _, stmt := range splitStatementsWithLines(content) {
+		if m := lintDrop.FindStringSubmatch(stmt.Text); m != nil {
+			kind := strings.ToLower(strings.Join(strings.Fields(m[1]), " "))
+			if kind != "table" && kind != "index" {
+				continue
+			}
+			for _, name := range strings.Split(m[3], ",") {
+				fields := strings.Fields(name)
+				if len(fields) == 0 {
+					continue
+				}
+				object := lintName(fields[0])
+				dropped[kind+":"+object] = true
+				if kind == "table" {
+					drops = append(drops, schemaObject{Kind: kind, Table: object, Name: object, Line: stmt.Line})
+				}
+			}
+			continue
+		}
+		m := lintAlterTable.FindStringSubmatch(stmt.Text)
+		if m == nil {
+			continue
+		}
+		table := lintName(m[1])
+		for _, action := range splitTopLevel(m[2]) {
+			action = strings.TrimSpace(action)
+			if named := lintDropNamed.FindStringSubmatch(action); named != nil {
+				dropped["index:"+lintName(named[3])] = true
+				continue
+			}
+			if column := lintDropColumn.FindStringSubmatch(action); column != nil && !lintTableKeywords[strings.ToUpper(lintName(column[2]))] {
+				dropped["column:"+table+"."+lintName(column[2])] = true
+			}
+		}
+	}
+	return dropped, drops
+}
+
+// CreatedTables lists the tables a migration file creates, unqualified and lowercased: CREATE TABLE
+// statements of a SQL migration's up section, or the createTable changes of a Liquibase changelog
+func CreatedTables(filePath, content string) []string {
+	var tables []string
+	if IsLiquibaseChangelog(filePath) && !strings.HasSuffix(strings.ToLower(filePath), ".sql") {
+		changelog, err := parseLiquibaseChangelog(filePath, content)
+		if err != nil || changelog == nil {
+			return nil
+		}
+		for _, entry := range changelog.Entries {
+			if entry.ChangeSet == nil {
+				continue
+			}
+			for _, change := range entry.ChangeSet.Changes {
+				if change.Type == "createTable" {
+					tables = append(tables, lintName(change.Attrs["tableName"]))
+				}
+			}
+		}
+		return tables
+	}
+
+	script := parseMigrationScript(filePath, content)
+	if script.Direction != directionUp {
+		return nil
+	}
+	up := script.Content
+	if loc := downSection.FindStringIndex(up); loc != nil {
+		up = up[:loc[0]]
+	}
+	for _, object := range createdObjects(up) {
+		if object.Kind == "table" {
+			tables = append(tables, object.Table)
+		}
+	}
+	return tables
+}
@@ -0,0 +1,593 @@
+package database
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"repo-explanation/internal/portable"
+)
+
+// liquibaseChangelog is a parsed Liquibase changelog: includes and changeSets in document order
+type liquibaseChangelog struct {
+	Path    string
+	Entries []liquibaseEntry
+}
+
+// liquibaseEntry is either an include (File or an includeAll Dir) or a changeSet
+type liquibaseEntry struct {
+	Include        string
+	IncludeAll     string
+	RelativeToFile bool
+	ChangeSet      *liquibaseChangeSet
+}
+
+type liquibaseChangeSet struct {
+	ID      string
+	Author  string
+	Changes []liquibaseChange
+}
+
+// liquibaseChange is one change of a changeSet, such as createTable, in either changelog format
+type liquibaseChange struct {
+	Type    string
+	Attrs   map[string]string
+	Columns []liquibaseColumn
+	Text    string // Body of <sql>, <createView> and similar elements
+}
+
+type liquibaseColumn struct {
+	Attrs       map[string]string
+	Constraints map[string]string
+}
+
+// IsLiquibaseChangelog reports whether a file may be a Liquibase changelog: an XML, YAML or SQL
+// file named like a changelog or inside a changelog folder (db/changelog/...)
+func IsLiquibaseChangelog(filePath string) bool {
+	slashPath := strings.ToLower(portable.Slash(filePath))
+	switch path.Ext(slashPath) {
+	case ".xml", ".yaml", ".yml", ".sql":
+	default:
+		return false
+	}
+	return strings.Contains(slashPath, "changelog")
+}
+
+// liquibaseFormattedSQL marks a SQL file as a Liquibase formatted SQL changelog
+const liquibaseFormattedSQL = "--liquibase formatted sql"
+
+// liquibaseMigrations follows every master changelog (one no other changelog includes) through its
+// includes and translates each changeSet into SQL, returning one migration per changelog file in the
+// order Liquibase applies them and the set of files it consumed, so they are not replayed again as
+// plain SQL. Rollback blocks are skipped.
+func liquibaseMigrations(files map[string]string) ([]Migration, map[string]bool) {
+	byPath := make(map[string]string, len(files))
+	for filePath, content := range files {
+		byPath[portable.Slash(filePath)] = portable.Text(content)
+	}
+
+	changelogs := make(map[string]*liquibaseChangelog)
+	formatted := make(map[string]bool) // Formatted SQL changelogs, applied as they are
+	for filePath, content := range byPath {
+		if !IsLiquibaseChangelog(filePath) {
+			continue
+		}
+		if strings.HasSuffix(strings.ToLower(filePath), ".sql") {
+			formatted[filePath] = strings.HasPrefix(strings.ToLower(strings.TrimSpace(content)), liquibaseFormattedSQL)
+			continue
+		}
+		changelog, err := parseLiquibaseChangelog(filePath, content)
+		if err != nil {
+			fmt.Printf("⚠️  Skipping Liquibase changelog %s: %v\n", filePath, err)
+			continue
+		}
+		if changelog != nil {
+			changelogs[filePath] = changelog
+		}
+	}
+	if len(changelogs) == 0 && !containsTrue(formatted) {
+		return nil, nil
+	}
+
+	included := make(map[string]bool)
+	for _, changelog := range changelogs {
+		for _, entry := range changelog.Entries {
+			for _, target := range resolveLiquibaseInclude(byPath, changelog.Path, entry) {
+				included[target] = true
+			}
+		}
+	}
+	var masters []string
+	for filePath := range changelogs {
+		if !included[filePath] {
+			masters = append(masters, filePath)
+		}
+	}
+	for filePath, isChangelog := range formatted {
+		if isChangelog && !included[filePath] {
+			masters = append(masters, filePath)
+		}
+	}
+	sort.Strings(masters)
+
+	var migrations []Migration
+	consumed := make(map[string]bool)
+	var visit func(filePath string)
+	visit = func(filePath string) {
+		if consumed[filePath] {
+			return
+		}
+		consumed[filePath] = true
+
+		changelog, ok := changelogs[filePath]
+		if !ok {
+			// A .sql changelog is applied as it is; formatted SQL's --rollback lines are comments
+			if content, exists := byPath[filePath]; exists && strings.HasSuffix(strings.ToLower(filePath), ".sql") {
+				migrations = append(migrations, Migration{Name: path.Base(filePath), SQL: content})
+			}
+			return
+		}
+
+		var sql strings.Builder
+		flush := func() {
+			if sql.Len() > 0 {
+				migrations = append(migrations, Migration{Name: path.Base(filePath), SQL: sql.String()})
+				sql.Reset()
+			}
+		}
+		for _, entry := range changelog.Entries {
+			if entry.ChangeSet == nil {
+				// Keep the changeSets before an include ahead of the included file's
+				flush()
+				for _, target := range resolveLiquibaseInclude(byPath, filePath, entry) {
+					visit(target)
+				}
+				continue
+			}
+			fmt.Fprintf(&sql, "-- changeset %s:%s\n", entry.ChangeSet.Author, entry.ChangeSet.ID)
+			for _, change := range entry.ChangeSet.Changes {
+				sql.WriteString(liquibaseChangeSQL(change, byPath, filePath, consumed))
+			}
+		}
+		flush()
+	}
+	for _, master := range masters {
+		visit(master)
+	}
+	return migrations, consumed
+}
+
+// resolveLiquibaseInclude returns the files an include names. Paths are relative to the changelog
+// with relativeToChangelogFile, otherwise to the classpath, which is matched as a path suffix
+// (db/changelog/x.yaml is src/main/resources/db/changelog/x.yaml).
+func resolveLiquibaseInclude(files map[string]string, from string, entry liquibaseEntry) []string {
+	target := entry.Include
+	if target == "" {
+		target = entry.IncludeAll
+	}
+	if target == "" {
+		return nil
+	}
+	target = strings.TrimPrefix(portable.Slash(target), "classpath:")
+
+	var candidates []string
+	if entry.RelativeToFile {
+		candidates = append(candidates, path.Join(path.Dir(from), target))
+	} else {
+		candidates = append(candidates, path.Clean(strings.TrimPrefix(target, "/")), path.Join(path.Dir(from), target))
+	}
+
+	if entry.Include != "" {
+		for _, candidate := range candidates {
+			if _, ok := files[candidate]; ok {
+				return []string{candidate}
+			}
+		}
+		for filePath := range files {
+			if strings.HasSuffix(filePath, "/"+candidates[0]) {
+				return []string{filePath}
+			}
+		}
+		return nil
+	}
+
+	// includeAll takes every changelog in the folder, alphabetically
+	var matches []string
+	for filePath := range files {
+		switch strings.ToLower(path.Ext(filePath)) {
+		case ".xml", ".yaml", ".yml", ".sql":
+		default:
+			continue
+		}
+		dir := path.Dir(filePath)
+		for _, candidate := range candidates {
+			if dir == candidate || strings.HasSuffix(dir, "/"+candidate) {
+				matches = append(matches, filePath)
+				break
+			}
+		}
+	}
+	sort.Strings(matches)
+	return matches
+}
+
+// parseLiquibaseChangelog parses an XML or YAML changelog, returning nil for files that are not one
+func parseLiquibaseChangelog(filePath, content string) (*liquibaseChangelog, error) {
+	if !strings.Contains(content, "databaseChangeLog") {
+		return nil, nil
+	}
+	if strings.HasSuffix(strings.ToLower(filePath), ".xml") {
+		return parseLiquibaseXML(filePath, content)
+	}
+	return parseLiquibaseYAML(filePath, content)
+}
+
+// xmlNode is a generic XML element
+type xmlNode struct {
+	Name     string
+	Attrs    map[string]string
+	Children []*xmlNode
+	Text     string
+}
+
+func parseLiquibaseXML(filePath, content string) (*liquibaseChangelog, error) {
+	decoder := xml.NewDecoder(strings.NewReader(content))
+	decoder.Strict = false
+	var stack []*xmlNode
+	var root *xmlNode
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid XML: %v", err)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &xmlNode{Name: t.Name.Local, Attrs: make(map[string]string)}
+			for _, attr := range t.Attr {
+				node.Attrs[attr.Name.Local] = attr.Value
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, node)
+			} else if root == nil {
+				root = node
+			}
+			stack = append(stack, node)
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].Text += string(t)
+			}
+		}
+	}
+	if root == nil || root.Name != "databaseChangeLog" {
+		return nil, nil
+	}
+
+	changelog := &liquibaseChangelog{Path: filePath}
+	for _, child := range root.Children {
+		switch child.Name {
+		case "include":
+			changelog.Entries = append(changelog.Entries, liquibaseEntry{Include: child.Attrs["file"], RelativeToFile: child.Attrs["relativeToChangelogFile"] == "true"})
+		case "includeAll":
+			changelog.Entries = append(changelog.Entries, liquibaseEntry{IncludeAll: child.Attrs["path"], RelativeToFile: child.Attrs["relativeToChangelogFile"] == "true"})
+		case "changeSet":
+			changeSet := &liquibaseChangeSet{ID: child.Attrs["id"], Author: child.Attrs["author"]}
+			for _, element := range child.Children {
+				switch element.Name {
+				case "rollback", "preConditions", "comment", "validCheckSum", "tagDatabase":
+					continue
+				}
+				change := liquibaseChange{Type: element.Name, Attrs: element.Attrs, Text: strings.TrimSpace(element.Text)}
+				for _, column := range element.Children {
+					if column.Name != "column" {
+						if column.Name == "selectQuery" || column.Name == "createProcedure" {
+							change.Text = strings.TrimSpace(column.Text)
+						}
+						continue
+					}
+					col := liquibaseColumn{Attrs: column.Attrs, Constraints: map[string]string{}}
+					for _, constraint := range column.Children {
+						if constraint.Name == "constraints" {
+							col.Constraints = constraint.Attrs
+						}
+					}
+					change.Columns = append(change.Columns, col)
+				}
+				changeSet.Changes = append(changeSet.Changes, change)
+			}
+			changelog.Entries = append(changelog.Entries, liquibaseEntry{ChangeSet: changeSet})
+		}
+	}
+	return changelog, nil
+}
+
+func parseLiquibaseYAML(filePath, content string) (*liquibaseChangelog, error) {
+	var document struct {
+		DatabaseChangeLog []map[string]interface{} `yaml:"databaseChangeLog"`
+	}
+	if err := yaml.Unmarshal([]byte(content), &document); err != nil {
+		return nil, fmt.Errorf("invalid YAML: %v", err)
+	}
+	if document.DatabaseChangeLog == nil {
+		return nil, nil
+	}
+
+	changelog := &liquibaseChangelog{Path: filePath}
+	for _, item := range document.DatabaseChangeLog {
+		for kind, value := range item {
+			attrs := yamlScalars(value)
+			switch kind {
+			case "include":
+				changelog.Entries = append(changelog.Entries, liquibaseEntry{Include: attrs["file"], RelativeToFile: attrs["relativeToChangelogFile"] == "true"})
+			case "includeAll":
+				changelog.Entries = append(changelog.Entries, liquibaseEntry{IncludeAll: attrs["path"], RelativeToFile: attrs["relativeToChangelogFile"] == "true"})
+			case "changeSet":
+				body, _ := value.(map[string]interface{})
+				changeSet := &liquibaseChangeSet{ID: attrs["id"], Author: attrs["author"]}
+				changes, _ := body["changes"].([]interface{})
+				for _, raw := range changes {
+					entry, _ := raw.(map[string]interface{})
+					for changeType, changeValue := range entry {
+						changeSet.Changes = append(changeSet.Changes, yamlChange(changeType, changeValue))
+					}
+				}
+				changelog.Entries = append(changelog.Entries, liquibaseEntry{ChangeSet: changeSet})
+			}
+		}
+	}
+	return changelog, nil
+}
+
+func yamlChange(changeType string, value interface{}) liquibaseChange {
+	change := liquibaseChange{Type: changeType, Attrs: yamlScalars(value)}
+	body, _ := value.(map[string]interface{})
+	columns, _ := body["columns"].([]interface{})
+	for _, raw := range columns {
+		entry, _ := raw.(map[string]interface{})
+		column, ok := entry["column"]
+		if !ok {
+			continue
+		}
+		columnBody, _ := column.(map[string]interface{})
+		change.Columns = append(change.Columns, liquibaseColumn{
+			Attrs:       yamlScalars(column),
+			Constraints: yamlScalars(columnBody["constraints"]),
+		})
+	}
+	change.Text = strings.TrimSpace(change.Attrs["sql"] + change.Attrs["selectQuery"] + change.Attrs["procedureBody"])
+	return change
+}
+
+// yamlScalars keeps the scalar values of a YAML mapping as strings
+func yamlScalars(value interface{}) map[string]string {
+	scalars := make(map[string]string)
+	mapping, _ := value.(map[string]interface{})
+	for key, v := range mapping {
+		switch v.(type) {
+		case map[string]interface{}, []interface{}, nil:
+			continue
+		}
+		scalars[key] = fmt.Sprint(v)
+	}
+	return scalars
+}
+
+// liquibaseChangeSQL translates one change into SQL statements; changes with no schema effect
+// (inserts, tags) and unknown ones become comments
+func liquibaseChangeSQL(change liquibaseChange, files map[string]string, from string, consumed map[string]bool) string {
+	a := change.Attrs
+	table := liquibaseTable(a, "tableName")
+	switch change.Type {
+	case "createTable":
+		var defs, primaryKey []string
+		for _, column := range change.Columns {
+			defs = append(defs, liquibaseColumnSQL(column))
+			if column.Constraints["primaryKey"] == "true" {
+				primaryKey = append(primaryKey, column.Attrs["name"])
+			}
+		}
+		if len(primaryKey) > 0 {
+			defs = append(defs, "PRIMARY KEY ("+strings.Join(primaryKey, ", ")+")")
+		}
+		return fmt.Sprintf("CREATE TABLE %s (\n  %s\n);\n", table, strings.Join(defs, ",\n  "))
+	case "addColumn":
+		var b strings.Builder
+		for _, column := range change.Columns {
+			fmt.Fprintf(&b, "ALTER TABLE %s ADD COLUMN %s;\n", table, liquibaseColumnSQL(column))
+			if column.Constraints["primaryKey"] == "true" {
+				fmt.Fprintf(&b, "ALTER TABLE %s ADD PRIMARY KEY (%s);\n", table, column.Attrs["name"])
+			}
+		}
+		return b.String()
+	case "dropColumn":
+		columns := liquibaseList(a["columnName"])
+		for _, column := range change.Columns {
+			columns = append(columns, column.Attrs["name"])
+		}
+		var b strings.Builder
+		for _, column := range columns {
+			fmt.Fprintf(&b, "ALTER TABLE %s DROP COLUMN %s;\n", table, column)
+		}
+		return b.String()
+	case "dropTable":
+		cascade := ""
+		if a["cascadeConstraints"] == "true" {
+			cascade = " CASCADE"
+		}
+		return fmt.Sprintf("DROP TABLE %s%s;\n", table, cascade)
+	case "renameTable":
+		return fmt.Sprintf("ALTER TABLE %s RENAME TO %s;\n", liquibaseTable(a, "oldTableName"), a["newTableName"])
+	case "renameColumn":
+		return fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s;\n", table, a["oldColumnName"], a["newColumnName"])
+	case "modifyDataType":
+		return fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s;\n", table, a["columnName"], a["newDataType"])
+	case "addNotNullConstraint":
+		return fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL;\n", table, a["columnName"])
+	case "dropNotNullConstraint":
+		return fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL;\n", table, a["columnName"])
+	case "addDefaultValue":
+		return fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET DEFAULT %s;\n", table, a["columnName"], liquibaseDefault(a))
+	case "addPrimaryKey":
+		return fmt.Sprintf("ALTER TABLE %s ADD %sPRIMARY KEY (%s);\n", table, liquibaseConstraintName(a["constraintName"]), strings.Join(liquibaseList(a["columnNames"]), ", "))
+	case "addUniqueConstraint":
+		return fmt.Sprintf("ALTER TABLE %s ADD %sUNIQUE (%s);\n", table, liquibaseConstraintName(a["constraintName"]), strings.Join(liquibaseList(a["columnNames"]), ", "))
+	case "addForeignKeyConstraint":
+		onDelete := ""
+		if action := a["onDelete"]; action != "" {
+			onDelete = " ON DELETE " + action
+		}
+		return fmt.Sprintf("ALTER TABLE %s ADD %sFOREIGN KEY (%s) REFERENCES %s (%s)%s;\n",
+			liquibaseTable(a, "baseTableName"), liquibaseConstraintName(a["constraintName"]),
+			strings.Join(liquibaseList(a["baseColumnNames"]), ", "), liquibaseTable(a, "referencedTableName"),
+			strings.Join(liquibaseList(a["referencedColumnNames"]), ", "), onDelete)
+	case "dropForeignKeyConstraint", "dropUniqueConstraint":
+		tableKey := "tableName"
+		if change.Type == "dropForeignKeyConstraint" {
+			tableKey = "baseTableName"
+		}
+		return fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s;\n", liquibaseTable(a, tableKey), a["constraintName"])
+	case "dropPrimaryKey":
+		return fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s;\n", table, a["constraintName"])
+	case "createIndex":
+		unique := ""
+		if a["unique"] == "true" {
+			unique = "UNIQUE "
+		}
+		var columns []string
+		for _, column := range change.Columns {
+			columns = append(columns, column.Attrs["name"])
+		}
+		return fmt.Sprintf("CREATE %sINDEX %s ON %s (%s);\n", unique, a["indexName"], table, strings.Join(columns, ", "))
+	case "dropIndex":
+		return fmt.Sprintf("DROP INDEX %s;\n", a["indexName"])
+	case "createView":
+		replace := ""
+		if a["replaceIfExists"] == "true" {
+			replace = "OR REPLACE "
+		}
+		return fmt.Sprintf("CREATE %sVIEW %s AS %s;\n", replace, liquibaseTable(a, "viewName"), strings.TrimSuffix(change.Text, ";"))
+	case "dropView":
+		return fmt.Sprintf("DROP VIEW %s;\n", liquibaseTable(a, "viewName"))
+	case "sql", "createProcedure":
+		return liquibaseRawSQL(change.Text)
+	case "sqlFile":
+		target := resolveLiquibaseInclude(files, from, liquibaseEntry{Include: a["path"], RelativeToFile: a["relativeToChangelogFile"] == "true"})
+		if len(target) == 0 {
+			return fmt.Sprintf("-- liquibase sqlFile %s not found\n", a["path"])
+		}
+		consumed[target[0]] = true
+		return liquibaseRawSQL(files[target[0]])
+	}
+	return fmt.Sprintf("-- liquibase %s not translated\n", change.Type)
+}
+
+// liquibaseColumnSQL renders a column definition with its inline constraints; primary keys are
+// declared by the table
+func liquibaseColumnSQL(column liquibaseColumn) string {
+	a, c := column.Attrs, column.Constraints
+	def := a["name"] + " " + a["type"]
+	if a["autoIncrement"] == "true" {
+		def += " GENERATED BY DEFAULT AS IDENTITY"
+	}
+	if value := liquibaseDefault(a); value != "" {
+		def += " DEFAULT " + value
+	}
+	if c["nullable"] == "false" {
+		def += " NOT NULL"
+	}
+	if c["unique"] == "true" {
+		def += " UNIQUE"
+	}
+	if references := c["references"]; references != "" {
+		def += " REFERENCES " + references
+	} else if refTable := c["referencedTableName"]; refTable != "" {
+		def += " REFERENCES " + refTable
+		if refColumns := c["referencedColumnNames"]; refColumns != "" {
+			def += " (" + strings.Join(liquibaseList(refColumns), ", ") + ")"
+		}
+	}
+	if c["deleteCascade"] == "true" {
+		def += " ON DELETE CASCADE"
+	}
+	return def
+}
+
+// liquibaseDefault renders the default value of a column or addDefaultValue change
+func liquibaseDefault(a map[string]string) string {
+	for _, key := range []string{"defaultValueNumeric", "defaultValueBoolean", "defaultValueComputed"} {
+		if value := a[key]; value != "" {
+			return value
+		}
+	}
+	for _, key := range []string{"defaultValue", "defaultValueDate"} {
+		if value, ok := a[key]; ok {
+			return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+		}
+	}
+	return ""
+}
+
+// liquibaseTable qualifies a table attribute with the change's schemaName
+func liquibaseTable(a map[string]string, key string) string {
+	schemaKey := "schemaName"
+	switch key {
+	case "baseTableName":
+		schemaKey = "baseTableSchemaName"
+	case "referencedTableName":
+		schemaKey = "referencedTableSchemaName"
+	}
+	if schema := a[schemaKey]; schema != "" {
+		return schema + "." + a[key]
+	}
+	return a[key]
+}
+
+func liquibaseConstraintName(name string) string {
+	if name == "" {
+		return ""
+	}
+	return "CONSTRAINT " + name + " "
+}
+
+// liquibaseList splits a comma-separated list of names
+func liquibaseList(list string) []string {
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func containsTrue(set map[string]bool) bool {
+	for _, value := range set {
+		if value {
+			return true
+		}
+	}
+	return false
+}
+
+// liquibaseRawSQL terminates raw SQL from a sql change or sqlFile
+func liquibaseRawSQL(sql string) string {
+	sql = strings.TrimSpace(sql)
+	if sql == "" {
+		return ""
+	}
+	if !strings.HasSuffix(sql, ";") {
+		sql += ";"
+	}
+	return sql + "\n"
+}
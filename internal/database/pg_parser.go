@@ -0,0 +1,952 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// The Postgres parser reads CREATE TABLE and ALTER TABLE from tokens rather than with regular
+// expressions, so quoted identifiers with spaces, defaults that span lines or contain commas, and
+// CHECK constraints with commas in them come out right. It covers the DDL that shapes the schema;
+// anything else (MySQL syntax, PARTITION OF, CREATE TABLE AS, ALTER actions such as RENAME)
+// returns errUnsupported and the statement is applied by the regex path instead.
+
+var errUnsupported = errors.New("not supported by the Postgres parser")
+
+type pgTokenKind int
+
+const (
+	pgEOF      pgTokenKind = iota
+	pgWord                 // Bare identifier or keyword
+	pgQuoted               // "Quoted identifier"
+	pgString               // 'string', E'string' or $tag$string$tag$
+	pgNumber               // 42, 1.5, 1e10
+	pgOperator             // ::, ->>, = ...
+	pgPunct                // ( ) [ ] , . ;
+)
+
+type pgToken struct {
+	kind       pgTokenKind
+	text       string
+	start, end int
+}
+
+// is reports whether the token is the bare keyword
+func (t pgToken) is(keyword string) bool {
+	return t.kind == pgWord && strings.EqualFold(t.text, keyword)
+}
+
+func (t pgToken) punct(s string) bool {
+	return t.kind == pgPunct && t.text == s
+}
+
+// tokenizePostgres splits a statement into tokens, dropping whitespace and comments
+func tokenizePostgres(sql string) ([]pgToken, error) {
+	var tokens []pgToken
+	i := 0
+	for i < len(sql) {
+		c := sql[i]
+		start := i
+		var kind pgTokenKind
+		switch {
+		case isSpace(c) || c == '\f' || c == '\v':
+			i++
+			continue
+		case strings.HasPrefix(sql[i:], "--"):
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				end = len(sql) - i
+			}
+			i += end
+			continue
+		case strings.HasPrefix(sql[i:], "/*"):
+			// Postgres block comments nest
+			depth := 0
+			for i < len(sql) {
+				if strings.HasPrefix(sql[i:], "/*") {
+					depth++
+					i += 2
+				} else if strings.HasPrefix(sql[i:], "*/") {
+					depth--
+					i += 2
+					if depth == 0 {
+						break
+					}
+				} else {
+					i++
+				}
+			}
+			if depth != 0 {
+				return nil, fmt.Errorf("unterminated comment")
+			}
+			continue
+		case c == '\'' || ((c == 'E' || c == 'e') && i+1 < len(sql) && sql[i+1] == '\''):
+			backslashes := c != '\''
+			if backslashes {
+				i++
+			}
+			i++
+			for {
+				if i >= len(sql) {
+					return nil, fmt.Errorf("unterminated string")
+				}
+				if backslashes && sql[i] == '\\' {
+					i += 2
+					continue
+				}
+				if sql[i] == '\'' {
+					if i+1 < len(sql) && sql[i+1] == '\'' {
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				i++
+			}
+			kind = pgString
+		case c == '"':
+			i++
+			for {
+				if i >= len(sql) {
+					return nil, fmt.Errorf("unterminated quoted identifier")
+				}
+				if sql[i] == '"' {
+					if i+1 < len(sql) && sql[i+1] == '"' {
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				i++
+			}
+			kind = pgQuoted
+		case c == '$' && dollarTag.MatchString(sql[i:]):
+			tag := dollarTag.FindString(sql[i:])
+			end := strings.Index(sql[i+len(tag):], tag)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated dollar-quoted string")
+			}
+			i += len(tag) + end + len(tag)
+			kind = pgString
+		case isDigit(c) || (c == '.' && i+1 < len(sql) && isDigit(sql[i+1])):
+			for i < len(sql) && (isDigit(sql[i]) || sql[i] == '.' || sql[i] == 'e' || sql[i] == 'E' ||
+				((sql[i] == '+' || sql[i] == '-') && (sql[i-1] == 'e' || sql[i-1] == 'E'))) {
+				i++
+			}
+			kind = pgNumber
+		case isWordStart(c):
+			for i < len(sql) && (isWordStart(sql[i]) || isDigit(sql[i]) || sql[i] == '$') {
+				i++
+			}
+			kind = pgWord
+		case strings.IndexByte("()[],.;", c) >= 0:
+			i++
+			kind = pgPunct
+		case strings.IndexByte("+-*/<>=~!@#%^&|?:", c) >= 0:
+			for i < len(sql) && strings.IndexByte("+-*/<>=~!@#%^&|?:", sql[i]) >= 0 &&
+				(i == start || (!strings.HasPrefix(sql[i:], "--") && !strings.HasPrefix(sql[i:], "/*"))) {
+				i++
+			}
+			kind = pgOperator
+		default:
+			// Backticks and other characters Postgres does not use belong to another dialect
+			return nil, errUnsupported
+		}
+		tokens = append(tokens, pgToken{kind: kind, text: sql[start:i], start: start, end: i})
+	}
+	return tokens, nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isWordStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+// pgParser walks the tokens of one statement
+type pgParser struct {
+	tokens []pgToken
+	pos    int
+}
+
+func (p *pgParser) peek() pgToken {
+	return p.peekAt(0)
+}
+
+func (p *pgParser) peekAt(offset int) pgToken {
+	if p.pos+offset >= len(p.tokens) {
+		return pgToken{kind: pgEOF}
+	}
+	return p.tokens[p.pos+offset]
+}
+
+func (p *pgParser) next() pgToken {
+	t := p.peek()
+	if t.kind != pgEOF {
+		p.pos++
+	}
+	return t
+}
+
+// accept consumes the keywords if the next tokens are exactly them
+func (p *pgParser) accept(keywords ...string) bool {
+	for i, keyword := range keywords {
+		if !p.peekAt(i).is(keyword) {
+			return false
+		}
+	}
+	p.pos += len(keywords)
+	return true
+}
+
+func (p *pgParser) expect(keywords ...string) error {
+	if !p.accept(keywords...) {
+		return fmt.Errorf("expected %s near %q", strings.Join(keywords, " "), p.peek().text)
+	}
+	return nil
+}
+
+func (p *pgParser) acceptPunct(s string) bool {
+	if p.peek().punct(s) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *pgParser) expectPunct(s string) error {
+	if !p.acceptPunct(s) {
+		return fmt.Errorf("expected %q near %q", s, p.peek().text)
+	}
+	return nil
+}
+
+// identifier reads one name, lowercased and unquoted like splitQualifiedName does
+func (p *pgParser) identifier() (string, error) {
+	t := p.next()
+	if t.kind != pgWord && t.kind != pgQuoted {
+		return "", fmt.Errorf("expected a name near %q", t.text)
+	}
+	if t.kind == pgQuoted {
+		return strings.ToLower(strings.ReplaceAll(t.text[1:len(t.text)-1], `""`, `"`)), nil
+	}
+	return strings.ToLower(t.text), nil
+}
+
+// qualifiedName reads a dotted name and returns it as written, for resolveName
+func (p *pgParser) qualifiedName() (string, error) {
+	from := p.pos
+	if _, err := p.identifier(); err != nil {
+		return "", err
+	}
+	for p.acceptPunct(".") {
+		if _, err := p.identifier(); err != nil {
+			return "", err
+		}
+	}
+	return p.text(from, p.pos), nil
+}
+
+// text renders tokens[from:to] as written, with comments dropped and whitespace collapsed to
+// single spaces
+func (p *pgParser) text(from, to int) string {
+	var b strings.Builder
+	for i := from; i < to; i++ {
+		t := p.tokens[i]
+		// Line breaks inside parentheses, as in multi-line defaults, do not leave padding
+		if i > from && t.start > p.tokens[i-1].end && !p.tokens[i-1].punct("(") && !t.punct(")") {
+			b.WriteByte(' ')
+		}
+		b.WriteString(t.text)
+	}
+	return b.String()
+}
+
+// columnList reads (a, b, c)
+func (p *pgParser) columnList() ([]string, error) {
+	if err := p.expectPunct("("); err != nil {
+		return nil, err
+	}
+	var columns []string
+	for {
+		column, err := p.identifier()
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, column)
+		if p.acceptPunct(")") {
+			return columns, nil
+		}
+		if err := p.expectPunct(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parenthesized reads a balanced (...) or [...] group and returns the text inside it
+func (p *pgParser) parenthesized() (string, error) {
+	if !p.peek().punct("(") && !p.peek().punct("[") {
+		return "", fmt.Errorf("expected \"(\" near %q", p.peek().text)
+	}
+	open := p.pos
+	depth := 0
+	for {
+		t := p.next()
+		switch {
+		case t.kind == pgEOF:
+			return "", fmt.Errorf("unbalanced parentheses")
+		case t.punct("(") || t.punct("["):
+			depth++
+		case t.punct(")") || t.punct("]"):
+			depth--
+			if depth == 0 {
+				return p.text(open+1, p.pos-1), nil
+			}
+		}
+	}
+}
+
+// atElementEnd reports whether a column, constraint or ALTER action ends at the next token
+func (p *pgParser) atElementEnd() bool {
+	t := p.peek()
+	return t.kind == pgEOF || t.punct(",") || t.punct(")") || t.punct(";")
+}
+
+// skipElement skips to the end of the current element, over nested parentheses
+func (p *pgParser) skipElement() error {
+	for !p.atElementEnd() {
+		if p.peek().punct("(") || p.peek().punct("[") {
+			if _, err := p.parenthesized(); err != nil {
+				return err
+			}
+			continue
+		}
+		p.next()
+	}
+	return nil
+}
+
+// columnConstraintWords start a column constraint, and so end a column's type or default
+var columnConstraintWords = []string{"CONSTRAINT", "NOT", "NULL", "DEFAULT", "PRIMARY", "UNIQUE", "REFERENCES", "CHECK", "GENERATED", "COLLATE", "DEFERRABLE", "INITIALLY"}
+
+// typeWords may follow the first word of a type, as in double precision or timestamp with time zone
+var typeWords = map[string]bool{"varying": true, "precision": true, "with": true, "without": true, "time": true, "zone": true, "to": true, "year": true, "month": true, "day": true, "hour": true, "minute": true, "second": true, "array": true}
+
+func (p *pgParser) atColumnConstraint() bool {
+	for _, word := range columnConstraintWords {
+		if p.peek().is(word) {
+			return true
+		}
+	}
+	return false
+}
+
+// dataType reads a column type, lowercased. Words a Postgres type cannot contain, such as MySQL's
+// UNSIGNED or AFTER, make the column unsupported rather than part of its type.
+func (p *pgParser) dataType() (string, error) {
+	from := p.pos
+	if _, err := p.qualifiedName(); err != nil {
+		return "", err
+	}
+	for !p.atElementEnd() && !p.atColumnConstraint() {
+		t := p.peek()
+		switch {
+		case t.punct("(") || t.punct("["):
+			if _, err := p.parenthesized(); err != nil {
+				return "", err
+			}
+		case t.kind == pgWord && typeWords[strings.ToLower(t.text)]:
+			p.next()
+		default:
+			return "", errUnsupported
+		}
+	}
+	return strings.ToLower(p.text(from, p.pos)), nil
+}
+
+// expression reads an expression up to the end of the element or the next column constraint
+func (p *pgParser) expression() (string, error) {
+	from := p.pos
+	for p.pos == from || (!p.atElementEnd() && !p.atColumnConstraint()) {
+		if p.atElementEnd() {
+			return "", fmt.Errorf("expected an expression near %q", p.peek().text)
+		}
+		if p.peek().punct("(") || p.peek().punct("[") {
+			if _, err := p.parenthesized(); err != nil {
+				return "", err
+			}
+			continue
+		}
+		p.next()
+	}
+	return p.text(from, p.pos), nil
+}
+
+// references reads REFERENCES table [(columns)] [MATCH ...] [ON DELETE|UPDATE action]...
+func (p *pgParser) references(se *StreamingSchemaExtractor, fk *CanonicalForeignKey) error {
+	raw, err := p.qualifiedName()
+	if err != nil {
+		return err
+	}
+	fk.RefTable = se.resolveName(raw)
+	if p.peek().punct("(") {
+		if fk.RefColumns, err = p.columnList(); err != nil {
+			return err
+		}
+	}
+	if p.accept("MATCH") {
+		p.next()
+	}
+	for p.accept("ON") {
+		target := &fk.OnDelete
+		if p.accept("UPDATE") {
+			target = &fk.OnUpdate
+		} else if err := p.expect("DELETE"); err != nil {
+			return err
+		}
+		var action string
+		switch {
+		case p.accept("NO", "ACTION"):
+			action = "NO ACTION"
+		case p.accept("RESTRICT"):
+			action = "RESTRICT"
+		case p.accept("CASCADE"):
+			action = "CASCADE"
+		case p.accept("SET", "NULL"):
+			action = "SET NULL"
+		case p.accept("SET", "DEFAULT"):
+			action = "SET DEFAULT"
+		default:
+			return fmt.Errorf("unknown referential action near %q", p.peek().text)
+		}
+		if strings.HasPrefix(action, "SET") && p.peek().punct("(") {
+			if _, err := p.columnList(); err != nil {
+				return err
+			}
+		}
+		*target = &action
+	}
+	return nil
+}
+
+// constraintAttributes skips [NOT] DEFERRABLE, INITIALLY DEFERRED|IMMEDIATE, NOT VALID and NO INHERIT
+func (p *pgParser) constraintAttributes() {
+	for {
+		switch {
+		case p.accept("DEFERRABLE"), p.accept("NOT", "DEFERRABLE"), p.accept("NOT", "VALID"), p.accept("NO", "INHERIT"):
+		case p.accept("INITIALLY"):
+			p.next()
+		default:
+			return
+		}
+	}
+}
+
+// indexParameters skips INCLUDE (...), WITH (...) and USING INDEX TABLESPACE name after a key
+func (p *pgParser) indexParameters() error {
+	for {
+		switch {
+		case p.accept("INCLUDE"), p.accept("WITH"):
+			if _, err := p.parenthesized(); err != nil {
+				return err
+			}
+		case p.accept("USING", "INDEX", "TABLESPACE"):
+			if _, err := p.identifier(); err != nil {
+				return err
+			}
+		default:
+			return nil
+		}
+	}
+}
+
+// pgColumn is a parsed column definition with its inline constraints
+type pgColumn struct {
+	name       string
+	column     *CanonicalColumn
+	primaryKey bool
+	unique     bool
+	foreignKey *CanonicalForeignKey
+	checks     []*CanonicalCheck
+}
+
+// columnDef reads name type [constraint...]
+func (p *pgParser) columnDef(se *StreamingSchemaExtractor) (*pgColumn, error) {
+	name, err := p.identifier()
+	if err != nil {
+		return nil, err
+	}
+	columnType, err := p.dataType()
+	if err != nil {
+		return nil, err
+	}
+	parsed := &pgColumn{name: name, column: &CanonicalColumn{Type: columnType, Nullable: true}}
+	column := parsed.column
+
+	var constraintName *string
+	for !p.atElementEnd() {
+		switch {
+		case p.accept("CONSTRAINT"):
+			name, err := p.identifier()
+			if err != nil {
+				return nil, err
+			}
+			constraintName = &name
+			continue
+		case p.accept("NOT", "NULL"):
+			column.Nullable = false
+		case p.accept("NULL"):
+		case p.accept("DEFAULT"):
+			value, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			column.Default = &value
+		case p.accept("PRIMARY", "KEY"):
+			parsed.primaryKey = true
+			column.Nullable = false
+			if err := p.indexParameters(); err != nil {
+				return nil, err
+			}
+		case p.accept("UNIQUE"):
+			parsed.unique = true
+			p.accept("NULLS", "NOT", "DISTINCT")
+			p.accept("NULLS", "DISTINCT")
+			if err := p.indexParameters(); err != nil {
+				return nil, err
+			}
+		case p.accept("REFERENCES"):
+			fk := &CanonicalForeignKey{Columns: []string{name}, Name: constraintName}
+			if err := p.references(se, fk); err != nil {
+				return nil, err
+			}
+			parsed.foreignKey = fk
+		case p.accept("CHECK"):
+			// Reading the parentheses whole keeps the commas inside them from splitting the column
+			expression, err := p.parenthesized()
+			if err != nil {
+				return nil, err
+			}
+			parsed.checks = append(parsed.checks, &CanonicalCheck{Name: constraintName, Expression: expression, Column: name})
+		case p.accept("GENERATED"):
+			if err := p.generated(column); err != nil {
+				return nil, err
+			}
+		case p.accept("COLLATE"):
+			if _, err := p.qualifiedName(); err != nil {
+				return nil, err
+			}
+		case p.accept("DEFERRABLE"), p.accept("NOT", "DEFERRABLE"), p.accept("NO", "INHERIT"):
+		case p.accept("INITIALLY"):
+			p.next()
+		default:
+			return nil, errUnsupported
+		}
+		constraintName = nil
+	}
+	return parsed, nil
+}
+
+// generated reads the rest of GENERATED ALWAYS|BY DEFAULT AS IDENTITY [(options)] or
+// GENERATED ALWAYS AS (expression) [STORED]
+func (p *pgParser) generated(column *CanonicalColumn) error {
+	kind := "always"
+	if p.accept("BY", "DEFAULT") {
+		kind = "by default"
+	} else if err := p.expect("ALWAYS"); err != nil {
+		return err
+	}
+	if err := p.expect("AS"); err != nil {
+		return err
+	}
+	if p.accept("IDENTITY") {
+		column.Identity = kind
+		column.Nullable = false // Identity columns are implicitly NOT NULL
+		if p.peek().punct("(") {
+			options, err := p.parenthesized()
+			if err != nil {
+				return err
+			}
+			column.IdentityOptions = options
+		}
+		return nil
+	}
+	expression, err := p.parenthesized()
+	if err != nil {
+		return err
+	}
+	column.Generated = &GeneratedColumn{Expression: expression, Stored: p.accept("STORED")}
+	if !column.Generated.Stored {
+		p.accept("VIRTUAL")
+	}
+	return nil
+}
+
+// pgConstraint is a parsed table constraint; EXCLUDE constraints leave it empty
+type pgConstraint struct {
+	name       *string
+	primaryKey []string
+	unique     []string
+	foreignKey *CanonicalForeignKey
+	check      *CanonicalCheck
+}
+
+// atTableConstraint reports whether a table constraint rather than a column starts here
+func (p *pgParser) atTableConstraint() bool {
+	t := p.peek()
+	switch {
+	case t.is("CONSTRAINT"), t.is("CHECK"), t.is("EXCLUDE"):
+		return true
+	case t.is("PRIMARY"):
+		return p.peekAt(1).is("KEY")
+	case t.is("FOREIGN"):
+		return p.peekAt(1).is("KEY")
+	case t.is("UNIQUE"):
+		return p.peekAt(1).punct("(") || p.peekAt(1).is("NULLS")
+	}
+	return false
+}
+
+// tableConstraint reads [CONSTRAINT name] PRIMARY KEY | UNIQUE | FOREIGN KEY | CHECK | EXCLUDE ...
+func (p *pgParser) tableConstraint(se *StreamingSchemaExtractor) (*pgConstraint, error) {
+	constraint := &pgConstraint{}
+	if p.accept("CONSTRAINT") {
+		name, err := p.identifier()
+		if err != nil {
+			return nil, err
+		}
+		constraint.name = &name
+	}
+
+	var err error
+	switch {
+	case p.accept("PRIMARY", "KEY"):
+		if constraint.primaryKey, err = p.columnList(); err != nil {
+			return nil, err
+		}
+		err = p.indexParameters()
+	case p.accept("UNIQUE"):
+		p.accept("NULLS", "NOT", "DISTINCT")
+		p.accept("NULLS", "DISTINCT")
+		if constraint.unique, err = p.columnList(); err != nil {
+			return nil, err
+		}
+		err = p.indexParameters()
+	case p.accept("FOREIGN", "KEY"):
+		fk := &CanonicalForeignKey{Name: constraint.name}
+		if fk.Columns, err = p.columnList(); err != nil {
+			return nil, err
+		}
+		if err := p.expect("REFERENCES"); err != nil {
+			return nil, err
+		}
+		err = p.references(se, fk)
+		constraint.foreignKey = fk
+	case p.accept("CHECK"):
+		constraint.check = &CanonicalCheck{Name: constraint.name}
+		constraint.check.Expression, err = p.parenthesized()
+	case p.accept("EXCLUDE"):
+		err = p.skipElement()
+	default:
+		return nil, errUnsupported
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.constraintAttributes()
+	if !p.atElementEnd() {
+		return nil, errUnsupported
+	}
+	return constraint, nil
+}
+
+// applyParsed applies CREATE TABLE and ALTER TABLE with the Postgres parser. It reports false when
+// the statement is left to the regex path: other statement types, and statements the parser does
+// not support or cannot read.
+func (se *StreamingSchemaExtractor) applyParsed(stmt DDLStatement) (bool, error) {
+	if stmt.Type != "CREATE_TABLE" && stmt.Type != "ALTER_TABLE" {
+		return false, nil
+	}
+	source := stmt.Source
+	if source == "" {
+		source = stmt.Statement
+	}
+	tokens, err := tokenizePostgres(source)
+	if err != nil {
+		return false, nil
+	}
+	p := &pgParser{tokens: tokens}
+
+	// Statements are parsed in full before the schema changes, so falling back never applies half
+	if stmt.Type == "CREATE_TABLE" {
+		key, table, err := p.createTable(se)
+		if err != nil {
+			return false, nil
+		}
+		if _, exists := se.schema.Tables[key]; exists {
+			fmt.Printf("⚠️ CREATE TABLE failed for %s: table %s already exists (skipping)\n", key, key)
+			return true, nil
+		}
+		se.addSchema(table.Schema)
+		se.schema.Tables[key] = table
+		return true, nil
+	}
+
+	key, actions, err := p.alterTable(se)
+	if err != nil {
+		return false, nil
+	}
+	table := se.tableForAlter(key)
+	for _, action := range actions {
+		action(table)
+	}
+	return true, nil
+}
+
+// createTable reads CREATE TABLE [IF NOT EXISTS] name (elements) [PARTITION BY ...] [...]
+func (p *pgParser) createTable(se *StreamingSchemaExtractor) (string, *CanonicalTable, error) {
+	if err := p.expect("CREATE", "TABLE"); err != nil {
+		return "", nil, err
+	}
+	p.accept("IF", "NOT", "EXISTS")
+	raw, err := p.qualifiedName()
+	if err != nil {
+		return "", nil, err
+	}
+	key := se.resolveName(raw)
+	schema, name := splitTableKey(key)
+	table := newCanonicalTable(schema, name)
+
+	// PARTITION OF, OF type and AS query are left to the regex path
+	if err := p.expectPunct("("); err != nil {
+		return "", nil, errUnsupported
+	}
+	for !p.acceptPunct(")") {
+		switch {
+		case p.atTableConstraint():
+			constraint, err := p.tableConstraint(se)
+			if err != nil {
+				return "", nil, err
+			}
+			addConstraint(table, constraint)
+		case p.accept("LIKE"):
+			raw, err := p.qualifiedName()
+			if err != nil {
+				return "", nil, err
+			}
+			if source, exists := se.schema.Tables[se.resolveName(raw)]; exists {
+				for columnName, column := range source.Columns {
+					copied := *column
+					table.Columns[columnName] = &copied
+				}
+			}
+			if err := p.skipElement(); err != nil {
+				return "", nil, err
+			}
+		default:
+			column, err := p.columnDef(se)
+			if err != nil {
+				return "", nil, err
+			}
+			addColumn(table, column)
+		}
+		if !p.acceptPunct(",") && !p.peek().punct(")") {
+			return "", nil, errUnsupported
+		}
+	}
+
+	// INHERITS, WITH, TABLESPACE and friends are ignored; only the partitioning is kept
+	if p.pos < len(p.tokens) {
+		rest := p.text(p.pos, len(p.tokens))
+		table.Partition = parsePartitionBy(rest)
+	}
+	se.resolveReferencedColumns(key, table)
+	return key, table, nil
+}
+
+// alterTable reads ALTER TABLE [IF EXISTS] [ONLY] name action [, action]... where each action adds
+// or drops a column or a constraint; the returned functions apply the actions
+func (p *pgParser) alterTable(se *StreamingSchemaExtractor) (string, []func(*CanonicalTable), error) {
+	if err := p.expect("ALTER", "TABLE"); err != nil {
+		return "", nil, err
+	}
+	p.accept("IF", "EXISTS")
+	p.accept("ONLY")
+	raw, err := p.qualifiedName()
+	if err != nil {
+		return "", nil, err
+	}
+	key := se.resolveName(raw)
+	if p.peek().kind == pgOperator && p.peek().text == "*" {
+		p.next()
+	}
+
+	var actions []func(*CanonicalTable)
+	for {
+		switch {
+		case p.accept("ADD"):
+			// MySQL's ADD INDEX / ADD KEY look like a column to this grammar
+			if p.peek().is("INDEX") || p.peek().is("KEY") {
+				return "", nil, errUnsupported
+			}
+			if p.atTableConstraint() {
+				constraint, err := p.tableConstraint(se)
+				if err != nil {
+					return "", nil, err
+				}
+				actions = append(actions, func(table *CanonicalTable) {
+					addConstraint(table, constraint)
+					se.resolveReferencedColumns(key, table)
+				})
+				break
+			}
+			p.accept("COLUMN")
+			ifNotExists := p.accept("IF", "NOT", "EXISTS")
+			column, err := p.columnDef(se)
+			if err != nil {
+				return "", nil, err
+			}
+			actions = append(actions, func(table *CanonicalTable) {
+				if _, exists := table.Columns[column.name]; exists && ifNotExists {
+					return
+				}
+				addColumn(table, column)
+				se.resolveReferencedColumns(key, table)
+			})
+		case p.accept("DROP", "CONSTRAINT"):
+			p.accept("IF", "EXISTS")
+			name, err := p.identifier()
+			if err != nil {
+				return "", nil, err
+			}
+			p.accept("CASCADE")
+			p.accept("RESTRICT")
+			actions = append(actions, func(table *CanonicalTable) { dropConstraint(table, name) })
+		case p.accept("DROP"):
+			p.accept("COLUMN")
+			p.accept("IF", "EXISTS")
+			name, err := p.identifier()
+			if err != nil {
+				return "", nil, err
+			}
+			p.accept("CASCADE")
+			p.accept("RESTRICT")
+			actions = append(actions, func(table *CanonicalTable) { removeColumn(table, name) })
+		default:
+			return "", nil, errUnsupported
+		}
+		if p.acceptPunct(",") {
+			continue
+		}
+		p.acceptPunct(";")
+		if p.peek().kind != pgEOF {
+			return "", nil, errUnsupported
+		}
+		return key, actions, nil
+	}
+}
+
+// newCanonicalTable returns an empty table
+func newCanonicalTable(schema, name string) *CanonicalTable {
+	return &CanonicalTable{
+		Schema:      schema,
+		Name:        name,
+		Columns:     make(map[string]*CanonicalColumn),
+		PrimaryKey:  []string{},
+		Unique:      [][]string{},
+		ForeignKeys: []*CanonicalForeignKey{},
+		Indexes:     []*CanonicalIndex{},
+	}
+}
+
+// tableForAlter returns the table an ALTER TABLE changes, creating it when a migration alters a
+// table before creating it, as the regex path does
+func (se *StreamingSchemaExtractor) tableForAlter(key string) *CanonicalTable {
+	if table, exists := se.schema.Tables[key]; exists {
+		return table
+	}
+	schema, name := splitTableKey(key)
+	se.addSchema(schema)
+	table := newCanonicalTable(schema, name)
+	se.schema.Tables[key] = table
+	return table
+}
+
+func addColumn(table *CanonicalTable, parsed *pgColumn) {
+	if parsed.primaryKey {
+		table.PrimaryKey = append(table.PrimaryKey, parsed.name)
+	}
+	if parsed.unique {
+		table.Unique = append(table.Unique, []string{parsed.name})
+	}
+	if parsed.foreignKey != nil {
+		table.ForeignKeys = append(table.ForeignKeys, parsed.foreignKey)
+	}
+	table.Checks = append(table.Checks, parsed.checks...)
+	table.Columns[parsed.name] = parsed.column
+}
+
+func addConstraint(table *CanonicalTable, constraint *pgConstraint) {
+	if len(constraint.primaryKey) > 0 {
+		table.PrimaryKey = constraint.primaryKey
+		for _, name := range constraint.primaryKey {
+			if column, exists := table.Columns[name]; exists {
+				column.Nullable = false
+			}
+		}
+	}
+	if len(constraint.unique) > 0 {
+		table.Unique = append(table.Unique, constraint.unique)
+	}
+	if constraint.foreignKey != nil {
+		table.ForeignKeys = append(table.ForeignKeys, constraint.foreignKey)
+	}
+	if constraint.check != nil {
+		table.Checks = append(table.Checks, constraint.check)
+	}
+}
+
+// dropConstraint removes a named foreign key or check; primary key and unique constraints are not
+// named in the canonical model and stay
+func dropConstraint(table *CanonicalTable, name string) {
+	var kept []*CanonicalForeignKey
+	for _, fk := range table.ForeignKeys {
+		if fk.Name == nil || *fk.Name != name {
+			kept = append(kept, fk)
+		}
+	}
+	table.ForeignKeys = kept
+
+	var checks []*CanonicalCheck
+	for _, check := range table.Checks {
+		if check.Name == nil || *check.Name != name {
+			checks = append(checks, check)
+		}
+	}
+	table.Checks = checks
+}
+
+// resolveReferencedColumns fills in the columns of REFERENCES clauses that leave them out, which
+// Postgres reads as the referenced table's primary key
+func (se *StreamingSchemaExtractor) resolveReferencedColumns(key string, table *CanonicalTable) {
+	for _, fk := range table.ForeignKeys {
+		if len(fk.RefColumns) > 0 {
+			continue
+		}
+		referenced, exists := se.schema.Tables[fk.RefTable]
+		if fk.RefTable == key {
+			referenced, exists = table, true
+		}
+		if exists && len(referenced.PrimaryKey) > 0 {
+			fk.RefColumns = append([]string(nil), referenced.PrimaryKey...)
+		} else {
+			// The referenced table is not known (yet); id is by far the most common key
+			fk.RefColumns = []string{"id"}
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package database
+
+import (
+	"strings"
+	"testing"
+)
+
+// extractTable runs one migration through the offline extractor and returns the named table with
+// the final migration rebuilt from the schema
+func extractTable(t *testing.T, sql, table string) (*CanonicalTable, string) {
+	t.Helper()
+	result, err := ExtractSchemaWithFinalMigrationOffline("", map[string]string{"db/migrations/001_init.sql": sql}, func(StreamingResponse) {})
+	if err != nil {
+		t.Fatalf("ExtractSchemaWithFinalMigrationOffline() error = %v", err)
+	}
+	parsed, ok := result.Schema.Tables[table]
+	if !ok {
+		var names []string
+		for name := range result.Schema.Tables {
+			names = append(names, name)
+		}
+		t.Fatalf("table %s missing; got %v", table, names)
+	}
+	return parsed, result.FinalMigrationSQL
+}
+
+func TestPostgresParserColumns(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		table    string
+		column   string
+		wantType string
+		wantDflt string // "" for no default
+		nullable bool
+	}{
+		{
+			name:     "quoted identifiers with spaces",
+			sql:      `CREATE TABLE "order items" ("line number" INT NOT NULL, "unit price" NUMERIC(10, 2));`,
+			table:    "order items",
+			column:   "line number",
+			wantType: "int",
+		},
+		{
+			name:     "multi-line default",
+			sql:      "CREATE TABLE events (\n  payload JSONB NOT NULL DEFAULT jsonb_build_object(\n    'kind', 'unknown'\n  ),\n  id INT\n);",
+			table:    "events",
+			column:   "payload",
+			wantType: "jsonb",
+			wantDflt: "jsonb_build_object('kind', 'unknown')",
+		},
+		{
+			name:     "default with commas",
+			sql:      "CREATE TABLE points (pos NUMERIC(6, 2)[] DEFAULT ARRAY[0, 0], id INT);",
+			table:    "points",
+			column:   "pos",
+			wantType: "numeric(6, 2)[]",
+			wantDflt: "ARRAY[0, 0]",
+			nullable: true,
+		},
+		{
+			name:     "default followed by a comment",
+			sql:      "CREATE TABLE notes (\n  body TEXT DEFAULT '-- none', -- placeholder, not a column\n  id INT\n);",
+			table:    "notes",
+			column:   "body",
+			wantType: "text",
+			wantDflt: "'-- none'",
+			nullable: true,
+		},
+		{
+			name:     "function default keeps its case",
+			sql:      "CREATE TABLE audits (created_at TIMESTAMPTZ NOT NULL DEFAULT now(), id INT);",
+			table:    "audits",
+			column:   "created_at",
+			wantType: "timestamptz",
+			wantDflt: "now()",
+		},
+		{
+			name:     "multi-word type",
+			sql:      "CREATE TABLE readings (taken_at TIMESTAMP WITH TIME ZONE, value DOUBLE PRECISION NOT NULL);",
+			table:    "readings",
+			column:   "taken_at",
+			wantType: "timestamp with time zone",
+			nullable: true,
+		},
+		{
+			name:     "multi-word type before a constraint",
+			sql:      "CREATE TABLE readings (value DOUBLE PRECISION NOT NULL, label CHARACTER VARYING(20));",
+			table:    "readings",
+			column:   "value",
+			wantType: "double precision",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The regex path must not be what reads these
+			tokens, err := tokenizePostgres(tt.sql)
+			if err == nil {
+				_, _, err = (&pgParser{tokens: tokens}).createTable(NewStreamingSchemaExtractor("postgres"))
+			}
+			if err != nil {
+				t.Fatalf("Postgres parser error = %v", err)
+			}
+
+			table, _ := extractTable(t, tt.sql, tt.table)
+			column, ok := table.Columns[tt.column]
+			if !ok {
+				t.Fatalf("column %q missing; got %v", tt.column, table.Columns)
+			}
+			if column.Type != tt.wantType {
+				t.Errorf("type = %q, want %q", column.Type, tt.wantType)
+			}
+			gotDefault := ""
+			if column.Default != nil {
+				gotDefault = *column.Default
+			}
+			if gotDefault != tt.wantDflt {
+				t.Errorf("default = %q, want %q", gotDefault, tt.wantDflt)
+			}
+			if column.Nullable != tt.nullable {
+				t.Errorf("nullable = %v, want %v", column.Nullable, tt.nullable)
+			}
+		})
+	}
+}
+
+func TestPostgresParserChecks(t *testing.T) {
+	sql := `CREATE TABLE products (
+  id INT PRIMARY KEY,
+  price NUMERIC CHECK (price > 0),
+  discount NUMERIC,
+  status TEXT NOT NULL DEFAULT 'Draft',
+  CONSTRAINT valid_discount CHECK (discount >= 0 AND discount < price),
+  CHECK (status IN ('Draft', 'Live', 'Retired'))
+);`
+	table, migration := extractTable(t, sql, "products")
+	if len(table.Columns) != 4 {
+		t.Errorf("got %d columns, want 4: %v", len(table.Columns), table.Columns)
+	}
+	if len(table.Checks) != 3 {
+		t.Fatalf("got %d checks, want 3: %+v", len(table.Checks), table.Checks)
+	}
+	for _, want := range []string{
+		"CHECK (price > 0)",
+		"CONSTRAINT valid_discount CHECK (discount >= 0 AND discount < price)",
+		"CHECK (status IN ('Draft', 'Live', 'Retired'))",
+		"DEFAULT 'Draft'",
+	} {
+		if !strings.Contains(migration, want) {
+			t.Errorf("final migration lacks %q:\n%s", want, migration)
+		}
+	}
+}
+
+func TestPostgresParserAlterChecks(t *testing.T) {
+	sql := `CREATE TABLE accounts (id INT PRIMARY KEY, balance NUMERIC CHECK (balance >= 0), owner TEXT);
+ALTER TABLE accounts ADD CONSTRAINT owner_set CHECK (owner <> '');
+ALTER TABLE accounts ADD CONSTRAINT owner_short CHECK (length(owner) < 100);
+ALTER TABLE accounts DROP CONSTRAINT owner_set;
+ALTER TABLE accounts DROP COLUMN balance;`
+	table, migration := extractTable(t, sql, "accounts")
+	if len(table.Checks) != 1 || table.Checks[0].Name == nil || *table.Checks[0].Name != "owner_short" {
+		t.Fatalf("checks = %+v, want only owner_short", table.Checks)
+	}
+	if !strings.Contains(migration, "CONSTRAINT owner_short CHECK (length(owner) < 100)") || strings.Contains(migration, "balance") {
+		t.Errorf("final migration:\n%s", migration)
+	}
+}
+
+func TestPostgresParserReferentialActions(t *testing.T) {
+	sql := `CREATE TABLE users (id INT PRIMARY KEY);
+CREATE TABLE orders (
+  id INT PRIMARY KEY,
+  user_id INT REFERENCES users ON DELETE CASCADE ON UPDATE NO ACTION,
+  reviewer_id INT,
+  CONSTRAINT orders_reviewer_fk FOREIGN KEY (reviewer_id) REFERENCES users (id) ON DELETE SET NULL
+);`
+	table, migration := extractTable(t, sql, "orders")
+	if len(table.ForeignKeys) != 2 {
+		t.Fatalf("got %d foreign keys, want 2: %+v", len(table.ForeignKeys), table.ForeignKeys)
+	}
+	tests := []struct {
+		column, onDelete, onUpdate string
+	}{
+		{"user_id", "CASCADE", "NO ACTION"},
+		{"reviewer_id", "SET NULL", ""},
+	}
+	for i, tt := range tests {
+		fk := table.ForeignKeys[i]
+		if fk.Columns[0] != tt.column || fk.RefTable != "users" || len(fk.RefColumns) != 1 || fk.RefColumns[0] != "id" {
+			t.Errorf("foreign key %d = %+v", i, fk)
+		}
+		if got := deref(fk.OnDelete); got != tt.onDelete {
+			t.Errorf("%s ON DELETE = %q, want %q", tt.column, got, tt.onDelete)
+		}
+		if got := deref(fk.OnUpdate); got != tt.onUpdate {
+			t.Errorf("%s ON UPDATE = %q, want %q", tt.column, got, tt.onUpdate)
+		}
+	}
+	if !strings.Contains(migration, "CONSTRAINT orders_reviewer_fk FOREIGN KEY (reviewer_id) REFERENCES users (id) ON DELETE SET NULL") {
+		t.Errorf("final migration lacks the named foreign key:\n%s", migration)
+	}
+}
+
+func TestPostgresParserMySQLFallback(t *testing.T) {
+	sql := "CREATE TABLE `items` (\n  `id` INT UNSIGNED NOT NULL AUTO_INCREMENT,\n  `name` VARCHAR(50) NOT NULL DEFAULT 'Unnamed',\n  PRIMARY KEY (`id`)\n) ENGINE=InnoDB;"
+	// Backticks, UNSIGNED and AUTO_INCREMENT are not Postgres; the regex path reads the statement
+	if tokens, err := tokenizePostgres(sql); err == nil {
+		if _, _, err := (&pgParser{tokens: tokens}).createTable(NewStreamingSchemaExtractor("postgres")); err == nil {
+			t.Error("the Postgres parser accepted MySQL DDL instead of leaving it to the regex path")
+		}
+	}
+
+	table, _ := extractTable(t, sql, "items")
+	for _, column := range []string{"id", "name"} {
+		if _, ok := table.Columns[column]; !ok {
+			t.Errorf("column %s missing from the regex fallback; got %v", column, table.Columns)
+		}
+	}
+	if name := table.Columns["name"]; name != nil && (name.Default == nil || *name.Default != "'Unnamed'") {
+		t.Errorf("name default = %v, want 'Unnamed'", name.Default)
+	}
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
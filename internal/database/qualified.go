@@ -0,0 +1,165 @@
+package database
+
+import (
+	"regexp"
+	"strings"
+
+	"repo-explanation/internal/mermaid"
+)
+
+// sqlIdentifier matches one identifier part: "quoted", `backticked`, [bracketed] or bare
+const sqlIdentifier = `(?:"[^"]+"|` + "`[^`]+`" + `|\[[^\]]+\]|[^\s(),;."\[\]` + "`" + `]+)`
+
+// sqlQualifiedIdentifier matches a dotted name such as auth.users or "billing"."invoices"
+const sqlQualifiedIdentifier = sqlIdentifier + `(?:\s*\.\s*` + sqlIdentifier + `)*`
+
+var (
+	createTableNameRegex = regexp.MustCompile(`(?i)CREATE TABLE\s+(?:IF NOT EXISTS\s+)?(` + sqlQualifiedIdentifier + `)`)
+	dropTableNameRegex   = regexp.MustCompile(`(?i)DROP TABLE\s+(?:IF EXISTS\s+)?(` + sqlQualifiedIdentifier + `)`)
+	alterTableNameRegex  = regexp.MustCompile(`(?i)ALTER TABLE\s+(?:IF EXISTS\s+)?(?:ONLY\s+)?(` + sqlQualifiedIdentifier + `)`)
+	indexTableNameRegex  = regexp.MustCompile(`(?i)\bON\s+(?:ONLY\s+)?(` + sqlQualifiedIdentifier + `)`)
+	inlineReferenceRegex = regexp.MustCompile(`(?i)REFERENCES\s+(` + sqlQualifiedIdentifier + `)\s*\(([^)]+)\)`)
+	foreignKeyDefRegex   = regexp.MustCompile(`(?i)FOREIGN KEY\s*\(([^)]+)\)\s*REFERENCES\s+(` + sqlQualifiedIdentifier + `)\s*\(([^)]+)\)`)
+	createEnumRegex      = regexp.MustCompile(`(?i)CREATE TYPE\s+(` + sqlQualifiedIdentifier + `)\s+AS\s+ENUM\s*\(([^)]+)\)`)
+	createViewRegex      = regexp.MustCompile(`(?i)CREATE VIEW\s+(` + sqlQualifiedIdentifier + `)\s+AS`)
+	dropViewRegex        = regexp.MustCompile(`(?i)DROP VIEW\s+(?:IF EXISTS\s+)?(` + sqlQualifiedIdentifier + `)`)
+
+	createSchemaRegex = regexp.MustCompile(`(?i)^CREATE\s+(?:SCHEMA|DATABASE)\s+(?:IF\s+NOT\s+EXISTS\s+)?(?:AUTHORIZATION\s+)?(` + sqlQualifiedIdentifier + `)`)
+	dropSchemaRegex   = regexp.MustCompile(`(?i)^DROP\s+(?:SCHEMA|DATABASE)\s+(?:IF\s+EXISTS\s+)?(` + sqlQualifiedIdentifier + `)`)
+	searchPathRegex   = regexp.MustCompile(`(?i)^SET\s+(?:(?:SESSION|LOCAL)\s+)?(?:search_path\s*(?:TO|=)|SCHEMA)\s*(.+)$`)
+	useDatabaseRegex  = regexp.MustCompile(`(?i)^USE\s+(` + sqlQualifiedIdentifier + `)\s*$`)
+)
+
+// defaultSchemas are the schemas an unqualified name lands in; tables there keep their bare name
+var defaultSchemas = map[string]bool{"": true, "public": true, "dbo": true}
+
+// splitQualifiedName splits a possibly quoted, dotted identifier into lowercased parts
+func splitQualifiedName(raw string) []string {
+	var parts []string
+	var current strings.Builder
+	var quote rune
+
+	for _, char := range strings.TrimSpace(raw) {
+		switch {
+		case quote != 0:
+			if char == quote {
+				quote = 0
+				continue
+			}
+			current.WriteRune(char)
+		case char == '"' || char == '`':
+			quote = char
+		case char == '[':
+			quote = ']'
+		case char == '.':
+			parts = append(parts, strings.ToLower(strings.TrimSpace(current.String())))
+			current.Reset()
+		default:
+			current.WriteRune(char)
+		}
+	}
+	return append(parts, strings.ToLower(strings.TrimSpace(current.String())))
+}
+
+// resolveQualifiedName returns the schema and object name for raw, falling back to
+// currentSchema for unqualified names. A three-part name (db.schema.table) keeps the last two.
+func resolveQualifiedName(raw, currentSchema string) (schema, name string) {
+	parts := splitQualifiedName(raw)
+	name = parts[len(parts)-1]
+	if len(parts) == 1 {
+		return normalizeSchemaName(currentSchema), name
+	}
+	return normalizeSchemaName(parts[len(parts)-2]), name
+}
+
+// qualifyName builds the canonical key for an object. Objects in the default schema keep their
+// bare name so single-schema projects are unaffected.
+func qualifyName(schema, name string) string {
+	if defaultSchemas[schema] {
+		return name
+	}
+	return schema + "." + name
+}
+
+// sqlName writes a lowercased name back as SQL, quoting it when it holds characters a bare
+// identifier cannot, such as the space in "order items"
+func sqlName(name string) string {
+	if !strings.ContainsAny(name, " \t\"-") {
+		return name
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// sqlNames writes a column list back as SQL; expressions in index column lists stay as they are
+func sqlNames(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = name
+		if !strings.Contains(name, "(") {
+			quoted[i] = sqlName(name)
+		}
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// sqlTableName writes a table key back as SQL
+func sqlTableName(key string) string {
+	schema, name := splitTableKey(key)
+	if schema == "" {
+		return sqlName(name)
+	}
+	return sqlName(schema) + "." + sqlName(name)
+}
+
+// splitTableKey is the inverse of qualifyName
+func splitTableKey(key string) (schema, name string) {
+	if idx := strings.Index(key, "."); idx >= 0 {
+		return key[:idx], key[idx+1:]
+	}
+	return "", key
+}
+
+// parseSchemaSwitch recognizes SET search_path, SET SCHEMA and USE, returning the schema that
+// unqualified names resolve to afterwards
+func parseSchemaSwitch(stmt string) (string, bool) {
+	if matches := useDatabaseRegex.FindStringSubmatch(stmt); matches != nil {
+		parts := splitQualifiedName(matches[1])
+		return normalizeSchemaName(parts[len(parts)-1]), true
+	}
+
+	matches := searchPathRegex.FindStringSubmatch(stmt)
+	if matches == nil {
+		return "", false
+	}
+	// The first entry of the search path is where new objects are created
+	for _, entry := range strings.Split(matches[1], ",") {
+		entry = strings.Trim(strings.TrimSpace(entry), `'`)
+		if entry == "" || entry == `"$user"` || entry == "$user" {
+			continue
+		}
+		return normalizeSchemaName(splitQualifiedName(entry)[0]), true
+	}
+	return "", true
+}
+
+// normalizeSchemaName maps the default schemas to ""
+func normalizeSchemaName(schema string) string {
+	if defaultSchemas[schema] {
+		return ""
+	}
+	return schema
+}
+
+// mermaidEntity returns a Mermaid-safe entity id for a table key; the schema separator becomes
+// "__" so "billing.users" and "billing_users" stay distinct
+func mermaidEntity(key string) string {
+	return mermaid.ID(strings.ReplaceAll(key, ".", "__"))
+}
+
+// entityHeader opens an erDiagram entity, labelled with the table's own name when its id differs
+func entityHeader(id, name string) string {
+	if id == name {
+		return id
+	}
+	return id + "[" + mermaid.Label(name) + "]"
+}
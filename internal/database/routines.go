@@ -0,0 +1,271 @@
+package database
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// CanonicalFunction represents a stored function or procedure
+type CanonicalFunction struct {
+	Kind      string `json:"kind"`      // function or procedure
+	Arguments string `json:"arguments"` // Argument list as written
+	Returns   string `json:"returns,omitempty"`
+	Language  string `json:"language,omitempty"`
+	Body      string `json:"body,omitempty"`
+	SQL       string `json:"sql"` // Definition as written, replayed in the final migration
+}
+
+// CanonicalTrigger represents a trigger attached to a table
+type CanonicalTrigger struct {
+	Name     string   `json:"name"`
+	Timing   string   `json:"timing"`  // before, after or instead of
+	Events   []string `json:"events"`  // insert, update, update of <columns>, delete, truncate
+	ForEach  string   `json:"forEach"` // row or statement
+	When     string   `json:"when,omitempty"`
+	Function string   `json:"function,omitempty"` // Function the trigger executes (Postgres)
+	Body     string   `json:"body,omitempty"`     // Inline trigger body (MySQL)
+	SQL      string   `json:"sql"`
+}
+
+var (
+	createFunctionRegex = regexp.MustCompile(`(?is)^CREATE\s+(?:OR\s+REPLACE\s+)?(?:DEFINER\s*=\s*\S+\s+)?(FUNCTION|PROCEDURE)\s+(` + sqlQualifiedIdentifier + `)\s*\(`)
+	dropFunctionRegex   = regexp.MustCompile(`(?is)^DROP\s+(?:FUNCTION|PROCEDURE)\s+(?:IF\s+EXISTS\s+)?(` + sqlQualifiedIdentifier + `)`)
+	returnsRegex        = regexp.MustCompile(`(?is)\bRETURNS\s+(.+?)(?:\s+(?:LANGUAGE|AS|IMMUTABLE|STABLE|VOLATILE|STRICT|CALLED|SECURITY|PARALLEL|COST|ROWS|SET|BEGIN|DETERMINISTIC|NOT|READS|MODIFIES|NO|CONTAINS|COMMENT)\b|\s*$)`)
+	languageRegex       = regexp.MustCompile(`(?i)\bLANGUAGE\s+'?(\w+)'?`)
+	mysqlBodyRegex      = regexp.MustCompile(`(?is)\bBEGIN\b.*$`)
+
+	createTriggerRegex = regexp.MustCompile(`(?is)^CREATE\s+(?:OR\s+REPLACE\s+)?(?:DEFINER\s*=\s*\S+\s+)?(?:CONSTRAINT\s+)?TRIGGER\s+(` + sqlQualifiedIdentifier + `)\s+(BEFORE|AFTER|INSTEAD\s+OF)\s+(.+?)\s+ON\s+(` + sqlQualifiedIdentifier + `)(.*)$`)
+	dropTriggerRegex   = regexp.MustCompile(`(?is)^DROP\s+TRIGGER\s+(?:IF\s+EXISTS\s+)?(` + sqlQualifiedIdentifier + `)(?:\s+ON\s+(` + sqlQualifiedIdentifier + `))?`)
+	triggerEventSplit  = regexp.MustCompile(`(?i)\s+OR\s+`)
+	forEachRegex       = regexp.MustCompile(`(?i)\bFOR\s+(?:EACH\s+)?(ROW|STATEMENT)\b`)
+	whenRegex          = regexp.MustCompile(`(?i)\bWHEN\s*\(`)
+	executeRegex       = regexp.MustCompile(`(?i)\bEXECUTE\s+(?:FUNCTION|PROCEDURE)\s+(` + sqlQualifiedIdentifier + `)\s*\(`)
+)
+
+// dollarQuotedBody returns the bounds of the first $tag$...$tag$ block in s, or -1
+func dollarQuotedBody(s string) (start, end int) {
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' {
+			continue
+		}
+		tag := dollarTag.FindString(s[i:])
+		if tag == "" {
+			continue
+		}
+		closing := strings.Index(s[i+len(tag):], tag)
+		if closing < 0 {
+			return -1, -1
+		}
+		return i, i + len(tag) + closing + len(tag)
+	}
+	return -1, -1
+}
+
+// parseFunction reads a CREATE FUNCTION / PROCEDURE statement
+func parseFunction(stmt string) (rawName string, function *CanonicalFunction, ok bool) {
+	loc := createFunctionRegex.FindStringSubmatchIndex(stmt)
+	if loc == nil {
+		return "", nil, false
+	}
+	open := loc[1] - 1
+	closeArgs := matchingParen(stmt, open)
+	if closeArgs < 0 {
+		return "", nil, false
+	}
+
+	function = &CanonicalFunction{
+		Kind:      strings.ToLower(stmt[loc[2]:loc[3]]),
+		Arguments: strings.Join(strings.Fields(stmt[open+1:closeArgs]), " "),
+		SQL:       stmt,
+	}
+
+	// Options are read with the body taken out, since the body may contain any keyword
+	options := stmt[closeArgs+1:]
+	if start, end := dollarQuotedBody(options); start >= 0 {
+		tagLength := len(dollarTag.FindString(options[start:]))
+		function.Body = strings.TrimSpace(options[start+tagLength : end-tagLength])
+		options = options[:start] + options[end:]
+	} else if body := mysqlBodyRegex.FindString(options); body != "" {
+		function.Body = strings.TrimSpace(body)
+		options = strings.TrimSuffix(options, body)
+	}
+
+	if matches := returnsRegex.FindStringSubmatch(options); matches != nil {
+		function.Returns = strings.Join(strings.Fields(matches[1]), " ")
+	}
+	if matches := languageRegex.FindStringSubmatch(options); matches != nil {
+		function.Language = strings.ToLower(matches[1])
+	}
+	return stmt[loc[4]:loc[5]], function, true
+}
+
+// parseTrigger reads a CREATE TRIGGER statement, returning the raw table name it is attached to
+func parseTrigger(stmt string) (rawTable string, trigger *CanonicalTrigger, ok bool) {
+	matches := createTriggerRegex.FindStringSubmatch(stmt)
+	if matches == nil {
+		return "", nil, false
+	}
+	_, name := resolveQualifiedName(matches[1], "")
+
+	trigger = &CanonicalTrigger{
+		Name:    name,
+		Timing:  strings.ToLower(strings.Join(strings.Fields(matches[2]), " ")),
+		ForEach: "statement", // Postgres default when FOR EACH is omitted
+		SQL:     stmt,
+	}
+	for _, event := range triggerEventSplit.Split(matches[3], -1) {
+		trigger.Events = append(trigger.Events, strings.ToLower(strings.Join(strings.Fields(event), " ")))
+	}
+
+	rest := matches[5]
+	if forEach := forEachRegex.FindStringSubmatchIndex(rest); forEach != nil {
+		trigger.ForEach = strings.ToLower(rest[forEach[2]:forEach[3]])
+		// MySQL triggers carry their body inline after FOR EACH ROW
+		if body := strings.TrimSpace(rest[forEach[1]:]); body != "" && !whenRegex.MatchString(body) && !executeRegex.MatchString(body) {
+			trigger.Body = body
+		}
+	}
+	if when := whenRegex.FindStringIndex(rest); when != nil {
+		if end := matchingParen(rest, when[1]-1); end >= 0 {
+			trigger.When = strings.TrimSpace(rest[when[1]:end])
+		}
+	}
+	if execute := executeRegex.FindStringSubmatch(rest); execute != nil {
+		trigger.Function = execute[1]
+	}
+	return matches[4], trigger, true
+}
+
+// applyCreateFunction applies CREATE FUNCTION / CREATE PROCEDURE
+func (se *StreamingSchemaExtractor) applyCreateFunction(stmt DDLStatement) error {
+	rawName, function, ok := parseFunction(stmt.Statement)
+	if !ok {
+		return fmt.Errorf("could not parse function definition")
+	}
+	schema, name := resolveQualifiedName(rawName, se.currentSchema)
+	se.addSchema(schema)
+	se.schema.Functions[qualifyName(schema, name)] = function
+	return nil
+}
+
+// applyDropFunction applies DROP FUNCTION / DROP PROCEDURE; with CASCADE the triggers using the
+// function go too
+func (se *StreamingSchemaExtractor) applyDropFunction(stmt DDLStatement) error {
+	matches := dropFunctionRegex.FindStringSubmatch(stmt.Statement)
+	if matches == nil {
+		return fmt.Errorf("could not extract function name from DROP FUNCTION")
+	}
+	name := se.resolveName(matches[1])
+	delete(se.schema.Functions, name)
+
+	if strings.Contains(strings.ToUpper(stmt.Statement), "CASCADE") {
+		for _, table := range se.schema.Tables {
+			var kept []*CanonicalTrigger
+			for _, trigger := range table.Triggers {
+				if trigger.Function != name {
+					kept = append(kept, trigger)
+				}
+			}
+			table.Triggers = kept
+		}
+	}
+	return nil
+}
+
+// applyCreateTrigger attaches a trigger to its table, replacing one of the same name
+func (se *StreamingSchemaExtractor) applyCreateTrigger(stmt DDLStatement) error {
+	rawTable, trigger, ok := parseTrigger(stmt.Statement)
+	if !ok {
+		return fmt.Errorf("could not parse trigger definition")
+	}
+	if trigger.Function != "" {
+		trigger.Function = se.resolveName(trigger.Function)
+	}
+
+	tableName := se.resolveName(rawTable)
+	table, exists := se.schema.Tables[tableName]
+	if !exists {
+		if _, isView := se.schema.Views[tableName]; isView {
+			return nil // INSTEAD OF triggers on views are not tracked
+		}
+		return fmt.Errorf("table %s does not exist", tableName)
+	}
+
+	for i, existing := range table.Triggers {
+		if existing.Name == trigger.Name {
+			table.Triggers[i] = trigger
+			return nil
+		}
+	}
+	table.Triggers = append(table.Triggers, trigger)
+	return nil
+}
+
+// applyDropTrigger removes a trigger from its table (Postgres) or from whichever table has it (MySQL)
+func (se *StreamingSchemaExtractor) applyDropTrigger(stmt DDLStatement) error {
+	matches := dropTriggerRegex.FindStringSubmatch(stmt.Statement)
+	if matches == nil {
+		return fmt.Errorf("could not extract trigger name from DROP TRIGGER")
+	}
+	_, name := resolveQualifiedName(matches[1], "")
+
+	for tableName, table := range se.schema.Tables {
+		if matches[2] != "" && tableName != se.resolveName(matches[2]) {
+			continue
+		}
+		var kept []*CanonicalTrigger
+		for _, trigger := range table.Triggers {
+			if trigger.Name != name {
+				kept = append(kept, trigger)
+			}
+		}
+		table.Triggers = kept
+	}
+	return nil
+}
+
+// generateRoutinesSQL replays function and trigger definitions, functions first since triggers
+// call them
+func (se *StreamingSchemaExtractor) generateRoutinesSQL() string {
+	var sql strings.Builder
+
+	if len(se.schema.Functions) > 0 {
+		sql.WriteString("-- ============================================\n")
+		sql.WriteString("-- FUNCTIONS\n")
+		sql.WriteString("-- ============================================\n\n")
+
+		var names []string
+		for name := range se.schema.Functions {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			sql.WriteString(se.schema.Functions[name].SQL)
+			sql.WriteString(";\n\n")
+		}
+	}
+
+	var tableNames []string
+	for tableName, table := range se.schema.Tables {
+		if len(table.Triggers) > 0 {
+			tableNames = append(tableNames, tableName)
+		}
+	}
+	if len(tableNames) > 0 {
+		sort.Strings(tableNames)
+		sql.WriteString("-- ============================================\n")
+		sql.WriteString("-- TRIGGERS\n")
+		sql.WriteString("-- ============================================\n\n")
+
+		for _, tableName := range tableNames {
+			for _, trigger := range se.schema.Tables[tableName].Triggers {
+				sql.WriteString(trigger.SQL)
+				sql.WriteString(";\n\n")
+			}
+		}
+	}
+
+	return sql.String()
+}
@@ -3,11 +3,15 @@ package database
 import (
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
 	"sort"
 	"strings"
 	"time"
+
+	"repo-explanation/internal/mermaid"
+	"repo-explanation/internal/portable"
 )
 
 // ColumnConstraint represents various column constraints
@@ -23,11 +27,12 @@ const (
 
 // Column represents a database column
 type Column struct {
-	Name         string             `json:"name"`
-	Type         string             `json:"type"`
-	Constraints  []ColumnConstraint `json:"constraints"`
-	DefaultValue string             `json:"default_value,omitempty"`
-	References   *ForeignKeyRef     `json:"references,omitempty"`
+	Name           string             `json:"name"`
+	Type           string             `json:"type"`
+	Constraints    []ColumnConstraint `json:"constraints"`
+	DefaultValue   string             `json:"default_value,omitempty"`
+	References     *ForeignKeyRef     `json:"references,omitempty"`
+	Classification string             `json:"classification,omitempty"` // pii, credential or financial
 }
 
 // ForeignKeyRef represents a foreign key reference
@@ -43,22 +48,68 @@ type Index struct {
 	Unique  bool     `json:"unique"`
 }
 
+// Trigger represents a trigger on a table
+type Trigger struct {
+	Name     string   `json:"name"`
+	Timing   string   `json:"timing"`
+	Events   []string `json:"events"`
+	ForEach  string   `json:"for_each"`
+	When     string   `json:"when,omitempty"`
+	Function string   `json:"function,omitempty"`
+}
+
+// Function represents a stored function or procedure
+type Function struct {
+	Name      string `json:"name"`
+	Kind      string `json:"kind"`
+	Arguments string `json:"arguments"`
+	Returns   string `json:"returns,omitempty"`
+	Language  string `json:"language,omitempty"`
+}
+
 // Table represents a database table
 type Table struct {
 	Name        string            `json:"name"`
 	Columns     map[string]Column `json:"columns"`
 	PrimaryKeys []string          `json:"primary_keys"`
 	Indexes     map[string]Index  `json:"indexes"`
+	Triggers    []Trigger         `json:"triggers,omitempty"`
 }
 
 // DatabaseSchema represents the complete database schema state
 type DatabaseSchema struct {
-	Tables            map[string]Table `json:"tables"`
-	ForeignKeys       []ForeignKeyRef  `json:"foreign_keys"`
-	MigrationPath     string           `json:"migration_path"`
-	GeneratedAt       time.Time        `json:"generated_at"`
-	FinalMigrationSQL string           `json:"final_migration_sql,omitempty"`
-	LLMRelationships  string           `json:"llm_relationships,omitempty"`
+	Tables            map[string]Table   `json:"tables"`
+	ForeignKeys       []ForeignKeyRef    `json:"foreign_keys"`
+	Functions         []Function         `json:"functions,omitempty"`
+	MigrationPath     string             `json:"migration_path"`
+	GeneratedAt       time.Time          `json:"generated_at"`
+	FinalMigrationSQL string             `json:"final_migration_sql,omitempty"`
+	LLMRelationships  string             `json:"llm_relationships,omitempty"`
+	Quality           *SchemaLintReport  `json:"quality,omitempty"`     // Design lint of the final schema
+	ERDSplit          *ERDSplit          `json:"erd_split,omitempty"`   // Domain subdiagrams, when asked for with --erd-split
+	Sensitivity       *SensitivityReport `json:"sensitivity,omitempty"` // PII, credential and financial columns
+}
+
+// Stable returns a copy for comparing results across runs: foreign keys and functions sorted, and
+// the generation time, final migration SQL and LLM relationships left out
+func (s *DatabaseSchema) Stable() *DatabaseSchema {
+	stable := &DatabaseSchema{
+		Tables:        s.Tables,
+		ForeignKeys:   append([]ForeignKeyRef{}, s.ForeignKeys...),
+		Functions:     append([]Function(nil), s.Functions...),
+		MigrationPath: s.MigrationPath,
+		Quality:       s.Quality,
+		ERDSplit:      s.ERDSplit,
+		Sensitivity:   s.Sensitivity,
+	}
+	sort.Slice(stable.ForeignKeys, func(i, j int) bool {
+		if stable.ForeignKeys[i].Table != stable.ForeignKeys[j].Table {
+			return stable.ForeignKeys[i].Table < stable.ForeignKeys[j].Table
+		}
+		return stable.ForeignKeys[i].Column < stable.ForeignKeys[j].Column
+	})
+	sort.Slice(stable.Functions, func(i, j int) bool { return stable.Functions[i].Name < stable.Functions[j].Name })
+	return stable
 }
 
 // MigrationFile represents a SQL migration file
@@ -94,11 +145,10 @@ func (se *SchemaExtractor) FindMigrationFolders(projectPath string, files map[st
 	// Extract unique folder paths from file paths
 	for filePath := range files {
 		if strings.HasSuffix(strings.ToLower(filePath), ".sql") {
-			dir := filepath.Dir(filePath)
+			dir := path.Dir(portable.Slash(filePath))
 
 			// Check if any part of the path contains "migration"
-			pathParts := strings.Split(dir, string(os.PathSeparator))
-			for _, part := range pathParts {
+			for _, part := range portable.Segments(dir) {
 				if strings.Contains(strings.ToLower(part), "migration") {
 					folderSet[dir] = true
 					break
@@ -131,8 +181,8 @@ func (se *SchemaExtractor) ExtractSchemaFromMigrations(projectPath string, files
 	// Collect migration files from the folder
 	var migrationFiles []MigrationFile
 	for filePath, content := range files {
-		if strings.HasPrefix(filePath, se.migrationPath) && strings.HasSuffix(strings.ToLower(filePath), ".sql") {
-			filename := filepath.Base(filePath)
+		if portable.Within(filePath, se.migrationPath) && strings.HasSuffix(strings.ToLower(filePath), ".sql") {
+			filename := path.Base(portable.Slash(filePath))
 			migrationFiles = append(migrationFiles, MigrationFile{
 				Path:      filePath,
 				Name:      filename,
@@ -175,7 +225,7 @@ func (se *SchemaExtractor) extractTimestampFromFilename(filename string) string
 
 // processMigration processes a single migration file
 func (se *SchemaExtractor) processMigration(migration MigrationFile) error {
-	content := strings.ToUpper(migration.Content)
+	content := strings.ToUpper(portable.Text(migration.Content))
 
 	// Split into statements
 	statements := se.splitSQLStatements(content)
@@ -786,3 +836,80 @@ func generateSchemaFilename(projectPath string) string {
 
 	return filename + "_database_schema.puml"
 }
+
+// MermaidERD renders the schema as a Mermaid erDiagram with one relationship per foreign key column
+func (s *DatabaseSchema) MermaidERD() string {
+	var tableNames []string
+	for tableName := range s.Tables {
+		tableNames = append(tableNames, tableName)
+	}
+	return s.mermaidERD(tableNames)
+}
+
+// mermaidERD renders the given tables and their foreign keys. A referenced table outside the
+// list is drawn as a bare entity, without its columns.
+func (s *DatabaseSchema) mermaidERD(tableNames []string) string {
+	var erd strings.Builder
+	erd.WriteString("erDiagram\n")
+	sort.Strings(tableNames)
+
+	for _, tableName := range tableNames {
+		table := s.Tables[tableName]
+		erd.WriteString(fmt.Sprintf("  %s {\n", entityHeader(mermaid.ID(tableName), tableName)))
+
+		var columnNames []string
+		for colName := range table.Columns {
+			columnNames = append(columnNames, colName)
+		}
+		sort.Strings(columnNames)
+
+		for _, colName := range columnNames {
+			column := table.Columns[colName]
+			var keys []string
+			for _, constraint := range column.Constraints {
+				if constraint == PrimaryKey {
+					keys = append(keys, "PK")
+				}
+			}
+			if column.References != nil {
+				keys = append(keys, "FK")
+			}
+			columnType := "unknown"
+			if strings.TrimSpace(column.Type) != "" {
+				columnType = mermaid.AttributeType(column.Type)
+			}
+			erd.WriteString(fmt.Sprintf("    %s %s", columnType, mermaid.ID(colName)))
+			if len(keys) > 0 {
+				erd.WriteString(" " + strings.Join(keys, ","))
+			}
+			if column.Classification != "" {
+				erd.WriteString(` "` + column.Classification + `"`)
+			}
+			erd.WriteString("\n")
+		}
+		erd.WriteString("  }\n")
+	}
+
+	for _, tableName := range tableNames {
+		table := s.Tables[tableName]
+		var columnNames []string
+		for colName, column := range table.Columns {
+			if column.References != nil {
+				columnNames = append(columnNames, colName)
+			}
+		}
+		sort.Strings(columnNames)
+
+		for _, colName := range columnNames {
+			ref := table.Columns[colName].References
+			erd.WriteString(fmt.Sprintf("  %s ||--o{ %s : \"%s -> %s.%s\"\n",
+				mermaid.ID(ref.Table), mermaid.ID(tableName), mermaid.Message(colName), mermaid.Message(ref.Table), mermaid.Message(ref.Column)))
+		}
+	}
+
+	diagram, err := mermaid.Clean(erd.String())
+	if err != nil {
+		fmt.Printf("⚠️  ERD is not valid Mermaid: %v\n", err)
+	}
+	return diagram
+}
@@ -0,0 +1,301 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Schema lint rule identifiers
+const (
+	RuleMissingPrimaryKey   = "missing-primary-key"
+	RuleUnindexedForeignKey = "unindexed-foreign-key"
+	RuleNamingConvention    = "naming-convention"
+	RuleNullableForeignKey  = "nullable-foreign-key"
+	RuleWideTable           = "wide-table"
+	RuleOrphanTable         = "orphan-table"
+)
+
+// wideTableColumns is the column count above which a table is reported as wide
+const wideTableColumns = 50
+
+// schemaPenalty is what each finding costs, scaled per table in the score
+var schemaPenalty = map[Severity]int{
+	SeverityError:   10,
+	SeverityWarning: 3,
+	SeverityInfo:    1,
+}
+
+// bookkeepingTables are migration-tool tables that are expected to stand alone
+var bookkeepingTables = map[string]bool{
+	"schema_migrations":     true,
+	"goose_db_version":      true,
+	"flyway_schema_history": true,
+	"migrations":            true,
+	"knex_migrations":       true,
+	"knex_migrations_lock":  true,
+	"ar_internal_metadata":  true,
+	"gorp_migrations":       true,
+	"databasechangelog":     true,
+	"databasechangeloglock": true,
+}
+
+// SchemaFinding is one design problem found in the final schema
+type SchemaFinding struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Table    string   `json:"table"`
+	Column   string   `json:"column,omitempty"`
+	Message  string   `json:"message"`
+}
+
+// SchemaLintReport scores a schema's design; 100 means no findings
+type SchemaLintReport struct {
+	Score    int             `json:"score"`
+	Grade    string          `json:"grade"`
+	Tables   int             `json:"tables"`
+	Findings []SchemaFinding `json:"findings"`
+	Errors   int             `json:"errors"`
+	Warnings int             `json:"warnings"`
+	Infos    int             `json:"infos"`
+}
+
+// LintSchema checks the final schema for missing primary keys, unindexed or nullable foreign
+// keys, inconsistent naming, very wide tables and tables with no relationships
+func LintSchema(schema *CanonicalSchema) *SchemaLintReport {
+	report := &SchemaLintReport{Findings: []SchemaFinding{}}
+	if schema == nil {
+		report.Score, report.Grade = 100, "A"
+		return report
+	}
+
+	// Partitions share their parent's design, so only logical tables are checked
+	var tableNames []string
+	for name, table := range schema.Tables {
+		if table.PartitionOf == nil {
+			tableNames = append(tableNames, name)
+		}
+	}
+	sort.Strings(tableNames)
+	report.Tables = len(tableNames)
+
+	add := func(rule string, severity Severity, table, column, format string, args ...interface{}) {
+		report.Findings = append(report.Findings, SchemaFinding{
+			Rule:     rule,
+			Severity: severity,
+			Table:    table,
+			Column:   column,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	referenced := make(map[string]bool)
+	for _, name := range tableNames {
+		for _, fk := range schema.Tables[name].ForeignKeys {
+			referenced[fk.RefTable] = true
+		}
+	}
+
+	for _, name := range tableNames {
+		table := schema.Tables[name]
+
+		if len(table.PrimaryKey) == 0 {
+			add(RuleMissingPrimaryKey, SeverityError, name, "", "table %s has no primary key", name)
+		}
+
+		if len(table.Columns) > wideTableColumns {
+			add(RuleWideTable, SeverityWarning, name, "", "table %s has %d columns (more than %d); consider splitting it", name, len(table.Columns), wideTableColumns)
+		}
+
+		for _, fk := range table.ForeignKeys {
+			columns := strings.Join(fk.Columns, ", ")
+			if !foreignKeyIndexed(table, fk.Columns) {
+				add(RuleUnindexedForeignKey, SeverityWarning, name, columns, "foreign key %s(%s) -> %s has no index; joins and cascading deletes will scan the table", name, columns, fk.RefTable)
+			}
+			for _, column := range fk.Columns {
+				if col, ok := table.Columns[column]; ok && col.Nullable {
+					add(RuleNullableForeignKey, SeverityInfo, name, column, "foreign key column %s.%s is nullable; add NOT NULL unless the relationship is optional", name, column)
+				}
+			}
+		}
+
+		_, bare := splitTableKey(name)
+		if len(tableNames) > 1 && len(table.ForeignKeys) == 0 && !referenced[name] && !bookkeepingTables[bare] {
+			add(RuleOrphanTable, SeverityInfo, name, "", "table %s has no foreign keys to or from other tables", name)
+		}
+	}
+
+	lintNaming(schema, tableNames, add)
+
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		a, b := report.Findings[i], report.Findings[j]
+		if a.Table != b.Table {
+			return a.Table < b.Table
+		}
+		return a.Column < b.Column
+	})
+
+	penalty := 0
+	for _, finding := range report.Findings {
+		switch finding.Severity {
+		case SeverityError:
+			report.Errors++
+		case SeverityWarning:
+			report.Warnings++
+		case SeverityInfo:
+			report.Infos++
+		}
+		penalty += schemaPenalty[finding.Severity]
+	}
+
+	// An error on every table costs the whole score; findings weigh less in larger schemas
+	report.Score = 100
+	if report.Tables > 0 {
+		report.Score = 100 - penalty*10/report.Tables
+		if report.Score < 0 {
+			report.Score = 0
+		}
+	}
+	report.Grade = schemaGrade(report.Score)
+	return report
+}
+
+// foreignKeyIndexed reports whether the FK columns lead an index, the primary key or a unique
+// constraint, in any order
+func foreignKeyIndexed(table *CanonicalTable, columns []string) bool {
+	leads := func(indexed []string) bool {
+		if len(indexed) < len(columns) {
+			return false
+		}
+		for _, column := range columns {
+			if !containsName(indexed[:len(columns)], column) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if leads(table.PrimaryKey) {
+		return true
+	}
+	for _, unique := range table.Unique {
+		if leads(unique) {
+			return true
+		}
+	}
+	for _, index := range table.Indexes {
+		if leads(index.Columns) {
+			return true
+		}
+	}
+	return false
+}
+
+// lintNaming flags tables and columns that break the convention most of the schema follows.
+// Identifiers are lowercased on extraction, so this looks at plurality and key naming rather
+// than letter case.
+func lintNaming(schema *CanonicalSchema, tableNames []string, add func(rule string, severity Severity, table, column, format string, args ...interface{})) {
+	// Plural vs singular table names
+	var plural, singular []string
+	for _, name := range tableNames {
+		if _, bare := splitTableKey(name); bookkeepingTables[bare] {
+			continue
+		} else if looksPlural(bare) {
+			plural = append(plural, name)
+		} else {
+			singular = append(singular, name)
+		}
+	}
+	if minority, style := namingMinority(plural, singular, "plural", "singular"); minority != nil {
+		for _, name := range minority {
+			add(RuleNamingConvention, SeverityInfo, name, "", "table name %s is not %s like most tables", name, style)
+		}
+	}
+
+	// Single-column primary keys named id vs <table>_id
+	var plainID, prefixedID []string
+	for _, name := range tableNames {
+		table := schema.Tables[name]
+		if _, bare := splitTableKey(name); len(table.PrimaryKey) != 1 || bookkeepingTables[bare] {
+			continue
+		}
+		if table.PrimaryKey[0] == "id" {
+			plainID = append(plainID, name)
+		} else {
+			prefixedID = append(prefixedID, name)
+		}
+	}
+	if minority, style := namingMinority(plainID, prefixedID, `named "id"`, "named after the table"); minority != nil {
+		for _, name := range minority {
+			add(RuleNamingConvention, SeverityInfo, name, schema.Tables[name].PrimaryKey[0], "primary key of %s is not %s like most tables", name, style)
+		}
+	}
+
+	// Foreign key columns ending in _id
+	var suffixed, unsuffixed []string
+	for _, name := range tableNames {
+		for _, fk := range schema.Tables[name].ForeignKeys {
+			if len(fk.Columns) != 1 {
+				continue
+			}
+			qualified := name + "." + fk.Columns[0]
+			if strings.HasSuffix(fk.Columns[0], "_id") {
+				suffixed = append(suffixed, qualified)
+			} else {
+				unsuffixed = append(unsuffixed, qualified)
+			}
+		}
+	}
+	if minority, _ := namingMinority(suffixed, unsuffixed, "", ""); minority != nil && len(suffixed) > len(unsuffixed) {
+		for _, qualified := range minority {
+			idx := strings.LastIndex(qualified, ".")
+			add(RuleNamingConvention, SeverityInfo, qualified[:idx], qualified[idx+1:], "foreign key column %s does not end in _id like most foreign keys", qualified)
+		}
+	}
+}
+
+// namingMinority returns the smaller group, and the style of the larger one, when the larger
+// group clearly dominates (at least two thirds of four or more names)
+func namingMinority(a, b []string, styleA, styleB string) ([]string, string) {
+	total := len(a) + len(b)
+	if total < 4 || len(a) == 0 || len(b) == 0 {
+		return nil, ""
+	}
+	if len(a)*3 >= total*2 {
+		return b, styleA
+	}
+	if len(b)*3 >= total*2 {
+		return a, styleB
+	}
+	return nil, ""
+}
+
+// looksPlural is a rough English plural check on the last word of a snake_case name
+func looksPlural(name string) bool {
+	word := name[strings.LastIndex(name, "_")+1:]
+	if !strings.HasSuffix(word, "s") || len(word) < 3 {
+		return strings.HasSuffix(word, "data") || strings.HasSuffix(word, "people")
+	}
+	for _, suffix := range []string{"ss", "us", "is", "ics"} {
+		if strings.HasSuffix(word, suffix) {
+			return false
+		}
+	}
+	return true
+}
+
+// schemaGrade maps a score to a letter grade
+func schemaGrade(score int) string {
+	switch {
+	case score >= 90:
+		return "A"
+	case score >= 80:
+		return "B"
+	case score >= 70:
+		return "C"
+	case score >= 60:
+		return "D"
+	default:
+		return "F"
+	}
+}
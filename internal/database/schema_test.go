@@ -0,0 +1,23 @@
+package database
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestFindMigrationFoldersWindowsPaths(t *testing.T) {
+	files := map[string]string{
+		`db\migrations\001_init.sql`:           "CREATE TABLE users (id INT);\r\n",
+		`db\migrations\002_orders.sql`:         "CREATE TABLE orders (id INT);\r\n",
+		`services\billing\Migrations\01_a.sql`: "CREATE TABLE invoices (id INT);\r\n",
+		`db\migrations_old\README.md`:          "Old migrations\r\n",
+		`db\seeds\seed.sql`:                    "INSERT INTO users VALUES (1);\r\n",
+		`services\billing\migration\notes.txt`: "not sql\r\n",
+	}
+	folders := NewSchemaExtractor().FindMigrationFolders("", files)
+	sort.Strings(folders)
+	if want := []string{"db/migrations", "services/billing/Migrations"}; !reflect.DeepEqual(folders, want) {
+		t.Errorf("FindMigrationFolders() = %q, want %q", folders, want)
+	}
+}
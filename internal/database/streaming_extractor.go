@@ -3,7 +3,6 @@ package database
 import (
 	"context"
 	"fmt"
-	"os"
 	"path/filepath"
 	"regexp"
 	"sort"
@@ -11,7 +10,9 @@ import (
 	"time"
 
 	"github.com/sashabaranov/go-openai"
-	"repo-explanation/config"
+	"repo-explanation/internal/mermaid"
+	internalOpenai "repo-explanation/internal/openai"
+	"repo-explanation/internal/portable"
 )
 
 // StreamingResponse represents a single streaming response event
@@ -30,28 +31,66 @@ type ProgressInfo struct {
 }
 
 // CanonicalSchema represents the complete canonical schema model
+// Objects outside the default schema are keyed by their schema-qualified name (auth.users).
 type CanonicalSchema struct {
-	Tables map[string]*CanonicalTable `json:"tables"`
-	Enums  map[string][]string        `json:"enums"`
-	Views  map[string]*View           `json:"views"`
+	Tables    map[string]*CanonicalTable    `json:"tables"`
+	Enums     map[string][]string           `json:"enums"`
+	Views     map[string]*View              `json:"views"`
+	Functions map[string]*CanonicalFunction `json:"functions,omitempty"`
+	Schemas   []string                      `json:"schemas,omitempty"` // Named schemas (Postgres) or databases (MySQL)
 }
 
 // CanonicalTable represents a table in canonical format
 type CanonicalTable struct {
+	Schema      string                        `json:"schema,omitempty"` // Empty for the default schema
+	Name        string                        `json:"name,omitempty"`
 	Columns     map[string]*CanonicalColumn   `json:"columns"`
 	PrimaryKey  []string                      `json:"primaryKey"`
 	Unique      [][]string                    `json:"unique"`
 	ForeignKeys []*CanonicalForeignKey        `json:"foreignKeys"`
 	Indexes     []*CanonicalIndex             `json:"indexes"`
 	Comment     *string                       `json:"comment"`
+	Partition   *CanonicalPartition           `json:"partition,omitempty"`   // Set on partitioned (parent) tables
+	PartitionOf *CanonicalPartitionOf         `json:"partitionOf,omitempty"` // Set on partitions
+	Triggers    []*CanonicalTrigger           `json:"triggers,omitempty"`
+	Checks      []*CanonicalCheck             `json:"checks,omitempty"`
+}
+
+// CanonicalCheck is a CHECK constraint, written on a column or on the table
+type CanonicalCheck struct {
+	Name       *string `json:"name"`
+	Expression string  `json:"expression"`       // As written, without the surrounding parentheses
+	Column     string  `json:"column,omitempty"` // Set when declared on a column, which dropping the column drops
+}
+
+// CanonicalPartition describes a PARTITION BY clause
+type CanonicalPartition struct {
+	Strategy string `json:"strategy"` // range, list or hash
+	Key      string `json:"key"`      // Partition key as written, e.g. "created_at" or "lower(email)"
+}
+
+// CanonicalPartitionOf links a partition to its parent table
+type CanonicalPartitionOf struct {
+	Parent string `json:"parent"`
+	Bound  string `json:"bound"` // e.g. "FOR VALUES FROM ('2024-01-01') TO ('2025-01-01')" or "DEFAULT"
 }
 
 // CanonicalColumn represents a column in canonical format
 type CanonicalColumn struct {
-	Type     string  `json:"type"`
-	Nullable bool    `json:"nullable"`
-	Default  *string `json:"default"`
-	Comment  *string `json:"comment"`
+	Type            string           `json:"type"`
+	Nullable        bool             `json:"nullable"`
+	Default         *string          `json:"default"`
+	Comment         *string          `json:"comment"`
+	Identity        string           `json:"identity,omitempty"`        // "always" or "by default" for identity columns
+	IdentityOptions string           `json:"identityOptions,omitempty"` // Sequence options, e.g. "START WITH 1000"
+	Generated       *GeneratedColumn `json:"generated,omitempty"`
+	Classification  string           `json:"classification,omitempty"` // pii, credential or financial
+}
+
+// GeneratedColumn describes a column computed from an expression
+type GeneratedColumn struct {
+	Expression string `json:"expression"`
+	Stored     bool   `json:"stored"`
 }
 
 // CanonicalForeignKey represents a foreign key in canonical format
@@ -85,8 +124,9 @@ type Migration struct {
 
 // StreamingSchemaExtractor handles streaming schema extraction
 type StreamingSchemaExtractor struct {
-	schema  *CanonicalSchema
-	dialect string
+	schema        *CanonicalSchema
+	dialect       string
+	currentSchema string // Schema unqualified names resolve to while applying a statement
 }
 
 // NewStreamingSchemaExtractor creates a new streaming schema extractor
@@ -97,9 +137,10 @@ func NewStreamingSchemaExtractor(dialect string) *StreamingSchemaExtractor {
 	
 	return &StreamingSchemaExtractor{
 		schema: &CanonicalSchema{
-			Tables: make(map[string]*CanonicalTable),
-			Enums:  make(map[string][]string),
-			Views:  make(map[string]*View),
+			Tables:    make(map[string]*CanonicalTable),
+			Enums:     make(map[string][]string),
+			Views:     make(map[string]*View),
+			Functions: make(map[string]*CanonicalFunction),
 		},
 		dialect: dialect,
 	}
@@ -110,6 +151,8 @@ type DDLStatement struct {
 	Type      string
 	Statement string
 	TableName string
+	Schema    string // Schema selected by SET search_path / USE when the statement was parsed
+	Source    string // The statement as written, for the Postgres parser
 }
 
 // BuildSchemaAndStream processes migrations and emits streaming responses
@@ -118,9 +161,10 @@ func (se *StreamingSchemaExtractor) BuildSchemaAndStream(migrations []Migration,
 	
 	// Initialize empty schema
 	se.schema = &CanonicalSchema{
-		Tables: make(map[string]*CanonicalTable),
-		Enums:  make(map[string][]string),
-		Views:  make(map[string]*View),
+		Tables:    make(map[string]*CanonicalTable),
+		Enums:     make(map[string][]string),
+		Views:     make(map[string]*View),
+		Functions: make(map[string]*CanonicalFunction),
 	}
 	
 	// Process each migration
@@ -263,15 +307,23 @@ func (se *StreamingSchemaExtractor) BuildSchemaAndStream(migrations []Migration,
 func (se *StreamingSchemaExtractor) parseMigrationSQL(sql string) ([]DDLStatement, error) {
 	var statements []DDLStatement
 	
-	// Split by semicolon and clean up
-	rawStatements := strings.Split(sql, ";")
+	// Split on semicolons outside strings and dollar-quoted function bodies, then clean up
+	rawStatements := splitStatementsWithLines(sql)
+	
+	// Each migration starts in the default schema
+	se.currentSchema = ""
 	
 	for _, rawStmt := range rawStatements {
-		cleanStmt := se.cleanSQLStatement(rawStmt)
+		cleanStmt := se.cleanSQLStatement(rawStmt.Text)
 		if cleanStmt == "" {
 			continue
 		}
 		
+		if schema, ok := parseSchemaSwitch(cleanStmt); ok {
+			se.currentSchema = schema
+			continue
+		}
+		
 		// Identify statement type
 		stmtType := se.identifyStatementType(cleanStmt)
 		if stmtType == "" {
@@ -281,10 +333,17 @@ func (se *StreamingSchemaExtractor) parseMigrationSQL(sql string) ([]DDLStatemen
 		// Extract table name if applicable
 		tableName := se.extractTableName(cleanStmt, stmtType)
 		
+		// Routine bodies keep their original layout; flattening would break line comments in them
+		if stmtType == "CREATE_FUNCTION" || stmtType == "CREATE_TRIGGER" {
+			cleanStmt = rawStmt.Text
+		}
+		
 		statements = append(statements, DDLStatement{
 			Type:      stmtType,
 			Statement: cleanStmt,
 			TableName: tableName,
+			Schema:    se.currentSchema,
+			Source:    rawStmt.Text,
 		})
 	}
 	
@@ -339,24 +398,36 @@ func (se *StreamingSchemaExtractor) identifyStatementType(stmt string) string {
 		return "CREATE_VIEW"
 	} else if strings.HasPrefix(upperStmt, "DROP VIEW") {
 		return "DROP_VIEW"
+	} else if strings.HasPrefix(upperStmt, "CREATE SCHEMA") || strings.HasPrefix(upperStmt, "CREATE DATABASE") {
+		return "CREATE_SCHEMA"
+	} else if strings.HasPrefix(upperStmt, "DROP SCHEMA") || strings.HasPrefix(upperStmt, "DROP DATABASE") {
+		return "DROP_SCHEMA"
+	} else if createFunctionRegex.MatchString(stmt) {
+		return "CREATE_FUNCTION"
+	} else if createTriggerRegex.MatchString(stmt) {
+		return "CREATE_TRIGGER"
+	} else if strings.HasPrefix(upperStmt, "DROP FUNCTION") || strings.HasPrefix(upperStmt, "DROP PROCEDURE") {
+		return "DROP_FUNCTION"
+	} else if strings.HasPrefix(upperStmt, "DROP TRIGGER") {
+		return "DROP_TRIGGER"
 	}
 	
 	return "" // Unsupported statement type
 }
 
-// extractTableName extracts table name from DDL statement
+// extractTableName extracts the schema-qualified table key from a DDL statement
 func (se *StreamingSchemaExtractor) extractTableName(stmt, stmtType string) string {
 	var regex *regexp.Regexp
 	
 	switch stmtType {
 	case "CREATE_TABLE":
-		regex = regexp.MustCompile(`CREATE TABLE\s+(?:IF NOT EXISTS\s+)?(?:"?([^"\s(]+)"?|\[([^\]]+)\]|([^\s(]+))`)
+		regex = createTableNameRegex
 	case "DROP_TABLE":
-		regex = regexp.MustCompile(`DROP TABLE\s+(?:IF EXISTS\s+)?(?:"?([^"\s;]+)"?|\[([^\]]+)\]|([^\s;]+))`)
+		regex = dropTableNameRegex
 	case "ALTER_TABLE":
-		regex = regexp.MustCompile(`ALTER TABLE\s+(?:"?([^"\s]+)"?|\[([^\]]+)\]|([^\s]+))`)
+		regex = alterTableNameRegex
 	case "CREATE_INDEX":
-		regex = regexp.MustCompile(`ON\s+(?:"?([^"\s(]+)"?|\[([^\]]+)\]|([^\s(]+))`)
+		regex = indexTableNameRegex
 	default:
 		return ""
 	}
@@ -366,13 +437,26 @@ func (se *StreamingSchemaExtractor) extractTableName(stmt, stmtType string) stri
 		return ""
 	}
 	
-	for i := 1; i < len(matches); i++ {
-		if matches[i] != "" {
-			return strings.ToLower(strings.TrimSpace(matches[i]))
+	return qualifyName(resolveQualifiedName(matches[1], se.currentSchema))
+}
+
+// resolveName resolves a possibly qualified object name against the current schema
+func (se *StreamingSchemaExtractor) resolveName(raw string) string {
+	return qualifyName(resolveQualifiedName(raw, se.currentSchema))
+}
+
+// addSchema records a named schema once
+func (se *StreamingSchemaExtractor) addSchema(schema string) {
+	if schema == "" {
+		return
+	}
+	for _, existing := range se.schema.Schemas {
+		if existing == schema {
+			return
 		}
 	}
-	
-	return ""
+	se.schema.Schemas = append(se.schema.Schemas, schema)
+	sort.Strings(se.schema.Schemas)
 }
 
 // applyStatement applies a DDL statement to the schema (with graceful error handling)
@@ -384,6 +468,15 @@ func (se *StreamingSchemaExtractor) applyStatement(stmt DDLStatement) error {
 		}
 	}()
 
+	se.currentSchema = stmt.Schema
+
+	// Postgres tables are read by the parser; what it cannot read falls through to the regex path
+	if se.dialect == "postgres" {
+		if handled, err := se.applyParsed(stmt); handled {
+			return err
+		}
+	}
+
 	switch stmt.Type {
 	case "CREATE_TABLE":
 		return se.applyCreateTableSafely(stmt)
@@ -401,6 +494,18 @@ func (se *StreamingSchemaExtractor) applyStatement(stmt DDLStatement) error {
 		return se.applyCreateViewSafely(stmt)
 	case "DROP_VIEW":
 		return se.applyDropViewSafely(stmt)
+	case "CREATE_SCHEMA":
+		return se.applyCreateSchema(stmt)
+	case "DROP_SCHEMA":
+		return se.applyDropSchema(stmt)
+	case "CREATE_FUNCTION":
+		return se.applyCreateFunction(stmt)
+	case "DROP_FUNCTION":
+		return se.applyDropFunction(stmt)
+	case "CREATE_TRIGGER":
+		return se.applyCreateTrigger(stmt)
+	case "DROP_TRIGGER":
+		return se.applyDropTrigger(stmt)
 	default:
 		// Don't fail on unsupported statements, just skip them
 		fmt.Printf("⚠️ Skipping unsupported statement type: %s\n", stmt.Type)
@@ -421,7 +526,11 @@ func (se *StreamingSchemaExtractor) applyCreateTable(stmt DDLStatement) error {
 	}
 	
 	// Create new table
+	schema, name := splitTableKey(tableName)
+	se.addSchema(schema)
 	table := &CanonicalTable{
+		Schema:      schema,
+		Name:        name,
 		Columns:     make(map[string]*CanonicalColumn),
 		PrimaryKey:  []string{},
 		Unique:      [][]string{},
@@ -431,7 +540,7 @@ func (se *StreamingSchemaExtractor) applyCreateTable(stmt DDLStatement) error {
 	}
 	
 	// Extract column definitions from CREATE TABLE statement
-	if err := se.parseCreateTableColumns(stmt.Statement, table); err != nil {
+	if err := se.parseCreateTableColumns(tableName, stmt.Statement, table); err != nil {
 		return err
 	}
 	
@@ -439,17 +548,52 @@ func (se *StreamingSchemaExtractor) applyCreateTable(stmt DDLStatement) error {
 	return nil
 }
 
-// parseCreateTableColumns parses column definitions from CREATE TABLE
-func (se *StreamingSchemaExtractor) parseCreateTableColumns(stmt string, table *CanonicalTable) error {
-	// Extract content between parentheses
-	parenRegex := regexp.MustCompile(`CREATE TABLE[^(]*\(\s*(.*)\s*\)`)
-	matches := parenRegex.FindStringSubmatch(stmt)
-	if len(matches) < 2 {
+// parseCreateTableColumns parses column definitions and partitioning from CREATE TABLE
+func (se *StreamingSchemaExtractor) parseCreateTableColumns(tableName, stmt string, table *CanonicalTable) error {
+	nameLoc := createTableNameRegex.FindStringIndex(stmt)
+	if nameLoc == nil {
 		return fmt.Errorf("could not extract column definitions")
 	}
+	rest := stmt[nameLoc[1]:]
+	
+	// CREATE TABLE child PARTITION OF parent [(constraints)] FOR VALUES ... | DEFAULT
+	if loc := partitionOfRegex.FindStringSubmatchIndex(rest); loc != nil {
+		table.PartitionOf = &CanonicalPartitionOf{Parent: se.resolveName(rest[loc[2]:loc[3]])}
+		rest = rest[loc[1]:]
+		if strings.HasPrefix(rest, "(") {
+			end := matchingParen(rest, 0)
+			if end < 0 {
+				return fmt.Errorf("unbalanced parentheses in partition definition")
+			}
+			if err := se.parseTableDefinitions(tableName, rest[1:end], table); err != nil {
+				return err
+			}
+			rest = rest[end+1:]
+		}
+		if partition := parsePartitionBy(rest); partition != nil {
+			table.Partition = partition
+			rest = rest[:partitionByRegex.FindStringIndex(rest)[0]]
+		}
+		table.PartitionOf.Bound = strings.TrimSpace(rest)
+		return nil
+	}
 	
-	columnDefs := matches[1]
+	// Extract content between the outer parentheses
+	open := strings.Index(rest, "(")
+	if open < 0 {
+		return fmt.Errorf("could not extract column definitions")
+	}
+	end := matchingParen(rest, open)
+	if end < 0 {
+		return fmt.Errorf("could not extract column definitions")
+	}
 	
+	table.Partition = parsePartitionBy(rest[end+1:])
+	return se.parseTableDefinitions(tableName, rest[open+1:end], table)
+}
+
+// parseTableDefinitions parses the comma-separated column and constraint definitions of a table
+func (se *StreamingSchemaExtractor) parseTableDefinitions(tableName, columnDefs string, table *CanonicalTable) error {
 	// Split column definitions (handle nested parentheses)
 	definitions := se.splitTableDefinitions(columnDefs)
 	
@@ -465,6 +609,10 @@ func (se *StreamingSchemaExtractor) parseCreateTableColumns(stmt string, table *
 			se.parsePrimaryKeyDef(def, table)
 		} else if strings.HasPrefix(upperDef, "FOREIGN KEY") || strings.HasPrefix(upperDef, "CONSTRAINT") {
 			se.parseForeignKeyDef(def, table)
+		} else if isInlineIndexDef(def) {
+			if err := se.parseInlineIndexDef(tableName, def, table); err != nil {
+				return err
+			}
 		} else if strings.HasPrefix(upperDef, "UNIQUE") {
 			se.parseUniqueDef(def, table)
 		} else {
@@ -511,8 +659,8 @@ func (se *StreamingSchemaExtractor) parseColumnDef(def string, table *CanonicalT
 		return fmt.Errorf("invalid column definition: %s", def)
 	}
 	
-	columnName := strings.ToLower(strings.Trim(parts[0], `"[]`))
-	columnType := strings.ToLower(parts[1])
+	columnName := strings.ToLower(strings.Trim(parts[0], "\"`[]"))
+	columnType := columnType(parts)
 	
 	// Create column
 	column := &CanonicalColumn{
@@ -522,6 +670,14 @@ func (se *StreamingSchemaExtractor) parseColumnDef(def string, table *CanonicalT
 		Comment:  nil,
 	}
 	
+	// Take identity and generation clauses out first so their keywords and expressions
+	// are not mistaken for DEFAULT, UNIQUE or REFERENCES constraints
+	def, column.Identity, column.IdentityOptions = extractIdentity(def)
+	def, column.Generated = extractGenerated(def)
+	if column.Identity != "" {
+		column.Nullable = false // Identity columns are implicitly NOT NULL
+	}
+	
 	// Parse constraints
 	upperDef := strings.ToUpper(def)
 	
@@ -541,9 +697,9 @@ func (se *StreamingSchemaExtractor) parseColumnDef(def string, table *CanonicalT
 		table.Unique = append(table.Unique, []string{columnName})
 	}
 	
-	// Extract default value
-	defaultRegex := regexp.MustCompile(`DEFAULT\s+([^,\s]+|\([^)]+\)|'[^']*')`)
-	defaultMatches := defaultRegex.FindStringSubmatch(upperDef)
+	// Extract default value, keeping its case: now() and 'Pending' are written as they are
+	defaultRegex := regexp.MustCompile(`(?i)DEFAULT\s+([^,\s]+|\([^)]+\)|'[^']*')`)
+	defaultMatches := defaultRegex.FindStringSubmatch(def)
 	if len(defaultMatches) > 1 {
 		defaultValue := defaultMatches[1]
 		column.Default = &defaultValue
@@ -563,16 +719,15 @@ func (se *StreamingSchemaExtractor) parseColumnDef(def string, table *CanonicalT
 
 // parseForeignKeyRef parses inline foreign key reference
 func (se *StreamingSchemaExtractor) parseForeignKeyRef(def string) *CanonicalForeignKey {
-	fkRegex := regexp.MustCompile(`REFERENCES\s+([^\s(]+)\s*\(([^)]+)\)`)
-	matches := fkRegex.FindStringSubmatch(strings.ToUpper(def))
+	matches := inlineReferenceRegex.FindStringSubmatch(def)
 	if len(matches) >= 3 {
-		refTable := strings.ToLower(strings.Trim(matches[1], `"[]`))
-		refColumn := strings.ToLower(strings.Trim(matches[2], `"[]`))
+		refTable := se.resolveName(matches[1])
+		refColumn := strings.ToLower(strings.Trim(matches[2], "\"`[]"))
 		
 		// Extract column name from beginning of definition
 		parts := strings.Fields(def)
 		if len(parts) > 0 {
-			columnName := strings.ToLower(strings.Trim(parts[0], `"[]`))
+			columnName := strings.ToLower(strings.Trim(parts[0], "\"`[]"))
 			
 			return &CanonicalForeignKey{
 				Columns:    []string{columnName},
@@ -594,7 +749,7 @@ func (se *StreamingSchemaExtractor) parsePrimaryKeyDef(def string, table *Canoni
 	if len(matches) > 1 {
 		columns := strings.Split(matches[1], ",")
 		for _, col := range columns {
-			col = strings.ToLower(strings.TrimSpace(strings.Trim(col, `"[]`)))
+			col = strings.ToLower(strings.TrimSpace(strings.Trim(col, "\"`[]")))
 			table.PrimaryKey = append(table.PrimaryKey, col)
 		}
 	}
@@ -602,21 +757,20 @@ func (se *StreamingSchemaExtractor) parsePrimaryKeyDef(def string, table *Canoni
 
 // parseForeignKeyDef parses FOREIGN KEY constraint
 func (se *StreamingSchemaExtractor) parseForeignKeyDef(def string, table *CanonicalTable) {
-	fkRegex := regexp.MustCompile(`FOREIGN KEY\s*\(([^)]+)\)\s*REFERENCES\s+([^\s(]+)\s*\(([^)]+)\)`)
-	matches := fkRegex.FindStringSubmatch(def)
+	matches := foreignKeyDefRegex.FindStringSubmatch(def)
 	if len(matches) >= 4 {
 		localCols := strings.Split(matches[1], ",")
-		refTable := strings.ToLower(strings.TrimSpace(strings.Trim(matches[2], `"[]`)))
+		refTable := se.resolveName(matches[2])
 		refCols := strings.Split(matches[3], ",")
 		
 		var localColumns []string
 		for _, col := range localCols {
-			localColumns = append(localColumns, strings.ToLower(strings.TrimSpace(strings.Trim(col, `"[]`))))
+			localColumns = append(localColumns, strings.ToLower(strings.TrimSpace(strings.Trim(col, "\"`[]"))))
 		}
 		
 		var refColumns []string
 		for _, col := range refCols {
-			refColumns = append(refColumns, strings.ToLower(strings.TrimSpace(strings.Trim(col, `"[]`))))
+			refColumns = append(refColumns, strings.ToLower(strings.TrimSpace(strings.Trim(col, "\"`[]"))))
 		}
 		
 		table.ForeignKeys = append(table.ForeignKeys, &CanonicalForeignKey{
@@ -638,7 +792,7 @@ func (se *StreamingSchemaExtractor) parseUniqueDef(def string, table *CanonicalT
 		columns := strings.Split(matches[1], ",")
 		var uniqueColumns []string
 		for _, col := range columns {
-			uniqueColumns = append(uniqueColumns, strings.ToLower(strings.TrimSpace(strings.Trim(col, `"[]`))))
+			uniqueColumns = append(uniqueColumns, strings.ToLower(strings.TrimSpace(strings.Trim(col, "\"`[]"))))
 		}
 		table.Unique = append(table.Unique, uniqueColumns)
 	}
@@ -666,7 +820,11 @@ func (se *StreamingSchemaExtractor) applyAlterTable(stmt DDLStatement) error {
 	table, exists := se.schema.Tables[tableName]
 	if !exists {
 		// Create table if it doesn't exist (some migrations might reference future tables)
+		schema, name := splitTableKey(tableName)
+		se.addSchema(schema)
 		table = &CanonicalTable{
+			Schema:      schema,
+			Name:        name,
 			Columns:     make(map[string]*CanonicalColumn),
 			PrimaryKey:  []string{},
 			Unique:      [][]string{},
@@ -679,14 +837,20 @@ func (se *StreamingSchemaExtractor) applyAlterTable(stmt DDLStatement) error {
 	
 	upperStmt := strings.ToUpper(stmt.Statement)
 	
-	if strings.Contains(upperStmt, "ADD COLUMN") || strings.Contains(upperStmt, "ADD ") {
+	if strings.Contains(upperStmt, "ATTACH PARTITION") {
+		return se.applyAttachPartition(stmt.Statement, tableName)
+	} else if strings.Contains(upperStmt, "DETACH PARTITION") {
+		return se.applyDetachPartition(stmt.Statement)
+	} else if loc := addIndexRegex.FindStringIndex(stmt.Statement); loc != nil {
+		return se.parseInlineIndexDef(tableName, stmt.Statement[loc[0]+len("ADD"):], table)
+	} else if addConstraintRegex.MatchString(stmt.Statement) {
+		return se.applyAddConstraint(stmt.Statement, table)
+	} else if strings.Contains(upperStmt, "ADD COLUMN") || strings.Contains(upperStmt, "ADD ") {
 		return se.applyAddColumn(stmt.Statement, table)
 	} else if strings.Contains(upperStmt, "DROP COLUMN") {
 		return se.applyDropColumn(stmt.Statement, table)
 	} else if strings.Contains(upperStmt, "ALTER COLUMN") || strings.Contains(upperStmt, "MODIFY COLUMN") {
 		return se.applyAlterColumn(stmt.Statement, table)
-	} else if strings.Contains(upperStmt, "ADD CONSTRAINT") {
-		return se.applyAddConstraint(stmt.Statement, table)
 	} else if strings.Contains(upperStmt, "DROP CONSTRAINT") {
 		return se.applyDropConstraint(stmt.Statement, table)
 	}
@@ -694,6 +858,33 @@ func (se *StreamingSchemaExtractor) applyAlterTable(stmt DDLStatement) error {
 	return nil
 }
 
+// applyAttachPartition applies ALTER TABLE parent ATTACH PARTITION child FOR VALUES ...
+func (se *StreamingSchemaExtractor) applyAttachPartition(stmt, parent string) error {
+	matches := attachRegex.FindStringSubmatch(stmt)
+	if len(matches) < 3 {
+		return fmt.Errorf("could not extract partition from ATTACH PARTITION")
+	}
+	child, exists := se.schema.Tables[se.resolveName(matches[1])]
+	if !exists {
+		return fmt.Errorf("partition %s does not exist", matches[1])
+	}
+	child.PartitionOf = &CanonicalPartitionOf{Parent: parent, Bound: strings.TrimSpace(matches[2])}
+	return nil
+}
+
+// applyDetachPartition applies ALTER TABLE parent DETACH PARTITION child; the child becomes a
+// standalone table
+func (se *StreamingSchemaExtractor) applyDetachPartition(stmt string) error {
+	matches := detachRegex.FindStringSubmatch(stmt)
+	if len(matches) < 2 {
+		return fmt.Errorf("could not extract partition from DETACH PARTITION")
+	}
+	if child, exists := se.schema.Tables[se.resolveName(matches[1])]; exists {
+		child.PartitionOf = nil
+	}
+	return nil
+}
+
 // applyAddColumn applies ADD COLUMN statement
 func (se *StreamingSchemaExtractor) applyAddColumn(stmt string, table *CanonicalTable) error {
 	addColumnRegex := regexp.MustCompile(`ADD\s+(?:COLUMN\s+)?(.+)`)
@@ -714,9 +905,12 @@ func (se *StreamingSchemaExtractor) applyDropColumn(stmt string, table *Canonica
 		return fmt.Errorf("could not extract column name from DROP COLUMN")
 	}
 	
-	columnName := strings.ToLower(strings.Trim(matches[1], `"[]`))
-	
-	// Remove column
+	removeColumn(table, strings.ToLower(strings.Trim(matches[1], "\"`[]")))
+	return nil
+}
+
+// removeColumn removes a column and takes it out of the primary key and unique constraints
+func removeColumn(table *CanonicalTable, columnName string) {
 	delete(table.Columns, columnName)
 	
 	// Remove from primary key if present
@@ -743,7 +937,14 @@ func (se *StreamingSchemaExtractor) applyDropColumn(stmt string, table *Canonica
 	}
 	table.Unique = newUnique
 	
-	return nil
+	// Remove the column's own CHECK constraints
+	var checks []*CanonicalCheck
+	for _, check := range table.Checks {
+		if check.Column != columnName {
+			checks = append(checks, check)
+		}
+	}
+	table.Checks = checks
 }
 
 // applyAlterColumn applies ALTER COLUMN statement
@@ -776,23 +977,114 @@ func (se *StreamingSchemaExtractor) applyDropConstraint(stmt string, table *Cano
 
 // applyCreateIndex applies CREATE INDEX statement
 func (se *StreamingSchemaExtractor) applyCreateIndex(stmt DDLStatement) error {
-	// Simplified implementation
+	loc := createIndexRegex.FindStringSubmatchIndex(stmt.Statement)
+	if loc == nil {
+		return fmt.Errorf("could not parse CREATE INDEX")
+	}
+	table, exists := se.schema.Tables[stmt.TableName]
+	if !exists {
+		return fmt.Errorf("table %s does not exist", stmt.TableName)
+	}
+	
+	open := loc[1] - 1
+	end := matchingParen(stmt.Statement, open)
+	if end < 0 {
+		return fmt.Errorf("unbalanced column list in CREATE INDEX")
+	}
+	
+	index := &CanonicalIndex{
+		Columns: indexColumns(stmt.Statement[open+1 : end]),
+		Unique:  loc[2] >= 0,
+	}
+	if loc[4] >= 0 {
+		_, index.Name = resolveQualifiedName(stmt.Statement[loc[4]:loc[5]], "")
+	} else {
+		_, tableName := splitTableKey(stmt.TableName)
+		index.Name = fmt.Sprintf("%s_%s_idx", tableName, strings.Join(index.Columns, "_"))
+	}
+	using := ""
+	if loc[6] >= 0 {
+		using = strings.ToLower(stmt.Statement[loc[6]:loc[7]])
+	} else if matches := indexUsingSuffixRegex.FindStringSubmatch(stmt.Statement[end+1:]); matches != nil {
+		using = strings.ToLower(matches[1])
+	}
+	if using != "" {
+		index.Using = &using
+	}
+	
+	se.addIndex(table, index)
+	return nil
+}
+
+// parseInlineIndexDef parses a MySQL INDEX / KEY definition from CREATE TABLE or ALTER TABLE ADD
+func (se *StreamingSchemaExtractor) parseInlineIndexDef(tableName, def string, table *CanonicalTable) error {
+	matches := inlineIndexRegex.FindStringSubmatch(def)
+	if matches == nil {
+		return fmt.Errorf("could not parse index definition: %s", def)
+	}
+	index := &CanonicalIndex{
+		Columns: indexColumns(matches[3]),
+		Unique:  matches[1] != "",
+	}
+	if matches[2] != "" {
+		_, index.Name = resolveQualifiedName(matches[2], "")
+	} else {
+		_, name := splitTableKey(tableName)
+		index.Name = fmt.Sprintf("%s_%s_idx", name, strings.Join(index.Columns, "_"))
+	}
+	se.addIndex(table, index)
 	return nil
 }
 
-// applyDropIndex applies DROP INDEX statement
+// addIndex adds an index to a table, replacing one of the same name
+func (se *StreamingSchemaExtractor) addIndex(table *CanonicalTable, index *CanonicalIndex) {
+	for i, existing := range table.Indexes {
+		if existing.Name == index.Name {
+			table.Indexes[i] = index
+			return
+		}
+	}
+	table.Indexes = append(table.Indexes, index)
+}
+
+// applyDropIndex applies DROP INDEX statement; index names are unique per schema, so every table
+// is searched
 func (se *StreamingSchemaExtractor) applyDropIndex(stmt DDLStatement) error {
-	// Simplified implementation
+	matches := dropIndexRegex.FindStringSubmatch(stmt.Statement)
+	if matches == nil {
+		return fmt.Errorf("could not parse DROP INDEX")
+	}
+	
+	dropped := make(map[string]bool)
+	for _, raw := range splitTopLevel(matches[1]) {
+		_, name := resolveQualifiedName(raw, "")
+		dropped[name] = true
+	}
+	
+	for tableName, table := range se.schema.Tables {
+		// MySQL names the table: DROP INDEX idx ON users
+		if matches[2] != "" && tableName != se.resolveName(matches[2]) {
+			continue
+		}
+		var kept []*CanonicalIndex
+		for _, index := range table.Indexes {
+			if !dropped[index.Name] {
+				kept = append(kept, index)
+			}
+		}
+		table.Indexes = kept
+	}
 	return nil
 }
 
 // applyCreateType applies CREATE TYPE statement
 func (se *StreamingSchemaExtractor) applyCreateType(stmt DDLStatement) error {
 	// Parse CREATE TYPE ... AS ENUM
-	enumRegex := regexp.MustCompile(`CREATE TYPE\s+([^\s]+)\s+AS\s+ENUM\s*\(([^)]+)\)`)
-	matches := enumRegex.FindStringSubmatch(stmt.Statement)
+	matches := createEnumRegex.FindStringSubmatch(stmt.Statement)
 	if len(matches) >= 3 {
-		typeName := strings.ToLower(strings.Trim(matches[1], `"[]`))
+		schema, name := resolveQualifiedName(matches[1], se.currentSchema)
+		se.addSchema(schema)
+		typeName := qualifyName(schema, name)
 		valuesStr := matches[2]
 		
 		var values []string
@@ -812,10 +1104,11 @@ func (se *StreamingSchemaExtractor) applyCreateType(stmt DDLStatement) error {
 // applyCreateView applies CREATE VIEW statement
 func (se *StreamingSchemaExtractor) applyCreateView(stmt DDLStatement) error {
 	// Extract view name
-	viewRegex := regexp.MustCompile(`CREATE VIEW\s+([^\s]+)\s+AS`)
-	matches := viewRegex.FindStringSubmatch(stmt.Statement)
+	matches := createViewRegex.FindStringSubmatch(stmt.Statement)
 	if len(matches) >= 2 {
-		viewName := strings.ToLower(strings.Trim(matches[1], `"[]`))
+		schema, name := resolveQualifiedName(matches[1], se.currentSchema)
+		se.addSchema(schema)
+		viewName := qualifyName(schema, name)
 		se.schema.Views[viewName] = &View{SQL: stmt.Statement}
 	}
 	
@@ -824,16 +1117,70 @@ func (se *StreamingSchemaExtractor) applyCreateView(stmt DDLStatement) error {
 
 // applyDropView applies DROP VIEW statement
 func (se *StreamingSchemaExtractor) applyDropView(stmt DDLStatement) error {
-	viewRegex := regexp.MustCompile(`DROP VIEW\s+(?:IF EXISTS\s+)?([^\s;]+)`)
-	matches := viewRegex.FindStringSubmatch(stmt.Statement)
+	matches := dropViewRegex.FindStringSubmatch(stmt.Statement)
 	if len(matches) >= 2 {
-		viewName := strings.ToLower(strings.Trim(matches[1], `"[]`))
+		viewName := se.resolveName(matches[1])
 		delete(se.schema.Views, viewName)
 	}
 	
 	return nil
 }
 
+// applyCreateSchema applies CREATE SCHEMA (or MySQL's CREATE DATABASE)
+func (se *StreamingSchemaExtractor) applyCreateSchema(stmt DDLStatement) error {
+	matches := createSchemaRegex.FindStringSubmatch(stmt.Statement)
+	if len(matches) < 2 {
+		return fmt.Errorf("could not extract schema name from CREATE SCHEMA")
+	}
+	parts := splitQualifiedName(matches[1])
+	se.addSchema(normalizeSchemaName(parts[len(parts)-1]))
+	return nil
+}
+
+// applyDropSchema applies DROP SCHEMA, removing every object the schema contained
+func (se *StreamingSchemaExtractor) applyDropSchema(stmt DDLStatement) error {
+	matches := dropSchemaRegex.FindStringSubmatch(stmt.Statement)
+	if len(matches) < 2 {
+		return fmt.Errorf("could not extract schema name from DROP SCHEMA")
+	}
+	parts := splitQualifiedName(matches[1])
+	schema := normalizeSchemaName(parts[len(parts)-1])
+	if schema == "" {
+		return nil
+	}
+	
+	prefix := schema + "."
+	for name := range se.schema.Tables {
+		if strings.HasPrefix(name, prefix) {
+			delete(se.schema.Tables, name)
+		}
+	}
+	for name := range se.schema.Enums {
+		if strings.HasPrefix(name, prefix) {
+			delete(se.schema.Enums, name)
+		}
+	}
+	for name := range se.schema.Views {
+		if strings.HasPrefix(name, prefix) {
+			delete(se.schema.Views, name)
+		}
+	}
+	for name := range se.schema.Functions {
+		if strings.HasPrefix(name, prefix) {
+			delete(se.schema.Functions, name)
+		}
+	}
+	
+	var remaining []string
+	for _, existing := range se.schema.Schemas {
+		if existing != schema {
+			remaining = append(remaining, existing)
+		}
+	}
+	se.schema.Schemas = remaining
+	return nil
+}
+
 // normalizeSchema normalizes the final schema
 func (se *StreamingSchemaExtractor) normalizeSchema() {
 	// Sort keys, resolve type aliases, validate foreign keys, etc.
@@ -851,31 +1198,56 @@ func (se *StreamingSchemaExtractor) normalizeSchema() {
 		// Generate deterministic names for unnamed constraints
 		for i, fk := range table.ForeignKeys {
 			if fk.Name == nil {
-				name := fmt.Sprintf("fk_%s_%s", tableName, strings.Join(fk.Columns, "_"))
+				name := fmt.Sprintf("fk_%s_%s", strings.ReplaceAll(tableName, ".", "_"), strings.Join(fk.Columns, "_"))
 				table.ForeignKeys[i].Name = &name
 			}
 		}
 	}
+	
+	classifyColumns(se.schema)
 }
 
 // generateMermaidERD generates Mermaid ERD from the final schema
 func (se *StreamingSchemaExtractor) generateMermaidERD() string {
-	var mermaid strings.Builder
+	var erd strings.Builder
 	
-	mermaid.WriteString("erDiagram\n")
+	erd.WriteString("erDiagram\n")
 	
-	// Sort table names for consistent output
+	// Sort table names for consistent output, keeping each schema's tables together
+	// (default schema first) since erDiagram has no native grouping. Partitions are physical
+	// storage of their parent and are left out.
 	var tableNames []string
-	for tableName := range se.schema.Tables {
+	for tableName, table := range se.schema.Tables {
+		if table.PartitionOf != nil {
+			continue
+		}
 		tableNames = append(tableNames, tableName)
 	}
-	sort.Strings(tableNames)
+	sort.Slice(tableNames, func(i, j int) bool {
+		schemaI, nameI := splitTableKey(tableNames[i])
+		schemaJ, nameJ := splitTableKey(tableNames[j])
+		if schemaI != schemaJ {
+			return schemaI < schemaJ
+		}
+		return nameI < nameJ
+	})
+	grouped := len(se.schema.Schemas) > 0
 	
 	// Generate table definitions
+	currentGroup := "\x00"
 	for _, tableName := range tableNames {
 		table := se.schema.Tables[tableName]
 		
-		mermaid.WriteString(fmt.Sprintf("  %s {\n", tableName))
+		schema, _ := splitTableKey(tableName)
+		if grouped && schema != currentGroup {
+			currentGroup = schema
+			if schema == "" {
+				schema = "default"
+			}
+			erd.WriteString(fmt.Sprintf("  %%%% schema: %s\n", schema))
+		}
+		
+		erd.WriteString(fmt.Sprintf("  %s {\n", entityHeader(mermaidEntity(tableName), tableName)))
 		
 		// Sort column names for consistent output
 		var columnNames []string
@@ -921,11 +1293,28 @@ func (se *StreamingSchemaExtractor) generateMermaidERD() string {
 			if len(annotations) > 0 {
 				annotationStr = " " + strings.Join(annotations, ",")
 			}
+			// One comment per attribute: how the value is produced, then its data class
+			var notes []string
+			if column.Identity != "" {
+				notes = append(notes, "identity")
+			} else if column.Generated != nil {
+				notes = append(notes, "generated")
+			}
+			if column.Classification != "" {
+				notes = append(notes, column.Classification)
+			}
+			if len(notes) > 0 {
+				annotationStr += ` "` + strings.Join(notes, ", ") + `"`
+			}
 			
-			mermaid.WriteString(fmt.Sprintf("    %s %s%s\n", column.Type, colName, annotationStr))
+			columnType := "unknown"
+			if strings.TrimSpace(column.Type) != "" {
+				columnType = mermaid.AttributeType(column.Type)
+			}
+			erd.WriteString(fmt.Sprintf("    %s %s%s\n", columnType, mermaid.ID(colName), annotationStr))
 		}
 		
-		mermaid.WriteString("  }\n")
+		erd.WriteString("  }\n")
 	}
 	
 	// Generate relationships
@@ -934,13 +1323,17 @@ func (se *StreamingSchemaExtractor) generateMermaidERD() string {
 		
 		for _, fk := range table.ForeignKeys {
 			if len(fk.Columns) == 1 && len(fk.RefColumns) == 1 {
-				mermaid.WriteString(fmt.Sprintf("  %s ||--o{ %s : \"%s -> %s.%s\"\n",
-					fk.RefTable, tableName, fk.Columns[0], fk.RefTable, fk.RefColumns[0]))
+				erd.WriteString(fmt.Sprintf("  %s ||--o{ %s : \"%s -> %s.%s\"\n",
+					mermaidEntity(fk.RefTable), mermaidEntity(tableName), mermaid.Message(fk.Columns[0]), mermaid.Message(fk.RefTable), mermaid.Message(fk.RefColumns[0])))
 			}
 		}
 	}
 	
-	return mermaid.String()
+	diagram, err := mermaid.Clean(erd.String())
+	if err != nil {
+		fmt.Printf("⚠️  ERD is not valid Mermaid: %v\n", err)
+	}
+	return diagram
 }
 
 // ExtractSchemaFromProjectResult contains the complete results of schema extraction
@@ -1003,8 +1396,19 @@ func ExtractSchemaFromProject(projectPath string, files map[string]string, callb
 	return finalSchema, finalMermaid, nil
 }
 
-// ExtractSchemaWithFinalMigration extracts schema and generates final migration SQL
-func ExtractSchemaWithFinalMigration(projectPath string, files map[string]string, callback func(StreamingResponse)) (*ExtractSchemaFromProjectResult, error) {
+// ExtractSchemaWithFinalMigration extracts schema and generates final migration SQL, asking client for implicit relationships
+func ExtractSchemaWithFinalMigration(projectPath string, files map[string]string, callback func(StreamingResponse), client *internalOpenai.Client) (*ExtractSchemaFromProjectResult, error) {
+	return ExtractSchemaWithFinalMigrationContext(context.Background(), projectPath, files, callback, client)
+}
+
+// ExtractSchemaWithFinalMigrationOffline extracts schema and final migration SQL without the LLM relationship pass
+func ExtractSchemaWithFinalMigrationOffline(projectPath string, files map[string]string, callback func(StreamingResponse)) (*ExtractSchemaFromProjectResult, error) {
+	return ExtractSchemaWithFinalMigrationContext(context.Background(), projectPath, files, callback, nil)
+}
+
+// ExtractSchemaWithFinalMigrationContext runs the extraction under ctx, asking client for implicit relationships.
+// The caller's client carries its model, language, transport and caches; a nil client skips the LLM pass.
+func ExtractSchemaWithFinalMigrationContext(ctx context.Context, projectPath string, files map[string]string, callback func(StreamingResponse), client *internalOpenai.Client) (*ExtractSchemaFromProjectResult, error) {
 	// Find migration files
 	migrations := findMigrationFiles(files)
 	if len(migrations) == 0 {
@@ -1029,6 +1433,11 @@ func ExtractSchemaWithFinalMigration(projectPath string, files map[string]string
 		}
 	})
 	
+	// Stop before the (slow) final generation and LLM steps if the caller gave up
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, fmt.Errorf("schema extraction cancelled: %v", ctxErr)
+	}
+	
 	// Generate final migration SQL regardless of any errors
 	if finalSchema != nil && len(finalSchema.Tables) > 0 {
 		finalMigrationSQL = extractor.GenerateFinalMigrationSQL()
@@ -1041,7 +1450,9 @@ func ExtractSchemaWithFinalMigration(projectPath string, files map[string]string
 		// Analyze implicit relationships with LLM
 		fmt.Printf("🔍 [DEBUG] Starting LLM relationship analysis phase\n")
 		var llmRelationships string
-		if finalMigrationSQL != "" {
+		if client == nil {
+			fmt.Printf("📴 [DEBUG] Offline mode - skipping LLM relationship analysis\n")
+		} else if finalMigrationSQL != "" {
 			fmt.Printf("✅ [DEBUG] Final migration SQL available for LLM analysis (%d chars)\n", len(finalMigrationSQL))
 			
 			callback(StreamingResponse{
@@ -1053,7 +1464,7 @@ func ExtractSchemaWithFinalMigration(projectPath string, files map[string]string
 			})
 			
 			fmt.Printf("🚀 [DEBUG] Calling analyzeImplicitRelationships...\n")
-			llmResult, err := analyzeImplicitRelationships(finalMigrationSQL)
+			llmResult, err := AnalyzeImplicitRelationships(ctx, client, finalMigrationSQL)
 			if err != nil {
 				fmt.Printf("❌ [DEBUG] LLM relationship analysis failed: %v\n", err)
 				fmt.Printf("❌ [DEBUG] Error type: %T\n", err)
@@ -1099,38 +1510,54 @@ func ExtractSchemaWithFinalMigration(projectPath string, files map[string]string
 	}, nil
 }
 
-// findMigrationFiles finds and sorts migration files from project files
+// IsMigrationFile reports whether schema extraction reads a file: a SQL file in a folder whose path
+// mentions "migration", or a Liquibase changelog
+func IsMigrationFile(filePath string) bool {
+	return isSQLMigration(filePath) || IsLiquibaseChangelog(filePath)
+}
+
+func isSQLMigration(filePath string) bool {
+	if !strings.HasSuffix(strings.ToLower(filePath), ".sql") {
+		return false
+	}
+	dir := filepath.Dir(portable.Slash(filePath))
+	return strings.Contains(strings.ToLower(dir), "migration")
+}
+
+// findMigrationFiles returns the up migrations among project files in the order they apply:
+// by folder, then by version (numerically, so 2_x runs before 10_y), then by name, with Flyway
+// repeatable scripts last. Down migrations (golang-migrate's .down.sql, Flyway's U files and the
+// down sections of goose, sql-migrate and dbmate files) are not replayed. Liquibase changelogs
+// follow, translated to SQL in the order their master changelog includes them.
 func findMigrationFiles(files map[string]string) []Migration {
-	var migrations []Migration
-	var migrationPaths []string
-	
-	// Find all SQL files in migration folders
-	for filePath := range files {
-		if strings.HasSuffix(strings.ToLower(filePath), ".sql") {
-			dir := filepath.Dir(filePath)
-			dirLower := strings.ToLower(dir)
-			
-			// Check if path contains "migration"
-			if strings.Contains(dirLower, "migration") {
-				migrationPaths = append(migrationPaths, filePath)
+	liquibase, consumed := liquibaseMigrations(files)
+	
+	var scripts []migrationScript
+	for filePath, content := range files {
+		if !isSQLMigration(filePath) || consumed[portable.Slash(filePath)] {
+			continue
+		}
+		script := parseMigrationScript(filePath, content)
+		if script.Direction != directionUp {
+			continue
+		}
+		if script.HasDown {
+			if loc := downSection.FindStringIndex(script.Content); loc != nil {
+				script.Content = script.Content[:loc[0]]
 			}
 		}
+		scripts = append(scripts, script)
 	}
+	sortMigrationScripts(scripts)
 	
-	// Sort migration files by name (assuming timestamp-based naming)
-	sort.Strings(migrationPaths)
-	
-	// Create migration objects
-	for _, path := range migrationPaths {
-		if content, exists := files[path]; exists {
-			migrations = append(migrations, Migration{
-				Name: filepath.Base(path),
-				SQL:  content,
-			})
-		}
+	migrations := make([]Migration, 0, len(scripts)+len(liquibase))
+	for _, script := range scripts {
+		migrations = append(migrations, Migration{
+			Name: script.Name,
+			SQL:  script.Content,
+		})
 	}
-	
-	return migrations
+	return append(migrations, liquibase...)
 }
 
 // ConvertToLegacySchema converts CanonicalSchema to legacy DatabaseSchema format
@@ -1147,11 +1574,12 @@ func ConvertToLegacySchema(canonical *CanonicalSchema, migrationPath string) *Da
 		columns := make(map[string]Column)
 		for colName, canonicalCol := range canonicalTable.Columns {
 			column := Column{
-				Name:         colName,
-				Type:         canonicalCol.Type,
-				Constraints:  []ColumnConstraint{},
-				DefaultValue: "",
-				References:   nil,
+				Name:           colName,
+				Type:           canonicalCol.Type,
+				Constraints:    []ColumnConstraint{},
+				DefaultValue:   "",
+				References:     nil,
+				Classification: canonicalCol.Classification,
 			}
 			
 			// Add constraints
@@ -1209,12 +1637,26 @@ func ConvertToLegacySchema(canonical *CanonicalSchema, migrationPath string) *Da
 			}
 		}
 		
+		// Convert triggers
+		var triggers []Trigger
+		for _, trigger := range canonicalTable.Triggers {
+			triggers = append(triggers, Trigger{
+				Name:     trigger.Name,
+				Timing:   trigger.Timing,
+				Events:   trigger.Events,
+				ForEach:  trigger.ForEach,
+				When:     trigger.When,
+				Function: trigger.Function,
+			})
+		}
+		
 		// Create legacy table
 		legacy.Tables[tableName] = Table{
 			Name:        tableName,
 			Columns:     columns,
 			PrimaryKeys: canonicalTable.PrimaryKey,
 			Indexes:     indexes,
+			Triggers:    triggers,
 		}
 		
 		// Add foreign keys to global list
@@ -1228,6 +1670,20 @@ func ConvertToLegacySchema(canonical *CanonicalSchema, migrationPath string) *Da
 		}
 	}
 	
+	// Convert functions, sorted for stable output
+	for name, function := range canonical.Functions {
+		legacy.Functions = append(legacy.Functions, Function{
+			Name:      name,
+			Kind:      function.Kind,
+			Arguments: function.Arguments,
+			Returns:   function.Returns,
+			Language:  function.Language,
+		})
+	}
+	sort.Slice(legacy.Functions, func(i, j int) bool {
+		return legacy.Functions[i].Name < legacy.Functions[j].Name
+	})
+	
 	return legacy
 }
 
@@ -1240,6 +1696,18 @@ func (se *StreamingSchemaExtractor) GenerateFinalMigrationSQL() string {
 	sql.WriteString("-- This file represents the final state after applying all migrations\n")
 	sql.WriteString("-- Run this single file to create the complete database schema\n\n")
 	
+	// Generate CREATE SCHEMA statements before anything is created inside them
+	if len(se.schema.Schemas) > 0 {
+		sql.WriteString("-- ============================================\n")
+		sql.WriteString("-- SCHEMAS\n")
+		sql.WriteString("-- ============================================\n\n")
+		
+		for _, schema := range se.schema.Schemas {
+			sql.WriteString(fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s;\n", schema))
+		}
+		sql.WriteString("\n")
+	}
+	
 	// Generate CREATE TYPE statements for enums
 	if len(se.schema.Enums) > 0 {
 		sql.WriteString("-- ============================================\n")
@@ -1295,6 +1763,9 @@ func (se *StreamingSchemaExtractor) GenerateFinalMigrationSQL() string {
 		}
 	}
 	
+	// Generate functions and the triggers that call them
+	sql.WriteString(se.generateRoutinesSQL())
+	
 	// Generate CREATE VIEW statements
 	if len(se.schema.Views) > 0 {
 		sql.WriteString("-- ============================================\n")
@@ -1317,7 +1788,23 @@ func (se *StreamingSchemaExtractor) GenerateFinalMigrationSQL() string {
 func (se *StreamingSchemaExtractor) generateCreateTableSQL(tableName string, table *CanonicalTable) string {
 	var sql strings.Builder
 	
-	sql.WriteString(fmt.Sprintf("CREATE TABLE %s (\n", tableName))
+	// Partitions inherit their columns; only their own constraints and bound are written
+	if table.PartitionOf != nil {
+		sql.WriteString(fmt.Sprintf("CREATE TABLE %s PARTITION OF %s", tableName, table.PartitionOf.Parent))
+		if constraints := se.tableConstraintDefs(table); len(constraints) > 0 {
+			sql.WriteString(" (\n" + strings.Join(constraints, ",\n") + "\n)")
+		}
+		if table.PartitionOf.Bound != "" {
+			sql.WriteString(" " + table.PartitionOf.Bound)
+		}
+		if table.Partition != nil {
+			sql.WriteString(fmt.Sprintf(" PARTITION BY %s (%s)", strings.ToUpper(table.Partition.Strategy), table.Partition.Key))
+		}
+		sql.WriteString(";\n")
+		return sql.String()
+	}
+	
+	sql.WriteString(fmt.Sprintf("CREATE TABLE %s (\n", sqlTableName(tableName)))
 	
 	// Get sorted column names for consistent output
 	var columnNames []string
@@ -1331,33 +1818,62 @@ func (se *StreamingSchemaExtractor) generateCreateTableSQL(tableName string, tab
 	// Generate column definitions
 	for _, colName := range columnNames {
 		column := table.Columns[colName]
-		colDef := fmt.Sprintf("    %s %s", colName, column.Type)
+		colDef := fmt.Sprintf("    %s %s", sqlName(colName), column.Type)
+		
+		// Add identity or generation clause
+		if column.Identity != "" {
+			colDef += fmt.Sprintf(" GENERATED %s AS IDENTITY", strings.ToUpper(column.Identity))
+			if column.IdentityOptions != "" {
+				colDef += fmt.Sprintf(" (%s)", column.IdentityOptions)
+			}
+		} else if column.Generated != nil {
+			kind := "VIRTUAL"
+			if column.Generated.Stored {
+				kind = "STORED"
+			}
+			colDef += fmt.Sprintf(" GENERATED ALWAYS AS (%s) %s", column.Generated.Expression, kind)
+		}
 		
 		// Add NOT NULL constraint
 		if !column.Nullable {
 			colDef += " NOT NULL"
 		}
 		
-		// Add DEFAULT value
-		if column.Default != nil {
+		// Add DEFAULT value (not allowed alongside identity or generation)
+		if column.Default != nil && column.Identity == "" && column.Generated == nil {
 			colDef += fmt.Sprintf(" DEFAULT %s", *column.Default)
 		}
 		
 		columnDefs = append(columnDefs, colDef)
 	}
 	
-	// Add table constraints
+	columnDefs = append(columnDefs, se.tableConstraintDefs(table)...)
+	
+	// Join all column definitions and constraints
+	sql.WriteString(strings.Join(columnDefs, ",\n"))
+	sql.WriteString("\n)")
+	if table.Partition != nil {
+		sql.WriteString(fmt.Sprintf(" PARTITION BY %s (%s)", strings.ToUpper(table.Partition.Strategy), table.Partition.Key))
+	}
+	sql.WriteString(";\n")
+	
+	return sql.String()
+}
+
+// tableConstraintDefs renders a table's primary key, unique, foreign key and check constraints
+func (se *StreamingSchemaExtractor) tableConstraintDefs(table *CanonicalTable) []string {
+	var columnDefs []string
 	
 	// Primary key constraint
 	if len(table.PrimaryKey) > 0 {
-		pkCols := strings.Join(table.PrimaryKey, ", ")
+		pkCols := sqlNames(table.PrimaryKey)
 		columnDefs = append(columnDefs, fmt.Sprintf("    PRIMARY KEY (%s)", pkCols))
 	}
 	
 	// Unique constraints
 	for _, uniqueCols := range table.Unique {
 		if len(uniqueCols) > 0 {
-			uniqueColsStr := strings.Join(uniqueCols, ", ")
+			uniqueColsStr := sqlNames(uniqueCols)
 			columnDefs = append(columnDefs, fmt.Sprintf("    UNIQUE (%s)", uniqueColsStr))
 		}
 	}
@@ -1365,14 +1881,14 @@ func (se *StreamingSchemaExtractor) generateCreateTableSQL(tableName string, tab
 	// Foreign key constraints
 	for _, fk := range table.ForeignKeys {
 		if len(fk.Columns) > 0 && len(fk.RefColumns) > 0 {
-			fkCols := strings.Join(fk.Columns, ", ")
-			refCols := strings.Join(fk.RefColumns, ", ")
+			fkCols := sqlNames(fk.Columns)
+			refCols := sqlNames(fk.RefColumns)
 			constraintName := ""
 			if fk.Name != nil {
-				constraintName = fmt.Sprintf("CONSTRAINT %s ", *fk.Name)
+				constraintName = fmt.Sprintf("CONSTRAINT %s ", sqlName(*fk.Name))
 			}
 			fkDef := fmt.Sprintf("    %sFOREIGN KEY (%s) REFERENCES %s (%s)", 
-				constraintName, fkCols, fk.RefTable, refCols)
+				constraintName, fkCols, sqlTableName(fk.RefTable), refCols)
 			
 			if fk.OnDelete != nil {
 				fkDef += fmt.Sprintf(" ON DELETE %s", *fk.OnDelete)
@@ -1385,16 +1901,21 @@ func (se *StreamingSchemaExtractor) generateCreateTableSQL(tableName string, tab
 		}
 	}
 	
-	// Join all column definitions and constraints
-	sql.WriteString(strings.Join(columnDefs, ",\n"))
-	sql.WriteString("\n);\n")
+	// Check constraints, column ones included, which read the same at table level
+	for _, check := range table.Checks {
+		constraintName := ""
+		if check.Name != nil {
+			constraintName = fmt.Sprintf("CONSTRAINT %s ", sqlName(*check.Name))
+		}
+		columnDefs = append(columnDefs, fmt.Sprintf("    %sCHECK (%s)", constraintName, check.Expression))
+	}
 	
-	return sql.String()
+	return columnDefs
 }
 
 // generateCreateIndexSQL generates CREATE INDEX statement
 func (se *StreamingSchemaExtractor) generateCreateIndexSQL(tableName string, index *CanonicalIndex) string {
-	indexCols := strings.Join(index.Columns, ", ")
+	indexCols := sqlNames(index.Columns)
 	uniqueStr := ""
 	if index.Unique {
 		uniqueStr = "UNIQUE "
@@ -1405,8 +1926,8 @@ func (se *StreamingSchemaExtractor) generateCreateIndexSQL(tableName string, ind
 		usingClause = fmt.Sprintf(" USING %s", *index.Using)
 	}
 	
-	return fmt.Sprintf("CREATE %sINDEX %s ON %s (%s)%s;", 
-		uniqueStr, index.Name, tableName, indexCols, usingClause)
+	return fmt.Sprintf("CREATE %sINDEX %s ON %s%s (%s);", 
+		uniqueStr, sqlName(index.Name), sqlTableName(tableName), usingClause, indexCols)
 }
 
 // sortTablesByDependencies sorts tables so that referenced tables come before referencing tables
@@ -1443,6 +1964,13 @@ func (se *StreamingSchemaExtractor) sortTablesByDependencies() []string {
 				}
 			}
 			
+			// Partitions can only be created after their parent
+			if table.PartitionOf != nil && !processed[table.PartitionOf.Parent] {
+				if _, known := se.schema.Tables[table.PartitionOf.Parent]; known {
+					canAdd = false
+				}
+			}
+			
 			if canAdd {
 				sorted = append(sorted, tableName)
 				processed[tableName] = true
@@ -1466,8 +1994,8 @@ func (se *StreamingSchemaExtractor) sortTablesByDependencies() []string {
 	return sorted
 }
 
-// analyzeImplicitRelationships uses LLM to analyze the final migration SQL and detect implicit relationships
-func analyzeImplicitRelationships(finalMigrationSQL string) (string, error) {
+// AnalyzeImplicitRelationships uses LLM to analyze the final migration SQL and detect implicit relationships
+func AnalyzeImplicitRelationships(ctx context.Context, client *internalOpenai.Client, finalMigrationSQL string) (string, error) {
 	fmt.Printf("🔍 [DEBUG] Starting analyzeImplicitRelationships function\n")
 	fmt.Printf("📊 [DEBUG] Final migration SQL length: %d characters\n", len(finalMigrationSQL))
 	
@@ -1510,9 +2038,7 @@ SQL Migration:
 	fmt.Printf("✅ [DEBUG] Prompt created successfully, total length: %d characters\n", len(prompt))
 	fmt.Printf("🚀 [DEBUG] Calling LLM API...\n")
 
-	// Call OpenAI API (we'll use the existing openai package)
-	// Note: We need to import and use the existing OpenAI client
-	result, err := callLLMForRelationshipAnalysis(prompt)
+	result, err := callLLMForRelationshipAnalysis(ctx, client, prompt)
 	
 	if err != nil {
 		fmt.Printf("❌ [DEBUG] LLM API call failed in analyzeImplicitRelationships: %v\n", err)
@@ -1525,57 +2051,24 @@ SQL Migration:
 	return result, nil
 }
 
-// callLLMForRelationshipAnalysis makes the actual LLM API call
-func callLLMForRelationshipAnalysis(prompt string) (string, error) {
+// callLLMForRelationshipAnalysis makes the actual LLM API call through the analysis's client
+func callLLMForRelationshipAnalysis(parent context.Context, client *internalOpenai.Client, prompt string) (string, error) {
 	fmt.Printf("🤖 [DEBUG] Starting LLM relationship analysis...\n")
 	fmt.Printf("📝 [DEBUG] Prompt length: %d characters\n", len(prompt))
 	fmt.Printf("📋 [DEBUG] First 200 chars of prompt: %s...\n", prompt[:minInt(200, len(prompt))])
 	
-	// Get OpenAI API key from environment variable (most reliable method)
-	apiKey := os.Getenv("OPENAI_API_KEY")
-	fmt.Printf("🔍 [DEBUG] Environment OPENAI_API_KEY exists: %t\n", apiKey != "")
-	
-	if apiKey == "" {
-		fmt.Printf("⚠️ [DEBUG] No API key in environment, trying config file...\n")
-		// Try loading from config file as fallback
-		cfg, err := config.LoadConfig("config.yaml")
-		if err != nil {
-			fmt.Printf("❌ [DEBUG] Config file load failed: %v\n", err)
-			return "", fmt.Errorf("OpenAI API key not found in environment variables and config file load failed: %v", err)
-		}
-		
-		if cfg.OpenAI.APIKey != "" {
-			apiKey = cfg.OpenAI.APIKey
-			fmt.Printf("✅ [DEBUG] Found API key in config file\n")
-		} else {
-			fmt.Printf("❌ [DEBUG] Config file has no OpenAI API key\n")
-			return "", fmt.Errorf("OpenAI API key not found in environment variables or config file")
-		}
-	} else {
-		fmt.Printf("✅ [DEBUG] Found API key in environment variable\n")
-	}
-	
-	if len(apiKey) < 10 {
-		fmt.Printf("❌ [DEBUG] API key too short: %d characters\n", len(apiKey))
-		return "", fmt.Errorf("invalid API key: too short (%d characters)", len(apiKey))
+	if client == nil {
+		return "", fmt.Errorf("no OpenAI client for relationship analysis")
 	}
 	
-	fmt.Printf("🔑 [DEBUG] Using OpenAI API key: %s...%s (length: %d)\n", apiKey[:minInt(8, len(apiKey))], apiKey[maxInt(0, len(apiKey)-8):], len(apiKey))
-	
-	// Create OpenAI client
-	fmt.Printf("🔧 [DEBUG] Creating OpenAI client...\n")
-	openaiCfg := openai.DefaultConfig(apiKey)
-	client := openai.NewClientWithConfig(openaiCfg)
-	fmt.Printf("✅ [DEBUG] OpenAI client created successfully\n")
-	
 	// Create context with timeout
 	fmt.Printf("⏱️ [DEBUG] Creating context with 60 second timeout...\n")
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	ctx, cancel := context.WithTimeout(parent, 60*time.Second)
 	defer cancel()
 	
 	// Prepare request
 	request := openai.ChatCompletionRequest{
-		Model:       "gpt-3.5-turbo", // Use reliable default model
+		Model:       client.Model(),
 		Temperature: 0.1, // Low temperature for consistent structural output
 		MaxTokens:   2000, // Sufficient for Mermaid diagrams
 		Messages: []openai.ChatCompletionMessage{
@@ -1622,35 +2115,14 @@ func callLLMForRelationshipAnalysis(prompt string) (string, error) {
 	fmt.Printf("📝 [DEBUG] Raw response length: %d characters\n", len(mermaidResponse))
 	fmt.Printf("📝 [DEBUG] First 500 chars of response: %s\n", mermaidResponse[:minInt(500, len(mermaidResponse))])
 	
-	// Handle markdown code blocks if present
-	if strings.HasPrefix(mermaidResponse, "```mermaid") {
-		fmt.Printf("🔧 [DEBUG] Detected markdown code block, extracting content...\n")
-		// Extract content between ```mermaid and ```
-		lines := strings.Split(mermaidResponse, "\n")
-		var extractedLines []string
-		inCodeBlock := false
-		for _, line := range lines {
-			if strings.HasPrefix(line, "```mermaid") {
-				inCodeBlock = true
-				continue
-			}
-			if strings.HasPrefix(line, "```") && inCodeBlock {
-				break
-			}
-			if inCodeBlock {
-				extractedLines = append(extractedLines, line)
-			}
-		}
-		mermaidResponse = strings.TrimSpace(strings.Join(extractedLines, "\n"))
-		fmt.Printf("📝 [DEBUG] Extracted from code block, new length: %d characters\n", len(mermaidResponse))
-		fmt.Printf("📝 [DEBUG] Extracted content: %s\n", mermaidResponse[:minInt(200, len(mermaidResponse))])
+	// Strip Markdown fences and literal \n escapes, then reject anything Mermaid could not render
+	mermaidResponse, err = mermaid.Clean(mermaidResponse)
+	if err == nil && !strings.HasPrefix(mermaidResponse, "erDiagram") {
+		err = fmt.Errorf("expected an erDiagram")
 	}
-	
-	// Validate that response starts with erDiagram
-	if !strings.HasPrefix(mermaidResponse, "erDiagram") {
-		fmt.Printf("❌ [DEBUG] Response doesn't start with 'erDiagram'\n")
-		fmt.Printf("❌ [DEBUG] Response starts with: %s\n", mermaidResponse[:minInt(50, len(mermaidResponse))])
-		return "", fmt.Errorf("invalid Mermaid response: doesn't start with 'erDiagram', got: %s", mermaidResponse[:minInt(100, len(mermaidResponse))])
+	if err != nil {
+		fmt.Printf("❌ [DEBUG] Invalid Mermaid response: %v\n", err)
+		return "", fmt.Errorf("invalid Mermaid response: %v, got: %s", err, mermaidResponse[:minInt(100, len(mermaidResponse))])
 	}
 	
 	fmt.Printf("✅ [DEBUG] Response validation passed\n")
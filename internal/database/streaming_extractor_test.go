@@ -0,0 +1,67 @@
+package database
+
+import (
+	"strings"
+	"testing"
+)
+
+// windowsMigrations is a golang-migrate and goose project as checked out on Windows: backslash
+// paths and CRLF line endings
+var windowsMigrations = map[string]string{
+	`db\migrations\1_users.up.sql`:   "CREATE TABLE users (\r\n  id SERIAL PRIMARY KEY,\r\n  email TEXT NOT NULL\r\n);\r\n",
+	`db\migrations\1_users.down.sql`: "DROP TABLE users;\r\n",
+	`db\migrations\2_orders.sql`:     "-- +goose Up\r\nCREATE TABLE orders (\r\n  id SERIAL PRIMARY KEY,\r\n  user_id INT REFERENCES users(id)\r\n);\r\n-- +goose Down\r\nDROP TABLE orders;\r\n",
+	`db\migrations\10_items.sql`:     "CREATE TABLE items (\r\n  id SERIAL PRIMARY KEY,\r\n  order_id INT\r\n);\r\n",
+	`src\main.go`:                    "package main\r\n",
+}
+
+func TestIsMigrationFileWindowsPaths(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{`db\migrations\1_users.up.sql`, true},
+		{`C:\work\repo\Migrations\V1__init.SQL`, true},
+		{`db\schema\1_users.sql`, false},
+		{`db\migrations\README.md`, false},
+		{`src\main\resources\db\changelog\db.changelog-master.yaml`, true},
+	}
+	for _, tt := range tests {
+		if got := IsMigrationFile(tt.path); got != tt.want {
+			t.Errorf("IsMigrationFile(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestFindMigrationFilesWindows(t *testing.T) {
+	migrations := findMigrationFiles(windowsMigrations)
+
+	var names []string
+	for _, migration := range migrations {
+		names = append(names, migration.Name)
+		if strings.Contains(migration.SQL, "\r") {
+			t.Errorf("%s still has carriage returns: %q", migration.Name, migration.SQL)
+		}
+		if strings.Contains(migration.SQL, "DROP TABLE") {
+			t.Errorf("%s kept its down section: %q", migration.Name, migration.SQL)
+		}
+	}
+	if got, want := strings.Join(names, ","), "1_users.up.sql,2_orders.sql,10_items.sql"; got != want {
+		t.Errorf("migration order = %s, want %s", got, want)
+	}
+}
+
+func TestExtractSchemaWindows(t *testing.T) {
+	result, err := ExtractSchemaWithFinalMigrationOffline("", windowsMigrations, func(StreamingResponse) {})
+	if err != nil {
+		t.Fatalf("ExtractSchemaWithFinalMigrationOffline() error = %v", err)
+	}
+	for _, table := range []string{"users", "orders", "items"} {
+		if _, ok := result.Schema.Tables[table]; !ok {
+			t.Errorf("table %s missing from the schema", table)
+		}
+	}
+	if strings.Contains(result.FinalMigrationSQL, "\r") {
+		t.Error("final migration contains carriage returns")
+	}
+}
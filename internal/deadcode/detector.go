@@ -0,0 +1,120 @@
+package deadcode
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"repo-explanation/internal/database"
+	"repo-explanation/internal/filestore"
+)
+
+// Finding is one likely-dead area of the codebase
+type Finding struct {
+	Name       string  `json:"name"` // Go import path, JS file or table name
+	Path       string  `json:"path"` // Location relative to the project root
+	Reason     string  `json:"reason"`
+	Confidence float64 `json:"confidence"` // 0.0 to 1.0
+}
+
+// Report lists the packages, files and tables nothing appears to use
+type Report struct {
+	GoPackages   []Finding `json:"go_packages,omitempty"`
+	JSFiles      []Finding `json:"js_files,omitempty"`
+	UnusedTables []Finding `json:"unused_tables,omitempty"`
+	Total        int       `json:"total"`
+	Summary      string    `json:"summary"`
+}
+
+// Directories that never hold first-party code worth judging
+var skippedDirs = map[string]bool{
+	".git": true, "node_modules": true, "vendor": true, "testdata": true,
+	"dist": true, "build": true, ".next": true, "coverage": true, "__pycache__": true,
+}
+
+// Detector finds code that no entrypoint reaches
+type Detector struct {
+	project *filestore.FileStore
+	files   map[string]string // Relative slash path -> content
+}
+
+// NewDetector creates a dead code detector for the project's files
+func NewDetector(project *filestore.FileStore) *Detector {
+	return &Detector{project: project}
+}
+
+// Detect reads the project and reports unreachable Go packages, unreachable JS files and
+// tables (from the extracted schema, which may be nil) that no code references.
+func (d *Detector) Detect(schema *database.DatabaseSchema) (*Report, error) {
+	fmt.Printf("🪦 [DEBUG] Starting dead code detection of %d files\n", d.project.Len())
+
+	d.loadFiles()
+
+	report := &Report{
+		GoPackages:   d.findDeadGoPackages(),
+		JSFiles:      d.findDeadJSFiles(),
+		UnusedTables: d.findUnusedTables(schema),
+	}
+	report.Total = len(report.GoPackages) + len(report.JSFiles) + len(report.UnusedTables)
+	report.Summary = fmt.Sprintf("%d unreachable Go packages, %d unreachable JS/TS files, %d unreferenced tables",
+		len(report.GoPackages), len(report.JSFiles), len(report.UnusedTables))
+
+	fmt.Printf("✅ [DEBUG] Dead code detection complete: %s\n", report.Summary)
+	return report, nil
+}
+
+// loadFiles reads the manifests and source files the detector needs. It does not use the crawled
+// files because the crawler's extension filter drops go.mod, .tsx and .jsx files.
+func (d *Detector) loadFiles() {
+	d.files = d.project.Contents(func(relPath string) bool {
+		return !filestore.InDirs(relPath, skippedDirs) && isRelevantFile(path.Base(relPath))
+	})
+}
+
+// isRelevantFile reports whether the detector needs a file's content
+func isRelevantFile(name string) bool {
+	switch name {
+	case "go.mod", "package.json", "tsconfig.json", "jsconfig.json", "index.html":
+		return true
+	}
+	return isCodeFile(name)
+}
+
+// isCodeFile reports whether a file is source code that may reference tables
+func isCodeFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".go", ".py", ".java", ".kt", ".rb", ".php", ".cs", ".rs", ".scala":
+		return true
+	}
+	return isJSFile(name)
+}
+
+// sortedPaths returns the loaded file paths in sorted order so findings are deterministic
+func (d *Detector) sortedPaths() []string {
+	paths := make([]string, 0, len(d.files))
+	for path := range d.files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// dirOf returns the slash directory of a relative path, "" for the project root
+func dirOf(path string) string {
+	dir := filepath.ToSlash(filepath.Dir(path))
+	if dir == "." {
+		return ""
+	}
+	return dir
+}
+
+// joinPath joins slash path elements, treating "" as the project root
+func joinPath(elem ...string) string {
+	joined := filepath.ToSlash(filepath.Join(elem...))
+	if joined == "." {
+		return ""
+	}
+	return joined
+}
@@ -0,0 +1,157 @@
+package deadcode
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var goModuleRegex = regexp.MustCompile(`(?m)^module\s+(\S+)`)
+
+// goPackage is one Go package directory
+type goPackage struct {
+	importPath string
+	dir        string
+	module     string
+	isMain     bool
+	isTools    bool // tools.go pattern: only exists to pin tool dependencies
+	imports    map[string]bool
+}
+
+// findDeadGoPackages flags packages that no main package reaches through its imports.
+// Only modules with a main package, or modules a main package reaches, are judged:
+// every package of a pure library module may be imported from outside the repository.
+func (d *Detector) findDeadGoPackages() []Finding {
+	modules := d.goModules()
+	if len(modules) == 0 {
+		return nil
+	}
+
+	packages := make(map[string]*goPackage)
+	fset := token.NewFileSet()
+
+	for _, path := range d.sortedPaths() {
+		if !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		dir := dirOf(path)
+		modDir, modPath, ok := owningModule(dir, modules)
+		if !ok {
+			continue
+		}
+
+		file, err := parser.ParseFile(fset, path, d.files[path], parser.ImportsOnly|parser.ParseComments)
+		if err != nil {
+			continue
+		}
+
+		importPath := modPath
+		if rel := strings.TrimPrefix(strings.TrimPrefix(dir, modDir), "/"); rel != "" {
+			importPath = modPath + "/" + rel
+		}
+
+		pkg, exists := packages[importPath]
+		if !exists {
+			pkg = &goPackage{importPath: importPath, dir: dir, module: modPath, imports: make(map[string]bool)}
+			packages[importPath] = pkg
+		}
+		if file.Name.Name == "main" {
+			pkg.isMain = true
+		}
+		if strings.Contains(d.files[path], "//go:build tools") || strings.Contains(d.files[path], "// +build tools") {
+			pkg.isTools = true
+		}
+		for _, imp := range file.Imports {
+			if value, err := strconv.Unquote(imp.Path.Value); err == nil {
+				pkg.imports[value] = true
+			}
+		}
+	}
+
+	// Walk the import graph from every main package
+	reachable := make(map[string]bool)
+	var queue []string
+	for importPath, pkg := range packages {
+		if pkg.isMain || pkg.isTools {
+			reachable[importPath] = true
+			queue = append(queue, importPath)
+		}
+	}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for imp := range packages[current].imports {
+			if _, local := packages[imp]; local && !reachable[imp] {
+				reachable[imp] = true
+				queue = append(queue, imp)
+			}
+		}
+	}
+
+	judgedModules := make(map[string]bool)
+	for importPath := range reachable {
+		judgedModules[packages[importPath].module] = true
+	}
+
+	var findings []Finding
+	for importPath, pkg := range packages {
+		if reachable[importPath] || !judgedModules[pkg.module] {
+			continue
+		}
+		reason := "Not imported by any main package"
+		if importers := importersOf(importPath, packages); len(importers) > 0 {
+			reason = fmt.Sprintf("Only imported by unreachable packages (%s)", strings.Join(importers, ", "))
+		}
+		findings = append(findings, Finding{
+			Name:       importPath,
+			Path:       pkg.dir,
+			Reason:     reason,
+			Confidence: 0.8,
+		})
+	}
+
+	sort.Slice(findings, func(i, j int) bool { return findings[i].Name < findings[j].Name })
+	return findings
+}
+
+// goModules maps each go.mod directory to its module path
+func (d *Detector) goModules() map[string]string {
+	modules := make(map[string]string)
+	for path, content := range d.files {
+		if !strings.HasSuffix("/"+path, "/go.mod") {
+			continue
+		}
+		if match := goModuleRegex.FindStringSubmatch(content); match != nil {
+			modules[dirOf(path)] = match[1]
+		}
+	}
+	return modules
+}
+
+// owningModule returns the nearest module containing dir
+func owningModule(dir string, modules map[string]string) (string, string, bool) {
+	for current := dir; ; current = dirOf(current) {
+		if modPath, ok := modules[current]; ok {
+			return current, modPath, true
+		}
+		if current == "" {
+			return "", "", false
+		}
+	}
+}
+
+// importersOf lists the local packages importing importPath
+func importersOf(importPath string, packages map[string]*goPackage) []string {
+	var importers []string
+	for path, pkg := range packages {
+		if pkg.imports[importPath] {
+			importers = append(importers, path)
+		}
+	}
+	sort.Strings(importers)
+	return importers
+}
@@ -0,0 +1,366 @@
+package deadcode
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	jsImportRegex    = regexp.MustCompile(`(?:import|export)\s[^'"]*?from\s*['"]([^'"]+)['"]|import\s*\(?\s*['"]([^'"]+)['"]|require\s*\(\s*['"]([^'"]+)['"]\s*\)`)
+	scriptFileRegex  = regexp.MustCompile(`[\w./-]+\.(?:[mc]?[jt]sx?)\b`)
+	htmlScriptRegex  = regexp.MustCompile(`<script[^>]+src=["']([^"']+)["']`)
+	jsonCommentRegex = regexp.MustCompile(`(?m)^\s*//.*$`)
+	// Bundler alias entries: "@": path.resolve(__dirname, "./src") or '@': fileURLToPath(new URL('./src', import.meta.url))
+	bundlerAliasRegex = regexp.MustCompile(`["']?([@~#][\w/-]*)["']?\s*:\s*(?:path\.(?:resolve|join)\(\s*__dirname\s*,|fileURLToPath\(\s*new URL\()\s*["']([^"']+)["']`)
+	jsExtensions      = []string{".ts", ".tsx", ".js", ".jsx", ".mjs", ".cjs"}
+	defaultEntryNames = []string{"index", "main", "server", "app", "src/index", "src/main", "src/server", "src/app"}
+)
+
+// jsPackage is a directory with a package.json and the JS/TS files under it
+type jsPackage struct {
+	dir      string
+	manifest map[string]interface{}
+	deps     map[string]bool
+	aliases  map[string][]string // tsconfig path alias prefix -> target directory prefixes
+	baseURL  string
+	files    []string
+}
+
+// findDeadJSFiles flags JS/TS files that no package.json entrypoint, script, framework
+// convention or test reaches through relative or aliased imports
+func (d *Detector) findDeadJSFiles() []Finding {
+	packages := d.jsPackages()
+	if len(packages) == 0 {
+		return nil
+	}
+
+	var findings []Finding
+	for _, pkg := range packages {
+		roots := d.jsRoots(pkg)
+		if len(roots) == 0 {
+			continue // Nothing to start from, so reachability means nothing
+		}
+
+		reachable := make(map[string]bool)
+		queue := append([]string{}, roots...)
+		for _, root := range roots {
+			reachable[root] = true
+		}
+		unresolvedAliases := false
+
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+			for _, spec := range jsImports(d.files[current]) {
+				target, local := d.resolveJSImport(pkg, current, spec)
+				if target == "" {
+					if local {
+						unresolvedAliases = true
+					}
+					continue
+				}
+				if !reachable[target] {
+					reachable[target] = true
+					queue = append(queue, target)
+				}
+			}
+		}
+
+		// Unresolved alias imports may hide real usages, so confidence drops
+		confidence := 0.7
+		if unresolvedAliases {
+			confidence = 0.4
+		}
+		for _, file := range pkg.files {
+			if reachable[file] || isJSSupportFile(file) {
+				continue
+			}
+			findings = append(findings, Finding{
+				Name:       file,
+				Path:       file,
+				Reason:     "Not reachable from any entrypoint in " + joinPath(pkg.dir, "package.json"),
+				Confidence: confidence,
+			})
+		}
+	}
+
+	sort.Slice(findings, func(i, j int) bool { return findings[i].Name < findings[j].Name })
+	return findings
+}
+
+// jsPackages groups JS/TS files by their nearest package.json
+func (d *Detector) jsPackages() []*jsPackage {
+	packages := make(map[string]*jsPackage)
+	for path, content := range d.files {
+		if !strings.HasSuffix("/"+path, "/package.json") {
+			continue
+		}
+		var manifest map[string]interface{}
+		if err := json.Unmarshal([]byte(content), &manifest); err != nil {
+			continue
+		}
+		pkg := &jsPackage{dir: dirOf(path), manifest: manifest, deps: make(map[string]bool), aliases: make(map[string][]string)}
+		for _, key := range []string{"dependencies", "devDependencies", "peerDependencies"} {
+			if deps, ok := manifest[key].(map[string]interface{}); ok {
+				for dep := range deps {
+					pkg.deps[dep] = true
+				}
+			}
+		}
+		d.loadAliases(pkg)
+		packages[pkg.dir] = pkg
+	}
+
+	for _, path := range d.sortedPaths() {
+		if !isJSFile(path) {
+			continue
+		}
+		for dir := dirOf(path); ; dir = dirOf(dir) {
+			if pkg, ok := packages[dir]; ok {
+				pkg.files = append(pkg.files, path)
+				break
+			}
+			if dir == "" {
+				break
+			}
+		}
+	}
+
+	dirs := make([]string, 0, len(packages))
+	for dir := range packages {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	result := make([]*jsPackage, 0, len(dirs))
+	for _, dir := range dirs {
+		result = append(result, packages[dir])
+	}
+	return result
+}
+
+// loadAliases reads import aliases from tsconfig.json/jsconfig.json paths and from Vite or webpack configs
+func (d *Detector) loadAliases(pkg *jsPackage) {
+	for _, name := range []string{"vite.config.js", "vite.config.ts", "vite.config.mjs", "webpack.config.js"} {
+		for _, match := range bundlerAliasRegex.FindAllStringSubmatch(d.files[joinPath(pkg.dir, name)], -1) {
+			prefix := strings.TrimSuffix(match[1], "/") + "/"
+			pkg.aliases[prefix] = append(pkg.aliases[prefix], joinPath(pkg.dir, match[2]))
+		}
+	}
+
+	for _, name := range []string{"tsconfig.json", "jsconfig.json"} {
+		content, ok := d.files[joinPath(pkg.dir, name)]
+		if !ok {
+			continue
+		}
+		var tsconfig struct {
+			CompilerOptions struct {
+				BaseURL string              `json:"baseUrl"`
+				Paths   map[string][]string `json:"paths"`
+			} `json:"compilerOptions"`
+		}
+		if err := json.Unmarshal([]byte(jsonCommentRegex.ReplaceAllString(content, "")), &tsconfig); err != nil {
+			continue
+		}
+		pkg.baseURL = joinPath(pkg.dir, tsconfig.CompilerOptions.BaseURL)
+		for alias, targets := range tsconfig.CompilerOptions.Paths {
+			prefix := strings.TrimSuffix(alias, "*")
+			for _, target := range targets {
+				pkg.aliases[prefix] = append(pkg.aliases[prefix], joinPath(pkg.baseURL, strings.TrimSuffix(target, "*")))
+			}
+		}
+		return
+	}
+}
+
+// jsRoots collects the package's entrypoints: manifest fields, files named in scripts,
+// index.html script tags, framework routing conventions, default entry names and support files
+func (d *Detector) jsRoots(pkg *jsPackage) []string {
+	var candidates []string
+
+	for _, key := range []string{"main", "module", "browser", "source"} {
+		if value, ok := pkg.manifest[key].(string); ok {
+			candidates = append(candidates, value)
+		}
+	}
+	candidates = append(candidates, manifestStrings(pkg.manifest["bin"])...)
+	candidates = append(candidates, manifestStrings(pkg.manifest["exports"])...)
+	for _, script := range manifestStrings(pkg.manifest["scripts"]) {
+		candidates = append(candidates, scriptFileRegex.FindAllString(script, -1)...)
+	}
+	for _, html := range []string{"index.html", "public/index.html"} {
+		for _, match := range htmlScriptRegex.FindAllStringSubmatch(d.files[joinPath(pkg.dir, html)], -1) {
+			candidates = append(candidates, strings.TrimPrefix(match[1], "/"))
+		}
+	}
+	candidates = append(candidates, defaultEntryNames...)
+
+	seen := make(map[string]bool)
+	var roots []string
+	add := func(file string) {
+		if file != "" && !seen[file] {
+			seen[file] = true
+			roots = append(roots, file)
+		}
+	}
+
+	for _, candidate := range candidates {
+		add(d.resolveJSFile(joinPath(pkg.dir, candidate)))
+		// Build outputs (dist/index.js) usually mirror a source entry
+		base := strings.TrimSuffix(candidate, filepath.Ext(candidate))
+		for _, outDir := range []string{"dist/", "build/", "lib/"} {
+			if strings.HasPrefix(base, outDir) {
+				add(d.resolveJSFile(joinPath(pkg.dir, "src", strings.TrimPrefix(base, outDir))))
+			}
+		}
+	}
+
+	for _, file := range pkg.files {
+		if isJSSupportFile(file) || isFrameworkRoute(strings.TrimPrefix(file, pkg.dir+"/"), pkg.deps) {
+			add(file)
+		}
+	}
+
+	return roots
+}
+
+// resolveJSImport resolves an import specifier to a loaded file.
+// local reports an aliased first-party specifier that did not resolve, which may hide a usage.
+func (d *Detector) resolveJSImport(pkg *jsPackage, from, spec string) (string, bool) {
+	if strings.HasPrefix(spec, ".") {
+		// An unresolved relative import is an asset or a missing file, neither hides a usage
+		return d.resolveJSFile(joinPath(dirOf(from), spec)), false
+	}
+
+	for prefix, targets := range pkg.aliases {
+		if prefix != "" && strings.HasPrefix(spec, prefix) {
+			for _, target := range targets {
+				if file := d.resolveJSFile(joinPath(target, strings.TrimPrefix(spec, prefix))); file != "" {
+					return file, true
+				}
+			}
+		}
+	}
+	if pkg.baseURL != "" || len(pkg.aliases) > 0 {
+		if file := d.resolveJSFile(joinPath(pkg.baseURL, spec)); file != "" {
+			return file, true
+		}
+	}
+
+	looksLocal := strings.HasPrefix(spec, "@/") || strings.HasPrefix(spec, "~/") || strings.HasPrefix(spec, "#")
+	return "", looksLocal
+}
+
+// resolveJSFile applies Node/TypeScript resolution: exact file, added extension, then directory index
+func (d *Detector) resolveJSFile(path string) string {
+	if _, ok := d.files[path]; ok && isJSFile(path) {
+		return path
+	}
+	// TypeScript allows importing "./x.js" for x.ts
+	trimmed := strings.TrimSuffix(path, filepath.Ext(path))
+	for _, base := range []string{path, trimmed} {
+		for _, ext := range jsExtensions {
+			if _, ok := d.files[base+ext]; ok {
+				return base + ext
+			}
+		}
+	}
+	for _, ext := range jsExtensions {
+		index := joinPath(path, "index"+ext)
+		if _, ok := d.files[index]; ok {
+			return index
+		}
+	}
+	return ""
+}
+
+// jsImports extracts every import, export-from, dynamic import and require specifier
+func jsImports(content string) []string {
+	var specs []string
+	for _, match := range jsImportRegex.FindAllStringSubmatch(content, -1) {
+		for _, group := range match[1:] {
+			if group != "" {
+				specs = append(specs, group)
+				break
+			}
+		}
+	}
+	return specs
+}
+
+// manifestStrings flattens a package.json value (string, map or nested exports) into its strings
+func manifestStrings(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case map[string]interface{}:
+		var out []string
+		for _, nested := range v {
+			out = append(out, manifestStrings(nested)...)
+		}
+		sort.Strings(out)
+		return out
+	case []interface{}:
+		var out []string
+		for _, nested := range v {
+			out = append(out, manifestStrings(nested)...)
+		}
+		return out
+	}
+	return nil
+}
+
+// isJSFile reports whether a file is JavaScript or TypeScript source
+func isJSFile(path string) bool {
+	if strings.HasSuffix(path, ".d.ts") {
+		return false
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, jsExt := range jsExtensions {
+		if ext == jsExt {
+			return true
+		}
+	}
+	return false
+}
+
+// isJSSupportFile reports files loaded by tooling rather than imported: tests, stories, configs and mocks
+func isJSSupportFile(path string) bool {
+	name := strings.ToLower(filepath.Base(path))
+	for _, marker := range []string{".test.", ".spec.", ".stories.", ".config.", "setuptests.", ".eslintrc", "babel.config", "jest.setup"} {
+		if strings.Contains(name, marker) {
+			return true
+		}
+	}
+	for _, dir := range []string{"__tests__/", "__mocks__/", ".storybook/", "e2e/", "cypress/", "test/", "tests/", "scripts/"} {
+		if strings.HasPrefix(path, dir) || strings.Contains(path, "/"+dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// isFrameworkRoute reports files a framework loads by convention (Next.js, Remix, SvelteKit-style routes)
+func isFrameworkRoute(relPath string, deps map[string]bool) bool {
+	if deps["next"] {
+		for _, dir := range []string{"pages/", "app/", "src/pages/", "src/app/"} {
+			if strings.HasPrefix(relPath, dir) {
+				return true
+			}
+		}
+		base := strings.TrimSuffix(relPath, filepath.Ext(relPath))
+		if base == "middleware" || base == "src/middleware" || base == "instrumentation" {
+			return true
+		}
+	}
+	if deps["@remix-run/react"] && strings.HasPrefix(relPath, "app/routes/") {
+		return true
+	}
+	if deps["nuxt"] && (strings.HasPrefix(relPath, "pages/") || strings.HasPrefix(relPath, "server/")) {
+		return true
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+package deadcode
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"repo-explanation/internal/database"
+)
+
+// Migration bookkeeping tables are owned by the migration tool, not the application
+var bookkeepingTables = map[string]bool{
+	"schema_migrations":     true,
+	"goose_db_version":      true,
+	"flyway_schema_history": true,
+	"migrations":            true,
+	"knex_migrations":       true,
+	"knex_migrations_lock":  true,
+	"__efmigrationshistory": true,
+	"ar_internal_metadata":  true,
+}
+
+// findUnusedTables flags schema tables whose name (or ORM model name) appears in no source file outside migrations
+func (d *Detector) findUnusedTables(schema *database.DatabaseSchema) []Finding {
+	if schema == nil || len(schema.Tables) == 0 {
+		return nil
+	}
+
+	var sources []string
+	for _, path := range d.sortedPaths() {
+		if isCodeFile(path) && !strings.Contains(strings.ToLower(path), "migration") {
+			sources = append(sources, d.files[path])
+		}
+	}
+	if len(sources) == 0 {
+		return nil // Schema-only repository; nothing to judge against
+	}
+
+	var findings []Finding
+	for name := range schema.Tables {
+		table := strings.ToLower(name)
+		if idx := strings.LastIndex(table, "."); idx >= 0 {
+			table = table[idx+1:] // Drop the schema qualifier
+		}
+		if bookkeepingTables[table] {
+			continue
+		}
+
+		patterns := tableReferencePatterns(table)
+		referenced := false
+		for _, content := range sources {
+			for _, pattern := range patterns {
+				if pattern.MatchString(content) {
+					referenced = true
+					break
+				}
+			}
+			if referenced {
+				break
+			}
+		}
+
+		if !referenced {
+			findings = append(findings, Finding{
+				Name:       name,
+				Path:       schema.MigrationPath,
+				Reason:     "Defined in migrations but never referenced by table or model name in code",
+				Confidence: 0.6,
+			})
+		}
+	}
+
+	sort.Slice(findings, func(i, j int) bool { return findings[i].Name < findings[j].Name })
+	return findings
+}
+
+// tableReferencePatterns matches a table by its SQL name and by the model names ORMs derive from it
+// (order_items -> OrderItem, OrderItems, orderItems)
+func tableReferencePatterns(table string) []*regexp.Regexp {
+	singular := singularize(table)
+	forms := map[string]bool{table: true, singular: true}
+
+	var patterns []*regexp.Regexp
+	for form := range forms {
+		patterns = append(patterns, regexp.MustCompile(`(?i)\b`+regexp.QuoteMeta(form)+`\b`))
+	}
+	// Model names are matched case-sensitively so "Order" does not match the SQL keyword "order"
+	for _, form := range []string{camelCase(singular), camelCase(table)} {
+		if form != "" {
+			patterns = append(patterns, regexp.MustCompile(`\b`+regexp.QuoteMeta(form)+`\b`))
+		}
+	}
+	return patterns
+}
+
+// singularize strips common English plural suffixes from the last word of a table name
+func singularize(table string) string {
+	switch {
+	case strings.HasSuffix(table, "ies"):
+		return strings.TrimSuffix(table, "ies") + "y"
+	case strings.HasSuffix(table, "sses"), strings.HasSuffix(table, "xes"), strings.HasSuffix(table, "ches"), strings.HasSuffix(table, "shes"):
+		return strings.TrimSuffix(table, "es")
+	case strings.HasSuffix(table, "s") && !strings.HasSuffix(table, "ss"):
+		return strings.TrimSuffix(table, "s")
+	}
+	return table
+}
+
+// camelCase converts snake_case to PascalCase
+func camelCase(name string) string {
+	var out strings.Builder
+	for _, part := range strings.Split(name, "_") {
+		if part == "" {
+			continue
+		}
+		out.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return out.String()
+}
@@ -0,0 +1,316 @@
+// Package dependents answers "who depends on this?" for a service, an internal package or a
+// database table, from the evidence an analysis already collected: service relationships (network
+// calls, configuration, shared queues), the package import graph, foreign keys and the services
+// querying each table.
+package dependents
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"repo-explanation/internal/pipeline"
+)
+
+// Kinds of target and dependent
+const (
+	Service = "service"
+	Package = "package"
+	Table   = "table"
+)
+
+// Dependent is one thing depending on the target
+type Dependent struct {
+	Name       string   `json:"name"`
+	Kind       string   `json:"kind"`                 // service, package or table
+	Via        []string `json:"via"`                  // How it depends: http, import, foreign key, query, ...
+	Evidence   []string `json:"evidence"`             // Where it was seen: file, and what was found there
+	Confidence float64  `json:"confidence,omitempty"` // Service relationships only, 0-1
+}
+
+// Report lists the dependents of one target
+type Report struct {
+	Target     string      `json:"target"`
+	Kind       string      `json:"kind"`
+	Dependents []Dependent `json:"dependents"`
+	Summary    string      `json:"summary"`
+}
+
+// kindOrder lists services first, then packages and tables
+var kindOrder = []string{Service, Package, Table}
+
+// maxEvidence bounds the evidence listed per dependent
+const maxEvidence = 5
+
+// Find returns the dependents of every service, package and table named query, one report per
+// match. A "service:", "package:" or "table:" prefix restricts the lookup to that kind.
+func Find(result *pipeline.AnalysisResult, query string) ([]Report, error) {
+	if result == nil {
+		return nil, fmt.Errorf("no analysis result")
+	}
+	kind, name := "", strings.TrimSpace(query)
+	if i := strings.Index(name, ":"); i > 0 {
+		switch prefix := strings.ToLower(name[:i]); prefix {
+		case Service, Package, Table:
+			kind, name = prefix, strings.TrimSpace(name[i+1:])
+		}
+	}
+	if name == "" {
+		return nil, fmt.Errorf("no service, package or table to look up")
+	}
+
+	var reports []Report
+	if kind == "" || kind == Service {
+		for _, service := range result.Services {
+			if strings.EqualFold(service.Name, name) {
+				reports = append(reports, serviceDependents(result, service.Name))
+			}
+		}
+	}
+	if (kind == "" || kind == Package) && result.PackageGraph != nil {
+		for _, pkg := range result.PackageGraph.Packages {
+			if pkg.Name == name || pkg.Path == name || strings.HasSuffix(pkg.Name, "/"+name) {
+				reports = append(reports, packageDependents(result, pkg.Name))
+			}
+		}
+	}
+	if (kind == "" || kind == Table) && result.DatabaseSchema != nil {
+		for tableName := range result.DatabaseSchema.Tables {
+			if strings.EqualFold(tableName, name) || strings.EqualFold(unqualified(tableName), name) {
+				reports = append(reports, tableDependents(result, tableName))
+			}
+		}
+	}
+	if len(reports) == 0 {
+		what := "service, package or table"
+		if kind != "" {
+			what = kind
+		}
+		return nil, fmt.Errorf("no %s named %q in the analysis", what, name)
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].Kind != reports[j].Kind {
+			return kindRank(reports[i].Kind) < kindRank(reports[j].Kind)
+		}
+		return reports[i].Target < reports[j].Target
+	})
+	return reports, nil
+}
+
+// serviceDependents lists the services calling or otherwise relying on a service
+func serviceDependents(result *pipeline.AnalysisResult, name string) Report {
+	found := newCollector()
+	for _, rel := range result.ServiceRelationships {
+		if rel.To != name || rel.From == name {
+			continue
+		}
+		dependent := found.add(rel.From, Service)
+		dependent.Confidence = max(dependent.Confidence, rel.Confidence)
+		if len(rel.Sources) == 0 {
+			found.note(dependent, string(rel.EvidenceType), evidence(rel.FilePath, rel.Evidence))
+		}
+		for _, source := range rel.Sources {
+			found.note(dependent, string(source.EvidenceType), evidence(source.FilePath, source.Evidence))
+		}
+	}
+	return found.report(name, Service)
+}
+
+// packageDependents lists the packages importing or declaring a dependency on a package
+func packageDependents(result *pipeline.AnalysisResult, name string) Report {
+	found := newCollector()
+	for _, dep := range result.PackageGraph.Dependencies {
+		if dep.To != name {
+			continue
+		}
+		dependent := found.add(dep.From, Package)
+		if dep.Manifest {
+			found.note(dependent, "manifest", dep.Evidence)
+		}
+		if dep.Imports > 0 {
+			detail := dep.Evidence
+			if dep.Imports > 1 {
+				detail = fmt.Sprintf("%s and %d more files", dep.Evidence, dep.Imports-1)
+			}
+			found.note(dependent, "import", detail)
+		}
+	}
+	return found.report(name, Package)
+}
+
+// tableDependents lists the tables with a foreign key to a table and the services using it
+func tableDependents(result *pipeline.AnalysisResult, name string) Report {
+	found := newCollector()
+	schema := result.DatabaseSchema
+	tableNames := make([]string, 0, len(schema.Tables))
+	for tableName := range schema.Tables {
+		tableNames = append(tableNames, tableName)
+	}
+	sort.Strings(tableNames)
+	for _, tableName := range tableNames {
+		if tableName == name {
+			continue
+		}
+		table := schema.Tables[tableName]
+		columns := make([]string, 0, len(table.Columns))
+		for columnName := range table.Columns {
+			columns = append(columns, columnName)
+		}
+		sort.Strings(columns)
+		for _, columnName := range columns {
+			ref := table.Columns[columnName].References
+			if ref == nil || !sameTable(ref.Table, name) {
+				continue
+			}
+			dependent := found.add(tableName, Table)
+			found.note(dependent, "foreign key", fmt.Sprintf("%s.%s → %s.%s", tableName, columnName, name, ref.Column))
+		}
+	}
+
+	if result.TableOwnership != nil {
+		for _, table := range result.TableOwnership.Tables {
+			if !sameTable(table.Table, name) {
+				continue
+			}
+			for _, ev := range table.Evidence {
+				location := ev.File
+				if ev.Line > 0 {
+					location = fmt.Sprintf("%s:%d", ev.File, ev.Line)
+				}
+				found.note(found.add(ev.Service, Service), ev.Kind, evidence(location, ev.Detail))
+			}
+		}
+	}
+	return found.report(name, Table)
+}
+
+// collector gathers dependents in the order they are first seen
+type collector struct {
+	dependents []*Dependent
+	byKey      map[string]*Dependent
+}
+
+func newCollector() *collector {
+	return &collector{byKey: make(map[string]*Dependent)}
+}
+
+func (c *collector) add(name, kind string) *Dependent {
+	key := kind + "\x00" + name
+	if dependent, ok := c.byKey[key]; ok {
+		return dependent
+	}
+	dependent := &Dependent{Name: name, Kind: kind, Via: []string{}, Evidence: []string{}}
+	c.byKey[key] = dependent
+	c.dependents = append(c.dependents, dependent)
+	return dependent
+}
+
+// note records how a dependent depends on the target and where that was seen
+func (c *collector) note(dependent *Dependent, via, detail string) {
+	if via != "" && !contains(dependent.Via, via) {
+		dependent.Via = append(dependent.Via, via)
+	}
+	if detail != "" && !contains(dependent.Evidence, detail) && len(dependent.Evidence) < maxEvidence {
+		dependent.Evidence = append(dependent.Evidence, detail)
+	}
+}
+
+func (c *collector) report(target, kind string) Report {
+	report := Report{Target: target, Kind: kind, Dependents: make([]Dependent, 0, len(c.dependents))}
+	for _, dependent := range c.dependents {
+		report.Dependents = append(report.Dependents, *dependent)
+	}
+	sort.SliceStable(report.Dependents, func(i, j int) bool {
+		if report.Dependents[i].Kind != report.Dependents[j].Kind {
+			return kindRank(report.Dependents[i].Kind) < kindRank(report.Dependents[j].Kind)
+		}
+		return report.Dependents[i].Name < report.Dependents[j].Name
+	})
+
+	counts := make(map[string]int)
+	for _, dependent := range report.Dependents {
+		counts[dependent.Kind]++
+	}
+	var parts []string
+	for _, k := range kindOrder {
+		if counts[k] > 0 {
+			parts = append(parts, plural(counts[k], k))
+		}
+	}
+	if len(parts) == 0 {
+		report.Summary = fmt.Sprintf("Nothing in the analysis depends on %s %s", kind, target)
+	} else {
+		report.Summary = fmt.Sprintf("%s %s is used by %s", strings.ToUpper(kind[:1])+kind[1:], target, strings.Join(parts, " and "))
+	}
+	return report
+}
+
+// Display prints a report for the terminal
+func (r *Report) Display() {
+	fmt.Printf("\n🔁 %s\n", r.Summary)
+	for _, dependent := range r.Dependents {
+		line := fmt.Sprintf("   • %s (%s", dependent.Name, dependent.Kind)
+		if len(dependent.Via) > 0 {
+			line += ", " + strings.Join(dependent.Via, ", ")
+		}
+		if dependent.Confidence > 0 {
+			line += fmt.Sprintf(", %.0f%%", dependent.Confidence*100)
+		}
+		fmt.Println(line + ")")
+		for _, ev := range dependent.Evidence {
+			fmt.Printf("     ↳ %s\n", ev)
+		}
+	}
+}
+
+func kindRank(kind string) int {
+	for i, k := range kindOrder {
+		if k == kind {
+			return i
+		}
+	}
+	return len(kindOrder)
+}
+
+func evidence(file, detail string) string {
+	switch {
+	case file == "":
+		return detail
+	case detail == "":
+		return file
+	}
+	return file + ": " + detail
+}
+
+// sameTable reports whether two table names name the same table; an unqualified name matches the
+// table in any schema
+func sameTable(a, b string) bool {
+	if a == b {
+		return true
+	}
+	return (!strings.Contains(a, ".") || !strings.Contains(b, ".")) && unqualified(a) == unqualified(b)
+}
+
+// unqualified drops the schema of a table name
+func unqualified(table string) string {
+	if i := strings.LastIndex(table, "."); i >= 0 {
+		return table[i+1:]
+	}
+	return table
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -2,6 +2,7 @@ package detector
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -36,10 +37,11 @@ func (dr *DetectionResult) DisplayResult() {
 
 	// Detailed scores
 	fmt.Println("\n📈 DETAILED SCORES:")
-	scores := []struct {
+	type typeScore struct {
 		Type  ProjectType
 		Score float64
-	}{
+	}
+	scores := []typeScore{
 		{Frontend, dr.Scores[Frontend]},
 		{Backend, dr.Scores[Backend]},
 		{Mobile, dr.Scores[Mobile]},
@@ -48,6 +50,19 @@ func (dr *DetectionResult) DisplayResult() {
 		{DevOps, dr.Scores[DevOps]},
 		{DataScience, dr.Scores[DataScience]},
 	}
+	// Custom types from a rule pack follow the built-in ones
+	var custom []ProjectType
+	for projectType := range dr.Scores {
+		switch projectType {
+		case Frontend, Backend, Mobile, Desktop, Library, DevOps, DataScience:
+		default:
+			custom = append(custom, projectType)
+		}
+	}
+	sort.Slice(custom, func(i, j int) bool { return custom[i] < custom[j] })
+	for _, projectType := range custom {
+		scores = append(scores, typeScore{projectType, dr.Scores[projectType]})
+	}
 
 	for _, score := range scores {
 		if score.Score > 0 {
@@ -2,8 +2,12 @@ package detector
 
 import (
 	"fmt"
-	"path/filepath"
+	"math"
+	"path"
+	"sort"
 	"strings"
+
+	"repo-explanation/internal/portable"
 )
 
 // ProjectType represents the detected type of project
@@ -30,39 +34,78 @@ type DetectionResult struct {
 	Scores        map[ProjectType]float64 `json:"scores"`
 }
 
+// Stable returns a copy for comparing results across runs, with evidence sorted and the
+// confidence and scores rounded to two decimals
+func (r *DetectionResult) Stable() *DetectionResult {
+	stable := &DetectionResult{
+		PrimaryType:   r.PrimaryType,
+		SecondaryType: r.SecondaryType,
+		Confidence:    math.Round(r.Confidence*100) / 100,
+		Evidence:      make(map[string][]string, len(r.Evidence)),
+		Scores:        make(map[ProjectType]float64, len(r.Scores)),
+	}
+	for category, evidence := range r.Evidence {
+		sorted := append([]string(nil), evidence...)
+		sort.Strings(sorted)
+		stable.Evidence[category] = sorted
+	}
+	for projectType, score := range r.Scores {
+		stable.Scores[projectType] = math.Round(score*100) / 100
+	}
+	return stable
+}
+
 // ProjectDetector analyzes file structures and determines project type
 type ProjectDetector struct {
-	frontendRules   []DetectionRule
-	backendRules    []DetectionRule
-	mobileRules     []DetectionRule
-	desktopRules    []DetectionRule
-	libraryRules    []DetectionRule
-	devopsRules     []DetectionRule
-	dataScienceRules []DetectionRule
+	types []ProjectType                   // Scored types in the order their rules are applied
+	rules map[ProjectType][]DetectionRule
 }
 
 // DetectionRule defines criteria for detecting project types
 type DetectionRule struct {
-	Name        string
-	Score       float64
-	FilePattern string
-	Extensions  []string
-	Directories []string
-	Keywords    []string
-	Required    bool
+	Name        string   `yaml:"name" json:"name"`
+	Score       float64  `yaml:"score" json:"score"`
+	FilePattern string   `yaml:"-" json:"-"`
+	Extensions  []string `yaml:"extensions" json:"extensions,omitempty"`
+	Directories []string `yaml:"directories" json:"directories,omitempty"`
+	Keywords    []string `yaml:"keywords" json:"keywords,omitempty"`
+	Required    bool     `yaml:"-" json:"-"`
 }
 
 // NewProjectDetector creates a new project type detector
 func NewProjectDetector() *ProjectDetector {
-	return &ProjectDetector{
-		frontendRules:    getFrontendRules(),
-		backendRules:     getBackendRules(),
-		mobileRules:      getMobileRules(),
-		desktopRules:     getDesktopRules(),
-		libraryRules:     getLibraryRules(),
-		devopsRules:      getDevopsRules(),
-		dataScienceRules: getDataScienceRules(),
+	pd := &ProjectDetector{rules: make(map[ProjectType][]DetectionRule)}
+	pd.addType(Frontend, getFrontendRules())
+	pd.addType(Backend, getBackendRules())
+	pd.addType(Mobile, getMobileRules())
+	pd.addType(Desktop, getDesktopRules())
+	pd.addType(Library, getLibraryRules())
+	pd.addType(DevOps, getDevopsRules())
+	pd.addType(DataScience, getDataScienceRules())
+	return pd
+}
+
+// NewProjectDetectorFromFile creates a detector with the built-in rules plus those of a rule pack;
+// an empty path uses the built-in rules only
+func NewProjectDetectorFromFile(rulesFile string) (*ProjectDetector, error) {
+	pd := NewProjectDetector()
+	if rulesFile == "" {
+		return pd, nil
 	}
+	pack, err := LoadRulePack(rulesFile)
+	if err != nil {
+		return nil, err
+	}
+	pd.ApplyRulePack(pack)
+	return pd, nil
+}
+
+// addType registers a scored project type with its rules
+func (pd *ProjectDetector) addType(projectType ProjectType, rules []DetectionRule) {
+	if _, exists := pd.rules[projectType]; !exists {
+		pd.types = append(pd.types, projectType)
+	}
+	pd.rules[projectType] = rules
 }
 
 // FileInfo represents a discovered file (avoiding import cycle)
@@ -74,19 +117,34 @@ type FileInfo struct {
 	IsDir        bool
 }
 
+// NeedsContent reports whether DetectProjectType reads a file's content: manifests, READMEs,
+// Makefiles and server entry points. Every other file is judged by its path alone.
+func NeedsContent(relativePath string) bool {
+	lower := strings.ToLower(relativePath)
+	filename := path.Base(portable.Slash(lower))
+	switch {
+	case strings.HasSuffix(lower, "package.json"), strings.Contains(filename, "readme"),
+		filename == "makefile", filename == "makefile.mk":
+		return true
+	case strings.HasSuffix(lower, "main.go"), strings.Contains(lower, "server.go"), strings.Contains(lower, "app.go"):
+		return true
+	}
+	switch filename {
+	case "app.py", "main.py", "server.py", "manage.py", "wsgi.py", "asgi.py":
+		return true
+	}
+	return false
+}
+
 // DetectProjectType analyzes files and determines project type
 func (pd *ProjectDetector) DetectProjectType(files []FileInfo, fileContents map[string]string) *DetectionResult {
 	scores := make(map[ProjectType]float64)
 	evidence := make(map[string][]string)
 	
 	// Initialize scores
-	scores[Frontend] = 0.0
-	scores[Backend] = 0.0
-	scores[Mobile] = 0.0
-	scores[Desktop] = 0.0
-	scores[Library] = 0.0
-	scores[DevOps] = 0.0
-	scores[DataScience] = 0.0
+	for _, projectType := range pd.types {
+		scores[projectType] = 0.0
+	}
 	
 	// Collect file information
 	extensions := make(map[string]int)
@@ -99,19 +157,15 @@ func (pd *ProjectDetector) DetectProjectType(files []FileInfo, fileContents map[
 			extensions[ext]++
 		}
 		
-		dir := filepath.Dir(file.RelativePath)
+		dir := path.Dir(portable.Slash(file.RelativePath))
 		directories[strings.ToLower(dir)] = true
-		filenames = append(filenames, strings.ToLower(filepath.Base(file.Path)))
+		filenames = append(filenames, strings.ToLower(path.Base(portable.Slash(file.Path))))
 	}
 	
 	// Apply detection rules
-	pd.applyRules(pd.frontendRules, Frontend, extensions, directories, filenames, scores, evidence)
-	pd.applyRules(pd.backendRules, Backend, extensions, directories, filenames, scores, evidence)
-	pd.applyRules(pd.mobileRules, Mobile, extensions, directories, filenames, scores, evidence)
-	pd.applyRules(pd.desktopRules, Desktop, extensions, directories, filenames, scores, evidence)
-	pd.applyRules(pd.libraryRules, Library, extensions, directories, filenames, scores, evidence)
-	pd.applyRules(pd.devopsRules, DevOps, extensions, directories, filenames, scores, evidence)
-	pd.applyRules(pd.dataScienceRules, DataScience, extensions, directories, filenames, scores, evidence)
+	for _, projectType := range pd.types {
+		pd.applyRules(pd.rules[projectType], projectType, extensions, directories, filenames, scores, evidence)
+	}
 	
 	// Apply intelligent package.json-based detection to override generic scoring
 	pd.applyPackageJsonIntelligence(fileContents, scores, evidence)
@@ -581,7 +635,7 @@ func (pd *ProjectDetector) hasFrontendStartupCommands(files []FileInfo, fileCont
 	
 	// Check README files for frontend startup instructions
 	for _, file := range files {
-		filename := strings.ToLower(filepath.Base(file.RelativePath))
+		filename := strings.ToLower(path.Base(portable.Slash(file.RelativePath)))
 		if strings.Contains(filename, "readme") {
 			content, exists := fileContents[file.RelativePath]
 			if exists && pd.hasReadmeFrontendCommands(content) {
@@ -592,7 +646,7 @@ func (pd *ProjectDetector) hasFrontendStartupCommands(files []FileInfo, fileCont
 	
 	// Check Makefile for frontend commands
 	for _, file := range files {
-		filename := strings.ToLower(filepath.Base(file.RelativePath))
+		filename := strings.ToLower(path.Base(portable.Slash(file.RelativePath)))
 		if filename == "makefile" || filename == "makefile.mk" {
 			content, exists := fileContents[file.RelativePath]
 			if exists && pd.hasMakefileFrontendCommands(content) {
@@ -618,7 +672,7 @@ func (pd *ProjectDetector) hasBackendStartupCommands(files []FileInfo, fileConte
 	
 	// Check README files for backend startup instructions
 	for _, file := range files {
-		filename := strings.ToLower(filepath.Base(file.RelativePath))
+		filename := strings.ToLower(path.Base(portable.Slash(file.RelativePath)))
 		if strings.Contains(filename, "readme") {
 			content, exists := fileContents[file.RelativePath]
 			if exists && pd.hasReadmeBackendCommands(content) {
@@ -629,7 +683,7 @@ func (pd *ProjectDetector) hasBackendStartupCommands(files []FileInfo, fileConte
 	
 	// Check Makefile for backend commands
 	for _, file := range files {
-		filename := strings.ToLower(filepath.Base(file.RelativePath))
+		filename := strings.ToLower(path.Base(portable.Slash(file.RelativePath)))
 		if filename == "makefile" || filename == "makefile.mk" {
 			content, exists := fileContents[file.RelativePath]
 			if exists && pd.hasMakefileBackendCommands(content) {
@@ -652,7 +706,7 @@ func (pd *ProjectDetector) hasBackendStartupCommands(files []FileInfo, fileConte
 	
 	// Check for Python server files
 	for _, file := range files {
-		filename := strings.ToLower(filepath.Base(file.RelativePath))
+		filename := strings.ToLower(path.Base(portable.Slash(file.RelativePath)))
 		if filename == "app.py" || filename == "main.py" || filename == "server.py" ||
 		   filename == "manage.py" || filename == "wsgi.py" || filename == "asgi.py" {
 			content, exists := fileContents[file.RelativePath]
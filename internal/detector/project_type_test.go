@@ -0,0 +1,48 @@
+package detector
+
+import (
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fullstackProject is a React frontend with a Go API; the README explains how to start both
+var fullstackProject = map[string]string{
+	"web/package.json":  "{\n  \"dependencies\": {\"react\": \"^18.0.0\", \"react-dom\": \"^18.0.0\"},\n  \"scripts\": {\"start\": \"react-scripts start\"}\n}\n",
+	"web/src/App.jsx":   "export default function App() { return null }\n",
+	"web/src/index.jsx": "import App from './App'\n",
+	"api/main.go":       "package main\n\nimport \"net/http\"\n\nfunc main() {\n\thttp.ListenAndServe(\":8080\", nil)\n}\n",
+	"api/handlers/x.go": "package handlers\n",
+	"api/go.mod":        "module api\n",
+	"README.md":         "# Shop\n\n## Running\n\n```\ncd web && npm start\ncd api && go run main.go\n```\n",
+	"Makefile":          "run-web:\n\tnpm run dev\n\nrun-api:\n\tgo run ./api\n",
+}
+
+// detect runs the detector on the project, with paths joined by sep and lines ended by eol
+func detect(project map[string]string, root, sep, eol string) *DetectionResult {
+	var files []FileInfo
+	contents := make(map[string]string)
+	for relativePath, content := range project {
+		relativePath = strings.ReplaceAll(relativePath, "/", sep)
+		files = append(files, FileInfo{
+			Path:         root + sep + relativePath,
+			RelativePath: relativePath,
+			Extension:    path.Ext(relativePath),
+		})
+		contents[relativePath] = strings.ReplaceAll(content, "\n", eol)
+	}
+	return NewProjectDetector().DetectProjectType(files, contents).Stable()
+}
+
+func TestDetectProjectTypeWindows(t *testing.T) {
+	unix := detect(fullstackProject, "/home/dev/shop", "/", "\n")
+	windows := detect(fullstackProject, `C:\Users\dev\shop`, `\`, "\r\n")
+
+	if unix.PrimaryType == Unknown {
+		t.Fatalf("project not detected: %+v", unix)
+	}
+	if !reflect.DeepEqual(windows, unix) {
+		t.Errorf("Windows checkout detected as\n%+v\nwant\n%+v", windows, unix)
+	}
+}
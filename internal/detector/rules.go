@@ -0,0 +1,133 @@
+package detector
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"repo-explanation/internal/portable"
+)
+
+// RulePack holds detection rules loaded from a file, teaching the detector about in-house
+// frameworks or project types without code changes
+type RulePack struct {
+	Types []RuleSet `yaml:"types" json:"types"`
+}
+
+// RuleSet adds rules to a built-in or custom project type. A rule named like an existing rule
+// of the same type replaces it; Replace drops all of the type's built-in rules first.
+type RuleSet struct {
+	Type    ProjectType     `yaml:"type" json:"type"`
+	Replace bool            `yaml:"replace" json:"replace,omitempty"`
+	Rules   []DetectionRule `yaml:"rules" json:"rules"`
+}
+
+// LoadRulePack reads a rule pack from a YAML or JSON (.json) file
+func LoadRulePack(path string) (*RulePack, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules file: %v", err)
+	}
+
+	var pack RulePack
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&pack)
+	} else {
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&pack)
+	}
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to parse rules file %s: %v", path, err)
+	}
+
+	if err := pack.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid rules file %s:%v", path, err)
+	}
+	return &pack, nil
+}
+
+// Validate checks every rule set, reporting all problems at once
+func (p *RulePack) Validate() error {
+	var problems []string
+	for i, set := range p.Types {
+		switch {
+		case strings.TrimSpace(string(set.Type)) == "":
+			problems = append(problems, fmt.Sprintf("types[%d].type is required", i))
+		case set.Type == Fullstack || set.Type == Unknown:
+			problems = append(problems, fmt.Sprintf("types[%d].type %q is derived by the detector and cannot have rules", i, set.Type))
+		}
+		if len(set.Rules) == 0 && !set.Replace {
+			problems = append(problems, fmt.Sprintf("types[%d].rules must list at least one rule", i))
+		}
+		for j, rule := range set.Rules {
+			if strings.TrimSpace(rule.Name) == "" {
+				problems = append(problems, fmt.Sprintf("types[%d].rules[%d].name is required", i, j))
+			}
+			if rule.Score == 0 {
+				problems = append(problems, fmt.Sprintf("types[%d].rules[%d].score must not be zero", i, j))
+			}
+			if len(rule.Extensions)+len(rule.Directories)+len(rule.Keywords) == 0 {
+				problems = append(problems, fmt.Sprintf("types[%d].rules[%d] must match on extensions, directories or keywords", i, j))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("\n  - %s", strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+// ApplyRulePack merges a rule pack into the detector's rules; unknown types become new scored types
+func (pd *ProjectDetector) ApplyRulePack(pack *RulePack) {
+	for _, set := range pack.Types {
+		var rules []DetectionRule
+		if !set.Replace {
+			rules = append(rules, pd.rules[set.Type]...)
+		}
+		for _, rule := range set.Rules {
+			rule = normalizeRule(rule)
+			replaced := false
+			for i := range rules {
+				if strings.EqualFold(rules[i].Name, rule.Name) {
+					rules[i] = rule
+					replaced = true
+					break
+				}
+			}
+			if !replaced {
+				rules = append(rules, rule)
+			}
+		}
+		pd.addType(set.Type, rules)
+	}
+}
+
+// normalizeRule lowercases extensions and directories and adds a missing leading dot, matching
+// how the detector indexes files
+func normalizeRule(rule DetectionRule) DetectionRule {
+	extensions := make([]string, 0, len(rule.Extensions))
+	for _, ext := range rule.Extensions {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext != "" && !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		extensions = append(extensions, ext)
+	}
+	rule.Extensions = extensions
+
+	directories := make([]string, 0, len(rule.Directories))
+	for _, dir := range rule.Directories {
+		directories = append(directories, strings.Trim(strings.ToLower(portable.Slash(dir)), "/"))
+	}
+	rule.Directories = directories
+	return rule
+}
@@ -0,0 +1,290 @@
+// Package diskcache accounts for the size of the on-disk caches and evicts least recently used
+// entries to keep them under a limit. An entry's modification time records when it was last
+// written or read, so eviction needs no index beside the files themselves.
+package diskcache
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"repo-explanation/internal/atrest"
+)
+
+// lowWater is the fraction of the limit that automatic eviction frees down to, so a full cache is
+// not rescanned on every write
+const lowWater = 0.9
+
+// Entry is one cached file
+type Entry struct {
+	Path     string
+	Kind     string // file, folder, project, details, llm or other
+	Bytes    int64
+	LastUsed time.Time
+}
+
+// KindStats totals the entries of one kind
+type KindStats struct {
+	Entries int   `json:"entries"`
+	Bytes   int64 `json:"bytes"`
+}
+
+// Stats describes a cache directory
+type Stats struct {
+	Directory string               `json:"directory"`
+	Entries   int                  `json:"entries"`
+	Bytes     int64                `json:"bytes"`
+	MaxBytes  int64                `json:"max_bytes"` // 0 for no limit
+	Expired   int                  `json:"expired"`   // Entries older than the TTL, which are never served
+	Oldest    time.Time            `json:"oldest_used,omitempty"`
+	Newest    time.Time            `json:"newest_used,omitempty"`
+	Kinds     map[string]KindStats `json:"kinds"`
+}
+
+// PruneResult reports what a prune removed
+type PruneResult struct {
+	Removed      int   `json:"removed"`
+	RemovedBytes int64 `json:"removed_bytes"`
+	Expired      int   `json:"expired"` // Of the removed entries, those older than the TTL
+	Entries      int   `json:"entries"` // Left after pruning
+	Bytes        int64 `json:"bytes"`
+}
+
+// summaryKindRegex reads the kind of a summary cache file: <name>_<kind>[_<language>]_<hash>.json
+var summaryKindRegex = regexp.MustCompile(`_(file|folder|project|details)(?:_[a-z]{2})?_[0-9a-f]{8}\.json$`)
+
+// Scan lists the entries under dir; a missing directory has none. Temporary files of writes in
+// progress are skipped.
+func Scan(dir string) ([]Entry, error) {
+	var entries []Entry
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ".json") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil // Removed while walking
+		}
+		entries = append(entries, Entry{Path: path, Kind: kindOf(dir, path), Bytes: info.Size(), LastUsed: info.ModTime()})
+		return nil
+	})
+	return entries, err
+}
+
+func kindOf(dir, path string) string {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return "other"
+	}
+	if first := strings.Split(filepath.ToSlash(rel), "/")[0]; first == "llm" {
+		return "llm"
+	}
+	if match := summaryKindRegex.FindStringSubmatch(filepath.Base(path)); match != nil {
+		return match[1]
+	}
+	return "other"
+}
+
+// Summarize totals the entries of dir. Expired entries are counted when ttl is positive, which
+// reads each entry's timestamp, opening encrypted entries with sealer.
+func Summarize(dir string, entries []Entry, maxBytes int64, ttl time.Duration, sealer *atrest.Sealer) Stats {
+	stats := Stats{Directory: dir, MaxBytes: maxBytes, Kinds: make(map[string]KindStats)}
+	for _, entry := range entries {
+		stats.Entries++
+		stats.Bytes += entry.Bytes
+		kind := stats.Kinds[entry.Kind]
+		kind.Entries++
+		kind.Bytes += entry.Bytes
+		stats.Kinds[entry.Kind] = kind
+		if stats.Oldest.IsZero() || entry.LastUsed.Before(stats.Oldest) {
+			stats.Oldest = entry.LastUsed
+		}
+		if entry.LastUsed.After(stats.Newest) {
+			stats.Newest = entry.LastUsed
+		}
+		if ttl > 0 && Expired(entry.Path, ttl, sealer) {
+			stats.Expired++
+		}
+	}
+	return stats
+}
+
+// Expired reports whether an entry was written more than ttl ago, reading the timestamp every
+// cache entry records. Unreadable entries, including those encrypted under another key, count as
+// expired, since they can never be served.
+func Expired(path string, ttl time.Duration, sealer *atrest.Sealer) bool {
+	data, err := sealer.ReadFile(path)
+	if err != nil {
+		return true
+	}
+	var entry struct {
+		Timestamp time.Time `json:"timestamp"`
+	}
+	if json.Unmarshal(data, &entry) != nil {
+		return true
+	}
+	return time.Since(entry.Timestamp) > ttl
+}
+
+// Prune removes the expired entries of dir when ttl is positive, then the least recently used
+// ones until the rest fit in maxBytes (0 for no limit). With dryRun nothing is deleted. Encrypted
+// entries are opened with sealer to read their timestamps.
+func Prune(dir string, maxBytes int64, ttl time.Duration, dryRun bool, sealer *atrest.Sealer) (PruneResult, error) {
+	entries, err := Scan(dir)
+	if err != nil {
+		return PruneResult{}, err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].LastUsed.Before(entries[j].LastUsed) })
+
+	var result PruneResult
+	var total int64
+	for _, entry := range entries {
+		total += entry.Bytes
+	}
+	remove := func(entry Entry) {
+		if !dryRun && os.Remove(entry.Path) != nil {
+			return
+		}
+		result.Removed++
+		result.RemovedBytes += entry.Bytes
+		total -= entry.Bytes
+	}
+
+	kept := entries[:0]
+	for _, entry := range entries {
+		if ttl > 0 && Expired(entry.Path, ttl, sealer) {
+			remove(entry)
+			result.Expired++
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	for len(kept) > 0 && maxBytes > 0 && total > maxBytes {
+		remove(kept[0])
+		kept = kept[1:]
+	}
+
+	result.Entries = len(kept)
+	result.Bytes = total
+	if !dryRun {
+		removeEmptyDirs(dir)
+	}
+	return result, nil
+}
+
+// removeEmptyDirs deletes the subdirectories of dir left empty by a prune, keeping dir itself
+func removeEmptyDirs(dir string) {
+	var dirs []string
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() && path != dir {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+	// Deepest first, so a parent empties once its children are gone
+	for i := len(dirs) - 1; i >= 0; i-- {
+		os.Remove(dirs[i])
+	}
+}
+
+// Touch marks an entry as used now, so eviction keeps it
+func Touch(path string) {
+	now := time.Now()
+	os.Chtimes(path, now, now)
+}
+
+// Budget tracks the size of one cache directory in a process and evicts least recently used
+// entries when a write takes it over the limit
+type Budget struct {
+	dir      string
+	maxBytes int64
+
+	mu      sync.Mutex
+	bytes   int64
+	scanned bool
+}
+
+var (
+	budgetsMu sync.Mutex
+	budgets   = make(map[string]*Budget)
+)
+
+// For returns the budget of dir, shared by every cache writing there. maxBytes of 0 disables eviction.
+func For(dir string, maxBytes int64) *Budget {
+	key := filepath.Clean(dir)
+	budgetsMu.Lock()
+	defer budgetsMu.Unlock()
+	budget, ok := budgets[key]
+	if !ok {
+		budget = &Budget{dir: dir}
+		budgets[key] = budget
+	}
+	budget.mu.Lock()
+	budget.maxBytes = maxBytes
+	budget.mu.Unlock()
+	return budget
+}
+
+// Wrote accounts for bytes just written. Past the limit, least recently used entries are evicted
+// down to 90% of it. A failed eviction is reported and the write stands.
+func (b *Budget) Wrote(bytes int64) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.maxBytes <= 0 {
+		return
+	}
+	if !b.scanned {
+		// The first write scans the directory, which already counts the entry just written
+		entries, err := Scan(b.dir)
+		if err != nil {
+			fmt.Printf("⚠️  Failed to measure the cache: %v\n", err)
+			return
+		}
+		b.bytes = 0
+		for _, entry := range entries {
+			b.bytes += entry.Bytes
+		}
+		b.scanned = true
+	} else {
+		b.bytes += bytes
+	}
+	if b.bytes <= b.maxBytes {
+		return
+	}
+
+	result, err := Prune(b.dir, int64(float64(b.maxBytes)*lowWater), 0, false, nil)
+	if err != nil {
+		fmt.Printf("⚠️  Failed to enforce the cache size limit: %v\n", err)
+		return
+	}
+	b.bytes = result.Bytes
+	fmt.Printf("🧹 Cache over %s: evicted %d least recently used entries (%s)\n", FormatBytes(b.maxBytes), result.Removed, FormatBytes(result.RemovedBytes))
+}
+
+// FormatBytes renders a size in KB, MB or GB
+func FormatBytes(bytes int64) string {
+	switch {
+	case bytes >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(bytes)/(1<<30))
+	case bytes >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(bytes)/(1<<20))
+	default:
+		return fmt.Sprintf("%.1f KB", float64(bytes)/(1<<10))
+	}
+}
@@ -0,0 +1,329 @@
+// Package doccoverage scores how well each service is documented, from its README (length,
+// sections and examples), the share of public declarations with a doc comment, and whether its
+// HTTP API has an OpenAPI specification, and ranks the least documented areas so onboarding leads
+// know where documentation effort pays off first.
+package doccoverage
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"repo-explanation/internal/filestore"
+	"repo-explanation/internal/microservices"
+	"repo-explanation/internal/portable"
+)
+
+// Readme describes one README
+type Readme struct {
+	File       string   `json:"file"` // Relative to the project root
+	Words      int      `json:"words"`
+	Topics     []string `json:"topics"`  // Sections it covers: setup, usage, configuration, testing, architecture, api
+	Missing    []string `json:"missing"` // Sections it lacks
+	CodeBlocks int      `json:"code_blocks"`
+	Quality    string   `json:"quality"` // stub, basic or good
+}
+
+// Area is the documentation coverage of one service or top-level directory
+type Area struct {
+	Name         string   `json:"name"`
+	Path         string   `json:"path"`
+	Readme       *Readme  `json:"readme,omitempty"`
+	Declarations int      `json:"declarations"` // Public functions, types and classes
+	Documented   int      `json:"documented"`   // Declarations with a doc comment
+	Density      float64  `json:"density"`      // Documented share of declarations, 0-1
+	APISpec      string   `json:"api_spec,omitempty"`
+	NeedsAPISpec bool     `json:"needs_api_spec"` // The area serves an HTTP API, or documents one
+	Score        float64  `json:"score"`          // 0-100
+	Grade        string   `json:"grade"`
+	Gaps         []string `json:"gaps"` // What to document first
+}
+
+// Report ranks the documentation coverage of a project's areas
+type Report struct {
+	Project         Area     `json:"project"`
+	Areas           []Area   `json:"areas"`            // Least documented first
+	LeastDocumented []string `json:"least_documented"` // Names of the areas scoring below passScore, worst first
+	Summary         string   `json:"summary"`
+}
+
+// Points per component; an area without an HTTP API is scored out of the other two
+const (
+	readmePoints  = 40
+	commentPoints = 40
+	specPoints    = 20
+)
+
+// README length bounds, in words
+const (
+	stubWords = 50
+	goodWords = 300
+)
+
+// passScore is the score from which an area counts as documented
+const passScore = 60
+
+// maxLeastDocumented bounds the ranked list of least documented areas
+const maxLeastDocumented = 5
+
+// Directories that never hold first-party code or docs
+var skippedDirs = map[string]bool{
+	".git": true, "node_modules": true, "vendor": true, "testdata": true, "dist": true, "build": true,
+	".next": true, "coverage": true, "__pycache__": true, ".venv": true, "venv": true,
+	"test": true, "tests": true, "__tests__": true, "spec": true,
+}
+
+// generatedSuffixes mark files whose doc comments nobody writes by hand
+var generatedSuffixes = []string{".pb.go", "_gen.go", ".gen.go", "_generated.go", ".d.ts", ".min.js", "_pb2.py"}
+
+// areaFiles collects what the walk finds in one area
+type areaFiles struct {
+	area    *Area
+	readme  string // Relative path of the area's own README
+	content string
+	specs   map[string]bool
+}
+
+// Analyze scores documentation coverage per service, or per top-level directory when the project
+// has no services
+func Analyze(projectName string, files *filestore.FileStore, services []microservices.DiscoveredService) (*Report, error) {
+	fmt.Printf("📚 [DEBUG] Scoring documentation coverage of: %s\n", projectName)
+
+	project := &areaFiles{area: &Area{Name: projectName, Path: "."}, specs: make(map[string]bool)}
+	areas := make(map[string]*areaFiles)
+	for _, service := range services {
+		servicePath := strings.Trim(portable.Slash(filepath.Clean(service.Path)), "/")
+		if servicePath == "" {
+			servicePath = "."
+		}
+		areas[service.Name] = &areaFiles{
+			area:  &Area{Name: service.Name, Path: servicePath, NeedsAPISpec: service.APIType == microservices.HTTPService},
+			specs: make(map[string]bool),
+		}
+	}
+
+	err := files.Walk(func(rel string) bool {
+		name := path.Base(rel)
+		if filestore.InDirs(rel, skippedDirs) || filestore.InHiddenDir(rel) || isTestFile(name) || isGenerated(name) {
+			return false
+		}
+		return ruleFor(strings.ToLower(path.Ext(rel))) != nil || isReadme(name) || maybeSpec(rel)
+	}, func(rel, content string) error {
+		dir := path.Dir(rel)
+		rule := ruleFor(strings.ToLower(path.Ext(rel)))
+		readme := isReadme(path.Base(rel))
+		text := portable.Text(content)
+
+		target := project
+		if len(services) > 0 {
+			if name := microservices.OwningService(dir, services); name != "" {
+				target = areas[name]
+			}
+		} else if top := topLevelDir(rel); top != "" {
+			if areas[top] == nil {
+				areas[top] = &areaFiles{area: &Area{Name: top, Path: top}, specs: make(map[string]bool)}
+			}
+			target = areas[top]
+		}
+
+		if readme {
+			if dir == "." && project.readme == "" {
+				project.readme, project.content = rel, text
+			}
+			if target != project && dir == target.area.Path && target.readme == "" {
+				target.readme, target.content = rel, text
+			}
+			return nil
+		}
+		if source := specSource(rel, text); source != "" {
+			target.specs[source] = true
+			project.specs[source] = true
+		}
+		if rule != nil {
+			total, documented := countDeclarations(rule, text)
+			target.area.Declarations += total
+			target.area.Documented += documented
+			if target != project {
+				project.area.Declarations += total
+				project.area.Documented += documented
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan documentation: %v", err)
+	}
+
+	report := &Report{Areas: []Area{}, LeastDocumented: []string{}}
+	for _, collected := range areas {
+		// Directories without code, such as docs or config folders, are not areas to document
+		if collected.area.Declarations == 0 && collected.readme == "" && len(services) == 0 {
+			continue
+		}
+		score(collected)
+		report.Areas = append(report.Areas, *collected.area)
+	}
+	for _, area := range report.Areas {
+		if area.NeedsAPISpec {
+			project.area.NeedsAPISpec = true
+		}
+	}
+	score(project)
+	report.Project = *project.area
+
+	sort.SliceStable(report.Areas, func(i, j int) bool {
+		if report.Areas[i].Score != report.Areas[j].Score {
+			return report.Areas[i].Score < report.Areas[j].Score
+		}
+		if report.Areas[i].Declarations != report.Areas[j].Declarations {
+			return report.Areas[i].Declarations > report.Areas[j].Declarations
+		}
+		return report.Areas[i].Name < report.Areas[j].Name
+	})
+	for _, area := range report.Areas {
+		if area.Score < passScore && len(report.LeastDocumented) < maxLeastDocumented {
+			report.LeastDocumented = append(report.LeastDocumented, area.Name)
+		}
+	}
+	report.Summary = summarize(report)
+	fmt.Printf("✅ [DEBUG] %s\n", report.Summary)
+	return report, nil
+}
+
+// score rates an area from its README, doc comment density and API specification, and lists its gaps
+func score(files *areaFiles) {
+	area := files.area
+	area.Gaps = []string{}
+	if files.readme != "" {
+		area.Readme = inspectReadme(files.readme, files.content)
+	}
+	if len(files.specs) > 0 {
+		area.APISpec = strings.Join(sortedKeys(files.specs), ", ")
+		area.NeedsAPISpec = true
+	}
+
+	var points, possible float64
+	possible += readmePoints
+	switch {
+	case area.Readme == nil:
+		area.Gaps = append(area.Gaps, "no README")
+	default:
+		points += readmeScore(area.Readme)
+		if area.Readme.Quality == "stub" {
+			area.Gaps = append(area.Gaps, fmt.Sprintf("README is a stub (%d words)", area.Readme.Words))
+		}
+		if len(area.Readme.Missing) > 0 && area.Readme.Quality != "stub" {
+			area.Gaps = append(area.Gaps, "README lacks "+strings.Join(area.Readme.Missing, ", ")+" sections")
+		}
+	}
+
+	if area.Declarations > 0 {
+		possible += commentPoints
+		area.Density = math.Round(float64(area.Documented)/float64(area.Declarations)*100) / 100
+		points += commentPoints * float64(area.Documented) / float64(area.Declarations)
+		if area.Density < 0.5 {
+			area.Gaps = append(area.Gaps, fmt.Sprintf("%d of %d public declarations have no doc comment", area.Declarations-area.Documented, area.Declarations))
+		}
+	}
+
+	if area.NeedsAPISpec {
+		possible += specPoints
+		if area.APISpec != "" {
+			points += specPoints
+		} else {
+			area.Gaps = append(area.Gaps, "no OpenAPI specification for its HTTP API")
+		}
+	}
+
+	area.Score = math.Round(points/possible*1000) / 10
+	area.Grade = grade(area.Score)
+}
+
+// readmeScore rates a README: presence, length up to goodWords, covered sections and examples
+func readmeScore(readme *Readme) float64 {
+	score := 10.0
+	score += 10 * math.Min(1, float64(readme.Words)/goodWords)
+	score += 15 * float64(len(readme.Topics)) / float64(len(readmeTopics))
+	if readme.CodeBlocks > 0 {
+		score += 5
+	}
+	return score
+}
+
+func grade(score float64) string {
+	switch {
+	case score >= 90:
+		return "A"
+	case score >= 80:
+		return "B"
+	case score >= 70:
+		return "C"
+	case score >= passScore:
+		return "D"
+	}
+	return "F"
+}
+
+func summarize(report *Report) string {
+	project := report.Project
+	summary := fmt.Sprintf("Documentation %.0f/100 (%s)", project.Score, project.Grade)
+	if project.Readme != nil {
+		summary += fmt.Sprintf(": %s README", project.Readme.Quality)
+	} else {
+		summary += ": no README"
+	}
+	if project.Declarations > 0 {
+		summary += fmt.Sprintf(", %.0f%% of %d public declarations documented", project.Density*100, project.Declarations)
+	}
+	if len(report.LeastDocumented) > 0 {
+		worst := make([]string, len(report.LeastDocumented))
+		for i, name := range report.LeastDocumented {
+			worst[i] = fmt.Sprintf("%s (%.0f)", name, report.Areas[i].Score)
+		}
+		summary += "; least documented: " + strings.Join(worst, ", ")
+	}
+	return summary
+}
+
+// maybeSpec reports whether a file could be an API specification, before reading it
+func maybeSpec(rel string) bool {
+	switch strings.ToLower(path.Ext(rel)) {
+	case ".yaml", ".yml", ".json", ".graphql", ".graphqls", ".gql":
+		return true
+	}
+	return false
+}
+
+// topLevelDir returns the first directory of a relative path, or "" for files at the root
+func topLevelDir(rel string) string {
+	if i := strings.Index(rel, "/"); i >= 0 {
+		return rel[:i]
+	}
+	return ""
+}
+
+func isGenerated(name string) bool {
+	for _, suffix := range generatedSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func isTestFile(name string) bool {
+	return strings.HasSuffix(name, "_test.go") || strings.Contains(name, ".test.") ||
+		strings.Contains(name, ".spec.") || strings.HasPrefix(name, "test_")
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
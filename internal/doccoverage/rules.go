@@ -0,0 +1,197 @@
+package doccoverage
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"repo-explanation/internal/portable"
+)
+
+// readmeTopics are the sections an onboarding README is expected to cover, matched against its
+// headings
+var readmeTopics = []struct {
+	name    string
+	pattern *regexp.Regexp
+}{
+	{"setup", regexp.MustCompile(`(?i)\b(?:install|installation|setup|set up|prerequisites|requirements|getting started)\b`)},
+	{"usage", regexp.MustCompile(`(?i)\b(?:usage|running|run locally|how to run|quick ?start|examples?)\b`)},
+	{"configuration", regexp.MustCompile(`(?i)\b(?:configuration|config|environment variables|settings|\.env)\b`)},
+	{"testing", regexp.MustCompile(`(?i)\b(?:tests?|testing)\b`)},
+	{"architecture", regexp.MustCompile(`(?i)\b(?:architecture|overview|design|structure|how it works)\b`)},
+	{"api", regexp.MustCompile(`(?i)\b(?:api|endpoints?|routes)\b`)},
+}
+
+var (
+	headingRegex   = regexp.MustCompile(`(?m)^(?:#{1,6}\s+.+|.+\n(?:=+|-+)\s*$)`)
+	codeBlockRegex = regexp.MustCompile("(?m)^\\s*(?:```|~~~)")
+	wordRegex      = regexp.MustCompile(`[A-Za-z][A-Za-z'-]*`)
+)
+
+// inspectReadme rates a README's length, sections and examples
+func inspectReadme(rel, content string) *Readme {
+	readme := &Readme{File: rel, Words: len(wordRegex.FindAllString(content, -1)), Missing: []string{}}
+	headings := strings.Join(headingRegex.FindAllString(content, -1), "\n")
+	for _, topic := range readmeTopics {
+		if topic.pattern.MatchString(headings) {
+			readme.Topics = append(readme.Topics, topic.name)
+		} else {
+			readme.Missing = append(readme.Missing, topic.name)
+		}
+	}
+	readme.CodeBlocks = len(codeBlockRegex.FindAllString(content, -1)) / 2
+	switch {
+	case readme.Words < stubWords:
+		readme.Quality = "stub"
+	case readme.Words >= goodWords && len(readme.Topics) >= 3 && readme.CodeBlocks > 0:
+		readme.Quality = "good"
+	default:
+		readme.Quality = "basic"
+	}
+	return readme
+}
+
+// isReadme reports whether a file name is a README
+func isReadme(name string) bool {
+	name = strings.ToLower(name)
+	base := strings.TrimSuffix(name, path.Ext(name))
+	return base == "readme" && (name == base || name == "readme.md" || name == "readme.rst" || name == "readme.txt" || name == "readme.adoc")
+}
+
+// declarationRule finds public declarations in one language family and tells whether each has a
+// doc comment
+type declarationRule struct {
+	extensions []string
+	pattern    *regexp.Regexp
+	documented func(lines []string, i int) bool
+}
+
+var declarationRules = []declarationRule{
+	{[]string{".go"}, regexp.MustCompile(`^(?:func\s+(?:\([^)]*\)\s*)?|type\s+|var\s+|const\s+)[A-Z]\w*`), commentAbove("//")},
+	{[]string{".py"}, regexp.MustCompile(`^(?:    )?(?:async\s+)?(?:def|class)\s+[A-Za-z]\w*`), docstringBelow},
+	{[]string{".js", ".jsx", ".ts", ".tsx", ".mjs", ".cjs"}, regexp.MustCompile(`^export\s+(?:default\s+)?(?:declare\s+)?(?:async\s+)?(?:function\*?|class|abstract\s+class|const|let|interface|type|enum)\b`), commentAbove("*/", "//")},
+	{[]string{".java", ".cs"}, regexp.MustCompile(`^\s*public\s+(?:[\w<>\[\],?]+\s+)*?(?:\w+\s*\(|(?:class|interface|enum|record|struct)\s+\w+)`), commentAbove("*/", "///")},
+	{[]string{".kt"}, regexp.MustCompile(`^\s*(?:public\s+|open\s+|abstract\s+|data\s+|sealed\s+|suspend\s+|override\s+)*(?:fun|class|interface|object)\s+\w+`), commentAbove("*/")},
+	{[]string{".rb"}, regexp.MustCompile(`^\s*(?:def|class|module)\s+[A-Za-z]\w*`), commentAbove("#")},
+	{[]string{".php"}, regexp.MustCompile(`^\s*(?:(?:final|abstract)\s+)?class\s+\w+|^\s*public\s+(?:static\s+)?function\s+\w+`), commentAbove("*/")},
+	{[]string{".rs"}, regexp.MustCompile(`^\s*pub\s+(?:async\s+)?(?:fn|struct|enum|trait|type|const|mod)\s+\w+`), commentAbove("///", "//!")},
+}
+
+var privateNameRegex = regexp.MustCompile(`\b(?:def|class)\s+_`)
+
+// ruleFor returns the declaration rule for a file extension
+func ruleFor(ext string) *declarationRule {
+	for i, r := range declarationRules {
+		for _, e := range r.extensions {
+			if e == ext {
+				return &declarationRules[i]
+			}
+		}
+	}
+	return nil
+}
+
+// countDeclarations counts a file's public declarations and those with a doc comment
+func countDeclarations(rule *declarationRule, content string) (total, documented int) {
+	lines := portable.Lines(content)
+	for i, line := range lines {
+		if !rule.pattern.MatchString(line) {
+			continue
+		}
+		// Underscored Python and Ruby members are private by convention
+		if privateNameRegex.MatchString(line) {
+			continue
+		}
+		total++
+		if rule.documented(lines, i) {
+			documented++
+		}
+	}
+	return total, documented
+}
+
+// commentAbove reports a declaration documented when the line above it, skipping annotations,
+// attributes and decorators, starts or ends a comment with one of the markers
+func commentAbove(markers ...string) func(lines []string, i int) bool {
+	return func(lines []string, i int) bool {
+		for j := i - 1; j >= 0 && j >= i-20; j-- {
+			above := strings.TrimSpace(lines[j])
+			if strings.HasPrefix(above, "@") || strings.HasPrefix(above, "#[") || (strings.HasPrefix(above, "[") && strings.HasSuffix(above, "]")) {
+				continue
+			}
+			for _, marker := range markers {
+				if above != "" && (strings.HasPrefix(above, marker) || strings.HasSuffix(above, marker)) {
+					return true
+				}
+			}
+			return false
+		}
+		return false
+	}
+}
+
+// docstringBelow reports a Python definition documented when its body opens with a docstring
+func docstringBelow(lines []string, i int) bool {
+	j := i
+	for ; j < len(lines) && j < i+10; j++ {
+		if strings.HasSuffix(strings.TrimSpace(lines[j]), ":") {
+			break
+		}
+	}
+	for j++; j < len(lines); j++ {
+		below := strings.TrimSpace(lines[j])
+		if below == "" {
+			continue
+		}
+		below = strings.TrimLeft(below, "rRbBuU")
+		return strings.HasPrefix(below, `"""`) || strings.HasPrefix(below, `'''`)
+	}
+	return false
+}
+
+// specNameRegex matches the file names of API specifications
+var specNameRegex = regexp.MustCompile(`(?i)^(?:openapi|swagger|api[-_]?spec|api[-_]?docs?)[\w.-]*\.(?:ya?ml|json)$`)
+
+// specContentRegex recognizes an OpenAPI or Swagger document from its opening lines
+var specContentRegex = regexp.MustCompile(`(?m)^\s*["']?(?:openapi|swagger)["']?\s*:\s*["']?[23]\.`)
+
+// specAnnotations are annotations and frameworks that generate an OpenAPI document from code
+var specAnnotations = []struct {
+	source  string
+	pattern *regexp.Regexp
+}{
+	{"swag annotations", regexp.MustCompile(`^\s*//\s*@(?:Router|Summary|Success)\s`)},
+	{"JSDoc @openapi", regexp.MustCompile(`^\s*\*\s*@(?:openapi|swagger)\b`)},
+	{"springdoc annotations", regexp.MustCompile(`^\s*@(?:Operation|Tag|ApiResponse)\(`)},
+	{"NestJS Swagger", regexp.MustCompile(`^\s*@Api(?:Operation|Tags|Property|Response)\(|from\s+["']@nestjs/swagger["']`)},
+	{"FastAPI (generated)", regexp.MustCompile(`=\s*FastAPI\(`)},
+	{"drf-spectacular", regexp.MustCompile(`^\s*(?:from|import)\s+drf_(?:spectacular|yasg)\b|^\s*["']drf_(?:spectacular|yasg)["'],`)},
+	{"Swashbuckle", regexp.MustCompile(`\.AddSwaggerGen\(|^\s*using\s+Swashbuckle\b`)},
+}
+
+// specSource names the API specification a file is or generates, or returns ""
+func specSource(rel, content string) string {
+	name := path.Base(rel)
+	ext := strings.ToLower(path.Ext(name))
+	switch {
+	case ext == ".yaml" || ext == ".yml" || ext == ".json":
+		head := content
+		if len(head) > 512 {
+			head = head[:512]
+		}
+		if specContentRegex.MatchString(head) || specNameRegex.MatchString(name) && strings.Contains(content, "paths") {
+			return rel
+		}
+		return ""
+	case ext == ".graphql" || ext == ".graphqls" || ext == ".gql":
+		return rel
+	}
+	for _, line := range portable.Lines(content) {
+		for _, annotation := range specAnnotations {
+			if annotation.pattern.MatchString(line) {
+				return annotation.source
+			}
+		}
+	}
+	return ""
+}
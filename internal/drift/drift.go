@@ -0,0 +1,217 @@
+// Package drift compares the repositories of a workspace against a golden template repository
+// and reports where each has drifted from it: missing health endpoints, Makefile targets that
+// are missing or changed, CI workflows that are missing or divergent, and template dependencies
+// pinned at other versions.
+package drift
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"repo-explanation/config"
+	"repo-explanation/internal/compare"
+	"repo-explanation/internal/pipeline"
+	"repo-explanation/internal/workspace"
+)
+
+// Report is the drift of every workspace repository from the template
+type Report struct {
+	Name         string            `json:"name"`
+	Template     string            `json:"template"`
+	Repositories []RepositoryDrift `json:"repositories"`
+	Summary      string            `json:"summary"`
+}
+
+// RepositoryDrift lists how one repository differs from the template
+type RepositoryDrift struct {
+	Name             string                     `json:"name"`
+	Path             string                     `json:"path"`
+	MissingFiles     []string                   `json:"missing_files,omitempty"`     // Top-level and .github files of the template
+	MissingEndpoints []string                   `json:"missing_endpoints,omitempty"` // Health, readiness and metrics endpoints, "GET /healthz"
+	Makefiles        []MakefileDrift            `json:"makefiles,omitempty"`
+	CI               []CIDrift                  `json:"ci,omitempty"`
+	Dependencies     []compare.DependencyChange `json:"dependencies,omitempty"` // From is the template's version
+	Findings         int                        `json:"findings"`
+	Error            string                     `json:"error,omitempty"`
+}
+
+// MakefileDrift compares one Makefile of the template with the file at the same path
+type MakefileDrift struct {
+	File           string   `json:"file"`
+	Missing        bool     `json:"missing,omitempty"`
+	MissingTargets []string `json:"missing_targets,omitempty"`
+	ChangedTargets []string `json:"changed_targets,omitempty"` // Recipe differs from the template's
+}
+
+// CI file states
+const (
+	CIMissing   = "missing"   // The template has the file, the repository does not
+	CIDivergent = "divergent" // Both have it, with other jobs or action versions
+	CIExtra     = "extra"     // Only the repository has it
+)
+
+// CIDrift compares one CI configuration file of the template with the file at the same path
+type CIDrift struct {
+	File        string   `json:"file"`
+	Status      string   `json:"status"`
+	MissingJobs []string `json:"missing_jobs,omitempty"`
+	ExtraJobs   []string `json:"extra_jobs,omitempty"`
+	Actions     []string `json:"actions,omitempty"` // "actions/checkout: v4 → v3"
+}
+
+// Analyzer analyzes the template and every repository of a workspace and compares them
+type Analyzer struct {
+	config    *config.Config
+	workspace *workspace.Workspace
+	options   pipeline.AnalysisOptions
+}
+
+// NewAnalyzer creates a drift analyzer; the workspace needs a template, and the template and
+// every repository must be available at their local Path. Options without phases run
+// compare.DefaultPhases.
+func NewAnalyzer(cfg *config.Config, ws *workspace.Workspace, opts pipeline.AnalysisOptions) (*Analyzer, error) {
+	if err := ws.Validate(); err != nil {
+		return nil, err
+	}
+	if ws.Template == nil {
+		return nil, fmt.Errorf("workspace %s has no template repository", ws.Name)
+	}
+	if len(opts.Phases) == 0 {
+		opts.Phases = compare.DefaultPhases
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid analysis options: %v", err)
+	}
+	for _, repo := range append([]workspace.Repository{*ws.Template}, ws.Repositories...) {
+		if repo.Path == "" {
+			return nil, fmt.Errorf("repository %s has not been checked out locally", repo.Name)
+		}
+	}
+
+	return &Analyzer{
+		config:    cfg,
+		workspace: ws,
+		options:   opts,
+	}, nil
+}
+
+// Analyze captures the template, then each repository, and reports how each repository differs
+// from the template. A repository that fails to analyze is reported with its error.
+func (da *Analyzer) Analyze(ctx context.Context, callback pipeline.ProgressCallback) (*Report, error) {
+	template := *da.workspace.Template
+	total := len(da.workspace.Repositories) + 1
+
+	fmt.Printf("📐 [DRIFT] Analyzing template %s\n", template.Name)
+	base, err := da.capture(ctx, template, da.progress(template.Name, 0, total, callback))
+	if err != nil {
+		return nil, fmt.Errorf("analysis of template %s failed: %v", template.Name, err)
+	}
+	baseStructure := readStructure(template.Path, da.config.GetScanFileBytes())
+
+	report := &Report{Name: da.workspace.Name, Template: template.Name}
+	for i, repo := range da.workspace.Repositories {
+		fmt.Printf("📐 [DRIFT] Analyzing repository %d/%d: %s\n", i+1, total-1, repo.Name)
+		drift := RepositoryDrift{Name: repo.Name, Path: repo.Path}
+		snap, err := da.capture(ctx, repo, da.progress(repo.Name, i+1, total, callback))
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("drift analysis cancelled: %v", ctx.Err())
+			}
+			fmt.Printf("⚠️  [DRIFT] Analysis of %s failed: %v\n", repo.Name, err)
+			drift.Error = err.Error()
+		} else {
+			drift = compareRepository(repo, base, snap, baseStructure, readStructure(repo.Path, da.config.GetScanFileBytes()))
+		}
+		report.Repositories = append(report.Repositories, drift)
+	}
+
+	report.Summary = summarize(report)
+	callback("progress", "📐 Drift compared", report.Summary, 100, nil)
+	fmt.Printf("✅ [DRIFT] %s\n", report.Summary)
+	return report, nil
+}
+
+// progress maps one repository's 0-100 progress into its share of the run
+func (da *Analyzer) progress(name string, index, total int, callback pipeline.ProgressCallback) pipeline.ProgressCallback {
+	return func(eventType, stage, message string, progress int, data interface{}) {
+		callback(eventType, fmt.Sprintf("[%s] %s", name, stage), message, (index*100+progress)*95/(total*100), nil)
+	}
+}
+
+// capture runs the scoped analysis on one repository and completes it with endpoints and dependencies
+func (da *Analyzer) capture(ctx context.Context, repo workspace.Repository, callback pipeline.ProgressCallback) (*compare.Snapshot, error) {
+	analyzer, err := pipeline.NewAnalyzerWithURL(da.config, repo.Path, repo.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create analyzer: %v", err)
+	}
+	if err := analyzer.SetOptions(da.options); err != nil {
+		return nil, fmt.Errorf("invalid analysis options: %v", err)
+	}
+	result, err := analyzer.AnalyzeProjectWithProgress(ctx, callback)
+	if err != nil {
+		return nil, err
+	}
+	return compare.Capture(repo.Path, compare.Ref{Ref: repo.Name}, result, da.config.GetScanFileBytes())
+}
+
+// operationalPathRegex matches the health, readiness, liveness, metrics and version endpoints a
+// template gives every service, as the last segment of the path
+var operationalPathRegex = regexp.MustCompile(`(?i)/(?:health|healthz|healthcheck|health-check|livez|live|liveness|readyz|ready|readiness|ping|status|metrics|version|info)/?$`)
+
+// compareRepository diffs a repository against the template; the dependency and endpoint
+// differences come from the same diff as branch comparison
+func compareRepository(repo workspace.Repository, base, snap *compare.Snapshot, baseStructure, structure *repoStructure) RepositoryDrift {
+	drift := RepositoryDrift{Name: repo.Name, Path: repo.Path}
+	diff := compare.Diff(base, snap)
+
+	for _, endpoint := range diff.Endpoints.Removed {
+		if operationalPathRegex.MatchString(endpoint.Path) {
+			drift.MissingEndpoints = append(drift.MissingEndpoints, endpoint.Key())
+		}
+	}
+	sort.Strings(drift.MissingEndpoints)
+	drift.Dependencies = diff.Dependencies.Changed
+
+	for _, file := range baseStructure.files {
+		if !structure.hasFile(file) {
+			drift.MissingFiles = append(drift.MissingFiles, file)
+		}
+	}
+	drift.Makefiles = compareMakefiles(baseStructure.makefiles, structure.makefiles)
+	drift.CI = compareCI(baseStructure.ci, structure.ci)
+
+	drift.Findings = len(drift.MissingFiles) + len(drift.MissingEndpoints) + len(drift.Dependencies)
+	for _, makefile := range drift.Makefiles {
+		if makefile.Missing {
+			drift.Findings++
+		}
+		drift.Findings += len(makefile.MissingTargets) + len(makefile.ChangedTargets)
+	}
+	drift.Findings += len(drift.CI)
+	return drift
+}
+
+// summarize returns a one-line summary of the report
+func summarize(report *Report) string {
+	drifted, failed, findings := 0, 0, 0
+	for _, repo := range report.Repositories {
+		switch {
+		case repo.Error != "":
+			failed++
+		case repo.Findings > 0:
+			drifted++
+			findings += repo.Findings
+		}
+	}
+	if drifted == 0 && failed == 0 {
+		return fmt.Sprintf("All %d repositories match template %s", len(report.Repositories), report.Template)
+	}
+	parts := []string{fmt.Sprintf("%d of %d repositories drifted from template %s (%d findings)", drifted, len(report.Repositories), report.Template, findings)}
+	if failed > 0 {
+		parts = append(parts, fmt.Sprintf("%d could not be analyzed", failed))
+	}
+	return strings.Join(parts, ", ")
+}
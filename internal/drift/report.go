@@ -0,0 +1,71 @@
+package drift
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Text renders the report as plain text, one block per repository that drifted
+func (r *Report) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n", r.Summary)
+
+	for _, repo := range r.Repositories {
+		switch {
+		case repo.Error != "":
+			fmt.Fprintf(&b, "\n❌ %s: %s\n", repo.Name, repo.Error)
+			continue
+		case repo.Findings == 0:
+			fmt.Fprintf(&b, "\n✅ %s matches the template\n", repo.Name)
+			continue
+		}
+		fmt.Fprintf(&b, "\n📐 %s (%d findings)\n", repo.Name, repo.Findings)
+		for _, file := range repo.MissingFiles {
+			fmt.Fprintf(&b, "  - missing file %s\n", file)
+		}
+		for _, endpoint := range repo.MissingEndpoints {
+			fmt.Fprintf(&b, "  - missing endpoint %s\n", endpoint)
+		}
+		for _, makefile := range repo.Makefiles {
+			if makefile.Missing {
+				fmt.Fprintf(&b, "  - missing %s\n", makefile.File)
+				continue
+			}
+			if len(makefile.MissingTargets) > 0 {
+				fmt.Fprintf(&b, "  - %s lacks targets %s\n", makefile.File, strings.Join(makefile.MissingTargets, ", "))
+			}
+			if len(makefile.ChangedTargets) > 0 {
+				fmt.Fprintf(&b, "  - %s targets differ from the template: %s\n", makefile.File, strings.Join(makefile.ChangedTargets, ", "))
+			}
+		}
+		for _, ci := range repo.CI {
+			switch ci.Status {
+			case CIMissing:
+				fmt.Fprintf(&b, "  - missing CI file %s\n", ci.File)
+			case CIExtra:
+				fmt.Fprintf(&b, "  - CI file %s is not in the template\n", ci.File)
+			default:
+				var changes []string
+				if len(ci.MissingJobs) > 0 {
+					changes = append(changes, "missing jobs "+strings.Join(ci.MissingJobs, ", "))
+				}
+				if len(ci.ExtraJobs) > 0 {
+					changes = append(changes, "extra jobs "+strings.Join(ci.ExtraJobs, ", "))
+				}
+				changes = append(changes, ci.Actions...)
+				fmt.Fprintf(&b, "  - %s diverges: %s\n", ci.File, strings.Join(changes, "; "))
+			}
+		}
+		for _, dep := range repo.Dependencies {
+			fmt.Fprintf(&b, "  - %s %s, template has %s (%s)\n", dep.Name, orNone(dep.To), orNone(dep.From), dep.Manifest)
+		}
+	}
+	return b.String()
+}
+
+func orNone(value string) string {
+	if value == "" {
+		return "(none)"
+	}
+	return value
+}
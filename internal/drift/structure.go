@@ -0,0 +1,262 @@
+package drift
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Directories that never hold files stamped from the template
+var skippedDirs = map[string]bool{
+	".git": true, "node_modules": true, "vendor": true, "dist": true, "build": true, ".next": true,
+	"__pycache__": true, ".venv": true, "venv": true, "target": true, "testdata": true,
+}
+
+// ciFiles are the CI configuration files outside .github/workflows
+var ciFiles = map[string]bool{
+	".gitlab-ci.yml": true, ".circleci/config.yml": true, "Jenkinsfile": true,
+	"azure-pipelines.yml": true, "bitbucket-pipelines.yml": true, ".travis.yml": true,
+}
+
+// repoStructure is what a template stamps into a repository, keyed by slash path
+type repoStructure struct {
+	files     []string          // Top-level files and .github files other than workflows
+	present   map[string]bool   // Every file seen, for the missing-file check
+	makefiles map[string]string // Makefile and *.mk contents
+	ci        map[string]string // CI configuration contents
+}
+
+func (s *repoStructure) hasFile(file string) bool {
+	return s.present[file]
+}
+
+// readStructure reads the files of a repository that templates stamp out, skipping Makefiles and
+// CI files larger than maxFileSize bytes
+func readStructure(root string, maxFileSize int64) *repoStructure {
+	s := &repoStructure{
+		present:   make(map[string]bool),
+		makefiles: make(map[string]string),
+		ci:        make(map[string]string),
+	}
+	filepath.Walk(root, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if filePath != root && skippedDirs[info.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(root, filePath)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		s.present[rel] = true
+
+		isCI := isCIFile(rel)
+		isMakefile := info.Name() == "Makefile" || info.Name() == "GNUmakefile" || strings.HasSuffix(info.Name(), ".mk")
+		if !strings.Contains(rel, "/") || (strings.HasPrefix(rel, ".github/") && !isCI) {
+			s.files = append(s.files, rel)
+		}
+		if (isCI || isMakefile) && info.Size() <= maxFileSize {
+			content, err := os.ReadFile(filePath)
+			if err != nil {
+				return nil
+			}
+			if isCI {
+				s.ci[rel] = string(content)
+			} else {
+				s.makefiles[rel] = string(content)
+			}
+		}
+		return nil
+	})
+	sort.Strings(s.files)
+	return s
+}
+
+func isCIFile(rel string) bool {
+	if ciFiles[rel] {
+		return true
+	}
+	ext := filepath.Ext(rel)
+	return strings.HasPrefix(rel, ".github/workflows/") && (ext == ".yml" || ext == ".yaml")
+}
+
+var makeTargetRegex = regexp.MustCompile(`^([A-Za-z0-9_.\-/ ]+?)\s*::?(?:[^=]|$)`)
+
+// makeTargets returns the explicit targets of a Makefile with their recipes, whitespace
+// normalized; special targets like .PHONY and pattern rules are left out
+func makeTargets(content string) map[string]string {
+	targets := make(map[string]string)
+	var current []string
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(line, "\t") {
+			recipe := strings.Join(strings.Fields(line), " ")
+			for _, target := range current {
+				targets[target] = strings.TrimSpace(targets[target] + "\n" + recipe)
+			}
+			continue
+		}
+		current = nil
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		m := makeTargetRegex.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		for _, target := range strings.Fields(m[1]) {
+			if strings.HasPrefix(target, ".") || strings.Contains(target, "%") {
+				continue
+			}
+			if _, ok := targets[target]; !ok {
+				targets[target] = ""
+			}
+			current = append(current, target)
+		}
+	}
+	return targets
+}
+
+// compareMakefiles reports the template Makefiles a repository lacks, and the targets of each
+// that are missing or run a different recipe
+func compareMakefiles(template, repo map[string]string) []MakefileDrift {
+	var drifts []MakefileDrift
+	for _, file := range sortedKeys(template) {
+		content, ok := repo[file]
+		if !ok {
+			drifts = append(drifts, MakefileDrift{File: file, Missing: true})
+			continue
+		}
+		want, have := makeTargets(template[file]), makeTargets(content)
+		drift := MakefileDrift{File: file}
+		for _, target := range sortedKeys(want) {
+			recipe, ok := have[target]
+			switch {
+			case !ok:
+				drift.MissingTargets = append(drift.MissingTargets, target)
+			case recipe != want[target]:
+				drift.ChangedTargets = append(drift.ChangedTargets, target)
+			}
+		}
+		if len(drift.MissingTargets) > 0 || len(drift.ChangedTargets) > 0 {
+			drifts = append(drifts, drift)
+		}
+	}
+	return drifts
+}
+
+// gitlabKeywords are the top-level keys of .gitlab-ci.yml that are not jobs
+var gitlabKeywords = map[string]bool{
+	"default": true, "include": true, "stages": true, "variables": true, "workflow": true,
+	"image": true, "services": true, "cache": true, "before_script": true, "after_script": true,
+}
+
+var usesRegex = regexp.MustCompile(`(?m)^\s*(?:-\s*)?uses:\s*['"]?([\w.\-/]+)@([\w.\-]+)`)
+
+// ciJobs returns the job names of a CI file: the jobs map of GitHub Actions and CircleCI, the
+// non-keyword top-level keys of GitLab CI and Bitbucket's pipelines; nil when it cannot be read
+func ciJobs(file, content string) []string {
+	var doc map[string]interface{}
+	if file == "Jenkinsfile" || yaml.Unmarshal([]byte(content), &doc) != nil {
+		return nil
+	}
+	var jobs []string
+	switch {
+	case file == ".gitlab-ci.yml":
+		for key := range doc {
+			if !gitlabKeywords[key] && !strings.HasPrefix(key, ".") {
+				jobs = append(jobs, key)
+			}
+		}
+	default:
+		if m, ok := doc["jobs"].(map[string]interface{}); ok {
+			for key := range m {
+				jobs = append(jobs, key)
+			}
+		}
+		if m, ok := doc["pipelines"].(map[string]interface{}); ok {
+			for key := range m {
+				jobs = append(jobs, key)
+			}
+		}
+	}
+	sort.Strings(jobs)
+	return jobs
+}
+
+// ciActions returns the version each GitHub action or CircleCI orb reference pins, by name
+func ciActions(content string) map[string]string {
+	actions := make(map[string]string)
+	for _, m := range usesRegex.FindAllStringSubmatch(content, -1) {
+		actions[m[1]] = m[2]
+	}
+	return actions
+}
+
+// compareCI reports the template CI files a repository lacks, those it has with other jobs or
+// action versions, and CI files only the repository has
+func compareCI(template, repo map[string]string) []CIDrift {
+	var drifts []CIDrift
+	for _, file := range sortedKeys(template) {
+		content, ok := repo[file]
+		if !ok {
+			drifts = append(drifts, CIDrift{File: file, Status: CIMissing})
+			continue
+		}
+		drift := CIDrift{File: file, Status: CIDivergent}
+		drift.MissingJobs, drift.ExtraJobs = diffLists(ciJobs(file, template[file]), ciJobs(file, content))
+		want, have := ciActions(template[file]), ciActions(content)
+		for _, name := range sortedKeys(want) {
+			if version, ok := have[name]; ok && version != want[name] {
+				drift.Actions = append(drift.Actions, name+": "+want[name]+" → "+version)
+			}
+		}
+		if len(drift.MissingJobs) > 0 || len(drift.ExtraJobs) > 0 || len(drift.Actions) > 0 {
+			drifts = append(drifts, drift)
+		}
+	}
+	for _, file := range sortedKeys(repo) {
+		if _, ok := template[file]; !ok {
+			drifts = append(drifts, CIDrift{File: file, Status: CIExtra})
+		}
+	}
+	return drifts
+}
+
+// diffLists returns the items of want missing from have, and those of have not in want
+func diffLists(want, have []string) (missing, extra []string) {
+	inWant := make(map[string]bool)
+	for _, item := range want {
+		inWant[item] = true
+	}
+	inHave := make(map[string]bool)
+	for _, item := range have {
+		inHave[item] = true
+		if !inWant[item] {
+			extra = append(extra, item)
+		}
+	}
+	for _, item := range want {
+		if !inHave[item] {
+			missing = append(missing, item)
+		}
+	}
+	return missing, extra
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,332 @@
+package editor
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"repo-explanation/internal/annotations"
+	"repo-explanation/internal/database"
+	"repo-explanation/internal/deadcode"
+	"repo-explanation/internal/dependents"
+	"repo-explanation/internal/jsonrpc"
+	internalOpenai "repo-explanation/internal/openai"
+)
+
+// maxLocations bounds the number of whereIs results
+const maxLocations = 20
+
+// FileExplanation is the answer to explainFile
+type FileExplanation struct {
+	Path          string                      `json:"path"`
+	Summary       *internalOpenai.FileSummary `json:"summary"`
+	Folder        string                      `json:"folder,omitempty"`
+	FolderPurpose string                      `json:"folder_purpose,omitempty"`
+	Service       string                      `json:"service,omitempty"`
+	DeadCode      *deadcode.Finding           `json:"dead_code,omitempty"`
+}
+
+// Location is one place in the repository that matches a whereIs query
+type Location struct {
+	Path    string  `json:"path"`
+	Kind    string  `json:"kind"` // "service", "folder" or "file"
+	Score   float64 `json:"score"`
+	Summary string  `json:"summary,omitempty"`
+}
+
+// TableSchema is the answer to schemaForTable
+type TableSchema struct {
+	Table         database.Table           `json:"table"`
+	References    []database.ForeignKeyRef `json:"references,omitempty"`
+	ReferencedBy  []string                 `json:"referenced_by,omitempty"`
+	MigrationPath string                   `json:"migration_path,omitempty"`
+}
+
+// explainFile summarizes a file and places it in its folder and service.
+// The summary works before the first analysis finishes; the surrounding context fills in once it has.
+func (s *Server) explainFile(ctx context.Context, path string) (interface{}, *jsonrpc.Error) {
+	analyzer, result := s.snapshot()
+	if analyzer == nil {
+		return nil, &jsonrpc.Error{Code: codeNotReady, Message: "server not initialized"}
+	}
+	rel, err := s.relativePath(path)
+	if err != nil {
+		return nil, &jsonrpc.Error{Code: jsonrpc.CodeInvalidParams, Message: err.Error()}
+	}
+
+	summary, err := analyzer.SummarizeFile(ctx, rel)
+	if err != nil {
+		return nil, jsonrpc.Errorf(jsonrpc.CodeInternalError, "failed to summarize %s: %v", rel, err)
+	}
+
+	explanation := &FileExplanation{Path: filepath.ToSlash(rel), Summary: summary}
+	if result == nil {
+		return explanation, nil
+	}
+
+	// Nearest analyzed folder; "root" is the pipeline's key for top-level files
+	for dir := filepath.Dir(rel); ; dir = filepath.Dir(dir) {
+		key := dir
+		if dir == "." {
+			key = "root"
+		}
+		if folder := result.FolderSummaries[key]; folder != nil {
+			explanation.Folder = filepath.ToSlash(dir)
+			explanation.FolderPurpose = folder.Purpose
+			break
+		}
+		if dir == "." {
+			break
+		}
+	}
+
+	// Innermost service containing the file
+	slashRel := filepath.ToSlash(rel)
+	longest := -1
+	for _, service := range result.Services {
+		servicePath := strings.Trim(filepath.ToSlash(filepath.Clean(service.Path)), "/")
+		if servicePath == "." {
+			servicePath = ""
+		}
+		if servicePath != "" && !strings.HasPrefix(slashRel, servicePath+"/") {
+			continue
+		}
+		if len(servicePath) > longest {
+			longest = len(servicePath)
+			explanation.Service = service.Name
+		}
+	}
+
+	if result.DeadCode != nil {
+		for i, finding := range result.DeadCode.JSFiles {
+			if filepath.ToSlash(finding.Path) == slashRel {
+				explanation.DeadCode = &result.DeadCode.JSFiles[i]
+			}
+		}
+		for i, finding := range result.DeadCode.GoPackages {
+			if filepath.ToSlash(finding.Path) == filepath.ToSlash(filepath.Dir(rel)) {
+				explanation.DeadCode = &result.DeadCode.GoPackages[i]
+			}
+		}
+	}
+	return explanation, nil
+}
+
+// whereIs ranks services, folders and files by how well their names and summaries match a feature description
+func (s *Server) whereIs(feature string) (interface{}, *jsonrpc.Error) {
+	analyzer, result := s.snapshot()
+	if result == nil {
+		return nil, &jsonrpc.Error{Code: codeNotReady, Message: "analysis in progress"}
+	}
+
+	terms := queryTerms(feature)
+	if len(terms) == 0 {
+		return []Location{}, nil
+	}
+
+	var locations []Location
+	for _, service := range result.Services {
+		if score := matchScore(terms, service.Path+" "+service.Name, service.Description); score > 0 {
+			locations = append(locations, Location{Path: filepath.ToSlash(service.Path), Kind: "service", Score: score + 0.5, Summary: service.Description})
+		}
+	}
+	for path, folder := range result.FolderSummaries {
+		if folder == nil {
+			continue
+		}
+		if path == "root" {
+			path = "."
+		}
+		if score := matchScore(terms, path+" "+strings.Join(folder.KeyModules, " "), folder.Purpose); score > 0 {
+			locations = append(locations, Location{Path: filepath.ToSlash(path), Kind: "folder", Score: score + 0.25, Summary: folder.Purpose})
+		}
+	}
+	for path, summary := range analyzer.FileSummaries() {
+		if summary == nil {
+			continue
+		}
+		names := path + " " + strings.Join(summary.KeyTypes, " ") + " " + strings.Join(summary.Functions, " ")
+		if score := matchScore(terms, names, summary.Purpose); score > 0 {
+			locations = append(locations, Location{Path: path, Kind: "file", Score: score, Summary: summary.Purpose})
+		}
+	}
+
+	sort.Slice(locations, func(i, j int) bool {
+		if locations[i].Score != locations[j].Score {
+			return locations[i].Score > locations[j].Score
+		}
+		return locations[i].Path < locations[j].Path
+	})
+	if len(locations) > maxLocations {
+		locations = locations[:maxLocations]
+	}
+	if locations == nil {
+		locations = []Location{}
+	}
+	return locations, nil
+}
+
+// dependents lists everything depending on a service, package or table, with its evidence
+func (s *Server) dependents(name string) (interface{}, *jsonrpc.Error) {
+	_, result := s.snapshot()
+	if result == nil {
+		return nil, &jsonrpc.Error{Code: codeNotReady, Message: "analysis in progress"}
+	}
+	reports, err := dependents.Find(result, name)
+	if err != nil {
+		return nil, &jsonrpc.Error{Code: jsonrpc.CodeInvalidParams, Message: err.Error()}
+	}
+	return reports, nil
+}
+
+// annotations returns the purpose and importance of every summarized file, or of one file when a
+// path is given. Until the first analysis finishes, the export a previous -annotations run left in
+// the repository is served instead.
+func (s *Server) annotations(path string) (interface{}, *jsonrpc.Error) {
+	s.mu.Lock()
+	notes, root := s.notes, s.root
+	s.mu.Unlock()
+	if notes == nil && root != "" {
+		notes, _ = annotations.Read(root)
+	}
+	if notes == nil {
+		return nil, &jsonrpc.Error{Code: codeNotReady, Message: "analysis in progress"}
+	}
+	if path == "" {
+		return notes, nil
+	}
+
+	rel, err := s.relativePath(path)
+	if err != nil {
+		return nil, &jsonrpc.Error{Code: jsonrpc.CodeInvalidParams, Message: err.Error()}
+	}
+	annotation, ok := notes.Files[filepath.ToSlash(rel)]
+	if !ok {
+		return nil, nil
+	}
+	return annotation, nil
+}
+
+// schemaForTable returns a table's columns along with the tables it references and is referenced by.
+// Unknown tables yield a null result, as LSP does for lookups that find nothing.
+func (s *Server) schemaForTable(name string) (interface{}, *jsonrpc.Error) {
+	_, result := s.snapshot()
+	if result == nil {
+		return nil, &jsonrpc.Error{Code: codeNotReady, Message: "analysis in progress"}
+	}
+	schema := result.DatabaseSchema
+	if schema == nil {
+		return nil, nil
+	}
+
+	key, ok := findTable(schema, name)
+	if !ok {
+		return nil, nil
+	}
+	table := schema.Tables[key]
+
+	tableSchema := &TableSchema{Table: table, MigrationPath: schema.MigrationPath}
+	for _, column := range sortedColumns(table) {
+		if column.References != nil {
+			tableSchema.References = append(tableSchema.References, *column.References)
+		}
+	}
+	for otherName, other := range schema.Tables {
+		for _, column := range sortedColumns(other) {
+			if column.References != nil && strings.EqualFold(unqualifiedTable(column.References.Table), unqualifiedTable(key)) {
+				tableSchema.ReferencedBy = append(tableSchema.ReferencedBy, otherName+"."+column.Name)
+			}
+		}
+	}
+	sort.Strings(tableSchema.ReferencedBy)
+	return tableSchema, nil
+}
+
+// findTable matches a table name case-insensitively, with or without its schema qualifier
+func findTable(schema *database.DatabaseSchema, name string) (string, bool) {
+	if _, ok := schema.Tables[name]; ok {
+		return name, true
+	}
+	var match string
+	for key := range schema.Tables {
+		if strings.EqualFold(key, name) || strings.EqualFold(unqualifiedTable(key), name) {
+			if match == "" || key < match {
+				match = key
+			}
+		}
+	}
+	return match, match != ""
+}
+
+// unqualifiedTable drops the schema qualifier from a table name
+func unqualifiedTable(name string) string {
+	if idx := strings.LastIndex(name, "."); idx >= 0 {
+		return name[idx+1:]
+	}
+	return name
+}
+
+// sortedColumns returns a table's columns in name order
+func sortedColumns(table database.Table) []database.Column {
+	columns := make([]database.Column, 0, len(table.Columns))
+	for _, column := range table.Columns {
+		columns = append(columns, column)
+	}
+	sort.Slice(columns, func(i, j int) bool { return columns[i].Name < columns[j].Name })
+	return columns
+}
+
+// queryStopWords carry no meaning in a feature description
+var queryStopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "where": true, "what": true, "how": true,
+	"with": true, "that": true, "this": true, "code": true, "does": true, "handled": true,
+}
+
+// queryTerms lowercases a feature description into distinct search terms, dropping short and filler words
+func queryTerms(feature string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(feature), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+
+	seen := make(map[string]bool)
+	var terms []string
+	for _, field := range fields {
+		if len(field) < 3 || queryStopWords[field] {
+			continue
+		}
+		// "payments" should find "payment_service"
+		if len(field) > 4 && strings.HasSuffix(field, "s") && !strings.HasSuffix(field, "ss") {
+			field = strings.TrimSuffix(field, "s")
+		}
+		if !seen[field] {
+			seen[field] = true
+			terms = append(terms, field)
+		}
+	}
+	return terms
+}
+
+// matchScore weighs matches in names and paths above matches in prose.
+// Every matched term counts for more than any weighting, so broader matches rank first.
+func matchScore(terms []string, names, description string) float64 {
+	names = strings.ToLower(names)
+	description = strings.ToLower(description)
+
+	score := 0.0
+	for _, term := range terms {
+		inNames := strings.Contains(names, term)
+		inDescription := strings.Contains(description, term)
+		if !inNames && !inDescription {
+			continue
+		}
+		score += 10
+		if inNames {
+			score += 2
+		}
+		if inDescription {
+			score++
+		}
+	}
+	return score
+}
@@ -0,0 +1,343 @@
+package editor
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"repo-explanation/config"
+	"repo-explanation/internal/annotations"
+	"repo-explanation/internal/jsonrpc"
+	"repo-explanation/internal/pipeline"
+)
+
+// codeNotReady is returned while the initial analysis is still running (LSP's ServerNotInitialized)
+const codeNotReady = -32002
+
+// Methods lists the custom requests editor plugins can send besides the LSP lifecycle messages
+var Methods = []string{"explainFile", "whereIs", "schemaForTable", "dependents", "annotations", "status"}
+
+// Server answers editor queries over JSON-RPC from one analysis kept up to date as files are saved
+type Server struct {
+	config  *config.Config
+	options pipeline.AnalysisOptions
+	writer  *jsonrpc.Writer
+
+	mu        sync.Mutex
+	root      string
+	analyzer  *pipeline.Analyzer
+	result    *pipeline.AnalysisResult
+	notes     *annotations.Export // File purposes and importance scores of the last analysis
+	analyzing bool
+	rerun     bool  // A file was saved while analyzing; run again afterwards
+	lastErr   error // Error of the most recent analysis, if it failed
+}
+
+// NewServer creates an editor server; root may be empty, in which case the client's initialize request sets it
+func NewServer(cfg *config.Config, root string, opts pipeline.AnalysisOptions) *Server {
+	return &Server{config: cfg, root: root, options: opts}
+}
+
+// Serve reads requests from in and writes responses to out until the client sends exit or closes the stream
+func (s *Server) Serve(ctx context.Context, in io.Reader, out io.Writer) error {
+	s.writer = jsonrpc.NewFramedWriter(out)
+	reader := bufio.NewReader(in)
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		body, err := jsonrpc.ReadFramed(reader)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var req jsonrpc.Request
+		if err := json.Unmarshal(body, &req); err != nil {
+			s.reply(json.RawMessage("null"), nil, jsonrpc.Errorf(jsonrpc.CodeParseError, "invalid JSON: %v", err))
+			continue
+		}
+		if req.Method == "" {
+			if !req.IsNotification() {
+				s.reply(req.ID, nil, &jsonrpc.Error{Code: jsonrpc.CodeInvalidRequest, Message: "missing method"})
+			}
+			continue
+		}
+		if req.Method == "exit" {
+			return nil
+		}
+
+		// Explaining a file may call the LLM, so requests are answered concurrently
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, rpcErr := s.handle(ctx, &req)
+			if !req.IsNotification() {
+				s.reply(req.ID, result, rpcErr)
+			}
+		}()
+	}
+}
+
+// handle dispatches one request
+func (s *Server) handle(ctx context.Context, req *jsonrpc.Request) (interface{}, *jsonrpc.Error) {
+	switch req.Method {
+	case "initialize":
+		return s.initialize(ctx, req)
+	case "initialized", "$/cancelRequest", "textDocument/didOpen", "textDocument/didChange", "textDocument/didClose":
+		return nil, nil
+	case "shutdown":
+		return nil, nil
+	case "textDocument/didSave":
+		return nil, s.didSave(ctx, req)
+	case "status":
+		return s.status(), nil
+	case "explainFile":
+		var params struct {
+			Path string `json:"path"`
+		}
+		if err := req.DecodeParams(&params); err != nil || params.Path == "" {
+			return nil, &jsonrpc.Error{Code: jsonrpc.CodeInvalidParams, Message: "explainFile requires a path"}
+		}
+		return s.explainFile(ctx, params.Path)
+	case "whereIs":
+		var params struct {
+			Feature string `json:"feature"`
+		}
+		if err := req.DecodeParams(&params); err != nil || strings.TrimSpace(params.Feature) == "" {
+			return nil, &jsonrpc.Error{Code: jsonrpc.CodeInvalidParams, Message: "whereIs requires a feature"}
+		}
+		return s.whereIs(params.Feature)
+	case "schemaForTable":
+		var params struct {
+			Name string `json:"name"`
+		}
+		if err := req.DecodeParams(&params); err != nil || params.Name == "" {
+			return nil, &jsonrpc.Error{Code: jsonrpc.CodeInvalidParams, Message: "schemaForTable requires a name"}
+		}
+		return s.schemaForTable(params.Name)
+	case "dependents":
+		var params struct {
+			Name string `json:"name"`
+		}
+		if err := req.DecodeParams(&params); err != nil || strings.TrimSpace(params.Name) == "" {
+			return nil, &jsonrpc.Error{Code: jsonrpc.CodeInvalidParams, Message: "dependents requires a name"}
+		}
+		return s.dependents(params.Name)
+	case "annotations":
+		var params struct {
+			Path string `json:"path"`
+		}
+		if err := req.DecodeParams(&params); err != nil {
+			return nil, &jsonrpc.Error{Code: jsonrpc.CodeInvalidParams, Message: "annotations takes an optional path"}
+		}
+		return s.annotations(params.Path)
+	}
+	return nil, jsonrpc.Errorf(jsonrpc.CodeMethodNotFound, "unknown method %q", req.Method)
+}
+
+// initialize creates the analyzer for the workspace root and starts the first analysis in the background
+func (s *Server) initialize(ctx context.Context, req *jsonrpc.Request) (interface{}, *jsonrpc.Error) {
+	var params struct {
+		RootURI  string `json:"rootUri"`
+		RootPath string `json:"rootPath"`
+	}
+	req.DecodeParams(&params)
+
+	s.mu.Lock()
+	if s.analyzer == nil {
+		if s.root == "" {
+			s.root = params.RootPath
+			if params.RootURI != "" {
+				s.root = pathFromURI(params.RootURI)
+			}
+		}
+		if s.root == "" {
+			s.mu.Unlock()
+			return nil, &jsonrpc.Error{Code: jsonrpc.CodeInvalidParams, Message: "no workspace root: pass rootUri or start the server with -path"}
+		}
+
+		analyzer, err := pipeline.NewAnalyzer(s.config, s.root)
+		if err == nil {
+			err = analyzer.SetOptions(s.options)
+		}
+		if err != nil {
+			s.mu.Unlock()
+			return nil, jsonrpc.Errorf(jsonrpc.CodeInternalError, "failed to create analyzer: %v", err)
+		}
+		analyzer.EnableIncremental()
+		s.analyzer = analyzer
+	}
+	s.mu.Unlock()
+
+	go s.analyze(ctx)
+
+	return map[string]interface{}{
+		"capabilities": map[string]interface{}{
+			"textDocumentSync": map[string]interface{}{"openClose": false, "save": true},
+			"experimental":     map[string]interface{}{"repoExplanation": Methods},
+		},
+		"serverInfo": map[string]string{"name": "repo-explanation"},
+	}, nil
+}
+
+// didSave marks a saved file as changed and refreshes the analysis
+func (s *Server) didSave(ctx context.Context, req *jsonrpc.Request) *jsonrpc.Error {
+	var params struct {
+		TextDocument struct {
+			URI string `json:"uri"`
+		} `json:"textDocument"`
+	}
+	if err := req.DecodeParams(&params); err != nil {
+		return &jsonrpc.Error{Code: jsonrpc.CodeInvalidParams, Message: err.Error()}
+	}
+
+	rel, err := s.relativePath(params.TextDocument.URI)
+	if err != nil {
+		return nil // Files outside the workspace do not affect the analysis
+	}
+
+	s.mu.Lock()
+	analyzer := s.analyzer
+	s.mu.Unlock()
+	if analyzer == nil {
+		return nil
+	}
+	analyzer.MarkChanged([]string{rel})
+	go s.analyze(ctx)
+	return nil
+}
+
+// analyze runs the pipeline, coalescing saves that arrive while a run is in progress into one more run
+func (s *Server) analyze(ctx context.Context) {
+	s.mu.Lock()
+	if s.analyzing {
+		s.rerun = true
+		s.mu.Unlock()
+		return
+	}
+	s.analyzing = true
+	analyzer := s.analyzer
+	s.mu.Unlock()
+
+	for {
+		runCtx, cancel := context.WithTimeout(ctx, s.config.GetAnalysisTimeout())
+		result, err := analyzer.AnalyzeProjectWithProgress(runCtx, func(eventType, stage, message string, progress int, data interface{}) {
+			if eventType == "progress" {
+				s.notify("analysis/progress", map[string]interface{}{"stage": stage, "message": message, "progress": progress})
+			}
+		})
+		cancel()
+
+		s.mu.Lock()
+		if err == nil {
+			s.result = result
+			s.notes = analyzer.Annotations()
+		}
+		s.lastErr = err
+		again := s.rerun && ctx.Err() == nil
+		s.rerun = false
+		if !again {
+			s.analyzing = false
+		}
+		s.mu.Unlock()
+
+		if err != nil {
+			s.notify("analysis/failed", map[string]string{"error": err.Error()})
+		} else {
+			s.notify("analysis/ready", s.status())
+		}
+		if !again {
+			return
+		}
+	}
+}
+
+// status describes the current analysis
+func (s *Server) status() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := map[string]interface{}{
+		"root":      s.root,
+		"ready":     s.result != nil,
+		"analyzing": s.analyzing,
+	}
+	if s.lastErr != nil {
+		status["error"] = s.lastErr.Error()
+	}
+	if s.result != nil {
+		status["services"] = len(s.result.Services)
+		status["folders"] = len(s.result.FolderSummaries)
+		if s.result.DatabaseSchema != nil {
+			status["tables"] = len(s.result.DatabaseSchema.Tables)
+		}
+	}
+	return status
+}
+
+// snapshot returns the analyzer and the latest result without holding the lock during queries
+func (s *Server) snapshot() (*pipeline.Analyzer, *pipeline.AnalysisResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.analyzer, s.result
+}
+
+// relativePath converts a file URI, absolute path or project-relative path into a project-relative path
+func (s *Server) relativePath(path string) (string, error) {
+	s.mu.Lock()
+	root := s.root
+	s.mu.Unlock()
+
+	path = pathFromURI(path)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(absRoot, filepath.Clean(path))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside the workspace", path)
+	}
+	return rel, nil
+}
+
+func (s *Server) reply(id json.RawMessage, result interface{}, rpcErr *jsonrpc.Error) {
+	if err := s.writer.Reply(id, result, rpcErr); err != nil {
+		fmt.Printf("❌ [EDITOR] Failed to write response: %v\n", err)
+	}
+}
+
+func (s *Server) notify(method string, params interface{}) {
+	if err := s.writer.Notify(method, params); err != nil {
+		fmt.Printf("❌ [EDITOR] Failed to write notification: %v\n", err)
+	}
+}
+
+// pathFromURI turns a file:// URI into a filesystem path; other strings are returned unchanged
+func pathFromURI(uri string) string {
+	if !strings.HasPrefix(uri, "file://") {
+		return uri
+	}
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return strings.TrimPrefix(uri, "file://")
+	}
+	path := parsed.Path
+	// file:///C:/repo on Windows
+	if len(path) > 2 && path[0] == '/' && path[2] == ':' {
+		path = path[1:]
+	}
+	return filepath.FromSlash(path)
+}
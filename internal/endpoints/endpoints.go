@@ -0,0 +1,306 @@
+package endpoints
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Endpoint is one HTTP route a project serves
+type Endpoint struct {
+	Method    string `json:"method"` // GET, POST, ... or ANY when the route accepts every method
+	Path      string `json:"path"`
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	Framework string `json:"framework"`
+}
+
+// Key identifies an endpoint independently of the file declaring it
+func (e Endpoint) Key() string {
+	return e.Method + " " + e.Path
+}
+
+// Directories that never hold the project's own routes
+var skippedDirs = map[string]bool{
+	".git": true, "node_modules": true, "vendor": true, "dist": true, "build": true, ".next": true,
+	"__pycache__": true, ".venv": true, "venv": true, "target": true, "testdata": true, "coverage": true,
+}
+
+var (
+	// Go routers: echo/gin/fiber/chi e.GET("/x", ...), r.Get("/x", ...), mux.HandleFunc("/x", ...)
+	goRouteRegex  = regexp.MustCompile(`\.(GET|POST|PUT|PATCH|DELETE|HEAD|OPTIONS|Get|Post|Put|Patch|Delete|Head|Options|Any|All|Handle|HandleFunc)\(\s*"(/[^"]*|(?:GET|POST|PUT|PATCH|DELETE) /[^"]*)"`)
+	goGroupRegex  = regexp.MustCompile(`(\w+)\s*:?=\s*\w+\.(?:Group|Route)\(\s*"(/[^"]*)"`)
+	goReceiverRef = regexp.MustCompile(`(\w+)\.(?:GET|POST|PUT|PATCH|DELETE|HEAD|OPTIONS|Get|Post|Put|Patch|Delete|Head|Options|Any|All|Handle|HandleFunc)\(`)
+
+	// Express, Fastify, Koa and Hono: app.get('/x', ...), router.post("/x", ...)
+	jsRouteRegex = regexp.MustCompile("\\b(?:app|router|server|route|routes|api|fastify|hono)\\.(get|post|put|patch|delete|all|options|head)\\(\\s*['\"`](/[^'\"`]*)")
+
+	// NestJS: @Controller('users') with @Get(':id')
+	nestControllerRegex = regexp.MustCompile(`@Controller\(\s*(?:['"]([^'"]*)['"])?`)
+	nestRouteRegex      = regexp.MustCompile(`@(Get|Post|Put|Patch|Delete|All|Options|Head)\(\s*(?:['"]([^'"]*)['"])?\s*\)`)
+
+	// Flask @app.route('/x', methods=['POST']) and FastAPI @router.get('/x')
+	flaskRouteRegex   = regexp.MustCompile(`@\w+\.route\(\s*['"]([^'"]+)['"](?:[^)]*methods\s*=\s*[\[(]([^\])]*)[\])])?`)
+	fastAPIRouteRegex = regexp.MustCompile(`@\w+\.(get|post|put|patch|delete|options|head)\(\s*['"]([^'"]*)['"]`)
+	djangoPathRegex   = regexp.MustCompile(`\b(?:re_)?path\(\s*r?['"]([^'"]*)['"]`)
+
+	// Spring: a class-level @RequestMapping prefix and method-level @GetMapping("/x")
+	springMappingRegex = regexp.MustCompile(`@(Get|Post|Put|Patch|Delete|Request)Mapping(?:\(\s*(?:(?:value|path)\s*=\s*)?\{?\s*"([^"]*)")?`)
+	springMethodRegex  = regexp.MustCompile(`method\s*=\s*\{?\s*RequestMethod\.(\w+)`)
+	javaClassRegex     = regexp.MustCompile(`\bclass\s+\w+`)
+
+	// Rails config/routes.rb: get '/x', to: ...
+	railsRouteRegex = regexp.MustCompile(`^\s*(get|post|put|patch|delete|match)\s+['"]([^'"]+)['"]`)
+)
+
+// Extract walks the project and lists the HTTP endpoints declared in its route registrations,
+// sorted by path and method. Source files larger than maxFileSize bytes are skipped.
+func Extract(projectPath string, maxFileSize int64) ([]Endpoint, error) {
+	var endpoints []Endpoint
+	err := filepath.Walk(projectPath, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if filePath != projectPath && skippedDirs[info.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Size() > maxFileSize || isTestFile(info.Name()) {
+			return nil
+		}
+		rel, err := filepath.Rel(projectPath, filePath)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+
+		var extract func(rel, content string) []Endpoint
+		switch ext := strings.ToLower(path.Ext(rel)); {
+		case ext == ".go":
+			extract = goEndpoints
+		case ext == ".js" || ext == ".ts" || ext == ".mjs" || ext == ".cjs":
+			extract = jsEndpoints
+		case ext == ".py":
+			extract = pythonEndpoints
+		case ext == ".java" || ext == ".kt":
+			extract = springEndpoints
+		case strings.HasSuffix(rel, "config/routes.rb"):
+			extract = railsEndpoints
+		default:
+			return nil
+		}
+
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil
+		}
+		endpoints = append(endpoints, extract(rel, string(content))...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk project: %v", err)
+	}
+
+	sort.SliceStable(endpoints, func(i, j int) bool {
+		if endpoints[i].Path != endpoints[j].Path {
+			return endpoints[i].Path < endpoints[j].Path
+		}
+		return endpoints[i].Method < endpoints[j].Method
+	})
+	return endpoints, nil
+}
+
+func goEndpoints(rel, content string) []Endpoint {
+	// Route groups declared in the same file prefix the routes registered on them
+	groups := make(map[string]string)
+	for _, match := range goGroupRegex.FindAllStringSubmatch(content, -1) {
+		groups[match[1]] = match[2]
+	}
+
+	var endpoints []Endpoint
+	for _, loc := range goRouteRegex.FindAllStringSubmatchIndex(content, -1) {
+		method := strings.ToUpper(content[loc[2]:loc[3]])
+		routePath := content[loc[4]:loc[5]]
+		switch method {
+		case "HANDLE", "HANDLEFUNC":
+			// Go 1.22 patterns carry the method: mux.HandleFunc("GET /users/{id}", ...)
+			if fields := strings.Fields(routePath); len(fields) == 2 {
+				method, routePath = fields[0], fields[1]
+			} else {
+				method = "ANY"
+			}
+		case "ALL":
+			method = "ANY"
+		}
+		if receiver := goReceiverRef.FindStringSubmatch(content[lineStart(content, loc[0]):loc[1]]); receiver != nil {
+			routePath = joinRoute(groups[receiver[1]], routePath)
+		}
+		endpoints = append(endpoints, Endpoint{Method: method, Path: routePath, File: rel, Line: lineOf(content, loc[0]), Framework: goFramework(content)})
+	}
+	return endpoints
+}
+
+func goFramework(content string) string {
+	switch {
+	case strings.Contains(content, "github.com/labstack/echo"):
+		return "echo"
+	case strings.Contains(content, "github.com/gin-gonic/gin"):
+		return "gin"
+	case strings.Contains(content, "github.com/gofiber/fiber"):
+		return "fiber"
+	case strings.Contains(content, "github.com/go-chi/chi"):
+		return "chi"
+	case strings.Contains(content, "github.com/gorilla/mux"):
+		return "gorilla"
+	}
+	return "net/http"
+}
+
+func jsEndpoints(rel, content string) []Endpoint {
+	var endpoints []Endpoint
+	framework := "express"
+	switch {
+	case strings.Contains(content, "fastify"):
+		framework = "fastify"
+	case strings.Contains(content, "hono"):
+		framework = "hono"
+	case strings.Contains(content, "koa"):
+		framework = "koa"
+	}
+	for _, loc := range jsRouteRegex.FindAllStringSubmatchIndex(content, -1) {
+		method := strings.ToUpper(content[loc[2]:loc[3]])
+		if method == "ALL" {
+			method = "ANY"
+		}
+		endpoints = append(endpoints, Endpoint{Method: method, Path: content[loc[4]:loc[5]], File: rel, Line: lineOf(content, loc[0]), Framework: framework})
+	}
+
+	if controller := nestControllerRegex.FindStringSubmatch(content); controller != nil {
+		prefix := controller[1]
+		for _, loc := range nestRouteRegex.FindAllStringSubmatchIndex(content, -1) {
+			method := strings.ToUpper(content[loc[2]:loc[3]])
+			if method == "ALL" {
+				method = "ANY"
+			}
+			sub := ""
+			if loc[4] >= 0 {
+				sub = content[loc[4]:loc[5]]
+			}
+			endpoints = append(endpoints, Endpoint{Method: method, Path: joinRoute(prefix, sub), File: rel, Line: lineOf(content, loc[0]), Framework: "nestjs"})
+		}
+	}
+	return endpoints
+}
+
+func pythonEndpoints(rel, content string) []Endpoint {
+	var endpoints []Endpoint
+	for _, loc := range flaskRouteRegex.FindAllStringSubmatchIndex(content, -1) {
+		routePath := content[loc[2]:loc[3]]
+		methods := []string{"GET"}
+		if loc[4] >= 0 {
+			methods = nil
+			for _, method := range strings.Split(content[loc[4]:loc[5]], ",") {
+				if method = strings.ToUpper(strings.Trim(strings.TrimSpace(method), `'"`)); method != "" {
+					methods = append(methods, method)
+				}
+			}
+		}
+		for _, method := range methods {
+			endpoints = append(endpoints, Endpoint{Method: method, Path: routePath, File: rel, Line: lineOf(content, loc[0]), Framework: "flask"})
+		}
+	}
+	for _, loc := range fastAPIRouteRegex.FindAllStringSubmatchIndex(content, -1) {
+		endpoints = append(endpoints, Endpoint{Method: strings.ToUpper(content[loc[2]:loc[3]]), Path: content[loc[4]:loc[5]], File: rel, Line: lineOf(content, loc[0]), Framework: "fastapi"})
+	}
+	if path.Base(rel) == "urls.py" {
+		for _, loc := range djangoPathRegex.FindAllStringSubmatchIndex(content, -1) {
+			endpoints = append(endpoints, Endpoint{Method: "ANY", Path: joinRoute("", content[loc[2]:loc[3]]), File: rel, Line: lineOf(content, loc[0]), Framework: "django"})
+		}
+	}
+	return endpoints
+}
+
+func springEndpoints(rel, content string) []Endpoint {
+	if !strings.Contains(content, "Mapping") {
+		return nil
+	}
+	// A @RequestMapping before the class declaration prefixes every method mapping
+	prefix := ""
+	classStart := len(content)
+	if loc := javaClassRegex.FindStringIndex(content); loc != nil {
+		classStart = loc[0]
+	}
+
+	var endpoints []Endpoint
+	for _, loc := range springMappingRegex.FindAllStringSubmatchIndex(content, -1) {
+		kind := content[loc[2]:loc[3]]
+		mappingPath := ""
+		if loc[4] >= 0 {
+			mappingPath = content[loc[4]:loc[5]]
+		}
+		if loc[0] < classStart {
+			if kind == "Request" {
+				prefix = mappingPath
+			}
+			continue
+		}
+		method := strings.ToUpper(kind)
+		if kind == "Request" {
+			method = "ANY"
+			lineEnd := strings.IndexByte(content[loc[0]:], '\n')
+			if lineEnd < 0 {
+				lineEnd = len(content) - loc[0]
+			}
+			if match := springMethodRegex.FindStringSubmatch(content[loc[0] : loc[0]+lineEnd]); match != nil {
+				method = strings.ToUpper(match[1])
+			}
+		}
+		endpoints = append(endpoints, Endpoint{Method: method, Path: joinRoute(prefix, mappingPath), File: rel, Line: lineOf(content, loc[0]), Framework: "spring"})
+	}
+	return endpoints
+}
+
+func railsEndpoints(rel, content string) []Endpoint {
+	var endpoints []Endpoint
+	for i, line := range strings.Split(content, "\n") {
+		if match := railsRouteRegex.FindStringSubmatch(line); match != nil {
+			method := strings.ToUpper(match[1])
+			if method == "MATCH" {
+				method = "ANY"
+			}
+			endpoints = append(endpoints, Endpoint{Method: method, Path: joinRoute("", match[2]), File: rel, Line: i + 1, Framework: "rails"})
+		}
+	}
+	return endpoints
+}
+
+// joinRoute joins a prefix and a route path into one path starting with "/"
+func joinRoute(prefix, routePath string) string {
+	joined := strings.Trim(prefix, "/")
+	if sub := strings.Trim(routePath, "/^$"); sub != "" {
+		if joined != "" {
+			joined += "/"
+		}
+		joined += sub
+	}
+	return "/" + joined
+}
+
+func lineOf(content string, offset int) int {
+	return strings.Count(content[:offset], "\n") + 1
+}
+
+func lineStart(content string, offset int) int {
+	return strings.LastIndexByte(content[:offset], '\n') + 1
+}
+
+func isTestFile(name string) bool {
+	lower := strings.ToLower(name)
+	return strings.HasSuffix(lower, "_test.go") || strings.Contains(lower, ".test.") || strings.Contains(lower, ".spec.") || strings.HasPrefix(lower, "test_")
+}
@@ -0,0 +1,318 @@
+package featureflags
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"repo-explanation/internal/filestore"
+)
+
+// Usage is one place a flag is checked
+type Usage struct {
+	File string `json:"file"` // Relative to the project root
+	Line int    `json:"line"`
+}
+
+// Flag is one feature flag or configuration toggle and the code that checks it
+type Flag struct {
+	Key      string  `json:"key"`
+	Provider string  `json:"provider"` // LaunchDarkly, Unleash, Flipt, OpenFeature, GrowthBook, Split or homegrown
+	Kind     string  `json:"kind"`     // sdk, config, env or helper
+	Files    int     `json:"files"`    // Distinct files checking the flag
+	Usages   []Usage `json:"usages"`
+}
+
+// Report is the inventory of flags a project checks
+type Report struct {
+	Providers []string `json:"providers,omitempty"` // Flag SDKs the project uses
+	Flags     []Flag   `json:"flags"`
+	Summary   string   `json:"summary"`
+}
+
+// provider is a flag SDK: the import markers that show it is used and the calls that take a flag key
+type provider struct {
+	name    string
+	markers []string // Lowercase substrings of an import or manifest entry
+	calls   []*regexp.Regexp
+}
+
+// keyPattern matches a quoted flag key; it is the only capture group of every call pattern
+const keyPattern = `["'` + "`" + `]([A-Za-z0-9_.:/\-]+)["'` + "`" + `]`
+
+var providers = []provider{
+	{
+		name:    "LaunchDarkly",
+		markers: []string{"launchdarkly", "ldclient", "ld-client"},
+		calls: []*regexp.Regexp{
+			regexp.MustCompile(`(?:[bB]ool|[sS]tring|[iI]nt|[fF]loat64|[dD]ouble|JSON|[jJ]son|[nN]umber)?[vV]ariation(?:Detail)?\s*\(\s*` + keyPattern),
+		},
+	},
+	{
+		name:    "Unleash",
+		markers: []string{"unleash"},
+		calls: []*regexp.Regexp{
+			regexp.MustCompile(`\b(?:[iI]sEnabled|is_enabled|useFlag|useVariant|[gG]etVariant|get_variant)\s*\(\s*` + keyPattern),
+		},
+	},
+	{
+		name:    "Flipt",
+		markers: []string{"flipt"},
+		calls: []*regexp.Regexp{
+			regexp.MustCompile(`\b(?:FlagKey|flagKey|flag_key)\s*[:=]\s*` + keyPattern),
+		},
+	},
+	{
+		name:    "OpenFeature",
+		markers: []string{"openfeature"},
+		calls: []*regexp.Regexp{
+			regexp.MustCompile(`\b(?:get(?:Boolean|String|Number|Object)(?:Value|Details)|(?:Boolean|String|Int|Float|Object)Value(?:Details)?|get_(?:boolean|string|integer|float|object)_(?:value|details))\s*\(\s*(?:ctx\s*,\s*)?` + keyPattern),
+		},
+	},
+	{
+		name:    "GrowthBook",
+		markers: []string{"growthbook"},
+		calls: []*regexp.Regexp{
+			regexp.MustCompile(`\b(?:isOn|isOff|IsOn|IsOff|is_on|is_off|getFeatureValue|get_feature_value|useFeatureIsOn|useFeatureValue|evalFeature|eval_feature)\s*\(\s*` + keyPattern),
+		},
+	},
+	{
+		name:    "Split",
+		markers: []string{"splitio", "@splitsoftware", "split-io"},
+		calls: []*regexp.Regexp{
+			regexp.MustCompile(`\b(?:getTreatment|get_treatment|Treatment)\s*\(\s*(?:[\w.]+\s*,\s*)?` + keyPattern),
+		},
+	},
+}
+
+// Homegrown flags: toggles on a config object, environment variables and flag helper functions
+var (
+	configFieldPattern = regexp.MustCompile(`\b(?:config|cfg|conf|settings|Config|Settings|options|opts)\.(?:(?:Features?|FeatureFlags|Flags)\.)?((?:Feature|Enable|FF)_?[A-Z]\w*|FEATURE_[A-Z0-9_]+|ENABLE_[A-Z0-9_]+)\b`)
+	flagObjectPattern  = regexp.MustCompile(`(?:^|[^\w.])(?:(?:features|flags)\.([A-Za-z_]\w*)\b|(?:features|featureFlags|feature_flags|flags)\[\s*` + keyPattern + `\s*\])`)
+	envPattern         = regexp.MustCompile(`(?:Getenv|LookupEnv|getenv|environ\.get|environ|process\.env|import\.meta\.env|ENV\.fetch|ENV)\s*(?:\(\s*|\[\s*|\.)["']?((?:(?:VITE|NEXT_PUBLIC|REACT_APP)_)?(?:(?:FEATURE|FF|ENABLE|FLAG)_[A-Z0-9_]+|[A-Z][A-Z0-9_]*_ENABLED))\b`)
+	helperPattern      = regexp.MustCompile(`\b(?:[iI]sFeatureEnabled|[fF]eatureEnabled|is_feature_enabled|feature_enabled|[hH]asFeature|has_feature|[iI]sFlagEnabled|is_flag_enabled|[fF]eatureFlag)\s*\(\s*(?:ctx\s*,\s*)?` + keyPattern)
+)
+
+// Methods on a flag object that are not flags themselves
+var flagObjectMethods = map[string]bool{
+	"get": true, "set": true, "has": true, "length": true, "map": true, "forEach": true, "keys": true,
+	"values": true, "items": true, "push": true, "filter": true, "includes": true, "Parse": true,
+	"String": true, "Bool": true, "Int": true, "Lookup": true, "Var": true, "Args": true, "NArg": true,
+	"summary": true, "Summary": true,
+}
+
+// Directories that never hold first-party code
+var skippedDirs = map[string]bool{
+	".git": true, "node_modules": true, "vendor": true, "testdata": true, "dist": true,
+	"build": true, ".next": true, "coverage": true, "__pycache__": true, ".venv": true, "venv": true,
+}
+
+var sourceExtensions = map[string]bool{
+	".go": true, ".js": true, ".jsx": true, ".ts": true, ".tsx": true, ".mjs": true, ".cjs": true,
+	".vue": true, ".svelte": true, ".py": true, ".rb": true, ".java": true, ".kt": true,
+	".cs": true, ".php": true, ".rs": true, ".swift": true, ".scala": true,
+}
+
+// Manifests whose dependency lists reveal a flag SDK
+var manifests = map[string]bool{
+	"package.json": true, "go.mod": true, "requirements.txt": true, "pyproject.toml": true,
+	"Gemfile": true, "pom.xml": true, "build.gradle": true, "build.gradle.kts": true,
+	"Cargo.toml": true, "composer.json": true,
+}
+
+// Scanner inventories the feature flags a project checks
+type Scanner struct {
+	project   *filestore.FileStore // Every file of the project
+	files     *filestore.FileStore // Source files, read on demand
+	manifests []string             // Manifest contents, lowercased
+}
+
+// NewScanner creates a feature flag scanner for the project's files
+func NewScanner(project *filestore.FileStore) *Scanner {
+	return &Scanner{project: project}
+}
+
+// Scan finds the flag SDKs in use and every flag key checked through them or through
+// homegrown toggles, with the files and lines that check each flag
+func (s *Scanner) Scan() (*Report, error) {
+	fmt.Printf("🚩 [DEBUG] Scanning feature flags in %d files\n", s.project.Len())
+	if err := s.loadFiles(); err != nil {
+		return nil, fmt.Errorf("failed to read project files: %v", err)
+	}
+
+	report := &Report{Flags: []Flag{}}
+	var active []provider
+	for _, p := range providers {
+		if s.uses(p) {
+			active = append(active, p)
+			report.Providers = append(report.Providers, p.name)
+		}
+	}
+
+	flags := make(map[string]*Flag)
+	record := func(providerName, kind, key, file string, line int) {
+		id := providerName + "\x00" + key
+		flag, ok := flags[id]
+		if !ok {
+			flag = &Flag{Key: key, Provider: providerName, Kind: kind}
+			flags[id] = flag
+		}
+		for _, usage := range flag.Usages {
+			if usage.File == file && usage.Line == line {
+				return
+			}
+		}
+		flag.Usages = append(flag.Usages, Usage{File: file, Line: line})
+	}
+
+	s.files.Walk(nil, func(file, content string) error {
+		for _, p := range active {
+			for _, call := range p.calls {
+				matchKeys(call, content, func(key string, line int) {
+					record(p.name, "sdk", key, file, line)
+				})
+			}
+		}
+		matchKeys(configFieldPattern, content, func(key string, line int) {
+			record("homegrown", "config", key, file, line)
+		})
+		matchKeys(flagObjectPattern, content, func(key string, line int) {
+			if !flagObjectMethods[key] {
+				record("homegrown", "config", key, file, line)
+			}
+		})
+		matchKeys(envPattern, content, func(key string, line int) {
+			record("homegrown", "env", key, file, line)
+		})
+		matchKeys(helperPattern, content, func(key string, line int) {
+			record("homegrown", "helper", key, file, line)
+		})
+		return nil
+	})
+
+	for _, flag := range flags {
+		files := make(map[string]bool)
+		for _, usage := range flag.Usages {
+			files[usage.File] = true
+		}
+		flag.Files = len(files)
+		report.Flags = append(report.Flags, *flag)
+	}
+	sort.Slice(report.Flags, func(i, j int) bool {
+		a, b := report.Flags[i], report.Flags[j]
+		if a.Files != b.Files {
+			return a.Files > b.Files
+		}
+		if a.Provider != b.Provider {
+			return a.Provider < b.Provider
+		}
+		return a.Key < b.Key
+	})
+
+	report.Summary = summarize(report)
+	fmt.Printf("✅ [DEBUG] %s\n", report.Summary)
+	return report, nil
+}
+
+// uses reports whether any import or manifest mentions the provider's SDK
+func (s *Scanner) uses(p provider) bool {
+	for _, manifest := range s.manifests {
+		for _, marker := range p.markers {
+			if strings.Contains(manifest, marker) {
+				return true
+			}
+		}
+	}
+	// errFound stops the walk at the first import of the SDK
+	errFound := errors.New("found")
+	err := s.files.Walk(nil, func(_, content string) error {
+		for _, line := range strings.Split(content, "\n") {
+			trimmed := strings.TrimSpace(line)
+			if !isImportLine(trimmed) {
+				continue
+			}
+			lower := strings.ToLower(trimmed)
+			for _, marker := range p.markers {
+				if strings.Contains(lower, marker) {
+					return errFound
+				}
+			}
+		}
+		return nil
+	})
+	return err == errFound
+}
+
+// isImportLine recognizes import statements closely enough to find SDK package names; Go
+// import blocks put bare quoted paths on their own lines
+func isImportLine(line string) bool {
+	for _, prefix := range []string{"import ", "from ", "require", "using ", "use ", "\""} {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return strings.Contains(line, "require(") || strings.Contains(line, "import(")
+}
+
+// matchKeys calls fn with the key and 1-based line of every match of pattern; the key is the
+// first non-empty capture group
+func matchKeys(pattern *regexp.Regexp, content string, fn func(key string, line int)) {
+	for _, loc := range pattern.FindAllStringSubmatchIndex(content, -1) {
+		for group := 1; group*2 < len(loc); group++ {
+			start, end := loc[group*2], loc[group*2+1]
+			if start < 0 || start == end {
+				continue
+			}
+			fn(content[start:end], strings.Count(content[:start], "\n")+1)
+			break
+		}
+	}
+}
+
+func summarize(report *Report) string {
+	if len(report.Flags) == 0 {
+		return "No feature flags found"
+	}
+	files := make(map[string]bool)
+	kinds := make(map[string]int)
+	for _, flag := range report.Flags {
+		kinds[flag.Kind]++
+		for _, usage := range flag.Usages {
+			files[usage.File] = true
+		}
+	}
+	var parts []string
+	for _, kind := range []string{"sdk", "config", "env", "helper"} {
+		if kinds[kind] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", kinds[kind], kind))
+		}
+	}
+	summary := fmt.Sprintf("%d feature flags checked in %d files (%s)", len(report.Flags), len(files), strings.Join(parts, ", "))
+	if len(report.Providers) > 0 {
+		summary += "; SDKs: " + strings.Join(report.Providers, ", ")
+	}
+	return summary
+}
+
+// loadFiles reads the manifests and keeps the source files for reading one at a time
+func (s *Scanner) loadFiles() error {
+	candidate := func(rel string) bool {
+		return !filestore.InDirs(rel, skippedDirs)
+	}
+	s.files = s.project.Filter(func(rel string) bool {
+		return candidate(rel) && !isManifest(path.Base(rel)) && sourceExtensions[path.Ext(rel)]
+	})
+	return s.project.Walk(func(rel string) bool {
+		return candidate(rel) && isManifest(path.Base(rel))
+	}, func(_, content string) error {
+		s.manifests = append(s.manifests, strings.ToLower(content))
+		return nil
+	})
+}
+
+func isManifest(name string) bool {
+	return manifests[name] || strings.HasPrefix(name, "requirements")
+}
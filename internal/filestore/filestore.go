@@ -0,0 +1,262 @@
+package filestore
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"repo-explanation/internal/portable"
+)
+
+// FileStore indexes a repository's files by relative path and reads their content on demand, so
+// analyzers only hold the files they are working on instead of the whole repository. Once every
+// file is added, a FileStore is safe for concurrent use.
+type FileStore struct {
+	entries     map[string]entry
+	mu          sync.Mutex
+	paths       []string // Sorted lazily; nil after Add
+	maxFileSize int64
+	transform   func(content string) string
+}
+
+type entry struct {
+	path    string  // Absolute path on disk; empty for in-memory files
+	size    int64   // Bytes on disk, or length of content
+	content *string // Set for in-memory files
+}
+
+// New creates an empty store. Files larger than maxFileSize (0 for no limit) are indexed but never
+// read, and transform (nil for none) is applied to every content read, e.g. to redact secrets.
+func New(maxFileSize int64, transform func(content string) string) *FileStore {
+	return &FileStore{
+		entries:     make(map[string]entry),
+		maxFileSize: maxFileSize,
+		transform:   transform,
+	}
+}
+
+// FromMap wraps contents already in memory, keyed by relative path
+func FromMap(files map[string]string) *FileStore {
+	store := New(0, nil)
+	for relPath, content := range files {
+		content := content
+		store.entries[portable.Slash(relPath)] = entry{size: int64(len(content)), content: &content}
+	}
+	return store
+}
+
+// Scan indexes every regular file under root by its path relative to root, without reading any.
+// Directories skip accepts (by relative path; nil skips none) are not entered, and symlinks are not
+// followed. Files larger than maxFileSize (0 for no limit) are indexed but never read.
+func Scan(root string, maxFileSize int64, skip func(relDir string) bool) *FileStore {
+	store := New(maxFileSize, nil)
+	filepath.WalkDir(root, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Skip what we can't read
+		}
+		relPath, err := filepath.Rel(root, filePath)
+		if err != nil || relPath == "." {
+			return nil
+		}
+		relPath = portable.Slash(relPath)
+		if d.IsDir() {
+			if skip != nil && skip(relPath) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		store.Add(relPath, filePath, info.Size())
+		return nil
+	})
+	return store
+}
+
+// Filter returns a store of the files match accepts, with this store's size limit and transform
+func (s *FileStore) Filter(match func(relPath string) bool) *FileStore {
+	filtered := New(s.maxFileSize, s.transform)
+	for relPath, e := range s.entries {
+		if match(relPath) {
+			filtered.entries[relPath] = e
+		}
+	}
+	return filtered
+}
+
+// InDirs reports whether one of the directories above relPath is named in dirs, for scanners that
+// skip dependency, build or test directories wherever they appear
+func InDirs(relPath string, dirs map[string]bool) bool {
+	segments := portable.Segments(relPath)
+	for _, dir := range segments[:max(len(segments)-1, 0)] {
+		if dirs[dir] {
+			return true
+		}
+	}
+	return false
+}
+
+// InHiddenDir reports whether one of the directories above relPath is hidden, such as .github
+func InHiddenDir(relPath string) bool {
+	segments := portable.Segments(relPath)
+	for _, dir := range segments[:max(len(segments)-1, 0)] {
+		if strings.HasPrefix(dir, ".") {
+			return true
+		}
+	}
+	return false
+}
+
+// Add indexes a file on disk under its relative path without reading it
+func (s *FileStore) Add(relPath, absPath string, size int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[portable.Slash(relPath)] = entry{path: absPath, size: size}
+	s.paths = nil
+}
+
+// Len returns the number of indexed files
+func (s *FileStore) Len() int {
+	return len(s.entries)
+}
+
+// Paths returns every indexed relative path, sorted
+func (s *FileStore) Paths() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.paths == nil {
+		s.paths = make([]string, 0, len(s.entries))
+		for relPath := range s.entries {
+			s.paths = append(s.paths, relPath)
+		}
+		sort.Strings(s.paths)
+	}
+	return s.paths
+}
+
+// Has reports whether a file is indexed
+func (s *FileStore) Has(relPath string) bool {
+	_, ok := s.entries[portable.Slash(relPath)]
+	return ok
+}
+
+// Size returns a file's size in bytes, or -1 when it is not indexed
+func (s *FileStore) Size(relPath string) int64 {
+	e, ok := s.entries[portable.Slash(relPath)]
+	if !ok {
+		return -1
+	}
+	return e.size
+}
+
+// Read returns a file's content; nothing is kept after the call
+func (s *FileStore) Read(relPath string) (string, error) {
+	e, ok := s.entries[portable.Slash(relPath)]
+	if !ok {
+		return "", fmt.Errorf("file %s is not in the store", relPath)
+	}
+	if s.maxFileSize > 0 && e.size > s.maxFileSize {
+		return "", fmt.Errorf("file %s is larger than %d bytes", relPath, s.maxFileSize)
+	}
+
+	var content string
+	if e.content != nil {
+		content = *e.content
+	} else {
+		data, err := os.ReadFile(e.path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read file %s: %v", e.path, err)
+		}
+		content = string(data)
+	}
+	if s.transform != nil {
+		content = s.transform(content)
+	}
+	return content, nil
+}
+
+// Head returns up to the first n bytes of a file, for checks that only look at its header. Size
+// limits do not apply, since the rest of the file is never read.
+func (s *FileStore) Head(relPath string, n int) (string, error) {
+	e, ok := s.entries[portable.Slash(relPath)]
+	if !ok {
+		return "", fmt.Errorf("file %s is not in the store", relPath)
+	}
+
+	var head string
+	if e.content != nil {
+		head = *e.content
+		if len(head) > n {
+			head = head[:n]
+		}
+	} else {
+		file, err := os.Open(e.path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read file %s: %v", e.path, err)
+		}
+		defer file.Close()
+		buf := make([]byte, n)
+		read, err := io.ReadFull(file, buf)
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			return "", fmt.Errorf("failed to read file %s: %v", e.path, err)
+		}
+		head = string(buf[:read])
+	}
+	if s.transform != nil {
+		head = s.transform(head)
+	}
+	return head, nil
+}
+
+// Walk reads the files match accepts (every file when match is nil) one at a time in path order.
+// Unreadable files are skipped; an error from visit stops the walk and is returned.
+func (s *FileStore) Walk(match func(relPath string) bool, visit func(relPath, content string) error) error {
+	for _, relPath := range s.Paths() {
+		if match != nil && !match(relPath) {
+			continue
+		}
+		content, err := s.Read(relPath)
+		if err != nil {
+			continue
+		}
+		if err := visit(relPath, content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Contents reads the files match accepts into a map, for analyzers that need a known subset at once
+func (s *FileStore) Contents(match func(relPath string) bool) map[string]string {
+	contents := make(map[string]string)
+	s.Walk(match, func(relPath, content string) error {
+		contents[relPath] = content
+		return nil
+	})
+	return contents
+}
+
+// Outline maps every indexed path to its content when match accepts it and to "" otherwise, for
+// analyzers that reason about the whole layout but read only a few kinds of files
+func (s *FileStore) Outline(match func(relPath string) bool) map[string]string {
+	outline := make(map[string]string, len(s.entries))
+	for _, relPath := range s.Paths() {
+		outline[relPath] = ""
+		if match(relPath) {
+			if content, err := s.Read(relPath); err == nil {
+				outline[relPath] = content
+			}
+		}
+	}
+	return outline
+}
@@ -0,0 +1,314 @@
+package frontend
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"repo-explanation/internal/filestore"
+)
+
+// Library is a dependency the app uses, with how many source files import it
+type Library struct {
+	Name    string `json:"name"`
+	Package string `json:"package"`
+	Files   int    `json:"files"` // Source files importing it; 0 for build-time tools such as Tailwind
+}
+
+// Route is one page the router serves
+type Route struct {
+	Path string `json:"path"`
+	File string `json:"file"`
+}
+
+// Router describes how the app maps URLs to pages
+type Router struct {
+	Library string  `json:"library"` // react-router, next, vue-router, angular, sveltekit, nuxt
+	Style   string  `json:"style"`   // config (routes declared in code) or file-based (pages, app)
+	Routes  []Route `json:"routes"`
+}
+
+// ComponentInventory counts the UI component files in an app
+type ComponentInventory struct {
+	Total   int `json:"total"`
+	Pages   int `json:"pages"`   // Files that are routes
+	Layouts int `json:"layouts"` // layout.* files and files under layouts/
+	Shared  int `json:"shared"`  // Files under components/
+}
+
+// App is one frontend application: a package.json that depends on a UI framework
+type App struct {
+	Name          string             `json:"name"`
+	Path          string             `json:"path"` // Directory relative to the project root, "" for the root
+	Framework     string             `json:"framework"`
+	Router        *Router            `json:"router,omitempty"`
+	State         []Library          `json:"state,omitempty"`
+	DesignSystems []Library          `json:"design_systems,omitempty"`
+	Components    ComponentInventory `json:"components"`
+	Summary       string             `json:"summary"`
+}
+
+// Report is the frontend architecture of a project
+type Report struct {
+	Apps    []App  `json:"apps"`
+	Summary string `json:"summary"`
+}
+
+// Directories that never hold the app's own source
+var skippedDirs = map[string]bool{
+	".git": true, "node_modules": true, "vendor": true, "dist": true, "build": true, ".next": true,
+	".nuxt": true, ".svelte-kit": true, "out": true, "coverage": true, "public": true, "storybook-static": true,
+}
+
+// Analyzer inventories the frontend apps in a project
+type Analyzer struct {
+	project *filestore.FileStore
+	files   map[string]string // Relative slash path -> content
+}
+
+// NewAnalyzer creates a frontend analyzer for the project's files
+func NewAnalyzer(project *filestore.FileStore) *Analyzer {
+	return &Analyzer{project: project}
+}
+
+// Analyze finds each frontend app and reports its framework, router and routes, state libraries,
+// design systems and component counts
+func (a *Analyzer) Analyze() (*Report, error) {
+	fmt.Printf("🖼️  [DEBUG] Starting frontend analysis of %d files\n", a.project.Len())
+
+	a.loadFiles()
+
+	report := &Report{Apps: []App{}}
+	roots := a.appRoots()
+	for _, root := range roots {
+		report.Apps = append(report.Apps, a.analyzeApp(root, roots))
+	}
+
+	if len(report.Apps) == 0 {
+		report.Summary = "No frontend app found"
+	} else {
+		var parts []string
+		for _, app := range report.Apps {
+			parts = append(parts, app.Summary)
+		}
+		report.Summary = strings.Join(parts, "; ")
+	}
+	fmt.Printf("✅ [DEBUG] Frontend analysis complete: %s\n", report.Summary)
+	return report, nil
+}
+
+// loadFiles reads package.json manifests and UI source files. It does not use the crawled files
+// because the crawler's extension filter drops .jsx, .tsx, .vue and .svelte files.
+func (a *Analyzer) loadFiles() {
+	a.files = a.project.Contents(func(rel string) bool {
+		name := path.Base(rel)
+		return !filestore.InDirs(rel, skippedDirs) &&
+			(name == "package.json" || name == "components.json" || isSourceFile(name))
+	})
+}
+
+// packageJSON is the part of a manifest the analyzer reads
+type packageJSON struct {
+	Name            string            `json:"name"`
+	Dependencies    map[string]string `json:"dependencies"`
+	DevDependencies map[string]string `json:"devDependencies"`
+}
+
+func (p packageJSON) has(dependency string) bool {
+	if _, ok := p.Dependencies[dependency]; ok {
+		return true
+	}
+	_, ok := p.DevDependencies[dependency]
+	return ok
+}
+
+// appRoots returns the directories whose package.json depends on a UI framework, sorted
+func (a *Analyzer) appRoots() []string {
+	var roots []string
+	for file, content := range a.files {
+		if path.Base(file) != "package.json" {
+			continue
+		}
+		var manifest packageJSON
+		if json.Unmarshal([]byte(content), &manifest) != nil {
+			continue
+		}
+		if detectFramework(manifest) != "" {
+			roots = append(roots, dirOf(file))
+		}
+	}
+	sort.Strings(roots)
+	return roots
+}
+
+// analyzeApp inventories the app rooted at root; files under a nested app root belong to that app
+func (a *Analyzer) analyzeApp(root string, roots []string) App {
+	var manifest packageJSON
+	json.Unmarshal([]byte(a.files[path.Join(root, "package.json")]), &manifest)
+
+	app := App{Name: manifest.Name, Path: root, Framework: detectFramework(manifest)}
+	if app.Name == "" {
+		app.Name = path.Base(root)
+		if root == "" {
+			app.Name = "root"
+		}
+	}
+
+	sources := make(map[string]string)
+	for file, content := range a.files {
+		if isSourceFile(file) && owningRoot(file, roots) == root {
+			sources[strings.TrimPrefix(strings.TrimPrefix(file, root), "/")] = content
+		}
+	}
+
+	app.Router = detectRouter(app.Framework, manifest, sources)
+	app.State = usedLibraries(stateLibraries, manifest, sources)
+	app.DesignSystems = usedLibraries(designSystems, manifest, sources)
+	if _, ok := a.files[path.Join(root, "components.json")]; ok && hasDir(sources, "components/ui") {
+		app.DesignSystems = append(app.DesignSystems, Library{Name: "shadcn/ui", Package: "components.json", Files: countImports(sources, "/components/ui/")})
+	}
+	if app.Router != nil {
+		for i := range app.Router.Routes {
+			app.Router.Routes[i].File = path.Join(root, app.Router.Routes[i].File)
+		}
+	}
+	app.Components = inventory(sources, app.Router)
+	app.Summary = summarizeApp(app)
+	return app
+}
+
+// detectFramework names the UI framework a manifest depends on, most specific first
+func detectFramework(manifest packageJSON) string {
+	for _, candidate := range []struct{ dependency, name string }{
+		{"next", "next"}, {"nuxt", "nuxt"}, {"@sveltejs/kit", "sveltekit"}, {"@remix-run/react", "remix"},
+		{"@angular/core", "angular"}, {"vue", "vue"}, {"svelte", "svelte"}, {"solid-js", "solid"},
+		{"preact", "preact"}, {"react", "react"},
+	} {
+		if manifest.has(candidate.dependency) {
+			return candidate.name
+		}
+	}
+	return ""
+}
+
+// isSourceFile reports whether a file can hold UI components or route declarations
+func isSourceFile(name string) bool {
+	if strings.Contains(name, ".test.") || strings.Contains(name, ".spec.") || strings.Contains(name, ".stories.") || strings.HasSuffix(name, ".d.ts") {
+		return false
+	}
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".js", ".jsx", ".ts", ".tsx", ".mjs", ".vue", ".svelte":
+		return true
+	}
+	return false
+}
+
+// isComponentFile reports whether a file is a UI component rather than plain logic
+func isComponentFile(file, content string) bool {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".jsx", ".tsx", ".vue", ".svelte":
+		return true
+	case ".js", ".ts":
+		// Components in .js files return JSX or register an Angular component
+		return strings.Contains(content, "@Component(") || (strings.Contains(content, "return (") && strings.Contains(content, "</"))
+	}
+	return false
+}
+
+// inventory counts component files, pages, layouts and shared components
+func inventory(sources map[string]string, router *Router) ComponentInventory {
+	pages := make(map[string]bool)
+	if router != nil {
+		for _, route := range router.Routes {
+			pages[route.File] = true
+		}
+	}
+
+	var counts ComponentInventory
+	for file, content := range sources {
+		if !isComponentFile(file, content) {
+			continue
+		}
+		counts.Total++
+		base := strings.TrimSuffix(path.Base(file), path.Ext(file))
+		switch {
+		case base == "layout" || base == "+layout" || strings.HasPrefix(base, "_app") || hasSegment(file, "layouts"):
+			counts.Layouts++
+		case hasSegment(file, "components"):
+			counts.Shared++
+		}
+	}
+	// Route files declared in router config may be plain .js modules, so pages are counted by route
+	counts.Pages = len(pages)
+	return counts
+}
+
+// summarizeApp describes an app in one line
+func summarizeApp(app App) string {
+	var summary strings.Builder
+	location := app.Path
+	if location == "" {
+		location = "root"
+	}
+	fmt.Fprintf(&summary, "%s (%s, %s)", app.Name, app.Framework, location)
+	if app.Router != nil {
+		fmt.Fprintf(&summary, ": %s routing with %d routes", app.Router.Library, len(app.Router.Routes))
+	}
+	if len(app.State) > 0 {
+		fmt.Fprintf(&summary, ", state in %s", libraryNames(app.State))
+	}
+	if len(app.DesignSystems) > 0 {
+		fmt.Fprintf(&summary, ", UI from %s", libraryNames(app.DesignSystems))
+	}
+	fmt.Fprintf(&summary, ", %d components", app.Components.Total)
+	return summary.String()
+}
+
+func libraryNames(libraries []Library) string {
+	names := make([]string, 0, len(libraries))
+	for _, library := range libraries {
+		names = append(names, library.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// owningRoot returns the deepest app root containing file
+func owningRoot(file string, roots []string) string {
+	owner, longest := "", -1
+	for _, root := range roots {
+		if (root == "" || strings.HasPrefix(file, root+"/")) && len(root) > longest {
+			owner, longest = root, len(root)
+		}
+	}
+	return owner
+}
+
+// dirOf returns the slash directory of a relative path, "" for the project root
+func dirOf(file string) string {
+	dir := path.Dir(file)
+	if dir == "." {
+		return ""
+	}
+	return dir
+}
+
+func hasSegment(file, segment string) bool {
+	for _, part := range strings.Split(path.Dir(file), "/") {
+		if part == segment {
+			return true
+		}
+	}
+	return false
+}
+
+func hasDir(sources map[string]string, dir string) bool {
+	for file := range sources {
+		if strings.HasPrefix(file, dir+"/") || strings.Contains(file, "/"+dir+"/") {
+			return true
+		}
+	}
+	return false
+}
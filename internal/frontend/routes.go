@@ -0,0 +1,268 @@
+package frontend
+
+import (
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// libraryPattern is a library the analyzer looks for: a dependency and the import that uses it
+type libraryPattern struct {
+	name     string
+	packages []string // Any of these dependencies marks the library as installed
+}
+
+// stateLibraries are client-side state management libraries
+var stateLibraries = []libraryPattern{
+	{"Redux", []string{"@reduxjs/toolkit", "redux", "react-redux"}},
+	{"Zustand", []string{"zustand"}},
+	{"Pinia", []string{"pinia"}},
+	{"Vuex", []string{"vuex"}},
+	{"MobX", []string{"mobx", "mobx-react", "mobx-react-lite"}},
+	{"Recoil", []string{"recoil"}},
+	{"Jotai", []string{"jotai"}},
+	{"NgRx", []string{"@ngrx/store"}},
+	{"XState", []string{"xstate"}},
+	{"TanStack Query", []string{"@tanstack/react-query", "@tanstack/vue-query", "react-query"}},
+	{"SWR", []string{"swr"}},
+	{"Apollo Client", []string{"@apollo/client"}},
+}
+
+// designSystems are component libraries and styling systems
+var designSystems = []libraryPattern{
+	{"Material UI", []string{"@mui/material", "@material-ui/core"}},
+	{"Ant Design", []string{"antd"}},
+	{"Chakra UI", []string{"@chakra-ui/react"}},
+	{"Mantine", []string{"@mantine/core"}},
+	{"Radix UI", []string{"@radix-ui/react-dialog", "@radix-ui/react-slot", "@radix-ui/themes", "@radix-ui/react-dropdown-menu"}},
+	{"Headless UI", []string{"@headlessui/react", "@headlessui/vue"}},
+	{"React Bootstrap", []string{"react-bootstrap"}},
+	{"Bootstrap", []string{"bootstrap"}},
+	{"Vuetify", []string{"vuetify"}},
+	{"Element Plus", []string{"element-plus"}},
+	{"Quasar", []string{"quasar"}},
+	{"Angular Material", []string{"@angular/material"}},
+	{"Tailwind CSS", []string{"tailwindcss"}},
+	{"styled-components", []string{"styled-components"}},
+	{"Emotion", []string{"@emotion/react", "@emotion/styled"}},
+}
+
+var (
+	importFromRegex  = regexp.MustCompile(`(?:from\s+|import\s*\(\s*|require\(\s*|import\s+)['"]([^'"]+)['"]`)
+	jsxRoutePath     = regexp.MustCompile(`<Route\b[^>]*?\bpath=\{?["'\x60]([^"'\x60]*)["'\x60]`)
+	objectRoutePath  = regexp.MustCompile(`\bpath\s*:\s*["'\x60]([^"'\x60]*)["'\x60]`)
+	nextPageExt      = regexp.MustCompile(`\.(?:jsx?|tsx?|mdx)$`)
+	appRouterPage    = regexp.MustCompile(`(?:^|/)page\.(?:jsx?|tsx?|mdx)$`)
+	svelteKitPage    = regexp.MustCompile(`(?:^|/)\+page\.svelte$`)
+	routeGroupRegex  = regexp.MustCompile(`^\(.*\)$`)
+	parallelSlotName = regexp.MustCompile(`^@`)
+)
+
+// usedLibraries returns the patterns the manifest installs, with the number of files importing each
+func usedLibraries(patterns []libraryPattern, manifest packageJSON, sources map[string]string) []Library {
+	var libraries []Library
+	for _, pattern := range patterns {
+		for _, pkg := range pattern.packages {
+			if manifest.has(pkg) {
+				libraries = append(libraries, Library{Name: pattern.name, Package: pkg, Files: countImports(sources, pattern.packages...)})
+				break
+			}
+		}
+	}
+	return libraries
+}
+
+// countImports counts source files importing any of the given packages (or import paths containing
+// a marker such as "/components/ui/")
+func countImports(sources map[string]string, packages ...string) int {
+	count := 0
+	for _, content := range sources {
+		for _, match := range importFromRegex.FindAllStringSubmatch(content, -1) {
+			if importsAny(match[1], packages) {
+				count++
+				break
+			}
+		}
+	}
+	return count
+}
+
+func importsAny(specifier string, packages []string) bool {
+	for _, pkg := range packages {
+		if strings.HasPrefix(pkg, "/") {
+			if strings.Contains("/"+specifier+"/", pkg) {
+				return true
+			}
+		} else if specifier == pkg || strings.HasPrefix(specifier, pkg+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// detectRouter identifies the router and lists its routes, from file-system conventions for Next,
+// Nuxt and SvelteKit and from route declarations in code for the others
+func detectRouter(framework string, manifest packageJSON, sources map[string]string) *Router {
+	switch framework {
+	case "next":
+		return nextRoutes(sources)
+	case "nuxt":
+		return fileRoutes("nuxt", sources, "pages", regexp.MustCompile(`\.vue$`))
+	case "sveltekit":
+		return svelteKitRoutes(sources)
+	case "remix":
+		return fileRoutes("remix", sources, "app/routes", nextPageExt)
+	}
+
+	var library string
+	var packages []string
+	switch {
+	case manifest.has("react-router-dom") || manifest.has("react-router"):
+		library, packages = "react-router", []string{"react-router-dom", "react-router"}
+	case manifest.has("@tanstack/react-router"):
+		library, packages = "tanstack-router", []string{"@tanstack/react-router"}
+	case manifest.has("vue-router"):
+		library, packages = "vue-router", []string{"vue-router"}
+	case manifest.has("@angular/router"):
+		library, packages = "angular", []string{"@angular/router"}
+	default:
+		return nil
+	}
+
+	router := &Router{Library: library, Style: "config", Routes: []Route{}}
+	seen := make(map[string]bool)
+	for _, file := range sortedKeys(sources) {
+		content := sources[file]
+		if !importsRouter(content, packages) {
+			continue
+		}
+		for _, pattern := range []*regexp.Regexp{jsxRoutePath, objectRoutePath} {
+			for _, match := range pattern.FindAllStringSubmatch(content, -1) {
+				routePath := match[1]
+				if library != "angular" && routePath != "*" && !strings.HasPrefix(routePath, "/") && routePath != "" {
+					// Nested route paths are relative to their parent
+					routePath = "/" + routePath
+				}
+				if routePath == "" {
+					routePath = "/"
+				}
+				if !seen[routePath] {
+					seen[routePath] = true
+					router.Routes = append(router.Routes, Route{Path: routePath, File: file})
+				}
+			}
+		}
+	}
+	return router
+}
+
+func importsRouter(content string, packages []string) bool {
+	for _, match := range importFromRegex.FindAllStringSubmatch(content, -1) {
+		if importsAny(match[1], packages) {
+			return true
+		}
+	}
+	return false
+}
+
+// nextRoutes reads the app/ directory router and the pages/ router, either of which may sit under src/
+func nextRoutes(sources map[string]string) *Router {
+	router := &Router{Library: "next", Style: "file-based", Routes: []Route{}}
+	var appRoutes, pageRoutes int
+
+	for _, file := range sortedKeys(sources) {
+		rel, dir := stripPrefix(file, "app", "src/app")
+		if dir != "" && appRouterPage.MatchString(rel) {
+			router.Routes = append(router.Routes, Route{Path: routeFromSegments(path.Dir(rel)), File: file})
+			appRoutes++
+			continue
+		}
+
+		rel, dir = stripPrefix(file, "pages", "src/pages")
+		if dir == "" || !nextPageExt.MatchString(rel) {
+			continue
+		}
+		base := path.Base(rel)
+		if strings.HasPrefix(base, "_") || strings.HasPrefix(rel, "api/") {
+			continue // _app, _document and API routes are not pages
+		}
+		router.Routes = append(router.Routes, Route{Path: routeFromFile(rel), File: file})
+		pageRoutes++
+	}
+
+	switch {
+	case appRoutes > 0 && pageRoutes > 0:
+		router.Style = "file-based (app and pages)"
+	case appRoutes > 0:
+		router.Style = "file-based (app)"
+	case pageRoutes > 0:
+		router.Style = "file-based (pages)"
+	}
+	return router
+}
+
+// svelteKitRoutes reads src/routes/**/+page.svelte
+func svelteKitRoutes(sources map[string]string) *Router {
+	router := &Router{Library: "sveltekit", Style: "file-based", Routes: []Route{}}
+	for _, file := range sortedKeys(sources) {
+		rel, dir := stripPrefix(file, "src/routes")
+		if dir != "" && svelteKitPage.MatchString(rel) {
+			router.Routes = append(router.Routes, Route{Path: routeFromSegments(path.Dir(rel)), File: file})
+		}
+	}
+	return router
+}
+
+// fileRoutes maps every matching file under dir to a route, as Nuxt and Remix do
+func fileRoutes(library string, sources map[string]string, dir string, match *regexp.Regexp) *Router {
+	router := &Router{Library: library, Style: "file-based", Routes: []Route{}}
+	for _, file := range sortedKeys(sources) {
+		rel, found := stripPrefix(file, dir, "src/"+dir)
+		if found != "" && match.MatchString(rel) {
+			router.Routes = append(router.Routes, Route{Path: routeFromFile(rel), File: file})
+		}
+	}
+	return router
+}
+
+// stripPrefix returns file relative to the first of dirs that contains it, and that dir
+func stripPrefix(file string, dirs ...string) (string, string) {
+	for _, dir := range dirs {
+		if strings.HasPrefix(file, dir+"/") {
+			return strings.TrimPrefix(file, dir+"/"), dir
+		}
+	}
+	return "", ""
+}
+
+// routeFromFile turns pages/blog/[slug].tsx into /blog/[slug] and pages/index.tsx into /
+func routeFromFile(rel string) string {
+	withoutExt := strings.TrimSuffix(rel, path.Ext(rel))
+	withoutExt = strings.TrimSuffix(withoutExt, "/index")
+	if withoutExt == "index" {
+		withoutExt = ""
+	}
+	return routeFromSegments(withoutExt)
+}
+
+// routeFromSegments drops route groups such as (marketing) and parallel slots such as @modal
+func routeFromSegments(dir string) string {
+	var segments []string
+	for _, segment := range strings.Split(dir, "/") {
+		if segment == "" || segment == "." || routeGroupRegex.MatchString(segment) || parallelSlotName.MatchString(segment) {
+			continue
+		}
+		segments = append(segments, segment)
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+func sortedKeys(sources map[string]string) []string {
+	keys := make([]string, 0, len(sources))
+	for key := range sources {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,546 @@
+package generated
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"repo-explanation/internal/filestore"
+	"repo-explanation/internal/gitignore"
+	"repo-explanation/internal/portable"
+)
+
+// Kinds of generated artifacts
+const (
+	Protobuf = "protobuf"
+	GRPC     = "grpc"
+	GraphQL  = "graphql"
+	OpenAPI  = "openapi"
+	SQL      = "sql"
+	Mock     = "mock"
+	Vendored = "vendored"
+	Other    = "other"
+)
+
+// Artifact is a file that is generated or vendored rather than written by the project's engineers
+type Artifact struct {
+	Path       string `json:"path"`
+	Generator  string `json:"generator,omitempty"` // e.g. protoc-gen-go, gqlgen, openapi-generator
+	Kind       string `json:"kind"`
+	Source     string `json:"source,omitempty"`     // Spec the file is generated from, e.g. api/user.proto
+	Reason     string `json:"reason"`               // marker, or gitattributes for linguist-generated / linguist-vendored
+	Evidence   string `json:"evidence,omitempty"`   // Marker line or .gitattributes rule
+	Regenerate string `json:"regenerate,omitempty"` // Command of the pipeline writing it
+}
+
+// Spec is a source specification and the files generated from it
+type Spec struct {
+	Path    string   `json:"path"`
+	Kind    string   `json:"kind"`
+	Outputs []string `json:"outputs"`
+}
+
+// Report lists the generated and vendored files excluded from LLM summarization
+type Report struct {
+	Artifacts []Artifact `json:"artifacts"`
+	Specs     []Spec     `json:"specs,omitempty"`
+	Folders   []string   `json:"folders,omitempty"` // Folders holding only generated or vendored files
+	Pipelines []Pipeline `json:"pipelines,omitempty"`
+	Summary   string     `json:"summary"`
+}
+
+// headBytes is how much of each file is read to look for a generation marker
+const headBytes = 2048
+
+var (
+	// Go's convention (https://go.dev/s/generatedcode), also used by gqlgen, sqlc, oapi-codegen and mockgen
+	goMarkerRegex = regexp.MustCompile(`(?m)^\s*(?://|#)\s*Code generated (.*?)\s*DO NOT EDIT`)
+	// Other generators say so in prose: "Generated by the protocol buffer compiler.  DO NOT EDIT!",
+	// "auto generated by OpenAPI Generator ... Do not edit the class manually"
+	generatedPhraseRegex = regexp.MustCompile(`(?i)\b(?:auto-?generated|auto generated|automatically generated|generated (?:by|from|with|using))\b`)
+	doNotEditRegex       = regexp.MustCompile(`(?i)\bdo not (?:edit|modify)\b|changes (?:to this file )?(?:may|will) be lost`)
+	sourceLineRegex      = regexp.MustCompile(`(?im)^\s*(?://|#|\*|--)?\s*source:\s*(\S+)`)
+	generatorRegex       = regexp.MustCompile(`(?i)\bby\s+([\w@./-]+)`)
+)
+
+// headLines is how many lines of the header are searched for a prose marker
+const headLines = 30
+
+// generators maps words found in markers to a generator name and the kind of artifact it writes,
+// most specific first
+var generators = []struct {
+	keyword *regexp.Regexp
+	name    string
+	kind    string
+}{
+	{keyword("protoc-gen-go-grpc"), "protoc-gen-go-grpc", GRPC},
+	{keyword("grpc-gateway"), "protoc-gen-grpc-gateway", GRPC},
+	{keyword("protoc-gen-"), "", Protobuf},
+	{keyword("protocol buffer compiler"), "protoc", Protobuf},
+	{keyword("protoc"), "protoc", Protobuf},
+	{keyword("buf.build"), "buf", Protobuf},
+	{keyword("gqlgen"), "gqlgen", GraphQL},
+	{keyword("graphql-codegen"), "graphql-codegen", GraphQL},
+	{keyword("genqlient"), "genqlient", GraphQL},
+	{keyword("apollo"), "apollo", GraphQL},
+	{keyword("graphql"), "", GraphQL},
+	{keyword("oapi-codegen"), "oapi-codegen", OpenAPI},
+	{keyword("openapi generator"), "openapi-generator", OpenAPI},
+	{keyword("openapi-generator"), "openapi-generator", OpenAPI},
+	{keyword("swagger"), "swagger-codegen", OpenAPI},
+	{keyword("ogen"), "ogen", OpenAPI},
+	{keyword("orval"), "orval", OpenAPI},
+	{keyword("openapi"), "", OpenAPI},
+	{keyword("sqlc"), "sqlc", SQL},
+	{keyword("mockgen"), "mockgen", Mock},
+	{keyword("mockery"), "mockery", Mock},
+}
+
+func keyword(word string) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)(?:^|[^\w-])` + regexp.QuoteMeta(word))
+}
+
+// protoSuffixes are the file name endings protoc plugins add to a .proto file's base name
+var protoSuffixes = []string{
+	"_grpc.pb.go", ".pb.gw.go", ".pb.validate.go", ".pb.go", "_pb2_grpc.py", "_pb2.pyi", "_pb2.py",
+	"_grpc_pb.js", "_grpc_pb.d.ts", "_pb.js", "_pb.d.ts", "_pb.ts", ".pb.ts", ".pb.cc", ".pb.h",
+}
+
+// skippedDirs never hold the project's own specs or .gitattributes rules
+var skippedDirs = map[string]bool{
+	".git": true, "node_modules": true, "vendor": true, "dist": true, "build": true, ".next": true,
+	"__pycache__": true, ".venv": true, "venv": true, "target": true,
+}
+
+// Detect finds the generated files among the crawled ones by their markers and by
+// linguist-generated / linguist-vendored rules in .gitattributes files, and links each to the spec it
+// is generated from and the pipeline regenerating it. Specs, generator configs and .gitattributes
+// files are looked up on disk under projectPath, since the crawler does not index them.
+func Detect(projectPath string, files *filestore.FileStore) *Report {
+	specPaths, attributes, pipelines := scanRepository(projectPath)
+	var artifacts []Artifact
+	for _, relPath := range files.Paths() {
+		if artifact, ok := attributes.match(relPath); ok {
+			artifacts = append(artifacts, artifact)
+			continue
+		}
+		head, err := files.Head(relPath, headBytes)
+		if err != nil {
+			continue
+		}
+		if artifact, ok := fromMarker(relPath, head); ok {
+			artifacts = append(artifacts, artifact)
+		}
+	}
+
+	// Specs are written by hand, so a folder holding one is not generated
+	paths := append(specPaths, files.Paths()...)
+	resolveOAPISpecs(pipelines, specPaths)
+	linkPipelines(pipelines, artifacts)
+	report := &Report{Artifacts: artifacts, Specs: linkSpecs(paths, artifacts), Folders: generatedFolders(paths, artifacts), Pipelines: pipelines}
+	if report.Artifacts == nil {
+		report.Artifacts = []Artifact{}
+	}
+	report.Summary = summarize(report)
+	return report
+}
+
+// scanRepository lists the spec files under projectPath and reads its .gitattributes rules and
+// code generator configs
+func scanRepository(projectPath string) ([]string, attributes, []Pipeline) {
+	var specPaths []string
+	var all attributes
+	var pipelines []Pipeline
+	filepath.WalkDir(projectPath, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if skippedDirs[d.Name()] && filePath != projectPath {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(projectPath, filePath)
+		if err != nil {
+			return nil
+		}
+		rel = portable.Slash(rel)
+		if isSpec(rel) {
+			specPaths = append(specPaths, rel)
+		}
+		if isPipelineConfig(rel) {
+			if content, err := os.ReadFile(filePath); err == nil {
+				pipelines = append(pipelines, parsePipelines(rel, string(content))...)
+			}
+		}
+		if d.Name() == ".gitattributes" {
+			if content, err := os.ReadFile(filePath); err == nil {
+				if rules, ok := parseAttributes(path.Dir(rel), string(content)); ok {
+					all = append(all, rules)
+				}
+			}
+		}
+		return nil
+	})
+	// Deepest directory first, so nested files override their parents
+	sort.SliceStable(all, func(i, j int) bool { return len(all[i].dir) > len(all[j].dir) })
+	return specPaths, all, pipelines
+}
+
+// fromMarker recognizes a generated file from its header
+func fromMarker(relPath, head string) (Artifact, bool) {
+	artifact := Artifact{Path: relPath, Reason: "marker"}
+	if matches := goMarkerRegex.FindStringSubmatch(head); matches != nil {
+		artifact.Evidence = strings.TrimSpace(matches[0])
+		artifact.Generator, artifact.Kind = identify(matches[1])
+	} else if marker, context := proseMarker(head); marker != "" {
+		artifact.Evidence = marker
+		artifact.Generator, artifact.Kind = identify(context)
+	} else {
+		return artifact, false
+	}
+	if len(artifact.Evidence) > 200 {
+		artifact.Evidence = artifact.Evidence[:200]
+	}
+	if matches := sourceLineRegex.FindStringSubmatch(head); matches != nil {
+		artifact.Source = strings.TrimPrefix(portable.Slash(matches[1]), "./")
+	}
+	// Protobuf output names give the generator away even when the marker does not
+	if artifact.Kind == Other && protoBase(relPath) != "" {
+		artifact.Kind = Protobuf
+	}
+	return artifact, true
+}
+
+// proseMarker finds an "@generated" tag, or a line saying the file is generated with a "do not
+// edit" warning on it or next to it. It returns the marker line and the lines around it.
+func proseMarker(head string) (string, string) {
+	lines := strings.Split(head, "\n")
+	if len(lines) > headLines {
+		lines = lines[:headLines]
+	}
+	for i, line := range lines {
+		window := strings.Join(lines[max(0, i-1):min(len(lines), i+2)], "\n")
+		if strings.Contains(line, "@generated") || (generatedPhraseRegex.MatchString(line) && doNotEditRegex.MatchString(window)) {
+			return strings.TrimSpace(line), window
+		}
+	}
+	return "", ""
+}
+
+// identify names the generator a marker mentions
+func identify(text string) (string, string) {
+	for _, g := range generators {
+		if !g.keyword.MatchString(text) {
+			continue
+		}
+		name := g.name
+		if name == "" {
+			name = generatorName(text)
+		}
+		return name, g.kind
+	}
+	return generatorName(text), Other
+}
+
+// generatorName reads "by <tool>" from a marker, e.g. "by github.com/99designs/gqlgen" as gqlgen
+func generatorName(text string) string {
+	matches := generatorRegex.FindStringSubmatch(text)
+	if matches == nil {
+		return ""
+	}
+	name := strings.TrimRight(matches[1], ".,;:")
+	if idx := strings.LastIndex(name, "/"); idx >= 0 && idx < len(name)-1 {
+		name = name[idx+1:]
+	}
+	return name
+}
+
+// attributeRules are the linguist rules of one .gitattributes file, relative to its directory
+type attributeRules struct {
+	dir                 string
+	generated, vendored *gitignore.GitIgnore
+	rules               []string
+}
+
+type attributes []attributeRules
+
+// parseAttributes reads the linguist-generated and linguist-vendored rules of a .gitattributes file
+func parseAttributes(dir, content string) (attributeRules, bool) {
+	rules := attributeRules{dir: dir, generated: gitignore.NewGitIgnore(), vendored: gitignore.NewGitIgnore()}
+	for _, line := range portable.Lines(content) {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		for _, attr := range fields[1:] {
+			var target *gitignore.GitIgnore
+			name, value, _ := strings.Cut(strings.TrimPrefix(attr, "-"), "=")
+			switch name {
+			case "linguist-generated":
+				target = rules.generated
+			case "linguist-vendored":
+				target = rules.vendored
+			default:
+				continue
+			}
+			pattern := fields[0]
+			if strings.HasPrefix(attr, "-") || value == "false" {
+				pattern = "!" + pattern
+			}
+			if target.AddPattern(pattern) == nil {
+				rules.rules = append(rules.rules, strings.TrimSpace(line))
+			}
+		}
+	}
+	return rules, len(rules.rules) > 0
+}
+
+// match checks a file, and each folder above it, against the rules of the .gitattributes files
+// that cover it
+func (all attributes) match(relPath string) (Artifact, bool) {
+	for _, rules := range all {
+		rel := relPath
+		if rules.dir != "." {
+			if !strings.HasPrefix(relPath, rules.dir+"/") {
+				continue
+			}
+			rel = strings.TrimPrefix(relPath, rules.dir+"/")
+		}
+		evidence := path.Join(rules.dir, ".gitattributes")
+		if matchesPathOrParent(rules.vendored, rel) {
+			return Artifact{Path: relPath, Kind: Vendored, Reason: "gitattributes", Evidence: evidence + ": linguist-vendored"}, true
+		}
+		if matchesPathOrParent(rules.generated, rel) {
+			artifact := Artifact{Path: relPath, Kind: Other, Reason: "gitattributes", Evidence: evidence + ": linguist-generated"}
+			if protoBase(relPath) != "" {
+				artifact.Kind = Protobuf
+			}
+			return artifact, true
+		}
+	}
+	return Artifact{}, false
+}
+
+func matchesPathOrParent(rules *gitignore.GitIgnore, rel string) bool {
+	if rules.IsIgnored(rel, false) {
+		return true
+	}
+	for dir := path.Dir(rel); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if rules.IsIgnored(dir, true) {
+			return true
+		}
+	}
+	return false
+}
+
+// linkSpecs resolves each artifact's source against the repository, inferring it from the artifact's
+// name and kind when the marker names none, and groups the artifacts per spec
+func linkSpecs(paths []string, artifacts []Artifact) []Spec {
+	byPath := make(map[string]*Spec)
+	var order []string
+	for i := range artifacts {
+		artifact := &artifacts[i]
+		spec := resolveSpec(paths, *artifact)
+		if spec == "" {
+			continue
+		}
+		artifact.Source = spec
+		if byPath[spec] == nil {
+			byPath[spec] = &Spec{Path: spec, Kind: specKind(spec, artifact.Kind)}
+			order = append(order, spec)
+		}
+		byPath[spec].Outputs = append(byPath[spec].Outputs, artifact.Path)
+	}
+	sort.Strings(order)
+	specs := make([]Spec, 0, len(order))
+	for _, spec := range order {
+		specs = append(specs, *byPath[spec])
+	}
+	return specs
+}
+
+// resolveSpec finds the spec file an artifact comes from: the source its marker names (matched by
+// path suffix, since generators record it relative to their own root), or the closest spec of the
+// right kind
+func resolveSpec(paths []string, artifact Artifact) string {
+	if artifact.Source != "" {
+		if found := closest(artifact.Path, paths, func(p string) bool {
+			return p == artifact.Source || strings.HasSuffix(p, "/"+artifact.Source)
+		}); found != "" {
+			return found
+		}
+		return artifact.Source
+	}
+
+	switch artifact.Kind {
+	case Protobuf, GRPC:
+		base := protoBase(artifact.Path)
+		if base == "" {
+			return ""
+		}
+		return closest(artifact.Path, paths, func(p string) bool { return path.Base(p) == base+".proto" })
+	case GraphQL:
+		return closest(artifact.Path, paths, func(p string) bool {
+			ext := path.Ext(p)
+			return ext == ".graphql" || ext == ".graphqls" || ext == ".gql"
+		})
+	case OpenAPI:
+		return closest(artifact.Path, paths, isOpenAPISpec)
+	}
+	return ""
+}
+
+// protoBase returns the .proto base name a protoc output is named after, or ""
+func protoBase(relPath string) string {
+	name := path.Base(relPath)
+	for _, suffix := range protoSuffixes {
+		if strings.HasSuffix(name, suffix) && len(name) > len(suffix) {
+			return strings.TrimSuffix(name, suffix)
+		}
+	}
+	return ""
+}
+
+// isSpec accepts the files generators read: .proto, GraphQL schemas and documents, OpenAPI documents
+// and SQL queries
+func isSpec(relPath string) bool {
+	switch strings.ToLower(path.Ext(relPath)) {
+	case ".proto", ".graphql", ".graphqls", ".gql", ".sql":
+		return true
+	}
+	return isOpenAPISpec(relPath)
+}
+
+func isOpenAPISpec(relPath string) bool {
+	name := strings.ToLower(path.Base(relPath))
+	for _, prefix := range []string{"openapi", "swagger"} {
+		for _, ext := range []string{".yaml", ".yml", ".json"} {
+			if name == prefix+ext {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// closest returns the accepted path sharing the longest directory prefix with relPath
+func closest(relPath string, paths []string, accept func(string) bool) string {
+	best, bestShared := "", -1
+	for _, p := range paths {
+		if p == relPath || !accept(p) {
+			continue
+		}
+		if shared := sharedDirs(path.Dir(relPath), path.Dir(p)); shared > bestShared {
+			best, bestShared = p, shared
+		}
+	}
+	return best
+}
+
+func sharedDirs(a, b string) int {
+	aParts, bParts := strings.Split(a, "/"), strings.Split(b, "/")
+	shared := 0
+	for shared < len(aParts) && shared < len(bParts) && aParts[shared] == bParts[shared] {
+		shared++
+	}
+	return shared
+}
+
+func specKind(spec, artifactKind string) string {
+	switch strings.ToLower(path.Ext(spec)) {
+	case ".proto":
+		return Protobuf
+	case ".graphql", ".graphqls", ".gql":
+		return GraphQL
+	case ".sql":
+		return SQL
+	}
+	if isOpenAPISpec(spec) {
+		return OpenAPI
+	}
+	return artifactKind
+}
+
+// generatedFolders lists the folders whose every file (including those of subfolders) is an artifact
+func generatedFolders(paths []string, artifacts []Artifact) []string {
+	isArtifact := make(map[string]bool, len(artifacts))
+	for _, artifact := range artifacts {
+		isArtifact[artifact.Path] = true
+	}
+	mixed := make(map[string]bool)
+	seen := make(map[string]bool)
+	for _, p := range paths {
+		for dir := path.Dir(p); dir != "."; dir = path.Dir(dir) {
+			seen[dir] = true
+			if !isArtifact[p] {
+				mixed[dir] = true
+			}
+		}
+	}
+	var folders []string
+	for dir := range seen {
+		if !mixed[dir] && (path.Dir(dir) == "." || mixed[path.Dir(dir)]) {
+			folders = append(folders, dir) // Only the topmost fully generated folder
+		}
+	}
+	sort.Strings(folders)
+	return folders
+}
+
+func summarize(report *Report) string {
+	if len(report.Artifacts) == 0 {
+		if len(report.Pipelines) > 0 {
+			return fmt.Sprintf("No generated or vendored files found; %d code generation pipelines configured", len(report.Pipelines))
+		}
+		return "No generated or vendored files found"
+	}
+	counts := make(map[string]int)
+	for _, artifact := range report.Artifacts {
+		counts[artifact.Kind]++
+	}
+	kinds := make([]string, 0, len(counts))
+	for kind := range counts {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	var parts []string
+	for _, kind := range kinds {
+		parts = append(parts, fmt.Sprintf("%d %s", counts[kind], kind))
+	}
+	summary := fmt.Sprintf("%d generated or vendored files skipped in LLM summarization (%s), linked to %d source specs",
+		len(report.Artifacts), strings.Join(parts, ", "), len(report.Specs))
+	if len(report.Pipelines) > 0 {
+		summary += fmt.Sprintf("; %d code generation pipelines", len(report.Pipelines))
+	}
+	return summary
+}
+
+// Describe is the summary recorded for an artifact in place of an LLM summary
+func (a Artifact) Describe() string {
+	var description string
+	if a.Kind == Vendored {
+		description = "Vendored third-party code"
+	} else {
+		description = "Generated " + a.Kind + " code"
+		if a.Kind == Other {
+			description = "Generated code"
+		}
+		if a.Generator != "" {
+			description += " (" + a.Generator + ")"
+		}
+	}
+	if a.Source != "" {
+		description += " from " + a.Source
+	}
+	if a.Regenerate != "" {
+		return description + "; not summarized, edit the source and regenerate with " + a.Regenerate
+	}
+	return description + "; not summarized, edit the source instead"
+}
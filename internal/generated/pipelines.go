@@ -0,0 +1,363 @@
+package generated
+
+import (
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"repo-explanation/internal/portable"
+)
+
+// Pipeline is a code generator configured in the repository: the command regenerating its output
+// and where that output goes
+type Pipeline struct {
+	Generator string   `json:"generator"` // sqlc, gqlgen, buf, protoc, graphql-codegen, openapi-generator, oapi-codegen
+	Kind      string   `json:"kind"`
+	Config    string   `json:"config"`         // File configuring it, e.g. db/sqlc.yaml or Makefile
+	Line      int      `json:"line,omitempty"` // Line of a Makefile invocation
+	Command   string   `json:"command"`        // Run from the project root to regenerate
+	Inputs    []string `json:"inputs"`         // Specs it reads, relative to the project root
+	Outputs   []string `json:"outputs"`        // Files and directories it writes; never edit them by hand
+}
+
+// isPipelineConfig accepts the files configuring a code generator
+func isPipelineConfig(relPath string) bool {
+	name := strings.ToLower(path.Base(relPath))
+	switch name {
+	case "sqlc.yaml", "sqlc.yml", "sqlc.json", "gqlgen.yml", "gqlgen.yaml", "buf.gen.yaml", "buf.gen.yml",
+		"codegen.yml", "codegen.yaml", "openapitools.json", "makefile", "gnumakefile":
+		return true
+	}
+	return strings.HasSuffix(name, ".mk") || openAPIGeneratorConfigRegex.MatchString(name) || strings.Contains(name, "oapi-codegen")
+}
+
+// openAPIGeneratorConfigRegex matches the usual names of openapi-generator batch configs
+var openAPIGeneratorConfigRegex = regexp.MustCompile(`^openapi[-_.]?(?:generator|gen|codegen)[\w.-]*\.(?:ya?ml|json)$`)
+
+// parsePipelines reads the generators a config file sets up. Paths in a config are relative to its
+// directory, where the generator runs.
+func parsePipelines(relPath, content string) []Pipeline {
+	name := strings.ToLower(path.Base(relPath))
+	if name == "makefile" || name == "gnumakefile" || strings.HasSuffix(name, ".mk") {
+		return parseMakefile(relPath, content)
+	}
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil || doc == nil {
+		return nil
+	}
+	dir := path.Dir(relPath)
+	pipeline := Pipeline{Config: relPath, Inputs: []string{}, Outputs: []string{}}
+	switch {
+	case strings.HasPrefix(name, "sqlc."):
+		pipeline.Generator, pipeline.Kind = "sqlc", SQL
+		pipeline.Command = inDir(dir, "sqlc generate")
+		for _, entry := range list(doc["sql"]) {
+			pipeline.Inputs = append(pipeline.Inputs, stringValues(entry["schema"])...)
+			pipeline.Inputs = append(pipeline.Inputs, stringValues(entry["queries"])...)
+			if gen, ok := entry["gen"].(map[string]interface{}); ok {
+				for _, language := range sortedKeys(gen) {
+					if target, ok := gen[language].(map[string]interface{}); ok {
+						pipeline.Outputs = append(pipeline.Outputs, stringValues(target["out"])...)
+					}
+				}
+			}
+			for _, plugin := range list(entry["codegen"]) {
+				pipeline.Outputs = append(pipeline.Outputs, stringValues(plugin["out"])...)
+			}
+		}
+		// Version 1 configs
+		for _, pkg := range list(doc["packages"]) {
+			pipeline.Inputs = append(pipeline.Inputs, stringValues(pkg["schema"])...)
+			pipeline.Inputs = append(pipeline.Inputs, stringValues(pkg["queries"])...)
+			pipeline.Outputs = append(pipeline.Outputs, stringValues(pkg["path"])...)
+		}
+	case strings.HasPrefix(name, "gqlgen."):
+		pipeline.Generator, pipeline.Kind = "gqlgen", GraphQL
+		pipeline.Command = inDir(dir, "go run github.com/99designs/gqlgen generate")
+		pipeline.Inputs = stringValues(doc["schema"])
+		// Resolvers are generated once and then written by hand, so they are not outputs
+		pipeline.Outputs = append(pipeline.Outputs, optionOr(doc["exec"], "generated.go", "filename", "dir")...)
+		pipeline.Outputs = append(pipeline.Outputs, optionOr(doc["model"], "models_gen.go", "filename")...)
+		pipeline.Outputs = append(pipeline.Outputs, optionOr(doc["federation"], "", "filename")...)
+	case strings.HasPrefix(name, "buf.gen."):
+		pipeline.Generator, pipeline.Kind = "buf", Protobuf
+		pipeline.Command = inDir(dir, "buf generate")
+		for _, input := range list(doc["inputs"]) {
+			pipeline.Inputs = append(pipeline.Inputs, stringValues(input["directory"])...)
+		}
+		for _, plugin := range list(doc["plugins"]) {
+			pipeline.Outputs = append(pipeline.Outputs, stringValues(plugin["out"])...)
+		}
+	case strings.HasPrefix(name, "codegen."):
+		generates, ok := doc["generates"].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		pipeline.Generator, pipeline.Kind = "graphql-codegen", GraphQL
+		pipeline.Command = inDir(dir, "npx graphql-codegen --config "+path.Base(relPath))
+		pipeline.Inputs = append(stringValues(doc["schema"]), stringValues(doc["documents"])...)
+		pipeline.Outputs = sortedKeys(generates)
+	case name == "openapitools.json":
+		cli, _ := doc["generator-cli"].(map[string]interface{})
+		generators, _ := cli["generators"].(map[string]interface{})
+		var pipelines []Pipeline
+		for _, key := range sortedKeys(generators) {
+			generator, _ := generators[key].(map[string]interface{})
+			p := Pipeline{Generator: "openapi-generator", Kind: OpenAPI, Config: relPath,
+				Command: inDir(dir, "npx @openapitools/openapi-generator-cli generate --generator-key "+key)}
+			p.Inputs = resolveAll(dir, append(stringValues(generator["inputSpec"]), stringValues(generator["glob"])...))
+			p.Outputs = resolveAll(dir, stringValues(generator["output"]))
+			if len(p.Outputs) > 0 {
+				pipelines = append(pipelines, p)
+			}
+		}
+		return pipelines
+	case strings.Contains(name, "oapi-codegen"):
+		pipeline.Generator, pipeline.Kind = "oapi-codegen", OpenAPI
+		pipeline.Command = inDir(dir, "oapi-codegen -config "+path.Base(relPath))
+		pipeline.Outputs = stringValues(doc["output"])
+	default:
+		// openapi-generator batch config
+		if doc["generatorName"] == nil {
+			return nil
+		}
+		pipeline.Generator, pipeline.Kind = "openapi-generator", OpenAPI
+		pipeline.Command = inDir(dir, "openapi-generator-cli batch "+path.Base(relPath))
+		pipeline.Inputs = stringValues(doc["inputSpec"])
+		pipeline.Outputs = stringValues(doc["outputDir"])
+	}
+	pipeline.Inputs = resolveAll(dir, pipeline.Inputs)
+	pipeline.Outputs = resolveAll(dir, pipeline.Outputs)
+	if len(pipeline.Outputs) == 0 {
+		return nil
+	}
+	return []Pipeline{pipeline}
+}
+
+var (
+	makeTargetRegex    = regexp.MustCompile(`^([\w./-]+)\s*:([^=]|$)`)
+	makeVariableRegex  = regexp.MustCompile(`^\s*(?:export\s+)?([A-Za-z_][\w]*)\s*(?::=|::=|\?=|=)\s*(.*)$`)
+	makeReferenceRegex = regexp.MustCompile(`\$[({]([A-Za-z_][\w]*)[)}]`)
+	protocRegex        = regexp.MustCompile(`(?:^|[\s;&|/])protoc(?:\s|$)`)
+	protocOutRegex     = regexp.MustCompile(`^--([\w-]+)_out=(?:[^:]*:)?(.+)$`)
+)
+
+// parseMakefile reads the protoc invocations of a Makefile, each regenerated by running the target
+// holding it
+func parseMakefile(relPath, content string) []Pipeline {
+	dir := path.Dir(relPath)
+	variables := make(map[string]string)
+	var pipelines []Pipeline
+	target := ""
+	lines := portable.Lines(content)
+	for i := 0; i < len(lines); i++ {
+		start := i
+		line := lines[i]
+		for strings.HasSuffix(line, "\\") && i+1 < len(lines) {
+			i++
+			line = strings.TrimSuffix(line, "\\") + " " + strings.TrimSpace(lines[i])
+		}
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		if !strings.HasPrefix(line, "\t") {
+			if m := makeTargetRegex.FindStringSubmatch(line); m != nil {
+				target = m[1]
+			} else if m := makeVariableRegex.FindStringSubmatch(line); m != nil {
+				variables[m[1]] = strings.TrimSpace(m[2])
+			}
+			continue
+		}
+		if !protocRegex.MatchString(line) {
+			continue
+		}
+		pipeline := Pipeline{Generator: "protoc", Kind: Protobuf, Config: relPath, Line: start + 1, Inputs: []string{}, Outputs: []string{}}
+		for _, field := range strings.Fields(expand(line, variables)) {
+			field = strings.Trim(field, `"'`)
+			if m := protocOutRegex.FindStringSubmatch(field); m != nil {
+				if strings.Contains(m[1], "grpc") {
+					pipeline.Kind = GRPC
+				}
+				pipeline.Outputs = appendUnique(pipeline.Outputs, m[2])
+			} else if strings.HasSuffix(field, ".proto") && !strings.HasPrefix(field, "-") {
+				pipeline.Inputs = appendUnique(pipeline.Inputs, field)
+			}
+		}
+		pipeline.Inputs = resolveAll(dir, pipeline.Inputs)
+		pipeline.Outputs = resolveAll(dir, pipeline.Outputs)
+		if len(pipeline.Outputs) == 0 {
+			continue
+		}
+		if target != "" && !strings.HasPrefix(target, ".") {
+			pipeline.Command = inDir(dir, "make "+target)
+		} else {
+			pipeline.Command = inDir(dir, strings.TrimSpace(line))
+		}
+		pipelines = append(pipelines, pipeline)
+	}
+	return pipelines
+}
+
+// expand substitutes the Makefile variables a line references, leaving unknown ones as written
+func expand(line string, variables map[string]string) string {
+	for depth := 0; depth < 5 && makeReferenceRegex.MatchString(line); depth++ {
+		line = makeReferenceRegex.ReplaceAllStringFunc(line, func(ref string) string {
+			if value, ok := variables[makeReferenceRegex.FindStringSubmatch(ref)[1]]; ok {
+				return value
+			}
+			return ref
+		})
+	}
+	return line
+}
+
+// resolveOAPISpecs completes the oapi-codegen pipelines, whose config does not name the spec, with
+// the closest OpenAPI document
+func resolveOAPISpecs(pipelines []Pipeline, specPaths []string) {
+	for i := range pipelines {
+		pipeline := &pipelines[i]
+		if pipeline.Generator != "oapi-codegen" || len(pipeline.Inputs) > 0 {
+			continue
+		}
+		spec := closest(pipeline.Config, specPaths, isOpenAPISpec)
+		if spec == "" {
+			continue
+		}
+		pipeline.Inputs = []string{spec}
+		if rel, err := filepath.Rel(path.Dir(pipeline.Config), spec); err == nil {
+			pipeline.Command += " " + portable.Slash(rel)
+		}
+	}
+}
+
+// linkPipelines records on each artifact the command regenerating it: that of the pipeline writing
+// where the artifact is and generating its kind of code
+func linkPipelines(pipelines []Pipeline, artifacts []Artifact) {
+	for i := range artifacts {
+		for _, pipeline := range pipelines {
+			if writes(pipeline, artifacts[i].Path) && sameFamily(pipeline.Kind, artifacts[i].Kind) {
+				artifacts[i].Regenerate = pipeline.Command
+				if artifacts[i].Generator == "" {
+					artifacts[i].Generator = pipeline.Generator
+				}
+				break
+			}
+		}
+	}
+}
+
+// writes reports whether a file is one of a pipeline's outputs or inside one of its directories
+func writes(pipeline Pipeline, relPath string) bool {
+	for _, output := range pipeline.Outputs {
+		if relPath == output || output == "." || strings.HasPrefix(relPath, output+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// sameFamily reports whether an artifact of kind artifactKind can come from a pipeline of kind
+// pipelineKind; protoc writes both protobuf and gRPC code
+func sameFamily(pipelineKind, artifactKind string) bool {
+	switch artifactKind {
+	case pipelineKind, Other:
+		return true
+	case Protobuf, GRPC:
+		return pipelineKind == Protobuf || pipelineKind == GRPC
+	}
+	return false
+}
+
+// inDir prefixes a command with a change to the directory it runs in
+func inDir(dir, command string) string {
+	if dir == "." {
+		return command
+	}
+	return "cd " + dir + " && " + command
+}
+
+// resolveAll makes config-relative paths relative to the project root; paths still holding
+// variables or leaving the project are kept as written
+func resolveAll(dir string, paths []string) []string {
+	resolved := []string{}
+	for _, p := range paths {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if !strings.Contains(p, "$") && !path.IsAbs(p) {
+			if joined := path.Join(dir, p); joined != ".." && !strings.HasPrefix(joined, "../") {
+				p = joined
+			}
+		}
+		resolved = appendUnique(resolved, strings.TrimSuffix(p, "/"))
+	}
+	return resolved
+}
+
+// optionOr reads the first of keys set on a config section, or fallback when the section or its keys
+// are missing
+func optionOr(section interface{}, fallback string, keys ...string) []string {
+	if options, ok := section.(map[string]interface{}); ok {
+		for _, key := range keys {
+			if values := stringValues(options[key]); len(values) > 0 {
+				return values
+			}
+		}
+	}
+	if fallback == "" {
+		return nil
+	}
+	return []string{fallback}
+}
+
+// list reads a YAML sequence of mappings
+func list(value interface{}) []map[string]interface{} {
+	items, _ := value.([]interface{})
+	var maps []map[string]interface{}
+	for _, item := range items {
+		if m, ok := item.(map[string]interface{}); ok {
+			maps = append(maps, m)
+		}
+	}
+	return maps
+}
+
+// stringValues reads a YAML string or sequence of strings
+func stringValues(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var values []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
@@ -0,0 +1,408 @@
+// Package glossary collects a project's domain terms (table names, service names and the business
+// nouns that recur across file summaries) and defines each one with citations to the files it
+// comes from
+package glossary
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Kind is where a term was found
+type Kind string
+
+const (
+	Table   Kind = "table"
+	Service Kind = "service"
+	Concept Kind = "concept" // A noun recurring across file summaries and type names
+)
+
+// maxSources bounds the files cited for a term
+const maxSources = 3
+
+// maxContext bounds the snippets kept as evidence for a term
+const maxContext = 3
+
+// maxColumns bounds the columns listed for a table
+const maxColumns = 12
+
+// Term is one glossary entry
+type Term struct {
+	Term       string   `json:"term"`
+	Kind       Kind     `json:"kind"`
+	Definition string   `json:"definition"`
+	Sources    []string `json:"sources"` // Files the definition is based on
+}
+
+// Glossary lists the project's terms alphabetically
+type Glossary struct {
+	Terms   []Term `json:"terms"`
+	Source  string `json:"source"` // "heuristic", or "heuristic+llm" when the LLM wrote the definitions
+	Summary string `json:"summary"`
+}
+
+// Candidate is a term with the evidence a definition may draw on
+type Candidate struct {
+	Term     string
+	Kind     Kind
+	Sources  []string
+	Context  []string // Column lists, service descriptions or summary sentences mentioning the term
+	Mentions int      // Files mentioning the term
+}
+
+// TableInfo is what the schema says about a table
+type TableInfo struct {
+	Columns []string
+	Sources []string // Migrations that create it
+}
+
+// ServiceInfo is what service discovery says about a service
+type ServiceInfo struct {
+	Name        string
+	Description string
+	Source      string // Entry point, or the service directory
+}
+
+// Input is the analysis output terms are collected from
+type Input struct {
+	Tables    map[string]TableInfo
+	Services  []ServiceInfo
+	Summaries map[string]string // File path to its summary, with key type names
+	Models    []string          // Data models named in the project summary
+}
+
+var (
+	wordRegex      = regexp.MustCompile(`[A-Za-z][A-Za-z]+`)
+	sentenceRegex  = regexp.MustCompile(`[^.!?\n]+[.!?]?`)
+	camelCaseRegex = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+)
+
+// Candidates collects terms from the tables, services and summaries. At most maxConcepts recurring
+// nouns are kept, those mentioned in the most files first; tables and services are always kept.
+func Candidates(in Input, maxConcepts int) []Candidate {
+	var candidates []Candidate
+	covered := make(map[string]bool)
+
+	tableNames := make([]string, 0, len(in.Tables))
+	for name := range in.Tables {
+		tableNames = append(tableNames, name)
+	}
+	sort.Strings(tableNames)
+	for _, name := range tableNames {
+		table := in.Tables[name]
+		candidate := Candidate{Term: name, Kind: Table, Sources: limit(table.Sources, maxSources)}
+		if columns := table.Columns; len(columns) > maxColumns {
+			candidate.Context = []string{fmt.Sprintf("columns: %s and %d more", strings.Join(columns[:maxColumns], ", "), len(columns)-maxColumns)}
+		} else if len(columns) > 0 {
+			candidate.Context = []string{"columns: " + strings.Join(columns, ", ")}
+		}
+		candidates = append(candidates, candidate)
+		for _, word := range splitIdentifier(name) {
+			covered[singular(word)] = true
+		}
+		covered[singular(normalize(name))] = true
+	}
+
+	for _, service := range in.Services {
+		candidate := Candidate{Term: service.Name, Kind: Service}
+		if service.Source != "" {
+			candidate.Sources = []string{service.Source}
+		}
+		if service.Description != "" {
+			candidate.Context = []string{service.Description}
+		}
+		candidates = append(candidates, candidate)
+		covered[singular(normalize(service.Name))] = true
+	}
+
+	return append(candidates, concepts(in, covered, maxConcepts)...)
+}
+
+// concepts finds nouns that recur across file summaries, skipping common programming vocabulary
+func concepts(in Input, covered map[string]bool, maxConcepts int) []Candidate {
+	paths := make([]string, 0, len(in.Summaries))
+	for path := range in.Summaries {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	files := make(map[string][]string)
+	found := make(map[string]bool)
+	for _, path := range paths {
+		seen := make(map[string]bool)
+		for _, word := range wordRegex.FindAllString(splitCamelCase(in.Summaries[path]), -1) {
+			term := singular(strings.ToLower(word))
+			if !isDomainWord(term) || covered[term] || seen[term] {
+				continue
+			}
+			seen[term] = true
+			files[term] = append(files[term], path)
+			found[term] = true
+		}
+	}
+
+	// A data model the project summary names counts even when few files mention it
+	models := make(map[string]bool)
+	for _, model := range in.Models {
+		term := singular(normalize(model))
+		if term != "" && !covered[term] {
+			models[term] = true
+			found[term] = true
+		}
+	}
+
+	// Small projects have few summaries; a term needs to recur, but not across many files
+	minFiles := 3
+	if len(in.Summaries) < 20 {
+		minFiles = 2
+	}
+	var terms []string
+	for term := range found {
+		if models[term] || len(files[term]) >= minFiles {
+			terms = append(terms, term)
+		}
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if models[terms[i]] != models[terms[j]] {
+			return models[terms[i]]
+		}
+		if len(files[terms[i]]) != len(files[terms[j]]) {
+			return len(files[terms[i]]) > len(files[terms[j]])
+		}
+		return terms[i] < terms[j]
+	})
+	if len(terms) > maxConcepts {
+		terms = terms[:maxConcepts]
+	}
+
+	candidates := make([]Candidate, 0, len(terms))
+	for _, term := range terms {
+		candidate := Candidate{Term: term, Kind: Concept, Sources: limit(files[term], maxSources), Mentions: len(files[term])}
+		for _, path := range files[term] {
+			if len(candidate.Context) == maxContext {
+				break
+			}
+			if sentence := sentenceMentioning(in.Summaries[path], term); sentence != "" {
+				candidate.Context = append(candidate.Context, path+": "+sentence)
+			}
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates
+}
+
+// Heuristic defines every candidate from its own evidence, for offline runs and when the LLM fails
+func Heuristic(candidates []Candidate) *Glossary {
+	terms := make([]Term, 0, len(candidates))
+	for _, candidate := range candidates {
+		terms = append(terms, Term{
+			Term:       candidate.Term,
+			Kind:       candidate.Kind,
+			Definition: heuristicDefinition(candidate),
+			Sources:    candidate.Sources,
+		})
+	}
+	return build(terms, "heuristic")
+}
+
+func heuristicDefinition(candidate Candidate) string {
+	switch candidate.Kind {
+	case Table:
+		if len(candidate.Context) > 0 {
+			return "Database table with " + candidate.Context[0]
+		}
+		return "Database table"
+	case Service:
+		if len(candidate.Context) > 0 {
+			return candidate.Context[0]
+		}
+		return "Service in this repository"
+	}
+	if candidate.Mentions == 0 {
+		return "Data model named in the project summary"
+	}
+	if len(candidate.Context) > 0 {
+		_, sentence, _ := strings.Cut(candidate.Context[0], ": ")
+		return fmt.Sprintf("Recurring term in %d files, e.g. %q", candidate.Mentions, sentence)
+	}
+	return fmt.Sprintf("Recurring term in %d files", candidate.Mentions)
+}
+
+// Definition is a definition written for one candidate, citing some of its sources
+type Definition struct {
+	Term       string
+	Definition string
+	Sources    []string
+	Generic    bool // The term is ordinary programming vocabulary rather than part of the domain
+}
+
+// Apply replaces the heuristic definitions with the given ones. A citation outside the candidate's
+// own sources is dropped, concepts marked generic are removed, and candidates left undefined keep
+// their heuristic definition.
+func Apply(candidates []Candidate, definitions []Definition) *Glossary {
+	byTerm := make(map[string]Definition, len(definitions))
+	for _, definition := range definitions {
+		byTerm[strings.ToLower(strings.TrimSpace(definition.Term))] = definition
+	}
+
+	terms := make([]Term, 0, len(candidates))
+	for _, candidate := range candidates {
+		term := Term{Term: candidate.Term, Kind: candidate.Kind, Definition: heuristicDefinition(candidate), Sources: candidate.Sources}
+		definition, ok := byTerm[strings.ToLower(candidate.Term)]
+		if ok && definition.Generic && candidate.Kind == Concept {
+			continue
+		}
+		if ok && strings.TrimSpace(definition.Definition) != "" {
+			term.Definition = strings.TrimSpace(definition.Definition)
+			var cited []string
+			for _, source := range definition.Sources {
+				if contains(candidate.Sources, source) && !contains(cited, source) {
+					cited = append(cited, source)
+				}
+			}
+			if len(cited) > 0 {
+				term.Sources = cited
+			}
+		}
+		terms = append(terms, term)
+	}
+	return build(terms, "heuristic+llm")
+}
+
+func build(terms []Term, source string) *Glossary {
+	sort.SliceStable(terms, func(i, j int) bool { return strings.ToLower(terms[i].Term) < strings.ToLower(terms[j].Term) })
+	counts := make(map[Kind]int)
+	for i := range terms {
+		if terms[i].Sources == nil {
+			terms[i].Sources = []string{}
+		}
+		counts[terms[i].Kind]++
+	}
+	return &Glossary{
+		Terms:  terms,
+		Source: source,
+		Summary: fmt.Sprintf("%d terms: %d tables, %d services, %d domain concepts",
+			len(terms), counts[Table], counts[Service], counts[Concept]),
+	}
+}
+
+// Markdown renders the glossary as a document section
+func (g *Glossary) Markdown() string {
+	var b strings.Builder
+	b.WriteString("## Glossary\n\n")
+	for _, term := range g.Terms {
+		fmt.Fprintf(&b, "- **%s** (%s): %s", term.Term, term.Kind, term.Definition)
+		if len(term.Sources) > 0 {
+			fmt.Fprintf(&b, " [%s]", strings.Join(term.Sources, ", "))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// sentenceMentioning returns the first sentence of text that mentions term
+func sentenceMentioning(text, term string) string {
+	for _, sentence := range sentenceRegex.FindAllString(text, -1) {
+		for _, word := range wordRegex.FindAllString(splitCamelCase(sentence), -1) {
+			if singular(strings.ToLower(word)) == term {
+				return strings.TrimSpace(sentence)
+			}
+		}
+	}
+	return ""
+}
+
+// isDomainWord rejects short words, verb and adverb forms, and common programming vocabulary
+func isDomainWord(word string) bool {
+	if len(word) < 4 || stopWords[word] {
+		return false
+	}
+	for _, suffix := range []string{"ing", "ed", "ly", "ize", "ise"} {
+		if strings.HasSuffix(word, suffix) {
+			return false
+		}
+	}
+	return true
+}
+
+// singular strips common English plural endings
+func singular(word string) string {
+	switch {
+	case strings.HasSuffix(word, "ies") && len(word) > 4:
+		return strings.TrimSuffix(word, "ies") + "y"
+	case strings.HasSuffix(word, "sses"), strings.HasSuffix(word, "shes"), strings.HasSuffix(word, "ches"), strings.HasSuffix(word, "xes"):
+		return strings.TrimSuffix(word, "es")
+	case strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") && !strings.HasSuffix(word, "us") && !strings.HasSuffix(word, "is") && len(word) > 3:
+		return strings.TrimSuffix(word, "s")
+	}
+	return word
+}
+
+// normalize lowercases a name and joins its words with spaces: order_items and OrderItems both
+// become "order items"
+func normalize(name string) string {
+	return strings.Join(splitIdentifier(name), " ")
+}
+
+func splitIdentifier(name string) []string {
+	return strings.FieldsFunc(strings.ToLower(splitCamelCase(name)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func splitCamelCase(text string) string {
+	return camelCaseRegex.ReplaceAllString(text, "$1 $2")
+}
+
+func limit(list []string, n int) []string {
+	if len(list) > n {
+		return append([]string{}, list[:n]...)
+	}
+	return append([]string{}, list...)
+}
+
+func contains(list []string, value string) bool {
+	for _, entry := range list {
+		if entry == value {
+			return true
+		}
+	}
+	return false
+}
+
+// stopWords are English function words and the programming vocabulary every codebase shares
+var stopWords = toSet(`
+about above after again against also among another around because been before being below
+between both called can contain contains could does doing during each either else every from
+have having here into itself just like made main many more most much must need needs only other
+over same should since some such than that their them then there these they this those through
+under until upon very what when where whether which while will with within without would your
+access action actions adapter application apps array base boolean body bool build builder cache
+call class client code collection command common component components config configuration
+console constant constants container content context controller core create current data
+default define defines definition delete dependency description detail details directory
+document documentation dto element entity entries entry enum error errors event example export
+exports feature field fields file files flag folder format function functions global handle
+handler handlers header helper helpers hook hooks html http https implementation import imports
+index info information init initial input instance interface internal item items json layer
+layout level library line list load local logger logic logs manager map mapper message method
+methods middleware mock model models module modules name names number object objects option
+options output package page pages param parameter parameters path point process program project
+prop props provide provider provides query queue readme record records ref repository request
+requests resource resources response result results return returns root route router routes
+schema script scripts server service services setting settings setup source sql state static
+status store string struct style styles support system table tables task template test tests
+text time type types update util utilities utility utils value values variable variables
+version view views wrapper yaml
+`)
+
+func toSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+	return set
+}
@@ -0,0 +1,193 @@
+package heuristics
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	internalOpenai "repo-explanation/internal/openai"
+)
+
+// Internal architecture patterns a service can be classified as
+const (
+	PatternLayered   = "layered"
+	PatternClean     = "clean"
+	PatternHexagonal = "hexagonal"
+	PatternMVC       = "mvc"
+	PatternFlat      = "flat"
+)
+
+// ArchitecturePatterns lists every valid classification
+var ArchitecturePatterns = []string{PatternLayered, PatternClean, PatternHexagonal, PatternMVC, PatternFlat}
+
+// directoryRoles maps well-known directory names to the architectural role they signal
+var directoryRoles = map[string]string{
+	"controller": "controller", "controllers": "controller",
+	"handler": "handler", "handlers": "handler", "routes": "handler", "router": "handler", "routers": "handler",
+	"transport": "handler", "delivery": "handler", "rest": "handler", "http": "handler", "endpoints": "handler", "resolvers": "handler",
+	"service": "service", "services": "service", "business": "service", "logic": "service",
+	"repository": "repository", "repositories": "repository", "repo": "repository", "dao": "repository",
+	"store": "repository", "storage": "repository", "persistence": "repository",
+	"model": "model", "models": "model",
+	"view": "view", "views": "view", "templates": "view",
+	"usecase": "usecase", "usecases": "usecase", "use_case": "usecase", "use_cases": "usecase", "use-cases": "usecase",
+	"interactor": "usecase", "interactors": "usecase", "application": "usecase",
+	"entity": "entity", "entities": "entity", "domain": "entity",
+	"port": "port", "ports": "port",
+	"adapter": "adapter", "adapters": "adapter", "inbound": "adapter", "outbound": "adapter", "driving": "adapter", "driven": "adapter",
+	"infrastructure": "infrastructure", "infra": "infrastructure", "interfaces": "infrastructure", "gateway": "infrastructure", "gateways": "infrastructure",
+}
+
+// ClassifyServiceArchitecture infers a service's internal architecture from its directory names.
+// servicePath is relative to the project root ("" or "." for the root); filePaths are project-relative.
+func ClassifyServiceArchitecture(servicePath string, filePaths []string) *internalOpenai.ServiceArchitecture {
+	servicePath = strings.Trim(filepath.ToSlash(filepath.Clean(servicePath)), "/")
+	if servicePath == "." {
+		servicePath = ""
+	}
+
+	roles := make(map[string][]string) // role -> directories signalling it
+	directories := make(map[string]bool)
+	fileCount := 0
+
+	for _, path := range filePaths {
+		path = filepath.ToSlash(path)
+		rel := path
+		if servicePath != "" {
+			if !strings.HasPrefix(path, servicePath+"/") {
+				continue
+			}
+			rel = strings.TrimPrefix(path, servicePath+"/")
+		}
+		fileCount++
+
+		segments := strings.Split(rel, "/")
+		for i := 0; i < len(segments)-1; i++ {
+			dir := strings.Join(segments[:i+1], "/") + "/"
+			if directories[dir] {
+				continue
+			}
+			directories[dir] = true
+			if role, ok := directoryRoles[strings.ToLower(segments[i])]; ok {
+				roles[role] = append(roles[role], dir)
+			}
+		}
+	}
+
+	if fileCount == 0 {
+		return nil
+	}
+
+	has := func(role string) bool { return len(roles[role]) > 0 }
+	scores := map[string]int{}
+	if has("port") || has("adapter") {
+		scores[PatternHexagonal] = 3*countTrue(has("port"), has("adapter")) + countTrue(has("entity"), has("usecase"))
+	}
+	if has("usecase") {
+		scores[PatternClean] = 3 + 2*countTrue(has("entity")) + countTrue(has("repository"), has("handler") || has("infrastructure"))
+	}
+	if countTrue(has("handler") || has("controller"), has("service"), has("repository")) >= 2 {
+		scores[PatternLayered] = 2*countTrue(has("handler") || has("controller"), has("service"), has("repository")) + countTrue(has("model"))
+	}
+	if has("controller") && (has("view") || has("model")) {
+		scores[PatternMVC] = 3 + 3*countTrue(has("view")) + 2*countTrue(has("model"))
+	}
+
+	if len(scores) == 0 {
+		evidence := []string{fmt.Sprintf("%d files in %d directories, no layer directories", fileCount, len(directories))}
+		confidence := 0.5
+		if len(directories) <= 2 {
+			confidence = 0.7
+		}
+		return &internalOpenai.ServiceArchitecture{
+			Pattern:    PatternFlat,
+			Confidence: confidence,
+			Evidence:   evidence,
+			Source:     "heuristic",
+			Rationale:  "No handler/service/repository, use case or port/adapter directories found",
+		}
+	}
+
+	// Highest score wins; ties resolve in ArchitecturePatterns order
+	best, runnerUp := "", 0
+	for _, pattern := range ArchitecturePatterns {
+		score, ok := scores[pattern]
+		if !ok {
+			continue
+		}
+		if best == "" || score > scores[best] {
+			if best != "" {
+				runnerUp = scores[best]
+			}
+			best = pattern
+		} else if score > runnerUp {
+			runnerUp = score
+		}
+	}
+
+	confidence := 0.4 + 0.06*float64(scores[best])
+	if scores[best]-runnerUp <= 1 && len(scores) > 1 {
+		confidence -= 0.15
+	}
+	if confidence > 0.9 {
+		confidence = 0.9
+	}
+
+	var evidence []string
+	var signals []string
+	for _, role := range patternRoles(best) {
+		if dirs := roles[role]; len(dirs) > 0 {
+			evidence = append(evidence, limitStrings(dirs, 2)...)
+			signals = append(signals, role)
+		}
+	}
+	for i := range evidence {
+		evidence[i] = filepath.ToSlash(filepath.Join(servicePath, evidence[i])) + "/"
+	}
+	sort.Strings(evidence)
+
+	return &internalOpenai.ServiceArchitecture{
+		Pattern:    best,
+		Confidence: confidence,
+		Evidence:   limitStrings(evidence, 6),
+		Source:     "heuristic",
+		Rationale:  fmt.Sprintf("Directories for %s layers", strings.Join(signals, ", ")),
+	}
+}
+
+// patternRoles lists the directory roles that count as evidence for a pattern
+func patternRoles(pattern string) []string {
+	switch pattern {
+	case PatternHexagonal:
+		return []string{"port", "adapter", "entity", "usecase"}
+	case PatternClean:
+		return []string{"usecase", "entity", "repository", "handler", "infrastructure"}
+	case PatternLayered:
+		return []string{"handler", "controller", "service", "repository", "model"}
+	case PatternMVC:
+		return []string{"controller", "view", "model"}
+	}
+	return nil
+}
+
+// IsArchitecturePattern reports whether pattern is a known classification
+func IsArchitecturePattern(pattern string) bool {
+	for _, p := range ArchitecturePatterns {
+		if p == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// countTrue returns how many of the conditions hold
+func countTrue(conditions ...bool) int {
+	n := 0
+	for _, c := range conditions {
+		if c {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,284 @@
+package heuristics
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	internalOpenai "repo-explanation/internal/openai"
+)
+
+var (
+	atxHeadingRegex  = regexp.MustCompile(`^(#{1,6})\s+(.+?)\s*#*\s*$`)
+	inlineCodeRegex  = regexp.MustCompile("`([^`]+)`")
+	emojiPrefixRegex = regexp.MustCompile(`^[^\p{L}\p{N}(\[]+`)
+	// A shell line starts with a program, a path or a variable assignment rather than a sentence
+	commandLikeRegex = regexp.MustCompile(`^(?:[a-z0-9_./~-][\w./~:@+-]*|[A-Z_][A-Z0-9_]*=\S*)(?:\s|$)`)
+
+	// Sections whose commands are setup or run instructions
+	setupSectionRegex = regexp.MustCompile(`(?i)install|setup|set up|getting started|quick ?start|prerequisite|requirement|development|develop|build|usage|running|run|deploy|configur|local|docker|test`)
+
+	// Commands that start the project, its dev server or its tests
+	runCommandRegex = regexp.MustCompile(`^(?:sudo\s+)?(?:` +
+		`(?:npm|pnpm|yarn|bun)\s+(?:run\s+)?(?:start|dev|serve|test|preview)\b|` +
+		`npx\s+\S+\s+(?:start|dev|serve)|` +
+		`go\s+(?:run|test)\b|` +
+		`make\s+(?:run|dev|start|serve|up|test)\b|` +
+		`docker[- ]compose\s+(?:\S+\s+)*up\b|docker\s+run\b|` +
+		`python3?\s+\S+\.py|python3?\s+-m\s+\S+|uvicorn\b|gunicorn\b|flask\s+run|python3?\s+manage\.py\s+runserver|pytest\b|` +
+		`cargo\s+(?:run|test)\b|dotnet\s+(?:run|test|watch)\b|` +
+		`(?:bundle\s+exec\s+)?rails\s+(?:s|server)\b|bundle\s+exec\s+\S+|` +
+		`(?:\./)?mvnw?\s+\S*(?:spring-boot:run|test)|(?:\./)?gradlew?\s+(?:bootRun|run|test)\b|` +
+		`deno\s+(?:run|task)\b|php\s+artisan\s+serve|mix\s+phx\.server|` +
+		`\./(?:bin/)?\S+` +
+		`)`)
+
+	// Commands that install, configure or build the project
+	setupCommandRegex = regexp.MustCompile(`^(?:sudo\s+)?(?:` +
+		`git\s+clone|cd\s+\S+|cp\s+\S*\.env|mv\s+\S*\.env|export\s+[A-Z_]+=|` +
+		`(?:npm|pnpm|yarn|bun)\s+(?:install|i|ci|add|run\s+build|build)\b|yarn$|` +
+		`go\s+(?:mod\s+\w+|get|install|build|generate)\b|` +
+		`pip3?\s+install|poetry\s+install|pipenv\s+install|uv\s+(?:sync|pip)|python3?\s+-m\s+venv|source\s+\S+/activate|` +
+		`bundle\s+install|gem\s+install|composer\s+install|cargo\s+build|dotnet\s+(?:restore|build)|` +
+		`make(?:\s+(?:install|build|setup|deps|init|migrate|\w+-build))?$|` +
+		`docker[- ]compose\s+(?:\S+\s+)*(?:build|pull)\b|docker\s+build\b|` +
+		`brew\s+install|apt(?:-get)?\s+install|` +
+		`\S*migrate\b|\S+\s+db:(?:create|migrate|setup|seed)` +
+		`)`)
+)
+
+// Limits keep the digest small enough to pass into every project-level prompt
+const (
+	maxDigestHeadings = 30
+	maxDigestCommands = 15
+)
+
+// IsDocumentationFile reports whether a file is prose documentation (README, CHANGELOG, docs/*.md, ...)
+// that is summarized deterministically instead of by the LLM
+func IsDocumentationFile(relativePath string) bool {
+	name := strings.ToLower(path.Base(strings.ReplaceAll(relativePath, "\\", "/")))
+	switch path.Ext(name) {
+	case ".md", ".mdx", ".markdown", ".rst", ".adoc":
+		return true
+	case "", ".txt":
+		stem := strings.TrimSuffix(name, path.Ext(name))
+		return stem == "readme" || stem == "changelog" || stem == "contributing" || stem == "install" || stem == "changes"
+	}
+	return false
+}
+
+// isChangelog reports whether a documentation file is a release history
+func isChangelog(relativePath string) bool {
+	name := strings.ToLower(path.Base(relativePath))
+	return strings.HasPrefix(name, "changelog") || strings.HasPrefix(name, "changes") || strings.HasPrefix(name, "history") || strings.HasPrefix(name, "releases")
+}
+
+// ExtractDocumentation reads the headings, setup steps and run commands out of a documentation file
+func ExtractDocumentation(relativePath, content string) *internalOpenai.DocumentationDigest {
+	digest := &internalOpenai.DocumentationDigest{Sources: []string{relativePath}}
+
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	section := ""
+	inCodeBlock := false
+	shellBlock, labelled := false, false
+	var block []string
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			if inCodeBlock {
+				if shellBlock {
+					// Unlabelled blocks may hold config or output, so only recognizable commands are kept
+					addCommands(digest, section, blockCommands(block), labelled)
+				}
+				inCodeBlock, block = false, nil
+				continue
+			}
+			inCodeBlock = true
+			info := strings.TrimSpace(strings.TrimLeft(trimmed, "`~"))
+			shellBlock, labelled = isShellFence(info), info != ""
+			continue
+		}
+		if inCodeBlock {
+			block = append(block, line)
+			continue
+		}
+
+		heading := ""
+		if match := atxHeadingRegex.FindStringSubmatch(trimmed); match != nil {
+			heading = match[2]
+		} else if trimmed != "" && i+1 < len(lines) && isSetextUnderline(lines[i+1]) && !isSetextUnderline(line) {
+			heading = trimmed
+		}
+		if heading != "" {
+			heading = cleanHeading(heading)
+			section = heading
+			if digest.Title == "" {
+				digest.Title = heading
+			}
+			digest.Headings = appendUnique(digest.Headings, heading)
+			continue
+		}
+
+		// "Run `make dev` to start" in a setup section counts as a command too
+		if setupSectionRegex.MatchString(section) {
+			for _, match := range inlineCodeRegex.FindAllStringSubmatch(trimmed, -1) {
+				command := strings.TrimPrefix(strings.TrimSpace(match[1]), "$ ")
+				if runCommandRegex.MatchString(command) || setupCommandRegex.MatchString(command) {
+					addCommands(digest, section, []string{command}, false)
+				}
+			}
+		}
+	}
+
+	if isChangelog(relativePath) {
+		if len(digest.Headings) > 1 {
+			digest.Description = fmt.Sprintf("Release history; latest entry: %s", digest.Headings[1])
+		} else {
+			digest.Description = "Release history"
+		}
+	} else {
+		digest.Description = ExtractReadmeSummary(content)
+	}
+
+	digest.Headings = limitStrings(digest.Headings, maxDigestHeadings)
+	digest.SetupSteps = limitStrings(digest.SetupSteps, maxDigestCommands)
+	digest.RunCommands = limitStrings(digest.RunCommands, maxDigestCommands)
+	return digest
+}
+
+// MergeDocumentation combines digests in order, so the top-level README's title and commands come first
+func MergeDocumentation(digests []*internalOpenai.DocumentationDigest) *internalOpenai.DocumentationDigest {
+	if len(digests) == 0 {
+		return nil
+	}
+	merged := &internalOpenai.DocumentationDigest{}
+	for _, digest := range digests {
+		if merged.Title == "" {
+			merged.Title = digest.Title
+		}
+		if merged.Description == "" && !strings.HasPrefix(digest.Description, "Release history") {
+			merged.Description = digest.Description
+		}
+		for _, heading := range digest.Headings {
+			merged.Headings = appendUnique(merged.Headings, heading)
+		}
+		for _, step := range digest.SetupSteps {
+			merged.SetupSteps = appendUnique(merged.SetupSteps, step)
+		}
+		for _, command := range digest.RunCommands {
+			merged.RunCommands = appendUnique(merged.RunCommands, command)
+		}
+		merged.Sources = append(merged.Sources, digest.Sources...)
+	}
+	merged.Headings = limitStrings(merged.Headings, maxDigestHeadings)
+	merged.SetupSteps = limitStrings(merged.SetupSteps, maxDigestCommands)
+	merged.RunCommands = limitStrings(merged.RunCommands, maxDigestCommands)
+	return merged
+}
+
+// SummarizeDocument builds the file summary of a documentation file from its digest: headings
+// stand in for key types and commands for functions
+func SummarizeDocument(relativePath, content string) *internalOpenai.FileSummary {
+	digest := ExtractDocumentation(relativePath, content)
+
+	purpose := "Documentation"
+	if digest.Title != "" {
+		purpose = fmt.Sprintf("Documentation: %s", digest.Title)
+	}
+	if digest.Description != "" {
+		purpose += ". " + digest.Description
+	}
+
+	return &internalOpenai.FileSummary{
+		Language:   documentLanguage(relativePath),
+		Purpose:    purpose,
+		KeyTypes:   limitStrings(digest.Headings, 10),
+		Functions:  limitStrings(append(append([]string{}, digest.SetupSteps...), digest.RunCommands...), 15),
+		Complexity: "low",
+	}
+}
+
+func documentLanguage(relativePath string) string {
+	switch strings.ToLower(path.Ext(relativePath)) {
+	case ".rst":
+		return "reStructuredText"
+	case ".adoc":
+		return "AsciiDoc"
+	case ".txt", "":
+		return "Text"
+	}
+	return "Markdown"
+}
+
+// addCommands files commands as run commands or setup steps. Unless lenient in a setup section,
+// commands are only kept when they look like one or the other.
+func addCommands(digest *internalOpenai.DocumentationDigest, section string, commands []string, lenient bool) {
+	inSetupSection := lenient && setupSectionRegex.MatchString(section)
+	for _, command := range commands {
+		switch {
+		case runCommandRegex.MatchString(command):
+			digest.RunCommands = appendUnique(digest.RunCommands, command)
+		case setupCommandRegex.MatchString(command) || (inSetupSection && commandLikeRegex.MatchString(command)):
+			digest.SetupSteps = appendUnique(digest.SetupSteps, command)
+		}
+	}
+}
+
+// blockCommands returns the commands of a shell code block, dropping comments, blank lines and,
+// when lines are prompted with "$ ", the output lines in between
+func blockCommands(block []string) []string {
+	prompted := false
+	for _, line := range block {
+		if strings.HasPrefix(strings.TrimSpace(line), "$ ") {
+			prompted = true
+			break
+		}
+	}
+
+	var commands []string
+	continued := ""
+	for _, line := range block {
+		trimmed := strings.TrimSpace(line)
+		if prompted && continued == "" {
+			if !strings.HasPrefix(trimmed, "$ ") {
+				continue
+			}
+			trimmed = strings.TrimPrefix(trimmed, "$ ")
+		}
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if strings.HasSuffix(trimmed, "\\") {
+			continued += strings.TrimSuffix(trimmed, "\\") + " "
+			continue
+		}
+		commands = append(commands, strings.TrimSpace(continued+trimmed))
+		continued = ""
+	}
+	return commands
+}
+
+// isShellFence reports whether a fence's info string marks a shell block; unlabelled blocks count too
+func isShellFence(info string) bool {
+	switch strings.ToLower(strings.Fields(info + " x")[0]) {
+	case "x", "sh", "bash", "shell", "console", "zsh", "shell-session", "terminal", "cmd", "powershell", "ps1", "fish":
+		return true
+	}
+	return false
+}
+
+func isSetextUnderline(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return len(trimmed) >= 3 && (strings.Trim(trimmed, "=") == "" || strings.Trim(trimmed, "-") == "")
+}
+
+// cleanHeading strips emphasis, links and leading emoji from a heading
+func cleanHeading(heading string) string {
+	heading = markdownLinkRegex.ReplaceAllString(heading, "$1")
+	heading = strings.NewReplacer("**", "", "__", "", "`", "").Replace(heading)
+	heading = emojiPrefixRegex.ReplaceAllString(heading, "")
+	return strings.TrimSpace(heading)
+}
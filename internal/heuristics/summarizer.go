@@ -0,0 +1,540 @@
+package heuristics
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"repo-explanation/internal/languages"
+	internalOpenai "repo-explanation/internal/openai"
+)
+
+// OfflineMarker is prefixed to purpose strings that were produced without an LLM
+const OfflineMarker = "[offline]"
+
+// LLMEnhanceableFields lists the result fields that an LLM-backed run would fill in with richer content
+var LLMEnhanceableFields = []string{
+	"project_summary.purpose",
+	"project_summary.architecture",
+	"project_summary.data_models",
+	"project_summary.external_services",
+	"project_summary.detailed_analysis",
+	"folder_summaries.*.purpose",
+	"folder_summaries.*.architecture",
+	"folder_summaries.*.file_summaries.*.purpose",
+	"database_schema.llm_relationships",
+	"helpful_questions",
+	"glossary.terms.*.definition",
+}
+
+// symbolPatterns holds the regexes used to pull types, functions and imports out of source files
+type symbolPatterns struct {
+	types     []*regexp.Regexp
+	functions []*regexp.Regexp
+	imports   []*regexp.Regexp
+}
+
+var patternsByLanguage = map[string]symbolPatterns{
+	"Go": {
+		types:     []*regexp.Regexp{regexp.MustCompile(`(?m)^type\s+([A-Za-z_]\w*)\s+(?:struct|interface)`)},
+		functions: []*regexp.Regexp{regexp.MustCompile(`(?m)^func\s+(?:\([^)]*\)\s*)?([A-Za-z_]\w*)\s*\(`)},
+		imports:   []*regexp.Regexp{regexp.MustCompile(`(?m)^\s*(?:import\s+)?(?:[A-Za-z_]\w*\s+)?"([^"]+)"\s*$`)},
+	},
+	"JavaScript": {
+		types: []*regexp.Regexp{regexp.MustCompile(`(?m)^\s*(?:export\s+)?(?:default\s+)?class\s+([A-Za-z_$][\w$]*)`)},
+		functions: []*regexp.Regexp{
+			regexp.MustCompile(`(?m)^\s*(?:export\s+)?(?:default\s+)?(?:async\s+)?function\s*\*?\s*([A-Za-z_$][\w$]*)`),
+			regexp.MustCompile(`(?m)^\s*(?:export\s+)?const\s+([A-Za-z_$][\w$]*)\s*=\s*(?:async\s*)?(?:\([^)]*\)|[A-Za-z_$][\w$]*)\s*=>`),
+		},
+		imports: []*regexp.Regexp{
+			regexp.MustCompile(`(?m)^\s*import\s+(?:[^'"]+\s+from\s+)?['"]([^'"]+)['"]`),
+			regexp.MustCompile(`require\(\s*['"]([^'"]+)['"]\s*\)`),
+		},
+	},
+	"Python": {
+		types:     []*regexp.Regexp{regexp.MustCompile(`(?m)^class\s+([A-Za-z_]\w*)`)},
+		functions: []*regexp.Regexp{regexp.MustCompile(`(?m)^(?:async\s+)?def\s+([A-Za-z_]\w*)`)},
+		imports: []*regexp.Regexp{
+			regexp.MustCompile(`(?m)^import\s+([\w.]+)`),
+			regexp.MustCompile(`(?m)^from\s+([\w.]+)\s+import`),
+		},
+	},
+	"Java": {
+		types:     []*regexp.Regexp{regexp.MustCompile(`(?m)^\s*(?:public\s+|protected\s+|private\s+)?(?:abstract\s+|final\s+)?(?:class|interface|enum|record)\s+([A-Za-z_]\w*)`)},
+		functions: []*regexp.Regexp{regexp.MustCompile(`(?m)^\s+(?:public|protected|private)\s+(?:static\s+)?[\w<>\[\], ]+\s+([a-z]\w*)\s*\(`)},
+		imports:   []*regexp.Regexp{regexp.MustCompile(`(?m)^import\s+(?:static\s+)?([\w.]+)`)},
+	},
+	"Rust": {
+		types:     []*regexp.Regexp{regexp.MustCompile(`(?m)^\s*(?:pub\s+)?(?:struct|enum|trait)\s+([A-Za-z_]\w*)`)},
+		functions: []*regexp.Regexp{regexp.MustCompile(`(?m)^\s*(?:pub\s+)?(?:async\s+)?fn\s+([A-Za-z_]\w*)`)},
+		imports:   []*regexp.Regexp{regexp.MustCompile(`(?m)^use\s+([\w:]+)`)},
+	},
+	"Ruby": {
+		types:     []*regexp.Regexp{regexp.MustCompile(`(?m)^\s*(?:class|module)\s+([A-Z]\w*)`)},
+		functions: []*regexp.Regexp{regexp.MustCompile(`(?m)^\s*def\s+(?:self\.)?([a-z_]\w*[?!]?)`)},
+		imports:   []*regexp.Regexp{regexp.MustCompile(`(?m)^\s*require(?:_relative)?\s+['"]([^'"]+)['"]`)},
+	},
+	"PHP": {
+		types:     []*regexp.Regexp{regexp.MustCompile(`(?m)^\s*(?:abstract\s+|final\s+)?(?:class|interface|trait)\s+([A-Za-z_]\w*)`)},
+		functions: []*regexp.Regexp{regexp.MustCompile(`(?m)function\s+([A-Za-z_]\w*)\s*\(`)},
+		imports:   []*regexp.Regexp{regexp.MustCompile(`(?m)^use\s+([\w\\]+)`)},
+	},
+	"SQL": {
+		types: []*regexp.Regexp{regexp.MustCompile(`(?im)^\s*CREATE\s+TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?["` + "`" + `]?([\w.]+)`)},
+	},
+}
+
+// sideEffectHints maps source substrings to the side effect they suggest
+var sideEffectHints = []struct {
+	needle string
+	effect string
+}{
+	{"http.ListenAndServe", "starts an HTTP server"},
+	{".Start(\":", "starts an HTTP server"},
+	{"app.listen(", "starts an HTTP server"},
+	{"http.Get(", "makes outbound HTTP requests"},
+	{"http.NewRequest", "makes outbound HTTP requests"},
+	{"fetch(", "makes outbound HTTP requests"},
+	{"axios", "makes outbound HTTP requests"},
+	{"requests.", "makes outbound HTTP requests"},
+	{"sql.Open", "opens a database connection"},
+	{"gorm.Open", "opens a database connection"},
+	{"mongoose.connect", "opens a database connection"},
+	{"os.WriteFile", "writes to the filesystem"},
+	{"os.Create", "writes to the filesystem"},
+	{"fs.writeFile", "writes to the filesystem"},
+	{"exec.Command", "executes external processes"},
+	{"child_process", "executes external processes"},
+	{"subprocess", "executes external processes"},
+	{"os.Getenv", "reads environment variables"},
+	{"process.env", "reads environment variables"},
+	{"os.environ", "reads environment variables"},
+}
+
+// externalServiceHints maps import fragments to well-known external services, checked in order
+var externalServiceHints = []struct {
+	needle  string
+	service string
+}{
+	{"redis", "Redis"},
+	{"postgres", "PostgreSQL"},
+	{"lib/pq", "PostgreSQL"},
+	{"pgx", "PostgreSQL"},
+	{"mysql", "MySQL"},
+	{"mongo", "MongoDB"},
+	{"kafka", "Kafka"},
+	{"amqp", "RabbitMQ"},
+	{"rabbitmq", "RabbitMQ"},
+	{"nats", "NATS"},
+	{"aws-sdk", "AWS"},
+	{"boto3", "AWS"},
+	{"cloud.google", "Google Cloud"},
+	{"firebase", "Firebase"},
+	{"stripe", "Stripe"},
+	{"twilio", "Twilio"},
+	{"sendgrid", "SendGrid"},
+	{"elasticsearch", "Elasticsearch"},
+	{"openai", "OpenAI"},
+	{"sentry", "Sentry"},
+	{"grpc", "gRPC"},
+}
+
+// LanguageForPath returns the language name for a file path based on its name and extension
+func LanguageForPath(path string) string {
+	return languageFor(path, "")
+}
+
+// languageFor detects the language from the path and, for ambiguous extensions, the content
+func languageFor(path, content string) string {
+	if lang, ok := languages.Detect(path, content); ok {
+		return lang.Name
+	}
+	return "Unknown"
+}
+
+// SummarizeFile builds a FileSummary from deterministic source heuristics
+func SummarizeFile(relativePath, content string) *internalOpenai.FileSummary {
+	language := languageFor(relativePath, content)
+
+	// TypeScript shares the JavaScript symbol patterns
+	patternLanguage := language
+	if patternLanguage == "TypeScript" {
+		patternLanguage = "JavaScript"
+	}
+
+	patterns := patternsByLanguage[patternLanguage]
+	summary := &internalOpenai.FileSummary{
+		Language:  language,
+		KeyTypes:  limitStrings(matchAll(patterns.types, content), 10),
+		Functions: limitStrings(matchAll(patterns.functions, content), 15),
+		Imports:   limitStrings(matchAll(patterns.imports, content), 15),
+	}
+
+	for _, hint := range sideEffectHints {
+		if strings.Contains(content, hint.needle) {
+			summary.SideEffects = appendUnique(summary.SideEffects, hint.effect)
+		}
+	}
+
+	summary.Complexity = complexityForContent(content, len(summary.Functions))
+	summary.Purpose = fmt.Sprintf("%s %s", OfflineMarker, describeFile(relativePath, content, summary))
+
+	return summary
+}
+
+// SummarizeFolder aggregates file and subfolder summaries into a FolderSummary without an LLM
+func SummarizeFolder(folderPath string, files map[string]internalOpenai.FileSummary, children map[string]internalOpenai.FolderSummary) *internalOpenai.FolderSummary {
+	languages := make(map[string]int)
+	var keyModules []string
+	var dependencies []string
+
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		summary := files[path]
+		if summary.Language != "" {
+			languages[summary.Language]++
+		}
+		if len(summary.KeyTypes) > 0 || len(summary.Functions) > 3 {
+			keyModules = append(keyModules, filepath.Base(path))
+		}
+		for _, imp := range summary.Imports {
+			dependencies = appendUnique(dependencies, imp)
+		}
+	}
+
+	subfolders := internalOpenai.SortedFolderPaths(children)
+	purpose := describeFolder(folderPath, len(files), languages)
+	if len(files) == 0 {
+		purpose = fmt.Sprintf("Folder grouping %d subfolders", len(subfolders))
+	}
+	if len(subfolders) > 0 {
+		names := make([]string, 0, len(subfolders))
+		for _, sub := range subfolders {
+			names = append(names, filepath.Base(sub))
+		}
+		purpose += fmt.Sprintf(" (subfolders: %s)", strings.Join(limitStrings(names, 8), ", "))
+	}
+
+	return &internalOpenai.FolderSummary{
+		Path:          folderPath,
+		Purpose:       fmt.Sprintf("%s %s", OfflineMarker, purpose),
+		Languages:     languages,
+		KeyModules:    limitStrings(keyModules, 10),
+		Dependencies:  limitStrings(dependencies, 20),
+		Architecture:  architectureHintForFolder(folderPath),
+		FileSummaries: files,
+		Subfolders:    subfolders,
+	}
+}
+
+// SummarizeProject builds a ProjectSummary from folder summaries and the README, if any
+func SummarizeProject(projectPath string, folders map[string]internalOpenai.FolderSummary, readme string) *internalOpenai.ProjectSummary {
+	languages := make(map[string]int)
+	var dataModels []string
+	var externalServices []string
+
+	folderPaths := make([]string, 0, len(folders))
+	for path := range folders {
+		folderPaths = append(folderPaths, path)
+	}
+	sort.Strings(folderPaths)
+
+	for _, path := range folderPaths {
+		folder := folders[path]
+		for lang, count := range folder.Languages {
+			languages[lang] += count
+		}
+		for _, dep := range folder.Dependencies {
+			if service := externalServiceForImport(dep); service != "" {
+				externalServices = appendUnique(externalServices, service)
+			}
+		}
+		if isModelFolder(path) {
+			for _, file := range folder.FileSummaries {
+				for _, typ := range file.KeyTypes {
+					dataModels = appendUnique(dataModels, typ)
+				}
+			}
+		}
+	}
+	sort.Strings(dataModels)
+	sort.Strings(externalServices)
+
+	purpose := ExtractReadmeSummary(readme)
+	if purpose == "" {
+		purpose = fmt.Sprintf("Project at %s written primarily in %s", filepath.Base(projectPath), dominantLanguage(languages))
+	}
+
+	return &internalOpenai.ProjectSummary{
+		Purpose:          fmt.Sprintf("%s %s", OfflineMarker, purpose),
+		Architecture:     fmt.Sprintf("%s %s", OfflineMarker, describeLayout(folderPaths)),
+		DataModels:       limitStrings(dataModels, 20),
+		ExternalServices: externalServices,
+		Languages:        languages,
+		FolderSummaries:  folders,
+	}
+}
+
+// ExtractReadmeSummary returns the first descriptive paragraph of a README with markdown stripped
+func ExtractReadmeSummary(readme string) string {
+	if strings.TrimSpace(readme) == "" {
+		return ""
+	}
+
+	var paragraph []string
+	inCodeBlock := false
+	lines := strings.Split(readme, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			inCodeBlock = !inCodeBlock
+			continue
+		}
+		if inCodeBlock {
+			continue
+		}
+
+		// Skip headings, badges, html and tables - they rarely describe the project
+		// Setext headings are a line of text underlined with === or ---
+		isSetextHeading := i+1 < len(lines) && isSetextUnderline(lines[i+1])
+		isNoise := isSetextHeading || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "![") ||
+			strings.HasPrefix(trimmed, "[![") || strings.HasPrefix(trimmed, "<") ||
+			strings.HasPrefix(trimmed, "|") || strings.HasPrefix(trimmed, "---") ||
+			strings.HasPrefix(trimmed, "===")
+
+		if trimmed == "" || isNoise {
+			if len(paragraph) > 0 {
+				break
+			}
+			continue
+		}
+		paragraph = append(paragraph, trimmed)
+	}
+
+	text := strings.Join(paragraph, " ")
+	text = markdownLinkRegex.ReplaceAllString(text, "$1")
+	text = strings.NewReplacer("**", "", "__", "", "`", "").Replace(text)
+
+	// Cut on a rune boundary so multi-byte characters are not split
+	if runes := []rune(text); len(runes) > 400 {
+		text = string(runes[:400]) + "..."
+	}
+	return strings.TrimSpace(text)
+}
+
+var markdownLinkRegex = regexp.MustCompile(`\[([^\]]+)\]\([^)]+\)`)
+
+// matchAll returns the unique first capture group of every pattern match
+func matchAll(patterns []*regexp.Regexp, content string) []string {
+	var results []string
+	for _, pattern := range patterns {
+		for _, match := range pattern.FindAllStringSubmatch(content, -1) {
+			if len(match) > 1 && match[1] != "" {
+				results = appendUnique(results, match[1])
+			}
+		}
+	}
+	return results
+}
+
+// describeFile produces a one-line description of a file from its name and symbols
+func describeFile(relativePath, content string, summary *internalOpenai.FileSummary) string {
+	base := strings.ToLower(filepath.Base(relativePath))
+	lowerPath := strings.ToLower(relativePath)
+
+	switch {
+	case base == "main.go" || base == "index.js" || base == "server.js" || base == "app.py" || base == "main.py":
+		return fmt.Sprintf("%s entry point", summary.Language)
+	case strings.HasSuffix(base, "_test.go") || strings.Contains(base, ".test.") || strings.Contains(base, ".spec.") || strings.HasPrefix(base, "test_"):
+		return fmt.Sprintf("%s tests", summary.Language)
+	case strings.Contains(lowerPath, "migration") && summary.Language == "SQL":
+		return "Database migration"
+	case base == "dockerfile" || strings.HasPrefix(base, "docker-compose"):
+		return "Container build/runtime configuration"
+	case base == "package.json" || base == "go.mod" || base == "requirements.txt" || base == "cargo.toml" || base == "pom.xml":
+		return "Dependency manifest"
+	case summary.Language == "Markdown":
+		if heading := firstHeading(content); heading != "" {
+			return fmt.Sprintf("Documentation: %s", heading)
+		}
+		return "Documentation"
+	}
+
+	var parts []string
+	if role := roleFromPath(lowerPath); role != "" {
+		parts = append(parts, role)
+	} else {
+		parts = append(parts, fmt.Sprintf("%s source", summary.Language))
+	}
+	if len(summary.KeyTypes) > 0 {
+		parts = append(parts, fmt.Sprintf("defining %s", strings.Join(limitStrings(summary.KeyTypes, 3), ", ")))
+	} else if len(summary.Functions) > 0 {
+		parts = append(parts, fmt.Sprintf("with %d functions", len(summary.Functions)))
+	}
+	return strings.Join(parts, " ")
+}
+
+// roleFromPath guesses a file's role from conventional directory and file names
+func roleFromPath(lowerPath string) string {
+	roles := []struct {
+		needle string
+		role   string
+	}{
+		{"controller", "Request controller"},
+		{"handler", "Request handler"},
+		{"route", "Routing definitions"},
+		{"middleware", "Middleware"},
+		{"model", "Data model"},
+		{"entity", "Data model"},
+		{"schema", "Schema definition"},
+		{"repositor", "Data access layer"},
+		{"service", "Service logic"},
+		{"config", "Configuration"},
+		{"util", "Utility helpers"},
+		{"helper", "Utility helpers"},
+		{"component", "UI component"},
+		{"hook", "UI hook"},
+		{"page", "UI page"},
+		{"cmd/", "Command entry point"},
+	}
+	for _, r := range roles {
+		if strings.Contains(lowerPath, r.needle) {
+			return r.role
+		}
+	}
+	return ""
+}
+
+// describeFolder produces a one-line description of a folder
+func describeFolder(folderPath string, fileCount int, languages map[string]int) string {
+	role := roleFromPath(strings.ToLower(folderPath) + "/")
+	if role == "" {
+		role = "Folder"
+	}
+	return fmt.Sprintf("%s containing %d %s files", role, fileCount, dominantLanguage(languages))
+}
+
+// architectureHintForFolder returns a layer name for well-known folder names
+func architectureHintForFolder(folderPath string) string {
+	lower := strings.ToLower(folderPath)
+	switch {
+	case strings.Contains(lower, "controller") || strings.Contains(lower, "handler") || strings.Contains(lower, "route"):
+		return "presentation layer"
+	case strings.Contains(lower, "service") || strings.Contains(lower, "usecase") || strings.Contains(lower, "domain"):
+		return "business logic layer"
+	case strings.Contains(lower, "model") || strings.Contains(lower, "repositor") || strings.Contains(lower, "migration") || strings.Contains(lower, "db"):
+		return "data layer"
+	case strings.Contains(lower, "component") || strings.Contains(lower, "page") || strings.Contains(lower, "view"):
+		return "UI layer"
+	}
+	return ""
+}
+
+// describeLayout guesses the overall architecture from the folder layout
+func describeLayout(folderPaths []string) string {
+	hasServices := false
+	hasLayers := 0
+	for _, path := range folderPaths {
+		lower := strings.ToLower(path)
+		if strings.HasPrefix(lower, "services/") || strings.HasPrefix(lower, "apps/") || strings.HasPrefix(lower, "packages/") {
+			hasServices = true
+		}
+		if architectureHintForFolder(path) != "" {
+			hasLayers++
+		}
+	}
+
+	switch {
+	case hasServices:
+		return "Multi-package repository (services/apps/packages layout)"
+	case hasLayers >= 2:
+		return "Layered application (controllers/services/models layout)"
+	case len(folderPaths) <= 2:
+		return "Small single-module project"
+	}
+	return "Single application"
+}
+
+// isModelFolder reports whether a folder usually holds data models
+func isModelFolder(path string) bool {
+	lower := strings.ToLower(path)
+	return strings.Contains(lower, "model") || strings.Contains(lower, "entit") || strings.Contains(lower, "schema") || strings.Contains(lower, "domain")
+}
+
+// externalServiceForImport maps an import path to a known external service
+func externalServiceForImport(imp string) string {
+	lower := strings.ToLower(imp)
+	for _, hint := range externalServiceHints {
+		if strings.Contains(lower, hint.needle) {
+			return hint.service
+		}
+	}
+	return ""
+}
+
+// dominantLanguage returns the language with the most files
+func dominantLanguage(languages map[string]int) string {
+	best := ""
+	bestCount := 0
+	for lang, count := range languages {
+		if lang == "Unknown" {
+			continue
+		}
+		if count > bestCount || (count == bestCount && lang < best) {
+			best = lang
+			bestCount = count
+		}
+	}
+	if best == "" {
+		return "mixed"
+	}
+	return best
+}
+
+// complexityForContent estimates complexity from line and function counts
+func complexityForContent(content string, functionCount int) string {
+	lines := strings.Count(content, "\n") + 1
+	switch {
+	case lines > 600 || functionCount > 30:
+		return "high"
+	case lines > 200 || functionCount > 10:
+		return "medium"
+	}
+	return "low"
+}
+
+// firstHeading returns the first markdown heading in content
+func firstHeading(content string) string {
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "#") {
+			return strings.TrimSpace(strings.TrimLeft(trimmed, "#"))
+		}
+	}
+	return ""
+}
+
+// appendUnique appends value if it is not already present
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
+
+// limitStrings truncates a slice to at most n entries
+func limitStrings(values []string, n int) []string {
+	if len(values) > n {
+		return values[:n]
+	}
+	return values
+}
@@ -0,0 +1,224 @@
+package infrastructure
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"repo-explanation/internal/filestore"
+	"repo-explanation/internal/mermaid"
+	"repo-explanation/internal/microservices"
+)
+
+// Inventory is the cloud infrastructure declared in a repository's Terraform code
+type Inventory struct {
+	Providers    []Provider     `json:"providers"`
+	Resources    []Resource     `json:"resources"`
+	Variables    []Variable     `json:"variables,omitempty"`
+	Links        []ResourceLink `json:"links,omitempty"`
+	Categories   map[string]int `json:"categories"`
+	Files        []string       `json:"files"`
+	MermaidGraph string         `json:"mermaid_graph"`
+	Summary      string         `json:"summary"`
+}
+
+// ResourceLink connects a service to a cloud resource it references
+type ResourceLink struct {
+	Service    string  `json:"service"`
+	Resource   string  `json:"resource"` // Terraform address, e.g. aws_sqs_queue.orders
+	Evidence   string  `json:"evidence"`
+	FilePath   string  `json:"file_path"`
+	Confidence float64 `json:"confidence"`
+}
+
+// Categories worth linking to services; networking and IAM plumbing are referenced by name everywhere
+var linkableCategories = map[string]bool{
+	"database":  true,
+	"cache":     true,
+	"messaging": true,
+	"storage":   true,
+	"secrets":   true,
+	"compute":   true,
+}
+
+// BuildInventory parses every .tf file in files and links the declared resources to the services
+// whose code or config references them, reading one file at a time.
+// Returns nil when the repository has no Terraform resources, providers or variables.
+func BuildInventory(files *filestore.FileStore, services []microservices.DiscoveredService) *Inventory {
+	inventory := &Inventory{Categories: make(map[string]int)}
+	providers := make(map[string]Provider)
+
+	files.Walk(IsTerraformFile, func(path, content string) error {
+		tf := ParseTerraform(path, content)
+		if len(tf.Providers) == 0 && len(tf.Resources) == 0 && len(tf.Variables) == 0 {
+			return nil
+		}
+		inventory.Files = append(inventory.Files, path)
+
+		for _, provider := range tf.Providers {
+			key := provider.Name + "." + provider.Alias
+			if _, exists := providers[key]; !exists {
+				providers[key] = provider
+			}
+		}
+		for _, resource := range tf.Resources {
+			inventory.Resources = append(inventory.Resources, resource)
+			inventory.Categories[resource.Category]++
+			// Providers used without an explicit provider block still belong in the inventory
+			if _, exists := providers[resource.Provider+"."]; !exists {
+				providers[resource.Provider+"."] = Provider{Name: resource.Provider}
+			}
+		}
+		inventory.Variables = append(inventory.Variables, tf.Variables...)
+		return nil
+	})
+
+	if len(inventory.Files) == 0 {
+		return nil
+	}
+
+	for _, key := range sortedKeys(providers) {
+		inventory.Providers = append(inventory.Providers, providers[key])
+	}
+
+	inventory.Links = linkResources(inventory.Resources, files, services)
+	inventory.MermaidGraph = generateMermaidGraph(inventory)
+	inventory.Summary = fmt.Sprintf("%d resources across %d providers, %d variables, %d service links",
+		len(inventory.Resources), len(inventory.Providers), len(inventory.Variables), len(inventory.Links))
+
+	fmt.Printf("☁️  [INFRA] %s\n", inventory.Summary)
+	return inventory
+}
+
+// resourceMatcher holds the patterns that identify references to one resource
+type resourceMatcher struct {
+	resource  Resource
+	cloudName string         // Literal cloud name, matched case-insensitively
+	envVar    *regexp.Regexp // Environment variable derived from the resource label (ORDERS_QUEUE_URL)
+}
+
+// linkResources finds the services whose files mention a resource's cloud name or label-derived env var
+func linkResources(resources []Resource, files *filestore.FileStore, services []microservices.DiscoveredService) []ResourceLink {
+	if len(services) == 0 {
+		return nil
+	}
+
+	var matchers []resourceMatcher
+	for _, resource := range resources {
+		if !linkableCategories[resource.Category] {
+			continue
+		}
+		matcher := resourceMatcher{resource: resource, cloudName: strings.ToLower(resource.CloudName)}
+		if label := strings.ToUpper(strings.ReplaceAll(resource.Name, "-", "_")); len(label) >= 4 && label != "MAIN" && label != "THIS" && label != "DEFAULT" {
+			matcher.envVar = regexp.MustCompile(`\b` + regexp.QuoteMeta(label) + `(?:_[A-Z0-9]+)*\b`)
+		}
+		if matcher.cloudName == "" && matcher.envVar == nil {
+			continue
+		}
+		matchers = append(matchers, matcher)
+	}
+
+	var links []ResourceLink
+	seen := make(map[string]bool)
+
+	owned := func(path string) bool {
+		return !IsTerraformFile(path) && microservices.OwningService(path, services) != ""
+	}
+	files.Walk(owned, func(path, content string) error {
+		service := microservices.OwningService(path, services)
+		lowerContent := strings.ToLower(content)
+
+		for _, matcher := range matchers {
+			key := service + "->" + matcher.resource.Address()
+			if seen[key] {
+				continue
+			}
+
+			var link *ResourceLink
+			if matcher.cloudName != "" && strings.Contains(lowerContent, matcher.cloudName) {
+				link = &ResourceLink{Evidence: fmt.Sprintf("References resource name %q", matcher.resource.CloudName), Confidence: 0.8}
+			} else if matcher.envVar != nil {
+				if envVar := matcher.envVar.FindString(content); envVar != "" {
+					link = &ResourceLink{Evidence: fmt.Sprintf("Reads environment variable %s", envVar), Confidence: 0.6}
+				}
+			}
+			if link == nil {
+				continue
+			}
+
+			link.Service = service
+			link.Resource = matcher.resource.Address()
+			link.FilePath = path
+			links = append(links, *link)
+			seen[key] = true
+		}
+		return nil
+	})
+
+	sort.SliceStable(links, func(i, j int) bool {
+		if links[i].Service != links[j].Service {
+			return links[i].Service < links[j].Service
+		}
+		return links[i].Resource < links[j].Resource
+	})
+	return links
+}
+
+// generateMermaidGraph draws providers as subgraphs of resources, with services pointing at what they use
+func generateMermaidGraph(inventory *Inventory) string {
+	graph := mermaid.NewFlowchart("LR")
+
+	byProvider := make(map[string][]Resource)
+	for _, resource := range inventory.Resources {
+		byProvider[resource.Provider] = append(byProvider[resource.Provider], resource)
+	}
+
+	providerNames := make([]string, 0, len(byProvider))
+	for name := range byProvider {
+		providerNames = append(providerNames, name)
+	}
+	sort.Strings(providerNames)
+
+	for _, provider := range providerNames {
+		graph.Subgraph("provider_"+provider, provider)
+		for _, resource := range byProvider[provider] {
+			label := resource.Name
+			if resource.CloudName != "" {
+				label = resource.CloudName
+			}
+			graph.Node(resource.Address(), resource.Category+": "+label, mermaid.Database)
+		}
+		graph.EndSubgraph()
+	}
+
+	if len(inventory.Links) > 0 {
+		graph.Blank()
+		services := make(map[string]bool)
+		for _, link := range inventory.Links {
+			if !services[link.Service] {
+				services[link.Service] = true
+				graph.Node("svc_"+link.Service, link.Service, mermaid.Rectangle)
+			}
+		}
+		for _, link := range inventory.Links {
+			graph.Edge("svc_"+link.Service, link.Resource, mermaid.Solid, "")
+		}
+	}
+
+	diagram, err := mermaid.Clean(graph.String())
+	if err != nil {
+		fmt.Printf("⚠️  Infrastructure graph is not valid Mermaid: %v\n", err)
+	}
+	return diagram
+}
+
+// sortedKeys returns the keys of a provider map in sorted order
+func sortedKeys(m map[string]Provider) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,275 @@
+package infrastructure
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// Top-level HCL block header: `resource "aws_sqs_queue" "orders" {`
+	blockHeaderRegex = regexp.MustCompile(`^\s*(resource|data|provider|variable|module|output)\s+"([^"]+)"(?:\s+"([^"]+)")?\s*\{`)
+	attributeRegex   = regexp.MustCompile(`^\s*([A-Za-z_][\w-]*)\s*=\s*(.+?)\s*$`)
+	interpolation    = regexp.MustCompile(`\$\{[^}]*\}`)
+	providerPrefixes = regexp.MustCompile(`^([a-z0-9]+)_`)
+)
+
+// Provider is a cloud or platform provider configured or used by the Terraform code
+type Provider struct {
+	Name   string `json:"name"`
+	Alias  string `json:"alias,omitempty"`
+	Region string `json:"region,omitempty"`
+	File   string `json:"file,omitempty"`
+}
+
+// Resource is a managed Terraform resource such as an RDS instance or SQS queue
+type Resource struct {
+	Type      string `json:"type"`
+	Name      string `json:"name"`
+	Category  string `json:"category"` // "database", "messaging", "storage", "iam", ...
+	Provider  string `json:"provider"`
+	CloudName string `json:"cloud_name,omitempty"` // Literal name given to the cloud resource, if any
+	File      string `json:"file"`
+}
+
+// Address returns the Terraform address of the resource (e.g. aws_sqs_queue.orders)
+func (r Resource) Address() string {
+	return r.Type + "." + r.Name
+}
+
+// Variable is a Terraform input variable
+type Variable struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Type        string `json:"type,omitempty"`
+	Default     string `json:"default,omitempty"`
+	HasDefault  bool   `json:"has_default"`
+	Sensitive   bool   `json:"sensitive,omitempty"`
+	File        string `json:"file"`
+}
+
+// TerraformFile holds the blocks parsed from a single .tf file
+type TerraformFile struct {
+	Path      string
+	Providers []Provider
+	Resources []Resource
+	Variables []Variable
+}
+
+// hclBlock is a top-level block with its direct attributes
+type hclBlock struct {
+	kind       string
+	labels     []string
+	attributes map[string]string
+}
+
+// IsTerraformFile reports whether a path is a Terraform configuration file
+func IsTerraformFile(path string) bool {
+	return strings.HasSuffix(strings.ToLower(path), ".tf")
+}
+
+// ParseTerraform extracts providers, managed resources and variables from a .tf file.
+// It is a lightweight line-based reader rather than a full HCL parser: only top-level
+// blocks and their direct attributes are read, which is all the inventory needs.
+func ParseTerraform(path, content string) *TerraformFile {
+	tf := &TerraformFile{Path: path}
+
+	for _, block := range parseBlocks(content) {
+		switch block.kind {
+		case "provider":
+			tf.Providers = append(tf.Providers, Provider{
+				Name:   block.labels[0],
+				Alias:  literalValue(block.attributes["alias"]),
+				Region: literalValue(block.attributes["region"]),
+				File:   path,
+			})
+		case "resource":
+			if len(block.labels) < 2 {
+				continue
+			}
+			tf.Resources = append(tf.Resources, Resource{
+				Type:      block.labels[0],
+				Name:      block.labels[1],
+				Category:  ResourceCategory(block.labels[0]),
+				Provider:  providerForType(block.labels[0]),
+				CloudName: cloudName(block.attributes),
+				File:      path,
+			})
+		case "variable":
+			raw, hasDefault := block.attributes["default"]
+			tf.Variables = append(tf.Variables, Variable{
+				Name:        block.labels[0],
+				Description: literalValue(block.attributes["description"]),
+				Type:        block.attributes["type"],
+				Default:     literalValue(raw),
+				HasDefault:  hasDefault,
+				Sensitive:   block.attributes["sensitive"] == "true",
+				File:        path,
+			})
+		}
+	}
+
+	return tf
+}
+
+// parseBlocks reads every top-level block and the attributes directly inside it
+func parseBlocks(content string) []hclBlock {
+	var blocks []hclBlock
+	var current *hclBlock
+	depth := 0
+	inComment := false
+
+	for _, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		code := stripComments(line, &inComment)
+		if strings.TrimSpace(code) == "" {
+			continue
+		}
+
+		if depth == 0 {
+			if match := blockHeaderRegex.FindStringSubmatch(code); match != nil {
+				labels := []string{match[2]}
+				if match[3] != "" {
+					labels = append(labels, match[3])
+				}
+				blocks = append(blocks, hclBlock{kind: match[1], labels: labels, attributes: make(map[string]string)})
+				current = &blocks[len(blocks)-1]
+			} else {
+				current = nil
+			}
+		} else if depth == 1 && current != nil {
+			if match := attributeRegex.FindStringSubmatch(code); match != nil {
+				current.attributes[match[1]] = match[2]
+			}
+		}
+
+		depth += braceDelta(code)
+		if depth <= 0 {
+			depth = 0
+			current = nil
+		}
+	}
+
+	return blocks
+}
+
+// stripComments removes #, // and /* */ comments outside of string literals
+func stripComments(line string, inComment *bool) string {
+	var out strings.Builder
+	inString := false
+
+	for i := 0; i < len(line); i++ {
+		ch := line[i]
+		if *inComment {
+			if ch == '*' && i+1 < len(line) && line[i+1] == '/' {
+				*inComment = false
+				i++
+			}
+			continue
+		}
+		if inString {
+			out.WriteByte(ch)
+			if ch == '\\' && i+1 < len(line) {
+				out.WriteByte(line[i+1])
+				i++
+			} else if ch == '"' {
+				inString = false
+			}
+			continue
+		}
+
+		switch {
+		case ch == '"':
+			inString = true
+		case ch == '#':
+			return out.String()
+		case ch == '/' && i+1 < len(line) && line[i+1] == '/':
+			return out.String()
+		case ch == '/' && i+1 < len(line) && line[i+1] == '*':
+			*inComment = true
+			i++
+			continue
+		}
+		out.WriteByte(ch)
+	}
+
+	return out.String()
+}
+
+// braceDelta counts opening minus closing braces outside of string literals.
+// Interpolations like "${var.name}" sit inside strings and are ignored.
+func braceDelta(code string) int {
+	delta := 0
+	inString := false
+	for i := 0; i < len(code); i++ {
+		switch ch := code[i]; {
+		case inString && ch == '\\':
+			i++
+		case ch == '"':
+			inString = !inString
+		case !inString && (ch == '{' || ch == '['):
+			delta++
+		case !inString && (ch == '}' || ch == ']'):
+			delta--
+		}
+	}
+	return delta
+}
+
+// literalValue unquotes a string attribute; non-string expressions are returned as written
+func literalValue(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if len(raw) >= 2 && strings.HasPrefix(raw, `"`) && strings.HasSuffix(raw, `"`) {
+		return raw[1 : len(raw)-1]
+	}
+	return raw
+}
+
+// cloudName returns the literal name given to the cloud resource, with interpolations removed
+func cloudName(attributes map[string]string) string {
+	for _, key := range []string{"name", "bucket", "identifier", "cluster_identifier", "queue_name", "function_name", "table_name", "topic", "repository_name"} {
+		raw, ok := attributes[key]
+		if !ok || !strings.HasPrefix(strings.TrimSpace(raw), `"`) {
+			continue
+		}
+		name := strings.Trim(interpolation.ReplaceAllString(literalValue(raw), ""), "-_./ ")
+		if len(name) >= 3 {
+			return name
+		}
+	}
+	return ""
+}
+
+// providerForType derives the provider from a resource type prefix (aws_s3_bucket -> aws)
+func providerForType(resourceType string) string {
+	if match := providerPrefixes.FindStringSubmatch(resourceType); match != nil {
+		return match[1]
+	}
+	return resourceType
+}
+
+// ResourceCategory groups a resource type into an inventory category
+func ResourceCategory(resourceType string) string {
+	t := strings.ToLower(resourceType)
+
+	categories := []struct {
+		name     string
+		patterns []string
+	}{
+		{"database", []string{"db_instance", "rds_", "_sql_", "dynamodb", "docdb", "postgresql", "mysql", "cosmosdb", "spanner", "bigtable", "redshift", "neptune"}},
+		{"cache", []string{"elasticache", "redis", "memcache"}},
+		{"messaging", []string{"sqs", "sns", "pubsub", "kinesis", "msk", "kafka", "eventbridge", "cloudwatch_event", "servicebus", "eventhub", "mq_broker"}},
+		{"storage", []string{"s3_bucket", "storage_bucket", "storage_account", "storage_container", "efs_", "ebs_volume"}},
+		{"iam", []string{"iam_", "service_account", "role_assignment", "kms_"}},
+		{"secrets", []string{"secretsmanager", "secret_manager", "key_vault", "ssm_parameter"}},
+		{"compute", []string{"lambda", "ecs_", "eks_", "instance", "cloud_run", "cloudfunctions", "container_cluster", "kubernetes_deployment", "app_service", "function_app", "autoscaling"}},
+		{"network", []string{"vpc", "subnet", "security_group", "lb", "route53", "dns", "cloudfront", "api_gateway", "apigateway", "nat_gateway", "internet_gateway", "network"}},
+	}
+
+	for _, category := range categories {
+		for _, pattern := range category.patterns {
+			if strings.Contains(t, pattern) {
+				return category.name
+			}
+		}
+	}
+	return "other"
+}
@@ -0,0 +1,142 @@
+// Package jobs inventories what runs in the background: cron entries, Kubernetes CronJobs,
+// scheduled CI workflows, in-process schedulers (Celery beat, APScheduler, Go cron libraries,
+// node-cron, NestJS, Spring) and queue workers (Celery, Sidekiq, ActiveJob, Bull).
+package jobs
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"repo-explanation/internal/filestore"
+	"repo-explanation/internal/microservices"
+)
+
+// Triggers
+const (
+	Scheduled = "schedule" // Runs on a cron expression or interval
+	Queued    = "queue"    // Runs when a message is put on a queue
+)
+
+// Job is one background job or worker
+type Job struct {
+	Name     string `json:"name"`
+	Kind     string `json:"kind"` // crontab, kubernetes_cronjob, github_actions, celery_beat, go_cron, bull, sidekiq, ...
+	Trigger  string `json:"trigger"`
+	Schedule string `json:"schedule,omitempty"` // Cron expression or interval as written
+	Cadence  string `json:"cadence,omitempty"`  // The schedule in words, e.g. "every 5 minutes"
+	Queue    string `json:"queue,omitempty"`
+	Handler  string `json:"handler,omitempty"` // Function, task, class or command the job runs
+	Service  string `json:"service,omitempty"` // Empty for files outside every discovered service
+	File     string `json:"file"`              // Relative to the project root
+	Line     int    `json:"line"`
+}
+
+// Report lists a project's background jobs
+type Report struct {
+	Jobs    []Job  `json:"jobs"`
+	Summary string `json:"summary"`
+}
+
+// Directories that never hold first-party code; .github is kept for scheduled workflows
+var skippedDirs = map[string]bool{
+	".git": true, "node_modules": true, "vendor": true, "testdata": true, "dist": true,
+	".next": true, "coverage": true, "__pycache__": true, ".venv": true, "venv": true,
+	"test": true, "tests": true, "__tests__": true, "spec": true,
+}
+
+var sourceExtensions = map[string]bool{
+	".go": true, ".js": true, ".jsx": true, ".ts": true, ".tsx": true, ".mjs": true, ".cjs": true,
+	".py": true, ".rb": true, ".java": true, ".kt": true,
+}
+
+// Analyze scans the project for background jobs and attributes each to the service whose
+// directory holds its definition
+func Analyze(files *filestore.FileStore, services []microservices.DiscoveredService) (*Report, error) {
+	fmt.Printf("⏰ [DEBUG] Inventorying background jobs of %d files\n", files.Len())
+
+	var found []Job
+	err := files.Walk(func(rel string) bool {
+		return !filestore.InDirs(rel, skippedDirs) && !isTestFile(path.Base(rel)) && scannerFor(rel) != nil
+	}, func(rel, content string) error {
+		service := microservices.OwningService(path.Dir(rel), services)
+		for _, job := range scannerFor(rel)(rel, content) {
+			job.File = rel
+			job.Service = service
+			if job.Trigger == Scheduled && job.Cadence == "" {
+				job.Cadence = Describe(job.Schedule)
+			}
+			found = append(found, job)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan for background jobs: %v", err)
+	}
+
+	sort.SliceStable(found, func(i, j int) bool {
+		if found[i].Service != found[j].Service {
+			return found[i].Service < found[j].Service
+		}
+		if found[i].File != found[j].File {
+			return found[i].File < found[j].File
+		}
+		return found[i].Line < found[j].Line
+	})
+	report := &Report{Jobs: found}
+	if report.Jobs == nil {
+		report.Jobs = []Job{}
+	}
+	report.Summary = summarize(report)
+	fmt.Printf("✅ [DEBUG] %s\n", report.Summary)
+	return report, nil
+}
+
+// scannerFor picks the scanner for a file from its name and location, or nil to skip it
+func scannerFor(rel string) func(rel, content string) []Job {
+	name := strings.ToLower(path.Base(rel))
+	ext := path.Ext(name)
+	switch {
+	case name == "crontab" || ext == ".cron" || strings.Contains(rel, "/cron.d/") || strings.HasPrefix(rel, "cron.d/"):
+		return scanCrontab
+	case (ext == ".yml" || ext == ".yaml") && strings.Contains(rel, ".github/workflows/"):
+		return scanWorkflow
+	case ext == ".yml" || ext == ".yaml":
+		return scanYAML
+	case sourceExtensions[ext]:
+		return scanSource
+	}
+	return nil
+}
+
+func summarize(report *Report) string {
+	if len(report.Jobs) == 0 {
+		return "No background jobs found"
+	}
+	scheduled, queued := 0, 0
+	kinds := make(map[string]int)
+	for _, job := range report.Jobs {
+		if job.Trigger == Scheduled {
+			scheduled++
+		} else {
+			queued++
+		}
+		kinds[job.Kind]++
+	}
+	names := make([]string, 0, len(kinds))
+	for kind := range kinds {
+		names = append(names, kind)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, kind := range names {
+		parts[i] = fmt.Sprintf("%s %d", kind, kinds[kind])
+	}
+	return fmt.Sprintf("%d scheduled jobs and %d queue workers (%s)", scheduled, queued, strings.Join(parts, ", "))
+}
+
+func isTestFile(name string) bool {
+	return strings.HasSuffix(name, "_test.go") || strings.Contains(name, ".test.") ||
+		strings.Contains(name, ".spec.") || strings.HasPrefix(name, "test_")
+}
@@ -0,0 +1,593 @@
+package jobs
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"repo-explanation/internal/portable"
+)
+
+// maxCommand bounds the command recorded for a cron entry
+const maxCommand = 120
+
+var (
+	crontabMacroRegex = regexp.MustCompile(`^(@\w+)\s+(.+)$`)
+	crontabEnvRegex   = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*\s*=`)
+)
+
+// scanCrontab reads crontab lines: five time fields, or a macro such as @daily, then the command.
+// Files under cron.d carry a user column before the command.
+func scanCrontab(rel, content string) []Job {
+	systemCrontab := strings.Contains("/"+rel, "/cron.d/") || path.Base(rel) == "crontab" && strings.HasPrefix(rel, "etc/")
+	var jobs []Job
+	for i, line := range portable.Lines(content) {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || crontabEnvRegex.MatchString(trimmed) {
+			continue
+		}
+		var schedule, command string
+		if match := crontabMacroRegex.FindStringSubmatch(trimmed); match != nil {
+			schedule, command = match[1], match[2]
+		} else {
+			fields := strings.Fields(trimmed)
+			if len(fields) < 6 {
+				continue
+			}
+			schedule, command = strings.Join(fields[:5], " "), strings.Join(fields[5:], " ")
+		}
+		if systemCrontab {
+			if fields := strings.Fields(command); len(fields) > 1 {
+				command = strings.Join(fields[1:], " ")
+			}
+		}
+		jobs = append(jobs, Job{
+			Name:     commandName(command),
+			Kind:     "crontab",
+			Trigger:  Scheduled,
+			Schedule: schedule,
+			Handler:  truncate(command),
+			Line:     i + 1,
+		})
+	}
+	return jobs
+}
+
+// commandName names a cron entry by the program it runs, the last one of a && chain
+func commandName(command string) string {
+	if i := strings.LastIndex(command, "&&"); i >= 0 {
+		command = command[i+2:]
+	}
+	for _, field := range strings.Fields(command) {
+		if strings.Contains(field, "=") || strings.HasPrefix(field, "/usr/bin/env") {
+			continue
+		}
+		return path.Base(field)
+	}
+	return command
+}
+
+func truncate(s string) string {
+	if len(s) > maxCommand {
+		return s[:maxCommand] + "..."
+	}
+	return s
+}
+
+var (
+	workflowNameRegex = regexp.MustCompile(`^name:\s*['"]?([^'"#]+?)['"]?\s*$`)
+	workflowCronRegex = regexp.MustCompile(`^\s*-\s*cron:\s*['"]([^'"]+)['"]`)
+)
+
+// scanWorkflow finds the cron triggers of a GitHub Actions workflow
+func scanWorkflow(rel, content string) []Job {
+	name := strings.TrimSuffix(path.Base(rel), path.Ext(rel))
+	lines := portable.Lines(content)
+	for _, line := range lines {
+		if match := workflowNameRegex.FindStringSubmatch(line); match != nil {
+			name = strings.TrimSpace(match[1])
+			break
+		}
+	}
+	var jobs []Job
+	for i, line := range lines {
+		if match := workflowCronRegex.FindStringSubmatch(line); match != nil {
+			jobs = append(jobs, Job{Name: name, Kind: "github_actions", Trigger: Scheduled, Schedule: match[1], Handler: rel, Line: i + 1})
+		}
+	}
+	return jobs
+}
+
+var (
+	cronJobKindRegex  = regexp.MustCompile(`^kind:\s*CronJob\s*$`)
+	yamlNameRegex     = regexp.MustCompile(`^\s+name:\s*['"]?([^'"#\s]+)`)
+	yamlScheduleRegex = regexp.MustCompile(`^\s+schedule:\s*['"]?([^'"#]+?)['"]?\s*(?:#.*)?$`)
+	yamlImageRegex    = regexp.MustCompile(`^\s+(?:-\s+)?image:\s*['"]?([^'"#\s]+)`)
+)
+
+// scanYAML finds Kubernetes CronJobs, read line by line so Helm templates work too, and the
+// schedule files of sidekiq-cron and sidekiq-scheduler
+func scanYAML(rel, content string) []Job {
+	var jobs []Job
+	lines := portable.Lines(content)
+	start := 0
+	for i := 0; i <= len(lines); i++ {
+		if i < len(lines) && !strings.HasPrefix(lines[i], "---") {
+			continue
+		}
+		if job, ok := cronJob(lines[start:i], start); ok {
+			jobs = append(jobs, job)
+		}
+		start = i + 1
+	}
+
+	name := strings.ToLower(path.Base(rel))
+	if strings.Contains(name, "schedule") || strings.Contains(name, "sidekiq") {
+		jobs = append(jobs, sidekiqSchedule(content)...)
+	}
+	return jobs
+}
+
+// cronJob reads one YAML document; offset is the line the document starts at
+func cronJob(lines []string, offset int) (Job, bool) {
+	isCronJob := false
+	for _, line := range lines {
+		isCronJob = isCronJob || cronJobKindRegex.MatchString(line)
+	}
+	if !isCronJob {
+		return Job{}, false
+	}
+	job := Job{Kind: "kubernetes_cronjob", Trigger: Scheduled}
+	inMetadata := false
+	for i, line := range lines {
+		if strings.HasPrefix(line, "metadata:") {
+			inMetadata = true
+			continue
+		}
+		if inMetadata && job.Name == "" {
+			if match := yamlNameRegex.FindStringSubmatch(line); match != nil {
+				job.Name = match[1]
+			}
+		}
+		if job.Schedule == "" {
+			if match := yamlScheduleRegex.FindStringSubmatch(line); match != nil {
+				job.Schedule = strings.TrimSpace(match[1])
+				job.Line = offset + i + 1
+			}
+		}
+		if job.Handler == "" {
+			if match := yamlImageRegex.FindStringSubmatch(line); match != nil {
+				job.Handler = match[1]
+			}
+		}
+	}
+	return job, job.Schedule != ""
+}
+
+// sidekiqSchedule finds every mapping with a cron or every key and a class: the jobs of
+// sidekiq-cron and sidekiq-scheduler, named by their key
+func sidekiqSchedule(content string) []Job {
+	var root yaml.Node
+	if yaml.Unmarshal([]byte(content), &root) != nil {
+		return nil
+	}
+	var jobs []Job
+	var walk func(name string, node *yaml.Node)
+	walk = func(name string, node *yaml.Node) {
+		if node.Kind == yaml.DocumentNode {
+			for _, child := range node.Content {
+				walk(name, child)
+			}
+			return
+		}
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		job := Job{Name: strings.TrimPrefix(name, ":"), Kind: "sidekiq", Trigger: Scheduled, Line: node.Line}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := strings.TrimPrefix(node.Content[i].Value, ":"), node.Content[i+1]
+			switch key {
+			case "cron", "every":
+				job.Schedule = value.Value
+				if key == "every" {
+					job.Cadence = "every " + value.Value
+				}
+			case "class":
+				job.Handler = value.Value
+			case "queue":
+				job.Queue = value.Value
+			default:
+				walk(node.Content[i].Value, value)
+			}
+		}
+		if job.Schedule != "" && job.Handler != "" {
+			jobs = append(jobs, job)
+		}
+	}
+	walk("", &root)
+	return jobs
+}
+
+// sourceRule matches one line of source code. build returns false when the match is not a job.
+type sourceRule struct {
+	languages string // Extensions the rule applies to, space separated
+	requires  string // Text the file must contain, such as the library's import; empty for none
+	pattern   *regexp.Regexp
+	build     func(match []string, lines []string, i int) (Job, bool)
+}
+
+func (r sourceRule) appliesTo(ext string) bool {
+	for _, lang := range strings.Fields(r.languages) {
+		if lang == ext {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	goFiles     = ".go"
+	jsFiles     = ".js .jsx .ts .tsx .mjs .cjs"
+	pythonFiles = ".py"
+	rubyFiles   = ".rb"
+	jvmFiles    = ".java .kt"
+)
+
+// scanSource matches the source rules against every code line
+func scanSource(rel, content string) []Job {
+	ext := path.Ext(rel)
+	lines := portable.Lines(content)
+	var jobs []Job
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "*") ||
+			(strings.HasPrefix(trimmed, "#") && (ext == ".py" || ext == ".rb")) {
+			continue
+		}
+		// Pattern definitions (such as this package's rules) name libraries without using them
+		if strings.Contains(line, "regexp.MustCompile(") || strings.Contains(line, "re.compile(") || strings.Contains(line, "new RegExp(") {
+			continue
+		}
+		for _, rule := range sourceRules {
+			if !rule.appliesTo(ext) || (rule.requires != "" && !strings.Contains(content, rule.requires)) {
+				continue
+			}
+			match := rule.pattern.FindStringSubmatch(line)
+			if match == nil {
+				continue
+			}
+			if job, ok := rule.build(match, lines, i); ok {
+				job.Line = i + 1
+				if job.Name == "" {
+					job.Name = strings.TrimSuffix(path.Base(rel), ext)
+				}
+				jobs = append(jobs, job)
+			}
+			break
+		}
+	}
+	return jobs
+}
+
+var (
+	funcNameRegex   = regexp.MustCompile(`(\w+)\s*\(`)
+	goFuncRegex     = regexp.MustCompile(`^func\s+(?:\([^)]*\)\s*)?(\w+)`)
+	assignRegex     = regexp.MustCompile(`(?:const|let|var)\s+(\w+)\s*=`)
+	rubyClassRegex  = regexp.MustCompile(`^\s*class\s+([\w:]+)`)
+	sidekiqQueue    = regexp.MustCompile(`sidekiq_options.*queue:\s*:?['"]?(\w+)`)
+	activeJobQueue  = regexp.MustCompile(`queue_as\s+:?['"]?(\w+)`)
+	doHandlerRegex  = regexp.MustCompile(`\.Do\(\s*([\w.]+)`)
+	newTaskRegex    = regexp.MustCompile(`NewTask\(\s*([\w.]+)`)
+	bullAddRegex    = regexp.MustCompile(`\.add\(\s*['"]([^'"]+)['"]`)
+	decoratorQueue  = regexp.MustCompile(`queue\s*=\s*['"]([^'"]+)['"]`)
+	celeryTaskName  = regexp.MustCompile(`['"]task['"]\s*:\s*['"]([^'"]+)['"]`)
+	celerySchedule  = regexp.MustCompile(`['"]schedule['"]\s*:\s*(.+?),?\s*$`)
+	celeryCrontab   = regexp.MustCompile(`crontab\((.*)\)`)
+	celeryTimedelta = regexp.MustCompile(`timedelta\((.*)\)`)
+	springCron      = regexp.MustCompile(`cron\s*=\s*"([^"]+)"`)
+	springFixed     = regexp.MustCompile(`fixed(?:Rate|Delay)\s*=\s*(\d+)`)
+	wheneverTask    = regexp.MustCompile(`^\s*(?:runner|rake|command)\s+['"]([^'"]+)['"]`)
+)
+
+// nextFunction returns the name of the function or method declared after a decorator or annotation
+func nextFunction(lines []string, i int) string {
+	for j := i + 1; j < len(lines) && j <= i+6; j++ {
+		trimmed := strings.TrimSpace(lines[j])
+		if trimmed == "" || strings.HasPrefix(trimmed, "@") {
+			continue
+		}
+		if match := funcNameRegex.FindStringSubmatch(trimmed); match != nil {
+			return match[1]
+		}
+	}
+	return ""
+}
+
+// enclosingGoFunc returns the Go function a line sits in
+func enclosingGoFunc(lines []string, i int) string {
+	for j := i; j >= 0; j-- {
+		if match := goFuncRegex.FindStringSubmatch(lines[j]); match != nil {
+			return match[1]
+		}
+	}
+	return ""
+}
+
+// enclosingClass returns the Ruby class a line sits in
+func enclosingClass(lines []string, i int) string {
+	for j := i; j >= 0; j-- {
+		if match := rubyClassRegex.FindStringSubmatch(lines[j]); match != nil {
+			return match[1]
+		}
+	}
+	return ""
+}
+
+// findNear returns the first match of pattern on the line or the few after it
+func findNear(pattern *regexp.Regexp, lines []string, i, after int) string {
+	for j := i; j < len(lines) && j <= i+after; j++ {
+		if match := pattern.FindStringSubmatch(lines[j]); match != nil {
+			return match[1]
+		}
+	}
+	return ""
+}
+
+// findInFile returns the first match of pattern anywhere in the file
+func findInFile(pattern *regexp.Regexp, lines []string) string {
+	return findNear(pattern, lines, 0, len(lines))
+}
+
+// unquote strips the quotes of a string literal
+func unquote(s string) string {
+	return strings.Trim(strings.TrimSpace(s), `'"`+"`")
+}
+
+// pythonSchedule reads a Celery or APScheduler schedule value: crontab(...), timedelta(...) or seconds
+func pythonSchedule(value string) (schedule, cadence string) {
+	value = strings.TrimSpace(value)
+	if match := celeryCrontab.FindStringSubmatch(value); match != nil {
+		schedule = cronFromKwargs(pythonKwargs(match[1]))
+		return schedule, Describe(schedule)
+	}
+	if match := celeryTimedelta.FindStringSubmatch(value); match != nil {
+		return value, intervalFromKwargs(pythonKwargs(match[1]))
+	}
+	if isNumber(strings.TrimSuffix(value, ".0")) {
+		return value, every(value, "second")
+	}
+	return value, ""
+}
+
+var sourceRules = []sourceRule{
+	// Go: robfig/cron
+	{goFiles, "robfig/cron", regexp.MustCompile(`\.Add(?:Func|Job)\(\s*"([^"]+)"\s*,\s*&?([\w.]+)?`), func(m []string, lines []string, i int) (Job, bool) {
+		handler := m[2]
+		if handler == "" || handler == "func" {
+			handler = enclosingGoFunc(lines, i)
+		}
+		return Job{Name: handler, Kind: "go_cron", Trigger: Scheduled, Schedule: m[1], Handler: handler}, true
+	}},
+	// Go: go-co-op/gocron v1
+	{goFiles, "gocron", regexp.MustCompile(`\.Every\(\s*([^)]*)\s*\)\.(\w+)\(\)(?:\.At\("([^"]+)"\))?`), func(m []string, lines []string, i int) (Job, bool) {
+		handler := findNear(doHandlerRegex, lines, i, 2)
+		unit := strings.TrimSuffix(strings.ToLower(m[2]), "s")
+		n := m[1]
+		if n == "" {
+			n = "1"
+		}
+		cadence := every(n, unit)
+		if m[3] != "" {
+			cadence += " at " + m[3]
+		}
+		return Job{Name: handler, Kind: "go_cron", Trigger: Scheduled, Schedule: strings.TrimPrefix(m[0], "."), Cadence: cadence, Handler: handler}, true
+	}},
+	{goFiles, "gocron", regexp.MustCompile(`(?:\.Cron|gocron\.CronJob)\(\s*"([^"]+)"`), func(m []string, lines []string, i int) (Job, bool) {
+		handler := findNear(doHandlerRegex, lines, i, 2)
+		if handler == "" {
+			handler = findNear(newTaskRegex, lines, i, 4)
+		}
+		return Job{Name: handler, Kind: "go_cron", Trigger: Scheduled, Schedule: m[1], Handler: handler}, true
+	}},
+	{goFiles, "gocron", regexp.MustCompile(`gocron\.DurationJob\(\s*([^,)]+)`), func(m []string, lines []string, i int) (Job, bool) {
+		handler := findNear(newTaskRegex, lines, i, 4)
+		return Job{Name: handler, Kind: "go_cron", Trigger: Scheduled, Schedule: m[1], Cadence: describeGoDuration(m[1]), Handler: handler}, true
+	}},
+	// Go: ticker loops
+	{goFiles, "", regexp.MustCompile(`time\.(?:NewTicker|Tick)\(\s*([^)]+?)\s*\)`), func(m []string, lines []string, i int) (Job, bool) {
+		handler := enclosingGoFunc(lines, i)
+		return Job{Name: handler, Kind: "go_ticker", Trigger: Scheduled, Schedule: m[1], Cadence: describeGoDuration(m[1]), Handler: handler}, true
+	}},
+
+	// JavaScript/TypeScript: NestJS schedule decorators
+	{jsFiles, "@nestjs/schedule", regexp.MustCompile(`@Cron\(\s*(['"][^'"]+['"]|CronExpression\.\w+)`), func(m []string, lines []string, i int) (Job, bool) {
+		handler := nextFunction(lines, i)
+		schedule := unquote(m[1])
+		job := Job{Name: handler, Kind: "nestjs", Trigger: Scheduled, Schedule: schedule, Handler: handler}
+		if strings.HasPrefix(schedule, "CronExpression.") {
+			job.Cadence = strings.ToLower(strings.ReplaceAll(strings.TrimPrefix(schedule, "CronExpression."), "_", " "))
+		}
+		return job, true
+	}},
+	{jsFiles, "@nestjs/schedule", regexp.MustCompile(`@Interval\(\s*(?:['"][^'"]+['"]\s*,\s*)?([\d_]+)\s*\)`), func(m []string, lines []string, i int) (Job, bool) {
+		handler := nextFunction(lines, i)
+		return Job{Name: handler, Kind: "nestjs", Trigger: Scheduled, Schedule: m[1] + "ms", Cadence: describeMillis(m[1]), Handler: handler}, true
+	}},
+	// JavaScript/TypeScript: node-cron, cron and node-schedule
+	{jsFiles, "cron", regexp.MustCompile(`(?:cron\.schedule|new CronJob|scheduleJob)\(\s*(?:['"][^'"]+['"]\s*,\s*)?['"` + "`" + `]([^'"` + "`" + `]+)['"` + "`" + `]`), func(m []string, lines []string, i int) (Job, bool) {
+		name := ""
+		if match := assignRegex.FindStringSubmatch(lines[i]); match != nil {
+			name = match[1]
+		}
+		return Job{Name: name, Kind: "node_cron", Trigger: Scheduled, Schedule: m[1]}, true
+	}},
+	{jsFiles, "cron", regexp.MustCompile(`cronTime:\s*['"]([^'"]+)['"]`), func(m []string, lines []string, i int) (Job, bool) {
+		return Job{Kind: "node_cron", Trigger: Scheduled, Schedule: m[1]}, true
+	}},
+	// JavaScript/TypeScript: Bull and BullMQ
+	{jsFiles, "bull", regexp.MustCompile(`new Worker\(\s*['"]([^'"]+)['"]`), func(m []string, lines []string, i int) (Job, bool) {
+		return Job{Name: m[1], Kind: "bull", Trigger: Queued, Queue: m[1]}, true
+	}},
+	{jsFiles, "bull", regexp.MustCompile(`@Processor\(\s*['"]([^'"]+)['"]`), func(m []string, lines []string, i int) (Job, bool) {
+		return Job{Name: m[1], Kind: "bull", Trigger: Queued, Queue: m[1], Handler: nextClass(lines, i)}, true
+	}},
+	{jsFiles, "bull", regexp.MustCompile(`(\w+)\.process\(\s*(?:['"]([^'"]+)['"])?`), func(m []string, lines []string, i int) (Job, bool) {
+		queue := bullQueueName(lines, m[1])
+		if queue == "" {
+			return Job{}, false
+		}
+		name := m[2]
+		if name == "" {
+			name = queue
+		}
+		return Job{Name: name, Kind: "bull", Trigger: Queued, Queue: queue}, true
+	}},
+	{jsFiles, "bull", regexp.MustCompile(`repeat:\s*\{\s*(?:cron|pattern)\s*:\s*['"]([^'"]+)['"]`), func(m []string, lines []string, i int) (Job, bool) {
+		return Job{Name: findBack(bullAddRegex, lines, i, 3), Kind: "bull", Trigger: Scheduled, Schedule: m[1]}, true
+	}},
+	{jsFiles, "bull", regexp.MustCompile(`repeat:\s*\{\s*every\s*:\s*([\d_]+)`), func(m []string, lines []string, i int) (Job, bool) {
+		return Job{Name: findBack(bullAddRegex, lines, i, 3), Kind: "bull", Trigger: Scheduled, Schedule: m[1] + "ms", Cadence: describeMillis(m[1])}, true
+	}},
+
+	// Python: Celery beat schedule entries
+	{pythonFiles, "beat_schedule", regexp.MustCompile(`^\s*['"]([^'"]+)['"]\s*:\s*\{`), celeryBeatEntry},
+	{pythonFiles, "BEAT_SCHEDULE", regexp.MustCompile(`^\s*['"]([^'"]+)['"]\s*:\s*\{`), celeryBeatEntry},
+	{pythonFiles, "celery", regexp.MustCompile(`^\s*@(?:\w+\.)?periodic_task\((.*)\)`), func(m []string, lines []string, i int) (Job, bool) {
+		handler := nextFunction(lines, i)
+		value := strings.TrimPrefix(strings.TrimSpace(m[1]), "run_every=")
+		schedule, cadence := pythonSchedule(value)
+		return Job{Name: handler, Kind: "celery_beat", Trigger: Scheduled, Schedule: schedule, Cadence: cadence, Handler: handler}, true
+	}},
+	// Python: Celery task workers
+	{pythonFiles, "celery", regexp.MustCompile(`^\s*@(?:\w+\.)?(?:task|shared_task)\b(.*)$`), func(m []string, lines []string, i int) (Job, bool) {
+		handler := nextFunction(lines, i)
+		queue := ""
+		if match := decoratorQueue.FindStringSubmatch(m[1]); match != nil {
+			queue = match[1]
+		}
+		return Job{Name: handler, Kind: "celery", Trigger: Queued, Queue: queue, Handler: handler}, true
+	}},
+	// Python: APScheduler
+	{pythonFiles, "apscheduler", regexp.MustCompile(`\.add_job\(\s*([\w.]+)\s*,\s*(?:trigger\s*=\s*)?['"](cron|interval)['"]\s*,?(.*)$`), func(m []string, lines []string, i int) (Job, bool) {
+		return apschedulerJob(m[1], m[2], m[3]), true
+	}},
+	{pythonFiles, "apscheduler", regexp.MustCompile(`^\s*@\w+\.scheduled_job\(\s*['"](cron|interval)['"]\s*,?(.*)$`), func(m []string, lines []string, i int) (Job, bool) {
+		return apschedulerJob(nextFunction(lines, i), m[1], m[2]), true
+	}},
+
+	// Ruby: Sidekiq workers, ActiveJob and whenever
+	{rubyFiles, "Sidekiq", regexp.MustCompile(`^\s*include\s+Sidekiq::(?:Worker|Job)\b`), func(m []string, lines []string, i int) (Job, bool) {
+		class := enclosingClass(lines, i)
+		queue := findInFile(sidekiqQueue, lines)
+		if queue == "" {
+			queue = "default"
+		}
+		return Job{Name: class, Kind: "sidekiq", Trigger: Queued, Queue: queue, Handler: class}, true
+	}},
+	{rubyFiles, "", regexp.MustCompile(`^\s*class\s+([\w:]+)\s*<\s*(?:ApplicationJob|ActiveJob::Base)\b`), func(m []string, lines []string, i int) (Job, bool) {
+		queue := findInFile(activeJobQueue, lines)
+		if queue == "" {
+			queue = "default"
+		}
+		return Job{Name: m[1], Kind: "active_job", Trigger: Queued, Queue: queue, Handler: m[1]}, true
+	}},
+	{rubyFiles, "every ", regexp.MustCompile(`^\s*every\s+(.+?)\s+do\b`), func(m []string, lines []string, i int) (Job, bool) {
+		task := findNear(wheneverTask, lines, i+1, 3)
+		if task == "" {
+			return Job{}, false
+		}
+		schedule := strings.TrimSpace(m[1])
+		cadence := strings.Replace(schedule, ".", " ", 1)
+		if interval, at, ok := strings.Cut(cadence, ","); ok {
+			cadence = interval + " at " + unquote(strings.TrimPrefix(strings.TrimSpace(at), "at:"))
+		}
+		return Job{Name: truncate(task), Kind: "whenever", Trigger: Scheduled, Schedule: schedule, Cadence: "every " + cadence, Handler: truncate(task)}, true
+	}},
+
+	// Java/Kotlin: Spring @Scheduled
+	{jvmFiles, "Scheduled", regexp.MustCompile(`@Scheduled\((.*)\)`), func(m []string, lines []string, i int) (Job, bool) {
+		handler := nextFunction(lines, i)
+		job := Job{Name: handler, Kind: "spring", Trigger: Scheduled, Handler: handler}
+		if match := springCron.FindStringSubmatch(m[1]); match != nil {
+			job.Schedule = match[1]
+		} else if match := springFixed.FindStringSubmatch(m[1]); match != nil {
+			job.Schedule = match[1] + "ms"
+			job.Cadence = describeMillis(match[1])
+		} else {
+			job.Schedule = m[1]
+		}
+		return job, true
+	}},
+}
+
+// celeryBeatEntry reads one entry of a beat schedule dictionary: its task and schedule follow on
+// the next few lines
+func celeryBeatEntry(m []string, lines []string, i int) (Job, bool) {
+	task, value := "", ""
+	for j := i + 1; j < len(lines) && j <= i+8; j++ {
+		if task == "" {
+			if match := celeryTaskName.FindStringSubmatch(lines[j]); match != nil {
+				task = match[1]
+			}
+		}
+		if value == "" {
+			if match := celerySchedule.FindStringSubmatch(lines[j]); match != nil {
+				value = match[1]
+			}
+		}
+		if strings.HasPrefix(strings.TrimSpace(lines[j]), "}") {
+			break
+		}
+	}
+	if task == "" || value == "" {
+		return Job{}, false
+	}
+	schedule, cadence := pythonSchedule(value)
+	return Job{Name: m[1], Kind: "celery_beat", Trigger: Scheduled, Schedule: schedule, Cadence: cadence, Handler: task}, true
+}
+
+// apschedulerJob builds an APScheduler job from its trigger and keyword arguments
+func apschedulerJob(handler, trigger, args string) Job {
+	kwargs := pythonKwargs(args)
+	job := Job{Name: handler, Kind: "apscheduler", Trigger: Scheduled, Handler: handler}
+	if trigger == "cron" {
+		job.Schedule = cronFromKwargs(kwargs)
+		job.Cadence = Describe(job.Schedule)
+	} else {
+		job.Schedule = strings.TrimSuffix(strings.TrimSpace(args), ")")
+		job.Cadence = intervalFromKwargs(kwargs)
+	}
+	return job
+}
+
+var (
+	classNameRegex = regexp.MustCompile(`class\s+(\w+)`)
+	bullQueueRegex = regexp.MustCompile(`(\w+)\s*=\s*new\s+(?:Bull|Queue)\(\s*['"]([^'"]+)['"]`)
+)
+
+// nextClass returns the class declared after a decorator
+func nextClass(lines []string, i int) string {
+	return findNear(classNameRegex, lines, i+1, 4)
+}
+
+// bullQueueName returns the queue a variable holds, when the file creates it
+func bullQueueName(lines []string, variable string) string {
+	for _, line := range lines {
+		if match := bullQueueRegex.FindStringSubmatch(line); match != nil && match[1] == variable {
+			return match[2]
+		}
+	}
+	return ""
+}
+
+// findBack returns the first match of pattern on the line or the few before it
+func findBack(pattern *regexp.Regexp, lines []string, i, before int) string {
+	for j := i; j >= 0 && j >= i-before; j-- {
+		if match := pattern.FindStringSubmatch(lines[j]); match != nil {
+			return match[1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,190 @@
+package jobs
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var cronMacros = map[string]string{
+	"@reboot":   "at boot",
+	"@yearly":   "every year on January 1st at midnight",
+	"@annually": "every year on January 1st at midnight",
+	"@monthly":  "on the 1st of every month at midnight",
+	"@weekly":   "every Sunday at midnight",
+	"@daily":    "every day at midnight",
+	"@midnight": "every day at midnight",
+	"@hourly":   "every hour",
+}
+
+var weekdays = []string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"}
+
+var weekdayNames = map[string]int{"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6}
+
+// Describe puts a cron expression in words, e.g. "*/5 * * * *" as "every 5 minutes". Macros such
+// as @daily and robfig's "@every 1h" are understood, and a leading seconds field is dropped.
+// Expressions with lists or ranges outside the day of week are left undescribed.
+func Describe(expr string) string {
+	expr = strings.TrimSpace(expr)
+	if described, ok := cronMacros[strings.ToLower(expr)]; ok {
+		return described
+	}
+	if strings.HasPrefix(expr, "@every ") {
+		return "every " + strings.TrimSpace(strings.TrimPrefix(expr, "@every "))
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) == 6 && isNumber(fields[0]) {
+		fields = fields[1:] // Seconds
+	}
+	if len(fields) != 5 {
+		return ""
+	}
+	minute, hour, dom, month, dow := fields[0], fields[1], fields[2], fields[3], fields[4]
+	if month != "*" {
+		return ""
+	}
+
+	switch {
+	case minute == "*" && hour == "*" && dom == "*" && dow == "*":
+		return "every minute"
+	case strings.HasPrefix(minute, "*/") && hour == "*" && dom == "*" && dow == "*":
+		return every(strings.TrimPrefix(minute, "*/"), "minute")
+	case isNumber(minute) && hour == "*" && dom == "*" && dow == "*":
+		return fmt.Sprintf("every hour at minute %s", minute)
+	case isNumber(minute) && strings.HasPrefix(hour, "*/") && dom == "*" && dow == "*":
+		return fmt.Sprintf("%s at minute %s", every(strings.TrimPrefix(hour, "*/"), "hour"), minute)
+	case !isNumber(minute) || !isNumber(hour):
+		return ""
+	}
+
+	h, _ := strconv.Atoi(hour)
+	m, _ := strconv.Atoi(minute)
+	at := fmt.Sprintf("at %02d:%02d", h, m)
+	switch {
+	case dom == "*" && dow == "*":
+		return "every day " + at
+	case dom == "*":
+		if days := describeWeekdays(dow); days != "" {
+			return "every " + days + " " + at
+		}
+	case dow == "*" && isNumber(dom):
+		return fmt.Sprintf("on day %s of every month %s", dom, at)
+	}
+	return ""
+}
+
+// describeWeekdays reads a day-of-week field: a day, a range or a list of days
+func describeWeekdays(field string) string {
+	if field == "1-5" || strings.EqualFold(field, "MON-FRI") {
+		return "weekday"
+	}
+	var names []string
+	for _, part := range strings.Split(field, ",") {
+		day, ok := weekday(part)
+		if !ok {
+			return ""
+		}
+		names = append(names, day)
+	}
+	return strings.Join(names, " and ")
+}
+
+func weekday(field string) (string, bool) {
+	if n, err := strconv.Atoi(field); err == nil && n >= 0 && n <= 7 {
+		return weekdays[n%7], true
+	}
+	if n, ok := weekdayNames[strings.ToUpper(field)]; ok {
+		return weekdays[n], true
+	}
+	return "", false
+}
+
+// every renders an interval, e.g. every("5", "minute") as "every 5 minutes"
+func every(n, unit string) string {
+	n = strings.TrimSuffix(strings.TrimSpace(n), ".0")
+	if n == "1" {
+		return "every " + unit
+	}
+	return fmt.Sprintf("every %s %ss", n, unit)
+}
+
+func isNumber(s string) bool {
+	_, err := strconv.Atoi(s)
+	return err == nil
+}
+
+// durationRegex reads Go durations such as 5 * time.Minute, time.Hour or 30*time.Second
+var durationRegex = regexp.MustCompile(`^(?:(\d+)\s*\*\s*)?time\.(Second|Minute|Hour)$`)
+
+// describeGoDuration puts a Go duration expression in words, or returns "" when it is not a literal
+func describeGoDuration(expr string) string {
+	match := durationRegex.FindStringSubmatch(strings.TrimSpace(expr))
+	if match == nil {
+		return ""
+	}
+	n := match[1]
+	if n == "" {
+		n = "1"
+	}
+	return every(n, strings.ToLower(match[2]))
+}
+
+// describeMillis puts an interval in milliseconds in words
+func describeMillis(ms string) string {
+	n, err := strconv.Atoi(strings.ReplaceAll(ms, "_", ""))
+	if err != nil || n <= 0 {
+		return ""
+	}
+	switch {
+	case n%3600000 == 0:
+		return every(strconv.Itoa(n/3600000), "hour")
+	case n%60000 == 0:
+		return every(strconv.Itoa(n/60000), "minute")
+	case n%1000 == 0:
+		return every(strconv.Itoa(n/1000), "second")
+	}
+	return every(strconv.Itoa(n), "millisecond")
+}
+
+// kwargRegex reads keyword arguments such as minute=0 or hour='*/3'
+var kwargRegex = regexp.MustCompile(`(\w+)\s*=\s*(?:'([^']*)'|"([^"]*)"|([\w.*/-]+))`)
+
+// pythonKwargs reads the keyword arguments of a call such as crontab(minute=0, hour='*/3')
+func pythonKwargs(args string) map[string]string {
+	values := make(map[string]string)
+	for _, match := range kwargRegex.FindAllStringSubmatch(args, -1) {
+		values[match[1]] = match[2] + match[3] + match[4]
+	}
+	return values
+}
+
+// cronFromKwargs builds a cron expression from Celery crontab() or APScheduler cron arguments
+func cronFromKwargs(kwargs map[string]string) string {
+	field := func(names ...string) string {
+		for _, name := range names {
+			if value, ok := kwargs[name]; ok {
+				return value
+			}
+		}
+		return "*"
+	}
+	return strings.Join([]string{
+		field("minute"),
+		field("hour"),
+		field("day_of_month", "day"),
+		field("month_of_year", "month"),
+		field("day_of_week"),
+	}, " ")
+}
+
+// intervalFromKwargs describes timedelta() or APScheduler interval arguments
+func intervalFromKwargs(kwargs map[string]string) string {
+	for _, unit := range []string{"weeks", "days", "hours", "minutes", "seconds"} {
+		if value, ok := kwargs[unit]; ok {
+			return every(value, strings.TrimSuffix(unit, "s"))
+		}
+	}
+	return ""
+}
@@ -0,0 +1,157 @@
+package jsonrpc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// JSON-RPC 2.0 error codes
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// Request is an incoming JSON-RPC request or notification (notifications have no ID)
+type Request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// IsNotification reports whether the sender expects no response
+func (r *Request) IsNotification() bool {
+	return len(r.ID) == 0 || string(r.ID) == "null"
+}
+
+// DecodeParams unmarshals the request params, treating missing params as empty
+func (r *Request) DecodeParams(target interface{}) error {
+	if len(r.Params) == 0 || string(r.Params) == "null" {
+		return nil
+	}
+	return json.Unmarshal(r.Params, target)
+}
+
+// Response is an outgoing JSON-RPC response; exactly one of Result and Error is set
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Notification is an outgoing JSON-RPC notification
+type Notification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+// Error is a JSON-RPC error object
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Errorf builds an error object with a formatted message
+func Errorf(code int, format string, args ...interface{}) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// ReadFramed reads one LSP-framed message: headers, a blank line, then Content-Length bytes of JSON
+func ReadFramed(reader *bufio.Reader) ([]byte, error) {
+	headers, err := textproto.NewReader(reader).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.Atoi(strings.TrimSpace(headers.Get("Content-Length")))
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("invalid Content-Length header %q", headers.Get("Content-Length"))
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(reader, body); err != nil {
+		return nil, fmt.Errorf("failed to read message body: %v", err)
+	}
+	return body, nil
+}
+
+// ReadLine reads one newline-delimited message, skipping blank lines
+func ReadLine(reader *bufio.Reader) ([]byte, error) {
+	for {
+		line, err := reader.ReadBytes('\n')
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 {
+			return line, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Writer serializes outgoing messages; responses and notifications can come from several goroutines
+type Writer struct {
+	mu     sync.Mutex
+	out    io.Writer
+	framed bool
+}
+
+// NewFramedWriter writes LSP-framed messages
+func NewFramedWriter(out io.Writer) *Writer {
+	return &Writer{out: out, framed: true}
+}
+
+// NewLineWriter writes one message per line
+func NewLineWriter(out io.Writer) *Writer {
+	return &Writer{out: out}
+}
+
+// Write sends one message
+func (w *Writer) Write(message interface{}) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.framed {
+		if _, err := fmt.Fprintf(w.out, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+			return err
+		}
+		_, err = w.out.Write(body)
+		return err
+	}
+	_, err = w.out.Write(append(body, '\n'))
+	return err
+}
+
+// Reply answers a request with either its result or an error
+func (w *Writer) Reply(id json.RawMessage, result interface{}, rpcErr *Error) error {
+	resp := Response{JSONRPC: "2.0", ID: id, Error: rpcErr}
+	if rpcErr == nil {
+		data, err := json.Marshal(result)
+		if err != nil {
+			resp.Error = Errorf(CodeInternalError, "failed to encode result: %v", err)
+		} else {
+			resp.Result = data
+		}
+	}
+	return w.Write(resp)
+}
+
+// Notify sends a notification
+func (w *Writer) Notify(method string, params interface{}) error {
+	return w.Write(Notification{JSONRPC: "2.0", Method: method, Params: params})
+}
@@ -0,0 +1,194 @@
+package languages
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"repo-explanation/internal/portable"
+)
+
+// Type groups languages the way github-linguist does; only programming and markup count towards percentages
+type Type string
+
+const (
+	Programming Type = "programming"
+	Markup      Type = "markup"
+	Data        Type = "data"
+	Prose       Type = "prose"
+)
+
+// Language is a detected language with the comment syntax used to count its lines
+type Language struct {
+	Name         string
+	Type         Type
+	LineComments []string
+	BlockComment [2]string // Opening and closing delimiters, empty when the language has none
+}
+
+var (
+	cStyle     = []string{"//"}
+	hashStyle  = []string{"#"}
+	cBlock     = [2]string{"/*", "*/"}
+	htmlBlock  = [2]string{"<!--", "-->"}
+	noComments = [2]string{}
+)
+
+func language(name string, typ Type, lineComments []string, block [2]string) *Language {
+	return &Language{Name: name, Type: typ, LineComments: lineComments, BlockComment: block}
+}
+
+var (
+	golang     = language("Go", Programming, cStyle, cBlock)
+	javascript = language("JavaScript", Programming, cStyle, cBlock)
+	typescript = language("TypeScript", Programming, cStyle, cBlock)
+	python     = language("Python", Programming, hashStyle, noComments)
+	java       = language("Java", Programming, cStyle, cBlock)
+	kotlin     = language("Kotlin", Programming, cStyle, cBlock)
+	ruby       = language("Ruby", Programming, hashStyle, noComments)
+	rust       = language("Rust", Programming, cStyle, cBlock)
+	php        = language("PHP", Programming, []string{"//", "#"}, cBlock)
+	csharp     = language("C#", Programming, cStyle, cBlock)
+	c          = language("C", Programming, cStyle, cBlock)
+	cpp        = language("C++", Programming, cStyle, cBlock)
+	objc       = language("Objective-C", Programming, cStyle, cBlock)
+	matlab     = language("MATLAB", Programming, []string{"%"}, noComments)
+	swift      = language("Swift", Programming, cStyle, cBlock)
+	scala      = language("Scala", Programming, cStyle, cBlock)
+	clojure    = language("Clojure", Programming, []string{";"}, noComments)
+	haskell    = language("Haskell", Programming, []string{"--"}, [2]string{"{-", "-}"})
+	ocaml      = language("OCaml", Programming, nil, [2]string{"(*", "*)"})
+	r          = language("R", Programming, hashStyle, noComments)
+	perl       = language("Perl", Programming, hashStyle, noComments)
+	prolog     = language("Prolog", Programming, []string{"%"}, cBlock)
+	lua        = language("Lua", Programming, []string{"--"}, noComments)
+	dart       = language("Dart", Programming, cStyle, cBlock)
+	elixir     = language("Elixir", Programming, hashStyle, noComments)
+	sql        = language("SQL", Data, []string{"--"}, cBlock)
+	shell      = language("Shell", Programming, hashStyle, noComments)
+	powershell = language("PowerShell", Programming, hashStyle, [2]string{"<#", "#>"})
+	vue        = language("Vue", Markup, cStyle, htmlBlock)
+	svelte     = language("Svelte", Markup, cStyle, htmlBlock)
+	html       = language("HTML", Markup, nil, htmlBlock)
+	css        = language("CSS", Markup, nil, cBlock)
+	scss       = language("SCSS", Markup, cStyle, cBlock)
+	less       = language("LESS", Markup, cStyle, cBlock)
+	protobuf   = language("Protocol Buffer", Data, cStyle, cBlock)
+	graphql    = language("GraphQL", Data, hashStyle, noComments)
+	hcl        = language("HCL", Programming, []string{"#", "//"}, cBlock)
+	dockerfile = language("Dockerfile", Programming, hashStyle, noComments)
+	makefile   = language("Makefile", Programming, hashStyle, noComments)
+	json       = language("JSON", Data, nil, noComments)
+	yaml       = language("YAML", Data, hashStyle, noComments)
+	toml       = language("TOML", Data, hashStyle, noComments)
+	ini        = language("INI", Data, []string{";", "#"}, noComments)
+	xml        = language("XML", Data, nil, htmlBlock)
+	markdown   = language("Markdown", Prose, nil, noComments)
+	rst        = language("reStructuredText", Prose, nil, noComments)
+	text       = language("Text", Prose, nil, noComments)
+)
+
+// byExtension maps lowercase extensions to languages; ambiguous extensions are resolved from content
+var byExtension = map[string]*Language{
+	".go": golang, ".js": javascript, ".jsx": javascript, ".mjs": javascript, ".cjs": javascript,
+	".ts": typescript, ".tsx": typescript, ".mts": typescript, ".cts": typescript,
+	".py": python, ".pyi": python, ".java": java, ".kt": kotlin, ".kts": kotlin,
+	".rb": ruby, ".rake": ruby, ".rs": rust, ".php": php, ".cs": csharp,
+	".c": c, ".h": c, ".cpp": cpp, ".cc": cpp, ".cxx": cpp, ".hpp": cpp, ".hh": cpp, ".hxx": cpp,
+	".m": objc, ".mm": objc, ".swift": swift, ".scala": scala, ".clj": clojure, ".cljs": clojure,
+	".hs": haskell, ".ml": ocaml, ".mli": ocaml, ".r": r, ".pl": perl, ".pm": perl, ".lua": lua,
+	".dart": dart, ".ex": elixir, ".exs": elixir, ".sql": sql,
+	".sh": shell, ".bash": shell, ".zsh": shell, ".ps1": powershell,
+	".vue": vue, ".svelte": svelte, ".html": html, ".htm": html, ".css": css, ".scss": scss, ".less": less,
+	".proto": protobuf, ".graphql": graphql, ".gql": graphql, ".tf": hcl, ".tfvars": hcl, ".hcl": hcl,
+	".dockerfile": dockerfile, ".makefile": makefile, ".mk": makefile,
+	".json": json, ".yaml": yaml, ".yml": yaml, ".toml": toml, ".ini": ini, ".cfg": ini, ".xml": xml,
+	".md": markdown, ".markdown": markdown, ".rst": rst, ".txt": text,
+}
+
+// byFilename maps lowercase file names that carry no useful extension
+var byFilename = map[string]*Language{
+	"dockerfile": dockerfile, "containerfile": dockerfile,
+	"makefile": makefile, "gnumakefile": makefile,
+	"rakefile": ruby, "gemfile": ruby, "jenkinsfile": language("Groovy", Programming, cStyle, cBlock),
+}
+
+// byInterpreter maps shebang interpreters to languages
+var byInterpreter = map[string]*Language{
+	"sh": shell, "bash": shell, "zsh": shell, "dash": shell, "ksh": shell,
+	"python": python, "python2": python, "python3": python,
+	"node": javascript, "deno": typescript, "ts-node": typescript,
+	"ruby": ruby, "perl": perl, "php": php, "lua": lua, "pwsh": powershell,
+}
+
+var (
+	cppMarkers    = regexp.MustCompile(`(?m)^\s*(#include\s*<(iostream|string|vector|map|memory|algorithm)>|namespace\s+\w+|template\s*<|class\s+\w+\s*[:{]|using\s+namespace\s+std)`)
+	objcMarkers   = regexp.MustCompile(`(?m)^\s*(@interface|@implementation|@protocol|#import\s)`)
+	prologMarkers = regexp.MustCompile(`(?m)^\s*:-|^\w+\([^)]*\)\s*:-`)
+)
+
+// Detect returns the language of a file from its name, then its content when the extension is
+// ambiguous (.h, .m, .pl) or missing (shebang). ok is false for unrecognized files.
+func Detect(path, content string) (*Language, bool) {
+	base := strings.ToLower(filepath.Base(portable.Slash(path)))
+	if lang, ok := byFilename[base]; ok {
+		return lang, true
+	}
+	if strings.HasPrefix(base, "dockerfile.") || strings.HasSuffix(base, ".dockerfile") {
+		return dockerfile, true
+	}
+
+	ext := strings.ToLower(filepath.Ext(base))
+	lang, ok := byExtension[ext]
+	switch ext {
+	case ".h":
+		if objcMarkers.MatchString(content) {
+			return objc, true
+		}
+		if cppMarkers.MatchString(content) {
+			return cpp, true
+		}
+	case ".m":
+		if content != "" && !objcMarkers.MatchString(content) {
+			return matlab, true
+		}
+	case ".pl":
+		if prologMarkers.MatchString(content) {
+			return prolog, true
+		}
+	}
+	if ok {
+		return lang, true
+	}
+	if lang, ok := fromShebang(content); ok {
+		return lang, true
+	}
+	return nil, false
+}
+
+// fromShebang reads the interpreter from a "#!/usr/bin/env python3" style first line
+func fromShebang(content string) (*Language, bool) {
+	if !strings.HasPrefix(content, "#!") {
+		return nil, false
+	}
+	line := content[2:]
+	if newline := strings.IndexByte(line, '\n'); newline >= 0 {
+		line = line[:newline]
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil, false
+	}
+	interpreter := filepath.Base(fields[0])
+	if interpreter == "env" {
+		for _, field := range fields[1:] {
+			if !strings.HasPrefix(field, "-") {
+				interpreter = field
+				break
+			}
+		}
+	}
+	interpreter = strings.TrimRight(interpreter, "0123456789.")
+	lang, ok := byInterpreter[interpreter]
+	return lang, ok
+}
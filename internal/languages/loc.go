@@ -0,0 +1,164 @@
+package languages
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"repo-explanation/internal/portable"
+)
+
+// Lines counts a file's lines by kind
+type Lines struct {
+	Total   int `json:"total"`
+	Code    int `json:"code"`
+	Comment int `json:"comment"`
+	Blank   int `json:"blank"`
+}
+
+func (l *Lines) add(other Lines) {
+	l.Total += other.Total
+	l.Code += other.Code
+	l.Comment += other.Comment
+	l.Blank += other.Blank
+}
+
+// FileStat is the language and line count of one file
+type FileStat struct {
+	Path     string `json:"path"`
+	Language string `json:"language"`
+	Type     Type   `json:"type"`
+	Bytes    int64  `json:"bytes"`
+	Lines
+}
+
+// LanguageStat aggregates the files of one language
+type LanguageStat struct {
+	Name  string `json:"name"`
+	Type  Type   `json:"type"`
+	Files int    `json:"files"`
+	Bytes int64  `json:"bytes"`
+	Lines
+	// Percentage is the share of code lines among programming and markup languages; data and prose are 0
+	Percentage float64 `json:"percentage"`
+}
+
+// Breakdown is the language mix of a set of files, largest language first
+type Breakdown struct {
+	Languages []LanguageStat `json:"languages"`
+	Total     Lines          `json:"total"`
+	Primary   string         `json:"primary,omitempty"` // Programming language with the most code
+}
+
+// Analyze detects a file's language and counts its lines; ok is false for unrecognized files
+func Analyze(path, content string) (FileStat, bool) {
+	lang, ok := Detect(path, content)
+	if !ok {
+		return FileStat{}, false
+	}
+	return FileStat{Path: path, Language: lang.Name, Type: lang.Type, Bytes: int64(len(content)), Lines: CountLines(lang, content)}, true
+}
+
+// CountLines splits content into code, comment and blank lines using the language's comment syntax.
+// A line holding both code and a comment counts as code.
+func CountLines(lang *Language, content string) Lines {
+	var lines Lines
+	if content == "" {
+		return lines
+	}
+	inBlock := false
+	for _, line := range portable.Lines(strings.TrimSuffix(portable.Text(content), "\n")) {
+		lines.Total++
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case inBlock:
+			lines.Comment++
+			if end := lang.BlockComment[1]; strings.Contains(trimmed, end) {
+				inBlock = false
+				if rest := strings.TrimSpace(trimmed[strings.Index(trimmed, end)+len(end):]); rest != "" && !isLineComment(lang, rest) {
+					lines.Comment--
+					lines.Code++
+				}
+			}
+		case trimmed == "":
+			lines.Blank++
+		case isLineComment(lang, trimmed):
+			lines.Comment++
+		case lang.BlockComment[0] != "" && strings.HasPrefix(trimmed, lang.BlockComment[0]):
+			lines.Comment++
+			rest := trimmed[len(lang.BlockComment[0]):]
+			if end := strings.Index(rest, lang.BlockComment[1]); end < 0 {
+				inBlock = true
+			} else if after := strings.TrimSpace(rest[end+len(lang.BlockComment[1]):]); after != "" && !isLineComment(lang, after) {
+				lines.Comment--
+				lines.Code++
+			}
+		default:
+			lines.Code++
+		}
+	}
+	return lines
+}
+
+func isLineComment(lang *Language, trimmed string) bool {
+	for _, prefix := range lang.LineComments {
+		if strings.HasPrefix(trimmed, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Summarize aggregates file stats per language
+func Summarize(files []FileStat) *Breakdown {
+	byName := make(map[string]*LanguageStat)
+	breakdown := &Breakdown{}
+	for _, file := range files {
+		stat, ok := byName[file.Language]
+		if !ok {
+			stat = &LanguageStat{Name: file.Language, Type: file.Type}
+			byName[file.Language] = stat
+		}
+		stat.Files++
+		stat.Bytes += file.Bytes
+		stat.add(file.Lines)
+		breakdown.Total.add(file.Lines)
+	}
+
+	counted := 0
+	for _, stat := range byName {
+		if stat.Type == Programming || stat.Type == Markup {
+			counted += stat.Code
+		}
+	}
+	primaryCode := 0
+	for _, stat := range byName {
+		if counted > 0 && (stat.Type == Programming || stat.Type == Markup) {
+			stat.Percentage = math.Round(float64(stat.Code)/float64(counted)*1000) / 10
+		}
+		if stat.Type == Programming && (stat.Code > primaryCode || stat.Code == primaryCode && stat.Name < breakdown.Primary) {
+			breakdown.Primary, primaryCode = stat.Name, stat.Code
+		}
+		breakdown.Languages = append(breakdown.Languages, *stat)
+	}
+	sort.Slice(breakdown.Languages, func(i, j int) bool {
+		a, b := breakdown.Languages[i], breakdown.Languages[j]
+		if a.Percentage != b.Percentage {
+			return a.Percentage > b.Percentage
+		}
+		if a.Code != b.Code {
+			return a.Code > b.Code
+		}
+		return a.Name < b.Name
+	})
+	return breakdown
+}
+
+// CodeLines maps each language to its code lines, the shape of the LLM-reported Languages maps
+func (b *Breakdown) CodeLines() map[string]int {
+	lines := make(map[string]int, len(b.Languages))
+	for _, stat := range b.Languages {
+		lines[stat.Name] = stat.Code
+	}
+	return lines
+}
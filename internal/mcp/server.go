@@ -0,0 +1,232 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"repo-explanation/config"
+	"repo-explanation/internal/jsonrpc"
+	"repo-explanation/internal/pipeline"
+)
+
+// protocolVersions are the MCP revisions this server speaks, newest first
+var protocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// resultWait bounds how long a tool call waits for a running analysis before asking the agent to retry
+const resultWait = 45 * time.Second
+
+// Server exposes one repository's analysis as MCP tools and resources over stdio
+type Server struct {
+	config  *config.Config
+	options pipeline.AnalysisOptions
+	root    string
+	writer  *jsonrpc.Writer
+
+	mu       sync.Mutex
+	analyzer *pipeline.Analyzer
+	result   *pipeline.AnalysisResult
+	stage    string        // Latest progress stage while analyzing
+	lastErr  error         // Error of the analysis, if it failed
+	done     chan struct{} // Closed when the analysis finishes
+}
+
+// NewServer creates an MCP server for the repository at root
+func NewServer(cfg *config.Config, root string, opts pipeline.AnalysisOptions) (*Server, error) {
+	analyzer, err := pipeline.NewAnalyzer(cfg, root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create analyzer: %v", err)
+	}
+	if err := analyzer.SetOptions(opts); err != nil {
+		return nil, err
+	}
+	// Keeps file summaries around for get_file_summary
+	analyzer.EnableIncremental()
+
+	return &Server{
+		config:   cfg,
+		options:  opts,
+		root:     root,
+		analyzer: analyzer,
+	}, nil
+}
+
+// Serve handles newline-delimited JSON-RPC messages until the client closes stdin
+func (s *Server) Serve(ctx context.Context, in io.Reader, out io.Writer) error {
+	s.writer = jsonrpc.NewLineWriter(out)
+	reader := bufio.NewReader(in)
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		line, err := jsonrpc.ReadLine(reader)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var req jsonrpc.Request
+		if err := json.Unmarshal(line, &req); err != nil {
+			s.reply(json.RawMessage("null"), nil, jsonrpc.Errorf(jsonrpc.CodeParseError, "invalid JSON: %v", err))
+			continue
+		}
+		if req.Method == "" {
+			// Responses to server-initiated requests; this server sends none
+			continue
+		}
+
+		// Tool calls can wait on the analysis, so requests are answered concurrently
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, rpcErr := s.handle(ctx, &req)
+			if !req.IsNotification() {
+				s.reply(req.ID, result, rpcErr)
+			}
+		}()
+	}
+}
+
+// handle dispatches one request
+func (s *Server) handle(ctx context.Context, req *jsonrpc.Request) (interface{}, *jsonrpc.Error) {
+	switch req.Method {
+	case "initialize":
+		return s.initialize(ctx, req)
+	case "notifications/initialized", "notifications/cancelled":
+		return nil, nil
+	case "ping":
+		return struct{}{}, nil
+	case "tools/list":
+		return map[string]interface{}{"tools": toolDefinitions}, nil
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := req.DecodeParams(&params); err != nil || params.Name == "" {
+			return nil, &jsonrpc.Error{Code: jsonrpc.CodeInvalidParams, Message: "tools/call requires a tool name"}
+		}
+		return s.callTool(ctx, params.Name, params.Arguments)
+	case "resources/list":
+		return map[string]interface{}{"resources": resourceDefinitions}, nil
+	case "resources/templates/list":
+		return map[string]interface{}{"resourceTemplates": resourceTemplates}, nil
+	case "resources/read":
+		var params struct {
+			URI string `json:"uri"`
+		}
+		if err := req.DecodeParams(&params); err != nil || params.URI == "" {
+			return nil, &jsonrpc.Error{Code: jsonrpc.CodeInvalidParams, Message: "resources/read requires a uri"}
+		}
+		return s.readResource(ctx, params.URI)
+	}
+	return nil, jsonrpc.Errorf(jsonrpc.CodeMethodNotFound, "unknown method %q", req.Method)
+}
+
+// initialize negotiates the protocol version and starts the analysis in the background
+func (s *Server) initialize(ctx context.Context, req *jsonrpc.Request) (interface{}, *jsonrpc.Error) {
+	var params struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	req.DecodeParams(&params)
+
+	version := protocolVersions[0]
+	for _, supported := range protocolVersions {
+		if supported == params.ProtocolVersion {
+			version = supported
+		}
+	}
+
+	s.startAnalysis(ctx)
+
+	return map[string]interface{}{
+		"protocolVersion": version,
+		"capabilities": map[string]interface{}{
+			"tools":     map[string]interface{}{},
+			"resources": map[string]interface{}{},
+		},
+		"serverInfo": map[string]string{"name": "repo-explanation", "version": "1.0.0"},
+		"instructions": "Knowledge base for the repository at " + s.root + ". Start with get_project_summary, " +
+			"then drill into services, folders, files, the database ERD or required secrets.",
+	}, nil
+}
+
+// startAnalysis runs the pipeline once; later calls are no-ops
+func (s *Server) startAnalysis(ctx context.Context) {
+	s.mu.Lock()
+	if s.done != nil {
+		s.mu.Unlock()
+		return
+	}
+	s.done = make(chan struct{})
+	s.mu.Unlock()
+
+	go func() {
+		runCtx, cancel := context.WithTimeout(ctx, s.config.GetAnalysisTimeout())
+		defer cancel()
+
+		result, err := s.analyzer.AnalyzeProjectWithProgress(runCtx, func(eventType, stage, message string, progress int, data interface{}) {
+			if eventType == "progress" {
+				s.mu.Lock()
+				s.stage = fmt.Sprintf("%s (%d%%)", stage, progress)
+				s.mu.Unlock()
+			}
+		})
+
+		s.mu.Lock()
+		s.result = result
+		s.lastErr = err
+		close(s.done)
+		s.mu.Unlock()
+
+		if err != nil {
+			fmt.Printf("❌ [MCP] Analysis failed: %v\n", err)
+		} else {
+			fmt.Println("✅ [MCP] Analysis complete")
+		}
+	}()
+}
+
+// waitForResult blocks until the analysis finishes or resultWait passes.
+// The returned error is meant for the agent: it explains why there is no result yet.
+func (s *Server) waitForResult(ctx context.Context) (*pipeline.AnalysisResult, error) {
+	s.startAnalysis(ctx)
+
+	s.mu.Lock()
+	done := s.done
+	s.mu.Unlock()
+
+	timer := time.NewTimer(resultWait)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+	case <-timer.C:
+		s.mu.Lock()
+		stage := s.stage
+		s.mu.Unlock()
+		return nil, fmt.Errorf("the repository analysis is still running (currently: %s); call this tool again shortly", stage)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.lastErr != nil {
+		return nil, fmt.Errorf("the repository analysis failed: %v", s.lastErr)
+	}
+	return s.result, nil
+}
+
+func (s *Server) reply(id json.RawMessage, result interface{}, rpcErr *jsonrpc.Error) {
+	if err := s.writer.Reply(id, result, rpcErr); err != nil {
+		fmt.Printf("❌ [MCP] Failed to write response: %v\n", err)
+	}
+}
@@ -0,0 +1,320 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"repo-explanation/internal/dependents"
+	"repo-explanation/internal/jsonrpc"
+	"repo-explanation/internal/mermaid"
+)
+
+// codeResourceNotFound is MCP's error code for unknown resource URIs
+const codeResourceNotFound = -32002
+
+// tool describes one MCP tool
+type tool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+// resource describes one MCP resource or resource template
+type resource struct {
+	URI         string `json:"uri,omitempty"`
+	URITemplate string `json:"uriTemplate,omitempty"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	MimeType    string `json:"mimeType"`
+}
+
+// noArguments is the input schema of tools without parameters
+var noArguments = map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+
+// pathArgument is the input schema of tools taking a repository-relative path
+func pathArgument(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"path": map[string]interface{}{"type": "string", "description": description},
+		},
+		"required": []string{"path"},
+	}
+}
+
+// nameArgument is the input schema of tools taking the name of a service, package or table
+func nameArgument(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"name": map[string]interface{}{"type": "string", "description": description},
+		},
+		"required": []string{"name"},
+	}
+}
+
+var toolDefinitions = []tool{
+	{
+		Name:        "get_project_summary",
+		Description: "Overall purpose, architecture, technologies and entry points of the repository, plus its detected project type",
+		InputSchema: noArguments,
+	},
+	{
+		Name:        "get_file_summary",
+		Description: "Purpose, key types, functions, imports and side effects of one file",
+		InputSchema: pathArgument("File path relative to the repository root"),
+	},
+	{
+		Name:        "get_folder_summary",
+		Description: "Purpose, languages and key modules of one folder, including what its subfolders do",
+		InputSchema: pathArgument("Folder path relative to the repository root; use . for the top level"),
+	},
+	{
+		Name:        "get_erd",
+		Description: "Database entity-relationship diagram (Mermaid erDiagram) reconstructed from the SQL migrations",
+		InputSchema: noArguments,
+	},
+	{
+		Name:        "get_service_graph",
+		Description: "Services found in the repository, the dependencies between them with evidence, and sequence diagrams of the key request flows",
+		InputSchema: noArguments,
+	},
+	{
+		Name:        "list_secrets",
+		Description: "Environment variables and configuration secrets each service needs in order to run",
+		InputSchema: noArguments,
+	},
+	{
+		Name:        "get_dependents",
+		Description: "Everything depending on a service, internal package or database table (calling services, importing packages, referencing tables and querying services), with the evidence for each",
+		InputSchema: nameArgument("Service, package or table name; prefix with service:, package: or table: when names collide"),
+	},
+}
+
+var resourceDefinitions = []resource{
+	{URI: "repo://summary", Name: "Project summary", Description: "Project summary and type", MimeType: "application/json"},
+	{URI: "repo://services", Name: "Service graph", Description: "Services and their relationships", MimeType: "application/json"},
+	{URI: "repo://erd", Name: "Database ERD", Description: "Mermaid erDiagram of the database schema", MimeType: "text/plain"},
+	{URI: "repo://secrets", Name: "Required secrets", Description: "Environment variables per service", MimeType: "application/json"},
+}
+
+var resourceTemplates = []resource{
+	{URITemplate: "repo://file/{path}", Name: "File summary", Description: "Summary of one file", MimeType: "application/json"},
+	{URITemplate: "repo://folder/{path}", Name: "Folder summary", Description: "Summary of one folder", MimeType: "application/json"},
+}
+
+// toolHandlers map tool names to the lookup behind them; resources share the same lookups
+var toolHandlers = map[string]func(s *Server, ctx context.Context, path string) (interface{}, error){
+	"get_project_summary": (*Server).projectSummary,
+	"get_file_summary":    (*Server).fileSummary,
+	"get_folder_summary":  (*Server).folderSummary,
+	"get_erd":             (*Server).erd,
+	"get_service_graph":   (*Server).serviceGraph,
+	"list_secrets":        (*Server).secrets,
+	"get_dependents":      (*Server).dependents,
+}
+
+// callTool runs a tool; lookup failures are reported to the agent as tool errors rather than protocol errors
+func (s *Server) callTool(ctx context.Context, name string, rawArguments json.RawMessage) (interface{}, *jsonrpc.Error) {
+	handler, ok := toolHandlers[name]
+	if !ok {
+		return nil, jsonrpc.Errorf(jsonrpc.CodeInvalidParams, "unknown tool %q", name)
+	}
+
+	var arguments struct {
+		Path string `json:"path"`
+		Name string `json:"name"`
+	}
+	if len(rawArguments) > 0 {
+		if err := json.Unmarshal(rawArguments, &arguments); err != nil {
+			return nil, jsonrpc.Errorf(jsonrpc.CodeInvalidParams, "invalid arguments: %v", err)
+		}
+	}
+
+	argument := arguments.Path
+	if argument == "" {
+		argument = arguments.Name
+	}
+	value, err := handler(s, ctx, argument)
+	if err != nil {
+		return toolContent(err.Error(), true), nil
+	}
+	text, err := renderText(value)
+	if err != nil {
+		return nil, jsonrpc.Errorf(jsonrpc.CodeInternalError, "failed to encode result: %v", err)
+	}
+	return toolContent(text, false), nil
+}
+
+// readResource serves a fixed resource or a file/folder template
+func (s *Server) readResource(ctx context.Context, uri string) (interface{}, *jsonrpc.Error) {
+	handlers := map[string]string{
+		"repo://summary":  "get_project_summary",
+		"repo://services": "get_service_graph",
+		"repo://erd":      "get_erd",
+		"repo://secrets":  "list_secrets",
+	}
+
+	toolName, path := handlers[uri], ""
+	switch {
+	case strings.HasPrefix(uri, "repo://file/"):
+		toolName, path = "get_file_summary", strings.TrimPrefix(uri, "repo://file/")
+	case strings.HasPrefix(uri, "repo://folder/"):
+		toolName, path = "get_folder_summary", strings.TrimPrefix(uri, "repo://folder/")
+	}
+	if toolName == "" {
+		return nil, jsonrpc.Errorf(codeResourceNotFound, "resource not found: %s", uri)
+	}
+
+	value, err := toolHandlers[toolName](s, ctx, path)
+	if err != nil {
+		return nil, jsonrpc.Errorf(codeResourceNotFound, "%s: %v", uri, err)
+	}
+	text, err := renderText(value)
+	if err != nil {
+		return nil, jsonrpc.Errorf(jsonrpc.CodeInternalError, "failed to encode resource: %v", err)
+	}
+
+	mimeType := "application/json"
+	if toolName == "get_erd" {
+		mimeType = "text/plain"
+	}
+	return map[string]interface{}{
+		"contents": []map[string]string{{"uri": uri, "mimeType": mimeType, "text": text}},
+	}, nil
+}
+
+func (s *Server) projectSummary(ctx context.Context, _ string) (interface{}, error) {
+	result, err := s.waitForResult(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"project_summary": result.ProjectSummary,
+		"project_type":    result.ProjectType,
+		"stats":           result.Stats,
+		"offline":         result.Offline,
+	}, nil
+}
+
+// fileSummary works without waiting for the analysis: unchanged files come from the cache
+func (s *Server) fileSummary(ctx context.Context, path string) (interface{}, error) {
+	rel, err := s.relativePath(path)
+	if err != nil {
+		return nil, err
+	}
+	summary, err := s.analyzer.SummarizeFile(ctx, rel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize %s: %v", path, err)
+	}
+	return map[string]interface{}{"path": filepath.ToSlash(rel), "summary": summary}, nil
+}
+
+func (s *Server) folderSummary(ctx context.Context, path string) (interface{}, error) {
+	rel, err := s.relativePath(path)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.waitForResult(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The pipeline keys top-level files under "root"
+	key := rel
+	if rel == "." {
+		key = "root"
+	}
+	folder := result.FolderSummaries[key]
+	if folder == nil {
+		return nil, fmt.Errorf("no summary for folder %s (it may contain no analyzable files)", path)
+	}
+	return folder, nil
+}
+
+func (s *Server) erd(ctx context.Context, _ string) (interface{}, error) {
+	result, err := s.waitForResult(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if result.DatabaseSchema == nil || len(result.DatabaseSchema.Tables) == 0 {
+		return "No database schema was found in the repository's SQL migrations.", nil
+	}
+	erd := result.DatabaseSchema.MermaidERD()
+	if result.TableOwnership != nil {
+		erd += result.TableOwnership.ERDClasses(mermaid.ID)
+	}
+	return erd, nil
+}
+
+func (s *Server) serviceGraph(ctx context.Context, _ string) (interface{}, error) {
+	result, err := s.waitForResult(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"services":       result.Services,
+		"relationships":  result.ServiceRelationships,
+		"sequence_flows": result.SequenceFlows,
+	}, nil
+}
+
+func (s *Server) dependents(ctx context.Context, name string) (interface{}, error) {
+	result, err := s.waitForResult(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return dependents.Find(result, name)
+}
+
+func (s *Server) secrets(ctx context.Context, _ string) (interface{}, error) {
+	result, err := s.waitForResult(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if result.ProjectSecrets == nil || result.ProjectSecrets.TotalVariables == 0 {
+		return "No environment variables or configuration secrets were found.", nil
+	}
+	return result.ProjectSecrets, nil
+}
+
+// relativePath validates a path argument, accepting absolute paths inside the repository
+func (s *Server) relativePath(path string) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("path is required")
+	}
+	rel := filepath.Clean(filepath.FromSlash(path))
+	if filepath.IsAbs(rel) {
+		absRoot, err := filepath.Abs(s.root)
+		if err != nil {
+			return "", err
+		}
+		if rel, err = filepath.Rel(absRoot, rel); err != nil {
+			return "", fmt.Errorf("%s is outside the repository", path)
+		}
+	}
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside the repository", path)
+	}
+	return rel, nil
+}
+
+// renderText returns strings as-is and everything else as indented JSON
+func renderText(value interface{}) (string, error) {
+	if text, ok := value.(string); ok {
+		return text, nil
+	}
+	data, err := json.MarshalIndent(value, "", "  ")
+	return string(data), err
+}
+
+// toolContent wraps text in an MCP tool result
+func toolContent(text string, isError bool) map[string]interface{} {
+	return map[string]interface{}{
+		"content": []map[string]string{{"type": "text", "text": text}},
+		"isError": isError,
+	}
+}
@@ -0,0 +1,92 @@
+package mermaid
+
+import (
+	"regexp"
+	"strings"
+)
+
+// invalidIDRegex matches the characters Mermaid does not accept in node and entity ids
+var invalidIDRegex = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// reservedIDs are keywords that break a diagram when used as a bare id
+var reservedIDs = map[string]bool{
+	"end": true, "graph": true, "flowchart": true, "subgraph": true, "style": true, "class": true,
+	"classdef": true, "click": true, "linkstyle": true, "default": true, "direction": true,
+	"call": true, "href": true,
+}
+
+// ID turns any name (a table such as "billing.user-accounts", a path, a package like
+// "@scope/ui") into an id every diagram type accepts: letters, digits and underscores, not
+// starting with a digit and never a keyword such as "end"
+func ID(name string) string {
+	id := strings.Trim(invalidIDRegex.ReplaceAllString(name, "_"), "_")
+	if id == "" {
+		return "node"
+	}
+	if id[0] >= '0' && id[0] <= '9' {
+		id = "n_" + id
+	}
+	if reservedIDs[strings.ToLower(id)] {
+		id += "_"
+	}
+	return id
+}
+
+// labelReplacer escapes text placed inside a quoted label
+var labelReplacer = strings.NewReplacer(
+	`"`, "#quot;",
+	"\r\n", " ",
+	"\n", " ",
+	"\r", " ",
+	`\n`, " ",
+	"\t", " ",
+)
+
+// Label quotes text for a node, subgraph or entity label, so brackets, dots and other syntax
+// characters are shown as written
+func Label(text string) string {
+	return `"` + strings.TrimSpace(labelReplacer.Replace(text)) + `"`
+}
+
+// edgeLabelReplacer escapes text placed between the pipes of an edge label
+var edgeLabelReplacer = strings.NewReplacer(
+	"|", "/",
+	`"`, "#quot;",
+	"\r\n", " ",
+	"\n", " ",
+	"\r", " ",
+	`\n`, " ",
+)
+
+// EdgeLabel escapes text for a flowchart edge label (-->|label|)
+func EdgeLabel(text string) string {
+	return strings.TrimSpace(edgeLabelReplacer.Replace(text))
+}
+
+// messageReplacer escapes sequence diagram messages and ER relationship labels, which end at the line
+var messageReplacer = strings.NewReplacer(";", ",", "#", "", "\r\n", " ", "\n", " ", "\r", " ", `\n`, " ")
+
+// Message escapes the free text after the colon of a sequence message or ER relationship
+func Message(text string) string {
+	return strings.TrimSpace(messageReplacer.Replace(text))
+}
+
+// AttributeType makes a column type safe for an erDiagram attribute: "character varying(255)"
+// becomes "character_varying(255)", "decimal(10,2)" becomes "decimal(10_2)" and other characters
+// Mermaid rejects are dropped
+func AttributeType(columnType string) string {
+	var b strings.Builder
+	for _, r := range strings.TrimSpace(columnType) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '(', r == ')', r == '[', r == ']':
+			b.WriteRune(r)
+		case r == ' ' || r == '-' || r == '.' || r == ',':
+			b.WriteRune('_')
+		}
+	}
+	attributeType := b.String()
+	if attributeType == "" || !(attributeType[0] == '_' || (attributeType[0] >= 'a' && attributeType[0] <= 'z') || (attributeType[0] >= 'A' && attributeType[0] <= 'Z')) {
+		attributeType = "type_" + attributeType
+	}
+	return attributeType
+}
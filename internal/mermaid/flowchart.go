@@ -0,0 +1,108 @@
+package mermaid
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Shape is how a flowchart node is drawn
+type Shape int
+
+const (
+	Rectangle Shape = iota
+	Rounded
+	Rhombus
+	Database
+	Stadium
+)
+
+var shapeBrackets = map[Shape][2]string{
+	Rectangle: {"[", "]"},
+	Rounded:   {"(", ")"},
+	Rhombus:   {"{", "}"},
+	Database:  {"[(", ")]"},
+	Stadium:   {"([", "])"},
+}
+
+// Arrow is the line drawn for a flowchart edge
+type Arrow string
+
+const (
+	Solid  Arrow = "-->"
+	Dotted Arrow = "-.->"
+	Thick  Arrow = "==>"
+)
+
+// Flowchart builds a flowchart from arbitrary names, escaping ids and labels as it goes
+type Flowchart struct {
+	direction string
+	lines     []string
+	depth     int
+}
+
+// NewFlowchart starts a flowchart drawn in direction (TD, LR, ...)
+func NewFlowchart(direction string) *Flowchart {
+	return &Flowchart{direction: direction}
+}
+
+// Node declares a node for name with the given label and shape
+func (f *Flowchart) Node(name, label string, shape Shape) {
+	brackets := shapeBrackets[shape]
+	f.add(fmt.Sprintf("%s%s%s%s", ID(name), brackets[0], Label(label), brackets[1]))
+}
+
+// Edge links two names; an empty label draws a bare arrow
+func (f *Flowchart) Edge(from, to string, arrow Arrow, label string) {
+	if label = EdgeLabel(label); label != "" {
+		f.add(fmt.Sprintf("%s %s|%s| %s", ID(from), arrow, label, ID(to)))
+		return
+	}
+	f.add(fmt.Sprintf("%s %s %s", ID(from), arrow, ID(to)))
+}
+
+// Subgraph opens a subgraph; every node declared until EndSubgraph is drawn inside it
+func (f *Flowchart) Subgraph(name, label string) {
+	f.add(fmt.Sprintf("subgraph %s [%s]", ID(name), Label(label)))
+	f.depth++
+}
+
+// EndSubgraph closes the innermost open subgraph
+func (f *Flowchart) EndSubgraph() {
+	if f.depth == 0 {
+		return
+	}
+	f.depth--
+	f.add("end")
+}
+
+// ClassDef defines a style class, e.g. ClassDef("hotspot", "fill:#fee2e2,stroke:#b91c1c")
+func (f *Flowchart) ClassDef(class, style string) {
+	f.add(fmt.Sprintf("classDef %s %s", ID(class), style))
+}
+
+// Class applies a style class to the node for name
+func (f *Flowchart) Class(name, class string) {
+	f.add(fmt.Sprintf("class %s %s", ID(name), ID(class)))
+}
+
+// Blank adds an empty line between sections
+func (f *Flowchart) Blank() {
+	f.lines = append(f.lines, "")
+}
+
+// String renders the flowchart, closing any subgraph left open
+func (f *Flowchart) String() string {
+	for f.depth > 0 {
+		f.EndSubgraph()
+	}
+	var b strings.Builder
+	b.WriteString("graph " + f.direction + "\n")
+	for _, line := range f.lines {
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
+func (f *Flowchart) add(line string) {
+	f.lines = append(f.lines, strings.Repeat("  ", f.depth+1)+line)
+}
@@ -0,0 +1,217 @@
+package mermaid
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestID(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"users", "users"},
+		{"billing.user-accounts", "billing_user_accounts"},
+		{"@scope/ui", "scope_ui"},
+		{`api\nserver`, "api_nserver"},
+		{`say "hi"`, "say_hi"},
+		{"services/auth-service/", "services_auth_service"},
+		{"9lives", "n_9lives"},
+		{"end", "end_"},
+		{"End", "End_"},
+		{"subgraph", "subgraph_"},
+		{"", "node"},
+		{"...", "node"},
+	}
+	for _, tt := range tests {
+		if got := ID(tt.name); got != tt.want {
+			t.Errorf("ID(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLabel(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"billing.accounts", `"billing.accounts"`},
+		{`He said "hi"`, `"He said #quot;hi#quot;"`},
+		{"first\nsecond", `"first second"`},
+		{"first\r\nsecond", `"first second"`},
+		{`first\nsecond`, `"first second"`},
+		{" [list] {map} (call) ", `"[list] {map} (call)"`},
+	}
+	for _, tt := range tests {
+		if got := Label(tt.text); got != tt.want {
+			t.Errorf("Label(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestEdgeLabel(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"GET /users", "GET /users"},
+		{"a|b", "a/b"},
+		{`"quoted"`, "#quot;quoted#quot;"},
+		{`one\ntwo`, "one two"},
+		{"  padded\n", "padded"},
+	}
+	for _, tt := range tests {
+		if got := EdgeLabel(tt.text); got != tt.want {
+			t.Errorf("EdgeLabel(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestMessage(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"POST /orders", "POST /orders"},
+		{"create; then notify", "create, then notify"},
+		{"issue #42", "issue 42"},
+		{`line\nbreak`, "line break"},
+	}
+	for _, tt := range tests {
+		if got := Message(tt.text); got != tt.want {
+			t.Errorf("Message(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestAttributeType(t *testing.T) {
+	tests := []struct {
+		columnType string
+		want       string
+	}{
+		{"integer", "integer"},
+		{"character varying(255)", "character_varying(255)"},
+		{"decimal(10,2)", "decimal(10_2)"},
+		{"text[]", "text[]"},
+		{"timestamp-with.tz", "timestamp_with_tz"},
+		{`"quoted"`, "quoted"},
+		{"2d", "type_2d"},
+		{"", "type_"},
+	}
+	for _, tt := range tests {
+		if got := AttributeType(tt.columnType); got != tt.want {
+			t.Errorf("AttributeType(%q) = %q, want %q", tt.columnType, got, tt.want)
+		}
+	}
+}
+
+func TestFlowchart(t *testing.T) {
+	f := NewFlowchart("TD")
+	f.Subgraph("services/api.gateway", "API gateway (edge)")
+	f.Node("api.gateway", `Gateway "v2"`, Rounded)
+	f.Node("end", "end", Rectangle)
+	f.EndSubgraph()
+	f.Node("billing-db", "billing.accounts", Database)
+	f.Edge("api.gateway", "billing-db", Solid, `reads|writes\nrows`)
+	f.Edge("end", "api.gateway", Dotted, "")
+	f.Blank()
+	f.ClassDef("hot-spot", "fill:#fee2e2")
+	f.Class("billing-db", "hot-spot")
+	f.Subgraph("left open", "Left open")
+	f.Node("9th", "Ninth", Stadium)
+
+	want := `graph TD
+  subgraph services_api_gateway ["API gateway (edge)"]
+    api_gateway("Gateway #quot;v2#quot;")
+    end_["end"]
+  end
+  billing_db[("billing.accounts")]
+  api_gateway -->|reads/writes rows| billing_db
+  end_ -.-> api_gateway
+
+  classDef hot_spot fill:#fee2e2
+  class billing_db hot_spot
+  subgraph left_open ["Left open"]
+    n_9th(["Ninth"])
+  end
+`
+	got := f.String()
+	if got != want {
+		t.Errorf("String() =\n%s\nwant\n%s", got, want)
+	}
+	if err := Validate(got); err != nil {
+		t.Errorf("Validate(flowchart) = %v", err)
+	}
+}
+
+func TestFlowchartIgnoresExtraEnd(t *testing.T) {
+	f := NewFlowchart("LR")
+	f.EndSubgraph()
+	f.Edge("a", "b", Thick, "")
+	if got, want := f.String(), "graph LR\n  a ==> b\n"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		diagram string
+		wantErr string // Substring of the expected error; empty when the diagram is valid
+	}{
+		{"flowchart", "graph TD\n  a[\"billing.accounts\"] --> b\n", ""},
+		{"comment before header", "%% generated\nflowchart LR\n  a --> b\n", ""},
+		{"edge label", "graph LR\n  a -->|\"v1.users\"| b\n  b -.-> c\n  c ==> a\n", ""},
+		{"styles", "graph TD\n  a --> b\n  classDef hot fill:#f00\n  class a hot\n  style b fill:#0f0\n", ""},
+		{"node class", "graph TD\n  a:::hot --> b\n", ""},
+		{"subgraph", "graph TD\n  subgraph s [\"Services\"]\n    a --> b\n  end\n", ""},
+		{"er diagram", "erDiagram\n  users {\n    int id PK\n    character_varying(255) email UK \"login.email\"\n  }\n  \"billing.accounts\" ||--o{ users : \"owns\"\n", ""},
+		{"other diagram type", "sequenceDiagram\n  a->>b: hi\n", ""},
+
+		{"empty", "  \n\n", "empty diagram"},
+		{"unknown type", "diagram TD\n", "unknown diagram type"},
+		{"unknown direction", "graph XY\n  a --> b\n", "unknown flowchart direction"},
+		{"literal newline escape", `graph TD\n  a --> b`, "literal \\n"},
+		{"dotted id", "graph TD\n  billing.accounts --> b\n", `invalid node id "billing.accounts"`},
+		{"dashed id", "graph TD\n  user-service --> b\n", `invalid node id "user-service"`},
+		{"quote in id", "graph TD\n  a\"b --> c\n", "unterminated quoted label"},
+		{"end as id", "graph TD\n  a --> end\n", `"end" cannot be used as a node id`},
+		{"unbalanced bracket", "graph TD\n  a[\"label\") --> b\n", "unbalanced"},
+		{"unclosed bracket", "graph TD\n  a[\"label\" --> b\n", "unclosed"},
+		{"unterminated label", "graph TD\n  a[\"label] --> b\n", "unterminated quoted label"},
+		{"open subgraph", "graph TD\n  subgraph s\n    a --> b\n", "not closed with end"},
+		{"stray end", "graph TD\n  a --> b\n  end\n", "end without subgraph"},
+		{"er dotted entity", "erDiagram\n  billing.accounts ||--o{ users : owns\n", "invalid entity name"},
+		{"er open block", "erDiagram\n  users {\n    int id PK\n", "entity block not closed"},
+		{"er bad attribute", "erDiagram\n  users {\n    character varying name\n  }\n", "invalid attribute"},
+		{"er garbage", "erDiagram\n  users -> orders\n", "cannot parse"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.diagram)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("Validate() = %v, want nil", err)
+			case tt.wantErr != "" && err == nil:
+				t.Errorf("Validate() = nil, want an error containing %q", tt.wantErr)
+			case tt.wantErr != "" && !strings.Contains(err.Error(), tt.wantErr):
+				t.Errorf("Validate() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClean(t *testing.T) {
+	diagram := "```mermaid\r\nerDiagram\\n  users ||--o{ orders : places   \r\n```\n"
+	got, err := Clean(diagram)
+	if err != nil {
+		t.Fatalf("Clean() error = %v", err)
+	}
+	if want := "erDiagram\n  users ||--o{ orders : places\n"; got != want {
+		t.Errorf("Clean() = %q, want %q", got, want)
+	}
+
+	if _, err := Clean("```\ngraph TD\n  a.b --> c\n```"); err == nil {
+		t.Error("Clean() accepted a dotted node id")
+	}
+}
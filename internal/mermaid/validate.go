@@ -0,0 +1,269 @@
+package mermaid
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// diagramTypes are the headers Validate recognizes; only flowcharts and ER diagrams are checked
+// beyond the header
+var diagramTypes = map[string]bool{
+	"graph": true, "flowchart": true, "erDiagram": true, "sequenceDiagram": true, "classDiagram": true,
+	"stateDiagram": true, "stateDiagram-v2": true, "mindmap": true, "gitGraph": true, "pie": true,
+	"journey": true, "gantt": true, "timeline": true, "quadrantChart": true, "requirementDiagram": true,
+	"C4Context": true, "C4Container": true, "C4Component": true, "C4Dynamic": true, "C4Deployment": true,
+	"block-beta": true, "architecture-beta": true, "sankey-beta": true, "xychart-beta": true,
+}
+
+var flowchartDirections = map[string]bool{"TB": true, "TD": true, "BT": true, "RL": true, "LR": true}
+
+var (
+	quotedRegex       = regexp.MustCompile(`"[^"]*"`)
+	edgeLabelRegex    = regexp.MustCompile(`\|[^|]*\|`)
+	arrowRegex        = regexp.MustCompile(`\s*(?:<?(?:-{2,}|-\.+-|={2,}|~{3})[->ox]?|&)\s*`)
+	nodeClassRegex    = regexp.MustCompile(`:::\w+$`)
+	flowchartIDRegex  = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+	erEntityRegex     = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_\-]*$`)
+	erBlockRegex      = regexp.MustCompile(`^("[^"]+"|[^\s{\[]+)(?:\["[^"]*"\])?\s*\{$`)
+	erRelationRegex   = regexp.MustCompile(`^("[^"]+"|\S+)\s+([|}o][|o]?(?:--|\.\.)[|o{][|{o]?)\s+("[^"]+"|\S+)\s*:\s*(.+)$`)
+	erAttributeRegex  = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_\-\[\]\(\)]*)\s+([A-Za-z_*][A-Za-z0-9_\-\[\]\(\)]*)((?:\s+(?:PK|FK|UK)(?:\s*,\s*(?:PK|FK|UK))*)?)(\s+"[^"]*")?$`)
+	flowchartKeywords = []string{"classDef ", "class ", "style ", "linkStyle ", "click ", "direction "}
+	erStyleKeywords   = []string{"classDef ", "class ", "style "}
+)
+
+// Normalize undoes the damage diagrams pick up on the way out of generators and LLM responses:
+// Markdown fences, literal \n escapes instead of newlines, CRLF line endings and trailing spaces
+func Normalize(diagram string) string {
+	diagram = strings.TrimSpace(diagram)
+	if strings.HasPrefix(diagram, "```") {
+		lines := strings.Split(diagram, "\n")
+		var body []string
+		for _, line := range lines[1:] {
+			if strings.HasPrefix(strings.TrimSpace(line), "```") {
+				break
+			}
+			body = append(body, line)
+		}
+		diagram = strings.Join(body, "\n")
+	}
+	diagram = strings.ReplaceAll(diagram, "\r\n", "\n")
+	diagram = strings.ReplaceAll(diagram, `\n`, "\n")
+
+	lines := strings.Split(strings.TrimSpace(diagram), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// Clean normalizes a diagram and validates the result
+func Clean(diagram string) (string, error) {
+	diagram = Normalize(diagram)
+	return diagram, Validate(diagram)
+}
+
+// Validate checks that a diagram starts with a known diagram type and, for flowcharts and ER
+// diagrams, that every statement parses: balanced brackets and subgraphs, ids without dots or
+// dashes that Mermaid would misread, and well-formed entities, attributes and relationships
+func Validate(diagram string) error {
+	lines := strings.Split(diagram, "\n")
+	header := -1
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "%%") {
+			continue
+		}
+		header = i
+		break
+	}
+	if header < 0 {
+		return fmt.Errorf("empty diagram")
+	}
+
+	fields := strings.Fields(lines[header])
+	kind := fields[0]
+	if !diagramTypes[kind] {
+		return fmt.Errorf("line %d: unknown diagram type %q", header+1, kind)
+	}
+	if strings.Contains(diagram, `\n`) {
+		return fmt.Errorf("diagram contains literal \\n escapes instead of newlines")
+	}
+
+	switch kind {
+	case "graph", "flowchart":
+		if len(fields) > 1 && !flowchartDirections[fields[1]] {
+			return fmt.Errorf("line %d: unknown flowchart direction %q", header+1, fields[1])
+		}
+		return validateFlowchart(lines, header+1)
+	case "erDiagram":
+		return validateERDiagram(lines, header+1)
+	}
+	return nil
+}
+
+func validateFlowchart(lines []string, start int) error {
+	subgraphs := 0
+	for i := start; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "%%") {
+			continue
+		}
+		lineNumber := i + 1
+		if line == "end" {
+			if subgraphs == 0 {
+				return fmt.Errorf("line %d: end without subgraph", lineNumber)
+			}
+			subgraphs--
+			continue
+		}
+		if strings.HasPrefix(line, "subgraph ") || line == "subgraph" {
+			subgraphs++
+			if err := checkBrackets(line); err != nil {
+				return fmt.Errorf("line %d: %v", lineNumber, err)
+			}
+			continue
+		}
+		if hasKeyword(line, flowchartKeywords) {
+			continue
+		}
+		if err := checkBrackets(line); err != nil {
+			return fmt.Errorf("line %d: %v", lineNumber, err)
+		}
+		for _, id := range statementIDs(line) {
+			if strings.EqualFold(id, "end") {
+				return fmt.Errorf("line %d: %q cannot be used as a node id", lineNumber, id)
+			}
+			if !flowchartIDRegex.MatchString(id) {
+				return fmt.Errorf("line %d: invalid node id %q", lineNumber, id)
+			}
+		}
+	}
+	if subgraphs > 0 {
+		return fmt.Errorf("%d subgraph(s) not closed with end", subgraphs)
+	}
+	return nil
+}
+
+func hasKeyword(line string, keywords []string) bool {
+	for _, keyword := range keywords {
+		if strings.HasPrefix(line, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// statementIDs returns the node ids of a flowchart statement: the text left between arrows once
+// labels and shapes are removed
+func statementIDs(line string) []string {
+	line = quotedRegex.ReplaceAllString(line, `""`)
+	line = edgeLabelRegex.ReplaceAllString(line, " ")
+	line = stripShapes(line)
+	var ids []string
+	for _, part := range arrowRegex.Split(line, -1) {
+		part = nodeClassRegex.ReplaceAllString(strings.TrimSpace(part), "")
+		if part != "" {
+			ids = append(ids, part)
+		}
+	}
+	return ids
+}
+
+// stripShapes drops bracketed node shapes, which checkBrackets has already found balanced
+func stripShapes(line string) string {
+	var b strings.Builder
+	depth := 0
+	for _, r := range line {
+		switch r {
+		case '[', '(', '{':
+			depth++
+			continue
+		case ']', ')', '}':
+			depth--
+			continue
+		}
+		if depth == 0 {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// checkBrackets verifies that brackets outside quoted text open and close in order
+func checkBrackets(line string) error {
+	pairs := map[rune]rune{']': '[', ')': '(', '}': '{'}
+	var stack []rune
+	line = edgeLabelRegex.ReplaceAllString(quotedRegex.ReplaceAllString(line, ""), " ")
+	for _, r := range line {
+		switch r {
+		case '"':
+			return fmt.Errorf("unterminated quoted label")
+		case '[', '(', '{':
+			stack = append(stack, r)
+		case ']', ')', '}':
+			if len(stack) == 0 || stack[len(stack)-1] != pairs[r] {
+				return fmt.Errorf("unbalanced %q", r)
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+	if len(stack) > 0 {
+		return fmt.Errorf("unclosed %q", stack[len(stack)-1])
+	}
+	return nil
+}
+
+func validateERDiagram(lines []string, start int) error {
+	inEntity := false
+	for i := start; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "%%") {
+			continue
+		}
+		lineNumber := i + 1
+		if inEntity {
+			if line == "}" {
+				inEntity = false
+				continue
+			}
+			if !erAttributeRegex.MatchString(line) {
+				return fmt.Errorf("line %d: invalid attribute %q (expected: type name [PK|FK|UK] [\"comment\"])", lineNumber, line)
+			}
+			continue
+		}
+		if match := erBlockRegex.FindStringSubmatch(line); match != nil {
+			if err := checkEntity(match[1]); err != nil {
+				return fmt.Errorf("line %d: %v", lineNumber, err)
+			}
+			inEntity = true
+			continue
+		}
+		if match := erRelationRegex.FindStringSubmatch(line); match != nil {
+			for _, entity := range []string{match[1], match[3]} {
+				if err := checkEntity(entity); err != nil {
+					return fmt.Errorf("line %d: %v", lineNumber, err)
+				}
+			}
+			continue
+		}
+		if erEntityRegex.MatchString(line) || hasKeyword(line, erStyleKeywords) {
+			continue
+		}
+		return fmt.Errorf("line %d: cannot parse %q", lineNumber, line)
+	}
+	if inEntity {
+		return fmt.Errorf("entity block not closed with }")
+	}
+	return nil
+}
+
+func checkEntity(name string) error {
+	if strings.HasPrefix(name, `"`) {
+		return nil
+	}
+	if !erEntityRegex.MatchString(name) {
+		return fmt.Errorf("invalid entity name %q", name)
+	}
+	return nil
+}
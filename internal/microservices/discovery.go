@@ -1,10 +1,14 @@
 package microservices
 
 import (
+	"path"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"fmt"
+
+	"repo-explanation/internal/portable"
 )
 
 // ServiceType represents the type of API a service exposes
@@ -24,6 +28,39 @@ type DiscoveredService struct {
 	APIType     ServiceType `json:"api_type"`
 	Port        string      `json:"port,omitempty"`
 	Description string      `json:"description,omitempty"`
+	Container   *Containerization `json:"container,omitempty"` // How the service is containerized, from its Dockerfile
+	Startup     []StartupStep     `json:"startup,omitempty"`   // What its entry point initializes, in order
+	UserSpecified []string        `json:"user_specified,omitempty"` // Fields pinned in .repo-analyzer.yaml rather than detected
+}
+
+// StableServices returns a copy of services sorted by path and name, for comparing results across runs
+func StableServices(services []DiscoveredService) []DiscoveredService {
+	stable := append([]DiscoveredService{}, services...)
+	sort.SliceStable(stable, func(i, j int) bool {
+		if stable[i].Path != stable[j].Path {
+			return stable[i].Path < stable[j].Path
+		}
+		return stable[i].Name < stable[j].Name
+	})
+	return stable
+}
+
+// OwningService returns the name of the service whose directory contains relPath, a file or
+// directory relative to the project root, preferring the deepest match. A service rooted at the
+// repository owns every path no other service claims; "" means no service does.
+func OwningService(relPath string, services []DiscoveredService) string {
+	owner := ""
+	deepest := -1
+	for _, service := range services {
+		if !portable.Within(relPath, service.Path) {
+			continue
+		}
+		if depth := len(portable.Segments(service.Path)); depth > deepest {
+			owner = service.Name
+			deepest = depth
+		}
+	}
+	return owner
 }
 
 // ServiceDiscovery handles microservice discovery in monorepos
@@ -99,7 +136,7 @@ func (sd *ServiceDiscovery) discoverNodeServices(files map[string]string, folder
 	
 	// Look for services/ or apps/ directories
 	for _, folder := range folderStructure {
-		if strings.Contains(folder, "/services/") || strings.Contains(folder, "/apps/") {
+		if portable.HasSegment(folder, "services") || portable.HasSegment(folder, "apps") {
 			service := sd.analyzeNodeServiceFolder(folder, files)
 			if service != nil {
 				services = append(services, *service)
@@ -116,14 +153,14 @@ func (sd *ServiceDiscovery) discoverReactServices(files map[string]string, folde
 	
 	// Look for microfrontend patterns
 	for _, folder := range folderStructure {
-		if strings.Contains(folder, "/apps/") || strings.Contains(folder, "/packages/") {
+		if portable.HasSegment(folder, "apps") || portable.HasSegment(folder, "packages") {
 			// Check if it has its own package.json and is a React app
-			packagePath := filepath.Join(folder, "package.json")
+			packagePath := path.Join(portable.Slash(folder), "package.json")
 			if packageContent, exists := files[packagePath]; exists {
 				if strings.Contains(packageContent, "react") && 
 				   (strings.Contains(packageContent, "react-scripts") || strings.Contains(packageContent, "vite") || strings.Contains(packageContent, "webpack")) {
 					
-					serviceName := filepath.Base(folder)
+					serviceName := path.Base(portable.Slash(folder))
 					services = append(services, DiscoveredService{
 						Name:       serviceName,
 						Path:       folder,
@@ -144,9 +181,9 @@ func (sd *ServiceDiscovery) scanCmdFolders(files map[string]string, folderStruct
 	var services []DiscoveredService
 	
 	for _, folder := range folderStructure {
-		if strings.Contains(folder, "/cmd/") {
+		if portable.HasSegment(folder, "cmd") {
 			// Extract service name from cmd/servicename pattern
-			parts := strings.Split(folder, "/")
+			parts := portable.Segments(folder)
 			var serviceName string
 			
 			for i, part := range parts {
@@ -157,7 +194,7 @@ func (sd *ServiceDiscovery) scanCmdFolders(files map[string]string, folderStruct
 			}
 			
 			if serviceName != "" {
-				mainGoPath := filepath.Join(folder, "main.go")
+				mainGoPath := path.Join(portable.Slash(folder), "main.go")
 				if _, exists := files[mainGoPath]; exists {
 					services = append(services, DiscoveredService{
 						Name:       serviceName,
@@ -178,7 +215,7 @@ func (sd *ServiceDiscovery) scanTopLevelMain(files map[string]string) []Discover
 	var services []DiscoveredService
 	
 	for filePath := range files {
-		if strings.HasSuffix(filePath, "main.go") && !strings.Contains(filePath, "/") {
+		if strings.HasSuffix(filePath, "main.go") && len(portable.Segments(filePath)) == 1 {
 			// Top-level main.go
 			serviceName := strings.TrimSuffix(filepath.Base(sd.projectPath), "/")
 			services = append(services, DiscoveredService{
@@ -381,7 +418,7 @@ func (sd *ServiceDiscovery) parseReadmeCommands(files map[string]string) []strin
 	
 	readmeContent := ""
 	for filePath, content := range files {
-		if strings.ToLower(filepath.Base(filePath)) == "readme.md" {
+		if strings.ToLower(path.Base(portable.Slash(filePath))) == "readme.md" {
 			readmeContent = content
 			break
 		}
@@ -449,16 +486,16 @@ func (sd *ServiceDiscovery) reconcileWithReadme(discovered []DiscoveredService,
 // analyzeNodeService analyzes a Node.js service from package.json
 func (sd *ServiceDiscovery) analyzeNodeService(packagePath, packageContent string, files map[string]string) *DiscoveredService {
 	// Extract service name from path
-	dir := filepath.Dir(packagePath)
-	serviceName := filepath.Base(dir)
+	dir := path.Dir(portable.Slash(packagePath))
+	serviceName := path.Base(dir)
 	
 	// Look for server entry point
 	entryPoints := []string{
-		filepath.Join(dir, "index.js"),
-		filepath.Join(dir, "server.js"),
-		filepath.Join(dir, "app.js"),
-		filepath.Join(dir, "src/index.js"),
-		filepath.Join(dir, "src/server.js"),
+		path.Join(dir, "index.js"),
+		path.Join(dir, "server.js"),
+		path.Join(dir, "app.js"),
+		path.Join(dir, "src/index.js"),
+		path.Join(dir, "src/server.js"),
 	}
 	
 	for _, entryPoint := range entryPoints {
@@ -480,7 +517,7 @@ func (sd *ServiceDiscovery) analyzeNodeService(packagePath, packageContent strin
 // analyzeNodeServiceFolder analyzes a Node.js service folder
 func (sd *ServiceDiscovery) analyzeNodeServiceFolder(folder string, files map[string]string) *DiscoveredService {
 	// Look for package.json in this folder
-	packagePath := filepath.Join(folder, "package.json")
+	packagePath := path.Join(portable.Slash(folder), "package.json")
 	if _, exists := files[packagePath]; exists {
 		return sd.analyzeNodeService(packagePath, files[packagePath], files)
 	}
@@ -492,7 +529,7 @@ func (sd *ServiceDiscovery) analyzeNodeServiceFolder(folder string, files map[st
 func (sd *ServiceDiscovery) findFiles(files map[string]string, filename string) []string {
 	var foundFiles []string
 	for filePath := range files {
-		if filepath.Base(filePath) == filename {
+		if path.Base(portable.Slash(filePath)) == filename {
 			foundFiles = append(foundFiles, filePath)
 		}
 	}
@@ -0,0 +1,34 @@
+package microservices
+
+import "testing"
+
+func TestOwningService(t *testing.T) {
+	services := []DiscoveredService{
+		{Name: "root", Path: "."},
+		{Name: "api", Path: "services/api"},
+		{Name: "api-admin", Path: "./services/api/admin/"},
+		{Name: "worker", Path: `services\worker`},
+	}
+	tests := []struct {
+		relPath string
+		want    string
+	}{
+		{"services/api/main.go", "api"},
+		{"services/api", "api"},
+		{"services/api/admin/handlers/users.go", "api-admin"},
+		{`services\worker\jobs\send.go`, "worker"},
+		{"services/api-gateway/main.go", "root"},
+		{"services/apiserver", "root"},
+		{"Makefile", "root"},
+		{".", "root"},
+	}
+	for _, tt := range tests {
+		if got := OwningService(tt.relPath, services); got != tt.want {
+			t.Errorf("OwningService(%q) = %q, want %q", tt.relPath, got, tt.want)
+		}
+	}
+
+	if got := OwningService("tools/gen.go", services[1:]); got != "" {
+		t.Errorf("OwningService outside every service = %q, want \"\"", got)
+	}
+}
@@ -0,0 +1,344 @@
+package microservices
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"repo-explanation/internal/portable"
+)
+
+// Containerization describes how a service is built into an image, read from its Dockerfile
+type Containerization struct {
+	Dockerfile   string        `json:"dockerfile"`
+	BaseImage    string        `json:"base_image"` // Image of the final stage
+	Stages       []DockerStage `json:"stages"`
+	ExposedPorts []string      `json:"exposed_ports,omitempty"`
+	BuildArgs    []DockerVar   `json:"build_args,omitempty"`
+	Env          []DockerVar   `json:"env,omitempty"` // ENV of the final stage
+	Entrypoint   string        `json:"entrypoint,omitempty"`
+	Cmd          string        `json:"cmd,omitempty"`
+	User         string        `json:"user,omitempty"`
+	Workdir      string        `json:"workdir,omitempty"`
+	Summary      string        `json:"summary"`
+}
+
+// DockerStage is one FROM section of a (possibly multistage) Dockerfile
+type DockerStage struct {
+	Name       string   `json:"name,omitempty"`
+	Image      string   `json:"image"`
+	CopiesFrom []string `json:"copies_from,omitempty"` // Stages or images files are copied from
+}
+
+// DockerVar is an ARG or ENV instruction
+type DockerVar struct {
+	Name       string `json:"name"`
+	Value      string `json:"value,omitempty"`
+	HasDefault bool   `json:"has_default"`
+	Stage      string `json:"stage,omitempty"` // Empty for ARGs declared before the first FROM
+}
+
+// predefinedBuildArgs are set by BuildKit, so they never need to be passed in
+var predefinedBuildArgs = map[string]bool{
+	"TARGETPLATFORM": true, "TARGETOS": true, "TARGETARCH": true, "TARGETVARIANT": true,
+	"BUILDPLATFORM": true, "BUILDOS": true, "BUILDARCH": true, "BUILDVARIANT": true,
+}
+
+var (
+	dockerFlagRegex     = regexp.MustCompile(`^--[a-z-]+(=\S*)?$`)
+	dockerCopyFromRegex = regexp.MustCompile(`(?i)--from=(\S+)`)
+	dockerVarRefRegex   = regexp.MustCompile(`\$\{?([A-Za-z_][A-Za-z0-9_]*)`)
+)
+
+// IsDockerfile reports whether a file name is a Dockerfile (Dockerfile, Dockerfile.prod, api.Dockerfile)
+func IsDockerfile(name string) bool {
+	lower := strings.ToLower(path.Base(portable.Slash(name)))
+	return lower == "dockerfile" || strings.HasPrefix(lower, "dockerfile.") || strings.HasSuffix(lower, ".dockerfile")
+}
+
+// ParseDockerfile reads base images, stages, exposed ports, build args, env, entrypoint and command
+func ParseDockerfile(name, content string) *Containerization {
+	container := &Containerization{Dockerfile: portable.Slash(name), Stages: []DockerStage{}}
+	var stage *DockerStage
+	var entrypoint, cmd, user, workdir string
+	var env []DockerVar
+
+	for _, instruction := range dockerInstructions(content) {
+		fields := strings.Fields(instruction)
+		keyword := strings.ToUpper(fields[0])
+		args := strings.TrimSpace(instruction[len(fields[0]):])
+
+		if keyword == "FROM" {
+			image, stageName := parseFrom(fields[1:])
+			container.Stages = append(container.Stages, DockerStage{Name: stageName, Image: image})
+			stage = &container.Stages[len(container.Stages)-1]
+			// Each stage starts from its base; only the last one ends up in the image
+			entrypoint, cmd, user, workdir, env = "", "", "", "", nil
+			continue
+		}
+
+		stageName := ""
+		if stage != nil {
+			stageName = stage.Name
+			if stageName == "" {
+				stageName = fmt.Sprintf("%d", len(container.Stages)-1)
+			}
+		}
+
+		switch keyword {
+		case "ARG":
+			for _, arg := range parseDockerVars(args, true) {
+				if !predefinedBuildArgs[arg.Name] {
+					arg.Stage = stageName
+					container.BuildArgs = append(container.BuildArgs, arg)
+				}
+			}
+		case "ENV":
+			for _, variable := range parseDockerVars(args, false) {
+				variable.Stage = stageName
+				env = append(env, variable)
+			}
+		case "EXPOSE":
+			for _, port := range fields[1:] {
+				container.ExposedPorts = appendUnique(container.ExposedPorts, strings.TrimSuffix(port, "/tcp"))
+			}
+		case "ENTRYPOINT":
+			entrypoint = dockerCommand(args)
+		case "CMD":
+			cmd = dockerCommand(args)
+		case "USER":
+			user = args
+		case "WORKDIR":
+			workdir = args
+		case "COPY", "ADD":
+			if stage != nil {
+				if matches := dockerCopyFromRegex.FindStringSubmatch(args); matches != nil {
+					stage.CopiesFrom = appendUnique(stage.CopiesFrom, matches[1])
+				}
+			}
+		}
+	}
+
+	if len(container.Stages) > 0 {
+		container.BaseImage = container.Stages[len(container.Stages)-1].Image
+	}
+	container.Entrypoint, container.Cmd, container.User, container.Workdir, container.Env = entrypoint, cmd, user, workdir, env
+	container.Summary = summarizeContainer(container)
+	return container
+}
+
+// RequiredBuildArgs returns the build args with no default, which must be passed with --build-arg
+func (c *Containerization) RequiredBuildArgs() []string {
+	var names []string
+	for _, arg := range c.BuildArgs {
+		if !arg.HasDefault {
+			names = appendUnique(names, arg.Name)
+		}
+	}
+	return names
+}
+
+// AttachContainers parses the Dockerfile in each service's directory and fills in the service port
+// from EXPOSE when none was detected
+func AttachContainers(services []DiscoveredService, files map[string]string) {
+	contents := make(map[string]string)
+	var dockerfiles []string
+	for filePath, content := range files {
+		if IsDockerfile(filePath) {
+			contents[portable.Slash(filePath)] = content
+			dockerfiles = append(dockerfiles, portable.Slash(filePath))
+		}
+	}
+	if len(dockerfiles) == 0 {
+		return
+	}
+	// Plain "Dockerfile" sorts before variants such as Dockerfile.dev in the same directory
+	sort.Slice(dockerfiles, func(i, j int) bool {
+		di, dj := path.Dir(dockerfiles[i]), path.Dir(dockerfiles[j])
+		if di != dj {
+			return di < dj
+		}
+		bi, bj := path.Base(dockerfiles[i]) == "Dockerfile", path.Base(dockerfiles[j]) == "Dockerfile"
+		if bi != bj {
+			return bi
+		}
+		return dockerfiles[i] < dockerfiles[j]
+	})
+
+	for i := range services {
+		servicePath := strings.Trim(path.Clean(portable.Slash(services[i].Path)), "/")
+		best := ""
+		for _, dockerfile := range dockerfiles {
+			if path.Dir(dockerfile) == servicePath {
+				best = dockerfile
+				break
+			}
+		}
+		// A lone service is built by the root Dockerfile wherever its code lives
+		if best == "" && len(services) == 1 {
+			for _, dockerfile := range dockerfiles {
+				if path.Dir(dockerfile) == "." {
+					best = dockerfile
+					break
+				}
+			}
+		}
+		if best == "" {
+			continue
+		}
+
+		services[i].Container = ParseDockerfile(best, contents[best])
+		if services[i].Port == "" && len(services[i].Container.ExposedPorts) > 0 {
+			services[i].Port = services[i].Container.ExposedPorts[0]
+		}
+	}
+}
+
+// dockerInstructions joins continuation lines and drops comments, returning one instruction per entry
+func dockerInstructions(content string) []string {
+	var instructions []string
+	var current strings.Builder
+	for _, line := range portable.Lines(content) {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "#") || (trimmed == "" && current.Len() == 0) {
+			continue
+		}
+		if strings.HasSuffix(trimmed, "\\") {
+			current.WriteString(strings.TrimSuffix(trimmed, "\\"))
+			current.WriteString(" ")
+			continue
+		}
+		current.WriteString(trimmed)
+		if instruction := strings.TrimSpace(current.String()); instruction != "" {
+			instructions = append(instructions, instruction)
+		}
+		current.Reset()
+	}
+	if instruction := strings.TrimSpace(current.String()); instruction != "" {
+		instructions = append(instructions, instruction)
+	}
+	return instructions
+}
+
+// parseFrom reads FROM [--platform=...] image [AS name]
+func parseFrom(fields []string) (image, name string) {
+	for i := 0; i < len(fields); i++ {
+		switch {
+		case dockerFlagRegex.MatchString(fields[i]):
+			continue
+		case strings.EqualFold(fields[i], "AS") && i+1 < len(fields):
+			name = strings.ToLower(fields[i+1])
+			i++
+		case image == "":
+			image = fields[i]
+		}
+	}
+	return image, name
+}
+
+// parseDockerVars reads ARG name[=default] or ENV key=value pairs, plus the legacy "ENV key value" form
+func parseDockerVars(args string, isArg bool) []DockerVar {
+	fields := splitDockerWords(args)
+	if len(fields) == 0 {
+		return nil
+	}
+	if !isArg && !strings.Contains(fields[0], "=") {
+		value := strings.TrimSpace(strings.TrimPrefix(args, fields[0]))
+		return []DockerVar{{Name: fields[0], Value: strings.Trim(value, `"'`), HasDefault: value != ""}}
+	}
+
+	var vars []DockerVar
+	for _, field := range fields {
+		name, value, hasValue := strings.Cut(field, "=")
+		vars = append(vars, DockerVar{Name: name, Value: strings.Trim(value, `"'`), HasDefault: hasValue})
+	}
+	return vars
+}
+
+// splitDockerWords splits on spaces outside quotes
+func splitDockerWords(s string) []string {
+	var words []string
+	var current strings.Builder
+	var quote rune
+	for _, char := range s {
+		switch {
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			}
+			current.WriteRune(char)
+		case char == '"' || char == '\'':
+			quote = char
+			current.WriteRune(char)
+		case char == ' ' || char == '\t':
+			if current.Len() > 0 {
+				words = append(words, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(char)
+		}
+	}
+	if current.Len() > 0 {
+		words = append(words, current.String())
+	}
+	return words
+}
+
+// dockerCommand renders the exec form (["./server", "-port", "8080"]) as a command line
+func dockerCommand(args string) string {
+	var parts []string
+	if strings.HasPrefix(args, "[") && json.Unmarshal([]byte(args), &parts) == nil {
+		return strings.Join(parts, " ")
+	}
+	return args
+}
+
+// DockerVarReferences returns the names referenced as $NAME or ${NAME} in an ENV value
+func DockerVarReferences(value string) []string {
+	var names []string
+	for _, match := range dockerVarRefRegex.FindAllStringSubmatch(value, -1) {
+		names = appendUnique(names, match[1])
+	}
+	return names
+}
+
+// summarizeContainer describes the build in one line
+func summarizeContainer(c *Containerization) string {
+	if len(c.Stages) == 0 {
+		return fmt.Sprintf("%s has no FROM instruction", c.Dockerfile)
+	}
+
+	var summary strings.Builder
+	if len(c.Stages) > 1 {
+		var images []string
+		for _, stage := range c.Stages[:len(c.Stages)-1] {
+			images = append(images, stage.Image)
+		}
+		fmt.Fprintf(&summary, "Multistage build (%d stages): built on %s, runs on %s", len(c.Stages), strings.Join(images, ", "), c.BaseImage)
+	} else {
+		fmt.Fprintf(&summary, "Single-stage build on %s", c.BaseImage)
+	}
+	if len(c.ExposedPorts) > 0 {
+		fmt.Fprintf(&summary, ", exposes %s", strings.Join(c.ExposedPorts, ", "))
+	}
+	if command := strings.TrimSpace(c.Entrypoint + " " + c.Cmd); command != "" {
+		fmt.Fprintf(&summary, ", starts with `%s`", command)
+	}
+	if required := c.RequiredBuildArgs(); len(required) > 0 {
+		fmt.Fprintf(&summary, "; needs --build-arg %s", strings.Join(required, ", "))
+	}
+	return summary.String()
+}
+
+func appendUnique(list []string, value string) []string {
+	for _, existing := range list {
+		if existing == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
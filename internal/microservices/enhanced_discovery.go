@@ -1,11 +1,14 @@
 package microservices
 
 import (
+	"path"
 	"path/filepath"
 	"regexp"
 	"strings"
 	"fmt"
 	"sort"
+
+	"repo-explanation/internal/portable"
 )
 
 // EnhancedServiceDiscovery provides deterministic microservice detection
@@ -21,7 +24,7 @@ type ServiceCandidate struct {
 	Name           string                 `json:"name"`
 	Path           string                 `json:"path"`
 	EntryPoint     string                 `json:"entry_point"`
-	DetectionType  string                 `json:"detection_type"` // "main_go", "makefile", "docker_compose", "directory"
+	DetectionType  string                 `json:"detection_type"` // "nx", "turborepo", "bazel", "pants", "main_go", "makefile", "docker_compose", "directory"
 	Confidence     float64               `json:"confidence"`     // 0.0 to 1.0
 	Evidence       []string              `json:"evidence"`
 	Language       string                `json:"language"`
@@ -39,6 +42,18 @@ func NewEnhancedServiceDiscovery(projectPath, projectType string) *EnhancedServi
 	}
 }
 
+// NeedsContent reports whether DiscoverMicroservices reads a file's content: Makefiles, Compose
+// files, Dockerfiles, package.json, monorepo tool manifests and entry points. Every other file
+// only contributes its path.
+func NeedsContent(relativePath string) bool {
+	filename := strings.ToLower(path.Base(portable.Slash(relativePath)))
+	switch filename {
+	case "makefile", "makefile.mk", "gnumakefile", "package.json", "main.go", "index.js", "server.js", "app.js", "main.js":
+		return true
+	}
+	return strings.HasPrefix(filename, "docker-compose") || IsDockerfile(relativePath) || IsMonorepoManifest(relativePath)
+}
+
 // DiscoverMicroservices discovers microservices using multiple deterministic patterns
 func (esd *EnhancedServiceDiscovery) DiscoverMicroservices(files map[string]string) ([]DiscoveredService, error) {
 	if esd.debug {
@@ -47,6 +62,14 @@ func (esd *EnhancedServiceDiscovery) DiscoverMicroservices(files map[string]stri
 	
 	var allCandidates []ServiceCandidate
 	
+	// Pattern 0: Projects declared by nx, Turborepo, Bazel or Pants (authoritative)
+	declaredProjects := DeclaredProjects(files)
+	monorepoCandidates := esd.discoverFromMonorepoTools(declaredProjects)
+	allCandidates = append(allCandidates, monorepoCandidates...)
+	if esd.debug && len(declaredProjects) > 0 {
+		fmt.Printf("🧱 Found %d projects declared by monorepo tools, %d of them runnable\n", len(declaredProjects), len(monorepoCandidates))
+	}
+	
 	// Pattern 1: Multiple main.go files (highest confidence)
 	mainGoCandidates := esd.discoverFromMainGoFiles(files)
 	allCandidates = append(allCandidates, mainGoCandidates...)
@@ -82,12 +105,18 @@ func (esd *EnhancedServiceDiscovery) DiscoverMicroservices(files map[string]stri
 		fmt.Printf("📁 Found %d directory structure based services\n", len(directoryCandidates))
 	}
 	
+	// Declared project boundaries override the directory heuristics
+	allCandidates = applyDeclaredBoundaries(allCandidates, declaredProjects)
+	
 	// Merge and deduplicate candidates
 	mergedCandidates := esd.mergeCandidates(allCandidates)
 	
 	// Filter and enhance with API detection
 	finalServices := esd.filterAndEnhanceServices(mergedCandidates, files)
 	
+	// Read how each service is containerized
+	AttachContainers(finalServices, files)
+	
 	if esd.debug {
 		fmt.Printf("✅ Enhanced discovery complete: %d final microservices detected\n", len(finalServices))
 		for i, service := range finalServices {
@@ -116,7 +145,7 @@ func (esd *EnhancedServiceDiscovery) discoverFromMainGoFiles(files map[string]st
 	if len(mainGoFiles) > 1 {
 		for _, mainGoFile := range mainGoFiles {
 			serviceName := esd.extractServiceNameFromPath(mainGoFile)
-			servicePath := filepath.Dir(mainGoFile)
+			servicePath := path.Dir(portable.Slash(mainGoFile))
 			
 			if serviceName != "" {
 				candidates = append(candidates, ServiceCandidate{
@@ -139,7 +168,7 @@ func (esd *EnhancedServiceDiscovery) discoverFromMainGoFiles(files map[string]st
 			if serviceName != "" {
 				candidates = append(candidates, ServiceCandidate{
 					Name:          serviceName,
-					Path:          filepath.Dir(mainGoFile),
+					Path:          path.Dir(portable.Slash(mainGoFile)),
 					EntryPoint:    mainGoFile,
 					DetectionType: "main_go",
 					Confidence:    0.7, // Medium confidence for single main.go in service structure
@@ -162,7 +191,7 @@ func (esd *EnhancedServiceDiscovery) discoverFromMakefile(files map[string]strin
 	var makefileContent string
 	var makefilePath string
 	for filePath, content := range files {
-		filename := strings.ToLower(filepath.Base(filePath))
+		filename := strings.ToLower(path.Base(portable.Slash(filePath)))
 		if filename == "makefile" || filename == "makefile.mk" || filename == "gnumakefile" {
 			makefileContent = content
 			makefilePath = filePath
@@ -186,7 +215,7 @@ func (esd *EnhancedServiceDiscovery) discoverFromMakefile(files map[string]strin
 	// Pattern 4: Service build targets
 	serviceBuildRegex := regexp.MustCompile(`^build-(\w+):|^(\w+)-build:`)
 	
-	lines := strings.Split(makefileContent, "\n")
+	lines := portable.Lines(makefileContent)
 	for lineNum, line := range lines {
 		line = strings.TrimSpace(line)
 		
@@ -234,7 +263,7 @@ func (esd *EnhancedServiceDiscovery) discoverFromDockerCompose(files map[string]
 	var dockerComposeContent string
 	var dockerComposePath string
 	for filePath, content := range files {
-		filename := strings.ToLower(filepath.Base(filePath))
+		filename := strings.ToLower(path.Base(portable.Slash(filePath)))
 		if filename == "docker-compose.yml" || filename == "docker-compose.yaml" || 
 		   filename == "docker-compose.override.yml" || filename == "docker-compose.prod.yml" {
 			dockerComposeContent = content
@@ -249,7 +278,7 @@ func (esd *EnhancedServiceDiscovery) discoverFromDockerCompose(files map[string]
 	
 	// Simple YAML parsing for services section
 	// Look for service definitions under "services:"
-	lines := strings.Split(dockerComposeContent, "\n")
+	lines := portable.Lines(dockerComposeContent)
 	inServicesSection := false
 	
 	for i, line := range lines {
@@ -342,7 +371,7 @@ func (esd *EnhancedServiceDiscovery) discoverFromDirectoryStructure(files map[st
 	
 	// Look for service-like directory patterns
 	for filePath := range files {
-		dirs := strings.Split(filePath, "/")
+		dirs := portable.Segments(filePath)
 		
 		for i, dir := range dirs {
 			// Check for service directory patterns
@@ -379,7 +408,7 @@ func (esd *EnhancedServiceDiscovery) discoverFromDirectoryStructure(files map[st
 
 func (esd *EnhancedServiceDiscovery) extractServiceNameFromPath(filePath string) string {
 	// Extract service name from various path patterns
-	dirs := strings.Split(filePath, "/")
+	dirs := portable.Segments(filePath)
 	
 	for i, dir := range dirs {
 		if esd.isServiceDirectory(dir) && i+1 < len(dirs) {
@@ -398,7 +427,7 @@ func (esd *EnhancedServiceDiscovery) extractServiceNameFromPath(filePath string)
 
 func (esd *EnhancedServiceDiscovery) isServiceLikeStructure(mainGoPath string) bool {
 	// Check if main.go is in a service-like directory structure
-	dirs := strings.Split(mainGoPath, "/")
+	dirs := portable.Segments(mainGoPath)
 	
 	for _, dir := range dirs {
 		if esd.isServiceDirectory(dir) {
@@ -506,9 +535,13 @@ func (esd *EnhancedServiceDiscovery) findEntryPointInDirectory(servicePath strin
 		servicePath + "/package.json",
 	}
 	
+	paths := make(map[string]string, len(files))
+	for filePath := range files {
+		paths[portable.Slash(filePath)] = filePath
+	}
 	for _, candidate := range candidates {
-		if _, exists := files[candidate]; exists {
-			return candidate
+		if filePath, exists := paths[candidate]; exists {
+			return filePath
 		}
 	}
 	
@@ -518,7 +551,7 @@ func (esd *EnhancedServiceDiscovery) findEntryPointInDirectory(servicePath strin
 func (esd *EnhancedServiceDiscovery) inferLanguageFromPath(servicePath string, files map[string]string) string {
 	// Check for language-specific files in the service directory
 	for filePath := range files {
-		if strings.HasPrefix(filePath, servicePath) {
+		if portable.Within(filePath, servicePath) {
 			if strings.HasSuffix(filePath, ".go") {
 				return "Go"
 			} else if strings.HasSuffix(filePath, ".js") || strings.HasSuffix(filePath, ".ts") {
@@ -548,7 +581,9 @@ func (esd *EnhancedServiceDiscovery) mergeCandidates(candidates []ServiceCandida
 				merged = candidate
 			}
 			merged.Evidence = append(merged.Evidence, candidate.Evidence...)
-			merged.DetectionType += "," + candidate.DetectionType
+			if !strings.Contains(","+merged.DetectionType+",", ","+candidate.DetectionType+",") {
+				merged.DetectionType += "," + candidate.DetectionType
+			}
 			candidateMap[key] = merged
 		} else {
 			candidateMap[key] = candidate
@@ -0,0 +1,564 @@
+package microservices
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"repo-explanation/internal/portable"
+)
+
+// DeclaredProject is a project boundary declared by a monorepo build tool: an nx project, a
+// Turborepo workspace package, or a Bazel or Pants package holding build targets
+type DeclaredProject struct {
+	Name        string   `json:"name"`
+	Path        string   `json:"path"` // Directory of the project, "." for the repository root
+	Tool        string   `json:"tool"` // "nx", "turborepo", "bazel" or "pants"
+	Application bool     `json:"application"`
+	EntryPoint  string   `json:"entry_point"`
+	Language    string   `json:"language"`
+	Evidence    []string `json:"evidence"`
+}
+
+// Rules of Bazel and Pants that build something runnable, with the language they build
+var binaryRules = map[string]string{
+	"go_binary": "Go", "go_image": "Go",
+	"java_binary": "Java", "java_image": "Java", "kt_jvm_binary": "Kotlin", "scala_binary": "Scala",
+	"py_binary": "Python", "py_image": "Python", "py3_image": "Python", "pex_binary": "Python",
+	"python_awslambda": "Python", "python_aws_lambda_function": "Python", "python_google_cloud_function": "Python",
+	"nodejs_binary": "Node.js", "nodejs_image": "Node.js", "js_binary": "Node.js",
+	"cc_binary": "C++", "rust_binary": "Rust",
+	"container_image": "", "oci_image": "", "docker_image": "",
+}
+
+// Rules of Bazel and Pants that declare a library or plain sources
+var libraryRuleRegex = regexp.MustCompile(`^(?:\w+_library|\w+_sources|go_package|go_mod|\w+_proto_library|proto_library|filegroup)$`)
+
+var (
+	buildRuleRegex    = regexp.MustCompile(`(?m)^(\w+)\(`)
+	buildNameRegex    = regexp.MustCompile(`\bname\s*=\s*["']([^"']+)["']`)
+	buildMainRegex    = regexp.MustCompile(`\b(?:main|entry_point)\s*=\s*["']([^"':]+)`)
+	buildSrcsRegex    = regexp.MustCompile(`\bsrcs\s*=\s*\[\s*["']([^"']+)["']`)
+	jsonCommentRegex  = regexp.MustCompile(`(?m)^\s*//.*$`)
+	nxServeExecutors  = []string{":serve", ":server", ":node", "dev-server"}
+	appScriptNames    = []string{"start", "dev", "serve"}
+	buildEntryPoints  = []string{"main.go", "main.py", "__main__.py", "app.py", "index.js", "server.js", "main.rs", "src/main.rs"}
+	monorepoToolOrder = map[string]int{"nx": 0, "turborepo": 1, "bazel": 2, "pants": 3}
+)
+
+// IsMonorepoManifest reports whether a file declares projects for nx, Turborepo, Bazel or Pants
+func IsMonorepoManifest(relativePath string) bool {
+	switch path.Base(portable.Slash(relativePath)) {
+	case "nx.json", "project.json", "workspace.json", "turbo.json", "BUILD", "BUILD.bazel", "BUILD.pants":
+		return true
+	}
+	return false
+}
+
+// DeclaredProjects reads the projects that nx (project.json, workspace.json), Turborepo (workspace
+// package.json files next to a turbo.json), Bazel (BUILD.bazel, or BUILD next to WORKSPACE or
+// MODULE.bazel) and Pants (BUILD next to pants.toml) declare, sorted by tool and path
+func DeclaredProjects(files map[string]string) []DeclaredProject {
+	paths := make(map[string]string, len(files))
+	for filePath := range files {
+		paths[portable.Slash(filePath)] = filePath
+	}
+	has := func(name string) bool {
+		_, ok := paths[name]
+		return ok
+	}
+
+	var projects []DeclaredProject
+	if has("nx.json") {
+		projects = append(projects, nxProjects(files, paths)...)
+		// Package-based nx workspaces declare projects through their package.json files
+		projects = append(projects, packageProjects("nx", files, paths, nil, projects)...)
+	}
+	if has("turbo.json") {
+		projects = append(projects, packageProjects("turborepo", files, paths, turboTasks(files[paths["turbo.json"]]), projects)...)
+	}
+	pants := has("pants.toml")
+	bazel := has("WORKSPACE") || has("WORKSPACE.bazel") || has("MODULE.bazel")
+	projects = append(projects, buildFileProjects(files, paths, bazel, pants)...)
+
+	sort.Slice(projects, func(i, j int) bool {
+		if projects[i].Tool != projects[j].Tool {
+			return monorepoToolOrder[projects[i].Tool] < monorepoToolOrder[projects[j].Tool]
+		}
+		return projects[i].Path < projects[j].Path
+	})
+	return projects
+}
+
+// nxTarget is one target of an nx project
+type nxTarget struct {
+	Executor string `json:"executor"`
+	Options  struct {
+		Main string `json:"main"`
+	} `json:"options"`
+}
+
+// nxProject is the part of a project.json, or of a workspace.json entry, that discovery reads
+type nxProject struct {
+	Name        string              `json:"name"`
+	Root        string              `json:"root"`
+	ProjectType string              `json:"projectType"`
+	Targets     map[string]nxTarget `json:"targets"`
+	Architect   map[string]nxTarget `json:"architect"` // Angular-style workspace.json
+}
+
+// nxProjects reads every project.json, plus the projects listed in a workspace.json
+func nxProjects(files map[string]string, paths map[string]string) []DeclaredProject {
+	var projects []DeclaredProject
+	seen := make(map[string]bool)
+	for _, slashed := range sortedPaths(paths) {
+		if path.Base(slashed) != "project.json" {
+			continue
+		}
+		var project nxProject
+		if err := json.Unmarshal(stripJSONComments(files[paths[slashed]]), &project); err != nil {
+			continue
+		}
+		root := path.Dir(slashed)
+		seen[root] = true
+		projects = append(projects, nxDeclaredProject(project, root, slashed, paths))
+	}
+
+	if workspace, ok := paths["workspace.json"]; ok {
+		var declared struct {
+			Projects map[string]json.RawMessage `json:"projects"`
+		}
+		json.Unmarshal(stripJSONComments(files[workspace]), &declared)
+		for _, name := range sortedRawKeys(declared.Projects) {
+			var project nxProject
+			// Entries are either the project's directory or its inline configuration
+			if json.Unmarshal(declared.Projects[name], &project.Root) != nil {
+				json.Unmarshal(declared.Projects[name], &project)
+			}
+			root := path.Clean(portable.Slash(project.Root))
+			if project.Root == "" || seen[root] {
+				continue
+			}
+			seen[root] = true
+			if project.Name == "" {
+				project.Name = name
+			}
+			projects = append(projects, nxDeclaredProject(project, root, "workspace.json", paths))
+		}
+	}
+	return projects
+}
+
+// nxDeclaredProject classifies an nx project by its projectType, or else by whether a target serves it
+func nxDeclaredProject(project nxProject, root, manifest string, paths map[string]string) DeclaredProject {
+	targets := project.Targets
+	if len(targets) == 0 {
+		targets = project.Architect
+	}
+	declared := DeclaredProject{
+		Name:       project.Name,
+		Path:       root,
+		Tool:       "nx",
+		EntryPoint: manifest,
+		Language:   "Node.js",
+	}
+	if declared.Name == "" {
+		declared.Name = projectNameFromPath(root)
+	}
+
+	var names []string
+	for name := range targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		target := targets[name]
+		executor := strings.ToLower(target.Executor)
+		switch {
+		case strings.HasPrefix(executor, "@nx-go/"):
+			declared.Language = "Go"
+		case strings.HasPrefix(executor, "@nxlv/python"):
+			declared.Language = "Python"
+		}
+		if main := path.Clean(portable.Slash(target.Options.Main)); target.Options.Main != "" {
+			if filePath, ok := paths[main]; ok && (name == "build" || declared.EntryPoint == manifest) {
+				declared.EntryPoint = filePath
+			}
+		}
+		if project.ProjectType == "" && (containsString(appScriptNames, name) || containsAny(executor, nxServeExecutors)) {
+			declared.Application = true
+		}
+	}
+	if project.ProjectType != "" {
+		declared.Application = project.ProjectType == "application"
+	}
+
+	kind := "library"
+	if declared.Application {
+		kind = "application"
+	}
+	declared.Evidence = []string{fmt.Sprintf("nx %s %q declared in %s", kind, declared.Name, manifest)}
+	if len(names) > 0 {
+		declared.Evidence = append(declared.Evidence, fmt.Sprintf("nx targets: %s", strings.Join(names, ", ")))
+	}
+	return declared
+}
+
+// turboTasks reads the task names of a turbo.json, each mapped to whether it is persistent
+func turboTasks(content string) map[string]bool {
+	var turbo struct {
+		Pipeline map[string]struct {
+			Persistent bool `json:"persistent"`
+		} `json:"pipeline"` // Turborepo 1.x
+		Tasks map[string]struct {
+			Persistent bool `json:"persistent"`
+		} `json:"tasks"` // Turborepo 2.x
+	}
+	json.Unmarshal(stripJSONComments(content), &turbo)
+
+	tasks := make(map[string]bool)
+	for name, task := range turbo.Pipeline {
+		tasks[turboTaskName(name)] = tasks[turboTaskName(name)] || task.Persistent
+	}
+	for name, task := range turbo.Tasks {
+		tasks[turboTaskName(name)] = tasks[turboTaskName(name)] || task.Persistent
+	}
+	return tasks
+}
+
+// packageProjects reads the workspace packages of an nx or Turborepo workspace. A package is an
+// application when it has a start, dev or serve script, or a script for a persistent task.
+// Packages already declared are skipped.
+func packageProjects(tool string, files map[string]string, paths map[string]string, tasks map[string]bool, declared []DeclaredProject) []DeclaredProject {
+	taken := make(map[string]bool)
+	for _, project := range declared {
+		taken[project.Path] = true
+	}
+
+	var projects []DeclaredProject
+	for _, slashed := range sortedPaths(paths) {
+		dir := path.Dir(slashed)
+		if path.Base(slashed) != "package.json" || dir == "." || taken[dir] || portable.HasSegment(slashed, "node_modules") {
+			continue
+		}
+		var manifest struct {
+			Name    string            `json:"name"`
+			Main    string            `json:"main"`
+			Scripts map[string]string `json:"scripts"`
+		}
+		if json.Unmarshal([]byte(files[paths[slashed]]), &manifest) != nil {
+			continue
+		}
+
+		project := DeclaredProject{
+			Name:       projectNameFromPackage(manifest.Name, dir),
+			Path:       dir,
+			Tool:       tool,
+			EntryPoint: paths[slashed],
+			Language:   "Node.js",
+		}
+		var scripts, turboScripts []string // turboScripts run a turbo.json task
+		for script := range manifest.Scripts {
+			scripts = append(scripts, script)
+		}
+		sort.Strings(scripts)
+		for _, script := range scripts {
+			persistent, isTask := tasks[script]
+			if isTask {
+				turboScripts = append(turboScripts, script)
+			}
+			if containsString(appScriptNames, script) || persistent {
+				project.Application = true
+			}
+		}
+
+		kind := "library"
+		if project.Application {
+			kind = "application"
+		}
+		if tool == "nx" {
+			project.Evidence = []string{fmt.Sprintf("nx %s %q in %s", kind, project.Name, slashed)}
+		} else {
+			project.Evidence = []string{fmt.Sprintf("Turborepo %s %q in %s", kind, project.Name, slashed)}
+		}
+		if len(turboScripts) > 0 {
+			project.Evidence = append(project.Evidence, fmt.Sprintf("turbo.json tasks: %s", strings.Join(turboScripts, ", ")))
+		}
+		projects = append(projects, project)
+	}
+	return projects
+}
+
+// turboTaskName drops the package of a "web#build" task
+func turboTaskName(name string) string {
+	if i := strings.LastIndex(name, "#"); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// buildFileProjects reads Bazel and Pants BUILD files. Each package holding a binary or image
+// target is an application; one holding only libraries or sources is a library.
+func buildFileProjects(files map[string]string, paths map[string]string, bazel, pants bool) []DeclaredProject {
+	var projects []DeclaredProject
+	for _, slashed := range sortedPaths(paths) {
+		tool := ""
+		switch path.Base(slashed) {
+		case "BUILD.bazel":
+			tool = "bazel"
+		case "BUILD.pants":
+			tool = "pants"
+		case "BUILD":
+			if pants {
+				tool = "pants"
+			} else if bazel {
+				tool = "bazel"
+			}
+		}
+		if tool == "" {
+			continue
+		}
+
+		dir := path.Dir(slashed)
+		project := DeclaredProject{Path: dir, Tool: tool, EntryPoint: paths[slashed]}
+		var binaries, libraries []string
+		for _, rule := range buildRules(files[paths[slashed]]) {
+			language, isBinary := binaryRules[rule.kind]
+			switch {
+			case isBinary:
+				binaries = append(binaries, fmt.Sprintf("%s(%s)", rule.kind, rule.name))
+				if project.Language == "" {
+					project.Language = language
+				}
+				if project.Name == "" && dir == "." {
+					project.Name = rule.name
+				}
+				if rule.main != "" && project.EntryPoint == paths[slashed] {
+					if filePath, ok := paths[path.Join(dir, rule.main)]; ok {
+						project.EntryPoint = filePath
+					}
+				}
+			case libraryRuleRegex.MatchString(rule.kind):
+				libraries = append(libraries, fmt.Sprintf("%s(%s)", rule.kind, rule.name))
+			}
+		}
+		if len(binaries) == 0 && len(libraries) == 0 {
+			continue
+		}
+
+		project.Application = len(binaries) > 0
+		if project.Application && project.EntryPoint == paths[slashed] {
+			// Sources given by glob() name no file, so fall back to a conventional entry point
+			for _, name := range buildEntryPoints {
+				if filePath, ok := paths[path.Join(dir, name)]; ok {
+					project.EntryPoint = filePath
+					break
+				}
+			}
+		}
+		if project.Name == "" {
+			project.Name = projectNameFromPath(dir)
+		}
+		if project.Language == "" {
+			project.Language = "Unknown"
+		}
+		label := "//" + strings.TrimPrefix(dir, ".")
+		if project.Application {
+			project.Evidence = []string{fmt.Sprintf("%s package %s builds %s", bazelOrPants(tool), label, strings.Join(binaries, ", "))}
+		} else {
+			project.Evidence = []string{fmt.Sprintf("%s package %s declares only %s", bazelOrPants(tool), label, strings.Join(libraries, ", "))}
+		}
+		projects = append(projects, project)
+	}
+	return projects
+}
+
+func bazelOrPants(tool string) string {
+	if tool == "pants" {
+		return "Pants"
+	}
+	return "Bazel"
+}
+
+// buildRule is one target declared in a BUILD file
+type buildRule struct {
+	kind string
+	name string
+	main string // main, entry_point or first source file
+}
+
+// buildRules reads the top-level rule calls of a BUILD file, skipping load() statements
+func buildRules(content string) []buildRule {
+	content = portable.Text(content)
+	var rules []buildRule
+	for _, match := range buildRuleRegex.FindAllStringSubmatchIndex(content, -1) {
+		kind := content[match[2]:match[3]]
+		if kind == "load" || kind == "package" {
+			continue
+		}
+		body := callBody(content[match[1]:])
+		rule := buildRule{kind: kind}
+		if name := buildNameRegex.FindStringSubmatch(body); name != nil {
+			rule.name = name[1]
+		}
+		if main := buildMainRegex.FindStringSubmatch(body); main != nil {
+			rule.main = main[1]
+		} else if srcs := buildSrcsRegex.FindStringSubmatch(body); srcs != nil {
+			rule.main = srcs[1]
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// callBody returns the arguments of a call up to its closing parenthesis, skipping parentheses
+// inside strings and comments
+func callBody(content string) string {
+	depth := 1
+	var quote byte
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			for i < len(content) && content[i] != '\n' {
+				i++
+			}
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return content[:i]
+			}
+		}
+	}
+	return content
+}
+
+// applyDeclaredBoundaries makes declared projects authoritative over heuristic candidates: a
+// candidate inside a declared application is renamed to it so the two merge, and one inside a
+// declared library is dropped. Candidates outside every declared project are kept.
+func applyDeclaredBoundaries(candidates []ServiceCandidate, projects []DeclaredProject) []ServiceCandidate {
+	if len(projects) == 0 {
+		return candidates
+	}
+	kept := candidates[:0]
+	for _, candidate := range candidates {
+		if _, declared := monorepoToolOrder[candidate.DetectionType]; declared {
+			kept = append(kept, candidate)
+			continue
+		}
+		var owner *DeclaredProject
+		for i := range projects {
+			project := &projects[i]
+			// A root project would own everything, which says nothing about the layout
+			if project.Path == "." || !portable.Within(candidate.Path, project.Path) {
+				continue
+			}
+			if owner == nil || len(project.Path) > len(owner.Path) {
+				owner = project
+			}
+		}
+		switch {
+		case owner == nil:
+			kept = append(kept, candidate)
+		case owner.Application:
+			candidate.Name = owner.Name
+			candidate.Path = owner.Path
+			kept = append(kept, candidate)
+		}
+	}
+	return kept
+}
+
+// discoverFromMonorepoTools turns declared applications into candidates, which outrank every
+// heuristic since the build tool defines them
+func (esd *EnhancedServiceDiscovery) discoverFromMonorepoTools(projects []DeclaredProject) []ServiceCandidate {
+	var candidates []ServiceCandidate
+	for _, project := range projects {
+		if !project.Application {
+			continue
+		}
+		candidates = append(candidates, ServiceCandidate{
+			Name:          project.Name,
+			Path:          project.Path,
+			EntryPoint:    project.EntryPoint,
+			DetectionType: project.Tool,
+			Confidence:    0.95,
+			Evidence:      project.Evidence,
+			Language:      project.Language,
+			APIType:       HTTPService,
+		})
+	}
+	return candidates
+}
+
+// projectNameFromPackage strips the scope of an npm package name, falling back to the directory
+func projectNameFromPackage(name, dir string) string {
+	if name == "" {
+		return projectNameFromPath(dir)
+	}
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+func projectNameFromPath(dir string) string {
+	if dir == "." || dir == "" {
+		return "root"
+	}
+	return path.Base(dir)
+}
+
+// stripJSONComments removes whole-line // comments, which nx and Turborepo accept
+func stripJSONComments(content string) []byte {
+	return []byte(jsonCommentRegex.ReplaceAllString(content, ""))
+}
+
+func sortedPaths(paths map[string]string) []string {
+	keys := make([]string, 0, len(paths))
+	for key := range paths {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedRawKeys(values map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAny(text string, needles []string) bool {
+	for _, needle := range needles {
+		if strings.Contains(text, needle) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,495 @@
+package microservices
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"repo-explanation/internal/portable"
+)
+
+// StartupStep is one thing a service does while starting, in the order its entry point does it
+type StartupStep struct {
+	Kind   string `json:"kind"`   // config, logging, database, migrations, cache, queue, router, app, server or shutdown
+	Detail string `json:"detail"` // The source line
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Via    string `json:"via,omitempty"` // Function the entry point calls that holds this step, if not the entry point itself
+}
+
+// SourceReader reads repository files by relative path, such as a filestore.FileStore
+type SourceReader interface {
+	Paths() []string
+	Has(relPath string) bool
+	Read(relPath string) (string, error)
+}
+
+const (
+	maxStartupSteps    = 15 // Per service
+	maxStartupFollowed = 8  // Functions followed out of the entry point, per service
+	maxStartupDetail   = 100
+)
+
+// startupKinds classify a source line, checked in order; the first match wins
+var startupKinds = []struct {
+	kind    string
+	pattern *regexp.Regexp
+}{
+	{"config", regexp.MustCompile(`(?i)(?:\b(?:godotenv|dotenv)\.(?:Load|config)\s*\(|require\(\s*['"]dotenv['"]\s*\)|\bload_dotenv\s*\(|\bviper\.(?:ReadInConfig|AutomaticEnv|SetConfigFile)\s*\(|\benvconfig\.Process\s*\(|\bflag\.Parse\s*\(|\b(?:config|cfg|conf|configs|settings)\.(?:Load|MustLoad|Read|Parse|New|Init|FromEnv)\w*\s*\(|\b(?:load|read|parse|init|new|get|setup)_?(?:config|configuration|settings)\w*\s*\(|\b(?:load|init|setup)_?env\w*\s*\()`)},
+	{"logging", regexp.MustCompile(`(?i)(?:\b(?:zap\.New\w*|logrus\.New|slog\.New|zerolog\.New|log\.SetOutput|log\.SetFlags|winston\.createLogger|pino|logging\.basicConfig|logging\.config\.\w+|(?:new|init|setup|create)_?logger)\s*\()`)},
+	{"migrations", regexp.MustCompile(`(?i)(?:\b(?:migrate\.\w+|goose\.Up\w*|AutoMigrate|run_?migrations?|alembic\.command\.upgrade)\s*\(|\.(?:AutoMigrate|Migrate|migrate)\s*\()`)},
+	{"database", regexp.MustCompile(`(?i)(?:\b(?:sql\.Open|sqlx\.(?:Open|Connect)|gorm\.Open|pgxpool\.(?:New|Connect)\w*|pgx\.Connect|mongo\.(?:Connect|NewClient)|ent\.Open|mongoose\.(?:connect|createConnection)|new\s+PrismaClient|new\s+Sequelize|new\s+DataSource|createConnection|createPool|knex|create_engine|SQLAlchemy|db\.init_app|psycopg2?\.connect|MongoClient|(?:connect|open|init|new|setup)_?(?:db|database|postgres|mysql|mongo|store|repository|repositories)\w*)\s*\()`)},
+	{"cache", regexp.MustCompile(`(?i)(?:\b(?:redis\.(?:NewClient|NewClusterClient|createClient|Redis|from_url)|new\s+Redis|memcache\.New|(?:connect|init|new|setup)_?(?:redis|cache)\w*)\s*\()`)},
+	{"queue", regexp.MustCompile(`(?i)(?:\b(?:kafka\.New\w*|sarama\.New\w*|amqp(?:lib)?\.(?:Dial|connect)\w*|nats\.Connect|sqs\.New\w*|pubsub\.NewClient|new\s+Kafka|Celery|(?:start|run|new|init|setup)_?(?:consumers?|workers?|subscribers?|queues?)\w*)\s*\(|\.(?:consumer|Consume|Subscribe)\s*\()`)},
+	{"router", regexp.MustCompile(`(?i)(?:\b(?:gin\.(?:New|Default)|echo\.New|chi\.NewRouter|mux\.NewRouter|http\.NewServeMux|httprouter\.New|fiber\.New|express(?:\.Router)?|new\s+Hono|fastify|Flask|FastAPI|APIRouter|grpc\.NewServer|NestFactory\.create|include_router|(?:register|setup|init|new|add|mount)_?\w*routes?)\s*\()`)},
+	{"app", regexp.MustCompile(`(?i)(?:\b(?:fx\.New|wire\.Build|(?:new|build|create|init)_?(?:app|application|server|container)|(?:app|application|server)\.New\w*)\s*\()`)},
+	{"server", regexp.MustCompile(`(?i)(?:\b(?:ListenAndServe\w*|uvicorn\.run|app\.run|web\.run_app|start_?server|run_?server)\s*\(|\.(?:Listen|Serve|Start|Run)\s*\()`)},
+	{"shutdown", regexp.MustCompile(`(?i)(?:\bsignal\.Notify(?:Context)?\s*\(|\bprocess\.on\(\s*['"]SIG\w+|\.(?:Shutdown|GracefulStop)\s*\(|\batexit\.register\s*\()`)},
+}
+
+// startupEntryPoints are tried, relative to the service directory, when its entry point is not a source file
+var startupEntryPoints = []string{
+	"main.go", "cmd/main.go", "cmd/server/main.go", "cmd/api/main.go",
+	"src/main.ts", "src/index.ts", "src/server.ts", "src/app.ts", "index.ts", "server.ts", "main.ts",
+	"src/index.js", "src/server.js", "index.js", "server.js", "app.js", "main.js",
+	"main.py", "app.py", "app/main.py", "src/main.py", "__main__.py", "manage.py", "wsgi.py",
+}
+
+var (
+	goFuncMainRegex    = regexp.MustCompile(`(?m)^func main\(\)\s*\{`)
+	goModuleLineRegex  = regexp.MustCompile(`(?m)^module\s+(\S+)`)
+	goImportSpecRegex  = regexp.MustCompile(`(?m)^\s*(?:import\s+)?(?:([A-Za-z_]\w*)\s+)?"([^"]+)"\s*$`)
+	goLocalCallRegex   = regexp.MustCompile(`(?:^|[^.\w])([A-Za-z_]\w*)\(`)
+	goPackageCallRegex = regexp.MustCompile(`\b([a-z]\w*)\.([A-Z]\w*)\(`)
+	jsImportBindRegex  = regexp.MustCompile(`(?:import\s+(?:(\w+)\s*,?\s*)?(?:\{([^}]*)\})?\s*from|(?:const|let|var)\s+(?:(\w+)|\{([^}]*)\})\s*=\s*require\()\s*\(?\s*['"](\.{1,2}/[^'"]+)['"]`)
+	jsStartScriptRegex = regexp.MustCompile(`(?:node|ts-node|tsx|nodemon|bun)\s+(?:-\S+\s+)*(\S+\.(?:js|mjs|cjs|ts))`)
+	pyImportRegex      = regexp.MustCompile(`(?m)^[ \t]*from[ \t]+(\.*[\w.]*)[ \t]+import[ \t]+(?:\(([^)]*)\)|([\w \t,]+))`)
+	identRegex         = regexp.MustCompile(`[A-Za-z_]\w*`)
+	commentLineRegex   = regexp.MustCompile(`^\s*(?://|#|/\*|\*)`)
+	importLineRegex    = regexp.MustCompile(`^\s*(?:import\b|from\s+\S+\s+import\b|(?:const|let|var)\s+.*=\s*require\()`)
+)
+
+// goKeywords are never followed as calls
+var goKeywords = map[string]bool{
+	"if": true, "for": true, "switch": true, "func": true, "return": true, "go": true, "defer": true, "select": true,
+	"make": true, "len": true, "cap": true, "append": true, "panic": true, "new": true, "close": true, "delete": true,
+	"copy": true, "print": true, "println": true, "recover": true, "string": true, "int": true, "error": true, "main": true,
+}
+
+// TraceStartup follows each service's entry point through its first layer of initialization
+// (its own lines and the repository functions it calls) and records the steps that load config,
+// connect to databases, caches and queues, set up routers and start serving
+func TraceStartup(services []DiscoveredService, source SourceReader) {
+	for i := range services {
+		tracer := &startupTracer{source: source, followed: make(map[string]bool)}
+		entry := tracer.entryPoint(services[i])
+		if entry == "" {
+			continue
+		}
+		tracer.traceEntry(entry)
+		services[i].Startup = tracer.steps
+	}
+}
+
+type startupTracer struct {
+	source   SourceReader
+	steps    []StartupStep
+	followed map[string]bool // Files and functions already followed
+}
+
+// entryPoint returns the source file a service starts from, resolving package.json scripts and
+// conventional names when the discovered entry point is a manifest or directory
+func (t *startupTracer) entryPoint(service DiscoveredService) string {
+	entry := portable.Slash(service.EntryPoint)
+	if startupLanguage(entry) != "" && t.source.Has(entry) {
+		return entry
+	}
+	dir := portable.Slash(service.Path)
+	if path.Base(entry) == "package.json" {
+		dir = path.Dir(entry)
+		if content, err := t.source.Read(entry); err == nil {
+			var manifest struct {
+				Main    string            `json:"main"`
+				Scripts map[string]string `json:"scripts"`
+			}
+			json.Unmarshal([]byte(content), &manifest)
+			candidates := []string{manifest.Main}
+			for _, script := range []string{"start", "dev", "serve"} {
+				if match := jsStartScriptRegex.FindStringSubmatch(manifest.Scripts[script]); match != nil {
+					candidates = append(candidates, match[1])
+				}
+			}
+			for _, candidate := range candidates {
+				if candidate == "" {
+					continue
+				}
+				if resolved := t.resolveJS(dir, "./"+strings.TrimPrefix(candidate, "./")); resolved != "" {
+					return resolved
+				}
+				// Built output usually mirrors the TypeScript sources
+				for _, built := range []string{"dist/", "build/", "lib/"} {
+					if strings.HasPrefix(candidate, built) {
+						source := "./src/" + strings.TrimSuffix(strings.TrimPrefix(candidate, built), path.Ext(candidate))
+						if resolved := t.resolveJS(dir, source); resolved != "" {
+							return resolved
+						}
+					}
+				}
+			}
+		}
+	}
+	if dir == "" || strings.HasPrefix(dir, "service-") {
+		return ""
+	}
+	for _, name := range startupEntryPoints {
+		if candidate := path.Join(dir, name); t.source.Has(candidate) {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// traceEntry scans the entry point: main() for Go, the whole module for JavaScript, TypeScript and Python
+func (t *startupTracer) traceEntry(entry string) {
+	content, err := t.source.Read(entry)
+	if err != nil {
+		return
+	}
+	content = portable.Text(content)
+	t.followed[entry] = true
+	if startupLanguage(entry) == "go" {
+		match := goFuncMainRegex.FindStringIndex(content)
+		if match == nil {
+			return
+		}
+		body := braceBlock(content, match[1]-1)
+		t.scan(entry, content, body, lineAt(content, match[1]-1), "", true)
+		return
+	}
+	t.scan(entry, content, content, 1, "", true)
+}
+
+// scan classifies each line of body, which starts at firstLine of file. When follow is set, the
+// repository function each line calls is scanned too.
+func (t *startupTracer) scan(file, content, body string, firstLine int, via string, follow bool) {
+	for offset, line := range strings.Split(body, "\n") {
+		if len(t.steps) >= maxStartupSteps {
+			return
+		}
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || commentLineRegex.MatchString(trimmed) {
+			continue
+		}
+		if kind := classifyStartupLine(trimmed); kind != "" {
+			detail := trimmed
+			if len(detail) > maxStartupDetail {
+				detail = detail[:maxStartupDetail] + "…"
+			}
+			t.steps = append(t.steps, StartupStep{Kind: kind, Detail: detail, File: file, Line: firstLine + offset, Via: via})
+		}
+		// Steps of a called function follow the call, so connectDB() is followed by the line that connects
+		if follow && len(t.followed) <= maxStartupFollowed && !importLineRegex.MatchString(trimmed) {
+			t.follow(file, content, trimmed)
+		}
+	}
+}
+
+// follow scans the body of the first repository function a line calls
+func (t *startupTracer) follow(file, content, line string) {
+	switch startupLanguage(file) {
+	case "go":
+		t.followGo(file, content, line)
+	case "js":
+		t.followImported(file, line, jsImports(content, path.Dir(file), t.resolveJS))
+	case "py":
+		t.followImported(file, line, t.pyImports(content, file))
+	}
+}
+
+func (t *startupTracer) followGo(file, content, line string) {
+	dir := path.Dir(file)
+	for _, call := range goPackageCallRegex.FindAllStringSubmatch(line, -1) {
+		if pkgDir := t.goImportDir(file, content, call[1]); pkgDir != "" {
+			if t.followGoFunc(pkgDir, call[2], call[1]+"."+call[2]) {
+				return
+			}
+		}
+	}
+	for _, call := range goLocalCallRegex.FindAllStringSubmatch(line, -1) {
+		if !goKeywords[call[1]] && t.followGoFunc(dir, call[1], call[1]) {
+			return
+		}
+	}
+}
+
+// followGoFunc scans func name of the package in dir, reporting whether it was found
+func (t *startupTracer) followGoFunc(dir, name, label string) bool {
+	key := dir + "#" + name
+	if t.followed[key] {
+		return false
+	}
+	definition := regexp.MustCompile(`(?m)^func ` + regexp.QuoteMeta(name) + `\([^)]*\)[^{\n]*\{`)
+	for _, candidate := range t.source.Paths() {
+		if path.Dir(candidate) != dir || !strings.HasSuffix(candidate, ".go") || strings.HasSuffix(candidate, "_test.go") {
+			continue
+		}
+		content, err := t.source.Read(candidate)
+		if err != nil {
+			continue
+		}
+		content = portable.Text(content)
+		match := definition.FindStringIndex(content)
+		if match == nil {
+			continue
+		}
+		t.followed[key] = true
+		t.scan(candidate, content, braceBlock(content, match[1]-1), lineAt(content, match[1]-1), label, false)
+		return true
+	}
+	return false
+}
+
+// goImportDir returns the repository directory of the package a Go file imports under alias, or
+// "" when it is not a package of the file's own module
+func (t *startupTracer) goImportDir(file, content, alias string) string {
+	modDir, module := t.goModule(path.Dir(file))
+	if module == "" {
+		return ""
+	}
+	for _, spec := range goImportSpecRegex.FindAllStringSubmatch(content, -1) {
+		importPath := spec[2]
+		name := spec[1]
+		if name == "" {
+			name = path.Base(importPath)
+		}
+		if name != alias || (importPath != module && !strings.HasPrefix(importPath, module+"/")) {
+			continue
+		}
+		return path.Join(modDir, strings.TrimPrefix(importPath, module))
+	}
+	return ""
+}
+
+// goModule finds the go.mod governing dir and returns its directory and module path
+func (t *startupTracer) goModule(dir string) (string, string) {
+	for {
+		if content, err := t.source.Read(path.Join(dir, "go.mod")); err == nil {
+			if match := goModuleLineRegex.FindStringSubmatch(content); match != nil {
+				return dir, match[1]
+			}
+			return "", ""
+		}
+		if dir == "." || dir == "/" || dir == "" {
+			return "", ""
+		}
+		dir = path.Dir(dir)
+	}
+}
+
+// followImported scans the function or module a line uses through a local import. A binding
+// that names a function defined in its module scans that function; otherwise, as for
+// `import app from './app'`, the whole module is scanned, since importing it runs it.
+func (t *startupTracer) followImported(file, line string, imports map[string]string) {
+	for _, ident := range identRegex.FindAllString(line, -1) {
+		target, ok := imports[ident]
+		if !ok || t.followed[target+"#"+ident] {
+			continue
+		}
+		content, err := t.source.Read(target)
+		if err != nil {
+			continue
+		}
+		content = portable.Text(content)
+		t.followed[target+"#"+ident] = true
+		if body, first, found := functionBody(content, ident, startupLanguage(target)); found {
+			t.scan(target, content, body, first, ident, false)
+		} else if !t.followed[target] {
+			t.followed[target] = true
+			t.scan(target, content, content, 1, ident, false)
+		}
+		return
+	}
+}
+
+// jsImports maps the names a JavaScript or TypeScript module imports from local files to those files
+func jsImports(content, dir string, resolve func(dir, specifier string) string) map[string]string {
+	imports := make(map[string]string)
+	for _, match := range jsImportBindRegex.FindAllStringSubmatch(content, -1) {
+		target := resolve(dir, match[5])
+		if target == "" {
+			continue
+		}
+		for _, names := range []string{match[1], match[2], match[3], match[4]} {
+			for _, name := range strings.Split(names, ",") {
+				name = strings.TrimSpace(name)
+				// { a as b } and { a: b } bind b
+				if fields := strings.FieldsFunc(name, func(r rune) bool { return r == ' ' || r == ':' }); len(fields) > 0 {
+					imports[fields[len(fields)-1]] = target
+				}
+			}
+		}
+	}
+	return imports
+}
+
+// resolveJS resolves a relative import specifier to a file, trying the usual extensions and index files
+func (t *startupTracer) resolveJS(dir, specifier string) string {
+	base := path.Join(dir, specifier)
+	for _, suffix := range []string{"", ".ts", ".tsx", ".js", ".mjs", ".cjs", "/index.ts", "/index.js"} {
+		candidate := base + suffix
+		if startupLanguage(candidate) != "" && t.source.Has(candidate) {
+			return candidate
+		}
+	}
+	// ESM TypeScript imports name the compiled .js file
+	if strings.HasSuffix(base, ".js") && t.source.Has(strings.TrimSuffix(base, ".js")+".ts") {
+		return strings.TrimSuffix(base, ".js") + ".ts"
+	}
+	return ""
+}
+
+// pyImports maps the names a Python module imports from repository modules to their files
+func (t *startupTracer) pyImports(content, file string) map[string]string {
+	imports := make(map[string]string)
+	for _, match := range pyImportRegex.FindAllStringSubmatch(content, -1) {
+		module := match[1]
+		var bases []string
+		if strings.HasPrefix(module, ".") {
+			dir := path.Dir(file)
+			dots := len(module) - len(strings.TrimLeft(module, "."))
+			for i := 1; i < dots; i++ {
+				dir = path.Dir(dir)
+			}
+			bases = []string{path.Join(dir, strings.ReplaceAll(strings.TrimLeft(module, "."), ".", "/"))}
+		} else {
+			relative := strings.ReplaceAll(module, ".", "/")
+			bases = []string{path.Join(path.Dir(file), relative), relative}
+		}
+		for _, name := range strings.Split(match[2]+match[3], ",") {
+			fields := strings.Fields(name)
+			if len(fields) == 0 {
+				continue
+			}
+			binding := fields[len(fields)-1] // import a as b binds b
+			for _, base := range bases {
+				// The name is a submodule or something defined in the module
+				if candidate := path.Join(base, fields[0]) + ".py"; t.source.Has(candidate) {
+					imports[binding] = candidate
+					break
+				}
+				if t.source.Has(base + ".py") {
+					imports[binding] = base + ".py"
+					break
+				}
+				if t.source.Has(base + "/__init__.py") {
+					imports[binding] = base + "/__init__.py"
+					break
+				}
+			}
+		}
+	}
+	return imports
+}
+
+// functionBody finds the definition of a function in a JavaScript, TypeScript or Python module
+// and returns its body and first line
+func functionBody(content, name, language string) (string, int, bool) {
+	quoted := regexp.QuoteMeta(name)
+	if language == "py" {
+		definition := regexp.MustCompile(`(?m)^([ \t]*)(?:async\s+)?def\s+` + quoted + `\s*\(`)
+		match := definition.FindStringSubmatchIndex(content)
+		if match == nil {
+			return "", 0, false
+		}
+		indent := match[3] - match[2]
+		lines := strings.Split(content[match[0]:], "\n")
+		end := 1
+		for end < len(lines) {
+			line := lines[end]
+			if strings.TrimSpace(line) != "" && len(line)-len(strings.TrimLeft(line, " \t")) <= indent {
+				break
+			}
+			end++
+		}
+		return strings.Join(lines[1:end], "\n"), lineAt(content, match[0]) + 1, true
+	}
+
+	definition := regexp.MustCompile(`(?m)(?:function\s*\*?\s*` + quoted + `\s*\([^)]*\)|\b` + quoted + `\s*=\s*(?:async\s*)?(?:function\s*)?\([^)]*\)\s*(?::\s*[^={]+)?(?:=>)?|^\s*(?:async\s+)?` + quoted + `\s*\([^)]*\))\s*(?::\s*[^{]+)?\{`)
+	match := definition.FindStringIndex(content)
+	if match == nil {
+		return "", 0, false
+	}
+	return braceBlock(content, match[1]-1), lineAt(content, match[1]-1), true
+}
+
+// braceBlock returns the text after the brace at open up to its match, skipping braces in
+// strings and comments
+func braceBlock(content string, open int) string {
+	depth := 0
+	for i := open; i < len(content); i++ {
+		switch c := content[i]; c {
+		case '"', '\'', '`':
+			for i++; i < len(content) && content[i] != c; i++ {
+				if content[i] == '\\' && c != '`' {
+					i++
+				}
+			}
+		case '/':
+			if i+1 < len(content) && content[i+1] == '/' {
+				for i < len(content) && content[i] != '\n' {
+					i++
+				}
+			} else if i+1 < len(content) && content[i+1] == '*' {
+				if end := strings.Index(content[i+2:], "*/"); end >= 0 {
+					i += end + 3
+				}
+			}
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return content[open+1 : i]
+			}
+		}
+	}
+	return content[open+1:]
+}
+
+// lineAt returns the 1-based line of a byte offset
+func lineAt(content string, offset int) int {
+	return strings.Count(content[:offset], "\n") + 1
+}
+
+func classifyStartupLine(line string) string {
+	for _, kind := range startupKinds {
+		if kind.pattern.MatchString(line) {
+			return kind.kind
+		}
+	}
+	return ""
+}
+
+// startupLanguage returns "go", "js" or "py" for a traceable source file, or ""
+func startupLanguage(file string) string {
+	switch path.Ext(file) {
+	case ".go":
+		return "go"
+	case ".js", ".mjs", ".cjs", ".ts", ".tsx":
+		return "js"
+	case ".py":
+		return "py"
+	}
+	return ""
+}
+
+// String renders a step as "kind: detail (file:line)"
+func (s StartupStep) String() string {
+	location := fmt.Sprintf("%s:%d", s.File, s.Line)
+	if s.Via != "" {
+		location += " via " + s.Via
+	}
+	return fmt.Sprintf("%s: %s (%s)", s.Kind, s.Detail, location)
+}
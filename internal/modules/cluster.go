@@ -0,0 +1,354 @@
+package modules
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"sort"
+	"strings"
+
+	"repo-explanation/internal/mermaid"
+)
+
+// Hub packages are imported by at least minHubFanIn packages and hubShare of the importing ones;
+// linking every module, they would pull the clustering into one blob
+const (
+	minHubFanIn = 4
+	hubShare    = 0.4
+)
+
+// genericSegments are directory names that say where code lives rather than what it does
+var genericSegments = map[string]bool{
+	"internal": true, "pkg": true, "src": true, "app": true, "apps": true, "lib": true, "libs": true,
+	"main": true, "java": true, "kotlin": true, "python": true, "com": true, "org": true, "net": true,
+	"io": true, "modules": true, "module": true, "packages": true, "cmd": true, "impl": true, ".": true,
+	"common": true, "shared": true, "util": true, "utils": true, "helpers": true,
+}
+
+// cluster removes the hub packages and merges the rest greedily by modularity gain (Clauset,
+// Newman and Moore); packages without imports either way join the module of their nearest
+// relative. It returns nil when fewer than two modules come out.
+func cluster(g *graph) *Report {
+	packages := sortedKeys(g.packages)
+	hubs := findHubs(g, packages)
+
+	// Undirected weights between the remaining packages
+	weights := make(map[string]map[string]float64)
+	total := 0.0
+	link := func(a, b string, w float64) {
+		if weights[a] == nil {
+			weights[a] = make(map[string]float64)
+		}
+		weights[a][b] += w
+	}
+	for _, from := range packages {
+		for to, w := range g.weights[from] {
+			if hubs[from] || hubs[to] {
+				continue
+			}
+			link(from, to, float64(w))
+			link(to, from, float64(w))
+			total += float64(w)
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+
+	// Every connected package starts as its own cluster
+	clusterOf := make(map[string]int)
+	var members [][]string
+	var isolated []string
+	for _, pkg := range packages {
+		if hubs[pkg] {
+			continue
+		}
+		if len(weights[pkg]) == 0 {
+			isolated = append(isolated, pkg)
+			continue
+		}
+		clusterOf[pkg] = len(members)
+		members = append(members, []string{pkg})
+	}
+	between := make([]map[int]float64, len(members))
+	degree := make([]float64, len(members))
+	for i := range members {
+		between[i] = make(map[int]float64)
+	}
+	for a, neighbours := range weights {
+		for b, w := range neighbours {
+			between[clusterOf[a]][clusterOf[b]] += w
+			degree[clusterOf[a]] += w
+		}
+	}
+
+	alive := make([]bool, len(members))
+	for i := range alive {
+		alive[i] = true
+	}
+	for {
+		bestGain, bestI, bestJ := 0.0, -1, -1
+		for i := range members {
+			if !alive[i] {
+				continue
+			}
+			for j, w := range between[i] {
+				if j <= i || !alive[j] {
+					continue
+				}
+				gain := w/total - 2*(degree[i]/(2*total))*(degree[j]/(2*total))
+				if gain > bestGain+1e-12 || (math.Abs(gain-bestGain) <= 1e-12 && bestI >= 0 && (i < bestI || (i == bestI && j < bestJ))) {
+					bestGain, bestI, bestJ = gain, i, j
+				}
+			}
+		}
+		if bestI < 0 {
+			break
+		}
+		// Merge bestJ into bestI; the diagonal holds twice the weight inside a cluster, as the
+		// degrees count both ends of every edge
+		for k, w := range between[bestJ] {
+			if k == bestJ {
+				between[bestI][bestI] += w
+				continue
+			}
+			if k == bestI {
+				between[bestI][bestI] += 2 * w
+				continue
+			}
+			between[bestI][k] += w
+			between[k][bestI] += w
+			delete(between[k], bestJ)
+		}
+		delete(between[bestI], bestJ)
+		degree[bestI] += degree[bestJ]
+		members[bestI] = append(members[bestI], members[bestJ]...)
+		for _, pkg := range members[bestJ] {
+			clusterOf[pkg] = bestI
+		}
+		alive[bestJ] = false
+	}
+
+	modularity := 0.0
+	for i := range members {
+		if alive[i] {
+			a := degree[i] / (2 * total)
+			modularity += between[i][i]/(2*total) - a*a
+		}
+	}
+
+	// Isolated packages join the cluster holding their closest relative by path; those without
+	// one are grouped by their top-level directory
+	strays := make(map[string]int)
+	for _, pkg := range isolated {
+		best, bestShared := -1, 0
+		for _, other := range packages {
+			c, ok := clusterOf[other]
+			if !ok || !alive[c] {
+				continue
+			}
+			if shared := sharedSegments(pkg, other); shared > bestShared {
+				best, bestShared = c, shared
+			}
+		}
+		if best < 0 {
+			top := strings.SplitN(pkg, "/", 2)[0]
+			c, ok := strays[top]
+			if !ok {
+				c = len(members)
+				strays[top] = c
+				members = append(members, nil)
+				alive = append(alive, true)
+				between = append(between, map[int]float64{})
+				degree = append(degree, 0)
+			}
+			best = c
+		}
+		members[best] = append(members[best], pkg)
+		clusterOf[pkg] = best
+	}
+
+	report := &Report{Packages: len(packages), Modularity: math.Round(modularity*100) / 100}
+	var index []int
+	for i := range members {
+		if alive[i] {
+			index = append(index, i)
+		}
+	}
+	if len(index) < 2 {
+		return nil
+	}
+	moduleOf := make(map[int]int)
+	for _, i := range index {
+		sort.Strings(members[i])
+		module := Module{Packages: members[i]}
+		for _, pkg := range members[i] {
+			module.Files += g.packages[pkg]
+		}
+		if inside := between[i][i] / 2; degree[i] > 0 {
+			module.Cohesion = math.Round(inside/(inside+degree[i]-between[i][i])*100) / 100
+		}
+		report.Modules = append(report.Modules, module)
+	}
+	sort.SliceStable(report.Modules, func(a, b int) bool {
+		if report.Modules[a].Files != report.Modules[b].Files {
+			return report.Modules[a].Files > report.Modules[b].Files
+		}
+		return report.Modules[a].Packages[0] < report.Modules[b].Packages[0]
+	})
+	for m, module := range report.Modules {
+		moduleOf[clusterOf[module.Packages[0]]] = m
+	}
+	// Centrality is each package's share of its module's internal weight
+	centrality := make(map[string]float64)
+	for a, neighbours := range weights {
+		for b, w := range neighbours {
+			if i := clusterOf[a]; i == clusterOf[b] && between[i][i] > 0 {
+				centrality[a] += w / between[i][i]
+			}
+		}
+	}
+	nameModules(report.Modules, centrality)
+
+	for pkg := range hubs {
+		report.Shared = append(report.Shared, pkg)
+	}
+	sort.Strings(report.Shared)
+
+	imports := make(map[[2]int]int)
+	for _, from := range packages {
+		for to, w := range g.weights[from] {
+			if hubs[from] || hubs[to] {
+				continue
+			}
+			a, b := moduleOf[clusterOf[from]], moduleOf[clusterOf[to]]
+			if a != b {
+				imports[[2]int{a, b}] += w
+			}
+		}
+	}
+	for pair, count := range imports {
+		report.Dependencies = append(report.Dependencies, Dependency{From: report.Modules[pair[0]].Name, To: report.Modules[pair[1]].Name, Imports: count})
+	}
+	sort.Slice(report.Dependencies, func(i, j int) bool {
+		if report.Dependencies[i].Imports != report.Dependencies[j].Imports {
+			return report.Dependencies[i].Imports > report.Dependencies[j].Imports
+		}
+		if report.Dependencies[i].From != report.Dependencies[j].From {
+			return report.Dependencies[i].From < report.Dependencies[j].From
+		}
+		return report.Dependencies[i].To < report.Dependencies[j].To
+	})
+	return report
+}
+
+// findHubs returns the packages imported by most of the importing packages
+func findHubs(g *graph, packages []string) map[string]bool {
+	fanIn := make(map[string]int)
+	importers := 0
+	for _, from := range packages {
+		if len(g.weights[from]) > 0 {
+			importers++
+		}
+		for to := range g.weights[from] {
+			fanIn[to]++
+		}
+	}
+	threshold := int(math.Ceil(hubShare * float64(importers)))
+	if threshold < minHubFanIn {
+		threshold = minHubFanIn
+	}
+	hubs := make(map[string]bool)
+	for pkg, count := range fanIn {
+		if count >= threshold {
+			hubs[pkg] = true
+		}
+	}
+	return hubs
+}
+
+// sharedSegments counts the leading directory names two paths have in common
+func sharedSegments(a, b string) int {
+	as, bs := strings.Split(a, "/"), strings.Split(b, "/")
+	n := 0
+	for n < len(as) && n < len(bs) && as[n] == bs[n] && as[n] != "." {
+		n++
+	}
+	return n
+}
+
+// nameModules names each module after the directory name most of its packages sit under,
+// skipping generic names and those every module shares. Ties go to the name of the package most
+// tied into the module, its core.
+func nameModules(modules []Module, centrality map[string]float64) {
+	inModules := make(map[string]int)
+	weights := make([]map[string]float64, len(modules))
+	for i, module := range modules {
+		weights[i] = make(map[string]float64)
+		for _, pkg := range module.Packages {
+			seen := make(map[string]bool)
+			for _, segment := range strings.Split(pkg, "/") {
+				if !genericSegments[strings.ToLower(segment)] && !seen[segment] {
+					seen[segment] = true
+					// Whole packages count first; centrality, below one, breaks ties
+					weights[i][segment] += 1 + centrality[pkg]
+				}
+			}
+		}
+		for segment := range weights[i] {
+			inModules[segment]++
+		}
+	}
+
+	taken := make(map[string]bool)
+	for i := range modules {
+		best, bestWeight := "", 0.0
+		for segment, weight := range weights[i] {
+			if len(modules) > 1 && inModules[segment] == len(modules) {
+				continue
+			}
+			if weight > bestWeight || (weight == bestWeight && segment < best) {
+				best, bestWeight = segment, weight
+			}
+		}
+		if best == "" {
+			best = path.Base(modules[i].Packages[0])
+		}
+		if best == "." || best == "" {
+			best = "root"
+		}
+		name := strings.ToLower(best)
+		for n := 2; taken[name]; n++ {
+			name = fmt.Sprintf("%s-%d", strings.ToLower(best), n)
+		}
+		taken[name] = true
+		modules[i].Name = name
+	}
+}
+
+// generateMermaidGraph draws the modules and the imports between them, with the shared packages
+// as one node
+func generateMermaidGraph(r *Report) string {
+	chart := mermaid.NewFlowchart("LR")
+	for _, module := range r.Modules {
+		chart.Node(nodeName(module.Name), fmt.Sprintf("%s (%d packages)", module.Name, len(module.Packages)), mermaid.Rounded)
+	}
+	if len(r.Shared) > 0 {
+		chart.Node("shared_kernel", fmt.Sprintf("shared: %s", strings.Join(r.Shared, ", ")), mermaid.Rectangle)
+		chart.ClassDef("shared", "fill:#f3f4f6,stroke:#9ca3af,stroke-dasharray:4 2")
+		chart.Class("shared_kernel", "shared")
+	}
+	for _, dep := range r.Dependencies {
+		chart.Edge(nodeName(dep.From), nodeName(dep.To), mermaid.Solid, fmt.Sprintf("%d", dep.Imports))
+	}
+
+	diagram, err := mermaid.Clean(chart.String())
+	if err != nil {
+		fmt.Printf("⚠️  Module graph is not valid Mermaid: %v\n", err)
+	}
+	return diagram
+}
+
+func nodeName(name string) string {
+	return "mod_" + name
+}
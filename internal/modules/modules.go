@@ -0,0 +1,378 @@
+// Package modules finds the feature modules inside a single service: it links the service's
+// packages (directories of source files) through their imports and clusters them by import
+// affinity, so a monolith gets an internal-architecture view instead of one service box.
+package modules
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"repo-explanation/internal/filestore"
+)
+
+// Module is a cluster of packages that import each other more than the rest
+type Module struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Packages    []string `json:"packages"` // Directories relative to the analyzed root
+	Files       int      `json:"files"`
+	Cohesion    float64  `json:"cohesion"` // Share of the module's import weight that stays inside it (0-1)
+}
+
+// Dependency is the import weight from one module to another
+type Dependency struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Imports int    `json:"imports"` // Source files of From importing a package of To
+}
+
+// Report is the module structure of one service
+type Report struct {
+	Language     string       `json:"language"`
+	Packages     int          `json:"packages"`
+	Modules      []Module     `json:"modules"`
+	Shared       []string     `json:"shared,omitempty"` // Packages most of the code imports (utilities, config), kept out of the modules
+	Dependencies []Dependency `json:"dependencies"`
+	Modularity   float64      `json:"modularity"` // Newman modularity of the clustering; above 0.3 the boundaries are clear
+	MermaidGraph string       `json:"mermaid_graph"`
+	Source       string       `json:"source"` // heuristic or heuristic+llm
+	Summary      string       `json:"summary"`
+}
+
+// Directories never holding the service's own source
+var skippedDirs = map[string]bool{
+	".git": true, "node_modules": true, "vendor": true, "dist": true, "build": true, ".next": true,
+	"target": true, "__pycache__": true, ".venv": true, "venv": true, "testdata": true, "coverage": true,
+	"migrations": true, "generated": true,
+}
+
+// minPackages is the smallest service worth clustering
+const minPackages = 4
+
+var (
+	goModuleRegex      = regexp.MustCompile(`(?m)^module\s+(\S+)`)
+	goImportLineRegex  = regexp.MustCompile(`(?m)^\s*import\s+(?:[a-zA-Z_.]\w*\s+)?"([^"]+)"`)
+	goImportBlockRegex = regexp.MustCompile(`(?s)import\s*\((.*?)\)`)
+	quotedRegex        = regexp.MustCompile(`"([^"]+)"`)
+	jsImportRegex      = regexp.MustCompile(`(?:from\s+|import\s*\(\s*|require\(\s*|import\s+)['"]([^'"]+)['"]`)
+	pyFromRegex        = regexp.MustCompile(`(?m)^\s*from\s+(\.*[\w.]*)\s+import\s+([\w, ()*]+)`)
+	pyImportRegex      = regexp.MustCompile(`(?m)^\s*import\s+([\w.]+(?:\s*,\s*[\w.]+)*)`)
+	jvmPackageRegex    = regexp.MustCompile(`(?m)^\s*package\s+([\w.]+)`)
+	jvmImportRegex     = regexp.MustCompile(`(?m)^\s*import\s+(?:static\s+)?([\w.]+)(?:\.\*)?\s*;?\s*$`)
+)
+
+// languages maps source extensions to the language whose imports are resolved
+var languages = map[string]string{
+	".go": "go", ".py": "python", ".java": "jvm", ".kt": "jvm",
+	".js": "js", ".jsx": "js", ".ts": "js", ".tsx": "js", ".mjs": "js", ".cjs": "js",
+}
+
+// source is a file read for its imports
+type source struct {
+	path     string // Slash path relative to the root
+	language string
+	content  string
+}
+
+// graph is the package import graph: weights[from][to] counts the files of from importing to
+type graph struct {
+	packages map[string]int // Package -> source files
+	weights  map[string]map[string]int
+}
+
+// Analyze links the packages of the project's files through their imports and clusters them. The
+// report is nil when the code has too few packages, or no imports between them, to cluster.
+func Analyze(project *filestore.FileStore) (*Report, error) {
+	fmt.Printf("🧩 [DEBUG] Detecting module boundaries of %d files\n", project.Len())
+
+	sources, err := loadSources(project)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source files: %v", err)
+	}
+	language := mainLanguage(sources)
+	if language == "" {
+		return nil, nil
+	}
+
+	g := buildGraph(project, sources, language)
+	if len(g.packages) < minPackages {
+		return nil, nil
+	}
+	report := cluster(g)
+	if report == nil {
+		return nil, nil
+	}
+	report.Language = language
+	report.Source = "heuristic"
+	report.finish()
+
+	fmt.Printf("✅ [DEBUG] Module boundaries complete: %s\n", report.Summary)
+	return report, nil
+}
+
+// Rename replaces the name and description of the module at index, keeping names unique and the
+// diagram in step; an empty or taken name is ignored
+func (r *Report) Rename(index int, name, description string) bool {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if index < 0 || index >= len(r.Modules) || name == "" || len(name) > 40 {
+		return false
+	}
+	old := r.Modules[index].Name
+	for i, module := range r.Modules {
+		if i != index && module.Name == name {
+			return false
+		}
+	}
+	r.Modules[index].Name = name
+	r.Modules[index].Description = strings.TrimSpace(description)
+	for i := range r.Dependencies {
+		if r.Dependencies[i].From == old {
+			r.Dependencies[i].From = name
+		}
+		if r.Dependencies[i].To == old {
+			r.Dependencies[i].To = name
+		}
+	}
+	r.Source = "heuristic+llm"
+	r.finish()
+	return true
+}
+
+// finish draws the diagram and writes the summary
+func (r *Report) finish() {
+	r.MermaidGraph = generateMermaidGraph(r)
+	r.Summary = fmt.Sprintf("%d modules across %d %s packages (modularity %.2f), %d shared packages, %d module dependencies",
+		len(r.Modules), r.Packages, r.Language, r.Modularity, len(r.Shared), len(r.Dependencies))
+}
+
+// loadSources reads the project's source files, leaving out tests
+func loadSources(project *filestore.FileStore) ([]source, error) {
+	var sources []source
+	err := project.Walk(func(rel string) bool {
+		_, ok := languages[path.Ext(rel)]
+		return ok && !skipped(rel) && !isTest(path.Base(rel))
+	}, func(rel, content string) error {
+		sources = append(sources, source{path: rel, language: languages[path.Ext(rel)], content: content})
+		return nil
+	})
+	return sources, err
+}
+
+// skipped reports whether a file lies in a dependency, build, generated or hidden directory
+func skipped(rel string) bool {
+	return filestore.InDirs(rel, skippedDirs) || filestore.InHiddenDir(rel)
+}
+
+func isTest(name string) bool {
+	lower := strings.ToLower(name)
+	return strings.HasSuffix(lower, "_test.go") || strings.HasPrefix(lower, "test_") || strings.HasSuffix(lower, "_test.py") ||
+		strings.Contains(lower, ".test.") || strings.Contains(lower, ".spec.") || strings.HasSuffix(lower, ".d.ts") ||
+		strings.HasSuffix(lower, "test.java") || strings.HasSuffix(lower, "test.kt")
+}
+
+// mainLanguage returns the language with the most source files; the others are left out, since
+// a service's modules are drawn from its backend code
+func mainLanguage(sources []source) string {
+	counts := make(map[string]int)
+	for _, src := range sources {
+		counts[src.language]++
+	}
+	best := ""
+	for language, count := range counts {
+		if count > counts[best] || (count == counts[best] && language < best) {
+			best = language
+		}
+	}
+	return best
+}
+
+// buildGraph resolves the imports of the main language's files to the packages they name
+func buildGraph(project *filestore.FileStore, sources []source, language string) *graph {
+	g := &graph{packages: make(map[string]int), weights: make(map[string]map[string]int)}
+	files := make(map[string]bool)
+	for _, src := range sources {
+		if src.language == language {
+			g.packages[path.Dir(src.path)]++
+			files[src.path] = true
+		}
+	}
+
+	var resolve func(src source) []string
+	switch language {
+	case "go":
+		modules := goModules(project)
+		resolve = func(src source) []string { return resolveGo(src, modules) }
+	case "python":
+		resolve = func(src source) []string { return resolvePython(src, files, g.packages) }
+	case "jvm":
+		packages := make(map[string]string)
+		for _, src := range sources {
+			if src.language == language {
+				if m := jvmPackageRegex.FindStringSubmatch(src.content); m != nil {
+					packages[m[1]] = path.Dir(src.path)
+				}
+			}
+		}
+		resolve = func(src source) []string { return resolveJVM(src, packages) }
+	default:
+		resolve = func(src source) []string { return resolveJS(src, g.packages) }
+	}
+
+	for _, src := range sources {
+		if src.language != language {
+			continue
+		}
+		from := path.Dir(src.path)
+		seen := make(map[string]bool)
+		for _, to := range resolve(src) {
+			if to == from || seen[to] || g.packages[to] == 0 {
+				continue
+			}
+			seen[to] = true
+			if g.weights[from] == nil {
+				g.weights[from] = make(map[string]int)
+			}
+			g.weights[from][to]++
+		}
+	}
+	return g
+}
+
+// goModules maps the directory of every go.mod in the project to its module path
+func goModules(project *filestore.FileStore) map[string]string {
+	modules := make(map[string]string)
+	project.Walk(func(rel string) bool {
+		return path.Base(rel) == "go.mod" && !skipped(rel)
+	}, func(rel, content string) error {
+		if m := goModuleRegex.FindStringSubmatch(content); m != nil {
+			modules[path.Dir(rel)] = m[1]
+		}
+		return nil
+	})
+	return modules
+}
+
+func resolveGo(src source, modules map[string]string) []string {
+	var specs []string
+	for _, m := range goImportLineRegex.FindAllStringSubmatch(src.content, -1) {
+		specs = append(specs, m[1])
+	}
+	for _, block := range goImportBlockRegex.FindAllStringSubmatch(src.content, -1) {
+		for _, quoted := range quotedRegex.FindAllStringSubmatch(block[1], -1) {
+			specs = append(specs, quoted[1])
+		}
+	}
+	var targets []string
+	for _, spec := range specs {
+		for dir, modulePath := range modules {
+			if spec == modulePath {
+				targets = append(targets, dir)
+			} else if strings.HasPrefix(spec, modulePath+"/") {
+				targets = append(targets, path.Join(dir, strings.TrimPrefix(spec, modulePath+"/")))
+			}
+		}
+	}
+	return targets
+}
+
+// resolveJS follows relative imports, and "@/" and "~/" aliases of src/, to the directory of the
+// imported file or the imported directory itself
+func resolveJS(src source, packages map[string]int) []string {
+	var targets []string
+	for _, m := range jsImportRegex.FindAllStringSubmatch(src.content, -1) {
+		spec := m[1]
+		var target string
+		switch {
+		case strings.HasPrefix(spec, "./") || strings.HasPrefix(spec, "../"):
+			target = path.Join(path.Dir(src.path), spec)
+		case strings.HasPrefix(spec, "@/") || strings.HasPrefix(spec, "~/"):
+			target = path.Join("src", spec[2:])
+		default:
+			continue
+		}
+		if packages[target] > 0 {
+			targets = append(targets, target)
+			continue
+		}
+		// A file without its extension: the package is its directory
+		targets = append(targets, path.Dir(target))
+	}
+	return targets
+}
+
+// resolvePython maps absolute imports of packages inside the project, at its root or under src/,
+// and relative imports to their directories
+func resolvePython(src source, files map[string]bool, packages map[string]int) []string {
+	var modules []string
+	for _, m := range pyFromRegex.FindAllStringSubmatch(src.content, -1) {
+		module := m[1]
+		if strings.HasPrefix(module, ".") {
+			dots := len(module) - len(strings.TrimLeft(module, "."))
+			dir := path.Dir(src.path)
+			for i := 1; i < dots; i++ {
+				dir = path.Dir(dir)
+			}
+			rest := strings.ReplaceAll(strings.TrimLeft(module, "."), ".", "/")
+			target := path.Join(dir, rest)
+			modules = append(modules, target)
+			// "from . import billing" names a subpackage
+			for _, name := range strings.Split(strings.Trim(m[2], "() "), ",") {
+				if name = strings.TrimSpace(name); name != "" && name != "*" {
+					modules = append(modules, path.Join(target, name))
+				}
+			}
+			continue
+		}
+		modules = append(modules, strings.ReplaceAll(module, ".", "/"), "src/"+strings.ReplaceAll(module, ".", "/"))
+	}
+	for _, m := range pyImportRegex.FindAllStringSubmatch(src.content, -1) {
+		for _, module := range strings.Split(m[1], ",") {
+			module = strings.ReplaceAll(strings.TrimSpace(module), ".", "/")
+			modules = append(modules, module, "src/"+module)
+		}
+	}
+
+	var targets []string
+	for _, module := range modules {
+		switch {
+		case packages[module] > 0:
+			targets = append(targets, module)
+		case files[module+".py"]:
+			targets = append(targets, path.Dir(module))
+		}
+	}
+	return targets
+}
+
+// resolveJVM maps imports of classes and packages declared in the project to their directories
+func resolveJVM(src source, packages map[string]string) []string {
+	var targets []string
+	for _, m := range jvmImportRegex.FindAllStringSubmatch(src.content, -1) {
+		name := m[1]
+		// The class name, and for static imports the member name, are dropped until a package matches
+		for name != "" {
+			if dir, ok := packages[name]; ok {
+				targets = append(targets, dir)
+				break
+			}
+			i := strings.LastIndex(name, ".")
+			if i < 0 {
+				break
+			}
+			name = name[:i]
+		}
+	}
+	return targets
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
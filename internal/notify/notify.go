@@ -0,0 +1,156 @@
+// Package notify delivers reports to people: JSON posted to webhooks (which Slack, Teams and
+// Mattermost incoming webhooks render from its text field) and plain-text email over SMTP.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"repo-explanation/config"
+)
+
+// Message is one report to deliver
+type Message struct {
+	Subject string      // Email subject; the first line of webhook text
+	Text    string      // Plain-text body
+	Data    interface{} // Machine-readable report posted to webhooks alongside the text
+}
+
+// Notifier delivers a message to one destination
+type Notifier interface {
+	Notify(ctx context.Context, message Message) error
+	String() string // Destination, for logs
+}
+
+// webhookTimeout bounds one webhook delivery
+const webhookTimeout = 15 * time.Second
+
+// Webhook posts messages as JSON to a URL
+type Webhook struct {
+	URL    string
+	client *http.Client
+}
+
+// NewWebhook creates a notifier posting to url
+func NewWebhook(url string) *Webhook {
+	return &Webhook{URL: url, client: &http.Client{Timeout: webhookTimeout}}
+}
+
+// webhookPayload is the body posted to webhooks
+type webhookPayload struct {
+	Text    string      `json:"text"`
+	Subject string      `json:"subject"`
+	Report  interface{} `json:"report,omitempty"`
+}
+
+// Notify posts the message; any status other than 2xx is an error
+func (w *Webhook) Notify(ctx context.Context, message Message) error {
+	body, err := json.Marshal(webhookPayload{
+		Text:    "*" + message.Subject + "*\n" + message.Text,
+		Subject: message.Subject,
+		Report:  message.Data,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %v", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid webhook URL: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook returned %s: %s", resp.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+func (w *Webhook) String() string {
+	return "webhook " + w.URL
+}
+
+// Email sends messages through an SMTP server
+type Email struct {
+	SMTP config.SMTPConfig
+	To   []string
+}
+
+// NewEmail creates a notifier mailing to through the configured server
+func NewEmail(smtpConfig config.SMTPConfig, to []string) *Email {
+	return &Email{SMTP: smtpConfig, To: to}
+}
+
+// Notify sends the message as a plain-text email; the server must offer STARTTLS when credentials are set
+func (e *Email) Notify(ctx context.Context, message Message) error {
+	port := e.SMTP.Port
+	if port == 0 {
+		port = 587
+	}
+	addr := net.JoinHostPort(e.SMTP.Host, strconv.Itoa(port))
+
+	var auth smtp.Auth
+	if e.SMTP.Username != "" {
+		auth = smtp.PlainAuth("", e.SMTP.Username, e.SMTP.Password, e.SMTP.Host)
+	}
+
+	var body bytes.Buffer
+	fmt.Fprintf(&body, "From: %s\r\n", e.SMTP.From)
+	fmt.Fprintf(&body, "To: %s\r\n", strings.Join(e.To, ", "))
+	fmt.Fprintf(&body, "Subject: %s\r\n", headerValue(message.Subject))
+	fmt.Fprintf(&body, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	body.WriteString("MIME-Version: 1.0\r\n")
+	body.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	body.WriteString(strings.ReplaceAll(message.Text, "\n", "\r\n"))
+
+	// net/smtp has no context support; run the send so cancellation stops the wait
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(addr, auth, e.SMTP.From, e.To, body.Bytes())
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("failed to send email via %s: %v", addr, err)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (e *Email) String() string {
+	return "email " + strings.Join(e.To, ", ")
+}
+
+// headerValue keeps a subject on one header line
+func headerValue(value string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(value)
+}
+
+// Send delivers the message to every notifier, returning the failures joined into one error
+func Send(ctx context.Context, notifiers []Notifier, message Message) error {
+	var failures []string
+	for _, notifier := range notifiers {
+		if err := notifier.Notify(ctx, message); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", notifier, err))
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("%s", strings.Join(failures, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+	"repo-explanation/internal/audit"
+)
+
+// StartAuditRun groups the following calls under a new audit run and returns its ID; "" when the
+// audit log is off
+func (c *Client) StartAuditRun(label string) string {
+	if c.audit == nil {
+		return ""
+	}
+	return c.audit.StartRun(label)
+}
+
+// AuditRun returns the current audit run ID, or "" when the audit log is off
+func (c *Client) AuditRun() string {
+	if c.audit == nil {
+		return ""
+	}
+	return c.audit.Run()
+}
+
+// recordCall appends a request and its outcome to the audit log, if enabled
+func (c *Client) recordCall(req openai.ChatCompletionRequest, resp openai.ChatCompletionResponse, latency time.Duration, cached bool, err error) {
+	if c.audit == nil {
+		return
+	}
+	entry := audit.Entry{
+		Model:       req.Model,
+		Temperature: req.Temperature,
+		MaxTokens:   req.MaxTokens,
+		LatencyMS:   latency.Milliseconds(),
+		Cached:      cached,
+	}
+	// A cached answer spent no tokens; its stored usage belongs to the call that paid for it
+	if !cached {
+		entry.PromptTokens = resp.Usage.PromptTokens
+		entry.CompletionTokens = resp.Usage.CompletionTokens
+		entry.TotalTokens = resp.Usage.TotalTokens
+	}
+	if req.ResponseFormat != nil {
+		entry.ResponseFormat = string(req.ResponseFormat.Type)
+	}
+	for _, message := range req.Messages {
+		entry.Messages = append(entry.Messages, audit.Message{Role: message.Role, Content: message.Content})
+	}
+	if len(resp.Choices) > 0 {
+		entry.Response = resp.Choices[0].Message.Content
+		entry.FinishReason = string(resp.Choices[0].FinishReason)
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	c.audit.Record(entry)
+}
+
+// Replay sends a recorded request to the API again, bypassing the prompt cache, and returns the
+// new response with its latency. The request is sent exactly as recorded, redactions included.
+func (c *Client) Replay(ctx context.Context, entry audit.Entry) (openai.ChatCompletionResponse, time.Duration, error) {
+	req := openai.ChatCompletionRequest{
+		Model:       entry.Model,
+		Temperature: entry.Temperature,
+		MaxTokens:   entry.MaxTokens,
+	}
+	if entry.ResponseFormat != "" {
+		req.ResponseFormat = &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatType(entry.ResponseFormat)}
+	}
+	for _, message := range entry.Messages {
+		req.Messages = append(req.Messages, openai.ChatCompletionMessage{Role: message.Role, Content: message.Content})
+	}
+
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return openai.ChatCompletionResponse{}, 0, fmt.Errorf("rate limit error: %v", err)
+	}
+	started := time.Now()
+	resp, err := c.client.CreateChatCompletion(ctx, req)
+	return resp, time.Since(started), err
+}
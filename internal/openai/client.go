@@ -5,10 +5,18 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/sashabaranov/go-openai"
 	"repo-explanation/config"
+	"repo-explanation/internal/atrest"
+	"repo-explanation/internal/audit"
+	"repo-explanation/internal/diskcache"
+	"repo-explanation/internal/prompts"
 )
 
 // Client wraps the OpenAI client with rate limiting and error handling
@@ -16,6 +24,13 @@ type Client struct {
 	client      *openai.Client
 	config      *config.Config
 	rateLimiter *RateLimiter
+	promptCache promptCache
+	observer    atomic.Pointer[CallObserver]
+	counters    llmCounters // Prompt cache and structured response outcomes of this client
+	focus       string // Profile focus added to project-level system prompts
+	language    string // Output language code for prose; "" is English
+	audit       *audit.Log // Set when audit.enabled is on
+	prompts     *prompts.Set // Built-in prompt templates and the overrides from openai.prompts_dir
 }
 
 // FileSummary represents the structured output from LLM analysis
@@ -39,6 +54,7 @@ type FolderSummary struct {
 	Dependencies []string                `json:"dependencies"`
 	Architecture string                  `json:"architecture"`
 	FileSummaries map[string]FileSummary `json:"file_summaries"`
+	Subfolders  []string                 `json:"subfolders,omitempty"` // Child folders folded into this summary
 }
 
 // ProjectSummary represents the final project overview
@@ -51,6 +67,18 @@ type ProjectSummary struct {
 	FolderSummaries map[string]FolderSummary `json:"folder_summaries"`
 	// Enhanced analysis fields
 	DetailedAnalysis *RepositoryAnalysis     `json:"detailed_analysis,omitempty"`
+	// Documentation is extracted from README, CHANGELOG and docs/ without an LLM
+	Documentation    *DocumentationDigest    `json:"documentation,omitempty"`
+}
+
+// DocumentationDigest is what the project's own documentation says about it and how to run it
+type DocumentationDigest struct {
+	Title       string   `json:"title,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Headings    []string `json:"headings,omitempty"`
+	SetupSteps  []string `json:"setup_steps,omitempty"`  // Install, configure and build commands
+	RunCommands []string `json:"run_commands,omitempty"` // Commands that start the project or its tests
+	Sources     []string `json:"sources,omitempty"`      // Documentation files the digest was read from
 }
 
 // RepositoryAnalysis contains detailed architectural analysis
@@ -73,18 +101,74 @@ type MonorepoService struct {
 	APIType      string `json:"api_type,omitempty"`      // http, grpc, graphql
 	Port         string `json:"port,omitempty"`          // service port if detected
 	EntryPoint   string `json:"entry_point,omitempty"`   // main.go, index.js, etc.
+	Container    string `json:"container,omitempty"`     // how the service is containerized, from its Dockerfile
+	Architecture *ServiceArchitecture `json:"architecture,omitempty"` // internal architecture pattern
+}
+
+// ServiceArchitecture classifies a service's internal structure with the directories that justify it
+type ServiceArchitecture struct {
+	Pattern    string   `json:"pattern"`    // layered, clean, hexagonal, mvc or flat
+	Confidence float64  `json:"confidence"` // 0.0 to 1.0
+	Evidence   []string `json:"evidence"`   // directories such as handlers/, usecase/, repository/
+	Source     string   `json:"source"`     // heuristic, llm or heuristic+llm
+	Rationale  string   `json:"rationale,omitempty"`
+}
+
+// SequenceFlowOutline describes a detected inter-service flow: its heuristic name and one line per call
+type SequenceFlowOutline struct {
+	Name  string
+	Steps []string
+}
+
+// SequenceFlowRefinement names a flow; Messages relabel the outline's steps in order
+type SequenceFlowRefinement struct {
+	Flow        int      `json:"flow"` // Index into the outlines
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Messages    []string `json:"messages"`
+}
+
+// ModuleOutline is a cluster of packages to name: its directories and what their files do
+type ModuleOutline struct {
+	Packages []string
+	Context  []string // File summaries and key types
+}
+
+// ModuleNaming names one module of the outlines
+type ModuleNaming struct {
+	Module      int    `json:"module"` // Index into the outlines
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// GlossaryTermContext is a candidate glossary term with the evidence its definition may cite
+type GlossaryTermContext struct {
+	Term    string
+	Kind    string   // table, service or concept
+	Sources []string // Files the definition may cite
+	Context []string // Column lists, service descriptions or summary sentences
+}
+
+// GlossaryDefinition defines one term in the project's context
+type GlossaryDefinition struct {
+	Term       string   `json:"term"`
+	Definition string   `json:"definition"`
+	Sources    []string `json:"sources"`
+	Generic    bool     `json:"generic"` // Ordinary programming vocabulary rather than a domain term
 }
 
 // NewClient creates a new OpenAI client with configuration
 func NewClient(cfg *config.Config) *Client {
 	// Create HTTP client with longer timeout for analysis operations
 	httpClient := &http.Client{
-		Timeout: 15 * time.Minute, // 15-minute timeout for individual API calls
+		Timeout:   15 * time.Minute, // 15-minute timeout for individual API calls
+		Transport: newTransport(cfg.OpenAI),
 	}
 	
 	// Configure OpenAI client with custom HTTP client
 	config := openai.DefaultConfig(cfg.OpenAI.APIKey)
 	config.HTTPClient = httpClient
+	config.OrgID = cfg.OpenAI.Organization
 	
 	if cfg.OpenAI.BaseURL != "" {
 		config.BaseURL = cfg.OpenAI.BaseURL
@@ -97,52 +181,99 @@ func NewClient(cfg *config.Config) *Client {
 		cfg.RateLimiting.RequestsPerDay,
 	)
 
-	return &Client{
+	c := &Client{
 		client:      client,
 		config:      cfg,
 		rateLimiter: rateLimiter,
+		promptCache: promptCache{
+			enabled: cfg.Cache.Enabled,
+			dir:     filepath.Join(cfg.Cache.Directory, "llm"),
+			ttl:     cfg.GetCacheTTL(),
+			budget:  diskcache.For(cfg.Cache.Directory, cfg.GetCacheMaxBytes()),
+			sealer:  atrest.For(cfg),
+		},
+	}
+	if cfg.Audit.Enabled {
+		c.audit = audit.NewLog(cfg.Audit.Path, atrest.For(cfg), cfg.OpenAI.APIKey)
 	}
+	templates, err := prompts.Load(cfg.OpenAI.PromptsDir)
+	if err != nil {
+		// Startup validates the directory; templates edited since fall back to the built-in prompts
+		fmt.Printf("⚠️  Using the built-in prompts: %v\n", err)
+		templates = prompts.Default()
+	}
+	c.prompts = templates
+	return c
+}
+
+// Model is the configured chat model
+func (c *Client) Model() string {
+	return c.config.OpenAI.Model
+}
+
+// PromptFingerprint identifies the prompt template overrides in use; empty for the built-in prompts
+func (c *Client) PromptFingerprint() string {
+	return c.prompts.Fingerprint()
+}
+
+// Messages renders the system and user prompts of a template; focused prompts are framed for the
+// analysis profile
+func (c *Client) Messages(name string, data interface{}, focused bool) ([]openai.ChatCompletionMessage, error) {
+	system, err := c.prompts.System(name)
+	if err != nil {
+		return nil, err
+	}
+	if focused {
+		system = c.WithFocus(system)
+	}
+	user, err := c.prompts.User(name, data)
+	if err != nil {
+		return nil, err
+	}
+	return []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: system},
+		{Role: openai.ChatMessageRoleUser, Content: user},
+	}, nil
+}
+
+// Ping checks that the API is reachable and accepts the key by listing the available models
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.client.ListModels(ctx)
+	return err
+}
+
+// SetFocus frames the project overview, architecture and question prompts for an analysis profile;
+// file and folder prompts stay neutral so their cached summaries are shared across profiles
+func (c *Client) SetFocus(focus string) {
+	c.focus = strings.TrimSpace(focus)
+}
+
+// WithFocus appends the profile focus, if any, to a system prompt
+func (c *Client) WithFocus(system string) string {
+	if c.focus == "" {
+		return system
+	}
+	return system + "\n\n" + c.focus
 }
 
 // AnalyzeFile sends file content to OpenAI for analysis
 func (c *Client) AnalyzeFile(ctx context.Context, filepath, content string) (*FileSummary, error) {
-	// Wait for rate limiter
-	if err := c.rateLimiter.Wait(ctx); err != nil {
-		return nil, fmt.Errorf("rate limit error: %v", err)
+	messages, err := c.Messages(prompts.File, prompts.FileData{Path: filepath, Content: content}, false)
+	if err != nil {
+		return nil, err
 	}
-
-	prompt := c.buildFileAnalysisPrompt(filepath, content)
 	
-	resp, err := c.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+	var summary FileSummary
+	if err := c.CreateJSONCompletion(ctx, openai.ChatCompletionRequest{
 		Model:       c.config.OpenAI.Model,
 		Temperature: c.config.OpenAI.Temperature,
 		MaxTokens:   c.config.OpenAI.MaxTokensPerRequest,
-		Messages: []openai.ChatCompletionMessage{
-			{
-				Role:    openai.ChatMessageRoleSystem,
-				Content: "You are a code analysis expert. Analyze the provided code and return ONLY valid JSON in the specified format. No additional text or explanations.",
-			},
-			{
-				Role:    openai.ChatMessageRoleUser,
-				Content: prompt,
-			},
-		},
+		Messages:    messages,
 		ResponseFormat: &openai.ChatCompletionResponseFormat{
 			Type: openai.ChatCompletionResponseFormatTypeJSONObject,
 		},
-	})
-
-	if err != nil {
-		return nil, fmt.Errorf("OpenAI API error: %v", err)
-	}
-
-	if len(resp.Choices) == 0 {
-		return nil, fmt.Errorf("no response from OpenAI")
-	}
-
-	var summary FileSummary
-	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &summary); err != nil {
-		return nil, fmt.Errorf("failed to parse response JSON: %v", err)
+	}, &summary); err != nil {
+		return nil, err
 	}
 
 	return &summary, nil
@@ -150,88 +281,65 @@ func (c *Client) AnalyzeFile(ctx context.Context, filepath, content string) (*Fi
 
 // AnalyzeFolder aggregates file summaries into a folder summary
 func (c *Client) AnalyzeFolder(ctx context.Context, folderPath string, fileSummaries map[string]FileSummary) (*FolderSummary, error) {
-	// Wait for rate limiter
-	if err := c.rateLimiter.Wait(ctx); err != nil {
-		return nil, fmt.Errorf("rate limit error: %v", err)
-	}
+	return c.AnalyzeFolderWithChildren(ctx, folderPath, fileSummaries, nil)
+}
 
-	prompt := c.buildFolderAnalysisPrompt(folderPath, fileSummaries)
+// AnalyzeFolderWithChildren aggregates a folder's own file summaries and its subfolders' summaries
+func (c *Client) AnalyzeFolderWithChildren(ctx context.Context, folderPath string, fileSummaries map[string]FileSummary, childSummaries map[string]FolderSummary) (*FolderSummary, error) {
+	messages, err := c.Messages(prompts.Folder, folderPromptData(folderPath, fileSummaries, childSummaries), false)
+	if err != nil {
+		return nil, err
+	}
 	
-	resp, err := c.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+	var summary FolderSummary
+	if err := c.CreateJSONCompletion(ctx, openai.ChatCompletionRequest{
 		Model:       c.config.OpenAI.Model,
 		Temperature: c.config.OpenAI.Temperature,
 		MaxTokens:   c.config.OpenAI.MaxTokensPerRequest,
-		Messages: []openai.ChatCompletionMessage{
-			{
-				Role:    openai.ChatMessageRoleSystem,
-				Content: "You are a software architecture expert. Analyze the provided folder structure and file summaries. Return ONLY valid JSON in the specified format.",
-			},
-			{
-				Role:    openai.ChatMessageRoleUser,
-				Content: prompt,
-			},
-		},
+		Messages:    messages,
 		ResponseFormat: &openai.ChatCompletionResponseFormat{
 			Type: openai.ChatCompletionResponseFormatTypeJSONObject,
 		},
-	})
-
-	if err != nil {
-		return nil, fmt.Errorf("OpenAI API error: %v", err)
-	}
-
-	if len(resp.Choices) == 0 {
-		return nil, fmt.Errorf("no response from OpenAI")
-	}
-
-	var summary FolderSummary
-	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &summary); err != nil {
-		return nil, fmt.Errorf("failed to parse response JSON: %v", err)
+	}, &summary); err != nil {
+		return nil, err
 	}
 
 	summary.FileSummaries = fileSummaries
+	summary.Subfolders = SortedFolderPaths(childSummaries)
 	return &summary, nil
 }
 
-// AnalyzeProject creates the final project summary
-func (c *Client) AnalyzeProject(ctx context.Context, projectPath string, folderSummaries map[string]FolderSummary) (*ProjectSummary, error) {
-	// Wait for rate limiter
-	if err := c.rateLimiter.Wait(ctx); err != nil {
-		return nil, fmt.Errorf("rate limit error: %v", err)
+// SortedFolderPaths returns the keys of a folder summary map in sorted order
+func SortedFolderPaths(folders map[string]FolderSummary) []string {
+	if len(folders) == 0 {
+		return nil
+	}
+	paths := make([]string, 0, len(folders))
+	for path := range folders {
+		paths = append(paths, path)
 	}
+	sort.Strings(paths)
+	return paths
+}
 
-	prompt := c.buildProjectAnalysisPrompt(projectPath, folderSummaries)
+// AnalyzeProject creates the final project summary
+func (c *Client) AnalyzeProject(ctx context.Context, projectPath string, folderSummaries map[string]FolderSummary, docs *DocumentationDigest) (*ProjectSummary, error) {
+	messages, err := c.Messages(prompts.Project, projectPromptData(projectPath, folderSummaries, docs), true)
+	if err != nil {
+		return nil, err
+	}
 	
-	resp, err := c.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+	var summary ProjectSummary
+	if err := c.CreateJSONCompletion(ctx, openai.ChatCompletionRequest{
 		Model:       c.config.OpenAI.Model,
 		Temperature: c.config.OpenAI.Temperature,
 		MaxTokens:   c.config.OpenAI.MaxTokensPerRequest,
-		Messages: []openai.ChatCompletionMessage{
-			{
-				Role:    openai.ChatMessageRoleSystem,
-				Content: "You are a senior software architect. Analyze the entire project structure and create a comprehensive overview. Return ONLY valid JSON. The summary field should be exactly 2 sentences explaining what this project does and its purpose.",
-			},
-			{
-				Role:    openai.ChatMessageRoleUser,
-				Content: prompt,
-			},
-		},
+		Messages:    messages,
 		ResponseFormat: &openai.ChatCompletionResponseFormat{
 			Type: openai.ChatCompletionResponseFormatTypeJSONObject,
 		},
-	})
-
-	if err != nil {
-		return nil, fmt.Errorf("OpenAI API error: %v", err)
-	}
-
-	if len(resp.Choices) == 0 {
-		return nil, fmt.Errorf("no response from OpenAI")
-	}
-
-	var summary ProjectSummary
-	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &summary); err != nil {
-		return nil, fmt.Errorf("failed to parse response JSON: %v", err)
+	}, &summary); err != nil {
+		return nil, err
 	}
 
 	summary.FolderSummaries = folderSummaries
@@ -240,235 +348,266 @@ func (c *Client) AnalyzeProject(ctx context.Context, projectPath string, folderS
 
 // AnalyzeRepositoryDetails performs detailed architectural analysis
 func (c *Client) AnalyzeRepositoryDetails(ctx context.Context, projectPath string, folderSummaries map[string]FolderSummary, fileSummaries map[string]FileSummary, importantFiles map[string]string) (*RepositoryAnalysis, error) {
-	// Wait for rate limiter
-	if err := c.rateLimiter.Wait(ctx); err != nil {
-		return nil, fmt.Errorf("rate limit error: %v", err)
+	messages, err := c.Messages(prompts.Repository, repositoryPromptData(folderSummaries, fileSummaries, importantFiles), true)
+	if err != nil {
+		return nil, err
 	}
-
-	prompt := c.buildDetailedAnalysisPrompt(projectPath, folderSummaries, fileSummaries, importantFiles)
 	
-	resp, err := c.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+	var analysis RepositoryAnalysis
+	if err := c.CreateJSONCompletion(ctx, openai.ChatCompletionRequest{
 		Model:       c.config.OpenAI.Model,
 		Temperature: 0.0, // Very low for consistent structured output
 		MaxTokens:   c.config.OpenAI.MaxTokensPerRequest,
-		Messages: []openai.ChatCompletionMessage{
-			{
-				Role:    openai.ChatMessageRoleSystem,
-				Content: `You are a precise repository analyst. Output STRICT JSON only, no prose, matching the provided schema exactly. 
-Do not guess. Use only evidence present in the repository summaries/metadata provided. 
-If uncertain, return "" or [] and lower confidence.`,
-			},
-			{
-				Role:    openai.ChatMessageRoleUser,
-				Content: prompt,
-			},
-		},
+		Messages:    messages,
 		ResponseFormat: &openai.ChatCompletionResponseFormat{
 			Type: openai.ChatCompletionResponseFormatTypeJSONObject,
 		},
-	})
+	}, &analysis); err != nil {
+		return nil, err
+	}
 
+	return &analysis, nil
+}
+
+// ClassifyServiceArchitecture asks the LLM to confirm or correct a heuristic architecture classification
+func (c *Client) ClassifyServiceArchitecture(ctx context.Context, service MonorepoService, directories []string, heuristic *ServiceArchitecture) (*ServiceArchitecture, error) {
+	heuristicJSON, _ := json.Marshal(heuristic)
+	messages, err := c.Messages(prompts.Architecture, prompts.ArchitectureData{
+		Name:        service.Name,
+		Language:    service.Language,
+		Path:        service.Path,
+		Directories: directories,
+		Heuristic:   string(heuristicJSON),
+	}, false)
 	if err != nil {
-		return nil, fmt.Errorf("OpenAI API error: %v", err)
+		return nil, err
 	}
 
-	if len(resp.Choices) == 0 {
-		return nil, fmt.Errorf("no response from OpenAI")
+	var classification ServiceArchitecture
+	if err := c.CreateJSONCompletion(ctx, openai.ChatCompletionRequest{
+		Model:       c.config.OpenAI.Model,
+		Temperature: 0.0,
+		MaxTokens:   300,
+		Messages:    messages,
+		ResponseFormat: &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONObject,
+		},
+	}, &classification); err != nil {
+		return nil, err
 	}
+	classification.Source = "llm"
+	return &classification, nil
+}
 
-	var analysis RepositoryAnalysis
-	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &analysis); err != nil {
-		return nil, fmt.Errorf("failed to parse detailed analysis JSON: %v", err)
+// RefineSequenceFlows asks the LLM for business names and clearer arrow labels for detected flows
+func (c *Client) RefineSequenceFlows(ctx context.Context, outlines []SequenceFlowOutline) ([]SequenceFlowRefinement, error) {
+	data := prompts.SequenceFlowsData{}
+	for _, outline := range outlines {
+		data.Flows = append(data.Flows, prompts.SequenceFlow{Name: outline.Name, Steps: outline.Steps})
+	}
+	messages, err := c.Messages(prompts.SequenceFlows, data, false)
+	if err != nil {
+		return nil, err
 	}
 
-	return &analysis, nil
+	var parsed struct {
+		Flows []SequenceFlowRefinement `json:"flows"`
+	}
+	if err := c.CreateJSONCompletion(ctx, openai.ChatCompletionRequest{
+		Model:       c.config.OpenAI.Model,
+		Temperature: 0.0,
+		MaxTokens:   800,
+		Messages:    messages,
+		ResponseFormat: &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONObject,
+		},
+	}, &parsed); err != nil {
+		return nil, err
+	}
+	return parsed.Flows, nil
 }
 
-func (c *Client) buildFileAnalysisPrompt(filepath, content string) string {
-	return fmt.Sprintf(`Analyze this code file and return a JSON object with the following structure:
-
-{
-  "language": "detected programming language",
-  "purpose": "brief description of what this file does",
-  "key_types": ["list", "of", "important", "types/classes/structs"],
-  "functions": ["list", "of", "important", "functions/methods"],
-  "imports": ["list", "of", "dependencies/imports"],
-  "side_effects": ["list", "of", "side", "effects", "if", "any"],
-  "risks": ["list", "of", "potential", "security", "risks", "if", "any"],
-  "complexity": "low|medium|high"
-}
+// NameModules asks the LLM for a business-capability name and a one-sentence description of
+// each package cluster
+func (c *Client) NameModules(ctx context.Context, projectPurpose string, outlines []ModuleOutline) ([]ModuleNaming, error) {
+	data := prompts.ModulesData{Purpose: projectPurpose}
+	for _, outline := range outlines {
+		data.Modules = append(data.Modules, prompts.ModuleOutline{Packages: outline.Packages, Context: outline.Context})
+	}
+	messages, err := c.Messages(prompts.Modules, data, false)
+	if err != nil {
+		return nil, err
+	}
 
-File path: %s
-Content:
-%s`, filepath, content)
+	var parsed struct {
+		Modules []ModuleNaming `json:"modules"`
+	}
+	if err := c.CreateJSONCompletion(ctx, openai.ChatCompletionRequest{
+		Model:       c.config.OpenAI.Model,
+		Temperature: 0.0,
+		MaxTokens:   1000,
+		Messages:    messages,
+		ResponseFormat: &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONObject,
+		},
+	}, &parsed); err != nil {
+		return nil, err
+	}
+	return parsed.Modules, nil
 }
 
-func (c *Client) buildFolderAnalysisPrompt(folderPath string, fileSummaries map[string]FileSummary) string {
-	summariesJSON, _ := json.Marshal(fileSummaries)
-	
-	return fmt.Sprintf(`Analyze this folder structure and its file summaries. Return a JSON object with this structure:
+// DefineGlossaryTerms asks the LLM to define each term as this project uses it, citing the files
+// listed for the term
+func (c *Client) DefineGlossaryTerms(ctx context.Context, projectPurpose string, terms []GlossaryTermContext) ([]GlossaryDefinition, error) {
+	data := prompts.GlossaryData{Purpose: projectPurpose}
+	for _, term := range terms {
+		data.Terms = append(data.Terms, prompts.GlossaryTerm{Term: term.Term, Kind: term.Kind, Sources: term.Sources, Context: term.Context})
+	}
+	messages, err := c.Messages(prompts.Glossary, data, false)
+	if err != nil {
+		return nil, err
+	}
 
-{
-  "path": "%s",
-  "purpose": "what this folder/module is responsible for",
-  "languages": {"language": count},
-  "key_modules": ["list", "of", "important", "files"],
-  "dependencies": ["external", "dependencies"],
-  "architecture": "brief description of the folder's architecture pattern"
+	var parsed struct {
+		Terms []GlossaryDefinition `json:"terms"`
+	}
+	if err := c.CreateJSONCompletion(ctx, openai.ChatCompletionRequest{
+		Model:       c.config.OpenAI.Model,
+		Temperature: 0.0,
+		MaxTokens:   2500,
+		Messages:    messages,
+		ResponseFormat: &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONObject,
+		},
+	}, &parsed); err != nil {
+		return nil, err
+	}
+	return parsed.Terms, nil
 }
 
-Folder path: %s
-File summaries: %s`, folderPath, folderPath, string(summariesJSON))
+// folderPromptData lays out a folder's file summaries and, without their file details to keep the
+// prompt small, its subfolders' summaries
+func folderPromptData(folderPath string, fileSummaries map[string]FileSummary, childSummaries map[string]FolderSummary) prompts.FolderData {
+	summariesJSON, _ := json.Marshal(fileSummaries)
+	data := prompts.FolderData{Path: folderPath, FileSummaries: string(summariesJSON)}
+	if len(childSummaries) > 0 {
+		type subfolderOverview struct {
+			Purpose      string   `json:"purpose"`
+			Architecture string   `json:"architecture"`
+			KeyModules   []string `json:"key_modules"`
+			Subfolders   []string `json:"subfolders,omitempty"`
+		}
+		overviews := make(map[string]subfolderOverview)
+		for path, child := range childSummaries {
+			overviews[path] = subfolderOverview{
+				Purpose:      child.Purpose,
+				Architecture: child.Architecture,
+				KeyModules:   child.KeyModules,
+				Subfolders:   child.Subfolders,
+			}
+		}
+		overviewsJSON, _ := json.Marshal(overviews)
+		data.Subfolders = string(overviewsJSON)
+	}
+	return data
 }
 
-func (c *Client) buildProjectAnalysisPrompt(projectPath string, folderSummaries map[string]FolderSummary) string {
+// projectPromptData adds the project's own documentation, the most reliable statement of its purpose
+func projectPromptData(projectPath string, folderSummaries map[string]FolderSummary, docs *DocumentationDigest) prompts.ProjectData {
 	summariesJSON, _ := json.Marshal(folderSummaries)
-	
-	return fmt.Sprintf(`Analyze this entire project and create a comprehensive overview. Look at component names, folder structures, route patterns, and business logic to intelligently guess the REAL purpose and business domain.
-
-Examples of good purpose detection:
-- Instead of "A React web application" → "A gift recommendation platform for helping users find personalized presents for their partners"
-- Instead of "An e-commerce platform" → "A marketplace for handmade jewelry where artisans can sell custom pieces to customers"  
-- Instead of "A task management app" → "A project collaboration tool for remote teams to track deadlines and share progress updates"
-
-Return a JSON object:
-
-{
-  "purpose": "intelligent guess of the specific business purpose/domain based on component names, routes, data models, and functionality (2-3 lines max)",
-  "architecture": "overall architecture description (MVC, microservices, etc.)",
-  "data_models": ["important", "data", "structures"],
-  "external_services": ["external", "apis", "databases", "services"],  
-  "languages": {"language": total_file_count}
-}
-
-IMPORTANT: 
-- Be specific about the business domain, not just the technology
-- Look for clues in component names (UserProfile, ProductCatalog, OrderHistory, etc.)
-- Analyze route patterns (/products, /checkout, /dashboard, etc.)
-- Consider data models (User, Order, Product, etc.) to understand the domain
-- If unclear, make an educated guess based on available evidence
-- Keep purpose concise but specific (2-3 lines maximum)
-
-Project path: %s
-Folder summaries: %s`, projectPath, string(summariesJSON))
+	data := prompts.ProjectData{Path: projectPath, FolderSummaries: string(summariesJSON)}
+	if docs != nil {
+		docsJSON, _ := json.Marshal(docs)
+		data.Documentation = string(docsJSON)
+	}
+	return data
 }
 
-func (c *Client) buildDetailedAnalysisPrompt(projectPath string, folderSummaries map[string]FolderSummary, fileSummaries map[string]FileSummary, importantFiles map[string]string) string {
-	// Convert summaries to JSON for the prompt
+func repositoryPromptData(folderSummaries map[string]FolderSummary, fileSummaries map[string]FileSummary, importantFiles map[string]string) prompts.RepositoryData {
 	folderSummariesJSON, _ := json.Marshal(folderSummaries)
 	fileSummariesJSON, _ := json.Marshal(fileSummaries)
 	importantFilesJSON, _ := json.Marshal(importantFiles)
-	
-	return fmt.Sprintf(`You are given structured summaries of a code repository (per-file and per-folder), plus key files (README, go.mod/package.json, docker/k8s manifests). 
-Determine:
-1) repo_summary_line: one concise sentence describing what the repo does.
-2) architecture: "monolith" or "microservices". If unclear, choose "monolith".
-3) repo_layout: "single-repo" or "monorepo".
-4) main_stacks: top-level stacks (language + core runtimes/frameworks) only; exclude minor libs and devtools.
-5) monorepo_services: IF repo_layout == "monorepo", list each deployable service with {name, path, language, short_purpose}; otherwise [].
-6) evidence_paths: file or directory paths that justify your answers (README, go.mod, docker-compose.yml, k8s manifests, turbo.json, lerna.json, pnpm-workspace.yaml, go.work, apps/*, services/*, cmd/*, etc.).
-7) confidence: 0.0–1.0 reflecting certainty.
-
-Rules:
-- Architecture vs Layout: "monorepo" is not an architecture. Report architecture separately as monolith/microservices, and layout as single-repo/monorepo.
-- Main stacks extraction:
-  - Go: go.mod modules and major imports (e.g., echo/gin/grpc, gorm/sqlx, kafka clients).
-  - JS/TS: package.json "dependencies" (frameworks like express/fastify/nestjs, prisma, knex).
-  - Python: pyproject/requirements (fastapi/django/flask, sqlalchemy).
-  - Java: build.gradle/pom.xml (spring-boot, vertx).
-  - Infra: docker-compose (services), k8s Deployments, terraform modules.
-- Treat dev/test-only deps as non-stacks (linters, formatters, test libs).
-- Service detection (monorepo):
-  - Evidence: top-level apps/ or services/ directories, multiple cmd/* binaries, multiple Dockerfiles, workspace files (lerna/turbo/nx/pnpm-workspace/go.work), compose with multiple services, k8s with multiple Deployments.
-  - Name = directory or README heading; short_purpose from that service's README or top comment.
-- Be conservative: if conflicting signals, prefer fewer stacks and lower confidence.
-- Output compact JSON only; no markdown.
-
-Inputs:
-- project_summaries: %s
-- folder_summaries: %s
-- important_files: %s
-
-Output schema:
-{
-  "repo_summary_line": "string",
-  "architecture": "monolith" | "microservices",
-  "repo_layout": "single-repo" | "monorepo",
-  "main_stacks": ["string", ...],
-  "monorepo_services": [
-    {"name": "string", "path": "string", "language": "string", "short_purpose": "string"}
-  ],
-  "evidence_paths": ["string", ...],
-  "confidence": 0.0
-}`, string(fileSummariesJSON), string(folderSummariesJSON), string(importantFilesJSON))
+	return prompts.RepositoryData{
+		FileSummaries:   string(fileSummariesJSON),
+		FolderSummaries: string(folderSummariesJSON),
+		ImportantFiles:  string(importantFilesJSON),
+	}
 }
 
 // AnalyzeFileLightweight provides brief file analysis optimized for speed
 func (c *Client) AnalyzeFileLightweight(ctx context.Context, filePath, content string) (*FileSummary, error) {
-	// Wait for rate limiter
-	if err := c.rateLimiter.Wait(ctx); err != nil {
-		return nil, fmt.Errorf("rate limit error: %v", err)
-	}
-
 	// Truncate content for faster analysis - just get the essence
 	truncatedContent := content
-	if len(content) > 2000 { // Much shorter than detailed analysis
-		truncatedContent = content[:2000] + "\n... [truncated for speed]"
+	if limit := c.config.Limits.FilePromptChars; len(content) > limit { // Much shorter than detailed analysis
+		truncatedContent = content[:limit] + "\n... [truncated for speed]"
 	}
 
-	prompt := c.buildLightweightFilePrompt(filePath, truncatedContent)
+	messages, err := c.Messages(prompts.FileLightweight, prompts.FileData{Path: filePath, Content: truncatedContent}, false)
+	if err != nil {
+		return nil, err
+	}
 	
-	resp, err := c.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+	var summary FileSummary
+	if err := c.CreateJSONCompletion(ctx, openai.ChatCompletionRequest{
 		Model:       c.config.OpenAI.Model,
 		Temperature: 0.1, // Lower temperature for faster, consistent responses
 		MaxTokens:   300,  // Much shorter response - just the essentials
-		Messages: []openai.ChatCompletionMessage{
-			{
-				Role:    openai.ChatMessageRoleSystem,
-				Content: "You are a code analyzer. Provide ONLY a brief JSON summary focused on file purpose and key elements. Be concise and fast.",
-			},
-			{
-				Role:    openai.ChatMessageRoleUser,
-				Content: prompt,
-			},
-		},
+		Messages:    messages,
 		ResponseFormat: &openai.ChatCompletionResponseFormat{
 			Type: openai.ChatCompletionResponseFormatTypeJSONObject,
 		},
-	})
-
-	if err != nil {
-		return nil, fmt.Errorf("OpenAI API error: %v", err)
-	}
-
-	if len(resp.Choices) == 0 {
-		return nil, fmt.Errorf("no response from OpenAI")
-	}
-
-	var summary FileSummary
-	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &summary); err != nil {
-		return nil, fmt.Errorf("failed to parse response JSON: %v", err)
+	}, &summary); err != nil {
+		return nil, err
 	}
 
 	return &summary, nil
 }
 
-// buildLightweightFilePrompt creates a minimal prompt for fast file analysis
-func (c *Client) buildLightweightFilePrompt(filePath, content string) string {
-	return fmt.Sprintf(`Analyze this file quickly and return ONLY a brief JSON summary:
-
-File: %s
-Content (truncated):
-%s
-
-Return JSON with ONLY these fields:
-{
-  "language": "detected language",
-  "purpose": "one sentence describing what this file does",
-  "key_types": ["main classes/functions (max 3)"],
-  "dependencies": ["key imports (max 3)"],
-  "complexity": "low|medium|high"
+// BatchFile is one small file sent in a batched summarization prompt
+type BatchFile struct {
+	Path    string
+	Content string
 }
 
-Be fast and concise. Focus on architectural relevance only.`, filePath, content)
+// AnalyzeFilesBatch summarizes several small files in one request. The result is keyed by path;
+// files the model skipped or misnamed are absent so the caller can analyze them individually.
+func (c *Client) AnalyzeFilesBatch(ctx context.Context, files []BatchFile) (map[string]*FileSummary, error) {
+	var parsed struct {
+		Files []struct {
+			Path string `json:"path"`
+			FileSummary
+		} `json:"files"`
+	}
+	batch := prompts.BatchData{}
+	for _, file := range files {
+		batch.Files = append(batch.Files, prompts.FileData{Path: file.Path, Content: file.Content})
+	}
+	messages, err := c.Messages(prompts.FileBatch, batch, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.CreateJSONCompletion(ctx, openai.ChatCompletionRequest{
+		Model:       c.config.OpenAI.Model,
+		Temperature: 0.1,
+		MaxTokens:   200*len(files) + 100, // Roughly a lightweight summary per file
+		Messages:    messages,
+		ResponseFormat: &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONObject,
+		},
+	}, &parsed); err != nil {
+		return nil, err
+	}
+
+	requested := make(map[string]bool, len(files))
+	for _, file := range files {
+		requested[file.Path] = true
+	}
+	summaries := make(map[string]*FileSummary, len(parsed.Files))
+	for _, entry := range parsed.Files {
+		if requested[entry.Path] && summaries[entry.Path] == nil {
+			summary := entry.FileSummary
+			summaries[entry.Path] = &summary
+		}
+	}
+	return summaries, nil
 }
@@ -0,0 +1,45 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// DefaultEmbeddingModel is the embedding model used when none is configured
+const DefaultEmbeddingModel = "text-embedding-3-small"
+
+// Embed returns the embedding vector of each text, in order. Embeddings are not cached and wait
+// for the rate limiter like any other API request.
+func (c *Client) Embed(ctx context.Context, model string, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	if model == "" {
+		model = DefaultEmbeddingModel
+	}
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return nil, fmt.Errorf("rate limit error: %v", err)
+	}
+	started := time.Now()
+	resp, err := c.client.CreateEmbeddings(ctx, openai.EmbeddingRequestStrings{Input: texts, Model: openai.EmbeddingModel(model)})
+	c.observeCall(time.Since(started), err)
+	if err != nil {
+		return nil, fmt.Errorf("embedding request failed: %v", err)
+	}
+
+	vectors := make([][]float32, len(texts))
+	for _, data := range resp.Data {
+		if data.Index >= 0 && data.Index < len(vectors) {
+			vectors[data.Index] = data.Embedding
+		}
+	}
+	for i, vector := range vectors {
+		if len(vector) == 0 {
+			return nil, fmt.Errorf("no embedding returned for input %d of %d", i+1, len(texts))
+		}
+	}
+	return vectors, nil
+}
@@ -0,0 +1,356 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// RepairStats counts how structured LLM responses were turned into Go values
+type RepairStats struct {
+	Clean    int64 `json:"clean"`    // Decoded as returned
+	Repaired int64 `json:"repaired"` // Decoded after deterministic repair
+	Reasked  int64 `json:"reasked"`  // Decoded after one re-ask
+	Failed   int64 `json:"failed"`   // Dropped
+}
+
+// Since returns the responses counted after an earlier snapshot
+func (s RepairStats) Since(before RepairStats) RepairStats {
+	return RepairStats{
+		Clean:    s.Clean - before.Clean,
+		Repaired: s.Repaired - before.Repaired,
+		Reasked:  s.Reasked - before.Reasked,
+		Failed:   s.Failed - before.Failed,
+	}
+}
+
+// CreateJSONCompletion sends a request whose answer must decode into v. A response that cannot be
+// decoded even after repair is dropped from the prompt cache and asked for once more, with the
+// problem spelled out, before giving up.
+func (c *Client) CreateJSONCompletion(ctx context.Context, req openai.ChatCompletionRequest, v interface{}) error {
+	content, err := c.completionContent(ctx, req)
+	if err != nil {
+		return err
+	}
+	repaired, decodeErr := DecodeJSON(content, v)
+	if decodeErr == nil {
+		if repaired {
+			c.counters.jsonRepaired.Add(1)
+		} else {
+			c.counters.jsonClean.Add(1)
+		}
+		return nil
+	}
+	c.promptCache.remove(promptCacheKey(c.localized(req)))
+
+	fmt.Printf("⚠️  LLM response was not usable JSON (%v), asking once more\n", decodeErr)
+	reask := req
+	reask.Temperature = 0
+	reask.Messages = append(append([]openai.ChatCompletionMessage{}, req.Messages...),
+		openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: content},
+		openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleUser,
+			Content: fmt.Sprintf("Your previous reply could not be used: %v. Reply again with ONLY the corrected JSON, matching the requested structure exactly.", decodeErr),
+		},
+	)
+	content, err = c.completionContent(ctx, reask)
+	if err == nil {
+		if _, err = DecodeJSON(content, v); err == nil {
+			c.counters.jsonReasked.Add(1)
+			return nil
+		}
+		c.promptCache.remove(promptCacheKey(c.localized(reask)))
+	}
+	c.counters.jsonFailed.Add(1)
+	return fmt.Errorf("failed to parse response JSON: %v", decodeErr)
+}
+
+func (c *Client) completionContent(ctx context.Context, req openai.ChatCompletionRequest) (string, error) {
+	resp, err := c.CreateChatCompletion(ctx, req)
+	if err != nil {
+		return "", fmt.Errorf("OpenAI API error: %v", err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("no response from OpenAI")
+	}
+	return resp.Choices[0].Message.Content, nil
+}
+
+// DecodeJSON decodes an LLM response into v after checking it against v's Go type.
+// Markdown fences, prose around the JSON and trailing commas are stripped, and values of a
+// near-miss type (a string where a list is expected, a number in quotes, an array wrapped in
+// a single-key object) are coerced. repaired reports whether any of that was needed.
+func DecodeJSON(content string, v interface{}) (repaired bool, err error) {
+	raw, parseErr := parseLenient(content)
+	if parseErr != nil {
+		text := repairJSONText(content)
+		if raw, err = parseLenient(text); err != nil {
+			return false, fmt.Errorf("invalid JSON: %v", parseErr)
+		}
+		repaired = true
+	}
+
+	var problems []string
+	conformed, changed := conform(raw, reflect.TypeOf(v), "$", &problems)
+	if len(problems) > 0 {
+		return repaired, fmt.Errorf("response does not match the expected schema: %s", strings.Join(problems, "; "))
+	}
+
+	data, err := json.Marshal(conformed)
+	if err != nil {
+		return repaired, err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return repaired, err
+	}
+	return repaired || changed, nil
+}
+
+func parseLenient(text string) (interface{}, error) {
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+	var raw interface{}
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("unexpected content after JSON value")
+	}
+	return raw, nil
+}
+
+// repairJSONText cuts the outermost JSON value out of surrounding fences or prose and drops trailing commas
+func repairJSONText(text string) string {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "```") {
+		if newline := strings.Index(text, "\n"); newline >= 0 {
+			text = text[newline+1:]
+		}
+		text = strings.TrimSuffix(strings.TrimSpace(text), "```")
+	}
+
+	start := strings.IndexAny(text, "{[")
+	if start >= 0 {
+		closer := byte('}')
+		if text[start] == '[' {
+			closer = ']'
+		}
+		if end := strings.LastIndexByte(text, closer); end > start {
+			text = text[start : end+1]
+		}
+	}
+	return removeTrailingCommas(text)
+}
+
+// removeTrailingCommas deletes commas that directly precede a closing bracket, leaving string contents alone
+func removeTrailingCommas(text string) string {
+	var b bytes.Buffer
+	inString, escaped := false, false
+	for i := 0; i < len(text); i++ {
+		ch := text[i]
+		if inString {
+			b.WriteByte(ch)
+			if escaped {
+				escaped = false
+			} else if ch == '\\' {
+				escaped = true
+			} else if ch == '"' {
+				inString = false
+			}
+			continue
+		}
+		if ch == '"' {
+			inString = true
+		} else if ch == ',' {
+			next := strings.TrimLeft(text[i+1:], " \t\r\n")
+			if next != "" && (next[0] == '}' || next[0] == ']') {
+				continue
+			}
+		}
+		b.WriteByte(ch)
+	}
+	return b.String()
+}
+
+// conform checks a decoded value against a Go type, coercing near misses and recording what cannot be fixed
+func conform(value interface{}, t reflect.Type, path string, problems *[]string) (interface{}, bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if value == nil {
+		return nil, false
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			*problems = append(*problems, fmt.Sprintf("%s: expected object, got %s", path, jsonKind(value)))
+			return value, false
+		}
+		changed := false
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.Anonymous && field.Type.Kind() == reflect.Struct {
+				if _, embeddedChanged := conform(object, field.Type, path, problems); embeddedChanged {
+					changed = true
+				}
+				continue
+			}
+			name := jsonFieldName(field)
+			if name == "" {
+				continue
+			}
+			if fieldValue, present := object[name]; present {
+				conformed, fieldChanged := conform(fieldValue, field.Type, path+"."+name, problems)
+				object[name] = conformed
+				changed = changed || fieldChanged
+			}
+		}
+		return object, changed
+
+	case reflect.Slice, reflect.Array:
+		switch typed := value.(type) {
+		case []interface{}:
+			changed := false
+			for i, item := range typed {
+				conformed, itemChanged := conform(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), problems)
+				typed[i] = conformed
+				changed = changed || itemChanged
+			}
+			return typed, changed
+		case map[string]interface{}:
+			// JSON mode forces an object, so arrays often arrive as {"questions": [...]}
+			if len(typed) == 1 {
+				for _, inner := range typed {
+					if list, ok := inner.([]interface{}); ok {
+						conformed, _ := conform(list, t, path, problems)
+						return conformed, true
+					}
+				}
+			}
+		}
+		conformed, _ := conform(value, t.Elem(), path+"[0]", problems)
+		return []interface{}{conformed}, true
+
+	case reflect.Map:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			*problems = append(*problems, fmt.Sprintf("%s: expected object, got %s", path, jsonKind(value)))
+			return value, false
+		}
+		changed := false
+		for key, item := range object {
+			conformed, itemChanged := conform(item, t.Elem(), path+"."+key, problems)
+			object[key] = conformed
+			changed = changed || itemChanged
+		}
+		return object, changed
+
+	case reflect.String:
+		switch typed := value.(type) {
+		case string:
+			return typed, false
+		case json.Number:
+			return typed.String(), true
+		case bool:
+			return strconv.FormatBool(typed), true
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if number, ok := numberValue(value); ok {
+			if _, err := strconv.ParseInt(number.String(), 10, 64); err == nil {
+				return number, value != interface{}(number)
+			}
+			if f, err := number.Float64(); err == nil {
+				return json.Number(strconv.FormatInt(int64(f), 10)), true
+			}
+		}
+
+	case reflect.Float32, reflect.Float64:
+		if number, ok := numberValue(value); ok {
+			return number, value != interface{}(number)
+		}
+
+	case reflect.Bool:
+		switch typed := value.(type) {
+		case bool:
+			return typed, false
+		case string:
+			if parsed, err := strconv.ParseBool(strings.TrimSpace(typed)); err == nil {
+				return parsed, true
+			}
+		}
+
+	case reflect.Interface:
+		return value, false
+	}
+
+	*problems = append(*problems, fmt.Sprintf("%s: expected %s, got %s", path, schemaKind(t), jsonKind(value)))
+	return value, false
+}
+
+// numberValue accepts JSON numbers and numbers quoted as strings
+func numberValue(value interface{}) (json.Number, bool) {
+	switch typed := value.(type) {
+	case json.Number:
+		return typed, true
+	case string:
+		if _, err := strconv.ParseFloat(strings.TrimSpace(typed), 64); err == nil {
+			return json.Number(strings.TrimSpace(typed)), true
+		}
+	}
+	return "", false
+}
+
+func jsonFieldName(field reflect.StructField) string {
+	if field.PkgPath != "" {
+		return ""
+	}
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return ""
+	}
+	if name := strings.Split(tag, ",")[0]; name != "" {
+		return name
+	}
+	return field.Name
+}
+
+// schemaKind names the JSON type a Go type decodes from
+func schemaKind(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Struct, reflect.Map:
+		return "object"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	}
+	return "number"
+}
+
+func jsonKind(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	}
+	return "null"
+}
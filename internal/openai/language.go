@@ -0,0 +1,70 @@
+package openai
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Languages maps the supported output language codes to the names used in prompts
+var Languages = map[string]string{
+	"de": "German",
+	"en": "English",
+	"es": "Spanish",
+	"fr": "French",
+	"id": "Indonesian",
+	"it": "Italian",
+	"ja": "Japanese",
+	"ko": "Korean",
+	"pt": "Portuguese",
+	"ru": "Russian",
+	"th": "Thai",
+	"vi": "Vietnamese",
+	"zh": "Simplified Chinese",
+}
+
+// NormalizeLanguage lowercases a language code and checks it is supported; English and an empty
+// code both mean the default, which is returned as ""
+func NormalizeLanguage(code string) (string, error) {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if code == "" || code == "en" {
+		return "", nil
+	}
+	if _, ok := Languages[code]; !ok {
+		codes := make([]string, 0, len(Languages))
+		for known := range Languages {
+			codes = append(codes, known)
+		}
+		sort.Strings(codes)
+		return "", fmt.Errorf("unsupported language %q (supported: %s)", code, strings.Join(codes, ", "))
+	}
+	return code, nil
+}
+
+// SetLanguage makes every later completion answer its prose in the given language. The code must
+// already be normalized; "" restores English.
+func (c *Client) SetLanguage(code string) {
+	c.language = code
+}
+
+// localized appends the output language instruction to a request's system prompt. Structured
+// values stay as they are in the source so paths, commands and SQL can still be used verbatim.
+func (c *Client) localized(req openai.ChatCompletionRequest) openai.ChatCompletionRequest {
+	if c.language == "" {
+		return req
+	}
+	instruction := fmt.Sprintf("Write every natural-language value in your answer (summaries, purposes, descriptions, explanations, questions, answers and definitions) in %s. "+
+		"Keep JSON keys, enumerated values, file paths, identifiers, code, shell commands, SQL, URLs and the names of packages, services, tables and columns exactly as they appear in the source, untranslated.",
+		Languages[c.language])
+
+	messages := append([]openai.ChatCompletionMessage{}, req.Messages...)
+	if len(messages) > 0 && messages[0].Role == openai.ChatMessageRoleSystem {
+		messages[0].Content += "\n\n" + instruction
+	} else {
+		messages = append([]openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleSystem, Content: instruction}}, messages...)
+	}
+	req.Messages = messages
+	return req
+}
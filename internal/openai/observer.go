@@ -0,0 +1,41 @@
+package openai
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// CallObserver is told the latency and outcome of every request that reaches the API
+type CallObserver func(latency time.Duration, err error)
+
+// ObserveCalls reports each API request to observer until it is replaced; nil stops reporting.
+// Requests answered from the prompt cache are not reported.
+func (c *Client) ObserveCalls(observer CallObserver) {
+	if observer == nil {
+		c.observer.Store(nil)
+		return
+	}
+	c.observer.Store(&observer)
+}
+
+func (c *Client) observeCall(latency time.Duration, err error) {
+	if observer := c.observer.Load(); observer != nil {
+		(*observer)(latency, err)
+	}
+}
+
+// IsRateLimited reports whether the API rejected a request with 429 Too Many Requests
+func IsRateLimited(err error) bool {
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.HTTPStatusCode == http.StatusTooManyRequests
+	}
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) {
+		return reqErr.HTTPStatusCode == http.StatusTooManyRequests
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package openai
+
+import (
+	"sort"
+	"strings"
+)
+
+// Price is what a model charges, in US dollars per million tokens
+type Price struct {
+	Input  float64 `json:"input_usd_per_million"`
+	Output float64 `json:"output_usd_per_million"`
+}
+
+// Cost prices a number of input and output tokens
+func (p Price) Cost(inputTokens, outputTokens int) float64 {
+	return (float64(inputTokens)*p.Input + float64(outputTokens)*p.Output) / 1e6
+}
+
+// modelPrices are the published list prices of the chat models the analyzer is commonly run with
+var modelPrices = map[string]Price{
+	"gpt-4o-mini":   {Input: 0.15, Output: 0.60},
+	"gpt-4o":        {Input: 2.50, Output: 10.00},
+	"gpt-4.1-nano":  {Input: 0.10, Output: 0.40},
+	"gpt-4.1-mini":  {Input: 0.40, Output: 1.60},
+	"gpt-4.1":       {Input: 2.00, Output: 8.00},
+	"gpt-4-turbo":   {Input: 10.00, Output: 30.00},
+	"gpt-4":         {Input: 30.00, Output: 60.00},
+	"gpt-3.5-turbo": {Input: 0.50, Output: 1.50},
+	"o4-mini":       {Input: 1.10, Output: 4.40},
+	"o3-mini":       {Input: 1.10, Output: 4.40},
+	"o3":            {Input: 2.00, Output: 8.00},
+}
+
+// PriceFor returns the list price of a model. Dated snapshots such as gpt-4o-2024-08-06 match
+// the longest known model name they start with.
+func PriceFor(model string) (Price, bool) {
+	model = strings.ToLower(strings.TrimSpace(model))
+	names := make([]string, 0, len(modelPrices))
+	for name := range modelPrices {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+	for _, name := range names {
+		if model == name || strings.HasPrefix(model, name+"-") {
+			return modelPrices[name], true
+		}
+	}
+	return Price{}, false
+}
@@ -0,0 +1,179 @@
+package openai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+	"repo-explanation/internal/atrest"
+	"repo-explanation/internal/diskcache"
+)
+
+// CacheStats counts LLM requests answered from the prompt cache versus sent to the API
+type CacheStats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+}
+
+// Since returns the requests counted after an earlier snapshot
+func (s CacheStats) Since(before CacheStats) CacheStats {
+	return CacheStats{Hits: s.Hits - before.Hits, Misses: s.Misses - before.Misses}
+}
+
+// HitRate is the fraction of requests answered from the cache
+func (s CacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// promptCache stores chat completions on disk keyed by a hash of everything that shapes the answer
+type promptCache struct {
+	enabled bool
+	dir     string
+	ttl     time.Duration
+	budget  *diskcache.Budget // Shared with the summary cache, whose directory holds this one
+	sealer  *atrest.Sealer    // Encrypts entries when encryption is enabled
+}
+
+// promptCacheEntry is one cached completion
+type promptCacheEntry struct {
+	Model     string                        `json:"model"`
+	Timestamp time.Time                     `json:"timestamp"`
+	Response  openai.ChatCompletionResponse `json:"response"`
+}
+
+// CreateChatCompletion sends a chat request, answering repeats of an identical request from the prompt cache.
+// Only requests that reach the API wait for the rate limiter. Every request goes to the audit log when it is on.
+func (c *Client) CreateChatCompletion(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	req = c.localized(req)
+	key := promptCacheKey(req)
+	if resp, ok := c.promptCache.get(key); ok {
+		c.counters.cacheHits.Add(1)
+		c.recordCall(req, resp, 0, true, nil)
+		return resp, nil
+	}
+	if c.promptCache.enabled {
+		c.counters.cacheMisses.Add(1)
+	}
+
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return openai.ChatCompletionResponse{}, fmt.Errorf("rate limit error: %v", err)
+	}
+	started := time.Now()
+	resp, err := c.client.CreateChatCompletion(ctx, req)
+	latency := time.Since(started)
+	c.observeCall(latency, err)
+	c.recordCall(req, resp, latency, false, err)
+	if err != nil {
+		return resp, err
+	}
+
+	// Empty and truncated answers are not worth replaying
+	if len(resp.Choices) > 0 && resp.Choices[0].FinishReason != openai.FinishReasonLength {
+		if err := c.promptCache.set(key, req.Model, resp); err != nil {
+			fmt.Printf("⚠️  Failed to cache LLM response: %v\n", err)
+		}
+	}
+	return resp, nil
+}
+
+// promptCacheKey hashes the model, sampling parameters, response format and messages
+func promptCacheKey(req openai.ChatCompletionRequest) string {
+	type message struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	}
+	keyed := struct {
+		Model          string    `json:"model"`
+		Temperature    float32   `json:"temperature"`
+		MaxTokens      int       `json:"max_tokens"`
+		ResponseFormat string    `json:"response_format"`
+		Messages       []message `json:"messages"`
+	}{
+		Model:       req.Model,
+		Temperature: req.Temperature,
+		MaxTokens:   req.MaxTokens,
+	}
+	if req.ResponseFormat != nil {
+		keyed.ResponseFormat = string(req.ResponseFormat.Type)
+	}
+	for _, msg := range req.Messages {
+		keyed.Messages = append(keyed.Messages, message{Role: msg.Role, Content: msg.Content})
+	}
+
+	data, _ := json.Marshal(keyed)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func (pc promptCache) path(key string) string {
+	return filepath.Join(pc.dir, key[:2], key+".json")
+}
+
+// get returns a cached completion that has not outlived the TTL
+func (pc promptCache) get(key string) (openai.ChatCompletionResponse, bool) {
+	if !pc.enabled {
+		return openai.ChatCompletionResponse{}, false
+	}
+	data, err := pc.sealer.ReadFile(pc.path(key))
+	if err != nil {
+		return openai.ChatCompletionResponse{}, false
+	}
+	var entry promptCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || time.Since(entry.Timestamp) > pc.ttl {
+		return openai.ChatCompletionResponse{}, false
+	}
+	diskcache.Touch(pc.path(key))
+	return entry.Response, true
+}
+
+// set stores a completion, writing through a temporary file so concurrent readers never see a partial entry
+func (pc promptCache) set(key, model string, resp openai.ChatCompletionResponse) error {
+	if !pc.enabled {
+		return nil
+	}
+	path := pc.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(promptCacheEntry{Model: model, Timestamp: time.Now(), Response: resp})
+	if err == nil {
+		data, err = pc.sealer.Seal(data)
+	}
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	pc.budget.Wrote(int64(len(data)))
+	return nil
+}
+
+// remove forgets a cached completion, e.g. one whose content turned out to be unusable
+func (pc promptCache) remove(key string) {
+	if pc.enabled {
+		os.Remove(pc.path(key))
+	}
+}
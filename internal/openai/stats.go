@@ -0,0 +1,38 @@
+package openai
+
+import "sync/atomic"
+
+// llmCounters count a client's prompt cache lookups and structured response outcomes. Each
+// analysis has its own client, so concurrent analyses do not count each other's requests.
+type llmCounters struct {
+	cacheHits    atomic.Int64
+	cacheMisses  atomic.Int64
+	jsonClean    atomic.Int64
+	jsonRepaired atomic.Int64
+	jsonReasked  atomic.Int64
+	jsonFailed   atomic.Int64
+}
+
+// LLMStats is a snapshot of a client's LLM counters, so a run can report its own share
+type LLMStats struct {
+	Cache CacheStats
+	JSON  RepairStats
+}
+
+// LLMStats returns the client's counters as they stand now
+func (c *Client) LLMStats() LLMStats {
+	return LLMStats{
+		Cache: CacheStats{Hits: c.counters.cacheHits.Load(), Misses: c.counters.cacheMisses.Load()},
+		JSON: RepairStats{
+			Clean:    c.counters.jsonClean.Load(),
+			Repaired: c.counters.jsonRepaired.Load(),
+			Reasked:  c.counters.jsonReasked.Load(),
+			Failed:   c.counters.jsonFailed.Load(),
+		},
+	}
+}
+
+// Since returns what was counted after an earlier snapshot
+func (s LLMStats) Since(before LLMStats) LLMStats {
+	return LLMStats{Cache: s.Cache.Since(before.Cache), JSON: s.JSON.Since(before.JSON)}
+}
@@ -0,0 +1,39 @@
+package openai
+
+import (
+	"net/http"
+	"net/url"
+
+	"repo-explanation/config"
+)
+
+// newTransport returns the transport of every LLM request: through openai.proxy when one is set
+// (otherwise the HTTPS_PROXY and NO_PROXY environment), with openai.headers added
+func newTransport(cfg config.OpenAIConfig) http.RoundTripper {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.Proxy != "" {
+		// Validated at startup
+		if proxyURL, err := url.Parse(cfg.Proxy); err == nil {
+			transport.Proxy = http.ProxyURL(proxyURL)
+		}
+	}
+	if len(cfg.Headers) == 0 {
+		return transport
+	}
+	return &headerTransport{base: transport, headers: cfg.Headers}
+}
+
+// headerTransport adds fixed headers to each request, for gateways that route or authorize by header
+type headerTransport struct {
+	base    http.RoundTripper
+	headers map[string]string
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// A RoundTripper must not modify the caller's request
+	req = req.Clone(req.Context())
+	for name, value := range t.headers {
+		req.Header.Set(name, value)
+	}
+	return t.base.RoundTrip(req)
+}
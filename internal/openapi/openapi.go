@@ -0,0 +1,381 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Document is an OpenAPI 3.0 document
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+// Info describes the API
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem holds the operations of one path
+type PathItem struct {
+	Get  *Operation `json:"get,omitempty"`
+	Post *Operation `json:"post,omitempty"`
+}
+
+// Operation documents one method on one path
+type Operation struct {
+	OperationID string              `json:"operationId,omitempty"`
+	Summary     string              `json:"summary,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Tags        []string            `json:"tags,omitempty"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+// Parameter is a path or query parameter
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+// RequestBody is the JSON body of a request
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response is one documented status code
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType pairs a content type with its schema
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components holds the named schemas referenced from operations
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// Schema is the subset of JSON Schema used to describe Go types
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// Endpoint describes a route in terms of Go values; the builder derives the schemas from their types
+type Endpoint struct {
+	Summary     string
+	Description string
+	Tag         string
+	Query       interface{}         // Struct whose `query` tagged fields are the query parameters
+	Request     interface{}         // JSON request body
+	Form        interface{}         // Struct whose `form` tagged fields make up a multipart/form-data body
+	Files       map[string]string   // Required file parts of the Form body, by field name, with their description
+	Responses   map[int]interface{} // Response body per status code
+	Stream      bool                // The success response is a text/event-stream of the 200 body type
+}
+
+// Builder collects endpoints into a Document
+type Builder struct {
+	doc   *Document
+	names map[reflect.Type]string
+}
+
+// NewBuilder starts an empty document
+func NewBuilder(title, version, description string) *Builder {
+	return &Builder{
+		doc: &Document{
+			OpenAPI:    "3.0.3",
+			Info:       Info{Title: title, Version: version, Description: description},
+			Paths:      make(map[string]*PathItem),
+			Components: Components{Schemas: make(map[string]*Schema)},
+		},
+		names: make(map[reflect.Type]string),
+	}
+}
+
+// Document returns the document built so far
+func (b *Builder) Document() *Document {
+	return b.doc
+}
+
+// Add documents method on route; only GET and POST are used by this API. Route segments written
+// as :name, as echo routes them, become required path parameters.
+func (b *Builder) Add(method, route string, endpoint Endpoint) error {
+	template, params := pathParameters(route)
+	item := b.doc.Paths[template]
+	if item == nil {
+		item = &PathItem{}
+		b.doc.Paths[template] = item
+	}
+
+	op := &Operation{
+		OperationID: operationID(method, route),
+		Summary:     endpoint.Summary,
+		Description: endpoint.Description,
+		Parameters:  params,
+		Responses:   make(map[string]Response),
+	}
+	if endpoint.Tag != "" {
+		op.Tags = []string{endpoint.Tag}
+	}
+	if endpoint.Query != nil {
+		op.Parameters = append(op.Parameters, b.queryParameters(reflect.TypeOf(endpoint.Query))...)
+	}
+	if endpoint.Request != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{"application/json": {Schema: b.schemaFor(reflect.TypeOf(endpoint.Request))}},
+		}
+	}
+
+	if endpoint.Form != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{"multipart/form-data": {Schema: b.formSchema(reflect.TypeOf(endpoint.Form), endpoint.Files)}},
+		}
+	}
+
+	statuses := make([]int, 0, len(endpoint.Responses))
+	for status := range endpoint.Responses {
+		statuses = append(statuses, status)
+	}
+	sort.Ints(statuses)
+	for _, status := range statuses {
+		response := Response{Description: http.StatusText(status)}
+		if body := endpoint.Responses[status]; body != nil {
+			contentType := "application/json"
+			if endpoint.Stream && status == http.StatusOK {
+				contentType = "text/event-stream"
+			}
+			response.Content = map[string]MediaType{contentType: {Schema: b.schemaFor(reflect.TypeOf(body))}}
+		}
+		op.Responses[fmt.Sprintf("%d", status)] = response
+	}
+
+	switch method {
+	case http.MethodGet:
+		item.Get = op
+	case http.MethodPost:
+		item.Post = op
+	default:
+		return fmt.Errorf("unsupported method %s for %s", method, route)
+	}
+	return nil
+}
+
+// pathParameters turns "/api/v1/repos/:id/timeline" into "/api/v1/repos/{id}/timeline" and its id parameter
+func pathParameters(route string) (string, []Parameter) {
+	segments := strings.Split(route, "/")
+	var params []Parameter
+	for i, segment := range segments {
+		if name, ok := strings.CutPrefix(segment, ":"); ok && name != "" {
+			segments[i] = "{" + name + "}"
+			params = append(params, Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// operationID turns "POST /api/v1/analyze/stream" into "postAnalyzeStream" and
+// "GET /api/v1/repos/:id/timeline" into "getReposIdTimeline"
+func operationID(method, route string) string {
+	id := strings.ToLower(method)
+	for _, part := range strings.FieldsFunc(route, func(r rune) bool { return r == '/' || r == '.' || r == '-' || r == '_' || r == ':' }) {
+		if part == "api" || (len(part) > 1 && part[0] == 'v' && strings.Trim(part[1:], "0123456789") == "") {
+			continue
+		}
+		id += strings.ToUpper(part[:1]) + part[1:]
+	}
+	return id
+}
+
+func (b *Builder) queryParameters(t reflect.Type) []Parameter {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	var params []Parameter
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Tag.Get("query")
+		if name == "" || field.PkgPath != "" {
+			continue
+		}
+		params = append(params, Parameter{Name: name, In: "query", Required: isRequired(field), Schema: b.schemaFor(field.Type)})
+	}
+	return params
+}
+
+// formSchema describes a multipart form of the tagged fields and the given file parts
+func (b *Builder) formSchema(t reflect.Type, files map[string]string) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Tag.Get("form")
+		if name == "" || field.PkgPath != "" {
+			continue
+		}
+		schema.Properties[name] = b.schemaFor(field.Type)
+		if isRequired(field) {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		schema.Properties[name] = &Schema{Type: "string", Format: "binary", Description: files[name]}
+		schema.Required = append(schema.Required, name)
+	}
+	return schema
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	durationType   = reflect.TypeOf(time.Duration(0))
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemaFor returns the schema of a Go type; named structs become components referenced by $ref
+func (b *Builder) schemaFor(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case durationType:
+		return &Schema{Type: "integer", Format: "int64"}
+	case rawMessageType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		name, known := b.names[t]
+		if !known {
+			name = b.componentName(t)
+			b.names[t] = name
+			// Register before filling in so recursive types end in a $ref
+			schema := &Schema{}
+			b.doc.Components.Schemas[name] = schema
+			*schema = *b.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: b.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: b.schemaFor(t.Elem())}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	}
+	// Interfaces and anything else accept any JSON value
+	return &Schema{}
+}
+
+// structSchema lists a struct's JSON properties, inlining embedded structs the way encoding/json does
+func (b *Builder) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			embedded := b.structSchema(fieldType)
+			for key, value := range embedded.Properties {
+				if _, exists := schema.Properties[key]; !exists {
+					schema.Properties[key] = value
+				}
+			}
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema.Properties[name] = b.schemaFor(field.Type)
+		if isRequired(field) {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	if len(schema.Properties) == 0 {
+		schema.Properties = nil
+	}
+	return schema
+}
+
+// componentName uses the type name, prefixed with its package when two packages share a name
+func (b *Builder) componentName(t reflect.Type) string {
+	name := t.Name()
+	for other, used := range b.names {
+		if used == name && other != t {
+			pkg := path.Base(t.PkgPath())
+			return strings.ToUpper(pkg[:1]) + pkg[1:] + name
+		}
+	}
+	return name
+}
+
+// isRequired reads the `validate:"required"` tag the request structs carry
+func isRequired(field reflect.StructField) bool {
+	for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+		if rule == "required" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package openapi
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// SchemaOf returns the schema of a Go value's type, registering the components it references
+func (b *Builder) SchemaOf(value interface{}) *Schema {
+	return b.schemaFor(reflect.TypeOf(value))
+}
+
+// Validate checks a decoded JSON value (maps, slices, float64s, ...) against a schema of this
+// document and returns one problem per mismatch, at most limit of them (0 for all). Null is
+// accepted anywhere, as Go encodes nil pointers, slices and maps.
+func (d *Document) Validate(schema *Schema, value interface{}, limit int) []string {
+	v := validator{doc: d, limit: limit}
+	v.check("$", schema, value)
+	return v.problems
+}
+
+type validator struct {
+	doc      *Document
+	limit    int
+	problems []string
+}
+
+func (v *validator) fail(at, format string, args ...interface{}) {
+	if v.limit == 0 || len(v.problems) < v.limit {
+		v.problems = append(v.problems, at+": "+fmt.Sprintf(format, args...))
+	}
+}
+
+func (v *validator) check(at string, schema *Schema, value interface{}) {
+	if schema == nil || value == nil {
+		return
+	}
+	if schema.Ref != "" {
+		name := strings.TrimPrefix(schema.Ref, "#/components/schemas/")
+		component, ok := v.doc.Components.Schemas[name]
+		if !ok {
+			v.fail(at, "unknown schema %s", schema.Ref)
+			return
+		}
+		schema = component
+	}
+
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			v.fail(at, "expected an object, got %s", jsonType(value))
+			return
+		}
+		for _, name := range schema.Required {
+			if _, ok := object[name]; !ok {
+				v.fail(at, "missing required property %s", name)
+			}
+		}
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			property, ok := schema.Properties[key]
+			switch {
+			case ok:
+				v.check(at+"."+key, property, object[key])
+			case schema.AdditionalProperties != nil:
+				v.check(at+"."+key, schema.AdditionalProperties, object[key])
+			case schema.Properties != nil:
+				v.fail(at, "unexpected property %s", key)
+			}
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			v.fail(at, "expected an array, got %s", jsonType(value))
+			return
+		}
+		for i, item := range items {
+			v.check(fmt.Sprintf("%s[%d]", at, i), schema.Items, item)
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			v.fail(at, "expected a string, got %s", jsonType(value))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			v.fail(at, "expected a boolean, got %s", jsonType(value))
+		}
+	case "integer":
+		if n, ok := value.(float64); !ok || n != math.Trunc(n) {
+			v.fail(at, "expected an integer, got %s", jsonType(value))
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			v.fail(at, "expected a number, got %s", jsonType(value))
+		}
+	}
+}
+
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "an object"
+	case []interface{}:
+		return "an array"
+	case string:
+		return "a string"
+	case bool:
+		return "a boolean"
+	case float64:
+		return "a number"
+	}
+	return fmt.Sprintf("%T", value)
+}
@@ -0,0 +1,213 @@
+package overrides
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"repo-explanation/internal/detector"
+	"repo-explanation/internal/microservices"
+	"repo-explanation/internal/portable"
+)
+
+// FileName is the override file the pipeline looks for in the repository root
+const FileName = ".repo-analyzer.yaml"
+
+// EvidenceCategory is the project type evidence entry naming the values the file pinned
+const EvidenceCategory = "User override"
+
+// File pins detection results the analyzer gets wrong. It is committed with the repository, so
+// every analysis of it, local or cloned, starts from the same corrections.
+type File struct {
+	PrimaryType   detector.ProjectType `yaml:"primary_type"`
+	SecondaryType detector.ProjectType `yaml:"secondary_type"`
+	ServicesOnly  bool                 `yaml:"services_only"` // Drop detected services the file does not list
+	Services      []Service            `yaml:"services"`
+}
+
+// Service corrects one detected service, matched by name or else by path, or adds one the
+// discovery missed. Empty fields keep the detected value.
+type Service struct {
+	Name        string `yaml:"name"`
+	Path        string `yaml:"path"`
+	EntryPoint  string `yaml:"entry_point"`
+	APIType     string `yaml:"api_type"`
+	Port        string `yaml:"port"`
+	Description string `yaml:"description"`
+	Ignore      bool   `yaml:"ignore"` // Remove the service from the results
+}
+
+// Load reads the override file in projectPath; a repository without one returns nil
+func Load(projectPath string) (*File, error) {
+	filePath := filepath.Join(projectPath, FileName)
+	data, err := os.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", FileName, err)
+	}
+
+	var file File
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to parse %s: %v", FileName, err)
+	}
+	if err := file.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s:%v", FileName, err)
+	}
+	for i := range file.Services {
+		if file.Services[i].Path != "" {
+			file.Services[i].Path = cleanPath(file.Services[i].Path)
+		}
+	}
+	return &file, nil
+}
+
+// Validate checks every entry, reporting all problems at once
+func (f *File) Validate() error {
+	var problems []string
+	if f.PrimaryType == detector.Unknown {
+		problems = append(problems, fmt.Sprintf("primary_type %q cannot be pinned; leave it out to keep detecting it", f.PrimaryType))
+	}
+	if f.SecondaryType != "" && f.SecondaryType == f.PrimaryType {
+		problems = append(problems, "secondary_type must differ from primary_type")
+	}
+	names := make(map[string]bool)
+	for i, service := range f.Services {
+		if strings.TrimSpace(service.Name) == "" && strings.TrimSpace(service.Path) == "" {
+			problems = append(problems, fmt.Sprintf("services[%d] needs a name or a path", i))
+		}
+		if service.Name != "" {
+			if names[service.Name] {
+				problems = append(problems, fmt.Sprintf("services[%d].name %q is listed twice", i, service.Name))
+			}
+			names[service.Name] = true
+		}
+		if service.Path != "" && (filepath.IsAbs(service.Path) || strings.HasPrefix(cleanPath(service.Path), "..")) {
+			problems = append(problems, fmt.Sprintf("services[%d].path must be relative to the repository root", i))
+		}
+		if service.Port != "" && strings.Trim(service.Port, "0123456789") != "" {
+			problems = append(problems, fmt.Sprintf("services[%d].port must be a number (got %q)", i, service.Port))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("\n  - %s", strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+// ApplyProjectType pins the project types the file sets, noting the detected values in the
+// result's evidence
+func (f *File) ApplyProjectType(result *detector.DetectionResult) {
+	if f == nil || result == nil || (f.PrimaryType == "" && f.SecondaryType == "") {
+		return
+	}
+	if f.PrimaryType != "" {
+		result.Evidence[EvidenceCategory] = append(result.Evidence[EvidenceCategory],
+			fmt.Sprintf("primary type %s set in %s (detected: %s)", f.PrimaryType, FileName, result.PrimaryType))
+		result.PrimaryType = f.PrimaryType
+		// The user's word is as certain as detection gets
+		result.Confidence = 10.0
+	}
+	if f.SecondaryType != "" {
+		detected := string(result.SecondaryType)
+		if detected == "" {
+			detected = "none"
+		}
+		result.Evidence[EvidenceCategory] = append(result.Evidence[EvidenceCategory],
+			fmt.Sprintf("secondary type %s set in %s (detected: %s)", f.SecondaryType, FileName, detected))
+		result.SecondaryType = f.SecondaryType
+	}
+}
+
+// ApplyServices corrects, adds and removes services as the file lists them. Every field the file
+// set is recorded in the service's UserSpecified list.
+func (f *File) ApplyServices(services []microservices.DiscoveredService) []microservices.DiscoveredService {
+	if f == nil || (len(f.Services) == 0 && !f.ServicesOnly) {
+		return services
+	}
+
+	matched := make([]bool, len(f.Services))
+	var result []microservices.DiscoveredService
+	for _, service := range services {
+		i := f.match(service)
+		if i < 0 {
+			if !f.ServicesOnly {
+				result = append(result, service)
+			}
+			continue
+		}
+		matched[i] = true
+		if f.Services[i].Ignore {
+			continue
+		}
+		result = append(result, f.Services[i].apply(service))
+	}
+
+	// Listed services the discovery did not find are added when the file says where they live
+	for i, override := range f.Services {
+		if matched[i] || override.Ignore || override.Path == "" {
+			continue
+		}
+		service := microservices.DiscoveredService{Name: override.Name, Path: override.Path, APIType: microservices.HTTPService}
+		if service.Name == "" {
+			service.Name = path.Base(override.Path)
+		}
+		service = override.apply(service)
+		service.UserSpecified = append([]string{"service"}, service.UserSpecified...)
+		result = append(result, service)
+	}
+	return result
+}
+
+// match returns the entry for a detected service: by name first, then by path
+func (f *File) match(service microservices.DiscoveredService) int {
+	for i, override := range f.Services {
+		if override.Name != "" && override.Name == service.Name {
+			return i
+		}
+	}
+	for i, override := range f.Services {
+		if override.Path != "" && override.Path == cleanPath(service.Path) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (o Service) apply(service microservices.DiscoveredService) microservices.DiscoveredService {
+	set := func(field string, target *string, value string) {
+		if value != "" {
+			*target = value
+			service.UserSpecified = append(service.UserSpecified, field)
+		}
+	}
+	// A name or path that only identifies the service pins nothing
+	if o.Name != service.Name {
+		set("name", &service.Name, o.Name)
+	}
+	if o.Path != cleanPath(service.Path) {
+		set("path", &service.Path, o.Path)
+	}
+	set("entry_point", &service.EntryPoint, o.EntryPoint)
+	set("port", &service.Port, o.Port)
+	set("description", &service.Description, o.Description)
+	if o.APIType != "" {
+		service.APIType = microservices.ServiceType(o.APIType)
+		service.UserSpecified = append(service.UserSpecified, "api_type")
+	}
+	return service
+}
+
+// cleanPath normalizes a repository-relative path for matching
+func cleanPath(p string) string {
+	p = path.Clean(portable.Slash(strings.TrimSpace(p)))
+	return strings.TrimPrefix(p, "./")
+}
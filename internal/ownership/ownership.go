@@ -0,0 +1,212 @@
+// Package ownership attributes each table of the extracted schema to the service that owns it,
+// combining where the migrations creating it live, which database each service connects to, and
+// which services declare models for it or query it. Tables used by several services are flagged.
+package ownership
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"repo-explanation/internal/database"
+	"repo-explanation/internal/filestore"
+	"repo-explanation/internal/microservices"
+	"repo-explanation/internal/portable"
+)
+
+// Evidence kinds, strongest first
+const (
+	Migration  = "migration"  // A migration in the service's folder creates the table
+	Model      = "model"      // The service declares an ORM model or entity mapped to the table
+	Connection = "connection" // The service connects to the database or schema holding the table
+	Query      = "query"      // The service's code queries the table
+)
+
+// weights rank the evidence kinds when choosing an owner
+var weights = map[string]int{Migration: 8, Model: 4, Connection: 2, Query: 1}
+
+// Evidence is one reason to tie a table to a service
+type Evidence struct {
+	Service string `json:"service"`
+	Kind    string `json:"kind"`
+	File    string `json:"file"`
+	Line    int    `json:"line,omitempty"`
+	Detail  string `json:"detail,omitempty"`
+}
+
+// Table is the ownership of one schema table
+type Table struct {
+	Table      string     `json:"table"`
+	Owner      string     `json:"owner,omitempty"` // Empty when no service shows any evidence
+	AccessedBy []string   `json:"accessed_by"`     // Every service with evidence, owner first
+	Shared     bool       `json:"shared"`          // More than one service uses the table
+	Evidence   []Evidence `json:"evidence"`
+}
+
+// Service is a service's share of the schema and the color its tables get in the ERD
+type Service struct {
+	Name      string   `json:"name"`
+	Color     string   `json:"color"`
+	Databases []string `json:"databases,omitempty"` // Databases named by its connection settings
+	Owns      int      `json:"owns"`
+}
+
+// Report maps the schema's tables to services
+type Report struct {
+	Tables   []Table   `json:"tables"`
+	Services []Service `json:"services"`
+	Shared   int       `json:"shared"`
+	Unowned  int       `json:"unowned"`
+	Summary  string    `json:"summary"`
+}
+
+// palette colors the services in the ERD; light fills keep entity text readable
+var palette = []string{
+	"#dbeafe", "#dcfce7", "#fef3c7", "#fce7f3", "#ede9fe", "#cffafe", "#ffedd5", "#e0e7ff", "#d9f99d", "#fecdd3",
+}
+
+// Directories that never hold first-party code
+var skippedDirs = map[string]bool{
+	".git": true, "node_modules": true, "vendor": true, "testdata": true, "dist": true, "build": true,
+	".next": true, "coverage": true, "__pycache__": true, ".venv": true, "venv": true,
+	"test": true, "tests": true, "__tests__": true, "spec": true,
+}
+
+var sourceExtensions = map[string]bool{
+	".go": true, ".js": true, ".jsx": true, ".ts": true, ".tsx": true, ".mjs": true, ".cjs": true,
+	".py": true, ".rb": true, ".java": true, ".kt": true, ".php": true, ".cs": true, ".prisma": true,
+}
+
+// Analyze attributes the tables of schema to services. It returns nil when there is no schema or
+// fewer than two services, since a single service trivially owns every table.
+func Analyze(files *filestore.FileStore, schema *database.DatabaseSchema, services []microservices.DiscoveredService) (*Report, error) {
+	if schema == nil || len(schema.Tables) == 0 || len(services) < 2 {
+		return nil, nil
+	}
+	fmt.Printf("🏷️  [DEBUG] Attributing %d tables to %d services\n", len(schema.Tables), len(services))
+
+	s := newScan(schema)
+	err := files.Walk(func(rel string) bool {
+		return !filestore.InDirs(rel, skippedDirs) && !isTestFile(path.Base(rel)) &&
+			classify(rel) != "" && microservices.OwningService(path.Dir(rel), services) != ""
+	}, func(rel, content string) error {
+		s.scan(classify(rel), rel, microservices.OwningService(path.Dir(rel), services), portable.Text(content))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan for table ownership: %v", err)
+	}
+
+	report := s.report(services)
+	fmt.Printf("✅ [DEBUG] %s\n", report.Summary)
+	return report, nil
+}
+
+// File kinds the scanner reads
+const (
+	kindMigration = "migration"
+	kindConfig    = "config"
+	kindSource    = "source"
+)
+
+// classify decides how a file is scanned, or "" to skip it
+func classify(rel string) string {
+	name := strings.ToLower(path.Base(rel))
+	ext := path.Ext(name)
+	switch {
+	case database.IsMigrationFile(rel):
+		return kindMigration
+	case strings.HasPrefix(name, ".env") || ext == ".properties" || ext == ".yaml" || ext == ".yml" || ext == ".toml" || ext == ".ini" ||
+		name == "config.json" || strings.HasPrefix(name, "appsettings") && ext == ".json":
+		return kindConfig
+	case sourceExtensions[ext] && !strings.Contains(strings.ToLower(rel), "migration"):
+		return kindSource
+	}
+	return ""
+}
+
+func isTestFile(name string) bool {
+	return strings.HasSuffix(name, "_test.go") || strings.Contains(name, ".test.") ||
+		strings.Contains(name, ".spec.") || strings.HasPrefix(name, "test_")
+}
+
+func summarize(report *Report) string {
+	owned := len(report.Tables) - report.Unowned
+	parts := make([]string, 0, len(report.Services))
+	for _, service := range report.Services {
+		if service.Owns > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d", service.Name, service.Owns))
+		}
+	}
+	summary := fmt.Sprintf("%d of %d tables attributed to a service", owned, len(report.Tables))
+	if len(parts) > 0 {
+		summary += " (" + strings.Join(parts, ", ") + ")"
+	}
+	return summary + fmt.Sprintf("; %d shared by several services", report.Shared)
+}
+
+// ERDClasses returns Mermaid erDiagram classDef and class statements that fill each table with
+// its owner's color and outline shared tables in red, for appending to an ERD whose entity ids
+// come from idOf
+func (r *Report) ERDClasses(idOf func(table string) string) string {
+	var b strings.Builder
+	byOwner := make(map[string][]string)
+	var shared []string
+	for _, table := range r.Tables {
+		if table.Owner != "" {
+			byOwner[table.Owner] = append(byOwner[table.Owner], idOf(table.Table))
+		}
+		if table.Shared {
+			shared = append(shared, idOf(table.Table))
+		}
+	}
+	for i, service := range r.Services {
+		if len(byOwner[service.Name]) == 0 {
+			continue
+		}
+		class := fmt.Sprintf("owner%d", i)
+		fmt.Fprintf(&b, "  classDef %s fill:%s\n", class, service.Color)
+		fmt.Fprintf(&b, "  class %s %s\n", strings.Join(byOwner[service.Name], ","), class)
+	}
+	if len(shared) > 0 {
+		b.WriteString("  classDef shared stroke:#dc2626,stroke-width:3px\n")
+		fmt.Fprintf(&b, "  class %s shared\n", strings.Join(shared, ","))
+	}
+	return b.String()
+}
+
+// tableKey lowercases a table name and drops its schema qualifier
+func tableKey(name string) string {
+	name = strings.ToLower(name)
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// schemaQualifier returns the schema part of a qualified table name, or ""
+func schemaQualifier(name string) string {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return strings.ToLower(name[:i])
+	}
+	return ""
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// lineOf returns the 1-based line of a byte offset
+func lineOf(content string, offset int) int {
+	return strings.Count(content[:offset], "\n") + 1
+}
+
+// identRegex validates names captured from config before they are treated as databases
+var identRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_\-]*$`)
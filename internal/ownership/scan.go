@@ -0,0 +1,266 @@
+package ownership
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"repo-explanation/internal/database"
+	"repo-explanation/internal/microservices"
+	"repo-explanation/internal/portable"
+)
+
+// maxEvidence bounds the evidence kept per table, service and kind; a table queried from fifty
+// places needs no more than a few examples
+const maxEvidence = 3
+
+// modelPatterns capture the table an ORM model or entity declaration maps to
+var modelPatterns = []struct {
+	name  string
+	regex *regexp.Regexp
+}{
+	{"Go TableName()", regexp.MustCompile(`func\s*\([^)]*\)\s*TableName\(\)\s*string\s*\{\s*return\s*"([\w.]+)"`)},
+	{"bun/go-pg table tag", regexp.MustCompile(`(?:bun|pg):"table:([\w.]+)`)},
+	{"SQLAlchemy __tablename__", regexp.MustCompile(`__tablename__\s*=\s*['"]([\w.]+)['"]`)},
+	{"Django db_table", regexp.MustCompile(`db_table\s*=\s*['"]([\w.]+)['"]`)},
+	{"JPA @Table", regexp.MustCompile(`@Table\s*\(\s*(?:name\s*=\s*)?"([\w.]+)"`)},
+	{"Sequelize tableName", regexp.MustCompile(`tableName\s*:\s*['"]([\w.]+)['"]`)},
+	{"TypeORM @Entity", regexp.MustCompile(`@Entity\s*\(\s*(?:\{[^}]*name\s*:\s*)?['"]([\w.]+)['"]`)},
+	{"ActiveRecord table_name", regexp.MustCompile(`self\.table_name\s*=\s*['"]([\w.]+)['"]`)},
+	{"Prisma @@map", regexp.MustCompile(`@@map\(\s*"([\w.]+)"\s*\)`)},
+	{"EF Core table", regexp.MustCompile(`(?:\[Table|ToTable)\(\s*"([\w.]+)"`)},
+}
+
+var (
+	// queryUpper finds tables after upper-case SQL keywords anywhere, which is how SQL is written
+	// in query strings; lower-case keywords only count on lines that also read as SQL
+	queryUpper   = regexp.MustCompile(`\b(?:FROM|JOIN|INTO|UPDATE)\s+["'` + "`" + `\[]?([A-Za-z_][\w.]*)`)
+	queryAnyCase = regexp.MustCompile(`(?i)\b(?:from|join|into|update)\s+["'` + "`" + `\[]?([A-Za-z_][\w.]*)`)
+	sqlLine      = regexp.MustCompile(`(?i)\b(?:select\b.*\bfrom|insert\s+into|update\s+\S+\s+set|delete\s+from)\b`)
+
+	// connectionPatterns capture database names from connection URLs and settings
+	connectionPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)\b(?:postgres(?:ql)?|mysql|mariadb|sqlserver|cockroachdb|mongodb(?:\+srv)?)://[^\s'"/]*/([A-Za-z_][\w\-]*)`),
+		regexp.MustCompile(`(?m)^\s*(?:export\s+)?(?:[A-Z_]*DB_NAME|[A-Z_]*DATABASE_NAME|POSTGRES_DB|MYSQL_DATABASE|PGDATABASE|DB_DATABASE)\s*[=:]\s*["']?([A-Za-z_][\w\-]*)`),
+		regexp.MustCompile(`(?m)^\s*(?:database|dbname|db_name|database_name)\s*[:=]\s*["']?([A-Za-z_][\w\-]*)["']?\s*$`),
+	}
+)
+
+// connection is a database a service's settings name
+type connection struct {
+	File string
+	Line int
+}
+
+// scan collects evidence for the tables of one schema
+type scan struct {
+	tables      map[string][]string              // Unqualified key to schema table names
+	evidence    map[string]map[string][]Evidence // Table to service to evidence
+	connections map[string]map[string]connection // Service to database to where it is named
+	migrations  map[string]map[string]bool       // Table to the folders of its migrations
+}
+
+func newScan(schema *database.DatabaseSchema) *scan {
+	s := &scan{
+		tables:      make(map[string][]string),
+		evidence:    make(map[string]map[string][]Evidence),
+		connections: make(map[string]map[string]connection),
+		migrations:  make(map[string]map[string]bool),
+	}
+	for name := range schema.Tables {
+		key := tableKey(name)
+		s.tables[key] = append(s.tables[key], name)
+	}
+	return s
+}
+
+// add records evidence for every schema table matching name
+func (s *scan) add(name string, e Evidence) {
+	for _, table := range s.tables[tableKey(name)] {
+		// A qualified reference only matches the table in that schema
+		if q := schemaQualifier(name); q != "" && schemaQualifier(table) != "" && q != schemaQualifier(table) {
+			continue
+		}
+		if s.evidence[table] == nil {
+			s.evidence[table] = make(map[string][]Evidence)
+		}
+		count := 0
+		for _, existing := range s.evidence[table][e.Service] {
+			if existing.Kind == e.Kind {
+				count++
+			}
+		}
+		if count < maxEvidence {
+			s.evidence[table][e.Service] = append(s.evidence[table][e.Service], e)
+		}
+		if e.Kind == Migration {
+			if s.migrations[table] == nil {
+				s.migrations[table] = make(map[string]bool)
+			}
+			for _, segment := range portable.Segments(e.File) {
+				s.migrations[table][strings.ToLower(segment)] = true
+			}
+		}
+	}
+}
+
+func (s *scan) scan(kind, rel, service, content string) {
+	switch kind {
+	case kindMigration:
+		for _, table := range database.CreatedTables(rel, content) {
+			s.add(table, Evidence{Service: service, Kind: Migration, File: rel, Detail: "creates the table"})
+		}
+	case kindConfig:
+		s.scanConnections(rel, service, content)
+	case kindSource:
+		s.scanConnections(rel, service, content)
+		for _, pattern := range modelPatterns {
+			for _, m := range pattern.regex.FindAllStringSubmatchIndex(content, -1) {
+				s.add(content[m[2]:m[3]], Evidence{Service: service, Kind: Model, File: rel, Line: lineOf(content, m[0]), Detail: pattern.name})
+			}
+		}
+		for i, line := range portable.Lines(content) {
+			regex := queryUpper
+			if sqlLine.MatchString(line) {
+				regex = queryAnyCase
+			}
+			for _, m := range regex.FindAllStringSubmatch(line, -1) {
+				s.add(m[1], Evidence{Service: service, Kind: Query, File: rel, Line: i + 1, Detail: strings.TrimSpace(m[0])})
+			}
+		}
+	}
+}
+
+func (s *scan) scanConnections(rel, service, content string) {
+	for _, regex := range connectionPatterns {
+		for _, m := range regex.FindAllStringSubmatchIndex(content, -1) {
+			name := strings.ToLower(content[m[2]:m[3]])
+			if !identRegex.MatchString(name) {
+				continue
+			}
+			if s.connections[service] == nil {
+				s.connections[service] = make(map[string]connection)
+			}
+			if _, seen := s.connections[service][name]; !seen {
+				s.connections[service][name] = connection{File: rel, Line: lineOf(content, m[0])}
+			}
+		}
+	}
+}
+
+// connect ties services to the tables of the databases they name: a database matching the table's
+// schema or a folder its migrations live in
+func (s *scan) connect() {
+	services := make([]string, 0, len(s.connections))
+	for service := range s.connections {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+	for _, names := range s.tables {
+		for _, table := range names {
+			for _, service := range services {
+				for _, db := range sortedConnections(s.connections[service]) {
+					if db != schemaQualifier(table) && !s.migrations[table][db] {
+						continue
+					}
+					c := s.connections[service][db]
+					s.add(table, Evidence{Service: service, Kind: Connection, File: c.File, Line: c.Line, Detail: "connects to database " + db})
+				}
+			}
+		}
+	}
+}
+
+func sortedConnections(connections map[string]connection) []string {
+	set := make(map[string]bool, len(connections))
+	for name := range connections {
+		set[name] = true
+	}
+	return sortedKeys(set)
+}
+
+// report picks each table's owner: the service with the strongest combined evidence, counting
+// each kind once so that many queries never outweigh a migration
+func (s *scan) report(services []microservices.DiscoveredService) *Report {
+	s.connect()
+
+	report := &Report{}
+	index := make(map[string]int, len(services))
+	for i, service := range services {
+		index[service.Name] = i
+		report.Services = append(report.Services, Service{
+			Name:      service.Name,
+			Color:     palette[i%len(palette)],
+			Databases: sortedConnections(s.connections[service.Name]),
+		})
+	}
+
+	var names []string
+	for _, tables := range s.tables {
+		names = append(names, tables...)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		table := Table{Table: name, AccessedBy: []string{}, Evidence: []Evidence{}}
+		scores := make(map[string]int)
+		for service, evidence := range s.evidence[name] {
+			kinds := make(map[string]bool)
+			for _, e := range evidence {
+				kinds[e.Kind] = true
+			}
+			for kind := range kinds {
+				scores[service] += weights[kind]
+			}
+			table.AccessedBy = append(table.AccessedBy, service)
+		}
+		sort.Slice(table.AccessedBy, func(i, j int) bool {
+			a, b := table.AccessedBy[i], table.AccessedBy[j]
+			if scores[a] != scores[b] {
+				return scores[a] > scores[b]
+			}
+			return a < b
+		})
+		for _, service := range table.AccessedBy {
+			evidence := s.evidence[name][service]
+			sort.SliceStable(evidence, func(i, j int) bool { return weights[evidence[i].Kind] > weights[evidence[j].Kind] })
+			table.Evidence = append(table.Evidence, evidence...)
+		}
+
+		if len(table.AccessedBy) == 0 {
+			report.Unowned++
+		} else {
+			table.Owner = table.AccessedBy[0]
+			report.Services[index[table.Owner]].Owns++
+		}
+		if len(table.AccessedBy) > 1 {
+			table.Shared = true
+			report.Shared++
+		}
+		report.Tables = append(report.Tables, table)
+	}
+
+	report.Summary = summarize(report)
+	return report
+}
+
+// Describe returns a one-line account of why a table belongs to its owner
+func (t Table) Describe() string {
+	if t.Owner == "" {
+		return "no service references it"
+	}
+	var reasons []string
+	seen := make(map[string]bool)
+	for _, e := range t.Evidence {
+		if e.Service != t.Owner || seen[e.Kind] {
+			continue
+		}
+		seen[e.Kind] = true
+		where := e.File
+		if e.Line > 0 {
+			where = fmt.Sprintf("%s:%d", e.File, e.Line)
+		}
+		reasons = append(reasons, fmt.Sprintf("%s (%s)", e.Kind, where))
+	}
+	return strings.Join(reasons, ", ")
+}
@@ -2,10 +2,10 @@ package pipeline
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"path"
 	"strings"
 	"sync"
 	"time"
@@ -13,13 +13,39 @@ import (
 	"github.com/sashabaranov/go-openai"
 	"repo-explanation/cache"
 	"repo-explanation/config"
+	"repo-explanation/internal/advisories"
+	"repo-explanation/internal/annotations"
+	"repo-explanation/internal/archmap"
+	"repo-explanation/internal/atrest"
+	"repo-explanation/internal/auth"
 	"repo-explanation/internal/chunker"
+	"repo-explanation/internal/complexity"
+	"repo-explanation/internal/contracts"
 	"repo-explanation/internal/database"
+	"repo-explanation/internal/deadcode"
+	"repo-explanation/internal/doccoverage"
+	"repo-explanation/internal/featureflags"
+	"repo-explanation/internal/frontend"
+	"repo-explanation/internal/filestore"
+	"repo-explanation/internal/generated"
+	"repo-explanation/internal/glossary"
+	"repo-explanation/internal/infrastructure"
 	"repo-explanation/internal/detector"
+	"repo-explanation/internal/heuristics"
+	"repo-explanation/internal/jobs"
 	"repo-explanation/internal/microservices"
+	"repo-explanation/internal/modules"
 	internalOpenai "repo-explanation/internal/openai"
+	"repo-explanation/internal/overrides"
+	"repo-explanation/internal/ownership"
+	"repo-explanation/internal/pkggraph"
+	"repo-explanation/internal/portable"
+	"repo-explanation/internal/privacy"
+	"repo-explanation/internal/prompts"
 	"repo-explanation/internal/relationships"
 	"repo-explanation/internal/secrets"
+	"repo-explanation/internal/tenancy"
+	"repo-explanation/internal/toolchain"
 )
 
 // Analyzer orchestrates the map-reduce analysis pipeline
@@ -29,6 +55,16 @@ type Analyzer struct {
 	cache      *cache.Cache
 	crawler    *Crawler
 	repositoryURL string // Repository URL for consistent cache keys
+	options    AnalysisOptions // Path and phase scoping
+	serviceScope *ServiceScope // Resolved from options.Service on the first crawl
+	incremental *incrementalState // Previous run's summaries, set by EnableIncremental
+	annotations *annotations.Export // Editor annotations of the last run's file summaries
+	concurrency *ConcurrencyStats // Effective map-phase concurrency of the last run
+	priming     bool              // PrimeCache is running the map phase at full concurrency
+	generatedFiles map[string]generated.Artifact // Generated and vendored files, skipped by the LLM phases
+	generatedFolders []string // Folders holding only generated or vendored files
+	privacy     *privacy.Anonymizer // Rewrites content for the LLM in privacy mode; nil otherwise
+	progress    ProgressCallback // Receives AnalyzeProject's phases instead of stdout, set by SetProgress
 }
 
 // HelpfulQuestion represents a project-specific question and answer pair
@@ -41,14 +77,44 @@ type HelpfulQuestion struct {
 type AnalysisResult struct {
 	ProjectSummary      *internalOpenai.ProjectSummary               `json:"project_summary"`
 	FolderSummaries     map[string]*internalOpenai.FolderSummary     `json:"folder_summaries"`
+	ArchitectureMap     *archmap.Map                         `json:"architecture_map,omitempty"`
 	// FileSummaries removed - not needed for architectural understanding and slows down API
 	ProjectType         *detector.DetectionResult            `json:"project_type"`
 	Stats               map[string]interface{}               `json:"stats"`
+	Generated           *generated.Report                    `json:"generated,omitempty"`
 	Services            []microservices.DiscoveredService    `json:"services,omitempty"`
 	ServiceRelationships []relationships.ServiceRelationship `json:"relationships,omitempty"`
+	SequenceFlows       []relationships.SequenceFlow         `json:"sequence_flows,omitempty"`
 	DatabaseSchema      *database.DatabaseSchema             `json:"database_schema,omitempty"`
+	TableOwnership      *ownership.Report                    `json:"table_ownership,omitempty"`
+	Tenancy             *tenancy.Report                      `json:"tenancy,omitempty"`
+	DocCoverage         *doccoverage.Report                  `json:"doc_coverage,omitempty"`
+	Infrastructure      *infrastructure.Inventory            `json:"infrastructure,omitempty"`
+	Toolchains          *toolchain.Report                    `json:"toolchains,omitempty"`
+	Advisories          *advisories.Report                   `json:"advisories,omitempty"`
+	Frontend            *frontend.Report                     `json:"frontend,omitempty"`
+	PackageGraph        *pkggraph.Graph                      `json:"package_graph,omitempty"`
+	Modules             *modules.Report                      `json:"modules,omitempty"`
+	FeatureFlags        *featureflags.Report                 `json:"feature_flags,omitempty"`
+	AuthSurface         *auth.Report                         `json:"auth_surface,omitempty"`
+	Jobs                *jobs.Report                         `json:"jobs,omitempty"`
+	DataContracts       *contracts.Report                    `json:"data_contracts,omitempty"`
+	Complexity          *complexity.Report                   `json:"complexity,omitempty"`
+	DeadCode            *deadcode.Report                     `json:"dead_code,omitempty"`
 	ProjectSecrets      *secrets.ProjectSecrets              `json:"project_secrets,omitempty"`
 	HelpfulQuestions    []HelpfulQuestion                    `json:"helpful_questions,omitempty"`
+	Glossary            *glossary.Glossary                   `json:"glossary,omitempty"`
+	// ServiceScope is set when the analysis was limited to one service and its dependencies
+	ServiceScope        *ServiceScope                        `json:"service_scope,omitempty"`
+	// Offline is set when the analysis ran without any LLM access
+	Offline             bool                                 `json:"offline,omitempty"`
+	// Quick is set when only the project summary came from the LLM
+	Quick               bool                                 `json:"quick,omitempty"`
+	// Language is the output language of LLM-written prose, when not English
+	Language            string                               `json:"language,omitempty"`
+	// Privacy is set when file contents were anonymized before reaching the LLM
+	Privacy             *PrivacyReport                       `json:"privacy,omitempty"`
+	LLMEnhanceableFields []string                            `json:"llm_enhanceable_fields,omitempty"`
 }
 
 // NewAnalyzer creates a new analyzer
@@ -58,13 +124,7 @@ func NewAnalyzer(cfg *config.Config, basePath string) (*Analyzer, error) {
 		return nil, fmt.Errorf("failed to create crawler: %v", err)
 	}
 	
-	return &Analyzer{
-		config:       cfg,
-		openaiClient: internalOpenai.NewClient(cfg),
-		cache:        cache.NewCache(cfg),
-		crawler:      crawler,
-		repositoryURL: "", // Will be set by controller if available
-	}, nil
+	return newAnalyzer(cfg, crawler, "")
 }
 
 // NewAnalyzerWithURL creates a new analyzer with repository URL for caching
@@ -74,13 +134,83 @@ func NewAnalyzerWithURL(cfg *config.Config, basePath, repositoryURL string) (*An
 		return nil, fmt.Errorf("failed to create crawler: %v", err)
 	}
 	
-	return &Analyzer{
+	return newAnalyzer(cfg, crawler, repositoryURL)
+}
+
+func newAnalyzer(cfg *config.Config, crawler *Crawler, repositoryURL string) (*Analyzer, error) {
+	anonymizer, err := privacy.New(cfg.Privacy)
+	if err != nil {
+		return nil, err
+	}
+	a := &Analyzer{
 		config:       cfg,
 		openaiClient: internalOpenai.NewClient(cfg),
 		cache:        cache.NewCache(cfg),
 		crawler:      crawler,
-		repositoryURL: repositoryURL,
-	}, nil
+		repositoryURL: repositoryURL, // Set by the controller for consistent cache keys across clones
+		privacy:      anonymizer,
+	}
+	variant := a.openaiClient.PromptFingerprint()
+	if anonymizer != nil {
+		variant += anonymizer.Fingerprint()
+	}
+	a.cache.SetPromptVariant(variant)
+	return a, nil
+}
+
+// SetOptions scopes the analysis to the given paths and phases; a profile supplies the phases when
+// none are given and frames the project-level prompts
+func (a *Analyzer) SetOptions(opts AnalysisOptions) error {
+	profile, err := a.config.Profile(opts.Profile)
+	if err != nil {
+		return err
+	}
+	if len(opts.Phases) == 0 {
+		opts.Phases = profile.Phases
+	}
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	opts.Language, _ = internalOpenai.NormalizeLanguage(opts.Language)
+	a.options = opts
+	a.serviceScope = nil
+	a.openaiClient.SetFocus(profile.Focus)
+	a.openaiClient.SetLanguage(opts.Language)
+	a.cache.SetLanguage(opts.Language)
+	return nil
+}
+
+// scopeRoot returns the directory whole-tree scanners are limited to: the service of a service
+// scope, whose dependencies only feed the file-based phases, or the single include
+func (a *Analyzer) scopeRoot() string {
+	if a.serviceScope != nil {
+		return a.serviceScope.Path
+	}
+	return a.options.ScopeRoot()
+}
+
+// scopedRootPath returns the directory that whole-tree scanners (secrets, dead code) should walk
+func (a *Analyzer) scopedRootPath() string {
+	if root := a.scopeRoot(); root != "" {
+		return filepath.Join(a.crawler.basePath, filepath.FromSlash(root))
+	}
+	return a.crawler.basePath
+}
+
+// inScope reports whether a slash path relative to the scoped root passes the include/exclude
+// filters, which are relative to the repository
+func (a *Analyzer) inScope(relPath string) bool {
+	return a.options.InScope(path.Join(a.scopeRoot(), relPath))
+}
+
+// scopedSources indexes the files whole-tree scanners read: those under the scoped root that pass
+// the include/exclude filters, so an excluded or unmatched directory is never scanned
+func (a *Analyzer) scopedSources() *filestore.FileStore {
+	sources := a.crawler.Sources(a.scopedRootPath())
+	if !a.options.IsScoped() {
+		return sources
+	}
+	return sources.Filter(a.inScope)
 }
 
 // ProgressCallback defines the signature for progress callbacks
@@ -88,6 +218,10 @@ type ProgressCallback func(eventType, stage, message string, progress int, data
 
 // AnalyzeProjectWithProgress performs the complete analysis pipeline with progress callbacks
 func (a *Analyzer) AnalyzeProjectWithProgress(ctx context.Context, callback ProgressCallback) (*AnalysisResult, error) {
+	llmBefore := a.openaiClient.LLMStats()
+	a.openaiClient.StartAuditRun(a.getAnalysisKey())
+	a.concurrency = nil
+	
 	// Phase 1: Discover files
 	callback("progress", "🔍 Scanning project structure...", "Discovering files and directories", 20, nil)
 	
@@ -96,16 +230,39 @@ func (a *Analyzer) AnalyzeProjectWithProgress(ctx context.Context, callback Prog
 		return nil, fmt.Errorf("file discovery failed: %v", err)
 	}
 	
+	if err := a.resolveServiceScope(files); err != nil {
+		return nil, err
+	}
+	if a.options.IsScoped() {
+		total := len(files)
+		files = a.options.filterFiles(files)
+		fmt.Printf("🎯 Scoped analysis: %d of %d files match include/exclude filters\n", len(files), total)
+	}
+	
 	stats := a.crawler.GetFileStats(files)
+	languageStats := a.detectLanguages(files, stats)
 	callback("progress", "📁 Files discovered", fmt.Sprintf("Found %d files (%.2f MB)", stats["total_files"].(int), stats["total_size_mb"]), 25, map[string]interface{}{
 		"file_count": stats["total_files"],
 		"total_size": stats["total_size_mb"],
 	})
+	generatedReport := a.detectGenerated(files)
+	if generatedReport != nil {
+		callback("data", "Generated code detected", generatedReport.Summary, 26, map[string]interface{}{
+			"generated": generatedReport,
+		})
+	}
 	
 	// Phase 1.5: Detect project type
 	callback("progress", "🎯 Detecting project type and framework...", "Analyzing project structure and dependencies", 30, nil)
 	
-	projectDetector := detector.NewProjectDetector()
+	projectDetector, err := detector.NewProjectDetectorFromFile(a.config.Detection.RulesFile)
+	if err != nil {
+		return nil, fmt.Errorf("project type detection failed: %v", err)
+	}
+	userOverrides, err := overrides.Load(a.crawler.basePath)
+	if err != nil {
+		return nil, fmt.Errorf("project type detection failed: %v", err)
+	}
 	
 	// Convert pipeline.FileInfo to detector.FileInfo to avoid import cycle
 	detectorFiles := make([]detector.FileInfo, len(files))
@@ -120,158 +277,276 @@ func (a *Analyzer) AnalyzeProjectWithProgress(ctx context.Context, callback Prog
 	}
 	
 	// Create file contents map for command-based detection
-	fileContents := make(map[string]string)
-	for _, file := range files {
-		content, err := a.crawler.ReadFile(file)
-		if err == nil {
-			fileContents[file.RelativePath] = content
-		}
-	}
+	fileContents := a.crawler.Store(files).Contents(detector.NeedsContent)
 	
 	projectType := projectDetector.DetectProjectType(detectorFiles, fileContents)
+	userOverrides.ApplyProjectType(projectType)
 	
 	callback("data", "Project type detected", "Project classification complete", 32, map[string]interface{}{
 		"project_type": projectType,
 	})
 	
-	// Phase 2: Map - Analyze individual files
-	callback("progress", "🧠 Analyzing individual files...", "Processing file contents with AI analysis", 35, nil)
-	
-	fileSummaries, err := a.mapPhaseWithProgress(ctx, files, callback)
-	if err != nil {
-		return nil, fmt.Errorf("map phase failed: %v", err)
-	}
-	
-	callback("data", "File analysis complete", fmt.Sprintf("Processed %d files (lightweight analysis)", len(fileSummaries)), 50, nil)
-	
-	// Phase 3: Reduce - Analyze folders
-	callback("progress", "📂 Analyzing folder structure...", "Organizing file analysis into folder summaries", 55, nil)
-	
-	folderSummaries, err := a.reducePhaseFolder(ctx, fileSummaries)
-	if err != nil {
-		return nil, fmt.Errorf("folder reduce phase failed: %v", err)
-	}
-	
-	callback("data", "Folder analysis complete", fmt.Sprintf("Analyzed %d folders", len(folderSummaries)), 60, map[string]interface{}{
-		"folder_summaries": folderSummaries,
-	})
-	
-	// Phase 4: Final Reduce - Analyze entire project
-	callback("progress", "🏗️ Generating project overview...", "Creating comprehensive project summary", 65, nil)
+	checkpoint := a.loadCheckpoint()
 	
-	projectSummary, err := a.reducePhaseProject(ctx, folderSummaries)
-	if err != nil {
-		return nil, fmt.Errorf("project reduce phase failed: %v", err)
-	}
-	
-	callback("data", "Project overview complete", "Project summary generated", 70, map[string]interface{}{
-		"project_summary": projectSummary,
-	})
-	
-	// Phase 5: Detailed architectural analysis
-	callback("progress", "🔍 Performing detailed architectural analysis...", "Deep-diving into project architecture and patterns", 72, nil)
-	
-	importantFiles := a.extractImportantFiles(files)
-	
-	// Convert pointer maps to value maps for the detailed analysis (with nil checks)
-	fileSummariesForAnalysis := make(map[string]internalOpenai.FileSummary)
-	for k, v := range fileSummaries {
-		if v != nil {
-			fileSummariesForAnalysis[k] = *v
+	// Phase 2: Map - Analyze individual files
+	fileSummaries := make(map[string]*internalOpenai.FileSummary)
+	if checkpoint.Completed(PhaseFiles) {
+		fileSummaries = checkpoint.FileSummaries
+		callback("data", "File analysis restored", fmt.Sprintf("Restored %d file summaries from checkpoint", len(fileSummaries)), 50, nil)
+	} else if a.options.PhaseEnabled(PhaseFiles) {
+		callback("progress", "🧠 Analyzing individual files...", "Processing file contents with AI analysis", 35, nil)
+		
+		phaseCtx, cancel := a.phaseContext(ctx, PhaseFiles)
+		fileSummaries, err = a.mapPhaseWithProgress(phaseCtx, files, callback)
+		completed, cancelErr := finishPhase(ctx, phaseCtx, cancel, PhaseFiles)
+		if cancelErr != nil {
+			return nil, cancelErr
 		}
+		if err != nil && completed {
+			return nil, fmt.Errorf("map phase failed: %v", err)
+		}
+		
+		callback("data", "File analysis complete", fmt.Sprintf("Processed %d files (lightweight analysis)", len(fileSummaries)), 50, nil)
+		
+		if completed {
+			checkpoint.FileSummaries = fileSummaries
+			a.saveCheckpoint(checkpoint, PhaseFiles)
+		}
+	} else {
+		callback("data", "File analysis skipped", "Phase 'files' not selected", 50, nil)
 	}
 	
-	folderSummariesForAnalysis := make(map[string]internalOpenai.FolderSummary)
-	for k, v := range folderSummaries {
-		if v != nil {
-			folderSummariesForAnalysis[k] = *v
+	// Phase 3: Reduce - Analyze folders (needs file summaries)
+	folderSummaries := make(map[string]*internalOpenai.FolderSummary)
+	if checkpoint.Completed(PhaseFolders) {
+		folderSummaries = checkpoint.FolderSummaries
+		callback("data", "Folder analysis restored", fmt.Sprintf("Restored %d folder summaries from checkpoint", len(folderSummaries)), 60, map[string]interface{}{
+			"folder_summaries": folderSummaries,
+		})
+	} else if a.options.PhaseEnabled(PhaseFolders) && len(fileSummaries) > 0 {
+		callback("progress", "📂 Analyzing folder structure...", "Organizing file analysis into folder summaries", 55, nil)
+		
+		phaseCtx, cancel := a.phaseContext(ctx, PhaseFolders)
+		folderSummaries, err = a.reducePhaseFolder(phaseCtx, fileSummaries)
+		completed, cancelErr := finishPhase(ctx, phaseCtx, cancel, PhaseFolders)
+		if cancelErr != nil {
+			return nil, cancelErr
 		}
+		if err != nil && completed {
+			return nil, fmt.Errorf("folder reduce phase failed: %v", err)
+		}
+		
+		callback("data", "Folder analysis complete", fmt.Sprintf("Analyzed %d folders", len(folderSummaries)), 60, map[string]interface{}{
+			"folder_summaries": folderSummaries,
+		})
+		
+		if completed {
+			checkpoint.FolderSummaries = folderSummaries
+			a.saveCheckpoint(checkpoint, PhaseFolders)
+		}
+	} else {
+		callback("data", "Folder analysis skipped", "Phase 'folders' not selected or no file summaries available", 60, nil)
 	}
 	
-	// Perform detailed analysis with caching and error recovery
-	var detailedAnalysis *internalOpenai.RepositoryAnalysis
-	var detailedErr error
-	
-	func() {
-		defer func() {
-			if r := recover(); r != nil {
-				fmt.Printf("⚠️  Detailed analysis panicked: %v\n", r)
-				detailedAnalysis = nil
-				detailedErr = fmt.Errorf("detailed analysis panicked: %v", r)
-			}
-		}()
+	// Phase 4: Final Reduce - Analyze entire project (needs folder summaries)
+	documentation := a.collectDocumentation(files)
+	projectSummary := &internalOpenai.ProjectSummary{Documentation: documentation}
+	if checkpoint.Completed(PhaseProject) && checkpoint.ProjectSummary != nil {
+		projectSummary = checkpoint.ProjectSummary
+		callback("data", "Project overview restored", "Project summary restored from checkpoint", 75, map[string]interface{}{
+			"project_summary": projectSummary,
+		})
+	} else if a.options.PhaseEnabled(PhaseProject) && len(folderSummaries) > 0 {
+		callback("progress", "🏗️ Generating project overview...", "Creating comprehensive project summary", 65, nil)
 		
-		// Check cache first if we have repository URL
-		if a.repositoryURL != "" {
-			if cachedAnalysis, found := a.cache.GetRepositoryDetails(a.repositoryURL, folderSummariesForAnalysis, fileSummariesForAnalysis, importantFiles); found {
-				fmt.Printf("✅ Using cached detailed analysis for: %s\n", a.repositoryURL)
-				detailedAnalysis = cachedAnalysis
-				return
+		phaseCtx, cancel := a.phaseContext(ctx, PhaseProject)
+		projectSummary, err = a.reducePhaseProject(phaseCtx, folderSummaries, documentation)
+		if err != nil {
+			if phaseCtx.Err() == nil {
+				cancel()
+				return nil, fmt.Errorf("project reduce phase failed: %v", err)
+			}
+			if cancelErr := checkCancelled(ctx); cancelErr != nil {
+				cancel()
+				return nil, cancelErr
 			}
+			fmt.Printf("⏱️  Project overview timed out - falling back to heuristic summary\n")
+			projectSummary = a.fallbackProjectSummary(folderSummaries)
+			projectSummary.Documentation = documentation
 		}
 		
-		// Generate new detailed analysis via LLM
-		fmt.Printf("🤖 Generating new detailed analysis via LLM for: %s\n", a.getAnalysisKey())
-		detailedAnalysis, detailedErr = a.openaiClient.AnalyzeRepositoryDetails(ctx, a.crawler.basePath, folderSummariesForAnalysis, fileSummariesForAnalysis, importantFiles)
+		callback("data", "Project overview complete", "Project summary generated", 70, map[string]interface{}{
+			"project_summary": projectSummary,
+		})
 		
-		// Cache the result if successful and we have repository URL
-		if detailedErr == nil && detailedAnalysis != nil && a.repositoryURL != "" {
-			if cacheErr := a.cache.SetRepositoryDetails(a.repositoryURL, folderSummariesForAnalysis, fileSummariesForAnalysis, importantFiles, detailedAnalysis); cacheErr != nil {
-				fmt.Printf("⚠️  Failed to cache detailed analysis: %v\n", cacheErr)
-			} else {
-				fmt.Printf("✅ Cached detailed analysis for: %s\n", a.repositoryURL)
-			}
+		// Phase 5: Detailed architectural analysis (part of the project phase)
+		callback("progress", "🔍 Performing detailed architectural analysis...", "Deep-diving into project architecture and patterns", 72, nil)
+		
+		detailedAnalysis, detailedErr := a.runDetailedAnalysis(phaseCtx, files, fileSummaries, folderSummaries)
+		completed, cancelErr := finishPhase(ctx, phaseCtx, cancel, PhaseProject)
+		if cancelErr != nil {
+			return nil, cancelErr
+		}
+		
+		if detailedErr != nil {
+			fmt.Printf("⚠️  Detailed analysis failed: %v\n", detailedErr)
+			callback("data", "Detailed analysis skipped", "Analysis failed but continuing with basic analysis", 75, map[string]interface{}{
+				"detailed_analysis": nil,
+			})
+		} else {
+			projectSummary.DetailedAnalysis = detailedAnalysis
+			callback("data", "Detailed analysis complete", "Architectural patterns identified", 75, map[string]interface{}{
+				"detailed_analysis": detailedAnalysis,
+			})
+		}
+		
+		if completed {
+			checkpoint.ProjectSummary = projectSummary
+			a.saveCheckpoint(checkpoint, PhaseProject)
 		}
-	}()
-	
-	if detailedErr != nil {
-		fmt.Printf("⚠️  Detailed analysis failed: %v\n", detailedErr)
-		callback("data", "Detailed analysis skipped", "Analysis failed but continuing with basic analysis", 75, map[string]interface{}{
-			"detailed_analysis": nil,
-		})
 	} else {
-		projectSummary.DetailedAnalysis = detailedAnalysis
-		callback("data", "Detailed analysis complete", "Architectural patterns identified", 75, map[string]interface{}{
-			"detailed_analysis": detailedAnalysis,
-		})
+		callback("data", "Project overview skipped", "Phase 'project' not selected or no folder summaries available", 70, nil)
 	}
 	
 	// Phase 6: Enhanced Microservice Discovery (works for all project types)
 	var discoveredServices []microservices.DiscoveredService  
 	var serviceRelationships []relationships.ServiceRelationship
-	
-	callback("progress", "⚙️ Analyzing microservices architecture...", "Using enhanced discovery patterns", 78, nil)
-	
-	discoveredServices = a.enhanceWithMicroserviceDiscovery(ctx, files, projectType, projectSummary)
-	
-	if len(discoveredServices) > 0 {
-		callback("data", "Microservice discovery complete", fmt.Sprintf("Found %d services", len(discoveredServices)), 80, map[string]interface{}{
-			"services": discoveredServices,
-		})
-	} else {
-		callback("data", "Microservice discovery complete", "No microservices detected", 80, map[string]interface{}{
-			"services": []microservices.DiscoveredService{},
+	var sequenceFlows []relationships.SequenceFlow
+	
+	if checkpoint.Completed(PhaseServices) {
+		discoveredServices = checkpoint.Services
+		serviceRelationships = checkpoint.ServiceRelationships
+		sequenceFlows = checkpoint.SequenceFlows
+		callback("data", "Microservice discovery restored", fmt.Sprintf("Restored %d services from checkpoint", len(discoveredServices)), 85, map[string]interface{}{
+			"services":       discoveredServices,
+			"relationships":  serviceRelationships,
+			"sequence_flows": sequenceFlows,
 		})
-	}
+	} else if a.options.PhaseEnabled(PhaseServices) {
+		callback("progress", "⚙️ Analyzing microservices architecture...", "Using enhanced discovery patterns", 78, nil)
+		
+		phaseCtx, cancel := a.phaseContext(ctx, PhaseServices)
+		discoveredServices = a.enhanceWithMicroserviceDiscovery(phaseCtx, files, projectType, projectSummary)
+		discoveredServices = userOverrides.ApplyServices(discoveredServices)
+		
+		if len(discoveredServices) > 0 {
+			callback("data", "Microservice discovery complete", fmt.Sprintf("Found %d services", len(discoveredServices)), 80, map[string]interface{}{
+				"services": discoveredServices,
+			})
+		} else {
+			callback("data", "Microservice discovery complete", "No microservices detected", 80, map[string]interface{}{
+				"services": []microservices.DiscoveredService{},
+			})
+		}
 		
 		// Phase 7: Service relationships
 		if len(discoveredServices) > 1 {
 			callback("progress", "🔗 Mapping service dependencies...", "Analyzing inter-service relationships", 82, nil)
 			
-			serviceRelationships = a.discoverServiceRelationships(files, discoveredServices, projectSummary)
+			serviceRelationships = a.discoverServiceRelationships(phaseCtx, files, discoveredServices, projectSummary)
 			
 			callback("data", "Service relationships mapped", fmt.Sprintf("Found %d relationships", len(serviceRelationships)), 85, map[string]interface{}{
 				"relationships": serviceRelationships,
 			})
+
+			sequenceFlows = a.buildSequenceFlows(phaseCtx, files, discoveredServices, serviceRelationships)
+			if len(sequenceFlows) > 0 {
+				callback("data", "Request flows reconstructed", fmt.Sprintf("Drew %d sequence diagrams", len(sequenceFlows)), 86, map[string]interface{}{
+					"sequence_flows": sequenceFlows,
+				})
+			}
+		}
+		
+		completed, cancelErr := finishPhase(ctx, phaseCtx, cancel, PhaseServices)
+		if cancelErr != nil {
+			return nil, cancelErr
 		}
+		if completed {
+			checkpoint.Services = discoveredServices
+			checkpoint.ServiceRelationships = serviceRelationships
+			checkpoint.SequenceFlows = sequenceFlows
+			a.saveCheckpoint(checkpoint, PhaseServices)
+		}
+	}
+
+	// The static scanners read the files they need from one index of the scoped root. Toolchain
+	// versions come from a handful of small files, so they are not a phase of their own.
+	sources := a.scopedSources()
+	toolchains := a.detectToolchains(sources, discoveredServices)
+	if toolchains != nil {
+		callback("data", "Toolchains detected", toolchains.Summary, 87, map[string]interface{}{
+			"toolchains": toolchains,
+		})
+	}
+	advisoryReport := a.checkAdvisories(files, discoveredServices, toolchains)
+	if advisoryReport != nil {
+		callback("data", "Version advisories checked", advisoryReport.Summary, 87, map[string]interface{}{
+			"advisories": advisoryReport,
+		})
+	}
+
+	frontendReport := a.analyzeFrontend(sources, projectType)
+	if frontendReport != nil {
+		callback("data", "Frontend architecture analyzed", frontendReport.Summary, 88, map[string]interface{}{
+			"frontend": frontendReport,
+		})
+	}
+
+	packageGraph := a.buildPackageGraph(sources)
+	if packageGraph != nil {
+		callback("data", "Package graph built", packageGraph.Summary, 89, map[string]interface{}{
+			"package_graph": packageGraph,
+		})
+	}
+
+	featureFlags := a.detectFeatureFlags(sources)
+	if featureFlags != nil {
+		callback("data", "Feature flags inventoried", featureFlags.Summary, 90, map[string]interface{}{
+			"feature_flags": featureFlags,
+		})
+	}
+
+	authSurface := a.analyzeAuthSurface(sources, discoveredServices)
+	if authSurface != nil {
+		callback("data", "Auth model analyzed", authSurface.Summary, 91, map[string]interface{}{
+			"auth_surface": authSurface,
+		})
+	}
+
+	backgroundJobs := a.detectJobs(sources, discoveredServices)
+	if backgroundJobs != nil {
+		callback("data", "Background jobs inventoried", backgroundJobs.Summary, 91, map[string]interface{}{
+			"jobs": backgroundJobs,
+		})
+	}
 
+	dataContracts := a.analyzeDataContracts(sources, discoveredServices, backgroundJobs)
+	if dataContracts != nil {
+		callback("data", "Data contracts inventoried", dataContracts.Summary, 91, map[string]interface{}{
+			"data_contracts": dataContracts,
+		})
+	}
+
+	architectureMap := a.buildArchitectureMap(folderSummaries)
+	if architectureMap != nil {
+		callback("data", "Architecture map built", fmt.Sprintf("Mapped %d folders", architectureMap.Folders), 91, map[string]interface{}{
+			"architecture_map": architectureMap,
+		})
+	}
+	
 	// Phase 8: Database schema extraction (with graceful error handling)
 	var databaseSchema *database.DatabaseSchema
-	if projectType != nil && (strings.ToLower(string(projectType.PrimaryType)) == "backend" || 
+	if checkpoint.Completed(PhaseSchema) {
+		databaseSchema = checkpoint.DatabaseSchema
+		callback("data", "Database schema restored", "Database schema restored from checkpoint", 92, map[string]interface{}{
+			"database_schema": databaseSchema,
+		})
+	} else if a.options.PhaseEnabled(PhaseSchema) && projectType != nil && (strings.ToLower(string(projectType.PrimaryType)) == "backend" || 
 							  strings.ToLower(string(projectType.PrimaryType)) == "fullstack") {
 		callback("progress", "🗄️ Extracting database schema...", "Analyzing database migrations and schema files", 88, nil)
 		
 		// Graceful database schema extraction with error recovery
+		phaseCtx, cancel := a.phaseContext(ctx, PhaseSchema)
 		func() {
 			defer func() {
 				if r := recover(); r != nil {
@@ -279,8 +554,12 @@ func (a *Analyzer) AnalyzeProjectWithProgress(ctx context.Context, callback Prog
 					databaseSchema = nil
 				}
 			}()
-			databaseSchema = a.extractDatabaseSchema(files)
+			databaseSchema = a.extractDatabaseSchema(phaseCtx, files)
 		}()
+		completed, cancelErr := finishPhase(ctx, phaseCtx, cancel, PhaseSchema)
+		if cancelErr != nil {
+			return nil, cancelErr
+		}
 		
 		if databaseSchema != nil {
 			callback("data", "Database schema extracted", "Database structure analyzed", 92, map[string]interface{}{
@@ -291,64 +570,256 @@ func (a *Analyzer) AnalyzeProjectWithProgress(ctx context.Context, callback Prog
 				"database_schema": nil,
 			})
 		}
+		
+		if completed {
+			checkpoint.DatabaseSchema = databaseSchema
+			a.saveCheckpoint(checkpoint, PhaseSchema)
+		}
 	}
 	
-	// Phase 8.5: Extract secrets and configuration
-	callback("progress", "🔐 Analyzing secrets and configuration...", "Scanning for required environment variables and configuration secrets", 93, nil)
+	tableOwnership := a.analyzeTableOwnership(sources, databaseSchema, discoveredServices)
+	if tableOwnership != nil {
+		callback("data", "Table ownership mapped", tableOwnership.Summary, 92, map[string]interface{}{
+			"table_ownership": tableOwnership,
+		})
+	}
+	tenancyModel := a.analyzeTenancy(sources, databaseSchema)
+	if tenancyModel != nil {
+		callback("data", "Tenancy model detected", tenancyModel.Summary, 92, map[string]interface{}{
+			"tenancy": tenancyModel,
+		})
+	}
 	
-	projectSecrets := a.extractProjectSecrets(ctx, a.crawler.basePath)
+	// Phase 8.2: Terraform infrastructure inventory
+	var infraInventory *infrastructure.Inventory
+	if checkpoint.Completed(PhaseInfra) {
+		infraInventory = checkpoint.Infrastructure
+		callback("data", "Infrastructure restored", "Infrastructure inventory restored from checkpoint", 93, map[string]interface{}{
+			"infrastructure": infraInventory,
+		})
+	} else if a.options.PhaseEnabled(PhaseInfra) {
+		callback("progress", "☁️ Mapping cloud infrastructure...", "Parsing Terraform providers, resources and variables", 92, nil)
+		
+		phaseCtx, cancel := a.phaseContext(ctx, PhaseInfra)
+		infraInventory = a.extractInfrastructure(phaseCtx, files, discoveredServices)
+		completed, cancelErr := finishPhase(ctx, phaseCtx, cancel, PhaseInfra)
+		if cancelErr != nil {
+			return nil, cancelErr
+		}
+		
+		if infraInventory != nil {
+			callback("data", "Infrastructure mapped", infraInventory.Summary, 93, map[string]interface{}{
+				"infrastructure": infraInventory,
+			})
+		}
+		
+		if completed {
+			checkpoint.Infrastructure = infraInventory
+			a.saveCheckpoint(checkpoint, PhaseInfra)
+		}
+	}
 	
-	if projectSecrets != nil && projectSecrets.TotalVariables > 0 {
-		callback("data", "Project secrets extracted", fmt.Sprintf("Found %d environment variables (%d required)", projectSecrets.TotalVariables, projectSecrets.RequiredCount), 94, map[string]interface{}{
+	// Phase 8.3: Dead code detection
+	var deadCodeReport *deadcode.Report
+	if checkpoint.Completed(PhaseDeadCode) {
+		deadCodeReport = checkpoint.DeadCode
+		callback("data", "Dead code report restored", "Dead code report restored from checkpoint", 93, map[string]interface{}{
+			"dead_code": deadCodeReport,
+		})
+	} else if a.options.PhaseEnabled(PhaseDeadCode) {
+		callback("progress", "🪦 Looking for dead code...", "Checking which packages, files and tables nothing uses", 93, nil)
+		
+		phaseCtx, cancel := a.phaseContext(ctx, PhaseDeadCode)
+		deadCodeReport = a.detectDeadCode(phaseCtx, sources, databaseSchema)
+		completed, cancelErr := finishPhase(ctx, phaseCtx, cancel, PhaseDeadCode)
+		if cancelErr != nil {
+			return nil, cancelErr
+		}
+		
+		if deadCodeReport != nil {
+			callback("data", "Dead code report ready", deadCodeReport.Summary, 93, map[string]interface{}{
+				"dead_code": deadCodeReport,
+			})
+		}
+		
+		if completed {
+			checkpoint.DeadCode = deadCodeReport
+			a.saveCheckpoint(checkpoint, PhaseDeadCode)
+		}
+	}
+	
+	// Phase 8.5: Extract secrets and configuration
+	var projectSecrets *secrets.ProjectSecrets
+	if checkpoint.Completed(PhaseSecrets) {
+		projectSecrets = checkpoint.ProjectSecrets
+		callback("data", "Project secrets restored", "Project secrets restored from checkpoint", 94, map[string]interface{}{
 			"project_secrets": projectSecrets,
 		})
+	} else if a.options.PhaseEnabled(PhaseSecrets) {
+		callback("progress", "🔐 Analyzing secrets and configuration...", "Scanning for required environment variables and configuration secrets", 93, nil)
+		
+		phaseCtx, cancel := a.phaseContext(ctx, PhaseSecrets)
+		projectSecrets = a.extractProjectSecrets(phaseCtx, sources, discoveredServices)
+		completed, cancelErr := finishPhase(ctx, phaseCtx, cancel, PhaseSecrets)
+		if cancelErr != nil {
+			return nil, cancelErr
+		}
+		
+		if projectSecrets != nil && projectSecrets.TotalVariables > 0 {
+			callback("data", "Project secrets extracted", fmt.Sprintf("Found %d environment variables (%d required)", projectSecrets.TotalVariables, projectSecrets.RequiredCount), 94, map[string]interface{}{
+				"project_secrets": projectSecrets,
+			})
+		}
+		
+		if completed {
+			checkpoint.ProjectSecrets = projectSecrets
+			a.saveCheckpoint(checkpoint, PhaseSecrets)
+		}
 	}
 	
-	// Phase 9: Generate helpful questions
-	callback("progress", "🤔 Generating helpful questions...", "Creating project-specific Q&A to accelerate development", 95, nil)
+	// Phase 9: Generate helpful questions (last phase, so it is not checkpointed)
+	var helpfulQuestions []HelpfulQuestion
+	if a.options.PhaseEnabled(PhaseQuestions) {
+		callback("progress", "🤔 Generating helpful questions...", "Creating project-specific Q&A to accelerate development", 95, nil)
+		
+		phaseCtx, cancel := a.phaseContext(ctx, PhaseQuestions)
+		helpfulQuestions = a.generateHelpfulQuestions(phaseCtx, projectSummary, projectType, discoveredServices, databaseSchema, fileSummaries)
+		if _, cancelErr := finishPhase(ctx, phaseCtx, cancel, PhaseQuestions); cancelErr != nil {
+			return nil, cancelErr
+		}
+		
+		if len(helpfulQuestions) > 0 {
+			callback("data", "Helpful questions generated", fmt.Sprintf("Generated %d project-specific questions", len(helpfulQuestions)), 96, map[string]interface{}{
+				"helpful_questions": helpfulQuestions,
+			})
+			fmt.Printf("✅ [DEBUG] Successfully generated %d helpful questions\n", len(helpfulQuestions))
+		} else {
+			fmt.Printf("⚠️ [DEBUG] No helpful questions generated - this may indicate an API timeout or parsing issue\n")
+			// Generate fallback questions based on project type
+			fallbackQuestions := a.generateFallbackQuestions(projectType, projectSummary)
+			if len(fallbackQuestions) > 0 {
+				callback("data", "Fallback questions generated", fmt.Sprintf("Generated %d fallback questions", len(fallbackQuestions)), 96, map[string]interface{}{
+					"helpful_questions": fallbackQuestions,
+				})
+				helpfulQuestions = fallbackQuestions
+				fmt.Printf("✅ [DEBUG] Generated %d fallback questions as backup\n", len(fallbackQuestions))
+			}
+		}
+	}
 	
-	helpfulQuestions := a.generateHelpfulQuestions(ctx, projectSummary, projectType, discoveredServices, databaseSchema, fileSummaries)
+	// Phase 10: Build the glossary (after the last checkpoint, like the questions)
+	var projectGlossary *glossary.Glossary
+	if a.options.PhaseEnabled(PhaseGlossary) {
+		callback("progress", "📖 Building glossary...", "Defining domain terms from tables, services and summaries", 97, nil)
+		
+		phaseCtx, cancel := a.phaseContext(ctx, PhaseGlossary)
+		projectGlossary = a.buildGlossary(phaseCtx, files, sources, projectSummary, discoveredServices, databaseSchema, fileSummaries)
+		if _, cancelErr := finishPhase(ctx, phaseCtx, cancel, PhaseGlossary); cancelErr != nil {
+			return nil, cancelErr
+		}
+		if projectGlossary != nil {
+			callback("data", "Glossary built", projectGlossary.Summary, 97, map[string]interface{}{
+				"glossary": projectGlossary,
+			})
+		}
+	}
 	
-	if len(helpfulQuestions) > 0 {
-		callback("data", "Helpful questions generated", fmt.Sprintf("Generated %d project-specific questions", len(helpfulQuestions)), 96, map[string]interface{}{
-			"helpful_questions": helpfulQuestions,
-		})
-		fmt.Printf("✅ [DEBUG] Successfully generated %d helpful questions\n", len(helpfulQuestions))
-	} else {
-		fmt.Printf("⚠️ [DEBUG] No helpful questions generated - this may indicate an API timeout or parsing issue\n")
-		// Generate fallback questions based on project type
-		fallbackQuestions := a.generateFallbackQuestions(projectType, projectSummary)
-		if len(fallbackQuestions) > 0 {
-			callback("data", "Fallback questions generated", fmt.Sprintf("Generated %d fallback questions", len(fallbackQuestions)), 96, map[string]interface{}{
-				"helpful_questions": fallbackQuestions,
+	// Module boundaries of a single-service backend, named by the LLM from the file summaries
+	var moduleReport *modules.Report
+	if a.options.PhaseEnabled(PhaseServices) {
+		phaseCtx, cancel := a.phaseContext(ctx, PhaseServices)
+		moduleReport = a.detectModuleBoundaries(phaseCtx, sources, projectType, discoveredServices, projectSummary, fileSummaries)
+		if _, cancelErr := finishPhase(ctx, phaseCtx, cancel, PhaseServices); cancelErr != nil {
+			return nil, cancelErr
+		}
+		if moduleReport != nil {
+			callback("data", "Module boundaries detected", moduleReport.Summary, 97, map[string]interface{}{
+				"modules": moduleReport,
 			})
-			helpfulQuestions = fallbackQuestions
-			fmt.Printf("✅ [DEBUG] Generated %d fallback questions as backup\n", len(fallbackQuestions))
 		}
 	}
 	
 	// Final result compilation
 	callback("progress", "📊 Generating comprehensive analysis...", "Compiling final analysis results", 98, nil)
 	
+	complexityReport := a.scoreComplexity(files, languageStats, discoveredServices, serviceRelationships, packageGraph, databaseSchema)
+	callback("data", "Complexity scored", complexityReport.Summary, 98, map[string]interface{}{
+		"complexity": complexityReport,
+	})
+	docCoverage := a.analyzeDocCoverage(sources, discoveredServices)
+	if docCoverage != nil {
+		callback("data", "Documentation coverage scored", docCoverage.Summary, 98, map[string]interface{}{
+			"doc_coverage": docCoverage,
+		})
+	}
+	
 	result := &AnalysisResult{
 		ProjectSummary:       projectSummary,
 		FolderSummaries:      folderSummaries,
+		ArchitectureMap:      architectureMap,
 		// FileSummaries:        fileSummaries, // Removed for performance - not needed in API response
 		ProjectType:          projectType,
 		Stats:                stats,
+		Generated:            generatedReport,
 		Services:             discoveredServices,
 		ServiceRelationships: serviceRelationships,
+		SequenceFlows:        sequenceFlows,
 		DatabaseSchema:       databaseSchema,
+		TableOwnership:       tableOwnership,
+		Tenancy:              tenancyModel,
+		Infrastructure:       infraInventory,
+		Toolchains:           toolchains,
+		Advisories:           advisoryReport,
+		Frontend:             frontendReport,
+		PackageGraph:         packageGraph,
+		Modules:              moduleReport,
+		FeatureFlags:         featureFlags,
+		AuthSurface:          authSurface,
+		Jobs:                 backgroundJobs,
+		DataContracts:        dataContracts,
+		Complexity:           complexityReport,
+		DocCoverage:          docCoverage,
+		DeadCode:             deadCodeReport,
 		ProjectSecrets:       projectSecrets,
 		HelpfulQuestions:     helpfulQuestions,
-	}
+		Glossary:             projectGlossary,
+		ServiceScope:         a.serviceScope,
+		Language:             a.options.Language,
+		Privacy:              a.privacyReport(files),
+	}
+	a.markOffline(result)
+	recordServiceLanguages(languageStats, discoveredServices, stats)
+	a.recordLLMStats(stats, llmBefore)
+	if run := a.openaiClient.AuditRun(); run != "" {
+		stats["llm_audit_run"] = run
+	}
+	a.recordConcurrency(stats)
+	a.rememberSummaries(fileSummaries, folderSummaries)
+	a.exportAnnotations(fileSummaries, discoveredServices, deadCodeReport)
+	a.clearCheckpoint()
 	
 	return result, nil
 }
 
+// recordLLMStats adds this run's prompt cache and JSON repair counts to the stats
+func (a *Analyzer) recordLLMStats(stats map[string]interface{}, before internalOpenai.LLMStats) {
+	run := a.openaiClient.LLMStats().Since(before)
+	stats["llm_cache"] = run.Cache
+	stats["llm_json"] = run.JSON
+	fmt.Printf("🧠 LLM cache: %d hits, %d misses (%.0f%% hit rate)\n", run.Cache.Hits, run.Cache.Misses, run.Cache.HitRate()*100)
+	if run.JSON.Repaired+run.JSON.Reasked+run.JSON.Failed > 0 {
+		fmt.Printf("🩹 LLM JSON: %d repaired, %d re-asked, %d dropped of %d responses\n",
+			run.JSON.Repaired, run.JSON.Reasked, run.JSON.Failed, run.JSON.Clean+run.JSON.Repaired+run.JSON.Reasked+run.JSON.Failed)
+	}
+}
+
 // AnalyzeProject performs the complete analysis pipeline (legacy method for backward compatibility)
 func (a *Analyzer) AnalyzeProject(ctx context.Context) (*AnalysisResult, error) {
-	fmt.Println("🔍 Discovering files...")
+	llmBefore := a.openaiClient.LLMStats()
+	a.openaiClient.StartAuditRun(a.getAnalysisKey())
+	a.concurrency = nil
+	
+	a.announce("🔍 Discovering files...", 20)
 	
 	// Phase 1: Discover files
 	files, err := a.crawler.CrawlFiles()
@@ -356,12 +827,30 @@ func (a *Analyzer) AnalyzeProject(ctx context.Context) (*AnalysisResult, error)
 		return nil, fmt.Errorf("file discovery failed: %v", err)
 	}
 	
+	if err := a.resolveServiceScope(files); err != nil {
+		return nil, err
+	}
+	if a.options.IsScoped() {
+		total := len(files)
+		files = a.options.filterFiles(files)
+		fmt.Printf("🎯 Scoped analysis: %d of %d files match include/exclude filters\n", len(files), total)
+	}
+	
 	stats := a.crawler.GetFileStats(files)
 	fmt.Printf("📁 Found %d files (%.2f MB)\n", stats["total_files"], stats["total_size_mb"])
+	languageStats := a.detectLanguages(files, stats)
+	generatedReport := a.detectGenerated(files)
 	
 	// Phase 1.5: Detect project type based on file structure
-	fmt.Println("🔍 Detecting project type...")
-	projectDetector := detector.NewProjectDetector()
+	a.announce("🔍 Detecting project type...", 30)
+	projectDetector, err := detector.NewProjectDetectorFromFile(a.config.Detection.RulesFile)
+	if err != nil {
+		return nil, fmt.Errorf("project type detection failed: %v", err)
+	}
+	userOverrides, err := overrides.Load(a.crawler.basePath)
+	if err != nil {
+		return nil, fmt.Errorf("project type detection failed: %v", err)
+	}
 	
 	// Convert pipeline.FileInfo to detector.FileInfo to avoid import cycle
 	detectorFiles := make([]detector.FileInfo, len(files))
@@ -376,129 +865,177 @@ func (a *Analyzer) AnalyzeProject(ctx context.Context) (*AnalysisResult, error)
 	}
 	
 	// Create file contents map for command-based detection
-	fileContents := make(map[string]string)
-	for _, file := range files {
-		content, err := a.crawler.ReadFile(file)
-		if err == nil {
-			fileContents[file.RelativePath] = content
-		}
-	}
+	fileContents := a.crawler.Store(files).Contents(detector.NeedsContent)
 	
 	projectType := projectDetector.DetectProjectType(detectorFiles, fileContents)
+	userOverrides.ApplyProjectType(projectType)
 	
 	// Display project type detection results
 	projectType.DisplayResult()
 	
-	// Phase 2: Map - Analyze individual files
-	fmt.Println("🧠 Analyzing files...")
-	fileSummaries, err := a.mapPhase(ctx, files)
-	if err != nil {
-		return nil, fmt.Errorf("map phase failed: %v", err)
-	}
-	
-	fmt.Printf("✅ Analyzed %d files\n", len(fileSummaries))
-	
-	// Phase 3: Reduce - Analyze folders
-	fmt.Println("📂 Analyzing folders...")
-	folderSummaries, err := a.reducePhaseFolder(ctx, fileSummaries)
-	if err != nil {
-		return nil, fmt.Errorf("folder reduce phase failed: %v", err)
-	}
-	
-	fmt.Printf("✅ Analyzed %d folders\n", len(folderSummaries))
-	
-	// Phase 4: Final Reduce - Analyze entire project
-	fmt.Println("🏗️  Analyzing project...")
-	projectSummary, err := a.reducePhaseProject(ctx, folderSummaries)
-	if err != nil {
-		return nil, fmt.Errorf("project reduce phase failed: %v", err)
-	}
-	
-	// Phase 5: Detailed architectural analysis
-	fmt.Println("🔍 Performing detailed architectural analysis...")
-	importantFiles := a.extractImportantFiles(files)
+	checkpoint := a.loadCheckpoint()
 	
-	// Convert pointer maps to value maps for the detailed analysis (with nil checks)
-	fileSummariesForAnalysis := make(map[string]internalOpenai.FileSummary)
-	for k, v := range fileSummaries {
-		if v != nil {
-			fileSummariesForAnalysis[k] = *v
+	// Phase 2: Map - Analyze individual files
+	fileSummaries := make(map[string]*internalOpenai.FileSummary)
+	if checkpoint.Completed(PhaseFiles) {
+		fileSummaries = checkpoint.FileSummaries
+		fmt.Printf("📍 Restored %d file summaries from checkpoint\n", len(fileSummaries))
+	} else if a.options.PhaseEnabled(PhaseFiles) {
+		a.announce("🧠 Analyzing files...", 35)
+		phaseCtx, cancel := a.phaseContext(ctx, PhaseFiles)
+		fileSummaries, err = a.mapPhase(phaseCtx, files)
+		completed, cancelErr := finishPhase(ctx, phaseCtx, cancel, PhaseFiles)
+		if cancelErr != nil {
+			return nil, cancelErr
 		}
-	}
-	
-	folderSummariesForAnalysis := make(map[string]internalOpenai.FolderSummary)
-	for k, v := range folderSummaries {
-		if v != nil {
-			folderSummariesForAnalysis[k] = *v
+		if err != nil && completed {
+			return nil, fmt.Errorf("map phase failed: %v", err)
+		}
+		
+		fmt.Printf("✅ Analyzed %d files\n", len(fileSummaries))
+		
+		if completed {
+			checkpoint.FileSummaries = fileSummaries
+			a.saveCheckpoint(checkpoint, PhaseFiles)
 		}
+	} else {
+		fmt.Println("⏭️  Skipping file analysis (phase 'files' not selected)")
 	}
 	
-	// Perform detailed analysis with caching and error recovery
-	var detailedAnalysis *internalOpenai.RepositoryAnalysis
-	var detailedErr error
-	
-	func() {
-		defer func() {
-			if r := recover(); r != nil {
-				fmt.Printf("⚠️  Detailed analysis panicked: %v\n", r)
-				detailedAnalysis = nil
-				detailedErr = fmt.Errorf("detailed analysis panicked: %v", r)
-			}
-		}()
+	// Phase 3: Reduce - Analyze folders (needs file summaries)
+	folderSummaries := make(map[string]*internalOpenai.FolderSummary)
+	if checkpoint.Completed(PhaseFolders) {
+		folderSummaries = checkpoint.FolderSummaries
+		fmt.Printf("📍 Restored %d folder summaries from checkpoint\n", len(folderSummaries))
+	} else if a.options.PhaseEnabled(PhaseFolders) && len(fileSummaries) > 0 {
+		a.announce("📂 Analyzing folders...", 55)
+		phaseCtx, cancel := a.phaseContext(ctx, PhaseFolders)
+		folderSummaries, err = a.reducePhaseFolder(phaseCtx, fileSummaries)
+		completed, cancelErr := finishPhase(ctx, phaseCtx, cancel, PhaseFolders)
+		if cancelErr != nil {
+			return nil, cancelErr
+		}
+		if err != nil && completed {
+			return nil, fmt.Errorf("folder reduce phase failed: %v", err)
+		}
 		
-		// Check cache first if we have repository URL
-		if a.repositoryURL != "" {
-			if cachedAnalysis, found := a.cache.GetRepositoryDetails(a.repositoryURL, folderSummariesForAnalysis, fileSummariesForAnalysis, importantFiles); found {
-				fmt.Printf("✅ Using cached detailed analysis for: %s\n", a.repositoryURL)
-				detailedAnalysis = cachedAnalysis
-				return
+		fmt.Printf("✅ Analyzed %d folders\n", len(folderSummaries))
+		
+		if completed {
+			checkpoint.FolderSummaries = folderSummaries
+			a.saveCheckpoint(checkpoint, PhaseFolders)
+		}
+	} else {
+		fmt.Println("⏭️  Skipping folder analysis (phase 'folders' not selected or no file summaries)")
+	}
+	
+	// Phase 4: Final Reduce - Analyze entire project (needs folder summaries)
+	documentation := a.collectDocumentation(files)
+	projectSummary := &internalOpenai.ProjectSummary{Documentation: documentation}
+	if checkpoint.Completed(PhaseProject) && checkpoint.ProjectSummary != nil {
+		projectSummary = checkpoint.ProjectSummary
+		fmt.Println("📍 Restored project summary from checkpoint")
+	} else if a.options.PhaseEnabled(PhaseProject) && len(folderSummaries) > 0 {
+		a.announce("🏗️  Analyzing project...", 65)
+		phaseCtx, cancel := a.phaseContext(ctx, PhaseProject)
+		projectSummary, err = a.reducePhaseProject(phaseCtx, folderSummaries, documentation)
+		if err != nil {
+			if phaseCtx.Err() == nil {
+				cancel()
+				return nil, fmt.Errorf("project reduce phase failed: %v", err)
 			}
+			if cancelErr := checkCancelled(ctx); cancelErr != nil {
+				cancel()
+				return nil, cancelErr
+			}
+			fmt.Printf("⏱️  Project overview timed out - falling back to heuristic summary\n")
+			projectSummary = a.fallbackProjectSummary(folderSummaries)
+			projectSummary.Documentation = documentation
 		}
 		
-		// Generate new detailed analysis via LLM
-		fmt.Printf("🤖 Generating new detailed analysis via LLM for: %s\n", a.getAnalysisKey())
-		detailedAnalysis, detailedErr = a.openaiClient.AnalyzeRepositoryDetails(ctx, a.crawler.basePath, folderSummariesForAnalysis, fileSummariesForAnalysis, importantFiles)
+		// Phase 5: Detailed architectural analysis
+		a.announce("🔍 Performing detailed architectural analysis...", 72)
+		detailedAnalysis, detailedErr := a.runDetailedAnalysis(phaseCtx, files, fileSummaries, folderSummaries)
+		completed, cancelErr := finishPhase(ctx, phaseCtx, cancel, PhaseProject)
+		if cancelErr != nil {
+			return nil, cancelErr
+		}
 		
-		// Cache the result if successful and we have repository URL
-		if detailedErr == nil && detailedAnalysis != nil && a.repositoryURL != "" {
-			if cacheErr := a.cache.SetRepositoryDetails(a.repositoryURL, folderSummariesForAnalysis, fileSummariesForAnalysis, importantFiles, detailedAnalysis); cacheErr != nil {
-				fmt.Printf("⚠️  Failed to cache detailed analysis: %v\n", cacheErr)
-			} else {
-				fmt.Printf("✅ Cached detailed analysis for: %s\n", a.repositoryURL)
-			}
+		if detailedErr != nil {
+			fmt.Printf("⚠️  Detailed analysis failed: %v\n", detailedErr)
+			// Continue without detailed analysis
+		} else {
+			projectSummary.DetailedAnalysis = detailedAnalysis
+			fmt.Println("✅ Detailed analysis complete!")
+		}
+		
+		if completed {
+			checkpoint.ProjectSummary = projectSummary
+			a.saveCheckpoint(checkpoint, PhaseProject)
 		}
-	}()
-	
-	if detailedErr != nil {
-		fmt.Printf("⚠️  Detailed analysis failed: %v\n", detailedErr)
-		// Continue without detailed analysis
 	} else {
-		projectSummary.DetailedAnalysis = detailedAnalysis
-		fmt.Println("✅ Detailed analysis complete!")
+		fmt.Println("⏭️  Skipping project overview (phase 'project' not selected or no folder summaries)")
 	}
 	
 	// Phase 6: Enhanced microservice discovery (CLI version - works for all project types)
 	var discoveredServices []microservices.DiscoveredService
 	var serviceRelationships []relationships.ServiceRelationship
-	
-	fmt.Println("🔍 Discovering microservices...")
-	discoveredServices = a.enhanceWithMicroserviceDiscovery(ctx, files, projectType, projectSummary)
-	fmt.Println("✅ Microservice discovery complete!")
-	
-	// Phase 7: Discover service relationships using the discovered services
-	if len(discoveredServices) > 1 {
-		fmt.Println("🔗 Discovering service relationships...")
-		serviceRelationships = a.discoverServiceRelationships(files, discoveredServices, projectSummary)
-		fmt.Println("✅ Service relationship discovery complete!")
+	var sequenceFlows []relationships.SequenceFlow
+	
+	if checkpoint.Completed(PhaseServices) {
+		discoveredServices = checkpoint.Services
+		serviceRelationships = checkpoint.ServiceRelationships
+		sequenceFlows = checkpoint.SequenceFlows
+		fmt.Printf("📍 Restored %d services from checkpoint\n", len(discoveredServices))
+	} else if a.options.PhaseEnabled(PhaseServices) {
+		a.announce("🔍 Discovering microservices...", 78)
+		phaseCtx, cancel := a.phaseContext(ctx, PhaseServices)
+		discoveredServices = a.enhanceWithMicroserviceDiscovery(phaseCtx, files, projectType, projectSummary)
+		discoveredServices = userOverrides.ApplyServices(discoveredServices)
+		fmt.Println("✅ Microservice discovery complete!")
+		
+		// Phase 7: Discover service relationships using the discovered services
+		if len(discoveredServices) > 1 {
+			a.announce("🔗 Discovering service relationships...", 82)
+			serviceRelationships = a.discoverServiceRelationships(phaseCtx, files, discoveredServices, projectSummary)
+			fmt.Println("✅ Service relationship discovery complete!")
+			sequenceFlows = a.buildSequenceFlows(phaseCtx, files, discoveredServices, serviceRelationships)
+		}
+		
+		completed, cancelErr := finishPhase(ctx, phaseCtx, cancel, PhaseServices)
+		if cancelErr != nil {
+			return nil, cancelErr
+		}
+		if completed {
+			checkpoint.Services = discoveredServices
+			checkpoint.ServiceRelationships = serviceRelationships
+			checkpoint.SequenceFlows = sequenceFlows
+			a.saveCheckpoint(checkpoint, PhaseServices)
+		}
 	}
 
+	sources := a.scopedSources()
+	toolchains := a.detectToolchains(sources, discoveredServices)
+	advisoryReport := a.checkAdvisories(files, discoveredServices, toolchains)
+	frontendReport := a.analyzeFrontend(sources, projectType)
+	packageGraph := a.buildPackageGraph(sources)
+	featureFlags := a.detectFeatureFlags(sources)
+	authSurface := a.analyzeAuthSurface(sources, discoveredServices)
+	backgroundJobs := a.detectJobs(sources, discoveredServices)
+	dataContracts := a.analyzeDataContracts(sources, discoveredServices, backgroundJobs)
+	architectureMap := a.buildArchitectureMap(folderSummaries)
+	
 	// Phase 8: Extract database schema from migrations (with graceful error handling)
 	var databaseSchema *database.DatabaseSchema
-	if projectType != nil && (strings.ToLower(string(projectType.PrimaryType)) == "backend" || 
+	if checkpoint.Completed(PhaseSchema) {
+		databaseSchema = checkpoint.DatabaseSchema
+		fmt.Println("📍 Restored database schema from checkpoint")
+	} else if a.options.PhaseEnabled(PhaseSchema) && projectType != nil && (strings.ToLower(string(projectType.PrimaryType)) == "backend" || 
 							  strings.ToLower(string(projectType.PrimaryType)) == "fullstack") {
-		fmt.Println("🗃️  Discovering database schema...")
+		a.announce("🗃️  Discovering database schema...", 88)
 		
 		// Graceful database schema extraction with error recovery
+		phaseCtx, cancel := a.phaseContext(ctx, PhaseSchema)
 		func() {
 			defer func() {
 				if r := recover(); r != nil {
@@ -506,28 +1043,162 @@ func (a *Analyzer) AnalyzeProject(ctx context.Context) (*AnalysisResult, error)
 					databaseSchema = nil
 				}
 			}()
-			databaseSchema = a.extractDatabaseSchema(files)
+			databaseSchema = a.extractDatabaseSchema(phaseCtx, files)
 		}()
+		completed, cancelErr := finishPhase(ctx, phaseCtx, cancel, PhaseSchema)
+		if cancelErr != nil {
+			return nil, cancelErr
+		}
 		
 		if databaseSchema != nil {
 			fmt.Println("✅ Database schema extraction complete!")
 		} else {
 			fmt.Println("⚠️  Database schema extraction skipped - no schema found")
 		}
+		
+		if completed {
+			checkpoint.DatabaseSchema = databaseSchema
+			a.saveCheckpoint(checkpoint, PhaseSchema)
+		}
+	}
+	tableOwnership := a.analyzeTableOwnership(sources, databaseSchema, discoveredServices)
+	tenancyModel := a.analyzeTenancy(sources, databaseSchema)
+	
+	// Phase 8.2: Terraform infrastructure inventory
+	var infraInventory *infrastructure.Inventory
+	if checkpoint.Completed(PhaseInfra) {
+		infraInventory = checkpoint.Infrastructure
+		fmt.Println("📍 Restored infrastructure inventory from checkpoint")
+	} else if a.options.PhaseEnabled(PhaseInfra) {
+		a.announce("☁️  Mapping cloud infrastructure...", 92)
+		phaseCtx, cancel := a.phaseContext(ctx, PhaseInfra)
+		infraInventory = a.extractInfrastructure(phaseCtx, files, discoveredServices)
+		completed, cancelErr := finishPhase(ctx, phaseCtx, cancel, PhaseInfra)
+		if cancelErr != nil {
+			return nil, cancelErr
+		}
+		if completed {
+			checkpoint.Infrastructure = infraInventory
+			a.saveCheckpoint(checkpoint, PhaseInfra)
+		}
 	}
 	
-	fmt.Println("✅ Project analysis complete!")
+	// Phase 8.3: Dead code detection
+	var deadCodeReport *deadcode.Report
+	if checkpoint.Completed(PhaseDeadCode) {
+		deadCodeReport = checkpoint.DeadCode
+		fmt.Println("📍 Restored dead code report from checkpoint")
+	} else if a.options.PhaseEnabled(PhaseDeadCode) {
+		a.announce("🪦 Looking for dead code...", 93)
+		phaseCtx, cancel := a.phaseContext(ctx, PhaseDeadCode)
+		deadCodeReport = a.detectDeadCode(phaseCtx, sources, databaseSchema)
+		completed, cancelErr := finishPhase(ctx, phaseCtx, cancel, PhaseDeadCode)
+		if cancelErr != nil {
+			return nil, cancelErr
+		}
+		if completed {
+			checkpoint.DeadCode = deadCodeReport
+			a.saveCheckpoint(checkpoint, PhaseDeadCode)
+		}
+	}
+	
+	// Phase 10: Glossary
+	var projectGlossary *glossary.Glossary
+	if a.options.PhaseEnabled(PhaseGlossary) {
+		a.announce("📖 Building glossary...", 97)
+		phaseCtx, cancel := a.phaseContext(ctx, PhaseGlossary)
+		projectGlossary = a.buildGlossary(phaseCtx, files, sources, projectSummary, discoveredServices, databaseSchema, fileSummaries)
+		if _, cancelErr := finishPhase(ctx, phaseCtx, cancel, PhaseGlossary); cancelErr != nil {
+			return nil, cancelErr
+		}
+	}
+	
+	var moduleReport *modules.Report
+	if a.options.PhaseEnabled(PhaseServices) {
+		phaseCtx, cancel := a.phaseContext(ctx, PhaseServices)
+		moduleReport = a.detectModuleBoundaries(phaseCtx, sources, projectType, discoveredServices, projectSummary, fileSummaries)
+		if _, cancelErr := finishPhase(ctx, phaseCtx, cancel, PhaseServices); cancelErr != nil {
+			return nil, cancelErr
+		}
+	}
+	
+	a.announce("✅ Project analysis complete!", 100)
 	
-	return &AnalysisResult{
+	complexityReport := a.scoreComplexity(files, languageStats, discoveredServices, serviceRelationships, packageGraph, databaseSchema)
+	docCoverage := a.analyzeDocCoverage(sources, discoveredServices)
+	
+	result := &AnalysisResult{
 		ProjectSummary:       projectSummary,
 		FolderSummaries:      folderSummaries,
+		ArchitectureMap:      architectureMap,
 		// FileSummaries:        fileSummaries, // Removed for performance - not needed in API response  
 		ProjectType:          projectType,
 		Stats:                stats,
+		Generated:            generatedReport,
 		Services:             discoveredServices,
 		ServiceRelationships: serviceRelationships,
+		SequenceFlows:        sequenceFlows,
 		DatabaseSchema:       databaseSchema,
-	}, nil
+		TableOwnership:       tableOwnership,
+		Tenancy:              tenancyModel,
+		Infrastructure:       infraInventory,
+		Toolchains:           toolchains,
+		Advisories:           advisoryReport,
+		Frontend:             frontendReport,
+		PackageGraph:         packageGraph,
+		Modules:              moduleReport,
+		FeatureFlags:         featureFlags,
+		AuthSurface:          authSurface,
+		Jobs:                 backgroundJobs,
+		DataContracts:        dataContracts,
+		Complexity:           complexityReport,
+		DocCoverage:          docCoverage,
+		DeadCode:             deadCodeReport,
+		Glossary:             projectGlossary,
+		ServiceScope:         a.serviceScope,
+		Language:             a.options.Language,
+		Privacy:              a.privacyReport(files),
+	}
+	a.markOffline(result)
+	recordServiceLanguages(languageStats, discoveredServices, stats)
+	a.recordLLMStats(stats, llmBefore)
+	if run := a.openaiClient.AuditRun(); run != "" {
+		stats["llm_audit_run"] = run
+	}
+	a.recordConcurrency(stats)
+	a.rememberSummaries(fileSummaries, folderSummaries)
+	a.exportAnnotations(fileSummaries, discoveredServices, deadCodeReport)
+	a.clearCheckpoint()
+	
+	return result, nil
+}
+
+// markOffline flags results produced without an LLM, or by a quick scan, and lists the fields a
+// full LLM run would enhance
+func (a *Analyzer) markOffline(result *AnalysisResult) {
+	if result == nil {
+		return
+	}
+	switch {
+	case a.config.Offline:
+		result.Offline = true
+		result.LLMEnhanceableFields = heuristics.LLMEnhanceableFields
+	case a.options.Quick:
+		result.Quick = true
+		result.LLMEnhanceableFields = quickEnhanceableFields()
+	}
+}
+
+// readReadme returns the content of the project's top-level README, if any
+func (a *Analyzer) readReadme() string {
+	candidates := []string{"README.md", "readme.md", "Readme.md", "README", "README.txt", "README.rst"}
+	for _, name := range candidates {
+		content, err := os.ReadFile(filepath.Join(a.crawler.basePath, name))
+		if err == nil {
+			return string(content)
+		}
+	}
+	return ""
 }
 
 // mapPhaseWithProgress analyzes individual files with progress callbacks
@@ -536,34 +1207,25 @@ func (a *Analyzer) mapPhaseWithProgress(ctx context.Context, files []FileInfo, c
 	totalFiles := len(files)
 	processedCount := 0
 	
-	// Calculate optimal worker count based on file count and configuration
-	// For large repositories, we can use more workers up to a reasonable limit
-	baseWorkers := a.config.RateLimiting.ConcurrentWorkers
-	maxWorkers := 12 // Maximum workers to avoid overwhelming the API
+	limiter := a.mapPhaseLimiter(totalFiles)
+	defer a.finishMapPhase(limiter)
+	numWorkers := limiter.max
 	
-	var numWorkers int
-	if totalFiles > 100 {
-		// For large repositories, scale workers up to maxWorkers
-		numWorkers = min(maxWorkers, max(baseWorkers, totalFiles/20))
-	} else {
-		// For smaller repositories, use base configuration
-		numWorkers = baseWorkers
-	}
-	
-	fmt.Printf("📊 [PERFORMANCE] Processing %d files with %d concurrent workers\n", totalFiles, numWorkers)
+	batches := a.planFileBatches(files)
+	fmt.Printf("📊 [PERFORMANCE] Processing %d files in %d LLM batches with %d concurrent workers\n", totalFiles, len(batches), limiter.current())
 	
 	// Create buffered channels for work distribution
-	jobs := make(chan FileInfo, totalFiles)
+	jobs := make(chan []FileInfo, len(batches))
 	results := make(chan fileResult, totalFiles)
 	
-	// Start worker goroutines
+	// Start worker goroutines; the limiter decides how many run at once
 	for i := 0; i < numWorkers; i++ {
-		go a.fileWorker(ctx, jobs, results)
+		go a.fileWorker(ctx, limiter, jobs, results)
 	}
 	
-	// Send all files to be processed
-	for _, file := range files {
-		jobs <- file
+	// Send all batches to be processed
+	for _, batch := range batches {
+		jobs <- batch
 	}
 	close(jobs)
 	
@@ -594,12 +1256,13 @@ func (a *Analyzer) mapPhaseWithProgress(ctx context.Context, files []FileInfo, c
 			
 			if processedCount%reportFrequency == 0 || processedCount == totalFiles {
 				callback("progress", "🧠 Analyzing individual files...", 
-					fmt.Sprintf("Analyzed %d/%d files (%d workers)", processedCount, totalFiles, numWorkers), 
+					fmt.Sprintf("Analyzed %d/%d files (%d workers)", processedCount, totalFiles, limiter.current()), 
 					progressPercentage, nil)
 			}
 			
 		case <-ctx.Done():
-			return nil, ctx.Err()
+			// Hand back what finished so a phase timeout can continue with partial results
+			return fileSummaries, ctx.Err()
 		}
 	}
 	
@@ -611,21 +1274,15 @@ func (a *Analyzer) mapPhase(ctx context.Context, files []FileInfo) (map[string]*
 	fileSummaries := make(map[string]*internalOpenai.FileSummary)
 	totalFiles := len(files)
 	
-	// Calculate optimal worker count (same logic as mapPhaseWithProgress)
-	baseWorkers := a.config.RateLimiting.ConcurrentWorkers
-	maxWorkers := 12
-	
-	var workerCount int
-	if totalFiles > 100 {
-		workerCount = min(maxWorkers, max(baseWorkers, totalFiles/20))
-	} else {
-		workerCount = baseWorkers
-	}
+	limiter := a.mapPhaseLimiter(totalFiles)
+	defer a.finishMapPhase(limiter)
+	workerCount := limiter.max
 	
-	fmt.Printf("📊 [PERFORMANCE] Processing %d files with %d concurrent workers (legacy mode)\n", totalFiles, workerCount)
+	batches := a.planFileBatches(files)
+	fmt.Printf("📊 [PERFORMANCE] Processing %d files in %d LLM batches with %d concurrent workers (legacy mode)\n", totalFiles, len(batches), limiter.current())
 	
 	// Create worker pool
-	jobs := make(chan FileInfo, len(files))
+	jobs := make(chan []FileInfo, len(batches))
 	results := make(chan fileResult, len(files))
 	
 	// Start workers
@@ -634,16 +1291,16 @@ func (a *Analyzer) mapPhase(ctx context.Context, files []FileInfo) (map[string]*
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			a.fileWorker(ctx, jobs, results)
+			a.fileWorker(ctx, limiter, jobs, results)
 		}()
 	}
 	
 	// Send jobs
 	go func() {
 		defer close(jobs)
-		for _, file := range files {
+		for _, batch := range batches {
 			select {
-			case jobs <- file:
+			case jobs <- batch:
 			case <-ctx.Done():
 				return
 			}
@@ -658,7 +1315,10 @@ func (a *Analyzer) mapPhase(ctx context.Context, files []FileInfo) (map[string]*
 	
 	// Collect results
 	processedCount := 0
+	finished := 0
 	for result := range results {
+		finished++
+		a.reportFiles(finished, len(files))
 		if result.err != nil {
 			fmt.Printf("⚠️  Error analyzing %s: %v\n", result.file.RelativePath, result.err)
 			continue
@@ -672,6 +1332,9 @@ func (a *Analyzer) mapPhase(ctx context.Context, files []FileInfo) (map[string]*
 		}
 	}
 	
+	if err := ctx.Err(); err != nil {
+		return fileSummaries, err
+	}
 	return fileSummaries, nil
 }
 
@@ -681,39 +1344,66 @@ type fileResult struct {
 	err     error
 }
 
-// fileWorker processes individual files
-func (a *Analyzer) fileWorker(ctx context.Context, jobs <-chan FileInfo, results chan<- fileResult) {
-	for file := range jobs {
-		select {
-		case <-ctx.Done():
+// fileWorker processes batches of files, sending one result per file. It holds a limiter slot per batch.
+func (a *Analyzer) fileWorker(ctx context.Context, limiter *adaptiveLimiter, jobs <-chan []FileInfo, results chan<- fileResult) {
+	for batch := range jobs {
+		if !limiter.acquire(ctx) {
 			return
-		default:
 		}
 		
-		summary, err := a.analyzeFile(ctx, file)
-		results <- fileResult{
-			file:    file,
-			summary: summary,
-			err:     err,
+		batchResults := a.analyzeFileBatch(ctx, batch)
+		limiter.release()
+		for _, result := range batchResults {
+			results <- result
 		}
 	}
 }
 
 // analyzeFile analyzes a single file
 func (a *Analyzer) analyzeFile(ctx context.Context, file FileInfo) (*internalOpenai.FileSummary, error) {
+	// Incremental runs keep the previous summary of files that did not change
+	if summary, ok := a.reusableFileSummary(file.RelativePath); ok {
+		return summary, nil
+	}
+	
+	// Generated and vendored files are recorded, not summarized
+	if summary, ok := a.generatedSummary(file); ok {
+		return summary, nil
+	}
+	
+	// Privacy mode never reads restricted files, so nothing of them can reach a prompt
+	if a.isRestricted(file.RelativePath) {
+		return restrictedSummary(file.RelativePath), nil
+	}
+	
 	// Read file content
 	content, err := a.crawler.ReadFile(file)
 	if err != nil {
 		return nil, err
 	}
 	
+	// Documentation is summarized from its headings and commands, not by the LLM
+	if heuristics.IsDocumentationFile(file.RelativePath) {
+		return heuristics.SummarizeDocument(file.RelativePath, content), nil
+	}
+	
+	// Offline mode uses heuristics and bypasses the cache so LLM results are never shadowed
+	if a.config.Offline {
+		return heuristics.SummarizeFile(file.RelativePath, content), nil
+	}
+	
 	// Check cache first
-	if summary, found := a.cache.GetFileSummary(file.Path, content); found {
+	if summary, found := a.cache.GetFileSummary(a.fileCacheKey(file), content); found {
 		return summary, nil
 	}
 	
+	// A quick scan keeps cached LLM summaries but makes no new per-file calls
+	if a.options.Quick {
+		return heuristics.SummarizeFile(file.RelativePath, content), nil
+	}
+	
 	// Chunk the file if necessary
-	chunks, err := chunker.ChunkFile(content, a.config.FileProcessing.ChunkSizeTokens, file.Path)
+	chunks, err := chunker.ChunkFile(a.llmContent(file.RelativePath, content), a.config.FileProcessing.ChunkSizeTokens, file.Path, a.config.FileProcessing.ChunkStrategy)
 	if err != nil {
 		return nil, err
 	}
@@ -739,14 +1429,14 @@ func (a *Analyzer) analyzeFile(ctx context.Context, file FileInfo) (*internalOpe
 	}
 	
 	// Cache the result
-	if err := a.cache.SetFileSummary(file.Path, content, summary); err != nil {
+	if err := a.cache.SetFileSummary(a.fileCacheKey(file), content, summary); err != nil {
 		fmt.Printf("⚠️  Failed to cache result for %s: %v\n", file.RelativePath, err)
 	}
 	
 	return summary, nil
 }
 
-// reducePhaseFolder analyzes folders based on their files
+// reducePhaseFolder analyzes folders bottom-up, so each parent folder sees its subfolders' summaries
 func (a *Analyzer) reducePhaseFolder(ctx context.Context, fileSummaries map[string]*internalOpenai.FileSummary) (map[string]*internalOpenai.FolderSummary, error) {
 	folderSummaries := make(map[string]*internalOpenai.FolderSummary)
 	
@@ -756,7 +1446,7 @@ func (a *Analyzer) reducePhaseFolder(ctx context.Context, fileSummaries map[stri
 	for filePath, summary := range fileSummaries {
 		dir := filepath.Dir(filePath)
 		if dir == "." {
-			dir = "root"
+			dir = rootFolder
 		}
 		
 		if folderFiles[dir] == nil {
@@ -765,40 +1455,144 @@ func (a *Analyzer) reducePhaseFolder(ctx context.Context, fileSummaries map[stri
 		folderFiles[dir][filePath] = summary
 	}
 	
-	// Analyze each folder
-	for folderPath, files := range folderFiles {
-		// Convert pointer map to value map for cache and API calls
-		filesForAPI := make(map[string]internalOpenai.FileSummary)
-		for k, v := range files {
-			filesForAPI[k] = *v
-		}
-		
-		// Check cache
-		if summary, found := a.cache.GetFolderSummary(folderPath, filesForAPI); found {
-			folderSummaries[folderPath] = summary
-			continue
-		}
-		
-		// Analyze with OpenAI
-		summary, err := a.openaiClient.AnalyzeFolder(ctx, folderPath, filesForAPI)
-		if err != nil {
-			fmt.Printf("⚠️  Error analyzing folder %s: %v\n", folderPath, err)
-			continue
+	tree := buildFolderTree(folderFiles)
+	totalFolders := 0
+	for _, level := range tree.levels {
+		totalFolders += len(level)
+	}
+	
+	// Use the configured worker count for every level
+	numWorkers := a.config.RateLimiting.ConcurrentWorkers
+	if numWorkers < 1 {
+		numWorkers = 1
+	}
+	
+	fmt.Printf("📊 [PERFORMANCE] Processing %d folders in %d levels with up to %d concurrent workers\n", totalFolders, len(tree.levels), numWorkers)
+	
+	// Deepest folders first; a level only starts once all of its subfolders are summarized
+	for _, level := range tree.levels {
+		if err := ctx.Err(); err != nil {
+			return folderSummaries, err
 		}
 		
-		folderSummaries[folderPath] = summary
+		results := a.reduceFolderLevel(ctx, level, folderFiles, tree.children, folderSummaries, min(numWorkers, len(level)))
 		
-		// Cache the result
-		if err := a.cache.SetFolderSummary(folderPath, filesForAPI, summary); err != nil {
-			fmt.Printf("⚠️  Failed to cache folder result for %s: %v\n", folderPath, err)
+		// Assemble results in folder order
+		for i, folderPath := range level {
+			result := results[i]
+			if result.err != nil {
+				if ctx.Err() == nil {
+					fmt.Printf("⚠️  Error analyzing folder %s: %v\n", folderPath, result.err)
+				}
+				continue
+			}
+			if result.summary != nil {
+				folderSummaries[folderPath] = result.summary
+			}
 		}
 	}
 	
-	return folderSummaries, nil
+	if err := ctx.Err(); err != nil {
+		return folderSummaries, err
+	}
+	return folderSummaries, nil
+}
+
+type folderResult struct {
+	summary *internalOpenai.FolderSummary
+	err     error
+}
+
+// reduceFolderLevel summarizes one level of the folder tree through a worker pool.
+// completed is only read while the level runs, so workers need no locking.
+func (a *Analyzer) reduceFolderLevel(ctx context.Context, level []string, folderFiles map[string]map[string]*internalOpenai.FileSummary, children map[string][]string, completed map[string]*internalOpenai.FolderSummary, numWorkers int) []folderResult {
+	// Each worker writes only to its job's slot
+	jobs := make(chan int, len(level))
+	results := make([]folderResult, len(level))
+	
+	var wg sync.WaitGroup
+	for w := 0; w < numWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				// Keep draining after cancellation so the dispatcher never blocks
+				if err := ctx.Err(); err != nil {
+					results[i] = folderResult{err: err}
+					continue
+				}
+				
+				folderPath := level[i]
+				childSummaries := make(map[string]internalOpenai.FolderSummary)
+				for _, child := range children[folderPath] {
+					// Subfolders that failed are simply left out of the parent's prompt
+					if summary := completed[child]; summary != nil {
+						childSummaries[child] = *summary
+					}
+				}
+				
+				summary, err := a.analyzeFolder(ctx, folderPath, folderFiles[folderPath], childSummaries)
+				results[i] = folderResult{summary: summary, err: err}
+			}
+		}()
+	}
+	
+	for i := range level {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	
+	return results
+}
+
+// analyzeFolder summarizes a single folder from its file summaries and its subfolders' summaries
+func (a *Analyzer) analyzeFolder(ctx context.Context, folderPath string, files map[string]*internalOpenai.FileSummary, childSummaries map[string]internalOpenai.FolderSummary) (*internalOpenai.FolderSummary, error) {
+	if summary, ok := a.reusableFolderSummary(folderPath); ok {
+		return summary, nil
+	}
+	
+	// Convert pointer map to value map for cache and API calls
+	filesForAPI := make(map[string]internalOpenai.FileSummary)
+	for k, v := range files {
+		filesForAPI[k] = *v
+	}
+	
+	if a.isGeneratedFolder(folderPath) {
+		summary := heuristics.SummarizeFolder(folderPath, filesForAPI, childSummaries)
+		summary.Purpose = "Generated or vendored code; not summarized"
+		return summary, nil
+	}
+	
+	if a.config.Offline {
+		return heuristics.SummarizeFolder(folderPath, filesForAPI, childSummaries), nil
+	}
+	
+	// Check cache
+	if summary, found := a.cache.GetFolderSummary(folderPath, filesForAPI, childSummaries); found {
+		return summary, nil
+	}
+	
+	if a.options.Quick {
+		return heuristics.SummarizeFolder(folderPath, filesForAPI, childSummaries), nil
+	}
+	
+	// Analyze with OpenAI
+	summary, err := a.openaiClient.AnalyzeFolderWithChildren(ctx, folderPath, filesForAPI, childSummaries)
+	if err != nil {
+		return nil, err
+	}
+	
+	// Cache the result
+	if err := a.cache.SetFolderSummary(folderPath, filesForAPI, childSummaries, summary); err != nil {
+		fmt.Printf("⚠️  Failed to cache folder result for %s: %v\n", folderPath, err)
+	}
+	
+	return summary, nil
 }
 
 // reducePhaseProject creates final project summary
-func (a *Analyzer) reducePhaseProject(ctx context.Context, folderSummaries map[string]*internalOpenai.FolderSummary) (*internalOpenai.ProjectSummary, error) {
+func (a *Analyzer) reducePhaseProject(ctx context.Context, folderSummaries map[string]*internalOpenai.FolderSummary, docs *internalOpenai.DocumentationDigest) (*internalOpenai.ProjectSummary, error) {
 	projectPath := a.crawler.basePath
 	
 	// Use repository URL for cache key if available, otherwise fall back to local path
@@ -806,6 +1600,8 @@ func (a *Analyzer) reducePhaseProject(ctx context.Context, folderSummaries map[s
 	if a.repositoryURL != "" {
 		cacheKey = a.repositoryURL
 	}
+	// Scoped and profiled analyses must not share the whole-repository summary
+	cacheKey += a.options.scopeKey() + a.options.profileKey()
 	
 	// Convert pointer map to value map for cache and API calls (with nil checks)
 	foldersForAPI := make(map[string]internalOpenai.FolderSummary)
@@ -815,18 +1611,30 @@ func (a *Analyzer) reducePhaseProject(ctx context.Context, folderSummaries map[s
 		}
 	}
 	
+	if a.config.Offline {
+		fmt.Printf("📴 Offline mode - building heuristic project summary for: %s\n", cacheKey)
+		summary := heuristics.SummarizeProject(projectPath, foldersForAPI, a.readReadme())
+		summary.Documentation = docs
+		return summary, nil
+	}
+	
 	// Check cache using repository URL as key
 	if summary, found := a.cache.GetProjectSummary(cacheKey, foldersForAPI); found {
 		fmt.Printf("✅ Using cached project summary for: %s\n", cacheKey)
+		summary.Documentation = docs
 		return summary, nil
 	}
 	
 	// Analyze with OpenAI
 	fmt.Printf("🤖 Generating new project summary via LLM for: %s\n", cacheKey)
-	summary, err := a.openaiClient.AnalyzeProject(ctx, projectPath, foldersForAPI)
+	if a.options.Quick {
+		return a.quickProjectSummary(ctx, projectPath, foldersForAPI, docs), nil
+	}
+	summary, err := a.openaiClient.AnalyzeProject(ctx, projectPath, foldersForAPI, docs)
 	if err != nil {
 		return nil, err
 	}
+	summary.Documentation = docs
 	
 	// Cache the result using repository URL as key
 	if err := a.cache.SetProjectSummary(cacheKey, foldersForAPI, summary); err != nil {
@@ -838,6 +1646,70 @@ func (a *Analyzer) reducePhaseProject(ctx context.Context, folderSummaries map[s
 	return summary, nil
 }
 
+// runDetailedAnalysis performs the cached, panic-safe detailed architectural analysis
+func (a *Analyzer) runDetailedAnalysis(ctx context.Context, files []FileInfo, fileSummaries map[string]*internalOpenai.FileSummary, folderSummaries map[string]*internalOpenai.FolderSummary) (detailedAnalysis *internalOpenai.RepositoryAnalysis, detailedErr error) {
+	importantFiles := a.extractImportantFiles(files)
+	
+	// Convert pointer maps to value maps for the detailed analysis (with nil checks)
+	fileSummariesForAnalysis := make(map[string]internalOpenai.FileSummary)
+	for k, v := range fileSummaries {
+		if v != nil {
+			fileSummariesForAnalysis[k] = *v
+		}
+	}
+	
+	folderSummariesForAnalysis := make(map[string]internalOpenai.FolderSummary)
+	for k, v := range folderSummaries {
+		if v != nil {
+			folderSummariesForAnalysis[k] = *v
+		}
+	}
+	
+	// Perform detailed analysis with caching and error recovery
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				fmt.Printf("⚠️  Detailed analysis panicked: %v\n", r)
+				detailedAnalysis = nil
+				detailedErr = fmt.Errorf("detailed analysis panicked: %v", r)
+			}
+		}()
+		
+		// Check cache first if we have repository URL
+		if a.repositoryURL != "" {
+			if cachedAnalysis, found := a.cache.GetRepositoryDetails(a.repositoryURL+a.options.profileKey(), folderSummariesForAnalysis, fileSummariesForAnalysis, importantFiles); found {
+				fmt.Printf("✅ Using cached detailed analysis for: %s\n", a.repositoryURL)
+				detailedAnalysis = cachedAnalysis
+				return
+			}
+		}
+		
+		if !a.deepLLM() {
+			fmt.Printf("📴 %s mode - skipping detailed LLM analysis for: %s\n", a.llmMode(), a.getAnalysisKey())
+			return
+		}
+		
+		// Generate new detailed analysis via LLM
+		fmt.Printf("🤖 Generating new detailed analysis via LLM for: %s\n", a.getAnalysisKey())
+		detailedAnalysis, detailedErr = a.openaiClient.AnalyzeRepositoryDetails(ctx, a.crawler.basePath, folderSummariesForAnalysis, fileSummariesForAnalysis, importantFiles)
+		
+		// Cache the result if successful and we have repository URL
+		if detailedErr == nil && detailedAnalysis != nil && a.repositoryURL != "" {
+			if cacheErr := a.cache.SetRepositoryDetails(a.repositoryURL+a.options.profileKey(), folderSummariesForAnalysis, fileSummariesForAnalysis, importantFiles, detailedAnalysis); cacheErr != nil {
+				fmt.Printf("⚠️  Failed to cache detailed analysis: %v\n", cacheErr)
+			} else {
+				fmt.Printf("✅ Cached detailed analysis for: %s\n", a.repositoryURL)
+			}
+		}
+	}()
+	
+	if detailedAnalysis != nil {
+		a.classifyServiceArchitectures(ctx, files, detailedAnalysis.MonorepoServices)
+	}
+	
+	return detailedAnalysis, detailedErr
+}
+
 // extractImportantFiles extracts key files that are important for architectural analysis
 func (a *Analyzer) extractImportantFiles(files []FileInfo) map[string]string {
 	importantFiles := make(map[string]string)
@@ -856,16 +1728,20 @@ func (a *Analyzer) extractImportantFiles(files []FileInfo) map[string]string {
 		filename := strings.ToLower(filepath.Base(file.Path))
 		filepath_lower := strings.ToLower(file.RelativePath)
 		
+		if a.isRestricted(file.RelativePath) {
+			continue
+		}
+		
 		// Check if file matches important patterns
 		for _, pattern := range importantPatterns {
 			if strings.Contains(filename, pattern) || strings.Contains(filepath_lower, pattern) {
-				// Read file content (limit to first 2000 characters for analysis)
+				// Read file content (limit to the first limits.important_file_chars characters for analysis)
 				content, err := a.crawler.ReadFile(file)
 				if err == nil {
-					if len(content) > 2000 {
-						content = content[:2000] + "..."
+					if limit := a.config.Limits.ImportantFileChars; len(content) > limit {
+						content = content[:limit] + "..."
 					}
-					importantFiles[file.RelativePath] = content
+					importantFiles[file.RelativePath] = a.llmContent(file.RelativePath, content)
 				}
 				break
 			}
@@ -875,12 +1751,9 @@ func (a *Analyzer) extractImportantFiles(files []FileInfo) map[string]string {
 	return importantFiles
 }
 
-// enhanceWithMicroserviceDiscovery enhances the analysis with intelligent microservice discovery
-func (a *Analyzer) enhanceWithMicroserviceDiscovery(ctx context.Context, files []FileInfo, projectType *detector.DetectionResult, projectSummary *internalOpenai.ProjectSummary) []microservices.DiscoveredService {
-	// Enhanced microservice discovery - now works for all project types, not just monorepos
-	fmt.Printf("🔍 Starting enhanced microservice discovery...\n")
-
-	// Determine project language/type for service discovery
+// discoveryProjectType picks the service discovery strategy (go, node.js or react.js) from the
+// detected project type and the files; "" when none applies
+func (a *Analyzer) discoveryProjectType(files []FileInfo, projectType *detector.DetectionResult) string {
 	var projectTypeStr string
 	if projectType != nil {
 		switch strings.ToLower(string(projectType.PrimaryType)) {
@@ -906,6 +1779,16 @@ func (a *Analyzer) enhanceWithMicroserviceDiscovery(ctx context.Context, files [
 			}
 		}
 	}
+	return projectTypeStr
+}
+
+// enhanceWithMicroserviceDiscovery enhances the analysis with intelligent microservice discovery
+func (a *Analyzer) enhanceWithMicroserviceDiscovery(ctx context.Context, files []FileInfo, projectType *detector.DetectionResult, projectSummary *internalOpenai.ProjectSummary) []microservices.DiscoveredService {
+	// Enhanced microservice discovery - now works for all project types, not just monorepos
+	fmt.Printf("🔍 Starting enhanced microservice discovery...\n")
+
+	// Determine project language/type for service discovery
+	projectTypeStr := a.discoveryProjectType(files, projectType)
 
 	if projectTypeStr == "" {
 		fmt.Printf("⚠️  Could not determine project type for microservice discovery\n")
@@ -915,15 +1798,13 @@ func (a *Analyzer) enhanceWithMicroserviceDiscovery(ctx context.Context, files [
 	// Use enhanced discovery for deterministic service detection
 	enhancedDiscovery := microservices.NewEnhancedServiceDiscovery(a.crawler.basePath, projectTypeStr)
 
-	// Convert files to map for enhanced discovery
-	fileMap := make(map[string]string)
-	
-	for _, file := range files {
-		content, err := a.crawler.ReadFile(file)
-		if err == nil {
-			fileMap[file.RelativePath] = content
-		}
+	// Discovery reasons about the whole layout but reads only manifests, Compose files,
+	// Dockerfiles and entry points
+	if ctx.Err() != nil {
+		fmt.Printf("⏱️  Microservice discovery interrupted: %v\n", ctx.Err())
+		return nil
 	}
+	fileMap := a.crawler.Store(files).Outline(microservices.NeedsContent)
 
 	// Use enhanced discovery (works with just file map, no folder structure needed)
 	discoveredServices, err := enhancedDiscovery.DiscoverMicroservices(fileMap)
@@ -937,6 +1818,19 @@ func (a *Analyzer) enhanceWithMicroserviceDiscovery(ctx context.Context, files [
 		return nil
 	}
 
+	// Trace what each entry point initializes, reading only the entry points and the functions they call
+	microservices.TraceStartup(discoveredServices, a.crawler.Store(files))
+
+	// Keep architecture classifications already made by the detailed analysis
+	previousArchitectures := make(map[string]*internalOpenai.ServiceArchitecture)
+	if projectSummary != nil && projectSummary.DetailedAnalysis != nil {
+		for _, service := range projectSummary.DetailedAnalysis.MonorepoServices {
+			if service.Architecture != nil {
+				previousArchitectures[filepath.Clean(service.Path)] = service.Architecture
+			}
+		}
+	}
+	
 	// Convert discovered services to MonorepoService format
 	var enhancedServices []internalOpenai.MonorepoService
 	for _, service := range discoveredServices {
@@ -958,12 +1852,22 @@ func (a *Analyzer) enhanceWithMicroserviceDiscovery(ctx context.Context, files [
 			APIType:      string(service.APIType),
 			Port:         service.Port,
 			EntryPoint:   service.EntryPoint,
+			Architecture: previousArchitectures[filepath.Clean(service.Path)],
+		}
+		if service.Container != nil {
+			enhancedService.Container = service.Container.Summary
 		}
 		enhancedServices = append(enhancedServices, enhancedService)
 	}
 
+	a.classifyServiceArchitectures(ctx, files, enhancedServices)
+	
 	// Enhance project summary with discovered services
-	if len(enhancedServices) > 0 {
+	if ctx.Err() != nil {
+		fmt.Printf("⏱️  Microservice discovery interrupted: %v\n", ctx.Err())
+		return nil
+	}
+	if len(enhancedServices) > 0 && projectSummary != nil {
 		// Update or create DetailedAnalysis if needed
 		if projectSummary.DetailedAnalysis == nil {
 			projectSummary.DetailedAnalysis = &internalOpenai.RepositoryAnalysis{
@@ -1086,14 +1990,19 @@ func (a *Analyzer) generateServicePurpose(serviceName string, apiType microservi
 }
 
 // discoverServiceRelationships discovers relationships between microservices
-func (a *Analyzer) discoverServiceRelationships(files []FileInfo, discoveredServices []microservices.DiscoveredService, projectSummary *internalOpenai.ProjectSummary) []relationships.ServiceRelationship {
+func (a *Analyzer) discoverServiceRelationships(ctx context.Context, files []FileInfo, discoveredServices []microservices.DiscoveredService, projectSummary *internalOpenai.ProjectSummary) []relationships.ServiceRelationship {
 	projectPath := a.crawler.basePath
 	cacheDir := "./relationships_cache"
 	
-	// Try to load from cache first
-	cachedGraph, err := relationships.LoadServiceGraphFromFile(projectPath, cacheDir)
-	if err != nil {
-		fmt.Printf("⚠️  Failed to load cache: %v\n", err)
+	// Try to load from cache first (scoped analyses bypass the whole-repository cache)
+	useCache := a.config.Cache.Enabled && !a.options.IsScoped()
+	var cachedGraph *relationships.ServiceGraph
+	var err error
+	if useCache {
+		cachedGraph, err = relationships.LoadServiceGraphFromFile(projectPath, cacheDir, a.config.GetRelationshipCacheTTL(), atrest.For(a.config))
+		if err != nil {
+			fmt.Printf("⚠️  Failed to load cache: %v\n", err)
+		}
 	}
 	
 	var serviceGraph *relationships.ServiceGraph
@@ -1104,17 +2013,13 @@ func (a *Analyzer) discoverServiceRelationships(files []FileInfo, discoveredServ
 	} else {
 		fmt.Println("🔍 Analyzing service relationships...")
 		
-		// Convert files to map for relationship discovery
-		fileMap := make(map[string]string)
-		for _, file := range files {
-			content, err := a.crawler.ReadFile(file)
-			if err == nil {
-				fileMap[file.RelativePath] = content
-			}
+		if ctx.Err() != nil {
+			fmt.Printf("⏱️  Service relationship discovery interrupted: %v\n", ctx.Err())
+			return []relationships.ServiceRelationship{}
 		}
 
-		// Create relationship discovery instance
-		relationshipDiscovery := relationships.NewRelationshipDiscovery(discoveredServices, fileMap)
+		// Create relationship discovery instance; it reads config and code files one at a time
+		relationshipDiscovery := relationships.NewRelationshipDiscovery(discoveredServices, a.crawler.Store(files))
 
 		// Discover relationships
 		serviceGraph, err = relationshipDiscovery.DiscoverRelationships(projectPath)
@@ -1124,11 +2029,20 @@ func (a *Analyzer) discoverServiceRelationships(files []FileInfo, discoveredServ
 		}
 		
 		// Save to cache
-		if err := serviceGraph.SaveToFile(cacheDir); err != nil {
-			fmt.Printf("⚠️  Failed to save relationship cache: %v\n", err)
+		if useCache {
+			if err := serviceGraph.SaveToFile(cacheDir, atrest.For(a.config)); err != nil {
+				fmt.Printf("⚠️  Failed to save relationship cache: %v\n", err)
+			}
 		}
 	}
 
+	// Filter after caching so the cache keeps every relationship whatever the thresholds
+	if filter := a.options.RelationshipFilter(a.config); filter.Active() {
+		total := len(serviceGraph.Relationships)
+		serviceGraph = serviceGraph.Filtered(filter)
+		fmt.Printf("🔎 Kept %d of %d service dependencies (%s)\n", len(serviceGraph.Relationships), total, filter)
+	}
+
 	// Display the service dependency graph
 	if len(serviceGraph.Relationships) > 0 {
 		fmt.Printf("\n%s\n", serviceGraph.ConsoleVisualization())
@@ -1165,14 +2079,15 @@ func (a *Analyzer) discoverServiceRelationships(files []FileInfo, discoveredServ
 }
 
 // extractDatabaseSchema extracts database schema from SQL migration files using streaming extractor
-func (a *Analyzer) extractDatabaseSchema(files []FileInfo) *database.DatabaseSchema {
-	// Convert files to map for schema extraction
-	fileMap := make(map[string]string)
-	for _, file := range files {
-		content, err := a.crawler.ReadFile(file)
-		if err == nil {
-			fileMap[file.RelativePath] = content
-		}
+func (a *Analyzer) extractDatabaseSchema(ctx context.Context, files []FileInfo) *database.DatabaseSchema {
+	// Only SQL migrations are read for schema extraction
+	fileMap := a.crawler.Store(files).Contents(database.IsMigrationFile)
+
+	// The implicit relationship pass asks this analysis's client, with its model, language and caches;
+	// privacy mode runs it separately on what may be sent
+	var relationshipClient *internalOpenai.Client
+	if a.deepLLM() && a.privacy == nil {
+		relationshipClient = a.openaiClient
 	}
 
 	// Extract schema using the streaming extractor with final migration generation
@@ -1183,20 +2098,29 @@ func (a *Analyzer) extractDatabaseSchema(files []FileInfo) *database.DatabaseSch
 			}
 		}()
 		
-		return database.ExtractSchemaWithFinalMigration("", fileMap, func(response database.StreamingResponse) {
+		return database.ExtractSchemaWithFinalMigrationContext(ctx, "", fileMap, func(response database.StreamingResponse) {
 			// Progress callback for database extraction
 			fmt.Printf("📋 Database extraction: %s (%s)\n", response.Phase, response.Message)
-		})
+		}, relationshipClient)
 	}()
 	
+	if err == nil && result != nil && result.Schema != nil && a.deepLLM() && a.privacy != nil {
+		result.LLMRelationships = a.privateRelationships(ctx, fileMap)
+	}
+
 	// Convert canonical schema to legacy format and add final migration SQL and LLM relationships
 	var schema *database.DatabaseSchema
 	if err == nil && result != nil && result.Schema != nil {
 		schema = database.ConvertToLegacySchema(result.Schema, "")
-		// Add the final migration SQL and LLM relationships to the schema
+		// Add the final migration SQL, LLM relationships and design lint to the schema
 		if schema != nil {
 			schema.FinalMigrationSQL = result.FinalMigrationSQL
 			schema.LLMRelationships = result.LLMRelationships
+			schema.Quality = database.LintSchema(result.Schema)
+			schema.Sensitivity = database.SummarizeSensitivity(result.Schema)
+			if a.options.ERDSplit {
+				schema.ERDSplit = schema.SplitERD()
+			}
 		}
 	}
 	
@@ -1220,26 +2144,278 @@ func (a *Analyzer) extractDatabaseSchema(files []FileInfo) *database.DatabaseSch
 	// Display summary
 	fmt.Printf("🗃️  Found %d database tables\n", len(schema.Tables))
 	for tableName, table := range schema.Tables {
+		if len(table.Triggers) > 0 {
+			fmt.Printf("   📊 %s (%d columns, %d triggers)\n", tableName, len(table.Columns), len(table.Triggers))
+			continue
+		}
 		fmt.Printf("   📊 %s (%d columns)\n", tableName, len(table.Columns))
 	}
+	if len(schema.Functions) > 0 {
+		fmt.Printf("   ⚙️  %d functions/procedures\n", len(schema.Functions))
+	}
+	if quality := schema.Quality; quality != nil {
+		fmt.Printf("🩺 Schema quality: %d/100 (%s) - %d errors, %d warnings, %d suggestions\n",
+			quality.Score, quality.Grade, quality.Errors, quality.Warnings, quality.Infos)
+	}
+	if sensitivity := schema.Sensitivity; sensitivity != nil {
+		fmt.Printf("🔐 Data sensitivity: %s\n", sensitivity.Summary)
+	}
 	
 	return schema
 }
 
-// extractProjectSecrets analyzes configuration files to extract required secrets
-func (a *Analyzer) extractProjectSecrets(ctx context.Context, projectPath string) *secrets.ProjectSecrets {
+// extractInfrastructure builds the Terraform inventory and links resources to the discovered services
+func (a *Analyzer) extractInfrastructure(ctx context.Context, files []FileInfo, services []microservices.DiscoveredService) *infrastructure.Inventory {
+	hasTerraform := false
+	for _, file := range files {
+		if infrastructure.IsTerraformFile(file.RelativePath) {
+			hasTerraform = true
+			break
+		}
+	}
+	if !hasTerraform {
+		fmt.Println("☁️  No Terraform files found")
+		return nil
+	}
+
+	// Service code and config are read as well, one file at a time, to find references to each resource
+	if ctx.Err() != nil {
+		fmt.Printf("⏱️  Infrastructure mapping interrupted: %v\n", ctx.Err())
+		return nil
+	}
+	return infrastructure.BuildInventory(a.crawler.Store(files), services)
+}
+
+// detectDeadCode lists Go packages, JS files and tables that nothing appears to use
+func (a *Analyzer) detectDeadCode(ctx context.Context, sources *filestore.FileStore, databaseSchema *database.DatabaseSchema) *deadcode.Report {
+	if ctx.Err() != nil {
+		return nil
+	}
+	
+	report, err := deadcode.NewDetector(sources).Detect(databaseSchema)
+	if err != nil {
+		fmt.Printf("⚠️  Dead code detection failed: %v\n", err)
+		return nil
+	}
+	if report.Total == 0 {
+		return nil
+	}
+	return report
+}
+
+// scopedServices re-roots service paths, which are relative to the repository, at the scoped root
+// that whole-tree scanners walk, dropping services outside the scope and, in a scoped analysis,
+// services whose directory is filtered out and that own none of the sources
+func (a *Analyzer) scopedServices(sources *filestore.FileStore, services []microservices.DiscoveredService) []microservices.DiscoveredService {
+	root := a.scopeRoot()
+	var scoped []microservices.DiscoveredService
+	for _, service := range services {
+		if root != "" {
+			if !portable.Within(service.Path, root) {
+				continue
+			}
+			service.Path = strings.TrimPrefix(strings.TrimPrefix(portable.Slash(service.Path), root), "/")
+		}
+		scoped = append(scoped, service)
+	}
+	if !a.options.IsScoped() {
+		return scoped
+	}
+
+	owners := make(map[string]bool)
+	for _, rel := range sources.Paths() {
+		owners[microservices.OwningService(rel, scoped)] = true
+	}
+	var owning []microservices.DiscoveredService
+	for _, service := range scoped {
+		if owners[service.Name] || a.inScope(portable.Slash(service.Path)) {
+			owning = append(owning, service)
+		}
+	}
+	return owning
+}
+
+// detectToolchains reports the runtime and tool versions the project's version files ask for
+func (a *Analyzer) detectToolchains(sources *filestore.FileStore, services []microservices.DiscoveredService) *toolchain.Report {
+	report, err := toolchain.Detect(sources, a.scopedServices(sources, services))
+	if err != nil {
+		fmt.Printf("⚠️  Toolchain detection failed: %v\n", err)
+		return nil
+	}
+	if len(report.Toolchains) == 0 {
+		return nil
+	}
+	fmt.Printf("🧰 %s\n", report.Summary)
+	return report
+}
+
+// checkAdvisories flags end-of-life and very old framework and runtime versions
+func (a *Analyzer) checkAdvisories(files []FileInfo, services []microservices.DiscoveredService, toolchains *toolchain.Report) *advisories.Report {
+	report := advisories.Check(a.crawler.Store(files), newServiceDirs(services).owner, toolchains, time.Now())
+	if report.Checked == 0 {
+		return nil
+	}
+	fmt.Printf("🗓️  %s\n", report.Summary)
+	return report
+}
+
+// analyzeFrontend reports routing, state management, design systems and components for projects
+// with a UI; other project types are skipped
+func (a *Analyzer) analyzeFrontend(sources *filestore.FileStore, projectType *detector.DetectionResult) *frontend.Report {
+	if projectType == nil || (projectType.PrimaryType != detector.Frontend && projectType.PrimaryType != detector.Fullstack) {
+		return nil
+	}
+	report, err := frontend.NewAnalyzer(sources).Analyze()
+	if err != nil {
+		fmt.Printf("⚠️  Frontend analysis failed: %v\n", err)
+		return nil
+	}
+	if len(report.Apps) == 0 {
+		return nil
+	}
+	return report
+}
+
+// buildPackageGraph links the repository's workspace packages, go.work modules or Go packages
+// through their manifests and imports; it is nil for single-package projects
+func (a *Analyzer) buildPackageGraph(sources *filestore.FileStore) *pkggraph.Graph {
+	graph, err := pkggraph.Build(sources)
+	if err != nil {
+		fmt.Printf("⚠️  Package graph failed: %v\n", err)
+		return nil
+	}
+	if len(graph.Packages) == 0 {
+		return nil
+	}
+	for _, hotspot := range graph.Hotspots {
+		fmt.Printf("🕸️  %s\n", hotspot.Message)
+	}
+	return graph
+}
+
+// detectFeatureFlags inventories flag SDK calls and homegrown toggles; it is nil when the project
+// checks no flags
+func (a *Analyzer) detectFeatureFlags(sources *filestore.FileStore) *featureflags.Report {
+	report, err := featureflags.NewScanner(sources).Scan()
+	if err != nil {
+		fmt.Printf("⚠️  Feature flag scan failed: %v\n", err)
+		return nil
+	}
+	if len(report.Flags) == 0 {
+		return nil
+	}
+	return report
+}
+
+// analyzeAuthSurface reports, per service, how requests are authenticated and authorized and where
+// that is enforced; it is nil when no service shows any auth mechanism
+func (a *Analyzer) analyzeAuthSurface(sources *filestore.FileStore, services []microservices.DiscoveredService) *auth.Report {
+	report, err := auth.Analyze(sources, a.scopedServices(sources, services))
+	if err != nil {
+		fmt.Printf("⚠️  Auth analysis failed: %v\n", err)
+		return nil
+	}
+	for _, service := range report.Services {
+		if len(service.Mechanisms) > 0 {
+			return report
+		}
+	}
+	return nil
+}
+
+// detectJobs inventories cron entries, scheduled workloads and queue workers; it is nil when the
+// project has none
+func (a *Analyzer) detectJobs(sources *filestore.FileStore, services []microservices.DiscoveredService) *jobs.Report {
+	report, err := jobs.Analyze(sources, a.scopedServices(sources, services))
+	if err != nil {
+		fmt.Printf("⚠️  Background job detection failed: %v\n", err)
+		return nil
+	}
+	if len(report.Jobs) == 0 {
+		return nil
+	}
+	return report
+}
+
+// analyzeDataContracts inventories Avro, JSON Schema and protobuf event contracts with the channels
+// they travel on; it is nil when the project has none
+func (a *Analyzer) analyzeDataContracts(sources *filestore.FileStore, services []microservices.DiscoveredService, backgroundJobs *jobs.Report) *contracts.Report {
+	report, err := contracts.Analyze(sources, a.scopedServices(sources, services), backgroundJobs)
+	if err != nil {
+		fmt.Printf("⚠️  Data contract detection failed: %v\n", err)
+		return nil
+	}
+	if len(report.Contracts) == 0 {
+		return nil
+	}
+	return report
+}
+
+// analyzeTableOwnership attributes the schema's tables to services; it is nil without a schema or
+// with fewer than two services
+func (a *Analyzer) analyzeTableOwnership(sources *filestore.FileStore, databaseSchema *database.DatabaseSchema, services []microservices.DiscoveredService) *ownership.Report {
+	report, err := ownership.Analyze(sources, databaseSchema, a.scopedServices(sources, services))
+	if err != nil {
+		fmt.Printf("⚠️  Table ownership mapping failed: %v\n", err)
+		return nil
+	}
+	return report
+}
+
+// analyzeDocCoverage scores how well each service is documented
+func (a *Analyzer) analyzeDocCoverage(sources *filestore.FileStore, services []microservices.DiscoveredService) *doccoverage.Report {
+	report, err := doccoverage.Analyze(filepath.Base(a.scopedRootPath()), sources, a.scopedServices(sources, services))
+	if err != nil {
+		fmt.Printf("⚠️  Documentation coverage failed: %v\n", err)
+		return nil
+	}
+	return report
+}
+
+// analyzeTenancy reports how the project separates its tenants' data; it is nil for single-tenant
+// projects
+func (a *Analyzer) analyzeTenancy(sources *filestore.FileStore, databaseSchema *database.DatabaseSchema) *tenancy.Report {
+	report, err := tenancy.Analyze(sources, databaseSchema)
+	if err != nil {
+		fmt.Printf("⚠️  Tenancy detection failed: %v\n", err)
+		return nil
+	}
+	return report
+}
+
+// buildArchitectureMap arranges the folder summaries into a tree named after the project directory
+func (a *Analyzer) buildArchitectureMap(folderSummaries map[string]*internalOpenai.FolderSummary) *archmap.Map {
+	root, err := filepath.Abs(a.crawler.basePath)
+	if err != nil {
+		root = a.crawler.basePath
+	}
+	return archmap.Build(filepath.Base(root), folderSummaries)
+}
+
+// extractProjectSecrets analyzes configuration files to extract required secrets, then attributes
+// them to the discovered services
+func (a *Analyzer) extractProjectSecrets(ctx context.Context, sources *filestore.FileStore, services []microservices.DiscoveredService) *secrets.ProjectSecrets {
 	fmt.Printf("🔐 [DEBUG] Starting project secrets extraction\n")
 	
-	// Create secret extractor
-	extractor := secrets.NewSecretExtractor(projectPath)
+	// Create secret extractor; it follows in-project symlinks, so it walks the scoped root itself
+	extractor := secrets.NewSecretExtractor(a.scopedRootPath())
+	if a.options.IsScoped() {
+		extractor.SetScope(a.inScope)
+	}
 	
 	// Extract secrets from configuration files
-	projectSecrets, err := extractor.ExtractSecrets()
+	projectSecrets, err := extractor.ExtractSecretsContext(ctx)
 	if err != nil {
 		fmt.Printf("⚠️ [DEBUG] Secret extraction failed: %v\n", err)
 		return nil
 	}
 	
+	services = a.scopedServices(sources, services)
+	if ctx.Err() == nil && len(services) > 0 {
+		extractor.AttributeToServices(projectSecrets, services)
+		fmt.Printf("🧭 [DEBUG] Attributed variables to %d services, %d unattributed\n", len(services), len(projectSecrets.Unattributed))
+	}
+	
 	fmt.Printf("✅ [DEBUG] Secret extraction completed: %d total variables, %d required\n", 
 		projectSecrets.TotalVariables, projectSecrets.RequiredCount)
 	
@@ -1256,8 +2432,14 @@ func (a *Analyzer) generateHelpfulQuestions(ctx context.Context, projectSummary
 		return []HelpfulQuestion{}
 	}
 	
+	// Offline and quick runs rely on the fallback questions
+	if !a.deepLLM() {
+		fmt.Printf("📴 [DEBUG] %s mode - skipping LLM question generation\n", a.llmMode())
+		return []HelpfulQuestion{}
+	}
+	
 	// Build context for LLM prompt
-	prompt := a.buildQuestionsPrompt(projectSummary, projectType, services, databaseSchema, fileSummaries)
+	prompt := a.buildQuestionsContext(projectSummary, projectType, services, databaseSchema, fileSummaries)
 	
 	fmt.Printf("✅ [DEBUG] Question prompt created (%d characters)\n", len(prompt))
 	
@@ -1272,28 +2454,24 @@ func (a *Analyzer) generateHelpfulQuestions(ctx context.Context, projectSummary
 	return questions
 }
 
-// buildQuestionsPrompt creates a comprehensive prompt for LLM question generation
-func (a *Analyzer) buildQuestionsPrompt(projectSummary *internalOpenai.ProjectSummary, projectType *detector.DetectionResult, services []microservices.DiscoveredService, databaseSchema *database.DatabaseSchema, fileSummaries map[string]*internalOpenai.FileSummary) string {
-	prompt := `You are a senior software engineer helping developers understand and work with a codebase. Based on the project analysis below, generate 5-7 helpful questions with detailed answers that would help developers:
-
-1. Understand the project structure and architecture quickly
-2. Get started with development faster  
-3. Avoid common pitfalls and mistakes
-4. Know the most important files and entry points
-5. Understand deployment and setup processes
-
-Make the questions SPECIFIC to this project - not generic programming questions. Focus on practical, actionable information.
-
-Return your response as a JSON array with this exact format:
-[
-  {
-    "question": "How do I set up the development environment for this project?",
-    "answer": "Detailed step-by-step answer specific to this project..."
-  }
-]
+// GenerateQuestions asks the LLM for the onboarding questions of a completed analysis, as the
+// questions phase does, but returns LLM failures instead of falling back to generic questions. The
+// file summaries are those of the last run, so EnableIncremental must be called before it.
+func (a *Analyzer) GenerateQuestions(ctx context.Context, result *AnalysisResult) ([]HelpfulQuestion, error) {
+	if !a.deepLLM() {
+		return nil, fmt.Errorf("questions are written by the LLM, which %s mode skips", a.llmMode())
+	}
+	if result == nil || result.ProjectSummary == nil || result.ProjectType == nil {
+		return nil, fmt.Errorf("the analysis has no project summary to ask questions about")
+	}
+	prompt := a.buildQuestionsContext(result.ProjectSummary, result.ProjectType, result.Services, result.DatabaseSchema, a.FileSummaries())
+	return a.callLLMForQuestions(ctx, prompt)
+}
 
-PROJECT ANALYSIS:
-`
+// buildQuestionsContext describes the project for the questions prompt
+func (a *Analyzer) buildQuestionsContext(projectSummary *internalOpenai.ProjectSummary, projectType *detector.DetectionResult, services []microservices.DiscoveredService, databaseSchema *database.DatabaseSchema, fileSummaries map[string]*internalOpenai.FileSummary) string {
+	// The instructions around this context come from the questions prompt template
+	prompt := ""
 
 	// Add project type and confidence
 	if projectType != nil {
@@ -1320,6 +2498,21 @@ PROJECT ANALYSIS:
 		if len(projectSummary.ExternalServices) > 0 {
 			prompt += fmt.Sprintf("External Services: %v\n", projectSummary.ExternalServices)
 		}
+		if docs := projectSummary.Documentation; docs != nil {
+			// Commands quoted from the project's own docs answer "how do I run this" better than guesses
+			if len(docs.SetupSteps) > 0 {
+				prompt += "\nDocumented setup steps (from " + strings.Join(docs.Sources, ", ") + "):\n"
+				for _, step := range docs.SetupSteps {
+					prompt += fmt.Sprintf("- %s\n", step)
+				}
+			}
+			if len(docs.RunCommands) > 0 {
+				prompt += "\nDocumented run commands:\n"
+				for _, command := range docs.RunCommands {
+					prompt += fmt.Sprintf("- %s\n", command)
+				}
+			}
+		}
 	}
 	
 	// Add services information
@@ -1352,17 +2545,6 @@ PROJECT ANALYSIS:
 			prompt += fmt.Sprintf("- %s\n", file)
 		}
 	}
-	
-	prompt += `
-
-IMPORTANT GUIDELINES:
-- Questions must be specific to THIS project, not generic
-- Focus on practical development needs (setup, architecture, deployment)
-- Include specific file names, commands, and project details in answers
-- Make answers actionable and detailed
-- Avoid questions about general programming concepts
-- Generate exactly 5-7 questions
-- Return ONLY the JSON array, no other text`
 
 	return prompt
 }
@@ -1390,76 +2572,45 @@ func (a *Analyzer) extractKeyFiles(fileSummaries map[string]*internalOpenai.File
 	}
 	
 	// Limit to most important ones
-	if len(keyFiles) > 8 {
-		keyFiles = keyFiles[:8]
+	if limit := a.config.Limits.KeyFiles; len(keyFiles) > limit {
+		keyFiles = keyFiles[:limit]
 	}
 	
 	return keyFiles
 }
 
 // callLLMForQuestions makes the LLM API call for question generation
-func (a *Analyzer) callLLMForQuestions(ctx context.Context, prompt string) ([]HelpfulQuestion, error) {
+func (a *Analyzer) callLLMForQuestions(ctx context.Context, projectContext string) ([]HelpfulQuestion, error) {
 	fmt.Printf("🤖 [DEBUG] Starting LLM call for question generation\n")
-	fmt.Printf("📝 [DEBUG] Prompt length: %d characters\n", len(prompt))
-	
-	// Get OpenAI API key from environment
-	apiKey := os.Getenv("OPENAI_API_KEY")
-	if apiKey == "" {
-		// Try loading from config file as fallback
-		cfg, err := config.LoadConfig("config.yaml")
-		if err == nil && cfg.OpenAI.APIKey != "" {
-			apiKey = cfg.OpenAI.APIKey
-		} else {
-			return nil, fmt.Errorf("OpenAI API key not found for question generation")
-		}
+	if a.config.OpenAI.APIKey == "" {
+		return nil, fmt.Errorf("OpenAI API key not found for question generation")
 	}
-	
-	// Create OpenAI client
-	openaiCfg := openai.DefaultConfig(apiKey)
-	client := openai.NewClientWithConfig(openaiCfg)
+	messages, err := a.openaiClient.Messages(prompts.Questions, prompts.QuestionsData{Context: projectContext}, true)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("📝 [DEBUG] Prompt length: %d characters\n", len(messages[1].Content))
 	
 	// Create context with extended timeout for question generation (5 minutes)
 	reqCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
 	defer cancel()
 	
-	// Make the API call
-	resp, err := client.CreateChatCompletion(reqCtx, openai.ChatCompletionRequest{
+	// Make the API call through the shared client so repeated prompts come from the LLM cache;
+	// the response is checked against the question schema and repaired or re-asked when malformed
+	var questions []HelpfulQuestion
+	err = a.openaiClient.CreateJSONCompletion(reqCtx, openai.ChatCompletionRequest{
 		Model:       "gpt-3.5-turbo",
 		Temperature: 0.3, // Slightly creative but still focused
 		MaxTokens:   3000, // Enough for detailed Q&A
-		Messages: []openai.ChatCompletionMessage{
-			{
-				Role:    openai.ChatMessageRoleSystem,
-				Content: "You are a helpful senior software engineer creating project-specific onboarding questions. Always return valid JSON arrays with question/answer objects. Be specific to the project details provided.",
-			},
-			{
-				Role:    openai.ChatMessageRoleUser,
-				Content: prompt,
-			},
-		},
+		Messages:    messages,
 		ResponseFormat: &openai.ChatCompletionResponseFormat{
 			Type: openai.ChatCompletionResponseFormatTypeJSONObject,
 		},
-	})
+	}, &questions)
 	
 	if err != nil {
-		fmt.Printf("❌ [DEBUG] OpenAI API call failed: %v\n", err)
-		return nil, fmt.Errorf("OpenAI API error: %v", err)
-	}
-	
-	if len(resp.Choices) == 0 {
-		return nil, fmt.Errorf("no response from OpenAI")
-	}
-	
-	responseContent := strings.TrimSpace(resp.Choices[0].Message.Content)
-	fmt.Printf("📝 [DEBUG] LLM response length: %d characters\n", len(responseContent))
-	
-	// Parse JSON response
-	var questions []HelpfulQuestion
-	if err := json.Unmarshal([]byte(responseContent), &questions); err != nil {
-		fmt.Printf("❌ [DEBUG] Failed to parse JSON response: %v\n", err)
-		fmt.Printf("📝 [DEBUG] Response content: %s\n", responseContent[:minInt(500, len(responseContent))])
-		return nil, fmt.Errorf("failed to parse LLM response: %v", err)
+		fmt.Printf("❌ [DEBUG] Question generation failed: %v\n", err)
+		return nil, err
 	}
 	
 	// Validate and filter questions
@@ -1489,9 +2640,20 @@ func (a *Analyzer) generateFallbackQuestions(projectType *detector.DetectionResu
 	fallbackQuestions := []HelpfulQuestion{}
 	
 	// Generic questions applicable to all project types
+	setupAnswer := "Check for setup files like package.json (Node.js), go.mod (Go), requirements.txt (Python), or README.md for installation instructions. Look for Docker files for containerized setup."
+	if projectSummary != nil && projectSummary.Documentation != nil && len(projectSummary.Documentation.SetupSteps)+len(projectSummary.Documentation.RunCommands) > 0 {
+		docs := projectSummary.Documentation
+		setupAnswer = fmt.Sprintf("Following %s:", strings.Join(docs.Sources[:min(len(docs.Sources), 3)], ", "))
+		if len(docs.SetupSteps) > 0 {
+			setupAnswer += fmt.Sprintf(" set up with `%s`.", strings.Join(docs.SetupSteps[:min(len(docs.SetupSteps), 6)], "`, `"))
+		}
+		if len(docs.RunCommands) > 0 {
+			setupAnswer += fmt.Sprintf(" Run it with `%s`.", strings.Join(docs.RunCommands[:min(len(docs.RunCommands), 4)], "`, `"))
+		}
+	}
 	fallbackQuestions = append(fallbackQuestions, HelpfulQuestion{
 		Question: "How do I set up the development environment for this project?",
-		Answer:   "Check for setup files like package.json (Node.js), go.mod (Go), requirements.txt (Python), or README.md for installation instructions. Look for Docker files for containerized setup.",
+		Answer:   setupAnswer,
 	})
 	
 	fallbackQuestions = append(fallbackQuestions, HelpfulQuestion{
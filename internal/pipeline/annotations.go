@@ -0,0 +1,55 @@
+package pipeline
+
+import (
+	"fmt"
+
+	"repo-explanation/internal/annotations"
+	"repo-explanation/internal/deadcode"
+	"repo-explanation/internal/microservices"
+	internalOpenai "repo-explanation/internal/openai"
+	"repo-explanation/internal/portable"
+)
+
+// Annotations returns the editor annotations of the last completed run, or nil before one
+func (a *Analyzer) Annotations() *annotations.Export {
+	return a.annotations
+}
+
+// exportAnnotations builds the editor annotations of this run's file summaries and, with
+// AnalysisOptions.Annotations, writes them into the repository. Generated and restricted files
+// are left out.
+func (a *Analyzer) exportAnnotations(fileSummaries map[string]*internalOpenai.FileSummary, services []microservices.DiscoveredService, deadCode *deadcode.Report) {
+	if len(fileSummaries) == 0 {
+		return
+	}
+	signals := annotations.Signals{Unused: make(map[string]bool), Skip: make(map[string]bool)}
+	for _, service := range services {
+		if service.EntryPoint != "" {
+			signals.EntryPoints = append(signals.EntryPoints, portable.Slash(service.EntryPoint))
+		}
+	}
+	if deadCode != nil {
+		for _, finding := range append(append([]deadcode.Finding{}, deadCode.GoPackages...), deadCode.JSFiles...) {
+			signals.Unused[portable.Slash(finding.Path)] = true
+		}
+	}
+	for filePath := range a.generatedFiles {
+		signals.Skip[filePath] = true
+	}
+	for filePath := range fileSummaries {
+		if a.isRestricted(filePath) {
+			signals.Skip[portable.Slash(filePath)] = true
+		}
+	}
+
+	a.annotations = annotations.Build(fileSummaries, signals)
+	if !a.options.Annotations {
+		return
+	}
+	target, err := a.annotations.Write(a.crawler.basePath)
+	if err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+		return
+	}
+	fmt.Printf("📝 Annotations for %d files written to %s\n", len(a.annotations.Files), target)
+}
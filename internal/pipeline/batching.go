@@ -0,0 +1,124 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+
+	"repo-explanation/internal/heuristics"
+	internalOpenai "repo-explanation/internal/openai"
+)
+
+// planFileBatches groups consecutive small files into batches of up to FileProcessing.BatchSize files
+// whose estimated tokens fit FileProcessing.ChunkSizeTokens. Every other file is a batch of one.
+func (a *Analyzer) planFileBatches(files []FileInfo) [][]FileInfo {
+	batchSize := a.config.FileProcessing.BatchSize
+	if batchSize <= 1 || !a.deepLLM() {
+		batches := make([][]FileInfo, len(files))
+		for i, file := range files {
+			batches[i] = []FileInfo{file}
+		}
+		return batches
+	}
+
+	var batches [][]FileInfo
+	var current []FileInfo
+	currentTokens := 0
+	flush := func() {
+		if len(current) > 0 {
+			batches = append(batches, current)
+			current, currentTokens = nil, 0
+		}
+	}
+	for _, file := range files {
+		// Bigger files keep their own prompt
+		if file.Size > int64(a.config.Limits.BatchFileBytes) {
+			batches = append(batches, []FileInfo{file})
+			continue
+		}
+		tokens := int(file.Size)/3 + 10 // Same conservative estimate the chunker uses
+		if len(current) >= batchSize || currentTokens+tokens > a.config.FileProcessing.ChunkSizeTokens {
+			flush()
+		}
+		current = append(current, file)
+		currentTokens += tokens
+	}
+	flush()
+	return batches
+}
+
+// analyzeFileBatch returns one result per file. Reused, offline and cached summaries are resolved first;
+// the remaining files share a single LLM call, and any file the batch misses is analyzed on its own.
+func (a *Analyzer) analyzeFileBatch(ctx context.Context, batch []FileInfo) []fileResult {
+	if len(batch) == 1 {
+		summary, err := a.analyzeFile(ctx, batch[0])
+		return []fileResult{{file: batch[0], summary: summary, err: err}}
+	}
+
+	results := make([]fileResult, 0, len(batch))
+	var pending []FileInfo
+	contents := make(map[string]string)
+	for _, file := range batch {
+		if summary, ok := a.reusableFileSummary(file.RelativePath); ok {
+			results = append(results, fileResult{file: file, summary: summary})
+			continue
+		}
+		if summary, ok := a.generatedSummary(file); ok {
+			results = append(results, fileResult{file: file, summary: summary})
+			continue
+		}
+		if a.isRestricted(file.RelativePath) {
+			results = append(results, fileResult{file: file, summary: restrictedSummary(file.RelativePath)})
+			continue
+		}
+		content, err := a.crawler.ReadFile(file)
+		if err != nil {
+			results = append(results, fileResult{file: file, err: err})
+			continue
+		}
+		// Documentation is summarized from its headings and commands, not by the LLM
+		if heuristics.IsDocumentationFile(file.RelativePath) {
+			results = append(results, fileResult{file: file, summary: heuristics.SummarizeDocument(file.RelativePath, content)})
+			continue
+		}
+		if a.config.Offline {
+			results = append(results, fileResult{file: file, summary: heuristics.SummarizeFile(file.RelativePath, content)})
+			continue
+		}
+		if summary, found := a.cache.GetFileSummary(a.fileCacheKey(file), content); found {
+			results = append(results, fileResult{file: file, summary: summary})
+			continue
+		}
+		pending = append(pending, file)
+		contents[file.RelativePath] = content
+	}
+	if len(pending) == 0 {
+		return results
+	}
+
+	var summaries map[string]*internalOpenai.FileSummary
+	if len(pending) > 1 {
+		inputs := make([]internalOpenai.BatchFile, len(pending))
+		for i, file := range pending {
+			inputs[i] = internalOpenai.BatchFile{Path: file.RelativePath, Content: a.llmContent(file.RelativePath, contents[file.RelativePath])}
+		}
+		var err error
+		summaries, err = a.openaiClient.AnalyzeFilesBatch(ctx, inputs)
+		if err != nil {
+			fmt.Printf("⚠️ Batch summarization of %d files failed, analyzing them individually: %v\n", len(pending), err)
+		}
+	}
+
+	for _, file := range pending {
+		summary := summaries[file.RelativePath]
+		if summary == nil {
+			summary, err := a.analyzeFile(ctx, file)
+			results = append(results, fileResult{file: file, summary: summary, err: err})
+			continue
+		}
+		if err := a.cache.SetFileSummary(a.fileCacheKey(file), contents[file.RelativePath], summary); err != nil {
+			fmt.Printf("⚠️  Failed to cache result for %s: %v\n", file.RelativePath, err)
+		}
+		results = append(results, fileResult{file: file, summary: summary})
+	}
+	return results
+}
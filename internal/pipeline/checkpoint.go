@@ -0,0 +1,154 @@
+package pipeline
+
+import (
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"repo-explanation/internal/atrest"
+	"repo-explanation/internal/database"
+	"repo-explanation/internal/deadcode"
+	"repo-explanation/internal/infrastructure"
+	"repo-explanation/internal/microservices"
+	internalOpenai "repo-explanation/internal/openai"
+	"repo-explanation/internal/relationships"
+	"repo-explanation/internal/secrets"
+)
+
+// Checkpoint records the output of every completed phase so an interrupted analysis can resume
+type Checkpoint struct {
+	Key                  string                                   `json:"key"`
+	ProjectPath          string                                   `json:"project_path"`
+	RepositoryURL        string                                   `json:"repository_url,omitempty"`
+	CompletedPhases      []string                                 `json:"completed_phases"`
+	FileSummaries        map[string]*internalOpenai.FileSummary   `json:"file_summaries,omitempty"`
+	FolderSummaries      map[string]*internalOpenai.FolderSummary `json:"folder_summaries,omitempty"`
+	ProjectSummary       *internalOpenai.ProjectSummary           `json:"project_summary,omitempty"`
+	Services             []microservices.DiscoveredService        `json:"services,omitempty"`
+	ServiceRelationships []relationships.ServiceRelationship      `json:"relationships,omitempty"`
+	SequenceFlows        []relationships.SequenceFlow             `json:"sequence_flows,omitempty"`
+	DatabaseSchema       *database.DatabaseSchema                 `json:"database_schema,omitempty"`
+	Infrastructure       *infrastructure.Inventory                `json:"infrastructure,omitempty"`
+	DeadCode             *deadcode.Report                         `json:"dead_code,omitempty"`
+	ProjectSecrets       *secrets.ProjectSecrets                  `json:"project_secrets,omitempty"`
+	UpdatedAt            time.Time                                `json:"updated_at"`
+}
+
+// Completed reports whether the phase finished in a previous run
+func (cp *Checkpoint) Completed(phase string) bool {
+	if cp == nil {
+		return false
+	}
+	for _, p := range cp.CompletedPhases {
+		if p == phase {
+			return true
+		}
+	}
+	return false
+}
+
+// markCompleted records a finished phase
+func (cp *Checkpoint) markCompleted(phase string) {
+	if !cp.Completed(phase) {
+		cp.CompletedPhases = append(cp.CompletedPhases, phase)
+	}
+}
+
+// checkpointKey identifies an analysis run by repository, scope, profile, mode and relationship filter
+func (a *Analyzer) checkpointKey() string {
+	key := a.getAnalysisKey() + a.options.scopeKey() + a.options.profileKey() + a.options.languageKey()
+	if a.config.Offline {
+		key += "#offline"
+	} else if a.options.Quick {
+		key += "#quick"
+	}
+	if filter := a.options.RelationshipFilter(a.config); filter.Active() {
+		key += "#relationships=" + filter.String()
+	}
+	return fmt.Sprintf("%x", md5.Sum([]byte(key)))
+}
+
+// checkpointPath returns the file a checkpoint is stored in
+func (a *Analyzer) checkpointPath() string {
+	dir := a.config.Output.OutputDirectory
+	if dir == "" {
+		dir = "./analysis_results"
+	}
+	return filepath.Join(dir, "checkpoints", a.checkpointKey()+".json")
+}
+
+// loadCheckpoint returns the saved checkpoint when resuming, or a fresh one
+func (a *Analyzer) loadCheckpoint() *Checkpoint {
+	fresh := &Checkpoint{
+		Key:           a.checkpointKey(),
+		ProjectPath:   a.crawler.basePath,
+		RepositoryURL: a.repositoryURL,
+	}
+
+	if !a.options.Resume {
+		return fresh
+	}
+
+	data, err := atrest.For(a.config).ReadFile(a.checkpointPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			fmt.Printf("📍 No checkpoint found for %s - starting from scratch\n", a.getAnalysisKey())
+		} else {
+			fmt.Printf("⚠️  Failed to read checkpoint: %v\n", err)
+		}
+		return fresh
+	}
+
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		fmt.Printf("⚠️  Ignoring corrupt checkpoint: %v\n", err)
+		return fresh
+	}
+	if cp.Key != fresh.Key {
+		fmt.Printf("⚠️  Checkpoint belongs to a different analysis - starting from scratch\n")
+		return fresh
+	}
+
+	// The clone directory may differ between server runs; keep the current one
+	cp.ProjectPath = a.crawler.basePath
+	fmt.Printf("📍 Resuming %s from checkpoint (completed phases: %v)\n", a.getAnalysisKey(), cp.CompletedPhases)
+	return &cp
+}
+
+// saveCheckpoint marks a phase complete and persists the checkpoint
+func (a *Analyzer) saveCheckpoint(cp *Checkpoint, phase string) {
+	cp.markCompleted(phase)
+	cp.UpdatedAt = time.Now()
+
+	path := a.checkpointPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		fmt.Printf("⚠️  Failed to create checkpoint directory: %v\n", err)
+		return
+	}
+
+	data, err := json.Marshal(cp)
+	if err != nil {
+		fmt.Printf("⚠️  Failed to encode checkpoint: %v\n", err)
+		return
+	}
+
+	// Write to a temp file first so a crash mid-write never leaves a truncated checkpoint
+	tmpPath := path + ".tmp"
+	if err := atrest.For(a.config).WriteFile(tmpPath, data, 0644); err != nil {
+		fmt.Printf("⚠️  Failed to write checkpoint: %v\n", err)
+		return
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		fmt.Printf("⚠️  Failed to save checkpoint: %v\n", err)
+	}
+}
+
+// clearCheckpoint removes the checkpoint after a successful analysis
+func (a *Analyzer) clearCheckpoint() {
+	if err := os.Remove(a.checkpointPath()); err != nil && !os.IsNotExist(err) {
+		fmt.Printf("⚠️  Failed to remove checkpoint: %v\n", err)
+	}
+}
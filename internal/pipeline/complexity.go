@@ -0,0 +1,117 @@
+package pipeline
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"repo-explanation/internal/complexity"
+	"repo-explanation/internal/database"
+	"repo-explanation/internal/languages"
+	"repo-explanation/internal/microservices"
+	"repo-explanation/internal/pkggraph"
+	"repo-explanation/internal/portable"
+	"repo-explanation/internal/relationships"
+)
+
+// createTableRegex captures the table name of a CREATE TABLE statement
+var createTableRegex = regexp.MustCompile(`(?i)\bcreate\s+table\s+(?:if\s+not\s+exists\s+)?([\w."` + "`" + `]+)`)
+
+// scoreComplexity rates the project and each service from the crawled files, their line counts,
+// the service and package dependency graphs and the schema
+func (a *Analyzer) scoreComplexity(files []FileInfo, fileStats []languages.FileStat, services []microservices.DiscoveredService,
+	rels []relationships.ServiceRelationship, packageGraph *pkggraph.Graph, schema *database.DatabaseSchema) *complexity.Report {
+	dirs := newServiceDirs(services)
+	project := complexity.Metrics{}
+	byService := make(map[string]*complexity.Metrics)
+	for _, service := range services {
+		byService[service.Name] = &complexity.Metrics{}
+	}
+
+	for _, file := range files {
+		if file.IsDir {
+			continue
+		}
+		project.Files++
+		if m := byService[dirs.owner(portable.Slash(file.RelativePath))]; m != nil {
+			m.Files++
+		}
+	}
+
+	projectLanguages := make(map[string]bool)
+	serviceLanguages := make(map[string]map[string]bool)
+	for _, stat := range fileStats {
+		if stat.Type != languages.Programming && stat.Type != languages.Markup {
+			continue
+		}
+		owner := dirs.owner(stat.Path)
+		project.LOC += stat.Code
+		if m := byService[owner]; m != nil {
+			m.LOC += stat.Code
+		}
+		if stat.Type != languages.Programming || stat.Code == 0 {
+			continue
+		}
+		projectLanguages[stat.Language] = true
+		if byService[owner] != nil {
+			if serviceLanguages[owner] == nil {
+				serviceLanguages[owner] = make(map[string]bool)
+			}
+			serviceLanguages[owner][stat.Language] = true
+		}
+	}
+	project.Languages = len(projectLanguages)
+	for name, langs := range serviceLanguages {
+		byService[name].Languages = len(langs)
+	}
+
+	project.Dependencies = len(rels)
+	if packageGraph != nil {
+		project.Dependencies += len(packageGraph.Dependencies)
+	}
+	callees := make(map[string]map[string]bool)
+	for _, rel := range rels {
+		if callees[rel.From] == nil {
+			callees[rel.From] = make(map[string]bool)
+		}
+		callees[rel.From][rel.To] = true
+	}
+	for name, to := range callees {
+		if m := byService[name]; m != nil {
+			m.Dependencies = len(to)
+		}
+	}
+
+	// Tables are attributed to the service holding the migrations that create them
+	allTables := make(map[string]bool)
+	serviceTables := make(map[string]map[string]bool)
+	a.crawler.Store(files).Walk(database.IsMigrationFile, func(relPath, content string) error {
+		owner := dirs.owner(relPath)
+		for _, match := range createTableRegex.FindAllStringSubmatch(content, -1) {
+			table := strings.ToLower(strings.NewReplacer(`"`, "", "`", "").Replace(match[1]))
+			allTables[table] = true
+			if byService[owner] != nil {
+				if serviceTables[owner] == nil {
+					serviceTables[owner] = make(map[string]bool)
+				}
+				serviceTables[owner][table] = true
+			}
+		}
+		return nil
+	})
+	project.Tables = len(allTables)
+	if schema != nil && len(schema.Tables) > 0 {
+		project.Tables = len(schema.Tables)
+	}
+	for name, tables := range serviceTables {
+		byService[name].Tables = len(tables)
+	}
+
+	var scores []complexity.Score
+	for _, service := range services {
+		scores = append(scores, complexity.Compute(service.Name, service.Path, *byService[service.Name]))
+	}
+	report := complexity.NewReport(project, scores)
+	fmt.Printf("🧮 %s\n", report.Summary)
+	return report
+}
@@ -0,0 +1,221 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	internalOpenai "repo-explanation/internal/openai"
+)
+
+// ConcurrencyStats reports how many map-phase workers ran, for the stats of a result
+type ConcurrencyStats struct {
+	Adaptive    bool `json:"adaptive"`
+	Initial     int  `json:"initial"`
+	Final       int  `json:"final"`
+	Peak        int  `json:"peak"`
+	Max         int  `json:"max"`
+	Increases   int  `json:"increases"`
+	Backoffs    int  `json:"backoffs"`
+	RateLimited int  `json:"rate_limited"` // 429 responses seen
+	Calls       int  `json:"calls"`        // API requests observed
+	AvgLatency  int  `json:"avg_latency_ms"`
+}
+
+const (
+	// maxErrorRate is the share of failed calls in a window above which the limiter shrinks
+	maxErrorRate = 0.1
+	// latencyBackoffFactor is how much slower than the best window a window may get before the limiter shrinks
+	latencyBackoffFactor = 2.0
+)
+
+// adaptiveLimiter gates map-phase workers through a limit that grows by one after each healthy
+// window of calls and halves on a 429, so throughput follows what the account's rate limit allows
+type adaptiveLimiter struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	limit    int
+	max      int
+	inFlight int
+
+	windowCalls   int
+	windowErrors  int
+	windowLatency time.Duration
+	bestLatency   time.Duration
+	sinceBackoff  int // Calls since the last backoff; 429s from requests already in flight are not counted twice
+	totalLatency  time.Duration
+
+	stats ConcurrencyStats
+}
+
+// newAdaptiveLimiter creates a limiter starting at initial workers and never exceeding max
+func newAdaptiveLimiter(initial, max int, adaptive bool) *adaptiveLimiter {
+	l := &adaptiveLimiter{
+		limit:        initial,
+		max:          max,
+		sinceBackoff: initial,
+		stats:        ConcurrencyStats{Adaptive: adaptive, Initial: initial, Final: initial, Peak: initial, Max: max},
+	}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+// acquire blocks until a worker slot is free; it returns false once ctx is done
+func (l *adaptiveLimiter) acquire(ctx context.Context) bool {
+	stop := context.AfterFunc(ctx, func() {
+		l.mu.Lock()
+		l.cond.Broadcast()
+		l.mu.Unlock()
+	})
+	defer stop()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for l.inFlight >= l.limit {
+		if ctx.Err() != nil {
+			return false
+		}
+		l.cond.Wait()
+	}
+	if ctx.Err() != nil {
+		return false
+	}
+	l.inFlight++
+	return true
+}
+
+// release frees a worker slot
+func (l *adaptiveLimiter) release() {
+	l.mu.Lock()
+	l.inFlight--
+	l.cond.Broadcast()
+	l.mu.Unlock()
+}
+
+// current returns the worker limit in effect
+func (l *adaptiveLimiter) current() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit
+}
+
+// observe records one API call and adjusts the limit at the end of each window
+func (l *adaptiveLimiter) observe(latency time.Duration, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.stats.Calls++
+	l.totalLatency += latency
+	l.sinceBackoff++
+
+	if internalOpenai.IsRateLimited(err) {
+		l.stats.RateLimited++
+		if l.sinceBackoff > l.limit {
+			l.setLimit(max(1, l.limit/2))
+			l.stats.Backoffs++
+			l.sinceBackoff = 0
+			l.resetWindow()
+			// The healthy latency may have been measured at a concurrency the account cannot sustain
+			l.bestLatency = 0
+		}
+		return
+	}
+
+	l.windowCalls++
+	l.windowLatency += latency
+	if err != nil {
+		l.windowErrors++
+	}
+	if l.windowCalls < l.limit {
+		return
+	}
+
+	average := l.windowLatency / time.Duration(l.windowCalls)
+	errorRate := float64(l.windowErrors) / float64(l.windowCalls)
+	switch {
+	case errorRate > maxErrorRate || (l.bestLatency > 0 && float64(average) > latencyBackoffFactor*float64(l.bestLatency)):
+		if l.limit > 1 {
+			l.setLimit(l.limit - 1)
+			l.stats.Backoffs++
+		}
+	case l.limit < l.max:
+		l.setLimit(l.limit + 1)
+		l.stats.Increases++
+	}
+	if l.bestLatency == 0 || average < l.bestLatency {
+		l.bestLatency = average
+	}
+	l.resetWindow()
+}
+
+func (l *adaptiveLimiter) setLimit(limit int) {
+	if limit != l.limit {
+		fmt.Printf("⚙️  [PERFORMANCE] Adjusting concurrent workers %d → %d\n", l.limit, limit)
+	}
+	l.limit = limit
+	l.stats.Final = limit
+	if limit > l.stats.Peak {
+		l.stats.Peak = limit
+	}
+	l.cond.Broadcast()
+}
+
+func (l *adaptiveLimiter) resetWindow() {
+	l.windowCalls, l.windowErrors, l.windowLatency = 0, 0, 0
+}
+
+// snapshot returns the stats so far
+func (l *adaptiveLimiter) snapshot() ConcurrencyStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	stats := l.stats
+	if stats.Calls > 0 {
+		stats.AvgLatency = int((l.totalLatency / time.Duration(stats.Calls)).Milliseconds())
+	}
+	return stats
+}
+
+// mapPhaseLimiter sizes the map phase: a fixed worker count scaled to the repository, or with
+// adaptive_concurrency, a limiter fed by every LLM call of the phase
+func (a *Analyzer) mapPhaseLimiter(totalFiles int) *adaptiveLimiter {
+	baseWorkers := a.config.RateLimiting.ConcurrentWorkers
+	if a.priming {
+		// Start at the ceiling and let 429s and slow windows bring the count down
+		workers := max(baseWorkers, a.config.RateLimiting.MaxConcurrentWorkers)
+		limiter := newAdaptiveLimiter(workers, workers, true)
+		a.openaiClient.ObserveCalls(limiter.observe)
+		return limiter
+	}
+	if a.config.RateLimiting.AdaptiveConcurrency && !a.config.Offline {
+		limiter := newAdaptiveLimiter(baseWorkers, a.config.RateLimiting.MaxConcurrentWorkers, true)
+		a.openaiClient.ObserveCalls(limiter.observe)
+		return limiter
+	}
+
+	// For large repositories, scale workers up to a limit that avoids overwhelming the API
+	maxWorkers := 12
+	numWorkers := baseWorkers
+	if totalFiles > 100 {
+		numWorkers = min(maxWorkers, max(baseWorkers, totalFiles/20))
+	}
+	return newAdaptiveLimiter(numWorkers, numWorkers, false)
+}
+
+// finishMapPhase stops observing LLM calls and keeps the limiter's stats for the result
+func (a *Analyzer) finishMapPhase(limiter *adaptiveLimiter) {
+	a.openaiClient.ObserveCalls(nil)
+	stats := limiter.snapshot()
+	a.concurrency = &stats
+	if stats.Adaptive {
+		fmt.Printf("⚙️  [PERFORMANCE] Adaptive concurrency: started at %d, peaked at %d, ended at %d workers (%d calls, %d rate limited, %dms average)\n",
+			stats.Initial, stats.Peak, stats.Final, stats.Calls, stats.RateLimited, stats.AvgLatency)
+	}
+}
+
+// recordConcurrency adds the map phase's effective concurrency to the stats
+func (a *Analyzer) recordConcurrency(stats map[string]interface{}) {
+	if a.concurrency != nil {
+		stats["concurrency"] = a.concurrency
+	}
+}
@@ -4,10 +4,13 @@ import (
 	"fmt"
 	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
 	"repo-explanation/config"
+	"repo-explanation/internal/database"
+	"repo-explanation/internal/filestore"
 	"repo-explanation/internal/gitignore"
 )
 
@@ -119,7 +122,7 @@ func (c *Crawler) CrawlFiles() ([]FileInfo, error) {
 		
 		fileInfo := FileInfo{
 			Path:         path,
-			RelativePath: relPath,
+			RelativePath: normalizedPath,
 			Size:         info.Size(),
 			Extension:    strings.ToLower(filepath.Ext(path)),
 			IsDir:        false,
@@ -153,6 +156,35 @@ func (c *Crawler) ReadFile(fileInfo FileInfo) (string, error) {
 	return content, nil
 }
 
+// Store indexes the crawled files for on-demand reading, with the same size limit and secret
+// redaction as ReadFile; no content is read until an analyzer asks for it
+func (c *Crawler) Store(files []FileInfo) *filestore.FileStore {
+	var transform func(string) string
+	if c.config.Security.RedactSecrets {
+		transform = c.redactSecrets
+	}
+	store := filestore.New(int64(c.config.FileProcessing.MaxFileSizeMB)*1024*1024, transform)
+	for _, file := range files {
+		if !file.IsDir {
+			store.Add(file.RelativePath, file.Path, file.Size)
+		}
+	}
+	return store
+}
+
+// sourceSkippedDirs are the directories no static scanner reads
+var sourceSkippedDirs = map[string]bool{".git": true, "node_modules": true, "vendor": true}
+
+// Sources indexes the files under dir, relative to it, for the static scanners (toolchains, auth,
+// jobs, contracts and the like), so the repository is walked once however many of them run. Unlike
+// CrawlFiles it keeps every extension and secret files, since each scanner picks the files it needs;
+// files over the limits.scan_file_kb setting are indexed but never read.
+func (c *Crawler) Sources(dir string) *filestore.FileStore {
+	return filestore.Scan(dir, c.config.GetScanFileBytes(), func(relDir string) bool {
+		return sourceSkippedDirs[path.Base(relDir)]
+	})
+}
+
 // redactSecrets removes potential secrets from content
 func (c *Crawler) redactSecrets(content string) string {
 	// List of patterns that might contain secrets
@@ -247,6 +279,9 @@ func (c *Crawler) isUnimportantDirectory(path string) bool {
 		// Package manager artifacts
 		".pnpm-store", ".yarn/cache", ".npm",
 		
+		// The analyzer's own exports, such as editor annotations
+		".repo-analyzer",
+		
 		// Language-specific build artifacts
 		"cmake-build-debug", "cmake-build-release", "obj", "debug", "release",
 	}
@@ -270,6 +305,11 @@ func (c *Crawler) isUnimportantFile(path string) bool {
 	lowerPath := strings.ToLower(path)
 	filename := strings.ToLower(filepath.Base(path))
 	
+	// Liquibase changelogs describe the schema, unlike CHANGELOG.md
+	if database.IsLiquibaseChangelog(path) {
+		return false
+	}
+	
 	// Skip files that don't provide architectural insight
 	unimportantFiles := []string{
 		// Lock files and dependencies
@@ -285,9 +325,6 @@ func (c *Crawler) isUnimportantFile(path string) bool {
 		// Test files (keep main test files, skip detailed test data)
 		".test.json", ".spec.json", "__snapshots__", ".coverage",
 		
-		// Generated files
-		"generated.go", "auto_generated", ".pb.go", ".gen.go",
-		
 		// Documentation that doesn't affect code architecture
 		"changelog", "license", "authors", "contributors", "code_of_conduct",
 		
@@ -0,0 +1,69 @@
+package pipeline
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"repo-explanation/internal/heuristics"
+	internalOpenai "repo-explanation/internal/openai"
+)
+
+// collectDocumentation extracts headings, setup steps and run commands from the README, docs/
+// and other top-level documentation, without an LLM
+func (a *Analyzer) collectDocumentation(files []FileInfo) *internalOpenai.DocumentationDigest {
+	var docs []FileInfo
+	for _, file := range files {
+		if heuristics.IsDocumentationFile(file.RelativePath) && documentationRank(file.RelativePath) < 5 && !a.isRestricted(file.RelativePath) {
+			docs = append(docs, file)
+		}
+	}
+	if len(docs) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(docs, func(i, j int) bool {
+		ri, rj := documentationRank(docs[i].RelativePath), documentationRank(docs[j].RelativePath)
+		if ri != rj {
+			return ri < rj
+		}
+		return docs[i].RelativePath < docs[j].RelativePath
+	})
+	if limit := a.config.Limits.DocumentationFiles; len(docs) > limit {
+		docs = docs[:limit]
+	}
+
+	var digests []*internalOpenai.DocumentationDigest
+	for _, file := range docs {
+		content, err := a.crawler.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		digests = append(digests, heuristics.ExtractDocumentation(file.RelativePath, content))
+	}
+	return heuristics.MergeDocumentation(digests)
+}
+
+// documentationRank orders documentation by how likely it is to describe the whole project:
+// the root README, other root docs, docs/, nested READMEs, then changelogs. 5 means not project documentation.
+func documentationRank(relativePath string) int {
+	slashed := strings.ReplaceAll(relativePath, "\\", "/")
+	name := strings.ToLower(path.Base(slashed))
+	dir := path.Dir(slashed)
+	isReadme := strings.HasPrefix(name, "readme")
+	isChangelog := strings.HasPrefix(name, "changelog") || strings.HasPrefix(name, "changes")
+
+	switch {
+	case isChangelog && dir == ".":
+		return 4
+	case isReadme && dir == ".":
+		return 0
+	case dir == ".":
+		return 1
+	case strings.HasPrefix(dir, "docs") || strings.HasPrefix(dir, "doc/") || dir == "doc":
+		return 2
+	case isReadme:
+		return 3
+	}
+	return 5
+}
@@ -0,0 +1,360 @@
+package pipeline
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"time"
+
+	"repo-explanation/internal/chunker"
+	"repo-explanation/internal/heuristics"
+	internalOpenai "repo-explanation/internal/openai"
+)
+
+// Prompt sizes the estimate assumes, in tokens. Fixed parts are the instructions of each prompt
+// template; summaries are the typical size of a file or folder summary once serialized.
+const (
+	filePromptTokens        = 250
+	batchFileTokens         = 15 // Per-file header in a batched prompt
+	fileOutputTokens        = 150
+	fileSummaryTokens       = 70
+	folderPromptTokens      = 200
+	folderSummaryTokens     = 100
+	folderOutputTokens      = 250
+	projectPromptTokens     = 550
+	projectOutputTokens     = 800
+	detailsPromptTokens     = 900
+	detailsOutputTokens     = 1200
+	architectureCallTokens  = 800
+	architectureOutput      = 300
+	sequenceFlowCallTokens  = 1500
+	sequenceFlowOutput      = 800
+	moduleNamingCallTokens  = 1500
+	moduleNamingOutput      = 500
+	glossaryCallTokens      = 3000
+	glossaryOutputTokens    = 1500
+	estimatedServiceClasses = 3 // Services classified by the LLM; the real count is only known after discovery
+)
+
+// Typical latency of one call, for the duration estimate
+var callLatency = map[string]time.Duration{
+	PhaseFiles:    3 * time.Second,
+	PhaseFolders:  4 * time.Second,
+	PhaseProject:  15 * time.Second,
+	PhaseServices: 5 * time.Second,
+	PhaseGlossary: 15 * time.Second,
+}
+
+// PhaseEstimate is the LLM work one phase would do
+type PhaseEstimate struct {
+	Phase        string        `json:"phase"`
+	Files        int           `json:"files,omitempty"`  // Files sent to the LLM
+	Chunks       int           `json:"chunks,omitempty"` // Chunks those files split into; only the first is sent
+	Folders      int           `json:"folders,omitempty"`
+	Cached       int           `json:"cached"` // Items the cache answers without a call
+	Calls        int           `json:"calls"`
+	InputTokens  int           `json:"input_tokens"`
+	OutputTokens int           `json:"output_tokens"`
+	CostUSD      float64       `json:"cost_usd"`
+	Duration     time.Duration `json:"duration_ns"`
+	Note         string        `json:"note,omitempty"`
+}
+
+// CostEstimate is what an analysis would send to the LLM, priced at the configured model
+type CostEstimate struct {
+	Model        string               `json:"model"`
+	Priced       bool                 `json:"priced"` // False when the model has no known price
+	Price        internalOpenai.Price `json:"price"`
+	Files        int                  `json:"files"` // Files crawled
+	Phases       []PhaseEstimate      `json:"phases"`
+	Calls        int                  `json:"calls"`
+	InputTokens  int                  `json:"input_tokens"`
+	OutputTokens int                  `json:"output_tokens"`
+	CostUSD      float64              `json:"cost_usd"`
+	Duration     time.Duration        `json:"duration_ns"`
+}
+
+// Estimate crawls the repository and works out the LLM calls, tokens, cost and duration of an
+// analysis with the current options, without calling the LLM. Files and folders the cache
+// already answers are not counted; folders and the project summary count as cached when every
+// file below them is.
+func (a *Analyzer) Estimate() (*CostEstimate, error) {
+	files, err := a.crawler.CrawlFiles()
+	if err != nil {
+		return nil, fmt.Errorf("file discovery failed: %v", err)
+	}
+	if err := a.resolveServiceScope(files); err != nil {
+		return nil, err
+	}
+	if a.options.IsScoped() {
+		files = a.options.filterFiles(files)
+	}
+	a.detectGenerated(files)
+
+	estimate := &CostEstimate{Model: a.config.OpenAI.Model, Files: len(files)}
+	estimate.Price, estimate.Priced = a.modelPrice()
+	if a.config.Offline {
+		return estimate, nil
+	}
+
+	// Files phase: what analyzeFileBatch would send
+	filesPhase := PhaseEstimate{Phase: PhaseFiles}
+	var pending []FileInfo
+	cachedFolders := make(map[string]bool) // Folder -> every file in it is cached
+	for _, file := range files {
+		folder := filepath.Dir(file.RelativePath)
+		if folder == "." {
+			folder = rootFolder
+		}
+		if _, ok := cachedFolders[folder]; !ok {
+			cachedFolders[folder] = true
+		}
+		if _, ok := a.reusableFileSummary(file.RelativePath); ok {
+			filesPhase.Cached++
+			continue
+		}
+		if _, ok := a.generatedSummary(file); ok {
+			continue
+		}
+		content, err := a.crawler.ReadFile(file)
+		if err != nil || heuristics.IsDocumentationFile(file.RelativePath) {
+			continue
+		}
+		if _, found := a.cache.GetFileSummary(a.fileCacheKey(file), content); found {
+			filesPhase.Cached++
+			continue
+		}
+		cachedFolders[folder] = false
+		if a.options.Quick {
+			continue
+		}
+		pending = append(pending, file)
+	}
+
+	// Folders need file summaries and the project needs folder summaries
+	filesRun := a.options.PhaseEnabled(PhaseFiles)
+	foldersRun := filesRun && a.options.PhaseEnabled(PhaseFolders)
+	projectRun := foldersRun && a.options.PhaseEnabled(PhaseProject)
+	allCached := true
+	for _, cached := range cachedFolders {
+		allCached = allCached && cached
+	}
+
+	if filesRun && a.deepLLM() {
+		for _, batch := range a.planFileBatches(pending) {
+			filesPhase.Calls++
+			if len(batch) > 1 {
+				filesPhase.InputTokens += filePromptTokens
+				for _, file := range batch {
+					filesPhase.Files++
+					filesPhase.Chunks++
+					filesPhase.InputTokens += batchFileTokens + int(file.Size)/3 + 10
+					filesPhase.OutputTokens += fileOutputTokens
+				}
+				continue
+			}
+
+			file := batch[0]
+			content, err := a.crawler.ReadFile(file)
+			if err != nil {
+				filesPhase.Calls--
+				continue
+			}
+			chunks, err := chunker.ChunkFile(content, a.config.FileProcessing.ChunkSizeTokens, file.Path, a.config.FileProcessing.ChunkStrategy)
+			if err != nil || len(chunks) == 0 {
+				filesPhase.Calls--
+				continue
+			}
+			// The lightweight prompt sends at most limits.file_prompt_chars characters of the first chunk
+			sent := min(len(chunks[0].Content), a.config.Limits.FilePromptChars)
+			filesPhase.Files++
+			filesPhase.Chunks += len(chunks)
+			filesPhase.InputTokens += filePromptTokens + sent/3 + 10
+			filesPhase.OutputTokens += fileOutputTokens
+		}
+		filesPhase.Duration = a.phaseDuration(PhaseFiles, []int{filesPhase.Calls}, a.config.RateLimiting.ConcurrentWorkers)
+		estimate.add(filesPhase)
+	}
+
+	// Folders phase: one call per folder of the summarization tree
+	folderFiles := make(map[string]map[string]*internalOpenai.FileSummary)
+	for _, file := range files {
+		folder := filepath.Dir(file.RelativePath)
+		if folder == "." {
+			folder = rootFolder
+		}
+		if folderFiles[folder] == nil {
+			folderFiles[folder] = make(map[string]*internalOpenai.FileSummary)
+		}
+		folderFiles[folder][file.RelativePath] = nil
+	}
+	tree := buildFolderTree(folderFiles)
+	if foldersRun && a.deepLLM() {
+		foldersPhase := PhaseEstimate{Phase: PhaseFolders}
+		var levelCalls []int
+		// Levels run deepest first, so a folder is cached only when its subfolders are
+		subtreeCached := make(map[string]bool)
+		for _, level := range tree.levels {
+			calls := 0
+			for _, folder := range level {
+				cached := cachedFolders[folder] || len(folderFiles[folder]) == 0
+				for _, child := range tree.children[folder] {
+					cached = cached && subtreeCached[child]
+				}
+				subtreeCached[folder] = cached
+				if a.isGeneratedFolder(folder) {
+					continue
+				}
+				if _, ok := a.reusableFolderSummary(folder); ok || cached {
+					foldersPhase.Cached++
+					continue
+				}
+				calls++
+				foldersPhase.Folders++
+				foldersPhase.InputTokens += folderPromptTokens + len(folderFiles[folder])*fileSummaryTokens + len(tree.children[folder])*folderSummaryTokens
+				foldersPhase.OutputTokens += folderOutputTokens
+			}
+			levelCalls = append(levelCalls, calls)
+			foldersPhase.Calls += calls
+		}
+		foldersPhase.Duration = a.phaseDuration(PhaseFolders, levelCalls, a.config.RateLimiting.ConcurrentWorkers)
+		estimate.add(foldersPhase)
+	}
+
+	// Project phase: the overview, then the detailed analysis, each over every summary
+	if projectRun {
+		projectPhase := PhaseEstimate{Phase: PhaseProject}
+		folderCount := 0
+		for _, level := range tree.levels {
+			folderCount += len(level)
+		}
+		summaries := len(files)*fileSummaryTokens + folderCount*folderSummaryTokens
+		if allCached {
+			projectPhase.Cached++
+		} else {
+			projectPhase.Calls++
+			projectPhase.InputTokens += projectPromptTokens + summaries
+			projectPhase.OutputTokens += projectOutputTokens
+		}
+		if a.deepLLM() && (!allCached || a.repositoryURL == "") {
+			important := 0
+			for _, content := range a.extractImportantFiles(files) {
+				important += len(content)/3 + 10
+			}
+			projectPhase.Calls++
+			projectPhase.InputTokens += detailsPromptTokens + summaries + len(files)*fileSummaryTokens + important
+			projectPhase.OutputTokens += detailsOutputTokens
+		}
+		if a.options.Quick {
+			projectPhase.Note = "quick scan: one project-level call"
+		}
+		projectPhase.Duration = a.phaseDuration(PhaseProject, []int{projectPhase.Calls}, 1)
+		estimate.add(projectPhase)
+	}
+
+	// Services and glossary prompts depend on what the analysis finds, so they are sized by their
+	// typical requests
+	if a.options.PhaseEnabled(PhaseServices) && a.deepLLM() {
+		estimate.add(PhaseEstimate{
+			Phase:        PhaseServices,
+			Calls:        estimatedServiceClasses + 2,
+			InputTokens:  estimatedServiceClasses*architectureCallTokens + sequenceFlowCallTokens + moduleNamingCallTokens,
+			OutputTokens: estimatedServiceClasses*architectureOutput + sequenceFlowOutput + moduleNamingOutput,
+			Duration:     a.phaseDuration(PhaseServices, []int{estimatedServiceClasses + 2}, 1),
+			Note:         "approximate: architecture, sequence flow and module naming calls depend on the services discovered",
+		})
+	}
+	if a.options.PhaseEnabled(PhaseGlossary) && a.deepLLM() {
+		estimate.add(PhaseEstimate{
+			Phase:        PhaseGlossary,
+			Calls:        1,
+			InputTokens:  glossaryCallTokens,
+			OutputTokens: glossaryOutputTokens,
+			Duration:     a.phaseDuration(PhaseGlossary, []int{1}, 1),
+			Note:         "approximate: depends on the terms found",
+		})
+	}
+	return estimate, nil
+}
+
+// modelPrice returns the price of the configured model, with the config's prices taking precedence
+func (a *Analyzer) modelPrice() (internalOpenai.Price, bool) {
+	price, ok := internalOpenai.PriceFor(a.config.OpenAI.Model)
+	if a.config.Estimate.InputUSDPerMillion > 0 {
+		price.Input = a.config.Estimate.InputUSDPerMillion
+		ok = true
+	}
+	if a.config.Estimate.OutputUSDPerMillion > 0 {
+		price.Output = a.config.Estimate.OutputUSDPerMillion
+		ok = true
+	}
+	return price, ok
+}
+
+// phaseDuration estimates how long a phase takes. Each step runs its calls through the workers
+// after the previous step finished, and no faster than the configured requests per minute allow.
+func (a *Analyzer) phaseDuration(phase string, steps []int, workers int) time.Duration {
+	if workers < 1 {
+		workers = 1
+	}
+	var total time.Duration
+	calls := 0
+	for _, n := range steps {
+		total += time.Duration(math.Ceil(float64(n)/float64(workers))) * callLatency[phase]
+		calls += n
+	}
+	if rpm := a.config.RateLimiting.RequestsPerMinute; rpm > 0 {
+		if limited := time.Duration(float64(calls) / float64(rpm) * float64(time.Minute)); limited > total {
+			total = limited
+		}
+	}
+	return total
+}
+
+// add prices a phase and adds it to the totals
+func (e *CostEstimate) add(phase PhaseEstimate) {
+	phase.CostUSD = e.Price.Cost(phase.InputTokens, phase.OutputTokens)
+	e.Phases = append(e.Phases, phase)
+	e.Calls += phase.Calls
+	e.InputTokens += phase.InputTokens
+	e.OutputTokens += phase.OutputTokens
+	e.CostUSD += phase.CostUSD
+	e.Duration += phase.Duration
+}
+
+// Display prints the estimate per phase
+func (e *CostEstimate) Display() {
+	fmt.Printf("\n💰 ESTIMATED LLM USAGE (%s, %d files crawled)\n", e.Model, e.Files)
+	if len(e.Phases) == 0 {
+		fmt.Println("   No LLM calls: offline mode or every selected phase is heuristic")
+		return
+	}
+	fmt.Printf("   %-15s %7s %7s %9s %12s %10s %10s\n", "Phase", "Files", "Calls", "Cached", "Input tok", "Output tok", "Cost")
+	for _, phase := range e.Phases {
+		items := ""
+		if phase.Files > 0 {
+			items = fmt.Sprintf("%d", phase.Files)
+		} else if phase.Folders > 0 {
+			items = fmt.Sprintf("%d dirs", phase.Folders)
+		}
+		fmt.Printf("   %-15s %7s %7d %9d %12d %10d %10s\n", phase.Phase, items, phase.Calls, phase.Cached, phase.InputTokens, phase.OutputTokens, e.formatCost(phase.CostUSD))
+		if phase.Chunks > phase.Files {
+			fmt.Printf("   %-15s %d chunks in total; only the first chunk of each file is sent\n", "", phase.Chunks)
+		}
+		if phase.Note != "" {
+			fmt.Printf("   %-15s %s\n", "", phase.Note)
+		}
+	}
+	fmt.Printf("   %-15s %7s %7d %9s %12d %10d %10s\n", "Total", "", e.Calls, "", e.InputTokens, e.OutputTokens, e.formatCost(e.CostUSD))
+	fmt.Printf("⏱️  Estimated duration: %s\n", e.Duration.Round(time.Second))
+	if !e.Priced {
+		fmt.Printf("⚠️  No price is known for %s; set estimate.input_usd_per_million and estimate.output_usd_per_million\n", e.Model)
+	}
+}
+
+func (e *CostEstimate) formatCost(usd float64) string {
+	if !e.Priced {
+		return "?"
+	}
+	return fmt.Sprintf("$%.4f", usd)
+}
@@ -0,0 +1,390 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	internalOpenai "repo-explanation/internal/openai"
+	"repo-explanation/internal/portable"
+)
+
+// Explanation is the on-demand summary of one file or folder of the project
+type Explanation struct {
+	Path         string                        `json:"path"` // Relative to the project root, slash-separated
+	Kind         string                        `json:"kind"` // file or folder
+	File         *internalOpenai.FileSummary   `json:"file,omitempty"`
+	Folder       *internalOpenai.FolderSummary `json:"folder,omitempty"`
+	Symbols      []string                      `json:"symbols"`       // Key types and functions
+	Dependencies []string                      `json:"dependencies"`  // What it imports, most used first
+	ReferencedBy []string                      `json:"referenced_by"` // Project files importing it
+	Files        int                           `json:"files"`         // Files summarized
+	Truncated    bool                          `json:"truncated,omitempty"`
+}
+
+// Bounds of an explanation, so explaining a large folder stays much cheaper than a full run
+const (
+	maxExplainFiles        = 150
+	maxExplainSymbols      = 20
+	maxExplainDependencies = 15
+	maxExplainReferences   = 50
+)
+
+var (
+	explainJSImportRegex = regexp.MustCompile(`(?:from\s*|import\s*\(?\s*|require\s*\(\s*)['"]((?:\.|[@~]/)[^'"]*)['"]`)
+	explainGoModRegex    = regexp.MustCompile(`(?m)^module\s+(\S+)`)
+)
+
+// Explain summarizes one file or folder without a full-project run: its files are summarized from the
+// last incremental run, the cache or the LLM (heuristics offline), folders are reduced bottom-up as in
+// a full run, and the project is scanned for the files that import it.
+func (a *Analyzer) Explain(ctx context.Context, target string) (*Explanation, error) {
+	rel, err := a.explainPath(target)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(filepath.Join(a.crawler.basePath, rel))
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := a.crawler.CrawlFiles()
+	if err != nil {
+		return nil, fmt.Errorf("file discovery failed: %v", err)
+	}
+	a.detectGenerated(files)
+
+	slashRel := portable.Slash(rel)
+	explanation := &Explanation{Path: slashRel, Symbols: []string{}, Dependencies: []string{}}
+	if !info.IsDir() {
+		summary, err := a.SummarizeFile(ctx, rel)
+		if err != nil {
+			return nil, fmt.Errorf("failed to summarize %s: %v", slashRel, err)
+		}
+		explanation.Kind = "file"
+		explanation.File = summary
+		explanation.Files = 1
+		explanation.Symbols = explainSymbols(map[string]*internalOpenai.FileSummary{slashRel: summary})
+		explanation.Dependencies = explainDependencies(map[string]*internalOpenai.FileSummary{slashRel: summary})
+	} else {
+		var inside []FileInfo
+		for _, file := range files {
+			if slashRel == "." || portable.Within(portable.Slash(file.RelativePath), slashRel) {
+				inside = append(inside, file)
+			}
+		}
+		if len(inside) == 0 {
+			return nil, fmt.Errorf("%s has no files to analyze", slashRel)
+		}
+		sort.Slice(inside, func(i, j int) bool { return inside[i].RelativePath < inside[j].RelativePath })
+		if len(inside) > maxExplainFiles {
+			inside = inside[:maxExplainFiles]
+			explanation.Truncated = true
+		}
+
+		summaries, err := a.mapPhase(ctx, inside)
+		if err != nil {
+			return nil, fmt.Errorf("failed to summarize %s: %v", slashRel, err)
+		}
+		folder, err := a.explainFolder(ctx, rel, summaries)
+		if err != nil {
+			return nil, fmt.Errorf("failed to summarize %s: %v", slashRel, err)
+		}
+		explanation.Kind = "folder"
+		explanation.Folder = folder
+		explanation.Files = len(summaries)
+		explanation.Symbols = explainSymbols(summaries)
+		explanation.Dependencies = explainDependencies(summaries)
+	}
+
+	explanation.ReferencedBy = a.referencesTo(slashRel, info.IsDir(), files)
+	return explanation, nil
+}
+
+// explainPath turns a path given relative to the project, or absolute inside it, into a clean relative path
+func (a *Analyzer) explainPath(target string) (string, error) {
+	target = strings.TrimSpace(target)
+	if target == "" {
+		return "", fmt.Errorf("no path to explain")
+	}
+	if filepath.IsAbs(target) {
+		rel, err := filepath.Rel(a.crawler.basePath, target)
+		if err != nil {
+			return "", fmt.Errorf("%s is not inside %s", target, a.crawler.basePath)
+		}
+		target = rel
+	}
+	rel := filepath.Clean(target)
+	if rel == ".." || strings.HasPrefix(portable.Slash(rel), "../") {
+		return "", fmt.Errorf("%s is not inside %s", target, a.crawler.basePath)
+	}
+	return rel, nil
+}
+
+// explainFolder reduces the summarized files to the summary of their common folder. Folders the
+// tree leaves out (a single subfolder and no files of its own) are summarized from their subfolders.
+func (a *Analyzer) explainFolder(ctx context.Context, rel string, summaries map[string]*internalOpenai.FileSummary) (*internalOpenai.FolderSummary, error) {
+	folders, err := a.reducePhaseFolder(ctx, summaries)
+	if err != nil {
+		return nil, err
+	}
+	key := rel
+	if key == "." {
+		key = rootFolder
+	}
+	if folder := folders[key]; folder != nil {
+		return folder, nil
+	}
+
+	direct := make(map[string]*internalOpenai.FileSummary)
+	for filePath, summary := range summaries {
+		if filepath.Dir(filePath) == rel {
+			direct[filePath] = summary
+		}
+	}
+	children := make(map[string]internalOpenai.FolderSummary)
+	for folderPath, summary := range folders {
+		parent := parentFolder(folderPath)
+		for parent != "" && parent != key && folders[parent] == nil {
+			parent = parentFolder(parent)
+		}
+		if parent == key {
+			children[folderPath] = *summary
+		}
+	}
+	return a.analyzeFolder(ctx, key, direct, children)
+}
+
+// explainSymbols lists the key types, then functions, of the summarized files
+func explainSymbols(summaries map[string]*internalOpenai.FileSummary) []string {
+	seen := make(map[string]bool)
+	symbols := []string{}
+	for _, pass := range []func(*internalOpenai.FileSummary) []string{
+		func(s *internalOpenai.FileSummary) []string { return s.KeyTypes },
+		func(s *internalOpenai.FileSummary) []string { return s.Functions },
+	} {
+		for _, filePath := range sortedSummaryPaths(summaries) {
+			for _, symbol := range pass(summaries[filePath]) {
+				if symbol != "" && !seen[symbol] && len(symbols) < maxExplainSymbols {
+					seen[symbol] = true
+					symbols = append(symbols, symbol)
+				}
+			}
+		}
+	}
+	return symbols
+}
+
+// explainDependencies ranks the imports of the summarized files by how many of them import each
+func explainDependencies(summaries map[string]*internalOpenai.FileSummary) []string {
+	counts := make(map[string]int)
+	for _, summary := range summaries {
+		seen := make(map[string]bool)
+		for _, imp := range summary.Imports {
+			if imp != "" && !seen[imp] {
+				seen[imp] = true
+				counts[imp]++
+			}
+		}
+	}
+	dependencies := make([]string, 0, len(counts))
+	for imp := range counts {
+		dependencies = append(dependencies, imp)
+	}
+	sort.Slice(dependencies, func(i, j int) bool {
+		if counts[dependencies[i]] != counts[dependencies[j]] {
+			return counts[dependencies[i]] > counts[dependencies[j]]
+		}
+		return dependencies[i] < dependencies[j]
+	})
+	if len(dependencies) > maxExplainDependencies {
+		dependencies = dependencies[:maxExplainDependencies]
+	}
+	return dependencies
+}
+
+func sortedSummaryPaths(summaries map[string]*internalOpenai.FileSummary) []string {
+	paths := make([]string, 0, len(summaries))
+	for filePath := range summaries {
+		paths = append(paths, filePath)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// referencesTo lists the project files outside target that import it: Go files importing its
+// package, JS/TS files importing it through a relative path and Python files importing its module
+func (a *Analyzer) referencesTo(target string, isDir bool, files []FileInfo) []string {
+	goPackage := a.goImportPath(target, isDir)
+	pythonModule := ""
+	if !isDir && strings.HasSuffix(target, ".py") {
+		pythonModule = strings.TrimSuffix(strings.TrimSuffix(target, ".py"), "/__init__")
+	} else if isDir && target != "." {
+		pythonModule = target
+	}
+	var pythonImport *regexp.Regexp
+	if pythonModule != "" {
+		dotted := strings.ReplaceAll(pythonModule, "/", ".")
+		// Python projects often keep their packages under src/
+		pattern := regexp.QuoteMeta(dotted)
+		if trimmed := strings.TrimPrefix(dotted, "src."); trimmed != dotted {
+			pattern = "(?:src\\.)?" + regexp.QuoteMeta(trimmed)
+		}
+		pythonImport = regexp.MustCompile(`(?m)^\s*(?:from\s+` + pattern + `(?:\.\w+)*\s+import\b|import\s+` + pattern + `\b)`)
+	}
+	targetStem := strings.TrimSuffix(target, path.Ext(target))
+
+	var references []string
+	for _, file := range files {
+		rel := portable.Slash(file.RelativePath)
+		if rel == target || isDir && (target == "." || portable.Within(rel, target)) {
+			continue
+		}
+		ext := strings.ToLower(path.Ext(rel))
+		if ext != ".go" && ext != ".py" && !isExplainJSFile(ext) {
+			continue
+		}
+		content, err := os.ReadFile(file.Path)
+		if err != nil {
+			continue
+		}
+		text := string(content)
+
+		matched := false
+		switch {
+		case ext == ".go" && goPackage != "":
+			matched = strings.Contains(text, `"`+goPackage+`"`) || isDir && strings.Contains(text, `"`+goPackage+`/`)
+		case ext == ".py" && pythonImport != nil:
+			matched = pythonImport.MatchString(text)
+		case isExplainJSFile(ext):
+			for _, match := range explainJSImportRegex.FindAllStringSubmatch(text, -1) {
+				resolved := path.Join(path.Dir(rel), match[1])
+				if !strings.HasPrefix(match[1], ".") {
+					// "@/lib/utils" is the bundler alias of the importing app's src folder
+					root, ok := srcRoot(rel)
+					if !ok {
+						continue
+					}
+					resolved = path.Join(root, match[1][2:])
+				}
+				if isDir && portable.Within(resolved, target) ||
+					!isDir && (resolved == target || resolved == targetStem || strings.TrimSuffix(resolved, path.Ext(resolved)) == targetStem ||
+						path.Base(targetStem) == "index" && resolved == path.Dir(target)) {
+					matched = true
+					break
+				}
+			}
+		}
+		if matched {
+			references = append(references, rel)
+		}
+	}
+	sort.Strings(references)
+	if len(references) > maxExplainReferences {
+		references = references[:maxExplainReferences]
+	}
+	if references == nil {
+		references = []string{}
+	}
+	return references
+}
+
+// goImportPath returns the Go import path of a Go file's package or of a folder, from the nearest
+// go.mod above it, or "" outside a Go module
+func (a *Analyzer) goImportPath(target string, isDir bool) string {
+	dir := target
+	if !isDir {
+		if !strings.HasSuffix(target, ".go") {
+			return ""
+		}
+		dir = path.Dir(target)
+	}
+	for current := dir; ; current = path.Dir(current) {
+		content, err := os.ReadFile(filepath.Join(a.crawler.basePath, filepath.FromSlash(current), "go.mod"))
+		if err == nil {
+			match := explainGoModRegex.FindStringSubmatch(string(content))
+			if match == nil {
+				return ""
+			}
+			if current == dir {
+				return match[1]
+			}
+			return match[1] + "/" + strings.TrimPrefix(dir, strings.TrimSuffix(current, ".")+"/")
+		}
+		if current == "." || current == "/" {
+			return ""
+		}
+	}
+}
+
+// srcRoot returns the src folder a file sits in, the usual target of the "@/" and "~/" aliases
+func srcRoot(rel string) (string, bool) {
+	segments := strings.Split(rel, "/")
+	for i := len(segments) - 2; i >= 0; i-- {
+		if segments[i] == "src" {
+			return strings.Join(segments[:i+1], "/"), true
+		}
+	}
+	return "", false
+}
+
+func isExplainJSFile(ext string) bool {
+	switch ext {
+	case ".js", ".jsx", ".ts", ".tsx", ".mjs", ".cjs", ".vue", ".svelte":
+		return true
+	}
+	return false
+}
+
+// Display prints an explanation for the terminal
+func (e *Explanation) Display() {
+	fmt.Println("\n" + strings.Repeat("=", 60))
+	fmt.Printf("🔎 %s (%s)\n", e.Path, e.Kind)
+	fmt.Println(strings.Repeat("=", 60))
+
+	switch {
+	case e.File != nil:
+		fmt.Printf("📝 %s\n", e.File.Purpose)
+		if e.File.Language != "" {
+			fmt.Printf("💻 Language: %s\n", e.File.Language)
+		}
+		if len(e.File.SideEffects) > 0 {
+			fmt.Printf("⚡ Side effects: %s\n", strings.Join(e.File.SideEffects, "; "))
+		}
+		if len(e.File.Risks) > 0 {
+			fmt.Printf("⚠️  Risks: %s\n", strings.Join(e.File.Risks, "; "))
+		}
+	case e.Folder != nil:
+		fmt.Printf("📝 %s\n", e.Folder.Purpose)
+		if e.Folder.Architecture != "" {
+			fmt.Printf("🏗️  Architecture: %s\n", e.Folder.Architecture)
+		}
+		if len(e.Folder.KeyModules) > 0 {
+			fmt.Printf("📦 Key modules: %s\n", strings.Join(e.Folder.KeyModules, ", "))
+		}
+		fmt.Printf("📄 Files summarized: %d", e.Files)
+		if e.Truncated {
+			fmt.Printf(" (the first %d only)", maxExplainFiles)
+		}
+		fmt.Println()
+	}
+	if len(e.Symbols) > 0 {
+		fmt.Printf("🔑 Key symbols: %s\n", strings.Join(e.Symbols, ", "))
+	}
+	if len(e.Dependencies) > 0 {
+		fmt.Printf("🔗 Depends on: %s\n", strings.Join(e.Dependencies, ", "))
+	}
+	if len(e.ReferencedBy) == 0 {
+		fmt.Println("👥 Referenced by: no project file imports it")
+		return
+	}
+	fmt.Printf("👥 Referenced by %d files:\n", len(e.ReferencedBy))
+	for _, rel := range e.ReferencedBy {
+		fmt.Printf("   • %s\n", rel)
+	}
+}
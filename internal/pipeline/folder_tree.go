@@ -0,0 +1,100 @@
+package pipeline
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	internalOpenai "repo-explanation/internal/openai"
+)
+
+// rootFolder is the folder key used for files at the top of the repository
+const rootFolder = "root"
+
+// folderTree orders folders so every folder is summarized after its subfolders
+type folderTree struct {
+	children map[string][]string // Folder -> nearest summarized subfolders, sorted
+	levels   [][]string          // Folders grouped by depth, deepest level first
+}
+
+// buildFolderTree derives the summarization hierarchy from the folders that contain files.
+// Ancestor directories are added when they branch into two or more subfolders; single-child
+// pass-through directories (e.g. src/main/java) are collapsed into their nearest summarized ancestor.
+func buildFolderTree(folderFiles map[string]map[string]*internalOpenai.FileSummary) *folderTree {
+	// Collect every folder with files plus all of its ancestors
+	candidates := make(map[string]bool)
+	for folder := range folderFiles {
+		for f := folder; f != ""; f = parentFolder(f) {
+			candidates[f] = true
+		}
+	}
+
+	childCount := make(map[string]int)
+	for f := range candidates {
+		if parent := parentFolder(f); parent != "" {
+			childCount[parent]++
+		}
+	}
+
+	included := make(map[string]bool)
+	for f := range candidates {
+		hasFiles := len(folderFiles[f]) > 0
+		// A file-less root would only duplicate the project summary
+		if f == rootFolder && !hasFiles {
+			continue
+		}
+		if hasFiles || childCount[f] >= 2 {
+			included[f] = true
+		}
+	}
+
+	tree := &folderTree{children: make(map[string][]string)}
+	byDepth := make(map[int][]string)
+	maxDepth := 0
+	for f := range included {
+		parent := parentFolder(f)
+		for parent != "" && !included[parent] {
+			parent = parentFolder(parent)
+		}
+		if parent != "" {
+			tree.children[parent] = append(tree.children[parent], f)
+		}
+
+		depth := folderDepth(f)
+		byDepth[depth] = append(byDepth[depth], f)
+		if depth > maxDepth {
+			maxDepth = depth
+		}
+	}
+
+	for _, kids := range tree.children {
+		sort.Strings(kids)
+	}
+	for depth := maxDepth; depth >= 0; depth-- {
+		if level := byDepth[depth]; len(level) > 0 {
+			sort.Strings(level)
+			tree.levels = append(tree.levels, level)
+		}
+	}
+	return tree
+}
+
+// parentFolder returns the folder key of a folder's parent, or "" for the root
+func parentFolder(folder string) string {
+	if folder == rootFolder {
+		return ""
+	}
+	dir := filepath.Dir(folder)
+	if dir == "." || dir == string(filepath.Separator) {
+		return rootFolder
+	}
+	return dir
+}
+
+// folderDepth returns how deep a folder sits below the repository root
+func folderDepth(folder string) int {
+	if folder == rootFolder {
+		return 0
+	}
+	return strings.Count(filepath.ToSlash(folder), "/") + 1
+}
@@ -0,0 +1,49 @@
+package pipeline
+
+import (
+	"fmt"
+
+	"repo-explanation/internal/generated"
+	"repo-explanation/internal/heuristics"
+	internalOpenai "repo-explanation/internal/openai"
+	"repo-explanation/internal/portable"
+)
+
+// detectGenerated finds generated and vendored files, which the map and folder phases then describe
+// without calling the LLM, and the pipelines generating them
+func (a *Analyzer) detectGenerated(files []FileInfo) *generated.Report {
+	report := generated.Detect(a.crawler.basePath, a.crawler.Store(files))
+	a.generatedFiles = make(map[string]generated.Artifact, len(report.Artifacts))
+	for _, artifact := range report.Artifacts {
+		a.generatedFiles[artifact.Path] = artifact
+	}
+	a.generatedFolders = report.Folders
+	if len(report.Artifacts) == 0 && len(report.Pipelines) == 0 {
+		return nil
+	}
+	fmt.Printf("🏭 %s\n", report.Summary)
+	return report
+}
+
+// generatedSummary stands in for the LLM summary of a generated or vendored file
+func (a *Analyzer) generatedSummary(file FileInfo) (*internalOpenai.FileSummary, bool) {
+	artifact, ok := a.generatedFiles[portable.Slash(file.RelativePath)]
+	if !ok {
+		return nil, false
+	}
+	return &internalOpenai.FileSummary{
+		Language:   heuristics.LanguageForPath(file.RelativePath),
+		Purpose:    artifact.Describe(),
+		Complexity: "low",
+	}, true
+}
+
+// isGeneratedFolder reports whether every file in a folder and its subfolders is generated or vendored
+func (a *Analyzer) isGeneratedFolder(folderPath string) bool {
+	for _, folder := range a.generatedFolders {
+		if portable.Within(folderPath, folder) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"repo-explanation/internal/database"
+	"repo-explanation/internal/filestore"
+	"repo-explanation/internal/glossary"
+	"repo-explanation/internal/heuristics"
+	"repo-explanation/internal/microservices"
+	internalOpenai "repo-explanation/internal/openai"
+)
+
+// buildGlossary collects domain terms from the schema, services and file summaries. Outside
+// offline mode and quick scans the LLM defines each term with citations; otherwise definitions
+// come from the terms' own evidence.
+func (a *Analyzer) buildGlossary(ctx context.Context, files []FileInfo, sources *filestore.FileStore, projectSummary *internalOpenai.ProjectSummary, services []microservices.DiscoveredService, databaseSchema *database.DatabaseSchema, fileSummaries map[string]*internalOpenai.FileSummary) *glossary.Glossary {
+	input := glossary.Input{
+		Tables:    a.glossaryTables(files, databaseSchema),
+		Summaries: make(map[string]string, len(fileSummaries)),
+	}
+	for _, service := range a.scopedServices(sources, services) {
+		source := service.EntryPoint
+		if source == "" {
+			source = service.Path
+		}
+		input.Services = append(input.Services, glossary.ServiceInfo{Name: service.Name, Description: service.Description, Source: source})
+	}
+	for path, summary := range fileSummaries {
+		if summary == nil {
+			continue
+		}
+		if _, generated := a.generatedFiles[path]; generated || a.isRestricted(path) {
+			continue
+		}
+		// Type names carry the domain vocabulary even when the summary is heuristic
+		text := strings.TrimSpace(strings.TrimPrefix(summary.Purpose, heuristics.OfflineMarker))
+		if len(summary.KeyTypes) > 0 {
+			text += ". Types: " + strings.Join(summary.KeyTypes, ", ")
+		}
+		input.Summaries[path] = text
+	}
+	if projectSummary != nil {
+		input.Models = projectSummary.DataModels
+	}
+
+	candidates := glossary.Candidates(input, a.config.Limits.GlossaryConcepts)
+	if len(candidates) == 0 {
+		return nil
+	}
+	if !a.deepLLM() || ctx.Err() != nil {
+		result := glossary.Heuristic(candidates)
+		fmt.Printf("📖 Glossary: %s\n", result.Summary)
+		return result
+	}
+
+	contexts := make([]internalOpenai.GlossaryTermContext, len(candidates))
+	for i, candidate := range candidates {
+		contexts[i] = internalOpenai.GlossaryTermContext{Term: candidate.Term, Kind: string(candidate.Kind), Sources: candidate.Sources, Context: candidate.Context}
+		if a.privacy != nil {
+			contexts[i].Context = make([]string, len(candidate.Context))
+			for j, text := range candidate.Context {
+				contexts[i].Context[j] = a.privacy.ScrubIdentifiers(text)
+			}
+		}
+	}
+	purpose := ""
+	if projectSummary != nil {
+		purpose = projectSummary.Purpose
+	}
+	llmDefinitions, err := a.openaiClient.DefineGlossaryTerms(ctx, purpose, contexts)
+	if err != nil {
+		fmt.Printf("⚠️  LLM glossary definitions failed, using heuristics: %v\n", err)
+		result := glossary.Heuristic(candidates)
+		fmt.Printf("📖 Glossary: %s\n", result.Summary)
+		return result
+	}
+
+	definitions := make([]glossary.Definition, len(llmDefinitions))
+	for i, definition := range llmDefinitions {
+		definitions[i] = glossary.Definition{Term: definition.Term, Definition: definition.Definition, Sources: definition.Sources, Generic: definition.Generic}
+	}
+	result := glossary.Apply(candidates, definitions)
+	fmt.Printf("📖 Glossary: %s\n", result.Summary)
+	return result
+}
+
+// glossaryTables lists the schema's tables with their columns and the migrations that create them
+func (a *Analyzer) glossaryTables(files []FileInfo, databaseSchema *database.DatabaseSchema) map[string]glossary.TableInfo {
+	if databaseSchema == nil || len(databaseSchema.Tables) == 0 {
+		return nil
+	}
+
+	createdIn := make(map[string][]string)
+	a.crawler.Store(files).Walk(func(path string) bool {
+		return strings.HasSuffix(strings.ToLower(path), ".sql")
+	}, func(path, content string) error {
+		for _, match := range createTableRegex.FindAllStringSubmatch(content, -1) {
+			table := strings.ToLower(match[1])
+			createdIn[table] = append(createdIn[table], path)
+		}
+		return nil
+	})
+
+	tables := make(map[string]glossary.TableInfo, len(databaseSchema.Tables))
+	for name, table := range databaseSchema.Tables {
+		columns := make([]string, 0, len(table.Columns))
+		for column := range table.Columns {
+			columns = append(columns, column)
+		}
+		sort.Strings(columns)
+		// Schema-qualified tables (billing.invoices) are created under their bare name
+		bare := strings.ToLower(name[strings.LastIndex(name, ".")+1:])
+		if a.onlyRestricted(createdIn[bare]) {
+			continue
+		}
+		tables[name] = glossary.TableInfo{Columns: columns, Sources: createdIn[bare]}
+	}
+	return tables
+}
@@ -0,0 +1,133 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	internalOpenai "repo-explanation/internal/openai"
+)
+
+// incrementalState keeps the previous run's summaries so a re-analysis only redoes what changed
+type incrementalState struct {
+	mu              sync.Mutex
+	fileSummaries   map[string]*internalOpenai.FileSummary
+	folderSummaries map[string]*internalOpenai.FolderSummary
+	changed         map[string]bool // project-relative, slash-separated
+}
+
+// EnableIncremental makes later runs reuse file and folder summaries untouched by MarkChanged.
+// The first run after enabling analyzes everything.
+func (a *Analyzer) EnableIncremental() {
+	a.incremental = &incrementalState{changed: make(map[string]bool)}
+}
+
+// MarkChanged records project-relative paths that were created, modified or deleted since the last run
+func (a *Analyzer) MarkChanged(paths []string) {
+	if a.incremental == nil {
+		return
+	}
+	a.incremental.mu.Lock()
+	defer a.incremental.mu.Unlock()
+	for _, path := range paths {
+		a.incremental.changed[filepath.ToSlash(filepath.Clean(path))] = true
+	}
+}
+
+// reusableFileSummary returns the previous summary of an unchanged file
+func (a *Analyzer) reusableFileSummary(relativePath string) (*internalOpenai.FileSummary, bool) {
+	state := a.incremental
+	if state == nil {
+		return nil, false
+	}
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	path := filepath.ToSlash(filepath.Clean(relativePath))
+	summary, ok := state.fileSummaries[path]
+	if !ok || state.changed[path] {
+		return nil, false
+	}
+	return summary, true
+}
+
+// reusableFolderSummary returns the previous summary of a folder with no changes anywhere in its subtree.
+// Subfolder summaries feed into their parents, so a change invalidates every ancestor up to the root.
+func (a *Analyzer) reusableFolderSummary(folderPath string) (*internalOpenai.FolderSummary, bool) {
+	state := a.incremental
+	if state == nil {
+		return nil, false
+	}
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	summary, ok := state.folderSummaries[folderPath]
+	if !ok {
+		return nil, false
+	}
+	if folderPath == rootFolder {
+		return summary, len(state.changed) == 0
+	}
+	prefix := filepath.ToSlash(folderPath) + "/"
+	for path := range state.changed {
+		if strings.HasPrefix(path, prefix) {
+			return nil, false
+		}
+	}
+	return summary, true
+}
+
+// rememberSummaries stores this run's summaries for the next incremental run and clears the change set
+func (a *Analyzer) rememberSummaries(fileSummaries map[string]*internalOpenai.FileSummary, folderSummaries map[string]*internalOpenai.FolderSummary) {
+	state := a.incremental
+	if state == nil {
+		return
+	}
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	state.fileSummaries = make(map[string]*internalOpenai.FileSummary, len(fileSummaries))
+	for path, summary := range fileSummaries {
+		state.fileSummaries[filepath.ToSlash(path)] = summary
+	}
+	state.folderSummaries = folderSummaries
+	state.changed = make(map[string]bool)
+}
+
+// FileSummaries returns the file summaries kept from the last incremental run
+func (a *Analyzer) FileSummaries() map[string]*internalOpenai.FileSummary {
+	state := a.incremental
+	if state == nil {
+		return nil
+	}
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	summaries := make(map[string]*internalOpenai.FileSummary, len(state.fileSummaries))
+	for path, summary := range state.fileSummaries {
+		summaries[path] = summary
+	}
+	return summaries
+}
+
+// SummarizeFile summarizes one project file on demand, reusing the last run or the cache when the file is unchanged
+func (a *Analyzer) SummarizeFile(ctx context.Context, relativePath string) (*internalOpenai.FileSummary, error) {
+	path := filepath.Join(a.crawler.basePath, relativePath)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", relativePath)
+	}
+
+	return a.analyzeFile(ctx, FileInfo{
+		Path:         path,
+		RelativePath: relativePath,
+		Size:         info.Size(),
+		Extension:    strings.ToLower(filepath.Ext(path)),
+	})
+}
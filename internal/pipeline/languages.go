@@ -0,0 +1,101 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"repo-explanation/internal/languages"
+	"repo-explanation/internal/microservices"
+)
+
+// ServiceLanguages is the language breakdown of the files under one discovered service
+type ServiceLanguages struct {
+	Service string `json:"service"`
+	Path    string `json:"path"`
+	*languages.Breakdown
+}
+
+// detectLanguages counts lines per language for every recognized file and records the breakdown in stats.
+// Unlike the LLM-reported language maps this is deterministic and works offline.
+func (a *Analyzer) detectLanguages(files []FileInfo, stats map[string]interface{}) []languages.FileStat {
+	fileStats := make([]languages.FileStat, 0, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(file.Path)
+		if err != nil {
+			continue
+		}
+		if stat, ok := languages.Analyze(filepath.ToSlash(file.RelativePath), string(data)); ok {
+			fileStats = append(fileStats, stat)
+		}
+	}
+
+	breakdown := languages.Summarize(fileStats)
+	stats["languages"] = breakdown.Languages
+	stats["loc"] = breakdown.Total
+	stats["primary_language"] = breakdown.Primary
+	if len(breakdown.Languages) > 0 {
+		top := breakdown.Languages[0]
+		fmt.Printf("🔤 %d languages, %d lines of code (%s %.1f%%)\n", len(breakdown.Languages), breakdown.Total.Code, top.Name, top.Percentage)
+	}
+	return fileStats
+}
+
+// recordServiceLanguages adds a per-service language breakdown to stats, attributing each file to the
+// most specific service directory that contains it
+func recordServiceLanguages(fileStats []languages.FileStat, services []microservices.DiscoveredService, stats map[string]interface{}) {
+	if len(services) == 0 || len(fileStats) == 0 {
+		return
+	}
+
+	dirs := newServiceDirs(services)
+	byService := make(map[string][]languages.FileStat)
+	for _, file := range fileStats {
+		if name := dirs.owner(file.Path); name != "" {
+			byService[name] = append(byService[name], file)
+		}
+	}
+
+	var breakdowns []ServiceLanguages
+	for _, service := range services {
+		files, ok := byService[service.Name]
+		if !ok {
+			continue
+		}
+		delete(byService, service.Name)
+		breakdowns = append(breakdowns, ServiceLanguages{Service: service.Name, Path: service.Path, Breakdown: languages.Summarize(files)})
+	}
+	stats["languages_by_service"] = breakdowns
+}
+
+// serviceDirs maps paths to the most specific discovered service directory containing them
+type serviceDirs []struct {
+	name, dir string
+}
+
+func newServiceDirs(services []microservices.DiscoveredService) serviceDirs {
+	dirs := make(serviceDirs, 0, len(services))
+	for _, service := range services {
+		dir := filepath.ToSlash(filepath.Clean(service.Path))
+		if filepath.Ext(dir) != "" {
+			// Some discovery strategies report the entry point file rather than its directory
+			dir = filepath.ToSlash(filepath.Dir(dir))
+		}
+		dirs = append(dirs, struct{ name, dir string }{service.Name, dir})
+	}
+	// Longest directory first so nested services win over their parents
+	sort.SliceStable(dirs, func(i, j int) bool { return len(dirs[i].dir) > len(dirs[j].dir) })
+	return dirs
+}
+
+// owner returns the service owning a slash-separated relative path, or "" when none does
+func (dirs serviceDirs) owner(path string) string {
+	for _, service := range dirs {
+		if service.dir == "." || strings.HasPrefix(path, service.dir+"/") {
+			return service.name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,102 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"repo-explanation/internal/detector"
+	"repo-explanation/internal/filestore"
+	"repo-explanation/internal/heuristics"
+	"repo-explanation/internal/microservices"
+	"repo-explanation/internal/modules"
+	internalOpenai "repo-explanation/internal/openai"
+)
+
+// Bounds of the evidence sent to the LLM per module
+const (
+	maxModulePackages = 12
+	maxModuleContext  = 6
+)
+
+// detectModuleBoundaries clusters the packages of a single-service backend into feature modules by
+// import affinity. Outside offline mode and quick scans the LLM names the clusters from their file
+// summaries; otherwise they keep the names of their directories. It is nil for frontends,
+// multi-service repositories and code with too few packages to cluster.
+func (a *Analyzer) detectModuleBoundaries(ctx context.Context, sources *filestore.FileStore, projectType *detector.DetectionResult, services []microservices.DiscoveredService, projectSummary *internalOpenai.ProjectSummary, fileSummaries map[string]*internalOpenai.FileSummary) *modules.Report {
+	if projectType != nil && projectType.PrimaryType == detector.Frontend {
+		return nil
+	}
+	if len(a.scopedServices(sources, services)) > 1 {
+		return nil
+	}
+	report, err := modules.Analyze(sources)
+	if err != nil {
+		fmt.Printf("⚠️  Module boundary detection failed: %v\n", err)
+		return nil
+	}
+	if report == nil {
+		return nil
+	}
+	if !a.deepLLM() || ctx.Err() != nil {
+		fmt.Printf("🧩 Modules: %s\n", report.Summary)
+		return report
+	}
+
+	// File summaries are keyed by repository path, module packages by path under the scoped root
+	prefix := ""
+	if root := a.scopeRoot(); root != "" {
+		prefix = root + "/"
+	}
+	byPackage := make(map[string][]string)
+	for filePath, summary := range fileSummaries {
+		if summary == nil || a.isRestricted(filePath) || !strings.HasPrefix(filePath, prefix) {
+			continue
+		}
+		if _, generated := a.generatedFiles[filePath]; generated {
+			continue
+		}
+		text := strings.TrimSpace(strings.TrimPrefix(summary.Purpose, heuristics.OfflineMarker))
+		if len(summary.KeyTypes) > 0 {
+			text += " Types: " + strings.Join(summary.KeyTypes, ", ")
+		}
+		if a.privacy != nil {
+			text = a.privacy.ScrubIdentifiers(text)
+		}
+		pkg := path.Dir(strings.TrimPrefix(filePath, prefix))
+		byPackage[pkg] = append(byPackage[pkg], path.Base(filePath)+": "+text)
+	}
+
+	outlines := make([]internalOpenai.ModuleOutline, len(report.Modules))
+	for i, module := range report.Modules {
+		outline := internalOpenai.ModuleOutline{Packages: module.Packages}
+		if len(outline.Packages) > maxModulePackages {
+			outline.Packages = outline.Packages[:maxModulePackages]
+		}
+		for _, pkg := range module.Packages {
+			context := byPackage[pkg]
+			sort.Strings(context)
+			outline.Context = append(outline.Context, context...)
+			if len(outline.Context) >= maxModuleContext {
+				outline.Context = outline.Context[:maxModuleContext]
+				break
+			}
+		}
+		outlines[i] = outline
+	}
+	purpose := ""
+	if projectSummary != nil {
+		purpose = projectSummary.Purpose
+	}
+	namings, err := a.openaiClient.NameModules(ctx, purpose, outlines)
+	if err != nil {
+		fmt.Printf("⚠️  LLM module naming failed, keeping directory names: %v\n", err)
+	}
+	for _, naming := range namings {
+		report.Rename(naming.Module, naming.Name, naming.Description)
+	}
+	fmt.Printf("🧩 Modules: %s\n", report.Summary)
+	return report
+}
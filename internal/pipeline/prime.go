@@ -0,0 +1,104 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"repo-explanation/internal/heuristics"
+)
+
+// PrimeReport is the outcome of priming the file summary cache for one repository
+type PrimeReport struct {
+	Files       int               `json:"files"`   // Files crawled
+	Cached      int               `json:"cached"`  // Already in the cache before the run
+	Primed      int               `json:"primed"`  // Summarized and written to the cache by this run
+	Skipped     int               `json:"skipped"` // Generated, vendored and documentation files, which are never sent to the LLM
+	Failed      int               `json:"failed"`
+	Concurrency *ConcurrencyStats `json:"concurrency,omitempty"`
+	Duration    time.Duration     `json:"duration_ns"`
+}
+
+// PrimeCache runs only the map phase, starting at max_concurrent_workers and backing off on rate
+// limits, so that a later analysis of the same repository finds every file summary in the cache.
+// Files the cache already answers are not sent again.
+func (a *Analyzer) PrimeCache(ctx context.Context) (*PrimeReport, error) {
+	if a.config.Offline {
+		return nil, fmt.Errorf("priming needs the LLM: offline analyses neither read nor write file summaries in the cache")
+	}
+	if !a.config.Cache.Enabled {
+		return nil, fmt.Errorf("priming needs the cache: set cache.enabled to true")
+	}
+
+	start := time.Now()
+	files, err := a.crawler.CrawlFiles()
+	if err != nil {
+		return nil, fmt.Errorf("file discovery failed: %v", err)
+	}
+	if err := a.resolveServiceScope(files); err != nil {
+		return nil, err
+	}
+	if a.options.IsScoped() {
+		files = a.options.filterFiles(files)
+	}
+	a.detectGenerated(files)
+
+	report := &PrimeReport{Files: len(files)}
+	var pending []FileInfo
+	for _, file := range files {
+		if _, ok := a.generatedSummary(file); ok {
+			report.Skipped++
+			continue
+		}
+		content, err := a.crawler.ReadFile(file)
+		if err != nil || heuristics.IsDocumentationFile(file.RelativePath) {
+			report.Skipped++
+			continue
+		}
+		if _, found := a.cache.GetFileSummary(a.fileCacheKey(file), content); found {
+			report.Cached++
+			continue
+		}
+		pending = append(pending, file)
+	}
+	fmt.Printf("🔥 Priming cache: %d files, %d already cached, %d to summarize\n", len(files), report.Cached, len(pending))
+
+	if len(pending) > 0 {
+		a.priming = true
+		summaries, err := a.mapPhase(ctx, pending)
+		a.priming = false
+		report.Primed = len(summaries)
+		report.Failed = len(pending) - len(summaries)
+		report.Concurrency = a.concurrency
+		if err != nil {
+			report.Duration = time.Since(start)
+			return report, fmt.Errorf("map phase failed: %v", err)
+		}
+	}
+	report.Duration = time.Since(start)
+	return report, nil
+}
+
+// fileCacheKey names a file in the cache. Clones of a repository land in a new temporary folder on
+// every request, so their files are keyed by repository URL and relative path instead of their
+// absolute path; the content hash still decides whether an entry is current.
+func (a *Analyzer) fileCacheKey(file FileInfo) string {
+	if a.repositoryURL == "" {
+		return file.Path
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(a.repositoryURL, "/"), ".git") + "/" + file.RelativePath
+}
+
+// Display prints the outcome of a priming run
+func (r *PrimeReport) Display() {
+	fmt.Printf("✅ Cache primed in %s: %d files summarized, %d already cached, %d skipped", r.Duration.Round(time.Second), r.Primed, r.Cached, r.Skipped)
+	if r.Failed > 0 {
+		fmt.Printf(", %d failed", r.Failed)
+	}
+	fmt.Println()
+	if r.Concurrency != nil {
+		fmt.Printf("   Workers: started at %d, peaked at %d, ended at %d (%d rate limited)\n",
+			r.Concurrency.Initial, r.Concurrency.Peak, r.Concurrency.Final, r.Concurrency.RateLimited)
+	}
+}
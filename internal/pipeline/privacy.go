@@ -0,0 +1,115 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"repo-explanation/internal/database"
+	"repo-explanation/internal/heuristics"
+	internalOpenai "repo-explanation/internal/openai"
+)
+
+// restrictedPurpose is the summary of a file under privacy.restricted_paths
+const restrictedPurpose = "Restricted file: its contents are not sent to the LLM (privacy.restricted_paths)"
+
+// PrivacyReport records what privacy mode withheld from the LLM
+type PrivacyReport struct {
+	StringsStripped         bool     `json:"strings_stripped"`
+	ContactCommentsStripped bool     `json:"contact_comments_stripped"`
+	SensitivePatterns       int      `json:"sensitive_patterns"`         // Identifier patterns replaced by placeholders
+	RestrictedFiles         []string `json:"restricted_files,omitempty"` // Files whose contents were never sent
+}
+
+// isRestricted reports whether privacy mode keeps every byte of a file away from the LLM
+func (a *Analyzer) isRestricted(relativePath string) bool {
+	if a.privacy == nil {
+		return false
+	}
+	relativePath = strings.ReplaceAll(relativePath, "\\", "/")
+	for _, pattern := range a.config.Privacy.RestrictedPaths {
+		if matchPathPattern(pattern, relativePath) {
+			return true
+		}
+	}
+	return false
+}
+
+// onlyRestricted reports whether every one of paths is restricted, for derived facts such as a
+// table whose every migration lies in a restricted folder
+func (a *Analyzer) onlyRestricted(paths []string) bool {
+	if a.privacy == nil || len(paths) == 0 {
+		return false
+	}
+	for _, path := range paths {
+		if !a.isRestricted(path) {
+			return false
+		}
+	}
+	return true
+}
+
+// restrictedSummary stands in for the summary of a restricted file; only its path is known
+func restrictedSummary(relativePath string) *internalOpenai.FileSummary {
+	return &internalOpenai.FileSummary{
+		Language:   heuristics.LanguageForPath(relativePath),
+		Purpose:    restrictedPurpose,
+		Complexity: "unknown",
+	}
+}
+
+// llmContent anonymizes file content on its way to the LLM; content is unchanged outside privacy mode
+func (a *Analyzer) llmContent(relativePath, content string) string {
+	if a.privacy == nil {
+		return content
+	}
+	return a.privacy.Anonymize(relativePath, content)
+}
+
+// privateRelationships asks the LLM for implicit table relationships in privacy mode. The final
+// migration is rebuilt from the unrestricted migrations only and anonymized like file content.
+func (a *Analyzer) privateRelationships(ctx context.Context, migrations map[string]string) (relationships string) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Printf("⚠️  Database schema extraction recovered from panic: %v\n", r)
+			relationships = ""
+		}
+	}()
+	allowed := make(map[string]string, len(migrations))
+	for path, content := range migrations {
+		if !a.isRestricted(path) {
+			allowed[path] = content
+		}
+	}
+	if len(allowed) == 0 {
+		return ""
+	}
+	result, err := database.ExtractSchemaWithFinalMigrationContext(ctx, "", allowed, func(database.StreamingResponse) {}, nil)
+	if err != nil || result == nil || result.FinalMigrationSQL == "" {
+		return ""
+	}
+	relationships, err = database.AnalyzeImplicitRelationships(ctx, a.openaiClient, a.llmContent("final_migration.sql", result.FinalMigrationSQL))
+	if err != nil {
+		fmt.Printf("⚠️  LLM relationship analysis failed: %v\n", err)
+		return ""
+	}
+	return relationships
+}
+
+// privacyReport lists what privacy mode withheld from the analysis of files
+func (a *Analyzer) privacyReport(files []FileInfo) *PrivacyReport {
+	if a.privacy == nil {
+		return nil
+	}
+	report := &PrivacyReport{
+		StringsStripped:         a.config.Privacy.StripStrings,
+		ContactCommentsStripped: a.config.Privacy.StripContactComments,
+		SensitivePatterns:       len(a.config.Privacy.SensitiveIdentifiers),
+	}
+	for _, file := range files {
+		if !file.IsDir && a.isRestricted(file.RelativePath) {
+			report.RestrictedFiles = append(report.RestrictedFiles, file.RelativePath)
+		}
+	}
+	return report
+}
@@ -0,0 +1,31 @@
+package pipeline
+
+import "fmt"
+
+// SetProgress makes AnalyzeProject report its phases and map-phase file counts to callback
+// instead of printing the phase lines, so the CLI can render a progress bar or JSON events.
+// A nil callback restores the printed phase lines.
+func (a *Analyzer) SetProgress(callback ProgressCallback) {
+	a.progress = callback
+}
+
+// announce starts a phase of AnalyzeProject; progress is the share of the analysis done, as in
+// the events of AnalyzeProjectWithProgress
+func (a *Analyzer) announce(stage string, progress int) {
+	if a.progress == nil {
+		fmt.Println(stage)
+		return
+	}
+	a.progress("progress", stage, "", progress, nil)
+}
+
+// reportFiles reports how many files the map phase of AnalyzeProject has finished
+func (a *Analyzer) reportFiles(done, total int) {
+	if a.progress == nil || total == 0 {
+		return
+	}
+	a.progress("files", "🧠 Analyzing files...", fmt.Sprintf("Analyzed %d/%d files", done, total), 35+done*15/total, map[string]interface{}{
+		"files_done":  done,
+		"files_total": total,
+	})
+}
@@ -0,0 +1,56 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"repo-explanation/internal/heuristics"
+	internalOpenai "repo-explanation/internal/openai"
+)
+
+// quickProjectTimeout bounds the single LLM call of a quick scan, so the scan finishes in about
+// half a minute; past it the heuristic project summary is used
+const quickProjectTimeout = 20 * time.Second
+
+// deepLLM reports whether per-file, per-folder and refinement LLM calls run; offline runs and
+// quick scans skip them
+func (a *Analyzer) deepLLM() bool {
+	return !a.config.Offline && !a.options.Quick
+}
+
+// llmMode names the mode that skips LLM calls, for log messages
+func (a *Analyzer) llmMode() string {
+	if a.config.Offline {
+		return "Offline"
+	}
+	return "Quick"
+}
+
+// quickProjectSummary asks the LLM for the project summary under quickProjectTimeout, falling
+// back to the heuristic summary when the call fails or runs out of time
+func (a *Analyzer) quickProjectSummary(ctx context.Context, projectPath string, folders map[string]internalOpenai.FolderSummary, docs *internalOpenai.DocumentationDigest) *internalOpenai.ProjectSummary {
+	callCtx, cancel := context.WithTimeout(ctx, quickProjectTimeout)
+	defer cancel()
+
+	summary, err := a.openaiClient.AnalyzeProject(callCtx, projectPath, folders, docs)
+	if err != nil {
+		fmt.Printf("⚠️  Quick scan project summary failed, using heuristics: %v\n", err)
+		summary = heuristics.SummarizeProject(projectPath, folders, a.readReadme())
+	}
+	summary.Documentation = docs
+	return summary
+}
+
+// quickEnhanceableFields lists the fields a full run would enhance after a quick scan: everything
+// an offline run leaves heuristic except the project summary
+func quickEnhanceableFields() []string {
+	var fields []string
+	for _, field := range heuristics.LLMEnhanceableFields {
+		if field == "project_summary.detailed_analysis" || !strings.HasPrefix(field, "project_summary.") {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
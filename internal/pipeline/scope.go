@@ -0,0 +1,270 @@
+package pipeline
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"repo-explanation/config"
+	internalOpenai "repo-explanation/internal/openai"
+	"repo-explanation/internal/relationships"
+)
+
+// Analysis phases that can be selected with AnalysisOptions.Phases
+const (
+	PhaseFiles     = "files"
+	PhaseFolders   = "folders"
+	PhaseProject   = "project"
+	PhaseServices  = "services"
+	PhaseSchema    = "schema"
+	PhaseInfra     = "infrastructure"
+	PhaseDeadCode  = "deadcode"
+	PhaseSecrets   = "secrets"
+	PhaseQuestions = "questions"
+	PhaseGlossary  = "glossary"
+)
+
+// AllPhases lists every selectable phase in pipeline order
+var AllPhases = []string{PhaseFiles, PhaseFolders, PhaseProject, PhaseServices, PhaseSchema, PhaseInfra, PhaseDeadCode, PhaseSecrets, PhaseQuestions, PhaseGlossary}
+
+// AnalysisOptions scopes an analysis to a subset of paths and phases
+type AnalysisOptions struct {
+	Include  []string `json:"include,omitempty"`  // Glob patterns (or directory prefixes) to keep
+	Exclude  []string `json:"exclude,omitempty"`  // Glob patterns (or directory prefixes) to drop
+	Service  string   `json:"service,omitempty"`  // Discovered service to limit the analysis to, with the services it calls
+	Phases   []string `json:"phases,omitempty"`   // Phases to run; empty means all
+	Resume   bool     `json:"resume,omitempty"`   // Pick up from the last checkpointed phase
+	Quick    bool     `json:"quick,omitempty"`    // Skip per-file and per-folder LLM calls; one project-level call only
+	Profile  string   `json:"profile,omitempty"`  // Named profile from the config that preselects phases and frames prompts
+	Language string   `json:"language,omitempty"` // Language code for LLM-written prose (en, vi, ja, ...); structured fields stay as in the source
+
+	MinConfidence float64  `json:"min_confidence,omitempty"` // Drop service relationships below this confidence; 0 uses the config
+	OnlyEvidence  []string `json:"only_evidence,omitempty"`  // Keep relationships with these evidence types; empty uses the config
+
+	ERDSplit    bool `json:"erd_split,omitempty"`   // Also split the database ERD into domain subdiagrams
+	Annotations bool `json:"annotations,omitempty"` // Write .repo-analyzer/annotations.json into the analyzed repository
+}
+
+// ParseList splits a comma-separated flag value into trimmed, non-empty entries
+func ParseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Validate checks that every requested phase, evidence type and output language is known
+func (o AnalysisOptions) Validate() error {
+	if _, err := internalOpenai.NormalizeLanguage(o.Language); err != nil {
+		return err
+	}
+	if o.Service != "" && len(o.Include) > 0 {
+		return fmt.Errorf("a service scope cannot be combined with include patterns")
+	}
+	if o.MinConfidence < 0 || o.MinConfidence > 1 {
+		return fmt.Errorf("min confidence must be between 0 and 1 (got %g)", o.MinConfidence)
+	}
+	if _, err := relationships.ParseEvidenceTypes(o.OnlyEvidence); err != nil {
+		return err
+	}
+	for _, phase := range o.Phases {
+		known := false
+		for _, p := range AllPhases {
+			if strings.EqualFold(phase, p) {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("unknown phase %q (valid phases: %s)", phase, strings.Join(AllPhases, ","))
+		}
+	}
+	return nil
+}
+
+// RelationshipFilter combines the relationship options with the config's relationships section;
+// options set for the run take precedence
+func (o AnalysisOptions) RelationshipFilter(cfg *config.Config) relationships.Filter {
+	minConfidence := o.MinConfidence
+	if minConfidence == 0 {
+		minConfidence = cfg.Relationships.MinConfidence
+	}
+	names := o.OnlyEvidence
+	if len(names) == 0 {
+		names = cfg.Relationships.OnlyEvidence
+	}
+	// Both sources are validated before an analysis starts
+	evidence, _ := relationships.ParseEvidenceTypes(names)
+	return relationships.Filter{MinConfidence: minConfidence, Evidence: evidence}
+}
+
+// PhaseEnabled reports whether the given phase should run
+func (o AnalysisOptions) PhaseEnabled(phase string) bool {
+	if len(o.Phases) == 0 {
+		return true
+	}
+	for _, p := range o.Phases {
+		if strings.EqualFold(p, phase) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsScoped reports whether any path filtering is configured
+func (o AnalysisOptions) IsScoped() bool {
+	return len(o.Include) > 0 || len(o.Exclude) > 0 || o.Service != ""
+}
+
+// InScope reports whether a slash-separated relative path passes the include/exclude filters
+func (o AnalysisOptions) InScope(relativePath string) bool {
+	if len(o.Include) > 0 {
+		included := false
+		for _, pattern := range o.Include {
+			if matchPathPattern(pattern, relativePath) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+
+	for _, pattern := range o.Exclude {
+		if matchPathPattern(pattern, relativePath) {
+			return false
+		}
+	}
+	return true
+}
+
+// ScopeRoot returns the single directory the analysis is limited to, or "" if there is none
+func (o AnalysisOptions) ScopeRoot() string {
+	if len(o.Include) != 1 || hasGlobMeta(o.Include[0]) {
+		return ""
+	}
+	return strings.Trim(path.Clean(o.Include[0]), "/")
+}
+
+// scopeKey returns a cache key suffix identifying the path scope, or "" when unscoped
+func (o AnalysisOptions) scopeKey() string {
+	if !o.IsScoped() {
+		return ""
+	}
+	key := fmt.Sprintf("#scope=%s!%s", strings.Join(o.Include, ","), strings.Join(o.Exclude, ","))
+	if o.Service != "" {
+		key += "#service=" + strings.ToLower(o.Service)
+	}
+	return key
+}
+
+// profileKey returns a cache key suffix identifying the profile, or "" when none is selected
+func (o AnalysisOptions) profileKey() string {
+	if o.Profile == "" {
+		return ""
+	}
+	return "#profile=" + o.Profile
+}
+
+// languageKey distinguishes checkpoints written in another output language
+func (o AnalysisOptions) languageKey() string {
+	if o.Language == "" {
+		return ""
+	}
+	return "#lang=" + o.Language
+}
+
+// filterFiles applies the include/exclude filters to crawled files
+func (o AnalysisOptions) filterFiles(files []FileInfo) []FileInfo {
+	if !o.IsScoped() {
+		return files
+	}
+
+	filtered := make([]FileInfo, 0, len(files))
+	for _, file := range files {
+		if o.InScope(strings.ReplaceAll(file.RelativePath, "\\", "/")) {
+			filtered = append(filtered, file)
+		}
+	}
+	return filtered
+}
+
+// matchPathPattern matches a path against a glob pattern; plain patterns match as directory prefixes
+func matchPathPattern(pattern, relativePath string) bool {
+	pattern = strings.TrimPrefix(strings.TrimSpace(pattern), "./")
+	if pattern == "" {
+		return false
+	}
+
+	if !hasGlobMeta(pattern) {
+		prefix := strings.TrimSuffix(pattern, "/")
+		return relativePath == prefix || strings.HasPrefix(relativePath, prefix+"/")
+	}
+
+	re, err := globToRegexp(pattern)
+	if err != nil {
+		return false
+	}
+	if re.MatchString(relativePath) {
+		return true
+	}
+
+	// A pattern such as "services/*" should also cover everything below the matched directory
+	for dir := path.Dir(relativePath); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if re.MatchString(dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasGlobMeta reports whether a pattern contains glob metacharacters
+func hasGlobMeta(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
+
+// globToRegexp converts a glob with ** support into an anchored regular expression
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	var re strings.Builder
+	re.WriteString("^")
+
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				// "**/" matches zero or more directories, "**" matches anything
+				if i+2 < len(pattern) && pattern[i+2] == '/' {
+					re.WriteString("(?:.*/)?")
+					i += 2
+				} else {
+					re.WriteString(".*")
+					i++
+				}
+			} else {
+				re.WriteString("[^/]*")
+			}
+		case '?':
+			re.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(pattern[i:], ']')
+			if end < 0 {
+				re.WriteString(`\[`)
+				continue
+			}
+			re.WriteString(pattern[i : i+end+1])
+			i += end
+		default:
+			re.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	re.WriteString("$")
+	return regexp.Compile(re.String())
+}
@@ -0,0 +1,103 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"repo-explanation/internal/microservices"
+	internalOpenai "repo-explanation/internal/openai"
+	"repo-explanation/internal/relationships"
+)
+
+// maxSequenceLabel bounds LLM-provided arrow labels so diagrams stay readable
+const maxSequenceLabel = 60
+
+// buildSequenceFlows reconstructs the top inter-service request flows as sequence diagrams.
+// Callee source files are read to match called routes to their handlers; outside offline mode
+// the LLM names each flow and relabels its calls.
+func (a *Analyzer) buildSequenceFlows(ctx context.Context, files []FileInfo, services []microservices.DiscoveredService, serviceRelationships []relationships.ServiceRelationship) []relationships.SequenceFlow {
+	if len(serviceRelationships) == 0 {
+		return nil
+	}
+
+	callees := make(map[string]bool)
+	for _, rel := range serviceRelationships {
+		callees[rel.To] = true
+	}
+	var calleePaths []string
+	for _, service := range services {
+		if callees[service.Name] {
+			calleePaths = append(calleePaths, strings.TrimSuffix(filepath.ToSlash(service.Path), "/")+"/")
+		}
+	}
+
+	if ctx.Err() != nil {
+		fmt.Printf("⏱️  Sequence flow reconstruction interrupted: %v\n", ctx.Err())
+		return nil
+	}
+	// Only the callees' files are read, to find their route handlers
+	fileMap := a.crawler.Store(files).Contents(func(path string) bool {
+		for _, prefix := range calleePaths {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		}
+		return false
+	})
+
+	flows := relationships.BuildSequenceFlows(services, serviceRelationships, fileMap, a.config.Limits.SequenceFlows)
+	if len(flows) == 0 || !a.deepLLM() {
+		return flows
+	}
+
+	outlines := make([]internalOpenai.SequenceFlowOutline, len(flows))
+	for i, flow := range flows {
+		outline := internalOpenai.SequenceFlowOutline{Name: flow.Name}
+		for _, step := range flow.Steps {
+			line := fmt.Sprintf("%s -> %s: %s (%s in %s)", step.From, step.To, step.Message, step.Evidence, step.FilePath)
+			if step.HandlerFile != "" {
+				line += ", handled in " + step.HandlerFile
+			}
+			outline.Steps = append(outline.Steps, line)
+		}
+		outlines[i] = outline
+	}
+
+	refinements, err := a.openaiClient.RefineSequenceFlows(ctx, outlines)
+	if err != nil {
+		fmt.Printf("⚠️  LLM sequence flow refinement failed: %v\n", err)
+		return flows
+	}
+	for _, refinement := range refinements {
+		applySequenceRefinement(flows, refinement)
+	}
+	return flows
+}
+
+// applySequenceRefinement takes the LLM's names and labels only when they fit the detected flow
+func applySequenceRefinement(flows []relationships.SequenceFlow, refinement internalOpenai.SequenceFlowRefinement) {
+	if refinement.Flow < 0 || refinement.Flow >= len(flows) {
+		return
+	}
+	flow := &flows[refinement.Flow]
+
+	name := strings.ToLower(strings.TrimSpace(refinement.Name))
+	if name == "" {
+		return
+	}
+	flow.Name = name
+	flow.Description = strings.TrimSpace(refinement.Description)
+
+	if len(refinement.Messages) == len(flow.Steps) {
+		for i, message := range refinement.Messages {
+			message = strings.TrimSpace(message)
+			if message != "" && len(message) <= maxSequenceLabel {
+				flow.Steps[i].Message = message
+			}
+		}
+	}
+	flow.Source = "heuristic+llm"
+	flow.Mermaid = flow.RenderMermaid()
+}
@@ -0,0 +1,144 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"repo-explanation/internal/heuristics"
+	internalOpenai "repo-explanation/internal/openai"
+)
+
+// classifyServiceArchitectures fills in the Architecture field of every service that lacks one.
+// The directory heuristic always runs; outside offline mode the LLM confirms or corrects it.
+func (a *Analyzer) classifyServiceArchitectures(ctx context.Context, files []FileInfo, services []internalOpenai.MonorepoService) {
+	if len(services) == 0 {
+		return
+	}
+
+	filePaths := make([]string, 0, len(files))
+	for _, file := range files {
+		filePaths = append(filePaths, filepath.ToSlash(file.RelativePath))
+	}
+
+	for i := range services {
+		service := &services[i]
+		if service.Architecture != nil {
+			continue
+		}
+		if ctx.Err() != nil {
+			fmt.Printf("⏱️  Architecture classification interrupted: %v\n", ctx.Err())
+			return
+		}
+
+		heuristic := heuristics.ClassifyServiceArchitecture(service.Path, filePaths)
+		if heuristic == nil {
+			continue
+		}
+		service.Architecture = heuristic
+
+		if !a.deepLLM() {
+			continue
+		}
+
+		directories := serviceDirectories(service.Path, filePaths, a.config.Limits.ArchitectureDirectories)
+		llmResult, err := a.openaiClient.ClassifyServiceArchitecture(ctx, *service, directories, heuristic)
+		if err != nil {
+			fmt.Printf("⚠️  LLM architecture classification failed for %s: %v\n", service.Name, err)
+			continue
+		}
+		service.Architecture = mergeArchitecture(heuristic, llmResult, directories)
+	}
+
+	for _, service := range services {
+		if service.Architecture != nil {
+			fmt.Printf("🏛️  %s: %s architecture (%.0f%%, %s)\n", service.Name, service.Architecture.Pattern, service.Architecture.Confidence*100, service.Architecture.Source)
+		}
+	}
+}
+
+// mergeArchitecture combines the heuristic and LLM classifications.
+// Agreement raises confidence; on disagreement the more confident answer wins.
+// LLM evidence is kept only when it names directories that actually exist.
+func mergeArchitecture(heuristic, llmResult *internalOpenai.ServiceArchitecture, directories []string) *internalOpenai.ServiceArchitecture {
+	if llmResult == nil || !heuristics.IsArchitecturePattern(llmResult.Pattern) {
+		return heuristic
+	}
+
+	known := make(map[string]bool, len(directories))
+	for _, dir := range directories {
+		known[dir] = true
+	}
+	var llmEvidence []string
+	for _, dir := range llmResult.Evidence {
+		dir = strings.TrimSuffix(filepath.ToSlash(dir), "/") + "/"
+		if known[dir] {
+			llmEvidence = append(llmEvidence, dir)
+		}
+	}
+
+	if llmResult.Pattern == heuristic.Pattern {
+		merged := *heuristic
+		merged.Confidence = heuristic.Confidence + (1-heuristic.Confidence)*0.5
+		merged.Evidence = mergeUnique(heuristic.Evidence, llmEvidence)
+		merged.Source = "heuristic+llm"
+		if llmResult.Rationale != "" {
+			merged.Rationale = llmResult.Rationale
+		}
+		return &merged
+	}
+
+	if llmResult.Confidence > heuristic.Confidence && len(llmEvidence) > 0 {
+		merged := *llmResult
+		merged.Evidence = llmEvidence
+		merged.Source = "llm"
+		return &merged
+	}
+	return heuristic
+}
+
+// serviceDirectories lists the first limit project-relative directories (with trailing slash) under a service
+func serviceDirectories(servicePath string, filePaths []string, limit int) []string {
+	servicePath = strings.Trim(filepath.ToSlash(filepath.Clean(servicePath)), "/")
+	if servicePath == "." {
+		servicePath = ""
+	}
+
+	seen := make(map[string]bool)
+	for _, path := range filePaths {
+		if servicePath != "" && !strings.HasPrefix(path, servicePath+"/") {
+			continue
+		}
+		for dir := filepath.ToSlash(filepath.Dir(path)); dir != "." && dir != servicePath && dir != ""; dir = filepath.ToSlash(filepath.Dir(dir)) {
+			seen[dir+"/"] = true
+		}
+	}
+
+	directories := make([]string, 0, len(seen))
+	for dir := range seen {
+		directories = append(directories, dir)
+	}
+	sort.Strings(directories)
+	if len(directories) > limit {
+		directories = directories[:limit]
+	}
+	return directories
+}
+
+// mergeUnique appends the values of b missing from a, preserving order
+func mergeUnique(a, b []string) []string {
+	seen := make(map[string]bool, len(a))
+	merged := append([]string{}, a...)
+	for _, value := range a {
+		seen[value] = true
+	}
+	for _, value := range b {
+		if !seen[value] {
+			seen[value] = true
+			merged = append(merged, value)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,111 @@
+package pipeline
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"repo-explanation/internal/detector"
+	"repo-explanation/internal/microservices"
+	"repo-explanation/internal/overrides"
+	"repo-explanation/internal/portable"
+	"repo-explanation/internal/relationships"
+)
+
+// ServiceScope records the service an analysis was limited to with AnalysisOptions.Service
+type ServiceScope struct {
+	Service      string   `json:"service"`
+	Path         string   `json:"path"`                   // Directory of the service
+	Dependencies []string `json:"dependencies,omitempty"` // Services it calls directly, whose directories were analyzed too
+	Directories  []string `json:"directories"`            // Every directory analyzed
+}
+
+// resolveServiceScope limits the analysis to the directory of the service named by
+// AnalysisOptions.Service and the directories of the services it calls directly. Services and
+// their relationships are discovered on the whole repository first, without the LLM, since the
+// dependencies lie outside the service. Runs once per analyzer; later calls keep the first scope.
+func (a *Analyzer) resolveServiceScope(files []FileInfo) error {
+	if a.options.Service == "" || a.serviceScope != nil {
+		return nil
+	}
+
+	projectDetector, err := detector.NewProjectDetectorFromFile(a.config.Detection.RulesFile)
+	if err != nil {
+		return fmt.Errorf("project type detection failed: %v", err)
+	}
+	userOverrides, err := overrides.Load(a.crawler.basePath)
+	if err != nil {
+		return fmt.Errorf("project type detection failed: %v", err)
+	}
+	detectorFiles := make([]detector.FileInfo, len(files))
+	for i, file := range files {
+		detectorFiles[i] = detector.FileInfo{Path: file.Path, RelativePath: file.RelativePath, Size: file.Size, Extension: file.Extension, IsDir: file.IsDir}
+	}
+	projectType := projectDetector.DetectProjectType(detectorFiles, a.crawler.Store(files).Contents(detector.NeedsContent))
+	userOverrides.ApplyProjectType(projectType)
+
+	var services []microservices.DiscoveredService
+	if projectTypeStr := a.discoveryProjectType(files, projectType); projectTypeStr != "" {
+		fileMap := a.crawler.Store(files).Outline(microservices.NeedsContent)
+		services, err = microservices.NewEnhancedServiceDiscovery(a.crawler.basePath, projectTypeStr).DiscoverMicroservices(fileMap)
+		if err != nil {
+			return fmt.Errorf("service discovery failed: %v", err)
+		}
+	}
+	services = userOverrides.ApplyServices(services)
+
+	var target *microservices.DiscoveredService
+	names := make([]string, 0, len(services))
+	for i, service := range services {
+		names = append(names, service.Name)
+		if strings.EqualFold(service.Name, a.options.Service) {
+			target = &services[i]
+		}
+	}
+	if target == nil {
+		if len(names) == 0 {
+			return fmt.Errorf("service %q not found: no services were discovered", a.options.Service)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("service %q not found (discovered services: %s)", a.options.Service, strings.Join(names, ", "))
+	}
+
+	scope := &ServiceScope{Service: target.Name, Path: portable.Slash(target.Path)}
+	if scope.Path == "" || scope.Path == "." {
+		return fmt.Errorf("service %q lives at the repository root; scoping to it would analyze everything", target.Name)
+	}
+	scope.Directories = []string{scope.Path}
+
+	graph, err := relationships.NewRelationshipDiscovery(services, a.crawler.Store(files)).DiscoverRelationships(a.crawler.basePath)
+	if err != nil {
+		return fmt.Errorf("service relationship discovery failed: %v", err)
+	}
+	graph = graph.Filtered(a.options.RelationshipFilter(a.config))
+	paths := make(map[string]string, len(services))
+	for _, service := range services {
+		paths[service.Name] = portable.Slash(service.Path)
+	}
+	seen := map[string]bool{target.Name: true}
+	for _, relationship := range graph.Relationships {
+		if relationship.From != target.Name || seen[relationship.To] {
+			continue
+		}
+		seen[relationship.To] = true
+		scope.Dependencies = append(scope.Dependencies, relationship.To)
+		if path := paths[relationship.To]; path != "" && path != "." && !portable.Within(path, scope.Path) {
+			scope.Directories = append(scope.Directories, path)
+		}
+	}
+	sort.Strings(scope.Dependencies)
+	sort.Strings(scope.Directories[1:])
+
+	// The service directory comes first, so whole-tree scanners walk only the service itself
+	a.options.Include = scope.Directories
+	a.serviceScope = scope
+	fmt.Printf("🎯 Service scope: %s (%s)", scope.Service, scope.Path)
+	if len(scope.Dependencies) > 0 {
+		fmt.Printf(" with its dependencies %s", strings.Join(scope.Dependencies, ", "))
+	}
+	fmt.Println()
+	return nil
+}
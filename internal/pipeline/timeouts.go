@@ -0,0 +1,53 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+
+	"repo-explanation/internal/heuristics"
+	internalOpenai "repo-explanation/internal/openai"
+)
+
+// phaseContext derives the context a phase runs under, bounded by its configured timeout
+func (a *Analyzer) phaseContext(ctx context.Context, phase string) (context.Context, context.CancelFunc) {
+	if timeout := a.config.GetPhaseTimeout(phase); timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// checkCancelled returns an error once the whole analysis has been cancelled or has timed out
+func checkCancelled(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("analysis cancelled: %v", err)
+	}
+	return nil
+}
+
+// finishPhase releases a phase context and reports whether the phase ran to completion.
+// A phase timeout is tolerated (the caller keeps its partial results), while cancellation
+// of the whole analysis is returned as an error.
+func finishPhase(parent, phaseCtx context.Context, cancel context.CancelFunc, phase string) (bool, error) {
+	phaseErr := phaseCtx.Err()
+	cancel()
+
+	if err := checkCancelled(parent); err != nil {
+		return false, err
+	}
+	if phaseErr != nil {
+		fmt.Printf("⏱️  Phase '%s' timed out - continuing with partial results\n", phase)
+		return false, nil
+	}
+	return true, nil
+}
+
+// fallbackProjectSummary builds a heuristic project summary when the LLM project phase times out
+func (a *Analyzer) fallbackProjectSummary(folderSummaries map[string]*internalOpenai.FolderSummary) *internalOpenai.ProjectSummary {
+	folders := make(map[string]internalOpenai.FolderSummary)
+	for k, v := range folderSummaries {
+		if v != nil {
+			folders[k] = *v
+		}
+	}
+	return heuristics.SummarizeProject(a.crawler.basePath, folders, a.readReadme())
+}
@@ -0,0 +1,365 @@
+package pkggraph
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"sort"
+	"strings"
+
+	"repo-explanation/internal/filestore"
+	"repo-explanation/internal/mermaid"
+)
+
+// Package is one node of the graph: a workspace member, a go.work module, or a Go package of the root module
+type Package struct {
+	Name   string `json:"name"`
+	Path   string `json:"path"` // Directory relative to the project root, "." for the root
+	Kind   string `json:"kind"` // npm, go-module or go-package
+	FanIn  int    `json:"fan_in"`
+	FanOut int    `json:"fan_out"`
+}
+
+// Dependency is an edge between two packages of the repository
+type Dependency struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Manifest bool   `json:"manifest"` // Declared in package.json or go.mod
+	Imports  int    `json:"imports"`  // Source files of From importing To
+	Evidence string `json:"evidence"` // Manifest or first importing file
+}
+
+// Hotspot is a package with far more dependents or dependencies than the rest
+type Hotspot struct {
+	Package   string `json:"package"`
+	Direction string `json:"direction"` // fan-in or fan-out
+	Count     int    `json:"count"`
+	Threshold int    `json:"threshold"`
+	Message   string `json:"message"`
+}
+
+// Graph is the package-level dependency graph inside one repository, distinct from the runtime service graph
+type Graph struct {
+	Sources      []string     `json:"sources"` // Where packages were declared: npm workspaces, pnpm-workspace.yaml, go.work, go.mod
+	Packages     []Package    `json:"packages"`
+	Dependencies []Dependency `json:"dependencies"`
+	Hotspots     []Hotspot    `json:"hotspots,omitempty"`
+	MermaidGraph string       `json:"mermaid_graph"`
+	Summary      string       `json:"summary"`
+}
+
+// minHotspotFan keeps small graphs from flagging packages with only a few edges
+const minHotspotFan = 5
+
+// Directories never holding workspace members or their source
+var skippedDirs = map[string]bool{
+	".git": true, "node_modules": true, "vendor": true, "dist": true, "build": true, ".next": true,
+	"target": true, "__pycache__": true, ".venv": true, "testdata": true, "coverage": true,
+}
+
+// member is a package being resolved: its node plus what is needed to find its edges
+type member struct {
+	Package
+	importPath string            // npm package name or Go import path other members import it by
+	manifest   string            // package.json or go.mod path
+	declared   map[string]string // Dependencies listed in the manifest -> manifest path
+}
+
+// Build reads npm/yarn/pnpm workspaces, go.work modules and, for a single Go module, its packages,
+// then links them through manifests and source imports
+func Build(project *filestore.FileStore) (*Graph, error) {
+	fmt.Printf("🕸️  [DEBUG] Building package graph from %d files\n", project.Len())
+
+	files := loadFiles(project)
+
+	graph := &Graph{Packages: []Package{}, Dependencies: []Dependency{}}
+	var members []*member
+
+	npmMembers, npmSources := npmWorkspaceMembers(files)
+	members = append(members, npmMembers...)
+	graph.Sources = append(graph.Sources, npmSources...)
+
+	goMembers, goSource := goMembers(files)
+	members = append(members, goMembers...)
+	if goSource != "" {
+		graph.Sources = append(graph.Sources, goSource)
+	}
+
+	if len(members) < 2 {
+		graph.Summary = "No multi-package workspace found"
+		return graph, nil
+	}
+
+	dependencies := link(members, files)
+	for _, dep := range dependencies {
+		for _, m := range members {
+			if m.Name == dep.From {
+				m.FanOut++
+			}
+			if m.Name == dep.To {
+				m.FanIn++
+			}
+		}
+	}
+
+	for _, m := range members {
+		graph.Packages = append(graph.Packages, m.Package)
+	}
+	sort.Slice(graph.Packages, func(i, j int) bool { return graph.Packages[i].Name < graph.Packages[j].Name })
+	graph.Dependencies = dependencies
+	graph.Hotspots = findHotspots(graph.Packages)
+	graph.MermaidGraph = generateMermaidGraph(graph)
+	graph.Summary = fmt.Sprintf("%d packages (%s), %d internal dependencies, %d hotspots",
+		len(graph.Packages), strings.Join(graph.Sources, ", "), len(graph.Dependencies), len(graph.Hotspots))
+
+	fmt.Printf("✅ [DEBUG] Package graph complete: %s\n", graph.Summary)
+	return graph, nil
+}
+
+// loadFiles reads manifests, workspace declarations and source files. It does not use the crawled
+// files because the crawler skips go.mod, go.work and .tsx/.jsx sources.
+func loadFiles(project *filestore.FileStore) map[string]string {
+	return project.Contents(func(rel string) bool {
+		return !filestore.InDirs(rel, skippedDirs) && !filestore.InHiddenDir(rel) && isGraphFile(path.Base(rel))
+	})
+}
+
+func isGraphFile(name string) bool {
+	switch name {
+	case "package.json", "pnpm-workspace.yaml", "go.mod", "go.work", "yarn.lock":
+		return true
+	}
+	if strings.HasSuffix(name, "_test.go") {
+		return false
+	}
+	return isGoSource(name) || isJSSource(name)
+}
+
+func isGoSource(name string) bool {
+	return strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, "_test.go")
+}
+
+func isJSSource(name string) bool {
+	switch path.Ext(name) {
+	case ".js", ".jsx", ".ts", ".tsx", ".mjs", ".cjs", ".vue", ".svelte":
+		return !strings.HasSuffix(name, ".d.ts")
+	}
+	return false
+}
+
+// link finds the edges between members, from manifests first and then from source imports
+func link(members []*member, files map[string]string) []Dependency {
+	byImportPath := make(map[string]*member)
+	dirs := make([]string, 0, len(members))
+	byDir := make(map[string][]*member)
+	for _, m := range members {
+		byImportPath[m.importPath] = m
+		if _, ok := byDir[m.Path]; !ok {
+			dirs = append(dirs, m.Path)
+		}
+		byDir[m.Path] = append(byDir[m.Path], m)
+	}
+
+	edges := make(map[string]*Dependency)
+	edge := func(from, to *member, evidence string) *Dependency {
+		key := from.Name + "\x00" + to.Name
+		if edges[key] == nil {
+			edges[key] = &Dependency{From: from.Name, To: to.Name, Evidence: evidence}
+		}
+		return edges[key]
+	}
+
+	for _, m := range members {
+		for name, manifest := range m.declared {
+			if target, ok := byImportPath[name]; ok && target != m {
+				edge(m, target, manifest).Manifest = true
+			}
+		}
+	}
+
+	for _, file := range sortedKeys(files) {
+		var imports []string
+		var kind string
+		switch {
+		case isGoSource(file):
+			imports, kind = goImports(files[file]), "go"
+		case isJSSource(file):
+			imports, kind = jsImports(files[file]), "npm"
+		default:
+			continue
+		}
+
+		from := owningMember(file, dirs, byDir, kind)
+		if from == nil {
+			continue
+		}
+		seen := make(map[*member]bool)
+		for _, spec := range imports {
+			target := resolveImport(spec, byImportPath, kind)
+			if target == nil || target == from || seen[target] {
+				continue
+			}
+			seen[target] = true
+			edge(from, target, file).Imports++
+		}
+	}
+
+	dependencies := make([]Dependency, 0, len(edges))
+	for _, dep := range edges {
+		dependencies = append(dependencies, *dep)
+	}
+	sort.Slice(dependencies, func(i, j int) bool {
+		if dependencies[i].From != dependencies[j].From {
+			return dependencies[i].From < dependencies[j].From
+		}
+		return dependencies[i].To < dependencies[j].To
+	})
+	return dependencies
+}
+
+// owningMember returns the member of the given kind whose directory most closely contains file.
+// Go packages own only the files directly in their directory.
+func owningMember(file string, dirs []string, byDir map[string][]*member, kind string) *member {
+	dir := path.Dir(file)
+	var owner *member
+	longest := -1
+	for _, candidate := range dirs {
+		for _, m := range byDir[candidate] {
+			if (kind == "go") != strings.HasPrefix(m.Kind, "go") {
+				continue
+			}
+			if m.Kind == "go-package" {
+				if dir == m.Path {
+					return m
+				}
+				continue
+			}
+			if (m.Path == "." || dir == m.Path || strings.HasPrefix(dir, m.Path+"/")) && len(m.Path) > longest {
+				owner, longest = m, len(m.Path)
+			}
+		}
+	}
+	return owner
+}
+
+// resolveImport maps an import specifier to the member it names: an exact match, or for Go
+// modules and npm packages, the longest import path the specifier is a subpath of
+func resolveImport(spec string, byImportPath map[string]*member, kind string) *member {
+	if m, ok := byImportPath[spec]; ok {
+		return m
+	}
+	var best *member
+	for importPath, m := range byImportPath {
+		if m.Kind == "go-package" || (kind == "go") != strings.HasPrefix(m.Kind, "go") {
+			continue
+		}
+		if strings.HasPrefix(spec, importPath+"/") && (best == nil || len(importPath) > len(best.importPath)) {
+			best = m
+		}
+	}
+	return best
+}
+
+// findHotspots flags packages whose fan-in or fan-out is more than two standard deviations above the mean
+func findHotspots(packages []Package) []Hotspot {
+	if len(packages) < 3 {
+		return nil
+	}
+	var hotspots []Hotspot
+	for _, direction := range []string{"fan-in", "fan-out"} {
+		counts := make([]float64, len(packages))
+		for i, pkg := range packages {
+			counts[i] = float64(fanCount(pkg, direction))
+		}
+		threshold := int(math.Floor(mean(counts)+2*stddev(counts))) + 1
+		if threshold < minHotspotFan {
+			threshold = minHotspotFan
+		}
+		for _, pkg := range packages {
+			count := fanCount(pkg, direction)
+			if count < threshold {
+				continue
+			}
+			message := fmt.Sprintf("%s is imported by %d packages; changes to it ripple widely", pkg.Name, count)
+			if direction == "fan-out" {
+				message = fmt.Sprintf("%s depends on %d packages; it may be doing too much", pkg.Name, count)
+			}
+			hotspots = append(hotspots, Hotspot{Package: pkg.Name, Direction: direction, Count: count, Threshold: threshold, Message: message})
+		}
+	}
+	return hotspots
+}
+
+func fanCount(pkg Package, direction string) int {
+	if direction == "fan-in" {
+		return pkg.FanIn
+	}
+	return pkg.FanOut
+}
+
+func mean(values []float64) float64 {
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+func stddev(values []float64) float64 {
+	m := mean(values)
+	sum := 0.0
+	for _, v := range values {
+		sum += (v - m) * (v - m)
+	}
+	return math.Sqrt(sum / float64(len(values)))
+}
+
+// generateMermaidGraph draws packages and their dependencies, highlighting hotspots
+func generateMermaidGraph(graph *Graph) string {
+	chart := mermaid.NewFlowchart("LR")
+	for _, pkg := range graph.Packages {
+		chart.Node(nodeName(pkg.Name), pkg.Name, mermaid.Rectangle)
+	}
+	kinds := make(map[string]string)
+	for _, pkg := range graph.Packages {
+		kinds[pkg.Name] = pkg.Kind
+	}
+	for _, dep := range graph.Dependencies {
+		arrow := mermaid.Solid
+		if !dep.Manifest && kinds[dep.From] != "go-package" {
+			// Imported without being declared in the manifest
+			arrow = mermaid.Dotted
+		}
+		chart.Edge(nodeName(dep.From), nodeName(dep.To), arrow, "")
+	}
+	if len(graph.Hotspots) > 0 {
+		chart.ClassDef("hotspot", "fill:#fee2e2,stroke:#b91c1c,stroke-width:2px")
+		flagged := make(map[string]bool)
+		for _, hotspot := range graph.Hotspots {
+			if !flagged[hotspot.Package] {
+				flagged[hotspot.Package] = true
+				chart.Class(nodeName(hotspot.Package), "hotspot")
+			}
+		}
+	}
+
+	diagram, err := mermaid.Clean(chart.String())
+	if err != nil {
+		fmt.Printf("⚠️  Package graph is not valid Mermaid: %v\n", err)
+	}
+	return diagram
+}
+
+// nodeName keeps "a/b" and "a-b" apart once both become Mermaid ids
+func nodeName(name string) string {
+	return "pkg_" + strings.ReplaceAll(name, "/", "__")
+}
+
+func sortedKeys(files map[string]string) []string {
+	keys := make([]string, 0, len(files))
+	for key := range files {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
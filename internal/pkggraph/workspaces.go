@@ -0,0 +1,285 @@
+package pkggraph
+
+import (
+	"encoding/json"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	goModuleRegex      = regexp.MustCompile(`(?m)^module\s+(\S+)`)
+	goRequireLineRegex = regexp.MustCompile(`(?m)^\s*require\s+(\S+)\s+\S+`)
+	goRequireBlock     = regexp.MustCompile(`(?s)require\s*\((.*?)\)`)
+	goWorkUseLine      = regexp.MustCompile(`(?m)^\s*use\s+([^\s(]+)`)
+	goWorkUseBlock     = regexp.MustCompile(`(?s)use\s*\((.*?)\)`)
+	goImportLineRegex  = regexp.MustCompile(`(?m)^\s*import\s+(?:[a-zA-Z_.]\w*\s+)?"([^"]+)"`)
+	goImportBlockRegex = regexp.MustCompile(`(?s)import\s*\((.*?)\)`)
+	quotedRegex        = regexp.MustCompile(`"([^"]+)"`)
+	jsImportRegex      = regexp.MustCompile(`(?:from\s+|import\s*\(\s*|require\(\s*|import\s+)['"]([^'"./][^'"]*)['"]`)
+)
+
+// packageJSON is the part of a manifest the graph reads
+type packageJSON struct {
+	Name                 string            `json:"name"`
+	Workspaces           json.RawMessage   `json:"workspaces"` // ["packages/*"] or {"packages": ["packages/*"]}
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+}
+
+// workspacePatterns returns the member globs of a workspaces field in either of its forms
+func (p packageJSON) workspacePatterns() []string {
+	var patterns []string
+	if json.Unmarshal(p.Workspaces, &patterns) == nil {
+		return patterns
+	}
+	var object struct {
+		Packages []string `json:"packages"`
+	}
+	json.Unmarshal(p.Workspaces, &object)
+	return object.Packages
+}
+
+// npmWorkspaceMembers resolves the root package.json workspaces and pnpm-workspace.yaml into members
+func npmWorkspaceMembers(files map[string]string) ([]*member, []string) {
+	var patterns, sources []string
+
+	var root packageJSON
+	if json.Unmarshal([]byte(files["package.json"]), &root) == nil {
+		if rootPatterns := root.workspacePatterns(); len(rootPatterns) > 0 {
+			patterns = append(patterns, rootPatterns...)
+			if _, ok := files["yarn.lock"]; ok {
+				sources = append(sources, "yarn workspaces")
+			} else {
+				sources = append(sources, "npm workspaces")
+			}
+		}
+	}
+
+	if content, ok := files["pnpm-workspace.yaml"]; ok {
+		var pnpm struct {
+			Packages []string `yaml:"packages"`
+		}
+		if yaml.Unmarshal([]byte(content), &pnpm) == nil && len(pnpm.Packages) > 0 {
+			patterns = append(patterns, pnpm.Packages...)
+			sources = append(sources, "pnpm-workspace.yaml")
+		}
+	}
+
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+
+	var members []*member
+	for _, file := range sortedKeys(files) {
+		if path.Base(file) != "package.json" || file == "package.json" {
+			continue
+		}
+		dir := path.Dir(file)
+		if !matchesWorkspace(dir, patterns) {
+			continue
+		}
+		var manifest packageJSON
+		if json.Unmarshal([]byte(files[file]), &manifest) != nil {
+			continue
+		}
+		name := manifest.Name
+		if name == "" {
+			name = dir
+		}
+		declared := make(map[string]string)
+		for _, deps := range []map[string]string{manifest.Dependencies, manifest.DevDependencies, manifest.PeerDependencies, manifest.OptionalDependencies} {
+			for dep := range deps {
+				declared[dep] = file
+			}
+		}
+		members = append(members, &member{
+			Package:    Package{Name: name, Path: dir, Kind: "npm"},
+			importPath: name,
+			manifest:   file,
+			declared:   declared,
+		})
+	}
+	return members, sources
+}
+
+// matchesWorkspace reports whether dir is matched by the workspace globs, honouring "!" exclusions
+func matchesWorkspace(dir string, patterns []string) bool {
+	matched := false
+	for _, pattern := range patterns {
+		pattern = strings.TrimSuffix(strings.TrimPrefix(pattern, "./"), "/")
+		if strings.HasPrefix(pattern, "!") {
+			if globMatch(strings.Split(strings.TrimPrefix(strings.TrimPrefix(pattern, "!"), "./"), "/"), strings.Split(dir, "/")) {
+				return false
+			}
+			continue
+		}
+		if globMatch(strings.Split(pattern, "/"), strings.Split(dir, "/")) {
+			matched = true
+		}
+	}
+	return matched
+}
+
+// globMatch matches path segments against pattern segments, where "**" spans any number of segments
+func globMatch(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if globMatch(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+		return false
+	}
+	return globMatch(pattern[1:], segments[1:])
+}
+
+// goMembers returns the go.work modules, or when there is no go.work, the packages of the root module
+func goMembers(files map[string]string) ([]*member, string) {
+	if work, ok := files["go.work"]; ok {
+		var members []*member
+		for _, dir := range goWorkUses(work) {
+			manifest := path.Join(dir, "go.mod")
+			content, ok := files[manifest]
+			if !ok {
+				continue
+			}
+			match := goModuleRegex.FindStringSubmatch(content)
+			if match == nil {
+				continue
+			}
+			declared := make(map[string]string)
+			for _, required := range goRequires(content) {
+				declared[required] = manifest
+			}
+			members = append(members, &member{
+				Package:    Package{Name: match[1], Path: dir, Kind: "go-module"},
+				importPath: match[1],
+				manifest:   manifest,
+				declared:   declared,
+			})
+		}
+		return members, "go.work"
+	}
+
+	content, ok := files["go.mod"]
+	if !ok {
+		return nil, ""
+	}
+	match := goModuleRegex.FindStringSubmatch(content)
+	if match == nil {
+		return nil, ""
+	}
+	modulePath := match[1]
+
+	// Directories holding nested modules are not part of the root module
+	var nestedModules []string
+	for file := range files {
+		if path.Base(file) == "go.mod" && file != "go.mod" {
+			nestedModules = append(nestedModules, path.Dir(file))
+		}
+	}
+
+	dirs := make(map[string]bool)
+	for file := range files {
+		if isGoSource(file) && !insideAny(file, nestedModules) {
+			dirs[path.Dir(file)] = true
+		}
+	}
+	var members []*member
+	for dir := range dirs {
+		importPath := modulePath
+		name := path.Base(modulePath)
+		if dir != "." {
+			importPath = modulePath + "/" + dir
+			name = dir
+		}
+		members = append(members, &member{
+			Package:    Package{Name: name, Path: dir, Kind: "go-package"},
+			importPath: importPath,
+		})
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].Path < members[j].Path })
+	return members, "go.mod"
+}
+
+// goWorkUses lists the module directories of a go.work file
+func goWorkUses(content string) []string {
+	var dirs []string
+	for _, match := range goWorkUseLine.FindAllStringSubmatch(content, -1) {
+		dirs = append(dirs, match[1])
+	}
+	for _, block := range goWorkUseBlock.FindAllStringSubmatch(content, -1) {
+		for _, line := range strings.Split(block[1], "\n") {
+			if fields := strings.Fields(strings.SplitN(line, "//", 2)[0]); len(fields) > 0 {
+				dirs = append(dirs, fields[0])
+			}
+		}
+	}
+	for i, dir := range dirs {
+		dirs[i] = path.Clean(strings.Trim(dir, `"`))
+	}
+	return dirs
+}
+
+// goRequires lists the module paths a go.mod requires
+func goRequires(content string) []string {
+	var modules []string
+	for _, match := range goRequireLineRegex.FindAllStringSubmatch(content, -1) {
+		modules = append(modules, match[1])
+	}
+	for _, block := range goRequireBlock.FindAllStringSubmatch(content, -1) {
+		for _, line := range strings.Split(block[1], "\n") {
+			if fields := strings.Fields(line); len(fields) >= 2 && !strings.HasPrefix(fields[0], "//") {
+				modules = append(modules, fields[0])
+			}
+		}
+	}
+	return modules
+}
+
+// goImports lists the import paths of a Go source file
+func goImports(content string) []string {
+	var imports []string
+	for _, match := range goImportLineRegex.FindAllStringSubmatch(content, -1) {
+		imports = append(imports, match[1])
+	}
+	for _, block := range goImportBlockRegex.FindAllStringSubmatch(content, -1) {
+		for _, quoted := range quotedRegex.FindAllStringSubmatch(block[1], -1) {
+			imports = append(imports, quoted[1])
+		}
+	}
+	return imports
+}
+
+// jsImports lists the bare (non-relative) import specifiers of a JS/TS source file
+func jsImports(content string) []string {
+	var imports []string
+	for _, match := range jsImportRegex.FindAllStringSubmatch(content, -1) {
+		imports = append(imports, match[1])
+	}
+	return imports
+}
+
+func insideAny(file string, dirs []string) bool {
+	for _, dir := range dirs {
+		if strings.HasPrefix(file, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package portable
+
+import "strings"
+
+// Slash converts Windows separators to forward slashes so repository paths compare the same on every OS
+func Slash(path string) string {
+	return strings.ReplaceAll(path, "\\", "/")
+}
+
+// Segments splits a path on either separator, dropping empty and "." segments
+func Segments(path string) []string {
+	var segments []string
+	for _, segment := range strings.Split(Slash(path), "/") {
+		if segment != "" && segment != "." {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+// HasSegment reports whether one of the path's directories or its file name is exactly segment
+func HasSegment(path, segment string) bool {
+	for _, s := range Segments(path) {
+		if s == segment {
+			return true
+		}
+	}
+	return false
+}
+
+// Within reports whether path is dir or lies below it, comparing whole segments so that
+// "db/migrations_old" is not within "db/migrations". An empty or "." dir contains everything.
+func Within(path, dir string) bool {
+	pathSegments, dirSegments := Segments(path), Segments(dir)
+	if len(dirSegments) > len(pathSegments) {
+		return false
+	}
+	for i, segment := range dirSegments {
+		if pathSegments[i] != segment {
+			return false
+		}
+	}
+	return true
+}
+
+// Text converts CRLF and lone CR line endings to LF
+func Text(content string) string {
+	if !strings.Contains(content, "\r") {
+		return content
+	}
+	return strings.ReplaceAll(strings.ReplaceAll(content, "\r\n", "\n"), "\r", "\n")
+}
+
+// Lines splits content into lines whatever its line endings
+func Lines(content string) []string {
+	return strings.Split(Text(content), "\n")
+}
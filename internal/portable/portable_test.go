@@ -0,0 +1,117 @@
+package portable
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSlash(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{`services\user\main.go`, "services/user/main.go"},
+		{`C:\work\repo\db\migrations`, "C:/work/repo/db/migrations"},
+		{`mixed/path\to\file.sql`, "mixed/path/to/file.sql"},
+		{"already/slashed", "already/slashed"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Slash(tt.path); got != tt.want {
+			t.Errorf("Slash(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestSegments(t *testing.T) {
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{`services\user\main.go`, []string{"services", "user", "main.go"}},
+		{`.\db\\migrations\`, []string{"db", "migrations"}},
+		{`C:\repo/cmd\api`, []string{"C:", "repo", "cmd", "api"}},
+		{"./a/./b/", []string{"a", "b"}},
+		{".", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := Segments(tt.path); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Segments(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestHasSegment(t *testing.T) {
+	tests := []struct {
+		path    string
+		segment string
+		want    bool
+	}{
+		{`services\user\main.go`, "services", true},
+		{`services\user\main.go`, "main.go", true},
+		{`src\microservices\user.go`, "services", false},
+		{`cmd-tools\api\main.go`, "cmd", false},
+		{`repo\cmd\api\main.go`, "cmd", true},
+	}
+	for _, tt := range tests {
+		if got := HasSegment(tt.path, tt.segment); got != tt.want {
+			t.Errorf("HasSegment(%q, %q) = %v, want %v", tt.path, tt.segment, got, tt.want)
+		}
+	}
+}
+
+func TestWithin(t *testing.T) {
+	tests := []struct {
+		path string
+		dir  string
+		want bool
+	}{
+		{`db\migrations\001_init.sql`, "db/migrations", true},
+		{"db/migrations/001_init.sql", `db\migrations`, true},
+		{`db\migrations`, `db\migrations\`, true},
+		{`db\migrations_old\001_init.sql`, "db/migrations", false},
+		{`db\001_init.sql`, "db/migrations", false},
+		{`db\migrations\001_init.sql`, "", true},
+		{`db\migrations\001_init.sql`, ".", true},
+	}
+	for _, tt := range tests {
+		if got := Within(tt.path, tt.dir); got != tt.want {
+			t.Errorf("Within(%q, %q) = %v, want %v", tt.path, tt.dir, got, tt.want)
+		}
+	}
+}
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"a\r\nb\r\n", "a\nb\n"},
+		{"a\rb", "a\nb"},
+		{"a\r\n\r\nb", "a\n\nb"},
+		{"a\nb", "a\nb"},
+	}
+	for _, tt := range tests {
+		if got := Text(tt.content); got != tt.want {
+			t.Errorf("Text(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		content string
+		want    []string
+	}{
+		{"CREATE TABLE users (\r\n  id INT\r\n);", []string{"CREATE TABLE users (", "  id INT", ");"}},
+		{"old\rmac\r", []string{"old", "mac", ""}},
+		{"mixed\r\nendings\nhere", []string{"mixed", "endings", "here"}},
+		{"", []string{""}},
+	}
+	for _, tt := range tests {
+		if got := Lines(tt.content); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Lines(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
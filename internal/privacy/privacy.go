@@ -0,0 +1,266 @@
+package privacy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"repo-explanation/config"
+)
+
+// Placeholders written in place of removed content
+const (
+	StringPlaceholder  = "…"
+	CommentPlaceholder = "[comment removed: contact or link]"
+)
+
+var (
+	identifierPattern = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*`)
+	contactPattern    = regexp.MustCompile(`(?i)[a-z0-9._%+-]+@[a-z0-9.-]+\.[a-z]{2,}|[a-z][a-z0-9+.-]*://\S+|\bwww\.[a-z0-9-]+\.[a-z]{2,}`)
+)
+
+// Anonymizer rewrites file contents before they are sent to the LLM: string literals are blanked,
+// comments holding emails or URLs are dropped, and sensitive identifiers are replaced by stable
+// placeholders, so the same name reads the same in every file
+type Anonymizer struct {
+	stripStrings         bool
+	stripContactComments bool
+	identifiers          []*regexp.Regexp
+	fingerprint          string
+}
+
+// New builds the anonymizer of a privacy config; it returns nil when privacy mode is off
+func New(cfg config.PrivacyConfig) (*Anonymizer, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	a := &Anonymizer{stripStrings: cfg.StripStrings, stripContactComments: cfg.StripContactComments}
+	for _, pattern := range cfg.SensitiveIdentifiers {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sensitive identifier pattern %q: %v", pattern, err)
+		}
+		a.identifiers = append(a.identifiers, re)
+	}
+
+	// Summaries of anonymized content must not be reused by runs with other settings
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%t\x00%t\x00%s\x00%s", cfg.StripStrings, cfg.StripContactComments,
+		strings.Join(cfg.SensitiveIdentifiers, "\x00"), strings.Join(cfg.RestrictedPaths, "\x00"))
+	a.fingerprint = hex.EncodeToString(hash.Sum(nil))[:12]
+	return a, nil
+}
+
+// Fingerprint identifies the privacy settings for cache keys
+func (a *Anonymizer) Fingerprint() string {
+	return a.fingerprint
+}
+
+// Anonymize rewrites the content of the file at relativePath; the file's extension decides its
+// comment and string syntax
+func (a *Anonymizer) Anonymize(relativePath, content string) string {
+	if a.stripStrings || a.stripContactComments {
+		content = a.scan(syntaxFor(relativePath), content)
+	}
+	return a.ScrubIdentifiers(content)
+}
+
+// ScrubIdentifiers replaces the sensitive identifiers in text, for derived text such as glossary
+// context that is sent to the LLM without going through Anonymize
+func (a *Anonymizer) ScrubIdentifiers(text string) string {
+	if len(a.identifiers) == 0 {
+		return text
+	}
+	return identifierPattern.ReplaceAllStringFunc(text, func(ident string) string {
+		for _, re := range a.identifiers {
+			if re.MatchString(ident) {
+				return Placeholder(ident)
+			}
+		}
+		return ident
+	})
+}
+
+// Placeholder is the stable stand-in for a sensitive identifier
+func Placeholder(ident string) string {
+	sum := sha256.Sum256([]byte(ident))
+	return "ident_" + hex.EncodeToString(sum[:])[:6]
+}
+
+// syntax describes where a language's comments and strings start
+type syntax struct {
+	lineComments []string // Comment markers running to the end of the line
+	blockComment [2]string
+	quotes       string // Quote characters of escapable strings
+	rawQuote     byte   // Quote character of strings without escapes (Go and JavaScript backticks)
+	tripleQuotes bool   // Python docstrings
+	hashComments bool   // "#" only starts a comment at the start of a line or after whitespace
+}
+
+var (
+	cLike  = syntax{lineComments: []string{"//"}, blockComment: [2]string{"/*", "*/"}, quotes: `"`}
+	script = syntax{lineComments: []string{"#"}, quotes: `"'`, hashComments: true}
+)
+
+var syntaxByExtension = map[string]syntax{
+	".go":    {lineComments: []string{"//"}, blockComment: [2]string{"/*", "*/"}, quotes: `"`, rawQuote: '`'},
+	".js":    {lineComments: []string{"//"}, blockComment: [2]string{"/*", "*/"}, quotes: `"'`, rawQuote: '`'},
+	".jsx":   {lineComments: []string{"//"}, blockComment: [2]string{"/*", "*/"}, quotes: `"'`, rawQuote: '`'},
+	".ts":    {lineComments: []string{"//"}, blockComment: [2]string{"/*", "*/"}, quotes: `"'`, rawQuote: '`'},
+	".tsx":   {lineComments: []string{"//"}, blockComment: [2]string{"/*", "*/"}, quotes: `"'`, rawQuote: '`'},
+	".php":   {lineComments: []string{"//", "#"}, blockComment: [2]string{"/*", "*/"}, quotes: `"'`},
+	".dart":  {lineComments: []string{"//"}, blockComment: [2]string{"/*", "*/"}, quotes: `"'`},
+	".java":  cLike,
+	".kt":    cLike,
+	".scala": cLike,
+	".swift": cLike,
+	".c":     cLike,
+	".h":     cLike,
+	".cpp":   cLike,
+	".hpp":   cLike,
+	".cs":    cLike,
+	".rs":    cLike,
+	".css":   {blockComment: [2]string{"/*", "*/"}, quotes: `"'`},
+	".scss":  {lineComments: []string{"//"}, blockComment: [2]string{"/*", "*/"}, quotes: `"'`},
+	".less":  {lineComments: []string{"//"}, blockComment: [2]string{"/*", "*/"}, quotes: `"'`},
+	".py":    {lineComments: []string{"#"}, quotes: `"'`, tripleQuotes: true, hashComments: true},
+	".rb":    script,
+	".sh":    script,
+	".bash":  script,
+	".zsh":   script,
+	".ps1":   script,
+	".r":     script,
+	".yaml":  script,
+	".yml":   script,
+	".toml":  script,
+	".tf":    {lineComments: []string{"#", "//"}, blockComment: [2]string{"/*", "*/"}, quotes: `"`, hashComments: true},
+	".ini":   {lineComments: []string{"#", ";"}, quotes: `"`, hashComments: true},
+	".cfg":   {lineComments: []string{"#", ";"}, quotes: `"`, hashComments: true},
+	".sql":   {lineComments: []string{"--"}, blockComment: [2]string{"/*", "*/"}, quotes: `'"`},
+	".hs":    {lineComments: []string{"--"}, blockComment: [2]string{"{-", "-}"}, quotes: `"`},
+	".lua":   {lineComments: []string{"--"}, quotes: `"'`},
+	".json":  {quotes: `"`},
+	".html":  {blockComment: [2]string{"<!--", "-->"}, quotes: `"'`},
+	".xml":   {blockComment: [2]string{"<!--", "-->"}, quotes: `"'`},
+}
+
+// syntaxFor picks the syntax of a file by extension, treating Dockerfiles and Makefiles as scripts;
+// unknown files get C-style comments and double-quoted strings
+func syntaxFor(relativePath string) syntax {
+	name := strings.ToLower(path.Base(strings.ReplaceAll(relativePath, `\`, "/")))
+	if s, ok := syntaxByExtension[path.Ext(name)]; ok {
+		return s
+	}
+	if strings.HasPrefix(name, "dockerfile") || name == "makefile" || strings.HasSuffix(name, ".dockerfile") || strings.HasSuffix(name, ".makefile") {
+		return script
+	}
+	return cLike
+}
+
+// scan copies content while blanking strings and dropping comments that name a contact or link
+func (a *Anonymizer) scan(s syntax, content string) string {
+	var out strings.Builder
+	out.Grow(len(content))
+	for i := 0; i < len(content); {
+		rest := content[i:]
+
+		if marker := s.lineCommentAt(content, i); marker != "" {
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			a.writeComment(&out, rest[:end], marker, "")
+			i += end
+			continue
+		}
+		if open := s.blockComment[0]; open != "" && strings.HasPrefix(rest, open) {
+			end := strings.Index(rest[len(open):], s.blockComment[1])
+			if end < 0 {
+				end = len(rest)
+			} else {
+				end += len(open) + len(s.blockComment[1])
+			}
+			a.writeComment(&out, rest[:end], open, s.blockComment[1])
+			i += end
+			continue
+		}
+		if s.tripleQuotes && (strings.HasPrefix(rest, `"""`) || strings.HasPrefix(rest, `'''`)) {
+			end := strings.Index(rest[3:], rest[:3])
+			if end < 0 {
+				end = len(rest)
+			} else {
+				end += 6
+			}
+			a.writeString(&out, rest[:end], rest[:3])
+			i += end
+			continue
+		}
+		c := content[i]
+		if strings.IndexByte(s.quotes, c) >= 0 || (s.rawQuote != 0 && c == s.rawQuote) {
+			end := stringEnd(rest, c == s.rawQuote)
+			a.writeString(&out, rest[:end], string(c))
+			i += end
+			continue
+		}
+		out.WriteByte(c)
+		i++
+	}
+	return out.String()
+}
+
+// lineCommentAt returns the line comment marker starting at content[i], if any
+func (s syntax) lineCommentAt(content string, i int) string {
+	for _, marker := range s.lineComments {
+		if !strings.HasPrefix(content[i:], marker) {
+			continue
+		}
+		// Hashes inside words, such as $# or a URL fragment, do not start comments
+		if marker == "#" && s.hashComments && i > 0 && !strings.ContainsRune(" \t\r\n", rune(content[i-1])) {
+			continue
+		}
+		return marker
+	}
+	return ""
+}
+
+// stringEnd returns the length of the string literal at the start of rest, including its quotes;
+// escapable strings end at the line end when unterminated, so a stray quote cannot swallow the file
+func stringEnd(rest string, raw bool) int {
+	quote := rest[0]
+	for i := 1; i < len(rest); i++ {
+		switch {
+		case rest[i] == '\\' && !raw:
+			i++
+		case rest[i] == quote:
+			return i + 1
+		case rest[i] == '\n' && !raw:
+			return i
+		}
+	}
+	return len(rest)
+}
+
+func (a *Anonymizer) writeString(out *strings.Builder, literal, quote string) {
+	if !a.stripStrings || len(literal) <= 2*len(quote) {
+		out.WriteString(literal)
+		return
+	}
+	out.WriteString(quote + StringPlaceholder)
+	if strings.HasSuffix(literal, quote) {
+		out.WriteString(quote)
+	}
+}
+
+func (a *Anonymizer) writeComment(out *strings.Builder, comment, open, close string) {
+	if !a.stripContactComments || !contactPattern.MatchString(comment) {
+		out.WriteString(comment)
+		return
+	}
+	out.WriteString(open + " " + CommentPlaceholder)
+	if close != "" {
+		out.WriteString(" " + close)
+	}
+}
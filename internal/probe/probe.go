@@ -0,0 +1,272 @@
+package probe
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"repo-explanation/config"
+	"repo-explanation/internal/mermaid"
+	"repo-explanation/internal/microservices"
+	"repo-explanation/internal/pipeline"
+	"repo-explanation/internal/relationships"
+)
+
+// Service statuses
+const (
+	StatusLive      = "live"      // Port open and the health check passed (or there was none to run)
+	StatusUnhealthy = "unhealthy" // Port open but the health check failed
+	StatusDown      = "down"      // Nothing listening on any expected port
+	StatusUnknown   = "unknown"   // No port could be found for the service
+)
+
+// DefaultTimeout bounds each TCP dial and HTTP health request
+const DefaultTimeout = 2 * time.Second
+
+// ServiceStatus is the live state of one discovered service
+type ServiceStatus struct {
+	Service    string   `json:"service"`
+	APIType    string   `json:"api_type,omitempty"`
+	Status     string   `json:"status"`
+	Port       int      `json:"port,omitempty"`     // The port that answered, or the most trusted candidate
+	Source     string   `json:"source,omitempty"`   // Where that port came from
+	Evidence   string   `json:"evidence,omitempty"` // File the port was read from
+	CheckedURL string   `json:"checked_url,omitempty"`
+	HTTPStatus int      `json:"http_status,omitempty"`
+	LatencyMs  int64    `json:"latency_ms,omitempty"`
+	Detail     string   `json:"detail,omitempty"`
+	Targets    []Target `json:"targets,omitempty"` // Every candidate port that was tried
+}
+
+// Report is the result of probing every discovered service
+type Report struct {
+	ProjectPath string          `json:"project_path"`
+	Host        string          `json:"host"`
+	CheckedAt   time.Time       `json:"checked_at"`
+	Services    []ServiceStatus `json:"services"`
+	Live        int             `json:"live"`
+	Unhealthy   int             `json:"unhealthy"`
+	Down        int             `json:"down"`
+	Unknown     int             `json:"unknown"`
+	Mermaid     string          `json:"mermaid"` // Service graph coloured by status
+}
+
+// Prober checks services listening on one host
+type Prober struct {
+	host    string
+	timeout time.Duration
+	client  *http.Client
+}
+
+// NewProber creates a prober for host ("localhost" when empty)
+func NewProber(host string, timeout time.Duration) *Prober {
+	if host == "" {
+		host = "localhost"
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Prober{
+		host:    host,
+		timeout: timeout,
+		client: &http.Client{
+			Timeout: timeout,
+			// A redirect still proves the service is answering
+			CheckRedirect: func(req *http.Request, via []*http.Request) error { return http.ErrUseLastResponse },
+		},
+	}
+}
+
+// Run discovers the services of a local checkout, works out their ports and probes them.
+// Discovery runs offline and skips every phase but services, so probing never spends LLM tokens.
+func Run(ctx context.Context, cfg *config.Config, projectPath string, prober *Prober) (*Report, error) {
+	offline := *cfg
+	offline.Offline = true
+	analyzer, err := pipeline.NewAnalyzer(&offline, projectPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create analyzer: %v", err)
+	}
+	if err := analyzer.SetOptions(pipeline.AnalysisOptions{Phases: []string{pipeline.PhaseServices}}); err != nil {
+		return nil, err
+	}
+	result, err := analyzer.AnalyzeProject(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("service discovery failed: %v", err)
+	}
+
+	targets := DiscoverTargets(projectPath, result.Services)
+	report := prober.Check(ctx, result.Services, result.ServiceRelationships, targets)
+	report.ProjectPath = projectPath
+	return report, nil
+}
+
+// Check probes every service's candidate ports concurrently
+func (p *Prober) Check(ctx context.Context, services []microservices.DiscoveredService, rels []relationships.ServiceRelationship, targets []Target) *Report {
+	byService := make(map[string][]Target)
+	for _, target := range targets {
+		byService[target.Service] = append(byService[target.Service], target)
+	}
+
+	statuses := make([]ServiceStatus, len(services))
+	var wg sync.WaitGroup
+	for i, service := range services {
+		wg.Add(1)
+		go func(i int, service microservices.DiscoveredService) {
+			defer wg.Done()
+			statuses[i] = p.checkService(ctx, service, byService[service.Name])
+		}(i, service)
+	}
+	wg.Wait()
+
+	report := &Report{Host: p.host, CheckedAt: time.Now(), Services: statuses}
+	for _, status := range statuses {
+		switch status.Status {
+		case StatusLive:
+			report.Live++
+		case StatusUnhealthy:
+			report.Unhealthy++
+		case StatusDown:
+			report.Down++
+		default:
+			report.Unknown++
+		}
+	}
+	report.Mermaid = renderMermaid(statuses, rels)
+	return report
+}
+
+// checkService tries the candidates in trust order and reports the first one that answers
+func (p *Prober) checkService(ctx context.Context, service microservices.DiscoveredService, targets []Target) ServiceStatus {
+	status := ServiceStatus{Service: service.Name, APIType: string(service.APIType), Status: StatusUnknown, Targets: targets}
+	if len(targets) == 0 {
+		status.Detail = "no port found in compose files, Makefiles, .env files or code"
+		return status
+	}
+
+	var unhealthy *ServiceStatus
+	for _, target := range targets {
+		result := p.checkTarget(ctx, target)
+		result.Service, result.APIType, result.Targets = status.Service, status.APIType, targets
+		switch result.Status {
+		case StatusLive:
+			return result
+		case StatusUnhealthy:
+			if unhealthy == nil {
+				unhealthy = &result
+			}
+		}
+	}
+	if unhealthy != nil {
+		return *unhealthy
+	}
+
+	status.Status = StatusDown
+	status.Port, status.Source, status.Evidence = targets[0].Port, targets[0].Source, targets[0].Evidence
+	ports := make([]string, len(targets))
+	for i, target := range targets {
+		ports[i] = strconv.Itoa(target.Port)
+	}
+	status.Detail = fmt.Sprintf("nothing listening on %s port %s", p.host, strings.Join(ports, ", "))
+	return status
+}
+
+// checkTarget dials the port and, for HTTP services, requests the health route
+func (p *Prober) checkTarget(ctx context.Context, target Target) ServiceStatus {
+	status := ServiceStatus{Port: target.Port, Source: target.Source, Evidence: target.Evidence, Status: StatusDown}
+	address := net.JoinHostPort(p.host, strconv.Itoa(target.Port))
+
+	start := time.Now()
+	dialer := net.Dialer{Timeout: p.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		status.Detail = err.Error()
+		return status
+	}
+	conn.Close()
+	status.Status = StatusLive
+	status.LatencyMs = time.Since(start).Milliseconds()
+	if !target.HTTP {
+		status.Detail = "TCP port open"
+		return status
+	}
+
+	path := target.HealthPath
+	if path == "" {
+		path = "/"
+	}
+	status.CheckedURL = "http://" + address + path
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, status.CheckedURL, nil)
+	if err != nil {
+		status.Detail = err.Error()
+		return status
+	}
+	start = time.Now()
+	resp, err := p.client.Do(req)
+	if err != nil {
+		// The port is open but does not speak HTTP; it is up, just not checkable
+		status.Detail = fmt.Sprintf("TCP port open, HTTP check failed: %v", err)
+		return status
+	}
+	resp.Body.Close()
+	status.HTTPStatus = resp.StatusCode
+	status.LatencyMs = time.Since(start).Milliseconds()
+
+	// Without a health route any answer counts; a health route has to succeed
+	healthy := resp.StatusCode < 500
+	if target.HealthPath != "" {
+		healthy = resp.StatusCode < 400
+	}
+	if !healthy {
+		status.Status = StatusUnhealthy
+	}
+	status.Detail = fmt.Sprintf("GET %s returned %d", path, resp.StatusCode)
+	return status
+}
+
+// renderMermaid draws the service graph with each node coloured by its status
+func renderMermaid(statuses []ServiceStatus, rels []relationships.ServiceRelationship) string {
+	var b strings.Builder
+	b.WriteString("graph LR\n")
+	ids := make(map[string]string)
+	for i, status := range statuses {
+		id := fmt.Sprintf("svc%d", i)
+		ids[status.Service] = id
+		label := status.Service
+		if status.Port > 0 {
+			label += fmt.Sprintf(" :%d", status.Port)
+		}
+		b.WriteString(fmt.Sprintf("    %s[%s]:::%s\n", id, mermaid.Label(label+"<br/>"+status.Status), status.Status))
+	}
+
+	seen := make(map[string]bool)
+	var edges []string
+	for _, rel := range rels {
+		from, to := ids[rel.From], ids[rel.To]
+		if from == "" || to == "" || from == to || seen[from+">"+to] {
+			continue
+		}
+		seen[from+">"+to] = true
+		edges = append(edges, fmt.Sprintf("    %s --> %s\n", from, to))
+	}
+	sort.Strings(edges)
+	for _, edge := range edges {
+		b.WriteString(edge)
+	}
+
+	b.WriteString("    classDef live fill:#d4edda,stroke:#28a745,color:#155724\n")
+	b.WriteString("    classDef unhealthy fill:#fff3cd,stroke:#ffc107,color:#856404\n")
+	b.WriteString("    classDef down fill:#f8d7da,stroke:#dc3545,color:#721c24\n")
+	b.WriteString("    classDef unknown fill:#e2e3e5,stroke:#6c757d,color:#383d41\n")
+
+	diagram, err := mermaid.Clean(b.String())
+	if err != nil {
+		fmt.Printf("⚠️  Status graph is not valid Mermaid: %v\n", err)
+	}
+	return diagram
+}
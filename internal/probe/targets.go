@@ -0,0 +1,379 @@
+package probe
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"repo-explanation/internal/microservices"
+)
+
+// Port sources, in the order they are trusted
+const (
+	SourceCompose  = "compose"
+	SourceMakefile = "makefile"
+	SourceEnv      = "env"
+	SourceCode     = "code"
+)
+
+// maxScanDepth bounds how deep the project is searched for compose files, Makefiles and handlers
+const maxScanDepth = 5
+
+// maxHandlerFiles bounds how many source files per service are searched for a health route and listen port
+const maxHandlerFiles = 400
+
+// Target is one port a service is expected to listen on locally
+type Target struct {
+	Service    string `json:"service"`
+	Port       int    `json:"port"`
+	Source     string `json:"source"`      // compose, makefile, env or code
+	Evidence   string `json:"evidence"`    // File the port was read from
+	HealthPath string `json:"health_path"` // HTTP path to check, empty for TCP-only checks
+	HTTP       bool   `json:"http"`
+}
+
+var (
+	makePortPattern    = regexp.MustCompile(`(?m)\b(?:[A-Z_]*PORT)\s*[:?+]?=\s*(\d{2,5})\b`)
+	makeFlagPattern    = regexp.MustCompile(`(?:--port[= ]|-p\s+)(?:[\d.]+:)?(\d{2,5})(?::\d+)?\b`)
+	envPortPattern     = regexp.MustCompile(`(?m)^\s*(?:export\s+)?(?:[A-Z_]*_)?PORT\s*=\s*"?(\d{2,5})"?\s*$`)
+	healthRoutePattern = regexp.MustCompile(`["'\x60](/(?:api/)?(?:v\d+/)?(?:healthz?|health-check|healthcheck|_health|readyz|livez|ready|ping|status))["'\x60]`)
+	healthURLPattern   = regexp.MustCompile(`https?://[^/\s"']+(/[^\s"']*)`)
+	listenPattern      = regexp.MustCompile(`(?i)(?:listen(?:andserve(?:tls)?)?|\.start|\.run|serve)\(\s*(?:["'\x60][\w.\-]*:(\d{2,5})["'\x60]|(\d{2,5})\b)`)
+)
+
+// skipDirs are never searched
+var skipDirs = map[string]bool{
+	".git": true, "node_modules": true, "vendor": true, "dist": true, "build": true, ".next": true, "target": true,
+}
+
+// composeFile is the part of a Compose file that says which host ports a service publishes
+type composeFile struct {
+	Services map[string]struct {
+		Build       interface{}   `yaml:"build"`
+		Ports       []interface{} `yaml:"ports"`
+		Healthcheck struct {
+			Test interface{} `yaml:"test"`
+		} `yaml:"healthcheck"`
+	} `yaml:"services"`
+}
+
+// DiscoverTargets works out where each service should be listening when the system runs locally.
+// Published Compose ports win over Makefile and .env ports, which win over ports found in code.
+func DiscoverTargets(projectPath string, services []microservices.DiscoveredService) []Target {
+	found := make(map[string][]Target)
+	add := func(target Target) {
+		for _, existing := range found[target.Service] {
+			if existing.Port == target.Port {
+				return
+			}
+		}
+		found[target.Service] = append(found[target.Service], target)
+	}
+
+	var composeFiles, makefiles, envFiles []string
+	filepath.WalkDir(projectPath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(projectPath, path)
+		if entry.IsDir() {
+			if rel != "." && (skipDirs[entry.Name()] || strings.Count(filepath.ToSlash(rel), "/") >= maxScanDepth) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		name := strings.ToLower(entry.Name())
+		switch {
+		case isComposeFile(name):
+			composeFiles = append(composeFiles, rel)
+		case name == "makefile" || name == "gnumakefile":
+			makefiles = append(makefiles, rel)
+		case name == ".env" || name == ".env.local" || name == ".env.development":
+			envFiles = append(envFiles, rel)
+		}
+		return nil
+	})
+
+	for _, rel := range composeFiles {
+		for _, target := range composeTargets(projectPath, rel, services) {
+			add(target)
+		}
+	}
+	for _, rel := range makefiles {
+		for _, target := range fileTargets(projectPath, rel, services, SourceMakefile, makePortPattern, makeFlagPattern) {
+			add(target)
+		}
+	}
+	for _, rel := range envFiles {
+		for _, target := range fileTargets(projectPath, rel, services, SourceEnv, envPortPattern) {
+			add(target)
+		}
+	}
+	sources := make(map[string]sourceScan)
+	for _, service := range services {
+		scan := scanSource(projectPath, service.Path)
+		sources[service.Name] = scan
+		if port, err := strconv.Atoi(service.Port); err == nil && validPort(port) {
+			add(Target{Service: service.Name, Port: port, Source: SourceCode, Evidence: firstNonEmpty(service.EntryPoint, service.Path)})
+		}
+		for _, listen := range scan.listens {
+			add(Target{Service: service.Name, Port: listen.port, Source: SourceCode, Evidence: listen.file})
+		}
+	}
+
+	var targets []Target
+	for _, service := range services {
+		healthPath := sources[service.Name].healthPath
+		isHTTP := service.APIType != microservices.GRPCService
+		for _, target := range found[service.Name] {
+			if target.HealthPath == "" {
+				target.HealthPath = healthPath
+			}
+			target.HTTP = isHTTP
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
+
+func isComposeFile(name string) bool {
+	if !strings.HasSuffix(name, ".yml") && !strings.HasSuffix(name, ".yaml") {
+		return false
+	}
+	return strings.HasPrefix(name, "docker-compose") || strings.HasPrefix(name, "compose")
+}
+
+// composeTargets reads published ports, matching Compose services to discovered services by name or build context
+func composeTargets(projectPath, rel string, services []microservices.DiscoveredService) []Target {
+	data, err := os.ReadFile(filepath.Join(projectPath, rel))
+	if err != nil {
+		return nil
+	}
+	var compose composeFile
+	if err := yaml.Unmarshal(data, &compose); err != nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(compose.Services))
+	for name := range compose.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var targets []Target
+	for _, name := range names {
+		definition := compose.Services[name]
+		service := matchComposeService(name, buildContext(definition.Build, filepath.Dir(rel)), services)
+		if service == "" {
+			continue
+		}
+		healthPath := composeHealthPath(definition.Healthcheck.Test)
+		for _, entry := range definition.Ports {
+			if port := publishedPort(entry); port > 0 {
+				targets = append(targets, Target{Service: service, Port: port, Source: SourceCompose, Evidence: filepath.ToSlash(rel), HealthPath: healthPath})
+			}
+		}
+	}
+	return targets
+}
+
+func matchComposeService(name, context string, services []microservices.DiscoveredService) string {
+	normalized := normalizeName(name)
+	for _, service := range services {
+		if normalizeName(service.Name) == normalized {
+			return service.Name
+		}
+	}
+	if context != "" {
+		for _, service := range services {
+			if filepath.ToSlash(filepath.Clean(service.Path)) == context {
+				return service.Name
+			}
+		}
+	}
+	return ""
+}
+
+// buildContext returns a Compose build context relative to the project root
+func buildContext(build interface{}, composeDir string) string {
+	var context string
+	switch typed := build.(type) {
+	case string:
+		context = typed
+	case map[string]interface{}:
+		context, _ = typed["context"].(string)
+	}
+	if context == "" {
+		return ""
+	}
+	return filepath.ToSlash(filepath.Clean(filepath.Join(composeDir, context)))
+}
+
+// publishedPort reads the host side of a Compose port mapping in short ("127.0.0.1:8080:80/tcp") or long syntax
+func publishedPort(entry interface{}) int {
+	// A bare container port ("80") is published on a random host port, so it cannot be probed
+	switch typed := entry.(type) {
+	case string:
+		parts := strings.Split(strings.SplitN(typed, "/", 2)[0], ":")
+		if len(parts) < 2 {
+			return 0
+		}
+		hostPort := parts[len(parts)-2]
+		// Ranges publish several ports; the first is enough to tell whether the service is up
+		port, _ := strconv.Atoi(strings.SplitN(hostPort, "-", 2)[0])
+		return validOrZero(port)
+	case map[string]interface{}:
+		switch published := typed["published"].(type) {
+		case int:
+			return validOrZero(published)
+		case string:
+			port, _ := strconv.Atoi(published)
+			return validOrZero(port)
+		}
+	}
+	return 0
+}
+
+// composeHealthPath takes the path of a URL in a Compose healthcheck command
+func composeHealthPath(test interface{}) string {
+	var command string
+	switch typed := test.(type) {
+	case string:
+		command = typed
+	case []interface{}:
+		parts := make([]string, 0, len(typed))
+		for _, part := range typed {
+			if text, ok := part.(string); ok {
+				parts = append(parts, text)
+			}
+		}
+		command = strings.Join(parts, " ")
+	}
+	if match := healthURLPattern.FindStringSubmatch(command); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// fileTargets reads ports from a Makefile or env file. A file inside a service's directory belongs
+// to that service; one at the root only counts when there is a single service.
+func fileTargets(projectPath, rel string, services []microservices.DiscoveredService, source string, patterns ...*regexp.Regexp) []Target {
+	dir := filepath.Dir(rel)
+	service := microservices.OwningService(dir, services)
+	if service == "" && dir == "." && len(services) == 1 {
+		service = services[0].Name
+	}
+	if service == "" {
+		return nil
+	}
+	data, err := os.ReadFile(filepath.Join(projectPath, rel))
+	if err != nil {
+		return nil
+	}
+
+	var targets []Target
+	for _, pattern := range patterns {
+		for _, match := range pattern.FindAllStringSubmatch(string(data), -1) {
+			if port, err := strconv.Atoi(match[1]); err == nil && validPort(port) {
+				targets = append(targets, Target{Service: service, Port: port, Source: source, Evidence: filepath.ToSlash(rel)})
+			}
+		}
+	}
+	return targets
+}
+
+// sourceScan is what a service's own code says about how to reach it
+type sourceScan struct {
+	healthPath string
+	listens    []listenPort
+}
+
+type listenPort struct {
+	port int
+	file string
+}
+
+// scanSource searches a service's source for a registered health or readiness route and hard-coded listen ports
+func scanSource(projectPath, servicePath string) sourceScan {
+	root := filepath.Join(projectPath, servicePath)
+	var scan sourceScan
+	scanned := 0
+	filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return filepath.SkipDir
+		}
+		if scanned >= maxHandlerFiles {
+			return filepath.SkipAll
+		}
+		if entry.IsDir() {
+			if path != root && skipDirs[entry.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".go", ".js", ".ts", ".py", ".java", ".kt", ".rb", ".rs", ".cs", ".php":
+		default:
+			return nil
+		}
+		scanned++
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		content := string(data)
+		for _, match := range healthRoutePattern.FindAllStringSubmatch(content, -1) {
+			if scan.healthPath == "" || healthRank(match[1]) < healthRank(scan.healthPath) {
+				scan.healthPath = match[1]
+			}
+		}
+		rel, _ := filepath.Rel(projectPath, path)
+		for _, match := range listenPattern.FindAllStringSubmatch(content, -1) {
+			port, _ := strconv.Atoi(firstNonEmpty(match[1], match[2]))
+			if validPort(port) {
+				scan.listens = append(scan.listens, listenPort{port: port, file: filepath.ToSlash(rel)})
+			}
+		}
+		return nil
+	})
+	return scan
+}
+
+// healthRank prefers dedicated health routes over generic ones like /status
+func healthRank(path string) int {
+	for i, name := range []string{"healthz", "health", "livez", "readyz", "ready", "ping", "status"} {
+		if strings.Contains(path, name) {
+			return i
+		}
+	}
+	return 99
+}
+
+func normalizeName(name string) string {
+	return strings.NewReplacer("-", "", "_", "", ".", "", " ", "").Replace(strings.ToLower(name))
+}
+
+func validPort(port int) bool {
+	return port > 0 && port <= 65535
+}
+
+func validOrZero(port int) int {
+	if validPort(port) {
+		return port
+	}
+	return 0
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
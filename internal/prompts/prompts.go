@@ -0,0 +1,311 @@
+package prompts
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+//go:embed templates/*.tmpl
+var builtin embed.FS
+
+// Names of the prompts. Each has a user prompt, <name>.tmpl, and a system prompt, <name>.system.tmpl.
+const (
+	File            = "file"             // Per-file summary
+	FileLightweight = "file_lightweight" // Per-file summary of a large file's first lines
+	FileBatch       = "file_batch"       // Several small files summarized in one request
+	Folder          = "folder"           // Folder summary from its files and subfolders
+	Project         = "project"          // Project overview from the folder summaries
+	Repository      = "repository"       // Architecture, layout, stacks and monorepo services
+	Architecture    = "architecture"     // Internal architecture of one service
+	SequenceFlows   = "sequence_flows"   // Names and labels for inter-service request flows
+	Modules         = "modules"          // Names for the feature modules inside one service
+	Glossary        = "glossary"         // Definitions of domain terms
+	Questions       = "questions"        // Onboarding questions and answers
+)
+
+// FileData fills the file and file_lightweight prompts
+type FileData struct {
+	Path    string
+	Content string
+}
+
+// BatchData fills the file_batch prompt
+type BatchData struct {
+	Files []FileData
+}
+
+// FolderData fills the folder prompt; the summaries are JSON
+type FolderData struct {
+	Path          string
+	FileSummaries string
+	Subfolders    string // Empty for folders without analyzed subfolders
+}
+
+// ProjectData fills the project prompt; the summaries and documentation are JSON
+type ProjectData struct {
+	Path            string
+	FolderSummaries string
+	Documentation   string // Empty when the project has no README or docs
+}
+
+// RepositoryData fills the repository prompt; every field is JSON
+type RepositoryData struct {
+	FileSummaries   string
+	FolderSummaries string
+	ImportantFiles  string
+}
+
+// ArchitectureData fills the architecture prompt
+type ArchitectureData struct {
+	Name        string
+	Language    string
+	Path        string
+	Directories []string
+	Heuristic   string // JSON of the heuristic classification
+}
+
+// SequenceFlow is one flow of the sequence_flows prompt
+type SequenceFlow struct {
+	Name  string
+	Steps []string
+}
+
+// SequenceFlowsData fills the sequence_flows prompt
+type SequenceFlowsData struct {
+	Flows []SequenceFlow
+}
+
+// ModuleOutline is one package cluster of the modules prompt
+type ModuleOutline struct {
+	Packages []string
+	Context  []string
+}
+
+// ModulesData fills the modules prompt
+type ModulesData struct {
+	Purpose string
+	Modules []ModuleOutline
+}
+
+// GlossaryTerm is one term of the glossary prompt
+type GlossaryTerm struct {
+	Term    string
+	Kind    string
+	Sources []string
+	Context []string
+}
+
+// GlossaryData fills the glossary prompt
+type GlossaryData struct {
+	Purpose string
+	Terms   []GlossaryTerm
+}
+
+// QuestionsData fills the questions prompt; Context is the project analysis as plain text
+type QuestionsData struct {
+	Context string
+}
+
+// samples exercise every field and loop of a template, so mistakes in an override are reported
+// when it is loaded rather than in the middle of an analysis
+var samples = map[string]interface{}{
+	File:            FileData{Path: "main.go", Content: "package main"},
+	FileLightweight: FileData{Path: "main.go", Content: "package main"},
+	FileBatch:       BatchData{Files: []FileData{{Path: "main.go", Content: "package main"}}},
+	Folder:          FolderData{Path: "cmd", FileSummaries: "{}", Subfolders: "{}"},
+	Project:         ProjectData{Path: "/repo", FolderSummaries: "{}", Documentation: "{}"},
+	Repository:      RepositoryData{FileSummaries: "{}", FolderSummaries: "{}", ImportantFiles: "{}"},
+	Architecture:    ArchitectureData{Name: "api", Language: "Go", Path: "services/api", Directories: []string{"handlers"}, Heuristic: "{}"},
+	SequenceFlows:   SequenceFlowsData{Flows: []SequenceFlow{{Name: "checkout", Steps: []string{"web -> api"}}}},
+	Modules:         ModulesData{Purpose: "A shop", Modules: []ModuleOutline{{Packages: []string{"internal/billing"}, Context: []string{"invoice.go: creates invoices"}}}},
+	Glossary:        GlossaryData{Purpose: "A shop", Terms: []GlossaryTerm{{Term: "order", Kind: "table", Sources: []string{"schema.sql"}, Context: []string{"id, total"}}}},
+	Questions:       QuestionsData{Context: "Project Type: Backend"},
+}
+
+// funcs are available to every template
+var funcs = template.FuncMap{
+	"join": strings.Join,
+	"inc":  func(i int) int { return i + 1 },
+}
+
+// Set holds the prompt templates of a client: the built-in ones, with any overrides from a directory
+type Set struct {
+	templates   map[string]*template.Template
+	overridden  []string
+	fingerprint string
+}
+
+var defaults = mustDefaults()
+
+func mustDefaults() *Set {
+	set := &Set{templates: make(map[string]*template.Template)}
+	for name := range samples {
+		for _, file := range []string{name + ".tmpl", name + ".system.tmpl"} {
+			text, err := builtin.ReadFile("templates/" + file)
+			if err != nil {
+				panic(err)
+			}
+			if err := set.parse(file, string(text)); err != nil {
+				panic(err)
+			}
+		}
+	}
+	return set
+}
+
+// Default returns the built-in prompts
+func Default() *Set {
+	return defaults
+}
+
+// Load returns the built-in prompts with the templates in dir replacing them, file by file; an
+// empty dir returns the built-in prompts. Files must be named after a prompt (file.tmpl,
+// file.system.tmpl, ...); other .tmpl files are rejected so a misspelled name is not ignored.
+func Load(dir string) (*Set, error) {
+	if dir == "" {
+		return defaults, nil
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list prompt templates: %v", err)
+	}
+	if len(paths) == 0 {
+		if _, err := os.Stat(dir); err != nil {
+			return nil, fmt.Errorf("failed to read prompt templates: %v", err)
+		}
+		return defaults, nil
+	}
+	sort.Strings(paths)
+
+	set := &Set{templates: make(map[string]*template.Template, len(defaults.templates))}
+	for file, tmpl := range defaults.templates {
+		set.templates[file] = tmpl
+	}
+	hash := sha256.New()
+	var problems []string
+	for _, path := range paths {
+		file := filepath.Base(path)
+		if _, known := samples[name(file)]; !known {
+			problems = append(problems, fmt.Sprintf("%s: unknown prompt (known: %s)", file, strings.Join(Names(), ", ")))
+			continue
+		}
+		text, err := os.ReadFile(path)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", file, err))
+			continue
+		}
+		if err := set.parse(file, string(text)); err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		set.overridden = append(set.overridden, file)
+		fmt.Fprintf(hash, "%s\x00%s\x00", file, text)
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid prompt templates in %s:\n  - %s", dir, strings.Join(problems, "\n  - "))
+	}
+	set.fingerprint = hex.EncodeToString(hash.Sum(nil))[:12]
+	return set, nil
+}
+
+// parse compiles one template file and checks it renders its sample data
+func (s *Set) parse(file, text string) error {
+	// Editors end files with a newline the prompt should not carry
+	text = strings.TrimSuffix(strings.TrimSuffix(text, "\n"), "\r")
+	tmpl, err := template.New(file).Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return err
+	}
+	var data interface{}
+	if !strings.HasSuffix(file, ".system.tmpl") {
+		data = samples[name(file)]
+	}
+	if err := tmpl.Execute(&strings.Builder{}, data); err != nil {
+		return err
+	}
+	s.templates[file] = tmpl
+	return nil
+}
+
+// name strips the template file extensions from a file name
+func name(file string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(file, ".tmpl"), ".system")
+}
+
+// Names lists the prompts a directory may override
+func Names() []string {
+	names := make([]string, 0, len(samples))
+	for name := range samples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// User renders the user prompt of name with data
+func (s *Set) User(name string, data interface{}) (string, error) {
+	return s.render(name+".tmpl", data)
+}
+
+// System renders the system prompt of name
+func (s *Set) System(name string) (string, error) {
+	return s.render(name+".system.tmpl", nil)
+}
+
+func (s *Set) render(file string, data interface{}) (string, error) {
+	tmpl, ok := s.templates[file]
+	if !ok {
+		return "", fmt.Errorf("unknown prompt template %s", file)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt template %s: %v", file, err)
+	}
+	return b.String(), nil
+}
+
+// Export copies the built-in templates into dir as a starting point for overrides. Files that
+// already exist are left alone; the written ones are returned.
+func Export(dir string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %v", dir, err)
+	}
+	entries, err := builtin.ReadDir("templates")
+	if err != nil {
+		return nil, err
+	}
+	var written []string
+	for _, entry := range entries {
+		target := filepath.Join(dir, entry.Name())
+		if _, err := os.Stat(target); err == nil {
+			continue
+		}
+		text, err := builtin.ReadFile("templates/" + entry.Name())
+		if err != nil {
+			return written, err
+		}
+		if err := os.WriteFile(target, text, 0644); err != nil {
+			return written, fmt.Errorf("failed to write %s: %v", target, err)
+		}
+		written = append(written, entry.Name())
+	}
+	return written, nil
+}
+
+// Overridden lists the template files that replaced built-in prompts
+func (s *Set) Overridden() []string {
+	return s.overridden
+}
+
+// Fingerprint identifies the overrides, so results summarized with other prompts are not reused;
+// it is empty for the built-in prompts
+func (s *Set) Fingerprint() string {
+	return s.fingerprint
+}
@@ -0,0 +1,195 @@
+package publish
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strings"
+
+	"repo-explanation/config"
+	"repo-explanation/internal/report"
+)
+
+// Confluence publishes reports as pages of a Confluence space through the REST API, which Cloud
+// and Data Center share
+type Confluence struct {
+	config config.ConfluenceConfig
+	client *http.Client
+}
+
+// confluencePage is the part of a content response the publisher reads
+type confluencePage struct {
+	ID      string `json:"id"`
+	Version struct {
+		Number int `json:"number"`
+	} `json:"version"`
+	Links struct {
+		Base  string `json:"base"`
+		WebUI string `json:"webui"`
+	} `json:"_links"`
+}
+
+// Publish writes the page in storage format, then uploads the diagrams, replacing attachments
+// of the same name
+func (c *Confluence) Publish(ctx context.Context, doc *report.Document) (string, error) {
+	existing, err := c.find(ctx, doc.Title)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up the page: %v", err)
+	}
+
+	body := map[string]interface{}{
+		"type":  "page",
+		"title": doc.Title,
+		"space": map[string]string{"key": c.config.SpaceKey},
+		"body": map[string]interface{}{
+			"storage": map[string]string{"value": confluenceStorage(doc), "representation": "storage"},
+		},
+	}
+	var page confluencePage
+	if existing == nil {
+		if c.config.ParentID != "" {
+			body["ancestors"] = []map[string]string{{"id": c.config.ParentID}}
+		}
+		err = doJSON(ctx, c.client, http.MethodPost, c.api("/content"), c.headers(), body, &page)
+	} else {
+		body["id"] = existing.ID
+		body["version"] = map[string]interface{}{"number": existing.Version.Number + 1, "message": "Re-published onboarding report"}
+		err = doJSON(ctx, c.client, http.MethodPut, c.api("/content/"+existing.ID), c.headers(), body, &page)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to save the page: %v", err)
+	}
+
+	for _, attachment := range doc.Attachments {
+		if err := c.attach(ctx, page.ID, attachment); err != nil {
+			return "", fmt.Errorf("failed to upload %s: %v", attachment.Name, err)
+		}
+	}
+
+	if page.Links.WebUI != "" {
+		base := page.Links.Base
+		if base == "" {
+			base = strings.TrimSuffix(c.config.BaseURL, "/")
+		}
+		return base + page.Links.WebUI, nil
+	}
+	return strings.TrimSuffix(c.config.BaseURL, "/") + "/pages/viewpage.action?pageId=" + page.ID, nil
+}
+
+// find returns the space's page with the title, or nil when there is none
+func (c *Confluence) find(ctx context.Context, title string) (*confluencePage, error) {
+	query := url.Values{}
+	query.Set("spaceKey", c.config.SpaceKey)
+	query.Set("title", title)
+	query.Set("type", "page")
+	query.Set("expand", "version")
+	var found struct {
+		Results []confluencePage `json:"results"`
+	}
+	if err := doJSON(ctx, c.client, http.MethodGet, c.api("/content?"+query.Encode()), c.headers(), nil, &found); err != nil {
+		return nil, err
+	}
+	if len(found.Results) == 0 {
+		return nil, nil
+	}
+	return &found.Results[0], nil
+}
+
+// attach creates or updates an attachment of the page
+func (c *Confluence) attach(ctx context.Context, pageID string, attachment report.Attachment) error {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename=%q`, attachment.Name))
+	header.Set("Content-Type", attachment.ContentType)
+	part, err := form.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	part.Write(attachment.Data)
+	form.WriteField("minorEdit", "true")
+	form.Close()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.api("/content/"+pageID+"/child/attachment"), &body)
+	if err != nil {
+		return err
+	}
+	for key, value := range c.headers() {
+		req.Header.Set(key, value)
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	// Confluence refuses multipart uploads without this header as a CSRF precaution
+	req.Header.Set("X-Atlassian-Token", "no-check")
+	return send(c.client, req, nil)
+}
+
+func (c *Confluence) api(path string) string {
+	return strings.TrimSuffix(c.config.BaseURL, "/") + "/rest/api" + path
+}
+
+// headers authenticate with the API token: basic auth with the account email on Cloud, a
+// bearer personal access token on Data Center
+func (c *Confluence) headers() map[string]string {
+	if c.config.Email != "" {
+		credentials := base64.StdEncoding.EncodeToString([]byte(c.config.Email + ":" + c.config.APIToken))
+		return map[string]string{"Authorization": "Basic " + credentials}
+	}
+	return map[string]string{"Authorization": "Bearer " + c.config.APIToken}
+}
+
+func (c *Confluence) String() string {
+	return "Confluence space " + c.config.SpaceKey + " at " + c.config.BaseURL
+}
+
+// confluenceStorage renders a report in Confluence storage format. Diagrams go in code macros,
+// followed by a link to their attachment.
+func confluenceStorage(doc *report.Document) string {
+	var b strings.Builder
+	for i, block := range doc.Blocks {
+		switch block.Kind {
+		case report.Heading:
+			// The page title already shows the top heading
+			if i == 0 && block.Level == 1 {
+				continue
+			}
+			fmt.Fprintf(&b, "<h%d>%s</h%d>", block.Level, html.EscapeString(block.Text), block.Level)
+		case report.Paragraph:
+			b.WriteString("<p>" + html.EscapeString(block.Text) + "</p>")
+		case report.List:
+			b.WriteString("<ul>")
+			for _, item := range block.Items {
+				b.WriteString("<li>" + html.EscapeString(item) + "</li>")
+			}
+			b.WriteString("</ul>")
+		case report.Code:
+			b.WriteString(`<ac:structured-macro ac:name="code">`)
+			// A CDATA section cannot contain its own terminator, so it is split around any occurrence
+			code := strings.ReplaceAll(block.Text, "]]>", "]]]]><![CDATA[>")
+			b.WriteString(`<ac:plain-text-body><![CDATA[` + code + `]]></ac:plain-text-body></ac:structured-macro>`)
+			if block.Attachment != "" {
+				b.WriteString(`<p>Source: <ac:link><ri:attachment ri:filename="` + html.EscapeString(block.Attachment) + `"/></ac:link></p>`)
+			}
+		case report.Table:
+			b.WriteString("<table><tbody>")
+			for r, row := range block.Rows {
+				cell := "td"
+				if r == 0 {
+					cell = "th"
+				}
+				b.WriteString("<tr>")
+				for _, text := range row {
+					b.WriteString("<" + cell + ">" + html.EscapeString(text) + "</" + cell + ">")
+				}
+				b.WriteString("</tr>")
+			}
+			b.WriteString("</tbody></table>")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,310 @@
+package publish
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/time/rate"
+	"repo-explanation/config"
+	"repo-explanation/internal/report"
+)
+
+const (
+	notionAPI     = "https://api.notion.com/v1"
+	notionVersion = "2022-06-28"
+	// Notion caps each text object at 2000 characters and each append at 100 blocks
+	notionTextLimit  = 2000
+	notionBlockLimit = 100
+)
+
+// Notion publishes reports as child pages of a Notion page. The API has no Markdown import, so
+// the report is written as native blocks: Mermaid diagrams render in code blocks, and every
+// diagram is uploaded as a file block below its code.
+type Notion struct {
+	config  config.NotionConfig
+	client  *http.Client
+	limiter *rate.Limiter
+	api     string
+}
+
+func newNotion(cfg config.NotionConfig, client *http.Client) *Notion {
+	// Notion allows an average of three requests per second per integration
+	return &Notion{config: cfg, client: client, limiter: rate.NewLimiter(3, 3), api: notionAPI}
+}
+
+// notionBlock is a block object as the API takes it
+type notionBlock map[string]interface{}
+
+// Publish finds the page under the parent by title, empties it or creates it, then appends the report
+func (n *Notion) Publish(ctx context.Context, doc *report.Document) (string, error) {
+	pageID, err := n.find(ctx, doc.Title)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up the page: %v", err)
+	}
+	if pageID == "" {
+		var page struct {
+			ID string `json:"id"`
+		}
+		err := n.do(ctx, http.MethodPost, "/pages", map[string]interface{}{
+			"parent":     map[string]string{"page_id": n.config.ParentPageID},
+			"properties": map[string]interface{}{"title": map[string]interface{}{"title": richText(doc.Title)}},
+		}, &page)
+		if err != nil {
+			return "", fmt.Errorf("failed to create the page: %v", err)
+		}
+		pageID = page.ID
+	} else if err := n.clear(ctx, pageID); err != nil {
+		return "", fmt.Errorf("failed to clear the page: %v", err)
+	}
+
+	blocks, err := n.blocks(ctx, doc)
+	if err != nil {
+		return "", err
+	}
+	for start := 0; start < len(blocks); start += notionBlockLimit {
+		end := start + notionBlockLimit
+		if end > len(blocks) {
+			end = len(blocks)
+		}
+		if err := n.do(ctx, http.MethodPatch, "/blocks/"+pageID+"/children", map[string]interface{}{"children": blocks[start:end]}, nil); err != nil {
+			return "", fmt.Errorf("failed to write the page: %v", err)
+		}
+	}
+
+	var page struct {
+		URL string `json:"url"`
+	}
+	if err := n.do(ctx, http.MethodGet, "/pages/"+pageID, nil, &page); err != nil {
+		return "", fmt.Errorf("failed to read the page: %v", err)
+	}
+	return page.URL, nil
+}
+
+// notionChildren is one page of a block's children
+type notionChildren struct {
+	Results []struct {
+		ID        string `json:"id"`
+		Type      string `json:"type"`
+		ChildPage struct {
+			Title string `json:"title"`
+		} `json:"child_page"`
+	} `json:"results"`
+	HasMore    bool   `json:"has_more"`
+	NextCursor string `json:"next_cursor"`
+}
+
+// children calls visit for every child block of a block, following pagination
+func (n *Notion) children(ctx context.Context, blockID string, visit func(id, kind, title string)) error {
+	cursor := ""
+	for {
+		path := "/blocks/" + blockID + "/children?page_size=100"
+		if cursor != "" {
+			path += "&start_cursor=" + cursor
+		}
+		var page notionChildren
+		if err := n.do(ctx, http.MethodGet, path, nil, &page); err != nil {
+			return err
+		}
+		for _, child := range page.Results {
+			visit(child.ID, child.Type, child.ChildPage.Title)
+		}
+		if !page.HasMore {
+			return nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// find returns the ID of the parent's child page with the title, or "" when there is none
+func (n *Notion) find(ctx context.Context, title string) (string, error) {
+	found := ""
+	err := n.children(ctx, n.config.ParentPageID, func(id, kind, childTitle string) {
+		if found == "" && kind == "child_page" && childTitle == title {
+			found = id
+		}
+	})
+	return found, err
+}
+
+// clear deletes the page's blocks, so re-publishing replaces the report rather than appending to it
+func (n *Notion) clear(ctx context.Context, pageID string) error {
+	var ids []string
+	if err := n.children(ctx, pageID, func(id, kind, title string) {
+		// Pages people added below the report are kept
+		if kind != "child_page" && kind != "child_database" {
+			ids = append(ids, id)
+		}
+	}); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := n.do(ctx, http.MethodDelete, "/blocks/"+id, nil, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// blocks converts the report to Notion blocks, uploading the attachments on the way
+func (n *Notion) blocks(ctx context.Context, doc *report.Document) ([]notionBlock, error) {
+	attachments := make(map[string]report.Attachment, len(doc.Attachments))
+	for _, attachment := range doc.Attachments {
+		attachments[attachment.Name] = attachment
+	}
+
+	var blocks []notionBlock
+	for i, block := range doc.Blocks {
+		switch block.Kind {
+		case report.Heading:
+			// The page title already shows the top heading
+			if i == 0 && block.Level == 1 {
+				continue
+			}
+			kind := fmt.Sprintf("heading_%d", block.Level)
+			blocks = append(blocks, notionBlock{"type": kind, kind: map[string]interface{}{"rich_text": richText(block.Text)}})
+		case report.Paragraph:
+			blocks = append(blocks, notionBlock{"type": "paragraph", "paragraph": map[string]interface{}{"rich_text": richText(block.Text)}})
+		case report.List:
+			for _, item := range block.Items {
+				blocks = append(blocks, notionBlock{"type": "bulleted_list_item", "bulleted_list_item": map[string]interface{}{"rich_text": richText(item)}})
+			}
+		case report.Code:
+			language := "plain text"
+			if block.Language == "mermaid" {
+				language = "mermaid"
+			}
+			blocks = append(blocks, notionBlock{"type": "code", "code": map[string]interface{}{"rich_text": richText(block.Text), "language": language}})
+			if attachment, ok := attachments[block.Attachment]; ok {
+				uploadID, err := n.upload(ctx, attachment)
+				if err != nil {
+					return nil, fmt.Errorf("failed to upload %s: %v", attachment.Name, err)
+				}
+				blocks = append(blocks, notionBlock{"type": "file", "file": map[string]interface{}{
+					"type":        "file_upload",
+					"file_upload": map[string]string{"id": uploadID},
+					"name":        attachment.Name,
+				}})
+			}
+		case report.Table:
+			width := len(block.Rows[0])
+			rows := make([]notionBlock, 0, len(block.Rows))
+			for _, row := range block.Rows {
+				cells := make([]interface{}, width)
+				for c := range cells {
+					text := ""
+					if c < len(row) {
+						text = row[c]
+					}
+					cells[c] = richText(text)
+				}
+				rows = append(rows, notionBlock{"type": "table_row", "table_row": map[string]interface{}{"cells": cells}})
+			}
+			// A table is created with its rows, within the same 100-block limit, so long tables are
+			// split into several that each repeat the header
+			header, body := rows[0], rows[1:]
+			for start := 0; start == 0 || start < len(body); start += notionBlockLimit - 1 {
+				end := start + notionBlockLimit - 1
+				if end > len(body) {
+					end = len(body)
+				}
+				chunk := append([]notionBlock{header}, body[start:end]...)
+				blocks = append(blocks, notionBlock{"type": "table", "table": map[string]interface{}{
+					"table_width":       width,
+					"has_column_header": true,
+					"children":          chunk,
+				}})
+			}
+		}
+	}
+	return blocks, nil
+}
+
+// upload sends an attachment through the file upload API and returns its ID
+func (n *Notion) upload(ctx context.Context, attachment report.Attachment) (string, error) {
+	// Notion only accepts common file types and checks the extension, so diagram sources are sent as text files
+	filename := attachment.Name + ".txt"
+	var created struct {
+		ID string `json:"id"`
+	}
+	if err := n.do(ctx, http.MethodPost, "/file_uploads", map[string]string{
+		"filename":     filename,
+		"content_type": "text/plain",
+	}, &created); err != nil {
+		return "", err
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename=%q`, filename))
+	header.Set("Content-Type", "text/plain")
+	part, err := form.CreatePart(header)
+	if err != nil {
+		return "", err
+	}
+	part.Write(attachment.Data)
+	form.Close()
+
+	if err := n.limiter.Wait(ctx); err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.api+"/file_uploads/"+created.ID+"/send", &body)
+	if err != nil {
+		return "", err
+	}
+	for key, value := range n.headers() {
+		req.Header.Set(key, value)
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	if err := send(n.client, req, nil); err != nil {
+		return "", err
+	}
+	return created.ID, nil
+}
+
+// do sends one JSON request within the rate limit
+func (n *Notion) do(ctx context.Context, method, path string, in, out interface{}) error {
+	if err := n.limiter.Wait(ctx); err != nil {
+		return err
+	}
+	return doJSON(ctx, n.client, method, n.api+path, n.headers(), in, out)
+}
+
+func (n *Notion) headers() map[string]string {
+	return map[string]string{
+		"Authorization":  "Bearer " + n.config.Token,
+		"Notion-Version": notionVersion,
+	}
+}
+
+func (n *Notion) String() string {
+	return "Notion page " + n.config.ParentPageID
+}
+
+// richText splits text into text objects within Notion's length limit
+func richText(text string) []map[string]interface{} {
+	parts := []map[string]interface{}{}
+	for text != "" {
+		cut := len(text)
+		if utf8.RuneCountInString(text) > notionTextLimit {
+			cut = 0
+			for i := 0; i < notionTextLimit; i++ {
+				_, size := utf8.DecodeRuneInString(text[cut:])
+				cut += size
+			}
+			// Prefer ending a part at a line break
+			if newline := strings.LastIndexByte(text[:cut], '\n'); newline > cut/2 {
+				cut = newline + 1
+			}
+		}
+		parts = append(parts, map[string]interface{}{"type": "text", "text": map[string]string{"content": text[:cut]}})
+		text = text[cut:]
+	}
+	return parts
+}
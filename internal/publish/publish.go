@@ -0,0 +1,132 @@
+// Package publish pushes onboarding reports to wikis: Confluence pages in storage format and
+// Notion pages built from blocks, with the diagrams uploaded as attachments. Each repository has
+// one page, found by title, which re-publishing updates in place.
+package publish
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"repo-explanation/config"
+	"repo-explanation/internal/report"
+)
+
+// Targets lists the wikis reports can be published to
+var Targets = []string{"confluence", "notion"}
+
+// requestTimeout bounds one API request
+const requestTimeout = 30 * time.Second
+
+// Publisher publishes a report to one wiki
+type Publisher interface {
+	// Publish creates the report's page, or updates it when one with the same title exists, and
+	// returns its URL
+	Publish(ctx context.Context, doc *report.Document) (string, error)
+	String() string // Destination, for logs
+}
+
+// New returns the publisher of target, checking that its settings are complete
+func New(target string, cfg config.PublishConfig) (Publisher, error) {
+	client := &http.Client{Timeout: requestTimeout}
+	var missing []string
+	require := func(value, key string) {
+		if strings.TrimSpace(value) == "" {
+			missing = append(missing, "publish."+target+"."+key)
+		}
+	}
+
+	var publisher Publisher
+	switch target {
+	case "confluence":
+		c := cfg.Confluence
+		require(c.BaseURL, "base_url")
+		require(c.APIToken, "api_token")
+		require(c.SpaceKey, "space_key")
+		publisher = &Confluence{config: c, client: client}
+	case "notion":
+		n := cfg.Notion
+		require(n.Token, "token")
+		require(n.ParentPageID, "parent_page_id")
+		publisher = newNotion(n, client)
+	default:
+		return nil, fmt.Errorf("unknown publish target %q (available: %s)", target, strings.Join(Targets, ", "))
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("publishing to %s needs %s in the config", target, strings.Join(missing, ", "))
+	}
+	return publisher, nil
+}
+
+// Title names a repository's page from the configured title
+func Title(pattern, repository string) string {
+	return strings.ReplaceAll(pattern, "{repo}", repository)
+}
+
+// APIError is a response outside 2xx, with the body the API explained it in
+type APIError struct {
+	Method string
+	URL    string
+	Status int
+	Body   string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s %s: HTTP %d: %s", e.Method, e.URL, e.Status, e.Body)
+}
+
+// doJSON sends a request with an optional JSON body and decodes a JSON response into out
+func doJSON(ctx context.Context, client *http.Client, method, url string, headers map[string]string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %v", err)
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	return send(client, req, out)
+}
+
+// send performs a request, turning non-2xx responses into APIErrors
+func send(client *http.Client, req *http.Request, out interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 10*1024*1024))
+	if err != nil {
+		return fmt.Errorf("failed to read response: %v", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		text := strings.TrimSpace(string(data))
+		if len(text) > 500 {
+			text = text[:500] + "..."
+		}
+		return &APIError{Method: req.Method, URL: req.URL.Redacted(), Status: resp.StatusCode, Body: text}
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode response of %s %s: %v", req.Method, req.URL.Redacted(), err)
+	}
+	return nil
+}
@@ -0,0 +1,339 @@
+// Package qaeval scores the onboarding questions the analyzer generates for a repository against
+// question and answer pairs maintainers expect, so changes to the questions prompt can be measured
+// rather than eyeballed.
+package qaeval
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"repo-explanation/config"
+	"repo-explanation/internal/console"
+	internalOpenai "repo-explanation/internal/openai"
+	"repo-explanation/internal/pipeline"
+	"repo-explanation/internal/snapshots"
+)
+
+// DefaultDir holds the suites run when none is named, relative to the repository root
+const DefaultDir = "internal/qaeval/suites"
+
+// Similarity measures
+const (
+	SimilarityEmbedding = "embedding" // Cosine similarity of embeddings
+	SimilarityLexical   = "lexical"   // Cosine similarity of word counts, for gateways without an embeddings endpoint
+)
+
+// defaultMinSimilarity is the similarity an answer must reach by default. Embeddings of texts on the
+// same topic rarely score below 0.7, while differently worded answers share few words.
+var defaultMinSimilarity = map[string]float64{
+	SimilarityEmbedding: 0.8,
+	SimilarityLexical:   0.3,
+}
+
+// Suite is a YAML file of the questions and answers expected for one repository
+type Suite struct {
+	Name           string  `yaml:"name"`            // Defaults to the file name
+	Fixture        string  `yaml:"fixture"`         // Embedded snapshot fixture to analyze
+	Path           string  `yaml:"path"`            // Or a repository directory, relative to the suite file
+	Similarity     string  `yaml:"similarity"`      // "embedding" (default) or "lexical"
+	EmbeddingModel string  `yaml:"embedding_model"` // Defaults to text-embedding-3-small
+	MinSimilarity  float64 `yaml:"min_similarity"`  // Defaults to 0.8 for embeddings and 0.3 for lexical similarity
+	Cases          []Case  `yaml:"cases"`
+
+	file string
+}
+
+// Case is one expected question. Its answer is compared with the answer to the generated question
+// closest to it, which must also contain every keyword.
+type Case struct {
+	Question      string   `yaml:"question"`
+	Answer        string   `yaml:"answer"`         // Reference answer; optional when keywords are given
+	Keywords      []string `yaml:"keywords"`       // Case-insensitive; "a|b" accepts either
+	MinSimilarity float64  `yaml:"min_similarity"` // Overrides the suite's
+}
+
+// CaseResult is the outcome of one case
+type CaseResult struct {
+	Question           string   `json:"question"`
+	Matched            string   `json:"matched,omitempty"` // Generated question taken to ask the same thing
+	Answer             string   `json:"answer,omitempty"`  // Its answer
+	QuestionSimilarity float64  `json:"question_similarity"`
+	Similarity         float64  `json:"similarity"`     // Of the answer to the reference answer
+	MinSimilarity      float64  `json:"min_similarity"` // 0 when the case has no reference answer
+	MissingKeywords    []string `json:"missing_keywords,omitempty"`
+	KeywordRecall      float64  `json:"keyword_recall"`
+	Score              float64  `json:"score"` // Mean of the similarity and the keyword recall
+	Passed             bool     `json:"passed"`
+}
+
+// Report is the outcome of a suite
+type Report struct {
+	Suite      string                     `json:"suite"`
+	Similarity string                     `json:"similarity"`
+	Generated  []pipeline.HelpfulQuestion `json:"generated"`
+	Cases      []CaseResult               `json:"cases"`
+	Score      float64                    `json:"score"` // Mean case score, comparable across prompt versions
+	Passed     int                        `json:"passed"`
+	Failed     int                        `json:"failed"`
+	Duration   time.Duration              `json:"duration"`
+}
+
+// OK reports whether every case passed
+func (r *Report) OK() bool {
+	return r.Failed == 0
+}
+
+// Load reads a suite file, or every .yaml and .yml suite in a directory
+func Load(path string) ([]*Suite, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{path}
+	if info.IsDir() {
+		files = nil
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if ext := filepath.Ext(entry.Name()); !entry.IsDir() && (ext == ".yaml" || ext == ".yml") {
+				files = append(files, filepath.Join(path, entry.Name()))
+			}
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("no .yaml suites in %s", path)
+		}
+	}
+
+	var suites []*Suite
+	for _, file := range files {
+		suite, err := loadSuite(file)
+		if err != nil {
+			return nil, err
+		}
+		suites = append(suites, suite)
+	}
+	return suites, nil
+}
+
+// loadSuite parses and validates one suite file, filling in its defaults
+func loadSuite(file string) (*Suite, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var suite Suite
+	if err := yaml.Unmarshal(content, &suite); err != nil {
+		return nil, fmt.Errorf("invalid suite %s: %v", file, err)
+	}
+	suite.file = file
+	if suite.Name == "" {
+		suite.Name = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	}
+	if suite.Similarity == "" {
+		suite.Similarity = SimilarityEmbedding
+	}
+	if _, ok := defaultMinSimilarity[suite.Similarity]; !ok {
+		return nil, fmt.Errorf("suite %s: unknown similarity %q (use %s or %s)", file, suite.Similarity, SimilarityEmbedding, SimilarityLexical)
+	}
+	if suite.MinSimilarity == 0 {
+		suite.MinSimilarity = defaultMinSimilarity[suite.Similarity]
+	}
+	if (suite.Fixture == "") == (suite.Path == "") {
+		return nil, fmt.Errorf("suite %s: set exactly one of fixture and path", file)
+	}
+	if len(suite.Cases) == 0 {
+		return nil, fmt.Errorf("suite %s has no cases", file)
+	}
+	for i, c := range suite.Cases {
+		if strings.TrimSpace(c.Question) == "" {
+			return nil, fmt.Errorf("suite %s: case %d has no question", file, i+1)
+		}
+		if strings.TrimSpace(c.Answer) == "" && len(c.Keywords) == 0 {
+			return nil, fmt.Errorf("suite %s: case %d needs an answer or keywords", file, i+1)
+		}
+	}
+	return &suite, nil
+}
+
+// Run analyzes the suite's repository, has the LLM write its onboarding questions and scores them
+// against the cases. The analysis uses cfg, so its prompt overrides and cache apply; its results
+// are written to a temporary directory.
+func Run(ctx context.Context, cfg *config.Config, suite *Suite, out *console.Console) (*Report, error) {
+	if cfg.Offline {
+		return nil, fmt.Errorf("the evaluation scores answers written by the LLM and cannot run offline")
+	}
+	started := time.Now()
+
+	workDir, err := os.MkdirTemp("", "qaeval-"+suite.Name+"-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create work directory: %v", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	repoDir := filepath.Join(filepath.Dir(suite.file), suite.Path)
+	if suite.Fixture != "" {
+		repoDir = filepath.Join(workDir, suite.Fixture)
+		if err := snapshots.WriteFixture(suite.Fixture, repoDir); err != nil {
+			return nil, err
+		}
+	}
+
+	runCfg := *cfg
+	runCfg.Output.OutputDirectory = filepath.Join(workDir, "output")
+	runCfg.Output.SaveIntermediateResults = false
+	analyzer, err := pipeline.NewAnalyzer(&runCfg, repoDir)
+	if err != nil {
+		return nil, err
+	}
+	analyzer.EnableIncremental()
+	// Everything the questions prompt describes, without the questions phase and its generic fallback
+	if err := analyzer.SetOptions(pipeline.AnalysisOptions{
+		Phases: []string{pipeline.PhaseFiles, pipeline.PhaseFolders, pipeline.PhaseProject, pipeline.PhaseServices, pipeline.PhaseSchema},
+	}); err != nil {
+		return nil, err
+	}
+	result, err := out.Analyze(ctx, analyzer)
+	if err != nil {
+		return nil, fmt.Errorf("analysis of %s failed: %v", suite.Name, err)
+	}
+
+	var generated []pipeline.HelpfulQuestion
+	err = out.Run(func() error {
+		var err error
+		generated, err = analyzer.GenerateQuestions(ctx, result)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("question generation failed: %v", err)
+	}
+	if len(generated) == 0 {
+		return nil, fmt.Errorf("the LLM wrote no questions")
+	}
+
+	report, err := score(ctx, internalOpenai.NewClient(&runCfg), suite, generated)
+	if err != nil {
+		return nil, err
+	}
+	report.Duration = time.Since(started).Round(time.Millisecond)
+	return report, nil
+}
+
+// score matches every case to a different generated question, closest questions first, and scores
+// the answers
+func score(ctx context.Context, client *internalOpenai.Client, suite *Suite, generated []pipeline.HelpfulQuestion) (*Report, error) {
+	// Texts are laid out as case questions, case answers, generated questions, generated answers
+	var texts []string
+	for _, c := range suite.Cases {
+		texts = append(texts, c.Question)
+	}
+	for _, c := range suite.Cases {
+		texts = append(texts, c.Answer)
+	}
+	for _, q := range generated {
+		texts = append(texts, q.Question)
+	}
+	for _, q := range generated {
+		texts = append(texts, q.Answer)
+	}
+	similarity, err := suite.similarity(ctx, client, texts)
+	if err != nil {
+		return nil, err
+	}
+	cases, questions := len(suite.Cases), len(generated)
+	caseAnswer := func(i int) int { return cases + i }
+	generatedQuestion := func(j int) int { return 2*cases + j }
+	generatedAnswer := func(j int) int { return 2*cases + questions + j }
+
+	type pair struct {
+		c, q       int
+		similarity float64
+	}
+	var pairs []pair
+	for i := range suite.Cases {
+		for j := range generated {
+			pairs = append(pairs, pair{i, j, similarity(i, generatedQuestion(j))})
+		}
+	}
+	sort.SliceStable(pairs, func(a, b int) bool { return pairs[a].similarity > pairs[b].similarity })
+	match := make(map[int]pair)
+	taken := make(map[int]bool)
+	for _, p := range pairs {
+		if _, done := match[p.c]; !done && !taken[p.q] {
+			match[p.c] = p
+			taken[p.q] = true
+		}
+	}
+
+	report := &Report{Suite: suite.Name, Similarity: suite.Similarity, Generated: generated}
+	for i, c := range suite.Cases {
+		hasAnswer := strings.TrimSpace(c.Answer) != ""
+		result := CaseResult{Question: c.Question}
+		if hasAnswer {
+			result.MinSimilarity = c.MinSimilarity
+			if result.MinSimilarity == 0 {
+				result.MinSimilarity = suite.MinSimilarity
+			}
+		}
+		if p, ok := match[i]; ok {
+			result.Matched = generated[p.q].Question
+			result.Answer = generated[p.q].Answer
+			result.QuestionSimilarity = round(p.similarity)
+			if hasAnswer {
+				result.Similarity = round(similarity(caseAnswer(i), generatedAnswer(p.q)))
+			}
+		}
+		result.MissingKeywords, result.KeywordRecall = checkKeywords(c.Keywords, result.Answer)
+
+		switch {
+		case hasAnswer && len(c.Keywords) > 0:
+			result.Score = (result.Similarity + result.KeywordRecall) / 2
+		case hasAnswer:
+			result.Score = result.Similarity
+		default:
+			result.Score = result.KeywordRecall
+		}
+		result.Score = round(result.Score)
+		result.Passed = result.Matched != "" && len(result.MissingKeywords) == 0 && (!hasAnswer || result.Similarity >= result.MinSimilarity)
+
+		if result.Passed {
+			report.Passed++
+		} else {
+			report.Failed++
+		}
+		report.Score += result.Score
+		report.Cases = append(report.Cases, result)
+	}
+	report.Score = round(report.Score / float64(cases))
+	return report, nil
+}
+
+// checkKeywords returns the keywords an answer lacks and the share it contains
+func checkKeywords(keywords []string, answer string) ([]string, float64) {
+	if len(keywords) == 0 {
+		return nil, 1
+	}
+	lower := strings.ToLower(answer)
+	var missing []string
+	for _, keyword := range keywords {
+		found := false
+		for _, alternative := range strings.Split(keyword, "|") {
+			if alternative = strings.ToLower(strings.TrimSpace(alternative)); alternative != "" && strings.Contains(lower, alternative) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, keyword)
+		}
+	}
+	return missing, round(float64(len(keywords)-len(missing)) / float64(len(keywords)))
+}
@@ -0,0 +1,102 @@
+package qaeval
+
+import (
+	"context"
+	"math"
+	"strings"
+	"unicode"
+
+	internalOpenai "repo-explanation/internal/openai"
+)
+
+// stopWords carry no topic and are left out of lexical similarity
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "by": true,
+	"can": true, "do": true, "does": true, "for": true, "from": true, "how": true, "i": true,
+	"in": true, "is": true, "it": true, "its": true, "of": true, "on": true, "or": true, "the": true,
+	"this": true, "to": true, "what": true, "where": true, "which": true, "with": true, "you": true,
+}
+
+// similarity returns the pairwise similarity, between 0 and 1, of texts under the suite's measure.
+// Embeddings of all texts are requested at once; empty texts are similar to nothing.
+func (s *Suite) similarity(ctx context.Context, client *internalOpenai.Client, texts []string) (func(i, j int) float64, error) {
+	if s.Similarity == SimilarityLexical {
+		counts := make([]map[string]float64, len(texts))
+		for i, text := range texts {
+			counts[i] = wordCounts(text)
+		}
+		return func(i, j int) float64 { return cosineCounts(counts[i], counts[j]) }, nil
+	}
+
+	// The embeddings API rejects empty inputs
+	var inputs []string
+	index := make([]int, len(texts))
+	for i, text := range texts {
+		index[i] = -1
+		if strings.TrimSpace(text) != "" {
+			index[i] = len(inputs)
+			inputs = append(inputs, text)
+		}
+	}
+	vectors, err := client.Embed(ctx, s.EmbeddingModel, inputs)
+	if err != nil {
+		return nil, err
+	}
+	return func(i, j int) float64 {
+		if index[i] < 0 || index[j] < 0 {
+			return 0
+		}
+		return cosine(vectors[index[i]], vectors[index[j]])
+	}, nil
+}
+
+// cosine is the cosine similarity of two embeddings, clamped at 0
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return math.Max(0, dot/math.Sqrt(normA*normB))
+}
+
+// wordCounts counts the lowercased words of a text, without stop words
+func wordCounts(text string) map[string]float64 {
+	counts := make(map[string]float64)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if !stopWords[word] {
+			counts[word]++
+		}
+	}
+	return counts
+}
+
+// cosineCounts is the cosine similarity of two word counts
+func cosineCounts(a, b map[string]float64) float64 {
+	var dot, normA, normB float64
+	for word, count := range a {
+		dot += count * b[word]
+		normA += count * count
+	}
+	for _, count := range b {
+		normB += count * count
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(normA*normB)
+}
+
+// round keeps two decimals, as scores are reported
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
 	"sort"
@@ -11,7 +12,11 @@ import (
 	"time"
 
 	"gopkg.in/yaml.v2"
+	"repo-explanation/internal/atrest"
+	"repo-explanation/internal/filestore"
+	"repo-explanation/internal/mermaid"
 	"repo-explanation/internal/microservices"
+	"repo-explanation/internal/portable"
 )
 
 // EvidenceType represents the type of evidence for a service relationship
@@ -21,16 +26,22 @@ const (
 	ConfigEvidence  EvidenceType = "config"
 	ImportEvidence  EvidenceType = "import"
 	NetworkEvidence EvidenceType = "network"
+
+	// Cross-repository evidence produced by workspace analysis
+	APISpecEvidence        EvidenceType = "api_spec"
+	ClientLibraryEvidence  EvidenceType = "client_library"
+	SharedDatabaseEvidence EvidenceType = "shared_database"
 )
 
 // ServiceRelationship represents a dependency between two services
 type ServiceRelationship struct {
-	From         string        `json:"from"`           // Source service name
-	To           string        `json:"to"`             // Target service name
-	EvidenceType EvidenceType  `json:"evidence_type"`  // Type of evidence
-	Evidence     string        `json:"evidence"`       // Specific evidence found
-	FilePath     string        `json:"file_path"`      // File where evidence was found
-	Confidence   float64       `json:"confidence"`     // Confidence level (0.0-1.0)
+	From         string         `json:"from"`              // Source service name
+	To           string         `json:"to"`                // Target service name
+	EvidenceType EvidenceType   `json:"evidence_type"`     // Type of evidence
+	Evidence     string         `json:"evidence"`          // Specific evidence found
+	FilePath     string         `json:"file_path"`         // File where evidence was found
+	Confidence   float64        `json:"confidence"`        // Confidence level (0.0-1.0)
+	Sources      []EvidenceItem `json:"sources,omitempty"` // Every piece of evidence merged into this edge, strongest first
 }
 
 // ServiceGraph represents the complete service dependency graph
@@ -51,11 +62,11 @@ type MermaidOutput struct {
 type RelationshipDiscovery struct {
 	services    []microservices.DiscoveredService
 	serviceMap  map[string]microservices.DiscoveredService // name -> service mapping
-	fileContent map[string]string                          // file path -> content
+	files       *filestore.FileStore                       // Read on demand, one file at a time
 }
 
 // NewRelationshipDiscovery creates a new relationship discovery instance
-func NewRelationshipDiscovery(services []microservices.DiscoveredService, fileContent map[string]string) *RelationshipDiscovery {
+func NewRelationshipDiscovery(services []microservices.DiscoveredService, files *filestore.FileStore) *RelationshipDiscovery {
 	serviceMap := make(map[string]microservices.DiscoveredService)
 	for _, service := range services {
 		serviceMap[service.Name] = service
@@ -67,7 +78,7 @@ func NewRelationshipDiscovery(services []microservices.DiscoveredService, fileCo
 	return &RelationshipDiscovery{
 		services:    services,
 		serviceMap:  serviceMap,
-		fileContent: fileContent,
+		files:       files,
 	}
 }
 
@@ -87,11 +98,11 @@ func (rd *RelationshipDiscovery) DiscoverRelationships(projectPath string) (*Ser
 	networkRels := rd.discoverNetworkRelationships()
 	relationships = append(relationships, networkRels...)
 
-	// Deduplicate relationships
-	relationships = rd.deduplicateRelationships(relationships)
+	// Merge evidence for the same pair of services and resolve edges pointing both ways
+	relationships = MergeEvidence(relationships)
 
 	// Generate Mermaid graph
-	mermaidGraph := rd.generateMermaidGraph(relationships)
+	mermaidGraph := generateMermaidGraph(rd.services, relationships)
 
 	return &ServiceGraph{
 		Services:      rd.services,
@@ -106,8 +117,12 @@ func (rd *RelationshipDiscovery) DiscoverRelationships(projectPath string) (*Ser
 func (rd *RelationshipDiscovery) discoverConfigRelationships() []ServiceRelationship {
 	var relationships []ServiceRelationship
 
-	for filePath, content := range rd.fileContent {
-		fileName := strings.ToLower(filepath.Base(filePath))
+	isConfig := func(filePath string) bool {
+		fileName := strings.ToLower(path.Base(portable.Slash(filePath)))
+		return strings.HasSuffix(fileName, ".yaml") || strings.HasSuffix(fileName, ".yml") || rd.looksLikeConfigFile(fileName)
+	}
+	rd.files.Walk(isConfig, func(filePath, content string) error {
+		fileName := strings.ToLower(path.Base(portable.Slash(filePath)))
 
 		// Parse Docker Compose files
 		if fileName == "docker-compose.yml" || fileName == "docker-compose.yaml" {
@@ -128,7 +143,8 @@ func (rd *RelationshipDiscovery) discoverConfigRelationships() []ServiceRelation
 			rels := rd.parseConfigFile(filePath, content)
 			relationships = append(relationships, rels...)
 		}
-	}
+		return nil
+	})
 
 	return relationships
 }
@@ -137,22 +153,15 @@ func (rd *RelationshipDiscovery) discoverConfigRelationships() []ServiceRelation
 func (rd *RelationshipDiscovery) discoverImportRelationships() []ServiceRelationship {
 	var relationships []ServiceRelationship
 
-	for filePath, content := range rd.fileContent {
-		// Only analyze Go files for now
-		if !strings.HasSuffix(filePath, ".go") {
-			continue
-		}
-
-		// Determine which service this file belongs to
-		serviceOwner := rd.getServiceOwnerFromPath(filePath)
-		if serviceOwner == "" {
-			continue
-		}
-
-		// Parse imports
-		rels := rd.parseGoImports(filePath, content, serviceOwner)
-		relationships = append(relationships, rels...)
+	// Only Go files inside a service are analyzed for now
+	owned := func(filePath string) bool {
+		return strings.HasSuffix(filePath, ".go") && rd.getServiceOwnerFromPath(filePath) != ""
 	}
+	rd.files.Walk(owned, func(filePath, content string) error {
+		rels := rd.parseGoImports(filePath, content, rd.getServiceOwnerFromPath(filePath))
+		relationships = append(relationships, rels...)
+		return nil
+	})
 
 	return relationships
 }
@@ -161,21 +170,15 @@ func (rd *RelationshipDiscovery) discoverImportRelationships() []ServiceRelation
 func (rd *RelationshipDiscovery) discoverNetworkRelationships() []ServiceRelationship {
 	var relationships []ServiceRelationship
 
-	for filePath, content := range rd.fileContent {
-		// Only analyze code files
-		if !rd.isCodeFile(filePath) {
-			continue
-		}
-
-		serviceOwner := rd.getServiceOwnerFromPath(filePath)
-		if serviceOwner == "" {
-			continue
-		}
-
-		// Parse network calls
-		rels := rd.parseNetworkCalls(filePath, content, serviceOwner)
-		relationships = append(relationships, rels...)
+	// Only code files inside a service are analyzed
+	owned := func(filePath string) bool {
+		return rd.isCodeFile(filePath) && rd.getServiceOwnerFromPath(filePath) != ""
 	}
+	rd.files.Walk(owned, func(filePath, content string) error {
+		rels := rd.parseNetworkCalls(filePath, content, rd.getServiceOwnerFromPath(filePath))
+		relationships = append(relationships, rels...)
+		return nil
+	})
 
 	return relationships
 }
@@ -476,8 +479,8 @@ func (rd *RelationshipDiscovery) parseEnvString(envStr, serviceOwner, filePath s
 
 func (rd *RelationshipDiscovery) getServiceOwnerFromPath(filePath string) string {
 	// Check if path contains cmd/{service-name}
-	if strings.Contains(filePath, "/cmd/") {
-		parts := strings.Split(filePath, "/")
+	if portable.HasSegment(filePath, "cmd") {
+		parts := portable.Segments(filePath)
 		for i, part := range parts {
 			if part == "cmd" && i+1 < len(parts) {
 				serviceName := parts[i+1]
@@ -494,8 +497,8 @@ func (rd *RelationshipDiscovery) getServiceOwnerFromPath(filePath string) string
 	}
 
 	// Check if path contains services/{service-name}
-	if strings.Contains(filePath, "/services/") {
-		parts := strings.Split(filePath, "/")
+	if portable.HasSegment(filePath, "services") {
+		parts := portable.Segments(filePath)
 		for i, part := range parts {
 			if part == "services" && i+1 < len(parts) {
 				serviceName := parts[i+1]
@@ -634,21 +637,6 @@ func (rd *RelationshipDiscovery) isIPAddress(host string) bool {
 	return false
 }
 
-func (rd *RelationshipDiscovery) deduplicateRelationships(relationships []ServiceRelationship) []ServiceRelationship {
-	seen := make(map[string]bool)
-	var unique []ServiceRelationship
-
-	for _, rel := range relationships {
-		key := fmt.Sprintf("%s->%s:%s", rel.From, rel.To, rel.EvidenceType)
-		if !seen[key] {
-			seen[key] = true
-			unique = append(unique, rel)
-		}
-	}
-
-	return unique
-}
-
 // ConsoleVisualization creates an ASCII visualization of the service graph
 func (sg *ServiceGraph) ConsoleVisualization() string {
 	if len(sg.Services) == 0 {
@@ -694,7 +682,10 @@ func (sg *ServiceGraph) ConsoleVisualization() string {
 				
 				result.WriteString(fmt.Sprintf("  │\n"))
 				result.WriteString(fmt.Sprintf("  ├─%s─► %s\n", icon, strings.ToUpper(rel.To)))
-				result.WriteString(fmt.Sprintf("  │     %s (%.1f)\n", rel.Evidence, rel.Confidence))
+				result.WriteString(fmt.Sprintf("  │     %s (%.2f)\n", rel.Evidence, rel.Confidence))
+				if len(rel.Sources) > 1 {
+					result.WriteString(fmt.Sprintf("  │     corroborated by %d more pieces of evidence\n", len(rel.Sources)-1))
+				}
 			}
 		} else {
 			result.WriteString("  │\n")
@@ -734,39 +725,29 @@ func (sg *ServiceGraph) ConsoleVisualization() string {
 	return result.String()
 }
 
-// generateMermaidGraph creates a Mermaid.js graph from service relationships
-func (rd *RelationshipDiscovery) generateMermaidGraph(relationships []ServiceRelationship) string {
-	var mermaid strings.Builder
-	
-	// Start with graph definition
-	mermaid.WriteString("graph TD\\n")
+// generateMermaidGraph creates a Mermaid.js graph from service relationships; edges below
+// LowConfidence are dashed
+func generateMermaidGraph(services []microservices.DiscoveredService, relationships []ServiceRelationship) string {
+	graph := mermaid.NewFlowchart("TD")
 	
-	// Add service nodes with styling
-	serviceSet := make(map[string]bool)
-	for _, service := range rd.services {
-		serviceName := rd.sanitizeServiceName(service.Name)
-		serviceSet[serviceName] = true
-		
-		// Add service node with API type styling
+	// Add service nodes with API type styling
+	for _, service := range services {
 		switch service.APIType {
 		case microservices.HTTPService:
-			mermaid.WriteString(fmt.Sprintf("  %s[%s - HTTP]\\n", serviceName, service.Name))
+			graph.Node(service.Name, service.Name+" - HTTP", mermaid.Rectangle)
 		case microservices.GRPCService:
-			mermaid.WriteString(fmt.Sprintf("  %s{%s - gRPC}\\n", serviceName, service.Name))
+			graph.Node(service.Name, service.Name+" - gRPC", mermaid.Rhombus)
 		case microservices.GraphQLService:
-			mermaid.WriteString(fmt.Sprintf("  %s(%s - GraphQL)\\n", serviceName, service.Name))
+			graph.Node(service.Name, service.Name+" - GraphQL", mermaid.Rounded)
 		default:
-			mermaid.WriteString(fmt.Sprintf("  %s[%s]\\n", serviceName, service.Name))
+			graph.Node(service.Name, service.Name, mermaid.Rectangle)
 		}
 	}
 	
 	// Add relationships/edges
 	if len(relationships) > 0 {
-		mermaid.WriteString("\\n")
+		graph.Blank()
 		for _, rel := range relationships {
-			fromService := rd.sanitizeServiceName(rel.From)
-			toService := rd.sanitizeServiceName(rel.To)
-			
 			// Determine edge label based on evidence type
 			var edgeLabel string
 			switch rel.EvidenceType {
@@ -783,28 +764,25 @@ func (rd *RelationshipDiscovery) generateMermaidGraph(relationships []ServiceRel
 			default:
 				edgeLabel = "depends"
 			}
-			
-			// Add edge with label
-			mermaid.WriteString(fmt.Sprintf("  %s -->|%s| %s\\n", fromService, edgeLabel, toService))
+			arrow := mermaid.Solid
+			if rel.Confidence < LowConfidence {
+				arrow = mermaid.Dotted
+			}
+			graph.Edge(rel.From, rel.To, arrow, edgeLabel)
 		}
 	}
 	
 	// Add styling for better visualization
-	mermaid.WriteString("\\n")
-	mermaid.WriteString("  classDef httpService fill:#e1f5fe,stroke:#01579b,stroke-width:2px\\n")
-	mermaid.WriteString("  classDef grpcService fill:#f3e5f5,stroke:#4a148c,stroke-width:2px\\n")
-	mermaid.WriteString("  classDef graphqlService fill:#e8f5e8,stroke:#1b5e20,stroke-width:2px\\n")
+	graph.Blank()
+	graph.ClassDef("httpService", "fill:#e1f5fe,stroke:#01579b,stroke-width:2px")
+	graph.ClassDef("grpcService", "fill:#f3e5f5,stroke:#4a148c,stroke-width:2px")
+	graph.ClassDef("graphqlService", "fill:#e8f5e8,stroke:#1b5e20,stroke-width:2px")
 	
-	return mermaid.String()
-}
-
-// sanitizeServiceName creates a valid Mermaid node identifier
-func (rd *RelationshipDiscovery) sanitizeServiceName(serviceName string) string {
-	// Replace hyphens and special characters with underscores
-	sanitized := strings.ReplaceAll(serviceName, "-", "_")
-	sanitized = strings.ReplaceAll(sanitized, ".", "_")
-	sanitized = strings.ReplaceAll(sanitized, " ", "_")
-	return sanitized
+	diagram, err := mermaid.Clean(graph.String())
+	if err != nil {
+		fmt.Printf("⚠️  Service graph is not valid Mermaid: %v\n", err)
+	}
+	return diagram
 }
 
 // GenerateMermaidJSON creates the JSON output format for Mermaid graphs
@@ -821,8 +799,8 @@ func (sg *ServiceGraph) GenerateMermaidJSON() (string, error) {
 	return string(jsonBytes), nil
 }
 
-// SaveToFile saves the service graph to a cache file
-func (sg *ServiceGraph) SaveToFile(cacheDir string) error {
+// SaveToFile saves the service graph to a cache file, encrypted when sealer is set
+func (sg *ServiceGraph) SaveToFile(cacheDir string, sealer *atrest.Sealer) error {
 	// Create cache directory if it doesn't exist
 	if err := os.MkdirAll(cacheDir, 0755); err != nil {
 		return fmt.Errorf("failed to create cache directory: %v", err)
@@ -839,15 +817,15 @@ func (sg *ServiceGraph) SaveToFile(cacheDir string) error {
 	}
 	
 	// Write to file
-	if err := os.WriteFile(filePath, jsonData, 0644); err != nil {
+	if err := sealer.WriteFile(filePath, jsonData, 0644); err != nil {
 		return fmt.Errorf("failed to write cache file: %v", err)
 	}
 	
 	return nil
 }
 
-// LoadFromFile loads a service graph from a cache file if it exists and is recent
-func LoadServiceGraphFromFile(projectPath, cacheDir string) (*ServiceGraph, error) {
+// LoadFromFile loads a service graph from a cache file if it exists and is younger than maxAge
+func LoadServiceGraphFromFile(projectPath, cacheDir string, maxAge time.Duration, sealer *atrest.Sealer) (*ServiceGraph, error) {
 	filename := generateCacheFilename(projectPath)
 	filePath := filepath.Join(cacheDir, filename)
 	
@@ -857,7 +835,7 @@ func LoadServiceGraphFromFile(projectPath, cacheDir string) (*ServiceGraph, erro
 	}
 	
 	// Read file
-	jsonData, err := os.ReadFile(filePath)
+	jsonData, err := sealer.ReadFile(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read cache file: %v", err)
 	}
@@ -868,8 +846,8 @@ func LoadServiceGraphFromFile(projectPath, cacheDir string) (*ServiceGraph, erro
 		return nil, fmt.Errorf("failed to unmarshal service graph: %v", err)
 	}
 	
-	// Check if cache is recent (less than 24 hours old)
-	if time.Since(serviceGraph.GeneratedAt) > 24*time.Hour {
+	// Check if cache is recent (younger than limits.relationship_cache_hours)
+	if time.Since(serviceGraph.GeneratedAt) > maxAge {
 		return nil, nil // Cache is stale, regenerate
 	}
 	
@@ -896,3 +874,8 @@ func generateCacheFilename(projectPath string) string {
 func (rd *RelationshipDiscovery) generateCacheFilename(projectPath string) string {
 	return generateCacheFilename(projectPath)
 }
+
+// MermaidGraph renders services and their relationships as the Mermaid flowchart of a service graph
+func MermaidGraph(services []microservices.DiscoveredService, relationships []ServiceRelationship) string {
+	return generateMermaidGraph(services, relationships)
+}
@@ -0,0 +1,34 @@
+package relationships
+
+import (
+	"testing"
+
+	"repo-explanation/internal/microservices"
+)
+
+func TestGetServiceOwnerFromPathWindows(t *testing.T) {
+	rd := NewRelationshipDiscovery([]microservices.DiscoveredService{
+		{Name: "user"},
+		{Name: "payment"},
+		{Name: "order-api"},
+	}, nil)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{`services\user\handlers\profile.go`, "user"},
+		{`C:\work\repo\services\user\main.go`, "user"},
+		{`cmd\payment-service\main.go`, "payment"},
+		{`cmd\order-api\main.go`, "order-api"},
+		{`services/user/handlers/profile.go`, "user"},
+		{`internal\microservices\user\client.go`, ""},
+		{`services\unknown\main.go`, ""},
+		{`cmd\`, ""},
+	}
+	for _, tt := range tests {
+		if got := rd.getServiceOwnerFromPath(tt.path); got != tt.want {
+			t.Errorf("getServiceOwnerFromPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package relationships
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LowConfidence is the confidence below which an edge is drawn dashed: a guess from a hostname or
+// a config value rather than an explicit depends_on or import
+const LowConfidence = 0.85
+
+// EvidenceTypes lists every evidence type a relationship can carry
+var EvidenceTypes = []EvidenceType{
+	ConfigEvidence, ImportEvidence, NetworkEvidence, APISpecEvidence, ClientLibraryEvidence, SharedDatabaseEvidence,
+}
+
+// Filter keeps the relationships that are confident enough and backed by the wanted evidence
+type Filter struct {
+	MinConfidence float64        // Relationships below this confidence are dropped; 0 keeps all
+	Evidence      []EvidenceType // Evidence types to keep; empty keeps all
+}
+
+// ParseEvidenceTypes turns names such as "config,import" into evidence types, rejecting unknown names
+func ParseEvidenceTypes(names []string) ([]EvidenceType, error) {
+	var types []EvidenceType
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		known := false
+		for _, evidenceType := range EvidenceTypes {
+			if EvidenceType(name) == evidenceType {
+				known = true
+				break
+			}
+		}
+		if !known {
+			valid := make([]string, len(EvidenceTypes))
+			for i, evidenceType := range EvidenceTypes {
+				valid[i] = string(evidenceType)
+			}
+			return nil, fmt.Errorf("unknown evidence type %q (valid types: %s)", name, strings.Join(valid, ","))
+		}
+		types = append(types, EvidenceType(name))
+	}
+	return types, nil
+}
+
+// Active reports whether the filter drops anything
+func (f Filter) Active() bool {
+	return f.MinConfidence > 0 || len(f.Evidence) > 0
+}
+
+// String describes the filter, e.g. "confidence >= 0.9, evidence config,import"
+func (f Filter) String() string {
+	var parts []string
+	if f.MinConfidence > 0 {
+		parts = append(parts, fmt.Sprintf("confidence >= %g", f.MinConfidence))
+	}
+	if len(f.Evidence) > 0 {
+		names := make([]string, len(f.Evidence))
+		for i, evidenceType := range f.Evidence {
+			names[i] = string(evidenceType)
+		}
+		parts = append(parts, "evidence "+strings.Join(names, ","))
+	}
+	if len(parts) == 0 {
+		return "all relationships"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Keep reports whether a relationship passes the filter
+func (f Filter) Keep(rel ServiceRelationship) bool {
+	if rel.Confidence < f.MinConfidence {
+		return false
+	}
+	if len(f.Evidence) == 0 {
+		return true
+	}
+	// A merged edge passes when any of its evidence has a wanted type
+	for _, item := range rel.items() {
+		for _, evidenceType := range f.Evidence {
+			if item.EvidenceType == evidenceType {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Apply returns the relationships that pass the filter
+func (f Filter) Apply(relationships []ServiceRelationship) []ServiceRelationship {
+	if !f.Active() {
+		return relationships
+	}
+	kept := make([]ServiceRelationship, 0, len(relationships))
+	for _, rel := range relationships {
+		if f.Keep(rel) {
+			kept = append(kept, rel)
+		}
+	}
+	return kept
+}
+
+// Filtered returns a copy of the graph holding only the relationships that pass the filter, with
+// the Mermaid graph redrawn to match
+func (sg *ServiceGraph) Filtered(f Filter) *ServiceGraph {
+	if !f.Active() {
+		return sg
+	}
+	filtered := *sg
+	filtered.Relationships = f.Apply(sg.Relationships)
+	filtered.MermaidGraph = generateMermaidGraph(sg.Services, filtered.Relationships)
+	return &filtered
+}
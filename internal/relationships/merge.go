@@ -0,0 +1,207 @@
+package relationships
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// EvidenceItem is one piece of evidence behind a relationship
+type EvidenceItem struct {
+	EvidenceType EvidenceType `json:"evidence_type"`
+	Evidence     string       `json:"evidence"`
+	FilePath     string       `json:"file_path"`
+	Confidence   float64      `json:"confidence"`
+}
+
+// maxCorroboratedConfidence caps confidence raised by corroboration; only a single explicit
+// declaration such as depends_on reaches 1.0
+const maxCorroboratedConfidence = 0.99
+
+// MergeEvidence combines every piece of evidence for the same pair of services into one edge.
+// Independent evidence (another evidence type or another file) raises the edge's confidence, and
+// where edges point both ways the direction backed only by ambiguous evidence is folded into the
+// other. Merging already merged relationships returns them unchanged.
+func MergeEvidence(rels []ServiceRelationship) []ServiceRelationship {
+	type pair struct{ from, to string }
+	items := make(map[pair][]EvidenceItem)
+	for _, rel := range rels {
+		if rel.From == "" || rel.To == "" || rel.From == rel.To {
+			continue
+		}
+		key := pair{rel.From, rel.To}
+		for _, item := range rel.items() {
+			if !containsItem(items[key], item) {
+				items[key] = append(items[key], item)
+			}
+		}
+	}
+
+	keys := make([]pair, 0, len(items))
+	for key := range items {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].from != keys[j].from {
+			return keys[i].from < keys[j].from
+		}
+		return keys[i].to < keys[j].to
+	})
+
+	// The losing side of a direction conflict only shows that the services are connected, which
+	// corroborates the edge that is kept
+	dropped := make(map[pair]bool)
+	for _, key := range keys {
+		reverse := pair{key.to, key.from}
+		if key.from > key.to || items[reverse] == nil {
+			continue
+		}
+		winner, loser := key, reverse
+		switch resolveDirection(items[key], items[reverse]) {
+		case 0:
+			continue
+		case 1:
+			winner, loser = reverse, key
+		}
+		dropped[loser] = true
+		items[winner] = append(items[winner], items[loser]...)
+		fmt.Printf("↔️  Resolved %s <-> %s as %s -> %s\n", key.from, key.to, winner.from, winner.to)
+	}
+
+	merged := make([]ServiceRelationship, 0, len(keys))
+	for _, key := range keys {
+		if dropped[key] {
+			continue
+		}
+		merged = append(merged, mergeItems(key.from, key.to, items[key]))
+	}
+	return merged
+}
+
+// resolveDirection decides between A->B (forward) and B->A (backward) evidence: 1 drops the
+// forward edge, 2 the backward one and 0 keeps both. Directional evidence wins over evidence that
+// only shows the services know each other; without any, the better corroborated side wins, and
+// two directional sides are a genuine mutual dependency.
+func resolveDirection(forward, backward []EvidenceItem) int {
+	forwardDirectional, backwardDirectional := anyDirectional(forward), anyDirectional(backward)
+	switch {
+	case forwardDirectional && !backwardDirectional:
+		return 2
+	case backwardDirectional && !forwardDirectional:
+		return 1
+	case forwardDirectional && backwardDirectional:
+		return 0
+	}
+	forwardConfidence, backwardConfidence := combinedConfidence(forward), combinedConfidence(backward)
+	switch {
+	case forwardConfidence > backwardConfidence:
+		return 2
+	case backwardConfidence > forwardConfidence:
+		return 1
+	}
+	return 0
+}
+
+// directional reports whether evidence shows which service makes the call: code in the caller
+// (imports, HTTP and gRPC clients, client libraries), a Compose depends_on or environment block, or
+// an oriented API spec. A service URL in a config file only shows that the two services are
+// connected, since shared .env files and callback settings name both sides.
+func directional(item EvidenceItem) bool {
+	switch item.EvidenceType {
+	case ImportEvidence, NetworkEvidence, ClientLibraryEvidence:
+		return true
+	case ConfigEvidence, APISpecEvidence:
+		return item.Confidence >= LowConfidence
+	}
+	return false
+}
+
+func anyDirectional(items []EvidenceItem) bool {
+	for _, item := range items {
+		if directional(item) {
+			return true
+		}
+	}
+	return false
+}
+
+// combinedConfidence treats each evidence type and file as an independent source: the edge is
+// wrong only if every source is, so confidences combine as 1 - Π(1 - c). Repeated evidence from
+// the same file and type counts once.
+func combinedConfidence(items []EvidenceItem) float64 {
+	strongest := make(map[string]float64)
+	for _, item := range items {
+		source := string(item.EvidenceType) + "\x00" + item.FilePath
+		strongest[source] = math.Max(strongest[source], item.Confidence)
+	}
+
+	doubt := 1.0
+	highest := 0.0
+	for _, confidence := range strongest {
+		doubt *= 1 - confidence
+		highest = math.Max(highest, confidence)
+	}
+	if len(strongest) == 1 || highest >= 1 {
+		return highest
+	}
+	return math.Min(math.Round((1-doubt)*100)/100, maxCorroboratedConfidence)
+}
+
+// mergeItems builds one edge whose evidence fields come from its strongest directional item, so a
+// folded-in reverse reference never describes the edge
+func mergeItems(from, to string, items []EvidenceItem) ServiceRelationship {
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Confidence != items[j].Confidence {
+			return items[i].Confidence > items[j].Confidence
+		}
+		if items[i].EvidenceType != items[j].EvidenceType {
+			return items[i].EvidenceType < items[j].EvidenceType
+		}
+		if items[i].FilePath != items[j].FilePath {
+			return items[i].FilePath < items[j].FilePath
+		}
+		return items[i].Evidence < items[j].Evidence
+	})
+
+	strongest := items[0]
+	for _, item := range items {
+		if directional(item) {
+			strongest = item
+			break
+		}
+	}
+	rel := ServiceRelationship{
+		From:         from,
+		To:           to,
+		EvidenceType: strongest.EvidenceType,
+		Evidence:     strongest.Evidence,
+		FilePath:     strongest.FilePath,
+		Confidence:   combinedConfidence(items),
+	}
+	if len(items) > 1 {
+		rel.Sources = items
+	}
+	return rel
+}
+
+// items returns the evidence behind a relationship: its merged sources, or its own fields
+func (rel ServiceRelationship) items() []EvidenceItem {
+	if len(rel.Sources) > 0 {
+		return rel.Sources
+	}
+	return []EvidenceItem{{
+		EvidenceType: rel.EvidenceType,
+		Evidence:     rel.Evidence,
+		FilePath:     rel.FilePath,
+		Confidence:   rel.Confidence,
+	}}
+}
+
+func containsItem(items []EvidenceItem, item EvidenceItem) bool {
+	for _, existing := range items {
+		if existing == item {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,347 @@
+package relationships
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"repo-explanation/internal/mermaid"
+	"repo-explanation/internal/microservices"
+	"repo-explanation/internal/portable"
+)
+
+// maxFlowSteps bounds the length of a flow; longer chains are cut into separate flows
+const maxFlowSteps = 6
+
+// maxFlowCandidates bounds path enumeration on densely connected graphs
+const maxFlowCandidates = 500
+
+// SequenceStep is one call from one service to another within a flow
+type SequenceStep struct {
+	From         string       `json:"from"`
+	To           string       `json:"to"`
+	Message      string       `json:"message"` // Arrow label, e.g. "HTTP /api/charge"
+	EvidenceType EvidenceType `json:"evidence_type"`
+	Evidence     string       `json:"evidence"`
+	FilePath     string       `json:"file_path"`              // Caller file the evidence was found in
+	HandlerFile  string       `json:"handler_file,omitempty"` // Callee file that registers the called route
+	Confidence   float64      `json:"confidence"`
+}
+
+// SequenceFlow is a chain of inter-service calls, rendered as a Mermaid sequence diagram
+type SequenceFlow struct {
+	Name         string         `json:"name"` // Business name of the flow, e.g. "checkout"
+	Description  string         `json:"description,omitempty"`
+	Participants []string       `json:"participants"`
+	Steps        []SequenceStep `json:"steps"`
+	Confidence   float64        `json:"confidence"` // Lowest step confidence
+	Source       string         `json:"source"`     // heuristic or heuristic+llm
+	Mermaid      string         `json:"mermaid"`
+}
+
+// Path renders the participants as "gateway → orders → payments"
+func (f *SequenceFlow) Path() string {
+	return strings.Join(f.Participants, " → ")
+}
+
+// BuildSequenceFlows turns the service graph into the top maxFlows call chains.
+// Chains start at services nothing else calls (gateways, frontends) and follow the strongest
+// evidence for each edge; HTTP paths are matched against route registrations in the callee.
+func BuildSequenceFlows(services []microservices.DiscoveredService, relationships []ServiceRelationship, fileContent map[string]string, maxFlows int) []SequenceFlow {
+	edges := strongestEdges(relationships)
+	if len(edges) == 0 || maxFlows <= 0 {
+		return nil
+	}
+
+	callees := make(map[string][]string)
+	called := make(map[string]bool)
+	for key, rel := range edges {
+		callees[key.from] = append(callees[key.from], rel.To)
+		called[rel.To] = true
+	}
+	var starts []string
+	for from := range callees {
+		sort.Strings(callees[from])
+		if !called[from] {
+			starts = append(starts, from)
+		}
+	}
+	if len(starts) == 0 {
+		// Every service is called by another (a cycle); start anywhere
+		for from := range callees {
+			starts = append(starts, from)
+		}
+	}
+	sort.Strings(starts)
+
+	var candidates [][]string
+	for _, start := range starts {
+		collectChains([]string{start}, callees, &candidates)
+	}
+
+	score := func(chain []string) float64 {
+		total := float64(len(chain)-1) * 10
+		for i := 0; i+1 < len(chain); i++ {
+			rel := edges[edgeKey{chain[i], chain[i+1]}]
+			total += rel.Confidence
+			if rel.EvidenceType == NetworkEvidence {
+				total += 1
+			}
+		}
+		return total
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		si, sj := score(candidates[i]), score(candidates[j])
+		if si != sj {
+			return si > sj
+		}
+		return strings.Join(candidates[i], ",") < strings.Join(candidates[j], ",")
+	})
+
+	servicePaths := make(map[string]string)
+	for _, service := range services {
+		servicePaths[service.Name] = portable.Slash(service.Path)
+	}
+
+	// Skip chains that only repeat calls already shown by a higher-ranked flow
+	covered := make(map[edgeKey]bool)
+	var flows []SequenceFlow
+	for _, chain := range candidates {
+		if len(flows) >= maxFlows {
+			break
+		}
+		fresh := false
+		for i := 0; i+1 < len(chain); i++ {
+			if !covered[edgeKey{chain[i], chain[i+1]}] {
+				fresh = true
+			}
+		}
+		if !fresh {
+			continue
+		}
+
+		flow := SequenceFlow{Participants: chain, Confidence: 1, Source: "heuristic"}
+		for i := 0; i+1 < len(chain); i++ {
+			key := edgeKey{chain[i], chain[i+1]}
+			covered[key] = true
+			rel := edges[key]
+			step := SequenceStep{
+				From:         rel.From,
+				To:           rel.To,
+				Message:      stepMessage(rel),
+				EvidenceType: rel.EvidenceType,
+				Evidence:     rel.Evidence,
+				FilePath:     rel.FilePath,
+				Confidence:   rel.Confidence,
+			}
+			if route := httpRoute(rel); route != "" {
+				step.HandlerFile = findRouteHandler(route, servicePaths[rel.To], fileContent)
+			}
+			if rel.Confidence < flow.Confidence {
+				flow.Confidence = rel.Confidence
+			}
+			flow.Steps = append(flow.Steps, step)
+		}
+		flow.Name = flowName(flow)
+		flow.Mermaid = flow.RenderMermaid()
+		flows = append(flows, flow)
+	}
+	return flows
+}
+
+// RenderMermaid draws the flow as nested synchronous calls, each answered in reverse order
+func (f *SequenceFlow) RenderMermaid() string {
+	var b strings.Builder
+	b.WriteString("sequenceDiagram\n")
+	for _, participant := range f.Participants {
+		if id := mermaid.ID(participant); id != participant {
+			b.WriteString(fmt.Sprintf("  participant %s as %s\n", id, participant))
+		} else {
+			b.WriteString(fmt.Sprintf("  participant %s\n", id))
+		}
+	}
+	for _, step := range f.Steps {
+		b.WriteString(fmt.Sprintf("  %s->>+%s: %s\n", mermaid.ID(step.From), mermaid.ID(step.To), mermaid.Message(step.Message)))
+	}
+	for i := len(f.Steps) - 1; i >= 0; i-- {
+		step := f.Steps[i]
+		b.WriteString(fmt.Sprintf("  %s-->>-%s: response\n", mermaid.ID(step.To), mermaid.ID(step.From)))
+	}
+	return b.String()
+}
+
+type edgeKey struct {
+	from, to string
+}
+
+// evidenceRank orders evidence by how directly it shows a runtime call
+var evidenceRank = map[EvidenceType]int{
+	NetworkEvidence:       4,
+	ClientLibraryEvidence: 3,
+	APISpecEvidence:       3,
+	ImportEvidence:        2,
+	ConfigEvidence:        1,
+}
+
+// strongestEdges keeps one relationship per service pair, preferring network calls and higher confidence
+func strongestEdges(relationships []ServiceRelationship) map[edgeKey]ServiceRelationship {
+	edges := make(map[edgeKey]ServiceRelationship)
+	for _, rel := range relationships {
+		if rel.From == "" || rel.To == "" || rel.From == rel.To || rel.EvidenceType == SharedDatabaseEvidence {
+			continue
+		}
+		key := edgeKey{rel.From, rel.To}
+		existing, ok := edges[key]
+		if !ok || evidenceRank[rel.EvidenceType] > evidenceRank[existing.EvidenceType] ||
+			(evidenceRank[rel.EvidenceType] == evidenceRank[existing.EvidenceType] && rel.Confidence > existing.Confidence) {
+			edges[key] = rel
+		}
+	}
+	return edges
+}
+
+// collectChains appends every maximal simple path starting with chain
+func collectChains(chain []string, callees map[string][]string, candidates *[][]string) {
+	if len(*candidates) >= maxFlowCandidates {
+		return
+	}
+	last := chain[len(chain)-1]
+	extended := false
+	if len(chain) <= maxFlowSteps {
+		for _, next := range callees[last] {
+			if containsString(chain, next) {
+				continue
+			}
+			extended = true
+			collectChains(append(append([]string{}, chain...), next), callees, candidates)
+		}
+	}
+	if !extended && len(chain) > 1 {
+		*candidates = append(*candidates, chain)
+	}
+}
+
+// stepMessage labels an arrow from the relationship evidence
+func stepMessage(rel ServiceRelationship) string {
+	if route := httpRoute(rel); route != "" {
+		return "HTTP " + route
+	}
+	lower := strings.ToLower(rel.Evidence)
+	switch {
+	case strings.Contains(lower, "grpc"):
+		return "gRPC call"
+	case rel.EvidenceType == NetworkEvidence:
+		return "HTTP request"
+	case rel.EvidenceType == ImportEvidence || rel.EvidenceType == ClientLibraryEvidence:
+		return "calls via client library"
+	case rel.EvidenceType == APISpecEvidence:
+		return "calls API"
+	}
+	return "depends on"
+}
+
+// httpRoute extracts the request path of an "HTTP call: <url>" relationship
+func httpRoute(rel ServiceRelationship) string {
+	if rel.EvidenceType != NetworkEvidence || !strings.HasPrefix(rel.Evidence, "HTTP call: ") {
+		return ""
+	}
+	raw := strings.TrimPrefix(rel.Evidence, "HTTP call: ")
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Path == "" || parsed.Path == "/" {
+		return ""
+	}
+	return parsed.Path
+}
+
+// routeLiteralPattern finds quoted path literals such as "/api/charge" in route registrations
+var routeLiteralPattern = regexp.MustCompile("[\"'`](/[A-Za-z0-9_/:{}.-]*)[\"'`]")
+
+// findRouteHandler returns the callee file that registers the route, or "" when none does.
+// Path parameters match any segment, so "/orders/{id}" and "/orders/:id" both match "/orders/42".
+func findRouteHandler(route, servicePath string, fileContent map[string]string) string {
+	if fileContent == nil {
+		return ""
+	}
+	var paths []string
+	for path := range fileContent {
+		if portable.Within(path, servicePath) {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		for _, match := range routeLiteralPattern.FindAllStringSubmatch(fileContent[path], -1) {
+			if routeMatches(match[1], route) {
+				return portable.Slash(path)
+			}
+		}
+	}
+	return ""
+}
+
+// routeMatches compares a registered route pattern with a concrete request path
+func routeMatches(pattern, route string) bool {
+	patternParts := strings.Split(strings.Trim(pattern, "/"), "/")
+	routeParts := strings.Split(strings.Trim(route, "/"), "/")
+	if len(patternParts) != len(routeParts) || (len(patternParts) == 1 && patternParts[0] == "") {
+		return false
+	}
+	for i, part := range patternParts {
+		if strings.HasPrefix(part, ":") || (strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}")) {
+			continue
+		}
+		if part != routeParts[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// flowName names a flow after the first route it calls, falling back to its entry service
+func flowName(flow SequenceFlow) string {
+	for _, step := range flow.Steps {
+		route := strings.TrimPrefix(step.Message, "HTTP ")
+		if route == step.Message {
+			continue
+		}
+		segments := strings.Split(strings.Trim(route, "/"), "/")
+		for i := len(segments) - 1; i >= 0; i-- {
+			segment := segments[i]
+			if segment == "" || strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "{") || isVersionSegment(segment) || isNumeric(segment) || segment == "api" {
+				continue
+			}
+			return segment
+		}
+	}
+	return flow.Participants[0] + " flow"
+}
+
+// isVersionSegment matches API version segments such as v1
+func isVersionSegment(segment string) bool {
+	return len(segment) >= 2 && segment[0] == 'v' && isNumeric(segment[1:])
+}
+
+// isNumeric matches IDs in concrete request paths such as /orders/42
+func isNumeric(segment string) bool {
+	for _, r := range segment {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return segment != ""
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,391 @@
+package report
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"repo-explanation/internal/c4"
+	"repo-explanation/internal/mermaid"
+	"repo-explanation/internal/pipeline"
+	"repo-explanation/internal/relationships"
+)
+
+// BlockKind is the kind of a report block
+type BlockKind string
+
+const (
+	Heading   BlockKind = "heading"
+	Paragraph BlockKind = "paragraph"
+	List      BlockKind = "list"
+	Code      BlockKind = "code"
+	Table     BlockKind = "table"
+)
+
+// Block is one element of the report. Publishers render blocks in their own format rather than
+// parsing the Markdown, so the report reads natively in Confluence and Notion.
+type Block struct {
+	Kind       BlockKind
+	Level      int        // Heading level, 1 to 3
+	Text       string     // Heading and paragraph text, or the code
+	Language   string     // Code language: mermaid, plantuml or text
+	Items      []string   // List items
+	Rows       [][]string // Table rows, the first being the header
+	Attachment string     // Name of the attachment a diagram's code is also uploaded as
+}
+
+// Attachment is a diagram file published with the report
+type Attachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// Document is the onboarding report of one repository
+type Document struct {
+	Title       string
+	Repository  string
+	Blocks      []Block
+	Attachments []Attachment
+}
+
+// Build writes the onboarding report of an analysis; title names the page it is published as
+func Build(title, repository string, result *pipeline.AnalysisResult) *Document {
+	d := &Document{Title: title, Repository: repository}
+	d.heading(1, title)
+	d.paragraph(fmt.Sprintf("Onboarding guide for %s, generated from an automated analysis of the repository. Re-publishing replaces this page.", repository))
+
+	d.overview(result)
+	d.gettingStarted(result)
+	d.services(result)
+	d.modules(result)
+	d.flows(result)
+	d.database(result)
+	d.diagrams(result)
+	d.glossary(result)
+	d.questions(result)
+	return d
+}
+
+func (d *Document) overview(result *pipeline.AnalysisResult) {
+	d.heading(2, "Overview")
+	summary := result.ProjectSummary
+	if summary != nil && summary.Purpose != "" {
+		d.paragraph(summary.Purpose)
+	}
+	var facts []string
+	if scope := result.ServiceScope; scope != nil {
+		fact := fmt.Sprintf("Scope: service %s (%s)", scope.Service, scope.Path)
+		if len(scope.Dependencies) > 0 {
+			fact += " and the services it calls: " + strings.Join(scope.Dependencies, ", ")
+		}
+		facts = append(facts, fact)
+	}
+	if result.ProjectType != nil && result.ProjectType.PrimaryType != "" {
+		kind := string(result.ProjectType.PrimaryType)
+		if result.ProjectType.SecondaryType != "" {
+			kind += " with " + string(result.ProjectType.SecondaryType)
+		}
+		facts = append(facts, "Project type: "+kind)
+	}
+	if summary != nil {
+		if summary.Architecture != "" {
+			facts = append(facts, "Architecture: "+summary.Architecture)
+		}
+		if languages := sortedByCount(summary.Languages); len(languages) > 0 {
+			facts = append(facts, "Languages: "+strings.Join(languages, ", "))
+		}
+		if details := summary.DetailedAnalysis; details != nil && len(details.MainStacks) > 0 {
+			facts = append(facts, "Main stacks: "+strings.Join(details.MainStacks, ", "))
+		}
+		if len(summary.ExternalServices) > 0 {
+			facts = append(facts, "External services: "+strings.Join(summary.ExternalServices, ", "))
+		}
+	}
+	if len(facts) > 0 {
+		d.list(facts)
+	}
+}
+
+func (d *Document) gettingStarted(result *pipeline.AnalysisResult) {
+	if result.ProjectSummary == nil || result.ProjectSummary.Documentation == nil {
+		return
+	}
+	docs := result.ProjectSummary.Documentation
+	if len(docs.SetupSteps) == 0 && len(docs.RunCommands) == 0 {
+		return
+	}
+	d.heading(2, "Getting started")
+	if len(docs.SetupSteps) > 0 {
+		d.heading(3, "Setup")
+		d.code("text", strings.Join(docs.SetupSteps, "\n"), "")
+	}
+	if len(docs.RunCommands) > 0 {
+		d.heading(3, "Run")
+		d.code("text", strings.Join(docs.RunCommands, "\n"), "")
+	}
+	if len(docs.Sources) > 0 {
+		d.paragraph("From " + strings.Join(docs.Sources, ", ") + ".")
+	}
+}
+
+func (d *Document) services(result *pipeline.AnalysisResult) {
+	if len(result.Services) == 0 {
+		return
+	}
+	d.heading(2, "Services")
+	rows := [][]string{{"Service", "Path", "API", "Port", "Description"}}
+	for _, service := range result.Services {
+		rows = append(rows, []string{service.Name, service.Path, string(service.APIType), service.Port, service.Description})
+	}
+	d.table(rows)
+
+	if len(result.ServiceRelationships) == 0 {
+		return
+	}
+	d.heading(3, "Dependencies")
+	var items []string
+	for _, rel := range result.ServiceRelationships {
+		items = append(items, fmt.Sprintf("%s → %s (%s, confidence %.0f%%)", rel.From, rel.To, rel.EvidenceType, rel.Confidence*100))
+	}
+	d.list(items)
+	d.diagram("Service graph", "services.mmd", "mermaid", relationships.MermaidGraph(result.Services, result.ServiceRelationships))
+}
+
+func (d *Document) modules(result *pipeline.AnalysisResult) {
+	report := result.Modules
+	if report == nil || len(report.Modules) == 0 {
+		return
+	}
+	d.heading(2, "Modules")
+	d.paragraph(report.Summary + ".")
+	rows := [][]string{{"Module", "Packages", "Files", "Cohesion", "Description"}}
+	for _, module := range report.Modules {
+		rows = append(rows, []string{module.Name, strings.Join(module.Packages, ", "), fmt.Sprintf("%d", module.Files), fmt.Sprintf("%.2f", module.Cohesion), module.Description})
+	}
+	d.table(rows)
+	if len(report.Shared) > 0 {
+		d.paragraph("Shared packages, kept out of the modules: " + strings.Join(report.Shared, ", ") + ".")
+	}
+	d.diagram("Module boundaries", "modules.mmd", "mermaid", report.MermaidGraph)
+}
+
+func (d *Document) flows(result *pipeline.AnalysisResult) {
+	if len(result.SequenceFlows) == 0 {
+		return
+	}
+	d.heading(2, "Request flows")
+	for _, flow := range result.SequenceFlows {
+		d.heading(3, flow.Name)
+		text := flow.Path()
+		if flow.Description != "" {
+			text = flow.Description + " (" + text + ")"
+		}
+		d.paragraph(text)
+		if flow.Mermaid != "" {
+			d.code("mermaid", flow.Mermaid, d.attach("flow_"+strings.Trim(mermaid.ID(flow.Name), "_")+".mmd", "mermaid", flow.Mermaid))
+		}
+	}
+}
+
+func (d *Document) database(result *pipeline.AnalysisResult) {
+	schema := result.DatabaseSchema
+	if schema == nil || len(schema.Tables) == 0 {
+		return
+	}
+	d.heading(2, "Database")
+	names := make([]string, 0, len(schema.Tables))
+	for name := range schema.Tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	rows := [][]string{{"Table", "Columns", "Primary key"}}
+	for _, name := range names {
+		table := schema.Tables[name]
+		rows = append(rows, []string{name, fmt.Sprintf("%d", len(table.Columns)), strings.Join(table.PrimaryKeys, ", ")})
+	}
+	d.table(rows)
+	if sensitivity := schema.Sensitivity; sensitivity != nil {
+		d.paragraph("Sensitive data: " + sensitivity.Summary + ".")
+		var items []string
+		for _, column := range sensitivity.Columns {
+			items = append(items, fmt.Sprintf("%s.%s: %s (%s)", column.Table, column.Column, column.Class, column.Label))
+		}
+		d.list(append(items, sensitivity.Warnings...))
+	}
+	if split := schema.ERDSplit; split != nil {
+		d.paragraph(split.Summary)
+		d.diagram("Domains", "database_erd_index.mmd", "mermaid", split.Index)
+		for _, domain := range split.Domains {
+			d.diagram("Entity relationships: "+domain.Name, "database_erd_"+strings.Trim(mermaid.ID(domain.Name), "_")+".mmd", "mermaid", domain.Mermaid)
+		}
+		return
+	}
+	d.diagram("Entity relationships", "database_erd.mmd", "mermaid", schema.MermaidERD())
+}
+
+func (d *Document) diagrams(result *pipeline.AnalysisResult) {
+	hasInfrastructure := result.Infrastructure != nil && result.Infrastructure.MermaidGraph != ""
+	if result.ArchitectureMap == nil && len(result.Services) == 0 && !hasInfrastructure {
+		return
+	}
+	d.heading(2, "Architecture diagrams")
+	if result.ArchitectureMap != nil {
+		d.diagram("Folder map", "architecture_map.mmd", "mermaid", result.ArchitectureMap.Root.Mindmap(0))
+	}
+	if hasInfrastructure {
+		d.diagram("Infrastructure", "infrastructure.mmd", "mermaid", result.Infrastructure.MermaidGraph)
+	}
+	if len(result.Services) > 0 {
+		model := c4.Build(d.Repository, result)
+		d.diagram("C4 context", "c4_context.puml", "plantuml", model.ContextPlantUML())
+		d.diagram("C4 containers", "c4_container.puml", "plantuml", model.ContainerPlantUML())
+	}
+}
+
+func (d *Document) glossary(result *pipeline.AnalysisResult) {
+	if result.Glossary == nil || len(result.Glossary.Terms) == 0 {
+		return
+	}
+	d.heading(2, "Glossary")
+	rows := [][]string{{"Term", "Definition"}}
+	for _, term := range result.Glossary.Terms {
+		rows = append(rows, []string{term.Term, term.Definition})
+	}
+	d.table(rows)
+}
+
+func (d *Document) questions(result *pipeline.AnalysisResult) {
+	if len(result.HelpfulQuestions) == 0 {
+		return
+	}
+	d.heading(2, "Questions newcomers ask")
+	for _, qa := range result.HelpfulQuestions {
+		d.heading(3, qa.Question)
+		d.paragraph(qa.Answer)
+	}
+}
+
+func (d *Document) heading(level int, text string) {
+	d.Blocks = append(d.Blocks, Block{Kind: Heading, Level: level, Text: text})
+}
+
+func (d *Document) paragraph(text string) {
+	if text = strings.TrimSpace(text); text != "" {
+		d.Blocks = append(d.Blocks, Block{Kind: Paragraph, Text: text})
+	}
+}
+
+func (d *Document) list(items []string) {
+	d.Blocks = append(d.Blocks, Block{Kind: List, Items: items})
+}
+
+func (d *Document) table(rows [][]string) {
+	d.Blocks = append(d.Blocks, Block{Kind: Table, Rows: rows})
+}
+
+func (d *Document) code(language, text, attachment string) {
+	d.Blocks = append(d.Blocks, Block{Kind: Code, Language: language, Text: strings.TrimRight(text, "\n"), Attachment: attachment})
+}
+
+// diagram adds a titled diagram, both inline and as an attachment
+func (d *Document) diagram(title, name, language, source string) {
+	if strings.TrimSpace(source) == "" {
+		return
+	}
+	d.heading(3, title)
+	d.code(language, source, d.attach(name, language, source))
+}
+
+// attach adds a diagram source as an attachment and returns its name
+func (d *Document) attach(name, language, source string) string {
+	contentType := "text/vnd.mermaid"
+	if language == "plantuml" {
+		contentType = "text/vnd.plantuml"
+	}
+	d.Attachments = append(d.Attachments, Attachment{Name: name, ContentType: contentType, Data: []byte(source)})
+	return name
+}
+
+// Markdown renders the report as GitHub-flavored Markdown; diagrams are fenced code blocks, which
+// GitHub renders for Mermaid
+func (d *Document) Markdown() string {
+	var b strings.Builder
+	for i, block := range d.Blocks {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		switch block.Kind {
+		case Heading:
+			b.WriteString(strings.Repeat("#", block.Level) + " " + block.Text + "\n")
+		case Paragraph:
+			b.WriteString(block.Text + "\n")
+		case List:
+			for _, item := range block.Items {
+				b.WriteString("- " + item + "\n")
+			}
+		case Code:
+			b.WriteString("```" + block.Language + "\n" + block.Text + "\n```\n")
+			if block.Attachment != "" {
+				b.WriteString("\nSource: [" + block.Attachment + "](" + block.Attachment + ")\n")
+			}
+		case Table:
+			for r, row := range block.Rows {
+				cells := make([]string, len(row))
+				for c, cell := range row {
+					cells[c] = markdownCell(cell)
+				}
+				b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+				if r == 0 {
+					b.WriteString("|" + strings.Repeat(" --- |", len(row)) + "\n")
+				}
+			}
+		}
+	}
+	return b.String()
+}
+
+// Write saves the Markdown report and its attachments in a folder of dir named after the
+// repository, returning the written paths
+func (d *Document) Write(dir string) ([]string, error) {
+	folder := filepath.Join(dir, strings.Trim(mermaid.ID(d.Repository), "_")+"_onboarding")
+	if err := os.MkdirAll(folder, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %v", err)
+	}
+	var paths []string
+	path := filepath.Join(folder, "README.md")
+	if err := os.WriteFile(path, []byte(d.Markdown()), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	paths = append(paths, path)
+	for _, attachment := range d.Attachments {
+		path := filepath.Join(folder, attachment.Name)
+		if err := os.WriteFile(path, attachment.Data, 0644); err != nil {
+			return paths, fmt.Errorf("failed to write %s: %v", path, err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+func markdownCell(text string) string {
+	text = strings.ReplaceAll(text, "|", `\|`)
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// sortedByCount lists map keys by descending count, then by name
+func sortedByCount(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
@@ -0,0 +1,226 @@
+package sandbox
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ErrUnsupportedArchive is returned for uploads that are neither zip nor (gzipped) tar archives
+var ErrUnsupportedArchive = errors.New("unsupported archive: upload a .zip, .tar.gz, .tgz or .tar file")
+
+// ArchiveLimits bound what an uploaded archive may expand to, so a small zip bomb cannot fill the disk
+type ArchiveLimits struct {
+	MaxBytes int64 // Total size of the extracted files; 0 for no limit
+	MaxFiles int   // Number of extracted files; 0 for no limit
+}
+
+// LimitError reports an archive that expands beyond its limits
+type LimitError struct {
+	Limit string
+}
+
+func (e *LimitError) Error() string {
+	return "archive expands beyond the server's limit of " + e.Limit
+}
+
+// Extract unpacks the zip or tar(.gz) archive at archivePath into dest, which must exist and be
+// empty. Entries are confined to dest: absolute paths and ".." are rejected, symlinks, hard links
+// and devices are skipped, and permissions are reduced to 0644 or 0755. It returns the project
+// root: the single top-level folder when every entry lies inside one, as in GitHub downloads,
+// and dest otherwise.
+func Extract(archivePath, dest string, limits ArchiveLimits) (string, error) {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open archive: %v", err)
+	}
+	defer file.Close()
+
+	header := make([]byte, 512)
+	n, _ := io.ReadFull(file, header)
+	header = header[:n]
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("failed to read archive: %v", err)
+	}
+
+	x := &extractor{dest: dest, limits: limits, tops: make(map[string]bool)}
+	switch {
+	case bytes.HasPrefix(header, []byte("PK\x03\x04")) || bytes.HasPrefix(header, []byte("PK\x05\x06")):
+		info, err := file.Stat()
+		if err != nil {
+			return "", fmt.Errorf("failed to read archive: %v", err)
+		}
+		err = x.zip(file, info.Size())
+		if err != nil {
+			return "", err
+		}
+	case bytes.HasPrefix(header, []byte{0x1f, 0x8b}):
+		gz, err := gzip.NewReader(bufio.NewReader(file))
+		if err != nil {
+			return "", fmt.Errorf("failed to read gzip archive: %v", err)
+		}
+		defer gz.Close()
+		if err := x.tar(gz); err != nil {
+			return "", err
+		}
+	case len(header) > 262 && string(header[257:262]) == "ustar":
+		if err := x.tar(file); err != nil {
+			return "", err
+		}
+	default:
+		return "", ErrUnsupportedArchive
+	}
+
+	if x.files == 0 {
+		return "", fmt.Errorf("archive contains no files")
+	}
+	if len(x.tops) == 1 && !x.rootFiles {
+		for top := range x.tops {
+			return filepath.Join(dest, top), nil
+		}
+	}
+	return dest, nil
+}
+
+// extractor writes archive entries below dest while counting them against the limits
+type extractor struct {
+	dest      string
+	limits    ArchiveLimits
+	files     int
+	bytes     int64
+	tops      map[string]bool // Top-level folders of the entries
+	rootFiles bool            // Some file lies directly in the archive root
+}
+
+func (x *extractor) zip(file *os.File, size int64) error {
+	reader, err := zip.NewReader(file, size)
+	if err != nil {
+		return fmt.Errorf("failed to read zip archive: %v", err)
+	}
+	for _, entry := range reader.File {
+		mode := entry.Mode()
+		if mode.IsDir() {
+			if _, err := x.target(entry.Name, true); err != nil {
+				return err
+			}
+			continue
+		}
+		if !mode.IsRegular() {
+			continue
+		}
+		rc, err := entry.Open()
+		if err != nil {
+			return fmt.Errorf("failed to read %s from the archive: %v", entry.Name, err)
+		}
+		err = x.write(entry.Name, mode, rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (x *extractor) tar(r io.Reader) error {
+	reader := tar.NewReader(r)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read tar archive: %v", err)
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if _, err := x.target(header.Name, true); err != nil {
+				return err
+			}
+		case tar.TypeReg, tar.TypeRegA:
+			if err := x.write(header.Name, header.FileInfo().Mode(), reader); err != nil {
+				return err
+			}
+		}
+		// Symlinks, hard links, devices and pax headers are skipped
+	}
+}
+
+// target maps an entry name to a path inside dest, rejecting names that would escape it
+func (x *extractor) target(name string, dir bool) (string, error) {
+	name = strings.ReplaceAll(name, `\`, "/")
+	if strings.HasPrefix(name, "/") || filepath.VolumeName(name) != "" {
+		return "", fmt.Errorf("archive entry %q has an absolute path", name)
+	}
+	clean := path.Clean(name)
+	if clean == "." {
+		return x.dest, nil
+	}
+	if clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("archive entry %q points outside the archive", name)
+	}
+	// Archive metadata folders do not count towards the project's top-level folder
+	if top := strings.SplitN(clean, "/", 2)[0]; top != "__MACOSX" && top != "pax_global_header" {
+		if dir || strings.Contains(clean, "/") {
+			x.tops[top] = true
+		} else {
+			x.rootFiles = true
+		}
+	}
+	return filepath.Join(x.dest, filepath.FromSlash(clean)), nil
+}
+
+func (x *extractor) write(name string, mode os.FileMode, r io.Reader) error {
+	target, err := x.target(name, false)
+	if err != nil {
+		return err
+	}
+	if strings.HasPrefix(path.Clean(strings.ReplaceAll(name, `\`, "/")), "__MACOSX/") {
+		return nil
+	}
+	x.files++
+	if x.limits.MaxFiles > 0 && x.files > x.limits.MaxFiles {
+		return &LimitError{Limit: fmt.Sprintf("%d files", x.limits.MaxFiles)}
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("failed to create folder for %s: %v", name, err)
+	}
+
+	perm := os.FileMode(0644)
+	if mode&0111 != 0 {
+		perm = 0755
+	}
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return fmt.Errorf("failed to extract %s: %v", name, err)
+	}
+	defer out.Close()
+
+	if x.limits.MaxBytes > 0 {
+		// Read one byte past the remaining budget to tell an exact fit from an overflow
+		remaining := x.limits.MaxBytes - x.bytes
+		written, err := io.Copy(out, io.LimitReader(r, remaining+1))
+		x.bytes += written
+		if err != nil {
+			return fmt.Errorf("failed to extract %s: %v", name, err)
+		}
+		if x.bytes > x.limits.MaxBytes {
+			return &LimitError{Limit: fmt.Sprintf("%d MB", x.limits.MaxBytes/(1024*1024))}
+		}
+		return nil
+	}
+	written, err := io.Copy(out, r)
+	x.bytes += written
+	if err != nil {
+		return fmt.Errorf("failed to extract %s: %v", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package sandbox
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// entry is one archive member; a non-empty link makes it a symlink to that target
+type entry struct {
+	name, body, link string
+}
+
+func writeZip(t *testing.T, entries []entry) string {
+	t.Helper()
+	archive := filepath.Join(t.TempDir(), "upload.zip")
+	file, err := os.Create(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	w := zip.NewWriter(file)
+	for _, e := range entries {
+		header := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+		body := e.body
+		if e.link != "" {
+			header.SetMode(os.ModeSymlink | 0777)
+			body = e.link
+		} else {
+			header.SetMode(0644)
+		}
+		out, err := w.CreateHeader(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := out.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return archive
+}
+
+func writeTarGz(t *testing.T, entries []entry) string {
+	t.Helper()
+	archive := filepath.Join(t.TempDir(), "upload.tar.gz")
+	file, err := os.Create(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	gz := gzip.NewWriter(file)
+	w := tar.NewWriter(gz)
+	for _, e := range entries {
+		header := &tar.Header{Name: e.name, Mode: 0644, Size: int64(len(e.body)), Typeflag: tar.TypeReg}
+		if e.link != "" {
+			header = &tar.Header{Name: e.name, Mode: 0777, Linkname: e.link, Typeflag: tar.TypeSymlink}
+		}
+		if err := w.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if e.link == "" {
+			if _, err := w.Write([]byte(e.body)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return archive
+}
+
+func TestExtract(t *testing.T) {
+	formats := []struct {
+		name  string
+		write func(*testing.T, []entry) string
+	}{
+		{"zip", writeZip},
+		{"tar.gz", writeTarGz},
+	}
+	tests := []struct {
+		name      string
+		entries   []entry
+		limits    ArchiveLimits
+		wantErr   string // Substring of the error; "" for success
+		wantLimit bool   // The error must be a LimitError
+	}{
+		{
+			name:    "single top-level folder",
+			entries: []entry{{name: "proj/main.go", body: "package main"}, {name: "proj/pkg/util.go", body: "package pkg"}},
+		},
+		{
+			name:    "zip slip",
+			entries: []entry{{name: "proj/main.go", body: "package main"}, {name: "../evil.sh", body: "rm -rf /"}},
+			wantErr: "points outside the archive",
+		},
+		{
+			name:    "zip slip through a folder",
+			entries: []entry{{name: "proj/../../evil.sh", body: "rm -rf /"}},
+			wantErr: "points outside the archive",
+		},
+		{
+			name:    "backslash zip slip",
+			entries: []entry{{name: `proj\..\..\evil.sh`, body: "rm -rf /"}},
+			wantErr: "points outside the archive",
+		},
+		{
+			name:    "absolute entry name",
+			entries: []entry{{name: "/tmp/evil.sh", body: "rm -rf /"}},
+			wantErr: "absolute path",
+		},
+		{
+			name:    "symlink entries are skipped",
+			entries: []entry{{name: "proj/main.go", body: "package main"}, {name: "proj/passwd", link: "/etc/passwd"}, {name: "proj/up", link: "../.."}},
+		},
+		{
+			name:    "size within the limit",
+			entries: []entry{{name: "proj/a.txt", body: "12345"}, {name: "proj/b.txt", body: "67890"}},
+			limits:  ArchiveLimits{MaxBytes: 10},
+		},
+		{
+			name:      "size over the limit",
+			entries:   []entry{{name: "proj/a.txt", body: "12345"}, {name: "proj/b.txt", body: "678901"}},
+			limits:    ArchiveLimits{MaxBytes: 10},
+			wantLimit: true,
+		},
+		{
+			name:    "entry count within the limit",
+			entries: []entry{{name: "proj/a.txt", body: "a"}, {name: "proj/b.txt", body: "b"}},
+			limits:  ArchiveLimits{MaxFiles: 2},
+		},
+		{
+			name:      "entry count over the limit",
+			entries:   []entry{{name: "proj/a.txt", body: "a"}, {name: "proj/b.txt", body: "b"}, {name: "proj/c.txt", body: "c"}},
+			limits:    ArchiveLimits{MaxFiles: 2},
+			wantLimit: true,
+		},
+	}
+	for _, format := range formats {
+		for _, tt := range tests {
+			t.Run(format.name+"/"+tt.name, func(t *testing.T) {
+				archive := format.write(t, tt.entries)
+				base := t.TempDir()
+				dest := filepath.Join(base, "src")
+				if err := os.Mkdir(dest, 0755); err != nil {
+					t.Fatal(err)
+				}
+
+				root, err := Extract(archive, dest, tt.limits)
+				if _, statErr := os.Stat(filepath.Join(base, "evil.sh")); statErr == nil {
+					t.Fatal("an entry was written outside the destination")
+				}
+				switch {
+				case tt.wantLimit:
+					var limit *LimitError
+					if !errors.As(err, &limit) {
+						t.Fatalf("Extract() error = %v, want a LimitError", err)
+					}
+					return
+				case tt.wantErr != "":
+					if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+						t.Fatalf("Extract() error = %v, want %q", err, tt.wantErr)
+					}
+					return
+				case err != nil:
+					t.Fatalf("Extract() error = %v", err)
+				}
+
+				if want := filepath.Join(dest, "proj"); root != want {
+					t.Errorf("root = %q, want %q", root, want)
+				}
+				for _, e := range tt.entries {
+					info, err := os.Lstat(filepath.Join(dest, filepath.FromSlash(e.name)))
+					if e.link != "" {
+						if err == nil {
+							t.Errorf("symlink entry %s was extracted as %v", e.name, info.Mode())
+						}
+						continue
+					}
+					if err != nil || !info.Mode().IsRegular() || info.Mode().Perm() != 0644 {
+						t.Errorf("entry %s = %v, %v; want a 0644 file", e.name, info, err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestExtractRejectsOtherFormats(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "upload.rar")
+	if err := os.WriteFile(archive, []byte("Rar!\x1a\x07\x00 not supported"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Extract(archive, t.TempDir(), ArchiveLimits{}); !errors.Is(err, ErrUnsupportedArchive) {
+		t.Errorf("Extract() error = %v, want ErrUnsupportedArchive", err)
+	}
+}
@@ -0,0 +1,104 @@
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrDisabled is returned when no roots are configured, so no local path may be used
+var ErrDisabled = errors.New("local paths are disabled on this server (configure server.allowed_roots to enable them)")
+
+// OutsideError reports a path that resolves outside every allowed root
+type OutsideError struct {
+	Path     string // Path as requested
+	Resolved string // Path after cleaning and following symlinks
+	Roots    []string
+}
+
+func (e *OutsideError) Error() string {
+	if e.Resolved != filepath.Clean(e.Path) {
+		return fmt.Sprintf("%s resolves to %s, which is outside the allowed roots (%s)", e.Path, e.Resolved, strings.Join(e.Roots, ", "))
+	}
+	return fmt.Sprintf("%s is outside the allowed roots (%s)", e.Path, strings.Join(e.Roots, ", "))
+}
+
+// Sandbox restricts user-supplied paths to a set of root directories
+type Sandbox struct {
+	roots []string
+}
+
+// New creates a sandbox over roots. Roots are canonicalized now; a root that does not exist yet
+// is kept as written and only matches once it does.
+func New(roots []string) *Sandbox {
+	s := &Sandbox{}
+	for _, root := range roots {
+		if root == "" {
+			continue
+		}
+		s.roots = append(s.roots, canonical(root))
+	}
+	return s
+}
+
+// Roots returns the canonical allowed roots
+func (s *Sandbox) Roots() []string {
+	return s.roots
+}
+
+// Resolve returns the canonical form of path if it lies inside an allowed root. ".." segments
+// are resolved and symlinks followed before the check, so neither can be used to escape.
+func (s *Sandbox) Resolve(path string) (string, error) {
+	if len(s.roots) == 0 {
+		return "", ErrDisabled
+	}
+	if !filepath.IsAbs(path) {
+		return "", fmt.Errorf("%s is not an absolute path", path)
+	}
+	resolved := canonical(path)
+	for _, root := range s.roots {
+		if within(resolved, root) {
+			return resolved, nil
+		}
+	}
+	return "", &OutsideError{Path: path, Resolved: resolved, Roots: s.roots}
+}
+
+// canonical cleans path and follows symlinks on the longest prefix that exists
+func canonical(path string) string {
+	path = filepath.Clean(path)
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+
+	// Resolve the existing parent and re-attach the missing tail
+	var tail []string
+	for dir := path; ; {
+		parent := filepath.Dir(dir)
+		tail = append([]string{filepath.Base(dir)}, tail...)
+		if parent == dir {
+			return path
+		}
+		if _, err := os.Lstat(parent); err == nil {
+			if resolved, err := filepath.EvalSymlinks(parent); err == nil {
+				return filepath.Join(append([]string{resolved}, tail...)...)
+			}
+			return path
+		}
+		dir = parent
+	}
+}
+
+// within reports whether path is root or below it
+func within(path, root string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
@@ -0,0 +1,96 @@
+package sandbox
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	base := t.TempDir()
+	root := filepath.Join(base, "data", "repos")
+	for _, dir := range []string{
+		filepath.Join(root, "app", "src"),
+		filepath.Join(base, "data", "repos-evil"),
+		filepath.Join(base, "etc"),
+	} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	symlink := func(target, link string) {
+		if err := os.Symlink(target, link); err != nil {
+			t.Skipf("symlinks unavailable: %v", err)
+		}
+	}
+	symlink(filepath.Join(base, "etc"), filepath.Join(root, "escape"))
+	symlink(filepath.Join(root, "app"), filepath.Join(root, "alias"))
+
+	s := New([]string{root})
+	canonicalRoot := s.Roots()[0]
+	tests := []struct {
+		name string
+		path string
+		want string // "" when the path must be rejected
+	}{
+		{"root itself", root, canonicalRoot},
+		{"inside", filepath.Join(root, "app", "src"), filepath.Join(canonicalRoot, "app", "src")},
+		{"not yet created", filepath.Join(root, "app", "new", "file.go"), filepath.Join(canonicalRoot, "app", "new", "file.go")},
+		{"dot-dot staying inside", filepath.Join(root, "app", "src") + "/../src", filepath.Join(canonicalRoot, "app", "src")},
+		{"dot-dot traversal", root + "/app/../../../etc", ""},
+		{"dot-dot to the parent", root + "/..", ""},
+		{"absolute path elsewhere", filepath.Join(base, "etc"), ""},
+		{"filesystem root", string(filepath.Separator), ""},
+		{"prefix confusion", filepath.Join(base, "data", "repos-evil"), ""},
+		{"prefix confusion below", filepath.Join(base, "data", "repos-evil", "x"), ""},
+		{"symlink out of the root", filepath.Join(root, "escape"), ""},
+		{"below a symlink out of the root", filepath.Join(root, "escape", "passwd"), ""},
+		{"symlink within the root", filepath.Join(root, "alias", "src"), filepath.Join(canonicalRoot, "app", "src")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Resolve(tt.path)
+			if tt.want == "" {
+				var outside *OutsideError
+				if !errors.As(err, &outside) {
+					t.Fatalf("Resolve(%q) = %q, %v; want an OutsideError", tt.path, got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Resolve(%q) = %q, %v; want %q", tt.path, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveRejectsRelativeAndDisabled(t *testing.T) {
+	if _, err := New(nil).Resolve("/srv/repo"); !errors.Is(err, ErrDisabled) {
+		t.Errorf("Resolve without roots = %v, want ErrDisabled", err)
+	}
+	root := t.TempDir()
+	if _, err := New([]string{root}).Resolve("repo/../.."); err == nil {
+		t.Error("Resolve accepted a relative path")
+	}
+}
+
+func TestResolveSymlinkedRoot(t *testing.T) {
+	base := t.TempDir()
+	real := filepath.Join(base, "real")
+	if err := os.MkdirAll(filepath.Join(real, "repo"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(base, "link")
+	if err := os.Symlink(real, link); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+
+	// A root configured through a symlink admits paths written either way
+	s := New([]string{link})
+	for _, path := range []string{filepath.Join(link, "repo"), filepath.Join(real, "repo")} {
+		if _, err := s.Resolve(path); err != nil {
+			t.Errorf("Resolve(%q) = %v", path, err)
+		}
+	}
+}
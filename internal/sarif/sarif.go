@@ -0,0 +1,215 @@
+// Package sarif writes lint findings and secret detections as SARIF 2.1.0 logs, the format GitHub
+// code scanning and most security dashboards import.
+package sarif
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"repo-explanation/internal/buildinfo"
+	"repo-explanation/internal/database"
+	"repo-explanation/internal/secrets"
+)
+
+// Version and Schema identify the SARIF format written
+const (
+	Version = "2.1.0"
+	Schema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+// toolName is the driver name dashboards group results under
+const toolName = "repo-explanation"
+
+// SARIF levels
+const (
+	LevelError   = "error"
+	LevelWarning = "warning"
+	LevelNote    = "note"
+)
+
+// Log is a SARIF log file
+type Log struct {
+	Schema  string `json:"$schema"`
+	Version string `json:"version"`
+	Runs    []*Run `json:"runs"`
+}
+
+// Run is the output of one tool invocation
+type Run struct {
+	Tool    Tool     `json:"tool"`
+	Results []Result `json:"results"`
+
+	rules map[string]int // Index of each rule in Tool.Driver.Rules
+}
+
+// Tool describes the analyzer
+type Tool struct {
+	Driver Driver `json:"driver"`
+}
+
+// Driver is the analyzer and the rules its results refer to
+type Driver struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	Rules   []Rule `json:"rules"`
+}
+
+// Rule is one kind of finding
+type Rule struct {
+	ID                   string        `json:"id"`
+	ShortDescription     Message       `json:"shortDescription"`
+	DefaultConfiguration Configuration `json:"defaultConfiguration"`
+	Properties           *Properties   `json:"properties,omitempty"`
+}
+
+// Configuration holds a rule's default level
+type Configuration struct {
+	Level string `json:"level"`
+}
+
+// Properties tag a rule; GitHub code scanning reads "security" to file it as a security alert
+type Properties struct {
+	Tags []string `json:"tags,omitempty"`
+}
+
+// Message is SARIF text
+type Message struct {
+	Text string `json:"text"`
+}
+
+// Result is one finding
+type Result struct {
+	RuleID    string     `json:"ruleId"`
+	RuleIndex int        `json:"ruleIndex"`
+	Level     string     `json:"level"`
+	Message   Message    `json:"message"`
+	Locations []Location `json:"locations,omitempty"`
+}
+
+// Location points at a file and, when known, a line
+type Location struct {
+	PhysicalLocation PhysicalLocation `json:"physicalLocation"`
+}
+
+// PhysicalLocation is a region of a repository file
+type PhysicalLocation struct {
+	ArtifactLocation ArtifactLocation `json:"artifactLocation"`
+	Region           *Region          `json:"region,omitempty"`
+}
+
+// ArtifactLocation is a file path relative to the repository root
+type ArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+// Region is a position within a file; SARIF lines and columns start at 1
+type Region struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// NewLog returns an empty log
+func NewLog() *Log {
+	return &Log{Schema: Schema, Version: Version, Runs: []*Run{}}
+}
+
+// NewRun adds a run of the analyzer to the log
+func (l *Log) NewRun() *Run {
+	run := &Run{
+		Tool: Tool{Driver: Driver{
+			Name:    toolName,
+			Version: buildinfo.Get().Version,
+			Rules:   []Rule{},
+		}},
+		Results: []Result{},
+		rules:   make(map[string]int),
+	}
+	l.Runs = append(l.Runs, run)
+	return run
+}
+
+// Write encodes the log as indented JSON
+func (l *Log) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(l); err != nil {
+		return fmt.Errorf("failed to encode SARIF: %v", err)
+	}
+	return nil
+}
+
+// Add records a result, registering its rule the first time it is used. A file of "" leaves the
+// result without a location and a line of 0 points at the whole file.
+func (r *Run) Add(ruleID, description, level string, tags []string, message, file string, line, column int) {
+	index, ok := r.rules[ruleID]
+	if !ok {
+		rule := Rule{ID: ruleID, ShortDescription: Message{Text: description}, DefaultConfiguration: Configuration{Level: level}}
+		if len(tags) > 0 {
+			rule.Properties = &Properties{Tags: tags}
+		}
+		index = len(r.Tool.Driver.Rules)
+		r.rules[ruleID] = index
+		r.Tool.Driver.Rules = append(r.Tool.Driver.Rules, rule)
+	}
+
+	result := Result{RuleID: ruleID, RuleIndex: index, Level: level, Message: Message{Text: message}}
+	if file != "" {
+		location := PhysicalLocation{ArtifactLocation: ArtifactLocation{URI: file, URIBaseID: "%SRCROOT%"}}
+		if line > 0 {
+			location.Region = &Region{StartLine: line, StartColumn: column}
+		}
+		result.Locations = []Location{{PhysicalLocation: location}}
+	}
+	r.Results = append(r.Results, result)
+}
+
+// level maps a lint severity to its SARIF level
+func level(severity database.Severity) string {
+	switch severity {
+	case database.SeverityError:
+		return LevelError
+	case database.SeverityWarning:
+		return LevelWarning
+	default:
+		return LevelNote
+	}
+}
+
+// AddMigrationLint adds the findings of a migration lint
+func (r *Run) AddMigrationLint(report *database.LintReport) {
+	for _, finding := range report.Findings {
+		r.Add("migration/"+finding.Rule, "Migration lint: "+finding.Rule, level(finding.Severity), nil,
+			finding.Message, finding.File, finding.Line, 0)
+	}
+}
+
+// AddSchemaLint adds the design findings of the schema the migrations build, located at the
+// migration creating each table when the migration lint saw it
+func (r *Run) AddSchemaLint(quality *database.SchemaLintReport, migrations *database.LintReport) {
+	for _, finding := range quality.Findings {
+		file, line := "", 0
+		if migrations != nil {
+			if definition, ok := migrations.Definition(finding.Table); ok {
+				file, line = definition.File, definition.Line
+			}
+		}
+		r.Add("schema/"+finding.Rule, "Schema lint: "+finding.Rule, level(finding.Severity), nil,
+			finding.Message, file, line, 0)
+	}
+}
+
+// AddCommittedSecrets adds credentials found in the repository's files. Formats of real
+// credentials are errors; passwords in URLs and secret-named env values, which may be local
+// development values, are warnings.
+func (r *Run) AddCommittedSecrets(found []secrets.CommittedSecret) {
+	for _, secret := range found {
+		severity := LevelWarning
+		if secret.Certain {
+			severity = LevelError
+		}
+		r.Add("secret/"+secret.Rule, "Committed secret: "+secret.Rule, severity, []string{"security", "secret"},
+			fmt.Sprintf("%s committed to the repository (%s)", secret.Description, secret.Preview), secret.File, secret.Line, secret.Column)
+	}
+}
@@ -0,0 +1,366 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"repo-explanation/internal/microservices"
+)
+
+// composeFile is the part of a Compose file that says which variables each service receives
+type composeFile struct {
+	Services map[string]yaml.Node `yaml:"services"`
+}
+
+type composeService struct {
+	Build       interface{} `yaml:"build"`
+	Environment interface{} `yaml:"environment"`
+	EnvFile     interface{} `yaml:"env_file"`
+}
+
+// AttributeToServices regroups the project's variables by discovered service. A service gets every
+// variable its code or config reads, wherever that variable is declared, plus the variables its
+// Compose definition passes in. Declared variables no service reads are listed as unattributed.
+func (se *SecretExtractor) AttributeToServices(projectSecrets *ProjectSecrets, services []microservices.DiscoveredService) {
+	if projectSecrets == nil || len(services) == 0 {
+		return
+	}
+
+	var all []SecretVariable
+	all = append(all, projectSecrets.GlobalSecrets...)
+	for _, service := range projectSecrets.Services {
+		all = append(all, service.Variables...)
+	}
+	declared := make(map[string]SecretVariable)
+	for _, variable := range se.deduplicateVariables(all) {
+		declared[variable.Name] = variable
+	}
+
+	variables := make(map[string]map[string]SecretVariable)
+	configFiles := make(map[string][]string)
+	attributed := make(map[string]bool)
+	attribute := func(service, name, file string) {
+		if variables[service] == nil {
+			variables[service] = make(map[string]SecretVariable)
+		}
+		if _, exists := variables[service][name]; exists {
+			return
+		}
+		if variable, ok := declared[name]; ok {
+			variables[service][name] = variable
+			attributed[name] = true
+			return
+		}
+		// Read in code but declared nowhere: worth listing, though nothing says it must be set
+		if file != "" && envNameRegex.MatchString(name) {
+			variables[service][name] = SecretVariable{
+				Name:        name,
+				Description: se.generateDescription(name, ""),
+				Type:        se.determineSecretType(name),
+				Example:     se.generateExample(name),
+				Source:      file,
+			}
+		}
+	}
+
+	// Chart and overlay values belong to the service they deploy, matched by name or else by where
+	// the chart or overlay sits
+	for _, variable := range all {
+		if variable.TargetService == "" {
+			continue
+		}
+		owner := matchComposeService(variable.TargetService, "", services)
+		if owner == "" {
+			owner = microservices.OwningService(filepath.ToSlash(filepath.Dir(variable.Source)), services)
+		}
+		if owner == "" {
+			continue
+		}
+		if variables[owner] == nil {
+			variables[owner] = make(map[string]SecretVariable)
+		}
+		variables[owner][variable.Origin+"\x00"+variable.Name] = variable
+		attributed[variable.Name] = true
+		configFiles[owner] = append(configFiles[owner], variable.Source)
+	}
+
+	// Config files inside a service's directory still belong to it
+	for _, grouped := range projectSecrets.Services {
+		owner := microservices.OwningService(se.relativePath(grouped.ServicePath), services)
+		if owner == "" {
+			continue
+		}
+		for _, variable := range grouped.Variables {
+			if variable.TargetService == "" {
+				attribute(owner, variable.Name, "")
+			}
+		}
+		configFiles[owner] = append(configFiles[owner], grouped.ConfigFiles...)
+	}
+
+	se.walkTextFiles(func(path string, content []byte) {
+		rel := se.relativePath(path)
+		if isComposeFile(filepath.Base(rel)) {
+			for service, names := range se.composeReferences(rel, content, services) {
+				for _, name := range names {
+					attribute(service, name, "")
+				}
+				configFiles[service] = append(configFiles[service], rel)
+			}
+			return
+		}
+
+		owner := microservices.OwningService(rel, services)
+		if owner == "" {
+			return
+		}
+		for name := range referencesIn(string(content), envReferencePatterns) {
+			if _, ok := declared[name]; ok {
+				attribute(owner, name, rel)
+			}
+		}
+		for name := range referencesIn(string(content), codeReadPatterns) {
+			attribute(owner, name, rel)
+		}
+	})
+
+	attributedServices := make([]ServiceSecrets, 0, len(services))
+	for _, service := range services {
+		serviceVariables := make([]SecretVariable, 0, len(variables[service.Name]))
+		for _, variable := range variables[service.Name] {
+			serviceVariables = append(serviceVariables, variable)
+		}
+		sort.Slice(serviceVariables, func(i, j int) bool { return serviceVariables[i].Name < serviceVariables[j].Name })
+
+		attributedServices = append(attributedServices, ServiceSecrets{
+			ServiceName: service.Name,
+			ServicePath: service.Path,
+			Variables:   serviceVariables,
+			ConfigFiles: uniqueSorted(configFiles[service.Name]),
+		})
+	}
+
+	unattributed := []SecretVariable{}
+	for name, variable := range declared {
+		if !attributed[name] {
+			unattributed = append(unattributed, variable)
+		}
+	}
+	sort.Slice(unattributed, func(i, j int) bool { return unattributed[i].Name < unattributed[j].Name })
+
+	projectSecrets.Services = attributedServices
+	projectSecrets.Unattributed = unattributed
+
+	// A variable read by several services is still one variable to set
+	counted := make(map[string]bool)
+	required := make(map[string]bool)
+	count := func(list []SecretVariable) {
+		for _, variable := range list {
+			key := variable.Origin + "\x00" + variable.Name
+			counted[key] = true
+			if variable.Required {
+				required[key] = true
+			}
+		}
+	}
+	count(projectSecrets.GlobalSecrets)
+	count(projectSecrets.Unattributed)
+	for _, service := range projectSecrets.Services {
+		count(service.Variables)
+	}
+	projectSecrets.TotalVariables = len(counted)
+	projectSecrets.RequiredCount = len(required)
+	projectSecrets.Summary = se.generateSummary(len(counted), len(required), len(services))
+}
+
+// composeReferences returns, per discovered service, the variables its Compose definition passes in
+// through environment, env_file or ${VAR} interpolation
+func (se *SecretExtractor) composeReferences(rel string, content []byte, services []microservices.DiscoveredService) map[string][]string {
+	var compose composeFile
+	if err := yaml.Unmarshal(content, &compose); err != nil {
+		return nil
+	}
+
+	composeDir := filepath.ToSlash(filepath.Dir(rel))
+	references := make(map[string][]string)
+	for name, node := range compose.Services {
+		var definition composeService
+		if err := node.Decode(&definition); err != nil {
+			continue
+		}
+		owner := matchComposeService(name, buildContext(definition.Build, composeDir), services)
+		if owner == "" {
+			continue
+		}
+
+		var names []string
+		switch environment := definition.Environment.(type) {
+		case []interface{}:
+			for _, entry := range environment {
+				if text, ok := entry.(string); ok {
+					names = append(names, strings.TrimSpace(strings.SplitN(text, "=", 2)[0]))
+				}
+			}
+		case map[string]interface{}:
+			for key := range environment {
+				names = append(names, key)
+			}
+		}
+
+		var envFiles []string
+		switch envFile := definition.EnvFile.(type) {
+		case string:
+			envFiles = append(envFiles, envFile)
+		case []interface{}:
+			for _, entry := range envFile {
+				switch typed := entry.(type) {
+				case string:
+					envFiles = append(envFiles, typed)
+				case map[string]interface{}:
+					if path, ok := typed["path"].(string); ok {
+						envFiles = append(envFiles, path)
+					}
+				}
+			}
+		}
+		for _, envFile := range envFiles {
+			entries, err := ParseEnvFile(filepath.Join(se.projectPath, filepath.FromSlash(composeDir), envFile))
+			if err != nil {
+				continue
+			}
+			for _, entry := range entries {
+				names = append(names, entry.Name)
+			}
+		}
+
+		if text, err := yaml.Marshal(&node); err == nil {
+			for name := range referencesIn(string(text), interpolationPatterns) {
+				names = append(names, name)
+			}
+		}
+		references[owner] = append(references[owner], names...)
+	}
+	return references
+}
+
+// walkTextFiles calls visit for every readable text file in scope under the project, skipping
+// dependency and build directories and files over 1MB
+func (se *SecretExtractor) walkTextFiles(visit func(path string, content []byte)) error {
+	return filepath.WalkDir(se.projectPath, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			switch d.Name() {
+			case "node_modules", ".git", "vendor", "dist", "build", ".next", "target", "__pycache__", ".venv":
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !se.readable(path) {
+			return nil
+		}
+		if d.Type()&os.ModeSymlink != 0 {
+			if _, inside := se.resolveInside(path); !inside {
+				return nil
+			}
+		}
+		info, err := d.Info()
+		if err != nil || info.Size() > 1024*1024 {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil || isBinary(content) {
+			return nil
+		}
+		visit(path, content)
+		return nil
+	})
+}
+
+// relativePath returns path relative to the project root with forward slashes, "" for the root itself
+func (se *SecretExtractor) relativePath(path string) string {
+	rel, err := filepath.Rel(se.projectPath, path)
+	if err != nil || rel == "." {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
+
+// referencesIn returns the variable names the patterns find in content
+func referencesIn(content string, patterns []*regexp.Regexp) map[string]bool {
+	names := make(map[string]bool)
+	for _, pattern := range patterns {
+		for _, match := range pattern.FindAllStringSubmatch(content, -1) {
+			for _, name := range match[1:] {
+				if name != "" {
+					names[name] = true
+				}
+			}
+		}
+	}
+	return names
+}
+
+func isComposeFile(name string) bool {
+	if !strings.HasSuffix(name, ".yml") && !strings.HasSuffix(name, ".yaml") {
+		return false
+	}
+	return strings.HasPrefix(name, "docker-compose") || strings.HasPrefix(name, "compose")
+}
+
+// matchComposeService matches a Compose service to a discovered service by name or build context
+func matchComposeService(name, context string, services []microservices.DiscoveredService) string {
+	normalized := normalizeServiceName(name)
+	for _, service := range services {
+		if normalizeServiceName(service.Name) == normalized {
+			return service.Name
+		}
+	}
+	if context != "" {
+		for _, service := range services {
+			if strings.Trim(filepath.ToSlash(filepath.Clean(service.Path)), "/") == context {
+				return service.Name
+			}
+		}
+	}
+	return ""
+}
+
+// buildContext returns a Compose build context relative to the project root
+func buildContext(build interface{}, composeDir string) string {
+	var context string
+	switch typed := build.(type) {
+	case string:
+		context = typed
+	case map[string]interface{}:
+		context, _ = typed["context"].(string)
+	}
+	if context == "" {
+		return ""
+	}
+	return filepath.ToSlash(filepath.Clean(filepath.Join(composeDir, context)))
+}
+
+func normalizeServiceName(name string) string {
+	return strings.NewReplacer("-", "", "_", "", ".", "", " ", "").Replace(strings.ToLower(name))
+}
+
+// uniqueSorted returns the distinct entries of list in order
+func uniqueSorted(list []string) []string {
+	seen := make(map[string]bool)
+	result := []string{}
+	for _, entry := range list {
+		if !seen[entry] {
+			seen[entry] = true
+			result = append(result, entry)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,253 @@
+package secrets
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// EnvEntry is one variable assignment in an env file
+type EnvEntry struct {
+	Name  string `json:"name"`
+	Value string `json:"-"` // Never echoed back: it may be a real secret
+	Line  int    `json:"line"`
+}
+
+// MissingVariable is a required variable that is unset or empty
+type MissingVariable struct {
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	Services []string `json:"services,omitempty"`
+	Source   string   `json:"source"`
+}
+
+// CheckResult compares an environment against the variables a project needs
+type CheckResult struct {
+	Source       string            `json:"source"` // Env file path, or "process environment"
+	Checked      int               `json:"checked"`
+	Missing      []MissingVariable `json:"missing"`
+	Placeholders []EnvEntry        `json:"placeholders"`
+	Unused       []EnvEntry        `json:"unused"`
+}
+
+// Passed reports whether every required variable has a real value.
+// Unused variables are only a warning: they do not fail the check.
+func (r *CheckResult) Passed() bool {
+	return len(r.Missing) == 0 && len(r.Placeholders) == 0
+}
+
+// envNameRegex matches conventional environment variable names; lowercase config keys found in YAML are not checked
+var envNameRegex = regexp.MustCompile(`^[A-Z_][A-Z0-9_]*$`)
+
+// codeReadPatterns find variable names read explicitly by source code
+var codeReadPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`os\.(?:Getenv|LookupEnv)\(\s*"([A-Za-z_][A-Za-z0-9_]*)"`),                                                      // Go
+	regexp.MustCompile(`(?:process\.env|import\.meta\.env)(?:\.([A-Za-z_][A-Za-z0-9_]*)|\[\s*['"]([A-Za-z_][A-Za-z0-9_]*)['"])`),       // Node, Vite
+	regexp.MustCompile(`os\.(?:getenv|environ\.get|environ\[)\(?\s*['"]([A-Za-z_][A-Za-z0-9_]*)['"]`),                                  // Python
+	regexp.MustCompile(`ENV(?:\.fetch\(|\[)\s*['"]([A-Za-z_][A-Za-z0-9_]*)['"]`),                                                       // Ruby
+	regexp.MustCompile(`(?:System\.getenv|env::var|std::env::var|Environment\.GetEnvironmentVariable)\(\s*"([A-Za-z_][A-Za-z0-9_]*)"`), // Java, Rust, C#
+}
+
+// interpolationPatterns find ${VAR} and $VAR references in YAML, compose files and shell
+var interpolationPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)`), // ${VAR}
+	regexp.MustCompile(`\$([A-Z_][A-Z0-9_]*)`),         // $VAR
+}
+
+// envReferencePatterns find variable names read by code and configuration
+var envReferencePatterns = append(append(append([]*regexp.Regexp{}, codeReadPatterns...), interpolationPatterns...),
+	regexp.MustCompile(`(?m)^\s*(?:export\s+)?([A-Z_][A-Z0-9_]*)\s*=`),   // KEY= in env templates
+	regexp.MustCompile(`(?m)^\s*(?:ENV|ARG)\s+([A-Za-z_][A-Za-z0-9_]*)`), // Dockerfile
+	regexp.MustCompile(`(?m)^\s*-?\s*([A-Z_][A-Z0-9_]*)\s*:`),            // environment: blocks
+)
+
+// Markers of values copied from a template and never filled in.
+// Unlike isEmptyOrPlaceholder, local addresses and ${...} interpolation are accepted as real values.
+var placeholderMarkers = []string{
+	"your_", "your-", "replace_", "replace-me", "replaceme", "changeme", "change_me", "change-me",
+	"placeholder", "todo", "fixme", "xxx", "***", "example", "insert_", "tbd",
+}
+
+// ParseEnvFile reads KEY=VALUE assignments, accepting export prefixes, quotes and trailing comments
+func ParseEnvFile(path string) ([]EnvEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open env file: %v", err)
+	}
+	defer file.Close()
+
+	var entries []EnvEntry
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		name := strings.TrimSpace(parts[0])
+		value := strings.TrimSpace(parts[1])
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') {
+			if end := strings.IndexByte(value[1:], value[0]); end >= 0 {
+				value = value[1 : end+1]
+			}
+		} else if idx := strings.Index(value, " #"); idx >= 0 {
+			value = strings.TrimSpace(value[:idx])
+		}
+		entries = append(entries, EnvEntry{Name: name, Value: value, Line: lineNum})
+	}
+	return entries, scanner.Err()
+}
+
+// ProcessEnvironment returns the current process environment as entries
+func ProcessEnvironment() []EnvEntry {
+	var entries []EnvEntry
+	for _, pair := range os.Environ() {
+		if parts := strings.SplitN(pair, "=", 2); len(parts) == 2 {
+			entries = append(entries, EnvEntry{Name: parts[0], Value: parts[1]})
+		}
+	}
+	return entries
+}
+
+// CheckEnvironment compares env entries against the project's required variables.
+// Unused variables are only reported when checkUnused is set: a process environment
+// always holds variables (PATH, HOME, ...) the project never reads.
+func (se *SecretExtractor) CheckEnvironment(projectSecrets *ProjectSecrets, entries []EnvEntry, source string, checkUnused bool) (*CheckResult, error) {
+	result := &CheckResult{Source: source, Missing: []MissingVariable{}, Placeholders: []EnvEntry{}, Unused: []EnvEntry{}}
+
+	values := make(map[string]EnvEntry, len(entries))
+	for _, entry := range entries {
+		values[entry.Name] = entry
+	}
+
+	required := make(map[string]*MissingVariable)
+	known := make(map[string]bool)
+	note := func(variable SecretVariable, service string) {
+		known[variable.Name] = true
+		// Chart values and generated Kubernetes secrets are set at deploy time, not in the local environment
+		if !variable.Required || variable.Origin != "" || !isCheckableName(variable.Name) {
+			return
+		}
+		existing, ok := required[variable.Name]
+		if !ok {
+			existing = &MissingVariable{Name: variable.Name, Type: variable.Type, Source: variable.Source}
+			required[variable.Name] = existing
+		}
+		if service != "" {
+			existing.Services = append(existing.Services, service)
+		}
+	}
+	if projectSecrets != nil {
+		for _, variable := range projectSecrets.GlobalSecrets {
+			note(variable, "")
+		}
+		for _, service := range projectSecrets.Services {
+			for _, variable := range service.Variables {
+				note(variable, service.ServiceName)
+			}
+		}
+	}
+
+	result.Checked = len(required)
+	for name, variable := range required {
+		if entry, ok := values[name]; !ok || entry.Value == "" {
+			result.Missing = append(result.Missing, *variable)
+		}
+	}
+	sort.Slice(result.Missing, func(i, j int) bool { return result.Missing[i].Name < result.Missing[j].Name })
+
+	// Placeholders only matter for variables the project reads; the process environment is full of unrelated values
+	for _, entry := range entries {
+		if (checkUnused || known[entry.Name]) && isPlaceholder(entry.Value) {
+			result.Placeholders = append(result.Placeholders, entry)
+		}
+	}
+
+	if checkUnused {
+		referenced, err := se.ReferencedVariables(source)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if !known[entry.Name] && !referenced[entry.Name] {
+				result.Unused = append(result.Unused, entry)
+			}
+		}
+	}
+
+	sort.Slice(result.Placeholders, func(i, j int) bool { return result.Placeholders[i].Line < result.Placeholders[j].Line })
+	sort.Slice(result.Unused, func(i, j int) bool { return result.Unused[i].Line < result.Unused[j].Line })
+	return result, nil
+}
+
+// ReferencedVariables collects every variable name the project's code and configuration read.
+// The env file being checked is skipped so its own assignments do not count as references.
+func (se *SecretExtractor) ReferencedVariables(excludeFile string) (map[string]bool, error) {
+	excluded := ""
+	if excludeFile != "" {
+		if abs, err := filepath.Abs(excludeFile); err == nil {
+			excluded = abs
+		}
+	}
+
+	referenced := make(map[string]bool)
+	err := se.walkTextFiles(func(path string, content []byte) {
+		if abs, err := filepath.Abs(path); err == nil && abs == excluded {
+			return
+		}
+		for name := range referencesIn(string(content), envReferencePatterns) {
+			referenced[name] = true
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan project for variable references: %v", err)
+	}
+	return referenced, nil
+}
+
+// isCheckableName limits the check to environment variable names (TF_VAR_ inputs keep Terraform's casing)
+func isCheckableName(name string) bool {
+	return envNameRegex.MatchString(name) || strings.HasPrefix(name, "TF_VAR_")
+}
+
+// isPlaceholder reports whether a value still looks like template text
+func isPlaceholder(value string) bool {
+	lower := strings.ToLower(strings.TrimSpace(value))
+	if lower == "" {
+		return false
+	}
+	if strings.HasPrefix(lower, "<") && strings.HasSuffix(lower, ">") {
+		return true
+	}
+	for _, marker := range placeholderMarkers {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// isBinary treats content with NUL bytes in its first block as binary
+func isBinary(content []byte) bool {
+	limit := len(content)
+	if limit > 8000 {
+		limit = 8000
+	}
+	for _, b := range content[:limit] {
+		if b == 0 {
+			return true
+		}
+	}
+	return false
+}
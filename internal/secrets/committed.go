@@ -0,0 +1,201 @@
+package secrets
+
+import (
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"repo-explanation/internal/gitignore"
+)
+
+// Committed secret rule identifiers
+const (
+	RulePrivateKey   = "private-key"
+	RuleAWSAccessKey = "aws-access-key"
+	RuleGitHubToken  = "github-token"
+	RuleGitLabToken  = "gitlab-token"
+	RuleSlackToken   = "slack-token"
+	RuleStripeKey    = "stripe-key"
+	RuleGoogleAPIKey = "google-api-key"
+	RuleSendGridKey  = "sendgrid-key"
+	RuleURLPassword  = "url-password"
+	RuleEnvFileValue = "env-file-secret"
+)
+
+// CommittedSecret is a credential written into a file of the repository. The value itself is
+// never kept: Preview shows only its first characters.
+type CommittedSecret struct {
+	Rule        string `json:"rule"`
+	Description string `json:"description"` // What the value is, e.g. "AWS access key ID"
+	Certain     bool   `json:"certain"`     // The value has the format of a real credential, rather than a secret-looking name
+	File        string `json:"file"`
+	Line        int    `json:"line"`
+	Column      int    `json:"column"`
+	Preview     string `json:"preview"`
+}
+
+// committedRule matches one credential format; value is the submatch holding the credential
+type committedRule struct {
+	id          string
+	description string
+	pattern     *regexp.Regexp
+	value       int
+}
+
+// committedRules match credentials by a format specific enough to report on sight
+var committedRules = []committedRule{
+	{RulePrivateKey, "Private key", regexp.MustCompile(`-----BEGIN (?:RSA |EC |DSA |OPENSSH |ENCRYPTED |PGP )?PRIVATE KEY(?: BLOCK)?-----`), 0},
+	{RuleAWSAccessKey, "AWS access key ID", regexp.MustCompile(`\b((?:AKIA|ASIA)[0-9A-Z]{16})\b`), 1},
+	{RuleGitHubToken, "GitHub token", regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`), 1},
+	{RuleGitLabToken, "GitLab personal access token", regexp.MustCompile(`\b(glpat-[A-Za-z0-9_-]{20,})`), 1},
+	{RuleSlackToken, "Slack token", regexp.MustCompile(`\b(xox[abposr]-[A-Za-z0-9-]{10,})`), 1},
+	{RuleStripeKey, "Stripe live secret key", regexp.MustCompile(`\b((?:sk|rk)_live_[A-Za-z0-9]{20,})`), 1},
+	{RuleGoogleAPIKey, "Google API key", regexp.MustCompile(`\b(AIza[0-9A-Za-z_-]{35})`), 1},
+	{RuleSendGridKey, "SendGrid API key", regexp.MustCompile(`\b(SG\.[A-Za-z0-9_-]{22}\.[A-Za-z0-9_-]{43})`), 1},
+	{RuleURLPassword, "Password in a connection URL", regexp.MustCompile(`\b(?:postgres(?:ql)?|mysql|mariadb|mongodb(?:\+srv)?|rediss?|amqps?)://([^:@/\s'"]+):([^@/\s'"]+)@`), 2},
+}
+
+// envAssignment matches KEY=value lines of env files
+var envAssignment = regexp.MustCompile(`^\s*(?:export\s+)?([A-Za-z_][A-Za-z0-9_]*)\s*=\s*(.*)$`)
+
+// strongSecretNames are the name parts that make an env variable a credential rather than config
+var strongSecretNames = []string{"secret", "token", "password", "passwd", "api_key", "apikey", "private_key", "access_key", "credential"}
+
+// minEnvSecretLength is the shortest env file value reported; shorter values are test stubs
+const minEnvSecretLength = 8
+
+// DetectCommitted finds credentials written into the repository: values with the format of a
+// cloud or SaaS credential, passwords in connection URLs, and secret-named variables given a real
+// value in an env file that is not a template. Files the root .gitignore excludes are not
+// committed and are skipped, as are values that read as examples.
+func (se *SecretExtractor) DetectCommitted() ([]CommittedSecret, error) {
+	ignore := gitignore.NewGitIgnore()
+	if err := ignore.LoadFromFile(filepath.Join(se.projectPath, ".gitignore")); err != nil {
+		return nil, err
+	}
+
+	var found []CommittedSecret
+	err := se.walkTextFiles(func(path string, content []byte) {
+		rel := se.relativePath(path)
+		if rel == "" || isIgnored(ignore, rel) {
+			return
+		}
+		envFile := isCommittedEnvFile(filepath.Base(path))
+		for i, line := range strings.Split(string(content), "\n") {
+			matched := false
+			for _, rule := range committedRules {
+				for _, m := range rule.pattern.FindAllStringSubmatchIndex(line, -1) {
+					value := line[m[2*rule.value]:m[2*rule.value+1]]
+					if isExampleValue(value) || isReference(value) {
+						continue
+					}
+					if rule.id == RuleURLPassword && value == line[m[2]:m[3]] {
+						continue // user:user, a local development default
+					}
+					matched = true
+					found = append(found, CommittedSecret{
+						Rule: rule.id, Description: rule.description, Certain: rule.id != RuleURLPassword,
+						File: rel, Line: i + 1, Column: m[2*rule.value] + 1, Preview: maskValue(value),
+					})
+				}
+			}
+			if envFile && !matched {
+				if secret, ok := envFileSecret(line); ok {
+					secret.File, secret.Line = rel, i+1
+					found = append(found, secret)
+				}
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(found, func(i, j int) bool {
+		if found[i].File != found[j].File {
+			return found[i].File < found[j].File
+		}
+		return found[i].Line < found[j].Line
+	})
+	return found, nil
+}
+
+// isIgnored reports whether the .gitignore excludes a file or one of its directories
+func isIgnored(ignore *gitignore.GitIgnore, rel string) bool {
+	for i, ch := range rel {
+		if ch == '/' && ignore.IsIgnored(rel[:i], true) {
+			return true
+		}
+	}
+	return ignore.IsIgnored(rel, false)
+}
+
+// isCommittedEnvFile reports whether a file is an env file holding real values rather than a template
+func isCommittedEnvFile(name string) bool {
+	lower := strings.ToLower(name)
+	if lower != ".env" && !strings.HasPrefix(lower, ".env.") && !strings.HasSuffix(lower, ".env") {
+		return false
+	}
+	for _, marker := range []string{"example", "sample", "template", "dist", "defaults"} {
+		if strings.Contains(lower, marker) {
+			return false
+		}
+	}
+	return true
+}
+
+// envFileSecret reports a secret-named assignment with a real value on an env file line
+func envFileSecret(line string) (CommittedSecret, bool) {
+	m := envAssignment.FindStringSubmatchIndex(line)
+	if m == nil {
+		return CommittedSecret{}, false
+	}
+	name, value := line[m[2]:m[3]], line[m[4]:m[5]]
+	if hash := strings.Index(value, " #"); hash >= 0 {
+		value = value[:hash]
+	}
+	value = strings.Trim(strings.TrimSpace(value), `"'`)
+	lowerName := strings.ToLower(name)
+	strong := false
+	for _, part := range strongSecretNames {
+		if strings.Contains(lowerName, part) {
+			strong = true
+			break
+		}
+	}
+	if !strong || len(value) < minEnvSecretLength || isReference(value) || isPlaceholder(value) || isExampleValue(value) {
+		return CommittedSecret{}, false
+	}
+	return CommittedSecret{
+		Rule: RuleEnvFileValue, Description: "Value of " + name + " in an env file",
+		Column: strings.Index(line, value) + 1, Preview: maskValue(value),
+	}, true
+}
+
+// isExampleValue reports values that documentation and tests use in place of real credentials
+func isExampleValue(value string) bool {
+	lower := strings.ToLower(value)
+	for _, marker := range []string{"example", "dummy", "fake", "test", "sample", "placeholder", "changeme", "xxxx", "password", "passwd"} {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// isReference reports values filled in elsewhere: ${VAR} and $VAR interpolation, format verbs and templates
+func isReference(value string) bool {
+	return strings.HasPrefix(value, "$") || strings.HasPrefix(value, "%") || strings.ContainsAny(value, "{<")
+}
+
+// maskValue keeps the first four characters of a credential, enough to recognize which one it is
+func maskValue(value string) string {
+	if strings.HasPrefix(value, "-----BEGIN") {
+		return value
+	}
+	if len(value) <= 4 {
+		return strings.Repeat("*", len(value))
+	}
+	return value[:4] + strings.Repeat("*", 8)
+}
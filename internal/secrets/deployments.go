@@ -0,0 +1,330 @@
+package secrets
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// {{ .Values.secrets.apiKey }}, {{ $.Values.db.password | quote }}
+	helmValueRegex = regexp.MustCompile(`\.Values\.([A-Za-z_][A-Za-z0-9_]*(?:\.[A-Za-z_][A-Za-z0-9_]*)*)`)
+	// {{ required "apiKey is required" .Values.apiKey }}
+	helmRequiredRegex = regexp.MustCompile("required\\s+(?:\"([^\"]*)\"|`([^`]*)`)\\s+\\(?\\$?\\.Values\\.([A-Za-z0-9_.]+)")
+	// {{ default "x" .Values.y }} and {{ .Values.y | default "x" }}
+	helmDefaultRegex     = regexp.MustCompile(`default\s+(?:"[^"]*"|\S+)\s+\(?\$?\.Values\.([A-Za-z0-9_.]+)`)
+	helmPipeDefaultRegex = regexp.MustCompile(`\.Values\.([A-Za-z0-9_.]+)\s*\|\s*default\b`)
+
+	// Suffixes dropped from a secret generator's name to guess the service it configures
+	generatorSuffixRegex = regexp.MustCompile(`(?i)[-_.]?(secrets?|env|credentials|creds|config)$`)
+)
+
+// isHelmChart reports whether dir holds a Helm chart
+func isHelmChart(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, "Chart.yaml"))
+	return err == nil
+}
+
+// isKustomization reports whether a file is a Kustomize kustomization
+func isKustomization(fileName string) bool {
+	return fileName == "kustomization.yaml" || fileName == "kustomization.yml" || fileName == "Kustomization"
+}
+
+// isDeploymentFile reports whether a config file is a Helm chart or a kustomization; their values
+// belong to the service they deploy rather than to the directory they sit in
+func isDeploymentFile(path string) bool {
+	fileName := filepath.Base(path)
+	return fileName == "Chart.yaml" || isKustomization(fileName)
+}
+
+// parseHelmChart reports the values a chart's templates read that values.yaml leaves empty or set
+// to a placeholder, and those the templates mark with required. Values that only look like
+// configuration (replicaCount, nodeSelector) are not reported when empty.
+func (se *SecretExtractor) parseHelmChart(chartFile string) []SecretVariable {
+	chartDir := filepath.Dir(chartFile)
+	rel := se.relativePath(chartDir)
+	if rel == "" {
+		rel = "."
+	}
+
+	var chart struct {
+		Name string `yaml:"name"`
+	}
+	if content, err := os.ReadFile(chartFile); err == nil {
+		yaml.Unmarshal(content, &chart)
+	}
+	if chart.Name == "" {
+		chart.Name = filepath.Base(chartDir)
+	}
+	fmt.Printf("🔍 [DEBUG] Parsing Helm chart: %s (%s)\n", chart.Name, rel)
+
+	values := make(map[string]string)
+	valuesFile := filepath.Join(chartDir, "values.yaml")
+	if content, err := os.ReadFile(valuesFile); err == nil {
+		var tree map[string]interface{}
+		if yaml.Unmarshal(content, &tree) == nil {
+			flattenValues("", tree, values)
+		}
+	}
+
+	referenced := make(map[string]bool)
+	required := make(map[string]string)
+	defaulted := make(map[string]bool)
+	filepath.WalkDir(filepath.Join(chartDir, "templates"), func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		text := string(content)
+		for _, match := range helmValueRegex.FindAllStringSubmatch(text, -1) {
+			referenced[match[1]] = true
+		}
+		for _, match := range helmRequiredRegex.FindAllStringSubmatch(text, -1) {
+			required[match[3]] = match[1] + match[2]
+		}
+		for _, pattern := range []*regexp.Regexp{helmDefaultRegex, helmPipeDefaultRegex} {
+			for _, match := range pattern.FindAllStringSubmatch(text, -1) {
+				defaulted[match[1]] = true
+			}
+		}
+		return nil
+	})
+
+	paths := make([]string, 0, len(referenced))
+	for path := range referenced {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	origin := fmt.Sprintf("helm chart %s (%s)", chart.Name, rel)
+	source := se.relativePath(valuesFile)
+	var variables []SecretVariable
+	for _, path := range paths {
+		value, set := values[path]
+		if !set && hasValuesUnder(values, path) {
+			continue // A map or list rendered whole, such as resources or env
+		}
+		if set && !se.isEmptyOrPlaceholder(value) {
+			continue
+		}
+		key := path[strings.LastIndex(path, ".")+1:]
+		message, isRequired := required[path]
+		if !isRequired && (defaulted[path] || !se.looksLikeSecret(key)) {
+			continue
+		}
+
+		description := se.generateDescription(key, value)
+		if message != "" {
+			description = message
+		}
+		variables = append(variables, SecretVariable{
+			Name:          path,
+			Description:   fmt.Sprintf("Helm value for chart %s (--set %s=...). %s", chart.Name, path, description),
+			Type:          se.determineSecretType(key),
+			Example:       se.generateExample(key),
+			Required:      true,
+			Source:        source,
+			Origin:        origin,
+			TargetService: chart.Name,
+		})
+		fmt.Printf("   ✓ Found Helm value: %s (required: %t, set: %t)\n", path, isRequired, set)
+	}
+	return variables
+}
+
+// flattenValues maps every scalar in a values tree to its dotted path
+func flattenValues(prefix string, tree map[string]interface{}, values map[string]string) {
+	for key, value := range tree {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		switch typed := value.(type) {
+		case map[string]interface{}:
+			if len(typed) == 0 {
+				values[path] = ""
+			}
+			flattenValues(path, typed, values)
+		case []interface{}:
+			if len(typed) == 0 {
+				values[path] = ""
+			} else {
+				values[path+".[]"] = fmt.Sprint(len(typed))
+			}
+		case nil:
+			values[path] = ""
+		default:
+			values[path] = fmt.Sprint(typed)
+		}
+	}
+}
+
+// hasValuesUnder reports whether values.yaml sets anything below path
+func hasValuesUnder(values map[string]string, path string) bool {
+	for key := range values {
+		if strings.HasPrefix(key, path+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// kustomization is the part of a kustomization that generates secrets
+type kustomization struct {
+	NamePrefix      string            `yaml:"namePrefix"`
+	SecretGenerator []secretGenerator `yaml:"secretGenerator"`
+}
+
+type secretGenerator struct {
+	Name     string   `yaml:"name"`
+	Literals []string `yaml:"literals"`
+	Envs     []string `yaml:"envs"`
+	Env      string   `yaml:"env"` // Older single-file form
+	Files    []string `yaml:"files"`
+}
+
+// parseKustomization reports the values a kustomization's secretGenerator entries need: literals
+// left empty or set to a placeholder, keys of env files with such values, and env or key files
+// that are not in the repository, which are usually kept out of git on purpose
+func (se *SecretExtractor) parseKustomization(path string) []SecretVariable {
+	overlayDir := filepath.Dir(path)
+	rel := se.relativePath(overlayDir)
+	if rel == "" {
+		rel = "."
+	}
+	source := se.relativePath(path)
+	fmt.Printf("🔍 [DEBUG] Parsing Kustomize overlay: %s\n", rel)
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var k kustomization
+	if err := yaml.Unmarshal(content, &k); err != nil {
+		fmt.Printf("⚠️ [DEBUG] Could not parse kustomization %s: %v\n", source, err)
+		return nil
+	}
+
+	origin := "kustomize overlay " + rel
+	var variables []SecretVariable
+	for _, generator := range k.SecretGenerator {
+		target := generatorSuffixRegex.ReplaceAllString(generator.Name, "")
+		if target == "" {
+			target = generator.Name
+		}
+		add := func(name, description, secretType, example, fileSource string) {
+			variables = append(variables, SecretVariable{
+				Name:          name,
+				Description:   fmt.Sprintf("Kustomize secret %s%s: %s", k.NamePrefix, generator.Name, description),
+				Type:          secretType,
+				Example:       example,
+				Required:      true,
+				Source:        fileSource,
+				Origin:        origin,
+				TargetService: target,
+			})
+			fmt.Printf("   ✓ Found secret generator value: %s (%s)\n", name, generator.Name)
+		}
+
+		for _, literal := range generator.Literals {
+			parts := strings.SplitN(literal, "=", 2)
+			key := strings.TrimSpace(parts[0])
+			value := ""
+			if len(parts) == 2 {
+				value = strings.Trim(strings.TrimSpace(parts[1]), `"'`)
+			}
+			if key != "" && se.isEmptyOrPlaceholder(value) {
+				add(key, se.generateDescription(key, value), se.determineSecretType(key), se.generateExample(key), source)
+			}
+		}
+
+		envFiles := generator.Envs
+		if generator.Env != "" {
+			envFiles = append(envFiles, generator.Env)
+		}
+		for _, envFile := range envFiles {
+			envPath := filepath.Join(overlayDir, filepath.FromSlash(envFile))
+			entries, err := ParseEnvFile(envPath)
+			if err != nil {
+				add(envFile, fmt.Sprintf("env file %s is not in the repository; create it with the keys the service reads", envFile), "secret", "", source)
+				continue
+			}
+			for _, entry := range entries {
+				if se.isEmptyOrPlaceholder(entry.Value) {
+					add(entry.Name, se.generateDescription(entry.Name, entry.Value), se.determineSecretType(entry.Name), se.generateExample(entry.Name), se.relativePath(envPath))
+				}
+			}
+		}
+
+		for _, file := range generator.Files {
+			key, filePath := filepath.Base(file), file
+			if parts := strings.SplitN(file, "=", 2); len(parts) == 2 {
+				key, filePath = parts[0], parts[1]
+			}
+			if _, err := os.Stat(filepath.Join(overlayDir, filepath.FromSlash(filePath))); err != nil {
+				add(key, fmt.Sprintf("file %s is not in the repository; provide it before building the overlay", filePath), "credential", "", source)
+			}
+		}
+	}
+	return variables
+}
+
+// extractDeploymentSecrets collects the variables of Helm charts and kustomizations, which configure
+// the service they deploy wherever they sit in the repository, in file order
+func (se *SecretExtractor) extractDeploymentSecrets(deploymentFiles []string, parsed map[string][]SecretVariable) []SecretVariable {
+	var variables []SecretVariable
+	for _, file := range deploymentFiles {
+		variables = append(variables, parsed[file]...)
+	}
+	return variables
+}
+
+// parseDeploymentFile parses a Helm chart or a kustomization
+func (se *SecretExtractor) parseDeploymentFile(file string) []SecretVariable {
+	if filepath.Base(file) == "Chart.yaml" {
+		return se.parseHelmChart(file)
+	}
+	return se.parseKustomization(file)
+}
+
+// mergeDeploymentSecrets files chart and overlay values under the service they deploy, adding a
+// service when no config directory matches it. A single-service project gets them all.
+func (se *SecretExtractor) mergeDeploymentSecrets(services []ServiceSecrets, variables []SecretVariable, isMonorepo bool) []ServiceSecrets {
+	touched := make(map[int]bool)
+	for _, variable := range variables {
+		index := -1
+		if !isMonorepo && len(services) > 0 {
+			index = 0
+		}
+		for i := range services {
+			if index < 0 && normalizeServiceName(services[i].ServiceName) == normalizeServiceName(variable.TargetService) {
+				index = i
+			}
+		}
+		if index < 0 {
+			services = append(services, ServiceSecrets{
+				ServiceName: variable.TargetService,
+				ServicePath: filepath.Join(se.projectPath, filepath.FromSlash(filepath.Dir(variable.Source))),
+				Variables:   []SecretVariable{},
+				ConfigFiles: []string{},
+			})
+			index = len(services) - 1
+		}
+		services[index].Variables = append(services[index].Variables, variable)
+		services[index].ConfigFiles = append(services[index].ConfigFiles, variable.Source)
+		touched[index] = true
+	}
+	for i := range touched {
+		services[i].Variables = se.deduplicateVariables(services[i].Variables)
+		services[i].ConfigFiles = uniqueSorted(services[i].ConfigFiles)
+	}
+	return services
+}
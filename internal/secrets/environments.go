@@ -0,0 +1,198 @@
+package secrets
+
+import (
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// EnvironmentSecrets lists the variables one deployment environment needs
+type EnvironmentSecrets struct {
+	Name      string           `json:"name"`      // production, staging, development, test, ...
+	Files     []string         `json:"files"`     // Its own config files, relative to the project
+	Variables []SecretVariable `json:"variables"` // Declared by its files and the base files they override
+	Specific  []string         `json:"specific"`  // Names needed here but not in every environment
+}
+
+// environmentAliases maps the environment names used in file and folder names to one spelling
+var environmentAliases = map[string]string{
+	"production": "production", "prod": "production", "prd": "production", "live": "production",
+	"staging": "staging", "stage": "staging", "stg": "staging",
+	"development": "development", "develop": "development", "dev": "development",
+	"test": "test", "testing": "test",
+	"qa": "qa", "uat": "uat", "preview": "preview", "sandbox": "sandbox", "demo": "demo",
+	"integration": "integration", "perf": "perf", "local": "local",
+}
+
+// environmentOrder sorts environments from production down; others follow alphabetically
+var environmentOrder = []string{"production", "staging", "uat", "qa", "preview", "demo", "sandbox", "integration", "perf", "test", "development", "local"}
+
+// environmentDirs are folders whose subfolders are named after environments (Kustomize overlays,
+// Terraform and config trees)
+var environmentDirs = map[string]bool{
+	"environments": true, "environment": true, "envs": true, "env": true, "overlays": true, "stages": true,
+	"config": true, "configs": true, "deploy": true, "deployments": true, "k8s": true, "kubernetes": true, "helm": true,
+}
+
+// suffixedNameRegex splits names such as values-staging.yaml, application-prod.properties and
+// docker-compose.prod.yml into their base name, environment and extension
+var suffixedNameRegex = regexp.MustCompile(`^(.+?)[-_.]([A-Za-z]+)\.(ya?ml|properties|json|toml|tfvars|conf|ini)$`)
+
+// bareNameRegex matches files named after the environment alone, such as production.yaml
+var bareNameRegex = regexp.MustCompile(`^([A-Za-z]+)\.(ya?ml|properties|json|toml|tfvars|conf|ini)$`)
+
+// environmentOf returns the environment a config file configures, or "" for files that apply to
+// every environment, and the base file it overrides (relative to the project), if any
+func environmentOf(rel string) (environment, base string) {
+	dir, name := path.Split(rel)
+	lower := strings.ToLower(name)
+
+	switch {
+	case strings.HasPrefix(lower, ".env."):
+		// .env.production, .env.production.local; .env.example and the like apply everywhere
+		part := strings.SplitN(strings.TrimPrefix(lower, ".env."), ".", 2)[0]
+		if env, ok := environmentAliases[part]; ok {
+			return env, dir + ".env"
+		}
+	case suffixedNameRegex.MatchString(name):
+		m := suffixedNameRegex.FindStringSubmatch(name)
+		if env, ok := environmentAliases[strings.ToLower(m[2])]; ok {
+			return env, dir + m[1] + "." + m[3]
+		}
+	}
+	if m := bareNameRegex.FindStringSubmatch(name); m != nil {
+		if env, ok := environmentAliases[strings.ToLower(m[1])]; ok {
+			return env, ""
+		}
+	}
+
+	// overlays/production/kustomization.yaml, environments/staging/terraform.tfvars
+	segments := strings.Split(strings.Trim(dir, "/"), "/")
+	for i := len(segments) - 1; i > 0; i-- {
+		if env, ok := environmentAliases[strings.ToLower(segments[i])]; ok && environmentDirs[strings.ToLower(segments[i-1])] {
+			return env, ""
+		}
+	}
+	return "", ""
+}
+
+// groupByEnvironment tags the variables of environment-specific files with their environment and
+// lists what each environment needs. Base files (.env next to .env.production, values.yaml next to
+// values-staging.yaml) apply to every environment, and so does a variable every environment
+// declares. It returns nil when no file is environment-specific.
+func (se *SecretExtractor) groupByEnvironment(parsed ...map[string][]SecretVariable) ([]EnvironmentSecrets, []string) {
+	files := make(map[string]string) // Relative path to environment
+	bases := make(map[string]bool)
+	variables := make(map[string][]SecretVariable)
+	for _, byFile := range parsed {
+		for _, file := range sortedFiles(byFile) {
+			vars := byFile[file]
+			rel := se.relativePath(file)
+			env, base := environmentOf(rel)
+			if env == "" {
+				continue
+			}
+			files[rel] = env
+			if base != "" {
+				bases[base] = true
+			}
+			for i := range vars {
+				vars[i].Environments = []string{env}
+			}
+			variables[env] = append(variables[env], vars...)
+		}
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	var baseVars []SecretVariable
+	for _, byFile := range parsed {
+		for _, file := range sortedFiles(byFile) {
+			if bases[se.relativePath(file)] {
+				baseVars = append(baseVars, byFile[file]...)
+			}
+		}
+	}
+
+	names := make(map[string]map[string]bool) // Environment to the names it needs
+	for env, vars := range variables {
+		names[env] = make(map[string]bool)
+		for _, v := range vars {
+			names[env][v.Name] = true
+		}
+	}
+	everywhere := make(map[string]bool)
+	for _, v := range baseVars {
+		everywhere[v.Name] = true
+	}
+	for name := range names[firstKey(names)] {
+		inAll := true
+		for env := range names {
+			inAll = inAll && names[env][name]
+		}
+		if inAll {
+			everywhere[name] = true
+		}
+	}
+
+	var environments []EnvironmentSecrets
+	for env, vars := range variables {
+		group := EnvironmentSecrets{Name: env, Files: []string{}, Specific: []string{}}
+		for rel, fileEnv := range files {
+			if fileEnv == env {
+				group.Files = append(group.Files, rel)
+			}
+		}
+		sort.Strings(group.Files)
+		group.Variables = se.deduplicateVariables(append(append([]SecretVariable{}, baseVars...), vars...))
+		sort.Slice(group.Variables, func(i, j int) bool { return group.Variables[i].Name < group.Variables[j].Name })
+		for name := range names[env] {
+			if !everywhere[name] {
+				group.Specific = append(group.Specific, name)
+			}
+		}
+		sort.Strings(group.Specific)
+		environments = append(environments, group)
+	}
+	sort.Slice(environments, func(i, j int) bool {
+		ri, rj := environmentRank(environments[i].Name), environmentRank(environments[j].Name)
+		if ri != rj {
+			return ri < rj
+		}
+		return environments[i].Name < environments[j].Name
+	})
+
+	required := make([]string, 0, len(everywhere))
+	for name := range everywhere {
+		required = append(required, name)
+	}
+	sort.Strings(required)
+	return environments, required
+}
+
+func environmentRank(env string) int {
+	for i, name := range environmentOrder {
+		if name == env {
+			return i
+		}
+	}
+	return len(environmentOrder)
+}
+
+func sortedFiles(byFile map[string][]SecretVariable) []string {
+	files := make([]string, 0, len(byFile))
+	for file := range byFile {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files
+}
+
+func firstKey(m map[string]map[string]bool) string {
+	for key := range m {
+		return key
+	}
+	return ""
+}
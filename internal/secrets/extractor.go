@@ -2,21 +2,31 @@ package secrets
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
+
+	"repo-explanation/internal/infrastructure"
+	"repo-explanation/internal/microservices"
 )
 
 // SecretVariable represents a required environment variable or secret
 type SecretVariable struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Type        string `json:"type"` // "api_key", "database_url", "secret", "config", "credential"
-	Example     string `json:"example,omitempty"`
-	Required    bool   `json:"required"`
-	Source      string `json:"source"` // file where it was found
+	Name          string `json:"name"`
+	Description   string `json:"description"`
+	Type          string `json:"type"` // "api_key", "database_url", "secret", "config", "credential"
+	Example       string `json:"example,omitempty"`
+	Required      bool   `json:"required"`
+	Source        string `json:"source"`                   // file where it was found
+	Origin        string `json:"origin,omitempty"`         // Helm chart or Kustomize overlay that needs the value
+	TargetService string `json:"target_service,omitempty"` // Service the chart or overlay deploys
+	Environments  []string `json:"environments,omitempty"`   // Environments whose own config files declare it
 }
 
 // ServiceSecrets represents secrets for a specific service/project
@@ -32,14 +42,76 @@ type ProjectSecrets struct {
 	ProjectType     string           `json:"project_type"`     // "monorepo", "single-service"
 	Services        []ServiceSecrets `json:"services"`
 	GlobalSecrets   []SecretVariable `json:"global_secrets"`   // project-wide secrets
+	Unattributed    []SecretVariable `json:"unattributed,omitempty"` // declared but read by no discovered service
+	Environments    []EnvironmentSecrets `json:"environments,omitempty"`        // per deployment environment, when config files are environment-specific
+	RequiredEverywhere []string          `json:"required_everywhere,omitempty"` // needed in every environment
 	TotalVariables  int              `json:"total_variables"`
 	RequiredCount   int              `json:"required_count"`
 	Summary         string           `json:"summary"`
 }
 
+// Stable returns a copy for comparing results across runs, with services, variables and config
+// files sorted and paths made relative to root
+func (p *ProjectSecrets) Stable(root string) *ProjectSecrets {
+	relative := func(path string) string {
+		if rel, err := filepath.Rel(root, path); err == nil && filepath.IsAbs(path) {
+			return filepath.ToSlash(rel)
+		}
+		return filepath.ToSlash(path)
+	}
+	variables := func(vars []SecretVariable) []SecretVariable {
+		stable := make([]SecretVariable, len(vars))
+		for i, v := range vars {
+			v.Source = relative(v.Source)
+			stable[i] = v
+		}
+		sort.SliceStable(stable, func(i, j int) bool {
+			if stable[i].Name != stable[j].Name {
+				return stable[i].Name < stable[j].Name
+			}
+			if stable[i].Source != stable[j].Source {
+				return stable[i].Source < stable[j].Source
+			}
+			return stable[i].Origin < stable[j].Origin
+		})
+		return stable
+	}
+
+	stable := *p
+	stable.GlobalSecrets = variables(p.GlobalSecrets)
+	if p.Unattributed != nil {
+		stable.Unattributed = variables(p.Unattributed)
+	}
+	if p.Environments != nil {
+		stable.Environments = make([]EnvironmentSecrets, len(p.Environments))
+		for i, env := range p.Environments {
+			env.Variables = variables(env.Variables)
+			stable.Environments[i] = env
+		}
+	}
+	stable.Services = make([]ServiceSecrets, len(p.Services))
+	for i, service := range p.Services {
+		service.ServicePath = relative(service.ServicePath)
+		service.Variables = variables(service.Variables)
+		configFiles := make([]string, len(service.ConfigFiles))
+		for j, file := range service.ConfigFiles {
+			configFiles[j] = relative(file)
+		}
+		sort.Strings(configFiles)
+		service.ConfigFiles = configFiles
+		stable.Services[i] = service
+	}
+	sort.SliceStable(stable.Services, func(i, j int) bool {
+		return stable.Services[i].ServicePath < stable.Services[j].ServicePath
+	})
+	return &stable
+}
+
 // SecretExtractor analyzes configuration files to find required secrets
 type SecretExtractor struct {
 	projectPath string
+	parsed      map[string][]SecretVariable // Variables of each config file, parsed up front
+	inScope     func(relPath string) bool   // Files it may read, by slash path under projectPath; nil reads all
 }
 
 // NewSecretExtractor creates a new secret extractor
@@ -49,18 +121,58 @@ func NewSecretExtractor(projectPath string) *SecretExtractor {
 	}
 }
 
+// SetScope limits the files the extractor reads to those inScope accepts, given their slash path
+// relative to the project
+func (se *SecretExtractor) SetScope(inScope func(relPath string) bool) {
+	se.inScope = inScope
+}
+
+// readable reports whether the file at path is within the scope set with SetScope
+func (se *SecretExtractor) readable(path string) bool {
+	return se.inScope == nil || se.inScope(se.relativePath(path))
+}
+
 // ExtractSecrets analyzes the project and extracts all required secrets
 func (se *SecretExtractor) ExtractSecrets() (*ProjectSecrets, error) {
+	return se.ExtractSecretsContext(context.Background())
+}
+
+// ExtractSecretsContext extracts secrets under ctx, stopping the walk and the parsing when it is
+// cancelled
+func (se *SecretExtractor) ExtractSecretsContext(ctx context.Context) (*ProjectSecrets, error) {
 	fmt.Printf("🔐 [DEBUG] Starting secret extraction for project: %s\n", se.projectPath)
 	
 	// Find all config files in the project
-	configFiles, err := se.findConfigFiles()
+	configFiles, err := se.findConfigFiles(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find config files: %v", err)
 	}
 	
 	fmt.Printf("🔍 [DEBUG] Found %d config files to analyze\n", len(configFiles))
 	
+	// Helm charts and kustomizations configure the service they deploy, wherever they sit
+	var deploymentFiles []string
+	var otherFiles []string
+	for _, file := range configFiles {
+		if isDeploymentFile(file) {
+			deploymentFiles = append(deploymentFiles, file)
+		} else {
+			otherFiles = append(otherFiles, file)
+		}
+	}
+	configFiles = otherFiles
+	
+	// Parse every file once, concurrently; services and global secrets share root files
+	se.parsed = se.parseAll(ctx, configFiles, se.parseConfigFile)
+	defer func() { se.parsed = nil }()
+	deploymentVars := se.parseAll(ctx, deploymentFiles, se.parseDeploymentFile)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	
+	// Tag variables from environment-specific files before services merge them
+	environments, requiredEverywhere := se.groupByEnvironment(se.parsed, deploymentVars)
+	
 	// Determine if this is a monorepo or single service
 	isMonorepo := se.isMonorepo(configFiles)
 	
@@ -75,6 +187,10 @@ func (se *SecretExtractor) ExtractSecrets() (*ProjectSecrets, error) {
 		services = []ServiceSecrets{singleService}
 	}
 	
+	if len(deploymentFiles) > 0 {
+		services = se.mergeDeploymentSecrets(services, se.extractDeploymentSecrets(deploymentFiles, deploymentVars), isMonorepo)
+	}
+	
 	// Extract global/project-wide secrets
 	globalSecrets = se.extractGlobalSecrets(configFiles)
 	
@@ -104,74 +220,216 @@ func (se *SecretExtractor) ExtractSecrets() (*ProjectSecrets, error) {
 	
 	summary := se.generateSummary(totalVars, requiredCount, len(services))
 	
+	if len(environments) > 0 {
+		summary += fmt.Sprintf(" Config differs across %d environments; %d variables are needed in every one.", len(environments), len(requiredEverywhere))
+	}
+	
 	return &ProjectSecrets{
-		ProjectType:    projectType,
-		Services:       services,
-		GlobalSecrets:  globalSecrets,
-		TotalVariables: totalVars,
-		RequiredCount:  requiredCount,
-		Summary:        summary,
+		ProjectType:        projectType,
+		Services:           services,
+		GlobalSecrets:      globalSecrets,
+		Environments:       environments,
+		RequiredEverywhere: requiredEverywhere,
+		TotalVariables:     totalVars,
+		RequiredCount:      requiredCount,
+		Summary:            summary,
 	}, nil
 }
 
-// findConfigFiles searches for configuration files in the project
-func (se *SecretExtractor) findConfigFiles() ([]string, error) {
+// findConfigFiles searches for configuration files in the project. Symlinks are followed only
+// while they resolve inside the project, so a link to a home directory or /etc is never read.
+func (se *SecretExtractor) findConfigFiles(ctx context.Context) ([]string, error) {
 	var configFiles []string
 	
 	fmt.Printf("🔍 [DEBUG] Searching for config files in: %s\n", se.projectPath)
 	
-	// Walk through project directory
-	err := filepath.Walk(se.projectPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil // Skip files we can't read
-		}
+	root := se.realRoot()
+	err := se.walkConfigFiles(ctx, se.projectPath, root, map[string]bool{root: true}, &configFiles)
+	
+	fmt.Printf("✅ [DEBUG] Found %d config files total\n", len(configFiles))
+	for i, file := range configFiles {
+		fmt.Printf("   %d. %s\n", i+1, file)
+	}
+	
+	return configFiles, err
+}
+
+// walkConfigFiles collects the config files under dir, whose resolved path is real. ancestors
+// holds the resolved directories being walked, so a symlink back to one of them is not followed.
+func (se *SecretExtractor) walkConfigFiles(ctx context.Context, dir, real string, ancestors map[string]bool, configFiles *[]string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil // Skip directories we can't read
+	}
+	
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		entryReal := filepath.Join(real, entry.Name())
+		isDir := entry.IsDir()
 		
-		if info.IsDir() {
-			// Skip certain directories
-			dirName := filepath.Base(path)
-			if dirName == "node_modules" || dirName == ".git" || dirName == "vendor" || dirName == "dist" || dirName == "build" {
-				return filepath.SkipDir
+		if entry.Type()&os.ModeSymlink != 0 {
+			target, ok := se.resolveInside(path)
+			if !ok {
+				continue
+			}
+			info, err := os.Stat(target)
+			if err != nil {
+				continue
+			}
+			entryReal, isDir = target, info.IsDir()
+			if isDir && ancestors[target] {
+				fmt.Printf("⏭️  [DEBUG] Skipping symlink loop: %s\n", path)
+				continue
 			}
-			return nil
-		}
-		
-		fileName := filepath.Base(path)
-		fileExt := filepath.Ext(fileName)
-		
-		// Check for specific patterns
-		isConfigFile := false
-		
-		// Check for .env files (any file starting with .env)
-		if strings.HasPrefix(fileName, ".env") {
-			isConfigFile = true
-			fmt.Printf("📋 [DEBUG] Found .env file: %s\n", path)
-		}
-		
-		// Check for .yaml and .yml files
-		if fileExt == ".yaml" || fileExt == ".yml" {
-			isConfigFile = true
-			fmt.Printf("📋 [DEBUG] Found YAML file: %s\n", path)
 		}
 		
-		// Check for other common config files
-		if fileName == "config.json" || fileName == "application.properties" || fileName == "docker-compose.yml" || fileName == "docker-compose.yaml" {
-			isConfigFile = true
-			fmt.Printf("📋 [DEBUG] Found config file: %s\n", path)
+		if isDir {
+			// Skip certain directories
+			switch entry.Name() {
+			case "node_modules", ".git", "vendor", "dist", "build":
+				continue
+			}
+			// A Helm chart is read as a whole: its templates only mean something against values.yaml
+			if isHelmChart(path) {
+				if !se.readable(filepath.Join(path, "Chart.yaml")) {
+					continue
+				}
+				*configFiles = append(*configFiles, filepath.Join(path, "Chart.yaml"))
+				fmt.Printf("📋 [DEBUG] Found Helm chart: %s\n", path)
+				// Per-environment values files (values-staging.yaml) are read as config of their own
+				chartEntries, _ := os.ReadDir(path)
+				for _, chartEntry := range chartEntries {
+					name := chartEntry.Name()
+					if env, _ := environmentOf(name); env != "" && strings.HasPrefix(name, "values") && !chartEntry.IsDir() && se.readable(filepath.Join(path, name)) {
+						*configFiles = append(*configFiles, filepath.Join(path, name))
+					}
+				}
+				continue
+			}
+			ancestors[entryReal] = true
+			err := se.walkConfigFiles(ctx, path, entryReal, ancestors, configFiles)
+			delete(ancestors, entryReal)
+			if err != nil {
+				return err
+			}
+			continue
 		}
 		
-		if isConfigFile {
-			configFiles = append(configFiles, path)
+		if se.readable(path) && isConfigFile(path) {
+			*configFiles = append(*configFiles, path)
 		}
-		
-		return nil
-	})
+	}
+	return nil
+}
+
+// isConfigFile reports whether a file may declare environment variables or secrets
+func isConfigFile(path string) bool {
+	fileName := filepath.Base(path)
+	fileExt := filepath.Ext(fileName)
 	
-	fmt.Printf("✅ [DEBUG] Found %d config files total\n", len(configFiles))
-	for i, file := range configFiles {
-		fmt.Printf("   %d. %s\n", i+1, file)
+	// Check for .env files (any file starting with .env)
+	if strings.HasPrefix(fileName, ".env") {
+		fmt.Printf("📋 [DEBUG] Found .env file: %s\n", path)
+		return true
 	}
 	
-	return configFiles, err
+	// Check for .yaml and .yml files
+	if fileExt == ".yaml" || fileExt == ".yml" {
+		fmt.Printf("📋 [DEBUG] Found YAML file: %s\n", path)
+		return true
+	}
+	
+	// Kustomize overlays generate secrets from literals and env files
+	if isKustomization(fileName) {
+		fmt.Printf("📋 [DEBUG] Found kustomization: %s\n", path)
+		return true
+	}
+	
+	// Check for other common config files, including per-environment variants such as
+	// application-prod.properties and config.production.json
+	if (strings.HasPrefix(fileName, "config") && fileExt == ".json") || (strings.HasPrefix(fileName, "application") && fileExt == ".properties") {
+		fmt.Printf("📋 [DEBUG] Found config file: %s\n", path)
+		return true
+	}
+	
+	// Dockerfile build args and ENV defaults are inputs to the image build
+	if microservices.IsDockerfile(fileName) {
+		fmt.Printf("📋 [DEBUG] Found Dockerfile: %s\n", path)
+		return true
+	}
+	
+	// Terraform variables are inputs that must be supplied at deploy time
+	if infrastructure.IsTerraformFile(fileName) {
+		fmt.Printf("📋 [DEBUG] Found Terraform file: %s\n", path)
+		return true
+	}
+	
+	return false
+}
+
+// realRoot returns the project path with symlinks resolved
+func (se *SecretExtractor) realRoot() string {
+	root, err := filepath.Abs(se.projectPath)
+	if err != nil {
+		root = se.projectPath
+	}
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		return resolved
+	}
+	return root
+}
+
+// resolveInside follows a symlink and reports whether its target lies inside the project; broken
+// links and links that escape the project are skipped
+func (se *SecretExtractor) resolveInside(path string) (string, bool) {
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", false
+	}
+	root := se.realRoot()
+	rel, err := filepath.Rel(root, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		fmt.Printf("⏭️  [DEBUG] Skipping symlink that leaves the project: %s → %s\n", path, target)
+		return "", false
+	}
+	return target, true
+}
+
+// parseAll parses files concurrently and returns each file's variables; files not reached before
+// ctx is cancelled are left out
+func (se *SecretExtractor) parseAll(ctx context.Context, files []string, parse func(string) []SecretVariable) map[string][]SecretVariable {
+	results := make([][]SecretVariable, len(files))
+	done := make([]bool, len(files))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(runtime.NumCPU(), len(files)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i], done[i] = parse(files[i]), true
+			}
+		}()
+	}
+	for i := range files {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	
+	parsed := make(map[string][]SecretVariable, len(files))
+	for i, file := range files {
+		if done[i] {
+			parsed[file] = results[i]
+		}
+	}
+	return parsed
 }
 
 // isMonorepo determines if this is a monorepo structure
@@ -296,6 +554,9 @@ func (se *SecretExtractor) extractGlobalSecrets(configFiles []string) []SecretVa
 
 // parseConfigFile analyzes a single config file for secrets
 func (se *SecretExtractor) parseConfigFile(filePath string) []SecretVariable {
+	if variables, ok := se.parsed[filePath]; ok {
+		return variables
+	}
 	var variables []SecretVariable
 	
 	content, err := os.ReadFile(filePath)
@@ -309,6 +570,10 @@ func (se *SecretExtractor) parseConfigFile(filePath string) []SecretVariable {
 	
 	fmt.Printf("🔍 [DEBUG] Parsing config file: %s\n", fileName)
 	
+	if microservices.IsDockerfile(fileName) {
+		return se.parseDockerfile(string(content), fileName)
+	}
+	
 	switch fileExt {
 	case ".env":
 		variables = se.parseEnvFile(string(content), fileName)
@@ -320,6 +585,8 @@ func (se *SecretExtractor) parseConfigFile(filePath string) []SecretVariable {
 		variables = se.parseTomlFile(string(content), fileName)
 	case ".properties":
 		variables = se.parsePropertiesFile(string(content), fileName)
+	case ".tf":
+		variables = se.parseTerraformFile(string(content), fileName)
 	default:
 		// Try to parse as env format by default
 		variables = se.parseEnvFile(string(content), fileName)
@@ -404,11 +671,13 @@ func (se *SecretExtractor) parseYamlFile(content, fileName string) []SecretVaria
 		
 		for _, match := range matches {
 			var envVar string
+			hasDefault := false
 			if len(match) > 1 {
 				envVar = match[1]
 				// Remove default values from ${VAR:default} format
 				if strings.Contains(envVar, ":") {
 					envVar = strings.Split(envVar, ":")[0]
+					hasDefault = true
 				}
 			}
 			
@@ -420,7 +689,7 @@ func (se *SecretExtractor) parseYamlFile(content, fileName string) []SecretVaria
 					Description: se.generateDescription(envVar, ""),
 					Type:        se.determineSecretType(envVar),
 					Example:     se.generateExample(envVar),
-					Required:    true, // Variables referenced in YAML are typically required
+					Required:    !hasDefault, // Variables referenced in YAML are required unless they have a default
 					Source:      fileName,
 				}
 				variables = append(variables, secret)
@@ -481,6 +750,84 @@ func (se *SecretExtractor) parsePropertiesFile(content, fileName string) []Secre
 	return se.parseEnvFile(content, fileName)
 }
 
+// parseTerraformFile reports Terraform input variables that have no default or are marked sensitive.
+// They are listed under their TF_VAR_ environment variable name.
+func (se *SecretExtractor) parseTerraformFile(content, fileName string) []SecretVariable {
+	var variables []SecretVariable
+	
+	fmt.Printf("🔍 [DEBUG] Parsing Terraform file: %s\n", fileName)
+	
+	for _, tfVar := range infrastructure.ParseTerraform(fileName, content).Variables {
+		if tfVar.HasDefault && !tfVar.Sensitive {
+			continue
+		}
+		
+		description := tfVar.Description
+		if description == "" {
+			description = se.generateDescription(tfVar.Name, tfVar.Default)
+		}
+		
+		secretType := se.determineSecretType(tfVar.Name)
+		if tfVar.Sensitive && secretType == "config" {
+			secretType = "secret"
+		}
+		
+		variables = append(variables, SecretVariable{
+			Name:        "TF_VAR_" + tfVar.Name,
+			Description: description,
+			Type:        secretType,
+			Example:     se.generateExample(tfVar.Name),
+			Required:    !tfVar.HasDefault,
+			Source:      fileName,
+		})
+		fmt.Printf("   ✓ Found Terraform variable: %s (default: %t, sensitive: %t)\n", tfVar.Name, tfVar.HasDefault, tfVar.Sensitive)
+	}
+	
+	return variables
+}
+
+// parseDockerfile reports build args without a default, which must be passed with --build-arg, and
+// ENV instructions whose value is empty or a placeholder
+func (se *SecretExtractor) parseDockerfile(content, fileName string) []SecretVariable {
+	var variables []SecretVariable
+	
+	fmt.Printf("🔍 [DEBUG] Parsing Dockerfile: %s\n", fileName)
+	
+	container := microservices.ParseDockerfile(fileName, content)
+	for _, arg := range container.BuildArgs {
+		if arg.HasDefault && !(se.looksLikeSecret(arg.Name) && se.isEmptyOrPlaceholder(arg.Value)) {
+			continue
+		}
+		variables = append(variables, SecretVariable{
+			Name:        arg.Name,
+			Description: fmt.Sprintf("Build argument (--build-arg %s=...). %s", arg.Name, se.generateDescription(arg.Name, arg.Value)),
+			Type:        se.determineSecretType(arg.Name),
+			Example:     se.generateExample(arg.Name),
+			Required:    true,
+			Source:      fileName,
+		})
+		fmt.Printf("   ✓ Found build argument: %s (default: %t)\n", arg.Name, arg.HasDefault)
+	}
+	
+	for _, env := range container.Env {
+		// ENV X=$X only copies a build arg into the image; the ARG is reported instead
+		if len(microservices.DockerVarReferences(env.Value)) > 0 || !se.isEmptyOrPlaceholder(env.Value) {
+			continue
+		}
+		variables = append(variables, SecretVariable{
+			Name:        env.Name,
+			Description: se.generateDescription(env.Name, env.Value),
+			Type:        se.determineSecretType(env.Name),
+			Example:     se.generateExample(env.Name),
+			Required:    true,
+			Source:      fileName,
+		})
+		fmt.Printf("   ✓ Found unset ENV: %s\n", env.Name)
+	}
+	
+	return se.deduplicateVariables(variables)
+}
+
 // isRequiredSecret determines if a variable represents a required secret
 func (se *SecretExtractor) isRequiredSecret(key, value, fileName string) bool {
 	// Don't include variables that already have values (unless they're examples)
@@ -668,7 +1015,12 @@ func (se *SecretExtractor) deduplicateVariables(variables []SecretVariable) []Se
 	seen := make(map[string]*SecretVariable)
 	
 	for _, variable := range variables {
-		if existing, exists := seen[variable.Name]; exists {
+		// The same key in two charts or overlays is two values to set
+		key := variable.Name
+		if variable.Origin != "" {
+			key = variable.Origin + "\x00" + variable.Name
+		}
+		if existing, exists := seen[key]; exists {
 			// Merge information - prefer more detailed descriptions
 			if len(variable.Description) > len(existing.Description) {
 				existing.Description = variable.Description
@@ -684,10 +1036,11 @@ func (se *SecretExtractor) deduplicateVariables(variables []SecretVariable) []Se
 			if !strings.Contains(existing.Source, variable.Source) {
 				existing.Source = existing.Source + ", " + variable.Source
 			}
+			existing.Environments = mergeEnvironments(existing.Environments, variable.Environments)
 		} else {
 			// Make a copy to avoid pointer issues
 			newVar := variable
-			seen[variable.Name] = &newVar
+			seen[key] = &newVar
 		}
 	}
 	
@@ -700,6 +1053,25 @@ func (se *SecretExtractor) deduplicateVariables(variables []SecretVariable) []Se
 	return result
 }
 
+// mergeEnvironments returns the union of two environment lists, in environment order
+func mergeEnvironments(a, b []string) []string {
+	if len(b) == 0 {
+		return a
+	}
+	merged := append([]string{}, a...)
+	for _, env := range b {
+		found := false
+		for _, existing := range merged {
+			found = found || existing == env
+		}
+		if !found {
+			merged = append(merged, env)
+		}
+	}
+	sort.SliceStable(merged, func(i, j int) bool { return environmentRank(merged[i]) < environmentRank(merged[j]) })
+	return merged
+}
+
 // generateSummary creates a summary of the secrets analysis
 func (se *SecretExtractor) generateSummary(total, required, services int) string {
 	if total == 0 {
@@ -0,0 +1,255 @@
+// Package selftest runs the whole pipeline on the analyzer's own source tree with the LLM
+// stubbed by offline summaries, and checks that every deterministic subsystem produced output
+// and that the result matches the schema the API publishes. It is a smoke test for packaging
+// and deployments: a binary missing embedded data, or a subsystem that broke, fails it.
+package selftest
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"repo-explanation/config"
+	"repo-explanation/internal/openapi"
+	"repo-explanation/internal/pipeline"
+)
+
+// Module is the module path the analyzer's go.mod declares
+const Module = "repo-explanation"
+
+// Check statuses
+const (
+	Pass = "pass"
+	Fail = "fail"
+	Skip = "skip" // Optional output the analyzer's own source gives no input for
+)
+
+// Check is the outcome of one subsystem's check
+type Check struct {
+	Name   string `json:"name"`
+	Field  string `json:"field"` // Key of the output in the analysis result
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Report is the outcome of a self-test run
+type Report struct {
+	SourceTree string        `json:"source_tree"`
+	Duration   time.Duration `json:"duration"`
+	Checks     []Check       `json:"checks"`
+	Passed     int           `json:"passed"`
+	Failed     int           `json:"failed"`
+	Skipped    int           `json:"skipped"`
+}
+
+// OK reports whether every check passed or was skipped
+func (r *Report) OK() bool {
+	return r.Failed == 0
+}
+
+// subsystems are the outputs of the analysis result the self-test expects, by JSON key. Required
+// outputs must be non-empty on the analyzer's own source; the others are checked when present,
+// since the analyzer has no schema, infrastructure code or data contracts of its own.
+var subsystems = []struct {
+	name     string
+	field    string
+	required bool
+}{
+	{"Project summary", "project_summary", true},
+	{"Folder summaries", "folder_summaries", true},
+	{"Architecture map", "architecture_map", true},
+	{"Project type detection", "project_type", true},
+	{"Statistics", "stats", true},
+	{"Service discovery", "services", true},
+	{"Service relationships", "relationships", true},
+	{"Package graph", "package_graph", true},
+	{"Module boundaries", "modules", false},
+	{"Toolchains", "toolchains", true},
+	{"Advisories", "advisories", true},
+	{"Frontend", "frontend", true},
+	{"Auth surface", "auth_surface", true},
+	{"Background jobs", "jobs", true},
+	{"Documentation coverage", "doc_coverage", true},
+	{"Complexity", "complexity", true},
+	{"Dead code", "dead_code", true},
+	{"Secrets", "project_secrets", true},
+	{"Helpful questions", "helpful_questions", true},
+	{"Glossary", "glossary", true},
+	{"Sequence flows", "sequence_flows", false},
+	{"Generated code", "generated", false},
+	{"Database schema", "database_schema", false},
+	{"Table ownership", "table_ownership", false},
+	{"Tenancy", "tenancy", false},
+	{"Infrastructure", "infrastructure", false},
+	{"Feature flags", "feature_flags", false},
+	{"Data contracts", "data_contracts", false},
+}
+
+// Locate finds the analyzer's source tree: the first directory, at or above one of the starting
+// points, whose go.mod declares Module
+func Locate(starts ...string) (string, error) {
+	for _, start := range starts {
+		if start == "" {
+			continue
+		}
+		dir, err := filepath.Abs(start)
+		if err != nil {
+			continue
+		}
+		for {
+			if declaresModule(filepath.Join(dir, "go.mod")) {
+				return dir, nil
+			}
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				break
+			}
+			dir = parent
+		}
+	}
+	return "", fmt.Errorf("the analyzer's source tree was not found; run from a checkout or pass -path")
+}
+
+func declaresModule(goMod string) bool {
+	file, err := os.Open(goMod)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`) == Module
+		}
+	}
+	return false
+}
+
+// Run analyzes sourceTree offline with every phase, in a temporary cache and output directory so
+// nothing from earlier runs is reused, and checks the result
+func Run(ctx context.Context, sourceTree string) (*Report, error) {
+	workDir, err := os.MkdirTemp("", "selftest-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create work directory: %v", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	cfg := config.Defaults()
+	cfg.Offline = true
+	cfg.Cache.Enabled = false
+	cfg.Cache.Directory = filepath.Join(workDir, "cache")
+	cfg.Output.OutputDirectory = filepath.Join(workDir, "output")
+	cfg.Output.SaveIntermediateResults = false
+	cfg.Audit.Enabled = false
+
+	analyzer, err := pipeline.NewAnalyzer(cfg, sourceTree)
+	if err != nil {
+		return nil, err
+	}
+	if err := analyzer.SetOptions(pipeline.AnalysisOptions{Phases: pipeline.AllPhases}); err != nil {
+		return nil, err
+	}
+
+	// The pipeline logs every phase; a self-test only reports its checks
+	started := time.Now()
+	result, err := quietly(func() (*pipeline.AnalysisResult, error) {
+		return analyzer.AnalyzeProjectWithProgress(ctx, func(eventType, stage, message string, progress int, data interface{}) {})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("analysis failed: %v", err)
+	}
+	report := &Report{SourceTree: sourceTree, Duration: time.Since(started).Round(time.Millisecond)}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the result: %v", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode the result: %v", err)
+	}
+
+	report.add(checkSchema(result, decoded))
+	if !result.Offline {
+		report.add(Check{Name: "LLM stub", Field: "offline", Status: Fail, Detail: "the analysis was not marked offline, so it may have called the LLM"})
+	}
+	for _, subsystem := range subsystems {
+		check := Check{Name: subsystem.name, Field: subsystem.field, Status: Pass}
+		switch {
+		case !isEmpty(decoded[subsystem.field]):
+		case subsystem.required:
+			check.Status = Fail
+			check.Detail = "no output"
+		default:
+			check.Status = Skip
+			check.Detail = "nothing of this kind in the analyzer's source"
+		}
+		report.add(check)
+	}
+	return report, nil
+}
+
+// checkSchema validates the encoded result against the AnalysisResult schema of the API document
+func checkSchema(result *pipeline.AnalysisResult, decoded map[string]interface{}) Check {
+	check := Check{Name: "Result schema", Field: "$", Status: Pass}
+	builder := openapi.NewBuilder("selftest", "", "")
+	schema := builder.SchemaOf(result)
+	if problems := builder.Document().Validate(schema, decoded, 10); len(problems) > 0 {
+		check.Status = Fail
+		check.Detail = strings.Join(problems, "; ")
+	}
+	return check
+}
+
+func (r *Report) add(check Check) {
+	switch check.Status {
+	case Pass:
+		r.Passed++
+	case Fail:
+		r.Failed++
+	default:
+		r.Skipped++
+	}
+	r.Checks = append(r.Checks, check)
+}
+
+func quietly(fn func() (*pipeline.AnalysisResult, error)) (*pipeline.AnalysisResult, error) {
+	stdout := os.Stdout
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		return fn()
+	}
+	os.Stdout = devNull
+	defer func() {
+		os.Stdout = stdout
+		devNull.Close()
+	}()
+	return fn()
+}
+
+// isEmpty reports whether a decoded JSON value holds nothing: null, "", [], {} or an object whose
+// values are all empty
+func isEmpty(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		for _, item := range v {
+			if !isEmpty(item) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,300 @@
+// Package snapshots runs the deterministic parts of the analysis (project detection, service
+// discovery, schema extraction and secrets) offline against embedded fixture repositories and
+// compares the results with golden JSON files, so parser refactors show every change in output.
+package snapshots
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"repo-explanation/config"
+	"repo-explanation/internal/database"
+	"repo-explanation/internal/detector"
+	"repo-explanation/internal/microservices"
+	"repo-explanation/internal/pipeline"
+	"repo-explanation/internal/secrets"
+)
+
+// UpdateEnv is the environment variable that rewrites the golden files instead of comparing against them
+const UpdateEnv = "UPDATE_SNAPSHOTS"
+
+// GoldenDir is where golden files live, relative to the repository root
+const GoldenDir = "internal/snapshots/golden"
+
+//go:embed fixtures/*.txtar
+var fixtureFS embed.FS
+
+//go:embed golden
+var goldenFS embed.FS
+
+// Snapshot is the stable form of one fixture's deterministic outputs
+type Snapshot struct {
+	Detection *detector.DetectionResult         `json:"detection"`
+	Services  []microservices.DiscoveredService `json:"services"`
+	Schema    *database.DatabaseSchema          `json:"schema,omitempty"`
+	Secrets   *secrets.ProjectSecrets           `json:"secrets,omitempty"`
+}
+
+// Result is the outcome of checking one fixture
+type Result struct {
+	Fixture string `json:"fixture"`
+	Status  string `json:"status"`         // "match", "mismatch", "missing" or "updated"
+	Diff    string `json:"diff,omitempty"` // Line diff from golden to actual, for mismatches
+}
+
+// Fixtures lists the embedded fixture repositories by name
+func Fixtures() []string {
+	entries, _ := fixtureFS.ReadDir("fixtures")
+	var names []string
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".txtar"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WriteFixture writes the files of an embedded fixture repository under dir
+func WriteFixture(fixture, dir string) error {
+	archive, err := fixtureFS.ReadFile("fixtures/" + fixture + ".txtar")
+	if err != nil {
+		return fmt.Errorf("unknown fixture %s (fixtures: %s)", fixture, strings.Join(Fixtures(), ", "))
+	}
+	if err := extract(archive, dir); err != nil {
+		return fmt.Errorf("failed to extract fixture %s: %v", fixture, err)
+	}
+	return nil
+}
+
+// Take writes a fixture to a temporary directory and analyzes it offline with only the
+// deterministic phases enabled
+func Take(ctx context.Context, fixture string) (*Snapshot, error) {
+	workDir, err := os.MkdirTemp("", "snapshot-"+fixture+"-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create fixture directory: %v", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	repoDir := filepath.Join(workDir, fixture)
+	if err := WriteFixture(fixture, repoDir); err != nil {
+		return nil, err
+	}
+
+	cfg := config.Defaults()
+	cfg.Offline = true
+	cfg.Cache.Enabled = false
+	cfg.Cache.Directory = filepath.Join(workDir, "cache")
+	cfg.Output.OutputDirectory = filepath.Join(workDir, "output")
+	cfg.Output.SaveIntermediateResults = false
+
+	analyzer, err := pipeline.NewAnalyzer(cfg, repoDir)
+	if err != nil {
+		return nil, err
+	}
+	if err := analyzer.SetOptions(pipeline.AnalysisOptions{
+		Phases: []string{pipeline.PhaseProject, pipeline.PhaseServices, pipeline.PhaseSchema, pipeline.PhaseSecrets},
+	}); err != nil {
+		return nil, err
+	}
+
+	// The pipeline logs every phase; a snapshot run only reports the comparison
+	result, err := quietly(func() (*pipeline.AnalysisResult, error) {
+		return analyzer.AnalyzeProjectWithProgress(ctx, func(eventType, stage, message string, progress int, data interface{}) {})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("analysis of fixture %s failed: %v", fixture, err)
+	}
+
+	snapshot := &Snapshot{Services: microservices.StableServices(result.Services)}
+	if result.ProjectType != nil {
+		snapshot.Detection = result.ProjectType.Stable()
+	}
+	if result.DatabaseSchema != nil {
+		snapshot.Schema = result.DatabaseSchema.Stable()
+		if rel, err := filepath.Rel(repoDir, snapshot.Schema.MigrationPath); err == nil && filepath.IsAbs(snapshot.Schema.MigrationPath) {
+			snapshot.Schema.MigrationPath = filepath.ToSlash(rel)
+		}
+	}
+	if result.ProjectSecrets != nil {
+		snapshot.Secrets = result.ProjectSecrets.Stable(repoDir)
+	}
+	for i := range snapshot.Services {
+		if rel, err := filepath.Rel(repoDir, snapshot.Services[i].Path); err == nil && filepath.IsAbs(snapshot.Services[i].Path) {
+			snapshot.Services[i].Path = filepath.ToSlash(rel)
+		}
+	}
+	return snapshot, nil
+}
+
+// Check snapshots every fixture and compares it with its golden file. With update set, the golden
+// files under goldenDir are rewritten instead.
+func Check(ctx context.Context, update bool, goldenDir string) ([]Result, error) {
+	var results []Result
+	for _, fixture := range Fixtures() {
+		actual, err := render(ctx, fixture)
+		if err != nil {
+			return results, err
+		}
+
+		result := Result{Fixture: fixture}
+		if update {
+			if err := os.MkdirAll(goldenDir, 0755); err != nil {
+				return results, fmt.Errorf("failed to create golden directory: %v", err)
+			}
+			if err := os.WriteFile(filepath.Join(goldenDir, fixture+".json"), actual, 0644); err != nil {
+				return results, fmt.Errorf("failed to write golden file for %s: %v", fixture, err)
+			}
+			result.Status = "updated"
+			results = append(results, result)
+			continue
+		}
+
+		golden, err := goldenFS.ReadFile("golden/" + fixture + ".json")
+		switch {
+		case err != nil:
+			result.Status = "missing"
+		case bytes.Equal(golden, actual):
+			result.Status = "match"
+		default:
+			result.Status = "mismatch"
+			result.Diff = diff(string(golden), string(actual))
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// render takes the snapshot of a fixture in the form golden files hold
+func render(ctx context.Context, fixture string) ([]byte, error) {
+	snapshot, err := Take(ctx, fixture)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode snapshot of %s: %v", fixture, err)
+	}
+	return append(data, '\n'), nil
+}
+
+// extract writes the files of a txtar archive under dir. The archive starts with a free-form
+// comment, and each file follows a "-- path --" line.
+func extract(archive []byte, dir string) error {
+	var name string
+	var content strings.Builder
+	flush := func() error {
+		if name == "" {
+			return nil
+		}
+		target := filepath.Join(dir, filepath.FromSlash(path.Clean(name)))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		return os.WriteFile(target, []byte(content.String()), 0644)
+	}
+
+	for _, line := range strings.SplitAfter(string(archive), "\n") {
+		trimmed := strings.TrimRight(line, "\r\n")
+		if strings.HasPrefix(trimmed, "-- ") && strings.HasSuffix(trimmed, " --") && len(trimmed) > 6 {
+			if err := flush(); err != nil {
+				return err
+			}
+			name = strings.TrimSpace(trimmed[3 : len(trimmed)-3])
+			if strings.HasPrefix(path.Clean(name), "..") || path.IsAbs(name) {
+				return fmt.Errorf("file %s escapes the fixture", name)
+			}
+			content.Reset()
+			continue
+		}
+		if name != "" {
+			content.WriteString(line)
+		}
+	}
+	return flush()
+}
+
+// quietly runs fn with standard output discarded
+func quietly(fn func() (*pipeline.AnalysisResult, error)) (*pipeline.AnalysisResult, error) {
+	stdout := os.Stdout
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		return fn()
+	}
+	os.Stdout = devNull
+	defer func() {
+		os.Stdout = stdout
+		devNull.Close()
+	}()
+	return fn()
+}
+
+// diff renders a line diff from want to got, keeping two lines of context around each change
+func diff(want, got string) string {
+	a, b := strings.Split(want, "\n"), strings.Split(got, "\n")
+
+	// Longest common subsequence table, filled from the end
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	type op struct {
+		kind byte
+		line string
+	}
+	var ops []op
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, op{' ', a[i]})
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] >= lcs[i+1][j]):
+			ops = append(ops, op{'+', b[j]})
+			j++
+		default:
+			ops = append(ops, op{'-', a[i]})
+			i++
+		}
+	}
+
+	// Show each change with its surrounding context, eliding long unchanged runs
+	const context = 2
+	show := make([]bool, len(ops))
+	for k, o := range ops {
+		if o.kind == ' ' {
+			continue
+		}
+		for c := max(k-context, 0); c <= min(k+context, len(ops)-1); c++ {
+			show[c] = true
+		}
+	}
+	var out strings.Builder
+	for k, o := range ops {
+		if !show[k] {
+			if k > 0 && show[k-1] {
+				out.WriteString("  ...\n")
+			}
+			continue
+		}
+		fmt.Fprintf(&out, "%c %s\n", o.kind, o.line)
+	}
+	return out.String()
+}
@@ -0,0 +1,41 @@
+package snapshots
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestSnapshots compares every fixture with its golden file on disk; with UPDATE_SNAPSHOTS set
+// the golden files are rewritten instead
+func TestSnapshots(t *testing.T) {
+	update := os.Getenv(UpdateEnv) != ""
+	fixtures := Fixtures()
+	if len(fixtures) == 0 {
+		t.Fatal("no fixtures embedded")
+	}
+	for _, fixture := range fixtures {
+		t.Run(fixture, func(t *testing.T) {
+			actual, err := render(context.Background(), fixture)
+			if err != nil {
+				t.Fatal(err)
+			}
+			goldenPath := filepath.Join("golden", fixture+".json")
+			if update {
+				if err := os.WriteFile(goldenPath, actual, 0644); err != nil {
+					t.Fatalf("failed to write golden file: %v", err)
+				}
+				return
+			}
+			golden, err := os.ReadFile(goldenPath)
+			if err != nil {
+				t.Fatalf("no golden file; run with %s=1 to create it: %v", UpdateEnv, err)
+			}
+			if !bytes.Equal(golden, actual) {
+				t.Errorf("output differs from %s; run with %s=1 to accept an intended change\n%s", goldenPath, UpdateEnv, diff(string(golden), string(actual)))
+			}
+		})
+	}
+}
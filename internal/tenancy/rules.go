@@ -0,0 +1,85 @@
+package tenancy
+
+import "regexp"
+
+// Tenancy models
+const (
+	SharedSchema      = "shared_schema"       // Tenants share tables, told apart by a tenant column
+	SchemaPerTenant   = "schema_per_tenant"   // Each tenant has its own schema in one database
+	DatabasePerTenant = "database_per_tenant" // Each tenant has its own database
+	Hybrid            = "hybrid"              // Strong signs of more than one model
+)
+
+var modelOrder = []string{SharedSchema, SchemaPerTenant, DatabasePerTenant}
+
+var modelLabels = map[string]string{
+	SharedSchema:      "Shared schema (rows scoped by tenant)",
+	SchemaPerTenant:   "Schema per tenant",
+	DatabasePerTenant: "Database per tenant",
+	Hybrid:            "Hybrid",
+}
+
+// tenantColumns are the column names that scope rows to a tenant, strongest first. Columns such as
+// account_id or team_id only count when most tables carry them, which the coverage check enforces.
+var tenantColumns = []string{
+	"tenant_id", "tenantid", "org_id", "organization_id", "organisation_id", "orgid",
+	"workspace_id", "account_id", "company_id", "team_id",
+}
+
+// tenantTables are the tables a tenant column usually references
+var tenantTables = []string{"tenants", "tenant", "organizations", "organization", "organisations", "orgs", "workspaces", "accounts", "companies", "teams"}
+
+// bookkeepingTables belong to migration tools and never carry a tenant column
+var bookkeepingTables = map[string]bool{
+	"schema_migrations": true, "goose_db_version": true, "flyway_schema_history": true, "ar_internal_metadata": true,
+	"django_migrations": true, "alembic_version": true, "knex_migrations": true, "knex_migrations_lock": true,
+	"databasechangelog": true, "databasechangeloglock": true, "migrations": true, "__efmigrationshistory": true,
+}
+
+// rule recognizes one tenancy library or idiom on a line. Rules without a model show that requests
+// are scoped to a tenant without telling how the data is separated. When tenantOnly is set the line
+// must also mention a tenant, which keeps generic schema switching out.
+type rule struct {
+	model      string
+	detail     string
+	pattern    *regexp.Regexp
+	tenantOnly bool
+}
+
+var rules = []rule{
+	// Tenant resolution, common to every model
+	{"", "tenant header", regexp.MustCompile(`(?i)["'](?:x-(?:tenant|org|organization|workspace)[-_]id|(?:tenant|org|organization|workspace)-id)["']`), false},
+	{"", "tenant middleware", regexp.MustCompile(`\b\w*(?:Tenant|Organization|Org)(?:Middleware|Resolver|Interceptor|Guard|Filter)\b`), false},
+	{"", "tenant context", regexp.MustCompile(`\b(?:[wW]ithTenant|[tT]enantFromContext|current_tenant|[cC]urrentTenant|[gG]etTenantI[dD]|get_tenant_id|set_current_tenant|TenantContext)\b`), false},
+	{"", "tenant subdomain", regexp.MustCompile(`(?i)subdomain[^\n]{0,40}tenant|tenant[^\n]{0,40}subdomain`), false},
+
+	// Shared schema
+	{SharedSchema, "query filtered by tenant", regexp.MustCompile(`(?i)\b(?:tenant|org|organization|workspace)_?id\s*=\s*(?:\$\d|\?|:\w+|%s|@\w+)`), false},
+	{SharedSchema, "ORM filter on tenant", regexp.MustCompile(`(?i)\b(?:where|filter|filter_by|find_by)\s*[:(]?\s*\{?\s*["']?(?:tenant|org|organization)_?id\b`), false},
+	{SharedSchema, "acts_as_tenant", regexp.MustCompile(`acts_as_tenant|ActsAsTenant`), false},
+	{SharedSchema, "django-multitenant", regexp.MustCompile(`django[-_]multitenant|TenantModelMixin`), false},
+	{SharedSchema, "Hibernate tenant discriminator", regexp.MustCompile(`@TenantId\b|MultiTenancyStrategy\.DISCRIMINATOR|@FilterDef\([^)]*[tT]enant`), false},
+	{SharedSchema, "Laravel BelongsToTenant", regexp.MustCompile(`\bBelongsToTenant\b|BelongsToPrimaryModel`), false},
+	{SharedSchema, "Postgres tenant setting", regexp.MustCompile(`(?i)set_config\(\s*'[\w.]*(?:tenant|org)[\w.]*'|\bSET\s+(?:LOCAL\s+)?\w+\.\w*(?:tenant|org)\w*\s*(?:=|TO)`), false},
+
+	// Schema per tenant
+	{SchemaPerTenant, "django-tenants", regexp.MustCompile(`django[-_]tenants|django[-_]tenant[-_]schemas|\bTenantMixin\b|\bTENANT_APPS\b`), false},
+	{SchemaPerTenant, "Apartment", regexp.MustCompile(`Apartment::Tenant|\bros-apartment\b|Apartment\.configure`), false},
+	{SchemaPerTenant, "Hibernate schema strategy", regexp.MustCompile(`MultiTenancyStrategy\.SCHEMA`), false},
+	{SchemaPerTenant, "Ecto prefix", regexp.MustCompile(`\bTriplex\.|prefix:\s*\w*[tT]enant`), false},
+	{SchemaPerTenant, "search_path per tenant", regexp.MustCompile(`(?i)search_path[^;\n]*(?:%s|[$#]\{|\{[\w.]+\}|["'` + "`" + `]\s*\+)`), true},
+	{SchemaPerTenant, "schema created per tenant", regexp.MustCompile(`(?i)CREATE\s+SCHEMA[^;\n]*(?:%s|[$#]\{|\{[\w.]+\}|["'` + "`" + `]\s*\+)`), true},
+
+	// Database per tenant
+	{DatabasePerTenant, "Hibernate database strategy", regexp.MustCompile(`MultiTenancyStrategy\.DATABASE`), false},
+	{DatabasePerTenant, "routing data source", regexp.MustCompile(`\bAbstractRoutingDataSource\b`), false},
+	{DatabasePerTenant, "stancl/tenancy", regexp.MustCompile(`stancl/tenancy|Stancl\\Tenancy`), false},
+	{DatabasePerTenant, "connection per tenant", regexp.MustCompile(`(?i)\b(?:get|resolve|open|new|connect)_?tenant_?(?:db|database|connection|datasource|data_source|dsn|pool)\w*\s*\(|\btenant\w*\.(?:dsn|database_?url|connection_?string|db_?url|db_?name)\b`), false},
+}
+
+var (
+	// enableRLSRegex and policyRegex find row-level security in migrations
+	enableRLSRegex = regexp.MustCompile(`(?i)ALTER\s+TABLE\s+(?:IF\s+EXISTS\s+)?(?:ONLY\s+)?([\w."]+)\s+ENABLE\s+ROW\s+LEVEL\s+SECURITY`)
+	policyRegex    = regexp.MustCompile(`(?is)CREATE\s+POLICY\s+[\w"]+\s+ON\s+([\w."]+)([^;]*)`)
+	settingRegex   = regexp.MustCompile(`(?i)current_setting\(\s*'([\w.]+)'`)
+)
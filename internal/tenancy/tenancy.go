@@ -0,0 +1,368 @@
+// Package tenancy works out how a project keeps its tenants' data apart: a tenant column on most
+// tables of one shared schema, a schema per tenant, or a database per tenant. It reads the extracted
+// schema for tenant columns, migrations for row-level security, and the code for tenant
+// middleware, tenancy libraries and per-tenant connections, since the model decides how every
+// query a new engineer writes must be scoped.
+package tenancy
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"repo-explanation/internal/database"
+	"repo-explanation/internal/filestore"
+	"repo-explanation/internal/portable"
+)
+
+// Evidence is one line showing how tenants are resolved or separated
+type Evidence struct {
+	Model  string `json:"model,omitempty"` // Empty for tenant resolution common to every model
+	Detail string `json:"detail"`
+	File   string `json:"file"` // Relative to the project root
+	Line   int    `json:"line,omitempty"`
+}
+
+// Report is the tenancy model of a project
+type Report struct {
+	Model            string         `json:"model"` // shared_schema, schema_per_tenant, database_per_tenant or hybrid
+	Label            string         `json:"label"`
+	Scores           map[string]int `json:"scores"`
+	TenantColumn     string         `json:"tenant_column,omitempty"`
+	TenantTable      string         `json:"tenant_table,omitempty"`       // Table the tenant column refers to
+	ScopedTables     []string       `json:"scoped_tables,omitempty"`      // Tables with the tenant column
+	GlobalTables     []string       `json:"global_tables,omitempty"`      // Tables without it, shared by every tenant
+	RowLevelSecurity []string       `json:"row_level_security,omitempty"` // Tables with row-level security enabled
+	TenantSetting    string         `json:"tenant_setting,omitempty"`     // Setting the policies read the tenant from
+	Evidence         []Evidence     `json:"evidence"`
+	Guidance         []string       `json:"guidance"`
+	Summary          string         `json:"summary"`
+}
+
+// Directories that never hold first-party code
+var skippedDirs = map[string]bool{
+	".git": true, "node_modules": true, "vendor": true, "testdata": true, "dist": true, "build": true,
+	".next": true, "coverage": true, "__pycache__": true, ".venv": true, "venv": true,
+	"test": true, "tests": true, "__tests__": true, "spec": true,
+}
+
+// maxEvidence bounds the lines kept per detail
+const maxEvidence = 3
+
+// minScore is the score below which the project is taken to be single-tenant
+const minScore = 3
+
+var sourceExtensions = map[string]bool{
+	".go": true, ".js": true, ".jsx": true, ".ts": true, ".tsx": true, ".mjs": true, ".cjs": true,
+	".py": true, ".rb": true, ".java": true, ".kt": true, ".cs": true, ".php": true, ".ex": true, ".exs": true,
+}
+
+// manifests declare the tenancy libraries a project depends on
+var manifests = map[string]bool{
+	"go.mod": true, "package.json": true, "requirements.txt": true, "pyproject.toml": true, "Pipfile": true,
+	"Gemfile": true, "composer.json": true, "pom.xml": true, "build.gradle": true, "build.gradle.kts": true, "mix.exs": true,
+}
+
+// Analyze reports the project's tenancy model, or nil when it shows no sign of serving several
+// tenants
+func Analyze(files *filestore.FileStore, schema *database.DatabaseSchema) (*Report, error) {
+	fmt.Printf("🏢 [DEBUG] Looking for multi-tenancy in %d files\n", files.Len())
+
+	report := &Report{Scores: make(map[string]int), Evidence: []Evidence{}, Guidance: []string{}}
+	policies := make(map[string]bool)
+	counts := make(map[string]int) // Model and detail to lines kept
+	err := files.Walk(func(rel string) bool {
+		name := path.Base(rel)
+		return !filestore.InDirs(rel, skippedDirs) && !isTestFile(name) &&
+			(database.IsMigrationFile(rel) || sourceExtensions[path.Ext(name)] || manifests[name])
+	}, func(rel, content string) error {
+		text := portable.Text(content)
+		if database.IsMigrationFile(rel) {
+			scanMigration(report, policies, rel, text)
+			return nil
+		}
+		for _, e := range scanFile(rel, text) {
+			key := e.Model + "\x00" + e.Detail
+			if counts[key] < maxEvidence {
+				report.Evidence = append(report.Evidence, e)
+			}
+			counts[key]++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan for multi-tenancy: %v", err)
+	}
+
+	scanSchema(report, schema)
+	report.RowLevelSecurity = sortedKeys(policies)
+	score(report)
+	if report.Model == "" {
+		fmt.Printf("✅ [DEBUG] No multi-tenancy found\n")
+		return nil, nil
+	}
+	report.Label = modelLabels[report.Model]
+	report.Guidance = guidance(report)
+	report.Summary = summarize(report)
+	fmt.Printf("✅ [DEBUG] %s\n", report.Summary)
+	return report, nil
+}
+
+// scanFile matches every rule against the file's code lines; each rule counts once per line
+func scanFile(rel, content string) []Evidence {
+	var evidence []Evidence
+	for i, line := range portable.Lines(content) {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "*") {
+			continue
+		}
+		// Pattern definitions (such as this package's rules) name libraries without using them
+		if strings.Contains(line, "regexp.MustCompile(") || strings.Contains(line, "re.compile(") || strings.Contains(line, "new RegExp(") {
+			continue
+		}
+		for _, r := range rules {
+			if r.tenantOnly && !strings.Contains(strings.ToLower(line), "tenant") {
+				continue
+			}
+			if r.pattern.MatchString(line) {
+				evidence = append(evidence, Evidence{Model: r.model, Detail: r.detail, File: rel, Line: i + 1})
+			}
+		}
+	}
+	return evidence
+}
+
+// scanMigration records the tables with row-level security and the setting their policies read
+func scanMigration(report *Report, policies map[string]bool, rel, content string) {
+	for _, m := range enableRLSRegex.FindAllStringSubmatchIndex(content, -1) {
+		table := tableName(content[m[2]:m[3]])
+		if policies[table] {
+			continue
+		}
+		policies[table] = true
+		if len(policies) <= maxEvidence {
+			report.Evidence = append(report.Evidence, Evidence{Model: SharedSchema, Detail: "row-level security", File: rel, Line: lineOf(content, m[0])})
+		}
+	}
+	for _, m := range policyRegex.FindAllStringSubmatch(content, -1) {
+		if setting := settingRegex.FindStringSubmatch(m[2]); setting != nil && report.TenantSetting == "" {
+			report.TenantSetting = setting[1]
+		}
+	}
+}
+
+// scanSchema finds the tenant column most tables carry
+func scanSchema(report *Report, schema *database.DatabaseSchema) {
+	if schema == nil {
+		return
+	}
+	var tables []string
+	for name := range schema.Tables {
+		if !bookkeepingTables[tableName(name)] {
+			tables = append(tables, name)
+		}
+	}
+	sort.Strings(tables)
+
+	for _, column := range tenantColumns {
+		var scoped, global []string
+		for _, name := range tables {
+			if hasColumn(schema.Tables[name], column) {
+				scoped = append(scoped, name)
+			} else {
+				global = append(global, name)
+			}
+		}
+		if len(scoped) < 2 {
+			continue
+		}
+		// The tenant table itself has no tenant column; leave it out of the coverage
+		tenantTable := referencedTable(schema, scoped, column)
+		global = remove(global, tenantTable)
+		if float64(len(scoped)) < 0.5*float64(len(scoped)+len(global)) {
+			continue
+		}
+		report.TenantColumn = column
+		report.TenantTable = tenantTable
+		report.ScopedTables = scoped
+		report.GlobalTables = global
+		return
+	}
+}
+
+// referencedTable is the table the tenant column refers to: a foreign key target, or else a table
+// named like tenants or organizations
+func referencedTable(schema *database.DatabaseSchema, scoped []string, column string) string {
+	for _, name := range scoped {
+		if c, ok := schema.Tables[name].Columns[column]; ok && c.References != nil {
+			return c.References.Table
+		}
+	}
+	prefix := strings.TrimSuffix(strings.TrimSuffix(column, "_id"), "id")
+	for _, candidate := range tenantTables {
+		if !strings.HasPrefix(candidate, prefix) {
+			continue
+		}
+		for name := range schema.Tables {
+			if tableName(name) == candidate {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// score weighs the evidence for each model and picks the strongest
+func score(report *Report) {
+	resolution := make(map[string]bool)
+	details := make(map[string]map[string]bool)
+	for _, e := range report.Evidence {
+		if e.Model == "" {
+			resolution[e.Detail] = true
+			continue
+		}
+		if details[e.Model] == nil {
+			details[e.Model] = make(map[string]bool)
+		}
+		details[e.Model][e.Detail] = true
+	}
+	// Each idiom counts once, so a tenant filter repeated in every query does not outweigh a library
+	for _, model := range modelOrder {
+		report.Scores[model] = 2 * len(details[model])
+	}
+	if report.TenantColumn != "" {
+		report.Scores[SharedSchema] += 3
+	}
+	if len(report.RowLevelSecurity) > 0 {
+		report.Scores[SharedSchema] += 2 // On top of the row-level security evidence itself
+	}
+
+	best, strong := "", 0
+	for _, model := range modelOrder {
+		if report.Scores[model] >= minScore {
+			strong++
+		}
+		if best == "" || report.Scores[model] > report.Scores[best] {
+			best = model
+		}
+	}
+	switch {
+	case strong > 1:
+		report.Model = Hybrid
+	case report.Scores[best]+len(resolution) >= minScore && report.Scores[best] > 0:
+		report.Model = best
+	}
+}
+
+// guidance tells a new engineer how to write queries under the model
+func guidance(report *Report) []string {
+	var lines []string
+	shared := report.Model == SharedSchema || report.Model == Hybrid && report.Scores[SharedSchema] >= minScore
+	if shared {
+		column := report.TenantColumn
+		if column == "" {
+			column = "the tenant column"
+		}
+		if len(report.ScopedTables) > 0 {
+			lines = append(lines, fmt.Sprintf("Every query on the %d tables with %s must filter by it; take the tenant from the request context, never from client input.", len(report.ScopedTables), column))
+			lines = append(lines, fmt.Sprintf("Include %s in new tables that hold tenant data, and lead their unique constraints and indexes with it.", column))
+		} else {
+			lines = append(lines, "Queries are filtered by tenant in code; follow the existing scoping helpers rather than adding filters by hand.")
+		}
+		if len(report.RowLevelSecurity) > 0 {
+			line := fmt.Sprintf("Postgres row-level security enforces the filter on %d tables", len(report.RowLevelSecurity))
+			if report.TenantSetting != "" {
+				line += fmt.Sprintf(" using the %s setting; set it per transaction (SET LOCAL) so pooled connections do not leak it", report.TenantSetting)
+			}
+			lines = append(lines, line+". Migrations and background jobs that run without it see no rows, or every row as a superuser.")
+		}
+		if len(report.GlobalTables) > 0 {
+			lines = append(lines, fmt.Sprintf("%s have no %s and are shared by every tenant; check that before storing tenant data in them.", listTables(report.GlobalTables), column))
+		}
+	}
+	if report.Model == SchemaPerTenant || report.Model == Hybrid && report.Scores[SchemaPerTenant] >= minScore {
+		lines = append(lines, "Each tenant's tables live in its own schema, selected per request (search_path or the library's tenant switch); queries must run after the switch, and shared tables stay in the public schema.")
+		lines = append(lines, "Migrations run once per tenant schema; a new table needs the tenant migration, not only the shared one.")
+	}
+	if report.Model == DatabasePerTenant || report.Model == Hybrid && report.Scores[DatabasePerTenant] >= minScore {
+		lines = append(lines, "Each tenant has its own database; resolve the tenant's connection before querying, and never cache a connection across tenants.")
+		lines = append(lines, "Migrations and backfills must run against every tenant database.")
+	}
+	return lines
+}
+
+func summarize(report *Report) string {
+	summary := report.Label
+	if report.Model == Hybrid {
+		var models []string
+		for _, model := range modelOrder {
+			if report.Scores[model] >= minScore {
+				models = append(models, strings.ToLower(modelLabels[model]))
+			}
+		}
+		summary += ": " + strings.Join(models, " and ")
+	}
+	if report.TenantColumn != "" {
+		summary += fmt.Sprintf("; %s on %d of %d tables", report.TenantColumn, len(report.ScopedTables), len(report.ScopedTables)+len(report.GlobalTables))
+	}
+	if len(report.RowLevelSecurity) > 0 {
+		summary += fmt.Sprintf("; row-level security on %d tables", len(report.RowLevelSecurity))
+	}
+	return summary
+}
+
+// listTables names up to five tables
+func listTables(tables []string) string {
+	if len(tables) <= 5 {
+		return strings.Join(tables, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(tables[:5], ", "), len(tables)-5)
+}
+
+func hasColumn(table database.Table, column string) bool {
+	for name := range table.Columns {
+		if strings.EqualFold(name, column) {
+			return true
+		}
+	}
+	return false
+}
+
+// tableName lowercases a table name and drops its quotes and schema qualifier
+func tableName(name string) string {
+	name = strings.ToLower(strings.ReplaceAll(name, `"`, ""))
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+func remove(values []string, value string) []string {
+	kept := values[:0]
+	for _, v := range values {
+		if v != value {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
+
+func isTestFile(name string) bool {
+	return strings.HasSuffix(name, "_test.go") || strings.Contains(name, ".test.") ||
+		strings.Contains(name, ".spec.") || strings.HasPrefix(name, "test_")
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// lineOf returns the 1-based line of a byte offset
+func lineOf(content string, offset int) int {
+	return strings.Count(content[:offset], "\n") + 1
+}
@@ -0,0 +1,384 @@
+package toolchain
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"repo-explanation/internal/filestore"
+	"repo-explanation/internal/microservices"
+	"repo-explanation/internal/portable"
+)
+
+// Source is one place that states which version of a toolchain the project needs
+type Source struct {
+	File       string `json:"file"`
+	Service    string `json:"service,omitempty"`
+	Constraint string `json:"constraint"` // As written, e.g. ">=18", "1.22", "golang:1.23-alpine"
+	Version    string `json:"version"`    // Version number read from the constraint
+	Exact      bool   `json:"exact"`      // A pin rather than a minimum
+}
+
+// Toolchain is a runtime or build tool the project needs, with the version to install
+type Toolchain struct {
+	Name    string   `json:"name"`
+	Version string   `json:"version"` // Highest pinned version, or ">= minimum" when no pin satisfies every minimum
+	Sources []Source `json:"sources"`
+}
+
+// Conflict is a disagreement between sources about a toolchain's version
+type Conflict struct {
+	Toolchain string   `json:"toolchain"`
+	Message   string   `json:"message"`
+	Sources   []Source `json:"sources"`
+}
+
+// Report lists the toolchains to install and the version conflicts between services
+type Report struct {
+	Toolchains []Toolchain `json:"toolchains"`
+	Conflicts  []Conflict  `json:"conflicts,omitempty"`
+	Summary    string      `json:"summary"`
+}
+
+// precision is how many version components matter when comparing pins; Node majors are
+// what break compatibility, while Go and Python change language and stdlib in minor releases
+var precision = map[string]int{
+	"go": 2, "node": 1, "python": 2, "ruby": 2, "java": 1, "rust": 2, "php": 2,
+	"npm": 1, "pnpm": 1, "yarn": 1,
+}
+
+// dockerImages maps official base images to the toolchain their tag versions
+var dockerImages = map[string]string{
+	"golang": "go", "node": "node", "python": "python", "ruby": "ruby", "rust": "rust", "php": "php",
+	"openjdk": "java", "eclipse-temurin": "java", "amazoncorretto": "java",
+}
+
+// toolVersionNames maps asdf / mise plugin names to toolchains
+var toolVersionNames = map[string]string{
+	"golang": "go", "go": "go", "nodejs": "node", "node": "node", "python": "python", "ruby": "ruby",
+	"java": "java", "rust": "rust", "php": "php", "pnpm": "pnpm", "yarn": "yarn",
+}
+
+// Directories that never state the project's own toolchain
+var skippedDirs = map[string]bool{
+	".git": true, "node_modules": true, "vendor": true, "testdata": true, "dist": true,
+	"build": true, ".next": true, "__pycache__": true, ".venv": true, "venv": true, "target": true,
+}
+
+var (
+	versionRegex          = regexp.MustCompile(`\d+(?:\.\d+){0,2}`)
+	goDirectiveRegex      = regexp.MustCompile(`(?m)^go\s+(\S+)`)
+	goToolchainRegex      = regexp.MustCompile(`(?m)^toolchain\s+go(\S+)`)
+	requiresPythonRegex   = regexp.MustCompile(`(?m)^\s*requires-python\s*=\s*["']([^"']+)["']`)
+	poetryPythonRegex     = regexp.MustCompile(`(?m)^\s*python\s*=\s*["']([^"']+)["']`)
+	gemfileRubyRegex      = regexp.MustCompile(`(?m)^\s*ruby\s+["']([^"']+)["']`)
+	rustToolchainRegex    = regexp.MustCompile(`(?m)^\s*channel\s*=\s*["']([^"']+)["']`)
+	runtimeTxtRegex       = regexp.MustCompile(`^python-(\S+)`)
+	minimumConstraintChar = regexp.MustCompile(`[><^~*xX]|\|\|`)
+)
+
+// Detect reads the project's version files (go.mod, .nvmrc, package.json engines, .python-version,
+// pyproject.toml, .ruby-version, Gemfile, .tool-versions, rust-toolchain, Dockerfile base images) and
+// attributes each to the service whose directory holds it
+func Detect(files *filestore.FileStore, services []microservices.DiscoveredService) (*Report, error) {
+	var sources []namedSource
+	err := files.Walk(func(rel string) bool {
+		return !filestore.InDirs(rel, skippedDirs) && isVersionFile(path.Base(rel))
+	}, func(rel, content string) error {
+		service := microservices.OwningService(path.Dir(rel), services)
+		for _, found := range parseVersionFile(rel, portable.Text(content)) {
+			found.source.File = rel
+			found.source.Service = service
+			sources = append(sources, found)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan for toolchain versions: %v", err)
+	}
+	return buildReport(sources), nil
+}
+
+// namedSource is a source before it is grouped under its toolchain
+type namedSource struct {
+	toolchain string
+	source    Source
+}
+
+func isVersionFile(name string) bool {
+	switch name {
+	case "go.mod", ".nvmrc", ".node-version", "package.json", ".python-version", "pyproject.toml",
+		"runtime.txt", ".ruby-version", "Gemfile", ".tool-versions", "rust-toolchain", "rust-toolchain.toml":
+		return true
+	}
+	return microservices.IsDockerfile(name)
+}
+
+// parseVersionFile reads the toolchain versions one file states
+func parseVersionFile(rel, content string) []namedSource {
+	var found []namedSource
+	add := func(toolchain, constraint string, exact bool) {
+		constraint = strings.TrimSpace(constraint)
+		version := versionRegex.FindString(constraint)
+		if version == "" {
+			return // lts/*, "stable", "latest" and the like name no version
+		}
+		// ">=18", "^3.10", "~> 3.2" and "20.x" are minimums, not pins
+		idx := strings.Index(constraint, version)
+		if minimumConstraintChar.MatchString(constraint[:idx]) || minimumConstraintChar.MatchString(constraint[idx+len(version):]) {
+			exact = false
+		}
+		found = append(found, namedSource{toolchain, Source{Constraint: constraint, Version: version, Exact: exact}})
+	}
+
+	switch name := path.Base(rel); name {
+	case "go.mod":
+		// The go directive is the minimum language version; toolchain pins the release to use
+		if matches := goDirectiveRegex.FindStringSubmatch(content); matches != nil {
+			add("go", ">="+matches[1], false)
+		}
+		if matches := goToolchainRegex.FindStringSubmatch(content); matches != nil {
+			add("go", matches[1], true)
+		}
+	case ".nvmrc", ".node-version":
+		add("node", strings.TrimPrefix(firstLine(content), "v"), true)
+	case "package.json":
+		var pkg struct {
+			Engines        map[string]string `json:"engines"`
+			PackageManager string            `json:"packageManager"`
+		}
+		if json.Unmarshal([]byte(content), &pkg) != nil {
+			return nil
+		}
+		for _, engine := range []string{"node", "npm", "pnpm", "yarn"} {
+			if constraint, ok := pkg.Engines[engine]; ok {
+				add(engine, constraint, true)
+			}
+		}
+		if manager, version, ok := strings.Cut(pkg.PackageManager, "@"); ok {
+			add(manager, strings.SplitN(version, "+", 2)[0], true)
+		}
+	case ".python-version":
+		add("python", firstLine(content), true)
+	case "runtime.txt":
+		if matches := runtimeTxtRegex.FindStringSubmatch(firstLine(content)); matches != nil {
+			add("python", matches[1], true)
+		}
+	case "pyproject.toml":
+		if matches := requiresPythonRegex.FindStringSubmatch(content); matches != nil {
+			add("python", matches[1], true)
+		} else if matches := poetryPythonRegex.FindStringSubmatch(content); matches != nil {
+			add("python", matches[1], true)
+		}
+	case ".ruby-version":
+		add("ruby", strings.TrimPrefix(firstLine(content), "ruby-"), true)
+	case "Gemfile":
+		if matches := gemfileRubyRegex.FindStringSubmatch(content); matches != nil {
+			add("ruby", matches[1], true)
+		}
+	case ".tool-versions":
+		for _, line := range portable.Lines(content) {
+			fields := strings.Fields(line)
+			if len(fields) >= 2 && !strings.HasPrefix(fields[0], "#") {
+				if toolchain, ok := toolVersionNames[fields[0]]; ok {
+					add(toolchain, fields[1], true)
+				}
+			}
+		}
+	case "rust-toolchain", "rust-toolchain.toml":
+		if matches := rustToolchainRegex.FindStringSubmatch(content); matches != nil {
+			add("rust", matches[1], true)
+		} else if !strings.Contains(content, "[") {
+			add("rust", firstLine(content), true)
+		}
+	default:
+		// Dockerfile: every stage's base image, with ${ARG} defaults substituted into the tag
+		container := microservices.ParseDockerfile(rel, content)
+		defaults := make(map[string]string)
+		for _, arg := range container.BuildArgs {
+			if arg.HasDefault {
+				defaults[arg.Name] = arg.Value
+			}
+		}
+		for _, stage := range container.Stages {
+			image := os.Expand(stage.Image, func(name string) string { return defaults[name] })
+			if toolchain, tag := imageToolchain(image); toolchain != "" {
+				before := len(found)
+				add(toolchain, tag, true)
+				if len(found) > before {
+					found[len(found)-1].source.Constraint = image
+				}
+			}
+		}
+	}
+	return found
+}
+
+// imageToolchain returns the toolchain an official image provides and its tag, for golang:1.23-alpine
+// or docker.io/library/node:20
+func imageToolchain(image string) (string, string) {
+	image = strings.SplitN(image, "@", 2)[0]
+	lastSlash := strings.LastIndex(image, "/")
+	nameAndTag := image[lastSlash+1:]
+	name, tag, ok := strings.Cut(nameAndTag, ":")
+	if !ok {
+		return "", ""
+	}
+	toolchain := dockerImages[name]
+	if toolchain == "" || tag == "" || !strings.ContainsAny(tag[:1], "0123456789") {
+		return "", ""
+	}
+	return toolchain, strings.SplitN(tag, "-", 2)[0]
+}
+
+// buildReport groups sources per toolchain, picks the version to install and finds conflicts
+func buildReport(found []namedSource) *Report {
+	report := &Report{Toolchains: []Toolchain{}}
+	byToolchain := make(map[string][]Source)
+	for _, entry := range found {
+		byToolchain[entry.toolchain] = append(byToolchain[entry.toolchain], entry.source)
+	}
+
+	names := make([]string, 0, len(byToolchain))
+	for name := range byToolchain {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		sources := byToolchain[name]
+		sort.SliceStable(sources, func(i, j int) bool { return sources[i].File < sources[j].File })
+		digits := precision[name]
+		if digits == 0 {
+			digits = 2
+		}
+
+		var highestPin, highestMinimum *Source
+		pins := make(map[string][]Source)
+		for i := range sources {
+			source := &sources[i]
+			if source.Exact {
+				pins[truncate(source.Version, digits)] = append(pins[truncate(source.Version, digits)], *source)
+				if highestPin == nil || compareVersions(source.Version, highestPin.Version) > 0 {
+					highestPin = source
+				}
+			} else if highestMinimum == nil || compareVersions(source.Version, highestMinimum.Version) > 0 {
+				highestMinimum = source
+			}
+		}
+
+		toolchain := Toolchain{Name: name, Sources: sources}
+		// A pin below some service's minimum cannot satisfy it, so the minimum wins
+		if highestPin != nil && (highestMinimum == nil || compareVersions(highestPin.Version, highestMinimum.Version) >= 0) {
+			toolchain.Version = highestPin.Version
+		} else {
+			toolchain.Version = ">= " + highestMinimum.Version
+		}
+		report.Toolchains = append(report.Toolchains, toolchain)
+
+		if len(pins) > 1 {
+			var versions []string
+			for version := range pins {
+				versions = append(versions, version)
+			}
+			sort.Slice(versions, func(i, j int) bool { return compareVersions(versions[i], versions[j]) < 0 })
+			var pinned []Source
+			var parts []string
+			for _, version := range versions {
+				pinned = append(pinned, pins[version]...)
+				parts = append(parts, fmt.Sprintf("%s (%s)", version, describeSources(pins[version])))
+			}
+			report.Conflicts = append(report.Conflicts, Conflict{
+				Toolchain: name,
+				Message:   fmt.Sprintf("%s is pinned to different versions: %s", name, strings.Join(parts, " vs ")),
+				Sources:   pinned,
+			})
+		}
+		if highestMinimum != nil {
+			for _, source := range sources {
+				if source.Exact && compareVersions(source.Version, highestMinimum.Version) < 0 && truncate(source.Version, digits) != truncate(highestMinimum.Version, digits) {
+					report.Conflicts = append(report.Conflicts, Conflict{
+						Toolchain: name,
+						Message:   fmt.Sprintf("%s %s pinned in %s is older than %s required by %s", name, source.Version, describeSources([]Source{source}), highestMinimum.Constraint, describeSources([]Source{*highestMinimum})),
+						Sources:   []Source{source, *highestMinimum},
+					})
+				}
+			}
+		}
+	}
+
+	report.Summary = summarize(report)
+	return report
+}
+
+// describeSources names the files (and services) behind a version
+func describeSources(sources []Source) string {
+	var parts []string
+	for _, source := range sources {
+		if source.Service != "" {
+			parts = append(parts, fmt.Sprintf("%s in %s", source.Service, source.File))
+		} else {
+			parts = append(parts, source.File)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func summarize(report *Report) string {
+	if len(report.Toolchains) == 0 {
+		return "No toolchain versions declared"
+	}
+	var parts []string
+	for _, toolchain := range report.Toolchains {
+		parts = append(parts, toolchain.Name+" "+toolchain.Version)
+	}
+	summary := "Install " + strings.Join(parts, ", ")
+	if len(report.Conflicts) > 0 {
+		summary += fmt.Sprintf("; %d version conflict(s) between services", len(report.Conflicts))
+	}
+	return summary
+}
+
+// truncate keeps the first digits components of a version
+func truncate(version string, digits int) string {
+	parts := strings.Split(version, ".")
+	if len(parts) > digits {
+		parts = parts[:digits]
+	}
+	return strings.Join(parts, ".")
+}
+
+// compareVersions compares dotted numeric versions; missing components count as zero
+func compareVersions(a, b string) int {
+	aParts, bParts := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		var x, y int
+		if i < len(aParts) {
+			x, _ = strconv.Atoi(aParts[i])
+		}
+		if i < len(bParts) {
+			y, _ = strconv.Atoi(bParts[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func firstLine(content string) string {
+	for _, line := range portable.Lines(content) {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			return line
+		}
+	}
+	return ""
+}
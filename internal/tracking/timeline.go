@@ -0,0 +1,180 @@
+package tracking
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"repo-explanation/internal/atrest"
+	"repo-explanation/internal/compare"
+)
+
+// maxHistory bounds the runs kept per repository; the oldest are dropped first
+const maxHistory = 1000
+
+// Timeline metrics, in the order dashboards list them
+const (
+	MetricServices        = "services"
+	MetricTables          = "tables"
+	MetricLOC             = "loc"
+	MetricDependencies    = "dependencies"
+	MetricRequiredSecrets = "required_secrets"
+)
+
+// Metrics lists the timeline metrics
+var Metrics = []string{MetricServices, MetricTables, MetricLOC, MetricDependencies, MetricRequiredSecrets}
+
+// Point is the size of a repository at one run
+type Point struct {
+	At     time.Time      `json:"at"`
+	Commit string         `json:"commit,omitempty"`
+	Values map[string]int `json:"values"` // Per metric
+}
+
+// Series is one metric across runs, oldest first, with the range a sparkline is scaled to
+type Series struct {
+	Values []int `json:"values"`
+	Min    int   `json:"min"`
+	Max    int   `json:"max"`
+	Latest int   `json:"latest"`
+	Change int   `json:"change"` // Latest minus the first value shown
+}
+
+// Timeline is how a tracked repository evolved across its runs. Timestamps, commits and the values
+// of every series line up index by index.
+type Timeline struct {
+	Repository string            `json:"repository"`
+	Runs       int               `json:"runs"` // Runs recorded, including those before the limit
+	Timestamps []time.Time       `json:"timestamps"`
+	Commits    []string          `json:"commits"`
+	Series     map[string]Series `json:"series"` // Keyed by metric
+}
+
+// Timeline returns the runs of a tracked repository, or only the latest limit runs when limit is positive
+func (s *Scheduler) Timeline(name string, limit int) (*Timeline, error) {
+	for _, repo := range s.repos {
+		if repo.Name != name {
+			continue
+		}
+		history, err := s.loadHistory(name)
+		if err != nil {
+			return nil, err
+		}
+		return buildTimeline(name, history, limit), nil
+	}
+	return nil, ErrUnknownRepository
+}
+
+// buildTimeline lays the latest limit points out as series
+func buildTimeline(name string, history []Point, limit int) *Timeline {
+	timeline := &Timeline{Repository: name, Runs: len(history), Timestamps: []time.Time{}, Commits: []string{}, Series: make(map[string]Series)}
+	if limit > 0 && len(history) > limit {
+		history = history[len(history)-limit:]
+	}
+	for _, point := range history {
+		timeline.Timestamps = append(timeline.Timestamps, point.At)
+		timeline.Commits = append(timeline.Commits, point.Commit)
+	}
+	for _, metric := range Metrics {
+		series := Series{Values: []int{}}
+		for i, point := range history {
+			value := point.Values[metric]
+			series.Values = append(series.Values, value)
+			if i == 0 || value < series.Min {
+				series.Min = value
+			}
+			if i == 0 || value > series.Max {
+				series.Max = value
+			}
+		}
+		if len(series.Values) > 0 {
+			series.Latest = series.Values[len(series.Values)-1]
+			series.Change = series.Latest - series.Values[0]
+		}
+		timeline.Series[metric] = series
+	}
+	return timeline
+}
+
+// pointOf measures a snapshot. Lines of code come from the complexity report and dependencies are
+// the packages the repository's manifests declare.
+func pointOf(snapshot *compare.Snapshot, at time.Time) Point {
+	point := Point{At: at.UTC(), Commit: snapshot.Ref.Commit, Values: make(map[string]int, len(Metrics))}
+	point.Values[MetricDependencies] = len(snapshot.Dependencies)
+	result := snapshot.Result
+	if result == nil {
+		return point
+	}
+	point.Values[MetricServices] = len(result.Services)
+	if result.DatabaseSchema != nil {
+		point.Values[MetricTables] = len(result.DatabaseSchema.Tables)
+	}
+	if result.Complexity != nil {
+		point.Values[MetricLOC] = result.Complexity.Project.Metrics.LOC
+	}
+	if result.ProjectSecrets != nil {
+		point.Values[MetricRequiredSecrets] = result.ProjectSecrets.RequiredCount
+	}
+	return point
+}
+
+// record appends a run to the repository's history. A repository tracked before histories were
+// kept starts its history with the snapshot of its previous run.
+func (s *Scheduler) record(name string, previous *compare.Snapshot, previousAt time.Time, current *compare.Snapshot, at time.Time) error {
+	history, err := s.loadHistory(name)
+	if err != nil {
+		fmt.Printf("⚠️  [TRACKING] Starting a new history for %s: %v\n", name, err)
+		history = nil
+	}
+	if len(history) == 0 && previous != nil {
+		history = append(history, pointOf(previous, previousAt))
+	}
+	history = append(history, pointOf(current, at))
+	if len(history) > maxHistory {
+		history = history[len(history)-maxHistory:]
+	}
+	return s.saveHistory(name, history)
+}
+
+// loadHistory reads the runs recorded for a repository, oldest first; nil before the first run
+func (s *Scheduler) loadHistory(name string) ([]Point, error) {
+	path := s.historyPath(name)
+	data, err := atrest.For(s.config).ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var history []Point
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, fmt.Errorf("%s is not a run history: %v", path, err)
+	}
+	return history, nil
+}
+
+// saveHistory replaces the stored history atomically, like saveSnapshot
+func (s *Scheduler) saveHistory(name string, history []Point) error {
+	data, err := json.Marshal(history)
+	if err != nil {
+		return fmt.Errorf("failed to encode history: %v", err)
+	}
+	path := s.historyPath(name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %v", err)
+	}
+	tmp := path + ".tmp"
+	if err := atrest.For(s.config).WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write history: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write history: %v", err)
+	}
+	return nil
+}
+
+func (s *Scheduler) historyPath(name string) string {
+	return filepath.Join(s.stateDir, "history", fileName(name)+".json")
+}
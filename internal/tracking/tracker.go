@@ -0,0 +1,370 @@
+// Package tracking re-analyzes the repositories a server deployment tracks, each on its own cron
+// schedule, and reports what changed architecturally since the previous run by webhook and email.
+package tracking
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"repo-explanation/config"
+	"repo-explanation/internal/atrest"
+	"repo-explanation/internal/compare"
+	"repo-explanation/internal/notify"
+	"repo-explanation/internal/pipeline"
+)
+
+var (
+	// ErrUnknownRepository is returned for a name that is not tracked
+	ErrUnknownRepository = errors.New("repository is not tracked")
+	// ErrRunning is returned when a run is requested while the repository is being analyzed
+	ErrRunning = errors.New("repository is already being analyzed")
+)
+
+// Status describes a tracked repository and its latest run
+type Status struct {
+	Name        string     `json:"name"`
+	Source      string     `json:"source"` // Local path or git URL
+	Schedule    string     `json:"schedule"`
+	NextRun     *time.Time `json:"next_run,omitempty"`
+	Running     bool       `json:"running"`
+	LastRun     *time.Time `json:"last_run,omitempty"`
+	LastCommit  string     `json:"last_commit,omitempty"`
+	LastSummary string     `json:"last_summary,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+}
+
+// Run is the outcome of one re-analysis
+type Run struct {
+	Repository string          `json:"repository"`
+	StartedAt  time.Time       `json:"started_at"`
+	Commit     string          `json:"commit,omitempty"`
+	Drift      *compare.Result `json:"drift,omitempty"` // Nil on the first run, which records the baseline
+	Summary    string          `json:"summary"`
+	Notified   []string        `json:"notified,omitempty"` // Destinations the report was sent to
+}
+
+// Scheduler runs the tracked repositories on their schedules
+type Scheduler struct {
+	config   *config.Config
+	stateDir string
+	repos    []*trackedRepo
+}
+
+type trackedRepo struct {
+	config.TrackedRepository
+	schedule  *Schedule
+	notifiers []notify.Notifier
+	running   sync.Mutex // Held for the duration of a run
+
+	mu     sync.Mutex
+	status Status
+}
+
+// NewScheduler parses every tracked repository's schedule and notification targets
+func NewScheduler(cfg *config.Config) (*Scheduler, error) {
+	s := &Scheduler{config: cfg, stateDir: cfg.Tracking.StateDirectory}
+	var problems []string
+	for _, repoConfig := range cfg.Tracking.Repositories {
+		schedule, err := ParseSchedule(repoConfig.Schedule)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("tracking.repositories %s: %v", repoConfig.Name, err))
+			continue
+		}
+		repo := &trackedRepo{TrackedRepository: repoConfig, schedule: schedule}
+		for _, url := range repoConfig.Webhooks {
+			repo.notifiers = append(repo.notifiers, notify.NewWebhook(url))
+		}
+		if len(repoConfig.Email) > 0 {
+			repo.notifiers = append(repo.notifiers, notify.NewEmail(cfg.Tracking.SMTP, repoConfig.Email))
+		}
+		repo.status = Status{Name: repoConfig.Name, Source: repo.source(), Schedule: repoConfig.Schedule}
+		if previous, modTime, err := s.loadSnapshot(repo.Name); err == nil && previous != nil {
+			repo.status.LastRun = &modTime
+			repo.status.LastCommit = previous.Ref.Commit
+		}
+		s.repos = append(s.repos, repo)
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid tracked repositories:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+	return s, nil
+}
+
+// Start runs every repository on its schedule until ctx is done
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, repo := range s.repos {
+		go s.loop(ctx, repo)
+	}
+	fmt.Printf("⏰ [TRACKING] Scheduled %d tracked repositories\n", len(s.repos))
+}
+
+func (s *Scheduler) loop(ctx context.Context, repo *trackedRepo) {
+	for {
+		next := repo.schedule.Next(time.Now())
+		if next.IsZero() {
+			fmt.Printf("⚠️  [TRACKING] Schedule %q of %s never matches\n", repo.Schedule, repo.Name)
+			return
+		}
+		repo.update(func(status *Status) { status.NextRun = &next })
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if _, err := s.run(ctx, repo); err != nil && !errors.Is(err, ErrRunning) {
+			fmt.Printf("❌ [TRACKING] %s: %v\n", repo.Name, err)
+		}
+	}
+}
+
+// Statuses lists the tracked repositories in configuration order
+func (s *Scheduler) Statuses() []Status {
+	statuses := make([]Status, len(s.repos))
+	for i, repo := range s.repos {
+		repo.mu.Lock()
+		statuses[i] = repo.status
+		repo.mu.Unlock()
+	}
+	return statuses
+}
+
+// Run re-analyzes the named repository now, outside its schedule
+func (s *Scheduler) Run(ctx context.Context, name string) (*Run, error) {
+	for _, repo := range s.repos {
+		if repo.Name == name {
+			return s.run(ctx, repo)
+		}
+	}
+	return nil, ErrUnknownRepository
+}
+
+// run updates the checkout, analyzes it, diffs it against the stored snapshot of the previous
+// run, stores the new one and sends the report
+func (s *Scheduler) run(ctx context.Context, repo *trackedRepo) (*Run, error) {
+	if !repo.running.TryLock() {
+		return nil, ErrRunning
+	}
+	defer repo.running.Unlock()
+
+	started := time.Now()
+	repo.update(func(status *Status) { status.Running = true })
+	run, err := s.analyze(ctx, repo, started)
+	repo.update(func(status *Status) {
+		status.Running = false
+		status.LastRun = &started
+		status.LastError = ""
+		if run != nil {
+			status.LastCommit = run.Commit
+			status.LastSummary = run.Summary
+		}
+		if err != nil {
+			status.LastError = err.Error()
+		}
+	})
+	return run, err
+}
+
+func (s *Scheduler) analyze(ctx context.Context, repo *trackedRepo, started time.Time) (*Run, error) {
+	fmt.Printf("⏰ [TRACKING] Re-analyzing %s (%s)\n", repo.Name, repo.source())
+	ctx, cancel := context.WithTimeout(ctx, s.config.GetAnalysisTimeout())
+	defer cancel()
+
+	projectPath, err := s.checkout(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	commit, _ := git(ctx, projectPath, "rev-parse", "HEAD") // Empty for a directory outside git
+
+	phases := repo.Phases
+	if len(phases) == 0 {
+		phases = compare.DefaultPhases
+	}
+	analyzer, err := pipeline.NewAnalyzerWithURL(s.config, projectPath, repo.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create analyzer: %v", err)
+	}
+	if err := analyzer.SetOptions(pipeline.AnalysisOptions{Phases: phases}); err != nil {
+		return nil, fmt.Errorf("invalid analysis options: %v", err)
+	}
+	result, err := analyzer.AnalyzeProjectWithProgress(ctx, func(string, string, string, int, interface{}) {})
+	if err != nil {
+		return nil, fmt.Errorf("analysis failed: %v", err)
+	}
+	current, err := compare.Capture(projectPath, compare.Ref{Ref: started.Format("2006-01-02 15:04"), Commit: commit}, result, s.config.GetScanFileBytes())
+	if err != nil {
+		return nil, err
+	}
+
+	previous, previousAt, err := s.loadSnapshot(repo.Name)
+	if err != nil {
+		fmt.Printf("⚠️  [TRACKING] Ignoring the previous snapshot of %s: %v\n", repo.Name, err)
+	}
+	run := &Run{Repository: repo.Name, StartedAt: started, Commit: commit}
+	if previous != nil {
+		run.Drift = compare.Diff(previous, current)
+		run.Drift.Path = repo.source()
+		run.Summary = run.Drift.Summary
+	} else {
+		run.Summary = fmt.Sprintf("Baseline recorded at %s", current.Ref.Ref)
+	}
+	if err := s.saveSnapshot(repo.Name, current); err != nil {
+		return nil, err
+	}
+	if err := s.record(repo.Name, previous, previousAt, current, started); err != nil {
+		fmt.Printf("⚠️  [TRACKING] %s: the run is missing from the timeline: %v\n", repo.Name, err)
+	}
+	fmt.Printf("✅ [TRACKING] %s: %s\n", repo.Name, run.Summary)
+
+	changed := run.Drift != nil && run.Drift.HasChanges()
+	if len(repo.notifiers) > 0 && (changed || repo.NotifyUnchanged) {
+		if err := notify.Send(ctx, repo.notifiers, report(repo, run)); err != nil {
+			return run, fmt.Errorf("analysis succeeded but the report was not delivered: %v", err)
+		}
+		for _, notifier := range repo.notifiers {
+			run.Notified = append(run.Notified, notifier.String())
+		}
+	}
+	return run, nil
+}
+
+// report renders a run as a notification
+func report(repo *trackedRepo, run *Run) notify.Message {
+	message := notify.Message{
+		Subject: fmt.Sprintf("[repo-analyzer] %s: %s", repo.Name, run.Summary),
+		Data:    run,
+	}
+	if run.Drift != nil {
+		message.Text = fmt.Sprintf("Repository: %s (%s)\n%s", repo.Name, repo.source(), run.Drift.Text())
+	} else {
+		message.Text = fmt.Sprintf("Repository: %s (%s)\n%s; the next run reports changes against it.\n", repo.Name, repo.source(), run.Summary)
+	}
+	return message
+}
+
+// checkout brings the repository up to date and returns the directory to analyze. Repositories
+// given by URL are cloned into the state directory once, then fetched.
+func (s *Scheduler) checkout(ctx context.Context, repo *trackedRepo) (string, error) {
+	if repo.URL == "" {
+		if repo.Pull {
+			if _, err := git(ctx, repo.Path, "pull", "--ff-only"); err != nil {
+				return "", fmt.Errorf("failed to pull %s: %v", repo.Path, err)
+			}
+		}
+		return repo.Path, nil
+	}
+
+	dir := filepath.Join(s.stateDir, "repos", fileName(repo.Name))
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+			return "", fmt.Errorf("failed to create clone directory: %v", err)
+		}
+		os.RemoveAll(dir) // A clone interrupted by a restart
+		cmd := exec.CommandContext(ctx, "git", "clone", "--depth", "1", repo.URL, dir)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return "", fmt.Errorf("failed to clone %s: %v: %s", repo.source(), err, strings.TrimSpace(string(output)))
+		}
+		return dir, nil
+	}
+	if _, err := git(ctx, dir, "fetch", "--depth", "1", "origin", "HEAD"); err != nil {
+		return "", fmt.Errorf("failed to fetch %s: %v", repo.source(), err)
+	}
+	if _, err := git(ctx, dir, "reset", "--hard", "FETCH_HEAD"); err != nil {
+		return "", fmt.Errorf("failed to update the clone of %s: %v", repo.source(), err)
+	}
+	return dir, nil
+}
+
+// loadSnapshot reads the snapshot of a repository's previous run; both are nil before the first run
+func (s *Scheduler) loadSnapshot(name string) (*compare.Snapshot, time.Time, error) {
+	path := s.snapshotPath(name)
+	data, err := atrest.For(s.config).ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, time.Time{}, nil
+	}
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	var snapshot compare.Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil || snapshot.Result == nil {
+		return nil, time.Time{}, fmt.Errorf("%s is not a snapshot: %v", path, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return &snapshot, info.ModTime(), nil
+}
+
+// saveSnapshot replaces the stored snapshot atomically, so a crash never leaves half a file
+func (s *Scheduler) saveSnapshot(name string, snapshot *compare.Snapshot) error {
+	if err := os.MkdirAll(s.stateDir, 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %v", err)
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %v", err)
+	}
+	path := s.snapshotPath(name)
+	tmp := path + ".tmp"
+	if err := atrest.For(s.config).WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write snapshot: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write snapshot: %v", err)
+	}
+	return nil
+}
+
+func (s *Scheduler) snapshotPath(name string) string {
+	return filepath.Join(s.stateDir, fileName(name)+".json")
+}
+
+func (r *trackedRepo) update(change func(status *Status)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	change(&r.status)
+}
+
+// source names where the repository comes from, without credentials embedded in its URL
+func (r *trackedRepo) source() string {
+	if r.URL == "" {
+		return r.Path
+	}
+	return credentialsRegex.ReplaceAllString(r.URL, "://")
+}
+
+var (
+	credentialsRegex = regexp.MustCompile(`://[^/@]+@`)
+	unsafeNameRegex  = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+)
+
+// fileName turns a repository name into a safe file name
+func fileName(name string) string {
+	if safe := strings.Trim(unsafeNameRegex.ReplaceAllString(name, "-"), ".-"); safe != "" {
+		return safe
+	}
+	return "repository"
+}
+
+// git runs a git command in dir and returns its trimmed output
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %v: %s", args[0], err, strings.TrimSpace(string(output)))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,193 @@
+package watch
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Op describes what happened to a file
+type Op string
+
+const (
+	OpCreated  Op = "created"
+	OpModified Op = "modified"
+	OpDeleted  Op = "deleted"
+)
+
+// Change is one file change, with a path relative to the watched root
+type Change struct {
+	Path string `json:"path"`
+	Op   Op     `json:"op"`
+}
+
+// fileState is what a snapshot remembers about a file
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// skippedDirs are never watched: VCS metadata, dependencies and build output
+var skippedDirs = map[string]bool{
+	".git": true, "node_modules": true, "vendor": true, "dist": true, "build": true,
+	".next": true, "target": true, "__pycache__": true, ".venv": true,
+}
+
+// Watcher polls a directory tree for changes and reports them in debounced batches.
+// Polling needs no platform-specific notification API and behaves the same on every OS and filesystem.
+type Watcher struct {
+	root     string
+	interval time.Duration
+	debounce time.Duration
+	ignored  map[string]bool // absolute directories to skip, e.g. the analyzer's own cache
+}
+
+// NewWatcher creates a watcher polling root every interval; a batch is emitted once
+// no further change has been seen for the debounce period
+func NewWatcher(root string, interval, debounce time.Duration, ignoredDirs ...string) (*Watcher, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	ignored := make(map[string]bool)
+	for _, dir := range ignoredDirs {
+		if dir == "" {
+			continue
+		}
+		if abs, err := filepath.Abs(dir); err == nil {
+			ignored[abs] = true
+		}
+	}
+	return &Watcher{root: absRoot, interval: interval, debounce: debounce, ignored: ignored}, nil
+}
+
+// Start polls until ctx is done, sending each debounced batch of changes on the returned channel
+func (w *Watcher) Start(ctx context.Context) <-chan []Change {
+	batches := make(chan []Change)
+
+	go func() {
+		defer close(batches)
+
+		previous := w.snapshot()
+		pending := make(map[string]Op)
+		var lastChange time.Time
+
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			current := w.snapshot()
+			changes := diffSnapshots(previous, current)
+			previous = current
+
+			if len(changes) > 0 {
+				for _, change := range changes {
+					mergeChange(pending, change)
+				}
+				lastChange = time.Now()
+				continue
+			}
+
+			if len(pending) == 0 || time.Since(lastChange) < w.debounce {
+				continue
+			}
+
+			batch := make([]Change, 0, len(pending))
+			for path, op := range pending {
+				batch = append(batch, Change{Path: path, Op: op})
+			}
+			sort.Slice(batch, func(i, j int) bool { return batch[i].Path < batch[j].Path })
+			pending = make(map[string]Op)
+
+			// Changes that cancelled out (created then deleted) leave an empty batch
+			if len(batch) == 0 {
+				continue
+			}
+			select {
+			case batches <- batch:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return batches
+}
+
+// snapshot records the size and modification time of every watched file
+func (w *Watcher) snapshot() map[string]fileState {
+	files := make(map[string]fileState)
+	filepath.WalkDir(w.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Files can vanish mid-walk; the next poll picks up the result
+		}
+		if d.IsDir() {
+			if path != w.root && (skippedDirs[d.Name()] || w.ignored[path]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(w.root, path)
+		if err != nil {
+			return nil
+		}
+		files[rel] = fileState{size: info.Size(), modTime: info.ModTime()}
+		return nil
+	})
+	return files
+}
+
+// diffSnapshots lists the files created, modified or deleted between two snapshots
+func diffSnapshots(previous, current map[string]fileState) []Change {
+	var changes []Change
+	for path, state := range current {
+		old, existed := previous[path]
+		switch {
+		case !existed:
+			changes = append(changes, Change{Path: path, Op: OpCreated})
+		case old.size != state.size || !old.modTime.Equal(state.modTime):
+			changes = append(changes, Change{Path: path, Op: OpModified})
+		}
+	}
+	for path := range previous {
+		if _, exists := current[path]; !exists {
+			changes = append(changes, Change{Path: path, Op: OpDeleted})
+		}
+	}
+	return changes
+}
+
+// mergeChange folds a change into the pending batch so each path is reported once
+// with its net effect (created+modified is created, created+deleted is nothing)
+func mergeChange(pending map[string]Op, change Change) {
+	previous, seen := pending[change.Path]
+	if !seen {
+		pending[change.Path] = change.Op
+		return
+	}
+	switch {
+	case previous == OpCreated && change.Op == OpDeleted:
+		delete(pending, change.Path)
+	case previous == OpCreated:
+		// Still a new file
+	case previous == OpDeleted && change.Op == OpCreated:
+		pending[change.Path] = OpModified
+	default:
+		pending[change.Path] = change.Op
+	}
+}
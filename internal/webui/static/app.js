@@ -0,0 +1,518 @@
+// Single-page UI for the analyzer API: starts a streaming analysis and renders the result.
+// Kept dependency-free so it can be embedded in the server binary; Mermaid is loaded on demand
+// and diagrams fall back to their source when it cannot be fetched.
+(function () {
+  "use strict";
+
+  const API = "/api/v1";
+  const MERMAID_URL = "https://cdn.jsdelivr.net/npm/mermaid@11/dist/mermaid.min.js";
+
+  const $ = (id) => document.getElementById(id);
+  let result = null;
+  let mermaidLoading = null;
+
+  function esc(value) {
+    return String(value == null ? "" : value).replace(/[&<>"']/g, (c) => ({
+      "&": "&amp;", "<": "&lt;", ">": "&gt;", '"': "&quot;", "'": "&#39;",
+    })[c]);
+  }
+
+  function chips(items) {
+    if (!items || !items.length) return '<span class="muted">none</span>';
+    return '<div class="chips">' + items.map((i) => "<span>" + esc(i) + "</span>").join("") + "</div>";
+  }
+
+  function card(title, body) {
+    return '<div class="card"><h3>' + esc(title) + "</h3>" + body + "</div>";
+  }
+
+  // --- Streaming analysis ---
+
+  async function analyze(event) {
+    event.preventDefault();
+    const target = $("target").value.trim();
+    const isURL = /^https?:\/\//.test(target);
+    const body = {
+      url: target,
+      type: isURL ? "github_url" : "local_path",
+      token: $("token").value.trim() || undefined,
+      offline: $("offline").checked,
+      quick: $("quick").checked,
+      profile: $("profile").value || undefined,
+      lang: $("lang").value || undefined,
+    };
+
+    result = null;
+    $("submit").disabled = true;
+    $("error").hidden = true;
+    $("results").hidden = true;
+    $("progress").hidden = false;
+    $("log").innerHTML = "";
+    setProgress(0, "Starting analysis...");
+
+    try {
+      const response = await fetch(API + "/analyze/stream", {
+        method: "POST",
+        headers: { "Content-Type": "application/json" },
+        body: JSON.stringify(body),
+      });
+      if (!response.ok) {
+        const failure = await response.json().catch(() => ({}));
+        throw new Error(failure.error || response.status + " " + response.statusText);
+      }
+      await readEvents(response.body.getReader());
+      if (!result) throw new Error("The stream ended before the analysis completed");
+    } catch (err) {
+      showError(err.message);
+    } finally {
+      $("submit").disabled = false;
+    }
+  }
+
+  // readEvents parses the Server-Sent Events of the response body, one "data:" line per event
+  async function readEvents(reader) {
+    const decoder = new TextDecoder();
+    let buffer = "";
+    for (;;) {
+      const { done, value } = await reader.read();
+      if (done) break;
+      buffer += decoder.decode(value, { stream: true });
+      let boundary;
+      while ((boundary = buffer.indexOf("\n\n")) >= 0) {
+        const chunk = buffer.slice(0, boundary);
+        buffer = buffer.slice(boundary + 2);
+        const data = chunk.split("\n").filter((l) => l.startsWith("data:")).map((l) => l.slice(5).trim()).join("");
+        if (data) handleEvent(JSON.parse(data));
+      }
+    }
+  }
+
+  function handleEvent(event) {
+    switch (event.type) {
+      case "progress":
+      case "stage":
+      case "data":
+        setProgress(event.progress, event.stage || event.message);
+        log(event.stage || event.message);
+        break;
+      case "complete":
+        setProgress(100, event.stage || "Analysis complete");
+        result = event.data;
+        render(result);
+        break;
+      case "error":
+        throw new Error(event.message || event.error || "Analysis failed");
+    }
+  }
+
+  function setProgress(percent, stage) {
+    if (percent > 0) $("bar-fill").style.width = Math.min(100, percent) + "%";
+    if (stage) $("stage").textContent = stage;
+  }
+
+  function log(message) {
+    if (!message) return;
+    const item = document.createElement("li");
+    item.textContent = message;
+    $("log").appendChild(item);
+    $("log").scrollTop = $("log").scrollHeight;
+  }
+
+  function showError(message) {
+    $("error").textContent = message;
+    $("error").hidden = false;
+  }
+
+  // --- Results ---
+
+  function render(data) {
+    $("tab-summary").innerHTML = renderSummary(data);
+    $("tab-services").innerHTML = renderServices(data);
+    $("tab-erd").innerHTML = renderDatabase(data);
+    $("tab-secrets").innerHTML = renderSecrets(data);
+    $("raw").textContent = JSON.stringify(data, null, 2);
+    $("results").hidden = false;
+    showTab("summary");
+  }
+
+  function renderSummary(data) {
+    const summary = data.project_summary || {};
+    const type = data.project_type || {};
+    let html = card("Overview",
+      "<p>" + esc(summary.purpose || "No summary available") + "</p>" +
+      (summary.architecture ? "<p><strong>Architecture:</strong> " + esc(summary.architecture) + "</p>" : "") +
+      (type.primary_type ? "<p><strong>Project type:</strong> " + esc(type.primary_type) + "</p>" : "") +
+      "<p><strong>Languages:</strong></p>" + chips(Object.keys(summary.languages || {})) +
+      "<p><strong>External services:</strong></p>" + chips(summary.external_services) +
+      (data.offline ? '<p class="muted">Offline analysis: summaries are heuristic.</p>' : "") +
+      (data.quick ? '<p class="muted">Quick scan: file and folder summaries are heuristic; run again without it for the deep analysis.</p>' : "") +
+      (data.privacy ? '<p class="muted">🔒 Privacy mode: contents were anonymized before reaching the LLM' +
+        ((data.privacy.restricted_files || []).length ? "; " + data.privacy.restricted_files.length + " restricted files were never sent" : "") + ".</p>" : ""));
+
+    const complexity = data.complexity;
+    if (complexity) {
+      html += card("Complexity & Onboarding",
+        "<p>" + esc(complexity.summary) + "</p>" +
+        "<table><tr><th>Factor</th><th>Value</th><th>Points</th></tr>" + complexity.project.factors.map((f) =>
+          "<tr><td>" + esc(f.name) + "</td><td>" + esc(f.value) + "</td><td>" + esc(f.points) + " / " + esc(f.weight) + "</td></tr>").join("") + "</table>" +
+        ((complexity.services || []).length ? chips(complexity.services.map((s) =>
+          s.name + ": " + Math.round(s.score) + " (" + s.level + "), " + s.onboarding)) : "") +
+        '<p class="muted">' + esc(complexity.formula) + "</p>");
+    }
+
+    const coverage = data.doc_coverage;
+    if (coverage) {
+      html += card("Documentation Coverage",
+        "<p>" + esc(coverage.summary) + "</p>" +
+        "<table><tr><th>Area</th><th>Score</th><th>README</th><th>Doc comments</th><th>API spec</th><th>Gaps</th></tr>" + coverage.areas.map((a) =>
+          "<tr><td>" + esc(a.name) + "</td><td>" + Math.round(a.score) + " (" + esc(a.grade) + ")</td><td>" + esc(a.readme ? a.readme.quality : "none") +
+          "</td><td>" + (a.declarations ? Math.round(a.density * 100) + "% of " + a.declarations : "") + "</td><td>" +
+          esc(a.api_spec || (a.needs_api_spec ? "missing" : "")) + "</td><td>" + esc(a.gaps.join("; ")) + "</td></tr>").join("") + "</table>");
+    }
+
+    const advisories = data.advisories;
+    if (advisories && advisories.advisories.length) {
+      html += card("Version Advisories",
+        "<p>" + esc(advisories.summary) + "</p>" +
+        "<table><tr><th>Status</th><th>Advisory</th><th>Where</th></tr>" + advisories.advisories.map((a) =>
+          "<tr><td>" + esc(a.status) + "</td><td>" + esc(a.message) + "</td><td>" +
+          esc((a.service ? a.service + ", " : "") + a.file) + "</td></tr>").join("") + "</table>");
+    }
+
+    const gen = data.generated;
+    if (gen && (gen.artifacts.length || (gen.pipelines || []).length)) {
+      html += card("Generated Code",
+        "<p>" + esc(gen.summary) + "</p>" +
+        "<table><tr><th>Spec</th><th>Kind</th><th>Generated files</th></tr>" + (gen.specs || []).map((s) =>
+          "<tr><td>" + esc(s.path) + "</td><td>" + esc(s.kind) + "</td><td>" + esc(s.outputs.join(", ")) + "</td></tr>").join("") + "</table>" +
+        ((gen.pipelines || []).length ? "<table><tr><th>Regenerate with</th><th>Generator</th><th>Writes (never edit)</th></tr>" + gen.pipelines.map((p) =>
+          "<tr><td><code>" + esc(p.command) + "</code></td><td>" + esc(p.generator) + "</td><td>" + esc(p.outputs.join(", ")) + "</td></tr>").join("") + "</table>" : "") +
+        chips(gen.artifacts.filter((a) => !a.source).map((a) => a.path + " (" + a.kind + ")")));
+    }
+
+    const jobs = data.jobs;
+    if (jobs && jobs.jobs.length) {
+      html += card("Background Jobs (" + jobs.jobs.length + ")",
+        "<p>" + esc(jobs.summary) + "</p>" +
+        "<table><tr><th>Job</th><th>Kind</th><th>When</th><th>Runs</th><th>Where</th></tr>" + jobs.jobs.map((j) =>
+          "<tr><td>" + esc(j.name) + "</td><td>" + esc(j.kind) + "</td><td>" +
+          esc(j.trigger === "queue" ? "queue " + (j.queue || "") : j.cadence || j.schedule) + "</td><td>" + esc(j.handler || "") +
+          "</td><td>" + esc((j.service ? j.service + ", " : "") + j.file + ":" + j.line) + "</td></tr>").join("") + "</table>");
+    }
+
+    const contracts = data.data_contracts;
+    if (contracts && contracts.contracts.length) {
+      html += card("Data Contracts (" + contracts.contracts.length + ")",
+        "<p>" + esc(contracts.summary) + "</p>" +
+        "<table><tr><th>Contract</th><th>Format</th><th>Channels</th><th>Producers</th><th>Consumers</th><th>File</th></tr>" + contracts.contracts.map((c) =>
+          "<tr><td>" + esc(c.name) + "</td><td>" + esc(c.format) + "</td><td>" + esc(c.channels.join(", ")) + "</td><td>" +
+          esc(c.producers.join(", ")) + "</td><td>" + esc(c.consumers.join(", ")) + "</td><td>" + esc(c.file + ":" + c.line) + "</td></tr>").join("") + "</table>");
+    }
+
+    const archMap = data.architecture_map;
+    if (archMap && archMap.root) {
+      html += card("Architecture Map (" + archMap.folders + " folders)", folderTree(archMap.root, 0));
+    }
+
+    const docs = summary.documentation;
+    if (docs && ((docs.setup_steps || []).length || (docs.run_commands || []).length)) {
+      html += card("How to Run",
+        ((docs.setup_steps || []).length ? "<p><strong>Setup</strong></p><pre>" + esc(docs.setup_steps.join("\n")) + "</pre>" : "") +
+        ((docs.run_commands || []).length ? "<p><strong>Run</strong></p><pre>" + esc(docs.run_commands.join("\n")) + "</pre>" : ""));
+    }
+
+    const glossary = data.glossary;
+    if (glossary && glossary.terms.length) {
+      html += card("Glossary (" + glossary.terms.length + " terms)",
+        "<p>" + esc(glossary.summary) + "</p>" +
+        "<table><tr><th>Term</th><th>Kind</th><th>Definition</th><th>Sources</th></tr>" + glossary.terms.map((t) =>
+          "<tr><td>" + esc(t.term) + "</td><td>" + esc(t.kind) + "</td><td>" + esc(t.definition) + "</td><td>" +
+          esc((t.sources || []).join(", ")) + "</td></tr>").join("") + "</table>");
+    }
+
+    const folders = Object.entries(data.folder_summaries || {}).sort((a, b) => a[0].localeCompare(b[0]));
+    if (folders.length) {
+      html += card("Folders (" + folders.length + ")", folders.map(([path, folder]) =>
+        "<details><summary>" + esc(path) + "</summary><p>" + esc(folder.purpose) + "</p>" +
+        (folder.key_modules && folder.key_modules.length ? chips(folder.key_modules) : "") + "</details>").join(""));
+    }
+
+    if (data.helpful_questions && data.helpful_questions.length) {
+      html += card("Helpful Questions", data.helpful_questions.map((q) =>
+        "<details><summary>" + esc(q.question) + "</summary><p>" + esc(q.answer) + "</p></details>").join(""));
+    }
+    return html;
+  }
+
+  // pinned marks services corrected in the repository's .repo-analyzer.yaml
+  function pinned(s) {
+    const fields = s.user_specified || [];
+    return fields.length ? ' <span title="Set in .repo-analyzer.yaml: ' + esc(fields.join(", ")) + '">📌</span>' : "";
+  }
+
+  function renderServices(data) {
+    const services = data.services || [];
+    if (!services.length) return card("Services", '<p class="muted">No services discovered.</p>') + renderModules(data);
+
+    let html = card("Services (" + services.length + ")",
+      "<table><tr><th>Name</th><th>Path</th><th>Type</th><th>Port</th><th>Entry point</th></tr>" +
+      services.map((s) => "<tr><td>" + esc(s.name) + pinned(s) + "</td><td>" + esc(s.path) + "</td><td>" + esc(s.api_type) +
+        "</td><td>" + esc(s.port) + "</td><td>" + esc(s.entry_point) + "</td></tr>").join("") + "</table>");
+
+    const traced = services.filter((s) => (s.startup || []).length);
+    if (traced.length) {
+      html += card("Startup Sequences", traced.map((s) =>
+        "<details><summary>" + esc(s.name) + " (" + s.startup.length + " steps)</summary><ol>" +
+        s.startup.map((step) => "<li><strong>" + esc(step.kind) + "</strong> <code>" + esc(step.detail) + "</code> " +
+          '<span class="muted">' + esc(step.file) + ":" + step.line + (step.via ? " via " + esc(step.via) : "") + "</span></li>").join("") +
+        "</ol></details>").join(""));
+    }
+
+    const relationships = data.relationships || [];
+    if (relationships.length) {
+      html += card("Service Graph", diagram(serviceGraph(services, relationships)));
+      html += card("Relationships (" + relationships.length + ")",
+        "<table><tr><th>From</th><th>To</th><th>Evidence</th><th>File</th></tr>" +
+        relationships.map((r) => "<tr><td>" + esc(r.from) + "</td><td>" + esc(r.to) + "</td><td>" + esc(r.evidence) +
+          "</td><td>" + esc(r.file_path) + "</td></tr>").join("") + "</table>");
+    }
+    return html + renderModules(data);
+  }
+
+  // renderModules shows the module boundaries found inside a single-service backend
+  function renderModules(data) {
+    const report = data.modules;
+    if (!report || !(report.modules || []).length) return "";
+    return card("Modules (" + report.modules.length + ")",
+      "<p>" + esc(report.summary) + "</p>" +
+      "<table><tr><th>Module</th><th>Packages</th><th>Files</th><th>Cohesion</th><th>Description</th></tr>" +
+      report.modules.map((m) => "<tr><td>" + esc(m.name) + "</td><td>" + esc(m.packages.join(", ")) + "</td><td>" + m.files +
+        "</td><td>" + m.cohesion.toFixed(2) + "</td><td>" + esc(m.description || "") + "</td></tr>").join("") + "</table>" +
+      ((report.shared || []).length ? '<p class="muted">Shared: ' + esc(report.shared.join(", ")) + "</p>" : "")) +
+      card("Module Boundaries", diagram(report.mermaid_graph));
+  }
+
+  function renderDatabase(data) {
+    const schema = data.database_schema;
+    const tables = schema && schema.tables ? Object.keys(schema.tables).sort() : [];
+    const tenancy = data.tenancy;
+    const tenancyCard = tenancy ? card("Multi-Tenancy: " + tenancy.label,
+      "<p>" + esc(tenancy.summary) + "</p>" +
+      "<ul>" + tenancy.guidance.map((line) => "<li>" + esc(line) + "</li>").join("") + "</ul>" +
+      "<table><tr><th>Evidence</th><th>File</th></tr>" + tenancy.evidence.map((e) =>
+        "<tr><td>" + esc(e.detail) + "</td><td>" + esc(e.file + (e.line ? ":" + e.line : "")) + "</td></tr>").join("") + "</table>") : "";
+    if (!tables.length) return card("Database", '<p class="muted">No database schema found.</p>') + tenancyCard;
+
+    const ownership = data.table_ownership;
+    const split = schema.erd_split;
+    let html = split ? card("ERD Domains", "<p>" + esc(split.summary) + "</p>" + diagram(split.index)) +
+      split.domains.map((d) => card("Entity Relationship Diagram: " + d.name + " (" + d.tables.length + " tables)", diagram(d.mermaid))).join("") :
+      card("Entity Relationship Diagram (" + tables.length + " tables)", diagram(erDiagram(schema, ownership)));
+    html += tenancyCard;
+    const sensitivity = schema.sensitivity;
+    if (sensitivity) {
+      html += card("Data Sensitivity",
+        "<p>" + esc(sensitivity.summary) + "</p>" +
+        "<ul>" + sensitivity.warnings.map((w) => "<li>" + esc(w) + "</li>").join("") + "</ul>" +
+        "<table><tr><th>Column</th><th>Class</th><th>Holds</th></tr>" + sensitivity.columns.map((c) =>
+          "<tr><td>" + esc(c.table + "." + c.column) + "</td><td>" + esc(c.class) + "</td><td>" + esc(c.label) + "</td></tr>").join("") + "</table>");
+    }
+    if (ownership && ownership.tables.length) {
+      html += card("Table Ownership",
+        "<p>" + esc(ownership.summary) + "</p>" +
+        '<div class="chips">' + ownership.services.filter((s) => s.owns).map((s) =>
+          '<span style="background:' + esc(s.color) + '">' + esc(s.name + " (" + s.owns + ")") + "</span>").join("") + "</div>" +
+        "<table><tr><th>Table</th><th>Owner</th><th>Accessed by</th><th>Evidence</th></tr>" + ownership.tables.map((t) =>
+          "<tr><td>" + esc(t.table) + (t.shared ? ' <span class="shared-tag">shared</span>' : "") + "</td><td>" + esc(t.owner || "—") +
+          "</td><td>" + esc(t.accessed_by.join(", ")) + "</td><td>" +
+          esc(t.evidence.filter((e) => e.service === t.owner).map((e) => e.kind + " " + e.file + (e.line ? ":" + e.line : "")).join(", ")) +
+          "</td></tr>").join("") + "</table>");
+    }
+    html += card("Tables", tables.map((name) => {
+      const table = schema.tables[name];
+      const columns = Object.values(table.columns || {});
+      return "<details><summary>" + esc(name) + " (" + columns.length + " columns)</summary>" +
+        "<table><tr><th>Column</th><th>Type</th><th>Constraints</th><th>Class</th><th>References</th></tr>" +
+        columns.map((c) => "<tr><td>" + esc(c.name) + "</td><td>" + esc(c.type) + "</td><td>" +
+          esc((c.constraints || []).join(", ")) + "</td><td>" + esc(c.classification || "") + "</td><td>" +
+          (c.references ? esc(c.references.table + "." + c.references.column) : "") + "</td></tr>").join("") +
+        "</table></details>";
+    }).join(""));
+    return html;
+  }
+
+  function renderSecrets(data) {
+    const secrets = data.project_secrets;
+    if (!secrets || !secrets.total_variables) return card("Secrets", '<p class="muted">No environment variables found.</p>');
+
+    const rows = (variables) => "<table><tr><th>Name</th><th>Type</th><th>Required</th><th>Source</th></tr>" +
+      variables.map((v) => "<tr><td>" + esc(v.name) + "</td><td>" + esc(v.type) + "</td><td>" + (v.required ? "yes" : "") +
+        "</td><td>" + esc(v.source) + (v.origin ? ' <span class="muted">(' + esc(v.origin) + ")</span>" : "") + "</td></tr>").join("") + "</table>";
+
+    let html = card("Summary", "<p>" + esc(secrets.summary) + "</p>");
+    if ((secrets.global_secrets || []).length) html += card("Global", rows(secrets.global_secrets));
+    (secrets.services || []).forEach((service) => {
+      html += card(service.service_name + " (" + service.service_path + ")", rows(service.variables || []));
+    });
+    if ((secrets.unattributed || []).length) html += card("Unattributed", rows(secrets.unattributed));
+    if ((secrets.environments || []).length) {
+      html += card("Environments",
+        "<p>Needed in every environment: " + esc((secrets.required_everywhere || []).join(", ") || "none") + "</p>" +
+        "<table><tr><th>Environment</th><th>Files</th><th>Only here</th></tr>" + secrets.environments.map((e) =>
+          "<tr><td>" + esc(e.name) + "</td><td>" + esc(e.files.join(", ")) + "</td><td>" + esc(e.specific.join(", ")) + "</td></tr>").join("") + "</table>");
+    }
+    return html;
+  }
+
+  // --- Diagrams ---
+
+  function mermaidID(name) {
+    return "n_" + String(name).replace(/[^A-Za-z0-9_]/g, "_");
+  }
+
+  // Relationships below this confidence are drawn dashed, as in the server-side graph
+  const LOW_CONFIDENCE = 0.85;
+
+  function serviceGraph(services, relationships) {
+    let graph = "graph TD\n";
+    services.forEach((s) => { graph += "    " + mermaidID(s.name) + '["' + s.name.replace(/"/g, "'") + '"]\n'; });
+    // One edge per pair, dashed when even the strongest evidence is low confidence
+    const best = {};
+    relationships.forEach((r) => {
+      const pair = mermaidID(r.from) + " " + mermaidID(r.to);
+      best[pair] = Math.max(best[pair] || 0, r.confidence || 0);
+    });
+    Object.keys(best).forEach((pair) => {
+      const ids = pair.split(" ");
+      graph += "    " + ids[0] + (best[pair] < LOW_CONFIDENCE ? " -.-> " : " --> ") + ids[1] + "\n";
+    });
+    return graph;
+  }
+
+  // erDiagram renders the schema; with an ownership report, tables take their owner's color and
+  // shared tables get a red outline
+  function erDiagram(schema, ownership) {
+    let graph = "erDiagram\n";
+    Object.keys(schema.tables).sort().forEach((name) => {
+      graph += "    " + mermaidID(name) + '["' + name + '"] {\n';
+      Object.values(schema.tables[name].columns || {}).forEach((c) => {
+        const type = (c.type || "unknown").replace(/[^A-Za-z0-9_]/g, "_");
+        const keys = [];
+        if ((c.constraints || []).indexOf("PK") >= 0) keys.push("PK");
+        if (c.references) keys.push("FK");
+        graph += "        " + type + " " + c.name.replace(/[^A-Za-z0-9_]/g, "_") + (keys.length ? " " + keys.join(",") : "") +
+          (c.classification ? ' "' + c.classification + '"' : "") + "\n";
+      });
+      graph += "    }\n";
+    });
+    Object.keys(schema.tables).forEach((name) => {
+      Object.values(schema.tables[name].columns || {}).forEach((c) => {
+        if (c.references && schema.tables[c.references.table]) {
+          graph += "    " + mermaidID(c.references.table) + " ||--o{ " + mermaidID(name) + " : has\n";
+        }
+      });
+    });
+    if (ownership) {
+      ownership.services.forEach((service, i) => {
+        const owned = ownership.tables.filter((t) => t.owner === service.name).map((t) => mermaidID(t.table));
+        if (!owned.length) return;
+        graph += "    classDef owner" + i + " fill:" + service.color + "\n    class " + owned.join(",") + " owner" + i + "\n";
+      });
+      const shared = ownership.tables.filter((t) => t.shared).map((t) => mermaidID(t.table));
+      if (shared.length) graph += "    classDef shared stroke:#dc2626,stroke-width:3px\n    class " + shared.join(",") + " shared\n";
+    }
+    return graph;
+  }
+
+  // folderTree renders an architecture map node as nested collapsible folders, the top levels open
+  function folderTree(node, depth) {
+    const label = "<strong>" + esc(depth ? node.name + "/" : node.name) + "</strong>" +
+      (node.purpose ? ' <span class="muted">' + esc(node.purpose) + "</span>" : "");
+    const children = node.children || [];
+    if (!children.length) return "<div>" + label + "</div>";
+    return "<details" + (depth < 1 ? " open" : "") + "><summary>" + label + "</summary>" +
+      '<div class="tree">' + children.map((c) => folderTree(c, depth + 1)).join("") + "</div></details>";
+  }
+
+  // diagram returns a placeholder holding the Mermaid source; it is rendered when its tab is shown
+  function diagram(source) {
+    return '<div class="diagram" data-source="' + esc(source) + '"><pre>' + esc(source) + "</pre></div>";
+  }
+
+  function loadMermaid() {
+    if (!mermaidLoading) {
+      mermaidLoading = new Promise((resolve, reject) => {
+        const script = document.createElement("script");
+        script.src = MERMAID_URL;
+        script.onload = () => {
+          window.mermaid.initialize({ startOnLoad: false, securityLevel: "strict", maxTextSize: 200000 });
+          resolve(window.mermaid);
+        };
+        script.onerror = reject;
+        document.head.appendChild(script);
+      });
+    }
+    return mermaidLoading;
+  }
+
+  async function renderDiagrams(container) {
+    const pending = container.querySelectorAll(".diagram:not([data-rendered])");
+    if (!pending.length) return;
+    let mermaid;
+    try {
+      mermaid = await loadMermaid();
+    } catch (err) {
+      return; // Offline: the Mermaid source stays visible
+    }
+    for (const [i, element] of Array.from(pending).entries()) {
+      element.dataset.rendered = "true";
+      try {
+        const { svg } = await mermaid.render("diagram-" + Date.now() + "-" + i, element.dataset.source);
+        element.innerHTML = svg;
+      } catch (err) {
+        element.insertAdjacentHTML("afterbegin", '<p class="muted">Diagram could not be rendered; showing its source.</p>');
+      }
+    }
+  }
+
+  // --- Tabs ---
+
+  function showTab(name) {
+    document.querySelectorAll("#tabs button").forEach((b) => b.classList.toggle("active", b.dataset.tab === name));
+    document.querySelectorAll(".tab").forEach((t) => { t.hidden = t.id !== "tab-" + name; });
+    renderDiagrams($("tab-" + name));
+  }
+
+  $("tabs").addEventListener("click", (event) => {
+    if (event.target.dataset.tab) showTab(event.target.dataset.tab);
+  });
+
+  $("download").addEventListener("click", () => {
+    const blob = new Blob([JSON.stringify(result, null, 2)], { type: "application/json" });
+    const link = document.createElement("a");
+    link.href = URL.createObjectURL(blob);
+    link.download = "analysis.json";
+    link.click();
+    URL.revokeObjectURL(link.href);
+  });
+
+  // loadProfiles fills the profile picker; without it every analyzer runs
+  async function loadProfiles() {
+    try {
+      const response = await fetch(API + "/profiles");
+      if (!response.ok) return;
+      const data = await response.json();
+      for (const profile of data.profiles || []) {
+        const option = document.createElement("option");
+        option.value = profile.name;
+        option.textContent = profile.name + (profile.description ? " - " + profile.description : "");
+        $("profile").appendChild(option);
+      }
+    } catch (err) {
+      // Older servers have no profiles endpoint
+    }
+  }
+
+  $("analyze-form").addEventListener("submit", analyze);
+  loadProfiles();
+})();
@@ -0,0 +1,27 @@
+// Package webui embeds the single-page web UI served by the API server, so a release binary is a
+// usable product on its own without the React frontend or a ./static directory
+package webui
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+//go:embed static
+var assets embed.FS
+
+// FS returns the UI files, rooted so that index.html is at the top
+func FS() fs.FS {
+	sub, err := fs.Sub(assets, "static")
+	if err != nil {
+		// The directory is embedded at build time, so this cannot fail at runtime
+		panic(err)
+	}
+	return sub
+}
+
+// HTTPFS returns the UI files as an http.FileSystem for static file middleware
+func HTTPFS() http.FileSystem {
+	return http.FS(FS())
+}
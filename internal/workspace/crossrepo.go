@@ -0,0 +1,470 @@
+package workspace
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"repo-explanation/internal/relationships"
+)
+
+var (
+	goModuleRegex      = regexp.MustCompile(`(?m)^module\s+(\S+)`)
+	goImportLineRegex  = regexp.MustCompile(`(?m)^\s*import\s+(?:[a-zA-Z_.]\w*\s+)?"([^"]+)"`)
+	goImportBlockRegex = regexp.MustCompile(`(?s)import\s*\((.*?)\)`)
+	quotedRegex        = regexp.MustCompile(`"([^"]+)"`)
+	protoPackageRegex  = regexp.MustCompile(`(?m)^\s*package\s+([\w.]+)\s*;`)
+	protoServiceRegex  = regexp.MustCompile(`(?m)^\s*service\s+(\w+)\s*\{`)
+)
+
+// Migration bookkeeping tables every migration tool creates; sharing them proves nothing
+var ignoredSharedTables = map[string]bool{
+	"schema_migrations":     true,
+	"goose_db_version":      true,
+	"flyway_schema_history": true,
+	"migrations":            true,
+	"knex_migrations":       true,
+	"__efmigrationshistory": true,
+}
+
+// repoFingerprint captures what a repository publishes to, and consumes from, the other repositories
+type repoFingerprint struct {
+	name          string
+	goModules     []string          // Module paths declared in go.mod files
+	npmPackages   []string          // Package names declared in package.json files
+	goImports     map[string]string // Import path -> first file importing it
+	npmDeps       map[string]string // Dependency name -> package.json declaring it
+	protoServices map[string]string // "package.Service" -> .proto file defining it
+	openAPITitles map[string]string // OpenAPI/Swagger title -> spec file
+	codeFiles     map[string]string // Source file -> content, for client usage lookups
+	tables        map[string]bool
+}
+
+// Directories never worth scanning for cross-repo evidence
+var skippedDirs = map[string]bool{
+	".git": true, "node_modules": true, "vendor": true, "dist": true, "build": true,
+	".next": true, "target": true, "__pycache__": true, ".venv": true,
+}
+
+// maxFingerprintFileSize bounds the files read during the cross-repo pass
+const maxFingerprintFileSize = 1024 * 1024
+
+// fingerprintRepository walks a repository and records its cross-repo touch points.
+// It does its own walk because the pipeline crawler skips go.mod, .proto and generated stubs.
+func fingerprintRepository(repo RepositoryResult) (*repoFingerprint, error) {
+	fp := &repoFingerprint{
+		name:          repo.Name,
+		goImports:     make(map[string]string),
+		npmDeps:       make(map[string]string),
+		protoServices: make(map[string]string),
+		openAPITitles: make(map[string]string),
+		codeFiles:     make(map[string]string),
+		tables:        make(map[string]bool),
+	}
+
+	err := filepath.WalkDir(repo.Path, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != repo.Path && skippedDirs[d.Name()] {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		relPath, err := filepath.Rel(repo.Path, path)
+		if err != nil {
+			return nil
+		}
+		relPath = filepath.ToSlash(relPath)
+		base := strings.ToLower(d.Name())
+		ext := strings.ToLower(filepath.Ext(base))
+
+		relevant := base == "go.mod" || base == "package.json" || ext == ".proto" || isSpecCandidate(base, ext) || isCodeFile(ext)
+		if !relevant {
+			return nil
+		}
+		if info, err := d.Info(); err != nil || info.Size() > maxFingerprintFileSize {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		content := string(data)
+
+		switch {
+		case base == "go.mod":
+			if match := goModuleRegex.FindStringSubmatch(content); match != nil {
+				fp.goModules = append(fp.goModules, match[1])
+			}
+		case base == "package.json":
+			fp.recordPackageJSON(relPath, content)
+		case ext == ".proto":
+			fp.recordProto(relPath, content)
+		case isSpecCandidate(base, ext):
+			if title := openAPITitle(content, ext); title != "" {
+				if _, exists := fp.openAPITitles[title]; !exists {
+					fp.openAPITitles[title] = relPath
+				}
+			}
+		}
+
+		if ext == ".go" {
+			for _, imp := range goImports(content) {
+				if _, exists := fp.goImports[imp]; !exists {
+					fp.goImports[imp] = relPath
+				}
+			}
+		}
+		if isCodeFile(ext) {
+			fp.codeFiles[relPath] = content
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if repo.Result != nil && repo.Result.DatabaseSchema != nil {
+		for table := range repo.Result.DatabaseSchema.Tables {
+			fp.tables[strings.ToLower(table)] = true
+		}
+	}
+
+	return fp, nil
+}
+
+// recordPackageJSON stores the package name and its dependencies
+func (fp *repoFingerprint) recordPackageJSON(relPath, content string) {
+	var pkg struct {
+		Name            string            `json:"name"`
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+		PeerDeps        map[string]string `json:"peerDependencies"`
+	}
+	if err := json.Unmarshal([]byte(content), &pkg); err != nil {
+		return
+	}
+	if pkg.Name != "" {
+		fp.npmPackages = append(fp.npmPackages, pkg.Name)
+	}
+	for _, deps := range []map[string]string{pkg.Dependencies, pkg.DevDependencies, pkg.PeerDeps} {
+		for dep := range deps {
+			if _, exists := fp.npmDeps[dep]; !exists {
+				fp.npmDeps[dep] = relPath
+			}
+		}
+	}
+}
+
+// recordProto stores every gRPC service a .proto file defines, qualified by its package
+func (fp *repoFingerprint) recordProto(relPath, content string) {
+	pkg := ""
+	if match := protoPackageRegex.FindStringSubmatch(content); match != nil {
+		pkg = match[1] + "."
+	}
+	for _, match := range protoServiceRegex.FindAllStringSubmatch(content, -1) {
+		key := pkg + match[1]
+		if _, exists := fp.protoServices[key]; !exists {
+			fp.protoServices[key] = relPath
+		}
+	}
+}
+
+// implementsGRPCService reports whether the repository contains a server implementation of the service
+func (fp *repoFingerprint) implementsGRPCService(service string) (string, bool) {
+	markers := []string{
+		"Unimplemented" + service + "Server",
+		"Register" + service + "Server",
+		"add_" + service + "Servicer_to_server",
+		service + "Servicer",
+	}
+	return fp.findInCode(markers)
+}
+
+// consumesGRPCService reports whether the repository creates a client for the service
+func (fp *repoFingerprint) consumesGRPCService(service string) (string, bool) {
+	markers := []string{
+		"New" + service + "Client(",
+		service + "Stub(",
+		service + "Client(",
+	}
+	return fp.findInCode(markers)
+}
+
+// findInCode returns the first source file (in sorted order) containing any marker
+func (fp *repoFingerprint) findInCode(markers []string) (string, bool) {
+	paths := make([]string, 0, len(fp.codeFiles))
+	for path := range fp.codeFiles {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		content := fp.codeFiles[path]
+		for _, marker := range markers {
+			if strings.Contains(content, marker) {
+				return path, true
+			}
+		}
+	}
+	return "", false
+}
+
+// discoverCrossRepoRelationships links repositories through client libraries, API specs and shared tables.
+// Relationships point from the consuming repository to the providing one.
+func discoverCrossRepoRelationships(fingerprints []*repoFingerprint) []relationships.ServiceRelationship {
+	var rels []relationships.ServiceRelationship
+
+	for _, consumer := range fingerprints {
+		for _, provider := range fingerprints {
+			if consumer == provider {
+				continue
+			}
+			rels = append(rels, clientLibraryRelationships(consumer, provider)...)
+			rels = append(rels, grpcRelationships(consumer, provider)...)
+		}
+	}
+
+	// Symmetric evidence is reported once per pair
+	for i := 0; i < len(fingerprints); i++ {
+		for j := i + 1; j < len(fingerprints); j++ {
+			rels = append(rels, sharedSpecRelationships(fingerprints[i], fingerprints[j])...)
+			if rel := sharedDatabaseRelationship(fingerprints[i], fingerprints[j]); rel != nil {
+				rels = append(rels, *rel)
+			}
+		}
+	}
+
+	return relationships.MergeEvidence(rels)
+}
+
+// clientLibraryRelationships finds imports of Go modules or npm packages published by the provider
+func clientLibraryRelationships(consumer, provider *repoFingerprint) []relationships.ServiceRelationship {
+	var rels []relationships.ServiceRelationship
+
+	for _, module := range provider.goModules {
+		// A fork or vendored copy declaring the same module is not a consumer
+		if containsString(consumer.goModules, module) {
+			continue
+		}
+		for _, imp := range sortedKeys(consumer.goImports) {
+			if imp == module || strings.HasPrefix(imp, module+"/") {
+				rels = append(rels, relationships.ServiceRelationship{
+					From:         consumer.name,
+					To:           provider.name,
+					EvidenceType: relationships.ClientLibraryEvidence,
+					Evidence:     fmt.Sprintf("Imports Go module %s (%s)", module, imp),
+					FilePath:     consumer.goImports[imp],
+					Confidence:   0.95,
+				})
+				break
+			}
+		}
+	}
+
+	for _, pkg := range provider.npmPackages {
+		if containsString(consumer.npmPackages, pkg) {
+			continue
+		}
+		if manifest, ok := consumer.npmDeps[pkg]; ok {
+			rels = append(rels, relationships.ServiceRelationship{
+				From:         consumer.name,
+				To:           provider.name,
+				EvidenceType: relationships.ClientLibraryEvidence,
+				Evidence:     fmt.Sprintf("Depends on npm package %s", pkg),
+				FilePath:     manifest,
+				Confidence:   0.95,
+			})
+		}
+	}
+
+	return rels
+}
+
+// grpcRelationships links a consumer creating a gRPC client to the provider implementing the service
+func grpcRelationships(consumer, provider *repoFingerprint) []relationships.ServiceRelationship {
+	var rels []relationships.ServiceRelationship
+
+	for _, key := range sortedKeys(provider.protoServices) {
+		service := key[strings.LastIndex(key, ".")+1:]
+		if _, implemented := provider.implementsGRPCService(service); !implemented {
+			continue
+		}
+		if _, alsoImplemented := consumer.implementsGRPCService(service); alsoImplemented {
+			continue
+		}
+		if file, ok := consumer.consumesGRPCService(service); ok {
+			rels = append(rels, relationships.ServiceRelationship{
+				From:         consumer.name,
+				To:           provider.name,
+				EvidenceType: relationships.APISpecEvidence,
+				Evidence:     fmt.Sprintf("gRPC client for %s", key),
+				FilePath:     file,
+				Confidence:   0.9,
+			})
+		}
+	}
+
+	return rels
+}
+
+// sharedSpecRelationships reports API specs (proto services, OpenAPI documents) present in both repositories
+func sharedSpecRelationships(a, b *repoFingerprint) []relationships.ServiceRelationship {
+	var rels []relationships.ServiceRelationship
+
+	for _, key := range sortedKeys(a.protoServices) {
+		if file, ok := b.protoServices[key]; ok {
+			rels = append(rels, specRelationship(a, b, fmt.Sprintf("Shared proto service %s", key), file, key))
+		}
+	}
+	for _, title := range sortedKeys(a.openAPITitles) {
+		if file, ok := b.openAPITitles[title]; ok {
+			rels = append(rels, specRelationship(a, b, fmt.Sprintf("Shared OpenAPI spec %q", title), file, ""))
+		}
+	}
+
+	return rels
+}
+
+// specRelationship orients a shared-spec relationship towards the repository implementing it when known
+func specRelationship(a, b *repoFingerprint, evidence, file, protoKey string) relationships.ServiceRelationship {
+	from, to := b, a
+	confidence := 0.5
+	if protoKey != "" {
+		service := protoKey[strings.LastIndex(protoKey, ".")+1:]
+		_, aImplements := a.implementsGRPCService(service)
+		_, bImplements := b.implementsGRPCService(service)
+		if aImplements != bImplements {
+			confidence = 0.8
+			if bImplements {
+				from, to = a, b
+			}
+		}
+	}
+	if confidence < 0.8 {
+		evidence += " (direction unknown)"
+	}
+
+	return relationships.ServiceRelationship{
+		From:         from.name,
+		To:           to.name,
+		EvidenceType: relationships.APISpecEvidence,
+		Evidence:     evidence,
+		FilePath:     file,
+		Confidence:   confidence,
+	}
+}
+
+// sharedDatabaseRelationship reports repositories whose migrations define the same tables
+func sharedDatabaseRelationship(a, b *repoFingerprint) *relationships.ServiceRelationship {
+	var shared []string
+	for table := range a.tables {
+		if b.tables[table] && !ignoredSharedTables[table] {
+			shared = append(shared, table)
+		}
+	}
+	if len(shared) == 0 {
+		return nil
+	}
+	sort.Strings(shared)
+
+	confidence := 0.5 + 0.1*float64(len(shared))
+	if confidence > 0.9 {
+		confidence = 0.9
+	}
+
+	listed := shared
+	if len(listed) > 5 {
+		listed = append(append([]string{}, shared[:5]...), fmt.Sprintf("+%d more", len(shared)-5))
+	}
+
+	return &relationships.ServiceRelationship{
+		From:         b.name,
+		To:           a.name,
+		EvidenceType: relationships.SharedDatabaseEvidence,
+		Evidence:     fmt.Sprintf("Shares tables: %s", strings.Join(listed, ", ")),
+		Confidence:   confidence,
+	}
+}
+
+// goImports extracts import paths from single-line and grouped Go import declarations
+func goImports(content string) []string {
+	var imports []string
+	for _, match := range goImportLineRegex.FindAllStringSubmatch(content, -1) {
+		imports = append(imports, match[1])
+	}
+	for _, block := range goImportBlockRegex.FindAllStringSubmatch(content, -1) {
+		for _, match := range quotedRegex.FindAllStringSubmatch(block[1], -1) {
+			imports = append(imports, match[1])
+		}
+	}
+	return imports
+}
+
+// openAPITitle returns info.title of an OpenAPI/Swagger document, or "" if the file is not one
+func openAPITitle(content, ext string) string {
+	var doc struct {
+		OpenAPI string `json:"openapi" yaml:"openapi"`
+		Swagger string `json:"swagger" yaml:"swagger"`
+		Info    struct {
+			Title string `json:"title" yaml:"title"`
+		} `json:"info" yaml:"info"`
+	}
+
+	var err error
+	if ext == ".json" {
+		err = json.Unmarshal([]byte(content), &doc)
+	} else {
+		err = yaml.Unmarshal([]byte(content), &doc)
+	}
+	if err != nil || (doc.OpenAPI == "" && doc.Swagger == "") {
+		return ""
+	}
+	return strings.TrimSpace(doc.Info.Title)
+}
+
+// isSpecCandidate reports whether a file name looks like an OpenAPI/Swagger document
+func isSpecCandidate(base, ext string) bool {
+	if ext != ".yaml" && ext != ".yml" && ext != ".json" {
+		return false
+	}
+	return strings.Contains(base, "openapi") || strings.Contains(base, "swagger") || strings.Contains(base, "api-spec")
+}
+
+// isCodeFile reports whether the extension belongs to source code scanned for client usage
+func isCodeFile(ext string) bool {
+	switch ext {
+	case ".go", ".js", ".jsx", ".ts", ".tsx", ".py", ".java", ".kt":
+		return true
+	}
+	return false
+}
+
+// sortedKeys returns the keys of a string map in sorted order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// containsString reports whether values contains target
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
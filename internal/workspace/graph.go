@@ -0,0 +1,129 @@
+package workspace
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"repo-explanation/internal/mermaid"
+	"repo-explanation/internal/microservices"
+	"repo-explanation/internal/relationships"
+)
+
+// buildCombinedGraph merges every repository's service graph into one, with services named "<repo>/<service>".
+// Repositories without discovered services appear as a single node named after the repository;
+// cross-repository relationships connect repository nodes.
+func buildCombinedGraph(repos []RepositoryResult, crossRepo []relationships.ServiceRelationship) *relationships.ServiceGraph {
+	graph := &relationships.ServiceGraph{
+		ProjectPath: "workspace",
+		GeneratedAt: time.Now(),
+	}
+
+	for _, repo := range repos {
+		var services []microservices.DiscoveredService
+		var rels []relationships.ServiceRelationship
+		if repo.Result != nil {
+			services = repo.Result.Services
+			rels = repo.Result.ServiceRelationships
+		}
+
+		if len(services) == 0 {
+			graph.Services = append(graph.Services, microservices.DiscoveredService{
+				Name:        repo.Name,
+				Path:        repo.Name,
+				Description: "Repository (no services detected)",
+			})
+			continue
+		}
+
+		for _, service := range services {
+			service.Name = qualifiedName(repo.Name, service.Name)
+			service.Path = repo.Name + "/" + strings.TrimPrefix(service.Path, "./")
+			graph.Services = append(graph.Services, service)
+		}
+		for _, rel := range rels {
+			rel.From = qualifiedName(repo.Name, rel.From)
+			rel.To = qualifiedName(repo.Name, rel.To)
+			rel.FilePath = repo.Name + "/" + rel.FilePath
+			graph.Relationships = append(graph.Relationships, rel)
+		}
+	}
+
+	for _, rel := range crossRepo {
+		if rel.FilePath != "" {
+			rel.FilePath = rel.From + "/" + rel.FilePath
+		}
+		graph.Relationships = append(graph.Relationships, rel)
+	}
+
+	graph.MermaidGraph = combinedMermaid(repos, graph)
+	return graph
+}
+
+// qualifiedName prefixes a service name with its repository
+func qualifiedName(repo, service string) string {
+	return repo + "/" + service
+}
+
+// combinedMermaid renders each repository as a subgraph of its services, linked by cross-repo edges;
+// low-confidence edges are dashed
+func combinedMermaid(repos []RepositoryResult, graph *relationships.ServiceGraph) string {
+	chart := mermaid.NewFlowchart("TD")
+
+	for _, repo := range repos {
+		if repo.Result == nil || len(repo.Result.Services) == 0 {
+			chart.Node(nodeName(repo.Name), repo.Name, mermaid.Rectangle)
+			continue
+		}
+
+		chart.Subgraph(nodeName(repo.Name), repo.Name)
+		for _, service := range repo.Result.Services {
+			chart.Node(nodeName(qualifiedName(repo.Name, service.Name)), service.Name, mermaid.Rectangle)
+		}
+		chart.EndSubgraph()
+	}
+
+	if len(graph.Relationships) > 0 {
+		chart.Blank()
+		for _, rel := range graph.Relationships {
+			arrow := mermaid.Solid
+			if rel.Confidence < relationships.LowConfidence {
+				arrow = mermaid.Dotted
+			}
+			chart.Edge(nodeName(rel.From), nodeName(rel.To), arrow, edgeLabel(rel))
+		}
+	}
+
+	diagram, err := mermaid.Clean(chart.String())
+	if err != nil {
+		fmt.Printf("⚠️  Workspace graph is not valid Mermaid: %v\n", err)
+	}
+	return diagram
+}
+
+// edgeLabel returns a short Mermaid edge label for a relationship
+func edgeLabel(rel relationships.ServiceRelationship) string {
+	switch rel.EvidenceType {
+	case relationships.ConfigEvidence:
+		return "config"
+	case relationships.ImportEvidence:
+		return "import"
+	case relationships.NetworkEvidence:
+		if strings.Contains(strings.ToLower(rel.Evidence), "grpc") {
+			return "grpc"
+		}
+		return "http"
+	case relationships.APISpecEvidence:
+		return "api spec"
+	case relationships.ClientLibraryEvidence:
+		return "client library"
+	case relationships.SharedDatabaseEvidence:
+		return "shared db"
+	}
+	return "depends"
+}
+
+// nodeName keeps "repo/service" apart from "repo-service" once both become Mermaid ids
+func nodeName(name string) string {
+	return strings.ReplaceAll(name, "/", "__")
+}
@@ -0,0 +1,216 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"repo-explanation/config"
+	"repo-explanation/internal/pipeline"
+	"repo-explanation/internal/relationships"
+)
+
+// Repository is one member of a workspace, given as a local path or a GitHub URL
+type Repository struct {
+	Name  string `yaml:"name" json:"name"`
+	Path  string `yaml:"path,omitempty" json:"path,omitempty"`
+	URL   string `yaml:"url,omitempty" json:"url,omitempty"`
+	Token string `yaml:"token,omitempty" json:"token,omitempty"` // GitHub personal access token for private repos
+}
+
+// Workspace groups the repositories that together make up one system
+type Workspace struct {
+	Name         string       `yaml:"name" json:"name"`
+	Repositories []Repository `yaml:"repositories" json:"repositories"`
+	Template     *Repository  `yaml:"template,omitempty" json:"template,omitempty"` // Golden repository the others were stamped from, for drift mode
+}
+
+// RepositoryResult holds the pipeline output for a single workspace repository
+type RepositoryResult struct {
+	Name   string                   `json:"name"`
+	Path   string                   `json:"path,omitempty"`
+	URL    string                   `json:"url,omitempty"`
+	Result *pipeline.AnalysisResult `json:"result,omitempty"`
+	Error  string                   `json:"error,omitempty"`
+}
+
+// Result is the combined analysis of every repository in a workspace
+type Result struct {
+	Name                   string                              `json:"name"`
+	Repositories           []RepositoryResult                  `json:"repositories"`
+	CrossRepoRelationships []relationships.ServiceRelationship `json:"cross_repo_relationships"`
+	CombinedGraph          *relationships.ServiceGraph         `json:"combined_graph"`
+}
+
+// LoadWorkspace reads a workspace definition from a YAML file; relative paths are resolved against the file
+func LoadWorkspace(path string) (*Workspace, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workspace file: %v", err)
+	}
+
+	var ws Workspace
+	if err := yaml.Unmarshal([]byte(os.ExpandEnv(string(data))), &ws); err != nil {
+		return nil, fmt.Errorf("failed to parse workspace YAML: %v", err)
+	}
+
+	baseDir := filepath.Dir(path)
+	for i := range ws.Repositories {
+		repoPath := ws.Repositories[i].Path
+		if repoPath != "" && !filepath.IsAbs(repoPath) {
+			ws.Repositories[i].Path = filepath.Join(baseDir, repoPath)
+		}
+	}
+	if ws.Template != nil && ws.Template.Path != "" && !filepath.IsAbs(ws.Template.Path) {
+		ws.Template.Path = filepath.Join(baseDir, ws.Template.Path)
+	}
+
+	if err := ws.Validate(); err != nil {
+		return nil, err
+	}
+	return &ws, nil
+}
+
+// Validate checks every repository has a source and fills in missing names
+func (w *Workspace) Validate() error {
+	if len(w.Repositories) == 0 {
+		return fmt.Errorf("workspace has no repositories")
+	}
+
+	seen := make(map[string]bool)
+	for i := range w.Repositories {
+		repo := &w.Repositories[i]
+		if repo.Path == "" && repo.URL == "" {
+			return fmt.Errorf("repository #%d needs a path or url", i+1)
+		}
+		if repo.Name == "" {
+			repo.Name = defaultRepositoryName(*repo)
+		}
+		if seen[repo.Name] {
+			return fmt.Errorf("duplicate repository name %q (set an explicit name)", repo.Name)
+		}
+		seen[repo.Name] = true
+	}
+	if w.Template != nil {
+		if w.Template.Path == "" && w.Template.URL == "" {
+			return fmt.Errorf("template needs a path or url")
+		}
+		if w.Template.Name == "" {
+			w.Template.Name = defaultRepositoryName(*w.Template)
+		}
+	}
+
+	if w.Name == "" {
+		w.Name = "workspace"
+	}
+	return nil
+}
+
+// defaultRepositoryName derives a name from the last URL or path segment
+func defaultRepositoryName(repo Repository) string {
+	source := repo.URL
+	if source == "" {
+		source = filepath.ToSlash(filepath.Clean(repo.Path))
+	}
+	source = strings.TrimSuffix(strings.TrimRight(source, "/"), ".git")
+	if idx := strings.LastIndex(source, "/"); idx >= 0 {
+		source = source[idx+1:]
+	}
+	return source
+}
+
+// Analyzer runs the pipeline on every workspace repository and then links the repositories together
+type Analyzer struct {
+	config    *config.Config
+	workspace *Workspace
+	options   pipeline.AnalysisOptions
+}
+
+// NewAnalyzer creates a workspace analyzer; every repository must already be available at its local Path
+func NewAnalyzer(cfg *config.Config, ws *Workspace, opts pipeline.AnalysisOptions) (*Analyzer, error) {
+	if err := ws.Validate(); err != nil {
+		return nil, err
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid analysis options: %v", err)
+	}
+	for _, repo := range ws.Repositories {
+		if repo.Path == "" {
+			return nil, fmt.Errorf("repository %s has not been checked out locally", repo.Name)
+		}
+	}
+
+	return &Analyzer{
+		config:    cfg,
+		workspace: ws,
+		options:   opts,
+	}, nil
+}
+
+// Analyze runs the per-repository pipelines followed by the cross-repository relationship pass
+func (wa *Analyzer) Analyze(ctx context.Context, callback pipeline.ProgressCallback) (*Result, error) {
+	result := &Result{Name: wa.workspace.Name}
+	total := len(wa.workspace.Repositories)
+
+	for i, repo := range wa.workspace.Repositories {
+		fmt.Printf("📦 [WORKSPACE] Analyzing repository %d/%d: %s\n", i+1, total, repo.Name)
+
+		// Map each repository's 0-100 progress into its share of the first 90%
+		repoCallback := func(eventType, stage, message string, progress int, data interface{}) {
+			overall := (i*100 + progress) * 90 / (total * 100)
+			callback(eventType, fmt.Sprintf("[%s] %s", repo.Name, stage), message, overall, data)
+		}
+
+		repoResult := RepositoryResult{Name: repo.Name, Path: repo.Path, URL: repo.URL}
+		analysis, err := wa.analyzeRepository(ctx, repo, repoCallback)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("workspace analysis cancelled: %v", ctx.Err())
+			}
+			fmt.Printf("⚠️  [WORKSPACE] Analysis of %s failed: %v\n", repo.Name, err)
+			repoResult.Error = err.Error()
+		}
+		repoResult.Result = analysis
+		result.Repositories = append(result.Repositories, repoResult)
+	}
+
+	callback("progress", "🔗 Linking repositories...", "Looking for shared API specs, client libraries and database schemas", 92, nil)
+
+	fingerprints := make([]*repoFingerprint, 0, total)
+	for _, repoResult := range result.Repositories {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("workspace analysis cancelled: %v", err)
+		}
+		fp, err := fingerprintRepository(repoResult)
+		if err != nil {
+			fmt.Printf("⚠️  [WORKSPACE] Skipping %s in cross-repo pass: %v\n", repoResult.Name, err)
+			continue
+		}
+		fingerprints = append(fingerprints, fp)
+	}
+
+	result.CrossRepoRelationships = wa.options.RelationshipFilter(wa.config).Apply(discoverCrossRepoRelationships(fingerprints))
+	result.CombinedGraph = buildCombinedGraph(result.Repositories, result.CrossRepoRelationships)
+
+	callback("data", "Workspace relationships mapped", fmt.Sprintf("Found %d cross-repository relationships", len(result.CrossRepoRelationships)), 98, map[string]interface{}{
+		"cross_repo_relationships": result.CrossRepoRelationships,
+		"combined_graph":           result.CombinedGraph,
+	})
+
+	return result, nil
+}
+
+// analyzeRepository runs the regular streaming pipeline for one repository
+func (wa *Analyzer) analyzeRepository(ctx context.Context, repo Repository, callback pipeline.ProgressCallback) (*pipeline.AnalysisResult, error) {
+	analyzer, err := pipeline.NewAnalyzerWithURL(wa.config, repo.Path, repo.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create analyzer: %v", err)
+	}
+	if err := analyzer.SetOptions(wa.options); err != nil {
+		return nil, fmt.Errorf("invalid analysis options: %v", err)
+	}
+	return analyzer.AnalyzeProjectWithProgress(ctx, callback)
+}
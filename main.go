@@ -1,18 +1,51 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io/fs"
+	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"repo-explanation/cli"
+	"repo-explanation/config"
 	"repo-explanation/controllers"
+	"repo-explanation/internal/console"
+	"repo-explanation/internal/atrest"
+	"repo-explanation/internal/audit"
+	"repo-explanation/internal/c4"
+	"repo-explanation/internal/contextpack"
 	"repo-explanation/internal/database"
+	"repo-explanation/internal/dependents"
 	"repo-explanation/internal/detector"
+	"repo-explanation/internal/diskcache"
+	"repo-explanation/internal/drift"
+	"repo-explanation/internal/editor"
+	"repo-explanation/internal/filestore"
+	"repo-explanation/internal/mcp"
+	"repo-explanation/internal/mermaid"
+	internalOpenai "repo-explanation/internal/openai"
+	"repo-explanation/internal/pipeline"
+	"repo-explanation/internal/prompts"
+	"repo-explanation/internal/probe"
+	"repo-explanation/internal/publish"
+	"repo-explanation/internal/qaeval"
+	"repo-explanation/internal/report"
+	"repo-explanation/internal/sarif"
 	"repo-explanation/internal/secrets"
+	"repo-explanation/internal/selftest"
+	"repo-explanation/internal/snapshots"
+	"repo-explanation/internal/tracking"
+	"repo-explanation/internal/watch"
+	"repo-explanation/internal/workspace"
 	"repo-explanation/routes"
 
 	"github.com/labstack/echo/v4"
@@ -20,53 +53,1186 @@ import (
 )
 
 func main() {
-	mode := flag.String("mode", "server", "Mode to run: 'server', 'cli', 'secrets', or 'debug-db'")
-	path := flag.String("path", "", "Path to analyze (for secrets mode)")
+	mode := flag.String("mode", "server", "Mode to run: 'server', 'cli', 'workspace', 'drift', 'watch', 'editor', 'mcp', 'secrets', 'secrets-check', 'probe', 'migrate-lint', 'snapshots', 'qa-eval', 'selftest', or 'debug-db'")
+	path := flag.String("path", "", "Path to analyze (for watch, editor, mcp, secrets, probe and migrate-lint modes)")
+	offline := flag.Bool("offline", false, "Run the analysis without any LLM calls (heuristic summaries only)")
+	include := flag.String("include", "", "Comma-separated globs or directories to analyze (e.g. services/payments)")
+	exclude := flag.String("exclude", "", "Comma-separated globs or directories to skip")
+	service := flag.String("service", "", "Limit the analysis to one discovered service's directory and the services it calls directly")
+	phases := flag.String("phases", "", "Comma-separated phases to run: "+strings.Join(pipeline.AllPhases, ","))
+	resume := flag.Bool("resume", false, "Resume an interrupted analysis from its last completed phase")
+	quick := flag.Bool("quick", false, "Quick scan: deterministic analyzers plus one LLM project summary, no per-file LLM calls")
+	minConfidence := flag.Float64("min-confidence", 0, "Drop service relationships below this confidence (0-1; 0 uses relationships.min_confidence from the config)")
+	onlyEvidence := flag.String("only-evidence", "", "Comma-separated relationship evidence types to keep, e.g. config,import,network")
+	profile := flag.String("profile", "", "Analysis profile from the config (built in: backend, frontend, data) that preselects phases and frames prompts")
+	lang := flag.String("lang", "", "Language of LLM-written summaries, questions and glossary (e.g. en, vi, ja); paths, commands and SQL stay as in the source")
+	probeHost := flag.String("probe-host", "localhost", "Host the services run on (for probe mode)")
+	envFile := flag.String("env-file", "", "Env file to validate (for secrets-check mode; defaults to the process environment)")
+	format := flag.String("format", formatText, "Output of secrets and migrate-lint modes: 'text' or 'sarif' (SARIF 2.1.0, for GitHub code scanning and security dashboards)")
+	estimate := flag.Bool("estimate", false, "Print the LLM calls, tokens, cost and duration analyzing -path would take, without calling the LLM")
+	primeCache := flag.Bool("prime-cache", false, "Summarize every file of -path into the cache at full concurrency (map phase only), so later analyses of it return quickly")
+	diagram := flag.String("diagram", "", "Analyze -path once and export a diagram instead of running a mode: 'c4' (C4-PlantUML and Structurizr DSL) or 'architecture' (folder tree as JSON and a Mermaid mindmap)")
+	publishTarget := flag.String("publish", "", "Analyze -path once and publish the onboarding report: 'markdown' (written to the output directory), 'confluence' or 'notion'")
+	quiet := flag.Bool("quiet", false, "Print only the results of -mode=cli, -diagram and -publish, without the analysis log")
+	logFormat := flag.String("log-format", "text", "Analysis log of -mode=cli, -diagram and -publish: 'text' (with a progress bar on terminals) or 'json' (one JSON object per line, for CI)")
+	erdSplit := flag.Bool("erd-split", false, "Split the database ERD into domain subdiagrams (users/auth, billing, catalog, ...) with an index diagram of the links between them, for large schemas")
+	annotate := flag.Bool("annotations", false, "Write .repo-analyzer/annotations.json (one-line purposes and importance scores of files, for editor extensions) into the analyzed repository")
+	suite := flag.String("suite", qaeval.DefaultDir, "Expected questions and answers as a YAML suite, or a directory of suites (for qa-eval mode)")
+	workspaceFile := flag.String("workspace", "workspace.yaml", "Workspace definition listing the repositories to analyze (for workspace and drift modes)")
 	flag.Parse()
 
+	if *offline {
+		// Applied as an environment override so every entrypoint honours the flag
+		os.Setenv("ANALYZER_OFFLINE", "true")
+	}
+
+	if flag.Arg(0) == "config" {
+		runConfigCommand(flag.Args()[1:])
+		return
+	}
+	if flag.Arg(0) == "prompts" {
+		runPromptsCommand(flag.Args()[1:])
+		return
+	}
+	if flag.Arg(0) == "audit" {
+		runAuditCommand(flag.Args()[1:])
+		return
+	}
+	if flag.Arg(0) == "cache" {
+		runCacheCommand(flag.Args()[1:])
+		return
+	}
+
+	out, err := console.New(*quiet, *logFormat)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	opts := pipeline.AnalysisOptions{
+		Include: pipeline.ParseList(*include),
+		Exclude: pipeline.ParseList(*exclude),
+		Service: strings.TrimSpace(*service),
+		Phases:  pipeline.ParseList(*phases),
+		Resume:  *resume,
+		Quick:   *quick,
+		Profile: *profile,
+		Language: *lang,
+
+		MinConfidence: *minConfidence,
+		OnlyEvidence:  pipeline.ParseList(*onlyEvidence),
+
+		ERDSplit:    *erdSplit,
+		Annotations: *annotate,
+	}
+
+	if *estimate {
+		if err := opts.Validate(); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		runEstimate(*path, opts)
+		return
+	}
+
+	if flag.Arg(0) == "explain" {
+		if err := opts.Validate(); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		runExplain(*path, flag.Args()[1:], opts)
+		return
+	}
+
+	if flag.Arg(0) == "dependents" {
+		if err := opts.Validate(); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		runDependents(*path, flag.Args()[1:], opts, out)
+		return
+	}
+
+	if flag.Arg(0) == "context-pack" {
+		if err := opts.Validate(); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		runContextPack(*path, flag.Args()[1:], opts, out)
+		return
+	}
+
+	if *primeCache {
+		if err := opts.Validate(); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		runPrimeCache(*path, opts)
+		return
+	}
+
+	if *diagram != "" {
+		if err := opts.Validate(); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		runDiagramExport(*path, *diagram, opts, out)
+		return
+	}
+
+	if *publishTarget != "" {
+		if err := opts.Validate(); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		runPublish(*path, *publishTarget, opts, out)
+		return
+	}
+
 	switch *mode {
 	case "server":
 		runServer()
 	case "cli":
-		runCLI()
+		if err := opts.Validate(); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		runCLI(opts, out)
+	case "workspace":
+		if err := opts.Validate(); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		runWorkspace(*workspaceFile, opts)
+	case "drift":
+		if err := opts.Validate(); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		runDrift(*workspaceFile, opts)
+	case "watch":
+		if err := opts.Validate(); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		runWatch(*path, opts)
+	case "editor":
+		if err := opts.Validate(); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			os.Exit(1)
+		}
+		runEditor(*path, opts)
+	case "mcp":
+		if err := opts.Validate(); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			os.Exit(1)
+		}
+		runMCP(*path, opts)
 	case "secrets":
-		runSecretsExtraction(*path)
+		runSecretsExtraction(*path, *format)
+	case "secrets-check":
+		runSecretsCheck(*path, *envFile)
+	case "probe":
+		runProbe(*path, *probeHost)
+	case "migrate-lint":
+		runMigrateLint(*path, *format)
+	case "snapshots":
+		runSnapshots()
+	case "qa-eval":
+		runQAEval(*suite, out)
+	case "selftest":
+		runSelfTest(*path)
 	case "debug-db":
-		runDebugDB()
+		runDebugDB(*erdSplit)
 	case "test-detection":
 		runDetectionTest(*path)
 	default:
 		fmt.Printf("Unknown mode: %s\n", *mode)
-		fmt.Println("Available modes: server, cli, debug-db")
+		fmt.Println("Available modes: server, cli, workspace, drift, watch, editor, mcp, secrets, secrets-check, probe, migrate-lint, snapshots, qa-eval, selftest, debug-db")
+		os.Exit(1)
+	}
+}
+
+func runServer() {
+	cfg := loadStartupConfig()
+
+	e := echo.New()
+	// Clients are told apart by the connection's address; X-Forwarded-For and X-Real-IP are
+	// client-supplied and would let anyone pick a fresh rate limit bucket per request
+	e.IPExtractor = echo.ExtractIPDirect()
+
+	// Middleware
+	e.Use(middleware.Logger())
+	e.Use(middleware.Recover())
+	e.Use(middleware.CORS())
+
+	// Initialize controllers
+	healthController := controllers.NewHealthController(cfg)
+	analysisController := controllers.NewAnalysisController(cfg)
+
+	// Re-analyze tracked repositories on their schedules
+	var scheduler *tracking.Scheduler
+	if len(cfg.Tracking.Repositories) > 0 {
+		var err error
+		scheduler, err = tracking.NewScheduler(cfg)
+		if err != nil {
+			fmt.Printf("❌ Failed to schedule tracked repositories: %v\n", err)
+			os.Exit(1)
+		}
+		scheduler.Start(context.Background())
+	}
+	trackingController := controllers.NewTrackingController(scheduler)
+
+	// Setup routes
+	routes.SetupRoutes(e, cfg, healthController, analysisController, trackingController)
+
+	// Start server
+	e.Logger.Fatal(e.Start(":8080"))
+}
+
+func runCLI(opts pipeline.AnalysisOptions, out *console.Console) {
+	repl := cli.NewREPLWithOptions(loadStartupConfig(), opts)
+	repl.SetConsole(out)
+	repl.Start()
+}
+
+// loadStartupConfig loads the process configuration once, exiting with every problem listed when it is unusable
+func loadStartupConfig() *config.Config {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Printf("❌ Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+	// Prompt template mistakes are reported now rather than halfway through an analysis
+	templates, err := prompts.Load(cfg.OpenAI.PromptsDir)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	if overridden := templates.Overridden(); len(overridden) > 0 {
+		fmt.Printf("📝 Prompt templates from %s: %s\n", cfg.OpenAI.PromptsDir, strings.Join(overridden, ", "))
+	}
+	// A key that cannot be loaded stops the process before anything is cached in the clear
+	if err := atrest.For(cfg).Err(); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	return cfg
+}
+
+// runPromptsCommand handles "prompts export <dir>"
+func runPromptsCommand(args []string) {
+	if len(args) != 2 || args[0] != "export" {
+		fmt.Println("Usage: prompts export <dir>")
+		os.Exit(2)
+	}
+	written, err := prompts.Export(args[1])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("📝 Wrote %d built-in prompt templates to %s; point openai.prompts_dir at it and delete the ones you keep as they are\n", len(written), args[1])
+}
+
+// runConfigCommand handles "config <subcommand>"
+func runConfigCommand(args []string) {
+	if len(args) != 1 || args[0] != "print-effective" {
+		fmt.Println("Usage: config print-effective")
+		os.Exit(2)
+	}
+
+	cfg, err := config.Resolve(config.FindConfigFile())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+	out, err := cfg.EffectiveYAML()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("# Effective configuration (source: %s)\n", cfg.Source())
+	if overrides := cfg.EnvOverrides(); len(overrides) > 0 {
+		fmt.Printf("# Environment overrides: %s\n", strings.Join(overrides, ", "))
+	}
+	fmt.Print(string(out))
+
+	// The merged values are printed even when invalid so the problem can be traced to its source
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Invalid configuration:%v\n", err)
+		os.Exit(1)
+	}
+}
+
+const auditUsage = `Usage:
+  audit runs                      List the runs in the LLM audit log
+  audit show <run|last> [call]    Print a run's prompts and responses; one call in full with call
+  audit replay <run|last> [call]  Send a run's prompts to the model again and compare the responses`
+
+// runAuditCommand inspects and replays the LLM audit log named by audit.path
+func runAuditCommand(args []string) {
+	if len(args) == 0 || (args[0] != "runs" && len(args) < 2) || len(args) > 3 {
+		fmt.Println(auditUsage)
+		os.Exit(2)
+	}
+
+	cfg, err := config.Resolve(config.FindConfigFile())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+	// Without the key an encrypted log cannot be read at all
+	sealer := atrest.For(cfg)
+	if err := sealer.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+	entries, err := audit.Read(cfg.Audit.Path, sealer)
+	if err != nil {
+		if os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "❌ No audit log at %s; set audit.enabled: true (or ANALYZER_AUDIT_ENABLED=true) and run an analysis\n", cfg.Audit.Path)
+		} else {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		}
+		os.Exit(1)
+	}
+
+	if args[0] == "runs" {
+		fmt.Printf("📜 %s\n", cfg.Audit.Path)
+		for _, run := range audit.Runs(entries) {
+			fmt.Printf("   %s  %s  %d calls (%d cached, %d failed), %d prompt + %d completion tokens  %s\n",
+				run.Run, run.Started.Local().Format("2006-01-02 15:04"), run.Calls, run.Cached, run.Errors,
+				run.PromptTokens, run.CompletionTokens, run.Label)
+		}
+		return
+	}
+
+	run, calls, err := audit.Calls(entries, args[1])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+	if len(args) == 3 {
+		number, err := strconv.Atoi(args[2])
+		if err != nil || number < 1 || number > len(calls) {
+			fmt.Fprintf(os.Stderr, "❌ Run %s has calls 1 to %d (got %s)\n", run, len(calls), args[2])
+			os.Exit(2)
+		}
+		calls = calls[number-1 : number]
+	}
+
+	switch args[0] {
+	case "show":
+		// A single call is printed in full; a whole run is abbreviated to keep it scannable
+		limit := 600
+		if len(args) == 3 {
+			limit = 0
+		}
+		for _, call := range calls {
+			printAuditCall(call, limit)
+		}
+	case "replay":
+		replayAuditCalls(cfg, calls)
+	default:
+		fmt.Println(auditUsage)
+		os.Exit(2)
+	}
+}
+
+// printAuditCall prints one recorded call, cutting each message to limit characters (0 prints everything)
+func printAuditCall(call audit.Entry, limit int) {
+	clip := func(text string) string {
+		if limit > 0 && len(text) > limit {
+			return text[:limit] + fmt.Sprintf("... (%d more characters)", len(text)-limit)
+		}
+		return text
+	}
+
+	status := fmt.Sprintf("%d ms, %d + %d tokens", call.LatencyMS, call.PromptTokens, call.CompletionTokens)
+	if call.Cached {
+		status = "prompt cache"
+	}
+	fmt.Printf("\n──── %s call %d · %s · %s · %s\n", call.Run, call.Call, call.Model, call.Time.Local().Format("15:04:05"), status)
+	for _, message := range call.Messages {
+		fmt.Printf("[%s]\n%s\n", message.Role, clip(message.Content))
+	}
+	if call.Error != "" {
+		fmt.Printf("[error]\n%s\n", call.Error)
+	} else {
+		fmt.Printf("[response, %s]\n%s\n", call.FinishReason, clip(call.Response))
+	}
+}
+
+// replayAuditCalls sends recorded prompts to the model again and reports whether the answers changed
+func replayAuditCalls(cfg *config.Config, calls []audit.Entry) {
+	if cfg.OpenAI.APIKey == "" {
+		fmt.Fprintln(os.Stderr, "❌ Replaying needs openai.api_key (set OPENAI_API_KEY)")
+		os.Exit(1)
+	}
+	// Replays are compared here rather than recorded as a new run
+	cfg.Audit.Enabled = false
+	client := internalOpenai.NewClient(cfg)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	changed := 0
+	for _, call := range calls {
+		resp, latency, err := client.Replay(ctx, call)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Call %d: %v\n", call.Call, err)
+			os.Exit(1)
+		}
+		response := ""
+		if len(resp.Choices) > 0 {
+			response = resp.Choices[0].Message.Content
+		}
+		if response == call.Response {
+			fmt.Printf("✅ Call %d: same response (%d ms, was %d ms)\n", call.Call, latency.Milliseconds(), call.LatencyMS)
+			continue
+		}
+		changed++
+		fmt.Printf("🔁 Call %d: response changed (%d ms, %d + %d tokens, was %d + %d)\n", call.Call, latency.Milliseconds(),
+			resp.Usage.PromptTokens, resp.Usage.CompletionTokens, call.PromptTokens, call.CompletionTokens)
+		if len(calls) == 1 {
+			fmt.Printf("[recorded]\n%s\n[replayed]\n%s\n", call.Response, response)
+		}
+	}
+	fmt.Printf("\n%d of %d responses changed\n", changed, len(calls))
+}
+
+const cacheUsage = `Usage:
+  cache stats                                          Show the cache size, entries by kind and expired entries
+  cache prune [-max-size-mb N] [-keep-expired] [-dry-run]
+                                                       Remove expired entries, then the least recently used ones
+                                                       until the cache fits in N MB (default cache.max_size_mb)`
+
+// runCacheCommand reports on and prunes the summary and LLM caches under cache.directory
+func runCacheCommand(args []string) {
+	if len(args) == 0 || (args[0] != "stats" && args[0] != "prune") {
+		fmt.Println(cacheUsage)
+		os.Exit(2)
+	}
+
+	cfg, err := config.Resolve(config.FindConfigFile())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+	dir := cfg.Cache.Directory
+	// Without the key every encrypted entry would look unreadable, and so expired
+	sealer := atrest.For(cfg)
+	if err := sealer.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	if args[0] == "stats" {
+		if len(args) != 1 {
+			fmt.Println(cacheUsage)
+			os.Exit(2)
+		}
+		entries, err := diskcache.Scan(dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Failed to read %s: %v\n", dir, err)
+			os.Exit(1)
+		}
+		stats := diskcache.Summarize(dir, entries, cfg.GetCacheMaxBytes(), cfg.GetCacheTTL(), sealer)
+		limit := "no limit"
+		if stats.MaxBytes > 0 {
+			limit = fmt.Sprintf("limit %s, %.0f%% used", diskcache.FormatBytes(stats.MaxBytes), 100*float64(stats.Bytes)/float64(stats.MaxBytes))
+		}
+		fmt.Printf("🗄️  %s: %d entries, %s (%s)\n", dir, stats.Entries, diskcache.FormatBytes(stats.Bytes), limit)
+		kinds := make([]string, 0, len(stats.Kinds))
+		for kind := range stats.Kinds {
+			kinds = append(kinds, kind)
+		}
+		sort.Strings(kinds)
+		for _, kind := range kinds {
+			fmt.Printf("   %-8s %6d entries  %s\n", kind, stats.Kinds[kind].Entries, diskcache.FormatBytes(stats.Kinds[kind].Bytes))
+		}
+		if stats.Entries > 0 {
+			fmt.Printf("   Least recently used: %s, most recently used: %s\n", stats.Oldest.Local().Format("2006-01-02 15:04"), stats.Newest.Local().Format("2006-01-02 15:04"))
+		}
+		if stats.Expired > 0 {
+			fmt.Printf("   %d entries are older than cache.ttl_hours (%d) and will never be served; run \"cache prune\" to remove them\n", stats.Expired, cfg.Cache.TTLHours)
+		}
+		return
+	}
+
+	flags := flag.NewFlagSet("cache prune", flag.ExitOnError)
+	maxSizeMB := flags.Int("max-size-mb", cfg.Cache.MaxSizeMB, "Size to prune the cache down to, in MB (0 removes only expired entries)")
+	keepExpired := flags.Bool("keep-expired", false, "Keep entries older than cache.ttl_hours unless the size limit needs their space")
+	dryRun := flags.Bool("dry-run", false, "Report what would be removed without deleting anything")
+	flags.Parse(args[1:])
+	if *maxSizeMB < 0 || flags.NArg() > 0 {
+		fmt.Println(cacheUsage)
+		os.Exit(2)
+	}
+
+	ttl := cfg.GetCacheTTL()
+	if *keepExpired {
+		ttl = 0
+	}
+	result, err := diskcache.Prune(dir, int64(*maxSizeMB)*1024*1024, ttl, *dryRun, sealer)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to prune %s: %v\n", dir, err)
+		os.Exit(1)
+	}
+	verb := "Removed"
+	if *dryRun {
+		verb = "Would remove"
+	}
+	fmt.Printf("🧹 %s %d entries (%s), %d of them expired; %d entries (%s) remain in %s\n",
+		verb, result.Removed, diskcache.FormatBytes(result.RemovedBytes), result.Expired, result.Entries, diskcache.FormatBytes(result.Bytes), dir)
+}
+
+func runWorkspace(workspaceFile string, opts pipeline.AnalysisOptions) {
+	cfg := loadStartupConfig()
+
+	ws, err := workspace.LoadWorkspace(workspaceFile)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	for _, repo := range ws.Repositories {
+		if repo.Path == "" {
+			fmt.Printf("❌ Repository %s has no local path; clone %s and set its path in %s\n", repo.Name, repo.URL, workspaceFile)
+			os.Exit(1)
+		}
+	}
+
+	analyzer, err := workspace.NewAnalyzer(cfg, ws, opts)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("🗂️  Analyzing workspace %s (%d repositories)\n", ws.Name, len(ws.Repositories))
+
+	// Ctrl-C aborts the analysis
+	sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, cancel := context.WithTimeout(sigCtx, cfg.GetAnalysisTimeout())
+	defer cancel()
+
+	result, err := analyzer.Analyze(ctx, func(eventType, stage, message string, progress int, data interface{}) {
+		if eventType == "progress" {
+			fmt.Printf("   %3d%% %s\n", progress, stage)
+		}
+	})
+	if err != nil {
+		fmt.Printf("❌ Workspace analysis failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("\n" + strings.Repeat("=", 60))
+	fmt.Printf("🗂️  WORKSPACE: %s\n", result.Name)
+	fmt.Println(strings.Repeat("=", 60))
+	for _, repo := range result.Repositories {
+		if repo.Error != "" {
+			fmt.Printf("❌ %s: %s\n", repo.Name, repo.Error)
+			continue
+		}
+		purpose := ""
+		services := 0
+		if repo.Result != nil {
+			services = len(repo.Result.Services)
+			if repo.Result.ProjectSummary != nil {
+				purpose = repo.Result.ProjectSummary.Purpose
+			}
+		}
+		fmt.Printf("📦 %s (%d services)\n", repo.Name, services)
+		if purpose != "" {
+			fmt.Printf("   %s\n", purpose)
+		}
+	}
+
+	fmt.Printf("\n🔗 Cross-repository relationships: %d\n", len(result.CrossRepoRelationships))
+	for _, rel := range result.CrossRepoRelationships {
+		fmt.Printf("   %s ──%s──► %s: %s (%.1f)\n", rel.From, rel.EvidenceType, rel.To, rel.Evidence, rel.Confidence)
+	}
+
+	if result.CombinedGraph != nil {
+		if mermaidJSON, err := result.CombinedGraph.GenerateMermaidJSON(); err == nil {
+			fmt.Println("\n📊 COMBINED MERMAID GRAPH (JSON):")
+			fmt.Println(strings.Repeat("─", 40))
+			fmt.Println(mermaidJSON)
+		}
+	}
+
+	// Save the full result next to the other analysis output, encrypted like the caches it was built from
+	outputPath := filepath.Join(cfg.Output.OutputDirectory, fmt.Sprintf("workspace_%s.json", strings.ReplaceAll(result.Name, string(os.PathSeparator), "_")))
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err == nil {
+		err = atrest.For(cfg).WriteFile(outputPath, data, 0644)
+	}
+	if err != nil {
+		fmt.Printf("⚠️  Failed to save workspace result: %v\n", err)
+	} else {
+		fmt.Printf("\n💾 Workspace result saved to %s\n", outputPath)
+	}
+}
+
+// runDrift analyzes the template and repositories of a workspace file and reports how each
+// repository has drifted from the template
+func runDrift(workspaceFile string, opts pipeline.AnalysisOptions) {
+	cfg := loadStartupConfig()
+
+	ws, err := workspace.LoadWorkspace(workspaceFile)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	if ws.Template == nil {
+		fmt.Printf("❌ %s has no template; add the golden repository under template:\n", workspaceFile)
+		os.Exit(1)
+	}
+	for _, repo := range append([]workspace.Repository{*ws.Template}, ws.Repositories...) {
+		if repo.Path == "" {
+			fmt.Printf("❌ Repository %s has no local path; clone %s and set its path in %s\n", repo.Name, repo.URL, workspaceFile)
+			os.Exit(1)
+		}
+	}
+
+	analyzer, err := drift.NewAnalyzer(cfg, ws, opts)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("📐 Comparing %d repositories against template %s\n", len(ws.Repositories), ws.Template.Name)
+
+	// Ctrl-C aborts the analysis
+	sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, cancel := context.WithTimeout(sigCtx, cfg.GetAnalysisTimeout())
+	defer cancel()
+
+	report, err := analyzer.Analyze(ctx, func(eventType, stage, message string, progress int, data interface{}) {
+		if eventType == "progress" {
+			fmt.Printf("   %3d%% %s\n", progress, stage)
+		}
+	})
+	if err != nil {
+		fmt.Printf("❌ Drift analysis failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("\n" + strings.Repeat("=", 60))
+	fmt.Printf("📐 TEMPLATE DRIFT: %s\n", report.Name)
+	fmt.Println(strings.Repeat("=", 60))
+	fmt.Print(report.Text())
+
+	outputPath := filepath.Join(cfg.Output.OutputDirectory, fmt.Sprintf("drift_%s.json", strings.ReplaceAll(report.Name, string(os.PathSeparator), "_")))
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err == nil {
+		err = atrest.For(cfg).WriteFile(outputPath, data, 0644)
+	}
+	if err != nil {
+		fmt.Printf("⚠️  Failed to save drift report: %v\n", err)
+	} else {
+		fmt.Printf("\n💾 Drift report saved to %s\n", outputPath)
+	}
+}
+
+// runEstimate prints what analyzing projectPath would send to the LLM and cost, making no API calls
+func runEstimate(projectPath string, opts pipeline.AnalysisOptions) {
+	if projectPath == "" {
+		fmt.Println("Usage: ./analyzer-api -estimate -path=<folder-path> [-quick] [-phases=...] [-include=...]")
+		os.Exit(1)
+	}
+	if info, err := os.Stat(projectPath); err != nil || !info.IsDir() {
+		fmt.Printf("❌ %s is not a directory\n", projectPath)
+		os.Exit(1)
+	}
+
+	cfg := loadStartupConfig()
+	analyzer, err := pipeline.NewAnalyzer(cfg, projectPath)
+	if err != nil {
+		fmt.Printf("❌ Failed to create analyzer: %v\n", err)
+		os.Exit(1)
+	}
+	if err := analyzer.SetOptions(opts); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	estimate, err := analyzer.Estimate()
+	if err != nil {
+		fmt.Printf("❌ Estimate failed: %v\n", err)
+		os.Exit(1)
+	}
+	estimate.Display()
+}
+
+// runPrimeCache fills the file summary cache for projectPath without running the later phases
+func runPrimeCache(projectPath string, opts pipeline.AnalysisOptions) {
+	if projectPath == "" {
+		fmt.Println("Usage: ./analyzer-api -prime-cache -path=<folder-path> [-include=...] [-exclude=...]")
+		os.Exit(1)
+	}
+	if info, err := os.Stat(projectPath); err != nil || !info.IsDir() {
+		fmt.Printf("❌ %s is not a directory\n", projectPath)
+		os.Exit(1)
+	}
+
+	cfg := loadStartupConfig()
+	analyzer, err := pipeline.NewAnalyzer(cfg, projectPath)
+	if err != nil {
+		fmt.Printf("❌ Failed to create analyzer: %v\n", err)
+		os.Exit(1)
+	}
+	if err := analyzer.SetOptions(opts); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, cancel := context.WithTimeout(sigCtx, cfg.GetAnalysisTimeout())
+	defer cancel()
+
+	report, err := analyzer.PrimeCache(ctx)
+	if report != nil {
+		report.Display()
+	}
+	if err != nil {
+		fmt.Printf("❌ Priming failed: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// runExplain summarizes one file or folder of a project on demand, without analyzing the whole project
+func runExplain(projectPath string, args []string, opts pipeline.AnalysisOptions) {
+	if projectPath == "" || len(args) != 1 {
+		fmt.Println("Usage: ./analyzer-api -path=<folder-path> explain <file-or-folder>")
+		os.Exit(2)
+	}
+	if info, err := os.Stat(projectPath); err != nil || !info.IsDir() {
+		fmt.Printf("❌ %s is not a directory\n", projectPath)
+		os.Exit(1)
+	}
+
+	cfg := loadStartupConfig()
+	analyzer, err := pipeline.NewAnalyzer(cfg, projectPath)
+	if err != nil {
+		fmt.Printf("❌ Failed to create analyzer: %v\n", err)
+		os.Exit(1)
+	}
+	if err := analyzer.SetOptions(opts); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, cancel := context.WithTimeout(sigCtx, cfg.GetAnalysisTimeout())
+	defer cancel()
+
+	explanation, err := analyzer.Explain(ctx, args[0])
+	if err != nil {
+		fmt.Printf("❌ Explain failed: %v\n", err)
+		os.Exit(1)
+	}
+	explanation.Display()
+}
+
+// runDependents analyzes a project and lists what depends on one of its services, packages or tables
+func runDependents(projectPath string, args []string, opts pipeline.AnalysisOptions, out *console.Console) {
+	if projectPath == "" || len(args) != 1 {
+		fmt.Println("Usage: ./analyzer-api -path=<folder-path> dependents <service|package|table>")
+		os.Exit(2)
+	}
+	if info, err := os.Stat(projectPath); err != nil || !info.IsDir() {
+		fmt.Printf("❌ %s is not a directory\n", projectPath)
+		os.Exit(1)
+	}
+
+	cfg := loadStartupConfig()
+	analyzer, err := pipeline.NewAnalyzer(cfg, projectPath)
+	if err != nil {
+		fmt.Printf("❌ Failed to create analyzer: %v\n", err)
+		os.Exit(1)
+	}
+	if err := analyzer.SetOptions(opts); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, cancel := context.WithTimeout(sigCtx, cfg.GetAnalysisTimeout())
+	defer cancel()
+
+	result, err := out.Analyze(ctx, analyzer)
+	if err != nil {
+		fmt.Printf("❌ Analysis failed: %v\n", err)
+		os.Exit(1)
+	}
+	reports, err := dependents.Find(result, args[0])
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	for i := range reports {
+		reports[i].Display()
+	}
+}
+
+const contextPackUsage = "Usage: ./analyzer-api -path=<folder-path> context-pack [-format=markdown|json] [-max-tokens=N] [-o=<file>|-]"
+
+// runContextPack analyzes a folder and writes its context pack, a bundle sized to paste into a
+// chat assistant, to the output directory, a file or stdout
+func runContextPack(projectPath string, args []string, opts pipeline.AnalysisOptions, out *console.Console) {
+	flags := flag.NewFlagSet("context-pack", flag.ExitOnError)
+	format := flags.String("format", contextpack.Markdown, "Bundle format: markdown or json")
+	maxTokens := flags.Int("max-tokens", contextpack.DefaultMaxTokens, "Largest size of the pack, in estimated tokens")
+	output := flags.String("o", "", "File to write, or - for stdout (defaults to the output directory)")
+	flags.Parse(args)
+	if projectPath == "" || flags.NArg() > 0 || *maxTokens <= 0 {
+		fmt.Println(contextPackUsage)
+		os.Exit(2)
+	}
+	if *format != contextpack.Markdown && *format != contextpack.JSON {
+		fmt.Printf("❌ Unknown format %q (available: markdown, json)\n", *format)
+		os.Exit(2)
+	}
+	if info, err := os.Stat(projectPath); err != nil || !info.IsDir() {
+		fmt.Printf("❌ %s is not a directory\n", projectPath)
+		os.Exit(1)
+	}
+
+	cfg := loadStartupConfig()
+	analyzer, err := pipeline.NewAnalyzer(cfg, projectPath)
+	if err != nil {
+		fmt.Printf("❌ Failed to create analyzer: %v\n", err)
+		os.Exit(1)
+	}
+	if err := analyzer.SetOptions(opts); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, cancel := context.WithTimeout(sigCtx, cfg.GetAnalysisTimeout())
+	defer cancel()
+
+	result, err := out.Analyze(ctx, analyzer)
+	if err != nil {
+		fmt.Printf("❌ Analysis failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	name := reportName(projectPath, result)
+	pack := contextpack.Build(name, result, *maxTokens)
+	content := []byte(pack.Markdown())
+	extension := ".md"
+	if *format == contextpack.JSON {
+		if content, err = json.MarshalIndent(pack, "", "  "); err != nil {
+			fmt.Printf("❌ Failed to encode the context pack: %v\n", err)
+			os.Exit(1)
+		}
+		extension = ".json"
+	}
+
+	if *output == "-" {
+		os.Stdout.Write(content)
+		return
+	}
+	target := *output
+	if target == "" {
+		if err := os.MkdirAll(cfg.Output.OutputDirectory, 0755); err != nil {
+			fmt.Printf("❌ Failed to create output directory: %v\n", err)
+			os.Exit(1)
+		}
+		target = filepath.Join(cfg.Output.OutputDirectory, strings.Trim(mermaid.ID(name), "_")+"_context_pack"+extension)
+	}
+	if err := os.WriteFile(target, content, 0644); err != nil {
+		fmt.Printf("❌ Failed to write %s: %v\n", target, err)
+		os.Exit(1)
+	}
+	fmt.Printf("📦 Context pack written to %s (about %d tokens)\n", target, pack.EstimatedTokens)
+	if len(pack.OmittedSections) > 0 {
+		fmt.Printf("   Left out to fit %d tokens: %s\n", pack.MaxTokens, strings.Join(pack.OmittedSections, ", "))
+	}
+}
+
+// runDiagramExport analyzes a folder once and writes the requested diagram files to the output directory
+func runDiagramExport(projectPath, diagram string, opts pipeline.AnalysisOptions, out *console.Console) {
+	if diagram != "c4" && diagram != "architecture" {
+		fmt.Printf("❌ Unknown diagram %q (available: c4, architecture)\n", diagram)
+		os.Exit(1)
+	}
+	if projectPath == "" {
+		fmt.Printf("Usage: ./analyzer-api -diagram=%s -path=<folder-path>\n", diagram)
+		os.Exit(1)
+	}
+	if info, err := os.Stat(projectPath); err != nil || !info.IsDir() {
+		fmt.Printf("❌ %s is not a directory\n", projectPath)
+		os.Exit(1)
+	}
+
+	cfg := loadStartupConfig()
+
+	analyzer, err := pipeline.NewAnalyzer(cfg, projectPath)
+	if err != nil {
+		fmt.Printf("❌ Failed to create analyzer: %v\n", err)
+		os.Exit(1)
+	}
+	if err := analyzer.SetOptions(opts); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, cancel := context.WithTimeout(sigCtx, cfg.GetAnalysisTimeout())
+	defer cancel()
+
+	result, err := out.Analyze(ctx, analyzer)
+	if err != nil {
+		fmt.Printf("❌ Analysis failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	name := reportName(projectPath, result)
+	var paths []string
+	switch diagram {
+	case "architecture":
+		if result.ArchitectureMap == nil {
+			fmt.Println("❌ No folder summaries to map; enable the folder phase")
+			os.Exit(1)
+		}
+		paths, err = result.ArchitectureMap.Export(cfg.Output.OutputDirectory)
+	default:
+		paths, err = c4.Build(name, result).Export(cfg.Output.OutputDirectory)
+	}
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println("📐 Diagrams written:")
+	for _, path := range paths {
+		fmt.Printf("   • %s\n", path)
+	}
+}
+
+// runPublish analyzes a folder once and publishes its onboarding report, updating the page
+// published for it before
+func runPublish(projectPath, target string, opts pipeline.AnalysisOptions, out *console.Console) {
+	if projectPath == "" {
+		fmt.Printf("Usage: ./analyzer-api -publish=%s -path=<folder-path>\n", target)
+		os.Exit(1)
+	}
+	if info, err := os.Stat(projectPath); err != nil || !info.IsDir() {
+		fmt.Printf("❌ %s is not a directory\n", projectPath)
+		os.Exit(1)
+	}
+
+	cfg := loadStartupConfig()
+
+	// Missing credentials are reported before the analysis rather than after it
+	var publisher publish.Publisher
+	if target != "markdown" {
+		var err error
+		if publisher, err = publish.New(target, cfg.Publish); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	analyzer, err := pipeline.NewAnalyzer(cfg, projectPath)
+	if err != nil {
+		fmt.Printf("❌ Failed to create analyzer: %v\n", err)
+		os.Exit(1)
+	}
+	if err := analyzer.SetOptions(opts); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, cancel := context.WithTimeout(sigCtx, cfg.GetAnalysisTimeout())
+	defer cancel()
+
+	result, err := out.Analyze(ctx, analyzer)
+	if err != nil {
+		fmt.Printf("❌ Analysis failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	name := reportName(projectPath, result)
+	doc := report.Build(publish.Title(cfg.Publish.Title, name), name, result)
+
+	if publisher == nil {
+		paths, err := doc.Write(cfg.Output.OutputDirectory)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("📝 Onboarding report written:")
+		for _, path := range paths {
+			fmt.Printf("   • %s\n", path)
+		}
+		return
+	}
+
+	fmt.Printf("📤 Publishing %q to %s...\n", doc.Title, publisher)
+	url, err := publisher.Publish(ctx, doc)
+	if err != nil {
+		fmt.Printf("❌ Publishing failed: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("✅ Published: %s\n", url)
+}
+
+func runWatch(projectPath string, opts pipeline.AnalysisOptions) {
+	if projectPath == "" {
+		fmt.Println("Usage: ./analyzer-api -mode=watch -path=<folder-path>")
+		os.Exit(1)
+	}
+	if info, err := os.Stat(projectPath); err != nil || !info.IsDir() {
+		fmt.Printf("❌ %s is not a directory\n", projectPath)
+		os.Exit(1)
+	}
+
+	cfg := loadStartupConfig()
+
+	analyzer, err := pipeline.NewAnalyzer(cfg, projectPath)
+	if err != nil {
+		fmt.Printf("❌ Failed to create analyzer: %v\n", err)
+		os.Exit(1)
+	}
+	if err := analyzer.SetOptions(opts); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	analyzer.EnableIncremental()
+
+	watcher, err := watch.NewWatcher(projectPath, time.Second, 2*time.Second, cfg.Cache.Directory, cfg.Output.OutputDirectory)
+	if err != nil {
+		fmt.Printf("❌ Failed to watch %s: %v\n", projectPath, err)
+		os.Exit(1)
+	}
+
+	// Results are pushed to clients of GET /api/v1/watch/stream
+	e := echo.New()
+	e.HideBanner = true
+	e.IPExtractor = echo.ExtractIPDirect()
+	e.Use(middleware.Recover())
+	e.Use(middleware.CORS())
+	watchController := controllers.NewWatchController()
+	routes.SetupWatchRoutes(e, controllers.NewHealthController(cfg), watchController)
+	go func() {
+		if err := e.Start(":8080"); err != nil && err != http.ErrServerClosed {
+			fmt.Printf("❌ Server error: %v\n", err)
+			os.Exit(1)
+		}
+	}()
+
+	// Ctrl-C stops watching
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	analyze := func(label string) {
+		runCtx, cancel := context.WithTimeout(ctx, cfg.GetAnalysisTimeout())
+		defer cancel()
+
+		start := time.Now()
+		result, err := analyzer.AnalyzeProjectWithProgress(runCtx, func(eventType, stage, message string, progress int, data interface{}) {
+			if eventType == "progress" {
+				fmt.Printf("   %3d%% %s\n", progress, stage)
+			}
+			watchController.Publish(eventType, stage, message, progress, data)
+		})
+		if err != nil {
+			if ctx.Err() == nil {
+				fmt.Printf("❌ Analysis failed: %v\n", err)
+				watchController.Publish("error", label, fmt.Sprintf("Analysis failed: %v", err), 0, nil)
+			}
+			return
+		}
+		fmt.Printf("✅ %s finished in %v\n", label, time.Since(start).Round(time.Millisecond))
+		watchController.Publish("complete", "🎉 Analysis complete!", label, 100, result)
+	}
+
+	fmt.Printf("👀 Watching %s (results streamed on http://localhost:8080/api/v1/watch/stream)\n", projectPath)
+	analyze("Initial analysis")
+
+	for batch := range watcher.Start(ctx) {
+		paths := make([]string, len(batch))
+		for i, change := range batch {
+			paths[i] = change.Path
+			fmt.Printf("   %s %s\n", change.Op, change.Path)
+		}
+		fmt.Printf("🔄 %d file(s) changed, re-analyzing\n", len(batch))
+		watchController.Publish("changes", "🔄 Files changed", fmt.Sprintf("%d file(s) changed", len(batch)), 0, batch)
+
+		analyzer.MarkChanged(paths)
+		analyze("Incremental re-analysis")
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	e.Shutdown(shutdownCtx)
+	fmt.Println("👋 Stopped watching")
+}
+
+// runEditor serves editor plugins over JSON-RPC on stdin/stdout
+func runEditor(projectPath string, opts pipeline.AnalysisOptions) {
+	// stdout carries the protocol, so all logging goes to stderr
+	protocolOut := os.Stdout
+	os.Stdout = os.Stderr
+
+	cfg := loadStartupConfig()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	server := editor.NewServer(cfg, projectPath, opts)
+	if err := server.Serve(ctx, os.Stdin, protocolOut); err != nil {
+		fmt.Printf("❌ Editor server stopped: %v\n", err)
 		os.Exit(1)
 	}
 }
 
-func runServer() {
-	e := echo.New()
+// runMCP serves the repository to coding agents over the MCP stdio transport
+func runMCP(projectPath string, opts pipeline.AnalysisOptions) {
+	// stdout carries the protocol, so all logging goes to stderr
+	protocolOut := os.Stdout
+	os.Stdout = os.Stderr
 
-	// Middleware
-	e.Use(middleware.Logger())
-	e.Use(middleware.Recover())
-	e.Use(middleware.CORS())
+	if projectPath == "" {
+		projectPath = "."
+	}
+	if info, err := os.Stat(projectPath); err != nil || !info.IsDir() {
+		fmt.Printf("❌ %s is not a directory\n", projectPath)
+		os.Exit(1)
+	}
 
-	// Initialize controllers
-	healthController := controllers.NewHealthController()
-	analysisController := controllers.NewAnalysisController()
+	cfg := loadStartupConfig()
 
-	// Setup routes
-	routes.SetupRoutes(e, healthController, analysisController)
+	server, err := mcp.NewServer(cfg, projectPath, opts)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
 
-	// Start server
-	e.Logger.Fatal(e.Start(":8080"))
-}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 
-func runCLI() {
-	repl := cli.NewREPL()
-	repl.Start()
+	if err := server.Serve(ctx, os.Stdin, protocolOut); err != nil {
+		fmt.Printf("❌ MCP server stopped: %v\n", err)
+		os.Exit(1)
+	}
 }
 
-func runSecretsExtraction(projectPath string) {
+func runSecretsExtraction(projectPath, format string) {
+	checkFindingsFormat(format)
 	if projectPath == "" {
 		args := flag.Args()
 		if len(args) == 0 {
@@ -79,10 +1245,23 @@ func runSecretsExtraction(projectPath string) {
 		projectPath = args[0]
 	}
 
-	fmt.Printf("🔍 Extracting secrets from: %s\n", projectPath)
-	
 	// Create secret extractor
 	extractor := secrets.NewSecretExtractor(projectPath)
+
+	// Credentials committed to the repository
+	committed, err := extractor.DetectCommitted()
+	if err != nil {
+		fmt.Printf("❌ Committed secret detection failed: %v\n", err)
+		os.Exit(1)
+	}
+	if format == formatSARIF {
+		log := sarif.NewLog()
+		log.NewRun().AddCommittedSecrets(committed)
+		writeSARIF(log)
+		return
+	}
+
+	fmt.Printf("🔍 Extracting secrets from: %s\n", projectPath)
 	
 	// Extract secrets from configuration files
 	projectSecrets, err := extractor.ExtractSecrets()
@@ -93,6 +1272,7 @@ func runSecretsExtraction(projectPath string) {
 	
 	if projectSecrets == nil || projectSecrets.TotalVariables == 0 {
 		fmt.Println("✅ No configuration secrets found that need to be set.")
+		printCommittedSecrets(committed)
 		return
 	}
 	
@@ -116,6 +1296,9 @@ func runSecretsExtraction(projectPath string) {
 			fmt.Printf("%d. %s\n", i+1, secret.Name)
 			fmt.Printf("   Type: %s\n", strings.ToUpper(secret.Type))
 			fmt.Printf("   Source: %s\n", secret.Source)
+			if secret.Origin != "" {
+				fmt.Printf("   Deployment: %s for service %s\n", secret.Origin, secret.TargetService)
+			}
 			fmt.Printf("   Description: %s\n", secret.Description)
 			if secret.Example != "" {
 				fmt.Printf("   Example: %s=%s\n", secret.Name, secret.Example)
@@ -139,6 +1322,9 @@ func runSecretsExtraction(projectPath string) {
 					fmt.Printf("  %d. %s\n", i+1, secret.Name)
 					fmt.Printf("     Type: %s\n", strings.ToUpper(secret.Type))
 					fmt.Printf("     Source: %s\n", secret.Source)
+					if secret.Origin != "" {
+						fmt.Printf("     Deployment: %s for service %s\n", secret.Origin, secret.TargetService)
+					}
 					fmt.Printf("     Description: %s\n", secret.Description)
 					if secret.Example != "" {
 						fmt.Printf("     Example: %s=%s\n", secret.Name, secret.Example)
@@ -152,6 +1338,24 @@ func runSecretsExtraction(projectPath string) {
 		}
 	}
 	
+	// Display Per-Environment Secrets
+	if len(projectSecrets.Environments) > 0 {
+		fmt.Println("🌐 ENVIRONMENTS")
+		fmt.Println(strings.Repeat("-", 40))
+		if len(projectSecrets.RequiredEverywhere) > 0 {
+			fmt.Printf("Needed in every environment: %s\n", strings.Join(projectSecrets.RequiredEverywhere, ", "))
+		}
+		for _, env := range projectSecrets.Environments {
+			fmt.Printf("🏷️  %s (%s)\n", env.Name, strings.Join(env.Files, ", "))
+			if len(env.Specific) > 0 {
+				fmt.Printf("   Only here: %s\n", strings.Join(env.Specific, ", "))
+			} else {
+				fmt.Println("   Nothing beyond the shared variables")
+			}
+		}
+		fmt.Println()
+	}
+	
 	// Setup Instructions
 	if projectSecrets.RequiredCount > 0 {
 		fmt.Println("🛠️  SETUP INSTRUCTIONS")
@@ -166,10 +1370,411 @@ func runSecretsExtraction(projectPath string) {
 		fmt.Println("💡 Tip: Check each service's README or documentation for specific setup instructions.")
 	}
 	
+	printCommittedSecrets(committed)
 	fmt.Println(strings.Repeat("=", 60))
 }
 
-func runDebugDB() {
+// printCommittedSecrets lists credentials found written into the repository
+func printCommittedSecrets(committed []secrets.CommittedSecret) {
+	if len(committed) == 0 {
+		return
+	}
+	fmt.Println()
+	fmt.Printf("🚨 COMMITTED SECRETS (%d)\n", len(committed))
+	fmt.Println(strings.Repeat("-", 40))
+	for _, secret := range committed {
+		icon := "⚠️ "
+		if secret.Certain {
+			icon = "❌"
+		}
+		fmt.Printf("%s %s:%d  %s (%s)\n", icon, secret.File, secret.Line, secret.Description, secret.Preview)
+	}
+	fmt.Println("Rotate these credentials and move them to the environment or a secret manager.")
+	fmt.Println()
+}
+
+// runSecretsCheck validates an env file (or the process environment) against the project's required variables.
+// Exit codes: 0 when everything is set, 1 when variables are missing or placeholders, 2 when the check could not run.
+func runSecretsCheck(projectPath, envFile string) {
+	if projectPath == "" {
+		projectPath = "."
+	}
+
+	// Extraction logs every parsed line, values included; keep that out of CI logs
+	stdout := os.Stdout
+	if devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0); err == nil {
+		os.Stdout = devNull
+		defer devNull.Close()
+	}
+	extractor := secrets.NewSecretExtractor(projectPath)
+	projectSecrets, err := extractor.ExtractSecrets()
+	os.Stdout = stdout
+	if err != nil {
+		fmt.Printf("❌ Secret extraction failed: %v\n", err)
+		os.Exit(2)
+	}
+
+	var entries []secrets.EnvEntry
+	source := "process environment"
+	if envFile != "" {
+		entries, err = secrets.ParseEnvFile(envFile)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(2)
+		}
+		source = envFile
+	} else {
+		entries = secrets.ProcessEnvironment()
+	}
+
+	result, err := extractor.CheckEnvironment(projectSecrets, entries, source, envFile != "")
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(2)
+	}
+
+	fmt.Printf("🔐 Checking %s against %d required variables in %s\n", result.Source, result.Checked, projectPath)
+
+	if len(result.Missing) > 0 {
+		fmt.Printf("\n❌ Missing required variables (%d):\n", len(result.Missing))
+		for _, variable := range result.Missing {
+			where := variable.Source
+			if len(variable.Services) > 0 {
+				where = fmt.Sprintf("%s; services: %s", where, strings.Join(variable.Services, ", "))
+			}
+			fmt.Printf("   • %s (%s, from %s)\n", variable.Name, variable.Type, where)
+		}
+	}
+
+	if len(result.Placeholders) > 0 {
+		fmt.Printf("\n❌ Placeholder values still present (%d):\n", len(result.Placeholders))
+		for _, entry := range result.Placeholders {
+			if entry.Line > 0 {
+				fmt.Printf("   • %s (line %d)\n", entry.Name, entry.Line)
+			} else {
+				fmt.Printf("   • %s\n", entry.Name)
+			}
+		}
+	}
+
+	if len(result.Unused) > 0 {
+		fmt.Printf("\n⚠️  Variables the project never reads (%d):\n", len(result.Unused))
+		for _, entry := range result.Unused {
+			fmt.Printf("   • %s (line %d)\n", entry.Name, entry.Line)
+		}
+	}
+
+	if !result.Passed() {
+		fmt.Println("\n❌ Environment check failed")
+		os.Exit(1)
+	}
+	fmt.Println("\n✅ All required variables are set")
+}
+
+// runProbe checks which of a local checkout's services are running and answering their health checks
+func runProbe(projectPath, host string) {
+	if projectPath == "" {
+		projectPath = "."
+	}
+	if info, err := os.Stat(projectPath); err != nil || !info.IsDir() {
+		fmt.Printf("❌ %s is not a directory\n", projectPath)
+		os.Exit(1)
+	}
+
+	// Probing never calls the LLM, so it must not require an API key
+	os.Setenv("ANALYZER_OFFLINE", "true")
+	cfg := loadStartupConfig()
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.GetAnalysisTimeout())
+	defer cancel()
+
+	report, err := probe.Run(ctx, cfg, projectPath, probe.NewProber(host, probe.DefaultTimeout))
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	icons := map[string]string{probe.StatusLive: "🟢", probe.StatusUnhealthy: "🟡", probe.StatusDown: "🔴", probe.StatusUnknown: "⚪"}
+	fmt.Printf("\n📡 Service status on %s (%d live, %d unhealthy, %d down, %d unknown)\n", report.Host, report.Live, report.Unhealthy, report.Down, report.Unknown)
+	for _, status := range report.Services {
+		port := "-"
+		if status.Port > 0 {
+			port = fmt.Sprintf(":%d", status.Port)
+		}
+		source := ""
+		if status.Evidence != "" {
+			source = fmt.Sprintf(" [%s: %s]", status.Source, status.Evidence)
+		}
+		fmt.Printf("   %s %-24s %-7s %-10s %s%s\n", icons[status.Status], status.Service, port, status.Status, status.Detail, source)
+	}
+	if len(report.Services) == 0 {
+		fmt.Println("   No services discovered")
+		return
+	}
+	fmt.Printf("\n```mermaid\n%s```\n", report.Mermaid)
+}
+
+// Output formats of the secrets and migrate-lint modes
+const (
+	formatText  = "text"
+	formatSARIF = "sarif"
+)
+
+// checkFindingsFormat exits when -format names no known output format
+func checkFindingsFormat(format string) {
+	if format != formatText && format != formatSARIF {
+		fmt.Printf("❌ Unknown format %q (available: %s, %s)\n", format, formatText, formatSARIF)
+		os.Exit(2)
+	}
+}
+
+// writeSARIF prints a SARIF log on stdout
+func writeSARIF(log *sarif.Log) {
+	if err := log.Write(os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(2)
+	}
+}
+
+// runMigrateLint reports problems in a project's SQL migrations and in the design of the schema
+// they build, exiting 1 when any migration finding is an error
+func runMigrateLint(projectPath, format string) {
+	checkFindingsFormat(format)
+	if projectPath == "" {
+		projectPath = "."
+	}
+	files, err := scanSQLFiles(projectPath)
+	if err != nil {
+		fmt.Printf("❌ Error scanning files: %v\n", err)
+		os.Exit(2)
+	}
+
+	report := database.LintMigrations(files)
+	quality := lintMigratedSchema(files)
+	if format == formatSARIF {
+		log := sarif.NewLog()
+		run := log.NewRun()
+		run.AddMigrationLint(report)
+		if quality != nil {
+			run.AddSchemaLint(quality, report)
+		}
+		writeSARIF(log)
+		if report.HasErrors() {
+			os.Exit(1)
+		}
+		return
+	}
+	if report.Migrations == 0 {
+		fmt.Printf("🗄️  No migration files found in %s\n", projectPath)
+		return
+	}
+	fmt.Printf("🗄️  Linted %d migration files in %s\n", report.Migrations, projectPath)
+
+	icons := map[database.Severity]string{database.SeverityError: "❌", database.SeverityWarning: "⚠️ ", database.SeverityInfo: "ℹ️ "}
+	file := ""
+	for _, finding := range report.Findings {
+		if finding.File != file {
+			file = finding.File
+			fmt.Printf("\n%s\n", file)
+		}
+		location := "-"
+		if finding.Line > 0 {
+			location = fmt.Sprintf("%d", finding.Line)
+		}
+		fmt.Printf("   %s %-5s %-8s %-26s %s\n", icons[finding.Severity], location, finding.Severity, finding.Rule, finding.Message)
+	}
+
+	fmt.Printf("\n%d errors, %d warnings, %d info\n", report.Errors, report.Warnings, report.Infos)
+	if quality != nil {
+		fmt.Printf("\n🩺 Schema quality: %d/100 (%s)\n", quality.Score, quality.Grade)
+		for _, finding := range quality.Findings {
+			fmt.Printf("   %s %-22s %s\n", icons[finding.Severity], finding.Rule, finding.Message)
+		}
+	}
+	if report.HasErrors() {
+		os.Exit(1)
+	}
+}
+
+// lintMigratedSchema builds the schema the SQL files create, without the LLM and with the
+// extractor's log discarded, and lints its design; nil when they create no tables
+func lintMigratedSchema(files map[string]string) *database.SchemaLintReport {
+	quiet, err := console.New(true, console.FormatText)
+	if err != nil {
+		return nil
+	}
+	var result *database.ExtractSchemaFromProjectResult
+	quiet.Run(func() error {
+		defer func() {
+			if r := recover(); r != nil {
+				result = nil
+			}
+		}()
+		result, err = database.ExtractSchemaWithFinalMigrationContext(context.Background(), "", files, func(database.StreamingResponse) {}, nil)
+		return err
+	})
+	if result == nil || result.Schema == nil || len(result.Schema.Tables) == 0 {
+		return nil
+	}
+	return database.LintSchema(result.Schema)
+}
+
+// scanSQLFiles reads the .sql files under rootPath, keyed by slash-separated relative path
+func scanSQLFiles(rootPath string) (map[string]string, error) {
+	files := make(map[string]string)
+	err := filepath.WalkDir(rootPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			switch d.Name() {
+			case ".git", "node_modules", "vendor":
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !strings.EqualFold(filepath.Ext(path), ".sql") {
+			return nil
+		}
+		relPath, err := filepath.Rel(rootPath, path)
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		files[filepath.ToSlash(relPath)] = string(content)
+		return nil
+	})
+	return files, err
+}
+
+// runSnapshots compares the deterministic outputs for each fixture repository with its golden
+// file, or rewrites the golden files when UPDATE_SNAPSHOTS is set. Run it from the repository root.
+func runSnapshots() {
+	update := os.Getenv(snapshots.UpdateEnv) != ""
+	results, err := snapshots.Check(context.Background(), update, snapshots.GoldenDir)
+	if err != nil {
+		fmt.Printf("❌ Snapshot run failed: %v\n", err)
+		os.Exit(2)
+	}
+
+	failed := 0
+	for _, result := range results {
+		switch result.Status {
+		case "match":
+			fmt.Printf("✅ %s\n", result.Fixture)
+		case "updated":
+			fmt.Printf("📝 %s: golden file updated\n", result.Fixture)
+		case "missing":
+			failed++
+			fmt.Printf("❌ %s: no golden file; run with %s=1 to create it\n", result.Fixture, snapshots.UpdateEnv)
+		default:
+			failed++
+			fmt.Printf("❌ %s: output differs from the golden file\n%s", result.Fixture, result.Diff)
+		}
+	}
+	if update {
+		fmt.Printf("\n%d golden files written to %s; rebuild to embed them\n", len(results), snapshots.GoldenDir)
+		return
+	}
+	fmt.Printf("\n%d of %d fixtures match\n", len(results)-failed, len(results))
+	if failed > 0 {
+		fmt.Printf("Review the diffs, then run with %s=1 to accept intended changes\n", snapshots.UpdateEnv)
+		os.Exit(1)
+	}
+}
+
+// runQAEval generates the onboarding questions of each suite's repository with the LLM and scores
+// them against the suite's expected answers, exiting 1 when a case fails
+func runQAEval(suitePath string, out *console.Console) {
+	suites, err := qaeval.Load(suitePath)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(2)
+	}
+	cfg := loadStartupConfig()
+
+	failed := 0
+	for _, suite := range suites {
+		report, err := qaeval.Run(context.Background(), cfg, suite, out)
+		if err != nil {
+			fmt.Printf("❌ %s: %v\n", suite.Name, err)
+			os.Exit(2)
+		}
+
+		fmt.Printf("\n🧪 %s: %d questions generated, %s similarity, %s\n", report.Suite, len(report.Generated), report.Similarity, report.Duration)
+		for _, result := range report.Cases {
+			status := "✅"
+			if !result.Passed {
+				status = "❌"
+			}
+			fmt.Printf("%s %s\n", status, result.Question)
+			if result.Matched == "" {
+				fmt.Printf("   no generated question left to match\n")
+				continue
+			}
+			fmt.Printf("   matched %q (%.2f)\n", result.Matched, result.QuestionSimilarity)
+			if result.MinSimilarity > 0 {
+				fmt.Printf("   answer similarity %.2f (min %.2f), keywords %.0f%%, score %.2f\n", result.Similarity, result.MinSimilarity, result.KeywordRecall*100, result.Score)
+			} else {
+				fmt.Printf("   keywords %.0f%%, score %.2f\n", result.KeywordRecall*100, result.Score)
+			}
+			if len(result.MissingKeywords) > 0 {
+				fmt.Printf("   missing keywords: %s\n", strings.Join(result.MissingKeywords, ", "))
+			}
+			if !result.Passed {
+				fmt.Printf("   answer: %s\n", result.Answer)
+			}
+		}
+		fmt.Printf("Score %.2f, %d of %d cases passed\n", report.Score, report.Passed, len(report.Cases))
+		failed += report.Failed
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// runSelfTest analyzes the analyzer's own source tree offline and checks every subsystem's output,
+// exiting 1 when a check fails. The tree is -path, or found above the working directory or the binary.
+func runSelfTest(sourcePath string) {
+	starts := []string{sourcePath}
+	if sourcePath == "" {
+		starts = []string{"."}
+		if executable, err := os.Executable(); err == nil {
+			starts = append(starts, filepath.Dir(executable))
+		}
+	}
+	sourceTree, err := selftest.Locate(starts...)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(2)
+	}
+
+	fmt.Printf("🩺 Self-testing on %s with the LLM stubbed...\n", sourceTree)
+	report, err := selftest.Run(context.Background(), sourceTree)
+	if err != nil {
+		fmt.Printf("❌ Self-test run failed: %v\n", err)
+		os.Exit(2)
+	}
+
+	for _, check := range report.Checks {
+		switch check.Status {
+		case selftest.Pass:
+			fmt.Printf("✅ %s\n", check.Name)
+		case selftest.Skip:
+			fmt.Printf("➖ %s: %s\n", check.Name, check.Detail)
+		default:
+			fmt.Printf("❌ %s (%s): %s\n", check.Name, check.Field, check.Detail)
+		}
+	}
+	fmt.Printf("\n%d passed, %d failed, %d skipped in %s\n", report.Passed, report.Failed, report.Skipped, report.Duration)
+	if !report.OK() {
+		os.Exit(1)
+	}
+}
+
+func runDebugDB(erdSplit bool) {
 	// Check if folder path is provided as argument
 	args := flag.Args()
 	if len(args) == 0 {
@@ -191,7 +1796,7 @@ func runDebugDB() {
 		os.Exit(1)
 	}
 	
-	fmt.Printf("✅ Found %d total files\n", len(files))
+	fmt.Printf("✅ Found %d total files\n", files.Len())
 
 	// Step 2: Filter for SQL files
 	fmt.Println("\n📄 Step 2: Filtering for SQL migration files...")
@@ -217,12 +1822,18 @@ func runDebugDB() {
 		return
 	}
 
+	// The relationship pass runs when the configuration has an API key and is not offline
+	var client *internalOpenai.Client
+	if cfg, err := config.Load(); err == nil && cfg.OpenAI.APIKey != "" && !cfg.Offline {
+		client = internalOpenai.NewClient(cfg)
+	}
+
 	// Step 4: Extract schema using streaming extractor with final migration generation
 	fmt.Println("\n🗄️ Step 4: Extracting database schema and generating final migration...")
 	result, err := database.ExtractSchemaWithFinalMigration(folderPath, sqlFiles, func(response database.StreamingResponse) {
 		fmt.Printf("   📋 %s: %s (Progress: %d/%d)\n", 
 			response.Phase, response.Message, response.Progress.Current, response.Progress.Total)
-	})
+	}, client)
 
 	if err != nil {
 		fmt.Printf("❌ Schema extraction failed: %v\n", err)
@@ -252,6 +1863,7 @@ func runDebugDB() {
 	fmt.Printf("📊 Tables found: %d\n", len(canonicalSchema.Tables))
 	fmt.Printf("📊 Enums found: %d\n", len(canonicalSchema.Enums))
 	fmt.Printf("📊 Views found: %d\n", len(canonicalSchema.Views))
+	fmt.Printf("📊 Functions found: %d\n", len(canonicalSchema.Functions))
 
 	// Display table details
 	if len(canonicalSchema.Tables) > 0 {
@@ -262,6 +1874,13 @@ func runDebugDB() {
 			fmt.Printf("     Primary Keys: %v\n", table.PrimaryKey)
 			fmt.Printf("     Foreign Keys: %d\n", len(table.ForeignKeys))
 			fmt.Printf("     Indexes: %d\n", len(table.Indexes))
+			for _, trigger := range table.Triggers {
+				action := trigger.Function
+				if action == "" {
+					action = "inline body"
+				}
+				fmt.Printf("     ⚡ Trigger %s: %s %s for each %s → %s\n", trigger.Name, trigger.Timing, strings.Join(trigger.Events, " or "), trigger.ForEach, action)
+			}
 			
 			// Show column details
 			for colName, column := range table.Columns {
@@ -278,6 +1897,25 @@ func runDebugDB() {
 		}
 	}
 
+	// Display schema design lint
+	quality := database.LintSchema(canonicalSchema)
+	fmt.Printf("\n🩺 Schema quality: %d/100 (%s)\n", quality.Score, quality.Grade)
+	icons := map[database.Severity]string{database.SeverityError: "❌", database.SeverityWarning: "⚠️ ", database.SeverityInfo: "ℹ️ "}
+	for _, finding := range quality.Findings {
+		fmt.Printf("   %s %-22s %s\n", icons[finding.Severity], finding.Rule, finding.Message)
+	}
+
+	// Display sensitive columns
+	if sensitivity := database.SummarizeSensitivity(canonicalSchema); sensitivity != nil {
+		fmt.Printf("\n🔐 Data sensitivity: %s\n", sensitivity.Summary)
+		for _, column := range sensitivity.Columns {
+			fmt.Printf("   • %s.%s: %s (%s)\n", column.Table, column.Column, column.Class, column.Label)
+		}
+		for _, warning := range sensitivity.Warnings {
+			fmt.Printf("   ⚠️  %s\n", warning)
+		}
+	}
+
 	// Display Mermaid ERD
 	if mermaidERD != "" {
 		fmt.Println("\n🎨 Mermaid ERD Generated:")
@@ -308,12 +1946,26 @@ func runDebugDB() {
 		fmt.Printf("   • %s.%s\n", fk.Table, fk.Column)
 	}
 
+	// Display the ERD split into domains
+	if erdSplit && len(legacySchema.Tables) > 0 {
+		split := legacySchema.SplitERD()
+		fmt.Printf("\n🧩 ERD Domains: %s\n", split.Summary)
+		fmt.Println(strings.Repeat("─", 40))
+		fmt.Println(split.Index)
+		for _, domain := range split.Domains {
+			fmt.Printf("%%%% domain: %s\n", domain.Name)
+			fmt.Println(domain.Mermaid)
+		}
+		fmt.Println(strings.Repeat("─", 40))
+	}
+
 	// Step 7: Display final migration SQL
 	if finalMigrationSQL != "" {
 		fmt.Println("\n🎯 Step 7: Final Migration SQL Generated")
 		fmt.Println(strings.Repeat("=", 60))
 		fmt.Printf("📄 Generated final migration (%d characters)\n", len(finalMigrationSQL))
-		fmt.Println("🚀 Users can run this single file instead of multiple migrations!\n")
+		fmt.Println("🚀 Users can run this single file instead of multiple migrations!")
+		fmt.Println()
 		
 		fmt.Println("📋 Final Migration Content:")
 		fmt.Println(strings.Repeat("─", 60))
@@ -326,7 +1978,8 @@ func runDebugDB() {
 		fmt.Println("\n🤖 Step 8: LLM Relationship Analysis Results")
 		fmt.Println(strings.Repeat("=", 60))
 		fmt.Printf("📊 LLM-generated Mermaid relationships (%d characters)\n", len(llmRelationships))
-		fmt.Println("🔍 Includes both explicit foreign keys AND implicit relationships!\n")
+		fmt.Println("🔍 Includes both explicit foreign keys AND implicit relationships!")
+		fmt.Println()
 		
 		fmt.Println("📋 LLM Relationship Diagram:")
 		fmt.Println(strings.Repeat("─", 60))
@@ -346,9 +1999,9 @@ func runDebugDB() {
 	}
 }
 
-// scanFiles recursively scans a directory for all files
-func scanFiles(rootPath string) (map[string]string, error) {
-	files := make(map[string]string)
+// scanFiles recursively indexes a directory's files without reading them
+func scanFiles(rootPath string) (*filestore.FileStore, error) {
+	files := filestore.New(0, nil)
 	
 	err := filepath.WalkDir(rootPath, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
@@ -365,31 +2018,24 @@ func scanFiles(rootPath string) (map[string]string, error) {
 			return err
 		}
 		
-		// Read file content
-		content, err := os.ReadFile(path)
+		info, err := d.Info()
 		if err != nil {
-			// Skip files we can't read
+			// Skip files we can't stat
 			return nil
 		}
 		
-		files[relPath] = string(content)
+		files.Add(relPath, path, info.Size())
 		return nil
 	})
 	
 	return files, err
 }
 
-// filterSQLFiles filters for SQL files that might contain migrations
-func filterSQLFiles(files map[string]string) map[string]string {
-	sqlFiles := make(map[string]string)
-	
-	for path, content := range files {
-		if strings.HasSuffix(strings.ToLower(path), ".sql") {
-			sqlFiles[path] = content
-		}
-	}
-	
-	return sqlFiles
+// filterSQLFiles reads the SQL files that might contain migrations
+func filterSQLFiles(files *filestore.FileStore) map[string]string {
+	return files.Contents(func(path string) bool {
+		return strings.HasSuffix(strings.ToLower(path), ".sql") || database.IsLiquibaseChangelog(path)
+	})
 }
 
 // findMigrationDirectories finds directories that contain "migration" in their path
@@ -417,6 +2063,19 @@ func findMigrationDirectories(sqlFiles map[string]string) []string {
 	return dirs
 }
 
+// reportName names the files written for an analysis after the project directory, and the service
+// when the analysis was limited to one
+func reportName(projectPath string, result *pipeline.AnalysisResult) string {
+	name := filepath.Base(projectPath)
+	if abs, err := filepath.Abs(projectPath); err == nil {
+		name = filepath.Base(abs)
+	}
+	if result.ServiceScope != nil {
+		name += "_" + result.ServiceScope.Service
+	}
+	return name
+}
+
 func runDetectionTest(projectPath string) {
 	if projectPath == "" {
 		fmt.Println("Please provide -path for detection testing")
@@ -425,9 +2084,14 @@ func runDetectionTest(projectPath string) {
 	
 	fmt.Printf("🧪 Testing project type detection for: %s\n", projectPath)
 	
+	cfg, err := config.Resolve(config.FindConfigFile())
+	if err != nil {
+		cfg = config.Defaults()
+	}
+	
 	// Use the project detector directly without full pipeline
 	// We'll mimic what the crawler does - discover files and read key ones
-	files, fileContents, err := discoverFilesForDetection(projectPath)
+	files, fileContents, err := discoverFilesForDetection(projectPath, int64(cfg.Limits.DetectionReadKB)*1024)
 	if err != nil {
 		fmt.Printf("❌ Error discovering files: %v\n", err)
 		return
@@ -435,8 +2099,12 @@ func runDetectionTest(projectPath string) {
 	
 	fmt.Printf("📁 Found %d files\n", len(files))
 	
-	// Use the detector directly
-	detector := detector.NewProjectDetector()
+	// Use the detector directly, with the configured rule pack if any
+	detector, err := detector.NewProjectDetectorFromFile(cfg.Detection.RulesFile)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
 	result := detector.DetectProjectType(files, fileContents)
 	
 	// Print detection results
@@ -468,7 +2136,7 @@ func runDetectionTest(projectPath string) {
 }
 
 // discoverFilesForDetection discovers files and reads important ones for detection testing
-func discoverFilesForDetection(projectPath string) ([]detector.FileInfo, map[string]string, error) {
+func discoverFilesForDetection(projectPath string, maxReadBytes int64) ([]detector.FileInfo, map[string]string, error) {
 	var files []detector.FileInfo
 	fileContents := make(map[string]string)
 	
@@ -501,7 +2169,7 @@ func discoverFilesForDetection(projectPath string) ([]detector.FileInfo, map[str
 			// Read important files for detection
 			if isImportantForDetection(relPath) {
 				content, err := os.ReadFile(path)
-				if err == nil && len(content) < 100*1024 { // Only read files < 100KB
+				if err == nil && int64(len(content)) < maxReadBytes { // Only read files below limits.detection_read_kb
 					fileContents[path] = string(content)
 				}
 			}
@@ -0,0 +1,31 @@
+package routes
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"repo-explanation/controllers"
+)
+
+// adminOnly lets a request through only with the configured admin token as its bearer token.
+// Without a token configured, admin endpoints answer 404 as if they did not exist.
+func adminOnly(token string, handler echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if token == "" {
+			return c.JSON(http.StatusNotFound, controllers.ErrorResponse{
+				Status: "error",
+				Error:  "Admin endpoints are disabled: set server.admin_token to enable them",
+			})
+		}
+		given, ok := strings.CutPrefix(c.Request().Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			return c.JSON(http.StatusUnauthorized, controllers.ErrorResponse{
+				Status: "error",
+				Error:  "A valid admin token is required (Authorization: Bearer <server.admin_token>)",
+			})
+		}
+		return handler(c)
+	}
+}
@@ -0,0 +1,72 @@
+package routes
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"repo-explanation/controllers"
+	"repo-explanation/internal/openapi"
+)
+
+// APIPrefix is the base path of the current API version
+const APIPrefix = "/api/v1"
+
+// legacyPrefix is the unversioned base path kept as a deprecated alias of APIPrefix
+const legacyPrefix = "/api"
+
+// route is an API endpoint together with its OpenAPI description, so the spec cannot drift from the handlers
+type route struct {
+	method  string
+	path    string // Relative to APIPrefix
+	handler echo.HandlerFunc
+	doc     openapi.Endpoint
+}
+
+// registerAPI mounts the routes under APIPrefix and the legacy prefix behind middlewares, then
+// serves the OpenAPI document describing them at APIPrefix/openapi.json
+func registerAPI(e *echo.Echo, routes []route, middlewares ...echo.MiddlewareFunc) error {
+	spec := openapi.NewBuilder("Repository Analyzer API", "1.0.0",
+		"Analyzes GitHub repositories and local checkouts: summaries, services, relationships, schemas and infrastructure.")
+
+	v1 := e.Group(APIPrefix)
+	for _, r := range routes {
+		v1.Add(r.method, r.path, r.handler, middlewares...)
+		e.Add(r.method, legacyPrefix+r.path, r.handler, append([]echo.MiddlewareFunc{deprecatedAlias}, middlewares...)...)
+		if len(middlewares) > 0 {
+			r.doc = documentLimits(r.method, r.doc)
+		}
+		if err := spec.Add(r.method, APIPrefix+r.path, r.doc); err != nil {
+			return err
+		}
+	}
+
+	v1.GET("/openapi.json", controllers.NewOpenAPIController(spec.Document()).Spec, middlewares...)
+	return nil
+}
+
+// deprecatedAlias marks responses served from the unversioned paths and points at their successor
+func deprecatedAlias(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		successor := APIPrefix + strings.TrimPrefix(c.Request().URL.Path, legacyPrefix)
+		c.Response().Header().Set("Deprecation", "true")
+		c.Response().Header().Set("Link", "<"+successor+`>; rel="successor-version"`)
+		return next(c)
+	}
+}
+
+// documentLimits adds the responses of the rate and body size limits to an endpoint
+func documentLimits(method string, doc openapi.Endpoint) openapi.Endpoint {
+	responses := make(map[int]interface{}, len(doc.Responses)+2)
+	for status, body := range doc.Responses {
+		responses[status] = body
+	}
+	if _, ok := responses[http.StatusTooManyRequests]; !ok {
+		responses[http.StatusTooManyRequests] = controllers.ErrorResponse{}
+	}
+	if method == http.MethodPost {
+		responses[http.StatusRequestEntityTooLarge] = nil
+	}
+	doc.Responses = responses
+	return doc
+}
@@ -0,0 +1,105 @@
+package routes
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"golang.org/x/time/rate"
+	"repo-explanation/config"
+	"repo-explanation/controllers"
+)
+
+// uploadPath is exempt from the body size limit; the upload handler enforces server.max_upload_mb
+const uploadPath = "/analysis/upload"
+
+// apiMiddleware returns the per-client rate limit and body size limit applied to every API route
+func apiMiddleware(cfg config.ServerConfig) []echo.MiddlewareFunc {
+	var middlewares []echo.MiddlewareFunc
+	if cfg.RequestsPerMinute > 0 {
+		middlewares = append(middlewares, clientRateLimiter(cfg.RequestsPerMinute, cfg.Burst))
+	}
+	if cfg.MaxBodyKB > 0 {
+		middlewares = append(middlewares, middleware.BodyLimitWithConfig(middleware.BodyLimitConfig{
+			Limit: fmt.Sprintf("%dK", cfg.MaxBodyKB),
+			Skipper: func(c echo.Context) bool {
+				return strings.HasSuffix(c.Path(), uploadPath)
+			},
+		}))
+	}
+	return middlewares
+}
+
+// clientRateLimiter allows each client IP requestsPerMinute requests, with bursts of up to burst.
+// The IP comes from the server's IPExtractor, which main sets to the connection's address.
+func clientRateLimiter(requestsPerMinute, burst int) echo.MiddlewareFunc {
+	retryAfter := fmt.Sprintf("%d", int(math.Ceil(60/float64(requestsPerMinute))))
+	return middleware.RateLimiterWithConfig(middleware.RateLimiterConfig{
+		Store: middleware.NewRateLimiterMemoryStoreWithConfig(middleware.RateLimiterMemoryStoreConfig{
+			Rate:      rate.Limit(float64(requestsPerMinute) / 60),
+			Burst:     burst,
+			ExpiresIn: 3 * time.Minute,
+		}),
+		IdentifierExtractor: func(c echo.Context) (string, error) {
+			return c.RealIP(), nil
+		},
+		DenyHandler: func(c echo.Context, identifier string, err error) error {
+			c.Response().Header().Set("Retry-After", retryAfter)
+			return c.JSON(http.StatusTooManyRequests, controllers.ErrorResponse{
+				Status: "error",
+				Error:  fmt.Sprintf("Rate limit exceeded: at most %d requests per minute per client", requestsPerMinute),
+			})
+		},
+	})
+}
+
+// analysisLimiter caps how many analyses run at once. Up to queueSize further requests wait for
+// a free slot; beyond that requests are turned away with 429 instead of piling up.
+type analysisLimiter struct {
+	slots   chan struct{}
+	pending atomic.Int64 // Running plus waiting
+	limit   int64
+}
+
+// newAnalysisLimiter returns nil, which limits nothing, when maxConcurrent is 0
+func newAnalysisLimiter(maxConcurrent, queueSize int) *analysisLimiter {
+	if maxConcurrent <= 0 {
+		return nil
+	}
+	return &analysisLimiter{
+		slots: make(chan struct{}, maxConcurrent),
+		limit: int64(maxConcurrent + queueSize),
+	}
+}
+
+// wrap holds a slot for the whole time handler runs, including streamed responses
+func (l *analysisLimiter) wrap(handler echo.HandlerFunc) echo.HandlerFunc {
+	if l == nil {
+		return handler
+	}
+	return func(c echo.Context) error {
+		if l.pending.Add(1) > l.limit {
+			l.pending.Add(-1)
+			c.Response().Header().Set("Retry-After", "30")
+			return c.JSON(http.StatusTooManyRequests, controllers.ErrorResponse{
+				Status: "error",
+				Error:  fmt.Sprintf("Server busy: %d analyses are running or queued, try again later", l.limit),
+			})
+		}
+		defer l.pending.Add(-1)
+
+		select {
+		case l.slots <- struct{}{}:
+			defer func() { <-l.slots }()
+		case <-c.Request().Context().Done():
+			// The client gave up while queued
+			return c.Request().Context().Err()
+		}
+		return handler(c)
+	}
+}
@@ -0,0 +1,44 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestClientRateLimiterIgnoresForwardedHeaders(t *testing.T) {
+	// Configured as in main
+	e := echo.New()
+	e.IPExtractor = echo.ExtractIPDirect()
+	e.GET("/api/v1/health", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, clientRateLimiter(1, 1))
+
+	request := func(remoteAddr, forwardedFor string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/health", nil)
+		req.RemoteAddr = remoteAddr
+		if forwardedFor != "" {
+			req.Header.Set(echo.HeaderXForwardedFor, forwardedFor)
+			req.Header.Set(echo.HeaderXRealIP, forwardedFor)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := request("203.0.113.7:40000", "198.51.100.1"); code != http.StatusOK {
+		t.Fatalf("first request = %d, want 200", code)
+	}
+	// A spoofed header from the same connection address shares the exhausted bucket
+	for _, forwarded := range []string{"198.51.100.2", "198.51.100.3, 10.0.0.1", ""} {
+		if code := request("203.0.113.7:40001", forwarded); code != http.StatusTooManyRequests {
+			t.Errorf("request with X-Forwarded-For %q = %d, want 429", forwarded, code)
+		}
+	}
+	// Another client has its own bucket
+	if code := request("203.0.113.8:40000", "198.51.100.1"); code != http.StatusOK {
+		t.Errorf("request from another address = %d, want 200", code)
+	}
+}
@@ -1,24 +1,171 @@
 package routes
 
 import (
+	"net/http"
 	"os"
-	
+
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"repo-explanation/config"
 	"repo-explanation/controllers"
+	"repo-explanation/internal/openapi"
+	"repo-explanation/internal/webui"
 )
 
-func SetupRoutes(e *echo.Echo, healthController *controllers.HealthController, analysisController *controllers.AnalysisController) {
-	// Health check route
-	e.GET("/health", healthController.HealthCheck)
-	
-	// API routes
-	api := e.Group("/api")
-	
-	// Repository analysis endpoints
-	api.POST("/analyze", analysisController.AnalyzeRepository)
-	api.POST("/analyze/stream", analysisController.StreamAnalyzeRepository)
-	
+func SetupRoutes(e *echo.Echo, cfg *config.Config, healthController *controllers.HealthController, analysisController *controllers.AnalysisController, trackingController *controllers.TrackingController) {
+	// Health, liveness, readiness and version probes
+	registerProbes(e, healthController)
+
+	// Analyses beyond the concurrency cap queue briefly, then get 429
+	limiter := newAnalysisLimiter(cfg.Server.MaxConcurrentAnalyses, cfg.Server.AnalysisQueueSize)
+
+	// API routes, served under /api/v1 and the deprecated unversioned /api
+	err := registerAPI(e, append(healthRoutes(healthController), []route{
+		// Repository analysis endpoints
+		{http.MethodPost, "/analyze", limiter.wrap(analysisController.AnalyzeRepository), openapi.Endpoint{
+			Tag:         "analysis",
+			Summary:     "Analyze a GitHub repository or local checkout",
+			Description: "Clones the repository (or, with type local_path, reads a checkout inside the server's allowed roots) and runs the analysis pipeline, returning the full result when it finishes. With context_pack set to markdown or json, the response is instead the context pack as a file download: the project summary, commands, services, service graph, key files, folders and ERD, sized to context_pack_tokens for pasting into a chat assistant.",
+			Request:     controllers.AnalysisRequest{},
+			Responses: map[int]interface{}{
+				http.StatusOK:                  controllers.AnalysisResponse{},
+				http.StatusBadRequest:          controllers.AnalysisResponse{},
+				http.StatusUnauthorized:        controllers.AnalysisResponse{},
+				http.StatusRequestTimeout:      controllers.AnalysisResponse{},
+				http.StatusInternalServerError: controllers.AnalysisResponse{},
+			},
+		}},
+		{http.MethodPost, "/analyze/stream", limiter.wrap(analysisController.StreamAnalyzeRepository), openapi.Endpoint{
+			Tag:         "analysis",
+			Summary:     "Analyze a GitHub repository or local checkout with progress events",
+			Description: "Server-Sent Events stream of progress, then one complete event carrying the analysis result.",
+			Request:     controllers.AnalysisRequest{},
+			Stream:      true,
+			Responses: map[int]interface{}{
+				http.StatusOK:         controllers.StreamEvent{},
+				http.StatusBadRequest: controllers.AnalysisResponse{},
+			},
+		}},
+		{http.MethodPost, "/workspace/analyze", limiter.wrap(analysisController.AnalyzeWorkspace), openapi.Endpoint{
+			Tag:         "analysis",
+			Summary:     "Analyze several repositories as one workspace",
+			Description: "Analyzes each repository and links services, APIs and shared databases across them.",
+			Request:     controllers.WorkspaceRequest{},
+			Responses: map[int]interface{}{
+				http.StatusOK:                  controllers.WorkspaceResponse{},
+				http.StatusBadRequest:          controllers.WorkspaceResponse{},
+				http.StatusUnauthorized:        controllers.WorkspaceResponse{},
+				http.StatusRequestTimeout:      controllers.WorkspaceResponse{},
+				http.StatusInternalServerError: controllers.WorkspaceResponse{},
+			},
+		}},
+
+		{http.MethodGet, "/profiles", analysisController.Profiles, openapi.Endpoint{
+			Tag:         "analysis",
+			Summary:     "List the analysis profiles",
+			Description: "Named profiles a request can select with its profile field; each preselects the phases to run and frames the project-level prompts.",
+			Responses:   map[int]interface{}{http.StatusOK: controllers.ProfilesResponse{}},
+		}},
+
+		// Architectural diff of two refs of a local checkout, for PR review
+		{http.MethodPost, "/analysis/compare", limiter.wrap(analysisController.CompareRefs), openapi.Endpoint{
+			Tag:         "analysis",
+			Summary:     "Compare the architecture of two git refs",
+			Description: "Checks out refA and refB of a local checkout into temporary worktrees, analyzes both and returns changed services, schema diffs, new endpoints, new dependencies and new secrets. The path must lie inside one of the server's allowed roots.",
+			Request:     controllers.CompareRequest{},
+			Responses: map[int]interface{}{
+				http.StatusOK:                  controllers.CompareResponse{},
+				http.StatusBadRequest:          controllers.CompareResponse{},
+				http.StatusForbidden:           controllers.CompareResponse{},
+				http.StatusRequestTimeout:      controllers.CompareResponse{},
+				http.StatusInternalServerError: controllers.CompareResponse{},
+			},
+		}},
+
+		// Projects the server cannot clone or read, uploaded as an archive
+		{http.MethodPost, "/analysis/upload", limiter.wrap(analysisController.AnalyzeUpload), openapi.Endpoint{
+			Tag:         "analysis",
+			Summary:     "Analyze a project uploaded as a zip or tar.gz archive",
+			Description: "Extracts the archive into a temporary folder and runs the analysis pipeline on it. The archive may be at most server.max_upload_mb and expand to at most server.max_extracted_mb; entries that would escape the folder are rejected and symlinks are skipped. With stream=true the response is the Server-Sent Events stream of /analyze/stream.",
+			Form:        controllers.UploadRequest{},
+			Files:       map[string]string{controllers.UploadArchiveField: ".zip, .tar.gz, .tgz or .tar of the project"},
+			Responses: map[int]interface{}{
+				http.StatusOK:                    controllers.AnalysisResponse{},
+				http.StatusBadRequest:            controllers.AnalysisResponse{},
+				http.StatusNotFound:              controllers.AnalysisResponse{},
+				http.StatusRequestTimeout:        controllers.AnalysisResponse{},
+				http.StatusRequestEntityTooLarge: controllers.AnalysisResponse{},
+				http.StatusInternalServerError:   controllers.AnalysisResponse{},
+			},
+		}},
+
+		// Live status of the services of a local checkout
+		{http.MethodGet, "/services/status", limiter.wrap(analysisController.ServiceStatus), openapi.Endpoint{
+			Tag:         "services",
+			Summary:     "Probe the services of a local checkout",
+			Description: "Discovers the services offline, reads their ports and reports which are live, unhealthy or down. The path must lie inside one of the server's allowed roots.",
+			Query:       controllers.ServiceStatusRequest{},
+			Responses: map[int]interface{}{
+				http.StatusOK:                  controllers.ServiceStatusResponse{},
+				http.StatusBadRequest:          controllers.ServiceStatusResponse{},
+				http.StatusForbidden:           controllers.ServiceStatusResponse{},
+				http.StatusInternalServerError: controllers.ServiceStatusResponse{},
+			},
+		}},
+
+		// Off-hours cache warming, behind server.admin_token
+		{http.MethodPost, "/admin/prime-cache", adminOnly(cfg.Server.AdminToken, limiter.wrap(analysisController.PrimeCache)), openapi.Endpoint{
+			Tag:         "admin",
+			Summary:     "Prime the file summary cache for a repository",
+			Description: "Runs only the map phase over a GitHub repository or local checkout, starting at rate_limiting.max_concurrent_workers and backing off on rate limits, so later analyses of it find every file summary in the cache. Requires the header Authorization: Bearer <server.admin_token>; answers 404 when no admin token is configured.",
+			Request:     controllers.PrimeCacheRequest{},
+			Responses: map[int]interface{}{
+				http.StatusOK:                  controllers.PrimeCacheResponse{},
+				http.StatusBadRequest:          controllers.PrimeCacheResponse{},
+				http.StatusUnauthorized:        controllers.ErrorResponse{},
+				http.StatusForbidden:           controllers.PrimeCacheResponse{},
+				http.StatusNotFound:            controllers.ErrorResponse{},
+				http.StatusInternalServerError: controllers.PrimeCacheResponse{},
+			},
+		}},
+
+		// Scheduled re-analysis of the repositories listed under tracking.repositories
+		{http.MethodGet, "/tracking", trackingController.Repositories, openapi.Endpoint{
+			Tag:         "tracking",
+			Summary:     "List the tracked repositories",
+			Description: "Each repository the server re-analyzes on a schedule, with its next run and the drift summary, commit and error of its last run.",
+			Responses:   map[int]interface{}{http.StatusOK: controllers.TrackingStatusResponse{}},
+		}},
+		{http.MethodPost, "/tracking/run", limiter.wrap(trackingController.Run), openapi.Endpoint{
+			Tag:         "tracking",
+			Summary:     "Re-analyze a tracked repository now",
+			Description: "Runs the repository's scheduled re-analysis immediately: updates the checkout, compares it against the previous run and sends the drift report to its webhooks and email addresses.",
+			Request:     controllers.TrackingRunRequest{},
+			Responses: map[int]interface{}{
+				http.StatusOK:                  controllers.TrackingRunResponse{},
+				http.StatusBadRequest:          controllers.TrackingRunResponse{},
+				http.StatusNotFound:            controllers.TrackingRunResponse{},
+				http.StatusConflict:            controllers.TrackingRunResponse{},
+				http.StatusInternalServerError: controllers.TrackingRunResponse{},
+			},
+		}},
+		{http.MethodGet, "/repos/:id/timeline", trackingController.Timeline, openapi.Endpoint{
+			Tag:         "tracking",
+			Summary:     "Show how a tracked repository evolved",
+			Description: "The services, tables, lines of code, declared dependencies and required secrets of each run of the tracked repository named by id, oldest first. Timestamps, commits and the values of each series line up by index, and each series carries its min, max, latest value and change for drawing sparklines; limit keeps only the latest runs.",
+			Query:       controllers.TimelineRequest{},
+			Responses: map[int]interface{}{
+				http.StatusOK:                  controllers.TimelineResponse{},
+				http.StatusBadRequest:          controllers.TimelineResponse{},
+				http.StatusNotFound:            controllers.TimelineResponse{},
+				http.StatusInternalServerError: controllers.TimelineResponse{},
+			},
+		}},
+	}...), apiMiddleware(cfg.Server)...)
+	if err != nil {
+		e.Logger.Fatal(err)
+	}
+
 	// Serve static files if they exist (for combined deployment)
 	staticDir := "./static"
 	if _, err := os.Stat(staticDir); err == nil {
@@ -28,5 +175,73 @@ func SetupRoutes(e *echo.Echo, healthController *controllers.HealthController, a
 			HTML5:  true,
 			Browse: false,
 		}))
+	} else if cfg.Server.WebUI {
+		// Otherwise serve the UI embedded in the binary
+		e.Use(middleware.StaticWithConfig(middleware.StaticConfig{
+			Root:       ".",
+			Index:      "index.html",
+			HTML5:      true,
+			Filesystem: webui.HTTPFS(),
+		}))
+	}
+}
+
+// SetupWatchRoutes registers the endpoints served in watch mode
+func SetupWatchRoutes(e *echo.Echo, healthController *controllers.HealthController, watchController *controllers.WatchController) {
+	registerProbes(e, healthController)
+
+	err := registerAPI(e, append(healthRoutes(healthController), []route{
+		// Live results: one event stream per client, updated after every re-analysis
+		{http.MethodGet, "/watch/stream", watchController.Stream, openapi.Endpoint{
+			Tag:         "watch",
+			Summary:     "Stream watch-mode analysis results",
+			Description: "Server-Sent Events; the latest complete result is replayed on connect and re-sent after every re-analysis.",
+			Stream:      true,
+			Responses:   map[int]interface{}{http.StatusOK: controllers.StreamEvent{}},
+		}},
+	}...))
+	if err != nil {
+		e.Logger.Fatal(err)
+	}
+}
+
+// registerProbes serves the health, liveness, readiness and version endpoints at the root, where
+// load balancers and Kubernetes probes expect them, without rate limits
+func registerProbes(e *echo.Echo, healthController *controllers.HealthController) {
+	e.GET("/health", healthController.HealthCheck)
+	e.GET("/healthz", healthController.Liveness)
+	e.GET("/readyz", healthController.Readiness)
+	e.GET("/version", healthController.Version)
+}
+
+// healthRoutes documents the probes under the API prefix as well
+func healthRoutes(healthController *controllers.HealthController) []route {
+	return []route{
+		{http.MethodGet, "/health", healthController.HealthCheck, openapi.Endpoint{
+			Tag:       "health",
+			Summary:   "Check the server is running",
+			Responses: map[int]interface{}{http.StatusOK: controllers.HealthResponse{}},
+		}},
+		{http.MethodGet, "/healthz", healthController.Liveness, openapi.Endpoint{
+			Tag:         "health",
+			Summary:     "Liveness probe",
+			Description: "Answers while the process can serve requests; no dependencies are checked.",
+			Responses:   map[int]interface{}{http.StatusOK: controllers.LivenessResponse{}},
+		}},
+		{http.MethodGet, "/readyz", healthController.Readiness, openapi.Endpoint{
+			Tag:         "health",
+			Summary:     "Readiness probe",
+			Description: "Checks that the configuration is valid, the OpenAI API is reachable with the configured key (unless offline; the result is reused for 30 seconds), and the output and cache directories are writable.",
+			Responses: map[int]interface{}{
+				http.StatusOK:                 controllers.ReadinessResponse{},
+				http.StatusServiceUnavailable: controllers.ReadinessResponse{},
+			},
+		}},
+		{http.MethodGet, "/version", healthController.Version, openapi.Endpoint{
+			Tag:         "health",
+			Summary:     "Build information",
+			Description: "Version, commit and build date injected at compile time, with the Go version and platform.",
+			Responses:   map[int]interface{}{http.StatusOK: controllers.VersionResponse{}},
+		}},
 	}
 }